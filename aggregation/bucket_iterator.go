@@ -0,0 +1,147 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"sort"
+
+	"github.com/lindb/lindb/pkg/collections"
+	"github.com/lindb/lindb/series"
+	"github.com/lindb/lindb/series/field"
+)
+
+// bucketGroupedIterator re-aggregates the data of fixed interval into buckets with variable length,
+// like calendar days of time zone, time slot of result data is the index of bucket.
+type bucketGroupedIterator struct {
+	it         series.GroupedIterator
+	interval   int64
+	boundaries []int64
+}
+
+// NewBucketGroupedIterator creates a grouped iterator which re-aggregates the data of interval into buckets,
+// boundaries are the start times of buckets in ascending order.
+func NewBucketGroupedIterator(it series.GroupedIterator, interval int64, boundaries []int64) series.GroupedIterator {
+	return &bucketGroupedIterator{
+		it:         it,
+		interval:   interval,
+		boundaries: boundaries,
+	}
+}
+
+// Tags returns the tags of series.
+func (g *bucketGroupedIterator) Tags() string {
+	return g.it.Tags()
+}
+
+// HasNext returns if the iteration has more field's iterator.
+func (g *bucketGroupedIterator) HasNext() bool {
+	return g.it.HasNext()
+}
+
+// Next returns the field's iterator with bucket data.
+func (g *bucketGroupedIterator) Next() series.Iterator {
+	return newBucketIterator(g.it.Next(), g.interval, g.boundaries)
+}
+
+// bucketIterator implements series.Iterator with the data aggregated by bucket.
+type bucketIterator struct {
+	fieldName field.Name
+	fieldType field.Type
+	startTime int64
+	fieldIt   series.FieldIterator
+	consumed  bool
+}
+
+// newBucketIterator aggregates the data of field into buckets by primitive aggregator type.
+func newBucketIterator(it series.Iterator, interval int64, boundaries []int64) series.Iterator {
+	var aggTypes []field.AggType
+	buckets := make(map[field.AggType]*collections.FloatArray)
+	for it.HasNext() {
+		startTime, fieldIt := it.Next()
+		if fieldIt == nil {
+			continue
+		}
+		for fieldIt.HasNext() {
+			primitiveIt := fieldIt.Next()
+			aggType := primitiveIt.AggType()
+			values, ok := buckets[aggType]
+			if !ok {
+				values = collections.NewFloatArray(len(boundaries))
+				buckets[aggType] = values
+				aggTypes = append(aggTypes, aggType)
+			}
+			for primitiveIt.HasNext() {
+				slot, value := primitiveIt.Next()
+				timestamp := startTime + int64(slot)*interval
+				idx := sort.Search(len(boundaries), func(i int) bool {
+					return boundaries[i] > timestamp
+				}) - 1
+				if idx < 0 {
+					continue
+				}
+				if values.HasValue(idx) {
+					value = aggType.Aggregate(values.GetValue(idx), value)
+				}
+				values.SetValue(idx, value)
+			}
+		}
+	}
+	fieldSeriesList := make([]*collections.FloatArray, len(aggTypes))
+	for idx, aggType := range aggTypes {
+		fieldSeriesList[idx] = buckets[aggType]
+	}
+	var startTime int64
+	if len(boundaries) > 0 {
+		startTime = boundaries[0]
+	}
+	return &bucketIterator{
+		fieldName: it.FieldName(),
+		fieldType: it.FieldType(),
+		startTime: startTime,
+		fieldIt:   newFieldIterator(0, aggTypes, fieldSeriesList),
+	}
+}
+
+// FieldName returns the field name.
+func (it *bucketIterator) FieldName() field.Name {
+	return it.fieldName
+}
+
+// FieldType returns the field type.
+func (it *bucketIterator) FieldType() field.Type {
+	return it.fieldType
+}
+
+// HasNext returns if the iteration has more field's iterator.
+func (it *bucketIterator) HasNext() bool {
+	if it.consumed {
+		return false
+	}
+	it.consumed = true
+	return true
+}
+
+// Next returns the field's iterator and the start time of first bucket.
+func (it *bucketIterator) Next() (startTime int64, fieldIt series.FieldIterator) {
+	return it.startTime, it.fieldIt
+}
+
+// MarshalBinary marshals the data.
+func (it *bucketIterator) MarshalBinary() ([]byte, error) {
+	return series.MarshalIterator(it)
+}
@@ -0,0 +1,85 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/collections"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series"
+	"github.com/lindb/lindb/series/field"
+)
+
+func TestBucketGroupedIterator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fieldSeries := series.NewMockIterator(ctrl)
+	fieldSeries.EXPECT().FieldName().Return(field.Name("f"))
+	fieldSeries.EXPECT().FieldType().Return(field.SumField)
+	fieldSeries.EXPECT().HasNext().Return(true)
+	fieldSeries.EXPECT().Next().Return(int64(0), newFieldIterator(0,
+		[]field.AggType{field.Sum, field.Max},
+		[]*collections.FloatArray{
+			generateFloatArray([]float64{1, 2, 3, 4, 5, 6}),
+			generateFloatArray([]float64{1, 2, 3, 4, 5, 6}),
+		}))
+	fieldSeries.EXPECT().HasNext().Return(true)
+	fieldSeries.EXPECT().Next().Return(int64(0), nil)
+	fieldSeries.EXPECT().HasNext().Return(false)
+	groupedIt := series.NewMockGroupedIterator(ctrl)
+	groupedIt.EXPECT().Tags().Return("host1")
+	groupedIt.EXPECT().HasNext().Return(true)
+	groupedIt.EXPECT().Next().Return(fieldSeries)
+	groupedIt.EXPECT().HasNext().Return(false)
+
+	// slot 0 before first bucket, slot 1/2 => bucket 0, slot 3/4/5 => bucket 1
+	boundaries := []int64{timeutil.OneHour, 3 * timeutil.OneHour}
+	it := NewBucketGroupedIterator(groupedIt, timeutil.OneHour, boundaries)
+	assert.Equal(t, "host1", it.Tags())
+	assert.True(t, it.HasNext())
+	bucketIt := it.Next()
+	assert.False(t, it.HasNext())
+	assert.Equal(t, field.Name("f"), bucketIt.FieldName())
+	assert.Equal(t, field.SumField, bucketIt.FieldType())
+	assert.True(t, bucketIt.HasNext())
+	startTime, fieldIt := bucketIt.Next()
+	assert.False(t, bucketIt.HasNext())
+	assert.Equal(t, timeutil.OneHour, startTime)
+	assert.True(t, fieldIt.HasNext())
+	sumIt := fieldIt.Next()
+	assert.Equal(t, field.Sum, sumIt.AggType())
+	expect := map[int]float64{0: 5, 1: 15}
+	for sumIt.HasNext() {
+		slot, value := sumIt.Next()
+		assert.Equal(t, expect[slot], value)
+	}
+	maxIt := fieldIt.Next()
+	assert.Equal(t, field.Max, maxIt.AggType())
+	expect = map[int]float64{0: 3, 1: 6}
+	for maxIt.HasNext() {
+		slot, value := maxIt.Next()
+		assert.Equal(t, expect[slot], value)
+	}
+	_, err := bucketIt.MarshalBinary()
+	assert.NoError(t, err)
+}
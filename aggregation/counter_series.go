@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// counterPoint represents the raw value of cumulative counter with the target slot it belongs to.
+type counterPoint struct {
+	timestamp  int64
+	familyTime int64
+	targetSlot int
+	value      float64
+	inRange    bool // if point in query time range, else only used as base of next point
+}
+
+// counterSeriesKey represents the key of counter series(low series index + field index).
+type counterSeriesKey struct {
+	lowSeriesIdx uint16
+	fieldIdx     int
+}
+
+// CounterSeries collects the raw values of cumulative counter per series from all time segments/files,
+// then converts counter into increase after all data of series merged, so that the increase between
+// the points stored in different time segments/files isn't lost.
+// 1. increase between two adjacent points is emitted into the target slot of latter point;
+// 2. value decrease means counter reset, the increase is the value after reset;
+// 3. first point of series has no increase, so that series appeared in query time range won't produce spike.
+// NOTICE: increase isn't extrapolated to the boundaries of target slot.
+type CounterSeries struct {
+	series map[counterSeriesKey][]counterPoint
+	mutex  sync.Mutex
+}
+
+// NewCounterSeries creates a CounterSeries instance.
+func NewCounterSeries() *CounterSeries {
+	return &CounterSeries{}
+}
+
+// Collect collects the raw values of counter series from source time range, source slot => target slot.
+func (c *CounterSeries) Collect(
+	lowSeriesIdx uint16, fieldIdx int,
+	familyTime int64, interval timeutil.Interval,
+	source, target timeutil.SlotRange, ratio uint16, baseSlot uint16, getter encoding.TSDValueGetter,
+) {
+	var points []counterPoint
+	end := int(target.End)
+	bs := int(baseSlot)
+	intervalVal := interval.Int64()
+	for movingSourceSlot := source.Start; movingSourceSlot <= source.End; movingSourceSlot++ {
+		value, ok := getter.GetValue(movingSourceSlot)
+		if !ok {
+			// no data, goto next loop
+			continue
+		}
+		targetSlot := bs + int(movingSourceSlot/ratio) // base slot + source slot(down sampling)
+		if targetSlot > end {
+			// exhausted when target slot > query end slot
+			break
+		}
+		points = append(points, counterPoint{
+			timestamp:  familyTime + int64(movingSourceSlot)*intervalVal,
+			familyTime: familyTime,
+			targetSlot: targetSlot,
+			value:      value,
+			// point before query start slot is kept as base of next point
+			inRange: targetSlot >= int(target.Start),
+		})
+	}
+	if len(points) == 0 {
+		return
+	}
+	key := counterSeriesKey{lowSeriesIdx: lowSeriesIdx, fieldIdx: fieldIdx}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.series == nil {
+		c.series = make(map[counterSeriesKey][]counterPoint)
+	}
+	c.series[key] = append(c.series[key], points...)
+}
+
+// Emit converts the collected counter of each series into increase in time order,
+// then emits increase into target slot, and resets the collected values.
+func (c *CounterSeries) Emit(emitValue func(lowSeriesIdx uint16, fieldIdx int, familyTime int64, targetPos int, value float64)) {
+	c.mutex.Lock()
+	series := c.series
+	c.series = nil
+	c.mutex.Unlock()
+
+	for key, points := range series {
+		// time segments/files maybe loaded concurrently, sort points by time
+		sort.SliceStable(points, func(i, j int) bool {
+			return points[i].timestamp < points[j].timestamp
+		})
+		var prev *counterPoint
+		for idx := range points {
+			point := &points[idx]
+			if idx+1 < len(points) && points[idx+1].timestamp == point.timestamp {
+				// same point stored in multi files(memory/disk), keep the latest loaded one
+				continue
+			}
+			if prev != nil && point.inRange {
+				increase := point.value - prev.value
+				if point.value < prev.value {
+					// counter reset
+					increase = point.value
+				}
+				emitValue(key.lowSeriesIdx, key.fieldIdx, point.familyTime, point.targetSlot, increase)
+			}
+			prev = point
+		}
+	}
+}
@@ -0,0 +1,164 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+type slotValues map[uint16]float64
+
+func (v slotValues) GetValue(slot uint16) (float64, bool) {
+	value, ok := v[slot]
+	return value, ok
+}
+
+type counterBlock struct {
+	lowSeriesIdx uint16
+	familyTime   int64
+	baseSlot     uint16
+	values       slotValues
+}
+
+type counterResult struct {
+	lowSeriesIdx uint16
+	familyTime   int64
+	targetPos    int
+}
+
+func TestCounterSeries(t *testing.T) {
+	interval := timeutil.Interval(10 * timeutil.OneSecond)
+	cases := []struct {
+		name   string
+		target timeutil.SlotRange
+		ratio  uint16
+		blocks []counterBlock
+		expect map[counterResult]float64
+	}{
+		{
+			name:   "no data",
+			target: timeutil.SlotRange{Start: 0, End: 10},
+			ratio:  1,
+			blocks: []counterBlock{{values: slotValues{}}},
+			expect: map[counterResult]float64{},
+		},
+		{
+			name:   "first point without increase",
+			target: timeutil.SlotRange{Start: 0, End: 10},
+			ratio:  1,
+			blocks: []counterBlock{{values: slotValues{0: 100}}},
+			expect: map[counterResult]float64{},
+		},
+		{
+			name:   "increase per slot and counter reset",
+			target: timeutil.SlotRange{Start: 0, End: 10},
+			ratio:  1,
+			blocks: []counterBlock{{values: slotValues{0: 100, 1: 110, 3: 130, 4: 5, 5: 15}}},
+			expect: map[counterResult]float64{{targetPos: 1}: 10, {targetPos: 3}: 20, {targetPos: 4}: 5, {targetPos: 5}: 10},
+		},
+		{
+			name:   "counter reset at edge of target slot",
+			target: timeutil.SlotRange{Start: 0, End: 2},
+			ratio:  4,
+			// slot 0-3 => 0, slot 4-7 => 1, slot 8-9 => 2
+			blocks: []counterBlock{{values: slotValues{0: 100, 3: 130, 4: 3, 7: 10, 8: 20}}},
+			expect: map[counterResult]float64{{targetPos: 0}: 30, {targetPos: 1}: 10, {targetPos: 2}: 10},
+		},
+		{
+			name:   "increase and counter reset between time segments",
+			target: timeutil.SlotRange{Start: 0, End: 20},
+			ratio:  1,
+			blocks: []counterBlock{
+				{familyTime: 0, baseSlot: 0, values: slotValues{8: 100, 9: 110}},
+				{familyTime: 100 * timeutil.OneSecond, baseSlot: 10, values: slotValues{0: 120, 1: 5}},
+			},
+			expect: map[counterResult]float64{
+				{targetPos: 9}: 10,
+				{familyTime: 100 * timeutil.OneSecond, targetPos: 10}: 10,
+				{familyTime: 100 * timeutil.OneSecond, targetPos: 11}: 5,
+			},
+		},
+		{
+			name:   "time segments loaded out of order",
+			target: timeutil.SlotRange{Start: 0, End: 20},
+			ratio:  1,
+			blocks: []counterBlock{
+				{familyTime: 100 * timeutil.OneSecond, baseSlot: 10, values: slotValues{0: 120, 1: 5}},
+				{familyTime: 0, baseSlot: 0, values: slotValues{8: 100, 9: 110}},
+			},
+			expect: map[counterResult]float64{
+				{targetPos: 9}: 10,
+				{familyTime: 100 * timeutil.OneSecond, targetPos: 10}: 10,
+				{familyTime: 100 * timeutil.OneSecond, targetPos: 11}: 5,
+			},
+		},
+		{
+			name:   "series appears/disappears in middle of time range",
+			target: timeutil.SlotRange{Start: 0, End: 20},
+			ratio:  1,
+			blocks: []counterBlock{
+				{lowSeriesIdx: 1, familyTime: 0, baseSlot: 0, values: slotValues{2: 10, 3: 20}},
+				{lowSeriesIdx: 2, familyTime: 100 * timeutil.OneSecond, baseSlot: 10, values: slotValues{3: 1000, 4: 1010}},
+			},
+			expect: map[counterResult]float64{
+				{lowSeriesIdx: 1, targetPos: 3}:                                        10,
+				{lowSeriesIdx: 2, familyTime: 100 * timeutil.OneSecond, targetPos: 14}: 10,
+			},
+		},
+		{
+			name:   "points before query time range used as base",
+			target: timeutil.SlotRange{Start: 2, End: 3},
+			ratio:  2,
+			blocks: []counterBlock{{values: slotValues{3: 10, 4: 15, 6: 20, 9: 50}}},
+			expect: map[counterResult]float64{{targetPos: 2}: 5, {targetPos: 3}: 5},
+		},
+		{
+			name:   "same points stored in multi files",
+			target: timeutil.SlotRange{Start: 0, End: 10},
+			ratio:  1,
+			blocks: []counterBlock{
+				{values: slotValues{1: 10, 2: 20}},
+				{values: slotValues{2: 20, 3: 25}},
+			},
+			expect: map[counterResult]float64{{targetPos: 2}: 10, {targetPos: 3}: 5},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			counters := NewCounterSeries()
+			for _, block := range tt.blocks {
+				counters.Collect(block.lowSeriesIdx, 0, block.familyTime, interval,
+					timeutil.SlotRange{Start: 0, End: 9}, tt.target, tt.ratio, block.baseSlot, block.values)
+			}
+			result := make(map[counterResult]float64)
+			counters.Emit(func(lowSeriesIdx uint16, fieldIdx int, familyTime int64, targetPos int, value float64) {
+				result[counterResult{lowSeriesIdx: lowSeriesIdx, familyTime: familyTime, targetPos: targetPos}] += value
+			})
+			assert.Equal(t, tt.expect, result)
+			// collected values reset after emit
+			counters.Emit(func(_ uint16, _ int, _ int64, _ int, _ float64) {
+				assert.Fail(t, "should not emit after reset")
+			})
+		})
+	}
+}
@@ -69,13 +69,15 @@ func putFloat64Slice(sl *[]float64) {
 }
 
 // DownSamplingMultiSeriesInto merges field data from source time range => target time range,
-// data will be merged into DownSamplingResult
+// data will be merged into DownSamplingResult, the value of latter decoder replaces the value of same target slot
+// for sum field if last write wins(decoders are ordered from old to new),
 // for example: source range[5,182]=>target range[0,6], ratio:30, source interval:10s, target interval:5min.
 func DownSamplingMultiSeriesInto(
 	target timeutil.SlotRange, ratio uint16, baseSlot uint16,
-	fieldType field.Type, decoders []*encoding.TSDDecoder,
+	fieldType field.Type, lastWriteWins bool, decoders []*encoding.TSDDecoder,
 	emitValue func(targetPos int, value float64),
 ) {
+	replace := fieldType.ReplaceOnRewrite(lastWriteWins)
 	targetValues := make([]float64, infBlockSize)
 	length := int(target.End-target.Start) + 1
 	if length <= infBlockSize {
@@ -108,8 +110,8 @@ func DownSamplingMultiSeriesInto(
 			if targetPos >= length {
 				break
 			}
-			// not set before
-			if math.IsInf(targetValues[targetPos], 1) {
+			// not set before, or replaced by the value of latter decoder
+			if replace || math.IsInf(targetValues[targetPos], 1) {
 				targetValues[targetPos] = value
 				// set before, aggregate
 			} else {
@@ -41,6 +41,8 @@ type Expression interface {
 	Eval(timeSeries series.GroupedIterator)
 	// ResultSet returns the eval result, returns field name(alias) => series data.
 	ResultSet() map[string]*collections.FloatArray
+	// QuantileOverflows returns the field names(alias) whose histogram quantile is beyond the largest bucket boundary.
+	QuantileOverflows() []string
 	// Reset resets the Expression context for reusing.
 	Reset()
 }
@@ -54,6 +56,9 @@ type expression struct {
 
 	fieldStore map[field.Name]fields.Field
 	resultSet  map[string]*collections.FloatArray // field => series
+
+	quantileOverflow  bool     // quantile of current select item is beyond the largest bucket boundary
+	quantileOverflows []string // field names whose quantile is beyond the largest bucket boundary
 }
 
 // NewExpression creates an Expression instance.
@@ -81,12 +86,16 @@ func (e *expression) Eval(timeSeries series.GroupedIterator) {
 	}
 
 	for _, selectItem := range e.selectItems {
+		e.quantileOverflow = false
 		values := e.eval(nil, selectItem)
 		if len(values) != 0 {
+			fieldName := selectItem.Rewrite()
 			if item, ok := selectItem.(*stmt.SelectItem); ok && len(item.Alias) > 0 {
-				e.resultSet[item.Alias] = values[0]
-			} else {
-				e.resultSet[item.Rewrite()] = values[0]
+				fieldName = item.Alias
+			}
+			e.resultSet[fieldName] = values[0]
+			if e.quantileOverflow {
+				e.quantileOverflows = append(e.quantileOverflows, fieldName)
 			}
 		}
 	}
@@ -97,6 +106,11 @@ func (e *expression) ResultSet() map[string]*collections.FloatArray {
 	return e.resultSet
 }
 
+// QuantileOverflows returns the field names(alias) whose histogram quantile is beyond the largest bucket boundary.
+func (e *expression) QuantileOverflows() []string {
+	return e.quantileOverflows
+}
+
 // prepare the field store.
 func (e *expression) prepare(timeSeries series.GroupedIterator) {
 	if timeSeries == nil {
@@ -155,13 +169,16 @@ func (e *expression) quantile(expr *stmt.CallExpr) []*collections.FloatArray {
 	var (
 		histogramFields = make(map[float64][]*collections.FloatArray)
 	)
-	if len(expr.Params) != 1 {
+	if len(expr.Params) == 0 || len(expr.Params) > 2 {
 		return nil
 	}
 	quantileValue, err := strconv.ParseFloat(expr.Params[0].Rewrite(), 64)
 	if err != nil {
 		return nil
 	}
+	if len(expr.Params) == 2 {
+		return e.histogramFamilyQuantile(quantileValue, expr.Params[1])
+	}
 	for fieldName, df := range e.fieldStore {
 		if df.Type() == field.HistogramField {
 			var upperBound float64
@@ -182,6 +199,34 @@ func (e *expression) quantile(expr *stmt.CallExpr) []*collections.FloatArray {
 	return []*collections.FloatArray{array}
 }
 
+// histogramFamilyQuantile calculates the quantile over bucket fields of histogram family(${family}__bucket_${boundary}),
+// the buckets are already summed across series of the group.
+func (e *expression) histogramFamilyQuantile(quantileValue float64, familyExpr stmt.Expr) []*collections.FloatArray {
+	family, ok := familyExpr.(*stmt.FieldExpr)
+	if !ok {
+		return nil
+	}
+	histogramBuckets := make(map[float64]*collections.FloatArray)
+	for fieldName, df := range e.fieldStore {
+		name, upperBound, err := metric.HistogramFamilyBucket(fieldName.String())
+		if err != nil || name != family.Name {
+			continue
+		}
+		if values := df.GetDefaultValues(); len(values) > 0 {
+			histogramBuckets[upperBound] = values[0]
+		}
+	}
+	if len(histogramBuckets) == 0 {
+		return nil
+	}
+	array, overflow, err := function.HistogramQuantileCall(quantileValue, histogramBuckets)
+	if err != nil {
+		return nil
+	}
+	e.quantileOverflow = e.quantileOverflow || overflow
+	return []*collections.FloatArray{array}
+}
+
 // funcCall calls the function
 func (e *expression) funcCall(expr *stmt.CallExpr) []*collections.FloatArray {
 	var params []*collections.FloatArray
@@ -232,4 +277,5 @@ func (e *expression) Reset() {
 		f.Reset()
 	}
 	e.resultSet = make(map[string]*collections.FloatArray)
+	e.quantileOverflows = nil
 }
@@ -366,3 +366,33 @@ func TestExpression_NotSupport_Expr(t *testing.T) {
 	resultSet = expression.ResultSet()
 	assert.Equal(t, 0, len(resultSet))
 }
+
+func TestExpression_HistogramFamilyQuantile(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	q, _ := sql.Parse("select quantile(0.4, latency) as p40, quantile(0.99, latency), quantile(0.99, cost) from cpu")
+	query := q.(*stmt.Query)
+	expression := NewExpression(timeutil.TimeRange{
+		Start: now,
+		End:   now + timeutil.OneHour*2,
+	}, timeutil.OneMinute, query.SelectItems)
+	timeSeries := series.NewMockGroupedIterator(ctrl)
+	gomock.InOrder(
+		timeSeries.EXPECT().HasNext().Return(true),
+		timeSeries.EXPECT().Next().Return(mockTimeSeries(ctrl, familyTime, "latency__bucket_1", field.SumField, field.Sum)),
+		timeSeries.EXPECT().HasNext().Return(true),
+		timeSeries.EXPECT().Next().Return(mockTimeSeries(ctrl, familyTime, "latency__bucket_+Inf", field.SumField, field.Sum)),
+		timeSeries.EXPECT().HasNext().Return(false),
+	)
+	expression.Eval(timeSeries)
+	resultSet := expression.ResultSet()
+	assert.Len(t, resultSet, 2)
+	assert.InDelta(t, 0.8, resultSet["p40"].GetValue(50-10), 0.001)
+	// quantile is beyond the largest boundary, returns the largest boundary
+	assert.Equal(t, 1.0, resultSet["quantile(0.99,latency)"].GetValue(50-10))
+	assert.Equal(t, []string{"quantile(0.99,latency)"}, expression.QuantileOverflows())
+
+	expression.Reset()
+	assert.Empty(t, expression.QuantileOverflows())
+}
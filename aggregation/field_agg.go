@@ -19,6 +19,7 @@ package aggregation
 
 import (
 	"math"
+	"sort"
 
 	"github.com/lindb/lindb/pkg/collections"
 	"github.com/lindb/lindb/series"
@@ -53,12 +54,7 @@ type fieldAggregator struct {
 // e.g. segment start time = 20190905 10:00:00, start = 10, end = 50, interval = 10 seconds,
 // real query time range {20190905 10:01:40 ~ 20190905 10:08:20}
 func NewFieldAggregator(aggSpec AggregatorSpec, segmentStartTime int64, start, end int) FieldAggregator {
-	// TODO maybe agg type has duplicate?
-	var aggTypes []field.AggType
-	for f := range aggSpec.Functions() {
-		aggTypes = append(aggTypes, aggSpec.GetFieldType().GetFuncFieldParams(f)...)
-	}
-
+	aggTypes := aggTypesOf(aggSpec)
 	agg := &fieldAggregator{
 		aggTypes:         aggTypes,
 		segmentStartTime: segmentStartTime,
@@ -74,39 +70,83 @@ func (a *fieldAggregator) ResultSet() (startTime int64, it series.FieldIterator)
 	return a.segmentStartTime, newFieldIterator(a.start, a.aggTypes, a.fieldSeriesList)
 }
 
-// Aggregate aggregates the field series into current aggregator
+// Aggregate aggregates the field series into current aggregator,
+// each primitive series is aggregated into the series with same agg type.
 func (a *fieldAggregator) Aggregate(it series.FieldIterator) {
 	for it.HasNext() {
 		pIt := it.Next()
+		idx := -1
+		for i, aggType := range a.aggTypes {
+			if aggType == pIt.AggType() {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			// agg type not need
+			continue
+		}
 		for pIt.HasNext() {
 			slot, value := pIt.Next()
-			a.AggregateBySlot(slot, value)
+			a.aggregate(idx, slot, value)
 		}
 	}
 }
 
 // AggregateBySlot aggregates the field series into current aggregator
 func (a *fieldAggregator) AggregateBySlot(slot int, value float64) {
+	for idx := range a.aggTypes {
+		a.aggregate(idx, slot, value)
+	}
+}
+
+// aggregate aggregates the value into the series of agg type by index.
+func (a *fieldAggregator) aggregate(idx, slot int, value float64) {
 	// drop inf value
 	if math.IsInf(value, 1) {
 		return
 	}
 	pos := slot - a.start
-	for idx, aggType := range a.aggTypes {
-		values := a.fieldSeriesList[idx]
-		if values == nil {
-			values = collections.NewFloatArray(a.end - a.start + 1)
-			values.SetValue(pos, value)
-			a.fieldSeriesList[idx] = values
-		} else {
-			// slot too large for last family
-			if values.HasValue(pos) {
-				values.SetValue(pos, aggType.Aggregate(values.GetValue(pos), value))
-			} else {
-				values.SetValue(pos, value)
+	values := a.fieldSeriesList[idx]
+	if values == nil {
+		values = collections.NewFloatArray(a.end - a.start + 1)
+		values.SetValue(pos, value)
+		a.fieldSeriesList[idx] = values
+		return
+	}
+	// slot too large for last family
+	if values.HasValue(pos) {
+		values.SetValue(pos, a.aggTypes[idx].Aggregate(values.GetValue(pos), value))
+	} else {
+		values.SetValue(pos, value)
+	}
+}
+
+// containsAggType checks if agg type in agg type list.
+// aggTypesOf returns the agg types(in order) which the functions of aggregator spec need.
+func aggTypesOf(aggSpec AggregatorSpec) (aggTypes []field.AggType) {
+	// different functions maybe need same agg type, e.g. sum(f)/rate(f) of sum field
+	for f := range aggSpec.Functions() {
+		for _, aggType := range aggSpec.GetFieldType().GetFuncFieldParams(f) {
+			if !containsAggType(aggTypes, aggType) {
+				aggTypes = append(aggTypes, aggType)
 			}
 		}
 	}
+	// keep agg types in order, because functions is map
+	sort.Slice(aggTypes, func(i, j int) bool {
+		return aggTypes[i] < aggTypes[j]
+	})
+	return aggTypes
+}
+
+func containsAggType(aggTypes []field.AggType, aggType field.AggType) bool {
+	for _, t := range aggTypes {
+		if t == aggType {
+			return true
+		}
+	}
+	return false
 }
 
 // reset aggregator context for reusing.
@@ -25,6 +25,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/pkg/collections"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 )
@@ -47,6 +48,7 @@ func TestFieldAggregator_Aggregate(t *testing.T) {
 	it.EXPECT().HasNext().Return(false)
 	pIt := series.NewMockPrimitiveIterator(ctrl)
 	it.EXPECT().Next().Return(pIt)
+	pIt.EXPECT().AggType().Return(field.Sum)
 	pIt.EXPECT().HasNext().Return(true)
 	pIt.EXPECT().Next().Return(20, 10.0)
 	pIt.EXPECT().HasNext().Return(false)
@@ -58,3 +60,36 @@ func TestFieldAggregator_Aggregate(t *testing.T) {
 
 	agg.reset()
 }
+
+func TestFieldAggregator_multiFunctions(t *testing.T) {
+	aggSpec := NewAggregatorSpec("f", field.SumField)
+	aggSpec.AddFunctionType(function.Rate)
+	aggSpec.AddFunctionType(function.Max)
+	aggSpec.AddFunctionType(function.Sum)
+	agg := NewFieldAggregator(aggSpec, 1, 0, 10)
+	// duplicate agg type removed, and in order
+	assert.Equal(t, []field.AggType{field.Sum, field.Max}, agg.(*fieldAggregator).aggTypes)
+
+	agg.AggregateBySlot(1, 2.0)
+	agg.AggregateBySlot(1, 3.0)
+	// each primitive series aggregates into series with same agg type
+	sumValues := collections.NewFloatArray(11)
+	sumValues.SetValue(1, 10)
+	maxValues := collections.NewFloatArray(11)
+	maxValues.SetValue(1, 4)
+	minValues := collections.NewFloatArray(11)
+	minValues.SetValue(1, 1)
+	agg.Aggregate(newFieldIterator(0, []field.AggType{field.Max, field.Sum, field.Min},
+		[]*collections.FloatArray{maxValues, sumValues, minValues}))
+
+	_, it := agg.ResultSet()
+	result := make(map[field.AggType]float64)
+	for it.HasNext() {
+		pIt := it.Next()
+		for pIt.HasNext() {
+			_, value := pIt.Next()
+			result[pIt.AggType()] = value
+		}
+	}
+	assert.Equal(t, map[field.AggType]float64{field.Sum: 15, field.Max: 4}, result)
+}
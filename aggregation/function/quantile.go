@@ -29,6 +29,7 @@ type bucket struct {
 	upperBound float64
 	count      float64
 	itr        *collections.FloatArrayIterator
+	values     *collections.FloatArray
 }
 
 type buckets []bucket
@@ -125,3 +126,78 @@ func QuantileCall(q float64, histogramFields map[float64][]*collections.FloatArr
 
 	return targetFloatArray, nil
 }
+
+// HistogramQuantileCall calculates the quantile of histogram buckets(upper bound => bucket count, not cumulative)
+// by linear interpolation within the bucket for each time slot.
+// 0 <= q <= 1
+// Buckets of series with different layouts are merged on the union of upper bounds, a bucket not existing in
+// a series is treated as empty, so that the count of coarse bucket is attributed to the union bucket ending at
+// its upper bound.
+// 1. time slot without any observation is not set;
+// 2. if the quantile falls into +Inf bucket, the largest finite upper bound is returned and overflow is true.
+func HistogramQuantileCall(
+	q float64,
+	histogramBuckets map[float64]*collections.FloatArray,
+) (result *collections.FloatArray, overflow bool, err error) {
+	if q < 0 || q > 1 {
+		return nil, false, fmt.Errorf("HistogramQuantileCall with illegal value: %f", q)
+	}
+	capacity := -1
+	bkts := make(buckets, 0, len(histogramBuckets))
+	for upperBound, values := range histogramBuckets {
+		if values == nil {
+			continue
+		}
+		if capacity >= 0 && values.Capacity() != capacity {
+			return nil, false, fmt.Errorf("HistogramQuantileCall with buckets of different capacity: %d/%d",
+				capacity, values.Capacity())
+		}
+		capacity = values.Capacity()
+		bkts = append(bkts, bucket{upperBound: upperBound, values: values})
+	}
+	sort.Sort(bkts)
+	if len(bkts) == 0 || math.IsInf(bkts[0].upperBound, +1) {
+		return nil, false, fmt.Errorf("HistogramQuantileCall without finite upper bound bucket")
+	}
+
+	result = collections.NewFloatArray(capacity)
+	for pos := 0; pos < capacity; pos++ {
+		// cumulative count of buckets in time slot
+		observations := 0.0
+		for idx := range bkts {
+			if bkts[idx].values.HasValue(pos) {
+				if v := bkts[idx].values.GetValue(pos); v > 0 {
+					observations += v
+				}
+			}
+			bkts[idx].count = observations
+		}
+		if observations == 0 {
+			continue
+		}
+		rank := q * observations
+		// find the first non-empty bucket which covers the rank
+		b := sort.Search(len(bkts), func(i int) bool { return bkts[i].count > 0 && bkts[i].count >= rank })
+		switch {
+		case math.IsInf(bkts[b].upperBound, +1):
+			result.SetValue(pos, bkts[b-1].upperBound)
+			overflow = true
+			continue
+		case b == 0 && bkts[0].upperBound <= 0:
+			result.SetValue(pos, bkts[0].upperBound)
+			continue
+		}
+		var (
+			bucketStart float64
+			bucketEnd   = bkts[b].upperBound
+			count       = bkts[b].count
+		)
+		if b > 0 {
+			bucketStart = bkts[b-1].upperBound
+			count -= bkts[b-1].count
+			rank -= bkts[b-1].count
+		}
+		result.SetValue(pos, bucketStart+(bucketEnd-bucketStart)*(rank/count))
+	}
+	return result, overflow, nil
+}
@@ -161,3 +161,68 @@ func Test_QuantileCallBadCases(t *testing.T) {
 	_, err = QuantileCall(0.9, fields)
 	assert.Error(t, err)
 }
+
+func Test_HistogramQuantileCall(t *testing.T) {
+	newBucket := func(data ...float64) *collections.FloatArray {
+		array := collections.NewFloatArray(len(data))
+		for idx := range data {
+			if !math.IsNaN(data[idx]) {
+				array.SetValue(idx, data[idx])
+			}
+		}
+		return array
+	}
+	nan := math.NaN()
+	fields := map[float64]*collections.FloatArray{
+		1:           newBucket(1, 0, nan, 0),
+		2:           newBucket(2, 0, nan, 0),
+		4:           newBucket(5, 4, nan, 1),
+		8:           newBucket(2, 0, nan, 0),
+		math.Inf(1): newBucket(0, 0, nan, 9),
+	}
+	_, _, err := HistogramQuantileCall(-1, fields)
+	assert.Error(t, err)
+	_, _, err = HistogramQuantileCall(1.01, fields)
+	assert.Error(t, err)
+
+	array, overflow, err := HistogramQuantileCall(0.5, fields)
+	assert.NoError(t, err)
+	assert.True(t, overflow)
+	// time slot without observation is not set
+	assert.False(t, array.HasValue(2))
+	assert.InDeltaSlice(t, []float64{2.8, 3, 8}, getDataFloatArray(array), 0.001)
+
+	array, overflow, err = HistogramQuantileCall(0.9, fields)
+	assert.NoError(t, err)
+	assert.True(t, overflow)
+	assert.InDeltaSlice(t, []float64{6, 3.8, 8}, getDataFloatArray(array), 0.001)
+
+	// quantile(0) returns the lower bound of first non-empty bucket
+	array, overflow, err = HistogramQuantileCall(0, fields)
+	assert.NoError(t, err)
+	assert.False(t, overflow)
+	assert.InDeltaSlice(t, []float64{0, 2, 2}, getDataFloatArray(array), 0.001)
+
+	// without +Inf bucket, quantile never beyond the largest boundary
+	array, overflow, err = HistogramQuantileCall(1, map[float64]*collections.FloatArray{
+		1: newBucket(1), 2: newBucket(1), 4: nil,
+	})
+	assert.NoError(t, err)
+	assert.False(t, overflow)
+	assert.Equal(t, []float64{2}, getDataFloatArray(array))
+
+	// upper bound of first bucket is not positive
+	array, _, err = HistogramQuantileCall(0.1, map[float64]*collections.FloatArray{
+		-1: newBucket(1), 2: newBucket(1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{-1}, getDataFloatArray(array))
+
+	// bad cases
+	_, _, err = HistogramQuantileCall(0.5, map[float64]*collections.FloatArray{math.Inf(1): newBucket(1)})
+	assert.Error(t, err)
+	_, _, err = HistogramQuantileCall(0.5, map[float64]*collections.FloatArray{})
+	assert.Error(t, err)
+	_, _, err = HistogramQuantileCall(0.5, map[float64]*collections.FloatArray{1: newBucket(1), 2: newBucket(1, 2)})
+	assert.Error(t, err)
+}
@@ -32,6 +32,7 @@ const (
 	Quantile
 	Stddev
 	Rate
+	Increase
 )
 
 // String return the function's name
@@ -57,11 +58,18 @@ func (t FuncType) String() string {
 		return "stddev"
 	case Rate:
 		return "rate"
+	case Increase:
+		return "increase"
 	default:
 		return "unknown"
 	}
 }
 
+// IsCounterFunc checks if function calculates the increase of counter, like rate/increase.
+func IsCounterFunc(t FuncType) bool {
+	return t == Rate || t == Increase
+}
+
 // IsSupportOrderBy checks if function support order by.
 func IsSupportOrderBy(t FuncType) bool {
 	return t == Sum || t == Min || t == Max || t == Count || t == Avg || t == Last || t == First || t == Stddev
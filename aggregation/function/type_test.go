@@ -34,9 +34,17 @@ func TestFuncTypeString(t *testing.T) {
 	assert.Equal(t, "quantile", Quantile.String())
 	assert.Equal(t, "stddev", Stddev.String())
 	assert.Equal(t, "rate", Rate.String())
+	assert.Equal(t, "increase", Increase.String())
 	assert.Equal(t, "unknown", Unknown.String())
 }
 
+func TestIsCounterFunc(t *testing.T) {
+	assert.True(t, IsCounterFunc(Rate))
+	assert.True(t, IsCounterFunc(Increase))
+	assert.False(t, IsCounterFunc(Sum))
+	assert.False(t, IsCounterFunc(Last))
+}
+
 func TestIsSupportOrderBy(t *testing.T) {
 	assert.True(t, IsSupportOrderBy(Max))
 	assert.False(t, IsSupportOrderBy(Quantile))
@@ -0,0 +1,133 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+
+	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/pkg/collections"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+// GapFiller fills the missing slots of group's series after down sampling, based on fill policy of group by time query.
+//
+// Interaction with aggregations:
+//  1. fill(null) keeps the missing slots as null(no point returned), same as query without fill option;
+//  2. fill(<value>) fills the missing slots of all select items with the constant value, including count;
+//  3. fill(previous)/fill(linear) don't fill the select items of count function, filled points are not real points,
+//     so that they shouldn't inflate the count. Other aggregations like sum are filled per slot,
+//     so summing a filled series over time includes the filled values.
+type GapFiller struct {
+	fill      stmt.FillType
+	fillValue float64
+	slots     int
+	skipped   map[string]struct{} // select items which not be filled
+}
+
+// NewGapFiller creates a gap filler, slots is the number of time slots of result series.
+func NewGapFiller(fill stmt.FillType, fillValue float64, slots int, selectItems []stmt.Expr) *GapFiller {
+	f := &GapFiller{
+		fill:      fill,
+		fillValue: fillValue,
+		slots:     slots,
+		skipped:   make(map[string]struct{}),
+	}
+	if fill == stmt.FillPrevious || fill == stmt.FillLinear {
+		for _, item := range selectItems {
+			selectItem, ok := item.(*stmt.SelectItem)
+			if !ok {
+				continue
+			}
+			if call, ok := selectItem.Expr.(*stmt.CallExpr); ok && call.FuncType == function.Count {
+				name := selectItem.Alias
+				if name == "" {
+					name = selectItem.Rewrite()
+				}
+				f.skipped[name] = struct{}{}
+			}
+		}
+	}
+	return f
+}
+
+// Fill fills the missing slots of group's result set(field name => series data) in place.
+func (f *GapFiller) Fill(resultSet map[string]*collections.FloatArray) {
+	if f.fill == stmt.FillDrop || f.fill == stmt.FillNull {
+		return
+	}
+	// select items may share same series data, cannot fill the data shared with skipped items
+	skipped := make(map[*collections.FloatArray]struct{}, len(f.skipped))
+	for name := range f.skipped {
+		if values, ok := resultSet[name]; ok {
+			skipped[values] = struct{}{}
+		}
+	}
+	filled := make(map[*collections.FloatArray]struct{}, len(resultSet))
+	for _, values := range resultSet {
+		if values == nil {
+			continue
+		}
+		if _, ok := skipped[values]; ok {
+			continue
+		}
+		if _, ok := filled[values]; ok {
+			continue
+		}
+		filled[values] = struct{}{}
+		FillGaps(values, f.fill, f.fillValue, f.slots)
+	}
+}
+
+// FillGaps fills the missing slots([0, slots)) of series data based on fill policy,
+// linear interpolation only fills the slots between two real points, never extrapolates past the ends.
+func FillGaps(values *collections.FloatArray, fill stmt.FillType, fillValue float64, slots int) {
+	if values == nil {
+		return
+	}
+	if slots > values.Capacity() {
+		slots = values.Capacity()
+	}
+	prevIdx := -1
+	for idx := 0; idx < slots; idx++ {
+		if hasPoint(values, idx) {
+			if fill == stmt.FillLinear && prevIdx >= 0 && idx-prevIdx > 1 {
+				prev, next := values.GetValue(prevIdx), values.GetValue(idx)
+				step := (next - prev) / float64(idx-prevIdx)
+				for gap := prevIdx + 1; gap < idx; gap++ {
+					values.SetValue(gap, prev+step*float64(gap-prevIdx))
+				}
+			}
+			prevIdx = idx
+			continue
+		}
+		switch fill {
+		case stmt.FillValue:
+			values.SetValue(idx, fillValue)
+		case stmt.FillPrevious:
+			if prevIdx >= 0 {
+				values.SetValue(idx, values.GetValue(prevIdx))
+			}
+		}
+	}
+}
+
+// hasPoint checks if the slot has real point, NaN(like division by zero) is treated as missing.
+func hasPoint(values *collections.FloatArray, idx int) bool {
+	return values.HasValue(idx) && !math.IsNaN(values.GetValue(idx))
+}
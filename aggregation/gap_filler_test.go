@@ -0,0 +1,155 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/collections"
+	"github.com/lindb/lindb/sql"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestFillGaps(t *testing.T) {
+	nan := math.NaN()
+	cases := []struct {
+		name      string
+		values    map[int]float64
+		fill      stmt.FillType
+		fillValue float64
+		slots     int
+		expect    map[int]float64
+	}{
+		{
+			name:   "fill null",
+			values: map[int]float64{1: 1, 3: 3},
+			fill:   stmt.FillNull,
+			slots:  5,
+			expect: map[int]float64{1: 1, 3: 3},
+		},
+		{
+			name:      "fill value",
+			values:    map[int]float64{1: 1, 3: 3},
+			fill:      stmt.FillValue,
+			fillValue: 10,
+			slots:     5,
+			expect:    map[int]float64{0: 10, 1: 1, 2: 10, 3: 3, 4: 10},
+		},
+		{
+			name:   "fill previous, no previous value before first point",
+			values: map[int]float64{1: 1, 3: 3},
+			fill:   stmt.FillPrevious,
+			slots:  5,
+			expect: map[int]float64{1: 1, 2: 1, 3: 3, 4: 3},
+		},
+		{
+			name:   "fill linear, never extrapolate past the ends",
+			values: map[int]float64{1: 1, 4: 7, 5: 8},
+			fill:   stmt.FillLinear,
+			slots:  7,
+			expect: map[int]float64{1: 1, 2: 3, 3: 5, 4: 7, 5: 8},
+		},
+		{
+			name:   "fill linear, one point",
+			values: map[int]float64{2: 2},
+			fill:   stmt.FillLinear,
+			slots:  5,
+			expect: map[int]float64{2: 2},
+		},
+		{
+			name:   "fill linear, NaN is missing",
+			values: map[int]float64{0: 0, 1: nan, 2: 4},
+			fill:   stmt.FillLinear,
+			slots:  3,
+			expect: map[int]float64{0: 0, 1: 2, 2: 4},
+		},
+		{
+			name:      "slots greater than capacity",
+			values:    map[int]float64{0: 1},
+			fill:      stmt.FillValue,
+			fillValue: 0,
+			slots:     100,
+			expect:    map[int]float64{0: 1, 1: 0, 2: 0, 3: 0, 4: 0, 5: 0, 6: 0, 7: 0, 8: 0, 9: 0},
+		},
+		{
+			name:      "fill only the slots of result",
+			values:    map[int]float64{0: 1},
+			fill:      stmt.FillValue,
+			fillValue: 0,
+			slots:     2,
+			expect:    map[int]float64{0: 1, 1: 0},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			values := collections.NewFloatArray(10)
+			for idx, val := range tt.values {
+				values.SetValue(idx, val)
+			}
+			FillGaps(values, tt.fill, tt.fillValue, tt.slots)
+			rs := make(map[int]float64)
+			it := values.NewIterator()
+			for it.HasNext() {
+				idx, val := it.Next()
+				rs[idx] = val
+			}
+			assert.Equal(t, tt.expect, rs)
+		})
+	}
+	// nil series data
+	FillGaps(nil, stmt.FillValue, 0, 10)
+}
+
+func TestGapFiller_Fill(t *testing.T) {
+	q, err := sql.Parse("select sum(f), count(f), count(f) as c, f from cpu group by time(1m) fill(previous)")
+	assert.NoError(t, err)
+	query := q.(*stmt.Query)
+	newValues := func() *collections.FloatArray {
+		values := collections.NewFloatArray(3)
+		values.SetValue(0, 1)
+		return values
+	}
+	shared := newValues()
+	resultSet := map[string]*collections.FloatArray{
+		"sum(f)":   newValues(),
+		"count(f)": newValues(),
+		"c":        shared,
+		"f":        shared,
+		"nil":      nil,
+	}
+	NewGapFiller(query.Fill, query.FillValue, 3, query.SelectItems).Fill(resultSet)
+	assert.Equal(t, 3, resultSet["sum(f)"].Size())
+	// filled points shouldn't inflate count
+	assert.Equal(t, 1, resultSet["count(f)"].Size())
+	assert.Equal(t, 1, resultSet["c"].Size())
+
+	// constant fill includes count
+	resultSet = map[string]*collections.FloatArray{"count(f)": newValues()}
+	NewGapFiller(stmt.FillValue, 0, 3, query.SelectItems).Fill(resultSet)
+	assert.Equal(t, 3, resultSet["count(f)"].Size())
+
+	// fill null/drop keeps series data
+	resultSet = map[string]*collections.FloatArray{"sum(f)": newValues()}
+	NewGapFiller(stmt.FillNull, 0, 3, query.SelectItems).Fill(resultSet)
+	NewGapFiller(stmt.FillDrop, 0, 3, query.SelectItems).Fill(resultSet)
+	assert.Equal(t, 1, resultSet["sum(f)"].Size())
+}
@@ -34,6 +34,8 @@ type GroupingAggregator interface {
 	TimeRange() timeutil.TimeRange
 	// Interval return the time interval of aggregator.
 	Interval() timeutil.Interval
+	// NumOfGroups returns the number of grouped series buffered by aggregator.
+	NumOfGroups() int
 }
 
 // groupingAggregator implements GroupingAggregator interface.
@@ -63,7 +65,11 @@ func NewGroupingAggregator(
 
 // Aggregate aggregates the time series data.
 func (ga *groupingAggregator) Aggregate(it series.GroupedIterator) {
-	seriesAgg := ga.getAggregator(it.Tags())
+	ga.aggregate(ga.getAggregator(it.Tags()), it)
+}
+
+// aggregate merges the time series data into the field aggregates of group.
+func (ga *groupingAggregator) aggregate(seriesAgg FieldAggregates, it series.GroupedIterator) {
 	var sAgg SeriesAggregator
 	for it.HasNext() {
 		seriesIt := it.Next()
@@ -122,6 +128,11 @@ func (ga *groupingAggregator) Interval() timeutil.Interval {
 	return ga.interval
 }
 
+// NumOfGroups returns the number of grouped series buffered by aggregator.
+func (ga *groupingAggregator) NumOfGroups() int {
+	return len(ga.aggregates)
+}
+
 // getAggregator returns the time series aggregator by the tag of time series.
 func (ga *groupingAggregator) getAggregator(tags string) (agg FieldAggregates) {
 	// get series aggregator
@@ -104,6 +104,7 @@ func TestGroupByAggregator_Aggregate(t *testing.T) {
 			agg.Aggregate(gIt)
 			rs := agg.ResultSet()
 			assert.NotNil(t, rs)
+			assert.Equal(t, 1, agg.NumOfGroups())
 		})
 	}
 
@@ -117,6 +118,7 @@ func TestGroupByAggregator_Aggregate(t *testing.T) {
 		AggregatorSpecs{})
 	rs := agg.ResultSet()
 	assert.Nil(t, rs)
+	assert.Zero(t, agg.NumOfGroups())
 	assert.Equal(t, timeutil.Interval(timeutil.OneSecond), agg.Interval())
 	assert.Equal(t,
 		timeutil.TimeRange{
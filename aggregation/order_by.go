@@ -154,19 +154,26 @@ type OrderBy interface {
 
 // resultLimiter represents a size limit container, implements OrderBy interface.
 type resultLimiter struct {
-	rows  []Row
-	limit int
+	rows   []Row
+	offset int
+	limit  int
+	pushed int
 }
 
-// NewResultLimiter creates a size limit container.
-func NewResultLimiter(limit int) OrderBy {
+// NewResultLimiter creates a size limit container, skips the first offset rows.
+func NewResultLimiter(offset, limit int) OrderBy {
 	return &resultLimiter{
-		limit: limit,
+		offset: offset,
+		limit:  limit,
 	}
 }
 
 // Push pushes row into limit container.
 func (r *resultLimiter) Push(row Row) {
+	r.pushed++
+	if r.pushed <= r.offset {
+		return
+	}
 	if len(r.rows) < r.limit {
 		r.rows = append(r.rows, row)
 	}
@@ -179,13 +186,15 @@ func (r *resultLimiter) ResultSet() []Row {
 
 // optNOrderBy implements OrderBy interface(top n).
 type topNOrderBy struct {
-	topn *topNHeap
+	topn   *topNHeap
+	offset int
 }
 
-// NewTopNOrderBy creates a topNOrderBy container instance.
-func NewTopNOrderBy(orderByItems []*OrderByItem, topN int) OrderBy {
+// NewTopNOrderBy creates a topNOrderBy container instance, skips the first offset rows after ordering.
+func NewTopNOrderBy(orderByItems []*OrderByItem, offset, topN int) OrderBy {
 	return &topNOrderBy{
-		topn: newTopNHeap(orderByItems, topN),
+		topn:   newTopNHeap(orderByItems, offset+topN),
+		offset: offset,
 	}
 }
 
@@ -196,5 +205,12 @@ func (o *topNOrderBy) Push(row Row) {
 
 // ResultSet returns result set for topN.
 func (o *topNOrderBy) ResultSet() []Row {
-	return o.topn.ResultSet()
+	if o.offset <= 0 {
+		return o.topn.ResultSet()
+	}
+	rows := o.topn.SortedResultSet()
+	if o.offset >= len(rows) {
+		return nil
+	}
+	return rows[o.offset:]
 }
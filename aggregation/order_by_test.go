@@ -31,7 +31,7 @@ func TestTopNOrderBy(t *testing.T) {
 	data := []float64{20, 1, 23, 40, 3, 50, 10, 43, 1000, 50, 20}
 	topNAsc := NewTopNOrderBy([]*OrderByItem{{
 		Desc: false,
-	}}, 5)
+	}}, 0, 5)
 	for _, d := range data {
 		r := newRow(d)
 		topNAsc.Push(r)
@@ -43,6 +43,28 @@ func TestTopNOrderBy(t *testing.T) {
 	}
 	sort.Float64s(rs)
 	assert.Equal(t, []float64{1, 3, 10, 20, 20}, rs)
+
+	// with offset
+	topNDesc := NewTopNOrderBy([]*OrderByItem{{
+		Desc: true,
+	}}, 2, 3)
+	for _, d := range data {
+		topNDesc.Push(newRow(d))
+	}
+	rs = nil
+	for _, r := range topNDesc.ResultSet() {
+		rs = append(rs, r.GetValue("", function.Count))
+	}
+	assert.Equal(t, []float64{50, 43, 40}, rs)
+
+	// offset out of range
+	topNDesc = NewTopNOrderBy([]*OrderByItem{{
+		Desc: true,
+	}}, 20, 3)
+	for _, d := range data {
+		topNDesc.Push(newRow(d))
+	}
+	assert.Empty(t, topNDesc.ResultSet())
 }
 
 func TestOrderByRow(t *testing.T) {
@@ -92,7 +114,7 @@ func TestOrderByRow(t *testing.T) {
 }
 
 func TestResultLimiter(t *testing.T) {
-	limiter := NewResultLimiter(2)
+	limiter := NewResultLimiter(0, 2)
 	r1 := newRow(1)
 	r2 := newRow(2)
 	r3 := newRow(3)
@@ -100,4 +122,10 @@ func TestResultLimiter(t *testing.T) {
 	limiter.Push(r2)
 	limiter.Push(r3)
 	assert.Equal(t, []Row{r1, r2}, limiter.ResultSet())
+
+	limiter = NewResultLimiter(1, 1)
+	limiter.Push(r1)
+	limiter.Push(r2)
+	limiter.Push(r3)
+	assert.Equal(t, []Row{r2}, limiter.ResultSet())
 }
@@ -77,6 +77,20 @@ func (a *aggregatorSpec) AddFunctionType(funcType function.FuncType) {
 	}
 }
 
+// IsCounterSpec checks if the values of field are cumulative counter, which need to be converted into
+// increase per series before aggregation, like rate/increase of last field.
+func IsCounterSpec(spec AggregatorSpec) bool {
+	if spec.GetFieldType() != field.LastField {
+		return false
+	}
+	for funcType := range spec.Functions() {
+		if function.IsCounterFunc(funcType) {
+			return true
+		}
+	}
+	return false
+}
+
 // Functions returns function types for down sampling.
 func (a *aggregatorSpec) Functions() map[function.FuncType]function.FuncType {
 	return a.functions
@@ -38,3 +38,14 @@ func TestAggregatorSpec_AddFunctionType(t *testing.T) {
 	agg.AddFunctionType(function.Sum)
 	assert.Equal(t, 1, len(agg.Functions()))
 }
+
+func TestIsCounterSpec(t *testing.T) {
+	agg := NewAggregatorSpec("f1", field.SumField)
+	agg.AddFunctionType(function.Rate)
+	assert.False(t, IsCounterSpec(agg))
+	agg = NewAggregatorSpec("f1", field.LastField)
+	agg.AddFunctionType(function.Last)
+	assert.False(t, IsCounterSpec(agg))
+	agg.AddFunctionType(function.Increase)
+	assert.True(t, IsCounterSpec(agg))
+}
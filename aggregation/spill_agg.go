@@ -0,0 +1,400 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series"
+	"github.com/lindb/lindb/series/field"
+)
+
+// for testing
+var (
+	createTempFn = os.CreateTemp
+	openFileFn   = os.Open
+)
+
+const (
+	// groupOverhead is the estimated memory of one group(map entry, tag values header and field aggregates).
+	groupOverhead = 64
+	// seriesAggOverhead is the estimated memory of series aggregator of one field, excludes the points.
+	seriesAggOverhead = 128
+	// spillFilePattern is the name pattern of temporary file which grouped series are spilled to.
+	spillFilePattern = "group-agg-*.spill"
+)
+
+var errSpillAggClosed = errors.New("spillable grouping aggregator is closed")
+
+// SpillableGroupingAggregator represents a grouping aggregator which tracks the estimated memory of grouped series,
+// the grouped series buffered in memory can be spilled to temporary files, then merged with others in the final pass.
+type SpillableGroupingAggregator interface {
+	GroupingAggregator
+	// MemoryUsage returns the estimated memory of grouped series buffered in memory.
+	MemoryUsage() int64
+	// PeakMemory returns the peak estimated memory of grouped series buffered in memory.
+	PeakMemory() int64
+	// SpilledBytes returns the total bytes of grouped series spilled to temporary files.
+	SpilledBytes() int64
+	// Spill writes the grouped series buffered in memory to a temporary file in order of tag values, then releases them.
+	Spill() error
+	// ForEach merges the grouped series in memory and spilled files, calls fn for each group in order of tag values.
+	ForEach(fn func(it series.GroupedIterator) error) error
+	// Close removes the temporary files spilled.
+	Close() error
+}
+
+// spillableGroupingAggregator implements SpillableGroupingAggregator interface.
+type spillableGroupingAggregator struct {
+	*groupingAggregator
+
+	dir       string
+	groupCost int64 // estimated memory of one group, excludes tag values
+	usage     int64
+	peak      int64
+	spilled   int64
+	files     []string
+	closed    bool
+}
+
+// NewSpillableGroupingAggregator creates a grouping aggregator which spills grouped series to temporary files under dir.
+func NewSpillableGroupingAggregator(
+	interval timeutil.Interval,
+	intervalRatio int,
+	timeRange timeutil.TimeRange,
+	aggSpecs AggregatorSpecs,
+	dir string,
+) SpillableGroupingAggregator {
+	return &spillableGroupingAggregator{
+		groupingAggregator: NewGroupingAggregator(interval, intervalRatio, timeRange, aggSpecs).(*groupingAggregator),
+		dir:                dir,
+		groupCost:          estimateGroupCost(interval, intervalRatio, timeRange, aggSpecs),
+	}
+}
+
+// Aggregate aggregates the time series data, tracks the memory of new group.
+func (ga *spillableGroupingAggregator) Aggregate(it series.GroupedIterator) {
+	tags := it.Tags()
+	seriesAgg, ok := ga.aggregates[tags]
+	if !ok {
+		seriesAgg = ga.getAggregator(tags)
+		ga.usage += ga.groupCost + int64(len(tags))
+		if ga.usage > ga.peak {
+			ga.peak = ga.usage
+		}
+	}
+	ga.aggregate(seriesAgg, it)
+}
+
+// ResultSet returns the result set of aggregator, the grouped series of spilled files are loaded into memory,
+// returns nil if failing to read spilled files, so ForEach is preferred if grouped series spilled.
+func (ga *spillableGroupingAggregator) ResultSet() series.GroupedIterators {
+	if len(ga.files) == 0 {
+		return ga.groupingAggregator.ResultSet()
+	}
+	var seriesList []series.GroupedIterator
+	if err := ga.ForEach(func(it series.GroupedIterator) error {
+		seriesList = append(seriesList, it)
+		return nil
+	}); err != nil {
+		return nil
+	}
+	return seriesList
+}
+
+// MemoryUsage returns the estimated memory of grouped series buffered in memory.
+func (ga *spillableGroupingAggregator) MemoryUsage() int64 {
+	return ga.usage
+}
+
+// PeakMemory returns the peak estimated memory of grouped series buffered in memory.
+func (ga *spillableGroupingAggregator) PeakMemory() int64 {
+	return ga.peak
+}
+
+// SpilledBytes returns the total bytes of grouped series spilled to temporary files.
+func (ga *spillableGroupingAggregator) SpilledBytes() int64 {
+	return ga.spilled
+}
+
+// Spill writes the grouped series buffered in memory to a temporary file in order of tag values, then releases them.
+// Record format: tags(uvarint len+bytes), num. of fields(uvarint), [field name(uvarint len+bytes), data(uvarint len+bytes)].
+func (ga *spillableGroupingAggregator) Spill() (err error) {
+	if ga.closed {
+		return errSpillAggClosed
+	}
+	if len(ga.aggregates) == 0 {
+		return nil
+	}
+	if ga.dir != "" {
+		if err := os.MkdirAll(ga.dir, 0o755); err != nil {
+			return err
+		}
+	}
+	f, err := createTempFn(ga.dir, spillFilePattern)
+	if err != nil {
+		return err
+	}
+	// keep file name first, so that it can be removed when closing if spilling failure
+	ga.files = append(ga.files, f.Name())
+	defer func() {
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	w := &spillWriter{w: bufio.NewWriter(f)}
+	for _, tags := range ga.sortedTags() {
+		it := ga.aggregates[tags].ResultSet(tags)
+		var fields [][2][]byte
+		for it.HasNext() {
+			seriesIt := it.Next()
+			data, err := seriesIt.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if len(data) == 0 {
+				continue
+			}
+			fields = append(fields, [2][]byte{[]byte(seriesIt.FieldName()), data})
+		}
+		w.writeBytes([]byte(tags))
+		w.writeUvarint(uint64(len(fields)))
+		for _, f := range fields {
+			w.writeBytes(f[0])
+			w.writeBytes(f[1])
+		}
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	ga.spilled += w.size
+	// release the grouped series in memory
+	ga.aggregates = make(map[string]FieldAggregates)
+	ga.usage = 0
+	return nil
+}
+
+// ForEach merges the grouped series in memory and spilled files, calls fn for each group in order of tag values.
+func (ga *spillableGroupingAggregator) ForEach(fn func(it series.GroupedIterator) error) error {
+	if len(ga.files) == 0 {
+		for _, tags := range ga.sortedTags() {
+			if err := fn(ga.aggregates[tags].ResultSet(tags)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	// spill the grouped series in memory too, then do k-way merge of all sorted files
+	if err := ga.Spill(); err != nil {
+		return err
+	}
+	readers := make([]*spillReader, 0, len(ga.files))
+	defer func() {
+		for _, r := range readers {
+			_ = r.f.Close()
+		}
+	}()
+	for _, name := range ga.files {
+		f, err := openFileFn(name)
+		if err != nil {
+			return err
+		}
+		r := &spillReader{f: f, r: bufio.NewReader(f)}
+		readers = append(readers, r)
+		if err := r.next(); err != nil {
+			return err
+		}
+	}
+	for {
+		tags, ok := minSpilledTags(readers)
+		if !ok {
+			return nil
+		}
+		seriesAgg := NewFieldAggregates(ga.interval, ga.intervalRatio, ga.timeRange, ga.aggSpecs)
+		for _, r := range readers {
+			// tag values are unique in each file
+			if r.eof || r.tags != tags {
+				continue
+			}
+			ga.aggregate(seriesAgg, series.NewGroupedIterator(tags, r.fields))
+			if err := r.next(); err != nil {
+				return err
+			}
+		}
+		if err := fn(seriesAgg.ResultSet(tags)); err != nil {
+			return err
+		}
+	}
+}
+
+// Close removes the temporary files spilled.
+func (ga *spillableGroupingAggregator) Close() error {
+	if ga.closed {
+		return nil
+	}
+	ga.closed = true
+	var err error
+	for _, name := range ga.files {
+		if removeErr := os.Remove(name); removeErr != nil && !os.IsNotExist(removeErr) {
+			err = removeErr
+		}
+	}
+	ga.files = nil
+	ga.aggregates = make(map[string]FieldAggregates)
+	ga.usage = 0
+	return err
+}
+
+// sortedTags returns the tag values of grouped series buffered in memory in order.
+func (ga *spillableGroupingAggregator) sortedTags() []string {
+	tagsList := make([]string, 0, len(ga.aggregates))
+	for tags := range ga.aggregates {
+		tagsList = append(tagsList, tags)
+	}
+	sort.Strings(tagsList)
+	return tagsList
+}
+
+// estimateGroupCost returns the estimated memory of one group(the points of all field aggregates), excludes tag values.
+func estimateGroupCost(interval timeutil.Interval, intervalRatio int, timeRange timeutil.TimeRange, aggSpecs AggregatorSpecs) int64 {
+	points := int64(1)
+	if step := interval.Int64() * int64(intervalRatio); step > 0 && timeRange.End > timeRange.Start {
+		points = (timeRange.End-timeRange.Start)/step + 1
+	}
+	cost := int64(groupOverhead)
+	for _, aggSpec := range aggSpecs {
+		// 8 bytes for each point of float array
+		cost += seriesAggOverhead + int64(len(aggTypesOf(aggSpec)))*points*8
+	}
+	return cost
+}
+
+// minSpilledTags returns the minimum tag values of current records, returns false if all files are read.
+func minSpilledTags(readers []*spillReader) (tags string, ok bool) {
+	for _, r := range readers {
+		if r.eof {
+			continue
+		}
+		if !ok || r.tags < tags {
+			tags = r.tags
+			ok = true
+		}
+	}
+	return tags, ok
+}
+
+// spillWriter writes the records of grouped series to spilled file.
+type spillWriter struct {
+	w       *bufio.Writer
+	scratch [binary.MaxVarintLen64]byte
+	size    int64
+	err     error
+}
+
+func (w *spillWriter) writeUvarint(v uint64) {
+	n := binary.PutUvarint(w.scratch[:], v)
+	w.write(w.scratch[:n])
+}
+
+func (w *spillWriter) writeBytes(data []byte) {
+	w.writeUvarint(uint64(len(data)))
+	w.write(data)
+}
+
+func (w *spillWriter) write(data []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(data)
+	w.size += int64(n)
+	w.err = err
+}
+
+func (w *spillWriter) flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// spillReader reads the records of grouped series from spilled file one by one.
+type spillReader struct {
+	f      *os.File
+	r      *bufio.Reader
+	eof    bool
+	tags   string
+	fields map[field.Name][]byte
+}
+
+// next reads the next record, sets eof if all records are read.
+func (r *spillReader) next() error {
+	tags, err := r.readBytes()
+	if err == io.EOF {
+		r.eof = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	numOfFields, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	fields := make(map[field.Name][]byte, numOfFields)
+	for i := uint64(0); i < numOfFields; i++ {
+		fieldName, err := r.readBytes()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		data, err := r.readBytes()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		fields[field.Name(fieldName)] = data
+	}
+	r.tags = string(tags)
+	r.fields = fields
+	return nil
+}
+
+func (r *spillReader) readBytes() ([]byte, error) {
+	length, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return data, nil
+}
+
+// unexpectedEOF returns io.ErrUnexpectedEOF if record is truncated.
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,215 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package aggregation
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series"
+	"github.com/lindb/lindb/series/field"
+)
+
+var (
+	spillNow, _        = timeutil.ParseTimestamp("20190702 19:10:00", "20060102 15:04:05")
+	spillFamilyTime, _ = timeutil.ParseTimestamp("20190702 19:00:00", "20060102 15:04:05")
+	spillInterval      = timeutil.Interval(10 * timeutil.OneSecond)
+	spillTimeRange     = timeutil.TimeRange{Start: spillNow, End: spillNow + timeutil.OneHour}
+)
+
+func newSpillAggSpecs() AggregatorSpecs {
+	spec := NewAggregatorSpec("f", field.SumField)
+	spec.AddFunctionType(function.Sum)
+	return AggregatorSpecs{spec}
+}
+
+// newSpillSeries returns the grouped series with value of slot 61 like the response of leaf node.
+func newSpillSeries(t *testing.T, tags string, value float64) series.GroupedIterator {
+	seriesAgg := NewSeriesAggregator(spillInterval, 1, spillTimeRange, newSpillAggSpecs()[0])
+	agg, ok := seriesAgg.GetAggregator(spillFamilyTime)
+	assert.True(t, ok)
+	agg.AggregateBySlot(61, value)
+	data, err := seriesAgg.ResultSet().MarshalBinary()
+	assert.NoError(t, err)
+	return series.NewGroupedIterator(tags, map[field.Name][]byte{"f": data})
+}
+
+// collectSpillAgg returns tag values => value of slot 61 by iterating the aggregator.
+func collectSpillAgg(t *testing.T, agg SpillableGroupingAggregator) (tagsList []string, values []float64) {
+	err := agg.ForEach(func(it series.GroupedIterator) error {
+		tagsList = append(tagsList, it.Tags())
+		for it.HasNext() {
+			seriesIt := it.Next()
+			for seriesIt.HasNext() {
+				_, fieldIt := seriesIt.Next()
+				for fieldIt.HasNext() {
+					pIt := fieldIt.Next()
+					for pIt.HasNext() {
+						_, value := pIt.Next()
+						values = append(values, value)
+					}
+				}
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	return tagsList, values
+}
+
+func TestSpillableGroupingAggregator_Spill(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "spill")
+	agg := NewSpillableGroupingAggregator(spillInterval, 1, spillTimeRange, newSpillAggSpecs(), dir)
+	assert.Zero(t, agg.MemoryUsage())
+	// spill nothing
+	assert.NoError(t, agg.Spill())
+	assert.Zero(t, agg.SpilledBytes())
+
+	agg.Aggregate(newSpillSeries(t, "b", 2))
+	agg.Aggregate(newSpillSeries(t, "a", 1))
+	agg.Aggregate(newSpillSeries(t, "a", 1))
+	assert.Equal(t, 2, agg.NumOfGroups())
+	usage := agg.MemoryUsage()
+	assert.Equal(t, 2*estimateGroupCost(spillInterval, 1, spillTimeRange, newSpillAggSpecs())+2, usage)
+
+	assert.NoError(t, agg.Spill())
+	assert.Zero(t, agg.MemoryUsage())
+	assert.Zero(t, agg.NumOfGroups())
+	assert.Equal(t, usage, agg.PeakMemory())
+	assert.True(t, agg.SpilledBytes() > 0)
+
+	agg.Aggregate(newSpillSeries(t, "c", 4))
+	agg.Aggregate(newSpillSeries(t, "a", 3))
+	tagsList, values := collectSpillAgg(t, agg)
+	assert.Equal(t, []string{"a", "b", "c"}, tagsList)
+	assert.Equal(t, []float64{5, 2, 4}, values)
+	// merge again
+	tagsList, values = collectSpillAgg(t, agg)
+	assert.Equal(t, []string{"a", "b", "c"}, tagsList)
+	assert.Equal(t, []float64{5, 2, 4}, values)
+	assert.Len(t, agg.ResultSet(), 3)
+	assert.Equal(t, usage, agg.PeakMemory())
+
+	err := agg.ForEach(func(_ series.GroupedIterator) error {
+		return fmt.Errorf("err")
+	})
+	assert.Error(t, err)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.NoError(t, agg.Close())
+	assert.NoError(t, agg.Close())
+	files, err = os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+	assert.Equal(t, errSpillAggClosed, agg.Spill())
+}
+
+func TestSpillableGroupingAggregator_InMemory(t *testing.T) {
+	agg := NewSpillableGroupingAggregator(spillInterval, 1, spillTimeRange, newSpillAggSpecs(), t.TempDir())
+	defer func() {
+		_ = agg.Close()
+	}()
+	agg.Aggregate(newSpillSeries(t, "b", 2))
+	agg.Aggregate(newSpillSeries(t, "a", 1))
+	tagsList, values := collectSpillAgg(t, agg)
+	assert.Equal(t, []string{"a", "b"}, tagsList)
+	assert.Equal(t, []float64{1, 2}, values)
+	assert.Len(t, agg.ResultSet(), 2)
+	assert.Zero(t, agg.SpilledBytes())
+
+	err := agg.ForEach(func(_ series.GroupedIterator) error {
+		return fmt.Errorf("err")
+	})
+	assert.Error(t, err)
+}
+
+func TestSpillableGroupingAggregator_Failure(t *testing.T) {
+	defer func() {
+		createTempFn = os.CreateTemp
+		openFileFn = os.Open
+	}()
+	t.Run("create spill dir failure", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		assert.NoError(t, os.WriteFile(file, []byte("abc"), 0o644))
+		agg := NewSpillableGroupingAggregator(spillInterval, 1, spillTimeRange, newSpillAggSpecs(), file)
+		agg.Aggregate(newSpillSeries(t, "a", 1))
+		assert.Error(t, agg.Spill())
+	})
+	t.Run("create spill file failure", func(t *testing.T) {
+		createTempFn = func(_, _ string) (*os.File, error) {
+			return nil, fmt.Errorf("err")
+		}
+		defer func() {
+			createTempFn = os.CreateTemp
+		}()
+		agg := NewSpillableGroupingAggregator(spillInterval, 1, spillTimeRange, newSpillAggSpecs(), t.TempDir())
+		agg.Aggregate(newSpillSeries(t, "a", 1))
+		assert.Error(t, agg.Spill())
+		assert.Equal(t, 1, agg.NumOfGroups())
+	})
+	t.Run("open spill file failure", func(t *testing.T) {
+		openFileFn = func(_ string) (*os.File, error) {
+			return nil, fmt.Errorf("err")
+		}
+		defer func() {
+			openFileFn = os.Open
+		}()
+		agg := NewSpillableGroupingAggregator(spillInterval, 1, spillTimeRange, newSpillAggSpecs(), t.TempDir())
+		defer func() {
+			_ = agg.Close()
+		}()
+		agg.Aggregate(newSpillSeries(t, "a", 1))
+		assert.NoError(t, agg.Spill())
+		assert.Error(t, agg.ForEach(func(_ series.GroupedIterator) error { return nil }))
+		assert.Nil(t, agg.ResultSet())
+	})
+	t.Run("spill file truncated", func(t *testing.T) {
+		agg := NewSpillableGroupingAggregator(spillInterval, 1, spillTimeRange, newSpillAggSpecs(), t.TempDir())
+		defer func() {
+			_ = agg.Close()
+		}()
+		agg.Aggregate(newSpillSeries(t, "a", 1))
+		assert.NoError(t, agg.Spill())
+		name := agg.(*spillableGroupingAggregator).files[0]
+		data, err := os.ReadFile(name)
+		assert.NoError(t, err)
+		for _, size := range []int{1, 3, 4, len(data) - 1} {
+			assert.NoError(t, os.WriteFile(name, data[:size], 0o644))
+			err = agg.ForEach(func(_ series.GroupedIterator) error { return nil })
+			assert.ErrorIs(t, err, io.ErrUnexpectedEOF)
+		}
+	})
+}
+
+func TestEstimateGroupCost(t *testing.T) {
+	// 361 points of 10s interval in 1 hour
+	assert.Equal(t, int64(groupOverhead+seriesAggOverhead+361*8),
+		estimateGroupCost(spillInterval, 1, spillTimeRange, newSpillAggSpecs()))
+	assert.Equal(t, int64(groupOverhead+seriesAggOverhead+8),
+		estimateGroupCost(0, 1, spillTimeRange, newSpillAggSpecs()))
+	assert.Equal(t, int64(groupOverhead), estimateGroupCost(spillInterval, 1, spillTimeRange, nil))
+}
@@ -19,6 +19,7 @@ package aggregation
 
 import (
 	"container/heap"
+	"sort"
 
 	"github.com/lindb/lindb/aggregation/function"
 	"github.com/lindb/lindb/pkg/collections"
@@ -116,3 +117,20 @@ func (h *topNHeap) Add(row Row) {
 func (h *topNHeap) ResultSet() []Row {
 	return h.rows
 }
+
+// SortedResultSet returns result set of topN in order(first is the best row),
+// the rows with same value are ordered by tags.
+func (h *topNHeap) SortedResultSet() []Row {
+	sort.SliceStable(h.rows, func(i, j int) bool {
+		if h.Less(j, i) {
+			return true
+		}
+		if h.Less(i, j) {
+			return false
+		}
+		tagsI, _ := h.rows[i].ResultSet()
+		tagsJ, _ := h.rows[j].ResultSet()
+		return tagsI < tagsJ
+	})
+	return h.rows
+}
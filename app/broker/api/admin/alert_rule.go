@@ -0,0 +1,211 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/validate"
+	"github.com/lindb/lindb/query"
+)
+
+// for testing
+var (
+	dryRunAlertRuleFn = query.DryRunAlertRule
+)
+
+var (
+	// AlertRulePath represents the alert rules of database api path.
+	AlertRulePath = "/database/alert-rule"
+	// AlertRuleListPath represents list the alert rules of all databases api path.
+	AlertRuleListPath = "/database/alert-rule/list"
+	// AlertRuleDryRunPath represents evaluating alert rule against historical data api path.
+	AlertRuleDryRunPath = "/database/alert-rule/dry-run"
+)
+
+type alertRuleParam struct {
+	Database string `form:"db" binding:"required"`
+}
+
+// AlertRuleAPI represents the alert rule of database admin rest api.
+type AlertRuleAPI struct {
+	deps   *depspkg.HTTPDeps
+	logger *logger.Logger
+}
+
+// NewAlertRuleAPI creates the alert rule admin api instance.
+func NewAlertRuleAPI(deps *depspkg.HTTPDeps) *AlertRuleAPI {
+	return &AlertRuleAPI{
+		deps:   deps,
+		logger: logger.GetLogger("Broker", "AlertRuleAPI"),
+	}
+}
+
+// Register adds the alert rule admin url route.
+func (d *AlertRuleAPI) Register(route gin.IRoutes) {
+	route.GET(AlertRuleListPath, d.List)
+	route.GET(AlertRulePath, d.GetByDatabase)
+	route.POST(AlertRulePath, d.Save)
+	route.DELETE(AlertRulePath, d.DeleteByDatabase)
+	route.POST(AlertRuleDryRunPath, d.DryRun)
+}
+
+// List returns the alert rules of all databases.
+func (d *AlertRuleAPI) List(c *gin.Context) {
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	kvs, err := d.deps.Repo.List(ctx, constants.AlertRulePath)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	var databaseRules []*models.AlertRules
+	for _, kv := range kvs {
+		rules := &models.AlertRules{}
+		if err := encoding.JSONUnmarshal(kv.Value, rules); err != nil {
+			d.logger.Warn("unmarshal alert rules failure", logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		databaseRules = append(databaseRules, rules)
+	}
+	http.OK(c, databaseRules)
+}
+
+// GetByDatabase gets the alert rules by database name.
+func (d *AlertRuleAPI) GetByDatabase(c *gin.Context) {
+	param := alertRuleParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	data, err := d.deps.Repo.Get(ctx, constants.GetAlertRulePath(param.Database))
+	if err != nil {
+		http.NotFound(c)
+		return
+	}
+	rules := &models.AlertRules{}
+	if err := encoding.JSONUnmarshal(data, rules); err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.OK(c, rules)
+}
+
+// Save creates or updates the alert rules of database, rules are evaluated by master,
+// alerts are notified to webhook of rule.
+func (d *AlertRuleAPI) Save(c *gin.Context) {
+	rules := &models.AlertRules{}
+	if err := c.ShouldBindJSON(rules); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validate.Validator.Struct(rules); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := rules.Validate(); err != nil {
+		http.Error(c, err)
+		return
+	}
+	for _, rule := range rules.Rules {
+		if err := validateRuleSQL(rule.Name, rule.SQL); err != nil {
+			http.Error(c, err)
+			return
+		}
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Put(ctx, constants.GetAlertRulePath(rules.Database), encoding.JSONMarshal(rules))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpSaveAlert,
+		map[string]string{"database": rules.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// DeleteByDatabase deletes the alert rules of database.
+func (d *AlertRuleAPI) DeleteByDatabase(c *gin.Context) {
+	param := alertRuleParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Delete(ctx, constants.GetAlertRulePath(param.Database))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpDropAlert,
+		map[string]string{"database": param.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// DryRun evaluates alert rule against historical data, returns the state transitions of alert series.
+func (d *AlertRuleAPI) DryRun(c *gin.Context) {
+	param := &models.AlertRuleDryRun{}
+	if err := c.ShouldBindJSON(param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validate.Validator.Struct(param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := param.Rule.Validate(); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validateRuleSQL(param.Rule.Name, param.Rule.SQL); err != nil {
+		http.Error(c, err)
+		return
+	}
+	transitions, err := dryRunAlertRuleFn(c.Request.Context(), &query.SearchMgr{
+		Timeout:           d.deps.BrokerCfg.Query.Timeout.Duration(),
+		CurNode:           *d.deps.Node,
+		Choose:            d.deps.StateMgr,
+		TaskMgr:           d.deps.TaskMgr,
+		TransportMgr:      d.deps.TransportMgr,
+		MaxBufferedSeries: d.deps.BrokerCfg.BrokerBase.Query.MaxBufferedSeries,
+		Memory:            d.deps.BrokerCfg.BrokerBase.Query.Memory,
+		ReplicaLag:        d.deps.ReplicaLag,
+		Hedger:            d.deps.Hedger,
+		Authorizer:        d.deps.Authorizer,
+	}, param.Database, param.Rule, param.Start, param.End)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.OK(c, transitions)
+}
@@ -0,0 +1,338 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/query"
+)
+
+func TestAlertRuleAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		dryRunAlertRuleFn = query.DryRunAlertRule
+		ctrl.Finish()
+	}()
+
+	mockRepo := state.NewMockRepository(ctrl)
+	api := NewAlertRuleAPI(&deps.HTTPDeps{
+		Ctx:  context.Background(),
+		Node: &models.StatelessNode{},
+		Repo: mockRepo,
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				HTTP: config.HTTP{
+					ReadTimeout: ltoml.Duration(time.Second)}},
+			Coordinator: config.RepoState{
+				Timeout: ltoml.Duration(time.Second * 5)},
+		},
+	})
+	r := gin.New()
+	api.Register(r)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		reqBody string
+		prepare func()
+		assert  func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			"list rules failure",
+			http.MethodGet,
+			AlertRuleListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.AlertRulePath).Return(nil, fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"list rules successfully",
+			http.MethodGet,
+			AlertRuleListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.AlertRulePath).Return([]state.KeyValue{
+					{Key: "a", Value: []byte(`{"database":"db","rules":[{"name":"r","sql":"select f from cpu group by host","condition":">","threshold":10,"interval":"1m","webhook":"http://127.0.0.1:9093/api/v2/alerts"}]}`)},
+					{Key: "b", Value: []byte(`abc`)},
+				}, nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			"get rules param invalid",
+			http.MethodGet,
+			AlertRulePath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get rules not found",
+			http.MethodGet,
+			AlertRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), constants.GetAlertRulePath("db")).Return(nil, state.ErrNotExist)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			"get rules, unmarshal failure",
+			http.MethodGet,
+			AlertRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte("abc"), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get rules successfully",
+			http.MethodGet,
+			AlertRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte(`{"database":"db"}`), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			"save rules, bad body",
+			http.MethodPost,
+			AlertRulePath,
+			`abc`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, database required",
+			http.MethodPost,
+			AlertRulePath,
+			`{"rules":[{"name":"r","sql":"select f from cpu group by host","condition":">","threshold":10,"interval":"1m","webhook":"http://127.0.0.1:9093/api/v2/alerts"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, invalid interval",
+			http.MethodPost,
+			AlertRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select f from cpu","condition":">","interval":"1ms","webhook":"http://127.0.0.1"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, bad sql",
+			http.MethodPost,
+			AlertRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select","condition":">","interval":"1m","webhook":"http://127.0.0.1"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, not data query",
+			http.MethodPost,
+			AlertRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"show databases","condition":">","interval":"1m","webhook":"http://127.0.0.1"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules failure",
+			http.MethodPost,
+			AlertRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select f from cpu group by host","condition":">","threshold":10,"interval":"1m","webhook":"http://127.0.0.1:9093/api/v2/alerts"}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetAlertRulePath("db"), gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules successfully",
+			http.MethodPost,
+			AlertRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select f from cpu group by host","condition":">","threshold":10,"interval":"1m","webhook":"http://127.0.0.1:9093/api/v2/alerts"}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetAlertRulePath("db"), gomock.Any()).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			"delete rules param invalid",
+			http.MethodDelete,
+			AlertRulePath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete rules failure",
+			http.MethodDelete,
+			AlertRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetAlertRulePath("db")).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete rules successfully",
+			http.MethodDelete,
+			AlertRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetAlertRulePath("db")).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			"dry-run, bad body",
+			http.MethodPost,
+			AlertRuleDryRunPath,
+			`abc`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"dry-run, rule required",
+			http.MethodPost,
+			AlertRuleDryRunPath,
+			`{"database":"db","start":1,"end":60000}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"dry-run, invalid rule",
+			http.MethodPost,
+			AlertRuleDryRunPath,
+			`{"database":"db","start":1,"end":60000,"rule":{"name":"r","sql":"select f from cpu","condition":"x","interval":"1m","webhook":"http://127.0.0.1"}}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"dry-run, bad sql",
+			http.MethodPost,
+			AlertRuleDryRunPath,
+			`{"database":"db","start":1,"end":60000,"rule":{"name":"r","sql":"select","condition":">","interval":"1m","webhook":"http://127.0.0.1"}}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"dry-run failure",
+			http.MethodPost,
+			AlertRuleDryRunPath,
+			`{"database":"db","start":1,"end":60000,"rule":{"name":"r","sql":"select f from cpu group by host","condition":">","threshold":10,"interval":"1m","webhook":"http://127.0.0.1:9093/api/v2/alerts"}}`,
+			func() {
+				dryRunAlertRuleFn = func(_ context.Context, _ *query.SearchMgr, _ string, _ *models.AlertRule,
+					_, _ int64) ([]*models.AlertTransition, error) {
+					return nil, fmt.Errorf("err")
+				}
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"dry-run successfully",
+			http.MethodPost,
+			AlertRuleDryRunPath,
+			`{"database":"db","start":1,"end":60000,"rule":{"name":"r","sql":"select f from cpu group by host","condition":">","threshold":10,"interval":"1m","webhook":"http://127.0.0.1:9093/api/v2/alerts"}}`,
+			func() {
+				dryRunAlertRuleFn = func(_ context.Context, _ *query.SearchMgr, _ string, _ *models.AlertRule,
+					_, _ int64) ([]*models.AlertTransition, error) {
+					return []*models.AlertTransition{{To: models.AlertFiring}}, nil
+				}
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, tt.method, tt.url, tt.reqBody)
+			if tt.assert != nil {
+				tt.assert(resp)
+			}
+		})
+	}
+}
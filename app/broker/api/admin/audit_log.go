@@ -0,0 +1,67 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	httppkg "github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	// AuditLogPath represents audit log of admin operations api path.
+	AuditLogPath = "/audit/log"
+)
+
+// AuditLogAPI represents audit log of admin operations rest api.
+type AuditLogAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewAuditLogAPI creates audit log api instance.
+func NewAuditLogAPI(deps *depspkg.HTTPDeps) *AuditLogAPI {
+	return &AuditLogAPI{
+		deps: deps,
+	}
+}
+
+// Register adds audit log url route.
+func (a *AuditLogAPI) Register(route gin.IRoutes) {
+	route.GET(AuditLogPath, a.List)
+}
+
+// List returns the recent audit entries(newest first) since given time(unix milliseconds).
+func (a *AuditLogAPI) List(c *gin.Context) {
+	var param struct {
+		Since int64 `form:"since"`
+		Limit int   `form:"limit"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	entries, err := a.deps.Master.AuditLog(time.UnixMilli(param.Since), param.Limit)
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, entries)
+}
@@ -0,0 +1,57 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/coordinator"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+)
+
+func TestAuditLogAPI_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	master := coordinator.NewMockMasterController(ctrl)
+	api := NewAuditLogAPI(&deps.HTTPDeps{
+		Master: master,
+	})
+	r := gin.New()
+	api.Register(r)
+
+	// bad param
+	resp := mock.DoRequest(t, r, http.MethodGet, AuditLogPath+"?limit=a", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// list failure
+	master.EXPECT().AuditLog(gomock.Any(), 10).Return(nil, fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodGet, AuditLogPath+"?limit=10", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// list ok
+	master.EXPECT().AuditLog(gomock.Any(), 0).Return([]*models.AuditEntry{{Operation: "flush_database"}}, nil)
+	resp = mock.DoRequest(t, r, http.MethodGet, AuditLogPath+"?since=1", "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
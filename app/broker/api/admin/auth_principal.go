@@ -0,0 +1,157 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/validate"
+)
+
+var (
+	// AuthPrincipalPath represents the per-database permissions of principal api path.
+	AuthPrincipalPath = "/auth/principal"
+	// AuthPrincipalListPath represents list all principals api path.
+	AuthPrincipalListPath = "/auth/principal/list"
+)
+
+type principalParam struct {
+	Name string `form:"name" binding:"required"`
+}
+
+// AuthPrincipalAPI represents the per-database permissions of principal admin rest api.
+type AuthPrincipalAPI struct {
+	deps   *depspkg.HTTPDeps
+	logger *logger.Logger
+}
+
+// NewAuthPrincipalAPI creates principal admin api instance.
+func NewAuthPrincipalAPI(deps *depspkg.HTTPDeps) *AuthPrincipalAPI {
+	return &AuthPrincipalAPI{
+		deps:   deps,
+		logger: logger.GetLogger("Broker", "AuthPrincipalAPI"),
+	}
+}
+
+// Register adds principal admin url route.
+func (a *AuthPrincipalAPI) Register(route gin.IRoutes) {
+	route.GET(AuthPrincipalListPath, a.List)
+	route.GET(AuthPrincipalPath, a.GetByName)
+	route.POST(AuthPrincipalPath, a.Save)
+	route.DELETE(AuthPrincipalPath, a.DeleteByName)
+}
+
+// List returns all principals with per-database permissions.
+func (a *AuthPrincipalAPI) List(c *gin.Context) {
+	ctx, cancel := a.deps.WithTimeout()
+	defer cancel()
+	kvs, err := a.deps.Repo.List(ctx, constants.AuthPrincipalPath)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	var principals []*models.Principal
+	for _, kv := range kvs {
+		principal := &models.Principal{}
+		if err := encoding.JSONUnmarshal(kv.Value, principal); err != nil {
+			a.logger.Warn("unmarshal principal failure", logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		principals = append(principals, principal)
+	}
+	http.OK(c, principals)
+}
+
+// GetByName gets the per-database permissions of principal by name.
+func (a *AuthPrincipalAPI) GetByName(c *gin.Context) {
+	param := principalParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := a.deps.WithTimeout()
+	defer cancel()
+	data, err := a.deps.Repo.Get(ctx, constants.GetAuthPrincipalPath(param.Name))
+	if err != nil {
+		http.NotFound(c)
+		return
+	}
+	principal := &models.Principal{}
+	if err := encoding.JSONUnmarshal(data, principal); err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.OK(c, principal)
+}
+
+// Save creates or updates the per-database permissions of principal.
+func (a *AuthPrincipalAPI) Save(c *gin.Context) {
+	principal := &models.Principal{}
+	if err := c.ShouldBindJSON(principal); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validate.Validator.Struct(principal); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := principal.Validate(); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := a.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := a.deps.Repo.Put(ctx, constants.GetAuthPrincipalPath(principal.Name), encoding.JSONMarshal(principal))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpSavePrincipal,
+		map[string]string{"principal": principal.Name}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// DeleteByName deletes the per-database permissions of principal by name.
+func (a *AuthPrincipalAPI) DeleteByName(c *gin.Context) {
+	param := principalParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := a.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := a.deps.Repo.Delete(ctx, constants.GetAuthPrincipalPath(param.Name))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpDropPrincipal,
+		map[string]string{"principal": param.Name}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
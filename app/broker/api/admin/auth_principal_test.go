@@ -0,0 +1,243 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+func TestAuthPrincipalAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := state.NewMockRepository(ctrl)
+	api := NewAuthPrincipalAPI(&deps.HTTPDeps{
+		Ctx:  context.Background(),
+		Repo: mockRepo,
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				HTTP: config.HTTP{
+					ReadTimeout: ltoml.Duration(time.Second)}},
+			Coordinator: config.RepoState{
+				Timeout: ltoml.Duration(time.Second * 5)},
+		},
+	})
+	r := gin.New()
+	api.Register(r)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		reqBody string
+		prepare func()
+		assert  func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			"list principal failure",
+			http.MethodGet,
+			AuthPrincipalListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.AuthPrincipalPath).Return(nil, fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"list principal successfully",
+			http.MethodGet,
+			AuthPrincipalListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.AuthPrincipalPath).Return([]state.KeyValue{
+					{Key: "a", Value: []byte(`{"name":"alice","permissions":{"db":["read"]}}`)},
+					{Key: "b", Value: []byte(`abc`)},
+				}, nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Contains(t, resp.Body.String(), "alice")
+			},
+		},
+		{
+			"get principal param invalid",
+			http.MethodGet,
+			AuthPrincipalPath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get principal not found",
+			http.MethodGet,
+			AuthPrincipalPath + "?name=alice",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), constants.GetAuthPrincipalPath("alice")).Return(nil, state.ErrNotExist)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			"get principal, unmarshal failure",
+			http.MethodGet,
+			AuthPrincipalPath + "?name=alice",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte("abc"), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get principal successfully",
+			http.MethodGet,
+			AuthPrincipalPath + "?name=alice",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte(`{"name":"alice"}`), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			"save principal, bad body",
+			http.MethodPost,
+			AuthPrincipalPath,
+			`abc`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save principal, name required",
+			http.MethodPost,
+			AuthPrincipalPath,
+			`{"permissions":{"db":["read"]}}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save principal, unknown permission",
+			http.MethodPost,
+			AuthPrincipalPath,
+			`{"name":"alice","permissions":{"db":["unknown"]}}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save principal failure",
+			http.MethodPost,
+			AuthPrincipalPath,
+			`{"name":"alice","permissions":{"db":["read"]}}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetAuthPrincipalPath("alice"), gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save principal successfully",
+			http.MethodPost,
+			AuthPrincipalPath,
+			`{"name":"alice","permissions":{"db":["read","write"]}}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetAuthPrincipalPath("alice"), gomock.Any()).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			"delete principal param invalid",
+			http.MethodDelete,
+			AuthPrincipalPath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete principal failure",
+			http.MethodDelete,
+			AuthPrincipalPath + "?name=alice",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetAuthPrincipalPath("alice")).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete principal successfully",
+			http.MethodDelete,
+			AuthPrincipalPath + "?name=alice",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetAuthPrincipalPath("alice")).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, tt.method, tt.url, tt.reqBody)
+			if tt.assert != nil {
+				tt.assert(resp)
+			}
+		})
+	}
+}
@@ -18,6 +18,9 @@
 package admin
 
 import (
+	"net/http/httputil"
+	"net/url"
+
 	"github.com/gin-gonic/gin"
 
 	depspkg "github.com/lindb/lindb/app/broker/deps"
@@ -26,10 +29,13 @@ import (
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 var (
 	DatabasePath = "/database"
+	// DatabaseRestorePath represents the path of restoring dropped database.
+	DatabaseRestorePath = "/database/restore"
 )
 
 // DatabaseAPI represents database admin rest api
@@ -46,11 +52,16 @@ func NewDatabaseAPI(deps *depspkg.HTTPDeps) *DatabaseAPI {
 	}
 }
 
-// Register adds database admin url route.
+// Register adds database url route.
 func (d *DatabaseAPI) Register(route gin.IRoutes) {
 	route.GET(DatabasePath, d.GetByName)
 }
 
+// RegisterAdmin adds database admin url route.
+func (d *DatabaseAPI) RegisterAdmin(route gin.IRoutes) {
+	route.PUT(DatabaseRestorePath, d.Restore)
+}
+
 // GetByName gets a database config by the name.
 func (d *DatabaseAPI) GetByName(c *gin.Context) {
 	var param struct {
@@ -66,9 +77,42 @@ func (d *DatabaseAPI) GetByName(c *gin.Context) {
 		http.NotFound(c)
 		return
 	}
+	database.SetGraceRemaining(timeutil.Now())
+	http.OK(c, database)
+}
+
+// Restore restores the dropped database within the grace period.
+func (d *DatabaseAPI) Restore(c *gin.Context) {
+	var param struct {
+		DatabaseName string `form:"name" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if !d.deps.Master.IsMaster() {
+		// if current node is not master, forward to master which manages the lifecycle of database
+		d.forwardToMaster(c)
+		return
+	}
+	database, err := d.deps.Master.RestoreDatabase(c.Request.Context(), param.DatabaseName)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
 	http.OK(c, database)
 }
 
+// forwardToMaster forwards the request to master, keeps the headers(e.g. token) of request.
+func (d *DatabaseAPI) forwardToMaster(c *gin.Context) {
+	target, err := url.Parse(d.deps.Master.GetMaster().Node.HTTPAddress())
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	httputil.NewSingleHostReverseProxy(target).ServeHTTP(c.Writer, c.Request)
+}
+
 func (d *DatabaseAPI) getByName(name string) (*models.Database, error) {
 	ctx, cancel := d.deps.WithTimeout()
 	defer cancel()
@@ -0,0 +1,158 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/validate"
+)
+
+var (
+	// DatabaseFieldAliasPath represents the field aliases of database api path.
+	DatabaseFieldAliasPath = "/database/field-alias"
+	// DatabaseFieldAliasListPath represents list the field aliases of all databases api path.
+	DatabaseFieldAliasListPath = "/database/alias/list"
+)
+
+type databaseFieldAliasParam struct {
+	Database string `form:"db" binding:"required"`
+}
+
+// DatabaseFieldAliasAPI represents the field aliases of database admin rest api.
+type DatabaseFieldAliasAPI struct {
+	deps   *depspkg.HTTPDeps
+	logger *logger.Logger
+}
+
+// NewDatabaseFieldAliasAPI creates the field aliases admin api instance.
+func NewDatabaseFieldAliasAPI(deps *depspkg.HTTPDeps) *DatabaseFieldAliasAPI {
+	return &DatabaseFieldAliasAPI{
+		deps:   deps,
+		logger: logger.GetLogger("Broker", "FieldAliasAPI"),
+	}
+}
+
+// Register adds the field aliases admin url route.
+func (d *DatabaseFieldAliasAPI) Register(route gin.IRoutes) {
+	route.GET(DatabaseFieldAliasListPath, d.List)
+	route.GET(DatabaseFieldAliasPath, d.GetByDatabase)
+	route.POST(DatabaseFieldAliasPath, d.Save)
+	route.DELETE(DatabaseFieldAliasPath, d.DeleteByDatabase)
+}
+
+// List returns the field aliases of all databases.
+func (d *DatabaseFieldAliasAPI) List(c *gin.Context) {
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	kvs, err := d.deps.Repo.List(ctx, constants.FieldAliasPath)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	var aliases []*models.DatabaseFieldAlias
+	for _, kv := range kvs {
+		alias := &models.DatabaseFieldAlias{}
+		if err := encoding.JSONUnmarshal(kv.Value, alias); err != nil {
+			d.logger.Warn("unmarshal field alias failure", logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		aliases = append(aliases, alias)
+	}
+	http.OK(c, aliases)
+}
+
+// GetByDatabase gets the field aliases by database name.
+func (d *DatabaseFieldAliasAPI) GetByDatabase(c *gin.Context) {
+	param := databaseFieldAliasParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	data, err := d.deps.Repo.Get(ctx, constants.GetFieldAliasPath(param.Database))
+	if err != nil {
+		http.NotFound(c)
+		return
+	}
+	alias := &models.DatabaseFieldAlias{}
+	if err := encoding.JSONUnmarshal(data, alias); err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.OK(c, alias)
+}
+
+// Save creates or updates the field aliases of database, circular aliases are rejected,
+// brokers reload the aliases via state machine.
+func (d *DatabaseFieldAliasAPI) Save(c *gin.Context) {
+	alias := &models.DatabaseFieldAlias{}
+	if err := c.ShouldBindJSON(alias); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validate.Validator.Struct(alias); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := alias.Validate(); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Put(ctx, constants.GetFieldAliasPath(alias.Database), encoding.JSONMarshal(alias))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpSaveFieldAlias,
+		map[string]string{"database": alias.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// DeleteByDatabase deletes the field aliases of database, fields are queried by name without aliasing.
+func (d *DatabaseFieldAliasAPI) DeleteByDatabase(c *gin.Context) {
+	param := databaseFieldAliasParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Delete(ctx, constants.GetFieldAliasPath(param.Database))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpDropFieldAlias,
+		map[string]string{"database": param.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
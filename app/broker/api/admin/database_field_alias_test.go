@@ -0,0 +1,243 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+func TestDatabaseFieldAliasAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := state.NewMockRepository(ctrl)
+	api := NewDatabaseFieldAliasAPI(&deps.HTTPDeps{
+		Ctx:  context.Background(),
+		Repo: mockRepo,
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				HTTP: config.HTTP{
+					ReadTimeout: ltoml.Duration(time.Second)}},
+			Coordinator: config.RepoState{
+				Timeout: ltoml.Duration(time.Second * 5)},
+		},
+	})
+	r := gin.New()
+	api.Register(r)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		reqBody string
+		prepare func()
+		assert  func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			"list alias failure",
+			http.MethodGet,
+			DatabaseFieldAliasListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.FieldAliasPath).Return(nil, fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"list alias successfully",
+			http.MethodGet,
+			DatabaseFieldAliasListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.FieldAliasPath).Return([]state.KeyValue{
+					{Key: "a", Value: []byte(`{"database":"db","aliases":[{"metric":"cpu","from":"usage","to":"used"}]}`)},
+					{Key: "b", Value: []byte(`abc`)},
+				}, nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Contains(t, resp.Body.String(), "cpu")
+			},
+		},
+		{
+			"get alias param invalid",
+			http.MethodGet,
+			DatabaseFieldAliasPath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get alias not found",
+			http.MethodGet,
+			DatabaseFieldAliasPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), constants.GetFieldAliasPath("db")).Return(nil, state.ErrNotExist)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			"get alias, unmarshal failure",
+			http.MethodGet,
+			DatabaseFieldAliasPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte("abc"), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get alias successfully",
+			http.MethodGet,
+			DatabaseFieldAliasPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte(`{"database":"db"}`), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			"save alias, bad body",
+			http.MethodPost,
+			DatabaseFieldAliasPath,
+			`abc`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save alias, database required",
+			http.MethodPost,
+			DatabaseFieldAliasPath,
+			`{"aliases":[{"metric":"cpu","from":"usage","to":"used"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save alias, circular alias",
+			http.MethodPost,
+			DatabaseFieldAliasPath,
+			`{"database":"db","aliases":[{"metric":"cpu","from":"usage","to":"used"},{"metric":"cpu","from":"used","to":"usage"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save alias failure",
+			http.MethodPost,
+			DatabaseFieldAliasPath,
+			`{"database":"db","aliases":[{"metric":"cpu","from":"usage","to":"used","since":1000}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetFieldAliasPath("db"), gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save alias successfully",
+			http.MethodPost,
+			DatabaseFieldAliasPath,
+			`{"database":"db","aliases":[{"metric":"cpu","from":"usage","to":"used","since":1000}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetFieldAliasPath("db"), gomock.Any()).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			"delete alias param invalid",
+			http.MethodDelete,
+			DatabaseFieldAliasPath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete alias failure",
+			http.MethodDelete,
+			DatabaseFieldAliasPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetFieldAliasPath("db")).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete alias successfully",
+			http.MethodDelete,
+			DatabaseFieldAliasPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetFieldAliasPath("db")).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, tt.method, tt.url, tt.reqBody)
+			if tt.assert != nil {
+				tt.assert(resp)
+			}
+		})
+	}
+}
@@ -33,6 +33,10 @@ var (
 	httpGet = http.Get
 	// FlushDatabasePath represents database flush api path.
 	FlushDatabasePath = "/database/flush"
+	// PauseFlushPath represents database flush pause api path.
+	PauseFlushPath = "/database/flush/pause"
+	// ResumeFlushPath represents database flush resume api path.
+	ResumeFlushPath = "/database/flush/resume"
 )
 
 // DatabaseFlusherAPI represents the memory database flush by manual.
@@ -53,6 +57,8 @@ func NewDatabaseFlusherAPI(deps *depspkg.HTTPDeps) *DatabaseFlusherAPI {
 // Register adds database flush admin url route.
 func (df *DatabaseFlusherAPI) Register(route gin.IRoutes) {
 	route.PUT(FlushDatabasePath, df.SubmitFlushTask)
+	route.PUT(PauseFlushPath, df.PauseFlush)
+	route.PUT(ResumeFlushPath, df.ResumeFlush)
 }
 
 // SubmitFlushTask submits the task which does flush job over memory database
@@ -68,7 +74,7 @@ func (df *DatabaseFlusherAPI) SubmitFlushTask(c *gin.Context) {
 	}
 	if df.deps.Master.IsMaster() {
 		// if current node is master, submits the flush task
-		if err := df.deps.Master.FlushDatabase(param.Cluster, param.Database); err != nil {
+		if err := df.deps.Master.FlushDatabase(c.Request.Context(), param.Cluster, param.Database); err != nil {
 			httppkg.Error(c, err)
 			return
 		}
@@ -95,3 +101,38 @@ func (df *DatabaseFlusherAPI) SubmitFlushTask(c *gin.Context) {
 	}
 	httppkg.OK(c, "success")
 }
+
+// PauseFlush pauses flushing of database on all storage nodes of cluster during maintenance(e.g. backup),
+// writes are still accepted.
+func (df *DatabaseFlusherAPI) PauseFlush(c *gin.Context) {
+	var param struct {
+		Cluster  string `json:"cluster" binding:"required"`
+		Database string `json:"database" binding:"required"`
+	}
+	if err := c.ShouldBind(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	if err := df.deps.Master.PauseFlush(c.Request.Context(), param.Cluster, param.Database); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, "success")
+}
+
+// ResumeFlush resumes flushing of database on all storage nodes of cluster.
+func (df *DatabaseFlusherAPI) ResumeFlush(c *gin.Context) {
+	var param struct {
+		Cluster  string `json:"cluster" binding:"required"`
+		Database string `json:"database" binding:"required"`
+	}
+	if err := c.ShouldBind(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	if err := df.deps.Master.ResumeFlush(c.Request.Context(), param.Cluster, param.Database); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, "success")
+}
@@ -64,13 +64,13 @@ func TestNewDatabaseFlusherAPI(t *testing.T) {
 
 	// submit err
 	master.EXPECT().IsMaster().Return(true)
-	master.EXPECT().FlushDatabase(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	master.EXPECT().FlushDatabase(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
 	resp = mock.DoRequest(t, r, http.MethodPut, FlushDatabasePath, `{"cluster":"test","database":"db"}`)
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 
 	// submit ok
 	master.EXPECT().IsMaster().Return(true)
-	master.EXPECT().FlushDatabase(gomock.Any(), gomock.Any()).Return(nil)
+	master.EXPECT().FlushDatabase(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 	resp = mock.DoRequest(t, r, http.MethodPut, FlushDatabasePath, `{"cluster":"test","database":"db"}`)
 	assert.Equal(t, http.StatusOK, resp.Code)
 
@@ -135,3 +135,35 @@ func TestNewDatabaseFlusherAPI(t *testing.T) {
 	resp = mock.DoRequest(t, r, http.MethodPut, FlushDatabasePath, `{"cluster":"test","database":"db"}`)
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
+
+func TestDatabaseFlusherAPI_PauseFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	master := coordinator.NewMockMasterController(ctrl)
+	flushAPI := NewDatabaseFlusherAPI(&deps.HTTPDeps{
+		Master: master,
+	})
+	r := gin.New()
+	flushAPI.Register(r)
+
+	// params invalid
+	resp := mock.DoRequest(t, r, http.MethodPut, PauseFlushPath, "{}")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	resp = mock.DoRequest(t, r, http.MethodPut, ResumeFlushPath, "{}")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	master.EXPECT().PauseFlush(gomock.Any(), "test", "db").Return(fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodPut, PauseFlushPath, `{"cluster":"test","database":"db"}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	master.EXPECT().PauseFlush(gomock.Any(), "test", "db").Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, PauseFlushPath, `{"cluster":"test","database":"db"}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	master.EXPECT().ResumeFlush(gomock.Any(), "test", "db").Return(fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodPut, ResumeFlushPath, `{"cluster":"test","database":"db"}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	master.EXPECT().ResumeFlush(gomock.Any(), "test", "db").Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, ResumeFlushPath, `{"cluster":"test","database":"db"}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
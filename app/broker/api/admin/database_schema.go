@@ -0,0 +1,158 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/validate"
+)
+
+var (
+	// DatabaseSchemaPath represents the metric schema registry of database api path.
+	DatabaseSchemaPath = "/database/schema"
+	// DatabaseSchemaListPath represents list the metric schema registry of all databases api path.
+	DatabaseSchemaListPath = "/database/schema/list"
+)
+
+type databaseSchemaParam struct {
+	Database string `form:"db" binding:"required"`
+}
+
+// DatabaseSchemaAPI represents the metric schema registry of database admin rest api.
+type DatabaseSchemaAPI struct {
+	deps   *depspkg.HTTPDeps
+	logger *logger.Logger
+}
+
+// NewDatabaseSchemaAPI creates the metric schema registry admin api instance.
+func NewDatabaseSchemaAPI(deps *depspkg.HTTPDeps) *DatabaseSchemaAPI {
+	return &DatabaseSchemaAPI{
+		deps:   deps,
+		logger: logger.GetLogger("Broker", "DatabaseSchemaAPI"),
+	}
+}
+
+// Register adds the metric schema registry admin url route.
+func (d *DatabaseSchemaAPI) Register(route gin.IRoutes) {
+	route.GET(DatabaseSchemaListPath, d.List)
+	route.GET(DatabaseSchemaPath, d.GetByDatabase)
+	route.POST(DatabaseSchemaPath, d.Save)
+	route.DELETE(DatabaseSchemaPath, d.DeleteByDatabase)
+}
+
+// List returns the metric schema registry of all databases.
+func (d *DatabaseSchemaAPI) List(c *gin.Context) {
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	kvs, err := d.deps.Repo.List(ctx, constants.DatabaseSchemaPath)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	var schemas []*models.DatabaseSchema
+	for _, kv := range kvs {
+		schema := &models.DatabaseSchema{}
+		if err := encoding.JSONUnmarshal(kv.Value, schema); err != nil {
+			d.logger.Warn("unmarshal metric schema failure", logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		schemas = append(schemas, schema)
+	}
+	http.OK(c, schemas)
+}
+
+// GetByDatabase gets the metric schema registry by database name.
+func (d *DatabaseSchemaAPI) GetByDatabase(c *gin.Context) {
+	param := databaseSchemaParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	data, err := d.deps.Repo.Get(ctx, constants.GetDatabaseSchemaPath(param.Database))
+	if err != nil {
+		http.NotFound(c)
+		return
+	}
+	schema := &models.DatabaseSchema{}
+	if err := encoding.JSONUnmarshal(data, schema); err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.OK(c, schema)
+}
+
+// Save creates or updates the metric schema registry of database,
+// brokers reload the schema via state machine.
+func (d *DatabaseSchemaAPI) Save(c *gin.Context) {
+	schema := &models.DatabaseSchema{}
+	if err := c.ShouldBindJSON(schema); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validate.Validator.Struct(schema); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := schema.Validate(); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Put(ctx, constants.GetDatabaseSchemaPath(schema.Database), encoding.JSONMarshal(schema))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpSaveSchema,
+		map[string]string{"database": schema.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// DeleteByDatabase deletes the metric schema registry of database, metrics are written without checking.
+func (d *DatabaseSchemaAPI) DeleteByDatabase(c *gin.Context) {
+	param := databaseSchemaParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Delete(ctx, constants.GetDatabaseSchemaPath(param.Database))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpDropSchema,
+		map[string]string{"database": param.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
@@ -0,0 +1,243 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+func TestDatabaseSchemaAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := state.NewMockRepository(ctrl)
+	api := NewDatabaseSchemaAPI(&deps.HTTPDeps{
+		Ctx:  context.Background(),
+		Repo: mockRepo,
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				HTTP: config.HTTP{
+					ReadTimeout: ltoml.Duration(time.Second)}},
+			Coordinator: config.RepoState{
+				Timeout: ltoml.Duration(time.Second * 5)},
+		},
+	})
+	r := gin.New()
+	api.Register(r)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		reqBody string
+		prepare func()
+		assert  func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			"list schema failure",
+			http.MethodGet,
+			DatabaseSchemaListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.DatabaseSchemaPath).Return(nil, fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"list schema successfully",
+			http.MethodGet,
+			DatabaseSchemaListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.DatabaseSchemaPath).Return([]state.KeyValue{
+					{Key: "a", Value: []byte(`{"database":"db","metrics":[{"name":"cpu"}]}`)},
+					{Key: "b", Value: []byte(`abc`)},
+				}, nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Contains(t, resp.Body.String(), "cpu")
+			},
+		},
+		{
+			"get schema param invalid",
+			http.MethodGet,
+			DatabaseSchemaPath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get schema not found",
+			http.MethodGet,
+			DatabaseSchemaPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), constants.GetDatabaseSchemaPath("db")).Return(nil, state.ErrNotExist)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			"get schema, unmarshal failure",
+			http.MethodGet,
+			DatabaseSchemaPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte("abc"), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get schema successfully",
+			http.MethodGet,
+			DatabaseSchemaPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte(`{"database":"db"}`), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			"save schema, bad body",
+			http.MethodPost,
+			DatabaseSchemaPath,
+			`abc`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save schema, database required",
+			http.MethodPost,
+			DatabaseSchemaPath,
+			`{"metrics":[{"name":"cpu"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save schema, unknown field type",
+			http.MethodPost,
+			DatabaseSchemaPath,
+			`{"database":"db","metrics":[{"name":"cpu","fields":[{"name":"f","type":"gauge"}]}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save schema failure",
+			http.MethodPost,
+			DatabaseSchemaPath,
+			`{"database":"db","metrics":[{"name":"cpu"}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetDatabaseSchemaPath("db"), gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save schema successfully",
+			http.MethodPost,
+			DatabaseSchemaPath,
+			`{"database":"db","mode":"strict","metrics":[{"name":"cpu","tagKeys":["host"]}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetDatabaseSchemaPath("db"), gomock.Any()).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			"delete schema param invalid",
+			http.MethodDelete,
+			DatabaseSchemaPath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete schema failure",
+			http.MethodDelete,
+			DatabaseSchemaPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseSchemaPath("db")).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete schema successfully",
+			http.MethodDelete,
+			DatabaseSchemaPath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseSchemaPath("db")).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, tt.method, tt.url, tt.reqBody)
+			if tt.assert != nil {
+				tt.assert(resp)
+			}
+		})
+	}
+}
@@ -19,8 +19,12 @@ package admin
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
 	"time"
 
@@ -30,9 +34,14 @@ import (
 
 	"github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator"
 	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 func TestDatabaseAPI_GetByName(t *testing.T) {
@@ -70,4 +79,58 @@ func TestDatabaseAPI_GetByName(t *testing.T) {
 	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte(`{"name":"xxx"}`), nil)
 	reps = mock.DoRequest(t, r, http.MethodGet, DatabasePath+"?name=xxx", "")
 	assert.Equal(t, http.StatusOK, reps.Code)
+
+	// get dropped database with remaining grace time
+	purgeAt := timeutil.Now() + timeutil.OneHour
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).
+		Return([]byte(fmt.Sprintf(`{"name":"xxx","deletion":{"deletedAt":1,"purgeAt":%d}}`, purgeAt)), nil)
+	reps = mock.DoRequest(t, r, http.MethodGet, DatabasePath+"?name=xxx", "")
+	assert.Equal(t, http.StatusOK, reps.Code)
+	database := &models.Database{}
+	assert.NoError(t, encoding.JSONUnmarshal(reps.Body.Bytes(), database))
+	assert.True(t, database.IsDeleted())
+	assert.NotEmpty(t, database.Deletion.GraceRemaining)
+}
+
+func TestDatabaseAPI_Restore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r := gin.New()
+	master := coordinator.NewMockMasterController(ctrl)
+	api := NewDatabaseAPI(&deps.HTTPDeps{
+		Ctx:    context.Background(),
+		Master: master,
+	})
+	api.RegisterAdmin(r)
+
+	// name empty
+	reps := mock.DoRequest(t, r, http.MethodPut, DatabaseRestorePath, "")
+	assert.Equal(t, http.StatusInternalServerError, reps.Code)
+	// restore failure
+	master.EXPECT().IsMaster().Return(true)
+	master.EXPECT().RestoreDatabase(gomock.Any(), "xxx").Return(nil, constants.ErrDatabasePurged)
+	reps = mock.DoRequest(t, r, http.MethodPut, DatabaseRestorePath+"?name=xxx", "")
+	assert.Equal(t, http.StatusInternalServerError, reps.Code)
+	// restore ok
+	master.EXPECT().IsMaster().Return(true)
+	master.EXPECT().RestoreDatabase(gomock.Any(), "xxx").Return(&models.Database{Name: "xxx"}, nil)
+	reps = mock.DoRequest(t, r, http.MethodPut, DatabaseRestorePath+"?name=xxx", "")
+	assert.Equal(t, http.StatusOK, reps.Code)
+
+	// forward to master if current node is not master
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, DatabaseRestorePath, r.URL.Path)
+		assert.Equal(t, "xxx", r.URL.Query().Get("name"))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer svr.Close()
+	u, err := url.Parse(svr.URL)
+	assert.NoError(t, err)
+	port, err := strconv.Atoi(u.Port())
+	assert.NoError(t, err)
+	master.EXPECT().IsMaster().Return(false)
+	master.EXPECT().GetMaster().Return(&models.Master{Node: &models.StatelessNode{HostIP: u.Hostname(), HTTPPort: uint16(port)}})
+	reps = mock.DoRequest(t, r, http.MethodPut, DatabaseRestorePath+"?name=xxx", "")
+	assert.Equal(t, http.StatusAccepted, reps.Code)
 }
@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	httppkg "github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	// MetadataExportPath represents metadata archive export api path.
+	MetadataExportPath = "/metadata/export"
+	// MetadataImportPath represents metadata archive import api path.
+	MetadataImportPath = "/metadata/import"
+)
+
+// MetadataAPI represents metadata backup/restore rest api.
+type MetadataAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewMetadataAPI creates metadata backup/restore api instance.
+func NewMetadataAPI(deps *depspkg.HTTPDeps) *MetadataAPI {
+	return &MetadataAPI{
+		deps: deps,
+	}
+}
+
+// Register adds metadata backup/restore url route.
+func (m *MetadataAPI) Register(route gin.IRoutes) {
+	route.GET(MetadataExportPath, m.Export)
+	route.PUT(MetadataImportPath, m.Import)
+}
+
+// Export returns the archive of all metadata owned by LinDB in state repository.
+func (m *MetadataAPI) Export(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := m.deps.Master.ExportMetadata(c.Request.Context(), &buf); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	c.Data(http.StatusOK, "application/json", buf.Bytes())
+}
+
+// Import imports the metadata archive in request body, returns the difference against current state,
+// only returns the difference if dry run, deletes the metadata not in archive if prune.
+func (m *MetadataAPI) Import(c *gin.Context) {
+	var param struct {
+		DryRun bool `form:"dryRun"`
+		Prune  bool `form:"prune"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	diff, err := m.deps.Master.ImportMetadata(c.Request.Context(), c.Request.Body, param.DryRun, param.Prune)
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, diff)
+}
@@ -0,0 +1,85 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/coordinator"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+)
+
+func TestMetadataAPI_Export(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	master := coordinator.NewMockMasterController(ctrl)
+	api := NewMetadataAPI(&deps.HTTPDeps{
+		Master: master,
+	})
+	r := gin.New()
+	api.Register(r)
+
+	master.EXPECT().ExportMetadata(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	resp := mock.DoRequest(t, r, http.MethodGet, MetadataExportPath, "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	master.EXPECT().ExportMetadata(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, w io.Writer) error {
+		_, err := w.Write([]byte(`{"version":1}`))
+		return err
+	})
+	resp = mock.DoRequest(t, r, http.MethodGet, MetadataExportPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"version":1}`, resp.Body.String())
+}
+
+func TestMetadataAPI_Import(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	master := coordinator.NewMockMasterController(ctrl)
+	api := NewMetadataAPI(&deps.HTTPDeps{
+		Master: master,
+	})
+	r := gin.New()
+	api.Register(r)
+
+	// bad param
+	resp := mock.DoRequest(t, r, http.MethodPut, MetadataImportPath+"?dryRun=abc", `{"version":1}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// import failure
+	master.EXPECT().ImportMetadata(gomock.Any(), gomock.Any(), false, false).Return(nil, fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodPut, MetadataImportPath, `{"version":1}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// dry run
+	master.EXPECT().ImportMetadata(gomock.Any(), gomock.Any(), true, true).
+		Return(&models.MetadataDiff{Added: []string{"/database/config/db"}}, nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, MetadataImportPath+"?dryRun=true&prune=true", `{"version":1}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "/database/config/db")
+}
@@ -0,0 +1,114 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/validate"
+)
+
+var (
+	// NamespaceRoutingPath represents the namespace routing table of write api path.
+	NamespaceRoutingPath = "/write/routing"
+)
+
+// NamespaceRoutingAPI represents the namespace=>database routing table of write api admin rest api.
+type NamespaceRoutingAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewNamespaceRoutingAPI creates the namespace routing admin api instance.
+func NewNamespaceRoutingAPI(deps *depspkg.HTTPDeps) *NamespaceRoutingAPI {
+	return &NamespaceRoutingAPI{
+		deps: deps,
+	}
+}
+
+// Register adds the namespace routing admin url route.
+func (r *NamespaceRoutingAPI) Register(route gin.IRoutes) {
+	route.GET(NamespaceRoutingPath, r.Get)
+	route.POST(NamespaceRoutingPath, r.Save)
+	route.DELETE(NamespaceRoutingPath, r.Delete)
+}
+
+// Get gets the namespace routing table.
+func (r *NamespaceRoutingAPI) Get(c *gin.Context) {
+	ctx, cancel := r.deps.WithTimeout()
+	defer cancel()
+	data, err := r.deps.Repo.Get(ctx, constants.GetNamespaceRoutingPath())
+	if err != nil {
+		http.NotFound(c)
+		return
+	}
+	routing := &models.NamespaceRouting{}
+	if err := encoding.JSONUnmarshal(data, routing); err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.OK(c, routing)
+}
+
+// Save creates or updates the namespace routing table, brokers reload the routing table via state machine.
+func (r *NamespaceRoutingAPI) Save(c *gin.Context) {
+	routing := &models.NamespaceRouting{}
+	if err := c.ShouldBindJSON(routing); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validate.Validator.Struct(routing); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := routing.Validate(); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := r.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := r.deps.Repo.Put(ctx, constants.GetNamespaceRoutingPath(), encoding.JSONMarshal(routing))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpSaveRouting, nil, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// Delete deletes the namespace routing table, writes without database are rejected.
+func (r *NamespaceRoutingAPI) Delete(c *gin.Context) {
+	ctx, cancel := r.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := r.deps.Repo.Delete(ctx, constants.GetNamespaceRoutingPath())
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpDropRouting, nil, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
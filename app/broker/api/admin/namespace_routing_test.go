@@ -0,0 +1,186 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+func TestNamespaceRoutingAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := state.NewMockRepository(ctrl)
+	api := NewNamespaceRoutingAPI(&deps.HTTPDeps{
+		Ctx:  context.Background(),
+		Repo: mockRepo,
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				HTTP: config.HTTP{
+					ReadTimeout: ltoml.Duration(time.Second)}},
+			Coordinator: config.RepoState{
+				Timeout: ltoml.Duration(time.Second * 5)},
+		},
+	})
+	r := gin.New()
+	api.Register(r)
+
+	tests := []struct {
+		name    string
+		method  string
+		reqBody string
+		prepare func()
+		assert  func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			"get routing not found",
+			http.MethodGet,
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), constants.GetNamespaceRoutingPath()).Return(nil, state.ErrNotExist)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			"get routing, unmarshal failure",
+			http.MethodGet,
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte("abc"), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get routing successfully",
+			http.MethodGet,
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(
+					[]byte(`{"routes":[{"namespace":"infra","database":"infra_metrics"}],"defaultDatabase":"db"}`), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Contains(t, resp.Body.String(), "infra_metrics")
+			},
+		},
+		{
+			"save routing, bad body",
+			http.MethodPost,
+			`abc`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save routing, database of rule required",
+			http.MethodPost,
+			`{"routes":[{"namespace":"infra"}],"defaultDatabase":"db"}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save routing, default database required in fallback mode",
+			http.MethodPost,
+			`{"routes":[{"namespace":"infra","database":"infra_metrics"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save routing failure",
+			http.MethodPost,
+			`{"routes":[{"namespace":"infra","database":"infra_metrics"}],"unknownNamespace":"reject"}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetNamespaceRoutingPath(), gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save routing successfully",
+			http.MethodPost,
+			`{"routes":[{"namespace":"infra","database":"infra_metrics"}],"unknownNamespace":"reject"}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetNamespaceRoutingPath(), gomock.Any()).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			"delete routing failure",
+			http.MethodDelete,
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetNamespaceRoutingPath()).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete routing successfully",
+			http.MethodDelete,
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetNamespaceRoutingPath()).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, tt.method, NamespaceRoutingPath, tt.reqBody)
+			if tt.assert != nil {
+				tt.assert(resp)
+			}
+		})
+	}
+}
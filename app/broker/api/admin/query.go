@@ -0,0 +1,76 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/audit"
+	httppkg "github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/query"
+)
+
+var (
+	// QueryListPath represents the running queries list api path.
+	QueryListPath = "/admin/queries"
+	// QueryPath represents the running query kill api path.
+	QueryPath = "/admin/queries/:id"
+)
+
+// QueryAPI represents the running queries' api, which lists and kills the running queries.
+type QueryAPI struct {
+	requestMgr query.RequestManager
+}
+
+// NewQueryAPI creates running query api instance.
+func NewQueryAPI() *QueryAPI {
+	return &QueryAPI{
+		requestMgr: query.GetRequestManager(),
+	}
+}
+
+// Register adds running query admin url route.
+func (q *QueryAPI) Register(route gin.IRoutes) {
+	route.GET(QueryListPath, q.List)
+	route.DELETE(QueryPath, q.Kill)
+}
+
+// List returns all running queries with stage and resource usage, the oldest first.
+func (q *QueryAPI) List(c *gin.Context) {
+	httppkg.OK(c, q.requestMgr.GetAliveRequests())
+}
+
+// Kill cancels the running query by request id, the client of query receives the cancelled by admin error.
+func (q *QueryAPI) Kill(c *gin.Context) {
+	id := c.Param("id")
+	startTime := time.Now()
+	var err error
+	if !q.requestMgr.KillRequest(id) {
+		err = constants.ErrQueryNotFound
+	}
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpKillQuery,
+		map[string]string{"requestId": id}, startTime, err)
+	if err != nil {
+		httppkg.NotFound(c)
+		return
+	}
+	httppkg.NoContent(c)
+}
@@ -0,0 +1,63 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/query"
+	queryctx "github.com/lindb/lindb/query/context"
+)
+
+func TestQueryAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := NewQueryAPI()
+	r := gin.New()
+	api.Register(r)
+
+	requestMgr := query.GetRequestManager()
+	requestID := requestMgr.NewRequest(&models.Request{DB: "db", SQL: "select f from cpu"})
+	defer requestMgr.CompleteRequest(requestID)
+	task := queryctx.NewMockTaskContext(ctrl)
+	requestMgr.AttachTask(requestID, task)
+
+	// list
+	task.EXPECT().Stage().Return("Physical Plan")
+	resp := mock.DoRequest(t, r, http.MethodGet, QueryListPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), requestID)
+	assert.Contains(t, resp.Body.String(), "Physical Plan")
+
+	// kill not found
+	resp = mock.DoRequest(t, r, http.MethodDelete, QueryListPath+"/not-found", "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	// kill
+	task.EXPECT().Kill()
+	resp = mock.DoRequest(t, r, http.MethodDelete, QueryListPath+"/"+requestID, "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
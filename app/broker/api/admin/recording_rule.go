@@ -0,0 +1,178 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/validate"
+	"github.com/lindb/lindb/sql"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+var (
+	// RecordingRulePath represents the recording rules of database api path.
+	RecordingRulePath = "/database/recording-rule"
+	// RecordingRuleListPath represents list the recording rules of all databases api path.
+	RecordingRuleListPath = "/database/recording-rule/list"
+)
+
+type recordingRuleParam struct {
+	Database string `form:"db" binding:"required"`
+}
+
+// RecordingRuleAPI represents the recording rule of database admin rest api.
+type RecordingRuleAPI struct {
+	deps   *depspkg.HTTPDeps
+	logger *logger.Logger
+}
+
+// NewRecordingRuleAPI creates the recording rule admin api instance.
+func NewRecordingRuleAPI(deps *depspkg.HTTPDeps) *RecordingRuleAPI {
+	return &RecordingRuleAPI{
+		deps:   deps,
+		logger: logger.GetLogger("Broker", "RecordingRuleAPI"),
+	}
+}
+
+// Register adds the recording rule admin url route.
+func (d *RecordingRuleAPI) Register(route gin.IRoutes) {
+	route.GET(RecordingRuleListPath, d.List)
+	route.GET(RecordingRulePath, d.GetByDatabase)
+	route.POST(RecordingRulePath, d.Save)
+	route.DELETE(RecordingRulePath, d.DeleteByDatabase)
+}
+
+// List returns the recording rules of all databases.
+func (d *RecordingRuleAPI) List(c *gin.Context) {
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	kvs, err := d.deps.Repo.List(ctx, constants.RecordingRulePath)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	var databaseRules []*models.RecordingRules
+	for _, kv := range kvs {
+		rules := &models.RecordingRules{}
+		if err := encoding.JSONUnmarshal(kv.Value, rules); err != nil {
+			d.logger.Warn("unmarshal recording rules failure", logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		databaseRules = append(databaseRules, rules)
+	}
+	http.OK(c, databaseRules)
+}
+
+// GetByDatabase gets the recording rules by database name.
+func (d *RecordingRuleAPI) GetByDatabase(c *gin.Context) {
+	param := recordingRuleParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	data, err := d.deps.Repo.Get(ctx, constants.GetRecordingRulePath(param.Database))
+	if err != nil {
+		http.NotFound(c)
+		return
+	}
+	rules := &models.RecordingRules{}
+	if err := encoding.JSONUnmarshal(data, rules); err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.OK(c, rules)
+}
+
+// Save creates or updates the recording rules of database, rules are evaluated by master.
+func (d *RecordingRuleAPI) Save(c *gin.Context) {
+	rules := &models.RecordingRules{}
+	if err := c.ShouldBindJSON(rules); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := validate.Validator.Struct(rules); err != nil {
+		http.Error(c, err)
+		return
+	}
+	if err := rules.Validate(); err != nil {
+		http.Error(c, err)
+		return
+	}
+	for _, rule := range rules.Rules {
+		if err := validateRuleSQL(rule.Name, rule.SQL); err != nil {
+			http.Error(c, err)
+			return
+		}
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Put(ctx, constants.GetRecordingRulePath(rules.Database), encoding.JSONMarshal(rules))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpSaveRule,
+		map[string]string{"database": rules.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// DeleteByDatabase deletes the recording rules of database.
+func (d *RecordingRuleAPI) DeleteByDatabase(c *gin.Context) {
+	param := recordingRuleParam{}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	ctx, cancel := d.deps.WithTimeout()
+	defer cancel()
+	startTime := time.Now()
+	err := d.deps.Repo.Delete(ctx, constants.GetRecordingRulePath(param.Database))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpDropRule,
+		map[string]string{"database": param.Database}, startTime, err)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	http.NoContent(c)
+}
+
+// validateRuleSQL checks if the sql of rule is a data query.
+func validateRuleSQL(name, ruleSQL string) error {
+	stmt, err := sql.Parse(ruleSQL)
+	if err != nil {
+		return err
+	}
+	if _, ok := stmt.(*stmtpkg.Query); !ok {
+		return fmt.Errorf("sql of rule[%s] must be data query", name)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+func TestRecordingRuleAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := state.NewMockRepository(ctrl)
+	api := NewRecordingRuleAPI(&deps.HTTPDeps{
+		Ctx:  context.Background(),
+		Repo: mockRepo,
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				HTTP: config.HTTP{
+					ReadTimeout: ltoml.Duration(time.Second)}},
+			Coordinator: config.RepoState{
+				Timeout: ltoml.Duration(time.Second * 5)},
+		},
+	})
+	r := gin.New()
+	api.Register(r)
+
+	tests := []struct {
+		name    string
+		method  string
+		url     string
+		reqBody string
+		prepare func()
+		assert  func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			"list rules failure",
+			http.MethodGet,
+			RecordingRuleListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.RecordingRulePath).Return(nil, fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"list rules successfully",
+			http.MethodGet,
+			RecordingRuleListPath,
+			``,
+			func() {
+				mockRepo.EXPECT().List(gomock.Any(), constants.RecordingRulePath).Return([]state.KeyValue{
+					{Key: "a", Value: []byte(`{"database":"db","rules":[{"name":"r","sql":"select f from cpu group by host","metric":"cpu_by_host","interval":"1m"}]}`)},
+					{Key: "b", Value: []byte(`abc`)},
+				}, nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			"get rules param invalid",
+			http.MethodGet,
+			RecordingRulePath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get rules not found",
+			http.MethodGet,
+			RecordingRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), constants.GetRecordingRulePath("db")).Return(nil, state.ErrNotExist)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, resp.Code)
+			},
+		},
+		{
+			"get rules, unmarshal failure",
+			http.MethodGet,
+			RecordingRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte("abc"), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"get rules successfully",
+			http.MethodGet,
+			RecordingRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte(`{"database":"db"}`), nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			},
+		},
+		{
+			"save rules, bad body",
+			http.MethodPost,
+			RecordingRulePath,
+			`abc`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, database required",
+			http.MethodPost,
+			RecordingRulePath,
+			`{"rules":[{"name":"r","sql":"select f from cpu group by host","metric":"cpu_by_host","interval":"1m"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, invalid interval",
+			http.MethodPost,
+			RecordingRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select f from cpu","metric":"m","interval":"1ms"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, bad sql",
+			http.MethodPost,
+			RecordingRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select","metric":"m","interval":"1m"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules, not data query",
+			http.MethodPost,
+			RecordingRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"show databases","metric":"m","interval":"1m"}]}`,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules failure",
+			http.MethodPost,
+			RecordingRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select f from cpu group by host","metric":"cpu_by_host","interval":"1m"}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetRecordingRulePath("db"), gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"save rules successfully",
+			http.MethodPost,
+			RecordingRulePath,
+			`{"database":"db","rules":[{"name":"r","sql":"select f from cpu group by host","metric":"cpu_by_host","interval":"1m"}]}`,
+			func() {
+				mockRepo.EXPECT().Put(gomock.Any(), constants.GetRecordingRulePath("db"), gomock.Any()).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+		{
+			"delete rules param invalid",
+			http.MethodDelete,
+			RecordingRulePath,
+			``,
+			nil,
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete rules failure",
+			http.MethodDelete,
+			RecordingRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetRecordingRulePath("db")).Return(fmt.Errorf("err"))
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			"delete rules successfully",
+			http.MethodDelete,
+			RecordingRulePath + "?db=db",
+			``,
+			func() {
+				mockRepo.EXPECT().Delete(gomock.Any(), constants.GetRecordingRulePath("db")).Return(nil)
+			},
+			func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, resp.Code)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, tt.method, tt.url, tt.reqBody)
+			if tt.assert != nil {
+				tt.assert(resp)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	httppkg "github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	// RollupBackfillPath represents backfill status of added rollup intervals api path.
+	RollupBackfillPath = "/database/rollup/backfill"
+)
+
+// rollupBackfillParam represents the param of rollup backfill status.
+type rollupBackfillParam struct {
+	Database string `form:"database" binding:"required"`
+}
+
+// RollupBackfillAPI represents the backfill status query of added rollup intervals,
+// history data of added rollup interval is backfilled by storage node automatically.
+type RollupBackfillAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewRollupBackfillAPI creates rollup backfill api instance.
+func NewRollupBackfillAPI(deps *depspkg.HTTPDeps) *RollupBackfillAPI {
+	return &RollupBackfillAPI{
+		deps: deps,
+	}
+}
+
+// Register adds rollup backfill admin url route.
+func (s *RollupBackfillAPI) Register(route gin.IRoutes) {
+	route.GET(RollupBackfillPath, s.Status)
+}
+
+// Status returns the backfill status of added rollup intervals, includes the progress of each shard replica.
+func (s *RollupBackfillAPI) Status(c *gin.Context) {
+	var param rollupBackfillParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	status, err := s.deps.Master.RollupBackfillStatus(param.Database)
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, status)
+}
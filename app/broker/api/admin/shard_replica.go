@@ -0,0 +1,90 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/models"
+	httppkg "github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	// ShardReplicaPath represents shard replica add api path.
+	ShardReplicaPath = "/database/shard/replica"
+	// ShardReplicaBootstrapPath represents bootstrap progress of new shard replica api path.
+	ShardReplicaBootstrapPath = "/database/shard/replica/bootstrap"
+)
+
+// shardReplicaParam represents the param of shard replica.
+type shardReplicaParam struct {
+	Database string `form:"database" json:"database" binding:"required"`
+	Shard    *int   `form:"shard" json:"shard" binding:"required"`
+	Node     *int   `form:"node" json:"node" binding:"required"`
+}
+
+// ShardReplicaAPI represents the shard replica add by manual, new replica is bootstrapped from leader's snapshot.
+type ShardReplicaAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewShardReplicaAPI creates shard replica api instance.
+func NewShardReplicaAPI(deps *depspkg.HTTPDeps) *ShardReplicaAPI {
+	return &ShardReplicaAPI{
+		deps: deps,
+	}
+}
+
+// Register adds shard replica admin url route.
+func (s *ShardReplicaAPI) Register(route gin.IRoutes) {
+	route.PUT(ShardReplicaPath, s.Add)
+	route.GET(ShardReplicaBootstrapPath, s.Progress)
+}
+
+// Add adds a new replica on storage node for the shard of database, returns the bootstrap task of replica.
+func (s *ShardReplicaAPI) Add(c *gin.Context) {
+	var param shardReplicaParam
+	if err := c.ShouldBind(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	task, err := s.deps.Master.AddReplica(c.Request.Context(), param.Database,
+		models.ShardID(*param.Shard), models.NodeID(*param.Node))
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, task)
+}
+
+// Progress returns the bootstrap progress of new shard replica.
+func (s *ShardReplicaAPI) Progress(c *gin.Context) {
+	var param shardReplicaParam
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	progress, err := s.deps.Master.ReplicaBootstrapProgress(param.Database,
+		models.ShardID(*param.Shard), models.NodeID(*param.Node))
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, progress)
+}
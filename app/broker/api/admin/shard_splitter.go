@@ -0,0 +1,66 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/models"
+	httppkg "github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	// SplitShardPath represents hot shard split api path.
+	SplitShardPath = "/database/shard/split"
+)
+
+// ShardSplitterAPI represents the hot shard split by manual.
+type ShardSplitterAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewShardSplitterAPI creates shard splitter api instance.
+func NewShardSplitterAPI(deps *depspkg.HTTPDeps) *ShardSplitterAPI {
+	return &ShardSplitterAPI{
+		deps: deps,
+	}
+}
+
+// Register adds shard split admin url route.
+func (s *ShardSplitterAPI) Register(route gin.IRoutes) {
+	route.PUT(SplitShardPath, s.Split)
+}
+
+// Split splits the hot shard of database, half key space of shard is routed to a new shard for future writes.
+func (s *ShardSplitterAPI) Split(c *gin.Context) {
+	var param struct {
+		Database string `json:"database" binding:"required"`
+		Shard    *int   `json:"shard" binding:"required"`
+	}
+	if err := c.ShouldBind(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	split, err := s.deps.Master.SplitShard(c.Request.Context(), param.Database, models.ShardID(*param.Shard))
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, split)
+}
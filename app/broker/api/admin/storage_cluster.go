@@ -18,11 +18,14 @@
 package admin
 
 import (
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	depspkg "github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/audit"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
@@ -90,7 +93,11 @@ func (s *StorageClusterAPI) DeleteByName(c *gin.Context) {
 	}
 	ctx, cancel := s.deps.WithTimeout()
 	defer cancel()
-	if err = s.deps.Repo.Delete(ctx, constants.GetStorageClusterConfigPath(param.ClusterName)); err != nil {
+	startTime := time.Now()
+	err = s.deps.Repo.Delete(ctx, constants.GetStorageClusterConfigPath(param.ClusterName))
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpDeleteStorage,
+		map[string]string{"storage": param.ClusterName}, startTime, err)
+	if err != nil {
 		http.Error(c, err)
 		return
 	}
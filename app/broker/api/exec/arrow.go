@@ -0,0 +1,249 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/gin-gonic/gin"
+	"github.com/go-http-utils/headers"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/query"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+const (
+	// ArrowStreamContentType represents the content type of apache arrow ipc stream query result.
+	ArrowStreamContentType = "application/vnd.apache.arrow.stream"
+	// ArrowTimestampColumn represents the column name of timestamp in arrow record batch.
+	ArrowTimestampColumn = "timestamp"
+	// arrowBatchRows represents how many rows are buffered before writing a record batch.
+	arrowBatchRows = 4096
+)
+
+// acceptArrow checks if client accepts apache arrow ipc stream.
+func acceptArrow(c *gin.Context) bool {
+	return strings.Contains(strings.ToLower(c.GetHeader(headers.Accept)), ArrowStreamContentType)
+}
+
+// arrowStreamWriter writes query result as apache arrow ipc stream over http chunked response,
+// implements models.ResultStream interface.
+//
+// Each row of record batch is one timestamp of series, columns are:
+//  1. timestamp(ms, UTC);
+//  2. one dictionary encoded string column per tag key(group by tag keys first);
+//  3. one float64 column per field, null if field has no value at the timestamp.
+//
+// The schema is built when the first series completed, rows are written as record batch every arrowBatchRows,
+// so that memory is bounded. After the end-of-stream marker, the trailer(result set without series, includes stats)
+// or error is written as one json line(models.StreamRecord).
+type arrowStreamWriter struct {
+	c       *gin.Context
+	groupBy []string
+	fields  []string
+
+	started bool
+	tagKeys []string
+	columns map[string]int // field name => column index
+	builder *array.RecordBuilder
+	writer  *ipc.Writer
+	rows    int
+}
+
+// newArrowStreamWriter creates an arrow stream writer with group by tag keys and field names of query.
+func newArrowStreamWriter(c *gin.Context, groupBy, fields []string) *arrowStreamWriter {
+	return &arrowStreamWriter{
+		c:       c,
+		groupBy: groupBy,
+		fields:  fields,
+	}
+}
+
+// newArrowStreamWriterOf creates an arrow stream writer for query statement.
+func newArrowStreamWriterOf(c *gin.Context, stmt stmtpkg.Statement) *arrowStreamWriter {
+	statement := stmt.(*stmtpkg.Query)
+	var fields []string
+	for _, item := range statement.SelectItems {
+		fields = append(fields, query.SelectItemName(item))
+	}
+	return newArrowStreamWriter(c, statement.GroupBy, fields)
+}
+
+// WriteSeries appends the rows of completed series, writes record batch if too many rows buffered.
+func (w *arrowStreamWriter) WriteSeries(series *models.Series) error {
+	if !w.started {
+		w.start(series.Tags)
+	}
+	for fieldName := range series.Fields {
+		if _, ok := w.columns[fieldName]; !ok {
+			return fmt.Errorf("field: %s not found in arrow schema", fieldName)
+		}
+	}
+	timestamps := seriesTimestamps(series)
+	for _, timestamp := range timestamps {
+		w.builder.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(timestamp))
+		for idx, tagKey := range w.tagKeys {
+			if err := w.builder.Field(idx + 1).(*array.BinaryDictionaryBuilder).AppendString(series.Tags[tagKey]); err != nil {
+				return err
+			}
+		}
+		for _, fieldName := range w.fields {
+			fieldBuilder := w.builder.Field(w.columns[fieldName]).(*array.Float64Builder)
+			if value, ok := series.Fields[fieldName][timestamp]; ok {
+				fieldBuilder.Append(value)
+			} else {
+				fieldBuilder.AppendNull()
+			}
+		}
+		w.rows++
+		if w.rows >= arrowBatchRows {
+			if err := w.flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteTrailer writes the buffered rows and end-of-stream marker,
+// then writes result set(without series) as final json line.
+func (w *arrowStreamWriter) WriteTrailer(resultSet *models.ResultSet) error {
+	if !w.started {
+		w.start(nil)
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	return w.close(&models.StreamRecord{Trailer: resultSet})
+}
+
+// WriteError writes end-of-stream marker and error as final json line if query failure after stream started.
+func (w *arrowStreamWriter) WriteError(err error) {
+	_ = w.close(&models.StreamRecord{Error: err.Error()})
+}
+
+// isStarted returns if the response is started.
+func (w *arrowStreamWriter) isStarted() bool {
+	return w.started
+}
+
+// start builds the schema based on tags of first series, then starts the response.
+func (w *arrowStreamWriter) start(tags map[string]string) {
+	w.started = true
+	w.tagKeys = append(w.tagKeys, w.groupBy...)
+	// implicit tag keys(e.g. database of multiple databases query) are not in group by
+	var extraKeys []string
+	for tagKey := range tags {
+		if !containsString(w.groupBy, tagKey) {
+			extraKeys = append(extraKeys, tagKey)
+		}
+	}
+	sort.Strings(extraKeys)
+	w.tagKeys = append(w.tagKeys, extraKeys...)
+
+	schemaFields := []arrow.Field{{Name: ArrowTimestampColumn, Type: arrow.FixedWidthTypes.Timestamp_ms}}
+	for _, tagKey := range w.tagKeys {
+		schemaFields = append(schemaFields, arrow.Field{
+			Name: tagKey,
+			Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int32, ValueType: arrow.BinaryTypes.String},
+		})
+	}
+	w.columns = make(map[string]int)
+	for _, fieldName := range w.fields {
+		w.columns[fieldName] = len(schemaFields)
+		schemaFields = append(schemaFields, arrow.Field{Name: fieldName, Type: arrow.PrimitiveTypes.Float64, Nullable: true})
+	}
+	schema := arrow.NewSchema(schemaFields, nil)
+	w.builder = array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	w.writer = ipc.NewWriter(w.c.Writer, ipc.WithSchema(schema))
+
+	w.c.Header(headers.ContentType, ArrowStreamContentType)
+	w.c.Status(http.StatusOK)
+}
+
+// flush writes the buffered rows as record batch, dictionaries are reset for each batch.
+func (w *arrowStreamWriter) flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	w.rows = 0
+	record := w.builder.NewRecord()
+	defer record.Release()
+
+	for idx := range w.tagKeys {
+		w.builder.Field(idx + 1).(array.DictionaryBuilder).ResetFull()
+	}
+	if err := w.writer.Write(record); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// close writes end-of-stream marker and final json line, then releases the builder.
+func (w *arrowStreamWriter) close(record *models.StreamRecord) error {
+	defer w.builder.Release()
+
+	if err := w.writer.Close(); err != nil {
+		return err
+	}
+	data := encoding.JSONMarshal(record)
+	data = append(data, '\n')
+	if _, err := w.c.Writer.Write(data); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// seriesTimestamps returns the sorted timestamps of all fields in series.
+func seriesTimestamps(series *models.Series) []int64 {
+	timestamps := make(map[int64]struct{})
+	for _, points := range series.Fields {
+		for timestamp := range points {
+			timestamps[timestamp] = struct{}{}
+		}
+	}
+	result := make([]int64, 0, len(timestamps))
+	for timestamp := range timestamps {
+		result = append(result, timestamp)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i] < result[j]
+	})
+	return result
+}
+
+// containsString checks if the value is in values.
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,240 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/ipc"
+	"github.com/gin-gonic/gin"
+	"github.com/go-http-utils/headers"
+	"github.com/stretchr/testify/assert"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/ltoml"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// arrowRow represents a row of arrow record batch for asserting.
+type arrowRow struct {
+	timestamp int64
+	host      string
+	value     *float64
+}
+
+func TestExecuteAPI_ExecuteWithArrow(t *testing.T) {
+	queryCmd := commands[stmtpkg.QueryStatement]
+	defer func() {
+		commands[stmtpkg.QueryStatement] = queryCmd
+	}()
+	api := NewExecuteAPI(&depspkg.HTTPDeps{
+		Ctx: context.Background(),
+		BrokerCfg: &config.Broker{BrokerBase: config.BrokerBase{
+			HTTP: config.HTTP{ReadTimeout: ltoml.Duration(time.Second * 10)},
+		}},
+		QueryLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			2,
+			time.Second*5,
+			metrics.NewLimitStatistics("exec", linmetric.BrokerRegistry),
+		),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	doRequest := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, ExecutePath,
+			strings.NewReader(`{"sql":"select f,g as v from cpu group by host","db":"test"}`))
+		req.Header.Set(headers.ContentType, "application/json")
+		req.Header.Set(headers.Accept, ArrowStreamContentType)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	// readStream reads the record batches of arrow stream, then reads the final json line.
+	readStream := func(body io.Reader) (schema *arrow.Schema, rows []arrowRow, batches int, final models.StreamRecord) {
+		buf := bufio.NewReader(body)
+		reader, err := ipc.NewReader(buf)
+		assert.NoError(t, err)
+		schema = reader.Schema()
+		for reader.Next() {
+			record := reader.Record()
+			batches++
+			timestamps := record.Column(0).(*array.Timestamp)
+			hosts := record.Column(schema.FieldIndices("host")[0]).(*array.Dictionary)
+			hostValues := hosts.Dictionary().(*array.String)
+			values := record.Column(schema.FieldIndices("f")[0]).(*array.Float64)
+			for i := 0; i < int(record.NumRows()); i++ {
+				row := arrowRow{
+					timestamp: int64(timestamps.Value(i)),
+					host:      hostValues.Value(hosts.GetValueIndex(i)),
+				}
+				if values.IsValid(i) {
+					value := values.Value(i)
+					row.value = &value
+				}
+				rows = append(rows, row)
+			}
+		}
+		assert.NoError(t, reader.Err())
+		reader.Release()
+		line, err := buf.ReadBytes('\n')
+		assert.NoError(t, err)
+		assert.NoError(t, encoding.JSONUnmarshal(line, &final))
+		return
+	}
+	newSeries := func(host string, points map[int64]float64) *models.Series {
+		series := models.NewSeries(map[string]string{"host": host}, host)
+		series.Fields["f"] = points
+		series.Fields["v"] = map[int64]float64{10: 1}
+		return series
+	}
+	floatPtr := func(v float64) *float64 {
+		return &v
+	}
+
+	cases := []struct {
+		name   string
+		cmd    statementExecFn
+		assert func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			name: "query failure before stream started",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				_ *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				return nil, fmt.Errorf("err")
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			name: "field not in schema",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				param *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				series := models.NewSeries(map[string]string{"host": "a"}, "a")
+				series.Fields["unknown"] = map[int64]float64{10: 1}
+				return nil, param.Stream.WriteSeries(series)
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				_, rows, _, final := readStream(resp.Body)
+				assert.Empty(t, rows)
+				assert.Equal(t, "field: unknown not found in arrow schema", final.Error)
+			},
+		},
+		{
+			name: "stream series with trailer",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				param *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				assert.NoError(t, param.Stream.WriteSeries(newSeries("a", map[int64]float64{20: 2, 10: 1})))
+				assert.NoError(t, param.Stream.WriteSeries(newSeries("b", map[int64]float64{20: 3})))
+				return &models.ResultSet{MetricName: "cpu", Stats: &models.NodeStats{}}, nil
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, ArrowStreamContentType, resp.Header().Get(headers.ContentType))
+				schema, rows, batches, final := readStream(resp.Body)
+				assert.Equal(t, []string{ArrowTimestampColumn, "host", "f", "v"},
+					[]string{schema.Field(0).Name, schema.Field(1).Name, schema.Field(2).Name, schema.Field(3).Name})
+				assert.Equal(t, arrow.DICTIONARY, schema.Field(1).Type.ID())
+				assert.Equal(t, 1, batches)
+				assert.Equal(t, []arrowRow{
+					{timestamp: 10, host: "a", value: floatPtr(1)},
+					{timestamp: 20, host: "a", value: floatPtr(2)},
+					{timestamp: 10, host: "b"},
+					{timestamp: 20, host: "b", value: floatPtr(3)},
+				}, rows)
+				assert.Equal(t, "cpu", final.Trailer.MetricName)
+				assert.NotNil(t, final.Trailer.Stats)
+			},
+		},
+		{
+			name: "implicit tag key not in group by",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				param *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				series := newSeries("a", map[int64]float64{10: 1})
+				series.Tags["_db"] = "db"
+				return nil, param.Stream.WriteSeries(series)
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				schema, rows, _, final := readStream(resp.Body)
+				assert.Equal(t, "_db", schema.Field(2).Name)
+				assert.Equal(t, []arrowRow{{timestamp: 10, host: "a", value: floatPtr(1)}}, rows)
+				assert.NotNil(t, final.Trailer)
+			},
+		},
+		{
+			name: "write multiple record batches",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				param *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				for i := 0; i < arrowBatchRows+1; i++ {
+					assert.NoError(t, param.Stream.WriteSeries(newSeries(fmt.Sprintf("host-%d", i), map[int64]float64{10: 1})))
+				}
+				return nil, nil
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				_, rows, batches, final := readStream(resp.Body)
+				assert.Equal(t, 2, batches)
+				assert.Len(t, rows, arrowBatchRows+1)
+				// dictionary is replaced for each batch
+				assert.Equal(t, fmt.Sprintf("host-%d", arrowBatchRows), rows[arrowBatchRows].host)
+				assert.NotNil(t, final.Trailer)
+			},
+		},
+		{
+			name: "empty result",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				_ *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				return nil, nil
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				schema, rows, batches, final := readStream(resp.Body)
+				assert.Len(t, schema.Fields(), 4)
+				assert.Empty(t, rows)
+				assert.Zero(t, batches)
+				assert.NotNil(t, final.Trailer)
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			commands[stmtpkg.QueryStatement] = tt.cmd
+			tt.assert(doRequest())
+		})
+	}
+}
@@ -48,4 +48,14 @@ func TestMetricMetadataCommand(t *testing.T) {
 	}, nil, &stmt.MetricMetadata{})
 	assert.NoError(t, err)
 	assert.Nil(t, rs)
+
+	statement := &stmt.MetricMetadata{Limit: 100}
+	_, err = MetricMetadataCommand(context.TODO(), &depspkg.HTTPDeps{
+		Node: &models.StatelessNode{},
+		BrokerCfg: &config.Broker{
+			Query: *config.NewDefaultQuery(),
+		},
+	}, &models.ExecuteParam{Limit: 10}, statement)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, statement.Limit)
 }
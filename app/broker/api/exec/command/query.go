@@ -39,10 +39,19 @@ func QueryCommand(ctx context.Context, deps *depspkg.HTTPDeps,
 		param,
 		stmt.(*stmtpkg.Query),
 		&query.SearchMgr{
-			Timeout:      deps.BrokerCfg.Query.Timeout.Duration(),
-			CurNode:      *deps.Node,
-			Choose:       deps.StateMgr,
-			TaskMgr:      deps.TaskMgr,
-			TransportMgr: deps.TransportMgr,
+			Timeout:           deps.BrokerCfg.Query.Timeout.Duration(),
+			CurNode:           *deps.Node,
+			Choose:            deps.StateMgr,
+			TaskMgr:           deps.TaskMgr,
+			TransportMgr:      deps.TransportMgr,
+			MaxBufferedSeries: deps.BrokerCfg.BrokerBase.Query.MaxBufferedSeries,
+			SlowQueryLog:      deps.SlowQueryLog,
+			ResultCache:       deps.ResultCache,
+			ReplicaLag:        deps.ReplicaLag,
+			Hedger:            deps.Hedger,
+			Authorizer:        deps.Authorizer,
+			Meter:             deps.Meter,
+			TopN:              deps.BrokerCfg.BrokerBase.Query.TopN,
+			Memory:            deps.BrokerCfg.BrokerBase.Query.Memory,
 		})
 }
@@ -19,13 +19,21 @@ package command
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
+	"github.com/go-resty/resty/v2"
 
 	depspkg "github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/auth"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/pkg/validate"
 	stmtpkg "github.com/lindb/lindb/sql/stmt"
 )
@@ -55,18 +63,44 @@ func SchemaCommand(ctx context.Context, deps *depspkg.HTTPDeps, _ *models.Execut
 	return nil, nil
 }
 
-// dropDatabase drops database config.
+// dropDatabase drops database via master, data of database is kept and can be restored within the grace period.
 func dropDatabase(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Schema) (interface{}, error) {
 	databaseName := stmt.Value
+	if !deps.Master.IsMaster() {
+		// if current node is not master, forward to master which manages the lifecycle of database
+		return forwardDropDatabase(ctx, deps, databaseName)
+	}
 	log.Info("drop database", logger.String("name", databaseName))
-	if err := deps.Repo.Delete(ctx, constants.GetDatabaseConfigPath(databaseName)); err != nil {
+	database, err := deps.Master.DropDatabase(ctx, databaseName)
+	if err != nil {
 		return nil, err
 	}
-	if err := deps.Repo.Delete(ctx, constants.GetDatabaseAssignPath(databaseName)); err != nil {
+	result := fmt.Sprintf("Drop database[%s] ok", databaseName)
+	if database.IsDeleted() {
+		result = fmt.Sprintf("Database[%s] scheduled for deletion at %s, can be restored before then", databaseName,
+			timeutil.FormatTimestamp(database.Deletion.PurgeAt, timeutil.DataTimeFormat2))
+	}
+	return &result, nil
+}
+
+// forwardDropDatabase forwards drop database statement to master with the token of request.
+func forwardDropDatabase(ctx context.Context, deps *depspkg.HTTPDeps, databaseName string) (interface{}, error) {
+	var result string
+	req := resty.New().R().SetContext(ctx).
+		SetBody(&models.ExecuteParam{SQL: fmt.Sprintf("drop database '%s'", databaseName)}).
+		SetHeader("Accept", "application/json").
+		SetResult(&result)
+	if token := auth.TokenFromContext(ctx); token != "" {
+		req.SetAuthToken(token)
+	}
+	resp, err := req.Post(deps.Master.GetMaster().Node.HTTPAddress() + constants.APIVersion1CliPath + "/exec")
+	if err != nil {
 		return nil, err
 	}
-	rs := fmt.Sprintf("Drop database[%s] ok", stmt.Value)
-	return &rs, nil
+	if resp.IsError() {
+		return nil, fmt.Errorf("forward drop database to master failure: %s", resp.String())
+	}
+	return &result, nil
 }
 
 // listDataBases returns database list in cluster.
@@ -76,6 +110,7 @@ func listDataBases(ctx context.Context, deps *depspkg.HTTPDeps) (interface{}, er
 		return nil, err
 	}
 	var dbs []*models.Database
+	now := timeutil.Now()
 	for _, val := range data {
 		db := &models.Database{}
 		err = encoding.JSONUnmarshal(val.Value, db)
@@ -85,17 +120,69 @@ func listDataBases(ctx context.Context, deps *depspkg.HTTPDeps) (interface{}, er
 			continue
 		}
 		db.Desc = db.String()
+		db.SetGraceRemaining(now)
 		dbs = append(dbs, db)
 	}
 	return dbs, nil
 }
 
+// markAddedIntervals validates the change of intervals based on the saved database config,
+// the history data of added rollup interval will be backfilled, so it's only available from next slot of interval,
+// keeps the available time of existing interval whose history data is being backfilled.
+func markAddedIntervals(ctx context.Context, deps *depspkg.HTTPDeps, database *models.Database) error {
+	data, err := deps.Repo.Get(ctx, constants.GetDatabaseConfigPath(database.Name))
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			// create new database
+			return nil
+		}
+		return err
+	}
+	oldDatabase := &models.Database{}
+	if err = encoding.JSONUnmarshal(data, oldDatabase); err != nil {
+		return err
+	}
+	if oldDatabase.IsDeleted() {
+		// cannot re-create dropped database before purged, restore it instead
+		return constants.ErrDatabaseDeleted
+	}
+	if oldDatabase.Option == nil {
+		return nil
+	}
+	added, err := database.Option.AddedIntervals(oldDatabase.Option)
+	if err != nil {
+		return err
+	}
+	availableFrom := make(map[timeutil.Interval]int64)
+	for _, interval := range oldDatabase.Option.Intervals {
+		availableFrom[interval.Interval] = interval.AvailableFrom
+	}
+	now := timeutil.Now()
+	for _, interval := range added {
+		intervalVal := interval.Interval.Int64()
+		availableFrom[interval.Interval] = timeutil.Truncate(now, intervalVal) + intervalVal
+	}
+	intervals := database.Option.Intervals
+	for idx := range intervals {
+		intervals[idx].AvailableFrom = availableFrom[intervals[idx].Interval]
+	}
+	if len(added) > 0 {
+		log.Info("add rollup intervals of database, history data will be backfilled",
+			logger.String("database", database.Name), logger.Any("intervals", added.String()))
+	}
+	return nil
+}
+
 // saveDataBase creates the database config if there is no database
 // config with the name database.Name, otherwise update the config.
-func saveDataBase(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Schema) (interface{}, error) {
+func saveDataBase(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Schema) (rs interface{}, err error) {
 	data := []byte(stmt.Value)
 	database := &models.Database{}
-	err := encoding.JSONUnmarshal(data, database)
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpSaveDatabase, map[string]string{"database": database.Name}, startTime, err)
+	}()
+	err = encoding.JSONUnmarshal(data, database)
 	if err != nil {
 		return nil, err
 	}
@@ -106,17 +193,20 @@ func saveDataBase(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Sch
 
 	opt := database.Option
 	// validate time series engine option
-	if err := opt.Validate(); err != nil {
+	if err = opt.Validate(); err != nil {
 		return nil, err
 	}
 	// set default value
 	opt.Default()
 	database.Option = opt // reset option after set default value
+	if err = markAddedIntervals(ctx, deps, database); err != nil {
+		return nil, err
+	}
 
 	log.Info("Saving Database", logger.String("config", stmt.Value))
-	if err := deps.Repo.Put(ctx, constants.GetDatabaseConfigPath(database.Name), data); err != nil {
+	if err = deps.Repo.Put(ctx, constants.GetDatabaseConfigPath(database.Name), encoding.JSONMarshal(database)); err != nil {
 		return nil, err
 	}
-	rs := "Create database ok"
-	return &rs, nil
+	result := "Create database ok"
+	return &result, nil
 }
@@ -20,15 +20,23 @@ package command
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 
 	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/coordinator"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/auth"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
@@ -38,8 +46,10 @@ func TestSchema(t *testing.T) {
 
 	opt := &option.DatabaseOption{}
 	repo := state.NewMockRepository(ctrl)
+	master := coordinator.NewMockMasterController(ctrl)
 	deps := &depspkg.HTTPDeps{
-		Repo: repo,
+		Repo:   repo,
+		Master: master,
 	}
 	databaseCfg := `{"name":"test","storage":"cluster-test","numOfShard":12,`
 	databaseCfg += `"replicaFactor":3,"option":{"intervals":[{"interval":"10s"}]}}`
@@ -65,6 +75,7 @@ func TestSchema(t *testing.T) {
 			name:      "create database, persist failure",
 			statement: &stmt.Schema{Type: stmt.CreateDatabaseSchemaType, Value: databaseCfg},
 			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, state.ErrNotExist)
 				repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
 			},
 			wantErr: true,
@@ -90,37 +101,124 @@ func TestSchema(t *testing.T) {
 			name:      "create database successfully",
 			statement: &stmt.Schema{Type: stmt.CreateDatabaseSchemaType, Value: databaseCfg},
 			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, state.ErrNotExist)
 				repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
 			},
 		},
 		{
-			name:      "drop database, but delete cfg failure",
+			name:      "update database, get old config failure",
+			statement: &stmt.Schema{Type: stmt.CreateDatabaseSchemaType, Value: databaseCfg},
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name:      "update database, unmarshal old config failure",
+			statement: &stmt.Schema{Type: stmt.CreateDatabaseSchemaType, Value: databaseCfg},
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]byte("err"), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:      "update database, writable interval changed",
+			statement: &stmt.Schema{Type: stmt.CreateDatabaseSchemaType, Value: databaseCfg},
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(encoding.JSONMarshal(&models.Database{
+					Name:   "test",
+					Option: &option.DatabaseOption{Intervals: option.Intervals{{Interval: timeutil.Interval(timeutil.OneMinute)}}},
+				}), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "update database, add rollup interval",
+			statement: &stmt.Schema{Type: stmt.CreateDatabaseSchemaType,
+				Value: `{"name":"test","storage":"cluster-test","numOfShard":12,"replicaFactor":3,` +
+					`"option":{"intervals":[{"interval":"10s"},{"interval":"5m"},{"interval":"1h"}]}}`},
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(encoding.JSONMarshal(&models.Database{
+					Name: "test",
+					Option: &option.DatabaseOption{Intervals: option.Intervals{
+						{Interval: timeutil.Interval(10 * timeutil.OneSecond)},
+						{Interval: timeutil.Interval(5 * timeutil.OneMinute), AvailableFrom: 100},
+					}},
+				}), nil)
+				repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, data []byte) error {
+						database := &models.Database{}
+						assert.NoError(t, encoding.JSONUnmarshal(data, database))
+						intervals := database.Option.Intervals
+						assert.Zero(t, intervals[0].AvailableFrom)
+						// keeps the available time of interval being backfilled
+						assert.Equal(t, int64(100), intervals[1].AvailableFrom)
+						assert.True(t, intervals[2].AvailableFrom > timeutil.Now())
+						return nil
+					})
+			},
+		},
+		{
+			name:      "create dropped database",
+			statement: &stmt.Schema{Type: stmt.CreateDatabaseSchemaType, Value: databaseCfg},
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), gomock.Any()).
+					Return([]byte(`{"name":"test","deletion":{"deletedAt":1,"purgeAt":2}}`), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:      "drop database failure",
 			statement: &stmt.Schema{Type: stmt.DropDatabaseSchemaType, Value: "test"},
 			prepare: func() {
-				// delete database cfg failure
-				repo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+				master.EXPECT().IsMaster().Return(true)
+				master.EXPECT().DropDatabase(gomock.Any(), "test").Return(nil, fmt.Errorf("err"))
 			},
 			wantErr: true,
 		},
 		{
-			name:      "drop database, but delete shard assignment failure",
+			name:      "drop database immediately",
+			statement: &stmt.Schema{Type: stmt.DropDatabaseSchemaType, Value: "test"},
+			prepare: func() {
+				master.EXPECT().IsMaster().Return(true)
+				master.EXPECT().DropDatabase(gomock.Any(), "test").Return(&models.Database{Name: "test"}, nil)
+			},
+		},
+		{
+			name:      "drop database, scheduled for deletion",
+			statement: &stmt.Schema{Type: stmt.DropDatabaseSchemaType, Value: "test"},
+			prepare: func() {
+				master.EXPECT().IsMaster().Return(true)
+				master.EXPECT().DropDatabase(gomock.Any(), "test").Return(&models.Database{
+					Name:     "test",
+					Deletion: &models.DatabaseDeletion{DeletedAt: 1, PurgeAt: 2},
+				}, nil)
+			},
+		},
+		{
+			name:      "drop database, forward to master failure",
 			statement: &stmt.Schema{Type: stmt.DropDatabaseSchemaType, Value: "test"},
 			prepare: func() {
-				// delete database cfg ok
-				repo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
-				// delete database shard assignment failure
-				repo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+				master.EXPECT().IsMaster().Return(false)
+				master.EXPECT().GetMaster().Return(&models.Master{Node: newMasterNode(t, http.StatusInternalServerError, `{"error":"err"}`)})
 			},
 			wantErr: true,
 		},
 		{
-			name:      "drop database successfully",
+			name:      "drop database, forward to master unreachable",
 			statement: &stmt.Schema{Type: stmt.DropDatabaseSchemaType, Value: "test"},
 			prepare: func() {
-				// delete database cfg ok
-				repo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
-				// delete database shard assignment ok
-				repo.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil)
+				master.EXPECT().IsMaster().Return(false)
+				master.EXPECT().GetMaster().Return(&models.Master{Node: &models.StatelessNode{HostIP: "127.0.0.1", HTTPPort: 1}})
+			},
+			wantErr: true,
+		},
+		{
+			name:      "drop database, forward to master successfully",
+			statement: &stmt.Schema{Type: stmt.DropDatabaseSchemaType, Value: "test"},
+			prepare: func() {
+				master.EXPECT().IsMaster().Return(false)
+				master.EXPECT().GetMaster().Return(&models.Master{Node: newMasterNode(t, http.StatusOK, `"Drop database[test] ok"`)})
 			},
 		},
 		{
@@ -210,10 +308,49 @@ func TestSchema(t *testing.T) {
 			if tt.prepare != nil {
 				tt.prepare()
 			}
-			rs, err := SchemaCommand(context.TODO(), deps, nil, tt.statement)
+			rs, err := SchemaCommand(auth.WithToken(context.TODO(), "abc"), deps, nil, tt.statement)
 			if (err != nil) != tt.wantErr && rs == nil {
 				t.Errorf("SchemaCommand() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestSchema_ListDroppedDatabase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	deps := &depspkg.HTTPDeps{Repo: repo}
+	now := timeutil.Now()
+	database := models.Database{
+		Name:     "test",
+		Option:   &option.DatabaseOption{},
+		Deletion: &models.DatabaseDeletion{DeletedAt: now, PurgeAt: now + timeutil.OneHour},
+	}
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{
+		{Key: "test", Value: encoding.JSONMarshal(&database)},
+	}, nil)
+	rs, err := SchemaCommand(context.TODO(), deps, nil, &stmt.Schema{Type: stmt.DatabaseSchemaType})
+	assert.NoError(t, err)
+	dbs := rs.([]*models.Database)
+	assert.Len(t, dbs, 1)
+	assert.True(t, dbs[0].IsDeleted())
+	assert.Contains(t, dbs[0].Desc, "scheduled for deletion at")
+	assert.NotEmpty(t, dbs[0].Deletion.GraceRemaining)
+}
+
+func newMasterNode(t *testing.T, code int, body string) *models.StatelessNode {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer abc", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(svr.Close)
+	u, err := url.Parse(svr.URL)
+	assert.NoError(t, err)
+	p, err := strconv.Atoi(u.Port())
+	assert.NoError(t, err)
+	return &models.StatelessNode{HostIP: u.Hostname(), HTTPPort: uint16(p)}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
@@ -98,10 +99,19 @@ func listStorages(ctx context.Context, deps *depspkg.HTTPDeps, _ *stmtpkg.Storag
 }
 
 // createStorage creates config of storage cluster.
-func createStorage(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Storage) (interface{}, error) {
+func createStorage(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Storage) (rs interface{}, err error) {
 	data := []byte(stmt.Value)
 	storage := &config.StorageCluster{}
-	err := encoding.JSONUnmarshal(data, storage)
+	startTime := time.Now()
+	defer func() {
+		// only record namespace, config includes the credential of repo
+		params := make(map[string]string)
+		if storage.Config != nil {
+			params["storage"] = storage.Config.Namespace
+		}
+		audit.GetAuditor().Record(ctx, audit.OpCreateStorage, params, startTime, err)
+	}()
+	err = encoding.JSONUnmarshal(data, storage)
 	if err != nil {
 		return nil, err
 	}
@@ -134,8 +144,8 @@ func createStorage(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.St
 		return nil
 	})
 	if errors.Is(state.ErrNotExist, err) {
-		rs := "Storage is exist"
-		return &rs, nil
+		result := "Storage is exist"
+		return &result, nil
 	}
 	if err != nil {
 		return nil, err
@@ -143,12 +153,16 @@ func createStorage(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.St
 	if !ok {
 		return nil, fmt.Errorf("create storage failure")
 	}
-	rs := "Create storage ok"
-	return &rs, nil
+	result := "Create storage ok"
+	return &result, nil
 }
 
 // recoverStorage recovers all database config/shard assignment by given storage.
-func recoverStorage(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Storage) (interface{}, error) {
+func recoverStorage(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.Storage) (rs interface{}, err error) {
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpRecoverStorage, map[string]string{"storage": stmt.Value}, startTime, err)
+	}()
 	storage, ok := deps.StateMgr.GetStorage(stmt.Value)
 	if !ok {
 		return nil, fmt.Errorf("storage not found")
@@ -211,14 +225,14 @@ func recoverStorage(ctx context.Context, deps *depspkg.HTTPDeps, stmt *stmtpkg.S
 		log.Info("recover shard assign",
 			logger.String("database", databaseName),
 			logger.Any("shardAssign", shardAssignment))
-		if err := deps.Repo.Put(ctx, constants.GetDatabaseAssignPath(databaseName), encoding.JSONMarshal(shardAssignment)); err != nil {
+		if err = deps.Repo.Put(ctx, constants.GetDatabaseAssignPath(databaseName), encoding.JSONMarshal(shardAssignment)); err != nil {
 			return nil, err
 		}
 		log.Info("recover database schema", logger.String("config", stmt.Value))
 		schema := databaseSchema[databaseName]
 		schema.NumOfShard = len(shardAssignment.Shards)
 		schema.ReplicaFactor = shardAssignment.GetReplicaFactor()
-		if err := deps.Repo.Put(ctx, constants.GetDatabaseConfigPath(databaseName), encoding.JSONMarshal(schema)); err != nil {
+		if err = deps.Repo.Put(ctx, constants.GetDatabaseConfigPath(databaseName), encoding.JSONMarshal(schema)); err != nil {
 			return nil, err
 		}
 		databaseNames = append(databaseNames, databaseName)
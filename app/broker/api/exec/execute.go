@@ -23,12 +23,16 @@ import (
 	"reflect"
 
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/lindb/lindb/app/broker/api/exec/command"
 	depspkg "github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/auth"
 	httppkg "github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/tracing"
 	sqlpkg "github.com/lindb/lindb/sql"
 	stmtpkg "github.com/lindb/lindb/sql/stmt"
 )
@@ -93,11 +97,18 @@ func (e *ExecuteAPI) Register(route gin.IRoutes) {
 // @Description 1. metric data/metadata query statement;
 // @Description 2. cluster metadata/state query statement;
 // @Description 3. database/storage management statement;
+// @Description metric data query result will be streamed as line-delimited json(one series per line,
+// @Description stats trailer as final line) if request header Accept is application/x-ndjson,
+// @Description or as apache arrow ipc stream(stats trailer as json line after end-of-stream marker)
+// @Description if request header Accept is application/vnd.apache.arrow.stream.
 // @Tags LinQL
 // @Accept json
 // @Param param body models.ExecuteParam ture "param data"
 // @Produce json
+// @Produce application/x-ndjson
+// @Produce application/vnd.apache.arrow.stream
 // @Success 200 {object} models.ResultSet
+// @Success 200 {object} models.StreamRecord
 // @Success 200 {object} models.Metadata
 // @Failure 404 {string} string "not found"
 // @Failure 500 {string} string "can't parse lin query language"
@@ -114,31 +125,153 @@ func (e *ExecuteAPI) Execute(c *gin.Context) {
 }
 
 // execute lin query language.
-func (e *ExecuteAPI) execute(c *gin.Context) error {
+func (e *ExecuteAPI) execute(c *gin.Context) (err error) {
 	ctx, cancel := e.deps.WithTimeout()
 	defer cancel()
+	// carry the actor of request for auditing admin operations
+	ctx = audit.WithActor(ctx, audit.ActorFromContext(c.Request.Context()))
+	// carry the authenticated principal of request for checking database permission
+	ctx = auth.WithPrincipal(ctx, auth.PrincipalFromContext(c.Request.Context()))
+	// carry the token of request for forwarding statement to master
+	ctx = auth.WithToken(ctx, auth.TokenFromContext(c.Request.Context()))
+	// continue the trace of client if request carries trace context(traceparent header)
+	ctx, span := tracing.StartSpan(tracing.ExtractHTTP(ctx, c.Request.Header), "broker.exec")
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
 
 	param := models.ExecuteParam{}
-	err := c.ShouldBind(&param)
+	err = c.ShouldBind(&param)
 	if err != nil {
 		return err
 	}
+	span.SetAttributes(attribute.String("sql", param.SQL))
+	// record the originating client of query, e.g. for slow query log
+	param.Client = c.ClientIP()
 	stmt, err := sqlParseFn(param.SQL)
 	if err != nil {
 		return err
 	}
+	if err := e.checkPermission(ctx, stmt); err != nil {
+		return err
+	}
+	if stmt.StatementType() == stmtpkg.QueryStatement {
+		switch {
+		case acceptArrow(c):
+			return e.executeWithStream(ctx, c, &param, stmt, newArrowStreamWriterOf(c, stmt))
+		case acceptStream(c):
+			return e.executeWithStream(ctx, c, &param, stmt, newResultStreamWriter(c))
+		}
+	}
 
-	if commandFn, ok := commands[stmt.StatementType()]; ok {
-		result, err := commandFn(ctx, e.deps, &param, stmt)
-		if err != nil {
-			return err
+	result, err := e.executeStatement(ctx, &param, stmt)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		httppkg.NotFound(c)
+	} else {
+		httppkg.OK(c, result)
+	}
+	return nil
+}
+
+// Execute executes lin query language with rate limit through go api(without http, e.g. embedded in tests),
+// returns nil result if not found.
+func Execute(ctx context.Context, deps *depspkg.HTTPDeps, param *models.ExecuteParam) (result interface{}, err error) {
+	e := NewExecuteAPI(deps)
+	err = deps.QueryLimiter.Do(func() error {
+		stmt, err0 := sqlParseFn(param.SQL)
+		if err0 != nil {
+			return err0
 		}
-		if result == nil || reflect.ValueOf(result).IsNil() {
-			httppkg.NotFound(c)
-		} else {
-			httppkg.OK(c, result)
+		if err0 = e.checkPermission(ctx, stmt); err0 != nil {
+			return err0
 		}
+		result, err0 = e.executeStatement(ctx, param, stmt)
+		return err0
+	})
+	return
+}
+
+// executeStatement executes the statement by the command of statement type, returns nil result if not found.
+func (e *ExecuteAPI) executeStatement(ctx context.Context,
+	param *models.ExecuteParam, stmt stmtpkg.Statement,
+) (interface{}, error) {
+	commandFn, ok := commands[stmt.StatementType()]
+	if !ok {
+		return nil, errors.New("can't parse lin query language")
+	}
+	result, err := commandFn(ctx, e.deps, param, stmt)
+	if err != nil {
+		return nil, err
+	}
+	if result == nil || reflect.ValueOf(result).IsNil() {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// checkPermission checks if the principal of request has the permission to execute the statement.
+// NOTE: metric data/metadata query is checked for each database by query authorizer.
+func (e *ExecuteAPI) checkPermission(ctx context.Context, stmt stmtpkg.Statement) error {
+	if e.deps.Authorizer == nil {
+		return nil
+	}
+	database, permission, ok := requiredPermission(stmt)
+	if !ok {
 		return nil
 	}
-	return errors.New("can't parse lin query language")
+	return e.deps.Authorizer.CheckPermission(auth.PrincipalFromContext(ctx), database, permission)
+}
+
+// requiredPermission returns the database(empty means cluster level) and the permission which statement requires.
+func requiredPermission(stmt stmtpkg.Statement) (database string, permission models.Permission, check bool) {
+	switch s := stmt.(type) {
+	case *stmtpkg.Query, *stmtpkg.MetricMetadata:
+		return "", "", false
+	case *stmtpkg.Schema:
+		switch s.Type {
+		case stmtpkg.CreateDatabaseSchemaType:
+			return "", models.PermissionAdmin, true
+		case stmtpkg.DropDatabaseSchemaType:
+			return s.Value, models.PermissionAdmin, true
+		default:
+			return "", models.PermissionRead, true
+		}
+	case *stmtpkg.Storage:
+		if s.Type == stmtpkg.StorageOpShow {
+			return "", models.PermissionRead, true
+		}
+		return "", models.PermissionAdmin, true
+	default:
+		// cluster metadata/state/request statement
+		return "", models.PermissionRead, true
+	}
+}
+
+// executeWithStream executes metric data query, emits series into result stream one by one.
+func (e *ExecuteAPI) executeWithStream(ctx context.Context, c *gin.Context,
+	param *models.ExecuteParam, stmt stmtpkg.Statement, stream resultStream,
+) error {
+	param.Stream = stream
+	result, err := commands[stmtpkg.QueryStatement](ctx, e.deps, param, stmt)
+	if err != nil {
+		if !stream.isStarted() {
+			// nothing written, response error directly
+			return err
+		}
+		e.logger.Error("execute query with stream failure", logger.String("sql", param.SQL), logger.Error(err))
+		stream.WriteError(err)
+		return nil
+	}
+	rs, ok := result.(*models.ResultSet)
+	if !ok || rs == nil {
+		rs = models.NewResultSet()
+	}
+	if err := stream.WriteTrailer(rs); err != nil {
+		// response already started, cannot response error
+		e.logger.Warn("write query result trailer failure", logger.String("sql", param.SQL), logger.Error(err))
+	}
+	return nil
 }
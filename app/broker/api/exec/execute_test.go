@@ -31,6 +31,7 @@ import (
 
 	"github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator"
 	"github.com/lindb/lindb/coordinator/broker"
 	masterpkg "github.com/lindb/lindb/coordinator/master"
@@ -39,6 +40,7 @@ import (
 	"github.com/lindb/lindb/internal/mock"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/auth"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/state"
 	"github.com/lindb/lindb/sql"
@@ -166,3 +168,71 @@ func TestExecuteAPI_Execute(t *testing.T) {
 		})
 	}
 }
+
+func TestExecute(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	master := coordinator.NewMockMasterController(ctrl)
+	httpDeps := &deps.HTTPDeps{
+		Ctx:    context.Background(),
+		Master: master,
+		QueryLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			2,
+			time.Second*5,
+			metrics.NewLimitStatistics("exec", linmetric.BrokerRegistry),
+		),
+	}
+	// parse sql failure
+	_, err := Execute(context.TODO(), httpDeps, &models.ExecuteParam{SQL: "show a"})
+	assert.Error(t, err)
+	// not found
+	master.EXPECT().GetMaster().Return(nil)
+	rs, err := Execute(context.TODO(), httpDeps, &models.ExecuteParam{SQL: "show master"})
+	assert.NoError(t, err)
+	assert.Nil(t, rs)
+	// found
+	master.EXPECT().GetMaster().Return(&models.Master{ElectTime: 1})
+	rs, err = Execute(context.TODO(), httpDeps, &models.ExecuteParam{SQL: "show master"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Master{ElectTime: 1}, rs)
+	// forbidden
+	authorizer := auth.NewMockAuthorizer(ctrl)
+	httpDeps.Authorizer = authorizer
+	authorizer.EXPECT().CheckPermission(gomock.Any(), "", models.PermissionRead).Return(constants.ErrForbidden)
+	_, err = Execute(context.TODO(), httpDeps, &models.ExecuteParam{SQL: "show master"})
+	assert.ErrorIs(t, err, constants.ErrForbidden)
+}
+
+func TestExecuteAPI_checkPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	api := NewExecuteAPI(&deps.HTTPDeps{})
+	// auth disabled
+	assert.NoError(t, api.checkPermission(context.TODO(), &stmtpkg.Storage{Type: stmtpkg.StorageOpCreate}))
+
+	authorizer := auth.NewMockAuthorizer(ctrl)
+	api = NewExecuteAPI(&deps.HTTPDeps{Authorizer: authorizer})
+	ctx := auth.WithPrincipal(context.TODO(), "alice")
+	cases := []struct {
+		stmt       stmtpkg.Statement
+		database   string
+		permission models.Permission
+	}{
+		{stmt: &stmtpkg.Schema{Type: stmtpkg.DatabaseNameSchemaType}, permission: models.PermissionRead},
+		{stmt: &stmtpkg.Schema{Type: stmtpkg.CreateDatabaseSchemaType}, permission: models.PermissionAdmin},
+		{stmt: &stmtpkg.Schema{Type: stmtpkg.DropDatabaseSchemaType, Value: "db"}, database: "db", permission: models.PermissionAdmin},
+		{stmt: &stmtpkg.Storage{Type: stmtpkg.StorageOpShow}, permission: models.PermissionRead},
+		{stmt: &stmtpkg.Storage{Type: stmtpkg.StorageOpDelete}, permission: models.PermissionAdmin},
+		{stmt: &stmtpkg.State{}, permission: models.PermissionRead},
+	}
+	for _, tt := range cases {
+		authorizer.EXPECT().CheckPermission("alice", tt.database, tt.permission).Return(constants.ErrForbidden)
+		assert.ErrorIs(t, api.checkPermission(ctx, tt.stmt), constants.ErrForbidden)
+	}
+	// query checked by query authorizer for each database
+	assert.NoError(t, api.checkPermission(ctx, &stmtpkg.Query{}))
+	assert.NoError(t, api.checkPermission(ctx, &stmtpkg.MetricMetadata{}))
+}
@@ -0,0 +1,110 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-http-utils/headers"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+const (
+	// NDJSONContentType represents the content type of line-delimited json query result stream.
+	NDJSONContentType = "application/x-ndjson"
+	// streamFlushBatch represents how many records are written before flushing the chunk.
+	streamFlushBatch = 128
+)
+
+// acceptStream checks if client accepts line-delimited json stream.
+func acceptStream(c *gin.Context) bool {
+	return strings.Contains(strings.ToLower(c.GetHeader(headers.Accept)), NDJSONContentType)
+}
+
+// resultStream represents the stream which writes query result over http chunked response.
+type resultStream interface {
+	models.ResultStream
+	// WriteTrailer writes result set(without series) as final record.
+	WriteTrailer(resultSet *models.ResultSet) error
+	// WriteError writes error as final record if query failure after stream started.
+	WriteError(err error)
+	// isStarted returns if the response is started.
+	isStarted() bool
+}
+
+// resultStreamWriter writes query result as line-delimited json over http chunked response,
+// implements models.ResultStream interface.
+type resultStreamWriter struct {
+	c       *gin.Context
+	started bool
+	pending int
+}
+
+// newResultStreamWriter creates a result stream writer.
+func newResultStreamWriter(c *gin.Context) *resultStreamWriter {
+	return &resultStreamWriter{c: c}
+}
+
+// WriteSeries writes a completed series as one line.
+func (w *resultStreamWriter) WriteSeries(series *models.Series) error {
+	return w.write(&models.StreamRecord{Series: series})
+}
+
+// WriteTrailer writes result set(without series) as final line, then flushes the response.
+func (w *resultStreamWriter) WriteTrailer(resultSet *models.ResultSet) error {
+	if err := w.write(&models.StreamRecord{Trailer: resultSet}); err != nil {
+		return err
+	}
+	w.c.Writer.Flush()
+	return nil
+}
+
+// WriteError writes error as final line if query failure after stream started.
+func (w *resultStreamWriter) WriteError(err error) {
+	_ = w.write(&models.StreamRecord{Error: err.Error()})
+	w.c.Writer.Flush()
+}
+
+// isStarted returns if the response is started.
+func (w *resultStreamWriter) isStarted() bool {
+	return w.started
+}
+
+// write writes the record as one line, flushes the chunk if too many records pending.
+func (w *resultStreamWriter) write(record *models.StreamRecord) error {
+	if !w.started {
+		w.started = true
+		w.c.Header(headers.ContentType, NDJSONContentType)
+		w.c.Status(http.StatusOK)
+	}
+	data := encoding.JSONMarshal(record)
+	data = append(data, '\n')
+	if _, err := w.c.Writer.Write(data); err != nil {
+		return err
+	}
+	w.pending++
+	if w.pending >= streamFlushBatch {
+		w.pending = 0
+		w.c.Writer.Flush()
+	}
+	return nil
+}
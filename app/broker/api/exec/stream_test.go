@@ -0,0 +1,156 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package exec
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-http-utils/headers"
+	"github.com/stretchr/testify/assert"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/ltoml"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+func TestExecuteAPI_ExecuteWithStream(t *testing.T) {
+	queryCmd := commands[stmtpkg.QueryStatement]
+	defer func() {
+		commands[stmtpkg.QueryStatement] = queryCmd
+	}()
+	api := NewExecuteAPI(&depspkg.HTTPDeps{
+		Ctx: context.Background(),
+		BrokerCfg: &config.Broker{BrokerBase: config.BrokerBase{
+			HTTP: config.HTTP{ReadTimeout: ltoml.Duration(time.Second * 10)},
+		}},
+		QueryLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			2,
+			time.Second*5,
+			metrics.NewLimitStatistics("exec", linmetric.BrokerRegistry),
+		),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	doRequest := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(http.MethodPut, ExecutePath,
+			strings.NewReader(`{"sql":"select f from cpu group by host","db":"test"}`))
+		req.Header.Set(headers.ContentType, "application/json")
+		req.Header.Set(headers.Accept, NDJSONContentType)
+		resp := httptest.NewRecorder()
+		r.ServeHTTP(resp, req)
+		return resp
+	}
+	readRecords := func(resp *httptest.ResponseRecorder) (records []models.StreamRecord) {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			record := models.StreamRecord{}
+			assert.NoError(t, encoding.JSONUnmarshal(scanner.Bytes(), &record))
+			records = append(records, record)
+		}
+		return
+	}
+
+	cases := []struct {
+		name   string
+		cmd    statementExecFn
+		assert func(resp *httptest.ResponseRecorder)
+	}{
+		{
+			name: "query failure before stream started",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				_ *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				return nil, fmt.Errorf("err")
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, resp.Code)
+			},
+		},
+		{
+			name: "query failure after stream started",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				param *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				assert.NoError(t, param.Stream.WriteSeries(models.NewSeries(nil, "")))
+				return nil, fmt.Errorf("err")
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				records := readRecords(resp)
+				assert.Len(t, records, 2)
+				assert.NotNil(t, records[0].Series)
+				assert.Equal(t, "err", records[1].Error)
+			},
+		},
+		{
+			name: "stream series with trailer",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				param *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				for i := 0; i < streamFlushBatch+1; i++ {
+					assert.NoError(t, param.Stream.WriteSeries(models.NewSeries(map[string]string{"host": "a"}, "a")))
+				}
+				return &models.ResultSet{MetricName: "cpu", Stats: &models.NodeStats{}}, nil
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				assert.Equal(t, NDJSONContentType, resp.Header().Get(headers.ContentType))
+				records := readRecords(resp)
+				assert.Len(t, records, streamFlushBatch+2)
+				trailer := records[len(records)-1].Trailer
+				assert.NotNil(t, trailer)
+				assert.Equal(t, "cpu", trailer.MetricName)
+				assert.NotNil(t, trailer.Stats)
+			},
+		},
+		{
+			name: "empty result",
+			cmd: func(_ context.Context, _ *depspkg.HTTPDeps,
+				_ *models.ExecuteParam, _ stmtpkg.Statement) (interface{}, error) {
+				return nil, nil
+			},
+			assert: func(resp *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, resp.Code)
+				records := readRecords(resp)
+				assert.Len(t, records, 1)
+				assert.NotNil(t, records[0].Trailer)
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			commands[stmtpkg.QueryStatement] = tt.cmd
+			tt.assert(doRequest())
+		})
+	}
+}
@@ -0,0 +1,52 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-http-utils/headers"
+
+	ingestCommon "github.com/lindb/lindb/ingestion/common"
+	httppkg "github.com/lindb/lindb/pkg/http"
+)
+
+// Decompress replaces the request body with the body decompressed by content encoding(gzip/zstd) in streaming,
+// shared by all protocols of write api, aborts the request if content encoding not supported or data corrupted.
+func (w *Write) Decompress(c *gin.Context) {
+	if c.Request.Body == nil {
+		c.Request.Body = http.NoBody
+	}
+	maxSize := int64(w.deps.BrokerCfg.BrokerBase.Ingestion.MaxDecompressedSize)
+	body, err := ingestCommon.NewDecompressedBody(c.Request.Body, c.GetHeader(headers.ContentEncoding), maxSize)
+	if err != nil {
+		httppkg.Error(c, err)
+		c.Abort()
+		return
+	}
+	defer func() {
+		_ = body.Close()
+	}()
+
+	c.Request.Body = body
+	// parsers read decompressed body as plain data
+	c.Request.Header.Del(headers.ContentEncoding)
+	c.Request.ContentLength = -1
+	c.Next()
+}
@@ -0,0 +1,213 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-http-utils/headers"
+	"github.com/golang/mock/gomock"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/replica"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func compressGzip(data []byte) string {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.String()
+}
+
+func compressZstd(data []byte) string {
+	w, _ := zstd.NewWriter(nil)
+	defer w.Close()
+	return string(w.EncodeAll(data, nil))
+}
+
+func TestWrite_Decompress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	stateMgr.EXPECT().GetNamespaceRouting().Return(nil, false).AnyTimes()
+	api := NewWrite(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout:       ltoml.Duration(time.Second * 2),
+					MaxDecompressedSize: ltoml.Size(4 * 1024),
+				},
+			},
+		},
+		CM:       cm,
+		StateMgr: stateMgr,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			metrics.NewLimitStatistics("decompress_write_test", linmetric.BrokerRegistry)),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	influxData := []byte(`
+measurement,foo=bar value=12 1439587925
+measurement value=12 1439587925
+`)
+	converter := metric.NewProtoConverter()
+	var brokerRow metric.BrokerRow
+	err := converter.ConvertTo(&protoMetricsV1.Metric{
+		Name:      "cpu",
+		Timestamp: timeutil.Now(),
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+	}, &brokerRow)
+	assert.NoError(t, err)
+	var buf bytes.Buffer
+	_, _ = brokerRow.WriteTo(&buf)
+	flatData := buf.Bytes()
+
+	newHeader := func(contentType, contentEncoding string) http.Header {
+		header := make(http.Header)
+		header.Set(headers.ContentType, contentType)
+		if contentEncoding != "" {
+			header.Set(headers.ContentEncoding, contentEncoding)
+		}
+		return header
+	}
+	path := WritePath + "?db=test"
+
+	cases := []struct {
+		name     string
+		body     string
+		header   http.Header
+		prepare  func()
+		wantCode int
+	}{
+		{
+			name:     "unsupported content encoding",
+			body:     string(influxData),
+			header:   newHeader(constants.ContentTypeInflux, "br"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "corrupted gzip header",
+			body:     string(influxData),
+			header:   newHeader(constants.ContentTypeInflux, "gzip"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "gzip influx",
+			body:     compressGzip(influxData),
+			header:   newHeader(constants.ContentTypeInflux, "gzip"),
+			prepare:  func() { cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil) },
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "zstd influx",
+			body:     compressZstd(influxData),
+			header:   newHeader(constants.ContentTypeInflux, "zstd"),
+			prepare:  func() { cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil) },
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "gzip flat",
+			body:     compressGzip(flatData),
+			header:   newHeader(constants.ContentTypeFlat, "gzip"),
+			prepare:  func() { cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil) },
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "zstd flat",
+			body:     compressZstd(flatData),
+			header:   newHeader(constants.ContentTypeFlat, "zstd"),
+			prepare:  func() { cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil) },
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "truncated gzip flat",
+			body:     compressGzip(bytes.Repeat(flatData, 10))[:40],
+			header:   newHeader(constants.ContentTypeFlat, "gzip"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "truncated zstd influx",
+			body:     compressZstd(bytes.Repeat(influxData, 10))[:30],
+			header:   newHeader(constants.ContentTypeInflux, "zstd"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "gzip bomb flat",
+			body:     compressGzip(bytes.Repeat(flatData, 10000)),
+			header:   newHeader(constants.ContentTypeFlat, "gzip"),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "zstd bomb influx",
+			body:     compressZstd([]byte(strings.Repeat(string(influxData), 10000))),
+			header:   newHeader(constants.ContentTypeInflux, "zstd"),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:     "uncompressed body exceeds limit",
+			body:     strings.Repeat(string(influxData), 1000),
+			header:   newHeader(constants.ContentTypeInflux, ""),
+			wantCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, http.MethodPut, path, tt.body, tt.header)
+			assert.Equal(t, tt.wantCode, resp.Code, resp.Body.String())
+			if tt.wantCode == http.StatusRequestEntityTooLarge {
+				assert.Contains(t, resp.Body.String(), `"code":"PayloadTooLarge"`)
+			}
+		})
+	}
+}
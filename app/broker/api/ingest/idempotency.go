@@ -0,0 +1,137 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/replica"
+)
+
+// idempotencyEntry represents the write request of idempotency token.
+type idempotencyEntry struct {
+	token    string
+	expireAt int64
+	done     chan struct{} // closed after write completed
+	err      error
+}
+
+// idempotencyWindow keeps the idempotency tokens of a database based on lru list.
+type idempotencyWindow struct {
+	entries map[string]*list.Element
+	lru     *list.List // front is the most recently used
+
+	statistics *metrics.IdempotencyStatistics
+}
+
+// remove removes the entry from window without lock.
+func (w *idempotencyWindow) remove(elem *list.Element) {
+	entry := w.lru.Remove(elem).(*idempotencyEntry)
+	delete(w.entries, entry.token)
+}
+
+// idempotencyWindows keeps the idempotency tokens of write requests per database,
+// tokens are bounded by ttl and max tokens of each database.
+type idempotencyWindows struct {
+	ttl       time.Duration
+	maxTokens int
+	windows   map[string]*idempotencyWindow
+
+	mutex sync.Mutex
+}
+
+// newIdempotencyWindows creates idempotency windows, returns nil if idempotent write disabled.
+func newIdempotencyWindows(ttl time.Duration, maxTokens int) *idempotencyWindows {
+	if ttl <= 0 || maxTokens <= 0 {
+		return nil
+	}
+	return &idempotencyWindows{
+		ttl:       ttl,
+		maxTokens: maxTokens,
+		windows:   make(map[string]*idempotencyWindow),
+	}
+}
+
+// Do executes write with idempotency token, duplicate token within window returns the result of original write
+// without re-writing(waits if original write is in progress), the token of failure write is removed so that
+// client can retry.
+func (ws *idempotencyWindows) Do(ctx context.Context, database, token string, write func() error) error {
+	ws.mutex.Lock()
+	window := ws.getOrCreateWindow(database)
+	now := timeutil.Now()
+	if elem, ok := window.entries[token]; ok {
+		entry := elem.Value.(*idempotencyEntry)
+		if entry.expireAt >= now {
+			window.lru.MoveToFront(elem)
+			ws.mutex.Unlock()
+
+			window.statistics.DedupHits.Incr()
+			select {
+			case <-entry.done:
+				return entry.err
+			case <-ctx.Done():
+				return replica.ErrIngestTimeout
+			}
+		}
+		window.remove(elem)
+		window.statistics.Evictions.Incr()
+	}
+	entry := &idempotencyEntry{
+		token:    token,
+		expireAt: now + ws.ttl.Milliseconds(),
+		done:     make(chan struct{}),
+	}
+	window.entries[token] = window.lru.PushFront(entry)
+	// evict the least recently used tokens if exceed max tokens
+	for window.lru.Len() > ws.maxTokens {
+		window.remove(window.lru.Back())
+		window.statistics.Evictions.Incr()
+	}
+	ws.mutex.Unlock()
+
+	entry.err = write()
+	close(entry.done)
+
+	if entry.err != nil {
+		ws.mutex.Lock()
+		if elem, ok := window.entries[token]; ok && elem.Value == entry {
+			window.remove(elem)
+		}
+		ws.mutex.Unlock()
+	}
+	return entry.err
+}
+
+// getOrCreateWindow returns the window of database, creates it if not exist, without lock.
+func (ws *idempotencyWindows) getOrCreateWindow(database string) *idempotencyWindow {
+	window, ok := ws.windows[database]
+	if !ok {
+		window = &idempotencyWindow{
+			entries:    make(map[string]*list.Element),
+			lru:        list.New(),
+			statistics: metrics.NewIdempotencyStatistics(database),
+		}
+		ws.windows[database] = window
+	}
+	return window
+}
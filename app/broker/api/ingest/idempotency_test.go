@@ -0,0 +1,94 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/replica"
+)
+
+func TestNewIdempotencyWindows(t *testing.T) {
+	assert.Nil(t, newIdempotencyWindows(0, 10))
+	assert.Nil(t, newIdempotencyWindows(time.Minute, 0))
+	assert.NotNil(t, newIdempotencyWindows(time.Minute, 10))
+}
+
+func TestIdempotencyWindows_Do(t *testing.T) {
+	ctx := context.TODO()
+	writes := 0
+	write := func() error {
+		writes++
+		return nil
+	}
+
+	ws := newIdempotencyWindows(time.Minute, 2)
+	assert.NoError(t, ws.Do(ctx, "db", "a", write))
+	assert.NoError(t, ws.Do(ctx, "db", "a", write))
+	assert.Equal(t, 1, writes)
+	// failure write can be retried
+	assert.Error(t, ws.Do(ctx, "db", "b", func() error { return fmt.Errorf("err") }))
+	assert.NoError(t, ws.Do(ctx, "db", "b", write))
+	assert.Equal(t, 2, writes)
+	// evict least recently used token
+	assert.NoError(t, ws.Do(ctx, "db", "c", write))
+	assert.Equal(t, 3, writes)
+	assert.NoError(t, ws.Do(ctx, "db", "a", write))
+	assert.Equal(t, 4, writes)
+	assert.Len(t, ws.windows["db"].entries, 2)
+
+	// token expired
+	ws = newIdempotencyWindows(time.Millisecond, 2)
+	assert.NoError(t, ws.Do(ctx, "db", "a", write))
+	time.Sleep(5 * time.Millisecond)
+	assert.NoError(t, ws.Do(ctx, "db", "a", write))
+	assert.Equal(t, 6, writes)
+}
+
+func TestIdempotencyWindows_Do_InProgress(t *testing.T) {
+	ws := newIdempotencyWindows(time.Minute, 10)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	go func() {
+		_ = ws.Do(context.TODO(), "db", "a", func() error {
+			close(started)
+			<-release
+			return fmt.Errorf("err")
+		})
+	}()
+	<-started
+
+	// timeout when waiting original write
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Millisecond)
+	defer cancel()
+	assert.Equal(t, replica.ErrIngestTimeout, ws.Do(ctx, "db", "a", func() error { return nil }))
+
+	// returns the result of original write
+	result := make(chan error)
+	go func() {
+		result <- ws.Do(context.TODO(), "db", "a", func() error { return nil })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	assert.Error(t, <-result)
+}
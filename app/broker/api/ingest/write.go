@@ -20,6 +20,7 @@ package ingest
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -36,6 +37,7 @@ import (
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/series/metric"
 )
 
@@ -46,7 +48,12 @@ var (
 
 // Write represents write api that processes flat/proto/influx protocol data.
 type Write struct {
-	deps *depspkg.HTTPDeps
+	deps        *depspkg.HTTPDeps
+	normalizers *ingestCommon.NormalizerCache
+	limiters    *ingestCommon.RowLimiterCache
+	enforcers   *ingestCommon.SchemaEnforcerCache
+	routers     *ingestCommon.NamespaceRouterCache
+	idempotency *idempotencyWindows // nil if idempotent write disabled
 
 	statistics struct {
 		flat   *linmetric.BoundHistogram
@@ -58,8 +65,14 @@ type Write struct {
 // NewWrite creates a writer instance.
 func NewWrite(deps *depspkg.HTTPDeps) *Write {
 	ingestStatistics := metrics.NewCommonIngestionStatistics()
+	ingestionCfg := deps.BrokerCfg.BrokerBase.Ingestion
 	return &Write{
-		deps: deps,
+		deps:        deps,
+		normalizers: ingestCommon.NewNormalizerCache(),
+		limiters:    ingestCommon.NewRowLimiterCache(),
+		enforcers:   ingestCommon.NewSchemaEnforcerCache(),
+		routers:     ingestCommon.NewNamespaceRouterCache(),
+		idempotency: newIdempotencyWindows(ingestionCfg.IdempotencyWindow.Duration(), ingestionCfg.IdempotencyMaxTokens),
 		statistics: struct {
 			flat   *linmetric.BoundHistogram
 			proto  *linmetric.BoundHistogram
@@ -74,8 +87,8 @@ func NewWrite(deps *depspkg.HTTPDeps) *Write {
 
 // Register adds the writer url route.
 func (w *Write) Register(route gin.IRoutes) {
-	route.POST(WritePath, w.Write)
-	route.PUT(WritePath, w.Write)
+	route.POST(WritePath, w.Decompress, w.Write)
+	route.PUT(WritePath, w.Decompress, w.Write)
 }
 
 // Write processes flat/proto/influx protocol data with ingest limit.
@@ -88,15 +101,19 @@ func (w *Write) Register(route gin.IRoutes) {
 // @Description 1. application/flatbuffer
 // @Description 2. application/protobuf
 // @Description 3. application/influx
+// @Description request body can be compressed with Content-Encoding gzip/zstd.
 // @Tags Write
 // @Accept application/flatbuffer
 // @Accept application/protobuf
 // @Accept application/influx
-// @Param db query string true "database name"
+// @Param db query string false "database name, rows are routed by namespace routing table if empty"
 // @Param ns query string false "namespace, default value: default-ns"
+// @Param Idempotency-Key header string false "idempotency token of batch, duplicate token within window is not re-written"
+// @Param Content-Encoding header string false "compression of request body, support gzip/zstd"
 // @Param string body string ture "metric data"
 // @Produce plain
 // @Success 204 {string} string ""
+// @Failure 413 {string} string "request body exceeds max decompressed size"
 // @Failure 500 {string} string "internal error"
 // @Router /write [put]
 // @Router /write [post]
@@ -113,20 +130,44 @@ func (w *Write) Write(c *gin.Context) {
 // parse flat/proto/influx protocol data, then write parsed data to database's write channel.
 func (w *Write) write(c *gin.Context) (err error) {
 	var param struct {
-		Database  string `form:"db" binding:"required"`
+		Database  string `form:"db"`
 		Namespace string `form:"ns"`
 	}
 	err = c.ShouldBindQuery(&param)
 	if err != nil {
 		return err
 	}
+	var router *ingestCommon.NamespaceRouter
+	if param.Database == "" {
+		// explicit database takes precedence, route rows by namespace only if database not input
+		routing, _ := w.deps.StateMgr.GetNamespaceRouting()
+		if router = w.routers.GetRouter(routing); router == nil {
+			return constants.ErrDatabaseNameRequired
+		}
+	}
 	ctx, cancel := context.WithTimeout(context.Background(),
 		w.deps.BrokerCfg.BrokerBase.Ingestion.IngestTimeout.Duration())
 	defer cancel()
 
-	if param.Namespace == "" {
+	if param.Namespace == "" && router == nil {
+		// keep namespace of each row for routing, empty namespace is routed as default namespace
 		param.Namespace = commonconstants.DefaultNamespace
 	}
+	token := c.Request.Header.Get(constants.IdempotencyKeyHeader)
+	if token == "" || w.idempotency == nil {
+		return w.writeRows(ctx, c, param.Database, param.Namespace, router)
+	}
+	return w.idempotency.Do(ctx, param.Database, token, func() error {
+		// carry token with rows, storage skips the rows re-written via another broker
+		return w.writeRows(replica.WithIdempotencyToken(ctx, token), c, param.Database, param.Namespace, router)
+	})
+}
+
+// writeRows parses rows from request body, then writes rows to database's write channel,
+// rows are routed to databases by namespace if router is not nil.
+func (w *Write) writeRows(ctx context.Context, c *gin.Context, database, namespace string,
+	router *ingestCommon.NamespaceRouter,
+) (err error) {
 	enrichedTags, err := ingestCommon.ExtractEnrichTags(c.Request)
 	if err != nil {
 		return err
@@ -135,20 +176,131 @@ func (w *Write) write(c *gin.Context) (err error) {
 	var rows *metric.BrokerBatchRows
 	switch {
 	case strings.HasPrefix(contentType, constants.ContentTypeFlat):
-		rows, err = flat.Parse(c.Request, enrichedTags, param.Namespace)
+		rows, err = flat.Parse(c.Request, enrichedTags, namespace)
 	case strings.HasPrefix(contentType, constants.ContentTypeInflux):
-		rows, err = influx.Parse(c.Request, enrichedTags, param.Namespace)
+		rows, err = influx.Parse(c.Request, enrichedTags, namespace)
 	case strings.HasPrefix(contentType, constants.ContentTypeProto):
-		rows, err = proto.Parse(c.Request, enrichedTags, param.Namespace)
+		rows, err = proto.Parse(c.Request, enrichedTags, namespace)
 	default:
 		err = fmt.Errorf("not support content type: %s, only support %s/%s/%s", contentType,
 			constants.ContentTypeFlat, constants.ContentTypeProto, constants.ContentTypeInflux)
 	}
+	if body, ok := c.Request.Body.(*ingestCommon.DecompressedBody); ok && body.Err() != nil {
+		// parser may stop at broken stream silently(e.g. flat decoder), failure of body takes precedence
+		return body.Err()
+	}
 	if err != nil {
 		return err
 	}
-	if err := w.deps.CM.Write(ctx, param.Database, rows); err != nil {
+	if router != nil {
+		return w.writeRoutedRows(ctx, router, rows)
+	}
+	if err := w.WriteRows(ctx, database, rows); err != nil {
+		return err
+	}
+	w.recordWrite(database, rows)
+	return nil
+}
+
+// writeRoutedRows splits rows by namespace routing table, then writes rows into each routed database.
+func (w *Write) writeRoutedRows(ctx context.Context, router *ingestCommon.NamespaceRouter, rows *metric.BrokerBatchRows) error {
+	batches, err := router.Route(rows)
+	if err != nil {
 		return err
 	}
+	databases := make([]string, 0, len(batches))
+	for database := range batches {
+		databases = append(databases, database)
+	}
+	// write databases in stable order
+	sort.Strings(databases)
+	for _, database := range databases {
+		batch := batches[database]
+		if err := w.WriteRows(ctx, database, batch); err != nil {
+			return err
+		}
+		w.recordWrite(database, batch)
+	}
 	return nil
 }
+
+// WriteBatch writes rows parsed by other protocol(such as grpc stream) with ingest limit,
+// duplicate token within idempotency window is not re-written.
+func (w *Write) WriteBatch(database, token string, rows *metric.BrokerBatchRows) error {
+	return w.deps.IngestLimiter.Do(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(),
+			w.deps.BrokerCfg.BrokerBase.Ingestion.IngestTimeout.Duration())
+		defer cancel()
+
+		write := func(ctx context.Context) error {
+			if err := w.WriteRows(ctx, database, rows); err != nil {
+				return err
+			}
+			w.recordWrite(database, rows)
+			return nil
+		}
+		if token == "" || w.idempotency == nil {
+			return write(ctx)
+		}
+		return w.idempotency.Do(ctx, database, token, func() error {
+			return write(replica.WithIdempotencyToken(ctx, token))
+		})
+	})
+}
+
+// recordWrite meters the rows written successfully.
+func (w *Write) recordWrite(database string, rows *metric.BrokerBatchRows) {
+	if w.deps.Meter != nil {
+		// only metering the rows written successfully, duplicate write(same idempotency token) is not re-written
+		w.deps.Meter.RecordWrite(database, rows)
+	}
+}
+
+// WriteRows applies normalization/ingestion limits/schema enforcement on parsed rows, then writes them into database.
+func (w *Write) WriteRows(ctx context.Context, database string, rows *metric.BrokerBatchRows) error {
+	if err := w.normalize(database, rows); err != nil {
+		return err
+	}
+	if err := w.enforceSchema(database, rows); err != nil {
+		return err
+	}
+	return w.deps.CM.Write(ctx, database, rows)
+}
+
+// normalize applies database's normalization rules, then ingestion limits on parsed rows,
+// rules/limits are reloaded when database config changed, rejects writes of dropped database.
+func (w *Write) normalize(database string, rows *metric.BrokerBatchRows) error {
+	databaseCfg, ok := w.deps.StateMgr.GetDatabaseCfg(database)
+	if ok && databaseCfg.IsDeleted() {
+		return constants.ErrDatabaseDeleted
+	}
+	if !ok || databaseCfg.Option == nil {
+		return nil
+	}
+	normalizer, err := w.normalizers.GetNormalizer(database, databaseCfg.Option.Normalize)
+	if err != nil {
+		return err
+	}
+	if normalizer != nil {
+		if err := rows.Normalize(normalizer); err != nil {
+			return err
+		}
+	}
+	// limits are checked after normalization, because metric name may be rewritten
+	limiter, err := w.limiters.GetLimiter(database, databaseCfg.Option.IngestLimits)
+	if err != nil || limiter == nil {
+		return err
+	}
+	return limiter.Limit(rows)
+}
+
+// enforceSchema checks rows against database's metric schema registry(after normalization),
+// schema changes are reloaded via state manager.
+func (w *Write) enforceSchema(database string, rows *metric.BrokerBatchRows) error {
+	schema, _ := w.deps.StateMgr.GetDatabaseSchema(database)
+	enforcer, err := w.enforcers.GetEnforcer(database, schema)
+	if err != nil || enforcer == nil {
+		return err
+	}
+	return enforcer.Enforce(rows)
+}
@@ -35,11 +35,14 @@ import (
 	"github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/internal/mock"
 	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/series/metric"
@@ -50,6 +53,10 @@ func TestWrite_Flat(t *testing.T) {
 	defer ctrl.Finish()
 
 	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	stateMgr.EXPECT().GetNamespaceRouting().Return(nil, false).AnyTimes()
 	api := NewWrite(&deps.HTTPDeps{
 		BrokerCfg: &config.Broker{
 			BrokerBase: config.BrokerBase{
@@ -58,7 +65,8 @@ func TestWrite_Flat(t *testing.T) {
 				},
 			},
 		},
-		CM: cm,
+		CM:       cm,
+		StateMgr: stateMgr,
 		IngestLimiter: concurrent.NewLimiter(
 			context.TODO(),
 			32,
@@ -68,13 +76,14 @@ func TestWrite_Flat(t *testing.T) {
 	r := gin.New()
 	api.Register(r)
 
-	// missing db param
+	// missing db param, no namespace routing
 	resp := mock.DoRequest(t, r, http.MethodPut, WritePath, "")
-	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 
 	// enrich_tag bad format
 	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test&ns=ns2&enrich_tag=a", "")
-	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"InvalidArgument"`)
 	// parse err
 	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test&enrich_tag=a=b", "error")
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
@@ -112,6 +121,10 @@ func TestWrite_Influx(t *testing.T) {
 	defer ctrl.Finish()
 
 	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	stateMgr.EXPECT().GetNamespaceRouting().Return(nil, false).AnyTimes()
 	api := NewWrite(&deps.HTTPDeps{
 		BrokerCfg: &config.Broker{
 			BrokerBase: config.BrokerBase{
@@ -120,7 +133,8 @@ func TestWrite_Influx(t *testing.T) {
 				},
 			},
 		},
-		CM: cm,
+		CM:       cm,
+		StateMgr: stateMgr,
 		IngestLimiter: concurrent.NewLimiter(
 			context.TODO(),
 			32,
@@ -133,13 +147,13 @@ func TestWrite_Influx(t *testing.T) {
 	header := make(http.Header)
 	header.Set(headers.ContentType, constants.ContentTypeInflux)
 
-	// missing db param
+	// missing db param, no namespace routing
 	resp := mock.DoRequest(t, r, http.MethodPut, WritePath, "")
-	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 
 	// enrich_tag bad format
 	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test&ns=ns2&enrich_tag=a", "")
-	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 
 	// influx line format without timestamp
 	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(io.ErrClosedPipe)
@@ -173,6 +187,10 @@ func TestWrite_Proto(t *testing.T) {
 	defer ctrl.Finish()
 
 	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	stateMgr.EXPECT().GetNamespaceRouting().Return(nil, false).AnyTimes()
 	api := NewWrite(&deps.HTTPDeps{
 		BrokerCfg: &config.Broker{
 			BrokerBase: config.BrokerBase{
@@ -181,7 +199,8 @@ func TestWrite_Proto(t *testing.T) {
 				},
 			},
 		},
-		CM: cm,
+		CM:       cm,
+		StateMgr: stateMgr,
 		IngestLimiter: concurrent.NewLimiter(
 			context.TODO(),
 			32,
@@ -191,13 +210,13 @@ func TestWrite_Proto(t *testing.T) {
 	r := gin.New()
 	api.Register(r)
 
-	// missing db param
+	// missing db param, no namespace routing
 	resp := mock.DoRequest(t, r, http.MethodPut, WritePath, "")
-	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 
 	// enrich_tag bad format
 	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test&ns=ns2&enrich_tag=a", "")
-	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
 
 	header := make(http.Header)
 	header.Set(headers.ContentType, constants.ContentTypeProto)
@@ -225,3 +244,348 @@ func TestWrite_Proto(t *testing.T) {
 	resp = mock.DoRequest(t, r, http.MethodPost, WritePath+"?db=test&ns=ns4&enrich_tag=a=b", string(data), header)
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 }
+
+func TestWrite_Normalize(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	api := NewWrite(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM:       cm,
+		StateMgr: stateMgr,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			metrics.NewLimitStatistics("normalize_write_test", linmetric.BrokerRegistry)),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	header := make(http.Header)
+	header.Set(headers.ContentType, constants.ContentTypeInflux)
+	body := `
+Measurement,Host=a,drop=b value=12 1439587925
+`
+	// invalid normalization rules
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{Option: &option.DatabaseOption{
+		Normalize: &option.NormalizeOption{MetricNameRewrites: []option.MetricNameRewrite{{Pattern: "a[b"}}},
+	}}, true)
+	resp := mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	// normalize rows
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{Option: &option.DatabaseOption{
+		Normalize: &option.NormalizeOption{
+			LowercaseMetricName: true,
+			LowercaseTagKey:     true,
+			TagFilter:           &option.TagFilterOption{Deny: []string{"drop"}},
+		},
+	}}, true)
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, rows *metric.BrokerBatchRows) error {
+			assert.Equal(t, 1, rows.Len())
+			m := rows.Rows()[0].Metric()
+			assert.Equal(t, "measurement", string(m.Name()))
+			assert.Equal(t, 1, m.KeyValuesLength())
+			return nil
+		})
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	// invalid ingestion limits
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{Option: &option.DatabaseOption{
+		IngestLimits: &option.IngestLimitOption{Action: "abc"},
+	}}, true)
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	// limits are checked after normalization
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{Option: &option.DatabaseOption{
+		Normalize: &option.NormalizeOption{
+			MetricNameRewrites: []option.MetricNameRewrite{{Pattern: "^Measurement$", Replacement: "measurement_total"}},
+		},
+		IngestLimits: &option.IngestLimitOption{MaxMetricNameLength: 12, MaxTagsPerSeries: 1},
+	}}, true)
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, rows *metric.BrokerBatchRows) error {
+			assert.Equal(t, 1, rows.Len())
+			m := rows.Rows()[0].Metric()
+			assert.Equal(t, "measureme...", string(m.Name()))
+			assert.Equal(t, 1, m.KeyValuesLength())
+			return nil
+		})
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	// database scheduled for deletion
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{
+		Option:   &option.DatabaseOption{},
+		Deletion: &models.DatabaseDeletion{DeletedAt: 1, PurgeAt: 2},
+	}, true)
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Contains(t, resp.Body.String(), constants.ErrDatabaseDeleted.Error())
+}
+
+func TestWrite_Idempotency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	api := NewWrite(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout:        ltoml.Duration(time.Second * 2),
+					IdempotencyWindow:    ltoml.Duration(time.Minute),
+					IdempotencyMaxTokens: 10,
+				},
+			},
+		},
+		CM:       cm,
+		StateMgr: stateMgr,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			metrics.NewLimitStatistics("idempotency_write_test", linmetric.BrokerRegistry)),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	converter := metric.NewProtoConverter()
+	var brokerRow metric.BrokerRow
+	assert.NoError(t, converter.ConvertTo(&protoMetricsV1.Metric{
+		Name:      "cpu",
+		Timestamp: timeutil.Now(),
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+	}, &brokerRow))
+	var buf bytes.Buffer
+	_, _ = brokerRow.WriteTo(&buf)
+	body := buf.String()
+
+	header := make(http.Header)
+	header.Set(headers.ContentType, constants.ContentTypeFlat)
+	header.Set(constants.IdempotencyKeyHeader, "token")
+
+	// write failure, token removed for retrying
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(io.ErrClosedPipe)
+	resp := mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// retry successfully, token carried to replication channel
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ string, _ *metric.BrokerBatchRows) error {
+			assert.Equal(t, "token", replica.IdempotencyTokenFromContext(ctx))
+			return nil
+		})
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	// duplicate token, not re-write
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	// same token of another database
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test2", body, header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
+
+func TestWrite_NamespaceRouting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	routing := &models.NamespaceRouting{
+		Routes:          []models.NamespaceRoute{{Namespace: "app", Database: "db1"}},
+		DefaultDatabase: "db2",
+	}
+	stateMgr.EXPECT().GetNamespaceRouting().Return(routing, true).AnyTimes()
+	api := NewWrite(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM:       cm,
+		StateMgr: stateMgr,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			metrics.NewLimitStatistics("routing_write_test", linmetric.BrokerRegistry)),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	converter := metric.NewProtoConverter()
+	var body bytes.Buffer
+	for _, namespace := range []string{"app", "other", "app"} {
+		var brokerRow metric.BrokerRow
+		assert.NoError(t, converter.ConvertTo(&protoMetricsV1.Metric{
+			Namespace: namespace,
+			Name:      "cpu",
+			Timestamp: timeutil.Now(),
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+		}, &brokerRow))
+		_, _ = brokerRow.WriteTo(&body)
+	}
+	header := make(http.Header)
+	header.Set(headers.ContentType, constants.ContentTypeFlat)
+
+	// rows routed by namespace, databases written in order
+	var written []string
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, database string, rows *metric.BrokerBatchRows) error {
+			written = append(written, database)
+			if database == "db1" {
+				assert.Equal(t, 2, rows.Len())
+			} else {
+				assert.Equal(t, 1, rows.Len())
+			}
+			return nil
+		}).Times(2)
+	resp := mock.DoRequest(t, r, http.MethodPut, WritePath, body.String(), header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.Equal(t, []string{"db1", "db2"}, written)
+	// explicit database takes precedence
+	cm.EXPECT().Write(gomock.Any(), "db3", gomock.Any()).Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=db3", body.String(), header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	// write failure
+	cm.EXPECT().Write(gomock.Any(), "db1", gomock.Any()).Return(io.ErrClosedPipe)
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath, body.String(), header)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// reject unknown namespace
+	routing.UnknownNamespace = models.UnknownNamespaceReject
+	routing.DefaultDatabase = ""
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath, body.String(), header)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+}
+
+func TestWrite_Schema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	api := NewWrite(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout: ltoml.Duration(time.Second * 2),
+				},
+			},
+		},
+		CM:       cm,
+		StateMgr: stateMgr,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			metrics.NewLimitStatistics("schema_write_test", linmetric.BrokerRegistry)),
+	})
+	r := gin.New()
+	api.Register(r)
+
+	header := make(http.Header)
+	header.Set(headers.ContentType, constants.ContentTypeInflux)
+	body := `
+cpu,host=a,ip=b usage=12 1439587925
+`
+	schema := &models.DatabaseSchema{
+		Database: "test",
+		Metrics: []models.MetricSchema{{
+			Name:    "cpu",
+			Fields:  []models.FieldSchema{{Name: "usage_last", Type: "last"}},
+			TagKeys: []string{"host"},
+		}},
+	}
+	// invalid schema
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(&models.DatabaseSchema{Database: "test", Mode: "abc"}, true)
+	resp := mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	// lenient, strip unknown tag/field
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(schema, true)
+	cm.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, rows *metric.BrokerBatchRows) error {
+			assert.Equal(t, 1, rows.Len())
+			m := rows.Rows()[0].Metric()
+			assert.Equal(t, 1, m.KeyValuesLength())
+			assert.Equal(t, 1, m.SimpleFieldsLength())
+			return nil
+		})
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	// strict, reject rows
+	strictSchema := *schema
+	strictSchema.Mode = models.SchemaModeStrict
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(&strictSchema, true)
+	resp = mock.DoRequest(t, r, http.MethodPut, WritePath+"?db=test", body, header)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+func TestWrite_WriteBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{}, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	api := NewWrite(&deps.HTTPDeps{
+		BrokerCfg: &config.Broker{
+			BrokerBase: config.BrokerBase{
+				Ingestion: config.Ingestion{
+					IngestTimeout:        ltoml.Duration(time.Second * 2),
+					IdempotencyWindow:    ltoml.Duration(time.Minute),
+					IdempotencyMaxTokens: 10,
+				},
+			},
+		},
+		CM:       cm,
+		StateMgr: stateMgr,
+		IngestLimiter: concurrent.NewLimiter(
+			context.TODO(),
+			32,
+			time.Second,
+			metrics.NewLimitStatistics("batch_write_test", linmetric.BrokerRegistry)),
+	})
+	rows := metric.NewBrokerBatchRows()
+
+	// write failure
+	cm.EXPECT().Write(gomock.Any(), "test", rows).Return(io.ErrClosedPipe)
+	assert.Equal(t, io.ErrClosedPipe, api.WriteBatch("test", "", rows))
+	// write without token
+	cm.EXPECT().Write(gomock.Any(), "test", rows).Return(nil)
+	assert.NoError(t, api.WriteBatch("test", "", rows))
+	// write with token, duplicate token not re-written
+	cm.EXPECT().Write(gomock.Any(), "test", rows).
+		DoAndReturn(func(ctx context.Context, _ string, _ *metric.BrokerBatchRows) error {
+			assert.Equal(t, "token", replica.IdempotencyTokenFromContext(ctx))
+			return nil
+		})
+	assert.NoError(t, api.WriteBatch("test", "token", rows))
+	assert.NoError(t, api.WriteBatch("test", "token", rows))
+}
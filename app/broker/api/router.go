@@ -28,7 +28,10 @@ import (
 	"github.com/lindb/lindb/constants"
 	apipkg "github.com/lindb/lindb/internal/api"
 	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/auth"
 	httppkg "github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/http/middleware"
 )
 
 // API represents broker http api.
@@ -37,15 +40,33 @@ type API struct {
 
 	database           *admin.DatabaseAPI
 	flusher            *admin.DatabaseFlusherAPI
+	splitter           *admin.ShardSplitterAPI
+	replica            *admin.ShardReplicaAPI
+	rollupBackfill     *admin.RollupBackfillAPI
 	storage            *admin.StorageClusterAPI
+	auditLog           *admin.AuditLogAPI
+	metadata           *admin.MetadataAPI
+	principal          *admin.AuthPrincipalAPI
+	schema             *admin.DatabaseSchemaAPI
+	fieldAlias         *admin.DatabaseFieldAliasAPI
+	routing            *admin.NamespaceRoutingAPI
+	recordingRule      *admin.RecordingRuleAPI
+	alertRule          *admin.AlertRuleAPI
+	query              *admin.QueryAPI
 	brokerStateMachine *state.BrokerStateMachineAPI
+	slowQuery          *state.SlowQueryAPI
+	usage              *state.UsageAPI
+	topMetrics         *state.TopMetricsAPI
 	request            *apipkg.RequestAPI
 	metricExplore      *apipkg.ExploreAPI
 	log                *apipkg.LoggerAPI
 	config             *apipkg.ConfigAPI
 	env                *apipkg.EnvAPI
+	diagnostics        *apipkg.DiagnosticsAPI
 	write              *ingest.Write
 	proxy              *httppkg.ReverseProxy
+
+	authorizer auth.Authorizer // nil means authentication disabled
 }
 
 // NewAPI creates broker http api.
@@ -54,40 +75,118 @@ func NewAPI(deps *depspkg.HTTPDeps) *API {
 		execute:            exec.NewExecuteAPI(deps),
 		database:           admin.NewDatabaseAPI(deps),
 		flusher:            admin.NewDatabaseFlusherAPI(deps),
+		splitter:           admin.NewShardSplitterAPI(deps),
+		replica:            admin.NewShardReplicaAPI(deps),
+		rollupBackfill:     admin.NewRollupBackfillAPI(deps),
 		storage:            admin.NewStorageClusterAPI(deps),
+		auditLog:           admin.NewAuditLogAPI(deps),
+		metadata:           admin.NewMetadataAPI(deps),
+		principal:          admin.NewAuthPrincipalAPI(deps),
+		schema:             admin.NewDatabaseSchemaAPI(deps),
+		fieldAlias:         admin.NewDatabaseFieldAliasAPI(deps),
+		routing:            admin.NewNamespaceRoutingAPI(deps),
+		recordingRule:      admin.NewRecordingRuleAPI(deps),
+		alertRule:          admin.NewAlertRuleAPI(deps),
+		query:              admin.NewQueryAPI(),
 		brokerStateMachine: state.NewBrokerStateMachineAPI(deps),
+		slowQuery:          state.NewSlowQueryAPI(deps),
+		usage:              state.NewUsageAPI(deps),
+		topMetrics:         state.NewTopMetricsAPI(deps),
 		request:            apipkg.NewRequestAPI(),
 		metricExplore:      apipkg.NewExploreAPI(deps.GlobalKeyValues, linmetric.BrokerRegistry),
 		log:                apipkg.NewLoggerAPI(deps.BrokerCfg.Logging.Dir),
 		config:             apipkg.NewConfigAPI(deps.Node, deps.BrokerCfg),
 		env:                apipkg.NewEnvAPI(deps.BrokerCfg.Monitor, constants.BrokerRole),
-		write:              ingest.NewWrite(deps),
-		proxy:              httppkg.NewReverseProxy(),
+		diagnostics: apipkg.NewDiagnosticsAPI(deps.Node, deps.BrokerCfg, deps.BrokerCfg.Logging.Dir,
+			diagnosticsItems(deps)...),
+		write:      ingest.NewWrite(deps),
+		proxy:      httppkg.NewReverseProxy(),
+		authorizer: deps.Authorizer,
 	}
 }
 
 // RegisterRouter registers http api router.
 func (api *API) RegisterRouter(router *gin.RouterGroup) {
 	v1 := router.Group(constants.APIVersion1)
-	// execute lin query language statement
+	if api.authorizer != nil {
+		v1.Use(middleware.TokenAuthentication(api.authorizer))
+	}
+	clusterRead := api.requirePermission(v1, models.PermissionRead, nil)
+	clusterAdmin := api.requirePermission(v1, models.PermissionAdmin, nil)
+
+	// execute lin query language statement(check permission based on statement)
 	api.execute.Register(v1)
 
-	api.database.Register(v1)
-	api.flusher.Register(v1)
-	api.storage.Register(v1)
+	api.database.Register(api.requirePermission(v1, models.PermissionRead, middleware.DatabaseFromQuery("name")))
+	api.database.RegisterAdmin(clusterAdmin)
+	api.flusher.Register(clusterAdmin)
+	api.splitter.Register(clusterAdmin)
+	api.replica.Register(clusterAdmin)
+	api.rollupBackfill.Register(clusterAdmin)
+	api.storage.Register(clusterAdmin)
+	api.auditLog.Register(clusterAdmin)
+	api.metadata.Register(clusterAdmin)
+	api.principal.Register(clusterAdmin)
+	api.schema.Register(clusterAdmin)
+	api.fieldAlias.Register(clusterAdmin)
+	api.routing.Register(clusterAdmin)
+	api.recordingRule.Register(clusterAdmin)
+	api.alertRule.Register(clusterAdmin)
+	api.query.Register(clusterAdmin)
 
 	// state
-	api.brokerStateMachine.Register(v1)
-	api.request.Register(v1)
+	api.brokerStateMachine.Register(clusterRead)
+	api.slowQuery.Register(clusterRead)
+	api.usage.Register(clusterRead)
+	api.topMetrics.Register(clusterRead)
+	api.request.Register(clusterRead)
 
 	// write metric data
-	api.write.Register(v1)
+	api.write.Register(api.requirePermission(v1, models.PermissionWrite, middleware.DatabaseFromQuery("db")))
 
 	// monitoring
-	api.metricExplore.Register(v1)
-	api.log.Register(v1)
-	api.config.Register(v1)
+	api.metricExplore.Register(clusterRead)
+	api.log.Register(clusterAdmin)
+	api.config.Register(clusterAdmin)
+	api.diagnostics.Register(clusterAdmin)
+
+	api.env.Register(clusterRead)
+	api.proxy.Register(clusterAdmin)
+}
 
-	api.env.Register(v1)
-	api.proxy.Register(v1)
+// diagnosticsItems returns the items of diagnostics bundle for broker, includes slow queries and cluster state.
+func diagnosticsItems(deps *depspkg.HTTPDeps) []apipkg.BundleItem {
+	return []apipkg.BundleItem{
+		{
+			Name: "slow_queries",
+			Collect: func() (interface{}, error) {
+				if deps.SlowQueryLog == nil {
+					return []*models.SlowQuery{}, nil
+				}
+				return deps.SlowQueryLog.GetSlowQueries(), nil
+			},
+		},
+		{
+			Name: "cluster_state",
+			Collect: func() (interface{}, error) {
+				return map[string]interface{}{
+					"master":    deps.Master.GetMaster(),
+					"liveNodes": deps.StateMgr.GetLiveNodes(),
+					"storages":  deps.StateMgr.GetStorageList(),
+					"databases": deps.StateMgr.GetDatabases(),
+				}, nil
+			},
+		},
+	}
+}
+
+// requirePermission returns the routes which require the permission on database(nil means cluster level),
+// returns the group directly if authentication disabled.
+func (api *API) requirePermission(group *gin.RouterGroup, permission models.Permission,
+	databaseFn func(c *gin.Context) string,
+) gin.IRoutes {
+	if api.authorizer == nil {
+		return group
+	}
+	return group.Group("", middleware.RequirePermission(api.authorizer, permission, databaseFn))
 }
@@ -18,16 +18,72 @@
 package api
 
 import (
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator"
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/auth"
+	"github.com/lindb/lindb/query"
 )
 
 func TestNewRouter(t *testing.T) {
 	r := NewAPI(&deps.HTTPDeps{BrokerCfg: &config.Broker{}})
 	r.RegisterRouter(gin.New().Group(constants.APIRoot))
 }
+
+func TestNewRouter_Auth(t *testing.T) {
+	authorizer := auth.NewAuthorizer(config.Auth{Enabled: true}, nil,
+		auth.NewStaticTokenValidator(map[string]string{"token": "alice"}))
+	r := NewAPI(&deps.HTTPDeps{BrokerCfg: &config.Broker{}, Authorizer: authorizer})
+	engine := gin.New()
+	r.RegisterRouter(engine.Group(constants.APIRoot))
+
+	// missing token
+	resp := mock.DoRequest(t, engine, http.MethodPut, constants.APIRoot+constants.APIVersion1+"/write?db=test", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	// no write permission
+	resp = mock.DoRequest(t, engine, http.MethodPut, constants.APIRoot+constants.APIVersion1+"/write?db=test", "",
+		http.Header{"Authorization": []string{"Bearer token"}})
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+}
+
+func TestDiagnosticsItems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	master := coordinator.NewMockMasterController(ctrl)
+	master.EXPECT().GetMaster().Return(&models.Master{})
+	stateMgr.EXPECT().GetLiveNodes().Return(nil)
+	stateMgr.EXPECT().GetStorageList().Return(nil)
+	stateMgr.EXPECT().GetDatabases().Return(nil)
+
+	items := diagnosticsItems(&deps.HTTPDeps{StateMgr: stateMgr, Master: master})
+	assert.Len(t, items, 2)
+	// slow query log disabled
+	rs, err := items[0].Collect()
+	assert.NoError(t, err)
+	assert.Empty(t, rs)
+	rs, err = items[1].Collect()
+	assert.NoError(t, err)
+	assert.Len(t, rs, 4)
+
+	items = diagnosticsItems(&deps.HTTPDeps{
+		SlowQueryLog: query.NewSlowQueryLog(time.Second, 10, linmetric.BrokerRegistry),
+	})
+	rs, err = items[0].Collect()
+	assert.NoError(t, err)
+	assert.Empty(t, rs)
+}
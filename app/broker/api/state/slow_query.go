@@ -0,0 +1,56 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	SlowQueriesPath = "/state/slow-queries"
+)
+
+// SlowQueryAPI represents slow query log related api.
+type SlowQueryAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewSlowQueryAPI creates a SlowQueryAPI instance.
+func NewSlowQueryAPI(deps *depspkg.HTTPDeps) *SlowQueryAPI {
+	return &SlowQueryAPI{
+		deps: deps,
+	}
+}
+
+// Register adds slow query url route.
+func (api *SlowQueryAPI) Register(route gin.IRoutes) {
+	route.GET(SlowQueriesPath, api.GetSlowQueries)
+}
+
+// GetSlowQueries returns the latest slow queries, the latest first.
+func (api *SlowQueryAPI) GetSlowQueries(c *gin.Context) {
+	if api.deps.SlowQueryLog == nil {
+		http.OK(c, []*models.SlowQuery{})
+		return
+	}
+	http.OK(c, api.deps.SlowQueryLog.GetSlowQueries())
+}
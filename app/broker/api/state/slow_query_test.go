@@ -0,0 +1,51 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/query"
+)
+
+func TestSlowQueryAPI_GetSlowQueries(t *testing.T) {
+	deps := &depspkg.HTTPDeps{}
+	api := NewSlowQueryAPI(deps)
+	r := gin.New()
+	api.Register(r)
+
+	// slow query log not set
+	resp := mock.DoRequest(t, r, http.MethodGet, SlowQueriesPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "[]", resp.Body.String())
+
+	deps.SlowQueryLog = query.NewSlowQueryLog(time.Second, 10, linmetric.BrokerRegistry)
+	deps.SlowQueryLog.Record(&models.SlowQuery{SQL: "select f from cpu", Cost: int64(time.Minute)})
+	resp = mock.DoRequest(t, r, http.MethodGet, SlowQueriesPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), "select f from cpu")
+}
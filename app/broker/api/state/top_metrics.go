@@ -0,0 +1,125 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-resty/resty/v2"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+var (
+	TopMetricsPath = "/state/top-metrics"
+	// metricUsagePath is the path of storage api which returns metric usage of storage node.
+	metricUsagePath = "/state/metric-usage"
+)
+
+// TopMetricsAPI represents the api which reports the top metrics by write/query usage across storage clusters.
+type TopMetricsAPI struct {
+	deps   *depspkg.HTTPDeps
+	logger *logger.Logger
+}
+
+// NewTopMetricsAPI creates a TopMetricsAPI instance.
+func NewTopMetricsAPI(deps *depspkg.HTTPDeps) *TopMetricsAPI {
+	return &TopMetricsAPI{
+		deps:   deps,
+		logger: logger.GetLogger("Broker", "TopMetricsAPI"),
+	}
+}
+
+// Register adds top metrics url route.
+func (api *TopMetricsAPI) Register(route gin.IRoutes) {
+	route.GET(TopMetricsPath, api.GetTopMetrics)
+}
+
+// GetTopMetrics returns the top metrics sorted by given dimension(points by default),
+// usages of same metric reported by storage nodes are merged, all storage clusters are included if storage not set.
+func (api *TopMetricsAPI) GetTopMetrics(c *gin.Context) {
+	var param struct {
+		Storage string `form:"storage"`
+		Sort    string `form:"sort"`
+		Limit   int    `form:"limit"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		http.Error(c, err)
+		return
+	}
+	sortBy, err := models.ParseMetricUsageSortBy(param.Sort)
+	if err != nil {
+		http.Error(c, err)
+		return
+	}
+	var storages []*models.StorageState
+	if param.Storage != "" {
+		storage, ok := api.deps.StateMgr.GetStorage(param.Storage)
+		if !ok {
+			http.NotFound(c)
+			return
+		}
+		storages = append(storages, storage)
+	} else {
+		storages = api.deps.StateMgr.GetStorageList()
+	}
+	var nodes []models.Node
+	for _, storage := range storages {
+		for id := range storage.LiveNodes {
+			n := storage.LiveNodes[id]
+			nodes = append(nodes, &n)
+		}
+	}
+	http.OK(c, models.TopMetricUsages(api.fetchMetricUsages(nodes), sortBy, param.Limit))
+}
+
+// fetchMetricUsages fetches all tracked metric usages from each storage node,
+// the usages of node are bounded by sketch capacity, fetching all makes merged result accurate.
+func (api *TopMetricsAPI) fetchMetricUsages(nodes []models.Node) []*models.MetricUsage {
+	result := make([][]*models.MetricUsage, len(nodes))
+	var wait sync.WaitGroup
+	wait.Add(len(nodes))
+	for idx := range nodes {
+		i := idx
+		go func() {
+			defer wait.Done()
+			address := nodes[i].HTTPAddress()
+			var usages []*models.MetricUsage
+			_, err := resty.New().R().
+				SetHeader("Accept", "application/json").
+				SetResult(&usages).
+				Get(address + constants.APIVersion1CliPath + metricUsagePath)
+			if err != nil {
+				api.logger.Error("get metric usage from storage node", logger.String("url", address), logger.Error(err))
+				return
+			}
+			result[i] = usages
+		}()
+	}
+	wait.Wait()
+	var rs []*models.MetricUsage
+	for _, usages := range result {
+		rs = append(rs, usages...)
+	}
+	return rs
+}
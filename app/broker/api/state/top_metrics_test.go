@@ -0,0 +1,121 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+)
+
+func TestTopMetricsAPI_GetTopMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[{"database":"db","metric":"cpu","points":10},{"database":"db","metric":"mem","points":20}]`))
+	}))
+	defer svr.Close()
+	u, err := url.Parse(svr.URL)
+	assert.NoError(t, err)
+	p, err := strconv.Atoi(u.Port())
+	assert.NoError(t, err)
+	storage := &models.StorageState{
+		LiveNodes: map[models.NodeID]models.StatefulNode{
+			1: {StatelessNode: models.StatelessNode{HostIP: u.Hostname(), HTTPPort: uint16(p)}, ID: 1},
+			2: {StatelessNode: models.StatelessNode{HostIP: u.Hostname(), HTTPPort: uint16(p)}, ID: 2},
+			// node unavailable
+			3: {StatelessNode: models.StatelessNode{HostIP: "127.0.0.1", HTTPPort: 1}, ID: 3},
+		},
+	}
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	api := NewTopMetricsAPI(&depspkg.HTTPDeps{StateMgr: stateMgr})
+	r := gin.New()
+	api.Register(r)
+
+	cases := []struct {
+		name    string
+		path    string
+		prepare func()
+		code    int
+		body    string
+	}{
+		{
+			name: "param invalid",
+			path: TopMetricsPath + "?limit=a",
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "sort dimension invalid",
+			path: TopMetricsPath + "?sort=abc",
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "storage not found",
+			path: TopMetricsPath + "?storage=a",
+			prepare: func() {
+				stateMgr.EXPECT().GetStorage("a").Return(nil, false)
+			},
+			code: http.StatusNotFound,
+		},
+		{
+			name: "top metrics of storage",
+			path: TopMetricsPath + "?storage=a&limit=1",
+			prepare: func() {
+				stateMgr.EXPECT().GetStorage("a").Return(storage, true)
+			},
+			code: http.StatusOK,
+			body: `[{"database":"db","metric":"mem","points":40,"flushBytes":0,"queries":0,"scanBytes":0}]`,
+		},
+		{
+			name: "top metrics of all storages",
+			path: TopMetricsPath,
+			prepare: func() {
+				stateMgr.EXPECT().GetStorageList().Return([]*models.StorageState{storage})
+			},
+			code: http.StatusOK,
+			body: `"metric":"cpu","points":20`,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, http.MethodGet, tt.path, "")
+			assert.Equal(t, tt.code, resp.Code)
+			if tt.body != "" {
+				assert.Contains(t, resp.Body.String(), tt.body)
+			}
+		})
+	}
+}
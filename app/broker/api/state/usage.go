@@ -0,0 +1,56 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"github.com/gin-gonic/gin"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	UsagePath = "/state/usage"
+)
+
+// UsageAPI represents usage metering related api.
+type UsageAPI struct {
+	deps *depspkg.HTTPDeps
+}
+
+// NewUsageAPI creates a UsageAPI instance.
+func NewUsageAPI(deps *depspkg.HTTPDeps) *UsageAPI {
+	return &UsageAPI{
+		deps: deps,
+	}
+}
+
+// Register adds usage url route.
+func (api *UsageAPI) Register(route gin.IRoutes) {
+	route.GET(UsagePath, api.GetUsage)
+}
+
+// GetUsage returns the usage of current metering interval(not flushed) by database/namespace.
+func (api *UsageAPI) GetUsage(c *gin.Context) {
+	if api.deps.Meter == nil {
+		http.OK(c, []*models.Usage{})
+		return
+	}
+	http.OK(c, api.deps.Meter.Usage())
+}
@@ -0,0 +1,54 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	depspkg "github.com/lindb/lindb/app/broker/deps"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/metering"
+)
+
+func TestUsageAPI_GetUsage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	deps := &depspkg.HTTPDeps{}
+	api := NewUsageAPI(deps)
+	r := gin.New()
+	api.Register(r)
+
+	// metering disabled
+	resp := mock.DoRequest(t, r, http.MethodGet, UsagePath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "[]", resp.Body.String())
+
+	meter := metering.NewMockMeter(ctrl)
+	deps.Meter = meter
+	meter.EXPECT().Usage().Return([]*models.Usage{{Database: "db", Namespace: "ns", Queries: 1}})
+	resp = mock.DoRequest(t, r, http.MethodGet, UsagePath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"queries":1`)
+}
@@ -25,8 +25,11 @@ import (
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/auth"
+	"github.com/lindb/lindb/pkg/metering"
 	"github.com/lindb/lindb/pkg/state"
 	"github.com/lindb/lindb/query"
+	queryctx "github.com/lindb/lindb/query/context"
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series/tag"
@@ -48,6 +51,15 @@ type HTTPDeps struct {
 	CM            replica.ChannelManager
 	IngestLimiter *concurrent.Limiter
 	QueryLimiter  *concurrent.Limiter
+	SlowQueryLog  query.SlowQueryLog
+	ResultCache   query.ResultCache
+	ReplicaLag    broker.ReplicaLagTracker
+	Hedger        *queryctx.Hedger
+	// Meter meters the usage of ingestion and query by database/namespace, nil means metering disabled.
+	Meter metering.Meter
+	// Authorizer authenticates the token of request and checks the permission of principal,
+	// nil means authentication disabled(auth.enabled=false).
+	Authorizer auth.Authorizer
 
 	GlobalKeyValues tag.Tags
 }
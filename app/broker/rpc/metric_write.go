@@ -0,0 +1,184 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"go.uber.org/atomic"
+
+	commonconstants "github.com/lindb/common/constants"
+
+	"github.com/lindb/lindb/ingestion/flat"
+	"github.com/lindb/lindb/pkg/errcode"
+	"github.com/lindb/lindb/pkg/logger"
+	protoIngestV1 "github.com/lindb/lindb/proto/gen/v1/ingest"
+	"github.com/lindb/lindb/series/metric"
+)
+
+//go:generate mockgen -source=./metric_write.go -destination=./metric_write_mock.go -package=rpc
+
+// for testing
+var (
+	nowFn = time.Now
+)
+
+var (
+	errIngestionNotReady = errcode.New(errcode.Unavailable, "broker is not ready for ingestion")
+	errHandshakeRequired = errcode.Wrap(errcode.InvalidArgument,
+		errors.New("handshake with database is required in first message"))
+)
+
+// slowWriteThreshold represents the write cost of batch which means channel is blocked by replication back-pressure.
+const slowWriteThreshold = 50 * time.Millisecond
+
+// RowWriter represents the writer which writes parsed rows into database's write channel.
+type RowWriter interface {
+	// WriteBatch writes rows with ingest limit, duplicate token within idempotency window is not re-written.
+	WriteBatch(database, token string, rows *metric.BrokerBatchRows) error
+}
+
+// MetricWriteHandler implements protoIngestV1.MetricWriteServer interface for handling grpc streaming write,
+// batches are decoded, then written via the same ingestion pipeline as http write api.
+type MetricWriteHandler struct {
+	maxWindow int
+	writer    atomic.Value // RowWriter, bound when broker is ready for ingestion
+
+	logger *logger.Logger
+}
+
+// NewMetricWriteHandler creates a grpc streaming write handler.
+func NewMetricWriteHandler(maxWindow int) *MetricWriteHandler {
+	if maxWindow <= 0 {
+		maxWindow = 1
+	}
+	return &MetricWriteHandler{
+		maxWindow: maxWindow,
+		logger:    logger.GetLogger("Broker", "MetricWriteRPC"),
+	}
+}
+
+// Bind binds the row writer, write stream is rejected before bound.
+func (h *MetricWriteHandler) Bind(writer RowWriter) {
+	h.writer.Store(writer)
+}
+
+// Write receives batches from stream, the first message must carry handshake with target database/namespace,
+// each batch is acknowledged in order with write result and the window of unacknowledged batches.
+func (h *MetricWriteHandler) Write(stream protoIngestV1.MetricWrite_WriteServer) error {
+	writer, ok := h.writer.Load().(RowWriter)
+	if !ok {
+		return errcode.GRPCError(errIngestionNotReady)
+	}
+	req, err := stream.Recv()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		h.logger.Error("receive write request err", logger.Error(err))
+		return errcode.GRPCError(err)
+	}
+	handshake := req.Handshake
+	if handshake == nil || handshake.Database == "" {
+		return errcode.GRPCError(errHandshakeRequired)
+	}
+	namespace := handshake.Namespace
+	if namespace == "" {
+		namespace = commonconstants.DefaultNamespace
+	}
+	window := newFlowWindow(h.maxWindow)
+	for {
+		resp := h.writeBatch(writer, handshake.Database, namespace, req, window)
+		if err := stream.Send(resp); err != nil {
+			return errcode.GRPCError(err)
+		}
+		req, err = stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			h.logger.Error("receive write request err",
+				logger.String("database", handshake.Database), logger.Error(err))
+			return errcode.GRPCError(err)
+		}
+	}
+}
+
+// writeBatch decodes the rows of batch, then writes them into database, returns the result of batch.
+func (h *MetricWriteHandler) writeBatch(
+	writer RowWriter,
+	database, namespace string,
+	req *protoIngestV1.WriteRequest,
+	window *flowWindow,
+) *protoIngestV1.WriteResponse {
+	resp := &protoIngestV1.WriteResponse{Sequence: req.Sequence}
+	if len(req.Rows) > 0 {
+		rows, rejected, err := flat.ParseRows(req.Rows, nil, namespace)
+		switch {
+		case err != nil:
+			resp.Err = err.Error()
+		case rows.Len() == 0 && rejected.Count == 0:
+			resp.Err = "empty metrics"
+		default:
+			resp.Rejected = int32(rejected.Count)
+			resp.Reasons = rejected.Reasons
+			if rows.Len() > 0 {
+				start := nowFn()
+				err = writer.WriteBatch(database, req.IdempotencyKey, rows)
+				window.adjust(nowFn().Sub(start))
+				if err != nil {
+					resp.Err = err.Error()
+					resp.Rejected += int32(rows.Len())
+				} else {
+					resp.Accepted = int32(rows.Len())
+				}
+			}
+		}
+	}
+	resp.Window = int32(window.size)
+	return resp
+}
+
+// flowWindow represents how many batches client can send without waiting for acknowledgement,
+// it increases additively when batch is written quickly, and decreases multiplicatively when
+// write channel is blocked by replication back-pressure.
+type flowWindow struct {
+	size int
+	max  int
+}
+
+// newFlowWindow creates a flow window with max size.
+func newFlowWindow(max int) *flowWindow {
+	return &flowWindow{size: max, max: max}
+}
+
+// adjust adjusts the window size based on the write cost of batch(write timeout also means slow).
+func (w *flowWindow) adjust(cost time.Duration) {
+	if cost >= slowWriteThreshold {
+		w.size /= 2
+		if w.size < 1 {
+			w.size = 1
+		}
+		return
+	}
+	if w.size < w.max {
+		w.size++
+	}
+}
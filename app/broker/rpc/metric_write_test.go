@@ -0,0 +1,157 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/pkg/errcode"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoIngestV1 "github.com/lindb/lindb/proto/gen/v1/ingest"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func encodeRows(t *testing.T, names ...string) []byte {
+	var buf bytes.Buffer
+	converter := metric.NewProtoConverter()
+	for _, name := range names {
+		var brokerRow metric.BrokerRow
+		assert.NoError(t, converter.ConvertTo(&protoMetricsV1.Metric{
+			Name:      name,
+			Timestamp: timeutil.Now(),
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+		}, &brokerRow))
+		_, _ = brokerRow.WriteTo(&buf)
+	}
+	return buf.Bytes()
+}
+
+func TestMetricWriteHandler_Write(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	writer := NewMockRowWriter(ctrl)
+	stream := protoIngestV1.NewMockMetricWrite_WriteServer(ctrl)
+	h := NewMetricWriteHandler(4)
+
+	// case 1: writer not bound
+	err := h.Write(stream)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.True(t, errcode.IsRetriable(err))
+
+	h.Bind(writer)
+	// case 2: recv EOF
+	stream.EXPECT().Recv().Return(nil, io.EOF)
+	assert.NoError(t, h.Write(stream))
+	// case 3: recv err
+	stream.EXPECT().Recv().Return(nil, fmt.Errorf("err"))
+	assert.Equal(t, codes.Internal, status.Code(h.Write(stream)))
+	// case 4: handshake missing
+	stream.EXPECT().Recv().Return(&protoIngestV1.WriteRequest{}, nil)
+	err = h.Write(stream)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, errcode.InvalidArgument, errcode.CodeOf(err))
+	// case 5: send err
+	stream.EXPECT().Recv().Return(&protoIngestV1.WriteRequest{
+		Handshake: &protoIngestV1.Handshake{Database: "test"}}, nil)
+	stream.EXPECT().Send(&protoIngestV1.WriteResponse{Window: 4}).Return(fmt.Errorf("err"))
+	assert.Equal(t, codes.Internal, status.Code(h.Write(stream)))
+	// case 6: write batches
+	gomock.InOrder(
+		stream.EXPECT().Recv().Return(&protoIngestV1.WriteRequest{
+			Handshake: &protoIngestV1.Handshake{Database: "test", Namespace: "ns"},
+			Sequence:  1,
+			Rows:      encodeRows(t, "cpu", "mem"),
+		}, nil),
+		writer.EXPECT().WriteBatch("test", "", gomock.Any()).
+			DoAndReturn(func(_, _ string, rows *metric.BrokerBatchRows) error {
+				assert.Equal(t, 2, rows.Len())
+				return nil
+			}),
+		stream.EXPECT().Send(&protoIngestV1.WriteResponse{Sequence: 1, Accepted: 2, Window: 4}).Return(nil),
+		// write failure
+		stream.EXPECT().Recv().Return(&protoIngestV1.WriteRequest{
+			Sequence:       2,
+			Rows:           encodeRows(t, "cpu"),
+			IdempotencyKey: "token",
+		}, nil),
+		writer.EXPECT().WriteBatch("test", "token", gomock.Any()).Return(fmt.Errorf("err")),
+		stream.EXPECT().Send(&protoIngestV1.WriteResponse{Sequence: 2, Rejected: 1, Err: "err", Window: 4}).Return(nil),
+		// bad rows
+		stream.EXPECT().Recv().Return(&protoIngestV1.WriteRequest{Sequence: 3, Rows: []byte("bad")}, nil),
+		stream.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoIngestV1.WriteResponse) error {
+			assert.Equal(t, uint64(3), resp.Sequence)
+			assert.Zero(t, resp.Accepted)
+			assert.NotEmpty(t, resp.Err)
+			return nil
+		}),
+		stream.EXPECT().Recv().Return(nil, fmt.Errorf("err")),
+	)
+	assert.Equal(t, codes.Internal, status.Code(h.Write(stream)))
+}
+
+func TestMetricWriteHandler_Window(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		nowFn = time.Now
+		ctrl.Finish()
+	}()
+
+	writer := NewMockRowWriter(ctrl)
+	writer.EXPECT().WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	now := time.Now()
+	cost := time.Duration(0)
+	calls := 0
+	nowFn = func() time.Time {
+		calls++
+		if calls%2 == 0 {
+			return now.Add(cost)
+		}
+		return now
+	}
+	h := NewMetricWriteHandler(4)
+	window := newFlowWindow(h.maxWindow)
+	req := &protoIngestV1.WriteRequest{Rows: encodeRows(t, "cpu")}
+	// back-pressure, shrink window
+	cost = time.Second
+	assert.Equal(t, int32(2), h.writeBatch(writer, "test", "ns", req, window).Window)
+	assert.Equal(t, int32(1), h.writeBatch(writer, "test", "ns", req, window).Window)
+	assert.Equal(t, int32(1), h.writeBatch(writer, "test", "ns", req, window).Window)
+	// recover, grow window
+	cost = time.Millisecond
+	assert.Equal(t, int32(2), h.writeBatch(writer, "test", "ns", req, window).Window)
+	assert.Equal(t, int32(3), h.writeBatch(writer, "test", "ns", req, window).Window)
+	assert.Equal(t, int32(4), h.writeBatch(writer, "test", "ns", req, window).Window)
+	assert.Equal(t, int32(4), h.writeBatch(writer, "test", "ns", req, window).Window)
+	// empty batch, window not changed
+	assert.Equal(t, int32(4), h.writeBatch(writer, "test", "ns", &protoIngestV1.WriteRequest{}, window).Window)
+
+	assert.Equal(t, 1, NewMetricWriteHandler(0).maxWindow)
+}
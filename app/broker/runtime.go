@@ -19,6 +19,7 @@ package broker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
@@ -29,7 +30,10 @@ import (
 
 	"github.com/lindb/lindb/app"
 	"github.com/lindb/lindb/app/broker/api"
+	"github.com/lindb/lindb/app/broker/api/exec"
+	"github.com/lindb/lindb/app/broker/api/ingest"
 	"github.com/lindb/lindb/app/broker/deps"
+	brokerrpc "github.com/lindb/lindb/app/broker/rpc"
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator"
@@ -40,15 +44,22 @@ import (
 	"github.com/lindb/lindb/internal/server"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/auth"
 	"github.com/lindb/lindb/pkg/hostutil"
 	httppkg "github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/metering"
 	"github.com/lindb/lindb/pkg/state"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	protoIngestV1 "github.com/lindb/lindb/proto/gen/v1/ingest"
 	"github.com/lindb/lindb/query"
+	queryctx "github.com/lindb/lindb/query/context"
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
 )
 
@@ -60,14 +71,30 @@ var (
 	newRegistry            = discovery.NewRegistry
 	newRepositoryFactory   = state.NewRepositoryFactory
 	newGRPCServer          = rpc.NewGRPCServer
+	newTLSProvider         = rpc.NewTLSProvider
 	newTaskClientFactory   = rpc.NewTaskClientFactory
 	newStateManager        = broker.NewStateManager
 	newChannelManager      = replica.NewChannelManager
 	newMasterController    = coordinator.NewMasterController
 	newHTTPServer          = httppkg.NewServer
 	serveGRPCFn            = serveGRPC
+	initTracingFn          = tracing.Init
 )
 
+var errBrokerNotRunning = errors.New("broker is not running")
+
+// EmbeddedBroker represents the broker runtime embedded in process(e.g. integration tests of other services),
+// writes/queries through go api instead of http.
+type EmbeddedBroker interface {
+	server.Service
+	// Write writes rows into database through ingestion pipeline(normalization/ingestion limits/schema enforcement).
+	Write(ctx context.Context, database string, rows *metric.BrokerBatchRows) error
+	// Execute executes lin query language, returns nil result if not found.
+	Execute(ctx context.Context, param *models.ExecuteParam) (interface{}, error)
+	// FlushWrite flushes the rows buffered in write channels, blocks until acknowledged by storage.
+	FlushWrite(ctx context.Context) error
+}
+
 // srv represents all services for broker
 type srv struct {
 	channelManager   replica.ChannelManager
@@ -83,7 +110,8 @@ type factory struct {
 }
 
 type rpcHandler struct {
-	handler *query.TaskHandler
+	handler     *query.TaskHandler
+	metricWrite *brokerrpc.MetricWriteHandler
 }
 
 // runtime represents broker runtime dependency
@@ -103,10 +131,18 @@ type runtime struct {
 	registry            discovery.Registry
 	stateMachineFactory discovery.StateMachineFactory
 	stateMgr            broker.StateManager
+	recordingRules      query.RecordingRuleEvaluator
+	alertRules          query.AlertRuleEvaluator
+	meter               metering.Meter
+	httpDeps            *deps.HTTPDeps // dependencies of api, nil before http server started
+	ingestion           *ingest.Write
 
-	grpcServer rpc.GRPCServer
-	rpcHandler *rpcHandler
-	queryPool  concurrent.Pool
+	grpcServer  rpc.GRPCServer
+	tlsProvider rpc.TLSProvider
+	rpcHandler  *rpcHandler
+	queryPool   concurrent.Pool
+
+	shutdownTracing tracing.Shutdown
 
 	ctx                 context.Context
 	cancel              context.CancelFunc
@@ -179,6 +215,15 @@ func (r *runtime) Run() error {
 		{Key: []byte("role"), Value: []byte(constants.BrokerRole)},
 	}
 	r.BaseRuntime = app.NewBaseRuntimeFn(r.ctx, r.config.Monitor, linmetric.BrokerRegistry, r.globalKeyValues)
+	// init transport security of intra-cluster grpc before any connection created
+	if err = r.initGRPCTLS(); err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("init grpc tls failure, err: %s", err)
+	}
+	if err = r.initTracing(); err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("init tracing failure, err: %s", err)
+	}
 
 	tackClientFct := newTaskClientFactory(r.ctx, r.node, rpc.GetBrokerClientConnFactory())
 	r.factory = factory{
@@ -207,6 +252,7 @@ func (r *runtime) Run() error {
 		TTL:              int64(r.config.Coordinator.LeaseTTL.Duration().Seconds()),
 		DiscoveryFactory: discoveryFactory,
 		RepoFactory:      r.repoFactory,
+		DropGracePeriod:  r.config.Master.DropGracePeriod.Duration(),
 	}
 	r.master = newMasterController(masterCfg)
 
@@ -253,8 +299,13 @@ func (r *runtime) Run() error {
 	}
 	r.logger.Info("broker state machine started successfully")
 
+	authorizer, err := r.newAuthorizer()
+	if err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("init http auth failure, err: %s", err)
+	}
 	// start http server
-	r.startHTTPServer()
+	r.startHTTPServer(authorizer)
 
 	if r.enableSystemMonitor {
 		// start system collector
@@ -287,6 +338,11 @@ func (r *runtime) Stop() {
 			r.logger.Info("stopped http server successfully")
 		}
 	}
+	if r.meter != nil {
+		// flush the remaining usage before channel manager closed
+		r.logger.Info("stopping usage meter...")
+		r.meter.Stop()
+	}
 
 	// close registry, deregister broker node from active list
 	if r.registry != nil {
@@ -301,6 +357,15 @@ func (r *runtime) Stop() {
 		}
 	}
 
+	if r.recordingRules != nil {
+		r.logger.Info("stopping recording rules evaluator...")
+		r.recordingRules.Stop()
+	}
+	if r.alertRules != nil {
+		r.logger.Info("stopping alert rules evaluator...")
+		r.alertRules.Stop()
+	}
+
 	if r.master != nil {
 		r.logger.Info("stopping master...")
 		r.master.Stop()
@@ -310,6 +375,15 @@ func (r *runtime) Stop() {
 		r.stateMachineFactory.Stop()
 	}
 
+	if r.shutdownTracing != nil {
+		// flush the pending spans of query
+		if err := r.shutdownTracing(r.ctx); err != nil {
+			r.logger.Error("shutdown tracing error", logger.Error(err))
+		}
+	}
+
+	// close auditor before state repo
+	audit.CloseAuditor()
 	if r.repo != nil {
 		r.logger.Info("closing state repo...")
 		if err := r.repo.Close(); err != nil {
@@ -348,11 +422,24 @@ func (r *runtime) Stop() {
 }
 
 // startHTTPServer starts http server for api rpcHandler
-func (r *runtime) startHTTPServer() {
+func (r *runtime) startHTTPServer(authorizer auth.Authorizer) {
 	r.logger.Info("starting HTTP server")
 	r.httpServer = newHTTPServer(r.config.BrokerBase.HTTP, true, linmetric.BrokerRegistry)
+	var resultCache query.ResultCache
+	if r.config.BrokerBase.Query.EnableResultCache {
+		resultCache = query.NewResultCache(
+			r.config.BrokerBase.Query.ResultCacheTTL.Duration(),
+			int64(r.config.BrokerBase.Query.ResultCacheMaxSize),
+			linmetric.BrokerRegistry,
+		)
+	}
+	// collect replication lag for failing over query to the most caught-up follower
+	replicaLag := broker.NewReplicaLagTracker(r.ctx, r.config.BrokerBase.Query.ReplicaLagInterval.Duration(), r.stateMgr)
+	replicaLag.Start()
+	// collect ingestion lag of storage families as database level data freshness
+	broker.NewIngestionLagTracker(r.ctx, r.config.BrokerBase.Query.ReplicaLagInterval.Duration(), r.stateMgr).Start()
 	// TODO login api is not registered
-	httpAPI := api.NewAPI(&deps.HTTPDeps{
+	httpDeps := &deps.HTTPDeps{
 		Ctx:          r.ctx,
 		Node:         r.node,
 		BrokerCfg:    r.config,
@@ -375,10 +462,80 @@ func (r *runtime) startHTTPServer() {
 			r.config.Query.Timeout.Duration(),
 			metrics.NewLimitStatistics("query", linmetric.BrokerRegistry),
 		),
+		SlowQueryLog: query.NewSlowQueryLog(
+			r.config.Query.SlowQueryThreshold.Duration(),
+			r.config.Query.SlowQueryLogSize,
+			linmetric.BrokerRegistry,
+		),
+		ResultCache:     resultCache,
+		ReplicaLag:      replicaLag,
+		Hedger:          queryctx.NewHedger(r.config.BrokerBase.Query.Hedge, linmetric.BrokerRegistry),
+		Authorizer:      authorizer,
 		GlobalKeyValues: r.globalKeyValues,
-	})
+	}
+	if meteringCfg := r.config.BrokerBase.Metering; meteringCfg.FlushInterval > 0 {
+		// usage is written back into internal database through ingestion path
+		r.meter = metering.NewMeter(r.ctx, meteringCfg.FlushInterval.Duration(), meteringCfg.Database,
+			r.node.Indicator(), ingest.NewWrite(httpDeps))
+		r.meter.Start()
+		httpDeps.Meter = r.meter
+	}
+	if r.rpcHandler != nil {
+		// accept grpc streaming write via the same ingestion pipeline
+		r.rpcHandler.metricWrite.Bind(ingest.NewWrite(httpDeps))
+	}
+	httpAPI := api.NewAPI(httpDeps)
 	httpAPI.RegisterRouter(r.httpServer.GetAPIRouter())
+	r.httpDeps = httpDeps
+	r.ingestion = ingest.NewWrite(httpDeps)
 	go r.runHTTPServer()
+
+	r.startRuleEvaluators(httpDeps)
+}
+
+// startRuleEvaluators starts evaluating recording/alert rules, rules are evaluated only when current node is master,
+// the result of recording rule is written back through ingestion path, alerts are notified to webhook.
+func (r *runtime) startRuleEvaluators(httpDeps *deps.HTTPDeps) {
+	searchMgr := &query.SearchMgr{
+		Timeout:           r.config.Query.Timeout.Duration(),
+		CurNode:           *r.node,
+		Choose:            r.stateMgr,
+		TaskMgr:           r.srv.taskManager,
+		TransportMgr:      r.srv.transportManager,
+		MaxBufferedSeries: r.config.BrokerBase.Query.MaxBufferedSeries,
+		Memory:            r.config.BrokerBase.Query.Memory,
+		ReplicaLag:        httpDeps.ReplicaLag,
+		Hedger:            httpDeps.Hedger,
+	}
+	r.recordingRules = query.NewRecordingRuleEvaluator(r.ctx, r.repo, r.master.IsMaster, searchMgr, ingest.NewWrite(httpDeps))
+	r.recordingRules.Start()
+	r.alertRules = query.NewAlertRuleEvaluator(r.ctx, r.repo, r.master.IsMaster, searchMgr,
+		query.NewAlertNotifier(linmetric.BrokerRegistry))
+	r.alertRules.Start()
+}
+
+// Write writes rows into database through ingestion pipeline(normalization/ingestion limits/schema enforcement).
+func (r *runtime) Write(ctx context.Context, database string, rows *metric.BrokerBatchRows) error {
+	if r.ingestion == nil {
+		return errBrokerNotRunning
+	}
+	return r.ingestion.WriteRows(ctx, database, rows)
+}
+
+// Execute executes lin query language, returns nil result if not found.
+func (r *runtime) Execute(ctx context.Context, param *models.ExecuteParam) (interface{}, error) {
+	if r.httpDeps == nil {
+		return nil, errBrokerNotRunning
+	}
+	return exec.Execute(ctx, r.httpDeps, param)
+}
+
+// FlushWrite flushes the rows buffered in write channels, blocks until acknowledged by storage.
+func (r *runtime) FlushWrite(ctx context.Context) error {
+	if r.srv.channelManager == nil {
+		return errBrokerNotRunning
+	}
+	return r.srv.channelManager.Flush(ctx)
 }
 
 // runHTTPServer runs http server.
@@ -397,6 +554,7 @@ func (r *runtime) startStateRepo() error {
 		return fmt.Errorf("start broker state repository error:%s", err)
 	}
 	r.repo = repo
+	audit.InitAuditor(r.node.Indicator(), &r.config.Logging, repo, linmetric.BrokerRegistry)
 	r.logger.Info("start broker state repository successfully")
 	return nil
 }
@@ -418,10 +576,52 @@ func (r *runtime) buildServiceDependency() {
 	r.srv = s
 }
 
+// initTracing initializes the tracer provider which exports the spans of query.
+// NOTE: in standalone mode, the tracer provider is shared by broker and storage.
+func (r *runtime) initTracing() (err error) {
+	serviceName := "lindb-broker"
+	if config.StandaloneMode {
+		serviceName = "lindb-standalone"
+	}
+	r.shutdownTracing, err = initTracingFn(r.ctx, r.config.Query.Tracing,
+		r.config.Query.SlowQueryThreshold.Duration(), serviceName, r.node.Indicator())
+	return err
+}
+
+// newAuthorizer creates the authorizer of http api if auth enabled, returns nil if auth disabled.
+func (r *runtime) newAuthorizer() (auth.Authorizer, error) {
+	authCfg := r.config.BrokerBase.Auth
+	if !authCfg.Enabled {
+		return nil, nil
+	}
+	tokens, err := authCfg.ParseTokens()
+	if err != nil {
+		return nil, err
+	}
+	r.logger.Info("http api token authentication enabled", logger.Int32("tokens", int32(len(tokens))))
+	return auth.NewAuthorizer(authCfg, r.stateMgr, auth.NewStaticTokenValidator(tokens)), nil
+}
+
+// initGRPCTLS initializes transport security of intra-cluster grpc server/clients if tls enabled.
+func (r *runtime) initGRPCTLS() error {
+	tlsCfg := r.config.BrokerBase.GRPC.TLS
+	if !tlsCfg.Enabled {
+		return nil
+	}
+	tlsProvider, err := newTLSProvider(r.ctx, tlsCfg, linmetric.BrokerRegistry)
+	if err != nil {
+		return err
+	}
+	r.tlsProvider = tlsProvider
+	rpc.GetBrokerClientConnFactory().SetTLSProvider(tlsProvider)
+	r.logger.Info("grpc tls enabled", logger.String("clientAuth", tlsCfg.ClientAuth))
+	return nil
+}
+
 // startGRPCServer starts the GRPC server
 func (r *runtime) startGRPCServer() {
 	r.logger.Info("starting GRPC server")
-	r.grpcServer = newGRPCServer(r.config.BrokerBase.GRPC, linmetric.BrokerRegistry)
+	r.grpcServer = newGRPCServer(r.config.BrokerBase.GRPC, linmetric.BrokerRegistry, r.tlsProvider)
 
 	// bind grpc handlers
 	r.rpcHandler = &rpcHandler{
@@ -432,9 +632,12 @@ func (r *runtime) startGRPCServer() {
 				r.stateMgr, r.srv.taskManager, r.srv.transportManager),
 			r.queryPool,
 		),
+		// bound with ingestion writer when http server started(broker state machine ready)
+		metricWrite: brokerrpc.NewMetricWriteHandler(r.config.BrokerBase.Ingestion.MaxWriteWindow),
 	}
 
 	protoCommonV1.RegisterTaskServiceServer(r.grpcServer.GetServer(), r.rpcHandler.handler)
+	protoIngestV1.RegisterMetricWriteServer(r.grpcServer.GetServer(), r.rpcHandler.metricWrite)
 
 	go serveGRPCFn(r.grpcServer)
 }
@@ -28,19 +28,25 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/app/broker/api/ingest"
+	"github.com/lindb/lindb/app/broker/deps"
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/coordinator"
 	brokerpkg "github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/internal/server"
+	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/hostutil"
 	httppkg "github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/tracing"
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/series/metric"
 )
 
 var cfg = config.Broker{
@@ -75,6 +81,47 @@ func TestBrokerRuntime_New(t *testing.T) {
 	assert.Equal(t, "broker", r.Name())
 }
 
+func TestBrokerRuntime_Embedded(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, ok := NewBrokerRuntime("version", &cfg, false).(EmbeddedBroker)
+	assert.True(t, ok)
+	// not running
+	assert.ErrorIs(t, r.Write(context.TODO(), "db", metric.NewBrokerBatchRows()), errBrokerNotRunning)
+	_, err := r.Execute(context.TODO(), &models.ExecuteParam{SQL: "show master"})
+	assert.ErrorIs(t, err, errBrokerNotRunning)
+	assert.ErrorIs(t, r.FlushWrite(context.TODO()), errBrokerNotRunning)
+
+	cm := replica.NewMockChannelManager(ctrl)
+	stateMgr := brokerpkg.NewMockStateManager(ctrl)
+	master := coordinator.NewMockMasterController(ctrl)
+	httpDeps := &deps.HTTPDeps{
+		Ctx:       context.TODO(),
+		BrokerCfg: &cfg,
+		CM:        cm,
+		StateMgr:  stateMgr,
+		Master:    master,
+		QueryLimiter: concurrent.NewLimiter(context.TODO(), 1, time.Second,
+			metrics.NewLimitStatistics("embedded", linmetric.BrokerRegistry)),
+	}
+	r1 := r.(*runtime)
+	r1.httpDeps = httpDeps
+	r1.ingestion = ingest.NewWrite(httpDeps)
+	r1.srv.channelManager = cm
+
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{}, false)
+	stateMgr.EXPECT().GetDatabaseSchema("db").Return(nil, false)
+	cm.EXPECT().Write(gomock.Any(), "db", gomock.Any()).Return(nil)
+	assert.NoError(t, r.Write(context.TODO(), "db", metric.NewBrokerBatchRows()))
+	master.EXPECT().GetMaster().Return(&models.Master{ElectTime: 1})
+	rs, err := r.Execute(context.TODO(), &models.ExecuteParam{SQL: "show master"})
+	assert.NoError(t, err)
+	assert.Equal(t, &models.Master{ElectTime: 1}, rs)
+	cm.EXPECT().Flush(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, r.FlushWrite(context.TODO()))
+}
+
 func TestBrokerRuntime_Run(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -327,6 +374,93 @@ func TestBrokerRuntime_startGrpcServer(t *testing.T) {
 		serveGRPC(grpcServer)
 	})
 }
+
+func TestBrokerRuntime_initGRPCTLS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newTLSProvider = rpc.NewTLSProvider
+		rpc.GetBrokerClientConnFactory().SetTLSProvider(nil)
+		ctrl.Finish()
+	}()
+
+	tlsCfg := cfg
+	r := &runtime{
+		ctx:    context.TODO(),
+		config: &tlsCfg,
+		logger: logger.GetLogger("Runtime", "Test"),
+	}
+	// tls disabled
+	assert.NoError(t, r.initGRPCTLS())
+	assert.Nil(t, r.tlsProvider)
+
+	tlsCfg.BrokerBase.GRPC.TLS.Enabled = true
+	newTLSProvider = func(_ context.Context, _ config.TLS, _ *linmetric.Registry) (rpc.TLSProvider, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, r.initGRPCTLS())
+	assert.Nil(t, r.tlsProvider)
+
+	tlsProvider := rpc.NewMockTLSProvider(ctrl)
+	newTLSProvider = func(_ context.Context, _ config.TLS, _ *linmetric.Registry) (rpc.TLSProvider, error) {
+		return tlsProvider, nil
+	}
+	assert.NoError(t, r.initGRPCTLS())
+	assert.Equal(t, tlsProvider, r.tlsProvider)
+}
+
+func TestBrokerRuntime_initTracing(t *testing.T) {
+	defer func() {
+		initTracingFn = tracing.Init
+		config.StandaloneMode = false
+	}()
+
+	r := &runtime{
+		ctx:    context.TODO(),
+		config: &cfg,
+		node:   &models.StatelessNode{HostIP: "127.0.0.1", GRPCPort: 9000},
+		logger: logger.GetLogger("Runtime", "Test"),
+	}
+	// tracing disabled
+	assert.NoError(t, r.initTracing())
+	assert.NoError(t, r.shutdownTracing(context.TODO()))
+
+	var serviceName string
+	initTracingFn = func(_ context.Context, _ config.Tracing, _ time.Duration, name, _ string) (tracing.Shutdown, error) {
+		serviceName = name
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, r.initTracing())
+	assert.Equal(t, "lindb-broker", serviceName)
+	config.StandaloneMode = true
+	assert.Error(t, r.initTracing())
+	assert.Equal(t, "lindb-standalone", serviceName)
+}
+
+func TestBrokerRuntime_newAuthorizer(t *testing.T) {
+	authCfg := cfg
+	r := &runtime{
+		ctx:    context.TODO(),
+		config: &authCfg,
+		logger: logger.GetLogger("Runtime", "Test"),
+	}
+	// auth disabled
+	authorizer, err := r.newAuthorizer()
+	assert.NoError(t, err)
+	assert.Nil(t, authorizer)
+
+	authCfg.BrokerBase.Auth = config.Auth{Enabled: true, Tokens: []string{"bad-token"}}
+	authorizer, err = r.newAuthorizer()
+	assert.Error(t, err)
+	assert.Nil(t, authorizer)
+
+	authCfg.BrokerBase.Auth = config.Auth{Enabled: true, Tokens: []string{"alice:token"}}
+	authorizer, err = r.newAuthorizer()
+	assert.NoError(t, err)
+	principal, err := authorizer.Authenticate("token")
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", principal)
+}
+
 func TestBrokerRuntime_RunHTTPServer(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -0,0 +1,271 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package embedded
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/app/standalone"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb"
+)
+
+// for testing
+var (
+	retryInterval = 100 * time.Millisecond
+)
+
+// Options represents the options of embedded standalone cluster.
+type Options struct {
+	// Dir is the root dir of data/wal/coordinator/log, uses temp dir of test if empty(removed after test).
+	Dir string
+	// StartTimeout is the timeout of waiting cluster/database ready, default: 1 minute.
+	StartTimeout time.Duration
+	// Configure customizes standalone config before cluster starting(dirs/ports are already assigned).
+	Configure func(cfg *config.Standalone)
+}
+
+// Standalone represents the embedded standalone cluster(broker+storage) running in process,
+// writes/queries data through go api instead of http.
+type Standalone struct {
+	t       testing.TB
+	cfg     *config.Standalone
+	runtime standalone.EmbeddedRuntime
+	timeout time.Duration
+}
+
+// StartStandalone starts the standalone cluster with temp dirs and random ports,
+// the cluster is stopped and all process-global states are reset when test finished.
+func StartStandalone(t testing.TB, opts Options) *Standalone {
+	t.Helper()
+	if opts.Dir == "" {
+		opts.Dir = t.TempDir()
+	}
+	if opts.StartTimeout <= 0 {
+		opts.StartTimeout = time.Minute
+	}
+	cfg := newStandaloneConfig(t, opts.Dir)
+	if opts.Configure != nil {
+		opts.Configure(cfg)
+	}
+
+	// keep the global states for restoring after test
+	standaloneMode := config.StandaloneMode
+	brokerCfg := config.GlobalBrokerConfig()
+	storageCfg := config.GlobalStorageConfig()
+	config.SetGlobalBrokerConfig(&cfg.BrokerBase)
+	config.SetGlobalStorageConfig(&cfg.StorageBase)
+
+	s := &Standalone{
+		t:       t,
+		cfg:     cfg,
+		runtime: standalone.NewEmbeddedRuntime(config.Version, cfg),
+		timeout: opts.StartTimeout,
+	}
+	t.Cleanup(func() {
+		s.runtime.Stop()
+		// reset singletons, so that the next cluster can be started in same process
+		tsdb.ResetFamilyManager()
+		tsdb.ResetMetricUsageTracker()
+		kv.InitStoreManager(nil)
+
+		config.StandaloneMode = standaloneMode
+		config.SetGlobalBrokerConfig(brokerCfg)
+		config.SetGlobalStorageConfig(storageCfg)
+	})
+	if err := s.runtime.Run(); err != nil {
+		t.Fatalf("start embedded standalone cluster failure: %s", err)
+	}
+	return s
+}
+
+// Config returns the config of standalone cluster.
+func (s *Standalone) Config() *config.Standalone {
+	return s.cfg
+}
+
+// Runtime returns the embedded standalone runtime.
+func (s *Standalone) Runtime() standalone.EmbeddedRuntime {
+	return s.runtime
+}
+
+// CreateDatabase creates the database after storage cluster is alive,
+// storage's name is set by cluster's namespace if empty.
+func (s *Standalone) CreateDatabase(database models.Database) {
+	s.t.Helper()
+	if database.Storage == "" {
+		database.Storage = s.cfg.Coordinator.Namespace
+	}
+	if database.NumOfShard <= 0 {
+		database.NumOfShard = 1
+	}
+	if database.ReplicaFactor <= 0 {
+		database.ReplicaFactor = 1
+	}
+	data, err := json.Marshal(&database)
+	if err != nil {
+		s.t.Fatalf("marshal database config failure: %s", err)
+	}
+	// master creates shard assignment when database config changed, so wait storage cluster is alive first
+	s.retry(fmt.Sprintf("wait storage [%s] alive", database.Storage), func() error {
+		rs, err := s.Execute("", "show storage alive")
+		if err != nil {
+			return err
+		}
+		storages, _ := rs.([]*models.StorageState)
+		for _, storage := range storages {
+			if storage.Name == database.Storage && len(storage.LiveNodes) > 0 {
+				return nil
+			}
+		}
+		return fmt.Errorf("storage [%s] not alive", database.Storage)
+	})
+	sql := "create database " + strings.ReplaceAll(string(data), `"`, `\"`)
+	s.retry(fmt.Sprintf("create database [%s]", database.Name), func() error {
+		_, err := s.Execute("", sql)
+		return err
+	})
+}
+
+// Write writes metrics into database through broker's ingestion pipeline,
+// waits until the write channel of database is created.
+func (s *Standalone) Write(database string, metrics ...*protoMetricsV1.Metric) {
+	s.t.Helper()
+	notFound := fmt.Sprintf("database [%s] not found", database)
+	s.retry(fmt.Sprintf("write database [%s]", database), func() error {
+		err := s.write(database, metrics)
+		if err != nil && err.Error() != notFound {
+			s.t.Fatalf("write database [%s] failure: %s", database, err)
+		}
+		return err
+	})
+}
+
+// write writes metrics into database.
+func (s *Standalone) write(database string, metrics []*protoMetricsV1.Metric) error {
+	rows := metric.NewBrokerBatchRows()
+	defer rows.Release()
+	converter, releaseFunc := metric.NewBrokerRowProtoConverter(nil, nil)
+	defer releaseFunc(converter)
+
+	for _, m := range metrics {
+		if err := rows.TryAppend(func(row *metric.BrokerRow) error {
+			return converter.ConvertTo(m, row)
+		}); err != nil {
+			return err
+		}
+	}
+	return s.runtime.Broker().Write(context.TODO(), database, rows)
+}
+
+// Query executes the metric query(select statement), returns nil if not found.
+func (s *Standalone) Query(database, sql string) *models.ResultSet {
+	s.t.Helper()
+	rs, err := s.Execute(database, sql)
+	if err != nil {
+		s.t.Fatalf("query database [%s] failure: %s, sql: %s", database, err, sql)
+	}
+	if rs == nil {
+		return nil
+	}
+	return rs.(*models.ResultSet)
+}
+
+// Execute executes lin query language, returns nil result if not found.
+func (s *Standalone) Execute(database, sql string) (interface{}, error) {
+	return s.runtime.Broker().Execute(context.TODO(), &models.ExecuteParam{
+		Database: database,
+		SQL:      sql,
+	})
+}
+
+// ForceFlushAll flushes the rows buffered in broker, then flushes memory data of storage to disk,
+// so that the queries read data from files after flushed.
+func (s *Standalone) ForceFlushAll() {
+	s.t.Helper()
+	ctx, cancel := context.WithTimeout(context.TODO(), s.timeout)
+	defer cancel()
+	if err := s.runtime.Broker().FlushWrite(ctx); err != nil {
+		s.t.Fatalf("flush broker write failure: %s", err)
+	}
+	if err := s.runtime.Storage().ForceFlush(ctx); err != nil {
+		s.t.Fatalf("force flush storage failure: %s", err)
+	}
+}
+
+// retry retries the action until success or timeout.
+func (s *Standalone) retry(action string, fn func() error) {
+	s.t.Helper()
+	deadline := time.Now().Add(s.timeout)
+	for {
+		err := fn()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			s.t.Fatalf("%s timeout, last error: %s", action, err)
+		}
+		time.Sleep(retryInterval)
+	}
+}
+
+// newStandaloneConfig creates the standalone config with dirs under given dir and random ports.
+func newStandaloneConfig(t testing.TB, dir string) *config.Standalone {
+	cfg := config.NewDefaultStandalone()
+	cfg.ETCD.Dir = filepath.Join(dir, "coordinator")
+	cfg.ETCD.URL = fmt.Sprintf("http://127.0.0.1:%d", freePort(t))
+	cfg.Coordinator.Endpoints = []string{cfg.ETCD.URL}
+	cfg.BrokerBase.HTTP.Port = freePort(t)
+	cfg.BrokerBase.GRPC.Port = freePort(t)
+	cfg.StorageBase.HTTP.Port = freePort(t)
+	cfg.StorageBase.GRPC.Port = freePort(t)
+	cfg.StorageBase.BrokerEndpoint = fmt.Sprintf("http://127.0.0.1:%d", cfg.BrokerBase.HTTP.Port)
+	cfg.StorageBase.TSDB.Dir = filepath.Join(dir, "data")
+	cfg.StorageBase.WAL.Dir = filepath.Join(dir, "wal")
+	cfg.StorageBase.DeadLetter.Dir = filepath.Join(dir, "dead-letter")
+	cfg.StorageBase.ReplicaBootstrap.Dir = filepath.Join(dir, "bootstrap")
+	cfg.Logging.Dir = filepath.Join(dir, "log")
+	// disable native metric pusher
+	cfg.Monitor.ReportInterval = ltoml.Duration(0)
+	return &cfg
+}
+
+// freePort returns a free tcp port of localhost.
+func freePort(t testing.TB) uint16 {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("pick free port failure: %s", err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+	return uint16(l.Addr().(*net.TCPAddr).Port)
+}
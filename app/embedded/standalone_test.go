@@ -0,0 +1,79 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build integration
+// +build integration
+
+package embedded
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+func TestStandalone_RoundTrip(t *testing.T) {
+	// start twice in same process, make sure that global states are reset after stopped
+	for i := 0; i < 2; i++ {
+		t.Run("round-trip-"+strconv.Itoa(i), func(t *testing.T) {
+			s := StartStandalone(t, Options{})
+			s.CreateDatabase(models.Database{
+				Name: "embedded",
+				Option: &option.DatabaseOption{
+					Intervals: option.Intervals{{
+						Interval:  timeutil.Interval(10 * timeutil.OneSecond),
+						Retention: timeutil.Interval(timeutil.OneMonth),
+					}},
+				},
+			})
+			now := timeutil.Now()
+			for j := 0; j < 10; j++ {
+				s.Write("embedded", &protoMetricsV1.Metric{
+					Name:      "cpu",
+					Timestamp: now,
+					Tags: []*protoMetricsV1.KeyValue{
+						{Key: "host", Value: "host" + strconv.Itoa(j)},
+					},
+					SimpleFields: []*protoMetricsV1.SimpleField{
+						{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1},
+					},
+				})
+			}
+			// read from files after flushed
+			s.ForceFlushAll()
+			assertSum(t, s.Query("embedded", "select f1 from cpu where time>now()-1h"), 10)
+		})
+	}
+}
+
+func assertSum(t *testing.T, rs *models.ResultSet, expect float64) {
+	if !assert.NotNil(t, rs) || !assert.Len(t, rs.Series, 1) {
+		return
+	}
+	sum := 0.0
+	for _, v := range rs.Series[0].Fields["f1"] {
+		sum += v
+	}
+	assert.Equal(t, expect, sum)
+}
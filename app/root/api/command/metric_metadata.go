@@ -35,6 +35,10 @@ var (
 func MetricMetadataCommand(ctx context.Context, deps *depspkg.HTTPDeps,
 	param *models.ExecuteParam, stmt stmtpkg.Statement) (interface{}, error) {
 	statement := stmt.(*stmtpkg.MetricMetadata)
+	if param != nil && param.Limit > 0 {
+		// limit of request param overrides the limit of statement
+		statement.Limit = param.Limit
+	}
 	return metricMetadataSearchWithResultFn(
 		ctx,
 		param,
@@ -44,5 +44,6 @@ func QueryCommand(ctx context.Context, deps *depspkg.HTTPDeps,
 			Choose:       deps.StateMgr,
 			TaskMgr:      deps.TaskMgr,
 			TransportMgr: deps.TransportMgr,
+			Memory:       deps.Cfg.QueryMemory,
 		})
 }
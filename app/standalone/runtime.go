@@ -47,6 +47,16 @@ var (
 
 var log = logger.GetLogger("Standalone", "Runtime")
 
+// EmbeddedRuntime represents the standalone runtime embedded in process(e.g. integration tests of other services),
+// exposes broker/storage runtime for writing/querying through go api.
+type EmbeddedRuntime interface {
+	server.Service
+	// Broker returns the embedded broker runtime.
+	Broker() broker.EmbeddedBroker
+	// Storage returns the embedded storage runtime.
+	Storage() storage.EmbeddedStorage
+}
+
 // runtime represents the runtime dependency of standalone mode
 type runtime struct {
 	version   string
@@ -80,6 +90,7 @@ func NewStandaloneRuntime(version string, cfg *config.Standalone, embedEtcd bool
 			&config.Broker{
 				Query:       cfg.Query,
 				Coordinator: cfg.Coordinator,
+				Master:      cfg.Master,
 				BrokerBase:  cfg.BrokerBase,
 				Monitor:     cfg.Monitor,
 				Logging:     cfg.Logging,
@@ -100,6 +111,21 @@ func NewStandaloneRuntime(version string, cfg *config.Standalone, embedEtcd bool
 	}
 }
 
+// NewEmbeddedRuntime creates the standalone runtime with embed etcd which can be embedded in process.
+func NewEmbeddedRuntime(version string, cfg *config.Standalone) EmbeddedRuntime {
+	return NewStandaloneRuntime(version, cfg, true).(*runtime)
+}
+
+// Broker returns the embedded broker runtime.
+func (r *runtime) Broker() broker.EmbeddedBroker {
+	return r.broker.(broker.EmbeddedBroker)
+}
+
+// Storage returns the embedded storage runtime.
+func (r *runtime) Storage() storage.EmbeddedStorage {
+	return r.storage.(storage.EmbeddedStorage)
+}
+
 // Name returns the cluster mode
 func (r *runtime) Name() string {
 	return "standalone"
@@ -67,6 +67,18 @@ func TestRuntime_New(t *testing.T) {
 	assert.Equal(t, "standalone", standalone.Name())
 }
 
+func TestRuntime_Embedded(t *testing.T) {
+	defer func() {
+		assert.NoError(t, fileutil.RemoveDir("data"))
+	}()
+	cfg := newDefaultStandaloneConfig(t)
+	standalone := NewEmbeddedRuntime("test-version", &cfg)
+	assert.NotNil(t, standalone.Broker())
+	assert.NotNil(t, standalone.Storage())
+	assert.Error(t, standalone.Broker().FlushWrite(context.TODO()))
+	assert.Error(t, standalone.Storage().ForceFlush(context.TODO()))
+}
+
 func TestRuntime_Run(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
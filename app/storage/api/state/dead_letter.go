@@ -0,0 +1,104 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/pkg/audit"
+	httppkg "github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/tsdb"
+)
+
+const (
+	DeadLetterPath       = "/state/dead-letter"
+	RecentDeadLetterPath = "/state/dead-letter/recent"
+	AckDeadLetterPath    = "/state/dead-letter/ack"
+)
+
+// DeadLetterAPI represents the api which samples/reads/acknowledges the rows rejected by write path.
+type DeadLetterAPI struct {
+	getSink func() tsdb.DeadLetterSink
+}
+
+// NewDeadLetterAPI creates a DeadLetterAPI instance.
+func NewDeadLetterAPI() *DeadLetterAPI {
+	return &DeadLetterAPI{
+		getSink: tsdb.GetDeadLetterSink,
+	}
+}
+
+// Register adds dead-letter api route.
+func (api *DeadLetterAPI) Register(route gin.IRoutes) {
+	route.GET(RecentDeadLetterPath, api.Recent)
+	route.GET(DeadLetterPath, api.Read)
+	route.PUT(AckDeadLetterPath, api.Ack)
+}
+
+// Recent returns the recent dead-letters(newest first), for sampling bad data.
+func (api *DeadLetterAPI) Recent(c *gin.Context) {
+	var param struct {
+		Limit int `form:"limit"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, api.getSink().Recent(param.Limit))
+}
+
+// Read returns the dead-letters in queue starting with given sequence, for replaying.
+func (api *DeadLetterAPI) Read(c *gin.Context) {
+	var param struct {
+		From  int64 `form:"from"`
+		Limit int   `form:"limit"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	rs, err := api.getSink().Read(param.From, param.Limit)
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, rs)
+}
+
+// Ack acknowledges the dead-letters before sequence(include) after replaying.
+func (api *DeadLetterAPI) Ack(c *gin.Context) {
+	var param struct {
+		Seq int64 `form:"seq" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	startTime := time.Now()
+	err := api.getSink().Ack(param.Seq)
+	audit.GetAuditor().Record(c.Request.Context(), audit.OpAckDeadLetter,
+		map[string]string{"seq": strconv.FormatInt(param.Seq, 10)}, startTime, err)
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.NoContent(c)
+}
@@ -0,0 +1,127 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestDeadLetterAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sink := tsdb.NewMockDeadLetterSink(ctrl)
+	api := NewDeadLetterAPI()
+	api.getSink = func() tsdb.DeadLetterSink {
+		return sink
+	}
+	r := gin.New()
+	api.Register(r)
+
+	cases := []struct {
+		name    string
+		method  string
+		path    string
+		prepare func()
+		code    int
+	}{
+		{
+			name:   "recent, param invalid",
+			method: http.MethodGet,
+			path:   RecentDeadLetterPath + "?limit=a",
+			code:   http.StatusInternalServerError,
+		},
+		{
+			name:   "recent dead-letters",
+			method: http.MethodGet,
+			path:   RecentDeadLetterPath + "?limit=10",
+			prepare: func() {
+				sink.EXPECT().Recent(10).Return([]*models.DeadLetter{{Database: "db"}})
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "read, param invalid",
+			method: http.MethodGet,
+			path:   DeadLetterPath + "?from=a",
+			code:   http.StatusInternalServerError,
+		},
+		{
+			name:   "read failure",
+			method: http.MethodGet,
+			path:   DeadLetterPath + "?from=1&limit=10",
+			prepare: func() {
+				sink.EXPECT().Read(int64(1), 10).Return(nil, fmt.Errorf("err"))
+			},
+			code: http.StatusInternalServerError,
+		},
+		{
+			name:   "read dead-letters",
+			method: http.MethodGet,
+			path:   DeadLetterPath + "?from=1&limit=10",
+			prepare: func() {
+				sink.EXPECT().Read(int64(1), 10).Return([]*models.DeadLetter{{Seq: 1}}, nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "ack, param invalid",
+			method: http.MethodPut,
+			path:   AckDeadLetterPath,
+			code:   http.StatusInternalServerError,
+		},
+		{
+			name:   "ack failure",
+			method: http.MethodPut,
+			path:   AckDeadLetterPath + "?seq=1",
+			prepare: func() {
+				sink.EXPECT().Ack(int64(1)).Return(fmt.Errorf("err"))
+			},
+			code: http.StatusInternalServerError,
+		},
+		{
+			name:   "ack dead-letters",
+			method: http.MethodPut,
+			path:   AckDeadLetterPath + "?seq=1",
+			prepare: func() {
+				sink.EXPECT().Ack(int64(1)).Return(nil)
+			},
+			code: http.StatusNoContent,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, tt.method, tt.path, "")
+			assert.Equal(t, tt.code, resp.Code)
+		})
+	}
+}
@@ -0,0 +1,65 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/models"
+	httppkg "github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/tsdb"
+)
+
+var (
+	MetricUsagePath = "/state/metric-usage"
+)
+
+// MetricUsageAPI represents the api which returns the top metrics by write/query usage of current node.
+type MetricUsageAPI struct {
+	getTracker func() tsdb.MetricUsageTracker
+}
+
+// NewMetricUsageAPI creates a MetricUsageAPI instance.
+func NewMetricUsageAPI() *MetricUsageAPI {
+	return &MetricUsageAPI{
+		getTracker: tsdb.GetMetricUsageTracker,
+	}
+}
+
+// Register adds metric usage api route.
+func (api *MetricUsageAPI) Register(route gin.IRoutes) {
+	route.GET(MetricUsagePath, api.GetMetricUsage)
+}
+
+// GetMetricUsage returns the top metrics sorted by given dimension(points by default).
+func (api *MetricUsageAPI) GetMetricUsage(c *gin.Context) {
+	var param struct {
+		Sort  string `form:"sort"`
+		Limit int    `form:"limit"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	sortBy, err := models.ParseMetricUsageSortBy(param.Sort)
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	httppkg.OK(c, api.getTracker().Top(sortBy, param.Limit))
+}
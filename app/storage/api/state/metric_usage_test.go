@@ -0,0 +1,80 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package state
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestMetricUsageAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tracker := tsdb.NewMockMetricUsageTracker(ctrl)
+	api := NewMetricUsageAPI()
+	api.getTracker = func() tsdb.MetricUsageTracker {
+		return tracker
+	}
+	r := gin.New()
+	api.Register(r)
+
+	cases := []struct {
+		name    string
+		path    string
+		prepare func()
+		code    int
+	}{
+		{
+			name: "param invalid",
+			path: MetricUsagePath + "?limit=a",
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "sort dimension invalid",
+			path: MetricUsagePath + "?sort=abc",
+			code: http.StatusInternalServerError,
+		},
+		{
+			name: "top metrics",
+			path: MetricUsagePath + "?sort=scanBytes&limit=10",
+			prepare: func() {
+				tracker.EXPECT().Top(models.SortByScanBytes, 10).Return([]*models.MetricUsage{{Database: "db", Metric: "cpu"}})
+			},
+			code: http.StatusOK,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := mock.DoRequest(t, r, http.MethodGet, tt.path, "")
+			assert.Equal(t, tt.code, resp.Code)
+		})
+	}
+}
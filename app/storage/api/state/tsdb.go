@@ -18,16 +18,27 @@
 package state
 
 import (
+	"context"
+	"strconv"
+	"time"
+
 	"github.com/gin-gonic/gin"
 
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
 	httppkg "github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/tsdb"
 )
 
 var (
-	MemoryDatabase = "/state/tsdb/memory"
+	MemoryDatabase   = "/state/tsdb/memory"
+	FlushPausePath   = "/state/tsdb/flush/pause"
+	FlushResumePath  = "/state/tsdb/flush/resume"
+	FlushPausedPath  = "/state/tsdb/flush/paused"
+	FamilyTierPath   = "/state/tsdb/family/tier"
+	FamilyUntierPath = "/state/tsdb/family/untier"
 )
 
 // TSDBAPI represents tsdb internal state rest api.
@@ -45,6 +56,11 @@ func NewTSDBAPI() *TSDBAPI {
 // Register adds the route for tsdb state api.
 func (db *TSDBAPI) Register(route gin.IRoutes) {
 	route.GET(MemoryDatabase, db.GetMemoryDatabaseState)
+	route.PUT(FlushPausePath, db.PauseFlush)
+	route.PUT(FlushResumePath, db.ResumeFlush)
+	route.GET(FlushPausedPath, db.GetFlushPausedDatabases)
+	route.PUT(FamilyTierPath, db.TierFamily)
+	route.PUT(FamilyUntierPath, db.UntierFamily)
 }
 
 // GetMemoryDatabaseState returns memory database
@@ -65,3 +81,112 @@ func (db *TSDBAPI) GetMemoryDatabaseState(c *gin.Context) {
 	})
 	httppkg.OK(c, rs)
 }
+
+// PauseFlush pauses flushing of the families for database on current node during maintenance.
+func (db *TSDBAPI) PauseFlush(c *gin.Context) {
+	db.setFlushPaused(c, true)
+}
+
+// ResumeFlush resumes flushing of the families for database on current node.
+func (db *TSDBAPI) ResumeFlush(c *gin.Context) {
+	db.setFlushPaused(c, false)
+}
+
+// GetFlushPausedDatabases returns the databases whose flushing is paused on current node.
+func (db *TSDBAPI) GetFlushPausedDatabases(c *gin.Context) {
+	httppkg.OK(c, tsdb.GetFamilyManager().FlushPausedDatabases())
+}
+
+// setFlushPaused pauses/resumes flushing of the families for database.
+func (db *TSDBAPI) setFlushPaused(c *gin.Context, paused bool) {
+	var param struct {
+		DB string `form:"db" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	startTime := time.Now()
+	op := audit.OpResumeFlush
+	if paused {
+		op = audit.OpPauseFlush
+		tsdb.GetFamilyManager().PauseFlush(param.DB)
+	} else {
+		tsdb.GetFamilyManager().ResumeFlush(param.DB)
+	}
+	audit.GetAuditor().Record(c.Request.Context(), op, map[string]string{"database": param.DB}, startTime, nil)
+	db.logger.Info("set flushing state of database",
+		logger.String("database", param.DB), logger.Any("paused", paused))
+	httppkg.NoContent(c)
+}
+
+// TierFamily moves the files of family to object storage on current node.
+func (db *TSDBAPI) TierFamily(c *gin.Context) {
+	db.setFamilyTiered(c, true)
+}
+
+// UntierFamily downloads the files of family from object storage back to local disk on current node,
+// for heavy analysis of old data.
+func (db *TSDBAPI) UntierFamily(c *gin.Context) {
+	db.setFamilyTiered(c, false)
+}
+
+// setFamilyTiered tiers/un-tiers the families of database/shard with given family time(same as memory state).
+func (db *TSDBAPI) setFamilyTiered(c *gin.Context, tiered bool) {
+	var param struct {
+		DB         string `form:"db" binding:"required"`
+		Shard      int32  `form:"shard"`
+		FamilyTime string `form:"familyTime" binding:"required"`
+	}
+	if err := c.ShouldBindQuery(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	var families []tsdb.DataFamily
+	tsdb.GetFamilyManager().WalkEntry(func(family tsdb.DataFamily) {
+		if param.DB == family.Shard().Database().Name() &&
+			param.Shard == int32(family.Shard().ShardID()) &&
+			param.FamilyTime == timeutil.FormatTimestamp(family.FamilyTime(), timeutil.DataTimeFormat2) {
+			families = append(families, family)
+		}
+	})
+	if len(families) == 0 {
+		httppkg.NotFound(c)
+		return
+	}
+	startTime := time.Now()
+	op := audit.OpUntierFamily
+	if tiered {
+		op = audit.OpTierFamily
+	}
+	err := db.tierFamilies(c.Request.Context(), families, tiered)
+	audit.GetAuditor().Record(c.Request.Context(), op, map[string]string{
+		"database":   param.DB,
+		"shard":      strconv.Itoa(int(param.Shard)),
+		"familyTime": param.FamilyTime,
+	}, startTime, err)
+	if err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	db.logger.Info("set tiering state of family",
+		logger.String("database", param.DB), logger.Any("shard", param.Shard),
+		logger.String("familyTime", param.FamilyTime), logger.Any("tiered", tiered))
+	httppkg.NoContent(c)
+}
+
+// tierFamilies tiers/un-tiers families, returns the first failure.
+func (db *TSDBAPI) tierFamilies(ctx context.Context, families []tsdb.DataFamily, tiered bool) error {
+	for _, family := range families {
+		var err error
+		if tiered {
+			err = family.Tier(ctx, nil)
+		} else {
+			err = family.Untier(ctx)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
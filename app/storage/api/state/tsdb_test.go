@@ -18,7 +18,9 @@
 package state
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -27,6 +29,7 @@ import (
 
 	"github.com/lindb/lindb/internal/mock"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/tsdb"
 )
 
@@ -37,7 +40,7 @@ func TestTSDBAPI_GetMemoryDatabaseState(t *testing.T) {
 	}()
 
 	f := tsdb.NewMockDataFamily(ctrl)
-	f.EXPECT().Indicator().Return("f")
+	f.EXPECT().Indicator().Return("f").Times(2)
 	f.EXPECT().GetState().Return(models.DataFamilyState{})
 	s := tsdb.NewMockShard(ctrl)
 	f.EXPECT().Shard().Return(s).AnyTimes()
@@ -45,6 +48,7 @@ func TestTSDBAPI_GetMemoryDatabaseState(t *testing.T) {
 	s.EXPECT().Database().Return(db)
 	db.EXPECT().Name().Return("test")
 	tsdb.GetFamilyManager().AddFamily(f)
+	defer tsdb.GetFamilyManager().RemoveFamily(f)
 
 	api := NewTSDBAPI()
 	r := gin.New()
@@ -57,3 +61,67 @@ func TestTSDBAPI_GetMemoryDatabaseState(t *testing.T) {
 	resp = mock.DoRequest(t, r, http.MethodGet, MemoryDatabase+"?db=test", "")
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
+
+func TestTSDBAPI_PauseFlush(t *testing.T) {
+	defer tsdb.GetFamilyManager().ResumeFlush("test")
+
+	api := NewTSDBAPI()
+	r := gin.New()
+	api.Register(r)
+
+	// params invalid
+	resp := mock.DoRequest(t, r, http.MethodPut, FlushPausePath, "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	resp = mock.DoRequest(t, r, http.MethodPut, FlushResumePath, "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+
+	resp = mock.DoRequest(t, r, http.MethodPut, FlushPausePath+"?db=test", "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.True(t, tsdb.GetFamilyManager().IsFlushPaused("test"))
+	resp = mock.DoRequest(t, r, http.MethodGet, FlushPausedPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `["test"]`, resp.Body.String())
+
+	resp = mock.DoRequest(t, r, http.MethodPut, FlushResumePath+"?db=test", "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	assert.False(t, tsdb.GetFamilyManager().IsFlushPaused("test"))
+}
+
+func TestTSDBAPI_TierFamily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	f := tsdb.NewMockDataFamily(ctrl)
+	f.EXPECT().Indicator().Return("tier-f").AnyTimes()
+	s := tsdb.NewMockShard(ctrl)
+	f.EXPECT().Shard().Return(s).AnyTimes()
+	f.EXPECT().FamilyTime().Return(int64(1665360000000)).AnyTimes()
+	db := tsdb.NewMockDatabase(ctrl)
+	s.EXPECT().Database().Return(db).AnyTimes()
+	s.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	db.EXPECT().Name().Return("test").AnyTimes()
+	tsdb.GetFamilyManager().AddFamily(f)
+	defer tsdb.GetFamilyManager().RemoveFamily(f)
+
+	api := NewTSDBAPI()
+	r := gin.New()
+	api.Register(r)
+
+	familyTime := url.QueryEscape(timeutil.FormatTimestamp(1665360000000, timeutil.DataTimeFormat2))
+	// params invalid
+	resp := mock.DoRequest(t, r, http.MethodPut, FamilyTierPath+"?db=test", "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// family not found
+	resp = mock.DoRequest(t, r, http.MethodPut, FamilyTierPath+"?db=test&shard=2&familyTime="+familyTime, "")
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// tier failure
+	f.EXPECT().Tier(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	resp = mock.DoRequest(t, r, http.MethodPut, FamilyTierPath+"?db=test&shard=1&familyTime="+familyTime, "")
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	f.EXPECT().Tier(gomock.Any(), gomock.Any()).Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, FamilyTierPath+"?db=test&shard=1&familyTime="+familyTime, "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	f.EXPECT().Untier(gomock.Any()).Return(nil)
+	resp = mock.DoRequest(t, r, http.MethodPut, FamilyUntierPath+"?db=test&shard=1&familyTime="+familyTime, "")
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
@@ -0,0 +1,132 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+//go:generate mockgen -source=./config_watcher.go -destination=./config_watcher_mock.go -package=storage
+
+// for testing
+var (
+	statFn                = os.Stat
+	reloadStorageConfigFn = config.ReloadStorageConfig
+)
+
+// ConfigWatcher represents the watcher which checks if config file changed periodically,
+// then reloads tsdb config(e.g. max-memdb-size/mutable-memdb-ttl) without restart.
+type ConfigWatcher interface {
+	// Start starts the watcher goroutine in background.
+	Start()
+	// Stop stops watching config reloading.
+	Stop()
+}
+
+// configWatcher implements ConfigWatcher interface.
+type configWatcher struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	path     string
+	interval time.Duration
+	modTime  time.Time
+
+	removeListener func()
+
+	statistics *metrics.ConfigReloadStatistics
+	logger     *logger.Logger
+}
+
+// newConfigWatcher creates a ConfigWatcher instance for config file.
+func newConfigWatcher(ctx context.Context, path string, interval time.Duration) ConfigWatcher {
+	c, cancel := context.WithCancel(ctx)
+	return &configWatcher{
+		ctx:        c,
+		cancel:     cancel,
+		path:       path,
+		interval:   interval,
+		statistics: metrics.NewConfigReloadStatistics(),
+		logger:     logger.GetLogger("Storage", "ConfigWatcher"),
+	}
+}
+
+// Start starts the watcher goroutine in background.
+func (w *configWatcher) Start() {
+	w.statistics.Version.Update(float64(config.StorageConfigVersion()))
+	// config also can be reloaded by SIGHUP signal, so listen all successful reloads
+	w.removeListener = config.AddStorageConfigListener(w.onReloaded)
+
+	if w.interval <= 0 || w.path == "" {
+		return
+	}
+	if stat, err := statFn(w.path); err == nil {
+		w.modTime = stat.ModTime()
+	}
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+// Stop stops watching config reloading.
+func (w *configWatcher) Stop() {
+	w.cancel()
+	if w.removeListener != nil {
+		w.removeListener()
+	}
+}
+
+// check reloads the config if config file modified.
+func (w *configWatcher) check() {
+	stat, err := statFn(w.path)
+	if err != nil {
+		w.logger.Warn("get config file stat failure", logger.String("path", w.path), logger.Error(err))
+		return
+	}
+	if stat.ModTime().Equal(w.modTime) {
+		return
+	}
+	w.modTime = stat.ModTime()
+	if err := reloadStorageConfigFn(); err != nil {
+		w.statistics.ReloadFailures.Incr()
+		w.logger.Error("reload tsdb config failure, keep old config",
+			logger.String("path", w.path), logger.Error(err))
+	}
+}
+
+// onReloaded logs the changed keys of config after config reloaded successfully.
+func (w *configWatcher) onReloaded(version int64, changes []string) {
+	w.statistics.Version.Update(float64(version))
+	w.statistics.Reloads.Incr()
+	w.logger.Info("reload tsdb config successfully",
+		logger.Int64("version", version), logger.Any("changes", changes))
+}
@@ -0,0 +1,86 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+)
+
+func TestConfigWatcher_check(t *testing.T) {
+	defer func() {
+		statFn = os.Stat
+		reloadStorageConfigFn = config.ReloadStorageConfig
+	}()
+	path := filepath.Join(t.TempDir(), "storage.toml")
+	assert.NoError(t, os.WriteFile(path, []byte("[storage]"), 0644))
+
+	reloads := 0
+	reloadStorageConfigFn = func() error {
+		reloads++
+		return fmt.Errorf("err")
+	}
+	w := newConfigWatcher(context.TODO(), path, time.Hour)
+	w.Start()
+	defer w.Stop()
+	w1 := w.(*configWatcher)
+
+	// not modified
+	w1.check()
+	assert.Equal(t, 0, reloads)
+	// modified, reload failure
+	modTime := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, modTime, modTime))
+	w1.check()
+	assert.Equal(t, 1, reloads)
+	// not modified after reload failure
+	w1.check()
+	assert.Equal(t, 1, reloads)
+
+	// get stat failure
+	statFn = func(_ string) (os.FileInfo, error) {
+		return nil, fmt.Errorf("err")
+	}
+	w1.check()
+	assert.Equal(t, 1, reloads)
+}
+
+func TestConfigWatcher_Start(t *testing.T) {
+	defer config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+
+	// disabled, but listen reloading
+	w := newConfigWatcher(context.TODO(), "", time.Millisecond)
+	w.Start()
+	tsdbCfg := config.GlobalStorageConfig().TSDB
+	tsdbCfg.MutableMemDBTTL *= 2
+	assert.NoError(t, config.ReloadStorageTSDBConfig(tsdbCfg))
+	w.Stop()
+
+	w = newConfigWatcher(context.TODO(), filepath.Join(t.TempDir(), "storage.toml"), time.Millisecond)
+	w.Start()
+	time.Sleep(10 * time.Millisecond)
+	w.Stop()
+}
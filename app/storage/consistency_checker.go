@@ -0,0 +1,254 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/coordinator/storage"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./consistency_checker.go -destination=./consistency_checker_mock.go -package=storage
+
+// for testing
+var (
+	digestFamilyFn = tsdb.DigestFamily
+)
+
+// ConsistencyChecker represents the consistency checker of shard replicas on storage node,
+// watches the consistency check tasks submitted by master, computes the digests of closed family
+// for each local shard replica, then reports the digests to master via state repo.
+type ConsistencyChecker interface {
+	// Start starts watching the consistency check tasks.
+	Start() error
+	// Stop stops watching and the digest computing in progress.
+	Stop()
+}
+
+// consistencyChecker implements ConsistencyChecker interface.
+type consistencyChecker struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	cfg       config.ConsistencyCheck
+	nodeID    models.NodeID
+	repo      state.Repository
+	discovery discovery.Discovery
+	stateMgr  storage.StateManager
+	engine    tsdb.Engine
+	isBusy    func() bool // returns true if foreground queries are executing
+	limiter   *rate.Limiter
+	tasks     chan *models.ConsistencyCheck
+
+	statistics *metrics.ConsistencyCheckStatistics
+	logger     *logger.Logger
+}
+
+// newConsistencyChecker creates a ConsistencyChecker instance.
+func newConsistencyChecker(
+	ctx context.Context,
+	cfg config.ConsistencyCheck,
+	nodeID models.NodeID,
+	discoveryFactory discovery.Factory,
+	stateMgr storage.StateManager,
+	engine tsdb.Engine,
+	isBusy func() bool,
+) ConsistencyChecker {
+	c, cancel := context.WithCancel(ctx)
+	scanRate := int(cfg.MaxScanRate)
+	limit := rate.Limit(scanRate)
+	if scanRate <= 0 {
+		limit = rate.Inf
+	}
+	checker := &consistencyChecker{
+		ctx:        c,
+		cancel:     cancel,
+		cfg:        cfg,
+		nodeID:     nodeID,
+		repo:       discoveryFactory.GetRepo(),
+		stateMgr:   stateMgr,
+		engine:     engine,
+		isBusy:     isBusy,
+		limiter:    rate.NewLimiter(limit, scanRate),
+		tasks:      make(chan *models.ConsistencyCheck, 16),
+		statistics: metrics.NewConsistencyCheckStatistics(),
+		logger:     logger.GetLogger("Storage", "ConsistencyChecker"),
+	}
+	checker.discovery = discoveryFactory.CreateDiscovery(constants.ConsistencyCheckPath, checker)
+	return checker
+}
+
+// Start starts watching the consistency check tasks.
+func (c *consistencyChecker) Start() error {
+	if err := c.discovery.Discovery(true); err != nil {
+		return err
+	}
+	// computes digests one by one, avoid impacting foreground write/query
+	go func() {
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case task := <-c.tasks:
+				c.check(task)
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops watching and the digest computing in progress.
+func (c *consistencyChecker) Stop() {
+	c.discovery.Close()
+	c.cancel()
+}
+
+// OnCreate receives the consistency check task submitted by master.
+func (c *consistencyChecker) OnCreate(key string, resource []byte) {
+	task := &models.ConsistencyCheck{}
+	if err := encoding.JSONUnmarshal(resource, task); err != nil {
+		c.logger.Warn("unmarshal consistency check task failure", logger.String("key", key), logger.Error(err))
+		return
+	}
+	select {
+	case c.tasks <- task:
+	case <-c.ctx.Done():
+	}
+}
+
+// OnDelete does nothing when consistency check task deleted.
+func (c *consistencyChecker) OnDelete(_ string) {}
+
+// check computes the digests of family for each local shard replica of database,
+// skips the replica which already reported digest for the task.
+func (c *consistencyChecker) check(task *models.ConsistencyCheck) {
+	for _, assignment := range c.stateMgr.GetDatabaseAssignments() {
+		if assignment.ShardAssignment == nil || assignment.ShardAssignment.Name != task.Database {
+			continue
+		}
+		for shardID, replica := range assignment.ShardAssignment.Shards {
+			if !replica.Contain(c.nodeID) {
+				continue
+			}
+			path := constants.GetConsistencyDigestPath(task.Database, shardID.Int(), int(c.nodeID))
+			if c.reported(path, task.ID) {
+				continue
+			}
+			digest := c.digest(task, shardID)
+			if c.ctx.Err() != nil {
+				// checker stopped, re-compute after restart
+				return
+			}
+			if err := c.repo.Put(c.ctx, path, encoding.JSONMarshal(digest)); err != nil {
+				c.statistics.CheckFailures.Incr()
+				c.logger.Error("report digest of shard replica failure",
+					logger.String("database", task.Database), logger.Any("shardID", shardID), logger.Error(err))
+			}
+		}
+	}
+}
+
+// reported returns if the digest of shard replica reported for the task.
+func (c *consistencyChecker) reported(path, checkID string) bool {
+	data, err := c.repo.Get(c.ctx, path)
+	if err != nil {
+		return false
+	}
+	digest := &models.ReplicaDigest{}
+	if err := encoding.JSONUnmarshal(data, digest); err != nil {
+		return false
+	}
+	return digest.CheckID == checkID
+}
+
+// digest computes the digest of family for the shard replica, error is recorded in digest.
+func (c *consistencyChecker) digest(task *models.ConsistencyCheck, shardID models.ShardID) *models.ReplicaDigest {
+	start := time.Now()
+	digest := &models.ReplicaDigest{
+		CheckID:    task.ID,
+		Database:   task.Database,
+		ShardID:    shardID,
+		NodeID:     c.nodeID,
+		FamilyTime: task.FamilyTime,
+	}
+	metrics, err := c.digestFamily(task, shardID)
+	if err != nil {
+		c.statistics.CheckFailures.Incr()
+		c.logger.Warn("compute digest of family failure",
+			logger.String("database", task.Database), logger.Any("shardID", shardID),
+			logger.Int64("familyTime", task.FamilyTime), logger.Error(err))
+		digest.Error = err.Error()
+	} else {
+		c.statistics.Checks.Incr()
+		c.statistics.CheckDuration.UpdateSince(start)
+		digest.Metrics = metrics
+	}
+	digest.ComputedAt = timeutil.Now()
+	return digest
+}
+
+// digestFamily finds the family of shard by family time, then computes the metric digests of it.
+func (c *consistencyChecker) digestFamily(task *models.ConsistencyCheck, shardID models.ShardID) ([]models.MetricDigest, error) {
+	shard, ok := c.engine.GetShard(task.Database, shardID)
+	if !ok {
+		return nil, errors.New("shard not found")
+	}
+	families := shard.GetDataFamilies(shard.CurrentInterval().Type(),
+		timeutil.TimeRange{Start: task.FamilyTime, End: task.FamilyTime})
+	for _, family := range families {
+		if family.FamilyTime() == task.FamilyTime {
+			return digestFamilyFn(c.ctx, family, c.throttle)
+		}
+	}
+	// family not exist, no data written
+	return nil, nil
+}
+
+// throttle limits the scan rate of family data, pauses when foreground queries are executing.
+func (c *consistencyChecker) throttle(ctx context.Context, bytes int) error {
+	c.statistics.ScanBytes.Add(float64(bytes))
+	for c.isBusy() {
+		c.statistics.Throttles.Incr()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(c.cfg.QueryBackoff.Duration()):
+		}
+	}
+	if c.limiter.Limit() == rate.Inf {
+		return nil
+	}
+	if bytes > c.limiter.Burst() {
+		bytes = c.limiter.Burst()
+	}
+	return c.limiter.WaitN(ctx, bytes)
+}
@@ -0,0 +1,225 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/coordinator/storage"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestConsistencyChecker_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	factory := discovery.NewMockFactory(ctrl)
+	d := discovery.NewMockDiscovery(ctrl)
+	factory.EXPECT().GetRepo().Return(nil).AnyTimes()
+	factory.EXPECT().CreateDiscovery(constants.ConsistencyCheckPath, gomock.Any()).Return(d).AnyTimes()
+	stateMgr := storage.NewMockStateManager(ctrl)
+
+	// start failure
+	d.EXPECT().Discovery(true).Return(fmt.Errorf("err"))
+	c := newConsistencyChecker(context.TODO(), config.ConsistencyCheck{}, 1, factory, stateMgr, nil, nil)
+	assert.Error(t, c.Start())
+
+	d.EXPECT().Discovery(true).Return(nil)
+	c = newConsistencyChecker(context.TODO(), config.ConsistencyCheck{}, 1, factory, stateMgr, nil, nil)
+	assert.NoError(t, c.Start())
+	checker := c.(*consistencyChecker)
+	// bad task
+	checker.OnCreate("key", []byte("abc"))
+	checker.OnDelete("key")
+	// task not for local replica
+	checked := make(chan struct{})
+	stateMgr.EXPECT().GetDatabaseAssignments().DoAndReturn(func() []*models.DatabaseAssignment {
+		close(checked)
+		return nil
+	})
+	checker.OnCreate("key", encoding.JSONMarshal(&models.ConsistencyCheck{ID: "1", Database: "db"}))
+	<-checked
+
+	d.EXPECT().Close()
+	c.Stop()
+	// enqueue after stopped
+	checker.OnCreate("key", encoding.JSONMarshal(&models.ConsistencyCheck{ID: "1", Database: "db"}))
+}
+
+func TestConsistencyChecker_check(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		digestFamilyFn = tsdb.DigestFamily
+		ctrl.Finish()
+	}()
+
+	factory := discovery.NewMockFactory(ctrl)
+	repo := state.NewMockRepository(ctrl)
+	factory.EXPECT().GetRepo().Return(repo).AnyTimes()
+	factory.EXPECT().CreateDiscovery(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	stateMgr := storage.NewMockStateManager(ctrl)
+	engine := tsdb.NewMockEngine(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	family := tsdb.NewMockDataFamily(ctrl)
+	stateMgr.EXPECT().GetDatabaseAssignments().Return([]*models.DatabaseAssignment{
+		{},
+		{ShardAssignment: &models.ShardAssignment{Name: "other"}},
+		{ShardAssignment: &models.ShardAssignment{Name: "db", Shards: map[models.ShardID]*models.Replica{
+			1: {Replicas: []models.NodeID{1, 2}},
+			2: {Replicas: []models.NodeID{2, 3}},
+		}}},
+	}).AnyTimes()
+	shard.EXPECT().CurrentInterval().Return(timeutil.Interval(10 * timeutil.OneSecond)).AnyTimes()
+	family.EXPECT().FamilyTime().Return(int64(10)).AnyTimes()
+	task := &models.ConsistencyCheck{ID: "check", Database: "db", FamilyTime: 10}
+	path := constants.GetConsistencyDigestPath("db", 1, 1)
+
+	cases := []struct {
+		name    string
+		prepare func()
+		digest  *models.ReplicaDigest
+	}{
+		{
+			name: "digest already reported",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), path).Return(encoding.JSONMarshal(&models.ReplicaDigest{CheckID: "check"}), nil)
+			},
+		},
+		{
+			name: "shard not found",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), path).Return([]byte("abc"), nil)
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(nil, false)
+			},
+			digest: &models.ReplicaDigest{Error: "shard not found"},
+		},
+		{
+			name: "family not found",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), path).Return(nil, state.ErrNotExist)
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+				shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			digest: &models.ReplicaDigest{},
+		},
+		{
+			name: "compute digest failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), path).Return(encoding.JSONMarshal(&models.ReplicaDigest{CheckID: "old"}), nil)
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+				shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return([]tsdb.DataFamily{family})
+				digestFamilyFn = func(_ context.Context, _ tsdb.DataFamily,
+					_ func(ctx context.Context, bytes int) error) ([]models.MetricDigest, error) {
+					return nil, fmt.Errorf("err")
+				}
+			},
+			digest: &models.ReplicaDigest{Error: "err"},
+		},
+		{
+			name: "compute digest successfully",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), path).Return(nil, state.ErrNotExist)
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+				shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return([]tsdb.DataFamily{family})
+				digestFamilyFn = func(_ context.Context, _ tsdb.DataFamily,
+					_ func(ctx context.Context, bytes int) error) ([]models.MetricDigest, error) {
+					return []models.MetricDigest{{Namespace: "ns", Metric: "cpu", Points: 10}}, nil
+				}
+			},
+			digest: &models.ReplicaDigest{Metrics: []models.MetricDigest{{Namespace: "ns", Metric: "cpu", Points: 10}}},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				digestFamilyFn = tsdb.DigestFamily
+			}()
+			tt.prepare()
+			if tt.digest != nil {
+				repo.EXPECT().Put(gomock.Any(), path, gomock.Any()).DoAndReturn(func(_ context.Context, _ string, data []byte) error {
+					digest := &models.ReplicaDigest{}
+					assert.NoError(t, encoding.JSONUnmarshal(data, digest))
+					assert.Equal(t, "check", digest.CheckID)
+					assert.Equal(t, models.ShardID(1), digest.ShardID)
+					assert.Equal(t, models.NodeID(1), digest.NodeID)
+					assert.Equal(t, int64(10), digest.FamilyTime)
+					assert.Equal(t, tt.digest.Error, digest.Error)
+					assert.Equal(t, tt.digest.Metrics, digest.Metrics)
+					return fmt.Errorf("err") // report failure, log it
+				})
+			}
+			c := newConsistencyChecker(context.TODO(), config.ConsistencyCheck{}, 1, factory, stateMgr, engine, nil)
+			c.(*consistencyChecker).check(task)
+		})
+	}
+
+	// checker stopped when computing digest
+	repo.EXPECT().Get(gomock.Any(), path).Return(nil, state.ErrNotExist)
+	engine.EXPECT().GetShard("db", models.ShardID(1)).Return(nil, false)
+	c := newConsistencyChecker(context.TODO(), config.ConsistencyCheck{}, 1, factory, stateMgr, engine, nil)
+	checker := c.(*consistencyChecker)
+	checker.cancel()
+	checker.check(task)
+}
+
+func TestConsistencyChecker_throttle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	factory := discovery.NewMockFactory(ctrl)
+	factory.EXPECT().GetRepo().Return(nil).AnyTimes()
+	factory.EXPECT().CreateDiscovery(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	busy := 2
+	isBusy := func() bool {
+		busy--
+		return busy >= 0
+	}
+	// no scan rate limit
+	c := newConsistencyChecker(context.TODO(), config.ConsistencyCheck{
+		QueryBackoff: ltoml.Duration(time.Millisecond),
+	}, 1, factory, nil, nil, isBusy).(*consistencyChecker)
+	assert.NoError(t, c.throttle(context.TODO(), 100))
+	assert.Equal(t, -1, busy)
+	// bytes exceed burst
+	c = newConsistencyChecker(context.TODO(), config.ConsistencyCheck{
+		MaxScanRate:  ltoml.Size(1024 * 1024),
+		QueryBackoff: ltoml.Duration(time.Millisecond),
+	}, 1, factory, nil, nil, isBusy).(*consistencyChecker)
+	assert.NoError(t, c.throttle(context.TODO(), 2*1024*1024))
+	// canceled when backoff
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	c.isBusy = func() bool { return true }
+	assert.Equal(t, context.Canceled, c.throttle(ctx, 100))
+}
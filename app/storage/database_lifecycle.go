@@ -32,6 +32,12 @@ import (
 
 //go:generate mockgen -source=./database_lifecycle.go -destination=./database_lifecycle_mock.go -package=storage
 
+// for testing
+var (
+	// hibernateCheckInterval represents how often to check idle shards for hibernation.
+	hibernateCheckInterval = time.Minute
+)
+
 // DatabaseLifecycle represents database's lifecycle manager include data and write ahead log.
 type DatabaseLifecycle interface {
 	// Startup startups database's lifecycle, includes background task(ttl etc.)
@@ -73,6 +79,7 @@ func NewDatabaseLifecycle(
 // Startup startups database's lifecycle, includes background task(ttl etc.)
 func (l *databaseLifecycle) Startup() {
 	l.ttlTask()
+	l.hibernateTask()
 }
 
 // Shutdown shutdowns database's lifecycle.
@@ -113,6 +120,8 @@ func (l *databaseLifecycle) ttlTask() {
 				l.tryDropDatabases()
 				// do data ttl
 				l.engine.TTL()
+				// reclaim unused series after expired data dropped
+				l.engine.GCSeries()
 				// do data compaction
 				tsdb.GetFamilyManager().WalkEntry(func(family tsdb.DataFamily) {
 					family.Compact()
@@ -129,6 +138,22 @@ func (l *databaseLifecycle) ttlTask() {
 	}()
 }
 
+// hibernateTask hibernates idle shards in background goroutine, idle period is reloaded from config for each check.
+func (l *databaseLifecycle) hibernateTask() {
+	ticker := time.NewTicker(hibernateCheckInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.engine.HibernateIdleShards()
+			case <-l.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
 // tryDropDatabases tries drop database's resource(data/write ahead log), keeps active databases.
 func (l *databaseLifecycle) tryDropDatabases() {
 	activeDatabases := make(map[string]struct{})
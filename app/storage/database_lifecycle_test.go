@@ -102,11 +102,35 @@ func TestDatabaseLifecycle_ttlTask(t *testing.T) {
 	config.SetGlobalStorageConfig(cfg)
 	repo.EXPECT().WalkEntry(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	engine.EXPECT().TTL().AnyTimes()
+	engine.EXPECT().GCSeries().AnyTimes()
 	engine.EXPECT().EvictSegment().AnyTimes()
 	dbLifecycle1.ttlTask()
 	<-ch
 }
 
+func TestDatabaseLifecycle_hibernateTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		hibernateCheckInterval = time.Minute
+		ctrl.Finish()
+	}()
+	hibernateCheckInterval = time.Millisecond * 10
+
+	engine := tsdb.NewMockEngine(ctrl)
+	checked := make(chan struct{}, 1)
+	engine.EXPECT().HibernateIdleShards().DoAndReturn(func() {
+		select {
+		case checked <- struct{}{}:
+		default:
+		}
+	}).MinTimes(1)
+
+	dbLifecycle := NewDatabaseLifecycle(context.TODO(), nil, nil, engine).(*databaseLifecycle)
+	dbLifecycle.hibernateTask()
+	<-checked
+	dbLifecycle.cancel()
+}
+
 func TestDatabaseLifecycle_dropDatabases(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
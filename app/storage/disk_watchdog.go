@@ -0,0 +1,129 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./disk_watchdog.go -destination=./disk_watchdog_mock.go -package=storage
+
+// DiskWatchdog represents the disk space watchdog which monitors free space of data/wal disk,
+// when free space is below low watermark, pauses compaction and rejects writes before disk is full,
+// resumes normal operation when free space is above high watermark.
+type DiskWatchdog interface {
+	// Start starts the watchdog goroutine in background.
+	Start()
+	// IsPaused returns if compaction/writes are paused because of low disk space.
+	IsPaused() bool
+}
+
+// diskWatchdog implements DiskWatchdog interface.
+type diskWatchdog struct {
+	ctx    context.Context
+	cfg    config.DiskWatchdog
+	paths  []string
+	paused atomic.Bool
+
+	statistics *metrics.DiskWatchdogStatistics
+	logger     *logger.Logger
+}
+
+// newDiskWatchdog creates a DiskWatchdog instance for data/wal paths.
+func newDiskWatchdog(ctx context.Context, cfg config.DiskWatchdog, paths ...string) DiskWatchdog {
+	return &diskWatchdog{
+		ctx:        ctx,
+		cfg:        cfg,
+		paths:      paths,
+		statistics: metrics.NewDiskWatchdogStatistics(),
+		logger:     logger.GetLogger("Storage", "DiskWatchdog"),
+	}
+}
+
+// Start starts the watchdog goroutine in background.
+func (w *diskWatchdog) Start() {
+	interval := w.cfg.CheckInterval.Duration()
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+}
+
+// IsPaused returns if compaction/writes are paused because of low disk space.
+func (w *diskWatchdog) IsPaused() bool {
+	return w.paused.Load()
+}
+
+// check checks the min free percent of all paths, pauses/resumes based on watermarks.
+func (w *diskWatchdog) check() {
+	minFreePercent := 100.0
+	checked := false
+	for _, path := range w.paths {
+		stat, err := diskUsageFn(w.ctx, path)
+		if err != nil {
+			w.logger.Warn("get disk usage failure", logger.String("path", path), logger.Error(err))
+			continue
+		}
+		checked = true
+		freePercent := 100 - stat.UsedPercent
+		w.statistics.FreePercent.WithTagValues(path).Update(freePercent)
+		if freePercent < minFreePercent {
+			minFreePercent = freePercent
+		}
+	}
+	if !checked {
+		return
+	}
+	switch {
+	case !w.paused.Load() && minFreePercent < w.cfg.LowWatermark:
+		w.paused.Store(true)
+		kv.PauseCompaction()
+		tsdb.RejectWrites()
+		w.statistics.LowSpace.Update(1)
+		w.statistics.Pauses.Incr()
+		w.logger.Warn("disk free space is below low watermark, pause compaction and reject writes",
+			logger.Any("freePercent", minFreePercent), logger.Any("lowWatermark", w.cfg.LowWatermark))
+	case w.paused.Load() && minFreePercent > w.cfg.HighWatermark:
+		w.paused.Store(false)
+		kv.ResumeCompaction()
+		tsdb.AcceptWrites()
+		w.statistics.LowSpace.Update(0)
+		w.logger.Info("disk free space is above high watermark, resume compaction and writes",
+			logger.Any("freePercent", minFreePercent), logger.Any("highWatermark", w.cfg.HighWatermark))
+	}
+}
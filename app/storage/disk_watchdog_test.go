@@ -0,0 +1,89 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestDiskWatchdog_check(t *testing.T) {
+	defer func() {
+		diskUsageFn = disk.UsageWithContext
+		kv.ResumeCompaction()
+		tsdb.AcceptWrites()
+	}()
+	usedPercent := map[string]float64{"data": 50, "wal": 50}
+	diskUsageFn = func(_ context.Context, path string) (*disk.UsageStat, error) {
+		if path == "err" {
+			return nil, fmt.Errorf("err")
+		}
+		return &disk.UsageStat{UsedPercent: usedPercent[path]}, nil
+	}
+	w := newDiskWatchdog(context.TODO(), config.DiskWatchdog{LowWatermark: 5, HighWatermark: 10}, "data", "wal", "err")
+	w1 := w.(*diskWatchdog)
+
+	w1.check()
+	assert.False(t, w.IsPaused())
+	// wal disk below low watermark
+	usedPercent["wal"] = 96
+	w1.check()
+	assert.True(t, w.IsPaused())
+	assert.True(t, kv.IsCompactionPaused())
+	assert.True(t, tsdb.IsWriteRejected())
+	// between low and high watermark, keep paused
+	usedPercent["wal"] = 92
+	w1.check()
+	assert.True(t, w.IsPaused())
+	// above high watermark, resume
+	usedPercent["wal"] = 80
+	w1.check()
+	assert.False(t, w.IsPaused())
+	assert.False(t, kv.IsCompactionPaused())
+	assert.False(t, tsdb.IsWriteRejected())
+
+	// all paths get disk usage failure
+	w = newDiskWatchdog(context.TODO(), config.DiskWatchdog{LowWatermark: 5, HighWatermark: 10}, "err")
+	w.(*diskWatchdog).check()
+	assert.False(t, w.IsPaused())
+}
+
+func TestDiskWatchdog_Start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	// disabled
+	newDiskWatchdog(ctx, config.DiskWatchdog{}, t.TempDir()).Start()
+	w := newDiskWatchdog(ctx, config.DiskWatchdog{
+		CheckInterval: ltoml.Duration(10 * time.Millisecond),
+		LowWatermark:  0.000001,
+		HighWatermark: 0.00001,
+	}, t.TempDir())
+	w.Start()
+	time.Sleep(50 * time.Millisecond)
+	assert.False(t, w.IsPaused())
+}
@@ -0,0 +1,87 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"path"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./flush_pause_watcher.go -destination=./flush_pause_watcher_mock.go -package=storage
+
+// FlushPauseWatcher represents the watcher of flush pause tasks submitted by master,
+// pauses flushing of database when task created, resumes it when task deleted.
+// NOTICE: only watches the task changes after started, so that pause state not survives process restarts.
+type FlushPauseWatcher interface {
+	// Start starts watching the flush pause tasks.
+	Start() error
+	// Stop stops watching the flush pause tasks.
+	Stop()
+}
+
+// flushPauseWatcher implements FlushPauseWatcher interface.
+type flushPauseWatcher struct {
+	familyMgr tsdb.FamilyManager
+	discovery discovery.Discovery
+
+	logger *logger.Logger
+}
+
+// newFlushPauseWatcher creates a FlushPauseWatcher instance.
+func newFlushPauseWatcher(discoveryFactory discovery.Factory, familyMgr tsdb.FamilyManager) FlushPauseWatcher {
+	w := &flushPauseWatcher{
+		familyMgr: familyMgr,
+		logger:    logger.GetLogger("Storage", "FlushPauseWatcher"),
+	}
+	w.discovery = discoveryFactory.CreateDiscovery(constants.FlushPausePath, w)
+	return w
+}
+
+// Start starts watching the flush pause tasks.
+func (w *flushPauseWatcher) Start() error {
+	return w.discovery.Discovery(false)
+}
+
+// Stop stops watching the flush pause tasks.
+func (w *flushPauseWatcher) Stop() {
+	w.discovery.Close()
+}
+
+// OnCreate pauses flushing of database when receiving the flush pause task.
+func (w *flushPauseWatcher) OnCreate(key string, resource []byte) {
+	pause := &models.FlushPause{}
+	if err := encoding.JSONUnmarshal(resource, pause); err != nil {
+		w.logger.Warn("unmarshal flush pause task failure", logger.String("key", key), logger.Error(err))
+		return
+	}
+	w.familyMgr.PauseFlush(pause.Database)
+	w.logger.Info("pause flushing of database", logger.String("database", pause.Database))
+}
+
+// OnDelete resumes flushing of database when flush pause task deleted.
+func (w *flushPauseWatcher) OnDelete(key string) {
+	database := path.Base(key)
+	w.familyMgr.ResumeFlush(database)
+	w.logger.Info("resume flushing of database", logger.String("database", database))
+}
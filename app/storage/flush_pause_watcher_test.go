@@ -0,0 +1,64 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestFlushPauseWatcher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	factory := discovery.NewMockFactory(ctrl)
+	d := discovery.NewMockDiscovery(ctrl)
+	factory.EXPECT().CreateDiscovery(constants.FlushPausePath, gomock.Any()).Return(d).AnyTimes()
+	familyMgr := tsdb.GetFamilyManager()
+	defer familyMgr.ResumeFlush("db")
+
+	// start failure
+	d.EXPECT().Discovery(false).Return(fmt.Errorf("err"))
+	w := newFlushPauseWatcher(factory, familyMgr)
+	assert.Error(t, w.Start())
+
+	d.EXPECT().Discovery(false).Return(nil)
+	w = newFlushPauseWatcher(factory, familyMgr)
+	assert.NoError(t, w.Start())
+	watcher := w.(*flushPauseWatcher)
+	// bad task
+	watcher.OnCreate("key", []byte("abc"))
+	assert.Empty(t, familyMgr.FlushPausedDatabases())
+
+	watcher.OnCreate(constants.GetFlushPausePath("db"), encoding.JSONMarshal(&models.FlushPause{Database: "db"}))
+	assert.True(t, familyMgr.IsFlushPaused("db"))
+	watcher.OnDelete(constants.GetFlushPausePath("db"))
+	assert.False(t, familyMgr.IsFlushPaused("db"))
+
+	d.EXPECT().Close()
+	w.Stop()
+}
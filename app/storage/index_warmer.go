@@ -0,0 +1,283 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./index_warmer.go -destination=./index_warmer_mock.go -package=storage
+
+const (
+	// indexWarmupFile is the file name of most queried metrics persisted under tsdb dir.
+	indexWarmupFile = "index_warmup.json"
+	// recoveryWaitInterval is the interval of checking if recovery completed before warming up.
+	recoveryWaitInterval = time.Second
+)
+
+// errWarmUpInterrupted represents warm-up stopped because of foreground queries.
+var errWarmUpInterrupted = errors.New("index warm-up interrupted by foreground queries")
+
+// IndexWarmer represents the index warmer of storage node, persists the most queried metrics periodically,
+// after restart warms up the index and the newest family of them in background once recovery completed,
+// so that the first queries need not wait for the cold index read from disk.
+type IndexWarmer interface {
+	// Start starts warming up after recovery, then persisting the most queried metrics periodically.
+	Start()
+	// Stop stops warming up, persists the most queried metrics.
+	Stop()
+	// Progress returns the progress of warm-up.
+	Progress() models.WarmUpProgress
+}
+
+// indexWarmer implements IndexWarmer interface.
+type indexWarmer struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	cfg         config.IndexWarmup
+	path        string
+	engine      tsdb.Engine
+	tracker     tsdb.MetricUsageTracker
+	isBusy      func() bool // returns true if foreground queries are executing
+	limiter     *rate.Limiter
+	progress    models.WarmUpProgress
+	lock        sync.RWMutex // guards progress
+	persistLock sync.Mutex   // guards persisting file
+
+	statistics *metrics.IndexWarmupStatistics
+	logger     *logger.Logger
+}
+
+// newIndexWarmer creates an IndexWarmer instance.
+func newIndexWarmer(
+	ctx context.Context,
+	cfg config.IndexWarmup,
+	dataDir string,
+	engine tsdb.Engine,
+	isBusy func() bool,
+) IndexWarmer {
+	c, cancel := context.WithCancel(ctx)
+	readRate := int(cfg.MaxReadRate)
+	limit := rate.Limit(readRate)
+	if readRate <= 0 {
+		limit = rate.Inf
+	}
+	state := models.WarmUpDisabled
+	if cfg.Enabled {
+		state = models.WarmUpPending
+	}
+	return &indexWarmer{
+		ctx:        c,
+		cancel:     cancel,
+		cfg:        cfg,
+		path:       filepath.Join(dataDir, indexWarmupFile),
+		engine:     engine,
+		tracker:    tsdb.GetMetricUsageTracker(),
+		isBusy:     isBusy,
+		limiter:    rate.NewLimiter(limit, readRate),
+		progress:   models.WarmUpProgress{State: state},
+		statistics: metrics.NewIndexWarmupStatistics(),
+		logger:     logger.GetLogger("Storage", "IndexWarmer"),
+	}
+}
+
+// Start starts warming up after recovery, then persisting the most queried metrics periodically.
+func (w *indexWarmer) Start() {
+	if !w.cfg.Enabled {
+		return
+	}
+	go func() {
+		w.warmUp()
+
+		ticker := time.NewTicker(w.cfg.PersistInterval.Duration())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.ctx.Done():
+				return
+			case <-ticker.C:
+				w.persist()
+			}
+		}
+	}()
+}
+
+// Stop stops warming up, persists the most queried metrics.
+func (w *indexWarmer) Stop() {
+	w.cancel()
+	if w.cfg.Enabled {
+		w.persist()
+	}
+}
+
+// Progress returns the progress of warm-up.
+func (w *indexWarmer) Progress() models.WarmUpProgress {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.progress
+}
+
+// warmUp warms up the metrics persisted by last run one by one after recovery completed,
+// stops immediately if foreground queries are executing.
+func (w *indexWarmer) warmUp() {
+	queried, err := w.load()
+	if err != nil {
+		w.logger.Warn("load most queried metrics failure, skip warm-up", logger.String("path", w.path), logger.Error(err))
+	}
+	if !w.waitRecovery() {
+		return
+	}
+	w.updateProgress(func(progress *models.WarmUpProgress) {
+		progress.State = models.WarmUpRunning
+		progress.Total = len(queried)
+	})
+	w.logger.Info("start warming up index", logger.Int("metrics", len(queried)))
+	start := time.Now()
+	for _, m := range queried {
+		if db, ok := w.engine.GetDatabase(m.Database); ok {
+			err := db.WarmUpMetric(w.ctx, m.MetricID, w.throttle)
+			switch {
+			case err == nil:
+				w.statistics.WarmedMetrics.Incr()
+			case errors.Is(err, errWarmUpInterrupted):
+				w.statistics.Interrupts.Incr()
+				w.updateProgress(func(progress *models.WarmUpProgress) {
+					progress.State = models.WarmUpInterrupted
+				})
+				w.logger.Info("warming up index interrupted by foreground queries", logger.Any("progress", w.Progress()))
+				return
+			case w.ctx.Err() != nil:
+				// warmer stopped
+				return
+			default:
+				w.statistics.WarmFailures.Incr()
+				w.logger.Warn("warm up index of metric failure",
+					logger.String("database", m.Database), logger.Any("metricID", m.MetricID), logger.Error(err))
+			}
+		}
+		w.updateProgress(func(progress *models.WarmUpProgress) {
+			progress.Warmed++
+		})
+	}
+	w.updateProgress(func(progress *models.WarmUpProgress) {
+		progress.State = models.WarmUpDone
+	})
+	w.logger.Info("warm up index completed",
+		logger.Any("progress", w.Progress()), logger.String("elapsed", time.Since(start).String()))
+}
+
+// waitRecovery waits for shards/families opened after startup, returns false if warmer stopped.
+func (w *indexWarmer) waitRecovery() bool {
+	ticker := time.NewTicker(recoveryWaitInterval)
+	defer ticker.Stop()
+	for w.engine.RecoveryProgress().Phase != models.RecoveryPhaseDone {
+		select {
+		case <-w.ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// throttle limits the read rate of index/blocks, returns errWarmUpInterrupted if foreground queries are executing.
+func (w *indexWarmer) throttle(ctx context.Context, bytes int) error {
+	if w.isBusy() {
+		return errWarmUpInterrupted
+	}
+	w.statistics.ReadBytes.Add(float64(bytes))
+	w.updateProgress(func(progress *models.WarmUpProgress) {
+		progress.Bytes += int64(bytes)
+	})
+	if w.limiter.Limit() == rate.Inf {
+		return nil
+	}
+	if bytes > w.limiter.Burst() {
+		bytes = w.limiter.Burst()
+	}
+	if err := w.limiter.WaitN(ctx, bytes); err != nil {
+		return err
+	}
+	if w.isBusy() {
+		return errWarmUpInterrupted
+	}
+	return nil
+}
+
+// updateProgress updates the progress of warm-up.
+func (w *indexWarmer) updateProgress(fn func(progress *models.WarmUpProgress)) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	fn(&w.progress)
+}
+
+// load loads the most queried metrics persisted by last run, returns nil if not exist.
+func (w *indexWarmer) load() ([]tsdb.QueriedMetric, error) {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var queried []tsdb.QueriedMetric
+	if err := encoding.JSONUnmarshal(data, &queried); err != nil {
+		return nil, err
+	}
+	return queried, nil
+}
+
+// persist persists the most queried metrics into local disk for warming up after next restart,
+// keeps the persisted metrics of last run until warmed up or no queries since restart.
+func (w *indexWarmer) persist() {
+	progress := w.Progress()
+	if !progress.IsWarm() {
+		return
+	}
+	queried := w.tracker.TopQueried(w.cfg.TopMetrics)
+	if len(queried) == 0 {
+		return
+	}
+	w.persistLock.Lock()
+	defer w.persistLock.Unlock()
+
+	tmp := w.path + ".tmp"
+	err := os.WriteFile(tmp, encoding.JSONMarshal(queried), 0o644)
+	if err == nil {
+		err = os.Rename(tmp, w.path)
+	}
+	if err != nil {
+		w.statistics.PersistFailures.Incr()
+		w.logger.Warn("persist most queried metrics failure", logger.String("path", w.path), logger.Error(err))
+	}
+}
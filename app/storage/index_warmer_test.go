@@ -0,0 +1,239 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestIndexWarmer_disabled(t *testing.T) {
+	dir := t.TempDir()
+	w := newIndexWarmer(context.TODO(), config.IndexWarmup{}, dir, nil, nil)
+	w.Start()
+	progress := w.Progress()
+	assert.Equal(t, models.WarmUpDisabled, progress.State)
+	assert.True(t, progress.IsWarm())
+	w.Stop()
+	_, err := os.Stat(filepath.Join(dir, indexWarmupFile))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestIndexWarmer_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+	engine := tsdb.NewMockEngine(ctrl)
+	tracker := tsdb.NewMockMetricUsageTracker(ctrl)
+	engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{Phase: models.RecoveryPhaseDone}).AnyTimes()
+	persisted := make(chan struct{})
+	tracker.EXPECT().TopQueried(10).DoAndReturn(func(_ int) []tsdb.QueriedMetric {
+		select {
+		case <-persisted:
+		default:
+			close(persisted)
+		}
+		return []tsdb.QueriedMetric{{Database: "db", MetricID: 1, Queries: 2}}
+	}).MinTimes(1)
+	w := newIndexWarmer(context.TODO(), config.IndexWarmup{
+		Enabled:         true,
+		TopMetrics:      10,
+		PersistInterval: ltoml.Duration(time.Millisecond),
+	}, dir, engine, func() bool { return false })
+	w.(*indexWarmer).tracker = tracker
+	w.Start()
+	<-persisted
+	w.Stop()
+	assert.Equal(t, models.WarmUpDone, w.Progress().State)
+	queried, err := w.(*indexWarmer).load()
+	assert.NoError(t, err)
+	assert.Equal(t, []tsdb.QueriedMetric{{Database: "db", MetricID: 1, Queries: 2}}, queried)
+}
+
+func TestIndexWarmer_warmUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	db := tsdb.NewMockDatabase(ctrl)
+	queried := []tsdb.QueriedMetric{
+		{Database: "db", MetricID: 1, Queries: 10},
+		{Database: "db", MetricID: 2, Queries: 5},
+		{Database: "other", MetricID: 3, Queries: 1},
+	}
+
+	cases := []struct {
+		name     string
+		data     []byte
+		prepare  func(cancel context.CancelFunc)
+		progress models.WarmUpProgress
+	}{
+		{
+			name: "no metrics persisted",
+			prepare: func(_ context.CancelFunc) {
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{Phase: models.RecoveryPhaseDone})
+			},
+			progress: models.WarmUpProgress{State: models.WarmUpDone},
+		},
+		{
+			name: "unmarshal persisted metrics failure",
+			data: []byte("abc"),
+			prepare: func(_ context.CancelFunc) {
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{Phase: models.RecoveryPhaseDone})
+			},
+			progress: models.WarmUpProgress{State: models.WarmUpDone},
+		},
+		{
+			name: "stopped before recovery completed",
+			data: encoding.JSONMarshal(queried),
+			prepare: func(cancel context.CancelFunc) {
+				engine.EXPECT().RecoveryProgress().DoAndReturn(func() *models.RecoveryProgress {
+					cancel()
+					return &models.RecoveryProgress{Phase: models.RecoveryPhaseFamily}
+				})
+			},
+			progress: models.WarmUpProgress{State: models.WarmUpPending},
+		},
+		{
+			name: "warm up completed, skip database not found and failure",
+			data: encoding.JSONMarshal(queried),
+			prepare: func(_ context.CancelFunc) {
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{Phase: models.RecoveryPhaseDone})
+				engine.EXPECT().GetDatabase("db").Return(db, true).Times(2)
+				engine.EXPECT().GetDatabase("other").Return(nil, false)
+				db.EXPECT().WarmUpMetric(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(ctx context.Context, _ interface{}, throttle func(ctx context.Context, bytes int) error) error {
+						return throttle(ctx, 100)
+					})
+				db.EXPECT().WarmUpMetric(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			progress: models.WarmUpProgress{State: models.WarmUpDone, Warmed: 3, Total: 3, Bytes: 100},
+		},
+		{
+			name: "interrupted by foreground queries",
+			data: encoding.JSONMarshal(queried),
+			prepare: func(_ context.CancelFunc) {
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{Phase: models.RecoveryPhaseDone})
+				engine.EXPECT().GetDatabase("db").Return(db, true)
+				db.EXPECT().WarmUpMetric(gomock.Any(), gomock.Any(), gomock.Any()).Return(errWarmUpInterrupted)
+			},
+			progress: models.WarmUpProgress{State: models.WarmUpInterrupted, Total: 3},
+		},
+		{
+			name: "stopped when warming up",
+			data: encoding.JSONMarshal(queried),
+			prepare: func(cancel context.CancelFunc) {
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{Phase: models.RecoveryPhaseDone})
+				engine.EXPECT().GetDatabase("db").Return(db, true)
+				db.EXPECT().WarmUpMetric(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, _ interface{}, _ func(ctx context.Context, bytes int) error) error {
+						cancel()
+						return context.Canceled
+					})
+			},
+			progress: models.WarmUpProgress{State: models.WarmUpRunning, Total: 3},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.data != nil {
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, indexWarmupFile), tt.data, 0o644))
+			}
+			w := newIndexWarmer(context.TODO(), config.IndexWarmup{Enabled: true}, dir, engine,
+				func() bool { return false }).(*indexWarmer)
+			tt.prepare(w.cancel)
+			w.warmUp()
+			assert.Equal(t, tt.progress, w.Progress())
+		})
+	}
+}
+
+func TestIndexWarmer_throttle(t *testing.T) {
+	busy := false
+	w := newIndexWarmer(context.TODO(), config.IndexWarmup{Enabled: true, MaxReadRate: 10},
+		t.TempDir(), nil, func() bool { return busy }).(*indexWarmer)
+	// bytes above burst
+	assert.NoError(t, w.throttle(context.TODO(), 100))
+	assert.Equal(t, int64(100), w.Progress().Bytes)
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.Error(t, w.throttle(ctx, 10))
+
+	busy = true
+	assert.ErrorIs(t, w.throttle(context.TODO(), 10), errWarmUpInterrupted)
+	assert.Equal(t, int64(110), w.Progress().Bytes)
+
+	// no limit
+	w = newIndexWarmer(context.TODO(), config.IndexWarmup{Enabled: true},
+		t.TempDir(), nil, func() bool { return false }).(*indexWarmer)
+	assert.NoError(t, w.throttle(context.TODO(), 100))
+}
+
+func TestIndexWarmer_persist(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+	tracker := tsdb.NewMockMetricUsageTracker(ctrl)
+	w := newIndexWarmer(context.TODO(), config.IndexWarmup{Enabled: true, TopMetrics: 2}, dir, nil, nil).(*indexWarmer)
+	w.tracker = tracker
+	path := filepath.Join(dir, indexWarmupFile)
+
+	// keep metrics of last run until warmed up
+	w.persist()
+	assert.NoFileExists(t, path)
+
+	w.progress.State = models.WarmUpInterrupted
+	// no queries since restart
+	tracker.EXPECT().TopQueried(2).Return(nil)
+	w.persist()
+	assert.NoFileExists(t, path)
+
+	queried := []tsdb.QueriedMetric{{Database: "db", MetricID: 1, Queries: 1}}
+	tracker.EXPECT().TopQueried(2).Return(queried).Times(2)
+	w.persist()
+	rs, err := w.load()
+	assert.NoError(t, err)
+	assert.Equal(t, queried, rs)
+
+	// write failure
+	w.path = filepath.Join(dir, "not-exist", indexWarmupFile)
+	w.persist()
+	assert.NoFileExists(t, w.path)
+	// read failure
+	w.path = dir
+	_, err = w.load()
+	assert.Error(t, err)
+}
@@ -0,0 +1,42 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb"
+)
+
+// collectNodeLoad collects the load of storage node from family manager stats and data disk usage.
+func collectNodeLoad(ctx context.Context, dataDir string) *models.NodeLoad {
+	stats := tsdb.GetFamilyManager().FamilyStats()
+	load := &models.NodeLoad{
+		MemDBTotalSize:      stats.MemDBTotalSize,
+		FamiliesNeedFlush:   stats.NeedFlush,
+		FamiliesFlushFailed: stats.FlushFailed,
+		WriteRejected:       tsdb.RejectedWrites(),
+		ReportTime:          timeutil.Now(),
+	}
+	if stat, err := diskUsageFn(ctx, dataDir); err == nil {
+		load.DiskFree = stat.Free
+	}
+	return load
+}
@@ -0,0 +1,46 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeLoad_collect(t *testing.T) {
+	defer func() {
+		diskUsageFn = disk.UsageWithContext
+	}()
+	diskUsageFn = func(_ context.Context, _ string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Free: 1024}, nil
+	}
+	load := collectNodeLoad(context.TODO(), t.TempDir())
+	assert.Equal(t, uint64(1024), load.DiskFree)
+	assert.NotZero(t, load.ReportTime)
+
+	// get disk usage failure
+	diskUsageFn = func(_ context.Context, _ string) (*disk.UsageStat, error) {
+		return nil, fmt.Errorf("err")
+	}
+	load = collectNodeLoad(context.TODO(), t.TempDir())
+	assert.Zero(t, load.DiskFree)
+}
@@ -0,0 +1,189 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/disk"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/replica"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./readiness.go -destination=./readiness_mock.go -package=storage
+
+// for testing
+var (
+	diskUsageFn = disk.UsageWithContext
+)
+
+// readiness check names
+const (
+	kvStoreCheck     = "kv-store"
+	recoveryCheck    = "recovery"
+	replicationCheck = "replication"
+	familyFlushCheck = "family-flush"
+	diskCheck        = "disk"
+)
+
+// ReadinessEvaluator represents the readiness evaluator of storage node,
+// node is ready when all dependency checks pass, node is warm when index warm-up completed.
+type ReadinessEvaluator interface {
+	// Evaluate evaluates all dependency checks, returns the readiness of node.
+	Evaluate() *models.Readiness
+}
+
+// readinessEvaluator implements ReadinessEvaluator interface.
+type readinessEvaluator struct {
+	ctx                context.Context
+	dataDir            string
+	minDiskFreePercent float64
+	engine             tsdb.Engine
+	walMgr             replica.WriteAheadLogManager
+	warmer             IndexWarmer
+}
+
+// newReadinessEvaluator creates a ReadinessEvaluator instance.
+func newReadinessEvaluator(
+	ctx context.Context,
+	dataDir string,
+	minDiskFreePercent float64,
+	engine tsdb.Engine,
+	walMgr replica.WriteAheadLogManager,
+	warmer IndexWarmer,
+) ReadinessEvaluator {
+	return &readinessEvaluator{
+		ctx:                ctx,
+		dataDir:            dataDir,
+		minDiskFreePercent: minDiskFreePercent,
+		engine:             engine,
+		walMgr:             walMgr,
+		warmer:             warmer,
+	}
+}
+
+// Evaluate evaluates all dependency checks, returns the readiness of node.
+func (r *readinessEvaluator) Evaluate() *models.Readiness {
+	readiness := models.NewReadiness([]models.ReadinessCheck{
+		r.checkKVStore(),
+		r.checkRecovery(),
+		r.checkReplication(),
+		r.checkFamilyFlush(),
+		r.checkDisk(),
+	})
+	if r.warmer != nil {
+		progress := r.warmer.Progress()
+		readiness.Warm = progress.IsWarm()
+		readiness.WarmUp = &progress
+	}
+	return readiness
+}
+
+// checkKVStore checks if tsdb engine(kv stores) opened.
+func (r *readinessEvaluator) checkKVStore() models.ReadinessCheck {
+	check := models.ReadinessCheck{Name: kvStoreCheck, Ready: r.engine != nil}
+	if !check.Ready {
+		check.Message = "tsdb engine not opened"
+	}
+	return check
+}
+
+// checkRecovery checks if shards/families opened after startup, reports the progress of recovery.
+func (r *readinessEvaluator) checkRecovery() models.ReadinessCheck {
+	check := models.ReadinessCheck{Name: recoveryCheck}
+	if r.engine == nil {
+		check.Message = "tsdb engine not opened"
+		return check
+	}
+	progress := r.engine.RecoveryProgress()
+	switch {
+	case progress.Err != "":
+		check.Message = fmt.Sprintf("recover %s failure: %s", progress.Phase, progress.Err)
+	case progress.Phase != models.RecoveryPhaseDone:
+		check.Message = fmt.Sprintf("recovering %s: %d/%d opened", progress.Phase, progress.Opened, progress.Total)
+	default:
+		check.Ready = true
+		if len(progress.Quarantined) > 0 {
+			check.Message = "quarantined: " + strings.Join(progress.Quarantined, ", ")
+		}
+	}
+	return check
+}
+
+// checkReplication checks if replication channels of all databases connected.
+func (r *readinessEvaluator) checkReplication() models.ReadinessCheck {
+	check := models.ReadinessCheck{Name: replicationCheck}
+	if r.engine == nil || r.walMgr == nil {
+		check.Message = "write ahead log not recovered"
+		return check
+	}
+	var failures []string
+	for db := range r.engine.GetAllDatabases() {
+		for _, state := range r.walMgr.GetReplicaState(db) {
+			for _, peer := range state.Replicators {
+				if peer.State == models.ReplicatorFailureState {
+					failures = append(failures, fmt.Sprintf("%s/%d/%s: %s",
+						db, state.ShardID, peer.Replicator, peer.StateErrMsg))
+				}
+			}
+		}
+	}
+	check.Ready = len(failures) == 0
+	if !check.Ready {
+		sort.Strings(failures)
+		check.Message = "replication channel disconnected: " + strings.Join(failures, ", ")
+	}
+	return check
+}
+
+// checkFamilyFlush checks if flush job of any family fails persistently.
+func (r *readinessEvaluator) checkFamilyFlush() models.ReadinessCheck {
+	var unhealthy []string
+	tsdb.GetFamilyManager().WalkEntry(func(family tsdb.DataFamily) {
+		if !family.IsHealthy() {
+			unhealthy = append(unhealthy, family.Indicator())
+		}
+	})
+	check := models.ReadinessCheck{Name: familyFlushCheck, Ready: len(unhealthy) == 0}
+	if !check.Ready {
+		sort.Strings(unhealthy)
+		check.Message = "flush failing persistently: " + strings.Join(unhealthy, ", ")
+	}
+	return check
+}
+
+// checkDisk checks if free space of data disk is above the threshold.
+func (r *readinessEvaluator) checkDisk() models.ReadinessCheck {
+	check := models.ReadinessCheck{Name: diskCheck}
+	stat, err := diskUsageFn(r.ctx, r.dataDir)
+	if err != nil {
+		check.Message = fmt.Sprintf("get disk usage failure: %s", err)
+		return check
+	}
+	freePercent := 100 - stat.UsedPercent
+	check.Ready = freePercent >= r.minDiskFreePercent
+	if !check.Ready {
+		check.Message = fmt.Sprintf("disk free %.2f%% is below threshold %.2f%%", freePercent, r.minDiskFreePercent)
+	}
+	return check
+}
@@ -0,0 +1,176 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/replica"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestReadinessEvaluator_Evaluate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		diskUsageFn = disk.UsageWithContext
+		ctrl.Finish()
+	}()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	walMgr := replica.NewMockWriteAheadLogManager(ctrl)
+	warmer := NewMockIndexWarmer(ctrl)
+	family := tsdb.NewMockDataFamily(ctrl)
+	family.EXPECT().Indicator().Return("db/1/20221010").AnyTimes()
+	tsdb.GetFamilyManager().AddFamily(family)
+	defer tsdb.GetFamilyManager().RemoveFamily(family)
+
+	checkFn := func(readiness *models.Readiness, name string) models.ReadinessCheck {
+		for _, check := range readiness.Checks {
+			if check.Name == name {
+				return check
+			}
+		}
+		t.Fatalf("check %s not found", name)
+		return models.ReadinessCheck{}
+	}
+
+	cases := []struct {
+		name    string
+		engine  tsdb.Engine
+		walMgr  replica.WriteAheadLogManager
+		warmer  IndexWarmer
+		prepare func()
+		assert  func(readiness *models.Readiness)
+	}{
+		{
+			name: "engine not opened",
+			prepare: func() {
+				family.EXPECT().IsHealthy().Return(true)
+				diskUsageFn = func(_ context.Context, _ string) (*disk.UsageStat, error) {
+					return &disk.UsageStat{UsedPercent: 10}, nil
+				}
+			},
+			assert: func(readiness *models.Readiness) {
+				assert.False(t, readiness.Ready)
+				assert.False(t, checkFn(readiness, kvStoreCheck).Ready)
+				assert.False(t, checkFn(readiness, recoveryCheck).Ready)
+				assert.False(t, checkFn(readiness, replicationCheck).Ready)
+				assert.True(t, checkFn(readiness, familyFlushCheck).Ready)
+				assert.True(t, checkFn(readiness, diskCheck).Ready)
+				assert.False(t, readiness.Warm)
+				assert.Nil(t, readiness.WarmUp)
+			},
+		},
+		{
+			name:   "replication channel disconnected, flush failing, get disk usage failure",
+			engine: engine,
+			walMgr: walMgr,
+			warmer: warmer,
+			prepare: func() {
+				warmer.EXPECT().Progress().Return(models.WarmUpProgress{State: models.WarmUpDone, Warmed: 2, Total: 2})
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{
+					Phase: models.RecoveryPhaseFamily, Opened: 1, Total: 10,
+				})
+				engine.EXPECT().GetAllDatabases().Return(map[string]tsdb.Database{"db": nil})
+				walMgr.EXPECT().GetReplicaState("db").Return([]models.FamilyLogReplicaState{{
+					ShardID: 1,
+					Replicators: []models.ReplicaPeerState{
+						{Replicator: "remote", State: models.ReplicatorFailureState, StateErrMsg: "err"},
+						{Replicator: "local", State: models.ReplicatorReadyState},
+					},
+				}})
+				family.EXPECT().IsHealthy().Return(false)
+				diskUsageFn = func(_ context.Context, _ string) (*disk.UsageStat, error) {
+					return nil, fmt.Errorf("err")
+				}
+			},
+			assert: func(readiness *models.Readiness) {
+				assert.False(t, readiness.Ready)
+				assert.True(t, checkFn(readiness, kvStoreCheck).Ready)
+				assert.Equal(t, "recovering family: 1/10 opened", checkFn(readiness, recoveryCheck).Message)
+				assert.Equal(t, "replication channel disconnected: db/1/remote: err", checkFn(readiness, replicationCheck).Message)
+				assert.Contains(t, checkFn(readiness, familyFlushCheck).Message, "db/1/20221010")
+				assert.False(t, checkFn(readiness, diskCheck).Ready)
+				// warm does not affect readiness
+				assert.True(t, readiness.Warm)
+				assert.Equal(t, 2, readiness.WarmUp.Warmed)
+			},
+		},
+		{
+			name:   "disk free below threshold",
+			engine: engine,
+			walMgr: walMgr,
+			prepare: func() {
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{
+					Phase: models.RecoveryPhaseFamily, Err: "err",
+				})
+				engine.EXPECT().GetAllDatabases().Return(nil)
+				family.EXPECT().IsHealthy().Return(true)
+				diskUsageFn = func(_ context.Context, _ string) (*disk.UsageStat, error) {
+					return &disk.UsageStat{UsedPercent: 99}, nil
+				}
+			},
+			assert: func(readiness *models.Readiness) {
+				assert.False(t, readiness.Ready)
+				assert.False(t, checkFn(readiness, diskCheck).Ready)
+				assert.Equal(t, "recover family failure: err", checkFn(readiness, recoveryCheck).Message)
+			},
+		},
+		{
+			name:   "ready",
+			engine: engine,
+			walMgr: walMgr,
+			warmer: warmer,
+			prepare: func() {
+				warmer.EXPECT().Progress().Return(models.WarmUpProgress{State: models.WarmUpRunning, Warmed: 1, Total: 2})
+				engine.EXPECT().RecoveryProgress().Return(&models.RecoveryProgress{
+					Phase: models.RecoveryPhaseDone, Quarantined: []string{"db/1/20221010.corrupt-1"},
+				})
+				engine.EXPECT().GetAllDatabases().Return(map[string]tsdb.Database{"db": nil})
+				walMgr.EXPECT().GetReplicaState("db").Return(nil)
+				family.EXPECT().IsHealthy().Return(true)
+				diskUsageFn = func(_ context.Context, _ string) (*disk.UsageStat, error) {
+					return &disk.UsageStat{UsedPercent: 50}, nil
+				}
+			},
+			assert: func(readiness *models.Readiness) {
+				assert.True(t, readiness.Ready)
+				assert.Len(t, readiness.Checks, 5)
+				assert.Equal(t, "quarantined: db/1/20221010.corrupt-1", checkFn(readiness, recoveryCheck).Message)
+				assert.False(t, readiness.Warm)
+				assert.Equal(t, models.WarmUpRunning, readiness.WarmUp.State)
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			evaluator := newReadinessEvaluator(context.TODO(), t.TempDir(), 5, tt.engine, tt.walMgr, tt.warmer)
+			tt.assert(evaluator.Evaluate())
+		})
+	}
+}
@@ -0,0 +1,164 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/replica"
+)
+
+//go:generate mockgen -source=./replica_bootstrapper.go -destination=./replica_bootstrapper_mock.go -package=storage
+
+// ReplicaBootstrapper represents the bootstrapper of new shard replica on storage node,
+// watches the bootstrap tasks submitted by master, transfers the snapshot from leader,
+// then reports the progress to master via state repo, interrupted transfer is resumed after retry interval.
+type ReplicaBootstrapper interface {
+	// Start starts watching the replica bootstrap tasks.
+	Start() error
+	// Stop stops watching and the bootstrapping in progress.
+	Stop()
+}
+
+// replicaBootstrapper implements ReplicaBootstrapper interface.
+type replicaBootstrapper struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	cfg       config.ReplicaBootstrap
+	nodeID    models.NodeID
+	repo      state.Repository
+	discovery discovery.Discovery
+	receiver  replica.SnapshotReceiver
+	running   map[string]struct{} // running tasks, key: task id
+
+	mutex sync.Mutex
+
+	logger *logger.Logger
+}
+
+// newReplicaBootstrapper creates a ReplicaBootstrapper instance.
+func newReplicaBootstrapper(
+	ctx context.Context,
+	cfg config.ReplicaBootstrap,
+	nodeID models.NodeID,
+	discoveryFactory discovery.Factory,
+	receiver replica.SnapshotReceiver,
+) ReplicaBootstrapper {
+	c, cancel := context.WithCancel(ctx)
+	bootstrapper := &replicaBootstrapper{
+		ctx:      c,
+		cancel:   cancel,
+		cfg:      cfg,
+		nodeID:   nodeID,
+		repo:     discoveryFactory.GetRepo(),
+		receiver: receiver,
+		running:  make(map[string]struct{}),
+		logger:   logger.GetLogger("Storage", "ReplicaBootstrapper"),
+	}
+	bootstrapper.discovery = discoveryFactory.CreateDiscovery(constants.ReplicaBootstrapPath, bootstrapper)
+	return bootstrapper
+}
+
+// Start starts watching the replica bootstrap tasks.
+func (b *replicaBootstrapper) Start() error {
+	return b.discovery.Discovery(true)
+}
+
+// Stop stops watching and the bootstrapping in progress.
+func (b *replicaBootstrapper) Stop() {
+	b.discovery.Close()
+	b.cancel()
+}
+
+// OnCreate receives the replica bootstrap task submitted by master, bootstraps the replica of current node.
+func (b *replicaBootstrapper) OnCreate(key string, resource []byte) {
+	task := &models.ReplicaBootstrap{}
+	if err := encoding.JSONUnmarshal(resource, task); err != nil {
+		b.logger.Warn("unmarshal replica bootstrap task failure", logger.String("key", key), logger.Error(err))
+		return
+	}
+	if task.Replica != b.nodeID || b.completed(task) {
+		return
+	}
+	b.mutex.Lock()
+	if _, ok := b.running[task.ID]; ok {
+		b.mutex.Unlock()
+		return
+	}
+	b.running[task.ID] = struct{}{}
+	b.mutex.Unlock()
+
+	go b.bootstrap(task)
+}
+
+// OnDelete does nothing when replica bootstrap task deleted.
+func (b *replicaBootstrapper) OnDelete(_ string) {}
+
+// bootstrap bootstraps the shard replica, retries until completed or bootstrapper stopped.
+func (b *replicaBootstrapper) bootstrap(task *models.ReplicaBootstrap) {
+	defer func() {
+		b.mutex.Lock()
+		delete(b.running, task.ID)
+		b.mutex.Unlock()
+	}()
+	for {
+		if err := b.receiver.Bootstrap(b.ctx, task, func(progress *models.ReplicaBootstrapProgress) {
+			b.report(task, progress)
+		}); err == nil {
+			return
+		}
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(b.cfg.RetryInterval.Duration()):
+		}
+	}
+}
+
+// report reports the bootstrap progress of shard replica to master.
+func (b *replicaBootstrapper) report(task *models.ReplicaBootstrap, progress *models.ReplicaBootstrapProgress) {
+	path := constants.GetReplicaBootstrapProgressPath(task.Database, task.ShardID.Int(), int(task.Replica))
+	if err := b.repo.Put(b.ctx, path, encoding.JSONMarshal(progress)); err != nil {
+		b.logger.Warn("report replica bootstrap progress failure",
+			logger.String("database", task.Database), logger.Any("shardID", task.ShardID), logger.Error(err))
+	}
+}
+
+// completed returns if the bootstrapping of task is completed.
+func (b *replicaBootstrapper) completed(task *models.ReplicaBootstrap) bool {
+	path := constants.GetReplicaBootstrapProgressPath(task.Database, task.ShardID.Int(), int(task.Replica))
+	data, err := b.repo.Get(b.ctx, path)
+	if err != nil {
+		return false
+	}
+	progress := &models.ReplicaBootstrapProgress{}
+	if err := encoding.JSONUnmarshal(data, progress); err != nil {
+		return false
+	}
+	return progress.TaskID == task.ID && progress.State == models.ReplicaBootstrapCompleted
+}
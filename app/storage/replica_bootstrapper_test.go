@@ -0,0 +1,116 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/replica"
+)
+
+func TestReplicaBootstrapper_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	factory := discovery.NewMockFactory(ctrl)
+	d := discovery.NewMockDiscovery(ctrl)
+	factory.EXPECT().GetRepo().Return(nil).AnyTimes()
+	factory.EXPECT().CreateDiscovery(constants.ReplicaBootstrapPath, gomock.Any()).Return(d).AnyTimes()
+
+	// start failure
+	d.EXPECT().Discovery(true).Return(fmt.Errorf("err"))
+	b := newReplicaBootstrapper(context.TODO(), config.ReplicaBootstrap{}, 1, factory, nil)
+	assert.Error(t, b.Start())
+
+	d.EXPECT().Discovery(true).Return(nil)
+	b = newReplicaBootstrapper(context.TODO(), config.ReplicaBootstrap{}, 1, factory, nil)
+	assert.NoError(t, b.Start())
+	// bad task
+	b.(*replicaBootstrapper).OnCreate("key", []byte("abc"))
+	// task not for current node
+	b.(*replicaBootstrapper).OnCreate("key", encoding.JSONMarshal(&models.ReplicaBootstrap{ID: "1", Replica: 2}))
+	b.(*replicaBootstrapper).OnDelete("key")
+
+	d.EXPECT().Close()
+	b.Stop()
+}
+
+func TestReplicaBootstrapper_bootstrap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	factory := discovery.NewMockFactory(ctrl)
+	repo := state.NewMockRepository(ctrl)
+	receiver := replica.NewMockSnapshotReceiver(ctrl)
+	factory.EXPECT().GetRepo().Return(repo).AnyTimes()
+	factory.EXPECT().CreateDiscovery(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	b := newReplicaBootstrapper(context.TODO(),
+		config.ReplicaBootstrap{RetryInterval: ltoml.Duration(time.Millisecond)}, 3, factory, receiver)
+	bootstrapper := b.(*replicaBootstrapper)
+	task := &models.ReplicaBootstrap{ID: "task", Database: "db", ShardID: 1, Leader: 1, Replica: 3}
+	progressPath := constants.GetReplicaBootstrapProgressPath("db", 1, 3)
+
+	// task completed
+	repo.EXPECT().Get(gomock.Any(), progressPath).Return(encoding.JSONMarshal(
+		&models.ReplicaBootstrapProgress{TaskID: "task", State: models.ReplicaBootstrapCompleted}), nil)
+	bootstrapper.OnCreate("key", encoding.JSONMarshal(task))
+
+	// bootstrap failure, then retry successfully
+	repo.EXPECT().Get(gomock.Any(), progressPath).Return([]byte("abc"), nil)
+	failed := &models.ReplicaBootstrapProgress{TaskID: "task", State: models.ReplicaBootstrapFailed}
+	completed := &models.ReplicaBootstrapProgress{TaskID: "task", State: models.ReplicaBootstrapCompleted}
+	done := make(chan struct{})
+	gomock.InOrder(
+		receiver.EXPECT().Bootstrap(gomock.Any(), task, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *models.ReplicaBootstrap, report func(progress *models.ReplicaBootstrapProgress)) error {
+				// task is running
+				bootstrapper.OnCreate("key", encoding.JSONMarshal(task))
+				report(failed)
+				return fmt.Errorf("err")
+			}),
+		receiver.EXPECT().Bootstrap(gomock.Any(), task, gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ *models.ReplicaBootstrap, report func(progress *models.ReplicaBootstrapProgress)) error {
+				report(completed)
+				return nil
+			}),
+	)
+	gomock.InOrder(
+		repo.EXPECT().Put(gomock.Any(), progressPath, encoding.JSONMarshal(failed)).Return(fmt.Errorf("err")),
+		repo.EXPECT().Put(gomock.Any(), progressPath, encoding.JSONMarshal(completed)).DoAndReturn(
+			func(_ context.Context, _ string, _ []byte) error {
+				close(done)
+				return nil
+			}),
+	)
+	repo.EXPECT().Get(gomock.Any(), progressPath).Return(nil, state.ErrNotExist)
+	bootstrapper.OnCreate("key", encoding.JSONMarshal(task))
+	<-done
+}
@@ -0,0 +1,200 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./rollup_backfiller.go -destination=./rollup_backfiller_mock.go -package=storage
+
+// for testing
+var (
+	rollupBackfillRetryInterval = time.Minute
+)
+
+// RollupBackfiller represents the backfiller of added rollup intervals on storage node,
+// watches the backfill tasks submitted by master, rolls up the history families of writable interval
+// into the families of added intervals, then reports the progress to master via state repo,
+// interrupted backfill is resumed from the last completed family after retry interval.
+type RollupBackfiller interface {
+	// Start starts watching the rollup backfill tasks.
+	Start() error
+	// Stop stops watching and the backfilling in progress.
+	Stop()
+}
+
+// rollupBackfiller implements RollupBackfiller interface.
+type rollupBackfiller struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	nodeID    models.NodeID
+	repo      state.Repository
+	discovery discovery.Discovery
+	engine    tsdb.Engine
+	running   map[string]struct{} // running tasks, key: task id
+
+	mutex sync.Mutex
+
+	logger *logger.Logger
+}
+
+// newRollupBackfiller creates a RollupBackfiller instance.
+func newRollupBackfiller(
+	ctx context.Context,
+	nodeID models.NodeID,
+	discoveryFactory discovery.Factory,
+	engine tsdb.Engine,
+) RollupBackfiller {
+	c, cancel := context.WithCancel(ctx)
+	backfiller := &rollupBackfiller{
+		ctx:     c,
+		cancel:  cancel,
+		nodeID:  nodeID,
+		repo:    discoveryFactory.GetRepo(),
+		engine:  engine,
+		running: make(map[string]struct{}),
+		logger:  logger.GetLogger("Storage", "RollupBackfiller"),
+	}
+	backfiller.discovery = discoveryFactory.CreateDiscovery(constants.RollupBackfillPath, backfiller)
+	return backfiller
+}
+
+// Start starts watching the rollup backfill tasks.
+func (b *rollupBackfiller) Start() error {
+	return b.discovery.Discovery(true)
+}
+
+// Stop stops watching and the backfilling in progress.
+func (b *rollupBackfiller) Stop() {
+	b.discovery.Close()
+	b.cancel()
+}
+
+// OnCreate receives the rollup backfill task submitted by master, backfills the shard replica of current node.
+func (b *rollupBackfiller) OnCreate(key string, resource []byte) {
+	task := &models.RollupBackfill{}
+	if err := encoding.JSONUnmarshal(resource, task); err != nil {
+		b.logger.Warn("unmarshal rollup backfill task failure", logger.String("key", key), logger.Error(err))
+		return
+	}
+	if task.Replica != b.nodeID {
+		return
+	}
+	progress := b.getProgress(task)
+	if progress.State == models.RollupBackfillCompleted {
+		return
+	}
+	b.mutex.Lock()
+	if _, ok := b.running[task.ID]; ok {
+		b.mutex.Unlock()
+		return
+	}
+	b.running[task.ID] = struct{}{}
+	b.mutex.Unlock()
+
+	go b.backfill(task, progress)
+}
+
+// OnDelete does nothing when rollup backfill task deleted.
+func (b *rollupBackfiller) OnDelete(_ string) {}
+
+// backfill backfills the added rollup intervals of shard replica, retries until completed or backfiller stopped.
+func (b *rollupBackfiller) backfill(task *models.RollupBackfill, progress *models.RollupBackfillProgress) {
+	defer func() {
+		b.mutex.Lock()
+		delete(b.running, task.ID)
+		b.mutex.Unlock()
+	}()
+	for {
+		err := b.doBackfill(task, progress)
+		if err == nil {
+			progress.State = models.RollupBackfillCompleted
+			progress.Error = ""
+			b.report(task, progress)
+			b.logger.Info("rollup backfill completed",
+				logger.String("database", task.Database), logger.Any("shardID", task.ShardID),
+				logger.String("intervals", task.Intervals.String()))
+			return
+		}
+		progress.State = models.RollupBackfillFailed
+		progress.Error = err.Error()
+		b.report(task, progress)
+		b.logger.Warn("rollup backfill failure, retry later",
+			logger.String("database", task.Database), logger.Any("shardID", task.ShardID), logger.Error(err))
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-time.After(rollupBackfillRetryInterval):
+		}
+	}
+}
+
+// doBackfill rolls up the history families of shard, resumes from the last completed family.
+func (b *rollupBackfiller) doBackfill(task *models.RollupBackfill, progress *models.RollupBackfillProgress) error {
+	shard, ok := b.engine.GetShard(task.Database, task.ShardID)
+	if !ok {
+		return constants.ErrShardNotFound
+	}
+	progress.State = models.RollupBackfillRunning
+	progress.Error = ""
+	b.report(task, progress)
+	return shard.BackfillRollup(task.Intervals, progress.CompletedFrom, func(completed, total int, completedFrom int64) {
+		progress.CompletedFamilies = completed
+		progress.TotalFamilies = total
+		progress.CompletedFrom = completedFrom
+		b.report(task, progress)
+	})
+}
+
+// report reports the backfill progress of shard replica to master.
+func (b *rollupBackfiller) report(task *models.RollupBackfill, progress *models.RollupBackfillProgress) {
+	progress.UpdatedAt = timeutil.Now()
+	path := constants.GetRollupBackfillProgressPath(task.Database, task.ShardID.Int(), int(task.Replica))
+	if err := b.repo.Put(b.ctx, path, encoding.JSONMarshal(progress)); err != nil {
+		b.logger.Warn("report rollup backfill progress failure",
+			logger.String("database", task.Database), logger.Any("shardID", task.ShardID), logger.Error(err))
+	}
+}
+
+// getProgress returns the reported progress of task, returns pending progress if not reported.
+func (b *rollupBackfiller) getProgress(task *models.RollupBackfill) *models.RollupBackfillProgress {
+	pending := &models.RollupBackfillProgress{TaskID: task.ID, State: models.RollupBackfillPending}
+	path := constants.GetRollupBackfillProgressPath(task.Database, task.ShardID.Int(), int(task.Replica))
+	data, err := b.repo.Get(b.ctx, path)
+	if err != nil {
+		return pending
+	}
+	progress := &models.RollupBackfillProgress{}
+	if err := encoding.JSONUnmarshal(data, progress); err != nil || progress.TaskID != task.ID {
+		return pending
+	}
+	return progress
+}
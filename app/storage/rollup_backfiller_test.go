@@ -0,0 +1,132 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/discovery"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestRollupBackfiller_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	factory := discovery.NewMockFactory(ctrl)
+	d := discovery.NewMockDiscovery(ctrl)
+	factory.EXPECT().GetRepo().Return(nil).AnyTimes()
+	factory.EXPECT().CreateDiscovery(constants.RollupBackfillPath, gomock.Any()).Return(d).AnyTimes()
+
+	// start failure
+	d.EXPECT().Discovery(true).Return(fmt.Errorf("err"))
+	b := newRollupBackfiller(context.TODO(), 1, factory, nil)
+	assert.Error(t, b.Start())
+
+	d.EXPECT().Discovery(true).Return(nil)
+	b = newRollupBackfiller(context.TODO(), 1, factory, nil)
+	assert.NoError(t, b.Start())
+	// bad task
+	b.(*rollupBackfiller).OnCreate("key", []byte("abc"))
+	// task not for current node
+	b.(*rollupBackfiller).OnCreate("key", encoding.JSONMarshal(&models.RollupBackfill{ID: "1", Replica: 2}))
+	b.(*rollupBackfiller).OnDelete("key")
+
+	d.EXPECT().Close()
+	b.Stop()
+}
+
+func TestRollupBackfiller_backfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		rollupBackfillRetryInterval = time.Minute
+		ctrl.Finish()
+	}()
+	rollupBackfillRetryInterval = time.Millisecond
+
+	factory := discovery.NewMockFactory(ctrl)
+	repo := state.NewMockRepository(ctrl)
+	engine := tsdb.NewMockEngine(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	factory.EXPECT().GetRepo().Return(repo).AnyTimes()
+	factory.EXPECT().CreateDiscovery(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	b := newRollupBackfiller(context.TODO(), 3, factory, engine)
+	backfiller := b.(*rollupBackfiller)
+	intervals := option.Intervals{{Interval: timeutil.Interval(timeutil.OneHour)}}
+	task := &models.RollupBackfill{ID: "task", Database: "db", ShardID: 1, Replica: 3, Intervals: intervals}
+	progressPath := constants.GetRollupBackfillProgressPath("db", 1, 3)
+
+	// task completed
+	repo.EXPECT().Get(gomock.Any(), progressPath).Return(encoding.JSONMarshal(
+		&models.RollupBackfillProgress{TaskID: "task", State: models.RollupBackfillCompleted}), nil)
+	backfiller.OnCreate("key", encoding.JSONMarshal(task))
+
+	// shard not found, then resume from previous progress successfully
+	repo.EXPECT().Get(gomock.Any(), progressPath).Return(encoding.JSONMarshal(
+		&models.RollupBackfillProgress{TaskID: "task", State: models.RollupBackfillFailed, CompletedFrom: 100}), nil)
+	gomock.InOrder(
+		engine.EXPECT().GetShard("db", models.ShardID(1)).Return(nil, false),
+		engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true),
+	)
+	shard.EXPECT().BackfillRollup(intervals, int64(100), gomock.Any()).DoAndReturn(
+		func(_ option.Intervals, _ int64, progress func(completed, total int, completedFrom int64)) error {
+			// task is running
+			backfiller.OnCreate("key", encoding.JSONMarshal(task))
+			progress(2, 3, 50)
+			return nil
+		})
+	var reported []models.RollupBackfillProgress
+	done := make(chan struct{})
+	repo.EXPECT().Put(gomock.Any(), progressPath, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ string, data []byte) error {
+			progress := models.RollupBackfillProgress{}
+			assert.NoError(t, encoding.JSONUnmarshal(data, &progress))
+			reported = append(reported, progress)
+			if progress.State == models.RollupBackfillCompleted {
+				close(done)
+				return nil
+			}
+			return fmt.Errorf("err")
+		}).Times(4)
+	repo.EXPECT().Get(gomock.Any(), progressPath).Return(nil, state.ErrNotExist)
+	backfiller.OnCreate("key", encoding.JSONMarshal(task))
+	<-done
+
+	assert.Len(t, reported, 4)
+	assert.Equal(t, models.RollupBackfillFailed, reported[0].State)
+	assert.Equal(t, constants.ErrShardNotFound.Error(), reported[0].Error)
+	assert.Equal(t, models.RollupBackfillRunning, reported[1].State)
+	assert.Equal(t, int64(100), reported[1].CompletedFrom)
+	assert.Equal(t, int64(50), reported[2].CompletedFrom)
+	assert.Equal(t, 2, reported[2].CompletedFamilies)
+	assert.Equal(t, 3, reported[2].TotalFamilies)
+	assert.Equal(t, models.RollupBackfillCompleted, reported[3].State)
+	assert.Empty(t, reported[3].Error)
+}
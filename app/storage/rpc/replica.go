@@ -21,21 +21,24 @@ import (
 	"context"
 	"io"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
 	"github.com/lindb/lindb/pkg/logger"
 	protoReplicaV1 "github.com/lindb/lindb/proto/gen/v1/replica"
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/rpc"
 )
 
+var errReplicaBootstrapping = errcode.New(errcode.StorageUnavailable, "shard replica is bootstrapping from snapshot")
+
 // ReplicaHandler implements replica.ReplicaServiceServer interface for handling replica rpc request.
 type ReplicaHandler struct {
-	walMgr replica.WriteAheadLogManager
+	walMgr      replica.WriteAheadLogManager
+	snapshotMgr replica.SnapshotManager
+	receiver    replica.SnapshotReceiver
 
 	logger *logger.Logger
 }
@@ -43,10 +46,14 @@ type ReplicaHandler struct {
 // NewReplicaHandler creates a replica handler.
 func NewReplicaHandler(
 	walMgr replica.WriteAheadLogManager,
+	snapshotMgr replica.SnapshotManager,
+	receiver replica.SnapshotReceiver,
 ) *ReplicaHandler {
 	return &ReplicaHandler{
-		walMgr: walMgr,
-		logger: logger.GetLogger("Storage", "ReplicaRPC"),
+		walMgr:      walMgr,
+		snapshotMgr: snapshotMgr,
+		receiver:    receiver,
+		logger:      logger.GetLogger("Storage", "ReplicaRPC"),
 	}
 }
 
@@ -54,6 +61,9 @@ func NewReplicaHandler(
 func (r *ReplicaHandler) GetReplicaAckIndex(_ context.Context,
 	request *protoReplicaV1.GetReplicaAckIndexRequest,
 ) (*protoReplicaV1.GetReplicaAckIndexResponse, error) {
+	if err := r.checkBootstrapping(request.Database, models.ShardID(request.Shard)); err != nil {
+		return nil, err
+	}
 	p, err := r.getOrCreatePartition(
 		request.Database,
 		models.ShardID(request.Shard),
@@ -61,7 +71,7 @@ func (r *ReplicaHandler) GetReplicaAckIndex(_ context.Context,
 		models.NodeID(request.Leader))
 	if err != nil {
 		r.logger.Error("get or create wal partition err, when do get replica ack index", logger.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, errcode.GRPCError(err)
 	}
 	return &protoReplicaV1.GetReplicaAckIndexResponse{
 		AckIndex: p.ReplicaAckIndex(),
@@ -72,6 +82,9 @@ func (r *ReplicaHandler) GetReplicaAckIndex(_ context.Context,
 func (r *ReplicaHandler) Reset(_ context.Context,
 	request *protoReplicaV1.ResetIndexRequest,
 ) (*protoReplicaV1.ResetIndexResponse, error) {
+	if err := r.checkBootstrapping(request.Database, models.ShardID(request.Shard)); err != nil {
+		return nil, err
+	}
 	p, err := r.getOrCreatePartition(
 		request.Database,
 		models.ShardID(request.Shard),
@@ -79,7 +92,7 @@ func (r *ReplicaHandler) Reset(_ context.Context,
 		models.NodeID(request.Leader))
 	if err != nil {
 		r.logger.Error("get or create wal partition err, when do reset replica index", logger.Error(err))
-		return nil, status.Error(codes.Internal, err.Error())
+		return nil, errcode.GRPCError(err)
 	}
 	p.ResetReplicaIndex(request.AppendIndex)
 	return &protoReplicaV1.ResetIndexResponse{}, nil
@@ -90,7 +103,10 @@ func (r *ReplicaHandler) Replica(server protoReplicaV1.ReplicaService_ReplicaSer
 	replicaState, err := r.getReplicaStateFromCtx(server.Context())
 	if err != nil {
 		r.logger.Error("get replica state err", logger.Error(err))
-		return status.Error(codes.InvalidArgument, err.Error())
+		return errcode.GRPCError(errcode.Wrap(errcode.InvalidArgument, err))
+	}
+	if err := r.checkBootstrapping(replicaState.Database, replicaState.ShardID); err != nil {
+		return err
 	}
 
 	p, err := r.getOrCreatePartition(
@@ -100,12 +116,12 @@ func (r *ReplicaHandler) Replica(server protoReplicaV1.ReplicaService_ReplicaSer
 		replicaState.Leader)
 	if err != nil {
 		r.logger.Error("get or create wal partition err, when do replica", logger.Error(err))
-		return status.Error(codes.Internal, err.Error())
+		return errcode.GRPCError(err)
 	}
 	err = p.BuildReplicaForFollower(replicaState.Leader, replicaState.Follower)
 	if err != nil {
 		r.logger.Error("build replica replica err", logger.Error(err))
-		return status.Error(codes.Internal, err.Error())
+		return errcode.GRPCError(err)
 	}
 	r.logger.Info("build replica stream channel successful", logger.String("replica", replicaState.String()))
 	// handle replica request from stream
@@ -116,7 +132,7 @@ func (r *ReplicaHandler) Replica(server protoReplicaV1.ReplicaService_ReplicaSer
 		}
 		if err != nil {
 			r.logger.Error("receive replica request err", logger.Error(err))
-			return status.Error(codes.Internal, err.Error())
+			return errcode.GRPCError(err)
 		}
 
 		resp := &protoReplicaV1.ReplicaResponse{}
@@ -133,11 +149,59 @@ func (r *ReplicaHandler) Replica(server protoReplicaV1.ReplicaService_ReplicaSer
 		}
 
 		if err := server.Send(resp); err != nil {
-			return status.Error(codes.Internal, err.Error())
+			return errcode.GRPCError(err)
 		}
 	}
 }
 
+// GetSnapshot creates the snapshot of shard for bootstrapping new replica.
+func (r *ReplicaHandler) GetSnapshot(_ context.Context,
+	request *protoReplicaV1.GetSnapshotRequest,
+) (*protoReplicaV1.GetSnapshotResponse, error) {
+	resp, err := r.snapshotMgr.CreateSnapshot(request.Database, models.ShardID(request.Shard))
+	if err != nil {
+		r.logger.Error("create shard snapshot err",
+			logger.String("database", request.Database), logger.Any("shard", request.Shard), logger.Error(err))
+		// not found error is mapped to codes.NotFound
+		return nil, errcode.GRPCError(err)
+	}
+	return resp, nil
+}
+
+// FetchSnapshot sends the file of snapshot in chunks from the offset.
+func (r *ReplicaHandler) FetchSnapshot(request *protoReplicaV1.FetchSnapshotRequest,
+	server protoReplicaV1.ReplicaService_FetchSnapshotServer,
+) error {
+	err := r.snapshotMgr.ReadSnapshot(request.SnapshotID, request.FamilyTime, table.FileNumber(request.FileNumber), request.Offset,
+		func(chunk *protoReplicaV1.SnapshotChunk) error {
+			return server.Send(chunk)
+		})
+	if err != nil {
+		r.logger.Warn("send snapshot file err",
+			logger.String("snapshotID", request.SnapshotID), logger.Int64("familyTime", request.FamilyTime),
+			logger.Int64("fileNumber", request.FileNumber), logger.Error(err))
+		// replica re-creates snapshot after snapshot released(mapped to codes.NotFound)
+		return errcode.GRPCError(err)
+	}
+	return nil
+}
+
+// ReleaseSnapshot releases the snapshot after new replica installed it.
+func (r *ReplicaHandler) ReleaseSnapshot(_ context.Context,
+	request *protoReplicaV1.ReleaseSnapshotRequest,
+) (*protoReplicaV1.ReleaseSnapshotResponse, error) {
+	r.snapshotMgr.ReleaseSnapshot(request.SnapshotID)
+	return &protoReplicaV1.ReleaseSnapshotResponse{}, nil
+}
+
+// checkBootstrapping rejects the replication from leader when shard replica is bootstrapping from snapshot.
+func (r *ReplicaHandler) checkBootstrapping(database string, shardID models.ShardID) error {
+	if r.receiver.IsBootstrapping(database, shardID) {
+		return errcode.GRPCError(errReplicaBootstrapping)
+	}
+	return nil
+}
+
 // getReplicaStateFromCtx gets replica relationship metadata from rpc context.
 func (r *ReplicaHandler) getReplicaStateFromCtx(ctx context.Context) (replicatorState models.ReplicaState, err error) {
 	replicaStateData, err := rpc.GetStringFromContext(ctx, constants.RPCMetaReplicaState)
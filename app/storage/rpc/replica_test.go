@@ -25,9 +25,14 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/errcode"
 	protoReplicaV1 "github.com/lindb/lindb/proto/gen/v1/replica"
 	"github.com/lindb/lindb/replica"
 )
@@ -39,19 +44,28 @@ func TestReplicaHandler_Replica(t *testing.T) {
 	}()
 
 	walMgr := replica.NewMockWriteAheadLogManager(ctrl)
+	receiver := replica.NewMockSnapshotReceiver(ctrl)
 	replicaServer := protoReplicaV1.NewMockReplicaService_ReplicaServer(ctrl)
-	r := NewReplicaHandler(walMgr)
+	r := NewReplicaHandler(walMgr, nil, receiver)
 
-	// case 5: create partition err
 	ctx := metadata.NewIncomingContext(context.TODO(),
 		metadata.Pairs(
 			constants.RPCMetaReplicaState, `{"database":"test-db","shardId":1,"leader":2,"follower":3}`,
 		))
 	replicaServer.EXPECT().Context().Return(ctx).AnyTimes()
+	// case 4: shard replica is bootstrapping
+	receiver.EXPECT().IsBootstrapping("test-db", models.ShardID(1)).Return(true)
+	err := r.Replica(replicaServer)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, errcode.StorageUnavailable, errcode.CodeOf(err))
+	assert.True(t, errcode.IsRetriable(err))
+
+	// case 5: create partition err
+	receiver.EXPECT().IsBootstrapping(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
 	wal := replica.NewMockWriteAheadLog(ctrl)
 	walMgr.EXPECT().GetOrCreateLog(gomock.Any()).Return(wal).AnyTimes()
 	wal.EXPECT().GetOrCreatePartition(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
-	err := r.Replica(replicaServer)
+	err = r.Replica(replicaServer)
 	assert.Error(t, err)
 
 	// case 6: build replica replica err
@@ -87,3 +101,48 @@ func TestReplicaHandler_Replica(t *testing.T) {
 	err = r.Replica(replicaServer)
 	assert.NoError(t, err)
 }
+
+func TestReplicaHandler_Snapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	snapshotMgr := replica.NewMockSnapshotManager(ctrl)
+	r := NewReplicaHandler(nil, snapshotMgr, nil)
+
+	// get snapshot
+	snapshotMgr.EXPECT().CreateSnapshot("test-db", models.ShardID(1)).Return(nil, constants.ErrShardNotFound)
+	_, err := r.GetSnapshot(context.TODO(), &protoReplicaV1.GetSnapshotRequest{Database: "test-db", Shard: 1})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	snapshotMgr.EXPECT().CreateSnapshot("test-db", models.ShardID(1)).Return(nil, fmt.Errorf("err"))
+	_, err = r.GetSnapshot(context.TODO(), &protoReplicaV1.GetSnapshotRequest{Database: "test-db", Shard: 1})
+	assert.Equal(t, codes.Internal, status.Code(err))
+	snapshotMgr.EXPECT().CreateSnapshot("test-db", models.ShardID(1)).
+		Return(&protoReplicaV1.GetSnapshotResponse{SnapshotID: "id"}, nil)
+	resp, err := r.GetSnapshot(context.TODO(), &protoReplicaV1.GetSnapshotRequest{Database: "test-db", Shard: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, "id", resp.SnapshotID)
+
+	// fetch snapshot
+	server := protoReplicaV1.NewMockReplicaService_FetchSnapshotServer(ctrl)
+	req := &protoReplicaV1.FetchSnapshotRequest{SnapshotID: "id", FamilyTime: 10, FileNumber: 5, Offset: 100}
+	snapshotMgr.EXPECT().ReadSnapshot("id", int64(10), table.FileNumber(5), int64(100), gomock.Any()).
+		Return(replica.ErrSnapshotNotFound)
+	assert.Equal(t, codes.NotFound, status.Code(r.FetchSnapshot(req, server)))
+	snapshotMgr.EXPECT().ReadSnapshot("id", int64(10), table.FileNumber(5), int64(100), gomock.Any()).
+		Return(fmt.Errorf("err"))
+	assert.Equal(t, codes.Internal, status.Code(r.FetchSnapshot(req, server)))
+	chunk := &protoReplicaV1.SnapshotChunk{Offset: 100}
+	snapshotMgr.EXPECT().ReadSnapshot("id", int64(10), table.FileNumber(5), int64(100), gomock.Any()).
+		DoAndReturn(func(_ string, _ int64, _ table.FileNumber, _ int64,
+			fn func(chunk *protoReplicaV1.SnapshotChunk) error,
+		) error {
+			return fn(chunk)
+		})
+	server.EXPECT().Send(chunk).Return(nil)
+	assert.NoError(t, r.FetchSnapshot(req, server))
+
+	// release snapshot
+	snapshotMgr.EXPECT().ReleaseSnapshot("id")
+	_, err = r.ReleaseSnapshot(context.TODO(), &protoReplicaV1.ReleaseSnapshotRequest{SnapshotID: "id"})
+	assert.NoError(t, err)
+}
@@ -19,20 +19,21 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"io"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
 	"github.com/lindb/lindb/pkg/logger"
 	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/rpc"
 )
 
+var errReplicasEmpty = errcode.Wrap(errcode.InvalidArgument, errors.New("replicas cannot be empty"))
+
 // WriteHandler implements protoWriteV1.WriteServiceServer interface for handling write rpc request.
 type WriteHandler struct {
 	walMgr replica.WriteAheadLogManager
@@ -55,10 +56,15 @@ func (r *WriteHandler) Write(server protoWriteV1.WriteService_WriteServer) error
 	familyState, err := r.getFamilyInfoFromCtx(server.Context())
 	if err != nil {
 		r.logger.Error("get param err", logger.Error(err))
-		return status.Error(codes.InvalidArgument, err.Error())
+		return errcode.GRPCError(errcode.Wrap(errcode.InvalidArgument, err))
 	}
 	if len(familyState.Shard.Replica.Replicas) == 0 {
-		return status.Error(codes.InvalidArgument, "replicas cannot be empty")
+		return errcode.GRPCError(errReplicasEmpty)
+	}
+	// codec negotiated by broker, empty(old broker) means snappy
+	codec, err := replica.ParseChunkCodec(familyState.Compression)
+	if err != nil {
+		return errcode.GRPCError(errcode.Wrap(errcode.InvalidArgument, err))
 	}
 
 	p, err := r.getOrCreatePartition(
@@ -68,12 +74,12 @@ func (r *WriteHandler) Write(server protoWriteV1.WriteService_WriteServer) error
 		familyState.Shard.Leader)
 	if err != nil {
 		r.logger.Error("get or create wal partition err, when do write", logger.Error(err))
-		return status.Error(codes.Internal, err.Error())
+		return errcode.GRPCError(err)
 	}
 	err = p.BuildReplicaForLeader(familyState.Shard.Leader, familyState.Shard.Replica.Replicas)
 	if err != nil {
 		r.logger.Error("build replica replica err", logger.Error(err))
-		return status.Error(codes.Internal, err.Error())
+		return errcode.GRPCError(err)
 	}
 
 	// handle write request from stream
@@ -84,19 +90,22 @@ func (r *WriteHandler) Write(server protoWriteV1.WriteService_WriteServer) error
 		}
 		if err != nil {
 			r.logger.Error("receive write request err", logger.Error(err))
-			return status.Error(codes.Internal, err.Error())
+			return errcode.GRPCError(err)
 		}
 
 		resp := &protoWriteV1.WriteResponse{}
-		// write wal log
-		err = p.WriteLog(req.Record)
+		// write wal log(snappy block format)
+		record, err := codec.ToSnappy(req.Record)
+		if err == nil {
+			err = p.WriteLog(record)
+		}
 
 		if err != nil {
 			resp.Err = err.Error()
 		}
 
 		if err := server.Send(resp); err != nil {
-			return status.Error(codes.Internal, err.Error())
+			return errcode.GRPCError(err)
 		}
 	}
 }
@@ -25,10 +25,13 @@ import (
 	"testing"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/metadata"
 
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
 	"github.com/lindb/lindb/replica"
 )
@@ -102,3 +105,54 @@ func TestWriteHandler_Write(t *testing.T) {
 	err = r.Write(replicaServer)
 	assert.NoError(t, err)
 }
+
+func TestWriteHandler_Write_Compression(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	walMgr := replica.NewMockWriteAheadLogManager(ctrl)
+	replicaServer := protoWriteV1.NewMockWriteService_WriteServer(ctrl)
+	r := NewWriteHandler(walMgr)
+	familyState := func(compression string) context.Context {
+		return metadata.NewIncomingContext(context.TODO(),
+			metadata.Pairs(constants.RPCMetaKeyFamilyState, string(encoding.JSONMarshal(&models.FamilyState{
+				Database:    "test-db",
+				Shard:       models.ShardState{ID: 1, Leader: 2, Replica: models.Replica{Replicas: []models.NodeID{1, 2}}},
+				FamilyTime:  12321,
+				Compression: compression,
+			}))))
+	}
+
+	// unknown codec
+	replicaServer.EXPECT().Context().Return(familyState("lz4"))
+	assert.Error(t, r.Write(replicaServer))
+
+	wal := replica.NewMockWriteAheadLog(ctrl)
+	p := replica.NewMockPartition(ctrl)
+	walMgr.EXPECT().GetOrCreateLog(gomock.Any()).Return(wal).AnyTimes()
+	wal.EXPECT().GetOrCreatePartition(gomock.Any(), gomock.Any(), gomock.Any()).Return(p, nil).AnyTimes()
+	p.EXPECT().BuildReplicaForLeader(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+	// zstd chunk is transcoded into snappy block of write ahead log
+	raw := []byte("cpu,host=1.1.1.1 usage=1")
+	codec, _ := replica.ParseChunkCodec("zstd:3")
+	record, err := codec.Encode(nil, raw)
+	assert.NoError(t, err)
+	replicaServer.EXPECT().Context().Return(familyState("zstd:3"))
+	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Record: record}, nil)
+	p.EXPECT().WriteLog(gomock.Any()).DoAndReturn(func(msg []byte) error {
+		decoded, err := snappy.Decode(nil, msg)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, decoded)
+		return nil
+	})
+	replicaServer.EXPECT().Send(&protoWriteV1.WriteResponse{}).Return(nil)
+	// corrupted chunk
+	replicaServer.EXPECT().Recv().Return(&protoWriteV1.WriteRequest{Record: []byte("bad-data")}, nil)
+	replicaServer.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoWriteV1.WriteResponse) error {
+		assert.NotEmpty(t, resp.Err)
+		return nil
+	})
+	replicaServer.EXPECT().Recv().Return(nil, io.EOF)
+	assert.NoError(t, r.Write(replicaServer))
+}
@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/lindb/lindb/app"
@@ -42,13 +43,16 @@ import (
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/hostutil"
 	httppkg "github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/objstore"
 	"github.com/lindb/lindb/pkg/state"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	protoReplicaV1 "github.com/lindb/lindb/proto/gen/v1/replica"
 	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
@@ -56,9 +60,21 @@ import (
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series/tag"
+	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb"
 )
 
+// EmbeddedStorage represents the storage runtime embedded in process(e.g. integration tests of other services).
+type EmbeddedStorage interface {
+	server.Service
+	// ForceFlush flushes memory data of all databases to disk after the rows in write ahead log are replicated,
+	// blocks until the rows appended are persisted.
+	ForceFlush(ctx context.Context) error
+	// ExecuteLocalQuery executes the data query against the local shards of database without broker round trip,
+	// returns the intermediate time series and the stats of leaf task.
+	ExecuteLocalQuery(ctx context.Context, db string, statement *stmt.Query) (*query.LocalResultSet, error)
+}
+
 // factory represents all factories for storage
 type factory struct {
 	taskServer rpc.TaskServerFactory
@@ -82,9 +98,13 @@ var (
 	mkDirIfNotExistFn         = fileutil.MkDirIfNotExist
 	readFileFn                = os.ReadFile
 	writeFileFn               = os.WriteFile
+	newTLSProvider            = rpc.NewTLSProvider
+	initTracingFn             = tracing.Init
 
 	atoiFn  = strconv.Atoi
 	existFn = fileutil.Exist
+
+	forceFlushCheckInterval = 50 * time.Millisecond
 )
 
 // runtime represents storage runtime dependency
@@ -107,9 +127,26 @@ type runtime struct {
 	stateMgr            storage.StateManager
 	walMgr              replica.WriteAheadLogManager
 	dbLifecycle         DatabaseLifecycle
+	readiness           ReadinessEvaluator
+	diskWatchdog        DiskWatchdog
+	configWatcher       ConfigWatcher
+	consistencyChecker  ConsistencyChecker
+	flushPauseWatcher   FlushPauseWatcher
+	snapshotMgr         replica.SnapshotManager
+	snapshotReceiver    replica.SnapshotReceiver
+	replicaBootstrapper ReplicaBootstrapper
+	rollupBackfiller    RollupBackfiller
+	indexWarmer         IndexWarmer
+	tierScheduler       TierScheduler
+	admission           *concurrent.AdmissionController
+	localQuery          query.LocalQueryEngine
+	notReady            bool             // readiness registered in node's info
+	load                *models.NodeLoad // load registered in node's info
+	nodeInfoLock        sync.Mutex       // guards readiness/load pushed into node's info
 
 	node            *models.StatefulNode
 	server          rpc.GRPCServer
+	tlsProvider     rpc.TLSProvider
 	repoFactory     state.RepositoryFactory
 	repo            state.Repository
 	factory         factory
@@ -118,6 +155,7 @@ type runtime struct {
 	httpServer      httppkg.Server
 	queryPool       concurrent.Pool
 	globalKeyValues tag.Tags
+	shutdownTracing tracing.Shutdown
 
 	log *logger.Logger
 }
@@ -168,9 +206,15 @@ func (r *runtime) Run() error {
 	}
 
 	opt := kv.StoreOptions{
-		Dir: config.GlobalStorageConfig().TSDB.Dir,
+		Dir:              config.GlobalStorageConfig().TSDB.Dir,
+		OrphanFileAction: kv.OrphanFileAction(config.GlobalStorageConfig().TSDB.OrphanFileAction),
 	}
 	kv.Options.Store(&opt)
+	// files of old families are moved to object storage if tiering enabled
+	if err := r.initTierStorage(myID); err != nil {
+		r.state = server.Failed
+		return err
+	}
 	r.jobScheduler = kv.NewJobScheduler(r.ctx, opt)
 	r.jobScheduler.Startup() // startup kv compact job scheduler
 
@@ -189,6 +233,8 @@ func (r *runtime) Run() error {
 	}
 	r.node = &models.StatefulNode{
 		ID: models.NodeID(r.myID),
+		// broker compresses the data of write stream by the codecs supported by storage
+		WriteCompressions: replica.WriteCompressions,
 		StatelessNode: models.StatelessNode{
 			HostIP:     ip,
 			GRPCPort:   r.config.StorageBase.GRPC.Port,
@@ -204,15 +250,25 @@ func (r *runtime) Run() error {
 		{Key: []byte("namespace"), Value: []byte(r.config.Coordinator.Namespace)},
 	}
 	r.BaseRuntime = app.NewBaseRuntimeFn(r.ctx, r.config.Monitor, linmetric.StorageRegistry, r.globalKeyValues)
+	// init transport security of intra-cluster grpc before any connection created
+	if err = r.initGRPCTLS(); err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("init grpc tls failure, err: %s", err)
+	}
+	if err = r.initTracing(); err != nil {
+		r.state = server.Failed
+		return fmt.Errorf("init tracing failure, err: %s", err)
+	}
 
 	r.factory = factory{taskServer: rpc.NewTaskServerFactory()}
 	r.stateMgr = storage.NewStateManager(r.ctx, r.node, engine)
 
+	cliFct := rpc.NewClientStreamFactory(r.ctx, r.node, rpc.GetStorageClientConnFactory())
 	walMgr := newWriteAheadLogManagerFn(
 		r.ctx,
 		r.config.StorageBase.WAL,
 		r.node.ID, r.engine,
-		rpc.NewClientStreamFactory(r.ctx, r.node, rpc.GetStorageClientConnFactory()),
+		cliFct,
 		r.stateMgr,
 	)
 	if err = walMgr.Recovery(); err != nil {
@@ -220,11 +276,25 @@ func (r *runtime) Run() error {
 		return err
 	}
 	r.walMgr = walMgr
+	// snapshot transfer between leader and new replica when bootstrapping replica
+	r.snapshotMgr = replica.NewSnapshotManager(r.ctx, r.config.StorageBase.ReplicaBootstrap, r.engine)
+	r.snapshotReceiver = replica.NewSnapshotReceiver(r.config.StorageBase.ReplicaBootstrap,
+		r.engine, r.walMgr, r.stateMgr, cliFct)
+	r.indexWarmer = newIndexWarmer(r.ctx, r.config.StorageBase.IndexWarmup, config.GlobalStorageConfig().TSDB.Dir,
+		r.engine, func() bool {
+			return r.admission.Executing() > 0
+		})
+	r.readiness = newReadinessEvaluator(r.ctx, config.GlobalStorageConfig().TSDB.Dir,
+		r.config.StorageBase.Health.MinDiskFreePercent, r.engine, r.walMgr, r.indexWarmer)
 
 	// start tcp server
 	r.startTCPServer()
 	// start http server
 	r.startHTTPServer()
+	// open families in background, the progress of recovery is published by readiness check
+	go r.recoverFamilies()
+	// warm up index of most queried metrics after recovery, the progress of warm-up is published by readiness
+	r.indexWarmer.Start()
 
 	// start state repo
 	if err := r.startStateRepo(); err != nil {
@@ -247,6 +317,49 @@ func (r *runtime) Run() error {
 	if err := r.stateMachineFactory.Start(); err != nil {
 		return fmt.Errorf("start state machines error: %s", err)
 	}
+	// start readiness check, push readiness into node's registration info
+	r.startReadinessCheck()
+	// start load report, push load into node's registration info
+	r.startLoadReport()
+	// start disk space watchdog, pause compaction/writes before disk is full
+	watchPaths := []string{config.GlobalStorageConfig().TSDB.Dir, r.config.StorageBase.WAL.Dir}
+	if bufferDir := config.GlobalStorageConfig().TSDB.BufferRootDir(); bufferDir != "" {
+		// write buffers placed on dedicated device
+		watchPaths = append(watchPaths, bufferDir)
+	}
+	r.diskWatchdog = newDiskWatchdog(r.ctx, r.config.StorageBase.DiskWatchdog, watchPaths...)
+	r.diskWatchdog.Start()
+	// start config watcher, reload tsdb config without restart when config file changed
+	r.configWatcher = newConfigWatcher(r.ctx, config.StorageConfigFile(),
+		r.config.StorageBase.ConfigReloadInterval.Duration())
+	r.configWatcher.Start()
+	// start flush pause watcher, pause/resume flushing of database by master during maintenance
+	r.flushPauseWatcher = newFlushPauseWatcher(discoveryFactory, tsdb.GetFamilyManager())
+	if err := r.flushPauseWatcher.Start(); err != nil {
+		return fmt.Errorf("start flush pause watcher error: %s", err)
+	}
+	// start consistency checker, compute digests of shard replicas for master
+	r.consistencyChecker = newConsistencyChecker(r.ctx, r.config.StorageBase.ConsistencyCheck,
+		r.node.ID, discoveryFactory, r.stateMgr, r.engine, func() bool {
+			return r.admission.Executing() > 0
+		})
+	if err := r.consistencyChecker.Start(); err != nil {
+		return fmt.Errorf("start consistency checker error: %s", err)
+	}
+	// start tier scheduler, move the files of old families to object storage
+	r.tierScheduler = newTierScheduler(r.ctx, r.config.StorageBase.Tiering, r.engine)
+	r.tierScheduler.Start()
+	// start replica bootstrapper, transfer snapshot from leader for new shard replica
+	r.replicaBootstrapper = newReplicaBootstrapper(r.ctx, r.config.StorageBase.ReplicaBootstrap,
+		r.node.ID, discoveryFactory, r.snapshotReceiver)
+	if err := r.replicaBootstrapper.Start(); err != nil {
+		return fmt.Errorf("start replica bootstrapper error: %s", err)
+	}
+	// start rollup backfiller, roll up history data into added rollup intervals
+	r.rollupBackfiller = newRollupBackfiller(r.ctx, r.node.ID, discoveryFactory, r.engine)
+	if err := r.rollupBackfiller.Start(); err != nil {
+		return fmt.Errorf("start rollup backfiller error: %s", err)
+	}
 
 	// start system collector
 	r.SystemCollector()
@@ -322,6 +435,172 @@ func (r *runtime) MustRegisterStateFulNode() error {
 	return constants.ErrStatefulNodeExist
 }
 
+// recoverFamilies opens the families of writable interval after startup, newest first.
+func (r *runtime) recoverFamilies() {
+	if err := r.engine.RecoverFamilies(); err != nil {
+		r.log.Error("recover families failure", logger.Error(err))
+	}
+}
+
+// startReadinessCheck evaluates the readiness of storage node periodically.
+func (r *runtime) startReadinessCheck() {
+	interval := r.config.StorageBase.Health.CheckInterval.Duration()
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+				r.checkReadiness()
+			}
+		}
+	}()
+}
+
+// checkReadiness logs the readiness transition and pushes it into node's registration info,
+// so that master can avoid routing to not-ready node.
+func (r *runtime) checkReadiness() {
+	r.nodeInfoLock.Lock()
+	defer r.nodeInfoLock.Unlock()
+
+	readiness := r.readiness.Evaluate()
+	if readiness.Ready != r.notReady {
+		// readiness not changed
+		return
+	}
+	if readiness.Ready {
+		r.log.Info("storage node becomes ready", logger.Int("indicator", int(r.node.ID)))
+	} else {
+		r.log.Warn("storage node becomes not ready",
+			logger.Int("indicator", int(r.node.ID)), logger.Any("checks", readiness.Checks))
+	}
+	node := *r.node
+	node.NotReady = !readiness.Ready
+	node.Load = r.load
+	if err := r.repo.Update(r.ctx, constants.GetLiveNodePath(strconv.Itoa(int(r.node.ID))), encoding.JSONMarshal(&node)); err != nil {
+		// retry next time
+		r.log.Error("push readiness into node's registration info failure",
+			logger.Int("indicator", int(r.node.ID)), logger.Error(err))
+		return
+	}
+	r.notReady = node.NotReady
+}
+
+// startLoadReport reports the load of storage node periodically.
+func (r *runtime) startLoadReport() {
+	interval := r.config.StorageBase.Health.LoadReportInterval.Duration()
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+				r.reportLoad()
+			}
+		}
+	}()
+}
+
+// reportLoad collects the load(memory database/flush backlog/disk free) of storage node,
+// then pushes it into node's registration info, so that master knows if node is overloaded.
+func (r *runtime) reportLoad() {
+	load := collectNodeLoad(r.ctx, config.GlobalStorageConfig().TSDB.Dir)
+
+	r.nodeInfoLock.Lock()
+	defer r.nodeInfoLock.Unlock()
+
+	node := *r.node
+	node.NotReady = r.notReady
+	node.Load = load
+	if err := r.repo.Update(r.ctx, constants.GetLiveNodePath(strconv.Itoa(int(r.node.ID))), encoding.JSONMarshal(&node)); err != nil {
+		// retry next time
+		r.log.Warn("push load into node's registration info failure",
+			logger.Int("indicator", int(r.node.ID)), logger.Error(err))
+		return
+	}
+	r.load = load
+}
+
+// announceRestart pushes the deadline of restart into node's registration info before deregistering,
+// so that master defers leader reassignment of the shards on this node during rolling restart.
+func (r *runtime) announceRestart() {
+	gracePeriod := r.config.StorageBase.RestartGracePeriod.Duration()
+	if gracePeriod <= 0 {
+		return
+	}
+	r.nodeInfoLock.Lock()
+	defer r.nodeInfoLock.Unlock()
+
+	node := *r.node
+	node.NotReady = r.notReady
+	node.Load = r.load
+	node.MaintenanceUntil = timeutil.Now() + gracePeriod.Milliseconds()
+	if err := r.repo.Update(r.ctx, constants.GetLiveNodePath(strconv.Itoa(int(r.node.ID))), encoding.JSONMarshal(&node)); err != nil {
+		// master does normal failure handling
+		r.log.Warn("announce restart into node's registration info failure",
+			logger.Int("indicator", int(r.node.ID)), logger.Error(err))
+		return
+	}
+	r.log.Info("announced restart of storage node",
+		logger.Int("indicator", int(r.node.ID)), logger.Any("gracePeriod", gracePeriod))
+}
+
+// ForceFlush flushes memory data of all databases to disk after the rows in write ahead log are replicated,
+// blocks until the rows appended are persisted.
+// NOTICE: replicators acknowledge the sequence after memory database persisted, so flush until all acknowledged.
+func (r *runtime) ForceFlush(ctx context.Context) error {
+	if r.engine == nil || r.walMgr == nil {
+		return errors.New("storage is not running")
+	}
+	ticker := time.NewTicker(forceFlushCheckInterval)
+	defer ticker.Stop()
+	for {
+		if err := r.engine.ForceFlush(ctx); err != nil {
+			return err
+		}
+		if r.isReplicaPersisted() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExecuteLocalQuery executes the data query against the local shards of database without broker round trip.
+func (r *runtime) ExecuteLocalQuery(ctx context.Context, db string, statement *stmt.Query) (*query.LocalResultSet, error) {
+	if r.localQuery == nil {
+		return nil, errors.New("storage is not running")
+	}
+	return r.localQuery.ExecuteLocalQuery(ctx, db, statement)
+}
+
+// isReplicaPersisted checks if the rows appended into write ahead log are persisted by all replicators.
+func (r *runtime) isReplicaPersisted() bool {
+	for name := range r.engine.GetAllDatabases() {
+		for _, family := range r.walMgr.GetReplicaState(name) {
+			for _, peer := range family.Replicators {
+				if peer.ACK < family.Append {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
 // State returns current storage server state
 func (r *runtime) State() server.State {
 	return r.state
@@ -334,10 +613,22 @@ func (r *runtime) startStateRepo() error {
 		return fmt.Errorf("start storage state repository error:%s", err)
 	}
 	r.repo = repo
+	audit.InitAuditor(r.node.Indicator(), &r.config.Logging, repo, linmetric.StorageRegistry)
 	r.log.Info("start storage state repository successfully")
 	return nil
 }
 
+// initTracing initializes the tracer provider which exports the spans of storage query execution.
+// NOTE: in standalone mode, the tracer provider is initialized by broker runtime.
+func (r *runtime) initTracing() (err error) {
+	if config.StandaloneMode {
+		return nil
+	}
+	r.shutdownTracing, err = initTracingFn(r.ctx, r.config.Query.Tracing,
+		r.config.Query.SlowQueryThreshold.Duration(), "lindb-storage", r.node.Indicator())
+	return err
+}
+
 // Stop stops storage server
 func (r *runtime) Stop() {
 	r.log.Info("stopping storage server...")
@@ -345,13 +636,46 @@ func (r *runtime) Stop() {
 
 	r.Shutdown()
 
+	if r.configWatcher != nil {
+		r.configWatcher.Stop()
+	}
+	if r.consistencyChecker != nil {
+		r.consistencyChecker.Stop()
+	}
+	if r.flushPauseWatcher != nil {
+		r.flushPauseWatcher.Stop()
+	}
+	if r.replicaBootstrapper != nil {
+		r.replicaBootstrapper.Stop()
+	}
+	if r.rollupBackfiller != nil {
+		r.rollupBackfiller.Stop()
+	}
+	if r.indexWarmer != nil {
+		r.indexWarmer.Stop()
+	}
+	if r.tierScheduler != nil {
+		r.tierScheduler.Stop()
+	}
+	if r.snapshotMgr != nil {
+		r.snapshotMgr.Close()
+	}
 	if r.jobScheduler != nil {
 		r.jobScheduler.Shutdown()
 	}
+	if r.shutdownTracing != nil {
+		// flush the pending spans of query
+		if err := r.shutdownTracing(r.ctx); err != nil {
+			r.log.Error("shutdown tracing error", logger.Error(err))
+		}
+	}
 
+	// close auditor before state repo
+	audit.CloseAuditor()
 	// close state repo if exist
 	if r.repo != nil {
 		r.log.Info("closing state repo...")
+		r.announceRestart()
 		if err := r.repo.Delete(r.ctx, constants.GetLiveNodePath(strconv.Itoa(int(r.node.ID)))); err != nil {
 			r.log.Warn("delete storage node register info")
 		}
@@ -413,8 +737,16 @@ func (r *runtime) startHTTPServer() {
 	configAPI.Register(v1)
 	requestAPI := stateapi.NewRequestAPI()
 	requestAPI.Register(v1)
+	deadLetterAPI := stateapi.NewDeadLetterAPI()
+	deadLetterAPI.Register(v1)
+	metricUsageAPI := stateapi.NewMetricUsageAPI()
+	metricUsageAPI.Register(v1)
 	metadataAPI := stateapi.NewMetadataAPI(r.engine)
 	metadataAPI.Register(v1)
+	diagnosticsAPI := api.NewDiagnosticsAPI(r.node, r.config, r.config.Logging.Dir, r.diagnosticsItems()...)
+	diagnosticsAPI.Register(v1)
+	healthAPI := api.NewHealthAPI(r.readiness.Evaluate)
+	healthAPI.Register(r.httpServer.GetRootRouter())
 
 	go func() {
 		if err := r.httpServer.Run(); err != http.ErrServerClosed {
@@ -424,9 +756,63 @@ func (r *runtime) startHTTPServer() {
 	}()
 }
 
+// familyStats represents the stats of data family in diagnostics bundle.
+type familyStats struct {
+	Database string                 `json:"database"`
+	Family   string                 `json:"family"`
+	Flushing bool                   `json:"flushing"`
+	State    models.DataFamilyState `json:"state"`
+}
+
+// diagnosticsItems returns the items of diagnostics bundle for storage, includes family stats and cluster state.
+func (r *runtime) diagnosticsItems() []api.BundleItem {
+	return []api.BundleItem{
+		{
+			Name: "families",
+			Collect: func() (interface{}, error) {
+				var rs []familyStats
+				tsdb.GetFamilyManager().WalkEntry(func(family tsdb.DataFamily) {
+					rs = append(rs, familyStats{
+						Database: family.Shard().Database().Name(),
+						Family:   family.Indicator(),
+						Flushing: family.IsFlushing(),
+						State:    family.GetState(),
+					})
+				})
+				return rs, nil
+			},
+		},
+		{
+			Name: "cluster_state",
+			Collect: func() (interface{}, error) {
+				return map[string]interface{}{
+					"liveNodes":           r.stateMgr.GetLiveNodes(),
+					"databaseAssignments": r.stateMgr.GetDatabaseAssignments(),
+				}, nil
+			},
+		},
+	}
+}
+
+// initGRPCTLS initializes transport security of intra-cluster grpc server/clients if tls enabled.
+func (r *runtime) initGRPCTLS() error {
+	tlsCfg := r.config.StorageBase.GRPC.TLS
+	if !tlsCfg.Enabled {
+		return nil
+	}
+	tlsProvider, err := newTLSProvider(r.ctx, tlsCfg, linmetric.StorageRegistry)
+	if err != nil {
+		return err
+	}
+	r.tlsProvider = tlsProvider
+	rpc.GetStorageClientConnFactory().SetTLSProvider(tlsProvider)
+	r.log.Info("grpc tls enabled", logger.String("clientAuth", tlsCfg.ClientAuth))
+	return nil
+}
+
 // startTCPServer starts tcp server
 func (r *runtime) startTCPServer() {
-	r.server = rpc.NewGRPCServer(r.config.StorageBase.GRPC, linmetric.StorageRegistry)
+	r.server = rpc.NewGRPCServer(r.config.StorageBase.GRPC, linmetric.StorageRegistry, r.tlsProvider)
 
 	// bind rpc handlers
 	r.bindRPCHandlers()
@@ -441,14 +827,25 @@ func (r *runtime) startTCPServer() {
 // bindRPCHandlers binds rpc handlers, registers task into grpc server
 func (r *runtime) bindRPCHandlers() {
 	//FIXME: (stone1100) need close
+	admissionCfg := r.config.StorageBase.QueryAdmission
+	r.admission = concurrent.NewAdmissionController(
+		admissionCfg.MaxConcurrentQueries,
+		int64(admissionCfg.MaxInflightScanBytes),
+		admissionCfg.MaxQueueSize,
+		admissionCfg.QueueTimeout.Duration(),
+		metrics.NewQueryAdmissionStatistics(),
+	)
 	leafTaskProcessor := query.NewLeafTaskProcessor(
 		r.node,
 		r.engine,
 		r.factory.taskServer,
+		r.admission,
 	)
+	// local query shares the admission and timeout of leaf task
+	r.localQuery = query.NewLocalQueryEngine(r.engine, r.admission, r.config.Query.Timeout.Duration())
 
 	r.rpcHandler = &rpcHandler{
-		replica: rpchandler.NewReplicaHandler(r.walMgr),
+		replica: rpchandler.NewReplicaHandler(r.walMgr, r.snapshotMgr, r.snapshotReceiver),
 		write:   rpchandler.NewWriteHandler(r.walMgr),
 		task: query.NewTaskHandler(
 			r.config.Query,
@@ -463,6 +860,27 @@ func (r *runtime) bindRPCHandlers() {
 	protoCommonV1.RegisterTaskServiceServer(r.server.GetServer(), r.rpcHandler.task)
 }
 
+// initTierStorage initializes the object storage and the cache of local copies for tiering if tiering enabled,
+// object keys are scoped by myid, because nodes may share the object storage.
+func (r *runtime) initTierStorage(myID int) error {
+	cfg := r.config.StorageBase.Tiering
+	if !cfg.Enabled() {
+		return nil
+	}
+	store, err := objstore.New(cfg)
+	if err != nil {
+		return fmt.Errorf("create object storage for tiering error: %s", err)
+	}
+	tierStorage, err := kv.NewTierStorage(store, strconv.Itoa(myID), cfg.CacheDir, int64(cfg.CacheSize))
+	if err != nil {
+		return fmt.Errorf("create tier storage error: %s", err)
+	}
+	kv.SetTierStorage(tierStorage)
+	r.log.Info("tiering enabled, files of old families are moved to object storage",
+		logger.String("backend", cfg.Backend))
+	return nil
+}
+
 // initMyID initializes myid for storage server.
 func (r *runtime) initMyID() (int, error) {
 	dataPath := config.GlobalStorageConfig().TSDB.Dir
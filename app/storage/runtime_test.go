@@ -32,14 +32,18 @@ import (
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
 	storagepkg "github.com/lindb/lindb/coordinator/storage"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/internal/mock"
 	"github.com/lindb/lindb/internal/server"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/hostutil"
+	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	"github.com/lindb/lindb/replica"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/tsdb"
@@ -353,3 +357,240 @@ func TestStorage_Run_With_Wrong_MyID(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, server.Failed, r.State())
 }
+
+func TestStorage_checkReadiness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	evaluator := NewMockReadinessEvaluator(ctrl)
+	repo := state.NewMockRepository(ctrl)
+	r := &runtime{
+		ctx:       context.TODO(),
+		node:      &models.StatefulNode{ID: 1},
+		readiness: evaluator,
+		repo:      repo,
+		log:       logger.GetLogger("Storage", "Test"),
+	}
+	notReady := models.NewReadiness([]models.ReadinessCheck{{Name: diskCheck}})
+	ready := models.NewReadiness(nil)
+
+	// readiness not changed
+	evaluator.EXPECT().Evaluate().Return(ready)
+	r.checkReadiness()
+	assert.False(t, r.notReady)
+	// push readiness failure, retry next time
+	evaluator.EXPECT().Evaluate().Return(notReady)
+	repo.EXPECT().Update(gomock.Any(), constants.GetLiveNodePath("1"), gomock.Any()).Return(fmt.Errorf("err"))
+	r.checkReadiness()
+	assert.False(t, r.notReady)
+	// becomes not ready
+	evaluator.EXPECT().Evaluate().Return(notReady)
+	repo.EXPECT().Update(gomock.Any(), constants.GetLiveNodePath("1"), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, val []byte) error {
+			node := models.StatefulNode{}
+			assert.NoError(t, encoding.JSONUnmarshal(val, &node))
+			assert.True(t, node.NotReady)
+			return nil
+		})
+	r.checkReadiness()
+	assert.True(t, r.notReady)
+	assert.False(t, r.node.NotReady)
+	// becomes ready
+	evaluator.EXPECT().Evaluate().Return(ready)
+	repo.EXPECT().Update(gomock.Any(), constants.GetLiveNodePath("1"), gomock.Any()).Return(nil)
+	r.checkReadiness()
+	assert.False(t, r.notReady)
+}
+
+func TestStorage_announceRestart(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	cfg := config.NewDefaultStorageBase()
+	r := &runtime{
+		ctx:    context.TODO(),
+		config: &config.Storage{StorageBase: *cfg},
+		node:   &models.StatefulNode{ID: 1},
+		repo:   repo,
+		log:    logger.GetLogger("Storage", "Test"),
+	}
+	// announce failure
+	repo.EXPECT().Update(gomock.Any(), constants.GetLiveNodePath("1"), gomock.Any()).Return(fmt.Errorf("err"))
+	r.announceRestart()
+	// announce restart
+	now := timeutil.Now()
+	repo.EXPECT().Update(gomock.Any(), constants.GetLiveNodePath("1"), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, val []byte) error {
+			node := models.StatefulNode{}
+			assert.NoError(t, encoding.JSONUnmarshal(val, &node))
+			assert.GreaterOrEqual(t, node.MaintenanceUntil, now+cfg.RestartGracePeriod.Duration().Milliseconds())
+			return nil
+		})
+	r.announceRestart()
+	assert.Zero(t, r.node.MaintenanceUntil)
+	// disabled
+	r.config.StorageBase.RestartGracePeriod = 0
+	r.announceRestart()
+}
+
+func TestStorage_reportLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	r := &runtime{
+		ctx:      context.TODO(),
+		node:     &models.StatefulNode{ID: 1},
+		repo:     repo,
+		notReady: true,
+		log:      logger.GetLogger("Storage", "Test"),
+	}
+	// push load failure, retry next time
+	repo.EXPECT().Update(gomock.Any(), constants.GetLiveNodePath("1"), gomock.Any()).Return(fmt.Errorf("err"))
+	r.reportLoad()
+	assert.Nil(t, r.load)
+	// push load with readiness
+	repo.EXPECT().Update(gomock.Any(), constants.GetLiveNodePath("1"), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, val []byte) error {
+			node := models.StatefulNode{}
+			assert.NoError(t, encoding.JSONUnmarshal(val, &node))
+			assert.True(t, node.NotReady)
+			assert.NotNil(t, node.Load)
+			return nil
+		})
+	r.reportLoad()
+	assert.NotNil(t, r.load)
+	assert.Nil(t, r.node.Load)
+}
+
+func TestStorage_ForceFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	r, ok := NewStorageRuntime("version", 1, &cfg).(EmbeddedStorage)
+	assert.True(t, ok)
+	// not running
+	assert.Error(t, r.ForceFlush(context.TODO()))
+
+	engine := tsdb.NewMockEngine(ctrl)
+	walMgr := replica.NewMockWriteAheadLogManager(ctrl)
+	r1 := r.(*runtime)
+	r1.engine = engine
+	r1.walMgr = walMgr
+	engine.EXPECT().GetAllDatabases().Return(map[string]tsdb.Database{"db": nil}).AnyTimes()
+
+	// flush failure
+	engine.EXPECT().ForceFlush(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, r.ForceFlush(context.TODO()))
+	// flush until replica persisted
+	engine.EXPECT().ForceFlush(gomock.Any()).Return(nil).Times(2)
+	gomock.InOrder(
+		walMgr.EXPECT().GetReplicaState("db").Return([]models.FamilyLogReplicaState{
+			{Append: 10, Replicators: []models.ReplicaPeerState{{ACK: 5}}},
+		}),
+		walMgr.EXPECT().GetReplicaState("db").Return([]models.FamilyLogReplicaState{
+			{Append: 10, Replicators: []models.ReplicaPeerState{{ACK: 10}}},
+		}),
+	)
+	assert.NoError(t, r.ForceFlush(context.TODO()))
+	// timeout
+	engine.EXPECT().ForceFlush(gomock.Any()).Return(nil).AnyTimes()
+	walMgr.EXPECT().GetReplicaState("db").Return([]models.FamilyLogReplicaState{
+		{Append: 10, Replicators: []models.ReplicaPeerState{{ACK: 5}}},
+	}).AnyTimes()
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, r.ForceFlush(ctx), context.DeadlineExceeded)
+}
+
+func TestStorage_initGRPCTLS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newTLSProvider = rpc.NewTLSProvider
+		rpc.GetStorageClientConnFactory().SetTLSProvider(nil)
+		ctrl.Finish()
+	}()
+
+	tlsCfg := cfg
+	r := &runtime{
+		ctx:    context.TODO(),
+		config: &tlsCfg,
+		log:    logger.GetLogger("Storage", "Test"),
+	}
+	// tls disabled
+	assert.NoError(t, r.initGRPCTLS())
+	assert.Nil(t, r.tlsProvider)
+
+	tlsCfg.StorageBase.GRPC.TLS.Enabled = true
+	newTLSProvider = func(_ context.Context, _ config.TLS, _ *linmetric.Registry) (rpc.TLSProvider, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, r.initGRPCTLS())
+	assert.Nil(t, r.tlsProvider)
+
+	tlsProvider := rpc.NewMockTLSProvider(ctrl)
+	newTLSProvider = func(_ context.Context, _ config.TLS, _ *linmetric.Registry) (rpc.TLSProvider, error) {
+		return tlsProvider, nil
+	}
+	assert.NoError(t, r.initGRPCTLS())
+	assert.Equal(t, tlsProvider, r.tlsProvider)
+}
+
+func TestStorage_initTracing(t *testing.T) {
+	defer func() {
+		initTracingFn = tracing.Init
+		config.StandaloneMode = false
+	}()
+
+	r := &runtime{
+		ctx:    context.TODO(),
+		config: &cfg,
+		node:   &models.StatefulNode{ID: 1},
+		log:    logger.GetLogger("Storage", "Test"),
+	}
+	initTracingFn = func(_ context.Context, _ config.Tracing, _ time.Duration, _, _ string) (tracing.Shutdown, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, r.initTracing())
+	// tracer provider initialized by broker in standalone mode
+	config.StandaloneMode = true
+	assert.NoError(t, r.initTracing())
+	assert.Nil(t, r.shutdownTracing)
+}
+
+func TestStorage_diagnosticsItems(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := tsdb.NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db")
+	shard := tsdb.NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(db)
+	family := tsdb.NewMockDataFamily(ctrl)
+	family.EXPECT().Indicator().Return("db/1/20221010").AnyTimes()
+	family.EXPECT().Shard().Return(shard)
+	family.EXPECT().IsFlushing().Return(true)
+	family.EXPECT().GetState().Return(models.DataFamilyState{ShardID: 1})
+	tsdb.GetFamilyManager().AddFamily(family)
+	defer tsdb.GetFamilyManager().RemoveFamily(family)
+
+	stateMgr := storagepkg.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetLiveNodes().Return(nil)
+	stateMgr.EXPECT().GetDatabaseAssignments().Return(nil)
+
+	r := &runtime{stateMgr: stateMgr}
+	items := r.diagnosticsItems()
+	assert.Len(t, items, 2)
+	rs, err := items[0].Collect()
+	assert.NoError(t, err)
+	assert.Equal(t, []familyStats{{
+		Database: "db",
+		Family:   "db/1/20221010",
+		Flushing: true,
+		State:    models.DataFamilyState{ShardID: 1},
+	}}, rs)
+	rs, err = items[1].Collect()
+	assert.NoError(t, err)
+	assert.Len(t, rs, 2)
+}
@@ -0,0 +1,129 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./tier_scheduler.go -destination=./tier_scheduler_mock.go -package=storage
+
+// TierScheduler represents the scheduler of storage node which moves the files of families older than
+// the tier after option of database to object storage periodically, shards are tiered one by one with
+// throttled upload rate, so that tiering never competes with writes/queries for network and disk.
+type TierScheduler interface {
+	// Start starts tiering periodically if tiering enabled.
+	Start()
+	// Stop stops tiering.
+	Stop()
+}
+
+// tierScheduler implements TierScheduler interface.
+type tierScheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	cfg     config.Tiering
+	engine  tsdb.Engine
+	limiter *rate.Limiter
+
+	logger *logger.Logger
+}
+
+// newTierScheduler creates a TierScheduler instance.
+func newTierScheduler(ctx context.Context, cfg config.Tiering, engine tsdb.Engine) TierScheduler {
+	c, cancel := context.WithCancel(ctx)
+	uploadRate := int(cfg.MaxUploadRate)
+	limit := rate.Limit(uploadRate)
+	if uploadRate <= 0 {
+		limit = rate.Inf
+	}
+	return &tierScheduler{
+		ctx:     c,
+		cancel:  cancel,
+		cfg:     cfg,
+		engine:  engine,
+		limiter: rate.NewLimiter(limit, uploadRate),
+		logger:  logger.GetLogger("Storage", "TierScheduler"),
+	}
+}
+
+// Start starts tiering periodically if tiering enabled.
+func (s *tierScheduler) Start() {
+	if !s.cfg.Enabled() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(s.cfg.CheckInterval.Duration())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+				s.tier()
+			}
+		}
+	}()
+}
+
+// Stop stops tiering.
+func (s *tierScheduler) Stop() {
+	s.cancel()
+}
+
+// tier moves the files of old families of all databases to object storage.
+func (s *tierScheduler) tier() {
+	for name, db := range s.engine.GetAllDatabases() {
+		start := time.Now()
+		tiered, err := db.TierFamilies(s.ctx, s.throttle)
+		if s.ctx.Err() != nil {
+			// scheduler stopped
+			return
+		}
+		if err != nil {
+			s.logger.Warn("tier families of database failure",
+				logger.String("database", name), logger.Error(err))
+			continue
+		}
+		if tiered > 0 {
+			s.logger.Info("tier families of database successfully",
+				logger.String("database", name),
+				logger.Int("families", tiered),
+				logger.String("elapsed", time.Since(start).String()))
+		}
+	}
+}
+
+// throttle limits the upload rate of tiering.
+func (s *tierScheduler) throttle(ctx context.Context, bytes int) error {
+	if s.limiter.Limit() == rate.Inf {
+		return nil
+	}
+	if bytes > s.limiter.Burst() {
+		bytes = s.limiter.Burst()
+	}
+	return s.limiter.WaitN(ctx, bytes)
+}
@@ -0,0 +1,79 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestTierScheduler_disabled(t *testing.T) {
+	s := newTierScheduler(context.TODO(), config.Tiering{}, nil)
+	s.Start()
+	s.Stop()
+}
+
+func TestTierScheduler_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	db1 := tsdb.NewMockDatabase(ctrl)
+	db2 := tsdb.NewMockDatabase(ctrl)
+	engine.EXPECT().GetAllDatabases().Return(map[string]tsdb.Database{"db1": db1, "db2": db2}).AnyTimes()
+	db1.EXPECT().TierFamilies(gomock.Any(), gomock.Any()).Return(0, fmt.Errorf("err")).AnyTimes()
+	tiered := make(chan struct{})
+	db2.EXPECT().TierFamilies(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, throttle kv.Throttle) (int, error) {
+			assert.NoError(t, throttle(ctx, 1024))
+			select {
+			case <-tiered:
+			default:
+				close(tiered)
+			}
+			return 1, nil
+		}).MinTimes(1)
+	s := newTierScheduler(context.TODO(), config.Tiering{
+		Backend:       "archive",
+		CheckInterval: ltoml.Duration(time.Millisecond),
+		MaxUploadRate: ltoml.Size(512),
+	}, engine)
+	s.Start()
+	<-tiered
+	s.Stop()
+}
+
+func TestTierScheduler_throttle(t *testing.T) {
+	s := newTierScheduler(context.TODO(), config.Tiering{}, nil).(*tierScheduler)
+	assert.NoError(t, s.throttle(context.TODO(), 1024))
+
+	s = newTierScheduler(context.TODO(), config.Tiering{MaxUploadRate: 1}, nil).(*tierScheduler)
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.Error(t, s.throttle(ctx, 1024))
+}
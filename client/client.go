@@ -0,0 +1,303 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package client provides the go client of LinDB cluster, which writes metric data and executes query via broker http api.
+//
+// The client sends requests to one of broker endpoints, switches to next endpoint if current endpoint fails,
+// the endpoints are refreshed periodically based on the alive brokers of cluster.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	resty "github.com/go-resty/resty/v2"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
+)
+
+// for testing
+var (
+	newTimerFn = time.NewTimer
+)
+
+// ErrNoBroker represents no broker endpoint is configured.
+var ErrNoBroker = errors.New("broker endpoint not found")
+
+// Config represents the configuration of client.
+type Config struct {
+	// Brokers represents the addresses of broker(e.g. http://127.0.0.1:9000), at least one.
+	Brokers []string
+	// Timeout represents the timeout of each http request, default: 10s.
+	Timeout time.Duration
+	// MaxRetries represents the max retries of request on retriable error, default: 3.
+	MaxRetries int
+	// RetryBackoff represents the initial backoff before retrying, doubled for each retry, default: 100ms.
+	RetryBackoff time.Duration
+	// MaxRetryBackoff represents the max backoff before retrying, default: 5s.
+	MaxRetryBackoff time.Duration
+	// DiscoveryInterval represents the interval of refreshing alive broker endpoints, default: 1m, negative means disabled.
+	DiscoveryInterval time.Duration
+}
+
+// withDefault returns the configuration with default values.
+func (cfg Config) withDefault() Config {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.MaxRetries < 0 {
+		cfg.MaxRetries = 0
+	} else if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 100 * time.Millisecond
+	}
+	if cfg.MaxRetryBackoff <= 0 {
+		cfg.MaxRetryBackoff = 5 * time.Second
+	}
+	if cfg.MaxRetryBackoff < cfg.RetryBackoff {
+		cfg.MaxRetryBackoff = cfg.RetryBackoff
+	}
+	if cfg.DiscoveryInterval == 0 {
+		cfg.DiscoveryInterval = time.Minute
+	}
+	return cfg
+}
+
+// ResponseError represents the error response of broker.
+type ResponseError struct {
+	StatusCode int
+	Message    string
+	// Code represents the stable error code, empty if broker responses error without code.
+	Code errcode.Code
+	// Retriable represents if the request can be retried, only valid if code not empty.
+	Retriable bool
+}
+
+// Error returns the message of error response.
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("status code: %d, message: %s", e.StatusCode, e.Message)
+}
+
+// Client represents the client of LinDB cluster.
+type Client interface {
+	// NewWriter creates an asynchronous writer which writes metric data into database,
+	// the writer stops if ctx is cancelled.
+	NewWriter(ctx context.Context, database string, cfg WriterConfig) Writer
+	// Query executes the query of database, then returns the result.
+	Query(ctx context.Context, database, sql string) (*QueryResult, error)
+	// Execute executes lin query language, then unmarshals the result into rs.
+	Execute(ctx context.Context, param models.ExecuteParam, rs interface{}) error
+	// Close stops refreshing broker endpoints, NOTE: the writers need to be closed by caller.
+	Close()
+}
+
+// client implements Client interface.
+type client struct {
+	cfg       Config
+	endpoints *brokerEndpoints
+	cli       *resty.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wait   sync.WaitGroup
+}
+
+// NewClient creates the client of LinDB cluster.
+func NewClient(cfg Config) (Client, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, ErrNoBroker
+	}
+	cfg = cfg.withDefault()
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &client{
+		cfg:       cfg,
+		endpoints: newBrokerEndpoints(cfg.Brokers),
+		cli:       resty.New().SetTimeout(cfg.Timeout),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+	if cfg.DiscoveryInterval > 0 {
+		c.wait.Add(1)
+		go c.discover()
+	}
+	return c, nil
+}
+
+// NewWriter creates an asynchronous writer which writes metric data into database.
+func (c *client) NewWriter(ctx context.Context, database string, cfg WriterConfig) Writer {
+	return newWriter(ctx, c, database, cfg)
+}
+
+// Query executes the query of database, then returns the result.
+func (c *client) Query(ctx context.Context, database, sql string) (*QueryResult, error) {
+	rs := models.NewResultSet()
+	if err := c.Execute(ctx, models.ExecuteParam{Database: database, SQL: sql}, rs); err != nil {
+		return nil, err
+	}
+	return &QueryResult{ResultSet: rs}, nil
+}
+
+// Execute executes lin query language, then unmarshals the result into rs.
+func (c *client) Execute(ctx context.Context, param models.ExecuteParam, rs interface{}) error {
+	_, err := c.do(ctx, func(ctx context.Context, endpoint string) error {
+		resp, err := c.cli.R().
+			SetContext(ctx).
+			SetBody(&param).
+			SetHeader("Accept", "application/json").
+			Put(endpoint + "/exec")
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() != http.StatusOK {
+			return newResponseError(resp)
+		}
+		if data := resp.Body(); rs != nil && len(data) > 0 {
+			return encoding.JSONUnmarshal(data, rs)
+		}
+		return nil
+	})
+	return err
+}
+
+// Close stops refreshing broker endpoints.
+func (c *client) Close() {
+	c.cancel()
+	c.wait.Wait()
+}
+
+// do sends the request to broker endpoint, retries it on next endpoint with backoff if retriable error,
+// returns the num. of retries.
+func (c *client) do(ctx context.Context, request func(ctx context.Context, endpoint string) error) (retries int, err error) {
+	backoff := c.cfg.RetryBackoff
+	for {
+		idx, endpoint := c.endpoints.get()
+		err = request(ctx, endpoint)
+		if err == nil || !isRetriable(ctx, err) || retries >= c.cfg.MaxRetries {
+			return retries, err
+		}
+		c.endpoints.failover(idx)
+		retries++
+		timer := newTimerFn(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return retries, ctx.Err()
+		case <-timer.C:
+		}
+		backoff *= 2
+		if backoff > c.cfg.MaxRetryBackoff {
+			backoff = c.cfg.MaxRetryBackoff
+		}
+	}
+}
+
+// discover refreshes the alive broker endpoints periodically.
+func (c *client) discover() {
+	defer c.wait.Done()
+
+	ticker := time.NewTicker(c.cfg.DiscoveryInterval)
+	defer ticker.Stop()
+
+	c.refreshEndpoints()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.refreshEndpoints()
+		}
+	}
+}
+
+// refreshEndpoints refreshes the endpoints based on the alive brokers of cluster, keeps current endpoints if failure.
+func (c *client) refreshEndpoints() {
+	var nodes []models.StatelessNode
+	if err := c.Execute(c.ctx, models.ExecuteParam{SQL: "show broker alive"}, &nodes); err != nil || len(nodes) == 0 {
+		return
+	}
+	var addresses []string
+	for idx := range nodes {
+		addresses = append(addresses, nodes[idx].HTTPAddress())
+	}
+	c.endpoints.update(addresses)
+}
+
+// newResponseError creates the error of response, message of response is structured error with code,
+// or json string responded by old version broker.
+func newResponseError(resp *resty.Response) error {
+	respErr := &ResponseError{StatusCode: resp.StatusCode(), Message: string(resp.Body())}
+	var structured struct {
+		Error     string       `json:"error"`
+		Code      errcode.Code `json:"code"`
+		Retriable bool         `json:"retriable"`
+	}
+	var msg string
+	switch {
+	case encoding.JSONUnmarshal(resp.Body(), &structured) == nil && structured.Code != "":
+		respErr.Message = structured.Error
+		respErr.Code = structured.Code
+		respErr.Retriable = structured.Retriable
+	case encoding.JSONUnmarshal(resp.Body(), &msg) == nil:
+		respErr.Message = msg
+	}
+	respErr.Message = strings.TrimSpace(respErr.Message)
+	return respErr
+}
+
+// isRetriable returns if the request can be retried(on other broker),
+// network error/broker overloaded/unavailable are retriable, retriable flag is preferred if response with error code.
+func isRetriable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var respErr *ResponseError
+	if !errors.As(err, &respErr) {
+		// network error
+		return true
+	}
+	if respErr.Code != "" {
+		return respErr.Retriable
+	}
+	switch respErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// endpointOf returns the api endpoint of broker address.
+func endpointOf(address string) string {
+	address = strings.TrimSuffix(strings.TrimSpace(address), "/")
+	if !strings.Contains(address, "://") {
+		address = "http://" + address
+	}
+	if strings.HasSuffix(address, constants.APIVersion1CliPath) {
+		return address
+	}
+	return address + constants.APIVersion1CliPath
+}
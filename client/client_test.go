@@ -0,0 +1,211 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
+)
+
+// newBroker creates a mock broker which responses the request of exec api by handler.
+func newBroker(handler func(w http.ResponseWriter, param *models.ExecuteParam)) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/exec", func(w http.ResponseWriter, r *http.Request) {
+		param := &models.ExecuteParam{}
+		_ = encoding.JSONUnmarshal(readBody(r), param)
+		handler(w, param)
+	})
+	return httptest.NewServer(mux)
+}
+
+func response(w http.ResponseWriter, code int, content interface{}) {
+	w.WriteHeader(code)
+	_, _ = w.Write(encoding.JSONMarshal(content))
+}
+
+func TestNewClient(t *testing.T) {
+	cli, err := NewClient(Config{})
+	assert.Equal(t, ErrNoBroker, err)
+	assert.Nil(t, cli)
+
+	cli, err = NewClient(Config{Brokers: []string{"127.0.0.1:9000"}, DiscoveryInterval: -1, MaxRetries: -1})
+	assert.NoError(t, err)
+	cfg := cli.(*client).cfg
+	assert.Equal(t, 10*time.Second, cfg.Timeout)
+	assert.Equal(t, 0, cfg.MaxRetries)
+	assert.Equal(t, 100*time.Millisecond, cfg.RetryBackoff)
+	assert.Equal(t, 5*time.Second, cfg.MaxRetryBackoff)
+	cli.Close()
+
+	cfg = Config{RetryBackoff: time.Minute}.withDefault()
+	assert.Equal(t, 3, cfg.MaxRetries)
+	assert.Equal(t, time.Minute, cfg.MaxRetryBackoff)
+	assert.Equal(t, time.Minute, cfg.DiscoveryInterval)
+}
+
+func TestClient_Query(t *testing.T) {
+	unavailable := newBroker(func(w http.ResponseWriter, _ *models.ExecuteParam) {
+		response(w, http.StatusServiceUnavailable, "unavailable")
+	})
+	defer unavailable.Close()
+	broker := newBroker(func(w http.ResponseWriter, param *models.ExecuteParam) {
+		switch param.SQL {
+		case "select f from cpu":
+			assert.Equal(t, "test", param.Database)
+			response(w, http.StatusOK, &models.ResultSet{MetricName: "cpu", Fields: []string{"f"}})
+		case "select f from disk":
+			response(w, http.StatusInternalServerError, map[string]interface{}{
+				"error": "too many series matched for query", "code": errcode.SeriesLimitExceeded,
+				"retriable": false, "message": "too many series matched for query",
+			})
+		default:
+			response(w, http.StatusInternalServerError, "metric not found")
+		}
+	})
+	defer broker.Close()
+
+	cli, err := NewClient(Config{
+		Brokers:           []string{unavailable.URL, broker.URL},
+		RetryBackoff:      time.Millisecond,
+		DiscoveryInterval: -1,
+	})
+	assert.NoError(t, err)
+	defer cli.Close()
+
+	// fail over to next broker
+	rs, err := cli.Query(context.TODO(), "test", "select f from cpu")
+	assert.NoError(t, err)
+	assert.Equal(t, "cpu", rs.MetricName)
+	_, endpoint := cli.(*client).endpoints.get()
+	assert.Equal(t, broker.URL+"/api/v1", endpoint)
+
+	// non-retriable error
+	rs, err = cli.Query(context.TODO(), "test", "select f from mem")
+	assert.Nil(t, rs)
+	assert.Equal(t, &ResponseError{StatusCode: http.StatusInternalServerError, Message: "metric not found"}, err)
+
+	// structured error with code
+	rs, err = cli.Query(context.TODO(), "test", "select f from disk")
+	assert.Nil(t, rs)
+	assert.Equal(t, &ResponseError{
+		StatusCode: http.StatusInternalServerError,
+		Message:    "too many series matched for query",
+		Code:       errcode.SeriesLimitExceeded,
+	}, err)
+}
+
+func TestClient_do(t *testing.T) {
+	cli, err := NewClient(Config{
+		Brokers:           []string{"1.1.1.1:9000", "1.1.1.2:9000"},
+		MaxRetries:        2,
+		RetryBackoff:      time.Millisecond,
+		MaxRetryBackoff:   2 * time.Millisecond,
+		DiscoveryInterval: -1,
+	})
+	assert.NoError(t, err)
+	defer cli.Close()
+	c := cli.(*client)
+
+	t.Run("retries exhausted", func(t *testing.T) {
+		var endpoints []string
+		retries, err := c.do(context.TODO(), func(_ context.Context, endpoint string) error {
+			endpoints = append(endpoints, endpoint)
+			return &ResponseError{StatusCode: http.StatusTooManyRequests}
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 2, retries)
+		assert.Equal(t, []string{
+			"http://1.1.1.1:9000/api/v1", "http://1.1.1.2:9000/api/v1", "http://1.1.1.1:9000/api/v1",
+		}, endpoints)
+	})
+	t.Run("context cancelled when backoff", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.TODO())
+		retries, err := c.do(ctx, func(_ context.Context, _ string) error {
+			cancel()
+			return errors.New("connection refused")
+		})
+		assert.Equal(t, 0, retries)
+		assert.EqualError(t, err, "connection refused")
+
+		ctx, cancel = context.WithCancel(context.TODO())
+		defer cancel()
+		newTimerFn = func(_ time.Duration) *time.Timer {
+			cancel()
+			return time.NewTimer(time.Hour)
+		}
+		defer func() {
+			newTimerFn = time.NewTimer
+		}()
+		retries, err = c.do(ctx, func(_ context.Context, _ string) error {
+			return errors.New("connection refused")
+		})
+		assert.Equal(t, 1, retries)
+		assert.Equal(t, context.Canceled, err)
+	})
+}
+
+func TestClient_discover(t *testing.T) {
+	discovered := atomic.NewBool(false)
+	var broker *httptest.Server
+	broker = newBroker(func(w http.ResponseWriter, param *models.ExecuteParam) {
+		assert.Equal(t, "show broker alive", param.SQL)
+		response(w, http.StatusOK, []models.StatelessNode{{HostIP: "1.1.1.1", HTTPPort: 9000}})
+		discovered.Store(true)
+	})
+	defer broker.Close()
+
+	cli, err := NewClient(Config{Brokers: []string{broker.URL}, DiscoveryInterval: time.Hour})
+	assert.NoError(t, err)
+	defer cli.Close()
+	assert.Eventually(t, discovered.Load, time.Second, time.Millisecond)
+	c := cli.(*client)
+	assert.Eventually(t, func() bool {
+		c.endpoints.mutex.RLock()
+		defer c.endpoints.mutex.RUnlock()
+		return len(c.endpoints.endpoints) == 2
+	}, time.Second, time.Millisecond)
+	c.endpoints.mutex.RLock()
+	assert.Equal(t, []string{"http://1.1.1.1:9000/api/v1", broker.URL + "/api/v1"}, c.endpoints.endpoints)
+	c.endpoints.mutex.RUnlock()
+}
+
+func TestIsRetriable(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.False(t, isRetriable(ctx, errors.New("err")))
+	assert.True(t, isRetriable(context.TODO(), errors.New("connection refused")))
+	assert.True(t, isRetriable(context.TODO(), &ResponseError{StatusCode: http.StatusBadGateway}))
+	assert.False(t, isRetriable(context.TODO(), &ResponseError{StatusCode: http.StatusBadRequest}))
+	// retriable flag is preferred if response with error code
+	assert.True(t, isRetriable(context.TODO(),
+		&ResponseError{StatusCode: http.StatusInternalServerError, Code: errcode.NodeBusy, Retriable: true}))
+	assert.False(t, isRetriable(context.TODO(),
+		&ResponseError{StatusCode: http.StatusServiceUnavailable, Code: errcode.WriteRejected}))
+	assert.Equal(t, "status code: 400, message: bad", (&ResponseError{StatusCode: 400, Message: "bad"}).Error())
+}
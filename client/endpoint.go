@@ -0,0 +1,89 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import "sync"
+
+// brokerEndpoints represents the api endpoints of brokers, requests are sent to current endpoint,
+// switches to next endpoint if current endpoint fails.
+type brokerEndpoints struct {
+	seeds     []string
+	endpoints []string
+	current   int
+	mutex     sync.RWMutex
+}
+
+// newBrokerEndpoints creates the broker endpoints with the addresses of broker.
+func newBrokerEndpoints(addresses []string) *brokerEndpoints {
+	seeds := dedupEndpoints(addresses)
+	return &brokerEndpoints{
+		seeds:     seeds,
+		endpoints: seeds,
+	}
+}
+
+// get returns the current endpoint and its index.
+func (e *brokerEndpoints) get() (idx int, endpoint string) {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	return e.current, e.endpoints[e.current]
+}
+
+// failover switches to next endpoint if the failure endpoint is still current endpoint.
+func (e *brokerEndpoints) failover(idx int) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if idx == e.current {
+		e.current = (e.current + 1) % len(e.endpoints)
+	}
+}
+
+// update updates endpoints with the alive brokers(seeds are kept for recovering), keeps using current endpoint if alive.
+func (e *brokerEndpoints) update(addresses []string) {
+	endpoints := dedupEndpoints(append(addresses, e.seeds...))
+
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	current := e.endpoints[e.current]
+	e.endpoints = endpoints
+	e.current = 0
+	for idx, endpoint := range endpoints {
+		if endpoint == current {
+			e.current = idx
+			break
+		}
+	}
+}
+
+// dedupEndpoints returns the api endpoints of addresses without duplicate, keeps the order of addresses.
+func dedupEndpoints(addresses []string) []string {
+	var endpoints []string
+	exist := make(map[string]struct{})
+	for _, address := range addresses {
+		endpoint := endpointOf(address)
+		if _, ok := exist[endpoint]; ok {
+			continue
+		}
+		exist[endpoint] = struct{}{}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointOf(t *testing.T) {
+	cases := []struct {
+		address  string
+		endpoint string
+	}{
+		{address: "127.0.0.1:9000", endpoint: "http://127.0.0.1:9000/api/v1"},
+		{address: " http://127.0.0.1:9000/ ", endpoint: "http://127.0.0.1:9000/api/v1"},
+		{address: "https://broker:9000", endpoint: "https://broker:9000/api/v1"},
+		{address: "http://broker:9000/api/v1", endpoint: "http://broker:9000/api/v1"},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.address, func(t *testing.T) {
+			assert.Equal(t, tt.endpoint, endpointOf(tt.address))
+		})
+	}
+}
+
+func TestBrokerEndpoints(t *testing.T) {
+	endpoints := newBrokerEndpoints([]string{"1.1.1.1:9000", "http://1.1.1.1:9000", "1.1.1.2:9000"})
+	assert.Equal(t, []string{"http://1.1.1.1:9000/api/v1", "http://1.1.1.2:9000/api/v1"}, endpoints.endpoints)
+	idx, endpoint := endpoints.get()
+	assert.Equal(t, 0, idx)
+	assert.Equal(t, "http://1.1.1.1:9000/api/v1", endpoint)
+
+	// switch to next endpoint
+	endpoints.failover(0)
+	idx, endpoint = endpoints.get()
+	assert.Equal(t, 1, idx)
+	assert.Equal(t, "http://1.1.1.2:9000/api/v1", endpoint)
+	// failure of stale endpoint, keep current endpoint
+	endpoints.failover(0)
+	idx, _ = endpoints.get()
+	assert.Equal(t, 1, idx)
+	// round robin
+	endpoints.failover(1)
+	idx, _ = endpoints.get()
+	assert.Equal(t, 0, idx)
+
+	// alive brokers discovered, keep current endpoint and seeds
+	endpoints.failover(0)
+	endpoints.update([]string{"http://1.1.1.3:9000", "http://1.1.1.2:9000"})
+	assert.Equal(t, []string{
+		"http://1.1.1.3:9000/api/v1", "http://1.1.1.2:9000/api/v1", "http://1.1.1.1:9000/api/v1",
+	}, endpoints.endpoints)
+	_, endpoint = endpoints.get()
+	assert.Equal(t, "http://1.1.1.2:9000/api/v1", endpoint)
+	// current endpoint not alive
+	endpoints.failover(1)
+	endpoints.failover(2)
+	_, endpoint = endpoints.get()
+	assert.Equal(t, "http://1.1.1.3:9000/api/v1", endpoint)
+	endpoints.update([]string{"http://1.1.1.4:9000"})
+	idx, endpoint = endpoints.get()
+	assert.Equal(t, 0, idx)
+	assert.Equal(t, "http://1.1.1.4:9000/api/v1", endpoint)
+}
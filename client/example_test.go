@@ -0,0 +1,76 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/client"
+)
+
+func ExampleWriter() {
+	cli, err := client.NewClient(client.Config{
+		Brokers: []string{"http://127.0.0.1:9000", "http://127.0.0.2:9000"},
+	})
+	if err != nil {
+		panic(err)
+	}
+	defer cli.Close()
+
+	writer := cli.NewWriter(context.Background(), "_internal", client.WriterConfig{
+		BatchSize:     500,
+		FlushInterval: time.Second,
+	})
+	// metrics are buffered, then written in batch asynchronously
+	_ = writer.Write(context.Background(), &protoMetricsV1.Metric{
+		Name:      "cpu",
+		Timestamp: time.Now().UnixMilli(),
+		Tags:      []*protoMetricsV1.KeyValue{{Key: "host", Value: "host1"}},
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "usage", Type: protoMetricsV1.SimpleFieldType_LAST, Value: 0.5},
+		},
+	})
+	// write remaining metrics before exit
+	if err := writer.Close(); err != nil {
+		fmt.Println(err)
+	}
+	stats := writer.Stats()
+	fmt.Printf("written: %d, dropped: %d, latency: %s\n", stats.Written, stats.Dropped, stats.LastLatency)
+}
+
+func ExampleQueryResult_Pivot() {
+	cli, err := client.NewClient(client.Config{Brokers: []string{"http://127.0.0.1:9000"}})
+	if err != nil {
+		panic(err)
+	}
+	defer cli.Close()
+
+	rs, err := cli.Query(context.Background(), "_internal", "select usage from cpu where time>now()-1h group by host")
+	if err != nil {
+		panic(err)
+	}
+	values := rs.Pivot("usage")
+	for _, timestamp := range rs.Timestamps() {
+		// series key => value, e.g. host=host1 => 0.5
+		fmt.Println(timestamp, values[timestamp])
+	}
+}
@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/lindb/lindb/models"
+)
+
+// QueryResult represents the result of metric data query.
+type QueryResult struct {
+	*models.ResultSet
+}
+
+// SeriesKey returns the key of series, formatted as tagKey=tagValue joined by comma in the order of group by tag keys.
+func (r *QueryResult) SeriesKey(series *models.Series) string {
+	var sb strings.Builder
+	for idx, tagKey := range r.GroupBy {
+		if idx > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(tagKey)
+		sb.WriteString("=")
+		sb.WriteString(series.Tags[tagKey])
+	}
+	return sb.String()
+}
+
+// Timestamps returns the sorted timestamps of all series.
+func (r *QueryResult) Timestamps() []int64 {
+	exist := make(map[int64]struct{})
+	var timestamps []int64
+	for _, series := range r.Series {
+		for _, points := range series.Fields {
+			for timestamp := range points {
+				if _, ok := exist[timestamp]; ok {
+					continue
+				}
+				exist[timestamp] = struct{}{}
+				timestamps = append(timestamps, timestamp)
+			}
+		}
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i] < timestamps[j]
+	})
+	return timestamps
+}
+
+// Pivot returns the values of field indexed by timestamp then series key, e.g. timestamp => host=a => value.
+func (r *QueryResult) Pivot(field string) map[int64]map[string]float64 {
+	result := make(map[int64]map[string]float64)
+	for _, series := range r.Series {
+		seriesKey := r.SeriesKey(series)
+		for timestamp, value := range series.Fields[field] {
+			values, ok := result[timestamp]
+			if !ok {
+				values = make(map[string]float64)
+				result[timestamp] = values
+			}
+			values[seriesKey] = value
+		}
+	}
+	return result
+}
+
+// PivotSeries returns the values of series indexed by timestamp then field name, e.g. timestamp => field => value.
+func PivotSeries(series *models.Series) map[int64]map[string]float64 {
+	result := make(map[int64]map[string]float64)
+	for field, points := range series.Fields {
+		for timestamp, value := range points {
+			values, ok := result[timestamp]
+			if !ok {
+				values = make(map[string]float64)
+				result[timestamp] = values
+			}
+			values[field] = value
+		}
+	}
+	return result
+}
@@ -0,0 +1,54 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+)
+
+func TestQueryResult_Pivot(t *testing.T) {
+	rs := &QueryResult{ResultSet: &models.ResultSet{
+		GroupBy: []string{"host", "app"},
+		Series: []*models.Series{
+			{
+				Tags:   map[string]string{"host": "a", "app": "x"},
+				Fields: map[string]map[int64]float64{"f": {10: 1, 20: 2}, "g": {30: 3}},
+			},
+			{
+				Tags:   map[string]string{"host": "b", "app": "y"},
+				Fields: map[string]map[int64]float64{"f": {20: 4}},
+			},
+		},
+	}}
+	assert.Equal(t, "host=a,app=x", rs.SeriesKey(rs.Series[0]))
+	assert.Equal(t, []int64{10, 20, 30}, rs.Timestamps())
+	assert.Equal(t, map[int64]map[string]float64{
+		10: {"host=a,app=x": 1},
+		20: {"host=a,app=x": 2, "host=b,app=y": 4},
+	}, rs.Pivot("f"))
+	assert.Empty(t, rs.Pivot("h"))
+	assert.Equal(t, map[int64]map[string]float64{
+		10: {"f": 1},
+		20: {"f": 2},
+		30: {"g": 3},
+	}, PivotSeries(rs.Series[0]))
+}
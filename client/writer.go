@@ -0,0 +1,358 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/gzip"
+	"go.uber.org/atomic"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/constants"
+)
+
+var (
+	// ErrWriterClosed represents the writer is closed or its context is cancelled.
+	ErrWriterClosed = errors.New("writer is closed")
+	// ErrBufferFull represents the buffer of writer is full, the metric is dropped.
+	ErrBufferFull = errors.New("write buffer is full, metric dropped")
+)
+
+// WriterConfig represents the configuration of asynchronous writer.
+type WriterConfig struct {
+	// Namespace represents the namespace of metric data, default: default-ns.
+	Namespace string
+	// BatchSize represents the max metrics of one write request, default: 1000.
+	BatchSize int
+	// FlushInterval represents the interval of writing the buffered metrics, default: 1s.
+	FlushInterval time.Duration
+	// BufferSize represents the max metrics waiting for writing, new metric is dropped if buffer is full, default: 10000.
+	BufferSize int
+	// DisableCompression disables compressing write request with gzip.
+	DisableCompression bool
+}
+
+// withDefault returns the configuration with default values.
+func (cfg WriterConfig) withDefault() WriterConfig {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10000
+	}
+	return cfg
+}
+
+// WriterStats represents the statistics of writer.
+type WriterStats struct {
+	Written       int64         // metrics written successfully
+	Dropped       int64         // metrics dropped(buffer full, write failure after retries or writer stopped)
+	Batches       int64         // write requests succeeded
+	FailedBatches int64         // write requests failed after retries
+	Retries       int64         // retries of write requests
+	LastLatency   time.Duration // latency of last write request(includes retries)
+	MaxLatency    time.Duration // max latency of write request(includes retries)
+}
+
+// Writer represents the asynchronous writer which buffers metrics, then writes them in batch.
+type Writer interface {
+	// Write adds the metric into buffer, returns ErrBufferFull if buffer is full.
+	Write(ctx context.Context, metric *protoMetricsV1.Metric) error
+	// Flush writes all buffered metrics, then returns the error of writing.
+	Flush(ctx context.Context) error
+	// Stats returns the statistics of writer.
+	Stats() WriterStats
+	// Close writes all buffered metrics, then stops the writer.
+	Close() error
+}
+
+// flushRequest represents the request of flushing buffered metrics.
+type flushRequest struct {
+	ctx  context.Context
+	done chan error
+}
+
+// writer implements Writer interface.
+type writer struct {
+	ctx      context.Context
+	cli      *client
+	database string
+	cfg      WriterConfig
+	id       string // prefix of idempotency token
+	seq      int64  // sequence of idempotency token
+
+	metrics chan *protoMetricsV1.Metric
+	flushes chan *flushRequest
+	closing chan struct{}
+	stopped chan struct{}
+	closed  bool
+	lastErr error
+	mutex   sync.RWMutex
+
+	batch  []*protoMetricsV1.Metric
+	buffer bytes.Buffer
+	gzip   *gzip.Writer
+
+	statistics struct {
+		written       atomic.Int64
+		dropped       atomic.Int64
+		batches       atomic.Int64
+		failedBatches atomic.Int64
+		retries       atomic.Int64
+		lastLatency   atomic.Duration
+		maxLatency    atomic.Duration
+	}
+}
+
+// newWriter creates an asynchronous writer, then starts writing in background.
+func newWriter(ctx context.Context, cli *client, database string, cfg WriterConfig) Writer {
+	cfg = cfg.withDefault()
+	w := &writer{
+		ctx:      ctx,
+		cli:      cli,
+		database: database,
+		cfg:      cfg,
+		id:       uuid.New().String(),
+		metrics:  make(chan *protoMetricsV1.Metric, cfg.BufferSize),
+		flushes:  make(chan *flushRequest),
+		closing:  make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	w.gzip = gzip.NewWriter(&w.buffer)
+	go w.run()
+	return w
+}
+
+// Write adds the metric into buffer, returns ErrBufferFull if buffer is full.
+func (w *writer) Write(ctx context.Context, metric *protoMetricsV1.Metric) error {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+
+	if w.closed || w.ctx.Err() != nil {
+		return ErrWriterClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case w.metrics <- metric:
+		return nil
+	default:
+		w.statistics.dropped.Inc()
+		return ErrBufferFull
+	}
+}
+
+// Flush writes all buffered metrics, then returns the error of writing.
+func (w *writer) Flush(ctx context.Context) error {
+	req := &flushRequest{ctx: ctx, done: make(chan error, 1)}
+	select {
+	case w.flushes <- req:
+	case <-w.stopped:
+		return ErrWriterClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the statistics of writer.
+func (w *writer) Stats() WriterStats {
+	return WriterStats{
+		Written:       w.statistics.written.Load(),
+		Dropped:       w.statistics.dropped.Load(),
+		Batches:       w.statistics.batches.Load(),
+		FailedBatches: w.statistics.failedBatches.Load(),
+		Retries:       w.statistics.retries.Load(),
+		LastLatency:   w.statistics.lastLatency.Load(),
+		MaxLatency:    w.statistics.maxLatency.Load(),
+	}
+}
+
+// Close writes all buffered metrics, then stops the writer, returns the last error of writing.
+func (w *writer) Close() error {
+	w.mutex.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.closing)
+	}
+	w.mutex.Unlock()
+
+	<-w.stopped
+	return w.lastErr
+}
+
+// run writes the buffered metrics when batch is full or flush interval reached.
+func (w *writer) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(w.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case metric := <-w.metrics:
+			w.batch = append(w.batch, metric)
+			if len(w.batch) >= w.cfg.BatchSize {
+				w.lastErr = w.flush(w.ctx)
+			}
+		case <-ticker.C:
+			w.lastErr = w.flush(w.ctx)
+		case req := <-w.flushes:
+			w.drain()
+			w.lastErr = w.flush(req.ctx)
+			req.done <- w.lastErr
+		case <-w.closing:
+			if w.ctx.Err() != nil {
+				w.discard()
+				return
+			}
+			// write remaining metrics with new context, because writer is stopped after closing
+			w.drain()
+			w.lastErr = w.flush(context.Background())
+			return
+		case <-w.ctx.Done():
+			w.discard()
+			return
+		}
+	}
+}
+
+// discard drops all buffered metrics because the context of writer is cancelled.
+func (w *writer) discard() {
+	w.drain()
+	w.statistics.dropped.Add(int64(len(w.batch)))
+	w.batch = nil
+	w.lastErr = ErrWriterClosed
+}
+
+// drain moves all buffered metrics into batch.
+func (w *writer) drain() {
+	for {
+		select {
+		case metric := <-w.metrics:
+			w.batch = append(w.batch, metric)
+		default:
+			return
+		}
+	}
+}
+
+// flush writes the metrics of batch, split into multiple requests if exceeding batch size.
+func (w *writer) flush(ctx context.Context) (err error) {
+	for len(w.batch) > 0 {
+		size := len(w.batch)
+		if size > w.cfg.BatchSize {
+			size = w.cfg.BatchSize
+		}
+		if writeErr := w.writeBatch(ctx, w.batch[:size]); writeErr != nil {
+			err = writeErr
+		}
+		w.batch = w.batch[size:]
+	}
+	w.batch = nil
+	return err
+}
+
+// writeBatch writes the metrics with idempotency token, the batch is dropped if failure after retries.
+func (w *writer) writeBatch(ctx context.Context, metrics []*protoMetricsV1.Metric) error {
+	data, err := w.encode(metrics)
+	if err != nil {
+		w.statistics.failedBatches.Inc()
+		w.statistics.dropped.Add(int64(len(metrics)))
+		return err
+	}
+	w.seq++
+	// retry via other broker is not re-written if the batch is already written
+	token := fmt.Sprintf("%s-%d", w.id, w.seq)
+	start := time.Now()
+	retries, err := w.cli.do(ctx, func(ctx context.Context, endpoint string) error {
+		req := w.cli.cli.R().
+			SetContext(ctx).
+			SetQueryParam("db", w.database).
+			SetHeader("Content-Type", constants.ContentTypeProto).
+			SetHeader(constants.IdempotencyKeyHeader, token).
+			SetBody(data)
+		if w.cfg.Namespace != "" {
+			req.SetQueryParam("ns", w.cfg.Namespace)
+		}
+		if !w.cfg.DisableCompression {
+			req.SetHeader("Content-Encoding", "gzip")
+		}
+		resp, err := req.Put(endpoint + "/write")
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode() != http.StatusNoContent && resp.StatusCode() != http.StatusOK {
+			return newResponseError(resp)
+		}
+		return nil
+	})
+	latency := time.Since(start)
+	w.statistics.lastLatency.Store(latency)
+	if latency > w.statistics.maxLatency.Load() {
+		w.statistics.maxLatency.Store(latency)
+	}
+	w.statistics.retries.Add(int64(retries))
+	if err != nil {
+		w.statistics.failedBatches.Inc()
+		w.statistics.dropped.Add(int64(len(metrics)))
+		return err
+	}
+	w.statistics.batches.Inc()
+	w.statistics.written.Add(int64(len(metrics)))
+	return nil
+}
+
+// encode marshals the metrics as protobuf, then compresses it if enabled.
+func (w *writer) encode(metrics []*protoMetricsV1.Metric) ([]byte, error) {
+	list := &protoMetricsV1.MetricList{Metrics: metrics}
+	data, err := list.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if w.cfg.DisableCompression {
+		return data, nil
+	}
+	w.buffer.Reset()
+	w.gzip.Reset(&w.buffer)
+	if _, err := w.gzip.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.gzip.Close(); err != nil {
+		return nil, err
+	}
+	return append([]byte{}, w.buffer.Bytes()...), nil
+}
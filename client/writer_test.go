@@ -0,0 +1,220 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/constants"
+)
+
+// readBody reads the body of request, decompresses it if gzip encoded.
+func readBody(r *http.Request) []byte {
+	var reader io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil
+		}
+		reader = gzipReader
+	}
+	data, _ := io.ReadAll(reader)
+	return data
+}
+
+// mockWriteBroker represents a mock broker which records the write requests.
+type mockWriteBroker struct {
+	server   *httptest.Server
+	mutex    sync.Mutex
+	tokens   []string
+	batches  [][]*protoMetricsV1.Metric
+	failures int // num. of requests responded with unavailable
+	status   int // status code of response if not unavailable
+}
+
+func newMockWriteBroker(t *testing.T) *mockWriteBroker {
+	broker := &mockWriteBroker{status: http.StatusNoContent}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/write", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test", r.URL.Query().Get("db"))
+		assert.Equal(t, constants.ContentTypeProto, r.Header.Get("Content-Type"))
+		broker.mutex.Lock()
+		defer broker.mutex.Unlock()
+
+		broker.tokens = append(broker.tokens, r.Header.Get(constants.IdempotencyKeyHeader))
+		if broker.failures > 0 {
+			broker.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if broker.status != http.StatusNoContent {
+			w.WriteHeader(broker.status)
+			_, _ = w.Write([]byte(`"write failure"`))
+			return
+		}
+		list := &protoMetricsV1.MetricList{}
+		assert.NoError(t, list.Unmarshal(readBody(r)))
+		broker.batches = append(broker.batches, list.Metrics)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	broker.server = httptest.NewServer(mux)
+	return broker
+}
+
+func (b *mockWriteBroker) numOfBatches() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.batches)
+}
+
+func newTestClient(t *testing.T, broker *mockWriteBroker) Client {
+	cli, err := NewClient(Config{
+		Brokers:           []string{broker.server.URL},
+		RetryBackoff:      time.Millisecond,
+		DiscoveryInterval: -1,
+	})
+	assert.NoError(t, err)
+	return cli
+}
+
+func newMetric(name string) *protoMetricsV1.Metric {
+	return &protoMetricsV1.Metric{
+		Name:      name,
+		Timestamp: time.Now().UnixMilli(),
+		Tags:      []*protoMetricsV1.KeyValue{{Key: "host", Value: "a"}},
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1},
+		},
+	}
+}
+
+func TestWriter_Close(t *testing.T) {
+	broker := newMockWriteBroker(t)
+	defer broker.server.Close()
+	cli := newTestClient(t, broker)
+	defer cli.Close()
+
+	w := cli.NewWriter(context.TODO(), "test", WriterConfig{BatchSize: 2, FlushInterval: time.Hour})
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, w.Write(context.TODO(), newMetric("cpu")))
+	}
+	// flush remaining metrics when close
+	assert.NoError(t, w.Close())
+	assert.NoError(t, w.Close())
+	assert.Equal(t, ErrWriterClosed, w.Write(context.TODO(), newMetric("cpu")))
+	assert.Equal(t, ErrWriterClosed, w.Flush(context.TODO()))
+
+	total := 0
+	for _, batch := range broker.batches {
+		assert.LessOrEqual(t, len(batch), 2)
+		total += len(batch)
+	}
+	assert.Equal(t, 5, total)
+	stats := w.Stats()
+	assert.Equal(t, int64(5), stats.Written)
+	assert.Equal(t, int64(len(broker.batches)), stats.Batches)
+	assert.Zero(t, stats.Dropped)
+	assert.True(t, stats.MaxLatency >= stats.LastLatency)
+}
+
+func TestWriter_Flush(t *testing.T) {
+	broker := newMockWriteBroker(t)
+	defer broker.server.Close()
+	cli := newTestClient(t, broker)
+	defer cli.Close()
+
+	w := cli.NewWriter(context.TODO(), "test", WriterConfig{FlushInterval: time.Hour, DisableCompression: true, Namespace: "ns"})
+	defer func() {
+		assert.NoError(t, w.Close())
+	}()
+	// retry with same idempotency token
+	broker.failures = 1
+	assert.NoError(t, w.Write(context.TODO(), newMetric("cpu")))
+	assert.NoError(t, w.Flush(context.TODO()))
+	assert.Equal(t, 1, broker.numOfBatches())
+	assert.Len(t, broker.tokens, 2)
+	assert.Equal(t, broker.tokens[0], broker.tokens[1])
+	assert.Equal(t, int64(1), w.Stats().Retries)
+
+	// write failure, metrics dropped
+	broker.status = http.StatusInternalServerError
+	assert.NoError(t, w.Write(context.TODO(), newMetric("cpu")))
+	err := w.Flush(context.TODO())
+	assert.Equal(t, &ResponseError{StatusCode: http.StatusInternalServerError, Message: "write failure"}, err)
+	stats := w.Stats()
+	assert.Equal(t, int64(1), stats.Dropped)
+	assert.Equal(t, int64(1), stats.FailedBatches)
+	assert.NotEqual(t, broker.tokens[0], broker.tokens[2])
+	broker.status = http.StatusNoContent
+
+	// context of flush cancelled
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.Equal(t, context.Canceled, w.Flush(ctx))
+}
+
+func TestWriter_Write(t *testing.T) {
+	broker := newMockWriteBroker(t)
+	defer broker.server.Close()
+	cli := newTestClient(t, broker)
+	defer cli.Close()
+
+	w := cli.NewWriter(context.TODO(), "test", WriterConfig{BatchSize: 1, FlushInterval: time.Millisecond, BufferSize: 1})
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.Equal(t, context.Canceled, w.Write(ctx, newMetric("cpu")))
+	// write when batch is full
+	assert.NoError(t, w.Write(context.TODO(), newMetric("cpu")))
+	assert.Eventually(t, func() bool {
+		return broker.numOfBatches() == 1
+	}, time.Second, time.Millisecond)
+	assert.NoError(t, w.Close())
+
+	// buffer full(writer not running)
+	w = &writer{ctx: context.TODO(), metrics: make(chan *protoMetricsV1.Metric, 1)}
+	assert.NoError(t, w.Write(context.TODO(), newMetric("cpu")))
+	assert.Equal(t, ErrBufferFull, w.Write(context.TODO(), newMetric("cpu")))
+	assert.Equal(t, int64(1), w.Stats().Dropped)
+}
+
+func TestWriter_ContextCancelled(t *testing.T) {
+	broker := newMockWriteBroker(t)
+	defer broker.server.Close()
+	cli := newTestClient(t, broker)
+	defer cli.Close()
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	w := cli.NewWriter(ctx, "test", WriterConfig{FlushInterval: time.Hour})
+	assert.NoError(t, w.Write(context.TODO(), newMetric("cpu")))
+	cancel()
+	assert.Equal(t, ErrWriterClosed, w.Close())
+	assert.Equal(t, ErrWriterClosed, w.Write(context.TODO(), newMetric("cpu")))
+	assert.Equal(t, int64(1), w.Stats().Dropped)
+	assert.Zero(t, broker.numOfBatches())
+}
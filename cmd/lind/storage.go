@@ -18,14 +18,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/lindb/lindb/app/storage"
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/query"
+	"github.com/lindb/lindb/tsdb"
 )
 
 const (
@@ -34,12 +39,25 @@ const (
 	defaultStorageCfgFile = "./" + storageCfgName
 )
 
+var (
+	queryPath     string
+	queryDatabase string
+	querySQL      string
+	queryTimeout  time.Duration
+)
+
 var runStorageCmd = &cobra.Command{
 	Use:   "run",
 	Short: "starts the storage",
 	RunE:  serveStorage,
 }
 
+var queryStorageCmd = &cobra.Command{
+	Use:   "query",
+	Short: "query the database of data directory by read-only engine, e.g. a copied data directory or backup",
+	RunE:  queryStorage,
+}
+
 // newStorageCmd returns a new storage-cmd
 func newStorageCmd() *cobra.Command {
 	storageCmd := &cobra.Command{
@@ -54,10 +72,22 @@ func newStorageCmd() *cobra.Command {
 		"profiling Go programs with pprof")
 	runStorageCmd.PersistentFlags().IntVar(&myID, "myid", 1,
 		"unique server id for single storage cluster")
+	queryStorageCmd.Flags().StringVar(&queryPath, "path", "",
+		"data directory of time series engine(tsdb dir of storage)")
+	queryStorageCmd.Flags().StringVar(&queryDatabase, "database", "",
+		"database name")
+	queryStorageCmd.Flags().StringVar(&querySQL, "sql", "",
+		"data query sql, e.g. select f from cpu where time>now()-1h")
+	queryStorageCmd.Flags().DurationVar(&queryTimeout, "timeout", time.Minute,
+		"query timeout")
+	_ = queryStorageCmd.MarkFlagRequired("path")
+	_ = queryStorageCmd.MarkFlagRequired("database")
+	_ = queryStorageCmd.MarkFlagRequired("sql")
 
 	storageCmd.AddCommand(
 		runStorageCmd,
 		initializeStorageConfigCmd,
+		queryStorageCmd,
 	)
 	return storageCmd
 }
@@ -90,8 +120,27 @@ func serveStorage(_ *cobra.Command, _ []string) error {
 
 	// start storage server
 	storageRuntime := storage.NewStorageRuntime(config.Version, myID, &storageCfg)
-	return run(ctx, storageRuntime, func() error {
-		newStorageCfg := config.Storage{}
-		return config.LoadAndSetStorageConfig(cfg, defaultStorageCfgFile, &newStorageCfg)
-	})
+	// only tsdb config can be reloaded without restart
+	return run(ctx, storageRuntime, config.ReloadStorageConfig)
+}
+
+// queryStorage opens the data directory by read-only engine, then queries the database and prints the result set.
+// NOTICE: data directory must not be locked by running storage, query a copy of it if storage is running.
+func queryStorage(_ *cobra.Command, _ []string) error {
+	engine, err := tsdb.NewEngineWithOption(tsdb.EngineOption{Dir: queryPath, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer engine.Close()
+
+	db, ok := engine.GetDatabase(queryDatabase)
+	if !ok {
+		return fmt.Errorf("database: %s not found in path: %s", queryDatabase, queryPath)
+	}
+	rs, err := query.SearchLocal(context.Background(), db, querySQL, queryTimeout)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(encoding.JSONMarshal(rs)))
+	return nil
 }
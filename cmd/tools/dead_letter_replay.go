@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	resty "github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+)
+
+var (
+	storageEndpoint string
+	brokerEndpoint  string
+	replayBatchSize int
+)
+
+var replayDeadLetterCmd = &cobra.Command{
+	Use:   "replay-dead-letter",
+	Short: "Replay the dead-letters of storage node through broker write api",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		replayed, err := replayDeadLetters(resty.New(), storageEndpoint, brokerEndpoint, replayBatchSize)
+		fmt.Printf("replayed dead-letters: %d\n", replayed)
+		return err
+	},
+}
+
+func init() {
+	replayDeadLetterCmd.Flags().StringVar(&storageEndpoint, "storage", "http://localhost:2892",
+		"storage http endpoint which dead-letters read from")
+	replayDeadLetterCmd.Flags().StringVar(&brokerEndpoint, "broker", "http://localhost:9000",
+		"broker http endpoint which dead-letters write to")
+	replayDeadLetterCmd.Flags().IntVar(&replayBatchSize, "batch", 100,
+		"num. of dead-letters replayed in one batch")
+}
+
+// replayDeadLetters reads dead-letters from storage in batch, re-submits them through broker write api,
+// then acknowledges the replayed dead-letters.
+func replayDeadLetters(cli *resty.Client, storage, broker string, batchSize int) (replayed int, err error) {
+	from := int64(0)
+	for {
+		var letters []*models.DeadLetter
+		resp, err := cli.R().
+			SetQueryParam("from", strconv.FormatInt(from, 10)).
+			SetQueryParam("limit", strconv.Itoa(batchSize)).
+			SetResult(&letters).
+			ForceContentType("application/json").
+			Get(storage + constants.APIVersion1CliPath + "/state/dead-letter")
+		if err != nil {
+			return replayed, err
+		}
+		if resp.IsError() {
+			return replayed, fmt.Errorf("read dead-letters failure: %s", resp.String())
+		}
+		if len(letters) == 0 {
+			return replayed, nil
+		}
+		if err := writeDeadLetters(cli, broker, letters); err != nil {
+			return replayed, err
+		}
+		lastSeq := letters[len(letters)-1].Seq
+		resp, err = cli.R().
+			SetQueryParam("seq", strconv.FormatInt(lastSeq, 10)).
+			Put(storage + constants.APIVersion1CliPath + "/state/dead-letter/ack")
+		if err != nil {
+			return replayed, err
+		}
+		if resp.IsError() {
+			return replayed, fmt.Errorf("ack dead-letters failure: %s", resp.String())
+		}
+		replayed += len(letters)
+		from = lastSeq + 1
+	}
+}
+
+// writeDeadLetters writes dead-letters as flat rows grouped by database/namespace.
+func writeDeadLetters(cli *resty.Client, broker string, letters []*models.DeadLetter) error {
+	type target struct{ database, namespace string }
+	var targets []target
+	blocks := make(map[target]*bytes.Buffer)
+	for _, letter := range letters {
+		m := flatMetricsV1.GetRootAsMetric(letter.Row, 0)
+		t := target{database: letter.Database, namespace: string(m.Namespace())}
+		block, ok := blocks[t]
+		if !ok {
+			block = &bytes.Buffer{}
+			blocks[t] = block
+			targets = append(targets, t)
+		}
+		// size prefix + flat row
+		var size [4]byte
+		binary.LittleEndian.PutUint32(size[:], uint32(len(letter.Row)))
+		block.Write(size[:])
+		block.Write(letter.Row)
+	}
+	for _, t := range targets {
+		resp, err := cli.R().
+			SetQueryParam("db", t.database).
+			SetQueryParam("ns", t.namespace).
+			SetHeader("Content-Type", constants.ContentTypeFlat).
+			SetBody(blocks[t].Bytes()).
+			Post(broker + constants.APIVersion1CliPath + "/write")
+		if err != nil {
+			return err
+		}
+		if resp.IsError() {
+			return fmt.Errorf("write dead-letters into database[%s] failure: %s", t.database, resp.String())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	resty "github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	commonseries "github.com/lindb/common/series"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+func mockDeadLetterRow(t *testing.T, namespace string) []byte {
+	builder := commonseries.CreateRowBuilder()
+	builder.AddMetricName([]byte("cpu"))
+	builder.AddNameSpace([]byte(namespace))
+	assert.NoError(t, builder.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeDeltaSum, 1))
+	data, err := builder.Build()
+	assert.NoError(t, err)
+	// remove size prefix
+	return append([]byte(nil), data[4:]...)
+}
+
+func TestReplayDeadLetters(t *testing.T) {
+	letters := []*models.DeadLetter{
+		{Seq: 1, Database: "db", Row: mockDeadLetterRow(t, "ns1")},
+		{Seq: 2, Database: "db", Row: mockDeadLetterRow(t, "ns2")},
+		{Seq: 3, Database: "db", Row: mockDeadLetterRow(t, "ns1")},
+	}
+	var (
+		acked      []string
+		written    []string
+		readFail   bool
+		ackFail    bool
+		brokerFail bool
+	)
+	storage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case constants.APIVersion1CliPath + "/state/dead-letter":
+			if readFail {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			if r.URL.Query().Get("from") == "0" {
+				_, _ = w.Write(encoding.JSONMarshal(letters))
+			} else {
+				_, _ = w.Write([]byte("[]"))
+			}
+		case constants.APIVersion1CliPath + "/state/dead-letter/ack":
+			if ackFail {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			acked = append(acked, r.URL.Query().Get("seq"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer storage.Close()
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if brokerFail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, constants.ContentTypeFlat, r.Header.Get("Content-Type"))
+		assert.Equal(t, "db", r.URL.Query().Get("db"))
+		assert.NotEmpty(t, body)
+		written = append(written, r.URL.Query().Get("ns"))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer broker.Close()
+
+	cli := resty.New()
+	replayed, err := replayDeadLetters(cli, storage.URL, broker.URL, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, replayed)
+	assert.Equal(t, []string{"3"}, acked)
+	assert.Equal(t, []string{"ns1", "ns2"}, written)
+
+	brokerFail = true
+	replayed, err = replayDeadLetters(cli, storage.URL, broker.URL, 10)
+	assert.Error(t, err)
+	assert.Zero(t, replayed)
+
+	brokerFail = false
+	ackFail = true
+	_, err = replayDeadLetters(cli, storage.URL, broker.URL, 10)
+	assert.Error(t, err)
+
+	readFail = true
+	_, err = replayDeadLetters(cli, storage.URL, broker.URL, 10)
+	assert.Error(t, err)
+
+	// endpoint unavailable
+	_, err = replayDeadLetters(cli, "http://127.0.0.1:1", broker.URL, 10)
+	assert.Error(t, err)
+}
+
+func TestReplayDeadLetterCmd(t *testing.T) {
+	os.Args = []string{"tools", "replay-dead-letter", "--storage", "http://127.0.0.1:1"}
+	main()
+}
@@ -33,6 +33,8 @@ var RootCmd = &cobra.Command{
 func init() {
 	RootCmd.AddCommand(
 		keyWordsCmd,
+		replayDeadLetterCmd,
+		metadataCmd,
 	)
 }
 
@@ -0,0 +1,117 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	resty "github.com/go-resty/resty/v2"
+	"github.com/spf13/cobra"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+)
+
+var (
+	metadataBroker string
+	metadataFile   string
+	metadataDryRun bool
+	metadataPrune  bool
+)
+
+var metadataCmd = &cobra.Command{
+	Use:   "metadata",
+	Short: "Backup/restore the metadata(databases/shard assignments/storage configs etc.) of state repository",
+}
+
+var exportMetadataCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the metadata of state repository into archive file through broker api",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		return exportMetadata(resty.New(), metadataBroker, metadataFile)
+	},
+}
+
+var importMetadataCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Import the metadata archive file into state repository through broker api",
+	RunE: func(_ *cobra.Command, _ []string) error {
+		diff, err := importMetadata(resty.New(), metadataBroker, metadataFile, metadataDryRun, metadataPrune)
+		if err != nil {
+			return err
+		}
+		if diff.IsEmpty() {
+			fmt.Println("metadata archive is same as current state")
+			return nil
+		}
+		fmt.Print(diff.String())
+		fmt.Printf("added: %d, updated: %d, deleted: %d, applied: %v\n",
+			len(diff.Added), len(diff.Updated), len(diff.Deleted), diff.Applied)
+		return nil
+	},
+}
+
+func init() {
+	metadataCmd.PersistentFlags().StringVar(&metadataBroker, "broker", "http://localhost:9000",
+		"broker http endpoint of master")
+	metadataCmd.PersistentFlags().StringVar(&metadataFile, "file", "metadata.json",
+		"metadata archive file")
+	importMetadataCmd.Flags().BoolVar(&metadataDryRun, "dry-run", false,
+		"only show the difference against current state")
+	importMetadataCmd.Flags().BoolVar(&metadataPrune, "prune", false,
+		"delete the metadata not in archive")
+	metadataCmd.AddCommand(exportMetadataCmd, importMetadataCmd)
+}
+
+// exportMetadata writes the metadata archive exported by broker into file.
+func exportMetadata(cli *resty.Client, broker, file string) error {
+	resp, err := cli.R().Get(broker + constants.APIVersion1CliPath + "/metadata/export")
+	if err != nil {
+		return err
+	}
+	if resp.IsError() {
+		return fmt.Errorf("export metadata failure: %s", resp.String())
+	}
+	return os.WriteFile(file, resp.Body(), 0644)
+}
+
+// importMetadata imports the metadata archive file through broker, returns the difference against current state.
+func importMetadata(cli *resty.Client, broker, file string, dryRun, prune bool) (*models.MetadataDiff, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	diff := &models.MetadataDiff{}
+	resp, err := cli.R().
+		SetQueryParam("dryRun", strconv.FormatBool(dryRun)).
+		SetQueryParam("prune", strconv.FormatBool(prune)).
+		SetHeader("Content-Type", "application/json").
+		SetBody(data).
+		SetResult(diff).
+		ForceContentType("application/json").
+		Put(broker + constants.APIVersion1CliPath + "/metadata/import")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("import metadata failure: %s", resp.String())
+	}
+	return diff, nil
+}
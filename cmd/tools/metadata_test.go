@@ -0,0 +1,86 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	resty "github.com/go-resty/resty/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+func TestMetadata_ExportImport(t *testing.T) {
+	archive := `{"version":1,"entries":[{"key":"/database/config/db","value":{"name":"db"}}]}`
+	var (
+		fail     bool
+		imported string
+		query    string
+	)
+	broker := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		switch r.URL.Path {
+		case constants.APIVersion1CliPath + "/metadata/export":
+			_, _ = w.Write([]byte(archive))
+		case constants.APIVersion1CliPath + "/metadata/import":
+			body, _ := io.ReadAll(r.Body)
+			imported = string(body)
+			query = r.URL.RawQuery
+			_, _ = w.Write(encoding.JSONMarshal(&models.MetadataDiff{Added: []string{"/database/config/db"}, Applied: true}))
+		}
+	}))
+	defer broker.Close()
+
+	file := filepath.Join(t.TempDir(), "metadata.json")
+	cli := resty.New()
+	// export
+	assert.NoError(t, exportMetadata(cli, broker.URL, file))
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, archive, string(data))
+	// import
+	diff, err := importMetadata(cli, broker.URL, file, true, false)
+	assert.NoError(t, err)
+	assert.Equal(t, archive, imported)
+	assert.Equal(t, "dryRun=true&prune=false", query)
+	assert.True(t, diff.Applied)
+	assert.Equal(t, []string{"/database/config/db"}, diff.Added)
+	// file not exist
+	_, err = importMetadata(cli, broker.URL, filepath.Join(t.TempDir(), "not_exist.json"), false, false)
+	assert.Error(t, err)
+	// broker failure
+	fail = true
+	assert.Error(t, exportMetadata(cli, broker.URL, file))
+	_, err = importMetadata(cli, broker.URL, file, false, false)
+	assert.Error(t, err)
+	// broker unreachable
+	assert.Error(t, exportMetadata(cli, "http://127.0.0.1:1", file))
+	_, err = importMetadata(cli, "http://127.0.0.1:1", file, false, false)
+	assert.Error(t, err)
+}
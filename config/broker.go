@@ -18,7 +18,10 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/lindb/lindb/pkg/ltoml"
@@ -58,8 +61,12 @@ read-timeout = "%s"`,
 }
 
 type Ingestion struct {
-	MaxConcurrency int            `toml:"max-concurrency"`
-	IngestTimeout  ltoml.Duration `toml:"ingest-timeout"`
+	MaxConcurrency       int            `toml:"max-concurrency"`
+	IngestTimeout        ltoml.Duration `toml:"ingest-timeout"`
+	IdempotencyWindow    ltoml.Duration `toml:"idempotency-window"`
+	IdempotencyMaxTokens int            `toml:"idempotency-max-tokens"`
+	MaxWriteWindow       int            `toml:"max-write-window"`
+	MaxDecompressedSize  ltoml.Size     `toml:"max-decompressed-size"`
 }
 
 func (i *Ingestion) TOML() string {
@@ -71,11 +78,35 @@ func (i *Ingestion) TOML() string {
 max-concurrency = %d
 ## maximum duration before timeout for server ingesting metrics
 ## Default: %s
-ingest-timeout = "%s"`,
+ingest-timeout = "%s"
+## how long the idempotency token(header "Idempotency-Key") of write request is kept,
+## duplicate tokens within the window return the original result without re-writing.
+## 0 disables idempotent write.
+## Default: %s
+idempotency-window = "%s"
+## maximum number of idempotency tokens kept per database, least recently used tokens are evicted.
+## Default: %d
+idempotency-max-tokens = %d
+## maximum number of unacknowledged batches per grpc write stream,
+## the window shrinks when downstream replication is slow.
+## Default: %d
+max-write-window = %d
+## maximum size of write request body after decompression(gzip/zstd content encoding),
+## request exceeds the limit is aborted with 413 status code. 0 means no limit.
+## Default: %s
+max-decompressed-size = "%s"`,
 		i.MaxConcurrency,
 		i.MaxConcurrency,
 		i.IngestTimeout.Duration().String(),
-		i.IngestTimeout.Duration().String())
+		i.IngestTimeout.Duration().String(),
+		i.IdempotencyWindow.Duration().String(),
+		i.IdempotencyWindow.Duration().String(),
+		i.IdempotencyMaxTokens,
+		i.IdempotencyMaxTokens,
+		i.MaxWriteWindow,
+		i.MaxWriteWindow,
+		i.MaxDecompressedSize.String(),
+		i.MaxDecompressedSize.String())
 }
 
 // User represents user model
@@ -93,11 +124,74 @@ password = "%s"`,
 		u.Password)
 }
 
+// Auth represents authentication/authorization config of broker http api.
+type Auth struct {
+	Enabled bool `toml:"enabled"`
+	// Tokens are the static tokens, format: principal:token.
+	Tokens []string `toml:"tokens"`
+	// Admins are the principals which have admin permission on all databases,
+	// used for bootstrapping the permissions stored in state repository.
+	Admins []string `toml:"admins"`
+}
+
+func (a *Auth) TOML() string {
+	tokens, _ := json.Marshal(nonNilStrings(a.Tokens))
+	admins, _ := json.Marshal(nonNilStrings(a.Admins))
+	return fmt.Sprintf(`
+## enabled enables token authentication and per-database authorization of HTTP API,
+## requests must carry the token via header "Authorization: Bearer <token>" if enabled.
+## Default: %v
+enabled = %v
+## tokens are the static tokens of principals, format: "principal:token".
+## Default: %s
+tokens = %s
+## admins are the principals which have admin permission on all databases.
+## Default: %s
+admins = %s`,
+		a.Enabled,
+		a.Enabled,
+		tokens,
+		tokens,
+		admins,
+		admins,
+	)
+}
+
+// ParseTokens returns the static tokens, token => principal.
+func (a *Auth) ParseTokens() (map[string]string, error) {
+	tokens := make(map[string]string)
+	for _, item := range a.Tokens {
+		idx := strings.Index(item, ":")
+		if idx <= 0 || idx == len(item)-1 {
+			return nil, fmt.Errorf("auth token must be format as principal:token")
+		}
+		principal, token := item[:idx], item[idx+1:]
+		if _, ok := tokens[token]; ok {
+			return nil, fmt.Errorf("auth token of principal[%s] is duplicated", principal)
+		}
+		tokens[token] = principal
+	}
+	return tokens, nil
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
 // Write represents config for write replication in broker.
 type Write struct {
 	BatchTimeout   ltoml.Duration `toml:"batch-timeout"`
 	BatchBlockSize ltoml.Size     `toml:"batch-block-size"`
 	GCTaskInterval ltoml.Duration `toml:"gc-task-interval"`
+	// write ahead log of databases which enable broker wal(database option: brokerWAL)
+	WALDir           string     `toml:"wal-dir"`
+	WALDataSizeLimit ltoml.Size `toml:"wal-data-size-limit"`
+	// buffer of batches failed sending to unavailable shard leader, bounded by bytes(per shard) and age
+	RetryBufferSize ltoml.Size     `toml:"retry-buffer-size"`
+	RetryBufferAge  ltoml.Duration `toml:"retry-buffer-age"`
 }
 
 func (rc *Write) TOML() string {
@@ -111,22 +205,144 @@ batch-timeout = "%s"
 batch-block-size = "%s"
 ## interval for how often expired write write family garbage collect task execute
 ## Default: %s
-gc-task-interval = "%s"`,
+gc-task-interval = "%s"
+## directory of write ahead log for the databases which enable broker wal(database option: brokerWAL),
+## batches are persisted before acknowledged to client, and replayed after broker restarted if not acknowledged by storage.
+## Default: %s
+wal-dir = "%s"
+## max size of write ahead log per database, write is rejected when exceeded.
+## Default: %s
+wal-data-size-limit = "%s"
+## max size of batches buffered per shard when shard leader is unavailable,
+## buffered batches are retried after shard recovered or new leader assigned, dropped when exceeded.
+## Default: %s
+retry-buffer-size = "%s"
+## max age of batches buffered for retrying, expired batches are dropped.
+## Default: %s
+retry-buffer-age = "%s"`,
 		rc.BatchTimeout.String(),
 		rc.BatchTimeout.String(),
 		rc.BatchBlockSize.String(),
 		rc.BatchBlockSize.String(),
 		rc.GCTaskInterval.String(),
 		rc.GCTaskInterval.String(),
+		rc.WALDir,
+		rc.WALDir,
+		rc.WALDataSizeLimit.String(),
+		rc.WALDataSizeLimit.String(),
+		rc.RetryBufferSize.String(),
+		rc.RetryBufferSize.String(),
+		rc.RetryBufferAge.String(),
+		rc.RetryBufferAge.String(),
+	)
+}
+
+// Metering represents the usage metering config of broker, usage is keyed by database/namespace.
+type Metering struct {
+	// FlushInterval is the interval of flushing usage into monitor database, metering is disabled if 0.
+	FlushInterval ltoml.Duration `toml:"flush-interval"`
+	// Database is the database which usage is written into.
+	Database string `toml:"database"`
+}
+
+func (m *Metering) TOML() string {
+	return fmt.Sprintf(`
+## interval for how often usage(points/bytes written, series touched, queries, scan bytes) is flushed,
+## metering is disabled when interval is set to 0.
+## Default: %s
+flush-interval = "%s"
+## database which usage is written into.
+## Default: %s
+database = "%s"`,
+		m.FlushInterval.String(),
+		m.FlushInterval.String(),
+		m.Database,
+		m.Database,
+	)
+}
+
+// Master represents the config of master which is elected among brokers and coordinates the cluster.
+type Master struct {
+	// DropGracePeriod is how long the dropped database is kept(restorable) before its data is removed,
+	// database is removed immediately if 0.
+	DropGracePeriod ltoml.Duration `toml:"drop-grace-period"`
+}
+
+// TOML returns master's configuration string as toml format.
+func (m *Master) TOML() string {
+	return fmt.Sprintf(`[master]
+## how long the dropped database is kept before its data is removed from storage,
+## writes/queries are rejected and database can be restored within grace period.
+## database is removed immediately when grace period is set to 0.
+## Default: %s
+drop-grace-period = "%s"`,
+		m.DropGracePeriod.String(),
+		m.DropGracePeriod.String(),
+	)
+}
+
+// NewDefaultMaster creates master default configuration.
+func NewDefaultMaster() *Master {
+	return &Master{
+		DropGracePeriod: ltoml.Duration(72 * time.Hour),
+	}
+}
+
+// BrokerQuery represents the query config of broker which merges the results of storage nodes.
+type BrokerQuery struct {
+	// MaxBufferedSeries limits the grouped series buffered for merging of one query.
+	MaxBufferedSeries  int            `toml:"max-buffered-series"`
+	EnableResultCache  bool           `toml:"enable-result-cache"`
+	ResultCacheTTL     ltoml.Duration `toml:"result-cache-ttl"`
+	ResultCacheMaxSize ltoml.Size     `toml:"result-cache-max-size"`
+	ReplicaLagInterval ltoml.Duration `toml:"replica-lag-interval"`
+	Hedge              Hedge          `toml:"hedge"`
+	TopN               TopN           `toml:"topn"`
+	Memory             QueryMemory    `toml:"memory"`
+}
+
+func (bq *BrokerQuery) TOML() string {
+	return fmt.Sprintf(`
+## Maximum number of grouped series buffered in memory for one query,
+## stream query(Accept: application/x-ndjson) spills grouped series to broker.query.memory.spill-dir if exceeded
+## and broker.query.memory.enable-spill is set, other query will fail if exceeded, please add more filter conditions.
+## Default: %d
+max-buffered-series = %d
+## Enable caching result of query whose time range is immutable(out of the write behind window of database).
+## Default: %v
+enable-result-cache = %v
+## Cached result will be expired after this duration.
+## Default: %s
+result-cache-ttl = "%s"
+## Maximum size of cached results.
+## Default: %s
+result-cache-max-size = "%s"
+## Interval of collecting replication lag of storage replicas,
+## used for choosing the most caught-up follower when query fails over from leader.
+## Default: %s
+replica-lag-interval = "%s"`,
+		bq.MaxBufferedSeries,
+		bq.MaxBufferedSeries,
+		bq.EnableResultCache,
+		bq.EnableResultCache,
+		bq.ResultCacheTTL,
+		bq.ResultCacheTTL,
+		bq.ResultCacheMaxSize,
+		bq.ResultCacheMaxSize,
+		bq.ReplicaLagInterval,
+		bq.ReplicaLagInterval,
 	)
 }
 
 // BrokerBase represents a broker configuration
 type BrokerBase struct {
-	HTTP      HTTP      `toml:"http"`
-	Ingestion Ingestion `toml:"ingestion"`
-	Write     Write     `toml:"write"`
-	GRPC      GRPC      `toml:"grpc"`
+	HTTP      HTTP        `toml:"http"`
+	Ingestion Ingestion   `toml:"ingestion"`
+	Write     Write       `toml:"write"`
+	GRPC      GRPC        `toml:"grpc"`
+	Auth      Auth        `toml:"auth"`
+	Metering  Metering    `toml:"metering"`
+	Query     BrokerQuery `toml:"query"`
 }
 
 // TOML returns broker's base configuration string as toml format.
@@ -145,11 +361,39 @@ func (bb *BrokerBase) TOML() string {
 [broker.write]%s
 
 ## Controls how GRPC Server are configured.
-[broker.grpc]%s`,
+[broker.grpc]%s
+
+## Transport security of intra-cluster GRPC.
+[broker.grpc.tls]%s
+
+## Authentication/authorization of HTTP API.
+[broker.auth]%s
+
+## Usage metering of ingestion and query.
+[broker.metering]%s
+
+## Controls how broker merges the results of storage nodes for query.
+[broker.query]%s
+
+## Controls how slow leaf requests are hedged to other replica(for database with hedged replica policy).
+[broker.query.hedge]%s
+
+## Controls how top-n query(group by with order by and limit) is pushed down to storage nodes.
+[broker.query.topn]%s
+
+## Controls the memory budget of grouped series buffered by broker for one query.
+[broker.query.memory]%s`,
 		bb.HTTP.TOML(),
 		bb.Ingestion.TOML(),
 		bb.Write.TOML(),
 		bb.GRPC.TOML(),
+		bb.GRPC.TLS.TOML(),
+		bb.Auth.TOML(),
+		bb.Metering.TOML(),
+		bb.Query.TOML(),
+		bb.Query.Hedge.TOML(),
+		bb.Query.TopN.TOML(),
+		bb.Query.Memory.TOML(),
 	)
 }
 
@@ -162,18 +406,45 @@ func NewDefaultBrokerBase() *BrokerBase {
 			WriteTimeout: ltoml.Duration(time.Second * 5),
 		},
 		Ingestion: Ingestion{
-			MaxConcurrency: 256,
-			IngestTimeout:  ltoml.Duration(time.Second * 5),
+			MaxConcurrency:       256,
+			IngestTimeout:        ltoml.Duration(time.Second * 5),
+			IdempotencyWindow:    ltoml.Duration(time.Minute * 5),
+			IdempotencyMaxTokens: 10000,
+			MaxWriteWindow:       16,
+			MaxDecompressedSize:  ltoml.Size(64 * 1024 * 1024),
 		},
 		Write: Write{
-			BatchTimeout:   ltoml.Duration(time.Second * 2),
-			BatchBlockSize: ltoml.Size(256 * 1024),
-			GCTaskInterval: ltoml.Duration(time.Minute),
+			BatchTimeout:     ltoml.Duration(time.Second * 2),
+			BatchBlockSize:   ltoml.Size(256 * 1024),
+			GCTaskInterval:   ltoml.Duration(time.Minute),
+			WALDir:           filepath.Join(defaultParentDir, "broker", "wal"),
+			WALDataSizeLimit: ltoml.Size(1024 * 1024 * 1024),
+			RetryBufferSize:  ltoml.Size(64 * 1024 * 1024),
+			RetryBufferAge:   ltoml.Duration(time.Minute * 5),
 		},
 		GRPC: GRPC{
 			Port:                 9001,
 			MaxConcurrentStreams: 1024,
 			ConnectTimeout:       ltoml.Duration(time.Second * 3),
+			TLS:                  NewDefaultTLS(),
+		},
+		Auth: Auth{
+			Tokens: []string{},
+			Admins: []string{},
+		},
+		Metering: Metering{
+			FlushInterval: ltoml.Duration(time.Minute),
+			Database:      "_internal",
+		},
+		Query: BrokerQuery{
+			MaxBufferedSeries:  1000000,
+			EnableResultCache:  false,
+			ResultCacheTTL:     ltoml.Duration(5 * time.Minute),
+			ResultCacheMaxSize: ltoml.Size(64 * 1024 * 1024),
+			ReplicaLagInterval: ltoml.Duration(10 * time.Second),
+			Hedge:              NewDefaultHedge(),
+			TopN:               NewDefaultTopN(),
+			Memory:             NewDefaultQueryMemory(),
 		},
 	}
 }
@@ -181,6 +452,7 @@ func NewDefaultBrokerBase() *BrokerBase {
 // Broker represents a broker configuration with common settings
 type Broker struct {
 	Coordinator RepoState  `toml:"coordinator"`
+	Master      Master     `toml:"master"`
 	Query       Query      `toml:"query"`
 	BrokerBase  BrokerBase `toml:"broker"`
 	Monitor     Monitor    `toml:"monitor"`
@@ -192,12 +464,16 @@ func (b *Broker) TOML() string {
 	return fmt.Sprintf(`## Coordinator related configuration.
 %s
 
+## Master related configuration.
+%s
+
 ## Query related configuration.
 %s
 %s
 %s
 %s`,
 		b.Coordinator.TOML(),
+		b.Master.TOML(),
 		b.Query.TOML(),
 		b.BrokerBase.TOML(),
 		b.Monitor.TOML(),
@@ -210,12 +486,16 @@ func NewDefaultBrokerTOML() string {
 	return fmt.Sprintf(`## Coordinator related configuration.
 %s
 
+## Master related configuration.
+%s
+
 ## Query related configuration.
 %s
 %s
 %s
 %s`,
 		NewDefaultCoordinator().TOML(),
+		NewDefaultMaster().TOML(),
 		NewDefaultQuery().TOML(),
 		NewDefaultBrokerBase().TOML(),
 		NewDefaultMonitor().TOML(),
@@ -223,11 +503,22 @@ func NewDefaultBrokerTOML() string {
 	)
 }
 
+// checkMasterCfg checks master configuration.
+func checkMasterCfg(masterCfg *Master) error {
+	if masterCfg.DropGracePeriod < 0 {
+		return fmt.Errorf("drop grace period of database cannot be negative")
+	}
+	return nil
+}
+
 // checkBrokerBaseCfg checks broker base configuration, if not set using default value.
 func checkBrokerBaseCfg(brokerBaseCfg *BrokerBase) error {
 	if err := checkGRPCCfg(&brokerBaseCfg.GRPC); err != nil {
 		return err
 	}
+	if err := checkAuthCfg(&brokerBaseCfg.Auth); err != nil {
+		return err
+	}
 	defaultBrokerCfg := NewDefaultBrokerBase()
 	// http check
 	if brokerBaseCfg.HTTP.Port <= 0 {
@@ -250,6 +541,9 @@ func checkBrokerBaseCfg(brokerBaseCfg *BrokerBase) error {
 	if brokerBaseCfg.Ingestion.MaxConcurrency <= 0 {
 		brokerBaseCfg.Ingestion.MaxConcurrency = defaultBrokerCfg.Ingestion.MaxConcurrency
 	}
+	if brokerBaseCfg.Ingestion.MaxWriteWindow <= 0 {
+		brokerBaseCfg.Ingestion.MaxWriteWindow = defaultBrokerCfg.Ingestion.MaxWriteWindow
+	}
 	// write check
 	if brokerBaseCfg.Write.BatchTimeout <= 0 {
 		brokerBaseCfg.Write.BatchTimeout = defaultBrokerCfg.Write.BatchTimeout
@@ -260,6 +554,56 @@ func checkBrokerBaseCfg(brokerBaseCfg *BrokerBase) error {
 	if brokerBaseCfg.Write.GCTaskInterval <= 0 {
 		brokerBaseCfg.Write.GCTaskInterval = defaultBrokerCfg.Write.GCTaskInterval
 	}
+	if brokerBaseCfg.Write.WALDir == "" {
+		brokerBaseCfg.Write.WALDir = defaultBrokerCfg.Write.WALDir
+	}
+	if brokerBaseCfg.Write.WALDataSizeLimit <= 0 {
+		brokerBaseCfg.Write.WALDataSizeLimit = defaultBrokerCfg.Write.WALDataSizeLimit
+	}
+	if brokerBaseCfg.Write.RetryBufferSize <= 0 {
+		brokerBaseCfg.Write.RetryBufferSize = defaultBrokerCfg.Write.RetryBufferSize
+	}
+	if brokerBaseCfg.Write.RetryBufferAge <= 0 {
+		brokerBaseCfg.Write.RetryBufferAge = defaultBrokerCfg.Write.RetryBufferAge
+	}
+	// metering check
+	if brokerBaseCfg.Metering.FlushInterval < 0 {
+		return fmt.Errorf("metering flush interval cannot be negative")
+	}
+	if brokerBaseCfg.Metering.Database == "" {
+		brokerBaseCfg.Metering.Database = defaultBrokerCfg.Metering.Database
+	}
+	// query check
+	if brokerBaseCfg.Query.MaxBufferedSeries <= 0 {
+		brokerBaseCfg.Query.MaxBufferedSeries = defaultBrokerCfg.Query.MaxBufferedSeries
+	}
+	if brokerBaseCfg.Query.ResultCacheTTL <= 0 {
+		brokerBaseCfg.Query.ResultCacheTTL = defaultBrokerCfg.Query.ResultCacheTTL
+	}
+	if brokerBaseCfg.Query.ResultCacheMaxSize <= 0 {
+		brokerBaseCfg.Query.ResultCacheMaxSize = defaultBrokerCfg.Query.ResultCacheMaxSize
+	}
+	if brokerBaseCfg.Query.ReplicaLagInterval <= 0 {
+		brokerBaseCfg.Query.ReplicaLagInterval = defaultBrokerCfg.Query.ReplicaLagInterval
+	}
+	checkHedgeCfg(&brokerBaseCfg.Query.Hedge)
+	checkTopNCfg(&brokerBaseCfg.Query.TopN)
+	checkQueryMemoryCfg(&brokerBaseCfg.Query.Memory)
 
 	return nil
 }
+
+// checkAuthCfg checks auth configuration of broker http api.
+func checkAuthCfg(authCfg *Auth) error {
+	if !authCfg.Enabled {
+		return nil
+	}
+	tokens, err := authCfg.ParseTokens()
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("auth tokens cannot be empty when auth enabled")
+	}
+	return nil
+}
@@ -42,3 +42,62 @@ func TestDumpExampleCfg(t *testing.T) {
 	assert.NoError(t, ltoml.WriteConfig("storage.toml.example", NewDefaultStorageTOML()))
 	assert.NoError(t, ltoml.WriteConfig("standalone.toml.example", NewDefaultStandaloneTOML()))
 }
+
+func TestAuth_ParseTokens(t *testing.T) {
+	cases := []struct {
+		name    string
+		tokens  []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "empty tokens",
+			want: map[string]string{},
+		},
+		{
+			name:   "parse tokens",
+			tokens: []string{"ops:abc", "reader:a:b"},
+			want:   map[string]string{"abc": "ops", "a:b": "reader"},
+		},
+		{
+			name:    "principal empty",
+			tokens:  []string{":abc"},
+			wantErr: true,
+		},
+		{
+			name:    "token empty",
+			tokens:  []string{"ops:"},
+			wantErr: true,
+		},
+		{
+			name:    "without separator",
+			tokens:  []string{"ops"},
+			wantErr: true,
+		},
+		{
+			name:    "duplicated token",
+			tokens:  []string{"ops:abc", "reader:abc"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &Auth{Tokens: tt.tokens}
+			got, err := auth.ParseTokens()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTokens() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_checkAuthCfg(t *testing.T) {
+	assert.NoError(t, checkAuthCfg(&Auth{}))
+	assert.Error(t, checkAuthCfg(&Auth{Enabled: true}))
+	assert.Error(t, checkAuthCfg(&Auth{Enabled: true, Tokens: []string{"abc"}}))
+	assert.NoError(t, checkAuthCfg(&Auth{Enabled: true, Tokens: []string{"ops:abc"}}))
+}
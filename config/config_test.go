@@ -20,6 +20,7 @@ package config
 import (
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -111,6 +112,20 @@ func Test_checkBrokerBaseCfg(t *testing.T) {
 	assert.NotZero(t, brokerCfg3.HTTP.IdleTimeout)
 	assert.NotZero(t, brokerCfg3.HTTP.WriteTimeout)
 	assert.NotZero(t, brokerCfg3.Ingestion.IngestTimeout)
+	assert.Equal(t, "_internal", brokerCfg3.Metering.Database)
+
+	// metering flush interval failure
+	brokerCfg4 := &BrokerBase{
+		GRPC:     GRPC{Port: 2379},
+		HTTP:     HTTP{Port: 9000},
+		Metering: Metering{FlushInterval: -1},
+	}
+	assert.Error(t, checkBrokerBaseCfg(brokerCfg4))
+}
+
+func Test_checkMasterCfg(t *testing.T) {
+	assert.NoError(t, checkMasterCfg(NewDefaultMaster()))
+	assert.Error(t, checkMasterCfg(&Master{DropGracePeriod: -1}))
 }
 
 func Test_checkStorageBaseCfg(t *testing.T) {
@@ -146,6 +161,103 @@ func Test_checkStorageBaseCfg(t *testing.T) {
 	assert.NotZero(t, storageCfg4.TSDB.FlushConcurrency)
 	assert.NotZero(t, storageCfg4.TSDB.MaxSeriesIDsNumber)
 	assert.NotZero(t, storageCfg4.TSDB.MaxTagKeysNumber)
+	assert.NotZero(t, storageCfg4.TSDB.RecoveryConcurrency)
+	assert.False(t, storageCfg4.TSDB.TolerateCorruptFamily)
+	assert.Zero(t, storageCfg4.TSDB.SeriesGCHorizon)
+	assert.NotZero(t, storageCfg4.TSDB.SeriesGCBatchSize)
+	assert.Zero(t, storageCfg4.TSDB.MaxMemDBTotalSize)
+	assert.Zero(t, storageCfg4.TSDB.TargetMemDBTotalSize)
+	storageCfg4.TSDB.MaxMemDBTotalSize = ltoml.Size(1000)
+	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
+	assert.Equal(t, ltoml.Size(800), storageCfg4.TSDB.TargetMemDBTotalSize)
+	// series gc horizon
+	storageCfg4.TSDB.SeriesGCHorizon = ltoml.Duration(-time.Hour)
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.SeriesGCHorizon = ltoml.Duration(time.Hour)
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.SeriesGCHorizon = ltoml.Duration(30 * 24 * time.Hour)
+	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
+	// write backoff
+	assert.Zero(t, storageCfg4.TSDB.MaxWriteBackoff)
+	storageCfg4.TSDB.MaxWriteBackoff = ltoml.Duration(-time.Second)
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.MaxWriteBackoff = 0
+	assert.NotEmpty(t, storageCfg4.DeadLetter.Dir)
+	assert.NotZero(t, storageCfg4.DeadLetter.MaxSize)
+	assert.NotZero(t, storageCfg4.DeadLetter.RateLimit)
+	assert.NotZero(t, storageCfg4.Health.CheckInterval)
+	assert.NotZero(t, storageCfg4.Health.LoadReportInterval)
+	assert.Equal(t, "quarantine", storageCfg4.TSDB.OrphanFileAction)
+	storageCfg4.TSDB.OrphanFileAction = "abc"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.TSDB.OrphanFileAction = "delete"
+	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
+	assert.NotZero(t, storageCfg4.DiskWatchdog.CheckInterval)
+	assert.NotZero(t, storageCfg4.DiskWatchdog.LowWatermark)
+	assert.Equal(t, NewDefaultStorageBase().ConsistencyCheck, storageCfg4.ConsistencyCheck)
+	assert.Equal(t, NewDefaultStorageBase().ReplicaBootstrap, storageCfg4.ReplicaBootstrap)
+	assert.Equal(t, NewDefaultStorageBase().IndexWarmup, storageCfg4.IndexWarmup)
+	assert.Equal(t, NewDefaultStorageBase().Tiering, storageCfg4.Tiering)
+	assert.False(t, storageCfg4.Tiering.Enabled())
+	storageCfg4.Tiering.Backend = "abc"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.Tiering.Backend = "archive"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.Tiering.ArchiveDir = "/mnt/archive"
+	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
+	assert.True(t, storageCfg4.Tiering.Enabled())
+	storageCfg4.Tiering.Backend = "s3"
+	assert.Error(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.Tiering.S3.Endpoint = "http://127.0.0.1:9000"
+	storageCfg4.Tiering.S3.Bucket = "lindb"
+	assert.NoError(t, checkStorageBaseCfg(storageCfg4))
+	storageCfg4.Tiering = Tiering{}
+
+	storageCfg5 := &StorageBase{
+		GRPC:   GRPC{Port: 2379},
+		TSDB:   TSDB{Dir: "/tmp/lindb"},
+		Health: Health{MinDiskFreePercent: 120},
+	}
+	assert.NoError(t, checkStorageBaseCfg(storageCfg5))
+	assert.Equal(t, NewDefaultStorageBase().Health.MinDiskFreePercent, storageCfg5.Health.MinDiskFreePercent)
+
+	storageCfg6 := &StorageBase{
+		GRPC:         GRPC{Port: 2379},
+		TSDB:         TSDB{Dir: "/tmp/lindb"},
+		DiskWatchdog: DiskWatchdog{LowWatermark: 10, HighWatermark: 5},
+	}
+	assert.NoError(t, checkStorageBaseCfg(storageCfg6))
+	assert.Equal(t, NewDefaultStorageBase().DiskWatchdog, storageCfg6.DiskWatchdog)
+
+	storageCfg7 := &StorageBase{
+		GRPC: GRPC{Port: 2379},
+		TSDB: TSDB{Dir: "/tmp/lindb"},
+		QueryAdmission: QueryAdmission{
+			MaxConcurrentQueries: -1,
+			MaxInflightScanBytes: NewDefaultStorageBase().QueryAdmission.MaxInflightScanBytes,
+			MaxQueueSize:         -1,
+		},
+	}
+	assert.NoError(t, checkStorageBaseCfg(storageCfg7))
+	assert.Equal(t, NewDefaultStorageBase().QueryAdmission, storageCfg7.QueryAdmission)
+
+	// buffer dir
+	storageCfg8 := &StorageBase{
+		GRPC: GRPC{Port: 2379},
+		TSDB: TSDB{Dir: "/tmp/lindb", SeparateBufferDir: true},
+	}
+	assert.Error(t, checkStorageBaseCfg(storageCfg8))
+	storageCfg8.TSDB.BufferDir = "/nvme/lindb/{database}"
+	assert.Error(t, checkStorageBaseCfg(storageCfg8))
+	storageCfg8.TSDB.BufferDir = "/tmp/lindb/buffer/{database}/{shard}"
+	assert.Error(t, checkStorageBaseCfg(storageCfg8))
+	storageCfg8.TSDB.BufferDir = "/tmp/lindb/{database}/{shard}"
+	assert.Error(t, checkStorageBaseCfg(storageCfg8))
+	storageCfg8.TSDB.BufferDir = "/tmp/lindb-buffer/{database}/{shard}"
+	assert.NoError(t, checkStorageBaseCfg(storageCfg8))
+	storageCfg8.TSDB.BufferDir = "/tmp/lindb/buffer/{database}/{shard}"
+	storageCfg8.TSDB.SeparateBufferDir = false
+	assert.NoError(t, checkStorageBaseCfg(storageCfg8))
 }
 
 func Test_checkCoordinatorCfg(t *testing.T) {
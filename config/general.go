@@ -20,6 +20,7 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -27,12 +28,46 @@ import (
 	"github.com/lindb/lindb/pkg/ltoml"
 )
 
+// redactedValue represents the value which replaces the sensitive value of configuration.
+const redactedValue = `"******"`
+
+// sensitiveKeys represents the keys of configuration which value is sensitive(password/token etc.).
+var sensitiveKeys = []string{"password", "token", "secret"}
+
 // Configuration represents node's configuration.
 type Configuration interface {
 	// TOML returns configuration string as toml format.
 	TOML() string
 }
 
+// Redact returns configuration string as toml format, sensitive values(password/token etc.) are redacted,
+// include the default value in comment.
+func Redact(cfg Configuration) string {
+	lines := strings.Split(cfg.TOML(), "\n")
+	for idx, line := range lines {
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 || strings.HasPrefix(line, "#") || !isSensitiveKey(kv[0]) {
+			continue
+		}
+		lines[idx] = strings.TrimRight(kv[0], " ") + " = " + redactedValue
+		if idx > 0 && strings.HasPrefix(lines[idx-1], "## Default:") {
+			lines[idx-1] = "## Default: " + redactedValue
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isSensitiveKey checks if the value of key is sensitive.
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(strings.TrimSpace(key))
+	for _, sensitiveKey := range sensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return true
+		}
+	}
+	return false
+}
+
 // RepoState represents state repository config
 type RepoState struct {
 	Namespace   string         `toml:"namespace" json:"namespace" validate:"required"`
@@ -122,6 +157,7 @@ type GRPC struct {
 	Port                 uint16         `toml:"port"`
 	MaxConcurrentStreams int            `toml:"max-concurrent-streams"`
 	ConnectTimeout       ltoml.Duration `toml:"connect-timeout"`
+	TLS                  TLS            `toml:"tls"`
 }
 
 func (g *GRPC) TOML() string {
@@ -144,6 +180,76 @@ connect-timeout = "%s"`,
 	)
 }
 
+// TLS client auth modes of grpc server.
+const (
+	TLSClientAuthNone             = "none"
+	TLSClientAuthRequest          = "request"
+	TLSClientAuthVerifyIfGiven    = "verify-if-given"
+	TLSClientAuthRequireAndVerify = "require-and-verify"
+)
+
+// TLS represents transport security config of intra-cluster grpc(server and client).
+type TLS struct {
+	Enabled        bool           `toml:"enabled"`
+	CertFile       string         `toml:"cert-file"`
+	KeyFile        string         `toml:"key-file"`
+	CAFile         string         `toml:"ca-file"`
+	ClientAuth     string         `toml:"client-auth"`
+	ServerName     string         `toml:"server-name"`
+	ReloadInterval ltoml.Duration `toml:"reload-interval"`
+}
+
+func (t *TLS) TOML() string {
+	return fmt.Sprintf(`
+## enabled enables TLS for grpc server and clients, all nodes of cluster must use the same setting.
+## Default: %v
+enabled = %v
+## cert-file is the certificate(PEM) used by grpc server and presented by grpc clients.
+## Default: "%s"
+cert-file = "%s"
+## key-file is the private key(PEM) of cert-file.
+## Default: "%s"
+key-file = "%s"
+## ca-file is the CA bundle(PEM) used to verify peer certificates, required when tls enabled.
+## Default: "%s"
+ca-file = "%s"
+## client-auth controls the policy of server for client certificates(mTLS),
+## supported: none/request/verify-if-given/require-and-verify.
+## Default: "%s"
+client-auth = "%s"
+## server-name is the name verified against server certificate,
+## the host of dialed address(node ip) is verified if it is empty.
+## Default: "%s"
+server-name = "%s"
+## reload-interval is the interval for checking certificate files changed,
+## certificates are also reloaded when process receives SIGHUP.
+## Default: %s
+reload-interval = "%s"`,
+		t.Enabled,
+		t.Enabled,
+		t.CertFile,
+		t.CertFile,
+		t.KeyFile,
+		t.KeyFile,
+		t.CAFile,
+		t.CAFile,
+		t.ClientAuth,
+		t.ClientAuth,
+		t.ServerName,
+		t.ServerName,
+		t.ReloadInterval.Duration().String(),
+		t.ReloadInterval.Duration().String(),
+	)
+}
+
+// NewDefaultTLS returns a new default tls config(disabled).
+func NewDefaultTLS() TLS {
+	return TLS{
+		ClientAuth:     TLSClientAuthNone,
+		ReloadInterval: ltoml.Duration(time.Minute),
+	}
+}
+
 // BrokerCluster represents config of broker cluster.
 type BrokerCluster struct {
 	Config *RepoState `json:"config"`
@@ -156,9 +262,12 @@ type StorageCluster struct {
 
 // Query represents query rpc config
 type Query struct {
-	QueryConcurrency int            `toml:"query-concurrency"`
-	IdleTimeout      ltoml.Duration `toml:"idle-timeout"`
-	Timeout          ltoml.Duration `toml:"timeout"`
+	QueryConcurrency   int            `toml:"query-concurrency"`
+	IdleTimeout        ltoml.Duration `toml:"idle-timeout"`
+	Timeout            ltoml.Duration `toml:"timeout"`
+	SlowQueryThreshold ltoml.Duration `toml:"slow-query-threshold"`
+	SlowQueryLogSize   int            `toml:"slow-query-log-size"`
+	Tracing            Tracing        `toml:"tracing"`
 }
 
 func (q *Query) TOML() string {
@@ -171,21 +280,216 @@ query-concurrency = %d
 idle-timeout = "%s"
 ## Maximum timeout threshold for query.
 ## Default: %s
-timeout = "%s"`,
+timeout = "%s"
+## Query which takes longer than this threshold will be recorded in slow query log.
+## Default: %s
+slow-query-threshold = "%s"
+## Maximum number of the latest slow queries kept in memory.
+## Default: %d
+slow-query-log-size = %d
+
+## Controls how query spans are traced and exported.
+[query.tracing]%s`,
 		q.QueryConcurrency,
 		q.QueryConcurrency,
 		q.IdleTimeout,
 		q.IdleTimeout,
 		q.Timeout,
 		q.Timeout,
+		q.SlowQueryThreshold,
+		q.SlowQueryThreshold,
+		q.SlowQueryLogSize,
+		q.SlowQueryLogSize,
+		q.Tracing.TOML(),
 	)
 }
 
 func NewDefaultQuery() *Query {
 	return &Query{
-		QueryConcurrency: 1024,
-		IdleTimeout:      ltoml.Duration(5 * time.Second),
-		Timeout:          ltoml.Duration(5 * time.Second),
+		QueryConcurrency:   1024,
+		IdleTimeout:        ltoml.Duration(5 * time.Second),
+		Timeout:            ltoml.Duration(5 * time.Second),
+		SlowQueryThreshold: ltoml.Duration(time.Second),
+		SlowQueryLogSize:   100,
+		Tracing:            NewDefaultTracing(),
+	}
+}
+
+// Tracing represents the configuration of query tracing(OpenTelemetry compatible).
+type Tracing struct {
+	Enabled           bool    `toml:"enabled"`
+	Endpoint          string  `toml:"endpoint"`
+	Insecure          bool    `toml:"insecure"`
+	SampleRatio       float64 `toml:"sample-ratio"`
+	SlowQuerySampling bool    `toml:"slow-query-sampling"`
+	MaxBufferedSpans  int     `toml:"max-buffered-spans"`
+}
+
+func (t *Tracing) TOML() string {
+	return fmt.Sprintf(`
+## enabled enables tracing query spans and exporting them via OTLP.
+## Default: %v
+enabled = %v
+## endpoint is the OTLP grpc endpoint(host:port) of trace collector.
+## Default: "%s"
+endpoint = "%s"
+## insecure disables transport security when exporting spans.
+## Default: %v
+insecure = %v
+## sample-ratio is the ratio[0, 1] of queries sampled when request has no trace header,
+## the sampling decision of trace header(traceparent) is always respected.
+## Default: %v
+sample-ratio = %v
+## slow-query-sampling records the spans of queries which are not sampled by ratio,
+## and only exports them when query takes longer than slow-query-threshold.
+## Default: %v
+slow-query-sampling = %v
+## max-buffered-spans is the maximum number of spans buffered for one query before slow query decision.
+## Default: %d
+max-buffered-spans = %d`,
+		t.Enabled,
+		t.Enabled,
+		t.Endpoint,
+		t.Endpoint,
+		t.Insecure,
+		t.Insecure,
+		t.SampleRatio,
+		t.SampleRatio,
+		t.SlowQuerySampling,
+		t.SlowQuerySampling,
+		t.MaxBufferedSpans,
+		t.MaxBufferedSpans,
+	)
+}
+
+// NewDefaultTracing returns a new default tracing config(disabled).
+func NewDefaultTracing() Tracing {
+	return Tracing{
+		Endpoint:          "127.0.0.1:4317",
+		Insecure:          true,
+		SampleRatio:       0,
+		SlowQuerySampling: true,
+		MaxBufferedSpans:  1024,
+	}
+}
+
+// Hedge represents the configuration of hedging slow leaf request to other replica.
+type Hedge struct {
+	Percentile float64        `toml:"percentile"`
+	MinDelay   ltoml.Duration `toml:"min-delay"`
+	MaxRate    float64        `toml:"max-rate"`
+}
+
+// TOML returns hedge's configuration string as toml format.
+func (h *Hedge) TOML() string {
+	return fmt.Sprintf(`
+## percentile(0, 100] of recent leaf response latencies used as the delay of hedging request,
+## leaf request which has not responded in this delay is duplicated to other replica.
+## Default: %v
+percentile = %v
+## min-delay is the minimum delay of hedging request.
+## Default: %s
+min-delay = "%s"
+## max-rate is the maximum ratio(0, 1] of hedged requests to all leaf requests,
+## so that hedging would not double cluster load during an incident.
+## Default: %v
+max-rate = %v`,
+		h.Percentile,
+		h.Percentile,
+		h.MinDelay,
+		h.MinDelay,
+		h.MaxRate,
+		h.MaxRate,
+	)
+}
+
+// NewDefaultHedge returns a new default hedge config.
+func NewDefaultHedge() Hedge {
+	return Hedge{
+		Percentile: 95,
+		MinDelay:   ltoml.Duration(10 * time.Millisecond),
+		MaxRate:    0.1,
+	}
+}
+
+// Defines all second pass modes of top-n query.
+const (
+	// TopNSecondPassAuto issues second pass only if order by aggregation is not decomposable(like avg).
+	TopNSecondPassAuto = "auto"
+	// TopNSecondPassAlways always issues second pass for exact values of candidates.
+	TopNSecondPassAlways = "always"
+	// TopNSecondPassNever never issues second pass, result values may be approximate.
+	TopNSecondPassNever = "never"
+)
+
+// TopN represents the configuration of top-n query pushdown.
+type TopN struct {
+	CandidateFactor int    `toml:"candidate-factor"`
+	SecondPass      string `toml:"second-pass"`
+}
+
+// TOML returns top-n's configuration string as toml format.
+func (t *TopN) TOML() string {
+	return fmt.Sprintf(`
+## candidate-factor makes each storage node keep top (offset+limit)*factor candidate groups by partial aggregates,
+## 0 disables pushdown, then broker materializes all groups before ordering.
+## Default: %d
+candidate-factor = %d
+## second-pass controls querying the candidates again for exact values, auto/always/never,
+## auto only issues second pass if order by aggregation is not decomposable(like avg).
+## Default: "%s"
+second-pass = "%s"`,
+		t.CandidateFactor,
+		t.CandidateFactor,
+		t.SecondPass,
+		t.SecondPass,
+	)
+}
+
+// NewDefaultTopN returns a new default top-n config.
+func NewDefaultTopN() TopN {
+	return TopN{
+		CandidateFactor: 3,
+		SecondPass:      TopNSecondPassAuto,
+	}
+}
+
+// QueryMemory represents the configuration of memory budget for grouping query.
+type QueryMemory struct {
+	Budget      ltoml.Size `toml:"budget"`
+	EnableSpill bool       `toml:"enable-spill"`
+	SpillDir    string     `toml:"spill-dir"`
+}
+
+// TOML returns query memory's configuration string as toml format.
+func (m *QueryMemory) TOML() string {
+	return fmt.Sprintf(`
+## budget is the maximum estimated memory of grouped series buffered for merging of one query,
+## it can be overridden by the memoryBudget param of request.
+## Default: %s
+budget = "%s"
+## enable-spill spills the grouped series to temporary files if budget exceeded, then merges them in the final pass,
+## query fails if budget exceeded when spilling is disabled.
+## Default: %v
+enable-spill = %v
+## spill-dir is the directory of temporary files which grouped series are spilled to.
+## Default: %s
+spill-dir = "%s"`,
+		m.Budget.String(),
+		m.Budget.String(),
+		m.EnableSpill,
+		m.EnableSpill,
+		strings.ReplaceAll(m.SpillDir, "\\", "\\\\"),
+		strings.ReplaceAll(m.SpillDir, "\\", "\\\\"),
+	)
+}
+
+// NewDefaultQueryMemory returns a new default query memory config.
+func NewDefaultQueryMemory() QueryMemory {
+	return QueryMemory{
+		Budget:      ltoml.Size(256 * 1024 * 1024),
+		EnableSpill: true,
+		SpillDir:    filepath.Join(defaultParentDir, "query", "spill"),
 	}
 }
 
@@ -218,6 +522,31 @@ func checkGRPCCfg(grpcCfg *GRPC) error {
 	if grpcCfg.ConnectTimeout <= 0 {
 		grpcCfg.ConnectTimeout = ltoml.Duration(time.Second * 3)
 	}
+	return checkTLSCfg(&grpcCfg.TLS)
+}
+
+func checkTLSCfg(tlsCfg *TLS) error {
+	defaultTLS := NewDefaultTLS()
+	if tlsCfg.ClientAuth == "" {
+		tlsCfg.ClientAuth = defaultTLS.ClientAuth
+	}
+	if tlsCfg.ReloadInterval <= 0 {
+		tlsCfg.ReloadInterval = defaultTLS.ReloadInterval
+	}
+	if !tlsCfg.Enabled {
+		return nil
+	}
+	if tlsCfg.CertFile == "" || tlsCfg.KeyFile == "" {
+		return fmt.Errorf("grpc tls cert-file and key-file cannot be empty when tls enabled")
+	}
+	if tlsCfg.CAFile == "" {
+		return fmt.Errorf("grpc tls ca-file cannot be empty when tls enabled")
+	}
+	switch tlsCfg.ClientAuth {
+	case TLSClientAuthNone, TLSClientAuthRequest, TLSClientAuthVerifyIfGiven, TLSClientAuthRequireAndVerify:
+	default:
+		return fmt.Errorf("unknown grpc tls client-auth: %s", tlsCfg.ClientAuth)
+	}
 	return nil
 }
 
@@ -232,4 +561,62 @@ func checkQueryCfg(queryCfg *Query) {
 	if queryCfg.IdleTimeout <= 0 {
 		queryCfg.IdleTimeout = defaultQuery.IdleTimeout
 	}
+	if queryCfg.SlowQueryThreshold <= 0 {
+		queryCfg.SlowQueryThreshold = defaultQuery.SlowQueryThreshold
+	}
+	if queryCfg.SlowQueryLogSize <= 0 {
+		queryCfg.SlowQueryLogSize = defaultQuery.SlowQueryLogSize
+	}
+	checkTracingCfg(&queryCfg.Tracing)
+}
+
+func checkHedgeCfg(hedgeCfg *Hedge) {
+	defaultHedge := NewDefaultHedge()
+	if hedgeCfg.Percentile <= 0 || hedgeCfg.Percentile > 100 {
+		hedgeCfg.Percentile = defaultHedge.Percentile
+	}
+	if hedgeCfg.MinDelay <= 0 {
+		hedgeCfg.MinDelay = defaultHedge.MinDelay
+	}
+	if hedgeCfg.MaxRate <= 0 || hedgeCfg.MaxRate > 1 {
+		hedgeCfg.MaxRate = defaultHedge.MaxRate
+	}
+}
+
+func checkTopNCfg(topNCfg *TopN) {
+	defaultTopN := NewDefaultTopN()
+	if topNCfg.CandidateFactor < 0 {
+		topNCfg.CandidateFactor = defaultTopN.CandidateFactor
+	}
+	switch topNCfg.SecondPass {
+	case TopNSecondPassAuto, TopNSecondPassAlways, TopNSecondPassNever:
+	default:
+		topNCfg.SecondPass = defaultTopN.SecondPass
+	}
+}
+
+func checkTracingCfg(tracingCfg *Tracing) {
+	defaultTracing := NewDefaultTracing()
+	if tracingCfg.Endpoint == "" {
+		tracingCfg.Endpoint = defaultTracing.Endpoint
+	}
+	if tracingCfg.SampleRatio < 0 {
+		tracingCfg.SampleRatio = 0
+	}
+	if tracingCfg.SampleRatio > 1 {
+		tracingCfg.SampleRatio = 1
+	}
+	if tracingCfg.MaxBufferedSpans <= 0 {
+		tracingCfg.MaxBufferedSpans = defaultTracing.MaxBufferedSpans
+	}
+}
+
+func checkQueryMemoryCfg(memoryCfg *QueryMemory) {
+	defaultMemory := NewDefaultQueryMemory()
+	if memoryCfg.Budget <= 0 {
+		memoryCfg.Budget = defaultMemory.Budget
+	}
+	if memoryCfg.SpillDir == "" {
+		memoryCfg.SpillDir = defaultMemory.SpillDir
+	}
 }
@@ -43,3 +43,122 @@ func TestGeneral_string(t *testing.T) {
 		strings.Join(repo.Endpoints, ","), repo.LeaseTTL, repo.Timeout, repo.DialTimeout),
 		repo.String())
 }
+
+func TestRedact(t *testing.T) {
+	cfg := &Broker{
+		Coordinator: *NewDefaultCoordinator(),
+		Query:       *NewDefaultQuery(),
+		BrokerBase:  *NewDefaultBrokerBase(),
+		Monitor:     *NewDefaultMonitor(),
+		Logging:     *NewDefaultLogging(),
+	}
+	cfg.Coordinator.Password = "etcd-pwd"
+	cfg.BrokerBase.Auth.Tokens = []string{"admin:admin-token"}
+	assert.Contains(t, cfg.TOML(), "etcd-pwd")
+
+	redacted := Redact(cfg)
+	assert.Contains(t, Redact(&User{UserName: "admin", Password: "admin-pwd"}), "admin")
+	assert.NotContains(t, Redact(&User{UserName: "admin", Password: "admin-pwd"}), "admin-pwd")
+	for _, secret := range []string{"etcd-pwd", "admin-token"} {
+		assert.NotContains(t, redacted, secret)
+	}
+	assert.Contains(t, redacted, `password = "******"`)
+	assert.Contains(t, redacted, `tokens = "******"`)
+	// other values are kept
+	assert.Contains(t, redacted, cfg.Coordinator.Namespace)
+	assert.Equal(t, len(strings.Split(cfg.TOML(), "\n")), len(strings.Split(redacted, "\n")))
+}
+
+func Test_checkTLSCfg(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     TLS
+		wantErr bool
+	}{
+		{
+			name: "tls disabled",
+			cfg:  TLS{},
+		},
+		{
+			name:    "cert file empty",
+			cfg:     TLS{Enabled: true, KeyFile: "key.pem"},
+			wantErr: true,
+		},
+		{
+			name:    "key file empty",
+			cfg:     TLS{Enabled: true, CertFile: "cert.pem"},
+			wantErr: true,
+		},
+		{
+			name:    "ca file empty",
+			cfg:     TLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem"},
+			wantErr: true,
+		},
+		{
+			name: "client auth none",
+			cfg:  TLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", CAFile: "ca.pem"},
+		},
+		{
+			name: "verify client with ca",
+			cfg: TLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", CAFile: "ca.pem",
+				ClientAuth: TLSClientAuthRequireAndVerify},
+		},
+		{
+			name:    "unknown client auth",
+			cfg:     TLS{Enabled: true, CertFile: "cert.pem", KeyFile: "key.pem", CAFile: "ca.pem", ClientAuth: "abc"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTLSCfg(&tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkTLSCfg() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.NotEmpty(t, tt.cfg.ClientAuth)
+				assert.NotZero(t, tt.cfg.ReloadInterval)
+			}
+		})
+	}
+}
+
+func Test_checkTopNCfg(t *testing.T) {
+	topNCfg := TopN{CandidateFactor: -1, SecondPass: "sometimes"}
+	checkTopNCfg(&topNCfg)
+	assert.Equal(t, NewDefaultTopN(), topNCfg)
+	topNCfg = TopN{CandidateFactor: 0, SecondPass: TopNSecondPassNever}
+	checkTopNCfg(&topNCfg)
+	assert.Equal(t, TopN{CandidateFactor: 0, SecondPass: TopNSecondPassNever}, topNCfg)
+}
+
+func Test_checkQueryMemoryCfg(t *testing.T) {
+	memoryCfg := QueryMemory{EnableSpill: true}
+	checkQueryMemoryCfg(&memoryCfg)
+	assert.Equal(t, NewDefaultQueryMemory(), memoryCfg)
+	memoryCfg = QueryMemory{Budget: 1024, SpillDir: "spill"}
+	checkQueryMemoryCfg(&memoryCfg)
+	assert.Equal(t, QueryMemory{Budget: 1024, SpillDir: "spill"}, memoryCfg)
+}
+
+func Test_checkHedgeCfg(t *testing.T) {
+	hedgeCfg := Hedge{Percentile: 101, MaxRate: -1}
+	checkHedgeCfg(&hedgeCfg)
+	assert.Equal(t, NewDefaultHedge(), hedgeCfg)
+	hedgeCfg = Hedge{Percentile: 99, MinDelay: 1, MaxRate: 0.5}
+	checkHedgeCfg(&hedgeCfg)
+	assert.Equal(t, Hedge{Percentile: 99, MinDelay: 1, MaxRate: 0.5}, hedgeCfg)
+}
+
+func Test_checkTracingCfg(t *testing.T) {
+	tracingCfg := Tracing{SampleRatio: -1}
+	checkTracingCfg(&tracingCfg)
+	assert.Equal(t, NewDefaultTracing().Endpoint, tracingCfg.Endpoint)
+	assert.Equal(t, NewDefaultTracing().MaxBufferedSpans, tracingCfg.MaxBufferedSpans)
+	assert.Zero(t, tracingCfg.SampleRatio)
+
+	tracingCfg = Tracing{SampleRatio: 2}
+	checkTracingCfg(&tracingCfg)
+	assert.Equal(t, float64(1), tracingCfg.SampleRatio)
+}
@@ -19,6 +19,7 @@ package config
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 
 	"github.com/lindb/lindb/pkg/ltoml"
@@ -36,16 +37,37 @@ var (
 	globalBrokerCfg  atomic.Value
 	globalStorageCfg atomic.Value
 
+	// storageCfgFile represents the config file which global storage config loaded from, used for reloading.
+	storageCfgFile atomic.Value
+	// storageCfgVersion represents the version of global storage config, increased after each successful reload.
+	storageCfgVersion int64
+	// storageCfgReloadLock makes sure that reloading global storage config is serial.
+	storageCfgReloadLock sync.Mutex
+	// storageCfgListeners represents the listeners which are notified after global storage config reloaded.
+	storageCfgListeners   = make(map[int64]StorageConfigListener)
+	storageCfgListenerSeq int64
+
 	// Profile represents profiling Go programs with pprof
 	Profile = false
 	// Doc enables swagger api doc.
 	Doc = false
 )
 
+// StorageConfigListener represents the listener which is notified after global storage config reloaded,
+// changes are the changed keys of config(key: old -> new).
+type StorageConfigListener func(version int64, changes []string)
+
+// cfgFile represents the config file name and default path.
+type cfgFile struct {
+	name        string
+	defaultPath string
+}
+
 func init() {
 	globalRootCfg.Store(NewDefaultRoot())
 	globalBrokerCfg.Store(NewDefaultBrokerBase())
 	globalStorageCfg.Store(NewDefaultStorageBase())
+	storageCfgFile.Store(cfgFile{})
 }
 
 // GlobalBrokerConfig returns the global broker config
@@ -75,6 +97,7 @@ func LoadAndSetRootConfig(cfgName, defaultPath string, rootCfg *Root) error {
 		return fmt.Errorf("decode root config file error: %s", err)
 	}
 	checkQueryCfg(&rootCfg.Query)
+	checkQueryMemoryCfg(&rootCfg.QueryMemory)
 	if err := checkCoordinatorCfg(&rootCfg.Coordinator); err != nil {
 		return fmt.Errorf("failed check coordinator config: %s", err)
 	}
@@ -92,6 +115,9 @@ func LoadAndSetBrokerConfig(cfgName, defaultPath string, brokerCfg *Broker) erro
 	if err := checkCoordinatorCfg(&brokerCfg.Coordinator); err != nil {
 		return fmt.Errorf("failed check coordinator config: %s", err)
 	}
+	if err := checkMasterCfg(&brokerCfg.Master); err != nil {
+		return fmt.Errorf("failed checking master config: %s", err)
+	}
 	if err := checkBrokerBaseCfg(&brokerCfg.BrokerBase); err != nil {
 		return fmt.Errorf("failed checking broker config: %s", err)
 	}
@@ -113,6 +139,7 @@ func LoadAndSetStorageConfig(cfgName, defaultPath string, storageCfg *Storage) e
 		return fmt.Errorf("failed checking storage config: %s", err)
 	}
 	globalStorageCfg.Store(&storageCfg.StorageBase)
+	storageCfgFile.Store(cfgFile{name: cfgName, defaultPath: defaultPath})
 	return nil
 }
 
@@ -127,6 +154,9 @@ func LoadAndSetStandAloneConfig(cfgName, defaultPath string, standaloneCfg *Stan
 	if err := checkCoordinatorCfg(&standaloneCfg.Coordinator); err != nil {
 		return fmt.Errorf("failed check coordinator config: %s", err)
 	}
+	if err := checkMasterCfg(&standaloneCfg.Master); err != nil {
+		return fmt.Errorf("failed checking master config: %s", err)
+	}
 	if err := checkBrokerBaseCfg(&standaloneCfg.BrokerBase); err != nil {
 		return fmt.Errorf("failed checking broker config: %s", err)
 	}
@@ -135,5 +165,79 @@ func LoadAndSetStandAloneConfig(cfgName, defaultPath string, standaloneCfg *Stan
 	}
 	globalBrokerCfg.Store(&standaloneCfg.BrokerBase)
 	globalStorageCfg.Store(&standaloneCfg.StorageBase)
+	storageCfgFile.Store(cfgFile{name: cfgName, defaultPath: defaultPath})
+	return nil
+}
+
+// StorageConfigFile returns the path of config file which global storage config loaded from,
+// returns empty if global storage config isn't loaded from file.
+func StorageConfigFile() string {
+	file := storageCfgFile.Load().(cfgFile)
+	if file.name != "" {
+		return file.name
+	}
+	return file.defaultPath
+}
+
+// StorageConfigVersion returns the version of global storage config, increased after each successful reload.
+func StorageConfigVersion() int64 {
+	return atomic.LoadInt64(&storageCfgVersion)
+}
+
+// AddStorageConfigListener adds a listener which is notified after global storage config reloaded,
+// returns the function which removes the listener.
+func AddStorageConfigListener(listener StorageConfigListener) (remove func()) {
+	storageCfgReloadLock.Lock()
+	defer storageCfgReloadLock.Unlock()
+
+	storageCfgListenerSeq++
+	id := storageCfgListenerSeq
+	storageCfgListeners[id] = listener
+	return func() {
+		storageCfgReloadLock.Lock()
+		defer storageCfgReloadLock.Unlock()
+
+		delete(storageCfgListeners, id)
+	}
+}
+
+// ReloadStorageConfig re-reads the config file which global storage config loaded from,
+// then swaps the tsdb config of global storage config(other settings require restart).
+func ReloadStorageConfig() error {
+	file := storageCfgFile.Load().(cfgFile)
+	if file.name == "" && file.defaultPath == "" {
+		return fmt.Errorf("storage config isn't loaded from config file")
+	}
+	// storage/standalone config file both keep storage config under [storage] section
+	newCfg := &struct {
+		StorageBase StorageBase `toml:"storage"`
+	}{}
+	if err := loadConfigFn(file.name, file.defaultPath, newCfg); err != nil {
+		return fmt.Errorf("decode storage config file error: %s", err)
+	}
+	return ReloadStorageTSDBConfig(newCfg.StorageBase.TSDB)
+}
+
+// ReloadStorageTSDBConfig validates the new tsdb config, then swaps the global storage config atomically
+// and notifies the listeners if config changed, the old config is kept if new config is invalid.
+func ReloadStorageTSDBConfig(tsdbCfg TSDB) error {
+	storageCfgReloadLock.Lock()
+	defer storageCfgReloadLock.Unlock()
+
+	oldCfg := GlobalStorageConfig()
+	if err := validateTSDBCfg(&oldCfg.TSDB, &tsdbCfg); err != nil {
+		return fmt.Errorf("invalid tsdb config: %s", err)
+	}
+	changes := diffTSDBCfg(&oldCfg.TSDB, &tsdbCfg)
+	if len(changes) == 0 {
+		return nil
+	}
+	newCfg := *oldCfg
+	newCfg.TSDB = tsdbCfg
+	globalStorageCfg.Store(&newCfg)
+	version := atomic.AddInt64(&storageCfgVersion, 1)
+	for _, listener := range storageCfgListeners {
+		listener(version, changes)
+	}
 	return nil
 }
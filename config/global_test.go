@@ -20,6 +20,7 @@ package config
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -61,6 +62,16 @@ func TestLoadAndSetBrokerConfig(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "valid master failure",
+			prepare: func(cfg *Broker) {
+				loadConfigFn = func(cfgPath, defaultCfgPath string, v interface{}) error {
+					return nil
+				}
+				cfg.Master.DropGracePeriod = -1
+			},
+			wantErr: true,
+		},
 		{
 			name: "valid broker failure",
 			prepare: func(cfg *Broker) {
@@ -307,3 +318,162 @@ func TestLoadAndSetStandaloneConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadStorageConfig(t *testing.T) {
+	defer func() {
+		loadConfigFn = ltoml.LoadConfig
+		storageCfgFile.Store(cfgFile{})
+		SetGlobalStorageConfig(NewDefaultStorageBase())
+	}()
+	SetGlobalStorageConfig(NewDefaultStorageBase())
+	// not loaded from config file
+	assert.Error(t, ReloadStorageConfig())
+
+	storageCfgFile.Store(cfgFile{name: "storage.toml"})
+	assert.Equal(t, "storage.toml", StorageConfigFile())
+	loadConfigFn = func(_, _ string, _ interface{}) error {
+		return fmt.Errorf("err")
+	}
+	assert.Error(t, ReloadStorageConfig())
+
+	var changes []string
+	var version int64
+	remove := AddStorageConfigListener(func(v int64, c []string) {
+		version = v
+		changes = c
+	})
+	defer remove()
+	loadConfigFn = func(_, _ string, v interface{}) error {
+		newCfg := v.(*struct {
+			StorageBase StorageBase `toml:"storage"`
+		})
+		newCfg.StorageBase = *NewDefaultStorageBase()
+		newCfg.StorageBase.TSDB.MaxMemDBSize = ltoml.Size(1024 * 1024 * 1024)
+		return nil
+	}
+	assert.NoError(t, ReloadStorageConfig())
+	assert.Equal(t, ltoml.Size(1024*1024*1024), GlobalStorageConfig().TSDB.MaxMemDBSize)
+	assert.Equal(t, StorageConfigVersion(), version)
+	assert.Equal(t, []string{"max-memdb-size: 500 MiB -> 1.0 GiB"}, changes)
+
+	// no change
+	changes = nil
+	assert.NoError(t, ReloadStorageConfig())
+	assert.Nil(t, changes)
+}
+
+func TestReloadStorageTSDBConfig(t *testing.T) {
+	defer SetGlobalStorageConfig(NewDefaultStorageBase())
+
+	cases := []struct {
+		name    string
+		prepare func(cfg *TSDB)
+		wantErr bool
+	}{
+		{
+			name: "negative ttl",
+			prepare: func(cfg *TSDB) {
+				cfg.MutableMemDBTTL = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "ttl jitter out of range",
+			prepare: func(cfg *TSDB) {
+				cfg.MutableMemDBTTLJitter = 1
+			},
+			wantErr: true,
+		},
+		{
+			name: "max memory usage out of range",
+			prepare: func(cfg *TSDB) {
+				cfg.MaxMemUsageBeforeFlush = 1.1
+			},
+			wantErr: true,
+		},
+		{
+			name: "target memory usage out of range",
+			prepare: func(cfg *TSDB) {
+				cfg.TargetMemUsageAfterFlush = -0.1
+			},
+			wantErr: true,
+		},
+		{
+			name: "target memory usage greater than max",
+			prepare: func(cfg *TSDB) {
+				cfg.TargetMemUsageAfterFlush = 0.8
+			},
+			wantErr: true,
+		},
+		{
+			name: "target memdb total size greater than max",
+			prepare: func(cfg *TSDB) {
+				cfg.MaxMemDBTotalSize = ltoml.Size(1024)
+				cfg.TargetMemDBTotalSize = ltoml.Size(2048)
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative limit",
+			prepare: func(cfg *TSDB) {
+				cfg.MaxTagKeysNumber = -1
+			},
+			wantErr: true,
+		},
+		{
+			name: "dir changed",
+			prepare: func(cfg *TSDB) {
+				cfg.Dir = "/tmp/new-dir"
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty dir",
+			prepare: func(cfg *TSDB) {
+				cfg.Dir = ""
+			},
+			wantErr: true,
+		},
+		{
+			name: "buffer dir changed",
+			prepare: func(cfg *TSDB) {
+				cfg.BufferDir = "/tmp/buffer/{database}/{shard}"
+			},
+			wantErr: true,
+		},
+		{
+			name: "reload successfully",
+			prepare: func(cfg *TSDB) {
+				cfg.MutableMemDBTTL = ltoml.Duration(time.Minute)
+				cfg.MaxMemUsageBeforeFlush = 0
+			},
+		},
+		{
+			name: "reload memdb total size",
+			prepare: func(cfg *TSDB) {
+				cfg.MaxMemDBTotalSize = ltoml.Size(1000)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			SetGlobalStorageConfig(NewDefaultStorageBase())
+			oldCfg := GlobalStorageConfig()
+			tsdbCfg := oldCfg.TSDB
+			tt.prepare(&tsdbCfg)
+			err := ReloadStorageTSDBConfig(tsdbCfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReloadStorageTSDBConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				// keep old config
+				assert.Equal(t, oldCfg, GlobalStorageConfig())
+			} else {
+				assert.NotEqual(t, oldCfg, GlobalStorageConfig())
+				// fill default value
+				assert.Equal(t, oldCfg.TSDB.MaxMemUsageBeforeFlush, GlobalStorageConfig().TSDB.MaxMemUsageBeforeFlush)
+			}
+		})
+	}
+}
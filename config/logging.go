@@ -37,6 +37,30 @@ type Logging struct {
 	MaxSize    ltoml.Size `toml:"maxsize"`
 	MaxBackups uint16     `toml:"maxbackups"`
 	MaxAge     uint16     `toml:"maxage"`
+	Audit      Audit      `toml:"audit"`
+}
+
+// Audit represents audit log of admin operations configuration,
+// audit log file(audit.log) shares the rotation settings of logging.
+type Audit struct {
+	PersistToRepo    bool `toml:"persist-to-repo"`
+	MaxEntriesInRepo int  `toml:"max-entries-in-repo"`
+}
+
+// TOML returns Audit's toml config string
+func (a *Audit) TOML() string {
+	return fmt.Sprintf(`
+## PersistToRepo enables persisting audit entries into state repository for cluster-wide querying.
+## Default: %v
+persist-to-repo = %v
+## MaxEntriesInRepo is the maximum number of audit entries each node keeps in state repository.
+## Default: %d
+max-entries-in-repo = %d`,
+		a.PersistToRepo,
+		a.PersistToRepo,
+		a.MaxEntriesInRepo,
+		a.MaxEntriesInRepo,
+	)
 }
 
 // TOML returns Logging's toml config string
@@ -63,7 +87,10 @@ maxbackups = %d
 ## and may not exactly correspond to calendar days due to daylight savings, leap seconds, etc.
 ## The default is not to remove old log files based on age.
 ## Default: %d
-maxage = %d`,
+maxage = %d
+
+## audit log of admin operations related configuration.
+[logging.audit]%s`,
 		strings.ReplaceAll(l.Dir, "\\", "\\\\"),
 		strings.ReplaceAll(l.Dir, "\\", "\\\\"),
 		l.Level,
@@ -74,6 +101,7 @@ maxage = %d`,
 		l.MaxBackups,
 		l.MaxAge,
 		l.MaxAge,
+		l.Audit.TOML(),
 	)
 }
 
@@ -85,5 +113,9 @@ func NewDefaultLogging() *Logging {
 		MaxSize:    ltoml.Size(100 * 1024 * 1024),
 		MaxBackups: 3,
 		MaxAge:     7,
+		Audit: Audit{
+			PersistToRepo:    false,
+			MaxEntriesInRepo: 1000,
+		},
 	}
 }
@@ -26,11 +26,12 @@ import (
 
 // Root represents a root configuration with common settings.
 type Root struct {
-	Coordinator RepoState `toml:"coordinator"`
-	Query       Query     `toml:"query"`
-	HTTP        HTTP      `toml:"http"`
-	Monitor     Monitor   `toml:"monitor"`
-	Logging     Logging   `toml:"logging"`
+	Coordinator RepoState   `toml:"coordinator"`
+	Query       Query       `toml:"query"`
+	QueryMemory QueryMemory `toml:"query-memory"`
+	HTTP        HTTP        `toml:"http"`
+	Monitor     Monitor     `toml:"monitor"`
+	Logging     Logging     `toml:"logging"`
 }
 
 // TOML returns root's configuration string as toml format.
@@ -41,6 +42,9 @@ func (r *Root) TOML() string {
 ## Query related configuration.
 %s
 
+## Controls the memory budget of grouped series buffered by root for one query.
+[query-memory]%s
+
 ## Controls how HTTP Server are configured.
 [http]%s
 
@@ -48,6 +52,7 @@ func (r *Root) TOML() string {
 %s`,
 		r.Coordinator.TOML(),
 		r.Query.TOML(),
+		r.QueryMemory.TOML(),
 		r.HTTP.TOML(),
 		r.Monitor.TOML(),
 		r.Logging.TOML(),
@@ -64,6 +69,7 @@ func NewDefaultRoot() *Root {
 	return &Root{
 		Coordinator: *NewDefaultCoordinator(),
 		Query:       *NewDefaultQuery(),
+		QueryMemory: NewDefaultQueryMemory(),
 		HTTP: HTTP{
 			Port:         3000,
 			IdleTimeout:  ltoml.Duration(time.Minute * 2),
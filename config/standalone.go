@@ -27,6 +27,7 @@ import (
 type Standalone struct {
 	ETCD        ETCD        `toml:"etcd"`
 	Coordinator RepoState   `toml:"coordinator"`
+	Master      Master      `toml:"master"`
 	Query       Query       `toml:"query"`
 	BrokerBase  BrokerBase  `toml:"broker"`
 	StorageBase StorageBase `toml:"storage"`
@@ -76,6 +77,9 @@ func NewDefaultStandaloneTOML() string {
 ## Coordinator related configuration.
 %s
 
+## Master related configuration.
+%s
+
 ## Query related configuration.
 %s
 %s
@@ -85,6 +89,7 @@ func NewDefaultStandaloneTOML() string {
 
 		NewDefaultETCD().TOML(),
 		NewDefaultCoordinator().TOML(),
+		NewDefaultMaster().TOML(),
 		NewDefaultQuery().TOML(),
 		NewDefaultBrokerBase().TOML(),
 		NewDefaultStorageBase().TOML(),
@@ -98,6 +103,7 @@ func NewDefaultStandalone() Standalone {
 	return Standalone{
 		ETCD:        *NewDefaultETCD(),
 		Coordinator: *NewDefaultCoordinator(),
+		Master:      *NewDefaultMaster(),
 		Query:       *NewDefaultQuery(),
 		BrokerBase:  *NewDefaultBrokerBase(),
 		StorageBase: *NewDefaultStorageBase(),
@@ -21,25 +21,45 @@ import (
 	"fmt"
 	"math"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/lindb/lindb/pkg/ltoml"
 )
 
+// Defines the variables of buffer dir template, replaced by database name/shard id of each shard.
+const (
+	BufferDirDatabaseVar = "{database}"
+	BufferDirShardVar    = "{shard}"
+)
+
 // TSDB represents the tsdb configuration.
 type TSDB struct {
 	Dir                      string         `toml:"dir"`
+	BufferDir                string         `toml:"buffer-dir"`
+	SeparateBufferDir        bool           `toml:"separate-buffer-dir"`
 	MaxMemDBSize             ltoml.Size     `toml:"max-memdb-size"`
 	MutableMemDBTTL          ltoml.Duration `toml:"mutable-memdb-ttl"`
+	MutableMemDBTTLJitter    float64        `toml:"mutable-memdb-ttl-jitter"`
 	MaxMemUsageBeforeFlush   float64        `toml:"max-mem-usage-before-flush"`
 	TargetMemUsageAfterFlush float64        `toml:"target-mem-usage-after-flush"`
 	FlushConcurrency         int            `toml:"flush-concurrency"`
+	MaxMemDBTotalSize        ltoml.Size     `toml:"max-memdb-total-size"`
+	TargetMemDBTotalSize     ltoml.Size     `toml:"target-memdb-total-size"`
+	MaxWriteBackoff          ltoml.Duration `toml:"max-write-backoff"`
 	MaxSeriesIDsNumber       int            `toml:"max-seriesIDs"`
 	SeriesSequenceCache      uint32         `toml:"series-sequence-cache"`
 	MetaSequenceCache        uint32         `toml:"meta-sequence-cache"`
 	MaxTagKeysNumber         int            `toml:"max-tagKeys"`
+	RecoveryConcurrency      int            `toml:"recovery-concurrency"`
+	TolerateCorruptFamily    bool           `toml:"tolerate-corrupt-family"`
+	OrphanFileAction         string         `toml:"orphan-file-action"`
+	SeriesGCHorizon          ltoml.Duration `toml:"series-gc-horizon"`
+	SeriesGCBatchSize        int            `toml:"series-gc-batch-size"`
+	ShardHibernateAfter      ltoml.Duration `toml:"shard-hibernate-after"`
 }
 
 func (t *TSDB) TOML() string {
@@ -47,6 +67,15 @@ func (t *TSDB) TOML() string {
 ## The TSDB directory where the time series data and meta file stores.
 ## Default: %s
 dir = "%s"
+## The directory template where the memdb write buffers of each shard store,
+## supports variables {database} and {shard}, e.g. "/nvme/lindb/buffer/{database}/{shard}".
+## Empty means under the shard directory of tsdb dir.
+## Buffers under old directory are abandoned after changed, unflushed data is replayed from wal.
+## Default: %s
+buffer-dir = "%s"
+## Buffer dir must not be inside tsdb dir if true, for placing buffers on dedicated device.
+## Default: %v
+separate-buffer-dir = %v
 
 ## Flush configuration
 ## 
@@ -59,6 +88,10 @@ max-memdb-size = "%s"
 ## event if the configured memdb-size is not reached.
 ## Default: %s
 mutable-memdb-ttl = "%s"
+## Mutable memdb ttl is shortened by a random ratio up to this value,
+## spreads out the flushes of memdbs created at the same time(e.g. after restart), 0 disables jitter.
+## Default: %.2f
+mutable-memdb-ttl-jitter = %.2f
 ## Global flush operation will be triggered
 ## when system memory usage is higher than this ratio.
 ## Default: %.2f
@@ -70,6 +103,19 @@ target-mem-usage-after-flush = %.2f
 ## concurrency of goroutines for flushing.
 ## Default: %d
 flush-concurrency = %d
+## Node-wide flush will pick the biggest families to flush
+## when total memdb size of all families is higher than this size, 0 disables it.
+## Default: %s
+max-memdb-total-size = "%s"
+## Node-wide flush will stop picking families
+## when total memdb size of all families is lower than this size.
+## Default: %s
+target-memdb-total-size = "%s"
+## Replica consumer of family slows down by at most this duration for each message
+## when memdb size of family is higher than max-memdb-size(flush falls behind),
+## the backoff is proportional to the overflow, 0 disables write backpressure.
+## Default: %s
+max-write-backoff = "%s"
 
 ## Time Series limitation
 ## 
@@ -78,34 +124,124 @@ flush-concurrency = %d
 max-seriesIDs = %d
 ## Limit for tagKeys
 ## Default: %d
-max-tagKeys = %d`,
+max-tagKeys = %d
+
+## Recovery configuration
+## 
+## concurrency of goroutines for opening shards and families after startup,
+## the newest families are opened first.
+## Default: %d
+recovery-concurrency = %d
+## Move the family(segment) which cannot be opened aside and report it,
+## instead of aborting the recovery of storage node.
+## Default: %v
+tolerate-corrupt-family = %v
+## How to handle the orphan files of family which are not referenced by any version when opening family,
+## orphan files are left by crash during flush/compaction, options: quarantine/delete.
+## Default: %s
+orphan-file-action = "%s"
+
+## Series GC configuration
+## 
+## Series which are not written within this horizon and not present in any retained family
+## are removed from the index, must be at least 24h, 0 disables series gc.
+## Series gc runs with ttl task.
+## Default: %s
+series-gc-horizon = "%s"
+## The max number of series reclaimed by each round of series gc for each shard.
+## Default: %d
+series-gc-batch-size = %d
+
+## Shard hibernation configuration
+## 
+## Shard without writes and queries within this idle period hibernates(releases write buffers
+## and skips per-shard statistics collecting), it is woken up by the next write or query,
+## 0 disables shard hibernation.
+## Default: %s
+shard-hibernate-after = "%s"`,
 		strings.ReplaceAll(t.Dir, "\\", "\\\\"),
 		strings.ReplaceAll(t.Dir, "\\", "\\\\"),
+		strings.ReplaceAll(t.BufferDir, "\\", "\\\\"),
+		strings.ReplaceAll(t.BufferDir, "\\", "\\\\"),
+		t.SeparateBufferDir,
+		t.SeparateBufferDir,
 		t.MaxMemDBSize.String(),
 		t.MaxMemDBSize.String(),
 		t.MutableMemDBTTL.String(),
 		t.MutableMemDBTTL.String(),
+		t.MutableMemDBTTLJitter,
+		t.MutableMemDBTTLJitter,
 		t.MaxMemUsageBeforeFlush,
 		t.MaxMemUsageBeforeFlush,
 		t.TargetMemUsageAfterFlush,
 		t.TargetMemUsageAfterFlush,
 		t.FlushConcurrency,
 		t.FlushConcurrency,
+		t.MaxMemDBTotalSize.String(),
+		t.MaxMemDBTotalSize.String(),
+		t.TargetMemDBTotalSize.String(),
+		t.TargetMemDBTotalSize.String(),
+		t.MaxWriteBackoff.String(),
+		t.MaxWriteBackoff.String(),
 		t.MaxSeriesIDsNumber,
 		t.MaxSeriesIDsNumber,
 		t.MaxTagKeysNumber,
 		t.MaxTagKeysNumber,
+		t.RecoveryConcurrency,
+		t.RecoveryConcurrency,
+		t.TolerateCorruptFamily,
+		t.TolerateCorruptFamily,
+		t.OrphanFileAction,
+		t.OrphanFileAction,
+		t.SeriesGCHorizon.String(),
+		t.SeriesGCHorizon.String(),
+		t.SeriesGCBatchSize,
+		t.SeriesGCBatchSize,
+		t.ShardHibernateAfter.String(),
+		t.ShardHibernateAfter.String(),
 	)
 }
 
+// BufferPath returns the memdb write buffer path of shard, returns empty if buffer dir not set.
+func (t *TSDB) BufferPath(database string, shardID int) string {
+	if t.BufferDir == "" {
+		return ""
+	}
+	path := strings.ReplaceAll(t.BufferDir, BufferDirDatabaseVar, database)
+	return filepath.Clean(strings.ReplaceAll(path, BufferDirShardVar, strconv.Itoa(shardID)))
+}
+
+// BufferRootDir returns the root path of buffer dir template(before the first variable),
+// returns empty if buffer dir not set.
+func (t *TSDB) BufferRootDir() string {
+	if t.BufferDir == "" {
+		return ""
+	}
+	root := t.BufferDir
+	if idx := strings.Index(root, "{"); idx >= 0 {
+		root = filepath.Dir(root[:idx+1])
+	}
+	return filepath.Clean(root)
+}
+
 // StorageBase represents a storage configuration
 type StorageBase struct {
-	BrokerEndpoint  string         `toml:"broker-endpoint"` // Broker http endpoint, auto register current storage cluster.
-	TTLTaskInterval ltoml.Duration `toml:"ttl-task-interval"`
-	HTTP            HTTP           `toml:"http"`
-	GRPC            GRPC           `toml:"grpc"`
-	TSDB            TSDB           `toml:"tsdb"`
-	WAL             WAL            `toml:"wal"`
+	BrokerEndpoint       string           `toml:"broker-endpoint"` // Broker http endpoint, auto register current storage cluster.
+	TTLTaskInterval      ltoml.Duration   `toml:"ttl-task-interval"`
+	ConfigReloadInterval ltoml.Duration   `toml:"config-reload-interval"` // check config file changed for reloading tsdb config
+	RestartGracePeriod   ltoml.Duration   `toml:"restart-grace-period"`   // announced to master when stopped gracefully
+	HTTP                 HTTP             `toml:"http"`
+	GRPC                 GRPC             `toml:"grpc"`
+	TSDB                 TSDB             `toml:"tsdb"`
+	WAL                  WAL              `toml:"wal"`
+	DeadLetter           DeadLetter       `toml:"dead-letter"`
+	Health               Health           `toml:"health"`
+	DiskWatchdog         DiskWatchdog     `toml:"disk-watchdog"`
+	QueryAdmission       QueryAdmission   `toml:"query-admission"`
+	ConsistencyCheck     ConsistencyCheck `toml:"consistency-check"`
+	ReplicaBootstrap     ReplicaBootstrap `toml:"replica-bootstrap"`
+	IndexWarmup          IndexWarmup      `toml:"index-warmup"`
+	Tiering              Tiering          `toml:"tiering"`
 }
 
 // TOML returns StorageBase's toml config string
@@ -116,6 +252,14 @@ func (s *StorageBase) TOML() string {
 ## interval for how often do ttl job
 ## Default: %s
 ttl-task-interval = "%s"
+## interval for how often to check if config file changed,
+## tsdb config(except dir) is reloaded without restart when changed, 0 disables it.
+## Default: %s
+config-reload-interval = "%s"
+## grace period announced to master when storage node is stopped gracefully(rolling restart),
+## master defers shard leader reassignment of the node until it expires, 0 disables it.
+## Default: %s
+restart-grace-period = "%s"
 ## Broker http endpoint which storage self register address
 ## Default: %s
 broker-endpoint = "%s"
@@ -126,27 +270,72 @@ broker-endpoint = "%s"
 ## Storage GRPC related configuration.
 [storage.grpc]%s
 
+## Transport security of intra-cluster GRPC.
+[storage.grpc.tls]%s
+
 ## Write Ahead Log related configuration.
 [storage.wal]%s
 
 ## TSDB related configuration.
-[storage.tsdb]%s`,
+[storage.tsdb]%s
+
+## Dead-letter related configuration.
+[storage.dead-letter]%s
+
+## Health check related configuration.
+[storage.health]%s
+
+## Disk space watchdog related configuration.
+[storage.disk-watchdog]%s
+
+## Admission control of query execution on storage node.
+[storage.query-admission]%s
+
+## Consistency check of shard replicas on storage node.
+[storage.consistency-check]%s
+
+## Bootstrapping new shard replica from snapshot of leader.
+[storage.replica-bootstrap]%s
+
+## Warming up the index of most queried metrics after storage node restart.
+[storage.index-warmup]%s
+
+## Moving the files of old families to object storage(cold storage tiering).
+[storage.tiering]%s
+
+## S3-compatible service used by s3 backend of tiering.
+[storage.tiering.s3]%s`,
 		s.TTLTaskInterval,
 		s.TTLTaskInterval,
+		s.ConfigReloadInterval,
+		s.ConfigReloadInterval,
+		s.RestartGracePeriod,
+		s.RestartGracePeriod,
 		s.BrokerEndpoint,
 		s.BrokerEndpoint,
 		s.HTTP.TOML(),
 		s.GRPC.TOML(),
+		s.GRPC.TLS.TOML(),
 		s.WAL.TOML(),
 		s.TSDB.TOML(),
+		s.DeadLetter.TOML(),
+		s.Health.TOML(),
+		s.DiskWatchdog.TOML(),
+		s.QueryAdmission.TOML(),
+		s.ConsistencyCheck.TOML(),
+		s.ReplicaBootstrap.TOML(),
+		s.IndexWarmup.TOML(),
+		s.Tiering.TOML(),
+		s.Tiering.S3.TOML(),
 	)
 }
 
 // WAL represents config for write ahead log in storage.
 type WAL struct {
-	Dir                string         `toml:"dir"`
-	DataSizeLimit      ltoml.Size     `toml:"data-size-limit"`
-	RemoveTaskInterval ltoml.Duration `toml:"remove-task-interval"`
+	Dir                  string         `toml:"dir"`
+	DataSizeLimit        ltoml.Size     `toml:"data-size-limit"`
+	RemoveTaskInterval   ltoml.Duration `toml:"remove-task-interval"`
+	IdempotencyMaxTokens int            `toml:"idempotency-max-tokens"`
 }
 
 func (rc *WAL) GetDataSizeLimit() int64 {
@@ -170,13 +359,332 @@ dir = "%s"
 data-size-limit = "%s"
 ## interval for how often remove expired write ahead log
 ## Default: %s
-remove-task-interval = "%s"`,
+remove-task-interval = "%s"
+## maximum number of idempotency tokens kept per shard family when replaying write ahead log,
+## rows carrying a token seen in the window are skipped, 0 disables the de-duplication.
+## Default: %d
+idempotency-max-tokens = %d`,
 		strings.ReplaceAll(rc.Dir, "\\", "\\\\"),
 		strings.ReplaceAll(rc.Dir, "\\", "\\\\"),
 		rc.DataSizeLimit.String(),
 		rc.DataSizeLimit.String(),
 		rc.RemoveTaskInterval.String(),
 		rc.RemoveTaskInterval.String(),
+		rc.IdempotencyMaxTokens,
+		rc.IdempotencyMaxTokens,
+	)
+}
+
+// DeadLetter represents config for dead-letter sink which keeps the rows rejected by storage write path.
+type DeadLetter struct {
+	Enabled   bool       `toml:"enabled"`
+	Dir       string     `toml:"dir"`
+	MaxSize   ltoml.Size `toml:"max-size"`
+	RateLimit int        `toml:"rate-limit"`
+	Endpoint  string     `toml:"endpoint"`
+}
+
+func (dl *DeadLetter) TOML() string {
+	return fmt.Sprintf(`
+## Enable dead-letter sink, rows rejected by write path will be kept for inspection and replay.
+## Default: %v
+enabled = %v
+## Dead-letter queue directory
+## Default: %s
+dir = "%s"
+## The max size of dead-letter queue, new dead-letters will be dropped when queue is full.
+## Default: %s
+max-size = "%s"
+## The max number of dead-letters per second, excess dead-letters will be dropped.
+## Default: %d
+rate-limit = %d
+## Http endpoint which dead-letters are forwarded to(POST json), use local queue if empty.
+## Default: %s
+endpoint = "%s"`,
+		dl.Enabled,
+		dl.Enabled,
+		strings.ReplaceAll(dl.Dir, "\\", "\\\\"),
+		strings.ReplaceAll(dl.Dir, "\\", "\\\\"),
+		dl.MaxSize.String(),
+		dl.MaxSize.String(),
+		dl.RateLimit,
+		dl.RateLimit,
+		dl.Endpoint,
+		dl.Endpoint,
+	)
+}
+
+// Health represents config for readiness check of storage node.
+type Health struct {
+	CheckInterval      ltoml.Duration `toml:"check-interval"`
+	MinDiskFreePercent float64        `toml:"min-disk-free-percent"`
+	LoadReportInterval ltoml.Duration `toml:"load-report-interval"`
+}
+
+func (h *Health) TOML() string {
+	return fmt.Sprintf(`
+## interval for how often evaluate the readiness of storage node
+## Default: %s
+check-interval = "%s"
+## storage node is not ready when the free percent of data disk is below this threshold, range in [0, 100]
+## Default: %v
+min-disk-free-percent = %v
+## interval for how often report the load(memory database/flush backlog/disk free) of storage node to master
+## Default: %s
+load-report-interval = "%s"`,
+		h.CheckInterval.String(),
+		h.CheckInterval.String(),
+		h.MinDiskFreePercent,
+		h.MinDiskFreePercent,
+		h.LoadReportInterval.String(),
+		h.LoadReportInterval.String(),
+	)
+}
+
+// DiskWatchdog represents config for disk space watchdog which monitors free space of data/wal disk.
+type DiskWatchdog struct {
+	CheckInterval ltoml.Duration `toml:"check-interval"`
+	LowWatermark  float64        `toml:"low-watermark"`
+	HighWatermark float64        `toml:"high-watermark"`
+}
+
+func (dw *DiskWatchdog) TOML() string {
+	return fmt.Sprintf(`
+## interval for how often check the free space of data/wal disk
+## Default: %s
+check-interval = "%s"
+## pause compaction and reject writes when the free percent of disk is below low watermark, range in (0, 100)
+## Default: %v
+low-watermark = %v
+## resume normal operation when the free percent of disk is above high watermark, must be > low watermark
+## Default: %v
+high-watermark = %v`,
+		dw.CheckInterval.String(),
+		dw.CheckInterval.String(),
+		dw.LowWatermark,
+		dw.LowWatermark,
+		dw.HighWatermark,
+		dw.HighWatermark,
+	)
+}
+
+// QueryAdmission represents config for admission control of leaf query execution in storage,
+// ingestion is not limited by it.
+type QueryAdmission struct {
+	MaxConcurrentQueries int            `toml:"max-concurrent-queries"`
+	MaxInflightScanBytes ltoml.Size     `toml:"max-inflight-scan-bytes"`
+	MaxQueueSize         int            `toml:"max-queue-size"`
+	QueueTimeout         ltoml.Duration `toml:"queue-timeout"`
+}
+
+func (qa *QueryAdmission) TOML() string {
+	return fmt.Sprintf(`
+## max number of leaf queries executing concurrently, 0 disables the admission control.
+## Default: %d
+max-concurrent-queries = %d
+## new queries wait when the bytes scanned by executing queries exceed this threshold, 0 means no limit.
+## Default: %s
+max-inflight-scan-bytes = "%s"
+## max number of queries waiting for admission, excess queries are rejected as node busy.
+## Default: %d
+max-queue-size = %d
+## max duration of query waiting for admission, query is rejected as node busy when timeout.
+## Default: %s
+queue-timeout = "%s"`,
+		qa.MaxConcurrentQueries,
+		qa.MaxConcurrentQueries,
+		qa.MaxInflightScanBytes.String(),
+		qa.MaxInflightScanBytes.String(),
+		qa.MaxQueueSize,
+		qa.MaxQueueSize,
+		qa.QueueTimeout.String(),
+		qa.QueueTimeout.String(),
+	)
+}
+
+// ConsistencyCheck represents config for computing the digests of family when checking consistency between replicas.
+type ConsistencyCheck struct {
+	MaxScanRate  ltoml.Size     `toml:"max-scan-rate"`
+	QueryBackoff ltoml.Duration `toml:"query-backoff"`
+}
+
+func (cc *ConsistencyCheck) TOML() string {
+	return fmt.Sprintf(`
+## max bytes of family data scanned per second when computing digests.
+## Default: %s
+max-scan-rate = "%s"
+## computing digests pauses this duration when foreground queries are executing.
+## Default: %s
+query-backoff = "%s"`,
+		cc.MaxScanRate.String(),
+		cc.MaxScanRate.String(),
+		cc.QueryBackoff.String(),
+		cc.QueryBackoff.String(),
+	)
+}
+
+// ReplicaBootstrap represents config for transferring family snapshots when bootstrapping new shard replica.
+type ReplicaBootstrap struct {
+	Dir           string         `toml:"dir"`
+	ChunkSize     ltoml.Size     `toml:"chunk-size"`
+	SnapshotTTL   ltoml.Duration `toml:"snapshot-ttl"`
+	RetryInterval ltoml.Duration `toml:"retry-interval"`
+}
+
+func (rb *ReplicaBootstrap) TOML() string {
+	return fmt.Sprintf(`
+## directory which keeps the snapshot files and transfer progress when receiving snapshot,
+## must be on the same file system as tsdb directory, files are moved into tsdb when installing.
+## Default: %s
+dir = "%s"
+## max bytes of snapshot chunk sent by leader.
+## Default: %s
+chunk-size = "%s"
+## snapshot of leader is released if no chunk fetched within this duration.
+## Default: %s
+snapshot-ttl = "%s"
+## interrupted transfer is resumed from last verified chunk after this duration.
+## Default: %s
+retry-interval = "%s"`,
+		strings.ReplaceAll(rb.Dir, "\\", "\\\\"),
+		strings.ReplaceAll(rb.Dir, "\\", "\\\\"),
+		rb.ChunkSize.String(),
+		rb.ChunkSize.String(),
+		rb.SnapshotTTL.String(),
+		rb.SnapshotTTL.String(),
+		rb.RetryInterval.String(),
+		rb.RetryInterval.String(),
+	)
+}
+
+// IndexWarmup represents config for warming up the index of most queried metrics after storage node restart.
+type IndexWarmup struct {
+	Enabled         bool           `toml:"enabled"`
+	TopMetrics      int            `toml:"top-metrics"`
+	MaxReadRate     ltoml.Size     `toml:"max-read-rate"`
+	PersistInterval ltoml.Duration `toml:"persist-interval"`
+}
+
+func (iw *IndexWarmup) TOML() string {
+	return fmt.Sprintf(`
+## Enable warming up the index postings and newest family blocks of most queried metrics after recovery,
+## warming up stops immediately if foreground queries are executing.
+## Default: %v
+enabled = %v
+## num. of most queried metrics which are persisted and warmed up after restart.
+## Default: %d
+top-metrics = %d
+## max bytes of index/blocks read per second when warming up.
+## Default: %s
+max-read-rate = "%s"
+## interval for how often to persist the most queried metrics.
+## Default: %s
+persist-interval = "%s"`,
+		iw.Enabled,
+		iw.Enabled,
+		iw.TopMetrics,
+		iw.TopMetrics,
+		iw.MaxReadRate.String(),
+		iw.MaxReadRate.String(),
+		iw.PersistInterval.String(),
+		iw.PersistInterval.String(),
+	)
+}
+
+// Tiering represents config for moving the files of old families to object storage,
+// families older than the tier-after option of database are tiered.
+type Tiering struct {
+	Backend       string         `toml:"backend"`
+	ArchiveDir    string         `toml:"archive-dir"`
+	CacheDir      string         `toml:"cache-dir"`
+	CacheSize     ltoml.Size     `toml:"cache-size"`
+	CheckInterval ltoml.Duration `toml:"check-interval"`
+	MaxUploadRate ltoml.Size     `toml:"max-upload-rate"`
+	S3            S3             `toml:"s3"`
+}
+
+// Enabled returns if tiering is enabled(backend is set).
+func (t *Tiering) Enabled() bool {
+	return t.Backend != ""
+}
+
+func (t *Tiering) TOML() string {
+	return fmt.Sprintf(`
+## backend of object storage which keeps the tiered files(archive/s3), empty disables tiering.
+## Default: %s
+backend = "%s"
+## archive dir(like NFS mount) used by archive backend.
+## Default: %s
+archive-dir = "%s"
+## directory which caches the tiered files downloaded for query.
+## Default: %s
+cache-dir = "%s"
+## max bytes of tiered files cached in cache dir, least recently read files are removed when exceeded.
+## Default: %s
+cache-size = "%s"
+## interval for how often to check the families need to be tiered for each shard.
+## Default: %s
+check-interval = "%s"
+## max bytes of family files uploaded per second when tiering.
+## Default: %s
+max-upload-rate = "%s"`,
+		t.Backend,
+		t.Backend,
+		strings.ReplaceAll(t.ArchiveDir, "\\", "\\\\"),
+		strings.ReplaceAll(t.ArchiveDir, "\\", "\\\\"),
+		strings.ReplaceAll(t.CacheDir, "\\", "\\\\"),
+		strings.ReplaceAll(t.CacheDir, "\\", "\\\\"),
+		t.CacheSize.String(),
+		t.CacheSize.String(),
+		t.CheckInterval.String(),
+		t.CheckInterval.String(),
+		t.MaxUploadRate.String(),
+		t.MaxUploadRate.String(),
+	)
+}
+
+// S3 represents config for S3-compatible service(AWS S3/MinIO etc.), requests are path-style.
+type S3 struct {
+	Endpoint        string `toml:"endpoint"`
+	Region          string `toml:"region"`
+	Bucket          string `toml:"bucket"`
+	Prefix          string `toml:"prefix"`
+	AccessKeyID     string `toml:"access-key-id"`
+	SecretAccessKey string `toml:"secret-access-key"`
+}
+
+func (s *S3) TOML() string {
+	return fmt.Sprintf(`
+## endpoint of service, like https://s3.us-east-1.amazonaws.com
+## Default: %s
+endpoint = "%s"
+## region of bucket.
+## Default: %s
+region = "%s"
+## bucket which keeps the tiered files.
+## Default: %s
+bucket = "%s"
+## key prefix of tiered files in bucket.
+## Default: %s
+prefix = "%s"
+## access key id of credential.
+## Default: %s
+access-key-id = "%s"
+## secret access key of credential.
+## Default: %s
+secret-access-key = "%s"`,
+		s.Endpoint,
+		s.Endpoint,
+		s.Region,
+		s.Region,
+		s.Bucket,
+		s.Bucket,
+		s.Prefix,
+		s.Prefix,
+		s.AccessKeyID,
+		s.AccessKeyID,
+		s.SecretAccessKey,
+		s.SecretAccessKey,
 	)
 }
 
@@ -210,8 +718,10 @@ func (s *Storage) TOML() string {
 // NewDefaultStorageBase returns a new default StorageBase struct
 func NewDefaultStorageBase() *StorageBase {
 	return &StorageBase{
-		TTLTaskInterval: ltoml.Duration(time.Hour * 24),
-		BrokerEndpoint:  "http://localhost:9000",
+		TTLTaskInterval:      ltoml.Duration(time.Hour * 24),
+		ConfigReloadInterval: ltoml.Duration(time.Second * 30),
+		RestartGracePeriod:   ltoml.Duration(time.Minute * 5),
+		BrokerEndpoint:       "http://localhost:9000",
 		HTTP: HTTP{
 			Port:         2892,
 			IdleTimeout:  ltoml.Duration(time.Minute * 2),
@@ -222,23 +732,75 @@ func NewDefaultStorageBase() *StorageBase {
 			Port:                 2891,
 			MaxConcurrentStreams: 1024,
 			ConnectTimeout:       ltoml.Duration(time.Second * 3),
+			TLS:                  NewDefaultTLS(),
 		},
 		WAL: WAL{
-			Dir:                filepath.Join(defaultParentDir, "storage", "wal"),
-			DataSizeLimit:      ltoml.Size(128 * 1024 * 1024),
-			RemoveTaskInterval: ltoml.Duration(time.Minute),
+			Dir:                  filepath.Join(defaultParentDir, "storage", "wal"),
+			DataSizeLimit:        ltoml.Size(128 * 1024 * 1024),
+			RemoveTaskInterval:   ltoml.Duration(time.Minute),
+			IdempotencyMaxTokens: 10000,
 		},
 		TSDB: TSDB{
 			Dir:                      filepath.Join(defaultParentDir, "storage", "data"),
 			MaxMemDBSize:             ltoml.Size(500 * 1024 * 1024),
 			MutableMemDBTTL:          ltoml.Duration(time.Minute * 30),
+			MutableMemDBTTLJitter:    0.1,
 			MaxMemUsageBeforeFlush:   0.75,
 			TargetMemUsageAfterFlush: 0.6,
 			FlushConcurrency:         int(math.Ceil(float64(runtime.GOMAXPROCS(-1)) / 2)),
+			MaxWriteBackoff:          ltoml.Duration(time.Second),
 			MaxSeriesIDsNumber:       200000,
 			SeriesSequenceCache:      1000,
 			MetaSequenceCache:        100,
 			MaxTagKeysNumber:         32,
+			RecoveryConcurrency:      runtime.GOMAXPROCS(-1),
+			OrphanFileAction:         "quarantine",
+			SeriesGCBatchSize:        100000,
+		},
+		DeadLetter: DeadLetter{
+			Dir:       filepath.Join(defaultParentDir, "storage", "dead-letter"),
+			MaxSize:   ltoml.Size(128 * 1024 * 1024),
+			RateLimit: 100,
+		},
+		Health: Health{
+			CheckInterval:      ltoml.Duration(time.Second * 10),
+			MinDiskFreePercent: 5,
+			LoadReportInterval: ltoml.Duration(time.Second * 30),
+		},
+		DiskWatchdog: DiskWatchdog{
+			CheckInterval: ltoml.Duration(time.Second * 10),
+			LowWatermark:  3,
+			HighWatermark: 8,
+		},
+		QueryAdmission: QueryAdmission{
+			MaxConcurrentQueries: 128,
+			MaxInflightScanBytes: ltoml.Size(1024 * 1024 * 1024),
+			MaxQueueSize:         1024,
+			QueueTimeout:         ltoml.Duration(time.Second),
+		},
+		ConsistencyCheck: ConsistencyCheck{
+			MaxScanRate:  ltoml.Size(32 * 1024 * 1024),
+			QueryBackoff: ltoml.Duration(time.Millisecond * 100),
+		},
+		ReplicaBootstrap: ReplicaBootstrap{
+			Dir:           filepath.Join(defaultParentDir, "storage", "bootstrap"),
+			ChunkSize:     ltoml.Size(1024 * 1024),
+			SnapshotTTL:   ltoml.Duration(time.Minute * 10),
+			RetryInterval: ltoml.Duration(time.Second * 30),
+		},
+		IndexWarmup: IndexWarmup{
+			TopMetrics:      256,
+			MaxReadRate:     ltoml.Size(8 * 1024 * 1024),
+			PersistInterval: ltoml.Duration(time.Minute * 5),
+		},
+		Tiering: Tiering{
+			CacheDir:      filepath.Join(defaultParentDir, "storage", "tiering-cache"),
+			CacheSize:     ltoml.Size(10 * 1024 * 1024 * 1024),
+			CheckInterval: ltoml.Duration(time.Hour),
+			MaxUploadRate: ltoml.Size(16 * 1024 * 1024),
+			S3: S3{
+				Region: "us-east-1",
+			},
 		},
 	}
 }
@@ -266,12 +828,18 @@ func checkTSDBCfg(tsdbCfg *TSDB) error {
 	if tsdbCfg.Dir == "" {
 		return fmt.Errorf("tsdb dir cannot be empty")
 	}
+	if err := checkBufferDir(tsdbCfg); err != nil {
+		return err
+	}
 	if tsdbCfg.MaxMemDBSize <= 0 {
 		tsdbCfg.MaxMemDBSize = defaultStorageCfg.TSDB.MaxMemDBSize
 	}
 	if tsdbCfg.MutableMemDBTTL <= 0 {
 		tsdbCfg.MutableMemDBTTL = defaultStorageCfg.TSDB.MutableMemDBTTL
 	}
+	if tsdbCfg.MutableMemDBTTLJitter < 0 || tsdbCfg.MutableMemDBTTLJitter >= 1 {
+		return fmt.Errorf("mutable-memdb-ttl-jitter must be in [0, 1)")
+	}
 	if tsdbCfg.MaxMemUsageBeforeFlush <= 0 {
 		tsdbCfg.MaxMemUsageBeforeFlush = defaultStorageCfg.TSDB.MaxMemUsageBeforeFlush
 	}
@@ -281,6 +849,13 @@ func checkTSDBCfg(tsdbCfg *TSDB) error {
 	if tsdbCfg.FlushConcurrency <= 0 {
 		tsdbCfg.FlushConcurrency = defaultStorageCfg.TSDB.FlushConcurrency
 	}
+	if tsdbCfg.TargetMemDBTotalSize <= 0 || tsdbCfg.TargetMemDBTotalSize > tsdbCfg.MaxMemDBTotalSize {
+		// default low watermark is 80% of high watermark
+		tsdbCfg.TargetMemDBTotalSize = tsdbCfg.MaxMemDBTotalSize / 5 * 4
+	}
+	if tsdbCfg.MaxWriteBackoff < 0 {
+		return fmt.Errorf("max-write-backoff cannot be negative")
+	}
 	if tsdbCfg.MaxSeriesIDsNumber <= 0 {
 		tsdbCfg.MaxSeriesIDsNumber = defaultStorageCfg.TSDB.MaxSeriesIDsNumber
 	}
@@ -293,9 +868,107 @@ func checkTSDBCfg(tsdbCfg *TSDB) error {
 	if tsdbCfg.MaxTagKeysNumber <= 0 {
 		tsdbCfg.MaxTagKeysNumber = defaultStorageCfg.TSDB.MaxTagKeysNumber
 	}
+	if tsdbCfg.RecoveryConcurrency <= 0 {
+		tsdbCfg.RecoveryConcurrency = defaultStorageCfg.TSDB.RecoveryConcurrency
+	}
+	switch tsdbCfg.OrphanFileAction {
+	case "":
+		tsdbCfg.OrphanFileAction = defaultStorageCfg.TSDB.OrphanFileAction
+	case "quarantine", "delete":
+	default:
+		return fmt.Errorf("orphan-file-action must be quarantine or delete")
+	}
+	if tsdbCfg.SeriesGCHorizon < 0 {
+		return fmt.Errorf("series-gc-horizon cannot be negative")
+	}
+	if tsdbCfg.SeriesGCHorizon > 0 && tsdbCfg.SeriesGCHorizon.Duration() < 24*time.Hour {
+		// written series are tracked per day
+		return fmt.Errorf("series-gc-horizon must be at least 24h")
+	}
+	if tsdbCfg.SeriesGCBatchSize <= 0 {
+		tsdbCfg.SeriesGCBatchSize = defaultStorageCfg.TSDB.SeriesGCBatchSize
+	}
+	return nil
+}
+
+// checkBufferDir checks if buffer dir template is unique for each shard,
+// and buffer dir is not inside tsdb dir when separation is requested.
+func checkBufferDir(tsdbCfg *TSDB) error {
+	if tsdbCfg.BufferDir == "" {
+		if tsdbCfg.SeparateBufferDir {
+			return fmt.Errorf("buffer-dir cannot be empty when separate-buffer-dir is set")
+		}
+		return nil
+	}
+	if !strings.Contains(tsdbCfg.BufferDir, BufferDirDatabaseVar) || !strings.Contains(tsdbCfg.BufferDir, BufferDirShardVar) {
+		return fmt.Errorf("buffer-dir must contain both %s and %s", BufferDirDatabaseVar, BufferDirShardVar)
+	}
+	if !tsdbCfg.SeparateBufferDir {
+		return nil
+	}
+	dataDir, err := filepath.Abs(tsdbCfg.Dir)
+	if err != nil {
+		return err
+	}
+	bufferDir, err := filepath.Abs(tsdbCfg.BufferRootDir())
+	if err != nil {
+		return err
+	}
+	if rel, err := filepath.Rel(dataDir, bufferDir); err == nil &&
+		(rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)))) {
+		return fmt.Errorf("buffer-dir %s cannot be inside tsdb dir %s when separate-buffer-dir is set",
+			tsdbCfg.BufferDir, tsdbCfg.Dir)
+	}
+	return nil
+}
+
+// validateTSDBCfg validates the new tsdb config for reloading, fills default value if not set.
+// NOTE: tsdb dir/buffer dir cannot be changed without restart.
+func validateTSDBCfg(oldCfg, newCfg *TSDB) error {
+	if newCfg.MutableMemDBTTL < 0 {
+		return fmt.Errorf("mutable-memdb-ttl cannot be negative")
+	}
+	if newCfg.MaxMemUsageBeforeFlush < 0 || newCfg.MaxMemUsageBeforeFlush > 1 {
+		return fmt.Errorf("max-mem-usage-before-flush must be in [0, 1]")
+	}
+	if newCfg.TargetMemUsageAfterFlush < 0 || newCfg.TargetMemUsageAfterFlush > 1 {
+		return fmt.Errorf("target-mem-usage-after-flush must be in [0, 1]")
+	}
+	if newCfg.FlushConcurrency < 0 || newCfg.MaxSeriesIDsNumber < 0 || newCfg.MaxTagKeysNumber < 0 {
+		return fmt.Errorf("flush-concurrency/max-seriesIDs/max-tagKeys cannot be negative")
+	}
+	if newCfg.MaxMemDBTotalSize > 0 && newCfg.TargetMemDBTotalSize > newCfg.MaxMemDBTotalSize {
+		return fmt.Errorf("target-memdb-total-size cannot be greater than max-memdb-total-size")
+	}
+	if err := checkTSDBCfg(newCfg); err != nil {
+		return err
+	}
+	if newCfg.TargetMemUsageAfterFlush > newCfg.MaxMemUsageBeforeFlush {
+		return fmt.Errorf("target-mem-usage-after-flush cannot be greater than max-mem-usage-before-flush")
+	}
+	if newCfg.Dir != oldCfg.Dir {
+		return fmt.Errorf("dir cannot be changed without restart")
+	}
+	if newCfg.BufferDir != oldCfg.BufferDir || newCfg.SeparateBufferDir != oldCfg.SeparateBufferDir {
+		return fmt.Errorf("buffer-dir/separate-buffer-dir cannot be changed without restart")
+	}
 	return nil
 }
 
+// diffTSDBCfg returns the changed keys of tsdb config, format: key: old -> new.
+func diffTSDBCfg(oldCfg, newCfg *TSDB) (changes []string) {
+	oldVal := reflect.ValueOf(oldCfg).Elem()
+	newVal := reflect.ValueOf(newCfg).Elem()
+	for i := 0; i < oldVal.NumField(); i++ {
+		oldField, newField := oldVal.Field(i).Interface(), newVal.Field(i).Interface()
+		if oldField != newField {
+			key := oldVal.Type().Field(i).Tag.Get("toml")
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", key, oldField, newField))
+		}
+	}
+	return changes
+}
+
 func checkStorageBaseCfg(storageBaseCfg *StorageBase) error {
 	if err := checkGRPCCfg(&storageBaseCfg.GRPC); err != nil {
 		return err
@@ -304,5 +977,138 @@ func checkStorageBaseCfg(storageBaseCfg *StorageBase) error {
 	if storageBaseCfg.TTLTaskInterval <= 0 {
 		storageBaseCfg.TTLTaskInterval = defaultStorageCfg.TTLTaskInterval
 	}
+	checkDeadLetterCfg(&storageBaseCfg.DeadLetter)
+	checkHealthCfg(&storageBaseCfg.Health)
+	checkDiskWatchdogCfg(&storageBaseCfg.DiskWatchdog)
+	checkQueryAdmissionCfg(&storageBaseCfg.QueryAdmission)
+	checkConsistencyCheckCfg(&storageBaseCfg.ConsistencyCheck)
+	checkReplicaBootstrapCfg(&storageBaseCfg.ReplicaBootstrap)
+	checkIndexWarmupCfg(&storageBaseCfg.IndexWarmup)
+	if err := checkTieringCfg(&storageBaseCfg.Tiering); err != nil {
+		return err
+	}
 	return checkTSDBCfg(&storageBaseCfg.TSDB)
 }
+
+func checkDeadLetterCfg(deadLetterCfg *DeadLetter) {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if deadLetterCfg.Dir == "" {
+		deadLetterCfg.Dir = defaultStorageCfg.DeadLetter.Dir
+	}
+	if deadLetterCfg.MaxSize <= 0 {
+		deadLetterCfg.MaxSize = defaultStorageCfg.DeadLetter.MaxSize
+	}
+	if deadLetterCfg.RateLimit <= 0 {
+		deadLetterCfg.RateLimit = defaultStorageCfg.DeadLetter.RateLimit
+	}
+}
+
+func checkHealthCfg(healthCfg *Health) {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if healthCfg.CheckInterval <= 0 {
+		healthCfg.CheckInterval = defaultStorageCfg.Health.CheckInterval
+	}
+	if healthCfg.MinDiskFreePercent < 0 || healthCfg.MinDiskFreePercent > 100 {
+		healthCfg.MinDiskFreePercent = defaultStorageCfg.Health.MinDiskFreePercent
+	}
+	if healthCfg.LoadReportInterval <= 0 {
+		healthCfg.LoadReportInterval = defaultStorageCfg.Health.LoadReportInterval
+	}
+}
+
+func checkDiskWatchdogCfg(diskWatchdogCfg *DiskWatchdog) {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if diskWatchdogCfg.CheckInterval <= 0 {
+		diskWatchdogCfg.CheckInterval = defaultStorageCfg.DiskWatchdog.CheckInterval
+	}
+	if diskWatchdogCfg.LowWatermark <= 0 || diskWatchdogCfg.LowWatermark >= 100 ||
+		diskWatchdogCfg.HighWatermark <= diskWatchdogCfg.LowWatermark || diskWatchdogCfg.HighWatermark >= 100 {
+		diskWatchdogCfg.LowWatermark = defaultStorageCfg.DiskWatchdog.LowWatermark
+		diskWatchdogCfg.HighWatermark = defaultStorageCfg.DiskWatchdog.HighWatermark
+	}
+}
+
+func checkQueryAdmissionCfg(queryAdmissionCfg *QueryAdmission) {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if queryAdmissionCfg.MaxConcurrentQueries < 0 {
+		queryAdmissionCfg.MaxConcurrentQueries = defaultStorageCfg.QueryAdmission.MaxConcurrentQueries
+	}
+	if queryAdmissionCfg.MaxQueueSize < 0 {
+		queryAdmissionCfg.MaxQueueSize = defaultStorageCfg.QueryAdmission.MaxQueueSize
+	}
+	if queryAdmissionCfg.QueueTimeout <= 0 {
+		queryAdmissionCfg.QueueTimeout = defaultStorageCfg.QueryAdmission.QueueTimeout
+	}
+}
+
+func checkConsistencyCheckCfg(consistencyCheckCfg *ConsistencyCheck) {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if consistencyCheckCfg.MaxScanRate <= 0 {
+		consistencyCheckCfg.MaxScanRate = defaultStorageCfg.ConsistencyCheck.MaxScanRate
+	}
+	if consistencyCheckCfg.QueryBackoff <= 0 {
+		consistencyCheckCfg.QueryBackoff = defaultStorageCfg.ConsistencyCheck.QueryBackoff
+	}
+}
+
+func checkReplicaBootstrapCfg(replicaBootstrapCfg *ReplicaBootstrap) {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if replicaBootstrapCfg.Dir == "" {
+		replicaBootstrapCfg.Dir = defaultStorageCfg.ReplicaBootstrap.Dir
+	}
+	if replicaBootstrapCfg.ChunkSize <= 0 {
+		replicaBootstrapCfg.ChunkSize = defaultStorageCfg.ReplicaBootstrap.ChunkSize
+	}
+	if replicaBootstrapCfg.SnapshotTTL <= 0 {
+		replicaBootstrapCfg.SnapshotTTL = defaultStorageCfg.ReplicaBootstrap.SnapshotTTL
+	}
+	if replicaBootstrapCfg.RetryInterval <= 0 {
+		replicaBootstrapCfg.RetryInterval = defaultStorageCfg.ReplicaBootstrap.RetryInterval
+	}
+}
+
+func checkIndexWarmupCfg(indexWarmupCfg *IndexWarmup) {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if indexWarmupCfg.TopMetrics <= 0 {
+		indexWarmupCfg.TopMetrics = defaultStorageCfg.IndexWarmup.TopMetrics
+	}
+	if indexWarmupCfg.MaxReadRate <= 0 {
+		indexWarmupCfg.MaxReadRate = defaultStorageCfg.IndexWarmup.MaxReadRate
+	}
+	if indexWarmupCfg.PersistInterval <= 0 {
+		indexWarmupCfg.PersistInterval = defaultStorageCfg.IndexWarmup.PersistInterval
+	}
+}
+
+func checkTieringCfg(tieringCfg *Tiering) error {
+	defaultStorageCfg := NewDefaultStorageBase()
+	if tieringCfg.CacheDir == "" {
+		tieringCfg.CacheDir = defaultStorageCfg.Tiering.CacheDir
+	}
+	if tieringCfg.CacheSize <= 0 {
+		tieringCfg.CacheSize = defaultStorageCfg.Tiering.CacheSize
+	}
+	if tieringCfg.CheckInterval <= 0 {
+		tieringCfg.CheckInterval = defaultStorageCfg.Tiering.CheckInterval
+	}
+	if tieringCfg.MaxUploadRate <= 0 {
+		tieringCfg.MaxUploadRate = defaultStorageCfg.Tiering.MaxUploadRate
+	}
+	if tieringCfg.S3.Region == "" {
+		tieringCfg.S3.Region = defaultStorageCfg.Tiering.S3.Region
+	}
+	switch tieringCfg.Backend {
+	case "":
+	case "archive":
+		if tieringCfg.ArchiveDir == "" {
+			return fmt.Errorf("archive-dir of tiering cannot be empty for archive backend")
+		}
+	case "s3":
+		if tieringCfg.S3.Endpoint == "" || tieringCfg.S3.Bucket == "" {
+			return fmt.Errorf("endpoint/bucket of tiering s3 cannot be empty for s3 backend")
+		}
+	default:
+		return fmt.Errorf("unknown backend of tiering: %s, only support archive/s3", tieringCfg.Backend)
+	}
+	return nil
+}
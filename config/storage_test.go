@@ -18,6 +18,7 @@
 package config
 
 import (
+	"path/filepath"
 	"testing"
 
 	"github.com/BurntSushi/toml"
@@ -40,3 +41,17 @@ func TestWAL_GetDataSizeLimit(t *testing.T) {
 	wal = &WAL{DataSizeLimit: 128 * 1024 * 1024}
 	assert.Equal(t, int64(128*1024*1024), wal.GetDataSizeLimit())
 }
+
+func TestTSDB_BufferPath(t *testing.T) {
+	cfg := &TSDB{}
+	assert.Empty(t, cfg.BufferPath("db", 1))
+	assert.Empty(t, cfg.BufferRootDir())
+
+	cfg.BufferDir = "/nvme/lindb/buffer/{database}/{shard}"
+	assert.Equal(t, filepath.Join("/nvme/lindb/buffer", "db", "1"), cfg.BufferPath("db", 1))
+	assert.Equal(t, filepath.Clean("/nvme/lindb/buffer"), cfg.BufferRootDir())
+
+	cfg.BufferDir = "/nvme/buffer-{database}/{shard}/"
+	assert.Equal(t, filepath.Join("/nvme/buffer-db", "2"), cfg.BufferPath("db", 2))
+	assert.Equal(t, filepath.Clean("/nvme"), cfg.BufferRootDir())
+}
@@ -30,4 +30,6 @@ const (
 	ContentTypeProto = "application/protobuf"
 	// ContentTypeInflux represents influx content type.
 	ContentTypeInflux = "application/influx"
+	// IdempotencyKeyHeader represents the header of idempotency token for write api.
+	IdempotencyKeyHeader = "Idempotency-Key"
 )
@@ -34,19 +34,39 @@ const (
 
 // defines all metadata type.
 const (
-	LiveNode        = "LiveNode"
-	DatabaseConfig  = "DatabaseConfig"
-	BrokerState     = "BrokerState"
-	StorageState    = "StorageState"
-	ShardAssignment = "ShardAssignment"
-	Master          = "Master"
-	StorageConfig   = "StorageConfig"
+	LiveNode         = "LiveNode"
+	DatabaseConfig   = "DatabaseConfig"
+	BrokerState      = "BrokerState"
+	StorageState     = "StorageState"
+	ShardAssignment  = "ShardAssignment"
+	Master           = "Master"
+	StorageConfig    = "StorageConfig"
+	AuthPrincipal    = "AuthPrincipal"
+	DatabaseSchema   = "DatabaseSchema"
+	FieldAlias       = "FieldAlias"
+	NamespaceRouting = "NamespaceRouting"
 )
 
 // defines common constants will be used in broker and storage.
 const (
 	// LiveNodesPath represents live nodes prefix path for node register.
 	LiveNodesPath = "/live/nodes"
+	// AuditLogPath represents audit log of admin operations prefix path.
+	AuditLogPath = "/audit/log"
+	// FlushPausePath represents the flush pause task of database in storage cluster.
+	FlushPausePath = "/flush/pause"
+	// ConsistencyCheckPath represents the consistency check task of database in storage cluster.
+	ConsistencyCheckPath = "/consistency/check"
+	// ConsistencyDigestPath represents the digests of shard replica reported by storage node.
+	ConsistencyDigestPath = "/consistency/digest"
+	// ReplicaBootstrapPath represents the bootstrap task of new shard replica submitted by master.
+	ReplicaBootstrapPath = "/replica/bootstrap/task"
+	// ReplicaBootstrapProgressPath represents the bootstrap progress of shard replica reported by storage node.
+	ReplicaBootstrapProgressPath = "/replica/bootstrap/progress"
+	// RollupBackfillPath represents the backfill task of added rollup intervals submitted by master.
+	RollupBackfillPath = "/rollup/backfill/task"
+	// RollupBackfillProgressPath represents the backfill progress of shard replica reported by storage node.
+	RollupBackfillProgressPath = "/rollup/backfill/progress"
 )
 
 // defines broker level constants will be used in broker.
@@ -65,8 +85,38 @@ const (
 	StorageStatePath = "/storage/state"
 	// BrokerConfigPath represents broker cluster's config.
 	BrokerConfigPath = "/broker/config"
+	// AuthPrincipalPath represents the per-database permissions of principal.
+	AuthPrincipalPath = "/auth/principal"
+	// DatabaseSchemaPath represents the metric schema registry of database.
+	DatabaseSchemaPath = "/database/schema"
+	// FieldAliasPath represents the field aliases of database.
+	FieldAliasPath = "/database/field-alias"
+	// RecordingRulePath represents the recording rules of database.
+	RecordingRulePath = "/recording/rule"
+	// RecordingRuleStatePath represents the evaluation state of recording rule.
+	RecordingRuleStatePath = "/recording/state"
+	// AlertRulePath represents the alert rules of database.
+	AlertRulePath = "/alert/rule"
+	// AlertRuleStatePath represents the evaluation state of alert rule.
+	AlertRuleStatePath = "/alert/state"
+	// NamespaceRoutingPath represents the namespace=>database routing table of write api.
+	NamespaceRoutingPath = "/write/routing"
 )
 
+// MetadataPaths represents the prefix paths of metadata owned by LinDB in broker state repository,
+// which are exported/imported as metadata archive, in order of applying.
+var MetadataPaths = []string{
+	StorageConfigPath,
+	DatabaseConfigPath,
+	ShardAssignmentPath,
+	DatabaseSchemaPath,
+	FieldAliasPath,
+	NamespaceRoutingPath,
+	RecordingRulePath,
+	AlertRulePath,
+	AuthPrincipalPath,
+}
+
 // GetBrokerClusterConfigPath returns path which storing config of broker cluster.
 func GetBrokerClusterConfigPath(name string) string {
 	return fmt.Sprintf("%s/%s", BrokerConfigPath, name)
@@ -91,7 +141,92 @@ func GetDatabaseAssignPath(name string) string {
 	return fmt.Sprintf("%s/%s", ShardAssignmentPath, name)
 }
 
+// GetAuthPrincipalPath returns path which storing permissions of principal.
+func GetAuthPrincipalPath(name string) string {
+	return fmt.Sprintf("%s/%s", AuthPrincipalPath, name)
+}
+
+// GetDatabaseSchemaPath returns path which storing metric schema registry of database.
+func GetDatabaseSchemaPath(name string) string {
+	return fmt.Sprintf("%s/%s", DatabaseSchemaPath, name)
+}
+
+// GetFieldAliasPath returns path which storing field aliases of database.
+func GetFieldAliasPath(name string) string {
+	return fmt.Sprintf("%s/%s", FieldAliasPath, name)
+}
+
+// GetNamespaceRoutingPath returns path which storing the namespace=>database routing table of write api.
+func GetNamespaceRoutingPath() string {
+	return fmt.Sprintf("%s/%s", NamespaceRoutingPath, "namespace")
+}
+
+// GetRecordingRulePath returns path which storing recording rules of database.
+func GetRecordingRulePath(name string) string {
+	return fmt.Sprintf("%s/%s", RecordingRulePath, name)
+}
+
+// GetRecordingRuleStatePath returns path which storing evaluation state of recording rule.
+func GetRecordingRuleStatePath(database, rule string) string {
+	return fmt.Sprintf("%s/%s/%s", RecordingRuleStatePath, database, rule)
+}
+
+// GetAlertRulePath returns path which storing alert rules of database.
+func GetAlertRulePath(name string) string {
+	return fmt.Sprintf("%s/%s", AlertRulePath, name)
+}
+
+// GetAlertRuleStatePath returns path which storing evaluation state of alert rule.
+func GetAlertRuleStatePath(database, rule string) string {
+	return fmt.Sprintf("%s/%s/%s", AlertRuleStatePath, database, rule)
+}
+
 // GetLiveNodePath returns live node register path.
 func GetLiveNodePath(node string) string {
 	return fmt.Sprintf("%s/%s", LiveNodesPath, node)
 }
+
+// GetAuditLogPath returns the path which storing audit entry of node in the spec slot.
+func GetAuditLogPath(node string, slot int) string {
+	return fmt.Sprintf("%s/%s/%d", AuditLogPath, node, slot)
+}
+
+// GetFlushPausePath returns the path which storing flush pause task of database.
+func GetFlushPausePath(database string) string {
+	return fmt.Sprintf("%s/%s", FlushPausePath, database)
+}
+
+// GetConsistencyCheckPath returns the path which storing consistency check task of database.
+func GetConsistencyCheckPath(database string) string {
+	return fmt.Sprintf("%s/%s", ConsistencyCheckPath, database)
+}
+
+// GetConsistencyDigestsPath returns the prefix path which storing digests of database's shard replicas.
+func GetConsistencyDigestsPath(database string) string {
+	return fmt.Sprintf("%s/%s", ConsistencyDigestPath, database)
+}
+
+// GetReplicaBootstrapPath returns the path which storing bootstrap task of shard replica on storage node.
+func GetReplicaBootstrapPath(database string, shardID, nodeID int) string {
+	return fmt.Sprintf("%s/%s/%d/%d", ReplicaBootstrapPath, database, shardID, nodeID)
+}
+
+// GetReplicaBootstrapProgressPath returns the path which storing bootstrap progress of shard replica on storage node.
+func GetReplicaBootstrapProgressPath(database string, shardID, nodeID int) string {
+	return fmt.Sprintf("%s/%s/%d/%d", ReplicaBootstrapProgressPath, database, shardID, nodeID)
+}
+
+// GetRollupBackfillPath returns the path which storing rollup backfill task of shard replica on storage node.
+func GetRollupBackfillPath(database string, shardID, nodeID int) string {
+	return fmt.Sprintf("%s/%s/%d/%d", RollupBackfillPath, database, shardID, nodeID)
+}
+
+// GetRollupBackfillProgressPath returns the path which storing rollup backfill progress of shard replica on storage node.
+func GetRollupBackfillProgressPath(database string, shardID, nodeID int) string {
+	return fmt.Sprintf("%s/%s/%d/%d", RollupBackfillProgressPath, database, shardID, nodeID)
+}
+
+// GetConsistencyDigestPath returns the path which storing digest of shard replica on storage node.
+func GetConsistencyDigestPath(database string, shardID, nodeID int) string {
+	return fmt.Sprintf("%s/%s/%d/%d", ConsistencyDigestPath, database, shardID, nodeID)
+}
@@ -46,3 +46,49 @@ func TestGetStorageStatePath(t *testing.T) {
 func TestGetBrokerClusterConfigPath(t *testing.T) {
 	assert.Equal(t, BrokerConfigPath+"/name", GetBrokerClusterConfigPath("name"))
 }
+
+func TestGetAuditLogPath(t *testing.T) {
+	assert.Equal(t, AuditLogPath+"/node/1", GetAuditLogPath("node", 1))
+}
+
+func TestGetAuthPrincipalPath(t *testing.T) {
+	assert.Equal(t, AuthPrincipalPath+"/name", GetAuthPrincipalPath("name"))
+}
+
+func TestGetDatabaseSchemaPath(t *testing.T) {
+	assert.Equal(t, DatabaseSchemaPath+"/db", GetDatabaseSchemaPath("db"))
+}
+
+func TestGetFieldAliasPath(t *testing.T) {
+	assert.Equal(t, FieldAliasPath+"/db", GetFieldAliasPath("db"))
+}
+
+func TestGetRecordingRulePath(t *testing.T) {
+	assert.Equal(t, RecordingRulePath+"/db", GetRecordingRulePath("db"))
+	assert.Equal(t, RecordingRuleStatePath+"/db/rule", GetRecordingRuleStatePath("db", "rule"))
+}
+
+func TestGetAlertRulePath(t *testing.T) {
+	assert.Equal(t, AlertRulePath+"/db", GetAlertRulePath("db"))
+	assert.Equal(t, AlertRuleStatePath+"/db/rule", GetAlertRuleStatePath("db", "rule"))
+}
+
+func TestGetFlushPausePath(t *testing.T) {
+	assert.Equal(t, FlushPausePath+"/db", GetFlushPausePath("db"))
+}
+
+func TestGetConsistencyPath(t *testing.T) {
+	assert.Equal(t, ConsistencyCheckPath+"/db", GetConsistencyCheckPath("db"))
+	assert.Equal(t, ConsistencyDigestPath+"/db", GetConsistencyDigestsPath("db"))
+	assert.Equal(t, ConsistencyDigestPath+"/db/1/2", GetConsistencyDigestPath("db", 1, 2))
+}
+
+func TestGetReplicaBootstrapPath(t *testing.T) {
+	assert.Equal(t, ReplicaBootstrapPath+"/db/1/2", GetReplicaBootstrapPath("db", 1, 2))
+	assert.Equal(t, ReplicaBootstrapProgressPath+"/db/1/2", GetReplicaBootstrapProgressPath("db", 1, 2))
+}
+
+func TestGetRollupBackfillPath(t *testing.T) {
+	assert.Equal(t, RollupBackfillPath+"/db/1/2", GetRollupBackfillPath("db", 1, 2))
+	assert.Equal(t, RollupBackfillProgressPath+"/db/1/2", GetRollupBackfillProgressPath("db", 1, 2))
+}
@@ -20,54 +20,107 @@ package constants
 import (
 	"errors"
 	"fmt"
+
+	"github.com/lindb/lindb/pkg/errcode"
 )
 
 var (
 	// ErrNotFound represents the data not found.
-	ErrNotFound = errors.New("not found")
+	ErrNotFound = errcode.New(errcode.NotFound, "not found")
 	// ErrTimeout represents exceed timeout.
-	ErrTimeout = errors.New("exceed timeout")
+	ErrTimeout = errcode.New(errcode.Timeout, "exceed timeout")
 
 	ErrTagValueFilterResultNotFound = fmt.Errorf("tag value fitler result %w", ErrNotFound)
 
-	ErrDatabaseNotFound     = fmt.Errorf("database %w", ErrNotFound)
-	ErrShardNotFound        = fmt.Errorf("shard %w", ErrNotFound)
-	ErrReplicaNotFound      = fmt.Errorf("replica %w", ErrNotFound)
-	ErrTargetNodesNotFound  = fmt.Errorf("target nodes %w", ErrNotFound)
-	ErrReceiveNodesNotFound = fmt.Errorf("receive nodes %w", ErrNotFound)
-	ErrMetricIDNotFound     = fmt.Errorf("metric %w", ErrNotFound)
-	ErrTagKeyIDNotFound     = fmt.Errorf("tag key %w", ErrNotFound)
-	ErrTagKeyMetaNotFound   = fmt.Errorf("tag key %w", ErrNotFound)
-	ErrTagValueSeqNotFound  = fmt.Errorf("tagValueSeq %w", ErrNotFound)
-	ErrTagValueIDNotFound   = fmt.Errorf("tag value %w", ErrNotFound)
-	ErrFieldNotFound        = fmt.Errorf("field %w", ErrNotFound)
-	ErrSeriesIDNotFound     = fmt.Errorf("seriesID %w", ErrNotFound)
-	ErrDataFamilyNotFound   = fmt.Errorf("data family %w", ErrNotFound)
-	ErrUnknownNodeChoose    = errors.New("unknown node choose")
+	ErrDatabaseNotFound         = fmt.Errorf("database %w", ErrNotFound)
+	ErrShardNotFound            = fmt.Errorf("shard %w", ErrNotFound)
+	ErrReplicaNotFound          = fmt.Errorf("replica %w", ErrNotFound)
+	ErrTargetNodesNotFound      = fmt.Errorf("target nodes %w", ErrNotFound)
+	ErrReceiveNodesNotFound     = fmt.Errorf("receive nodes %w", ErrNotFound)
+	ErrMetricIDNotFound         = fmt.Errorf("metric %w", ErrNotFound)
+	ErrTagKeyIDNotFound         = fmt.Errorf("tag key %w", ErrNotFound)
+	ErrTagKeyMetaNotFound       = fmt.Errorf("tag key %w", ErrNotFound)
+	ErrTagValueSeqNotFound      = fmt.Errorf("tagValueSeq %w", ErrNotFound)
+	ErrTagValueIDNotFound       = fmt.Errorf("tag value %w", ErrNotFound)
+	ErrFieldNotFound            = fmt.Errorf("field %w", ErrNotFound)
+	ErrSeriesIDNotFound         = fmt.Errorf("seriesID %w", ErrNotFound)
+	ErrDataFamilyNotFound       = fmt.Errorf("data family %w", ErrNotFound)
+	ErrConsistencyCheckNotFound = fmt.Errorf("consistency check %w", ErrNotFound)
+	ErrReplicaBootstrapNotFound = fmt.Errorf("replica bootstrap %w", ErrNotFound)
+	ErrRollupBackfillNotFound   = fmt.Errorf("rollup backfill %w", ErrNotFound)
+	ErrQueryNotFound            = fmt.Errorf("running query %w", ErrNotFound)
+	ErrUnknownNodeChoose        = errors.New("unknown node choose")
 
 	// ErrDataFileCorruption represents data in tsdb's file is corrupted
 	ErrDataFileCorruption = errors.New("data corruption")
 
-	ErrInfluxLineTooLong = errors.New("influx line is too long")
+	ErrInfluxLineTooLong = errcode.New(errcode.InvalidArgument, "influx line is too long")
+	// ErrRequestBodyTooLarge represents the (decompressed) size of write request body exceeds the limit.
+	ErrRequestBodyTooLarge = errcode.New(errcode.PayloadTooLarge, "request body exceeds max decompressed size")
+	// ErrCorruptedRequestBody represents the compressed write request body cannot be decompressed.
+	ErrCorruptedRequestBody = errcode.New(errcode.InvalidArgument, "corrupted compressed request body")
 
-	ErrBadEnrichTagQueryFormat = errors.New("enrich_tag has the wrong format")
+	ErrBadEnrichTagQueryFormat = errcode.New(errcode.InvalidArgument, "enrich_tag has the wrong format")
 	// ErrNoLiveReplica represents no live replica node for current shard.
-	ErrNoLiveReplica = errors.New("no live replica for shard")
+	ErrNoLiveReplica = errcode.New(errcode.StorageUnavailable, "no live replica for shard")
 	// ErrNoLiveNode represents no live node for current cluster.
-	ErrNoLiveNode = errors.New("no live node for cluster")
+	ErrNoLiveNode = errcode.New(errcode.StorageUnavailable, "no live node for cluster")
 	// ErrNameEmpty represents name is empty.
-	ErrNameEmpty = errors.New("name cannot be empty")
+	ErrNameEmpty = errcode.New(errcode.InvalidArgument, "name cannot be empty")
+	// ErrNotMaster represents current broker node is not master.
+	ErrNotMaster = errcode.New(errcode.NotMaster, "current node is not master")
 	// ErrNoStorageCluster represents storage cluster not exist.
 	ErrNoStorageCluster = errors.New("storage cluster not exist")
 	// ErrStatefulNodeExist represents stateful node already register.
 	ErrStatefulNodeExist = errors.New("stateful node already register")
 	// ErrDatabaseNameRequired represents database not input.
-	ErrDatabaseNameRequired = errors.New("database name cannot be empty")
+	ErrDatabaseNameRequired = errcode.New(errcode.InvalidArgument, "database name cannot be empty")
+	// ErrNamespaceNotRouted represents no database routed for namespace of rows written without database.
+	ErrNamespaceNotRouted = errcode.New(errcode.InvalidArgument, "no database routed for namespace")
 	// ErrStorageNameRequired represents storage name not input.
-	ErrStorageNameRequired = errors.New("storage name cannot be empty")
+	ErrStorageNameRequired = errcode.New(errcode.InvalidArgument, "storage name cannot be empty")
 	// ErrEmptySelectList represents empty select list.
-	ErrEmptySelectList = errors.New("select item list is empty")
+	ErrEmptySelectList = errcode.New(errcode.InvalidArgument, "select item list is empty")
+	// ErrUnknownName represents the metric/fields referenced by query are unknown,
+	// tolerated like not found if other nodes have them.
+	ErrUnknownName = errcode.New(errcode.NotFound, "unknown metric/field referenced by query")
+
+	// ErrTooManyBufferedSeries represents the grouped series buffered by broker exceed the limit.
+	ErrTooManyBufferedSeries = errcode.New(errcode.SeriesLimitExceeded, "too many grouped series buffered for query")
+	// ErrMemoryBudgetExceeded represents the memory of grouped series buffered by broker exceed the budget of query.
+	ErrMemoryBudgetExceeded = errcode.New(errcode.MemoryLimitExceeded, "memory budget exceeded for query")
+	// ErrQueryKilled represents the running query is killed by admin.
+	ErrQueryKilled = errcode.New(errcode.QueryKilled, "query cancelled by admin")
+	// ErrTooManySeries represents the series matched by query exceed the limit of database.
+	ErrTooManySeries = errcode.New(errcode.SeriesLimitExceeded, "too many series matched for query")
+	// ErrNodeBusy represents storage node rejects query by admission control, query can be retried on other replica.
+	ErrNodeBusy = errcode.New(errcode.NodeBusy, "node busy")
+
+	// ErrWriteRejected represents storage rejects writes because of low disk space.
+	ErrWriteRejected = errcode.New(errcode.WriteRejected, "write rejected because of low disk space")
+	// ErrInsufficientDiskSpace represents free disk space is not enough for flushing memory database.
+	ErrInsufficientDiskSpace = errors.New("insufficient disk space for flush")
+	// ErrWritePanic represents writing rows replicated from leader panics, the batch is dropped.
+	ErrWritePanic = errors.New("panic when writing rows")
+	// ErrOutOfTimeRange represents all rows of write request are out of acceptable write time range of database.
+	ErrOutOfTimeRange = errcode.New(errcode.OutOfTimeRange, "metric timestamp out of acceptable time range")
+	// ErrInvalidSequence represents the replica sequence is invalid(duplicate or out of order) for data family.
+	ErrInvalidSequence = errcode.New(errcode.InvalidSequence, "invalid replica sequence")
+	// ErrReadOnly represents the write/flush/drop operation is rejected by read-only time series engine.
+	ErrReadOnly = errcode.New(errcode.ReadOnly, "time series engine is read-only")
+
+	// ErrUnauthenticated represents the token of request is missing or invalid.
+	ErrUnauthenticated = errcode.New(errcode.Unauthenticated, "unauthenticated")
+	// ErrForbidden represents the principal of request has no permission for the operation.
+	ErrForbidden = errcode.New(errcode.Forbidden, "permission denied")
+
+	// ErrDatabaseDeleted represents the database is dropped, writes/queries are rejected until restored or purged.
+	ErrDatabaseDeleted = errcode.New(errcode.NotFound, "database scheduled for deletion")
+	// ErrDatabaseNotDeleted represents restoring the database which is not dropped.
+	ErrDatabaseNotDeleted = errors.New("database not scheduled for deletion")
+	// ErrDatabasePurged represents restoring the dropped database after its grace period.
+	ErrDatabasePurged = errors.New("grace period of dropped database expired")
 
 	ErrDatabaseNotExist       = errors.New("database not exist")
-	ErrNoAvailableStorageNode = errors.New("no available storage node for server")
+	ErrNoAvailableStorageNode = errcode.New(errcode.StorageUnavailable, "no available storage node for server")
 )
@@ -26,6 +26,19 @@ const (
 	DefaultMaxFieldsCount = math.MaxUint8
 	// MaxSuggestions represents the max number of suggestions count
 	MaxSuggestions = 100
+	// MaxSuggestionPageSize represents the max page size of paginated metadata query(metric names/tag keys).
+	MaxSuggestionPageSize = 10000
+	// MaxPaginatedSuggestions represents the max number of values returned by all pages of paginated metadata query,
+	// protects against clients paging forever.
+	MaxPaginatedSuggestions = 1000000
+	// MaxCloseMatchSuggestions represents the max number of close-match suggestions of unknown metric/field.
+	MaxCloseMatchSuggestions = 5
+	// MaxCloseMatchScanMetrics represents the max number of metric names scanned by close-match suggestion,
+	// keeps suggestion fast on large namespace.
+	MaxCloseMatchScanMetrics = 100000
+	// MaxPatternMatchedMetrics represents the max number of metrics matched by metric name pattern of query,
+	// each matched metric is scanned by a sub query.
+	MaxPatternMatchedMetrics = 64
 
 	// MetricMaxAheadDuration controls the global max write ahead duration.
 	// If current timestamp is 2021-08-19 23:00:00, metric after 2021-08-20 23:00:00 will be dropped.
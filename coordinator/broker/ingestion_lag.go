@@ -0,0 +1,153 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/internal/client"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+//go:generate mockgen -source=./ingestion_lag.go -destination=./ingestion_lag_mock.go -package=broker
+
+// for testing
+var (
+	newFamilyStateCliFn = client.NewFamilyStateCli
+)
+
+// IngestionLagTracker represents the tracker which collects the ingestion lag of storage families periodically,
+// aggregates them into database level data freshness.
+type IngestionLagTracker interface {
+	// Start starts collecting ingestion lag in background.
+	Start()
+	// GetLag returns the ingestion lag of database, which is the lag of the stalest shard.
+	GetLag(database string) (time.Duration, bool)
+}
+
+// ingestionLagTracker implements IngestionLagTracker interface.
+type ingestionLagTracker struct {
+	ctx      context.Context
+	interval time.Duration
+	stateMgr StateManager
+	cli      client.FamilyStateCli
+
+	lags       map[string]time.Duration
+	statistics map[string]*metrics.FreshnessStatistics
+	mutex      sync.RWMutex
+
+	logger *logger.Logger
+}
+
+// NewIngestionLagTracker creates an IngestionLagTracker instance.
+func NewIngestionLagTracker(ctx context.Context, interval time.Duration, stateMgr StateManager) IngestionLagTracker {
+	return &ingestionLagTracker{
+		ctx:        ctx,
+		interval:   interval,
+		stateMgr:   stateMgr,
+		cli:        newFamilyStateCliFn(),
+		lags:       make(map[string]time.Duration),
+		statistics: make(map[string]*metrics.FreshnessStatistics),
+		logger:     logger.GetLogger("Broker", "IngestionLagTracker"),
+	}
+}
+
+// Start starts collecting ingestion lag in background.
+func (t *ingestionLagTracker) Start() {
+	if t.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+				t.collect()
+			}
+		}
+	}()
+}
+
+// GetLag returns the ingestion lag of database, which is the lag of the stalest shard.
+func (t *ingestionLagTracker) GetLag(database string) (time.Duration, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	lag, ok := t.lags[database]
+	return lag, ok
+}
+
+// collect collects the family state of all databases from live storage nodes,
+// the lag of shard is the freshest one of all replicas/families,
+// the lag of database is the stalest one of all shards.
+func (t *ingestionLagTracker) collect() {
+	lags := make(map[string]time.Duration)
+	for _, database := range t.stateMgr.GetDatabases() {
+		storage, ok := t.stateMgr.GetStorage(database.Storage)
+		if !ok {
+			continue
+		}
+		shardLags := make(map[models.ShardID]int64)
+		for idx := range storage.LiveNodes {
+			node := storage.LiveNodes[idx]
+			states, err := t.cli.FetchFamilyState(database.Name, &node)
+			if err != nil {
+				t.logger.Warn("fetch family state failure, ignore it",
+					logger.String("database", database.Name),
+					logger.String("node", node.Indicator()),
+					logger.Error(err))
+				continue
+			}
+			for _, state := range states {
+				if lag, ok := shardLags[state.ShardID]; !ok || state.IngestionLag < lag {
+					shardLags[state.ShardID] = state.IngestionLag
+				}
+			}
+		}
+		if len(shardLags) == 0 {
+			continue
+		}
+		var lag int64
+		for _, shardLag := range shardLags {
+			if shardLag > lag {
+				lag = shardLag
+			}
+		}
+		lags[database.Name] = time.Duration(lag) * time.Millisecond
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for database, lag := range lags {
+		statistics, ok := t.statistics[database]
+		if !ok {
+			statistics = metrics.NewFreshnessStatistics(database)
+			t.statistics[database] = statistics
+		}
+		statistics.IngestionLag.Update(float64(lag.Milliseconds()))
+	}
+	t.lags = lags
+}
@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package broker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/client"
+	"github.com/lindb/lindb/models"
+)
+
+func TestIngestionLagTracker_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer func() {
+		cancel()
+		ctrl.Finish()
+	}()
+
+	stateMgr := NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabases().Return(nil).AnyTimes()
+	// disabled
+	tracker := NewIngestionLagTracker(ctx, 0, stateMgr)
+	tracker.Start()
+	tracker = NewIngestionLagTracker(ctx, 10*time.Millisecond, stateMgr)
+	tracker.Start()
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestIngestionLagTracker_Collect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newFamilyStateCliFn = client.NewFamilyStateCli
+		ctrl.Finish()
+	}()
+
+	cli := client.NewMockFamilyStateCli(ctrl)
+	newFamilyStateCliFn = func() client.FamilyStateCli {
+		return cli
+	}
+	stateMgr := NewMockStateManager(ctrl)
+	node1 := models.StatefulNode{StatelessNode: models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000}, ID: 1}
+	node2 := models.StatefulNode{StatelessNode: models.StatelessNode{HostIP: "1.1.1.2", GRPCPort: 9000}, ID: 2}
+	node3 := models.StatefulNode{StatelessNode: models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 9000}, ID: 3}
+	stateMgr.EXPECT().GetDatabases().Return([]models.Database{
+		{Name: "test", Storage: "storage"},
+		{Name: "test_2", Storage: "storage_2"},
+	}).AnyTimes()
+	stateMgr.EXPECT().GetStorage("storage").Return(&models.StorageState{
+		LiveNodes: map[models.NodeID]models.StatefulNode{1: node1, 2: node2, 3: node3},
+	}, true).AnyTimes()
+	stateMgr.EXPECT().GetStorage("storage_2").Return(nil, false).AnyTimes()
+
+	tracker := NewIngestionLagTracker(context.TODO(), time.Second, stateMgr).(*ingestionLagTracker)
+	cli.EXPECT().FetchFamilyState("test", gomock.Any()).DoAndReturn(
+		func(_ string, node models.Node) ([]models.DataFamilyState, error) {
+			switch node.Indicator() {
+			case node1.Indicator():
+				return []models.DataFamilyState{
+					{ShardID: 1, IngestionLag: 5000},
+					{ShardID: 1, IngestionLag: 1000},
+					{ShardID: 2, IngestionLag: 30000},
+				}, nil
+			case node2.Indicator():
+				return []models.DataFamilyState{
+					{ShardID: 2, IngestionLag: 20000},
+				}, nil
+			default:
+				return nil, fmt.Errorf("err")
+			}
+		}).Times(3)
+	tracker.collect()
+
+	// shard 1 => 1s, shard 2 => 20s
+	lag, ok := tracker.GetLag("test")
+	assert.True(t, ok)
+	assert.Equal(t, 20*time.Second, lag)
+	assert.Equal(t, float64(20000), tracker.statistics["test"].IngestionLag.Get())
+	_, ok = tracker.GetLag("test_2")
+	assert.False(t, ok)
+
+	// no family state reported
+	cli.EXPECT().FetchFamilyState("test", gomock.Any()).Return(nil, nil).Times(3)
+	tracker.collect()
+	_, ok = tracker.GetLag("test")
+	assert.False(t, ok)
+}
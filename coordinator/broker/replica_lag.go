@@ -0,0 +1,159 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package broker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/internal/client"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+//go:generate mockgen -source=./replica_lag.go -destination=./replica_lag_mock.go -package=broker
+
+// for testing
+var (
+	newReplicaStateCliFn = client.NewReplicaStateCli
+)
+
+// ReplicaLag represents the replication lag of shard's replica.
+type ReplicaLag struct {
+	// Pending is the num. of write ahead log entries not replicated to replica.
+	Pending int64
+	// CaughtUpAt is the last time the replica caught up with leader, zero means unknown.
+	CaughtUpAt time.Time
+}
+
+// ReplicaLagTracker represents the tracker which collects the replication lag of storage replicas periodically,
+// used for choosing the most caught-up follower when query fails over from leader.
+type ReplicaLagTracker interface {
+	// Start starts collecting replication lag in background.
+	Start()
+	// GetLag returns the replication lag of shard's replica on node.
+	GetLag(database string, shardID models.ShardID, node string) (ReplicaLag, bool)
+}
+
+// replicaKey represents the key of shard's replica.
+type replicaKey struct {
+	database string
+	shardID  models.ShardID
+	node     string
+}
+
+// replicaLagTracker implements ReplicaLagTracker interface.
+type replicaLagTracker struct {
+	ctx      context.Context
+	interval time.Duration
+	stateMgr StateManager
+	cli      client.ReplicaStateCli
+
+	lags  map[replicaKey]ReplicaLag
+	mutex sync.RWMutex
+
+	logger *logger.Logger
+}
+
+// NewReplicaLagTracker creates a ReplicaLagTracker instance.
+func NewReplicaLagTracker(ctx context.Context, interval time.Duration, stateMgr StateManager) ReplicaLagTracker {
+	return &replicaLagTracker{
+		ctx:      ctx,
+		interval: interval,
+		stateMgr: stateMgr,
+		cli:      newReplicaStateCliFn(),
+		lags:     make(map[replicaKey]ReplicaLag),
+		logger:   logger.GetLogger("Broker", "ReplicaLagTracker"),
+	}
+}
+
+// Start starts collecting replication lag in background.
+func (t *replicaLagTracker) Start() {
+	if t.interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+				t.collect()
+			}
+		}
+	}()
+}
+
+// GetLag returns the replication lag of shard's replica on node.
+func (t *replicaLagTracker) GetLag(database string, shardID models.ShardID, node string) (ReplicaLag, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	lag, ok := t.lags[replicaKey{database: database, shardID: shardID, node: node}]
+	return lag, ok
+}
+
+// collect collects the replica state of all databases from live storage nodes,
+// the pending of replica is summed by all families of shard.
+func (t *replicaLagTracker) collect() {
+	now := time.Now()
+	pendings := make(map[replicaKey]int64)
+	for _, database := range t.stateMgr.GetDatabases() {
+		storage, ok := t.stateMgr.GetStorage(database.Storage)
+		if !ok {
+			continue
+		}
+		for idx := range storage.LiveNodes {
+			node := storage.LiveNodes[idx]
+			states, err := t.cli.FetchReplicaState(database.Name, &node)
+			if err != nil {
+				t.logger.Warn("fetch replica state failure, ignore it",
+					logger.String("database", database.Name),
+					logger.String("node", node.Indicator()),
+					logger.Error(err))
+				continue
+			}
+			for _, state := range states {
+				for _, replicator := range state.Replicators {
+					replicaNode, ok := storage.LiveNodes[models.ParseNodeID(replicator.Replicator)]
+					if !ok {
+						continue
+					}
+					key := replicaKey{database: database.Name, shardID: state.ShardID, node: replicaNode.Indicator()}
+					pendings[key] += replicator.Pending
+				}
+			}
+		}
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	lags := make(map[replicaKey]ReplicaLag, len(pendings))
+	for key, pending := range pendings {
+		lag := ReplicaLag{Pending: pending, CaughtUpAt: t.lags[key].CaughtUpAt}
+		if pending <= 0 {
+			lag.CaughtUpAt = now
+		}
+		lags[key] = lag
+	}
+	t.lags = lags
+}
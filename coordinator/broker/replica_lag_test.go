@@ -0,0 +1,114 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package broker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/client"
+	"github.com/lindb/lindb/models"
+)
+
+func TestReplicaLagTracker_Start(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer func() {
+		cancel()
+		ctrl.Finish()
+	}()
+
+	stateMgr := NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetDatabases().Return(nil).AnyTimes()
+	// disabled
+	tracker := NewReplicaLagTracker(ctx, 0, stateMgr)
+	tracker.Start()
+	tracker = NewReplicaLagTracker(ctx, 10*time.Millisecond, stateMgr)
+	tracker.Start()
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestReplicaLagTracker_Collect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newReplicaStateCliFn = client.NewReplicaStateCli
+		ctrl.Finish()
+	}()
+
+	cli := client.NewMockReplicaStateCli(ctrl)
+	newReplicaStateCliFn = func() client.ReplicaStateCli {
+		return cli
+	}
+	stateMgr := NewMockStateManager(ctrl)
+	node1 := models.StatefulNode{StatelessNode: models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000}, ID: 1}
+	node2 := models.StatefulNode{StatelessNode: models.StatelessNode{HostIP: "1.1.1.2", GRPCPort: 9000}, ID: 2}
+	stateMgr.EXPECT().GetDatabases().Return([]models.Database{
+		{Name: "test", Storage: "storage"},
+		{Name: "test_2", Storage: "storage_2"},
+	}).AnyTimes()
+	stateMgr.EXPECT().GetStorage("storage").Return(&models.StorageState{
+		LiveNodes: map[models.NodeID]models.StatefulNode{1: node1, 2: node2},
+	}, true).AnyTimes()
+	stateMgr.EXPECT().GetStorage("storage_2").Return(nil, false).AnyTimes()
+
+	tracker := NewReplicaLagTracker(context.TODO(), time.Second, stateMgr).(*replicaLagTracker)
+	cli.EXPECT().FetchReplicaState("test", gomock.Any()).DoAndReturn(
+		func(_ string, node models.Node) ([]models.FamilyLogReplicaState, error) {
+			if node.Indicator() == node2.Indicator() {
+				return nil, fmt.Errorf("err")
+			}
+			return []models.FamilyLogReplicaState{
+				{ShardID: 1, Replicators: []models.ReplicaPeerState{{Replicator: "2", Pending: 10}, {Replicator: "3", Pending: 1}}},
+				{ShardID: 1, Replicators: []models.ReplicaPeerState{{Replicator: "2", Pending: 5}}},
+				{ShardID: 2, Replicators: []models.ReplicaPeerState{{Replicator: "2", Pending: 0}}},
+			}, nil
+		}).Times(2)
+	tracker.collect()
+
+	lag, ok := tracker.GetLag("test", 1, node2.Indicator())
+	assert.True(t, ok)
+	assert.Equal(t, int64(15), lag.Pending)
+	assert.True(t, lag.CaughtUpAt.IsZero())
+	lag, ok = tracker.GetLag("test", 2, node2.Indicator())
+	assert.True(t, ok)
+	assert.Equal(t, int64(0), lag.Pending)
+	caughtUpAt := lag.CaughtUpAt
+	assert.False(t, caughtUpAt.IsZero())
+	// node not alive
+	_, ok = tracker.GetLag("test", 1, "1.1.1.3:9000")
+	assert.False(t, ok)
+
+	// keep last caught up time if replica is lagging
+	cli.EXPECT().FetchReplicaState("test", gomock.Any()).Return([]models.FamilyLogReplicaState{
+		{ShardID: 2, Replicators: []models.ReplicaPeerState{{Replicator: "2", Pending: 3}}},
+	}, nil)
+	cli.EXPECT().FetchReplicaState("test", gomock.Any()).Return(nil, fmt.Errorf("err"))
+	tracker.collect()
+	lag, ok = tracker.GetLag("test", 2, node2.Indicator())
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), lag.Pending)
+	assert.Equal(t, caughtUpAt, lag.CaughtUpAt)
+	// replica not reported
+	_, ok = tracker.GetLag("test", 1, node2.Indicator())
+	assert.False(t, ok)
+}
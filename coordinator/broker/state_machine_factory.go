@@ -48,6 +48,30 @@ func init() {
 			return &models.StorageState{}
 		},
 	}
+	StateMachinePaths[constants.AuthPrincipal] = models.StateMachineInfo{
+		Path: constants.AuthPrincipalPath,
+		CreateState: func() interface{} {
+			return &models.Principal{}
+		},
+	}
+	StateMachinePaths[constants.DatabaseSchema] = models.StateMachineInfo{
+		Path: constants.DatabaseSchemaPath,
+		CreateState: func() interface{} {
+			return &models.DatabaseSchema{}
+		},
+	}
+	StateMachinePaths[constants.FieldAlias] = models.StateMachineInfo{
+		Path: constants.FieldAliasPath,
+		CreateState: func() interface{} {
+			return &models.DatabaseFieldAlias{}
+		},
+	}
+	StateMachinePaths[constants.NamespaceRouting] = models.StateMachineInfo{
+		Path: constants.NamespaceRoutingPath,
+		CreateState: func() interface{} {
+			return &models.NamespaceRouting{}
+		},
+	}
 }
 
 // stateMachineFactory implements discovery.StateMachineFactory.
@@ -98,6 +122,34 @@ func (f *stateMachineFactory) Start() (err error) {
 	}
 	f.stateMachines = append(f.stateMachines, sm)
 
+	f.logger.Debug("starting AuthPrincipalStateMachine")
+	sm, err = f.createAuthPrincipalStateMachine()
+	if err != nil {
+		return err
+	}
+	f.stateMachines = append(f.stateMachines, sm)
+
+	f.logger.Debug("starting DatabaseSchemaStateMachine")
+	sm, err = f.createDatabaseSchemaStateMachine()
+	if err != nil {
+		return err
+	}
+	f.stateMachines = append(f.stateMachines, sm)
+
+	f.logger.Debug("starting FieldAliasStateMachine")
+	sm, err = f.createFieldAliasStateMachine()
+	if err != nil {
+		return err
+	}
+	f.stateMachines = append(f.stateMachines, sm)
+
+	f.logger.Debug("starting NamespaceRoutingStateMachine")
+	sm, err = f.createNamespaceRoutingStateMachine()
+	if err != nil {
+		return err
+	}
+	f.stateMachines = append(f.stateMachines, sm)
+
 	f.logger.Info("started BrokerStateMachines")
 	return nil
 }
@@ -151,6 +203,58 @@ func (f *stateMachineFactory) createStorageStatusStateMachine() (discovery.State
 	)
 }
 
+// createAuthPrincipalStateMachine creates the permissions of principal state machine.
+func (f *stateMachineFactory) createAuthPrincipalStateMachine() (discovery.StateMachine, error) {
+	return discovery.NewStateMachineFn(
+		f.ctx,
+		discovery.AuthPrincipalStateMachine,
+		f.discoveryFactory,
+		constants.AuthPrincipalPath,
+		true,
+		f.onAuthPrincipalChanged,
+		f.onAuthPrincipalDeletion,
+	)
+}
+
+// createDatabaseSchemaStateMachine creates the metric schema registry of database state machine.
+func (f *stateMachineFactory) createDatabaseSchemaStateMachine() (discovery.StateMachine, error) {
+	return discovery.NewStateMachineFn(
+		f.ctx,
+		discovery.DatabaseSchemaStateMachine,
+		f.discoveryFactory,
+		constants.DatabaseSchemaPath,
+		true,
+		f.onDatabaseSchemaChanged,
+		f.onDatabaseSchemaDeletion,
+	)
+}
+
+// createFieldAliasStateMachine creates the field aliases of database state machine.
+func (f *stateMachineFactory) createFieldAliasStateMachine() (discovery.StateMachine, error) {
+	return discovery.NewStateMachineFn(
+		f.ctx,
+		discovery.FieldAliasStateMachine,
+		f.discoveryFactory,
+		constants.FieldAliasPath,
+		true,
+		f.onFieldAliasChanged,
+		f.onFieldAliasDeletion,
+	)
+}
+
+// createNamespaceRoutingStateMachine creates the namespace routing table of write api state machine.
+func (f *stateMachineFactory) createNamespaceRoutingStateMachine() (discovery.StateMachine, error) {
+	return discovery.NewStateMachineFn(
+		f.ctx,
+		discovery.NamespaceRoutingStateMachine,
+		f.discoveryFactory,
+		constants.NamespaceRoutingPath,
+		true,
+		f.onNamespaceRoutingChanged,
+		f.onNamespaceRoutingDeletion,
+	)
+}
+
 // onDatabaseConfigChanged triggers when database config modified(create/update)
 func (f *stateMachineFactory) onDatabaseConfigChanged(key string, data []byte) {
 	f.stateMgr.EmitEvent(&discovery.Event{
@@ -201,3 +305,71 @@ func (f *stateMachineFactory) onStorageDeletion(key string) {
 		Key:  key,
 	})
 }
+
+// onAuthPrincipalChanged triggers when the permissions of principal modified(create/update).
+func (f *stateMachineFactory) onAuthPrincipalChanged(key string, data []byte) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type:  discovery.AuthPrincipalChanged,
+		Key:   key,
+		Value: data,
+	})
+}
+
+// onAuthPrincipalDeletion triggers when principal is deletion.
+func (f *stateMachineFactory) onAuthPrincipalDeletion(key string) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type: discovery.AuthPrincipalDeletion,
+		Key:  key,
+	})
+}
+
+// onDatabaseSchemaChanged triggers when the metric schema registry of database modified(create/update).
+func (f *stateMachineFactory) onDatabaseSchemaChanged(key string, data []byte) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type:  discovery.DatabaseSchemaChanged,
+		Key:   key,
+		Value: data,
+	})
+}
+
+// onDatabaseSchemaDeletion triggers when the metric schema registry of database is deletion.
+func (f *stateMachineFactory) onDatabaseSchemaDeletion(key string) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type: discovery.DatabaseSchemaDeletion,
+		Key:  key,
+	})
+}
+
+// onFieldAliasChanged triggers when the field aliases of database modified(create/update).
+func (f *stateMachineFactory) onFieldAliasChanged(key string, data []byte) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type:  discovery.FieldAliasChanged,
+		Key:   key,
+		Value: data,
+	})
+}
+
+// onFieldAliasDeletion triggers when the field aliases of database is deletion.
+func (f *stateMachineFactory) onFieldAliasDeletion(key string) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type: discovery.FieldAliasDeletion,
+		Key:  key,
+	})
+}
+
+// onNamespaceRoutingChanged triggers when the namespace routing table modified(create/update).
+func (f *stateMachineFactory) onNamespaceRoutingChanged(key string, data []byte) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type:  discovery.NamespaceRoutingChanged,
+		Key:   key,
+		Value: data,
+	})
+}
+
+// onNamespaceRoutingDeletion triggers when the namespace routing table is deletion.
+func (f *stateMachineFactory) onNamespaceRoutingDeletion(key string) {
+	f.stateMgr.EmitEvent(&discovery.Event{
+		Type: discovery.NamespaceRoutingDeletion,
+		Key:  key,
+	})
+}
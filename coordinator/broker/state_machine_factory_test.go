@@ -54,10 +54,48 @@ func TestStateMachineFactory_Start(t *testing.T) {
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(fmt.Errorf("err"))
 	err = fct.Start()
 	assert.Error(t, err)
+	// auth principal sm err
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(fmt.Errorf("err"))
+	err = fct.Start()
+	assert.Error(t, err)
+	// database schema sm err
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(fmt.Errorf("err"))
+	err = fct.Start()
+	assert.Error(t, err)
+	// field alias sm err
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(fmt.Errorf("err"))
+	err = fct.Start()
+	assert.Error(t, err)
+	// namespace routing sm err
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(fmt.Errorf("err"))
+	err = fct.Start()
+	assert.Error(t, err)
 	// all state machines are ok
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
 	err = fct.Start()
 	assert.NoError(t, err)
 }
@@ -137,8 +175,92 @@ func TestStateMachineFactory_OnStorage(t *testing.T) {
 	fct1.onStorageStateChange("/key", []byte("value"))
 }
 
+func TestStateMachineFactory_OnAuthPrincipal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := NewMockStateManager(ctrl)
+	fct := NewStateMachineFactory(context.TODO(), nil, stateMgr)
+	fct1 := fct.(*stateMachineFactory)
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type: discovery.AuthPrincipalDeletion,
+		Key:  "/key",
+	})
+	fct1.onAuthPrincipalDeletion("/key")
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type:  discovery.AuthPrincipalChanged,
+		Key:   "/key",
+		Value: []byte("value"),
+	})
+	fct1.onAuthPrincipalChanged("/key", []byte("value"))
+}
+
+func TestStateMachineFactory_OnDatabaseSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := NewMockStateManager(ctrl)
+	fct := NewStateMachineFactory(context.TODO(), nil, stateMgr)
+	fct1 := fct.(*stateMachineFactory)
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type: discovery.DatabaseSchemaDeletion,
+		Key:  "/key",
+	})
+	fct1.onDatabaseSchemaDeletion("/key")
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type:  discovery.DatabaseSchemaChanged,
+		Key:   "/key",
+		Value: []byte("value"),
+	})
+	fct1.onDatabaseSchemaChanged("/key", []byte("value"))
+}
+
+func TestStateMachineFactory_OnFieldAlias(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := NewMockStateManager(ctrl)
+	fct := NewStateMachineFactory(context.TODO(), nil, stateMgr)
+	fct1 := fct.(*stateMachineFactory)
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type: discovery.FieldAliasDeletion,
+		Key:  "/key",
+	})
+	fct1.onFieldAliasDeletion("/key")
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type:  discovery.FieldAliasChanged,
+		Key:   "/key",
+		Value: []byte("value"),
+	})
+	fct1.onFieldAliasChanged("/key", []byte("value"))
+}
+
+func TestStateMachineFactory_OnNamespaceRouting(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := NewMockStateManager(ctrl)
+	fct := NewStateMachineFactory(context.TODO(), nil, stateMgr)
+	fct1 := fct.(*stateMachineFactory)
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type: discovery.NamespaceRoutingDeletion,
+		Key:  "/key",
+	})
+	fct1.onNamespaceRoutingDeletion("/key")
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type:  discovery.NamespaceRoutingChanged,
+		Key:   "/key",
+		Value: []byte("value"),
+	})
+	fct1.onNamespaceRoutingChanged("/key", []byte("value"))
+}
+
 func TestStateMachineFactory_CreateState(t *testing.T) {
 	assert.NotNil(t, StateMachinePaths[constants.LiveNode].CreateState())
 	assert.NotNil(t, StateMachinePaths[constants.DatabaseConfig].CreateState())
 	assert.NotNil(t, StateMachinePaths[constants.StorageState].CreateState())
+	assert.NotNil(t, StateMachinePaths[constants.AuthPrincipal].CreateState())
+	assert.NotNil(t, StateMachinePaths[constants.DatabaseSchema].CreateState())
+	assert.NotNil(t, StateMachinePaths[constants.FieldAlias].CreateState())
+	assert.NotNil(t, StateMachinePaths[constants.NamespaceRouting].CreateState())
 }
@@ -19,6 +19,7 @@ package broker
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"sort"
 	"sync"
@@ -36,6 +37,9 @@ import (
 
 //go:generate mockgen -source=./state_manager.go -destination=./state_manager_mock.go -package=broker
 
+// ReplicaRank returns the rank of shard's replica on node, the replica with lower rank is preferred.
+type ReplicaRank func(shardID models.ShardID, node string) int64
+
 // StateManager represents broker state manager, maintains broker node/database/storage states in memory.
 type StateManager interface {
 	flow.NodeChoose
@@ -53,10 +57,23 @@ type StateManager interface {
 	// and chooses the leader replica if the shard has multi-replica.
 	// returns storage node => shard id list
 	GetQueryableReplicas(databaseName string) (map[string][]models.ShardID, error)
+	// GetAlternativeReplicas returns the live replicas(except the excluded nodes) of shards,
+	// used for retrying query on other replica when leader fails, prefers the replica with the lowest rank.
+	// returns storage node => shard id list
+	GetAlternativeReplicas(databaseName string, shardIDs []models.ShardID,
+		excludeNodes map[string]struct{}, rank ReplicaRank) (map[string][]models.ShardID, error)
 	// GetStorage returns storage state by name.
 	GetStorage(name string) (*models.StorageState, bool)
 	// GetStorageList returns all storage state list.
 	GetStorageList() (rs []*models.StorageState)
+	// GetPrincipal returns the per-database permissions of principal by name.
+	GetPrincipal(name string) (models.Principal, bool)
+	// GetDatabaseSchema returns the metric schema registry of database.
+	GetDatabaseSchema(databaseName string) (*models.DatabaseSchema, bool)
+	// GetFieldAlias returns the field aliases of database.
+	GetFieldAlias(databaseName string) (*models.DatabaseFieldAlias, bool)
+	// GetNamespaceRouting returns the namespace=>database routing table of write api.
+	GetNamespaceRouting() (*models.NamespaceRouting, bool)
 
 	WatchShardStateChangeEvent(fn func(databaseCfg models.Database,
 		shards map[models.ShardID]models.ShardState,
@@ -71,9 +88,13 @@ type stateManager struct {
 
 	// state cache
 	currentNode models.StatelessNode
-	storages    map[string]*models.StorageState // storage state
-	databases   map[string]models.Database      // database config
-	nodes       map[string]models.StatelessNode // live nodes of broker cluster
+	storages    map[string]*models.StorageState       // storage state
+	databases   map[string]models.Database            // database config
+	nodes       map[string]models.StatelessNode       // live nodes of broker cluster
+	principals  map[string]models.Principal           // permissions of principal
+	schemas     map[string]*models.DatabaseSchema     // metric schema registry of database
+	aliases     map[string]*models.DatabaseFieldAlias // field aliases of database
+	routing     *models.NamespaceRouting              // namespace routing table of write api
 
 	callbacks []func(databaseCfg models.Database,
 		shards map[models.ShardID]models.ShardState,
@@ -108,6 +129,9 @@ func NewStateManager(
 		storages:          make(map[string]*models.StorageState),
 		databases:         make(map[string]models.Database),
 		nodes:             make(map[string]models.StatelessNode),
+		principals:        make(map[string]models.Principal),
+		schemas:           make(map[string]*models.DatabaseSchema),
+		aliases:           make(map[string]*models.DatabaseFieldAlias),
 		events:            make(chan *discovery.Event, 10),
 		statistics:        metrics.NewStateManagerStatistics(linmetric.BrokerRegistry),
 		logger:            logger.GetLogger("Broker", "StateManager"),
@@ -211,6 +235,22 @@ func (m *stateManager) processEvent(event *discovery.Event) {
 		err = m.onStorageStateChange(event.Key, event.Value)
 	case discovery.StorageStateDeletion:
 		m.onStorageDelete(event.Key)
+	case discovery.AuthPrincipalChanged:
+		err = m.onAuthPrincipalChange(event.Key, event.Value)
+	case discovery.AuthPrincipalDeletion:
+		m.onAuthPrincipalDelete(event.Key)
+	case discovery.DatabaseSchemaChanged:
+		err = m.onDatabaseSchemaChange(event.Key, event.Value)
+	case discovery.DatabaseSchemaDeletion:
+		m.onDatabaseSchemaDelete(event.Key)
+	case discovery.FieldAliasChanged:
+		err = m.onFieldAliasChange(event.Key, event.Value)
+	case discovery.FieldAliasDeletion:
+		m.onFieldAliasDelete(event.Key)
+	case discovery.NamespaceRoutingChanged:
+		err = m.onNamespaceRoutingChange(event.Key, event.Value)
+	case discovery.NamespaceRoutingDeletion:
+		m.onNamespaceRoutingDelete(event.Key)
 	}
 	if err != nil {
 		m.statistics.HandleEventFailure.WithTagValues(eventType, constants.BrokerRole).Incr()
@@ -356,6 +396,120 @@ func (m *stateManager) onStorageDelete(key string) {
 	}
 }
 
+// onAuthPrincipalChange triggers when the permissions of principal create/modify.
+func (m *stateManager) onAuthPrincipalChange(key string, data []byte) error {
+	m.logger.Info("permissions of principal are modified",
+		logger.String("key", key),
+		logger.String("data", string(data)))
+
+	principal := models.Principal{}
+	if err := encoding.JSONUnmarshal(data, &principal); err != nil {
+		m.logger.Error("permissions of principal modified but unmarshal error", logger.Error(err))
+		return err
+	}
+	if principal.Name == "" {
+		m.logger.Error("principal name cannot be empty")
+		return constants.ErrNameEmpty
+	}
+
+	m.principals[principal.Name] = principal
+	return nil
+}
+
+// onAuthPrincipalDelete triggers when principal is deletion.
+func (m *stateManager) onAuthPrincipalDelete(key string) {
+	m.logger.Info("principal deleted",
+		logger.String("key", key))
+
+	_, name := filepath.Split(key)
+
+	delete(m.principals, name)
+}
+
+// onDatabaseSchemaChange triggers when the metric schema registry of database create/modify.
+func (m *stateManager) onDatabaseSchemaChange(key string, data []byte) error {
+	m.logger.Info("metric schema of database is modified",
+		logger.String("key", key),
+		logger.String("data", string(data)))
+
+	schema := &models.DatabaseSchema{}
+	if err := encoding.JSONUnmarshal(data, schema); err != nil {
+		m.logger.Error("metric schema of database modified but unmarshal error", logger.Error(err))
+		return err
+	}
+	if schema.Database == "" {
+		m.logger.Error("database name cannot be empty")
+		return constants.ErrNameEmpty
+	}
+
+	m.schemas[schema.Database] = schema
+	return nil
+}
+
+// onDatabaseSchemaDelete triggers when the metric schema registry of database is deletion.
+func (m *stateManager) onDatabaseSchemaDelete(key string) {
+	m.logger.Info("metric schema of database deleted",
+		logger.String("key", key))
+
+	_, name := filepath.Split(key)
+
+	delete(m.schemas, name)
+}
+
+// onFieldAliasChange triggers when the field aliases of database create/modify.
+func (m *stateManager) onFieldAliasChange(key string, data []byte) error {
+	m.logger.Info("field alias of database is modified",
+		logger.String("key", key),
+		logger.String("data", string(data)))
+
+	alias := &models.DatabaseFieldAlias{}
+	if err := encoding.JSONUnmarshal(data, alias); err != nil {
+		m.logger.Error("field alias of database modified but unmarshal error", logger.Error(err))
+		return err
+	}
+	if alias.Database == "" {
+		m.logger.Error("database name cannot be empty")
+		return constants.ErrNameEmpty
+	}
+
+	m.aliases[alias.Database] = alias
+	return nil
+}
+
+// onFieldAliasDelete triggers when the field aliases of database is deletion.
+func (m *stateManager) onFieldAliasDelete(key string) {
+	m.logger.Info("field alias of database deleted",
+		logger.String("key", key))
+
+	_, name := filepath.Split(key)
+
+	delete(m.aliases, name)
+}
+
+// onNamespaceRoutingChange triggers when the namespace routing table create/modify.
+func (m *stateManager) onNamespaceRoutingChange(key string, data []byte) error {
+	m.logger.Info("namespace routing is modified",
+		logger.String("key", key),
+		logger.String("data", string(data)))
+
+	routing := &models.NamespaceRouting{}
+	if err := encoding.JSONUnmarshal(data, routing); err != nil {
+		m.logger.Error("namespace routing modified but unmarshal error", logger.Error(err))
+		return err
+	}
+
+	m.routing = routing
+	return nil
+}
+
+// onNamespaceRoutingDelete triggers when the namespace routing table is deletion.
+func (m *stateManager) onNamespaceRoutingDelete(key string) {
+	m.logger.Info("namespace routing deleted",
+		logger.String("key", key))
+
+	m.routing = nil
+}
+
 // GetCurrentNode returns the current broker node.
 func (m *stateManager) GetCurrentNode() models.StatelessNode {
 	return m.currentNode
@@ -421,6 +575,41 @@ func (m *stateManager) GetStorageList() (rs []*models.StorageState) {
 	return
 }
 
+// GetPrincipal returns the per-database permissions of principal by name.
+func (m *stateManager) GetPrincipal(name string) (models.Principal, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	principal, ok := m.principals[name]
+	return principal, ok
+}
+
+// GetDatabaseSchema returns the metric schema registry of database.
+func (m *stateManager) GetDatabaseSchema(databaseName string) (*models.DatabaseSchema, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	schema, ok := m.schemas[databaseName]
+	return schema, ok
+}
+
+// GetFieldAlias returns the field aliases of database.
+func (m *stateManager) GetFieldAlias(databaseName string) (*models.DatabaseFieldAlias, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	alias, ok := m.aliases[databaseName]
+	return alias, ok
+}
+
+// GetNamespaceRouting returns the namespace=>database routing table of write api.
+func (m *stateManager) GetNamespaceRouting() (*models.NamespaceRouting, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.routing, m.routing != nil
+}
+
 // GetQueryableReplicas returns the queryable replicas, else return detail error msg.::x
 // returns storage node => shard id list
 func (m *stateManager) GetQueryableReplicas(databaseName string) (map[string][]models.ShardID, error) {
@@ -460,7 +649,18 @@ func (m *stateManager) GetQueryableReplicas(databaseName string) (map[string][]m
 	result := make(map[string][]models.ShardID)
 	for shardID, shardState := range shards {
 		if shardState.State == models.OnlineShard {
-			node := liveNodes[shardState.Leader]
+			node, ok := liveNodes[shardState.Leader]
+			if !ok {
+				// leader is restarting(master defers leader reassignment), query a live replica instead
+				node, ok = liveReplica(liveNodes, shardState)
+			}
+			if !ok {
+				m.logger.Warn("shard has no live replica ignore it, maybe query data will be lost",
+					logger.String("storage", database.Storage),
+					logger.String("database", databaseName),
+					logger.Any("shard", shardState.ID))
+				continue
+			}
 			nodeID := node.Indicator()
 			result[nodeID] = append(result[nodeID], shardID)
 		} else {
@@ -473,6 +673,68 @@ func (m *stateManager) GetQueryableReplicas(databaseName string) (map[string][]m
 	return result, nil
 }
 
+// liveReplica returns the first live replica of shard.
+func liveReplica(liveNodes map[models.NodeID]models.StatefulNode, shardState models.ShardState) (models.StatefulNode, bool) {
+	for _, nodeID := range shardState.Replica.Replicas {
+		if node, ok := liveNodes[nodeID]; ok {
+			return node, true
+		}
+	}
+	return models.StatefulNode{}, false
+}
+
+// GetAlternativeReplicas returns the live replicas(except the excluded nodes) of shards,
+// chooses the replica with the lowest rank for each shard(the first one in replica list if rank not set),
+// else returns error if any shard has no available replica.
+func (m *stateManager) GetAlternativeReplicas(databaseName string, shardIDs []models.ShardID,
+	excludeNodes map[string]struct{}, rank ReplicaRank,
+) (map[string][]models.ShardID, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	database, ok := m.databases[databaseName]
+	if !ok {
+		return nil, constants.ErrDatabaseNotFound
+	}
+	storageState, ok := m.storages[database.Storage]
+	if !ok {
+		return nil, constants.ErrNoStorageCluster
+	}
+	shards := storageState.ShardStates[databaseName]
+	result := make(map[string][]models.ShardID)
+	for _, shardID := range shardIDs {
+		shardState, ok := shards[shardID]
+		if !ok || shardState.State != models.OnlineShard {
+			return nil, fmt.Errorf("%w: %d", constants.ErrNoLiveReplica, shardID)
+		}
+		chosen := ""
+		var chosenRank int64
+		for _, nodeID := range shardState.Replica.Replicas {
+			node, ok := storageState.LiveNodes[nodeID]
+			if !ok {
+				continue
+			}
+			nodeIndicator := node.Indicator()
+			if _, excluded := excludeNodes[nodeIndicator]; excluded {
+				continue
+			}
+			if rank == nil {
+				chosen = nodeIndicator
+				break
+			}
+			if r := rank(shardID, nodeIndicator); chosen == "" || r < chosenRank {
+				chosen = nodeIndicator
+				chosenRank = r
+			}
+		}
+		if chosen == "" {
+			return nil, fmt.Errorf("%w: %d", constants.ErrNoLiveReplica, shardID)
+		}
+		result[chosen] = append(result[chosen], shardID)
+	}
+	return result, nil
+}
+
 // buildShardAssign builds the data write channel and related shard state.
 func (m *stateManager) notifyShardStateChange(storageState *models.StorageState) {
 	liveNodes := storageState.LiveNodes
@@ -103,6 +103,152 @@ func TestStateManager_DatabaseConfig(t *testing.T) {
 	mgr.Close()
 }
 
+func TestStateManager_AuthPrincipal(t *testing.T) {
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil)
+	// case 1: unmarshal principal err
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.AuthPrincipalChanged,
+		Key:   "/ops",
+		Value: []byte("221"),
+	})
+	// case 2: principal name empty
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.AuthPrincipalChanged,
+		Key:   "/ops",
+		Value: []byte("{}"),
+	})
+	// case 3: cache principal
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.AuthPrincipalChanged,
+		Key:   "/ops",
+		Value: []byte(`{"name":"ops","permissions":{"db":["read"]}}`),
+	})
+	time.Sleep(time.Second) // wait
+	principal, ok := mgr.GetPrincipal("ops")
+	assert.True(t, ok)
+	assert.True(t, principal.HasPermission("db", models.PermissionRead))
+
+	// case 4: remove principal
+	mgr.EmitEvent(&discovery.Event{
+		Type: discovery.AuthPrincipalDeletion,
+		Key:  "/ops",
+	})
+	time.Sleep(time.Second) // wait
+	_, ok = mgr.GetPrincipal("ops")
+	assert.False(t, ok)
+
+	mgr.Close()
+}
+
+func TestStateManager_DatabaseSchema(t *testing.T) {
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil)
+	// case 1: unmarshal schema err
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.DatabaseSchemaChanged,
+		Key:   "/db",
+		Value: []byte("221"),
+	})
+	// case 2: database name empty
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.DatabaseSchemaChanged,
+		Key:   "/db",
+		Value: []byte("{}"),
+	})
+	// case 3: cache schema
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.DatabaseSchemaChanged,
+		Key:   "/db",
+		Value: []byte(`{"database":"db","mode":"strict","metrics":[{"name":"cpu"}]}`),
+	})
+	time.Sleep(time.Second) // wait
+	schema, ok := mgr.GetDatabaseSchema("db")
+	assert.True(t, ok)
+	assert.Equal(t, models.SchemaModeStrict, schema.Mode)
+	assert.Len(t, schema.Metrics, 1)
+
+	// case 4: remove schema
+	mgr.EmitEvent(&discovery.Event{
+		Type: discovery.DatabaseSchemaDeletion,
+		Key:  "/db",
+	})
+	time.Sleep(time.Second) // wait
+	_, ok = mgr.GetDatabaseSchema("db")
+	assert.False(t, ok)
+
+	mgr.Close()
+}
+
+func TestStateManager_FieldAlias(t *testing.T) {
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil)
+	// case 1: unmarshal alias err
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.FieldAliasChanged,
+		Key:   "/db",
+		Value: []byte("221"),
+	})
+	// case 2: database name empty
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.FieldAliasChanged,
+		Key:   "/db",
+		Value: []byte("{}"),
+	})
+	// case 3: cache alias
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.FieldAliasChanged,
+		Key:   "/db",
+		Value: []byte(`{"database":"db","aliases":[{"metric":"cpu","from":"usage","to":"used","since":10}]}`),
+	})
+	time.Sleep(time.Second) // wait
+	alias, ok := mgr.GetFieldAlias("db")
+	assert.True(t, ok)
+	assert.Len(t, alias.Aliases, 1)
+
+	// case 4: remove alias
+	mgr.EmitEvent(&discovery.Event{
+		Type: discovery.FieldAliasDeletion,
+		Key:  "/db",
+	})
+	time.Sleep(time.Second) // wait
+	_, ok = mgr.GetFieldAlias("db")
+	assert.False(t, ok)
+
+	mgr.Close()
+}
+
+func TestStateManager_NamespaceRouting(t *testing.T) {
+	mgr := NewStateManager(context.TODO(), models.StatelessNode{}, nil, nil)
+	_, ok := mgr.GetNamespaceRouting()
+	assert.False(t, ok)
+	// case 1: unmarshal routing err
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.NamespaceRoutingChanged,
+		Key:   "/write/routing/namespace",
+		Value: []byte("221"),
+	})
+	// case 2: cache routing
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.NamespaceRoutingChanged,
+		Key:   "/write/routing/namespace",
+		Value: []byte(`{"routes":[{"namespace":"infra","database":"infra_metrics"}],"defaultDatabase":"db"}`),
+	})
+	time.Sleep(time.Second) // wait
+	routing, ok := mgr.GetNamespaceRouting()
+	assert.True(t, ok)
+	assert.Len(t, routing.Routes, 1)
+	assert.Equal(t, "db", routing.DefaultDatabase)
+
+	// case 3: remove routing
+	mgr.EmitEvent(&discovery.Event{
+		Type: discovery.NamespaceRoutingDeletion,
+		Key:  "/write/routing/namespace",
+	})
+	time.Sleep(time.Second) // wait
+	_, ok = mgr.GetNamespaceRouting()
+	assert.False(t, ok)
+
+	mgr.Close()
+}
+
 func TestStateManager_Node(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -243,7 +389,7 @@ func TestStateManager_ShardState(t *testing.T) {
 		Value: encoding.JSONMarshal(&models.StorageState{
 			Name: "test",
 			ShardStates: map[string]map[models.ShardID]models.ShardState{
-				"db": {1: models.ShardState{ID: 1, State: models.OnlineShard}, 2: models.ShardState{ID: 2}},
+				"db": {1: models.ShardState{ID: 1, State: models.OnlineShard, Leader: 1}, 2: models.ShardState{ID: 2}},
 			},
 			LiveNodes: map[models.NodeID]models.StatefulNode{1: {
 				StatelessNode: models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000},
@@ -394,3 +540,138 @@ func TestStateManager_Choose(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, plans, 1)
 }
+
+func TestStateManager_GetQueryableReplicas_LeaderRestarting(t *testing.T) {
+	mgr := &stateManager{
+		databases: map[string]models.Database{"test": {Storage: "test"}},
+		storages: map[string]*models.StorageState{
+			"test": {
+				LiveNodes: map[models.NodeID]models.StatefulNode{
+					1: {StatelessNode: models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000}},
+					2: {StatelessNode: models.StatelessNode{HostIP: "1.1.1.2", GRPCPort: 9000}},
+				},
+				ShardStates: map[string]map[models.ShardID]models.ShardState{
+					"test": {
+						1: {ID: 1, State: models.OnlineShard, Leader: 1, Replica: models.Replica{Replicas: []models.NodeID{1, 2}}},
+						// leader is restarting, query live replica
+						2: {ID: 2, State: models.OnlineShard, Leader: 3, Replica: models.Replica{Replicas: []models.NodeID{3, 2}}},
+						// no live replica
+						3: {ID: 3, State: models.OnlineShard, Leader: 3, Replica: models.Replica{Replicas: []models.NodeID{3}}},
+					},
+				},
+			},
+		},
+		logger: logger.GetLogger("Test", "StateManager"),
+	}
+	replicas, err := mgr.GetQueryableReplicas("test")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]models.ShardID{
+		"1.1.1.1:9000": {1},
+		"1.1.1.2:9000": {2},
+	}, replicas)
+}
+
+func TestStateManager_GetAlternativeReplicas(t *testing.T) {
+	mgr := &stateManager{
+		databases: map[string]models.Database{
+			"test_1": {Storage: "test_1"},
+			"test_2": {Storage: "test_2"},
+		},
+		storages: map[string]*models.StorageState{
+			"test_1": {
+				LiveNodes: map[models.NodeID]models.StatefulNode{
+					1: {StatelessNode: models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000}},
+					2: {StatelessNode: models.StatelessNode{HostIP: "1.1.1.2", GRPCPort: 9000}},
+				},
+				ShardStates: map[string]map[models.ShardID]models.ShardState{
+					"test_1": {
+						1: {
+							State:   models.OnlineShard,
+							Leader:  models.NodeID(1),
+							Replica: models.Replica{Replicas: []models.NodeID{1, 2}},
+						},
+						2: {
+							State:   models.OnlineShard,
+							Leader:  models.NodeID(1),
+							Replica: models.Replica{Replicas: []models.NodeID{1, 3}},
+						},
+						3: {
+							State: models.OfflineShard,
+						},
+					},
+				},
+			},
+		},
+		logger: logger.GetLogger("Test", "StateManager"),
+	}
+	cases := []struct {
+		name     string
+		database string
+		shardIDs []models.ShardID
+		excludes map[string]struct{}
+		rank     ReplicaRank
+		replicas map[string][]models.ShardID
+		wantErr  bool
+	}{
+		{
+			name:     "database not found",
+			database: "test",
+			wantErr:  true,
+		},
+		{
+			name:     "storage not found",
+			database: "test_2",
+			wantErr:  true,
+		},
+		{
+			name:     "shard offline",
+			database: "test_1",
+			shardIDs: []models.ShardID{3},
+			wantErr:  true,
+		},
+		{
+			name:     "no alternative replica",
+			database: "test_1",
+			shardIDs: []models.ShardID{1, 2},
+			excludes: map[string]struct{}{"1.1.1.1:9000": {}},
+			wantErr:  true,
+		},
+		{
+			name:     "choose alternative replica",
+			database: "test_1",
+			shardIDs: []models.ShardID{1},
+			excludes: map[string]struct{}{"1.1.1.1:9000": {}},
+			replicas: map[string][]models.ShardID{"1.1.1.2:9000": {1}},
+		},
+		{
+			name:     "choose first replica",
+			database: "test_1",
+			shardIDs: []models.ShardID{1, 2},
+			replicas: map[string][]models.ShardID{"1.1.1.1:9000": {1, 2}},
+		},
+		{
+			name:     "choose replica with lowest rank",
+			database: "test_1",
+			shardIDs: []models.ShardID{1, 2},
+			rank: func(_ models.ShardID, node string) int64 {
+				if node == "1.1.1.2:9000" {
+					return 0
+				}
+				return 10
+			},
+			replicas: map[string][]models.ShardID{"1.1.1.2:9000": {1}, "1.1.1.1:9000": {2}},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			replicas, err := mgr.GetAlternativeReplicas(tt.database, tt.shardIDs, tt.excludes, tt.rank)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.replicas, replicas)
+		})
+	}
+}
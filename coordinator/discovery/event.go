@@ -33,6 +33,17 @@ const (
 	StorageConfigDeletion
 	BrokerConfigChanged
 	BrokerConfigDeletion
+	AuthPrincipalChanged
+	AuthPrincipalDeletion
+	DatabaseSchemaChanged
+	DatabaseSchemaDeletion
+	FieldAliasChanged
+	FieldAliasDeletion
+	NamespaceRoutingChanged
+	NamespaceRoutingDeletion
+	NodeMaintenanceExpired
+	RollupBackfillCheck
+	DatabasePurgeCheck
 )
 
 // String returns string value of EventType.
@@ -62,6 +73,28 @@ func (e EventType) String() string {
 		return "BrokerConfigChanged"
 	case BrokerConfigDeletion:
 		return "BrokerConfigDeletion"
+	case AuthPrincipalChanged:
+		return "AuthPrincipalChanged"
+	case AuthPrincipalDeletion:
+		return "AuthPrincipalDeletion"
+	case DatabaseSchemaChanged:
+		return "DatabaseSchemaChanged"
+	case DatabaseSchemaDeletion:
+		return "DatabaseSchemaDeletion"
+	case FieldAliasChanged:
+		return "FieldAliasChanged"
+	case FieldAliasDeletion:
+		return "FieldAliasDeletion"
+	case NamespaceRoutingChanged:
+		return "NamespaceRoutingChanged"
+	case NamespaceRoutingDeletion:
+		return "NamespaceRoutingDeletion"
+	case NodeMaintenanceExpired:
+		return "NodeMaintenanceExpired"
+	case RollupBackfillCheck:
+		return "RollupBackfillCheck"
+	case DatabasePurgeCheck:
+		return "DatabasePurgeCheck"
 	default:
 		return "unknown"
 	}
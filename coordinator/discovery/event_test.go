@@ -38,4 +38,16 @@ func TestEventType_String(t *testing.T) {
 
 	assert.Equal(t, "BrokerConfigDeletion", BrokerConfigDeletion.String())
 	assert.Equal(t, "BrokerConfigChanged", BrokerConfigChanged.String())
+
+	assert.Equal(t, "AuthPrincipalChanged", AuthPrincipalChanged.String())
+	assert.Equal(t, "AuthPrincipalDeletion", AuthPrincipalDeletion.String())
+	assert.Equal(t, "DatabaseSchemaChanged", DatabaseSchemaChanged.String())
+	assert.Equal(t, "DatabaseSchemaDeletion", DatabaseSchemaDeletion.String())
+	assert.Equal(t, "FieldAliasChanged", FieldAliasChanged.String())
+	assert.Equal(t, "FieldAliasDeletion", FieldAliasDeletion.String())
+	assert.Equal(t, "NamespaceRoutingChanged", NamespaceRoutingChanged.String())
+	assert.Equal(t, "NamespaceRoutingDeletion", NamespaceRoutingDeletion.String())
+	assert.Equal(t, "NodeMaintenanceExpired", NodeMaintenanceExpired.String())
+	assert.Equal(t, "RollupBackfillCheck", RollupBackfillCheck.String())
+	assert.Equal(t, "DatabasePurgeCheck", DatabasePurgeCheck.String())
 }
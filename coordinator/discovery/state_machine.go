@@ -48,6 +48,10 @@ const (
 	StorageNodeStateMachine
 	BrokerConfigStateMachine
 	BrokerNodeStateMachine
+	AuthPrincipalStateMachine
+	DatabaseSchemaStateMachine
+	FieldAliasStateMachine
+	NamespaceRoutingStateMachine
 )
 
 // String returns state machine type desc.
@@ -69,6 +73,14 @@ func (st StateMachineType) String() string {
 		return "BrokerConfigStateMachine"
 	case BrokerNodeStateMachine:
 		return "BrokerNodeStateMachine"
+	case AuthPrincipalStateMachine:
+		return "AuthPrincipalStateMachine"
+	case DatabaseSchemaStateMachine:
+		return "DatabaseSchemaStateMachine"
+	case FieldAliasStateMachine:
+		return "FieldAliasStateMachine"
+	case NamespaceRoutingStateMachine:
+		return "NamespaceRoutingStateMachine"
 	default:
 		return "Unknown"
 	}
@@ -40,6 +40,10 @@ func TestStateMachineType_String(t *testing.T) {
 	assert.Equal(t, (StateMachineType(0)).String(), "Unknown")
 	assert.Equal(t, BrokerConfigStateMachine.String(), "BrokerConfigStateMachine")
 	assert.Equal(t, BrokerNodeStateMachine.String(), "BrokerNodeStateMachine")
+	assert.Equal(t, AuthPrincipalStateMachine.String(), "AuthPrincipalStateMachine")
+	assert.Equal(t, DatabaseSchemaStateMachine.String(), "DatabaseSchemaStateMachine")
+	assert.Equal(t, FieldAliasStateMachine.String(), "FieldAliasStateMachine")
+	assert.Equal(t, NamespaceRoutingStateMachine.String(), "NamespaceRoutingStateMachine")
 }
 
 func TestNewMockStateMachine(t *testing.T) {
@@ -53,13 +53,22 @@ func (r *replicaLeaderElector) ElectLeader(shardAssignment *models.ShardAssignme
 		err = constants.ErrShardNotFound
 		return
 	}
-	// build live replica node
+	// build live replica node, prefer the ready node
 	liveReplicaNodes := models.Replica{}
+	var notReadyReplicas []models.NodeID
 	for _, replica := range replicas.Replicas {
-		if _, ok := liveNodes[replica]; ok {
+		if node, ok := liveNodes[replica]; ok {
+			if node.NotReady {
+				notReadyReplicas = append(notReadyReplicas, replica)
+				continue
+			}
 			liveReplicaNodes.Replicas = append(liveReplicaNodes.Replicas, replica)
 		}
 	}
+	if len(liveReplicaNodes.Replicas) == 0 {
+		// no ready replica node, fallback to not-ready node, better than offline shard
+		liveReplicaNodes.Replicas = notReadyReplicas
+	}
 	if len(liveReplicaNodes.Replicas) == 0 {
 		// no live replica node
 		err = constants.ErrNoLiveReplica
@@ -42,4 +42,17 @@ func TestReplicaLeaderElector_ElectLeader(t *testing.T) {
 	leader, err := elect.ElectLeader(shardAssignment, liveNodes, models.ShardID(1))
 	assert.NoError(t, err)
 	assert.Equal(t, models.NodeID(1), leader)
+
+	// prefer ready node
+	shardAssignment.AddReplica(models.ShardID(1), models.NodeID(2))
+	liveNodes[models.NodeID(1)] = models.StatefulNode{ID: 1, NotReady: true}
+	liveNodes[models.NodeID(2)] = models.StatefulNode{ID: 2}
+	leader, err = elect.ElectLeader(shardAssignment, liveNodes, models.ShardID(1))
+	assert.NoError(t, err)
+	assert.Equal(t, models.NodeID(2), leader)
+	// fallback to not-ready node
+	delete(liveNodes, models.NodeID(2))
+	leader, err = elect.ElectLeader(shardAssignment, liveNodes, models.ShardID(1))
+	assert.NoError(t, err)
+	assert.Equal(t, models.NodeID(1), leader)
 }
@@ -20,6 +20,7 @@ package master
 import (
 	"fmt"
 	"math/rand"
+	"sort"
 
 	"github.com/lindb/lindb/models"
 )
@@ -71,6 +72,9 @@ func ModifyShardAssignment(storageNodeIDs []models.NodeID, cfg *models.Database,
 	fixedStartIndex int, startShardID models.ShardID) error {
 	numOfShard := cfg.NumOfShard - len(shardAssignment.Shards)
 	replicaFactor := cfg.ReplicaFactor
+	if len(shardAssignment.Splits) > 0 {
+		return fmt.Errorf("shard assign error for databaes[%s], because shards are split", cfg.Name)
+	}
 	if numOfShard <= 0 {
 		return fmt.Errorf("shard assign error for databaes[%s], because add num. of shard <=0", cfg.Name)
 	}
@@ -87,6 +91,45 @@ func ModifyShardAssignment(storageNodeIDs []models.NodeID, cfg *models.Database,
 	return nil
 }
 
+// SplitShardAssignment splits the hot shard, adds a new target shard which takes over half key space of source shard,
+// replicas of target shard are placed on the nodes without source shard's replica first, then the nodes with fewer replicas.
+func SplitShardAssignment(storageNodeIDs []models.NodeID, shardAssignment *models.ShardAssignment,
+	source models.ShardID) (*models.ShardSplit, error) {
+	sourceReplica, ok := shardAssignment.Shards[source]
+	if !ok {
+		return nil, fmt.Errorf("split shard error for database[%s], because shard[%d] not exist",
+			shardAssignment.Name, source)
+	}
+	replicaFactor := len(sourceReplica.Replicas)
+	if replicaFactor > len(storageNodeIDs) {
+		return nil, fmt.Errorf("split shard error for database[%s], bacause replica factor > num. of storage nodes",
+			shardAssignment.Name)
+	}
+	numOfReplicas := make(map[models.NodeID]int)
+	for _, replica := range shardAssignment.Shards {
+		for _, nodeID := range replica.Replicas {
+			numOfReplicas[nodeID]++
+		}
+	}
+	nodeIDs := append([]models.NodeID{}, storageNodeIDs...)
+	sort.Slice(nodeIDs, func(i, j int) bool {
+		iSource, jSource := sourceReplica.Contain(nodeIDs[i]), sourceReplica.Contain(nodeIDs[j])
+		if iSource != jSource {
+			return !iSource
+		}
+		if numOfReplicas[nodeIDs[i]] != numOfReplicas[nodeIDs[j]] {
+			return numOfReplicas[nodeIDs[i]] < numOfReplicas[nodeIDs[j]]
+		}
+		return nodeIDs[i] < nodeIDs[j]
+	})
+	split := models.ShardSplit{Source: source, Target: shardAssignment.NextShardID()}
+	for _, nodeID := range nodeIDs[:replicaFactor] {
+		shardAssignment.AddReplica(split.Target, nodeID)
+	}
+	shardAssignment.Splits = append(shardAssignment.Splits, split)
+	return &shardAssignment.Splits[len(shardAssignment.Splits)-1], nil
+}
+
 // assignReplicasToStorageNodes assigns replica list for storage storageCluster
 // which database's each shard based on selected node list in storageCluster.
 func assignReplicasToStorageNodes(storageNodeIDs []models.NodeID,
@@ -105,3 +105,37 @@ func TestModifyShardAssignment(t *testing.T) {
 		}, models.NewShardAssignment("test"), -1, models.ShardID(1))
 	assert.Error(t, err)
 }
+
+func TestSplitShardAssignment(t *testing.T) {
+	shardAssignment := models.NewShardAssignment("test")
+	shardAssignment.AddReplica(0, 1)
+	shardAssignment.AddReplica(0, 2)
+	shardAssignment.AddReplica(1, 2)
+	shardAssignment.AddReplica(1, 3)
+
+	// shard not exist
+	_, err := SplitShardAssignment([]models.NodeID{1, 2, 3, 4}, shardAssignment, 5)
+	assert.Error(t, err)
+	// not enough nodes
+	_, err = SplitShardAssignment([]models.NodeID{1}, shardAssignment, 0)
+	assert.Error(t, err)
+
+	split, err := SplitShardAssignment([]models.NodeID{1, 2, 3, 4}, shardAssignment, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ShardSplit{Source: 0, Target: 2}, *split)
+	// prefers nodes without source replica, then fewer replicas
+	assert.Equal(t, []models.NodeID{4, 3}, shardAssignment.Shards[2].Replicas)
+	assert.Equal(t, 2, shardAssignment.NumOfBaseShards())
+
+	// split the target of split
+	split, err = SplitShardAssignment([]models.NodeID{1, 2, 3, 4}, shardAssignment, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ShardSplit{Source: 2, Target: 3}, *split)
+	assert.Equal(t, []models.NodeID{1, 2}, shardAssignment.Shards[3].Replicas)
+	assert.Len(t, shardAssignment.Splits, 2)
+
+	// database expansion not allowed after split
+	err = ModifyShardAssignment([]models.NodeID{1, 2, 3, 4},
+		&models.Database{Name: "test", NumOfShard: 6, ReplicaFactor: 2}, shardAssignment, -1, 4)
+	assert.Error(t, err)
+}
@@ -57,6 +57,12 @@ func init() {
 			return &models.ShardAssignment{}
 		},
 	}
+	StateMachinePaths[constants.DatabaseSchema] = models.StateMachineInfo{
+		Path: constants.DatabaseSchemaPath,
+		CreateState: func() interface{} {
+			return &models.DatabaseSchema{}
+		},
+	}
 	StateMachinePaths[constants.StorageState] = models.StateMachineInfo{
 		Path: constants.StorageStatePath,
 		CreateState: func() interface{} {
@@ -110,6 +116,12 @@ func (f *StateMachineFactory) Start() (err error) {
 		return err
 	}
 	f.stateMachines = append(f.stateMachines, sm)
+	f.logger.Debug("starting DatabaseSchemaStateMachine")
+	sm, err = f.createDatabaseSchemaStateMachine()
+	if err != nil {
+		return err
+	}
+	f.stateMachines = append(f.stateMachines, sm)
 
 	f.logger.Info("started MasterStateMachines")
 	return nil
@@ -194,6 +206,30 @@ func (f *StateMachineFactory) createShardAssignmentStateMachine() (discovery.Sta
 		})
 }
 
+// createDatabaseSchemaStateMachine creates the metric schema registry of database state machine,
+// field level retentions of schema are synced to storage cluster with database option.
+func (f *StateMachineFactory) createDatabaseSchemaStateMachine() (discovery.StateMachine, error) {
+	return discovery.NewStateMachine(
+		f.ctx,
+		discovery.DatabaseSchemaStateMachine,
+		f.discoveryFactory,
+		constants.DatabaseSchemaPath,
+		true,
+		func(key string, data []byte) {
+			f.stateMgr.EmitEvent(&discovery.Event{
+				Type:  discovery.DatabaseSchemaChanged,
+				Key:   key,
+				Value: data,
+			})
+		},
+		func(key string) {
+			f.stateMgr.EmitEvent(&discovery.Event{
+				Type: discovery.DatabaseSchemaDeletion,
+				Key:  key,
+			})
+		})
+}
+
 // createStorageNodeStateMachine creates storage node state machine.
 func (f *StateMachineFactory) createStorageNodeStateMachine(storageName string,
 	discoveryFactory discovery.Factory,
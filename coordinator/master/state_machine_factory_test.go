@@ -55,10 +55,18 @@ func TestStateMachineFactory_Start(t *testing.T) {
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(fmt.Errorf("err"))
 	err = fct.Start()
 	assert.Error(t, err)
+	// database schema sm err
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(fmt.Errorf("err"))
+	err = fct.Start()
+	assert.Error(t, err)
 	// all state machines are ok
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
 	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
 	err = fct.Start()
 	assert.NoError(t, err)
 }
@@ -163,6 +171,35 @@ func TestStateMachineFactory_ShardAssign(t *testing.T) {
 	sm.OnDelete("/test")
 }
 
+func TestStateMachineFactory_DatabaseSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := NewMockStateManager(ctrl)
+	discoveryFct := discovery.NewMockFactory(ctrl)
+	discovery1 := discovery.NewMockDiscovery(ctrl)
+	discoveryFct.EXPECT().CreateDiscovery(gomock.Any(), gomock.Any()).Return(discovery1)
+	discovery1.EXPECT().Discovery(gomock.Any()).Return(nil)
+	fct := NewStateMachineFactory(context.TODO(), discoveryFct, stateMgr)
+
+	sm, err := fct.createDatabaseSchemaStateMachine()
+	assert.NoError(t, err)
+	assert.NotNil(t, sm)
+
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type:  discovery.DatabaseSchemaChanged,
+		Key:   "/test",
+		Value: []byte("value"),
+	})
+	sm.OnCreate("/test", []byte("value"))
+
+	stateMgr.EXPECT().EmitEvent(&discovery.Event{
+		Type: discovery.DatabaseSchemaDeletion,
+		Key:  "/test",
+	})
+	sm.OnDelete("/test")
+}
+
 func TestStateMachineFactory_StorageNode(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -198,6 +235,7 @@ func TestStateMachineFactory_CreateState(t *testing.T) {
 	assert.NotNil(t, StateMachinePaths[constants.Master].CreateState())
 	assert.NotNil(t, StateMachinePaths[constants.DatabaseConfig].CreateState())
 	assert.NotNil(t, StateMachinePaths[constants.StorageConfig].CreateState())
+	assert.NotNil(t, StateMachinePaths[constants.DatabaseSchema].CreateState())
 	assert.NotNil(t, StateMachinePaths[constants.ShardAssignment].CreateState())
 	assert.NotNil(t, StateMachinePaths[constants.StorageState].CreateState())
 }
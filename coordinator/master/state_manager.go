@@ -20,12 +20,17 @@ package master
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/config"
@@ -37,11 +42,20 @@ import (
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
 	statepkg "github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 //go:generate mockgen -source=./state_manager.go -destination=./state_manager_mock.go -package=master
 
+// for testing
+var (
+	afterFuncFn = time.AfterFunc
+	// rollupBackfillCheckInterval represents the interval of checking the progress of rollup backfill.
+	rollupBackfillCheckInterval = 30 * time.Second
+)
+
 // StateManager represents master state manager, state coordinator.
 type StateManager interface {
 	discovery.StateMachineEventHandle
@@ -60,6 +74,18 @@ type StateManager interface {
 	GetShardAssignments() []models.ShardAssignment
 	// GetStorageStates returns current storage state list.
 	GetStorageStates() []*models.StorageState
+	// SplitShard splits the hot shard of database, creates a new shard which takes over part of future writes.
+	SplitShard(databaseName string, shardID models.ShardID) (*models.ShardSplit, error)
+	// AddReplica adds a new replica on storage node for the shard of database, new replica is bootstrapped
+	// from the snapshot of shard leader.
+	AddReplica(databaseName string, shardID models.ShardID, nodeID models.NodeID) (*models.ReplicaBootstrap, error)
+	// GetRollupBackfillStatus returns the rollup backfill status of database, includes the progress of each replica.
+	GetRollupBackfillStatus(databaseName string) (*models.RollupBackfillStatus, error)
+	// DropDatabase marks database deleted, data of database is kept and can be restored within grace period,
+	// the database is purged after grace period, or immediately if grace period is 0.
+	DropDatabase(databaseName string, gracePeriod time.Duration) (*models.Database, error)
+	// RestoreDatabase restores the dropped database within grace period.
+	RestoreDatabase(databaseName string) (*models.Database, error)
 }
 
 // stateManager implements StateManager.
@@ -80,6 +106,9 @@ type stateManager struct {
 	storages         map[string]StorageCluster
 	databases        map[string]*models.Database
 	shardAssignments map[string]*models.ShardAssignment
+	schemas          map[string]*models.DatabaseSchema
+	backfillChecks   map[string]struct{} // databases which rollup backfill check is scheduled
+	purgeChecks      map[string]struct{} // dropped databases which purge check is scheduled
 
 	events chan *discovery.Event
 
@@ -106,6 +135,9 @@ func NewStateManager(
 		storages:              make(map[string]StorageCluster),
 		databases:             make(map[string]*models.Database),
 		shardAssignments:      make(map[string]*models.ShardAssignment),
+		schemas:               make(map[string]*models.DatabaseSchema),
+		backfillChecks:        make(map[string]struct{}),
+		purgeChecks:           make(map[string]struct{}),
 		elector:               newReplicaLeaderElector(),
 		events:                make(chan *discovery.Event, 10),
 		running:               atomic.NewBool(true),
@@ -169,10 +201,20 @@ func (m *stateManager) processEvent(event *discovery.Event) {
 		err = m.onDatabaseCfgDelete(event.Key)
 	case discovery.ShardAssignmentChanged:
 		err = m.onShardAssignmentChange(event.Key, event.Value)
+	case discovery.DatabaseSchemaChanged:
+		err = m.onDatabaseSchemaChange(event.Key, event.Value)
+	case discovery.DatabaseSchemaDeletion:
+		err = m.onDatabaseSchemaDelete(event.Key)
 	case discovery.NodeStartup:
 		err = m.onStorageNodeStartup(event.Attributes[storageNameKey], event.Key, event.Value)
 	case discovery.NodeFailure:
 		err = m.onStorageNodeFailure(event.Attributes[storageNameKey], event.Key)
+	case discovery.NodeMaintenanceExpired:
+		err = m.onNodeMaintenanceExpired(event.Attributes[storageNameKey], event.Key)
+	case discovery.RollupBackfillCheck:
+		err = m.onRollupBackfillCheck(event.Key)
+	case discovery.DatabasePurgeCheck:
+		err = m.onDatabasePurgeCheck(event.Key)
 	}
 	if err != nil {
 		m.statistics.HandleEventFailure.WithTagValues(eventType, constants.MasterRole).Incr()
@@ -203,6 +245,12 @@ func (m *stateManager) onDatabaseCfgChange(key string, data []byte) error {
 			logger.Error(err))
 		return err
 	}
+	if cfg.IsDeleted() {
+		// dropped database keeps shard assignment(data in storage cluster) until purged
+		m.databases[cfg.Name] = cfg
+		m.scheduleDatabasePurgeCheck(cfg)
+		return nil
+	}
 
 	m.shardAssignment(cfg)
 	return nil
@@ -238,6 +286,80 @@ func (m *stateManager) onDatabaseCfgDelete(key string) error {
 	return nil
 }
 
+// onDatabaseSchemaChange triggers when the metric schema registry of database create/modify,
+// syncs the field level retentions to storage cluster.
+func (m *stateManager) onDatabaseSchemaChange(key string, data []byte) error {
+	m.logger.Info("database schema is changed",
+		logger.String("key", key),
+		logger.String("data", string(data)))
+	schema := &models.DatabaseSchema{}
+	if err := encoding.JSONUnmarshal(data, schema); err != nil {
+		m.logger.Error("database schema is changed, but unmarshal error",
+			logger.Error(err))
+		return err
+	}
+	m.schemas[schema.Database] = schema
+	return m.syncDatabaseOption(schema.Database)
+}
+
+// onDatabaseSchemaDelete triggers when the metric schema registry of database is deletion,
+// removes the field level retentions from storage cluster.
+func (m *stateManager) onDatabaseSchemaDelete(key string) error {
+	m.logger.Info("database schema deleted",
+		logger.String("key", key))
+	name := strings.TrimPrefix(key, constants.GetDatabaseSchemaPath(""))
+	if _, ok := m.schemas[name]; !ok {
+		return nil
+	}
+	delete(m.schemas, name)
+	return m.syncDatabaseOption(name)
+}
+
+// syncDatabaseOption saves the database option into related storage cluster if shard assignment exist.
+func (m *stateManager) syncDatabaseOption(databaseName string) error {
+	databaseCfg, ok := m.databases[databaseName]
+	if !ok {
+		return nil
+	}
+	cluster, ok := m.storages[databaseCfg.Storage]
+	if !ok {
+		return nil
+	}
+	shardAssign, err := m.GetShardAssign(databaseName)
+	if err != nil {
+		if err == statepkg.ErrNotExist {
+			return nil
+		}
+		return err
+	}
+	if err := cluster.SaveDatabaseAssignment(shardAssign, m.databaseOption(databaseCfg)); err != nil {
+		m.logger.Error("sync database option to storage error",
+			logger.String("storage", databaseCfg.Storage),
+			logger.String("database", databaseName),
+			logger.Error(err))
+		return err
+	}
+	return nil
+}
+
+// databaseOption returns the database option synced to storage cluster,
+// which includes the field level retentions/precisions of database schema.
+func (m *stateManager) databaseOption(databaseCfg *models.Database) *option.DatabaseOption {
+	schema, ok := m.schemas[databaseCfg.Name]
+	if !ok || databaseCfg.Option == nil {
+		return databaseCfg.Option
+	}
+	retentions := schema.FieldRetentions()
+	precisions := schema.FieldPrecisions()
+	if len(retentions) == 0 && len(precisions) == 0 {
+		return databaseCfg.Option
+	}
+	opt := *databaseCfg.Option
+	opt.FieldRetentions = retentions
+	opt.FieldPrecisions = precisions
+	return &opt
+}
+
 // onShardAssignmentChange triggers when shard assignment modify.
 func (m *stateManager) onShardAssignmentChange(key string, data []byte) error {
 	m.logger.Info("database's shard assignment is changed",
@@ -290,23 +412,49 @@ func (m *stateManager) onStorageConfigDelete(key string) {
 
 // onStorageNodeStartup triggers when storage node online
 func (m *stateManager) onStorageNodeStartup(storageName, key string, data []byte) error {
-	m.logger.Info("new storage node online in storage cluster",
-		logger.String("storage", storageName),
-		logger.String("key", key),
-		logger.String("data", string(data)))
-
 	node := models.StatefulNode{}
 	if err := json.Unmarshal(data, &node); err != nil {
-		m.logger.Error("new storage node online in storage cluster but unmarshal error", logger.Error(err))
+		m.logger.Error("new storage node online in storage cluster but unmarshal error",
+			logger.String("storage", storageName),
+			logger.String("key", key),
+			logger.Error(err))
 		return err
 	}
 
 	cluster := m.storages[storageName]
 	s := cluster.GetState()
 
+	if liveNode, ok := s.LiveNodes[node.ID]; ok && liveNode.SameRegistration(&node) {
+		// only load of node changed(reported periodically), keep the latest load without syncing state
+		s.NodeOnline(node)
+		return nil
+	}
+	m.logger.Info("new storage node online in storage cluster",
+		logger.String("storage", storageName),
+		logger.String("key", key),
+		logger.String("data", string(data)))
+
 	s.NodeOnline(node)
 
+	if node.MaintenanceUntil > 0 {
+		// node announces graceful restart, defers leader reassignment when it goes offline
+		s.StartMaintenance(node.ID, node.MaintenanceUntil)
+		m.logger.Info("storage node is going to restart",
+			logger.String("storage", storageName),
+			logger.Any("node", node.ID),
+			logger.String("until", timeutil.FormatTimestamp(node.MaintenanceUntil, timeutil.DataTimeFormat2)))
+	} else if _, ok := s.MaintenanceDeadline(node.ID); ok {
+		s.EndMaintenance(node.ID)
+		m.logger.Info("storage node is back from restart",
+			logger.String("storage", storageName),
+			logger.Any("node", node.ID))
+	}
+
 	m.onNodeStartup(s, node)
+	if node.NotReady {
+		// node registration info changed, node not ready, transfer leaders to ready replicas
+		m.onNodeNotReady(s, node.ID)
+	}
 
 	return m.syncState(s)
 }
@@ -330,11 +478,63 @@ func (m *stateManager) onStorageNodeFailure(storageName, key string) error {
 	nodeID := models.NodeID(id)
 	s.NodeOffline(nodeID)
 	// 2. do node offline state change
-	m.onNodeFailure(s, nodeID)
+	if until, ok := s.MaintenanceDeadline(nodeID); ok && timeutil.Now() < until {
+		// node is restarting gracefully, keep the leaders on it until deadline
+		m.onNodeMaintenance(s, nodeID)
+		m.scheduleMaintenanceExpiry(storageName, nodeID, until)
+	} else {
+		s.EndMaintenance(nodeID)
+		m.onNodeFailure(s, nodeID)
+	}
+
+	return m.syncState(s)
+}
 
+// onNodeMaintenanceExpired triggers when the node in maintenance doesn't come back before deadline,
+// does normal node failure handling.
+func (m *stateManager) onNodeMaintenanceExpired(storageName, key string) error {
+	id, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		m.logger.Error("parse maintenance node id err", logger.Error(err))
+		return nil
+	}
+	cluster, ok := m.storages[storageName]
+	if !ok {
+		return nil
+	}
+	s := cluster.GetState()
+	nodeID := models.NodeID(id)
+	until, ok := s.MaintenanceDeadline(nodeID)
+	if !ok {
+		// node is back
+		return nil
+	}
+	if _, live := s.LiveNodes[nodeID]; live || timeutil.Now() < until {
+		// node is back(announces restart again), wait next deadline
+		return nil
+	}
+	m.logger.Warn("storage node doesn't come back before deadline of restart, elect new leaders for shards",
+		logger.String("storage", storageName),
+		logger.Any("node", nodeID))
+	s.EndMaintenance(nodeID)
+	m.onNodeFailure(s, nodeID)
 	return m.syncState(s)
 }
 
+// scheduleMaintenanceExpiry emits maintenance expired event after deadline of restart.
+func (m *stateManager) scheduleMaintenanceExpiry(storageName string, nodeID models.NodeID, until int64) {
+	afterFuncFn(time.Duration(until-timeutil.Now())*time.Millisecond, func() {
+		select {
+		case m.events <- &discovery.Event{
+			Type:       discovery.NodeMaintenanceExpired,
+			Key:        strconv.Itoa(int(nodeID)),
+			Attributes: map[string]string{storageNameKey: storageName},
+		}:
+		case <-m.ctx.Done():
+		}
+	})
+}
+
 // register starts storage state machine which watch storage state change.
 func (m *stateManager) register(cfg *config.StorageCluster) error {
 	name := cfg.Config.Namespace
@@ -442,7 +642,7 @@ func (m *stateManager) shardAssignment(databaseCfg *models.Database) {
 				logger.Error(err))
 			return
 		}
-	case len(shardAssign.Shards) != databaseCfg.NumOfShard:
+	case shardAssign.NumOfBaseShards() != databaseCfg.NumOfShard:
 		m.logger.Info("modify shard assignment starting....",
 			logger.String("storage", databaseCfg.Storage),
 			logger.Any("database", databaseCfg.Name))
@@ -458,6 +658,16 @@ func (m *stateManager) shardAssignment(databaseCfg *models.Database) {
 		m.logger.Info("no data changed, just trigger shard assignment data modify event",
 			logger.String("storage", databaseCfg.Storage),
 			logger.Any("database", databaseCfg.Name))
+		if cluster != nil {
+			// sync database option(compaction policy etc.) to storage cluster
+			if err := cluster.SaveDatabaseAssignment(shardAssign, m.databaseOption(databaseCfg)); err != nil {
+				m.logger.Error("sync database option to storage error",
+					logger.String("storage", databaseCfg.Storage),
+					logger.Any("database", databaseCfg.Name),
+					logger.Error(err))
+				return
+			}
+		}
 		data := encoding.JSONMarshal(shardAssign)
 		if err := m.masterRepo.Put(m.ctx, constants.GetDatabaseAssignPath(shardAssign.Name), data); err != nil {
 			m.logger.Error("trigger shard assignment data modify event",
@@ -466,7 +676,182 @@ func (m *stateManager) shardAssignment(databaseCfg *models.Database) {
 				logger.Error(err))
 			return
 		}
+		if cluster != nil {
+			if err := m.submitRollupBackfill(cluster, databaseCfg, shardAssign); err != nil {
+				m.logger.Error("submit rollup backfill error",
+					logger.String("storage", databaseCfg.Storage),
+					logger.Any("database", databaseCfg.Name),
+					logger.Error(err))
+			}
+		}
+	}
+}
+
+// submitRollupBackfill submits the backfill task of rollup intervals whose history data is incomplete
+// to each shard replica if task not submitted, then schedules the check of backfill progress.
+func (m *stateManager) submitRollupBackfill(
+	cluster StorageCluster,
+	databaseCfg *models.Database,
+	shardAssign *models.ShardAssignment,
+) error {
+	intervals := backfillIntervals(databaseCfg.Option)
+	if len(intervals) == 0 {
+		return nil
+	}
+	// schedules the check first, retry if submit failure
+	m.scheduleRollupBackfillCheck(databaseCfg.Name)
+	for shardID, replica := range shardAssign.Shards {
+		for _, nodeID := range replica.Replicas {
+			task, err := cluster.GetRollupBackfill(databaseCfg.Name, shardID, nodeID)
+			if err != nil && !errors.Is(err, constants.ErrRollupBackfillNotFound) {
+				return err
+			}
+			if task != nil && sameIntervals(task.Intervals, intervals) {
+				// task already submitted
+				continue
+			}
+			if err := cluster.SubmitRollupBackfill(&models.RollupBackfill{
+				ID:        uuid.New().String(),
+				Database:  databaseCfg.Name,
+				ShardID:   shardID,
+				Replica:   nodeID,
+				Intervals: intervals,
+				CreatedAt: timeutil.Now(),
+			}); err != nil {
+				return err
+			}
+		}
 	}
+	return nil
+}
+
+// scheduleRollupBackfillCheck emits rollup backfill check event after check interval,
+// only one check is scheduled for each database.
+func (m *stateManager) scheduleRollupBackfillCheck(databaseName string) {
+	if _, ok := m.backfillChecks[databaseName]; ok {
+		return
+	}
+	m.backfillChecks[databaseName] = struct{}{}
+	afterFuncFn(rollupBackfillCheckInterval, func() {
+		select {
+		case m.events <- &discovery.Event{
+			Type: discovery.RollupBackfillCheck,
+			Key:  databaseName,
+		}:
+		case <-m.ctx.Done():
+		}
+	})
+}
+
+// onRollupBackfillCheck checks the rollup backfill progress of all shard replicas, moves the available time of
+// rollup intervals forward to history based on the slowest replica, resets it after all replicas completed,
+// then saves the database config which is synced to broker and storage.
+func (m *stateManager) onRollupBackfillCheck(databaseName string) error {
+	delete(m.backfillChecks, databaseName)
+	databaseCfg, ok := m.databases[databaseName]
+	if !ok {
+		return nil
+	}
+	cluster, ok := m.storages[databaseCfg.Storage]
+	if !ok {
+		return nil
+	}
+	shardAssign, err := m.GetShardAssign(databaseName)
+	if err != nil {
+		return err
+	}
+	// re-submit the task if replica missing it, then schedules next check if backfill not completed
+	if err := m.submitRollupBackfill(cluster, databaseCfg, shardAssign); err != nil {
+		return err
+	}
+	if len(backfillIntervals(databaseCfg.Option)) == 0 {
+		return nil
+	}
+	completed := true
+	var completedFrom int64
+	for shardID, replica := range shardAssign.Shards {
+		for _, nodeID := range replica.Replicas {
+			progress, err := cluster.GetRollupBackfillProgress(databaseName, shardID, nodeID)
+			if err != nil {
+				return err
+			}
+			if progress.State == models.RollupBackfillCompleted {
+				continue
+			}
+			completed = false
+			if progress.CompletedFrom <= 0 {
+				// replica not backfilled any family
+				completedFrom = math.MaxInt64
+			} else if progress.CompletedFrom > completedFrom {
+				completedFrom = progress.CompletedFrom
+			}
+		}
+	}
+	opt := *databaseCfg.Option
+	opt.Intervals = make(option.Intervals, len(databaseCfg.Option.Intervals))
+	copy(opt.Intervals, databaseCfg.Option.Intervals)
+	changed := false
+	for idx := range opt.Intervals {
+		interval := &opt.Intervals[idx]
+		if interval.AvailableFrom <= 0 {
+			continue
+		}
+		availableFrom := interval.AvailableFrom
+		switch {
+		case completed:
+			availableFrom = 0
+		case completedFrom < math.MaxInt64:
+			// history data is complete from next slot of interval
+			intervalVal := interval.Interval.Int64()
+			if from := timeutil.Truncate(completedFrom+intervalVal-1, intervalVal); from < availableFrom {
+				availableFrom = from
+			}
+		}
+		if availableFrom != interval.AvailableFrom {
+			interval.AvailableFrom = availableFrom
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	newCfg := *databaseCfg
+	newCfg.Option = &opt
+	m.logger.Info("rollup backfill progress of database changed",
+		logger.String("database", databaseName),
+		logger.Any("completed", completed),
+		logger.String("intervals", opt.Intervals.String()))
+	return m.masterRepo.Put(m.ctx, constants.GetDatabaseConfigPath(databaseName), encoding.JSONMarshal(&newCfg))
+}
+
+// backfillIntervals returns the rollup intervals whose history data is being backfilled.
+func backfillIntervals(databaseOption *option.DatabaseOption) (intervals option.Intervals) {
+	if databaseOption == nil {
+		return nil
+	}
+	for _, interval := range databaseOption.Intervals {
+		if interval.AvailableFrom > 0 {
+			intervals = append(intervals, interval)
+		}
+	}
+	return intervals
+}
+
+// sameIntervals checks if the interval values of two interval list are same.
+func sameIntervals(a, b option.Intervals) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	values := make(map[timeutil.Interval]struct{})
+	for _, interval := range a {
+		values[interval.Interval] = struct{}{}
+	}
+	for _, interval := range b {
+		if _, ok := values[interval.Interval]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
 func (m *stateManager) onNodeStartup(state *models.StorageState, node models.StatefulNode) {
@@ -520,6 +905,76 @@ func (m *stateManager) onNodeFailure(state *models.StorageState, nodeID models.N
 	}
 }
 
+// onNodeMaintenance keeps the leaders on the node restarting gracefully, avoids leader churn,
+// except the shards without any live replica, which are marked offline by normal failure handling.
+func (m *stateManager) onNodeMaintenance(state *models.StorageState, nodeID models.NodeID) {
+	leadersOnRestartingNode := state.LeadersOnNode(nodeID)
+	liveNodes := state.LiveNodes
+	for db, shards := range leadersOnRestartingNode {
+		shardAssignment := state.ShardAssignments[db]
+		shardStates := state.ShardStates[db]
+		for _, shardID := range shards {
+			if hasLiveReplica(shardAssignment, liveNodes, shardID) {
+				m.shardLeaderStatistics.DeferredElections.Incr()
+				continue
+			}
+			shardState := shardStates[shardID]
+			shardState.State = models.OfflineShard
+			shardState.Leader = models.NoLeader
+			shardStates[shardID] = shardState
+			m.logger.Warn("shard has no live replica when leader is restarting, mark it offline",
+				logger.String("db", db),
+				logger.Any("shard", shardID),
+				logger.Any("node", nodeID))
+		}
+	}
+}
+
+// hasLiveReplica returns if any replica of shard is alive.
+func hasLiveReplica(shardAssignment *models.ShardAssignment, liveNodes map[models.NodeID]models.StatefulNode,
+	shardID models.ShardID,
+) bool {
+	if shardAssignment == nil {
+		return false
+	}
+	replica, ok := shardAssignment.Shards[shardID]
+	if !ok {
+		return false
+	}
+	for _, nodeID := range replica.Replicas {
+		if _, live := liveNodes[nodeID]; live {
+			return true
+		}
+	}
+	return false
+}
+
+// onNodeNotReady transfers the leaders on not-ready node to the ready replicas,
+// keeps the leader if no ready replica.
+func (m *stateManager) onNodeNotReady(state *models.StorageState, nodeID models.NodeID) {
+	leadersOnNotReadyNode := state.LeadersOnNode(nodeID)
+	liveNodes := state.LiveNodes
+	for db, shards := range leadersOnNotReadyNode {
+		shardAssignment := state.ShardAssignments[db]
+		shardStates := state.ShardStates[db]
+		for _, shardID := range shards {
+			leader, err := m.elector.ElectLeader(shardAssignment, liveNodes, shardID)
+			if err != nil || liveNodes[leader].NotReady {
+				continue
+			}
+			m.shardLeaderStatistics.LeaderElections.Incr()
+			shardState := shardStates[shardID]
+			shardState.Leader = leader
+			shardStates[shardID] = shardState
+			m.logger.Info("transfer shard leader from not-ready node",
+				logger.String("db", shardAssignment.Name),
+				logger.Any("shard", shardID),
+				logger.Any("from", nodeID),
+				logger.Any("leader", leader))
+		}
+	}
+}
+
 // syncState syncs storage state into state repo.
 func (m *stateManager) syncState(state *models.StorageState) error {
 	// TODO add timeout
@@ -577,7 +1032,7 @@ func (m *stateManager) createShardAssignment(
 		return nil, err
 	}
 	// save shard assignment into related storage repo.
-	if err := cluster.SaveDatabaseAssignment(shardAssign, cfg.Option); err != nil {
+	if err := cluster.SaveDatabaseAssignment(shardAssign, m.databaseOption(cfg)); err != nil {
 		return nil, err
 	}
 
@@ -589,10 +1044,10 @@ func (m *stateManager) modifyShardAssignment(
 	shardAssign *models.ShardAssignment,
 ) error {
 	nodes := make(map[models.NodeID]*models.StatefulNode)
-	if len(shardAssign.Shards) > cfg.NumOfShard { // reduce shardAssign's shards
+	if shardAssign.NumOfBaseShards() > cfg.NumOfShard { // reduce shardAssign's shards
 		// TODO implement the reduce shards, is needed?
 		panic("not implemented")
-	} else if len(shardAssign.Shards) < cfg.NumOfShard { // add shardAssign's shards
+	} else if shardAssign.NumOfBaseShards() < cfg.NumOfShard { // add shardAssign's shards
 		liveNodes, err := cluster.GetLiveNodes()
 		if err != nil {
 			return err
@@ -627,12 +1082,184 @@ func (m *stateManager) modifyShardAssignment(
 	}
 
 	// save shard assignment into related storage repo.
-	if err := cluster.SaveDatabaseAssignment(shardAssign, cfg.Option); err != nil {
+	if err := cluster.SaveDatabaseAssignment(shardAssign, m.databaseOption(cfg)); err != nil {
 		return err
 	}
 	return nil
 }
 
+// SplitShard splits the hot shard of database, creates a new shard on other node which takes over half key space
+// of the shard for future writes, historical data stays queryable on the shard.
+// 1) add target shard and split into shard assignment
+// 2) save shard assignment into related storage cluster(storage node creates target shard)
+// 3) brokers switch routing after shard state synced by shard assignment change event
+func (m *stateManager) SplitShard(databaseName string, shardID models.ShardID) (*models.ShardSplit, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	cfg, ok := m.databases[databaseName]
+	if !ok {
+		return nil, constants.ErrDatabaseNotFound
+	}
+	cluster, ok := m.storages[cfg.Storage]
+	if !ok {
+		return nil, constants.ErrNoStorageCluster
+	}
+	shardAssign, err := m.GetShardAssign(databaseName)
+	if err != nil {
+		return nil, err
+	}
+	liveNodes, err := cluster.GetLiveNodes()
+	if err != nil {
+		return nil, err
+	}
+	var nodeIDs []models.NodeID
+	for idx := range liveNodes {
+		nodeIDs = append(nodeIDs, liveNodes[idx].ID)
+	}
+	split, err := SplitShardAssignment(nodeIDs, shardAssign, shardID)
+	if err != nil {
+		return nil, err
+	}
+	split.CreatedAt = timeutil.Now()
+	m.logger.Info("split shard",
+		logger.String("database", databaseName),
+		logger.Any("split", split),
+		logger.Any("replicas", shardAssign.Shards[split.Target].Replicas))
+
+	data := encoding.JSONMarshal(shardAssign)
+	if err := m.masterRepo.Put(m.ctx, constants.GetDatabaseAssignPath(databaseName), data); err != nil {
+		return nil, err
+	}
+	// save shard assignment into related storage repo.
+	if err := cluster.SaveDatabaseAssignment(shardAssign, m.databaseOption(cfg)); err != nil {
+		return nil, err
+	}
+	return split, nil
+}
+
+// AddReplica adds a new replica on storage node for the shard of database, new replica is bootstrapped
+// from the snapshot of shard leader instead of replaying whole write ahead log.
+// 1) submit bootstrap task into related storage cluster(new replica node transfers snapshot from leader)
+// 2) add replica into shard assignment
+// 3) save shard assignment into related storage cluster(leader replicates after snapshot's sequence cut)
+func (m *stateManager) AddReplica(databaseName string, shardID models.ShardID, nodeID models.NodeID) (*models.ReplicaBootstrap, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	cfg, ok := m.databases[databaseName]
+	if !ok {
+		return nil, constants.ErrDatabaseNotFound
+	}
+	cluster, ok := m.storages[cfg.Storage]
+	if !ok {
+		return nil, constants.ErrNoStorageCluster
+	}
+	shardAssign, err := m.GetShardAssign(databaseName)
+	if err != nil {
+		return nil, err
+	}
+	replica, ok := shardAssign.Shards[shardID]
+	if !ok {
+		return nil, constants.ErrShardNotFound
+	}
+	if replica.Contain(nodeID) {
+		return nil, fmt.Errorf("node[%d] is already the replica of shard[%d]", nodeID, shardID)
+	}
+	liveNodes, err := cluster.GetLiveNodes()
+	if err != nil {
+		return nil, err
+	}
+	live := false
+	for idx := range liveNodes {
+		if liveNodes[idx].ID == nodeID {
+			live = true
+			break
+		}
+	}
+	if !live {
+		return nil, fmt.Errorf("node[%d] is not alive", nodeID)
+	}
+	shardState, ok := cluster.GetState().ShardStates[databaseName][shardID]
+	if !ok || shardState.State != models.OnlineShard {
+		return nil, constants.ErrNoLiveReplica
+	}
+	// submit bootstrap task before replica assigned, avoid replicating from leader's write ahead log
+	task := &models.ReplicaBootstrap{
+		ID:        uuid.New().String(),
+		Database:  databaseName,
+		ShardID:   shardID,
+		Leader:    shardState.Leader,
+		Replica:   nodeID,
+		CreatedAt: timeutil.Now(),
+	}
+	if err := cluster.SubmitReplicaBootstrap(task); err != nil {
+		return nil, err
+	}
+	replica.Replicas = append(replica.Replicas, nodeID)
+	m.logger.Info("add shard replica",
+		logger.String("database", databaseName),
+		logger.Any("shardID", shardID),
+		logger.Any("replicas", replica.Replicas),
+		logger.Any("leader", shardState.Leader))
+
+	data := encoding.JSONMarshal(shardAssign)
+	if err := m.masterRepo.Put(m.ctx, constants.GetDatabaseAssignPath(databaseName), data); err != nil {
+		return nil, err
+	}
+	// save shard assignment into related storage repo.
+	if err := cluster.SaveDatabaseAssignment(shardAssign, m.databaseOption(cfg)); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// GetRollupBackfillStatus returns the rollup backfill status of database, includes the progress of each replica.
+func (m *stateManager) GetRollupBackfillStatus(databaseName string) (*models.RollupBackfillStatus, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	databaseCfg, ok := m.databases[databaseName]
+	if !ok {
+		return nil, constants.ErrDatabaseNotFound
+	}
+	cluster, ok := m.storages[databaseCfg.Storage]
+	if !ok {
+		return nil, constants.ErrNoStorageCluster
+	}
+	shardAssign, err := m.GetShardAssign(databaseName)
+	if err != nil {
+		return nil, err
+	}
+	status := &models.RollupBackfillStatus{Database: databaseName}
+	if databaseCfg.Option != nil {
+		status.Intervals = databaseCfg.Option.Intervals
+	}
+	shardIDs := make([]models.ShardID, 0, len(shardAssign.Shards))
+	for shardID := range shardAssign.Shards {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool { return shardIDs[i] < shardIDs[j] })
+	for _, shardID := range shardIDs {
+		for _, nodeID := range shardAssign.Shards[shardID].Replicas {
+			progress, err := cluster.GetRollupBackfillProgress(databaseName, shardID, nodeID)
+			if err != nil {
+				if errors.Is(err, constants.ErrRollupBackfillNotFound) {
+					// no backfill task
+					continue
+				}
+				return nil, err
+			}
+			status.Replicas = append(status.Replicas, models.RollupBackfillReplica{
+				ShardID:  shardID,
+				NodeID:   nodeID,
+				Progress: progress,
+			})
+		}
+	}
+	return status, nil
+}
+
 // GetShardAssign returns shard assignment by database name, return not exist err if it's not exist.
 func (m *stateManager) GetShardAssign(databaseName string) (*models.ShardAssignment, error) {
 	data, err := m.masterRepo.Get(m.ctx, constants.GetDatabaseAssignPath(databaseName))
@@ -707,6 +1334,9 @@ func (m *stateManager) initializeShardState(storage StorageCluster, shardAssignm
 	for shardID, replicas := range shardAssignment.Shards {
 		leader, err := m.elector.ElectLeader(shardAssignment, liveNodes, shardID)
 		shardState := models.ShardState{ID: shardID, Replica: *replicas}
+		if split, ok := shardAssignment.GetSplit(shardID); ok {
+			shardState.Split = split
+		}
 		m.shardLeaderStatistics.LeaderElections.Incr()
 		if err != nil {
 			shardState.State = models.OfflineShard
@@ -725,3 +1355,115 @@ func (m *stateManager) initializeShardState(storage StorageCluster, shardAssignm
 	storageState.ShardAssignments[shardAssignment.Name] = shardAssignment
 	storageState.ShardStates[shardAssignment.Name] = shardStates
 }
+
+// DropDatabase marks database deleted, data of database is kept and can be restored within grace period,
+// the database is purged after grace period, or immediately if grace period is 0.
+func (m *stateManager) DropDatabase(databaseName string, gracePeriod time.Duration) (*models.Database, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	cfg, ok := m.databases[databaseName]
+	if !ok {
+		return nil, constants.ErrDatabaseNotFound
+	}
+	if cfg.IsDeleted() {
+		// already dropped, keeps the original grace period
+		newCfg := *cfg
+		return &newCfg, nil
+	}
+	if gracePeriod <= 0 {
+		if err := m.purgeDatabase(databaseName); err != nil {
+			return nil, err
+		}
+		newCfg := *cfg
+		return &newCfg, nil
+	}
+	now := timeutil.Now()
+	newCfg := *cfg
+	newCfg.Deletion = &models.DatabaseDeletion{
+		DeletedAt: now,
+		PurgeAt:   now + gracePeriod.Milliseconds(),
+	}
+	if err := m.masterRepo.Put(m.ctx, constants.GetDatabaseConfigPath(databaseName), encoding.JSONMarshal(&newCfg)); err != nil {
+		return nil, err
+	}
+	m.logger.Info("database scheduled for deletion",
+		logger.String("database", databaseName),
+		logger.String("purgeAt", timeutil.FormatTimestamp(newCfg.Deletion.PurgeAt, timeutil.DataTimeFormat2)))
+	// update memory state directly, avoid purging restored database before config change event received
+	m.databases[databaseName] = &newCfg
+	result := newCfg
+	return &result, nil
+}
+
+// RestoreDatabase restores the dropped database within grace period.
+func (m *stateManager) RestoreDatabase(databaseName string) (*models.Database, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	cfg, ok := m.databases[databaseName]
+	if !ok {
+		return nil, constants.ErrDatabaseNotFound
+	}
+	if !cfg.IsDeleted() {
+		return nil, constants.ErrDatabaseNotDeleted
+	}
+	if cfg.Deletion.Remaining(timeutil.Now()) <= 0 {
+		return nil, constants.ErrDatabasePurged
+	}
+	newCfg := *cfg
+	newCfg.Deletion = nil
+	if err := m.masterRepo.Put(m.ctx, constants.GetDatabaseConfigPath(databaseName), encoding.JSONMarshal(&newCfg)); err != nil {
+		return nil, err
+	}
+	m.logger.Info("dropped database restored", logger.String("database", databaseName))
+	m.databases[databaseName] = &newCfg
+	result := newCfg
+	return &result, nil
+}
+
+// scheduleDatabasePurgeCheck emits database purge check event after grace period of dropped database,
+// only one check is scheduled for each database.
+func (m *stateManager) scheduleDatabasePurgeCheck(databaseCfg *models.Database) {
+	if _, ok := m.purgeChecks[databaseCfg.Name]; ok {
+		return
+	}
+	m.purgeChecks[databaseCfg.Name] = struct{}{}
+	databaseName := databaseCfg.Name
+	afterFuncFn(databaseCfg.Deletion.Remaining(timeutil.Now()), func() {
+		select {
+		case m.events <- &discovery.Event{
+			Type: discovery.DatabasePurgeCheck,
+			Key:  databaseName,
+		}:
+		case <-m.ctx.Done():
+		}
+	})
+}
+
+// onDatabasePurgeCheck purges the dropped database if its grace period expired,
+// re-schedules the check if database dropped again after restored.
+func (m *stateManager) onDatabasePurgeCheck(databaseName string) error {
+	delete(m.purgeChecks, databaseName)
+	databaseCfg, ok := m.databases[databaseName]
+	if !ok || !databaseCfg.IsDeleted() {
+		// database removed or restored
+		return nil
+	}
+	if databaseCfg.Deletion.Remaining(timeutil.Now()) > 0 {
+		m.scheduleDatabasePurgeCheck(databaseCfg)
+		return nil
+	}
+	m.logger.Info("grace period of dropped database expired, purge database",
+		logger.String("database", databaseName))
+	return m.purgeDatabase(databaseName)
+}
+
+// purgeDatabase removes database config and shard assignment, then storage nodes remove data of database
+// after database assignment dropped from storage cluster(triggered by config deletion event).
+func (m *stateManager) purgeDatabase(databaseName string) error {
+	if err := m.masterRepo.Delete(m.ctx, constants.GetDatabaseConfigPath(databaseName)); err != nil {
+		return err
+	}
+	return m.masterRepo.Delete(m.ctx, constants.GetDatabaseAssignPath(databaseName))
+}
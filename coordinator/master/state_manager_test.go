@@ -27,11 +27,13 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/discovery"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 func TestStateManager_Close(t *testing.T) {
@@ -99,6 +101,130 @@ func TestStateManager_DropDatabase(t *testing.T) {
 	mgr.Close()
 }
 
+func TestStateManager_SoftDropDatabase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		afterFuncFn = time.AfterFunc
+		ctrl.Finish()
+	}()
+
+	var checkFns []func()
+	var delays []time.Duration
+	afterFuncFn = func(d time.Duration, f func()) *time.Timer {
+		delays = append(delays, d)
+		checkFns = append(checkFns, f)
+		return nil
+	}
+	repo := state.NewMockRepository(ctrl)
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	defer mgr.Close()
+	mgr1 := mgr.(*stateManager)
+	cfgPath := constants.GetDatabaseConfigPath("test")
+
+	// database not found
+	_, err := mgr.DropDatabase("test", time.Hour)
+	assert.ErrorIs(t, err, constants.ErrDatabaseNotFound)
+	_, err = mgr.RestoreDatabase("test")
+	assert.ErrorIs(t, err, constants.ErrDatabaseNotFound)
+
+	mgr1.databases["test"] = &models.Database{Name: "test", Storage: "test"}
+	// restore database not dropped
+	_, err = mgr.RestoreDatabase("test")
+	assert.ErrorIs(t, err, constants.ErrDatabaseNotDeleted)
+	// mark deleted failure
+	repo.EXPECT().Put(gomock.Any(), cfgPath, gomock.Any()).Return(fmt.Errorf("err"))
+	_, err = mgr.DropDatabase("test", time.Hour)
+	assert.Error(t, err)
+	assert.False(t, mgr1.databases["test"].IsDeleted())
+	// mark deleted, data is kept within grace period
+	var savedCfg []byte
+	repo.EXPECT().Put(gomock.Any(), cfgPath, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, data []byte) error {
+			savedCfg = data
+			return nil
+		})
+	database, err := mgr.DropDatabase("test", time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, database.IsDeleted())
+	assert.Equal(t, time.Hour, time.Duration(database.Deletion.PurgeAt-database.Deletion.DeletedAt)*time.Millisecond)
+	// drop again, keeps original grace period
+	database2, err := mgr.DropDatabase("test", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, database.Deletion, database2.Deletion)
+
+	// config change event of dropped database schedules purge check, shard assignment is kept
+	mgr1.mutex.Lock()
+	assert.NoError(t, mgr1.onDatabaseCfgChange(cfgPath, savedCfg))
+	assert.NoError(t, mgr1.onDatabaseCfgChange(cfgPath, savedCfg))
+	mgr1.mutex.Unlock()
+	assert.Len(t, checkFns, 1)
+	assert.True(t, delays[0] > 59*time.Minute && delays[0] <= time.Hour)
+
+	// restore within grace period
+	repo.EXPECT().Put(gomock.Any(), cfgPath, gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, data []byte) error {
+			cfg := &models.Database{}
+			assert.NoError(t, encoding.JSONUnmarshal(data, cfg))
+			assert.False(t, cfg.IsDeleted())
+			return nil
+		})
+	database, err = mgr.RestoreDatabase("test")
+	assert.NoError(t, err)
+	assert.False(t, database.IsDeleted())
+	// purge check of restored database does nothing
+	mgr1.mutex.Lock()
+	assert.NoError(t, mgr1.onDatabasePurgeCheck("test"))
+	assert.NoError(t, mgr1.onDatabasePurgeCheck("not-exist"))
+
+	// purge check before grace period expired, re-schedules the check
+	now := timeutil.Now()
+	mgr1.databases["test"] = &models.Database{Name: "test",
+		Deletion: &models.DatabaseDeletion{DeletedAt: now, PurgeAt: now + timeutil.OneMinute}}
+	assert.NoError(t, mgr1.onDatabasePurgeCheck("test"))
+	assert.Len(t, checkFns, 2)
+	// grace period expired, cannot restore, database is purged
+	mgr1.databases["test"] = &models.Database{Name: "test",
+		Deletion: &models.DatabaseDeletion{DeletedAt: now - timeutil.OneHour, PurgeAt: now - 1}}
+	mgr1.mutex.Unlock()
+	_, err = mgr.RestoreDatabase("test")
+	assert.ErrorIs(t, err, constants.ErrDatabasePurged)
+	mgr1.mutex.Lock()
+	repo.EXPECT().Delete(gomock.Any(), cfgPath).Return(fmt.Errorf("err"))
+	assert.Error(t, mgr1.onDatabasePurgeCheck("test"))
+	repo.EXPECT().Delete(gomock.Any(), cfgPath).Return(nil)
+	repo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseAssignPath("test")).Return(nil)
+	assert.NoError(t, mgr1.onDatabasePurgeCheck("test"))
+	mgr1.mutex.Unlock()
+
+	// drop immediately if grace period is 0
+	mgr1.databases["test2"] = &models.Database{Name: "test2"}
+	repo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseConfigPath("test2")).Return(fmt.Errorf("err"))
+	_, err = mgr.DropDatabase("test2", 0)
+	assert.Error(t, err)
+	repo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseConfigPath("test2")).Return(nil)
+	repo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseAssignPath("test2")).Return(nil)
+	database, err = mgr.DropDatabase("test2", 0)
+	assert.NoError(t, err)
+	assert.False(t, database.IsDeleted())
+
+	// purge check event emitted after grace period
+	done := make(chan struct{})
+	mgr1.mutex.Lock()
+	mgr1.databases["test3"] = &models.Database{Name: "test3",
+		Deletion: &models.DatabaseDeletion{DeletedAt: now - timeutil.OneHour, PurgeAt: now - 1}}
+	mgr1.scheduleDatabasePurgeCheck(mgr1.databases["test3"])
+	mgr1.mutex.Unlock()
+	assert.Zero(t, delays[len(delays)-1])
+	repo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseConfigPath("test3")).Return(nil)
+	repo.EXPECT().Delete(gomock.Any(), constants.GetDatabaseAssignPath("test3")).
+		DoAndReturn(func(_ context.Context, _ string) error {
+			close(done)
+			return nil
+		})
+	checkFns[len(checkFns)-1]()
+	<-done
+}
+
 func TestStateManager_NotRunning(t *testing.T) {
 	mgr := NewStateManager(context.TODO(), nil, nil)
 	mgr1 := mgr.(*stateManager)
@@ -282,7 +408,18 @@ func TestStateManager_DatabaseCfg(t *testing.T) {
 		Key:   "/database/test",
 		Value: data,
 	})
-	// case 6: trigger modify event
+	// case 6: sync database option err
+	storage1.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(encoding.JSONMarshal(&models.ShardAssignment{
+		Shards: map[models.ShardID]*models.Replica{1: nil, 2: nil, 3: nil},
+	}), nil)
+	mgr.EmitEvent(&discovery.Event{
+		Type:  discovery.DatabaseConfigChanged,
+		Key:   "/database/test",
+		Value: data,
+	})
+	// case 7: trigger modify event
+	storage1.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).Return(nil)
 	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
 	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(encoding.JSONMarshal(&models.ShardAssignment{
 		Shards: map[models.ShardID]*models.Replica{1: nil, 2: nil, 3: nil},
@@ -454,6 +591,295 @@ func TestStateManager_modifyShardAssign(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestStateManager_SplitShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	storage := NewMockStorageCluster(ctrl)
+	storage.EXPECT().Close().AnyTimes()
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	mgr1 := mgr.(*stateManager)
+	assignData := encoding.JSONMarshal(&models.ShardAssignment{Name: "test",
+		Shards: map[models.ShardID]*models.Replica{0: {Replicas: []models.NodeID{1}}, 1: {Replicas: []models.NodeID{2}}}})
+
+	// database not found
+	_, err := mgr.SplitShard("test", 1)
+	assert.Equal(t, constants.ErrDatabaseNotFound, err)
+	// storage not found
+	mgr1.databases["test"] = &models.Database{Name: "test", Storage: "test"}
+	_, err = mgr.SplitShard("test", 1)
+	assert.Equal(t, constants.ErrNoStorageCluster, err)
+	mgr1.storages["test"] = storage
+	// get shard assignment failure
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	_, err = mgr.SplitShard("test", 1)
+	assert.Error(t, err)
+	// get live nodes failure
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(assignData, nil).AnyTimes()
+	storage.EXPECT().GetLiveNodes().Return(nil, fmt.Errorf("err"))
+	_, err = mgr.SplitShard("test", 1)
+	assert.Error(t, err)
+	storage.EXPECT().GetLiveNodes().Return([]models.StatefulNode{{ID: 1}, {ID: 2}, {ID: 3}}, nil).AnyTimes()
+	// shard not found
+	_, err = mgr.SplitShard("test", 5)
+	assert.Error(t, err)
+	// save shard assignment failure
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	_, err = mgr.SplitShard("test", 1)
+	assert.Error(t, err)
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	storage.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	_, err = mgr.SplitShard("test", 1)
+	assert.Error(t, err)
+	// split ok
+	storage.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(shardAssign *models.ShardAssignment, _ *option.DatabaseOption) error {
+			assert.Equal(t, []models.NodeID{3}, shardAssign.Shards[2].Replicas)
+			assert.Equal(t, 2, shardAssign.NumOfBaseShards())
+			return nil
+		})
+	split, err := mgr.SplitShard("test", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ShardID(1), split.Source)
+	assert.Equal(t, models.ShardID(2), split.Target)
+	assert.True(t, split.CreatedAt > 0)
+}
+
+func TestStateManager_AddReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	storage := NewMockStorageCluster(ctrl)
+	storage.EXPECT().Close().AnyTimes()
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	mgr1 := mgr.(*stateManager)
+	assignData := encoding.JSONMarshal(&models.ShardAssignment{Name: "test",
+		Shards: map[models.ShardID]*models.Replica{0: {Replicas: []models.NodeID{1}}, 1: {Replicas: []models.NodeID{2}}}})
+	storageState := models.NewStorageState("test")
+
+	// database not found
+	_, err := mgr.AddReplica("test", 1, 3)
+	assert.Equal(t, constants.ErrDatabaseNotFound, err)
+	// storage not found
+	mgr1.databases["test"] = &models.Database{Name: "test", Storage: "test"}
+	_, err = mgr.AddReplica("test", 1, 3)
+	assert.Equal(t, constants.ErrNoStorageCluster, err)
+	mgr1.storages["test"] = storage
+	// get shard assignment failure
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	_, err = mgr.AddReplica("test", 1, 3)
+	assert.Error(t, err)
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(assignData, nil).AnyTimes()
+	// shard not found
+	_, err = mgr.AddReplica("test", 5, 3)
+	assert.Equal(t, constants.ErrShardNotFound, err)
+	// node is already replica
+	_, err = mgr.AddReplica("test", 1, 2)
+	assert.Error(t, err)
+	// get live nodes failure
+	storage.EXPECT().GetLiveNodes().Return(nil, fmt.Errorf("err"))
+	_, err = mgr.AddReplica("test", 1, 3)
+	assert.Error(t, err)
+	storage.EXPECT().GetLiveNodes().Return([]models.StatefulNode{{ID: 1}, {ID: 2}, {ID: 3}}, nil).AnyTimes()
+	// node not alive
+	_, err = mgr.AddReplica("test", 1, 4)
+	assert.Error(t, err)
+	// shard leader not found
+	storage.EXPECT().GetState().Return(storageState).AnyTimes()
+	_, err = mgr.AddReplica("test", 1, 3)
+	assert.Equal(t, constants.ErrNoLiveReplica, err)
+	storageState.ShardStates["test"] = map[models.ShardID]models.ShardState{
+		1: {ID: 1, State: models.OnlineShard, Leader: 2},
+	}
+	// submit task failure
+	storage.EXPECT().SubmitReplicaBootstrap(gomock.Any()).Return(fmt.Errorf("err"))
+	_, err = mgr.AddReplica("test", 1, 3)
+	assert.Error(t, err)
+	storage.EXPECT().SubmitReplicaBootstrap(gomock.Any()).Return(nil).AnyTimes()
+	// save shard assignment failure
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	_, err = mgr.AddReplica("test", 1, 3)
+	assert.Error(t, err)
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	storage.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	_, err = mgr.AddReplica("test", 1, 3)
+	assert.Error(t, err)
+	// add ok
+	storage.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(shardAssign *models.ShardAssignment, _ *option.DatabaseOption) error {
+			assert.Equal(t, []models.NodeID{2, 3}, shardAssign.Shards[1].Replicas)
+			return nil
+		})
+	task, err := mgr.AddReplica("test", 1, 3)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, task.ID)
+	assert.Equal(t, models.NodeID(2), task.Leader)
+	assert.Equal(t, models.NodeID(3), task.Replica)
+}
+
+func TestStateManager_RollupBackfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		afterFuncFn = time.AfterFunc
+		ctrl.Finish()
+	}()
+
+	var checkFns []func()
+	afterFuncFn = func(d time.Duration, f func()) *time.Timer {
+		assert.Equal(t, rollupBackfillCheckInterval, d)
+		checkFns = append(checkFns, f)
+		return nil
+	}
+	repo := state.NewMockRepository(ctrl)
+	storage := NewMockStorageCluster(ctrl)
+	storage.EXPECT().Close().AnyTimes()
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	defer mgr.Close()
+	mgr1 := mgr.(*stateManager)
+	assign := &models.ShardAssignment{Name: "test",
+		Shards: map[models.ShardID]*models.Replica{1: {Replicas: []models.NodeID{1, 2}}}}
+	repo.EXPECT().Get(gomock.Any(), constants.GetDatabaseAssignPath("test")).
+		Return(encoding.JSONMarshal(assign), nil).AnyTimes()
+	minute := option.Interval{Interval: timeutil.Interval(timeutil.OneMinute)}
+	hour := option.Interval{Interval: timeutil.Interval(timeutil.OneHour), AvailableFrom: 10 * timeutil.OneHour}
+	cfg := &models.Database{Name: "test", Storage: "test",
+		Option: &option.DatabaseOption{Intervals: option.Intervals{minute, hour}}}
+
+	mgr1.mutex.Lock()
+	mgr1.storages["test"] = storage
+	// no backfill intervals
+	mgr1.databases["test"] = &models.Database{Name: "test", Storage: "test",
+		Option: &option.DatabaseOption{Intervals: option.Intervals{minute}}}
+	assert.NoError(t, mgr1.onRollupBackfillCheck("test"))
+	assert.Empty(t, checkFns)
+
+	// submit task failure, retry in next check
+	mgr1.databases["test"] = cfg
+	storage.EXPECT().GetRollupBackfill("test", models.ShardID(1), models.NodeID(1)).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, mgr1.submitRollupBackfill(storage, cfg, assign))
+	assert.Len(t, checkFns, 1)
+	// check is scheduled only once
+	storage.EXPECT().GetRollupBackfill("test", models.ShardID(1), models.NodeID(1)).
+		Return(nil, constants.ErrRollupBackfillNotFound)
+	storage.EXPECT().SubmitRollupBackfill(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, mgr1.submitRollupBackfill(storage, cfg, assign))
+	assert.Len(t, checkFns, 1)
+	mgr1.mutex.Unlock()
+
+	// check emits event, submits task for each replica, skips replica which task submitted
+	storage.EXPECT().GetRollupBackfill("test", models.ShardID(1), models.NodeID(1)).
+		Return(nil, constants.ErrRollupBackfillNotFound)
+	storage.EXPECT().SubmitRollupBackfill(gomock.Any()).
+		DoAndReturn(func(task *models.RollupBackfill) error {
+			assert.Equal(t, models.NodeID(1), task.Replica)
+			assert.Equal(t, option.Intervals{hour}, task.Intervals)
+			return nil
+		})
+	storage.EXPECT().GetRollupBackfill("test", models.ShardID(1), models.NodeID(2)).
+		Return(&models.RollupBackfill{Intervals: option.Intervals{{Interval: hour.Interval}}}, nil)
+	// replica 1 backfilled families after 5h, replica 2 backfilled families after 8h
+	storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(1)).
+		Return(&models.RollupBackfillProgress{State: models.RollupBackfillRunning, CompletedFrom: 5 * timeutil.OneHour}, nil)
+	storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(2)).
+		Return(&models.RollupBackfillProgress{State: models.RollupBackfillRunning, CompletedFrom: 8*timeutil.OneHour - 1}, nil)
+	done := make(chan struct{})
+	repo.EXPECT().Put(gomock.Any(), constants.GetDatabaseConfigPath("test"), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, data []byte) error {
+			database := &models.Database{}
+			assert.NoError(t, encoding.JSONUnmarshal(data, database))
+			assert.Equal(t, 8*timeutil.OneHour, database.Option.Intervals[1].AvailableFrom)
+			close(done)
+			return nil
+		})
+	checkFns[0]()
+	<-done
+	time.Sleep(10 * time.Millisecond) // wait event handled
+	assert.Len(t, checkFns, 2)
+
+	mgr1.mutex.Lock()
+	defer mgr1.mutex.Unlock()
+	storage.EXPECT().GetRollupBackfill(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&models.RollupBackfill{Intervals: option.Intervals{hour}}, nil).AnyTimes()
+	// progress not changed
+	storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(1)).
+		Return(&models.RollupBackfillProgress{State: models.RollupBackfillPending}, nil)
+	storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(2)).
+		Return(&models.RollupBackfillProgress{State: models.RollupBackfillCompleted}, nil)
+	assert.NoError(t, mgr1.onRollupBackfillCheck("test"))
+	// get progress failure
+	storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(1)).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, mgr1.onRollupBackfillCheck("test"))
+	// all replicas completed
+	storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), gomock.Any()).
+		Return(&models.RollupBackfillProgress{State: models.RollupBackfillCompleted}, nil).Times(2)
+	repo.EXPECT().Put(gomock.Any(), constants.GetDatabaseConfigPath("test"), gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, data []byte) error {
+			database := &models.Database{}
+			assert.NoError(t, encoding.JSONUnmarshal(data, database))
+			assert.Zero(t, database.Option.Intervals[1].AvailableFrom)
+			return nil
+		})
+	assert.NoError(t, mgr1.onRollupBackfillCheck("test"))
+	// database/storage not found
+	assert.NoError(t, mgr1.onRollupBackfillCheck("not_exist"))
+	mgr1.databases["test2"] = &models.Database{Name: "test2", Storage: "test2"}
+	assert.NoError(t, mgr1.onRollupBackfillCheck("test2"))
+}
+
+func TestStateManager_GetRollupBackfillStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	storage := NewMockStorageCluster(ctrl)
+	storage.EXPECT().Close().AnyTimes()
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	defer mgr.Close()
+	mgr1 := mgr.(*stateManager)
+	assignData := encoding.JSONMarshal(&models.ShardAssignment{Name: "test",
+		Shards: map[models.ShardID]*models.Replica{2: {Replicas: []models.NodeID{1}}, 1: {Replicas: []models.NodeID{2, 3}}}})
+
+	// database not found
+	_, err := mgr.GetRollupBackfillStatus("test")
+	assert.Equal(t, constants.ErrDatabaseNotFound, err)
+	// storage not found
+	intervals := option.Intervals{{Interval: timeutil.Interval(timeutil.OneHour), AvailableFrom: 10}}
+	mgr1.databases["test"] = &models.Database{Name: "test", Storage: "test",
+		Option: &option.DatabaseOption{Intervals: intervals}}
+	_, err = mgr.GetRollupBackfillStatus("test")
+	assert.Equal(t, constants.ErrNoStorageCluster, err)
+	mgr1.storages["test"] = storage
+	// get shard assignment failure
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	_, err = mgr.GetRollupBackfillStatus("test")
+	assert.Error(t, err)
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(assignData, nil).AnyTimes()
+	// get progress failure
+	storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(2)).Return(nil, fmt.Errorf("err"))
+	_, err = mgr.GetRollupBackfillStatus("test")
+	assert.Error(t, err)
+	// get status ok, ignore replica without task
+	progress := &models.RollupBackfillProgress{TaskID: "task", State: models.RollupBackfillRunning}
+	gomock.InOrder(
+		storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(2)).Return(progress, nil),
+		storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(1), models.NodeID(3)).
+			Return(nil, constants.ErrRollupBackfillNotFound),
+		storage.EXPECT().GetRollupBackfillProgress("test", models.ShardID(2), models.NodeID(1)).Return(progress, nil),
+	)
+	status, err := mgr.GetRollupBackfillStatus("test")
+	assert.NoError(t, err)
+	assert.Equal(t, &models.RollupBackfillStatus{
+		Database:  "test",
+		Intervals: intervals,
+		Replicas: []models.RollupBackfillReplica{
+			{ShardID: 1, NodeID: 2, Progress: progress},
+			{ShardID: 2, NodeID: 1, Progress: progress},
+		},
+	}, status)
+}
+
 func TestStateManager_StorageNodeStartup(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -522,6 +948,174 @@ func TestStateManager_StorageNodeStartup(t *testing.T) {
 	mgr.Close()
 }
 
+func TestStateManager_StorageNodeNotReady(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	storage := NewMockStorageCluster(ctrl)
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	mgr1 := mgr.(*stateManager)
+	mgr1.storages["test"] = storage
+	shardAssignment := &models.ShardAssignment{
+		Name: "test",
+		Shards: map[models.ShardID]*models.Replica{
+			1: {Replicas: []models.NodeID{1, 2}},
+			2: {Replicas: []models.NodeID{1}},
+		},
+	}
+	storageState := &models.StorageState{
+		Name:      "test",
+		LiveNodes: map[models.NodeID]models.StatefulNode{2: {ID: 2}},
+		ShardStates: map[string]map[models.ShardID]models.ShardState{"test": {
+			1: {ID: 1, State: models.OnlineShard, Leader: 1},
+			2: {ID: 2, State: models.OnlineShard, Leader: 1},
+		}},
+		ShardAssignments: map[string]*models.ShardAssignment{"test": shardAssignment},
+	}
+	storage.EXPECT().GetState().Return(storageState)
+	err := mgr1.onStorageNodeStartup("test", "/test/1", []byte(`{"id":1,"notReady":true}`))
+	assert.NoError(t, err)
+	// transfer leader to ready replica
+	assert.Equal(t, models.NodeID(2), storageState.ShardStates["test"][1].Leader)
+	// keep leader if no ready replica
+	assert.Equal(t, models.NodeID(1), storageState.ShardStates["test"][2].Leader)
+	assert.Equal(t, models.OnlineShard, storageState.ShardStates["test"][2].State)
+}
+
+func TestStateManager_StorageNodeLoad(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	storage := NewMockStorageCluster(ctrl)
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	mgr1 := mgr.(*stateManager)
+	mgr1.storages["test"] = storage
+	storageState := models.NewStorageState("test")
+	storage.EXPECT().GetState().Return(storageState).AnyTimes()
+
+	// node online, sync state
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, mgr1.onStorageNodeStartup("test", "/test/1", []byte(`{"id":1}`)))
+	assert.Nil(t, storageState.LiveNodes[1].Load)
+	// only load changed, keep latest load without syncing state
+	assert.NoError(t, mgr1.onStorageNodeStartup("test", "/test/1",
+		[]byte(`{"id":1,"load":{"memDBTotalSize":100,"familiesNeedFlush":2}}`)))
+	load := storageState.LiveNodes[1].Load
+	assert.Equal(t, int64(100), load.MemDBTotalSize)
+	assert.Equal(t, 2, load.FamiliesNeedFlush)
+	// registration info changed with load, sync state
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, mgr1.onStorageNodeStartup("test", "/test/1",
+		[]byte(`{"id":1,"notReady":true,"load":{"memDBTotalSize":200}}`)))
+	assert.Equal(t, int64(200), storageState.LiveNodes[1].Load.MemDBTotalSize)
+}
+
+func TestStateManager_StorageNodeMaintenance(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		afterFuncFn = time.AfterFunc
+		ctrl.Finish()
+	}()
+
+	var expiryFn func()
+	var expiryDelay time.Duration
+	afterFuncFn = func(d time.Duration, f func()) *time.Timer {
+		expiryDelay = d
+		expiryFn = f
+		return nil
+	}
+	repo := state.NewMockRepository(ctrl)
+	repo.EXPECT().Put(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	storage := NewMockStorageCluster(ctrl)
+	storage.EXPECT().Close().AnyTimes()
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	defer mgr.Close()
+	mgr1 := mgr.(*stateManager)
+	storageState := &models.StorageState{
+		Name:      "test",
+		LiveNodes: map[models.NodeID]models.StatefulNode{2: {ID: 2}},
+		ShardStates: map[string]map[models.ShardID]models.ShardState{"test": {
+			1: {ID: 1, State: models.OnlineShard, Leader: 1},
+			2: {ID: 2, State: models.OnlineShard, Leader: 1},
+		}},
+		ShardAssignments: map[string]*models.ShardAssignment{"test": {
+			Name: "test",
+			Shards: map[models.ShardID]*models.Replica{
+				1: {Replicas: []models.NodeID{1, 2}},
+				2: {Replicas: []models.NodeID{1}},
+			},
+		}},
+	}
+	storage.EXPECT().GetState().Return(storageState).AnyTimes()
+	mgr1.mutex.Lock()
+	mgr1.storages["test"] = storage
+	mgr1.mutex.Unlock()
+	announce := func() {
+		until := timeutil.Now() + time.Minute.Milliseconds()
+		assert.NoError(t, mgr1.onStorageNodeStartup("test", "/test/1",
+			[]byte(fmt.Sprintf(`{"id":1,"maintenanceUntil":%d}`, until))))
+	}
+
+	mgr1.mutex.Lock()
+	// case 1: node announces restart, then goes offline
+	announce()
+	_, ok := storageState.MaintenanceDeadline(1)
+	assert.True(t, ok)
+	assert.NoError(t, mgr1.onStorageNodeFailure("test", "/test/1"))
+	_, ok = storageState.LiveNodes[1]
+	assert.False(t, ok)
+	// keep leader if shard has live replica
+	assert.Equal(t, models.ShardState{ID: 1, State: models.OnlineShard, Leader: 1}, storageState.ShardStates["test"][1])
+	// mark shard offline if no live replica
+	assert.Equal(t, models.ShardState{ID: 2, State: models.OfflineShard, Leader: models.NoLeader},
+		storageState.ShardStates["test"][2])
+	assert.True(t, expiryDelay > 0 && expiryDelay <= time.Minute)
+
+	// case 2: node is back before deadline
+	assert.NoError(t, mgr1.onStorageNodeStartup("test", "/test/1", []byte(`{"id":1}`)))
+	_, ok = storageState.MaintenanceDeadline(1)
+	assert.False(t, ok)
+	assert.Equal(t, models.NodeID(1), storageState.ShardStates["test"][1].Leader)
+	assert.Equal(t, models.ShardState{ID: 2, State: models.OnlineShard, Leader: 1}, storageState.ShardStates["test"][2])
+	// expired event ignored
+	assert.NoError(t, mgr1.onNodeMaintenanceExpired("test", "1"))
+	assert.Equal(t, models.NodeID(1), storageState.ShardStates["test"][1].Leader)
+
+	// case 3: deadline not reached
+	announce()
+	assert.NoError(t, mgr1.onStorageNodeFailure("test", "/test/1"))
+	assert.NoError(t, mgr1.onNodeMaintenanceExpired("test", "1"))
+	assert.Equal(t, models.NodeID(1), storageState.ShardStates["test"][1].Leader)
+
+	// case 4: bad expired event
+	assert.NoError(t, mgr1.onNodeMaintenanceExpired("test", "a"))
+	assert.NoError(t, mgr1.onNodeMaintenanceExpired("not_exist", "1"))
+
+	// case 5: node doesn't come back before deadline, timer expiry
+	storageState.StartMaintenance(1, timeutil.Now()-1)
+	mgr1.mutex.Unlock()
+	expiryFn()
+	time.Sleep(100 * time.Millisecond)
+	mgr1.mutex.Lock()
+	_, ok = storageState.MaintenanceDeadline(1)
+	assert.False(t, ok)
+	// elect new leader for shard
+	assert.Equal(t, models.NodeID(2), storageState.ShardStates["test"][1].Leader)
+
+	// case 6: node goes offline after deadline, normal failure handling
+	storageState.NodeOnline(models.StatefulNode{ID: 1})
+	storageState.ShardStates["test"][1] = models.ShardState{ID: 1, State: models.OnlineShard, Leader: 1}
+	storageState.StartMaintenance(1, timeutil.Now()-1)
+	assert.NoError(t, mgr1.onStorageNodeFailure("test", "/test/1"))
+	_, ok = storageState.MaintenanceDeadline(1)
+	assert.False(t, ok)
+	assert.Equal(t, models.NodeID(2), storageState.ShardStates["test"][1].Leader)
+	mgr1.mutex.Unlock()
+}
+
 func TestStateManager_StorageNodeFailure(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -591,3 +1185,71 @@ func TestStateManager_StorageNodeFailure(t *testing.T) {
 	mgr1.mutex.Unlock()
 	mgr.Close()
 }
+
+func TestStateManager_DatabaseSchema(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	storage := NewMockStorageCluster(ctrl)
+	mgr := NewStateManager(context.TODO(), repo, nil)
+	mgr1 := mgr.(*stateManager)
+	defer mgr.Close()
+	schema := &models.DatabaseSchema{Database: "test", Metrics: []models.MetricSchema{
+		{Name: "cpu", Precision: 3, Fields: []models.FieldSchema{{Name: "debug", Type: "sum", Retention: "7d"}}},
+	}}
+
+	// case 1: unmarshal schema err
+	mgr1.processEvent(&discovery.Event{Type: discovery.DatabaseSchemaChanged, Key: "/database/schema/test", Value: []byte("value")})
+	assert.Empty(t, mgr1.schemas)
+	// case 2: database not found
+	mgr1.processEvent(&discovery.Event{
+		Type: discovery.DatabaseSchemaChanged, Key: "/database/schema/test", Value: encoding.JSONMarshal(schema),
+	})
+	assert.Len(t, mgr1.schemas, 1)
+
+	cfg := &models.Database{Name: "test", Storage: "test", Option: &option.DatabaseOption{Ahead: "1h"}}
+	mgr1.databases["test"] = cfg
+	mgr1.storages["test"] = storage
+	// case 3: get shard assignment err
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, mgr1.syncDatabaseOption("test"))
+	// case 4: shard assignment not exist
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(nil, state.ErrNotExist)
+	assert.NoError(t, mgr1.syncDatabaseOption("test"))
+	// case 5: sync field retentions
+	shardAssign := &models.ShardAssignment{Name: "test"}
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(encoding.JSONMarshal(shardAssign), nil)
+	storage.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ *models.ShardAssignment, opt *option.DatabaseOption) error {
+			assert.Equal(t, "1h", opt.Ahead)
+			assert.Equal(t, []option.FieldRetention{
+				{Namespace: "default-ns", Metric: "cpu", Field: "debug", Retention: "7d"},
+			}, opt.FieldRetentions)
+			assert.Equal(t, []option.FieldPrecision{
+				{Namespace: "default-ns", Metric: "cpu", Precision: 3},
+			}, opt.FieldPrecisions)
+			return nil
+		})
+	mgr1.processEvent(&discovery.Event{
+		Type: discovery.DatabaseSchemaChanged, Key: "/database/schema/test", Value: encoding.JSONMarshal(schema),
+	})
+	assert.Empty(t, cfg.Option.FieldRetentions)
+	assert.Empty(t, cfg.Option.FieldPrecisions)
+	// case 6: sync option err
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(encoding.JSONMarshal(shardAssign), nil)
+	storage.EXPECT().SaveDatabaseAssignment(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, mgr1.syncDatabaseOption("test"))
+	// case 7: delete schema, remove field retentions
+	repo.EXPECT().Get(gomock.Any(), gomock.Any()).Return(encoding.JSONMarshal(shardAssign), nil)
+	storage.EXPECT().SaveDatabaseAssignment(gomock.Any(), cfg.Option).Return(nil)
+	mgr1.processEvent(&discovery.Event{Type: discovery.DatabaseSchemaDeletion, Key: "/database/schema/test"})
+	assert.Empty(t, mgr1.schemas)
+	// case 8: delete schema not exist
+	mgr1.processEvent(&discovery.Event{Type: discovery.DatabaseSchemaDeletion, Key: "/database/schema/test"})
+	// case 9: storage not found
+	delete(mgr1.storages, "test")
+	assert.NoError(t, mgr1.syncDatabaseOption("test"))
+	storage.EXPECT().Close()
+	mgr1.storages["test"] = storage
+}
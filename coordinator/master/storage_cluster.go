@@ -20,6 +20,7 @@ package master
 import (
 	"context"
 	"encoding/json"
+	"errors"
 
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
@@ -48,6 +49,28 @@ type StorageCluster interface {
 	GetLiveNodes() ([]models.StatefulNode, error)
 	// FlushDatabase submits the coordinator task for flushing memory database by name
 	FlushDatabase(databaseName string) error
+	// PauseFlush submits the flush pause task of database, storage nodes pause flushing of the database
+	// until the task is deleted.
+	PauseFlush(pause *models.FlushPause) error
+	// ResumeFlush deletes the flush pause task of database, storage nodes resume flushing of the database.
+	ResumeFlush(databaseName string) error
+	// SubmitConsistencyCheck submits the consistency check task of database, storage nodes compute
+	// the digests of shard replicas, then report them into storage state repo.
+	SubmitConsistencyCheck(check *models.ConsistencyCheck) error
+	// GetConsistencyReport returns the report of latest consistency check for database.
+	GetConsistencyReport(databaseName string) (*models.ConsistencyReport, error)
+	// SubmitReplicaBootstrap submits the bootstrap task of new shard replica, new replica node transfers
+	// the snapshot from leader, then reports the progress into storage state repo.
+	SubmitReplicaBootstrap(task *models.ReplicaBootstrap) error
+	// GetReplicaBootstrapProgress returns the bootstrap progress of shard replica on storage node.
+	GetReplicaBootstrapProgress(databaseName string, shardID models.ShardID, nodeID models.NodeID) (*models.ReplicaBootstrapProgress, error)
+	// SubmitRollupBackfill submits the rollup backfill task of shard replica on storage node, storage node
+	// rolls up the history data into added rollup intervals, then reports the progress into storage state repo.
+	SubmitRollupBackfill(task *models.RollupBackfill) error
+	// GetRollupBackfill returns the rollup backfill task of shard replica on storage node.
+	GetRollupBackfill(databaseName string, shardID models.ShardID, nodeID models.NodeID) (*models.RollupBackfill, error)
+	// GetRollupBackfillProgress returns the rollup backfill progress of shard replica on storage node.
+	GetRollupBackfillProgress(databaseName string, shardID models.ShardID, nodeID models.NodeID) (*models.RollupBackfillProgress, error)
 	// SaveDatabaseAssignment saves database assignment in storage state repo.
 	SaveDatabaseAssignment(
 		shardAssign *models.ShardAssignment,
@@ -149,6 +172,207 @@ func (c *storageCluster) FlushDatabase(_ string) error {
 	panic("need impl")
 }
 
+// PauseFlush submits the flush pause task of database, storage nodes pause flushing of the database
+// until the task is deleted.
+func (c *storageCluster) PauseFlush(pause *models.FlushPause) error {
+	if err := c.storageRepo.Put(c.ctx, constants.GetFlushPausePath(pause.Database), encoding.JSONMarshal(pause)); err != nil {
+		return err
+	}
+	c.logger.Info("pause flush of database successfully",
+		logger.String("storage", c.cfg.Config.Namespace),
+		logger.String("database", pause.Database))
+	return nil
+}
+
+// ResumeFlush deletes the flush pause task of database, storage nodes resume flushing of the database.
+func (c *storageCluster) ResumeFlush(databaseName string) error {
+	if err := c.storageRepo.Delete(c.ctx, constants.GetFlushPausePath(databaseName)); err != nil {
+		return err
+	}
+	c.logger.Info("resume flush of database successfully",
+		logger.String("storage", c.cfg.Config.Namespace),
+		logger.String("database", databaseName))
+	return nil
+}
+
+// SubmitConsistencyCheck submits the consistency check task of database, storage nodes compute
+// the digests of shard replicas, then report them into storage state repo.
+func (c *storageCluster) SubmitConsistencyCheck(check *models.ConsistencyCheck) error {
+	if err := c.storageRepo.Put(c.ctx, constants.GetConsistencyCheckPath(check.Database), encoding.JSONMarshal(check)); err != nil {
+		return err
+	}
+	c.logger.Info("submit consistency check successfully",
+		logger.String("storage", c.cfg.Config.Namespace),
+		logger.String("database", check.Database),
+		logger.String("check", check.ID))
+	return nil
+}
+
+// SubmitReplicaBootstrap submits the bootstrap task of new shard replica, new replica node transfers
+// the snapshot from leader, then reports the progress into storage state repo.
+func (c *storageCluster) SubmitReplicaBootstrap(task *models.ReplicaBootstrap) error {
+	path := constants.GetReplicaBootstrapPath(task.Database, task.ShardID.Int(), int(task.Replica))
+	if err := c.storageRepo.Put(c.ctx, path, encoding.JSONMarshal(task)); err != nil {
+		return err
+	}
+	c.logger.Info("submit replica bootstrap successfully",
+		logger.String("storage", c.cfg.Config.Namespace),
+		logger.String("database", task.Database),
+		logger.Any("shardID", task.ShardID),
+		logger.Any("replica", task.Replica),
+		logger.String("task", task.ID))
+	return nil
+}
+
+// GetReplicaBootstrapProgress returns the bootstrap progress of shard replica on storage node,
+// returns pending progress if storage node not reported progress of the task.
+func (c *storageCluster) GetReplicaBootstrapProgress(
+	databaseName string,
+	shardID models.ShardID,
+	nodeID models.NodeID,
+) (*models.ReplicaBootstrapProgress, error) {
+	data, err := c.storageRepo.Get(c.ctx, constants.GetReplicaBootstrapPath(databaseName, shardID.Int(), int(nodeID)))
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return nil, constants.ErrReplicaBootstrapNotFound
+		}
+		return nil, err
+	}
+	task := &models.ReplicaBootstrap{}
+	if err = encoding.JSONUnmarshal(data, task); err != nil {
+		return nil, err
+	}
+	pending := &models.ReplicaBootstrapProgress{TaskID: task.ID, State: models.ReplicaBootstrapPending}
+	data, err = c.storageRepo.Get(c.ctx, constants.GetReplicaBootstrapProgressPath(databaseName, shardID.Int(), int(nodeID)))
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return pending, nil
+		}
+		return nil, err
+	}
+	progress := &models.ReplicaBootstrapProgress{}
+	if err = encoding.JSONUnmarshal(data, progress); err != nil {
+		return nil, err
+	}
+	if progress.TaskID != task.ID {
+		// progress of previous task
+		return pending, nil
+	}
+	return progress, nil
+}
+
+// SubmitRollupBackfill submits the rollup backfill task of shard replica on storage node, storage node
+// rolls up the history data into added rollup intervals, then reports the progress into storage state repo.
+func (c *storageCluster) SubmitRollupBackfill(task *models.RollupBackfill) error {
+	path := constants.GetRollupBackfillPath(task.Database, task.ShardID.Int(), int(task.Replica))
+	if err := c.storageRepo.Put(c.ctx, path, encoding.JSONMarshal(task)); err != nil {
+		return err
+	}
+	c.logger.Info("submit rollup backfill successfully",
+		logger.String("storage", c.cfg.Config.Namespace),
+		logger.String("database", task.Database),
+		logger.Any("shardID", task.ShardID),
+		logger.Any("node", task.Replica),
+		logger.String("intervals", task.Intervals.String()),
+		logger.String("task", task.ID))
+	return nil
+}
+
+// GetRollupBackfill returns the rollup backfill task of shard replica on storage node.
+func (c *storageCluster) GetRollupBackfill(
+	databaseName string,
+	shardID models.ShardID,
+	nodeID models.NodeID,
+) (*models.RollupBackfill, error) {
+	data, err := c.storageRepo.Get(c.ctx, constants.GetRollupBackfillPath(databaseName, shardID.Int(), int(nodeID)))
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return nil, constants.ErrRollupBackfillNotFound
+		}
+		return nil, err
+	}
+	task := &models.RollupBackfill{}
+	if err = encoding.JSONUnmarshal(data, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// GetRollupBackfillProgress returns the rollup backfill progress of shard replica on storage node,
+// returns pending progress if storage node not reported progress of the task.
+func (c *storageCluster) GetRollupBackfillProgress(
+	databaseName string,
+	shardID models.ShardID,
+	nodeID models.NodeID,
+) (*models.RollupBackfillProgress, error) {
+	task, err := c.GetRollupBackfill(databaseName, shardID, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	pending := &models.RollupBackfillProgress{TaskID: task.ID, State: models.RollupBackfillPending}
+	data, err := c.storageRepo.Get(c.ctx, constants.GetRollupBackfillProgressPath(databaseName, shardID.Int(), int(nodeID)))
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return pending, nil
+		}
+		return nil, err
+	}
+	progress := &models.RollupBackfillProgress{}
+	if err = encoding.JSONUnmarshal(data, progress); err != nil {
+		return nil, err
+	}
+	if progress.TaskID != task.ID {
+		// progress of previous task
+		return pending, nil
+	}
+	return progress, nil
+}
+
+// GetConsistencyReport returns the report of latest consistency check for database.
+func (c *storageCluster) GetConsistencyReport(databaseName string) (*models.ConsistencyReport, error) {
+	data, err := c.storageRepo.Get(c.ctx, constants.GetConsistencyCheckPath(databaseName))
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return nil, constants.ErrConsistencyCheckNotFound
+		}
+		return nil, err
+	}
+	check := &models.ConsistencyCheck{}
+	if err = encoding.JSONUnmarshal(data, check); err != nil {
+		return nil, err
+	}
+	data, err = c.storageRepo.Get(c.ctx, constants.GetDatabaseAssignPath(databaseName))
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return nil, constants.ErrDatabaseNotExist
+		}
+		return nil, err
+	}
+	assignment := &models.DatabaseAssignment{}
+	if err = encoding.JSONUnmarshal(data, assignment); err != nil {
+		return nil, err
+	}
+	if assignment.ShardAssignment == nil {
+		return nil, constants.ErrDatabaseNotExist
+	}
+	kvs, err := c.storageRepo.List(c.ctx, constants.GetConsistencyDigestsPath(databaseName))
+	if err != nil {
+		return nil, err
+	}
+	digests := make([]*models.ReplicaDigest, 0, len(kvs))
+	for _, kv := range kvs {
+		digest := &models.ReplicaDigest{}
+		if err = encoding.JSONUnmarshal(kv.Value, digest); err != nil {
+			c.logger.Warn("unmarshal digest of shard replica failure, ignore it",
+				logger.String("storage", c.cfg.Config.Namespace),
+				logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		digests = append(digests, digest)
+	}
+	return models.BuildConsistencyReport(check, assignment.ShardAssignment, digests), nil
+}
+
 // SaveDatabaseAssignment saves database assignment in storage state repo.
 func (c *storageCluster) SaveDatabaseAssignment(
 	shardAssign *models.ShardAssignment,
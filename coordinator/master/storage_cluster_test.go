@@ -19,6 +19,7 @@ package master
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -26,6 +27,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/discovery"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
@@ -243,3 +245,405 @@ func TestStorageCluster_DropDatabaseAssignment(t *testing.T) {
 	err = sc.DropDatabaseAssignment("test")
 	assert.NoError(t, err)
 }
+
+func TestStorageCluster_SubmitConsistencyCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sc := &storageCluster{
+		cfg:         &config.StorageCluster{Config: &config.RepoState{Namespace: "test"}},
+		storageRepo: repo,
+		logger:      logger.GetLogger("Master", "Test"),
+	}
+	check := &models.ConsistencyCheck{ID: "check", Database: "db"}
+	repo.EXPECT().Put(gomock.Any(), constants.GetConsistencyCheckPath("db"), gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, sc.SubmitConsistencyCheck(check))
+
+	repo.EXPECT().Put(gomock.Any(), constants.GetConsistencyCheckPath("db"), encoding.JSONMarshal(check)).Return(nil)
+	assert.NoError(t, sc.SubmitConsistencyCheck(check))
+}
+
+func TestStorageCluster_PauseFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sc := &storageCluster{
+		cfg:         &config.StorageCluster{Config: &config.RepoState{Namespace: "test"}},
+		storageRepo: repo,
+		logger:      logger.GetLogger("Master", "Test"),
+	}
+	pause := &models.FlushPause{Database: "db"}
+	repo.EXPECT().Put(gomock.Any(), constants.GetFlushPausePath("db"), gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, sc.PauseFlush(pause))
+	repo.EXPECT().Put(gomock.Any(), constants.GetFlushPausePath("db"), encoding.JSONMarshal(pause)).Return(nil)
+	assert.NoError(t, sc.PauseFlush(pause))
+
+	repo.EXPECT().Delete(gomock.Any(), constants.GetFlushPausePath("db")).Return(fmt.Errorf("err"))
+	assert.Error(t, sc.ResumeFlush("db"))
+	repo.EXPECT().Delete(gomock.Any(), constants.GetFlushPausePath("db")).Return(nil)
+	assert.NoError(t, sc.ResumeFlush("db"))
+}
+
+func TestStorageCluster_SubmitReplicaBootstrap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sc := &storageCluster{
+		cfg:         &config.StorageCluster{Config: &config.RepoState{Namespace: "test"}},
+		storageRepo: repo,
+		logger:      logger.GetLogger("Master", "Test"),
+	}
+	task := &models.ReplicaBootstrap{ID: "task", Database: "db", ShardID: 1, Leader: 1, Replica: 3}
+	path := constants.GetReplicaBootstrapPath("db", 1, 3)
+	repo.EXPECT().Put(gomock.Any(), path, gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, sc.SubmitReplicaBootstrap(task))
+
+	repo.EXPECT().Put(gomock.Any(), path, encoding.JSONMarshal(task)).Return(nil)
+	assert.NoError(t, sc.SubmitReplicaBootstrap(task))
+}
+
+func TestStorageCluster_GetReplicaBootstrapProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sc := &storageCluster{
+		cfg:         &config.StorageCluster{Config: &config.RepoState{Namespace: "test"}},
+		storageRepo: repo,
+		logger:      logger.GetLogger("Master", "Test"),
+	}
+	taskPath := constants.GetReplicaBootstrapPath("db", 1, 3)
+	progressPath := constants.GetReplicaBootstrapProgressPath("db", 1, 3)
+	task := encoding.JSONMarshal(&models.ReplicaBootstrap{ID: "task", Database: "db", ShardID: 1, Replica: 3})
+	pending := &models.ReplicaBootstrapProgress{TaskID: "task", State: models.ReplicaBootstrapPending}
+
+	cases := []struct {
+		name     string
+		prepare  func()
+		progress *models.ReplicaBootstrapProgress
+		err      error
+	}{
+		{
+			name: "task not found",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(nil, state.ErrNotExist)
+			},
+			err: constants.ErrReplicaBootstrapNotFound,
+		},
+		{
+			name: "get task failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(nil, fmt.Errorf("err"))
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "unmarshal task failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return([]byte("abc"), nil)
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "progress not reported",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(nil, state.ErrNotExist)
+			},
+			progress: pending,
+		},
+		{
+			name: "get progress failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(nil, fmt.Errorf("err"))
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "unmarshal progress failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return([]byte("abc"), nil)
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "progress of previous task",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(encoding.JSONMarshal(
+					&models.ReplicaBootstrapProgress{TaskID: "old", State: models.ReplicaBootstrapCompleted}), nil)
+			},
+			progress: pending,
+		},
+		{
+			name: "get progress successfully",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(encoding.JSONMarshal(
+					&models.ReplicaBootstrapProgress{TaskID: "task", State: models.ReplicaBootstrapTransferring, TotalFamilies: 2}), nil)
+			},
+			progress: &models.ReplicaBootstrapProgress{TaskID: "task", State: models.ReplicaBootstrapTransferring, TotalFamilies: 2},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			progress, err := sc.GetReplicaBootstrapProgress("db", 1, 3)
+			if tt.err != nil {
+				assert.Error(t, err)
+				if errors.Is(tt.err, constants.ErrNotFound) {
+					assert.Equal(t, tt.err, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.progress, progress)
+		})
+	}
+}
+
+func TestStorageCluster_SubmitRollupBackfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sc := &storageCluster{
+		cfg:         &config.StorageCluster{Config: &config.RepoState{Namespace: "test"}},
+		storageRepo: repo,
+		logger:      logger.GetLogger("Master", "Test"),
+	}
+	task := &models.RollupBackfill{ID: "task", Database: "db", ShardID: 1, Replica: 3}
+	path := constants.GetRollupBackfillPath("db", 1, 3)
+	repo.EXPECT().Put(gomock.Any(), path, gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, sc.SubmitRollupBackfill(task))
+
+	repo.EXPECT().Put(gomock.Any(), path, encoding.JSONMarshal(task)).Return(nil)
+	assert.NoError(t, sc.SubmitRollupBackfill(task))
+}
+
+func TestStorageCluster_GetRollupBackfillProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sc := &storageCluster{
+		cfg:         &config.StorageCluster{Config: &config.RepoState{Namespace: "test"}},
+		storageRepo: repo,
+		logger:      logger.GetLogger("Master", "Test"),
+	}
+	taskPath := constants.GetRollupBackfillPath("db", 1, 3)
+	progressPath := constants.GetRollupBackfillProgressPath("db", 1, 3)
+	task := encoding.JSONMarshal(&models.RollupBackfill{ID: "task", Database: "db", ShardID: 1})
+	pending := &models.RollupBackfillProgress{TaskID: "task", State: models.RollupBackfillPending}
+
+	cases := []struct {
+		name     string
+		prepare  func()
+		progress *models.RollupBackfillProgress
+		err      error
+	}{
+		{
+			name: "task not found",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(nil, state.ErrNotExist)
+			},
+			err: constants.ErrRollupBackfillNotFound,
+		},
+		{
+			name: "get task failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(nil, fmt.Errorf("err"))
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "unmarshal task failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return([]byte("abc"), nil)
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "progress not reported",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(nil, state.ErrNotExist)
+			},
+			progress: pending,
+		},
+		{
+			name: "get progress failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(nil, fmt.Errorf("err"))
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "unmarshal progress failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return([]byte("abc"), nil)
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "progress of previous task",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(encoding.JSONMarshal(
+					&models.RollupBackfillProgress{TaskID: "old", State: models.RollupBackfillCompleted}), nil)
+			},
+			progress: pending,
+		},
+		{
+			name: "get progress successfully",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), taskPath).Return(task, nil)
+				repo.EXPECT().Get(gomock.Any(), progressPath).Return(encoding.JSONMarshal(
+					&models.RollupBackfillProgress{TaskID: "task", State: models.RollupBackfillRunning, CompletedFrom: 10}), nil)
+			},
+			progress: &models.RollupBackfillProgress{TaskID: "task", State: models.RollupBackfillRunning, CompletedFrom: 10},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			progress, err := sc.GetRollupBackfillProgress("db", 1, 3)
+			if tt.err != nil {
+				assert.Error(t, err)
+				if errors.Is(tt.err, constants.ErrNotFound) {
+					assert.Equal(t, tt.err, err)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.progress, progress)
+		})
+	}
+}
+
+func TestStorageCluster_GetConsistencyReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sc := &storageCluster{
+		cfg:         &config.StorageCluster{Config: &config.RepoState{Namespace: "test"}},
+		storageRepo: repo,
+		logger:      logger.GetLogger("Master", "Test"),
+	}
+	checkPath := constants.GetConsistencyCheckPath("db")
+	assignPath := constants.GetDatabaseAssignPath("db")
+	digestsPath := constants.GetConsistencyDigestsPath("db")
+	check := encoding.JSONMarshal(&models.ConsistencyCheck{ID: "check", Database: "db"})
+	assignment := encoding.JSONMarshal(&models.DatabaseAssignment{ShardAssignment: &models.ShardAssignment{
+		Name:   "db",
+		Shards: map[models.ShardID]*models.Replica{1: {Replicas: []models.NodeID{1, 2}}},
+	}})
+
+	cases := []struct {
+		name    string
+		prepare func()
+		err     error
+	}{
+		{
+			name: "check not found",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(nil, state.ErrNotExist)
+			},
+			err: constants.ErrConsistencyCheckNotFound,
+		},
+		{
+			name: "get check failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(nil, fmt.Errorf("err"))
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "unmarshal check failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return([]byte("abc"), nil)
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "database not found",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(check, nil)
+				repo.EXPECT().Get(gomock.Any(), assignPath).Return(nil, state.ErrNotExist)
+			},
+			err: constants.ErrDatabaseNotExist,
+		},
+		{
+			name: "get database assignment failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(check, nil)
+				repo.EXPECT().Get(gomock.Any(), assignPath).Return(nil, fmt.Errorf("err"))
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "unmarshal database assignment failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(check, nil)
+				repo.EXPECT().Get(gomock.Any(), assignPath).Return([]byte("abc"), nil)
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "shard assignment not found",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(check, nil)
+				repo.EXPECT().Get(gomock.Any(), assignPath).Return(encoding.JSONMarshal(&models.DatabaseAssignment{}), nil)
+			},
+			err: constants.ErrDatabaseNotExist,
+		},
+		{
+			name: "list digests failure",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(check, nil)
+				repo.EXPECT().Get(gomock.Any(), assignPath).Return(assignment, nil)
+				repo.EXPECT().List(gomock.Any(), digestsPath).Return(nil, fmt.Errorf("err"))
+			},
+			err: fmt.Errorf("err"),
+		},
+		{
+			name: "build report successfully",
+			prepare: func() {
+				repo.EXPECT().Get(gomock.Any(), checkPath).Return(check, nil)
+				repo.EXPECT().Get(gomock.Any(), assignPath).Return(assignment, nil)
+				repo.EXPECT().List(gomock.Any(), digestsPath).Return([]state.KeyValue{
+					{Key: "bad", Value: []byte("abc")},
+					{Key: "1", Value: encoding.JSONMarshal(&models.ReplicaDigest{CheckID: "check", ShardID: 1, NodeID: 1})},
+				}, nil)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			report, err := sc.GetConsistencyReport("db")
+			if tt.err == nil {
+				assert.NoError(t, err)
+				assert.False(t, report.Completed)
+				assert.Equal(t, []models.NodeID{2}, report.Shards[0].Pending)
+				return
+			}
+			assert.Error(t, err)
+			assert.Nil(t, report)
+			if errors.Is(tt.err, constants.ErrNotFound) || tt.err == constants.ErrDatabaseNotExist {
+				assert.Equal(t, tt.err, err)
+			}
+		})
+	}
+}
@@ -18,20 +18,29 @@
 package coordinator
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/discovery"
 	"github.com/lindb/lindb/coordinator/elect"
 	masterpkg "github.com/lindb/lindb/coordinator/master"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 //go:generate mockgen -source=./master_controller.go -destination=./master_controller_mock.go -package=coordinator
@@ -54,6 +63,9 @@ type MasterCfg struct {
 	Node models.Node
 	Repo state.Repository
 
+	// DropGracePeriod is how long the dropped database is kept before purged, purges immediately if 0.
+	DropGracePeriod time.Duration
+
 	// factory
 	DiscoveryFactory discovery.Factory
 	RepoFactory      state.RepositoryFactory
@@ -72,7 +84,35 @@ type MasterController interface {
 	// Stop stops master if current node is master, cleanup master context and stops state machine
 	Stop()
 	// FlushDatabase submits the coordinator task for flushing memory database by cluster and database name
-	FlushDatabase(cluster string, databaseName string) error
+	FlushDatabase(ctx context.Context, cluster string, databaseName string) error
+	// PauseFlush pauses flushing of database on all storage nodes of cluster during maintenance.
+	PauseFlush(ctx context.Context, cluster, databaseName string) error
+	// ResumeFlush resumes flushing of database on all storage nodes of cluster.
+	ResumeFlush(ctx context.Context, cluster, databaseName string) error
+	// CheckConsistency submits the consistency check task which compares the replicas of closed family.
+	CheckConsistency(ctx context.Context, cluster, databaseName string, familyTime int64) (*models.ConsistencyCheck, error)
+	// ConsistencyReport returns the report of latest consistency check by cluster and database name.
+	ConsistencyReport(cluster, databaseName string) (*models.ConsistencyReport, error)
+	// SplitShard splits the hot shard of database, future writes of half key space are routed to a new shard.
+	SplitShard(ctx context.Context, databaseName string, shardID models.ShardID) (*models.ShardSplit, error)
+	// AddReplica adds a new replica on storage node for the shard of database, bootstrapped from leader's snapshot.
+	AddReplica(ctx context.Context, databaseName string, shardID models.ShardID, nodeID models.NodeID) (*models.ReplicaBootstrap, error)
+	// ReplicaBootstrapProgress returns the bootstrap progress of new shard replica on storage node.
+	ReplicaBootstrapProgress(databaseName string, shardID models.ShardID, nodeID models.NodeID) (*models.ReplicaBootstrapProgress, error)
+	// DropDatabase drops database, writes/queries are rejected, data of database is kept and can be restored
+	// within the grace period, then the database is purged.
+	DropDatabase(ctx context.Context, databaseName string) (*models.Database, error)
+	// RestoreDatabase restores the dropped database within the grace period.
+	RestoreDatabase(ctx context.Context, databaseName string) (*models.Database, error)
+	// RollupBackfillStatus returns the backfill status of added rollup intervals of database.
+	RollupBackfillStatus(databaseName string) (*models.RollupBackfillStatus, error)
+	// AuditLog returns the recent audit entries of admin operations since given time, limit <= 0 means no limit.
+	AuditLog(since time.Time, limit int) ([]*models.AuditEntry, error)
+	// ExportMetadata writes all metadata owned by LinDB in state repository as versioned archive.
+	ExportMetadata(ctx context.Context, w io.Writer) error
+	// ImportMetadata validates the metadata archive, then applies the difference against current state atomically,
+	// returns the difference without applying if dry run, deletes the metadata not in archive if prune.
+	ImportMetadata(ctx context.Context, r io.Reader, dryRun, prune bool) (*models.MetadataDiff, error)
 	// GetStateManager returns master's state manager.
 	GetStateManager() masterpkg.StateManager
 	// WatchMasterElected adds callback after master finished election.
@@ -216,8 +256,13 @@ func (m *masterController) Stop() {
 }
 
 // FlushDatabase submits the coordinator task for flushing memory database by cluster and database name
-func (m *masterController) FlushDatabase(cluster, databaseName string) error {
+func (m *masterController) FlushDatabase(ctx context.Context, cluster, databaseName string) (err error) {
 	if m.IsMaster() {
+		startTime := time.Now()
+		defer func() {
+			audit.GetAuditor().Record(ctx, audit.OpFlushDatabase,
+				map[string]string{"cluster": cluster, "database": databaseName}, startTime, err)
+		}()
 		m.mutex.Lock()
 		defer m.mutex.Unlock()
 
@@ -230,6 +275,351 @@ func (m *masterController) FlushDatabase(cluster, databaseName string) error {
 	return nil
 }
 
+// PauseFlush pauses flushing of database on all storage nodes of cluster during maintenance.
+func (m *masterController) PauseFlush(ctx context.Context, cluster, databaseName string) (err error) {
+	if !m.IsMaster() {
+		return constants.ErrNotMaster
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpPauseFlush,
+			map[string]string{"cluster": cluster, "database": databaseName}, startTime, err)
+	}()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storage := m.stateMgr.GetStorageCluster(cluster)
+	if storage == nil {
+		return constants.ErrNoStorageCluster
+	}
+	return storage.PauseFlush(&models.FlushPause{
+		Database:  databaseName,
+		CreatedAt: timeutil.Now(),
+	})
+}
+
+// ResumeFlush resumes flushing of database on all storage nodes of cluster.
+func (m *masterController) ResumeFlush(ctx context.Context, cluster, databaseName string) (err error) {
+	if !m.IsMaster() {
+		return constants.ErrNotMaster
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpResumeFlush,
+			map[string]string{"cluster": cluster, "database": databaseName}, startTime, err)
+	}()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storage := m.stateMgr.GetStorageCluster(cluster)
+	if storage == nil {
+		return constants.ErrNoStorageCluster
+	}
+	return storage.ResumeFlush(databaseName)
+}
+
+// CheckConsistency submits the consistency check task which compares the replicas of closed family.
+func (m *masterController) CheckConsistency(
+	ctx context.Context,
+	cluster, databaseName string,
+	familyTime int64,
+) (check *models.ConsistencyCheck, err error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpCheckConsistency,
+			map[string]string{"cluster": cluster, "database": databaseName,
+				"familyTime": strconv.FormatInt(familyTime, 10)}, startTime, err)
+	}()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storage := m.stateMgr.GetStorageCluster(cluster)
+	if storage == nil {
+		return nil, constants.ErrNoStorageCluster
+	}
+	check = &models.ConsistencyCheck{
+		ID:         uuid.New().String(),
+		Database:   databaseName,
+		FamilyTime: familyTime,
+		CreatedAt:  timeutil.Now(),
+	}
+	if err = storage.SubmitConsistencyCheck(check); err != nil {
+		return nil, err
+	}
+	return check, nil
+}
+
+// ConsistencyReport returns the report of latest consistency check by cluster and database name.
+func (m *masterController) ConsistencyReport(cluster, databaseName string) (*models.ConsistencyReport, error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	storage := m.stateMgr.GetStorageCluster(cluster)
+	if storage == nil {
+		return nil, constants.ErrNoStorageCluster
+	}
+	return storage.GetConsistencyReport(databaseName)
+}
+
+// SplitShard splits the hot shard of database, future writes of half key space are routed to a new shard.
+func (m *masterController) SplitShard(
+	ctx context.Context,
+	databaseName string,
+	shardID models.ShardID,
+) (split *models.ShardSplit, err error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpSplitShard,
+			map[string]string{"database": databaseName, "shard": shardID.String()}, startTime, err)
+	}()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stateMgr.SplitShard(databaseName, shardID)
+}
+
+// AddReplica adds a new replica on storage node for the shard of database, bootstrapped from leader's snapshot.
+func (m *masterController) AddReplica(
+	ctx context.Context,
+	databaseName string,
+	shardID models.ShardID,
+	nodeID models.NodeID,
+) (task *models.ReplicaBootstrap, err error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpAddReplica,
+			map[string]string{"database": databaseName, "shard": shardID.String(),
+				"node": strconv.Itoa(int(nodeID))}, startTime, err)
+	}()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stateMgr.AddReplica(databaseName, shardID, nodeID)
+}
+
+// DropDatabase drops database, writes/queries are rejected, data of database is kept and can be restored
+// within the grace period, then the database is purged.
+func (m *masterController) DropDatabase(ctx context.Context, databaseName string) (database *models.Database, err error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpDropDatabase,
+			map[string]string{"database": databaseName}, startTime, err)
+	}()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stateMgr.DropDatabase(databaseName, m.cfg.DropGracePeriod)
+}
+
+// RestoreDatabase restores the dropped database within the grace period.
+func (m *masterController) RestoreDatabase(ctx context.Context, databaseName string) (database *models.Database, err error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpRestoreDatabase,
+			map[string]string{"database": databaseName}, startTime, err)
+	}()
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stateMgr.RestoreDatabase(databaseName)
+}
+
+// ReplicaBootstrapProgress returns the bootstrap progress of new shard replica on storage node.
+func (m *masterController) ReplicaBootstrapProgress(
+	databaseName string,
+	shardID models.ShardID,
+	nodeID models.NodeID,
+) (*models.ReplicaBootstrapProgress, error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, database := range m.stateMgr.GetDatabases() {
+		if database.Name != databaseName {
+			continue
+		}
+		storage := m.stateMgr.GetStorageCluster(database.Storage)
+		if storage == nil {
+			return nil, constants.ErrNoStorageCluster
+		}
+		return storage.GetReplicaBootstrapProgress(databaseName, shardID, nodeID)
+	}
+	return nil, constants.ErrDatabaseNotFound
+}
+
+// RollupBackfillStatus returns the backfill status of added rollup intervals of database.
+func (m *masterController) RollupBackfillStatus(databaseName string) (*models.RollupBackfillStatus, error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.stateMgr.GetRollupBackfillStatus(databaseName)
+}
+
+// AuditLog returns the recent audit entries of admin operations since given time, limit <= 0 means no limit.
+func (m *masterController) AuditLog(since time.Time, limit int) ([]*models.AuditEntry, error) {
+	return audit.GetAuditor().List(m.ctx, since, limit)
+}
+
+// ExportMetadata writes all metadata owned by LinDB in state repository as versioned archive.
+func (m *masterController) ExportMetadata(ctx context.Context, w io.Writer) error {
+	entries, err := m.listMetadata(ctx)
+	if err != nil {
+		return err
+	}
+	archive := &models.MetadataArchive{
+		Version:   models.MetadataArchiveVersion,
+		CreatedAt: timeutil.Now(),
+	}
+	for _, entry := range entries {
+		archive.Entries = append(archive.Entries, models.MetadataEntry{Key: entry.Key, Value: entry.Value})
+	}
+	_, err = w.Write(encoding.JSONMarshal(archive))
+	return err
+}
+
+// ImportMetadata validates the metadata archive, then applies the difference against current state atomically,
+// returns the difference without applying if dry run, deletes the metadata not in archive if prune.
+// The changed metadata is watched by state machines of master/brokers, so the storage nodes are reconciled
+// as normal after imported.
+func (m *masterController) ImportMetadata(
+	ctx context.Context,
+	r io.Reader,
+	dryRun, prune bool,
+) (_ *models.MetadataDiff, err error) {
+	if !m.IsMaster() {
+		return nil, constants.ErrNotMaster
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	archive := &models.MetadataArchive{}
+	if err = encoding.JSONUnmarshal(data, archive); err != nil {
+		return nil, fmt.Errorf("unmarshal metadata archive error: %s", err)
+	}
+	if err = archive.Validate(); err != nil {
+		return nil, err
+	}
+	current, err := m.listMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	diff := diffMetadata(archive, current, prune)
+	if dryRun || diff.IsEmpty() {
+		return diff, nil
+	}
+	startTime := time.Now()
+	defer func() {
+		audit.GetAuditor().Record(ctx, audit.OpImportMetadata,
+			map[string]string{
+				"added":   strconv.Itoa(len(diff.Added)),
+				"updated": strconv.Itoa(len(diff.Updated)),
+				"deleted": strconv.Itoa(len(diff.Deleted)),
+			}, startTime, err)
+	}()
+	changed := make(map[string]struct{})
+	for _, key := range append(append([]string{}, diff.Added...), diff.Updated...) {
+		changed[key] = struct{}{}
+	}
+	// all or nothing, puts metadata in order of applying(storage config first, then database config etc.)
+	txn := m.cfg.Repo.NewTransaction()
+	for _, entry := range archive.Entries {
+		if _, ok := changed[entry.Key]; ok {
+			txn.Put(entry.Key, entry.Value)
+		}
+	}
+	for _, key := range diff.Deleted {
+		txn.Delete(key)
+	}
+	if err = m.cfg.Repo.Commit(ctx, txn); err != nil {
+		return nil, err
+	}
+	diff.Applied = true
+	log.Info("import metadata archive successfully",
+		logger.Int("added", len(diff.Added)), logger.Int("updated", len(diff.Updated)),
+		logger.Int("deleted", len(diff.Deleted)))
+	return diff, nil
+}
+
+// listMetadata returns all metadata owned by LinDB in state repository, in order of applying.
+func (m *masterController) listMetadata(ctx context.Context) (entries []state.KeyValue, err error) {
+	for _, path := range constants.MetadataPaths {
+		kvs, err := m.cfg.Repo.List(ctx, path+constants.StatePathSeparator)
+		if err != nil {
+			return nil, err
+		}
+		sort.Slice(kvs, func(i, j int) bool {
+			return kvs[i].Key < kvs[j].Key
+		})
+		entries = append(entries, kvs...)
+	}
+	return entries, nil
+}
+
+// diffMetadata returns the difference between metadata archive and current state,
+// the metadata not in archive is deleted only if prune.
+func diffMetadata(archive *models.MetadataArchive, current []state.KeyValue, prune bool) *models.MetadataDiff {
+	diff := &models.MetadataDiff{}
+	currentValues := make(map[string][]byte)
+	for _, kv := range current {
+		currentValues[kv.Key] = kv.Value
+	}
+	archiveKeys := make(map[string]struct{})
+	for _, entry := range archive.Entries {
+		archiveKeys[entry.Key] = struct{}{}
+		value, ok := currentValues[entry.Key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, entry.Key)
+		case !jsonEqual(value, entry.Value):
+			diff.Updated = append(diff.Updated, entry.Key)
+		}
+	}
+	if prune {
+		for _, kv := range current {
+			if _, ok := archiveKeys[kv.Key]; !ok {
+				diff.Deleted = append(diff.Deleted, kv.Key)
+			}
+		}
+	}
+	return diff
+}
+
+// jsonEqual returns true if two json values are same after formatted.
+func jsonEqual(a, b []byte) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var bufA, bufB bytes.Buffer
+	if json.Compact(&bufA, a) != nil || json.Compact(&bufB, b) != nil {
+		return false
+	}
+	return bytes.Equal(bufA.Bytes(), bufB.Bytes())
+}
+
 // WatchMasterElected adds callback after master finished election.
 func (m *masterController) WatchMasterElected(fn func(master *models.Master)) {
 	m.mutex.Lock()
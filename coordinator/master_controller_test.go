@@ -18,13 +18,17 @@
 package coordinator
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/discovery"
 	"github.com/lindb/lindb/coordinator/elect"
 	masterpkg "github.com/lindb/lindb/coordinator/master"
@@ -83,7 +87,7 @@ func TestMasterController_OnFailOver(t *testing.T) {
 		{
 			name: "register master done failure",
 			prepare: func() {
-				discovery1.EXPECT().Discovery(gomock.Any()).Return(nil).MaxTimes(3)
+				discovery1.EXPECT().Discovery(gomock.Any()).Return(nil).MaxTimes(4)
 				registry.EXPECT().Register(gomock.Any()).Return(fmt.Errorf("err"))
 			},
 			wantErr: true,
@@ -91,7 +95,7 @@ func TestMasterController_OnFailOver(t *testing.T) {
 		{
 			name: "elect master successfully",
 			prepare: func() {
-				discovery1.EXPECT().Discovery(gomock.Any()).Return(nil).MaxTimes(3)
+				discovery1.EXPECT().Discovery(gomock.Any()).Return(nil).MaxTimes(4)
 				registry.EXPECT().Register(gomock.Any()).Return(nil)
 			},
 			wantErr: false,
@@ -256,10 +260,383 @@ func TestMasterController_FlushDatabase(t *testing.T) {
 			if tt.prepare != nil {
 				tt.prepare()
 			}
-			err := mc.FlushDatabase("test", "db")
+			err := mc.FlushDatabase(context.TODO(), "test", "db")
 			if (err != nil) != tt.wantErr {
 				t.Errorf("FlushDatabase() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestMasterController_AuditLog(t *testing.T) {
+	mc := &masterController{ctx: context.TODO()}
+	entries, err := mc.AuditLog(time.Now(), 10)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestMasterController_CheckConsistency(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	storage := masterpkg.NewMockStorageCluster(ctrl)
+	cases := []struct {
+		name    string
+		prepare func()
+		wantErr bool
+	}{
+		{
+			name: "isn't master",
+			prepare: func() {
+				masterElect.EXPECT().IsMaster().Return(false)
+			},
+			wantErr: true,
+		},
+		{
+			name: "storage not found",
+			prepare: func() {
+				masterElect.EXPECT().IsMaster().Return(true)
+				stateMgr.EXPECT().GetStorageCluster("test").Return(nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "submit check failure",
+			prepare: func() {
+				masterElect.EXPECT().IsMaster().Return(true)
+				stateMgr.EXPECT().GetStorageCluster("test").Return(storage)
+				storage.EXPECT().SubmitConsistencyCheck(gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "submit check successfully",
+			prepare: func() {
+				masterElect.EXPECT().IsMaster().Return(true)
+				stateMgr.EXPECT().GetStorageCluster("test").Return(storage)
+				storage.EXPECT().SubmitConsistencyCheck(gomock.Any()).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			mc := &masterController{
+				elect:    masterElect,
+				stateMgr: stateMgr,
+			}
+			tt.prepare()
+			check, err := mc.CheckConsistency(context.TODO(), "test", "db", 10)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckConsistency() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.NotEmpty(t, check.ID)
+				assert.Equal(t, "db", check.Database)
+				assert.Equal(t, int64(10), check.FamilyTime)
+			}
+		})
+	}
+}
+
+func TestMasterController_PauseFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	storage := masterpkg.NewMockStorageCluster(ctrl)
+	mc := &masterController{
+		elect:    masterElect,
+		stateMgr: stateMgr,
+	}
+	// isn't master
+	masterElect.EXPECT().IsMaster().Return(false).Times(2)
+	assert.Equal(t, constants.ErrNotMaster, mc.PauseFlush(context.TODO(), "test", "db"))
+	assert.Equal(t, constants.ErrNotMaster, mc.ResumeFlush(context.TODO(), "test", "db"))
+	// storage not found
+	masterElect.EXPECT().IsMaster().Return(true).AnyTimes()
+	stateMgr.EXPECT().GetStorageCluster("test").Return(nil).Times(2)
+	assert.Equal(t, constants.ErrNoStorageCluster, mc.PauseFlush(context.TODO(), "test", "db"))
+	assert.Equal(t, constants.ErrNoStorageCluster, mc.ResumeFlush(context.TODO(), "test", "db"))
+	// submit task
+	stateMgr.EXPECT().GetStorageCluster("test").Return(storage).AnyTimes()
+	storage.EXPECT().PauseFlush(gomock.Any()).DoAndReturn(func(pause *models.FlushPause) error {
+		assert.Equal(t, "db", pause.Database)
+		return nil
+	})
+	assert.NoError(t, mc.PauseFlush(context.TODO(), "test", "db"))
+	storage.EXPECT().ResumeFlush("db").Return(fmt.Errorf("err"))
+	assert.Error(t, mc.ResumeFlush(context.TODO(), "test", "db"))
+}
+
+func TestMasterController_SplitShard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	mc := &masterController{
+		elect:    masterElect,
+		stateMgr: stateMgr,
+	}
+	masterElect.EXPECT().IsMaster().Return(false)
+	_, err := mc.SplitShard(context.TODO(), "db", 1)
+	assert.Equal(t, constants.ErrNotMaster, err)
+
+	masterElect.EXPECT().IsMaster().Return(true)
+	stateMgr.EXPECT().SplitShard("db", models.ShardID(1)).Return(&models.ShardSplit{Source: 1, Target: 3}, nil)
+	split, err := mc.SplitShard(context.TODO(), "db", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ShardID(3), split.Target)
+}
+
+func TestMasterController_DropDatabase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	mc := &masterController{
+		cfg:      &MasterCfg{DropGracePeriod: time.Hour},
+		elect:    masterElect,
+		stateMgr: stateMgr,
+	}
+	masterElect.EXPECT().IsMaster().Return(false).Times(2)
+	_, err := mc.DropDatabase(context.TODO(), "db")
+	assert.Equal(t, constants.ErrNotMaster, err)
+	_, err = mc.RestoreDatabase(context.TODO(), "db")
+	assert.Equal(t, constants.ErrNotMaster, err)
+
+	masterElect.EXPECT().IsMaster().Return(true).Times(2)
+	stateMgr.EXPECT().DropDatabase("db", time.Hour).
+		Return(&models.Database{Name: "db", Deletion: &models.DatabaseDeletion{}}, nil)
+	database, err := mc.DropDatabase(context.TODO(), "db")
+	assert.NoError(t, err)
+	assert.True(t, database.IsDeleted())
+	stateMgr.EXPECT().RestoreDatabase("db").Return(&models.Database{Name: "db"}, nil)
+	database, err = mc.RestoreDatabase(context.TODO(), "db")
+	assert.NoError(t, err)
+	assert.False(t, database.IsDeleted())
+}
+
+func TestMasterController_AddReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	mc := &masterController{
+		elect:    masterElect,
+		stateMgr: stateMgr,
+	}
+	masterElect.EXPECT().IsMaster().Return(false)
+	_, err := mc.AddReplica(context.TODO(), "db", 1, 3)
+	assert.Equal(t, constants.ErrNotMaster, err)
+
+	masterElect.EXPECT().IsMaster().Return(true)
+	stateMgr.EXPECT().AddReplica("db", models.ShardID(1), models.NodeID(3)).Return(&models.ReplicaBootstrap{ID: "task"}, nil)
+	task, err := mc.AddReplica(context.TODO(), "db", 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, "task", task.ID)
+}
+
+func TestMasterController_ReplicaBootstrapProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	storage := masterpkg.NewMockStorageCluster(ctrl)
+	mc := &masterController{
+		elect:    masterElect,
+		stateMgr: stateMgr,
+	}
+	// isn't master
+	masterElect.EXPECT().IsMaster().Return(false)
+	_, err := mc.ReplicaBootstrapProgress("db", 1, 3)
+	assert.Equal(t, constants.ErrNotMaster, err)
+	masterElect.EXPECT().IsMaster().Return(true).AnyTimes()
+	// database not found
+	stateMgr.EXPECT().GetDatabases().Return([]models.Database{{Name: "other", Storage: "test"}})
+	_, err = mc.ReplicaBootstrapProgress("db", 1, 3)
+	assert.Equal(t, constants.ErrDatabaseNotFound, err)
+	stateMgr.EXPECT().GetDatabases().Return([]models.Database{{Name: "db", Storage: "test"}}).AnyTimes()
+	// storage not found
+	stateMgr.EXPECT().GetStorageCluster("test").Return(nil)
+	_, err = mc.ReplicaBootstrapProgress("db", 1, 3)
+	assert.Equal(t, constants.ErrNoStorageCluster, err)
+	// get progress
+	stateMgr.EXPECT().GetStorageCluster("test").Return(storage)
+	storage.EXPECT().GetReplicaBootstrapProgress("db", models.ShardID(1), models.NodeID(3)).
+		Return(&models.ReplicaBootstrapProgress{TaskID: "task", State: models.ReplicaBootstrapCompleted}, nil)
+	progress, err := mc.ReplicaBootstrapProgress("db", 1, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReplicaBootstrapCompleted, progress.State)
+}
+
+func TestMasterController_RollupBackfillStatus(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	mc := &masterController{
+		elect:    masterElect,
+		stateMgr: stateMgr,
+	}
+	// isn't master
+	masterElect.EXPECT().IsMaster().Return(false)
+	_, err := mc.RollupBackfillStatus("db")
+	assert.Equal(t, constants.ErrNotMaster, err)
+	masterElect.EXPECT().IsMaster().Return(true)
+	stateMgr.EXPECT().GetRollupBackfillStatus("db").Return(&models.RollupBackfillStatus{Database: "db"}, nil)
+	status, err := mc.RollupBackfillStatus("db")
+	assert.NoError(t, err)
+	assert.Equal(t, "db", status.Database)
+}
+
+func TestMasterController_ConsistencyReport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	stateMgr := masterpkg.NewMockStateManager(ctrl)
+	storage := masterpkg.NewMockStorageCluster(ctrl)
+	mc := &masterController{
+		elect:    masterElect,
+		stateMgr: stateMgr,
+	}
+	// isn't master
+	masterElect.EXPECT().IsMaster().Return(false)
+	report, err := mc.ConsistencyReport("test", "db")
+	assert.Equal(t, constants.ErrNotMaster, err)
+	assert.Nil(t, report)
+	// storage not found
+	masterElect.EXPECT().IsMaster().Return(true).AnyTimes()
+	stateMgr.EXPECT().GetStorageCluster("test").Return(nil)
+	report, err = mc.ConsistencyReport("test", "db")
+	assert.Equal(t, constants.ErrNoStorageCluster, err)
+	assert.Nil(t, report)
+	// get report
+	stateMgr.EXPECT().GetStorageCluster("test").Return(storage)
+	storage.EXPECT().GetConsistencyReport("db").Return(&models.ConsistencyReport{Completed: true}, nil)
+	report, err = mc.ConsistencyReport("test", "db")
+	assert.NoError(t, err)
+	assert.True(t, report.Completed)
+}
+
+func TestMasterController_ExportMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	mc := &masterController{cfg: &MasterCfg{Repo: repo}}
+
+	// list failure
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, mc.ExportMetadata(context.TODO(), &bytes.Buffer{}))
+
+	repo.EXPECT().List(gomock.Any(), constants.DatabaseConfigPath+"/").Return([]state.KeyValue{
+		{Key: "/database/config/db2", Value: []byte(`{"name":"db2"}`)},
+		{Key: "/database/config/db1", Value: []byte(`{"name":"db1"}`)},
+	}, nil)
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	buf := &bytes.Buffer{}
+	assert.NoError(t, mc.ExportMetadata(context.TODO(), buf))
+	archive := &models.MetadataArchive{}
+	assert.NoError(t, encoding.JSONUnmarshal(buf.Bytes(), archive))
+	assert.Equal(t, models.MetadataArchiveVersion, archive.Version)
+	assert.Len(t, archive.Entries, 2)
+	assert.Equal(t, "/database/config/db1", archive.Entries[0].Key)
+	assert.Equal(t, `{"name":"db1"}`, string(archive.Entries[0].Value))
+}
+
+func TestMasterController_ImportMetadata(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	repo := state.NewMockRepository(ctrl)
+	txn := state.NewMockTransaction(ctrl)
+	mc := &masterController{elect: masterElect, cfg: &MasterCfg{Repo: repo}}
+	archive := string(encoding.JSONMarshal(&models.MetadataArchive{
+		Version: models.MetadataArchiveVersion,
+		Entries: []models.MetadataEntry{
+			{Key: "/storage/config/s1", Value: []byte(`{"config":{"namespace":"s1"}}`)},
+			{Key: "/database/config/db1", Value: []byte(`{"name":"db1","storage":"s1"}`)},
+			{Key: "/database/config/db2", Value: []byte(`{"name":"db2","storage":"s1"}`)},
+		},
+	}))
+
+	// isn't master
+	masterElect.EXPECT().IsMaster().Return(false)
+	_, err := mc.ImportMetadata(context.TODO(), strings.NewReader(archive), false, false)
+	assert.Equal(t, constants.ErrNotMaster, err)
+
+	masterElect.EXPECT().IsMaster().Return(true).AnyTimes()
+	// invalid archive
+	_, err = mc.ImportMetadata(context.TODO(), strings.NewReader("xx"), false, false)
+	assert.Error(t, err)
+	_, err = mc.ImportMetadata(context.TODO(), strings.NewReader(`{"version":2}`), false, false)
+	assert.Error(t, err)
+	// list failure
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	_, err = mc.ImportMetadata(context.TODO(), strings.NewReader(archive), false, false)
+	assert.Error(t, err)
+
+	repo.EXPECT().List(gomock.Any(), constants.DatabaseConfigPath+"/").Return([]state.KeyValue{
+		{Key: "/database/config/db1", Value: []byte(`{"name": "db1", "storage": "s1"}`)},
+		{Key: "/database/config/db2", Value: []byte(`{"name":"db2","storage":"s0"}`)},
+		{Key: "/database/config/db3", Value: []byte(`{"name":"db3","storage":"s1"}`)},
+	}, nil).AnyTimes()
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	// dry run
+	diff, err := mc.ImportMetadata(context.TODO(), strings.NewReader(archive), true, true)
+	assert.NoError(t, err)
+	assert.False(t, diff.Applied)
+	assert.Equal(t, []string{"/storage/config/s1"}, diff.Added)
+	assert.Equal(t, []string{"/database/config/db2"}, diff.Updated)
+	assert.Equal(t, []string{"/database/config/db3"}, diff.Deleted)
+	assert.Equal(t, "+ /storage/config/s1\n~ /database/config/db2\n- /database/config/db3\n", diff.String())
+	// commit failure
+	repo.EXPECT().NewTransaction().Return(txn)
+	txn.EXPECT().Put(gomock.Any(), gomock.Any()).Times(2)
+	repo.EXPECT().Commit(gomock.Any(), txn).Return(fmt.Errorf("err"))
+	_, err = mc.ImportMetadata(context.TODO(), strings.NewReader(archive), false, false)
+	assert.Error(t, err)
+	// apply in order of archive, not prune
+	txn = state.NewMockTransaction(ctrl)
+	gomock.InOrder(
+		repo.EXPECT().NewTransaction().Return(txn),
+		txn.EXPECT().Put("/storage/config/s1", gomock.Any()),
+		txn.EXPECT().Put("/database/config/db2", gomock.Any()),
+		txn.EXPECT().Delete("/database/config/db3"),
+		repo.EXPECT().Commit(gomock.Any(), txn).Return(nil),
+	)
+	diff, err = mc.ImportMetadata(context.TODO(), strings.NewReader(archive), false, true)
+	assert.NoError(t, err)
+	assert.True(t, diff.Applied)
+}
+
+func TestMasterController_ImportMetadata_NoChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	masterElect := elect.NewMockElection(ctrl)
+	repo := state.NewMockRepository(ctrl)
+	mc := &masterController{elect: masterElect, cfg: &MasterCfg{Repo: repo}}
+	masterElect.EXPECT().IsMaster().Return(true)
+	repo.EXPECT().List(gomock.Any(), constants.DatabaseConfigPath+"/").Return([]state.KeyValue{
+		{Key: "/database/config/db1", Value: []byte(`{"name":"db1"}`)},
+	}, nil)
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
+	diff, err := mc.ImportMetadata(context.TODO(), strings.NewReader(
+		`{"version":1,"entries":[{"key":"/database/config/db1","value":{"name": "db1"}}]}`), false, false)
+	assert.NoError(t, err)
+	assert.True(t, diff.IsEmpty())
+	assert.False(t, diff.Applied)
+}
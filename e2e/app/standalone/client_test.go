@@ -0,0 +1,70 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build integration
+// +build integration
+
+package standalone
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/client"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+func TestClient_WriteAndQuery(t *testing.T) {
+	cli, err := client.NewClient(client.Config{
+		// unavailable broker, fail over to standalone broker
+		Brokers: []string{"http://127.0.0.1:9999", "http://127.0.0.1:9000"},
+	})
+	assert.NoError(t, err)
+	defer cli.Close()
+
+	writer := cli.NewWriter(context.Background(), "_internal", client.WriterConfig{BatchSize: 10})
+	timestamp := timeutil.Now()
+	for i := 0; i < 25; i++ {
+		assert.NoError(t, writer.Write(context.Background(), &protoMetricsV1.Metric{
+			Name:      "client_data",
+			Timestamp: timestamp,
+			Tags:      []*protoMetricsV1.KeyValue{{Key: "host", Value: "host" + strconv.Itoa(i)}},
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1},
+			},
+		}))
+	}
+	assert.NoError(t, writer.Close())
+	stats := writer.Stats()
+	assert.Equal(t, int64(25), stats.Written)
+	assert.Equal(t, int64(3), stats.Batches)
+
+	// wait data write complete
+	time.Sleep(5 * time.Second)
+	rs, err := cli.Query(context.Background(), "_internal", "select f1 from client_data where time>now()-1h group by host limit 100")
+	assert.NoError(t, err)
+	assert.Len(t, rs.Series, 25)
+	for _, values := range rs.Pivot("f1") {
+		assert.Len(t, values, 25)
+	}
+}
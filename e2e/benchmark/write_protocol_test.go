@@ -0,0 +1,158 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build benchmark
+// +build benchmark
+
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-http-utils/headers"
+	"github.com/go-resty/resty/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoIngestV1 "github.com/lindb/lindb/proto/gen/v1/ingest"
+	"github.com/lindb/lindb/series/metric"
+)
+
+const (
+	rowsPerBatch = 500
+	httpEndpoint = "http://127.0.0.1:9000/api/v1/write?db=_internal"
+	grpcEndpoint = "127.0.0.1:9001"
+)
+
+// buildBatch builds flat buffer encoded batch with rowsPerBatch rows.
+func buildBatch(b *testing.B, seq int) []byte {
+	timestamp := timeutil.Now()
+	converter := metric.NewProtoConverter()
+	var buf bytes.Buffer
+	for i := 0; i < rowsPerBatch; i++ {
+		var brokerRow metric.BrokerRow
+		if err := converter.ConvertTo(&protoMetricsV1.Metric{
+			Name:      "bench_write_protocol",
+			Timestamp: timestamp,
+			Tags: []*protoMetricsV1.KeyValue{
+				{Key: "host", Value: "host" + strconv.Itoa(i)},
+				{Key: "batch", Value: strconv.Itoa(seq % 10)},
+			},
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: float64(i)},
+			},
+		}, &brokerRow); err != nil {
+			b.Fatal(err)
+		}
+		_, _ = brokerRow.WriteTo(&buf)
+	}
+	return buf.Bytes()
+}
+
+func buildBatches(b *testing.B) [][]byte {
+	batches := make([][]byte, 10)
+	for i := range batches {
+		batches[i] = buildBatch(b, i)
+	}
+	return batches
+}
+
+// BenchmarkWrite_HTTP writes batches via http write api, request by request.
+func BenchmarkWrite_HTTP(b *testing.B) {
+	batches := buildBatches(b)
+	cli := resty.New()
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		r := cli.R()
+		r.Header.Set(headers.ContentType, constants.ContentTypeFlat)
+		resp, err := r.SetBody(batches[i%len(batches)]).Put(httpEndpoint)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if resp.IsError() {
+			b.Fatal(resp.String())
+		}
+	}
+	b.ReportMetric(float64(b.N*rowsPerBatch)/time.Since(start).Seconds(), "rows/s")
+}
+
+// BenchmarkWrite_GRPC writes batches via grpc streaming write, keeps at most window batches in flight.
+func BenchmarkWrite_GRPC(b *testing.B) {
+	batches := buildBatches(b)
+	conn, err := grpc.Dial(grpcEndpoint, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+	stream, err := protoIngestV1.NewMetricWriteClient(conn).Write(context.TODO())
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := stream.Send(&protoIngestV1.WriteRequest{
+		Handshake: &protoIngestV1.Handshake{Database: "_internal"},
+	}); err != nil {
+		b.Fatal(err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		b.Fatal(err)
+	}
+	window := int(resp.Window)
+	inflight := 0
+	recv := func() {
+		resp, err := stream.Recv()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if resp.Err != "" {
+			b.Fatal(fmt.Errorf("batch: %d, err: %s", resp.Sequence, resp.Err))
+		}
+		window = int(resp.Window)
+		inflight--
+	}
+	b.ResetTimer()
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		for inflight >= window {
+			recv()
+		}
+		if err := stream.Send(&protoIngestV1.WriteRequest{
+			Sequence: uint64(i + 1),
+			Rows:     batches[i%len(batches)],
+		}); err != nil {
+			b.Fatal(err)
+		}
+		inflight++
+	}
+	for inflight > 0 {
+		recv()
+	}
+	b.ReportMetric(float64(b.N*rowsPerBatch)/time.Since(start).Seconds(), "rows/s")
+	_ = stream.CloseSend()
+}
@@ -20,6 +20,8 @@ package flow
 import (
 	"context"
 	"encoding/binary"
+	"errors"
+	"fmt"
 	"sort"
 	"sync"
 	"time"
@@ -29,6 +31,7 @@ import (
 	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -57,6 +60,32 @@ func NewTaskContextWithTimeout(ctx context.Context, timeout time.Duration) *Task
 	}
 }
 
+// WithTimeout shrinks the deadline of task context if the timeout is less than current deadline.
+func (ctx *TaskContext) WithTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+	c, cancel := context.WithTimeout(ctx.Ctx, timeout)
+	parentCancel := ctx.Cancel
+	ctx.Ctx = c
+	ctx.Cancel = func() {
+		cancel()
+		parentCancel()
+	}
+}
+
+// CheckTimeout returns ErrTimeout if task's deadline exceeded, returns canceled error if task canceled.
+func (ctx *TaskContext) CheckTimeout() error {
+	if ctx == nil || ctx.Ctx == nil {
+		return nil
+	}
+	err := ctx.Ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return constants.ErrTimeout
+	}
+	return err
+}
+
 // Release releases context's resource after query.
 func (ctx *TaskContext) Release() {
 	ctx.Cancel()
@@ -91,9 +120,62 @@ type StorageExecuteContext struct {
 	// for group by query store tag value ids for each group tag key
 	GroupingTagValueIDs []*roaring.Bitmap
 
+	// MaxSeries is the max num. of series across all shards of current node for one query,
+	// which is the share of current node split from the series limit per query, 0 means no limit.
+	MaxSeries   int
+	numOfSeries atomic.Uint64
+
+	// CollectStats represents if it needs to collect the execution stats of operators(explain query),
+	// keep it false for normal query, avoid allocation on hot path.
+	CollectStats bool
+
+	// OnScan records the bytes of data scanned from data files, used for admission control of storage node.
+	OnScan func(bytes int)
+
+	// max data timestamp of each shard in query time range, only collected if query tracks timestamp,
+	// guarded by separate lock, because response may be sent when collecting tag values with lock.
+	shardMaxTimestamps map[models.ShardID]int64
+	timestampsLock     sync.Mutex
+
 	mutex sync.Mutex
 }
 
+// Context returns the context of query task which carries the trace span, returns background context if not set.
+func (ctx *StorageExecuteContext) Context() context.Context {
+	if ctx == nil || ctx.TaskCtx == nil || ctx.TaskCtx.Ctx == nil {
+		return context.Background()
+	}
+	return ctx.TaskCtx.Ctx
+}
+
+// AddScanBytes records the bytes of data scanned if OnScan is set.
+func (ctx *StorageExecuteContext) AddScanBytes(bytes int) {
+	if ctx.OnScan != nil {
+		ctx.OnScan(bytes)
+	}
+}
+
+// RecordMaxTimestamp records the max data timestamp of shard, keeps the bigger one.
+func (ctx *StorageExecuteContext) RecordMaxTimestamp(shardID models.ShardID, timestamp int64) {
+	ctx.timestampsLock.Lock()
+	defer ctx.timestampsLock.Unlock()
+
+	if ctx.shardMaxTimestamps == nil {
+		ctx.shardMaxTimestamps = make(map[models.ShardID]int64)
+	}
+	if timestamp > ctx.shardMaxTimestamps[shardID] {
+		ctx.shardMaxTimestamps[shardID] = timestamp
+	}
+}
+
+// ShardMaxTimestamps returns the max data timestamp of each shard recorded.
+func (ctx *StorageExecuteContext) ShardMaxTimestamps() map[models.ShardID]int64 {
+	ctx.timestampsLock.Lock()
+	defer ctx.timestampsLock.Unlock()
+
+	return ctx.shardMaxTimestamps
+}
+
 // CollectTagValues collects tag value with lock.
 func (ctx *StorageExecuteContext) CollectTagValues(fn func()) {
 	ctx.mutex.Lock()
@@ -229,6 +311,9 @@ type ShardExecuteContext struct {
 
 	GroupingContext         GroupingContext // after get grouping context if it has grouping query
 	SeriesIDsAfterFiltering *roaring.Bitmap // after data filter
+
+	// FamilyFilterStats collects the stats of data family which is filtering, nil if not collect stats.
+	FamilyFilterStats *models.DataFamilyFilterStats
 }
 
 // NewShardExecuteContext creates a shard execute context.
@@ -247,6 +332,29 @@ func (ctx *ShardExecuteContext) IsSeriesIDsEmpty() bool {
 	return ctx.SeriesIDsAfterFiltering.IsEmpty()
 }
 
+// CheckSeriesLimit adds the num. of series after filtering into storage context,
+// returns err if the num. of series across all shards of current node exceeds the share of series limit per query.
+func (ctx *ShardExecuteContext) CheckSeriesLimit() error {
+	storageCtx := ctx.StorageExecuteCtx
+	if storageCtx.MaxSeries <= 0 {
+		return nil
+	}
+	numOfSeries := storageCtx.numOfSeries.Add(ctx.SeriesIDsAfterFiltering.GetCardinality())
+	if numOfSeries > uint64(storageCtx.MaxSeries) {
+		return fmt.Errorf("%w, limit of node's shards: %d, please add more filter conditions",
+			constants.ErrTooManySeries, storageCtx.MaxSeries)
+	}
+	return nil
+}
+
+// CheckTimeout returns ErrTimeout if query's deadline exceeded.
+func (ctx *ShardExecuteContext) CheckTimeout() error {
+	if ctx.StorageExecuteCtx == nil {
+		return nil
+	}
+	return ctx.StorageExecuteCtx.TaskCtx.CheckTimeout()
+}
+
 // Release releases shard context's resource after query.
 func (ctx *ShardExecuteContext) Release() {
 	if ctx.TimeSegmentContext != nil {
@@ -294,10 +402,20 @@ type DataLoadContext struct {
 
 	Decoder      *encoding.TSDDecoder
 	DownSampling func(slotRange timeutil.SlotRange, seriesIdx uint16, fieldIdx int, getter encoding.TSDValueGetter)
+	// cumulative counter of series collected from all time segments, shared by all data load tasks
+	Counters *aggregation.CounterSeries
 
 	PendingDataLoadTasks *atomic.Int32
 }
 
+// Context returns the context of query task which carries the trace span, returns background context if not set.
+func (ctx *DataLoadContext) Context() context.Context {
+	if ctx == nil || ctx.ShardExecuteCtx == nil {
+		return context.Background()
+	}
+	return ctx.ShardExecuteCtx.StorageExecuteCtx.Context()
+}
+
 // PrepareAggregatorWithoutGrouping prepares context for without grouping query.
 func (ctx *DataLoadContext) PrepareAggregatorWithoutGrouping() {
 	ctx.WithoutGroupingSeriesAgg = &GroupingSeriesAgg{
@@ -428,6 +546,7 @@ func (ctx *DataLoadContext) IterateLowSeriesIDs(lowSeriesIDsFromStorage roaring.
 
 // Reduce reduces down sampling result.
 func (ctx *DataLoadContext) Reduce(reduceFn func(it series.GroupedIterator)) {
+	ctx.emitCounters()
 	if ctx.IsGrouping {
 		for _, groupAgg := range ctx.GroupingSeriesAgg {
 			groupAgg.reduce(reduceFn)
@@ -437,6 +556,19 @@ func (ctx *DataLoadContext) Reduce(reduceFn func(it series.GroupedIterator)) {
 	}
 }
 
+// emitCounters converts the cumulative counter of series into increase after all time segments loaded,
+// then merges increase into the series aggregator.
+func (ctx *DataLoadContext) emitCounters() {
+	if ctx.Counters == nil {
+		return
+	}
+	ctx.Counters.Emit(func(lowSeriesIdx uint16, fieldIdx int, familyTime int64, targetPos int, value float64) {
+		if agg, ok := ctx.GetSeriesAggregator(lowSeriesIdx, fieldIdx).GetAggregator(familyTime); ok {
+			agg.AggregateBySlot(targetPos, value)
+		}
+	})
+}
+
 // TimeSegmentContexts represents the time segment slice in query time range.
 type TimeSegmentContexts []*TimeSegmentResultSet
 
@@ -19,6 +19,7 @@ package flow
 
 import (
 	"context"
+	"errors"
 	"sort"
 	"testing"
 	"time"
@@ -29,6 +30,9 @@ import (
 	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
@@ -57,6 +61,15 @@ func TestStorageExecuteContext_collectGroupingTagValueIDs(t *testing.T) {
 func TestStorageExecuteContext(t *testing.T) {
 	assert.True(t, (&StorageExecuteContext{Query: &stmt.Query{Condition: &stmt.FieldExpr{}}}).HasWhereCondition())
 	assert.False(t, (&StorageExecuteContext{Query: &stmt.Query{}}).HasWhereCondition())
+
+	ctx := &StorageExecuteContext{}
+	ctx.AddScanBytes(10)
+	scanBytes := 0
+	ctx.OnScan = func(bytes int) {
+		scanBytes += bytes
+	}
+	ctx.AddScanBytes(10)
+	assert.Equal(t, 10, scanBytes)
 }
 
 func TestStorageExecuteContext_CalcSlotRange(t *testing.T) {
@@ -103,6 +116,15 @@ func TestStorageExecuteContext_SortFields(t *testing.T) {
 	assert.Equal(t, field.Metas{{ID: 1}, {ID: 3}, {ID: 4}}, ctx.Fields)
 }
 
+func TestStorageExecuteContext_RecordMaxTimestamp(t *testing.T) {
+	ctx := &StorageExecuteContext{}
+	assert.Nil(t, ctx.ShardMaxTimestamps())
+	ctx.RecordMaxTimestamp(1, 10)
+	ctx.RecordMaxTimestamp(1, 5)
+	ctx.RecordMaxTimestamp(2, 20)
+	assert.Equal(t, map[models.ShardID]int64{1: 10, 2: 20}, ctx.ShardMaxTimestamps())
+}
+
 func TestStorageExecuteContext_Release(t *testing.T) {
 	ctx := &StorageExecuteContext{
 		TaskCtx: NewTaskContextWithTimeout(context.TODO(), time.Second),
@@ -156,6 +178,47 @@ func TestShardExecuteContext(t *testing.T) {
 	ctx.Release()
 }
 
+func TestShardExecuteContext_CheckSeriesLimit(t *testing.T) {
+	// no limit
+	ctx := NewShardExecuteContext(&StorageExecuteContext{})
+	ctx.SeriesIDsAfterFiltering = roaring.BitmapOf(1, 2, 3)
+	assert.NoError(t, ctx.CheckSeriesLimit())
+
+	// count series across all shards
+	storageCtx := &StorageExecuteContext{MaxSeries: 5}
+	shard1 := NewShardExecuteContext(storageCtx)
+	shard1.SeriesIDsAfterFiltering = roaring.BitmapOf(1, 2, 3)
+	assert.NoError(t, shard1.CheckSeriesLimit())
+	shard2 := NewShardExecuteContext(storageCtx)
+	shard2.SeriesIDsAfterFiltering = roaring.BitmapOf(1, 2, 3)
+	err := shard2.CheckSeriesLimit()
+	assert.True(t, errors.Is(err, constants.ErrTooManySeries))
+}
+
+func TestTaskContext_Timeout(t *testing.T) {
+	var nilCtx *TaskContext
+	assert.NoError(t, nilCtx.CheckTimeout())
+
+	taskCtx := NewTaskContextWithTimeout(context.TODO(), time.Minute)
+	assert.NoError(t, taskCtx.CheckTimeout())
+	// ignore invalid timeout
+	taskCtx.WithTimeout(0)
+	assert.NoError(t, taskCtx.CheckTimeout())
+	// shrink deadline
+	taskCtx.WithTimeout(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	assert.Equal(t, constants.ErrTimeout, taskCtx.CheckTimeout())
+	shardCtx := NewShardExecuteContext(&StorageExecuteContext{TaskCtx: taskCtx})
+	assert.Equal(t, constants.ErrTimeout, shardCtx.CheckTimeout())
+	taskCtx.Release()
+	assert.NoError(t, (&ShardExecuteContext{}).CheckTimeout())
+
+	// canceled
+	taskCtx = NewTaskContextWithTimeout(context.TODO(), time.Minute)
+	taskCtx.Release()
+	assert.Equal(t, context.Canceled, taskCtx.CheckTimeout())
+}
+
 func TestGroupingSeriesAgg_reduce(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -345,3 +408,56 @@ func TestTimeSegmentContexts(t *testing.T) {
 	}
 	sort.Sort(segments)
 }
+
+func TestExecuteContext_Context(t *testing.T) {
+	var storageCtx *StorageExecuteContext
+	assert.NotNil(t, storageCtx.Context())
+	assert.NotNil(t, (&DataLoadContext{}).Context())
+
+	ctx := context.WithValue(context.TODO(), struct{}{}, "trace")
+	storageCtx = &StorageExecuteContext{TaskCtx: &TaskContext{Ctx: ctx}}
+	assert.Equal(t, ctx, storageCtx.Context())
+	loadCtx := &DataLoadContext{ShardExecuteCtx: NewShardExecuteContext(storageCtx)}
+	assert.Equal(t, ctx, loadCtx.Context())
+}
+
+func TestDataLoadContext_Reduce_Counters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	agg := aggregation.NewMockSeriesAggregator(ctrl)
+	agg.EXPECT().Reset().AnyTimes()
+	fAgg := aggregation.NewMockFieldAggregator(ctrl)
+	ctx := &DataLoadContext{
+		ShardExecuteCtx: &ShardExecuteContext{
+			StorageExecuteCtx: &StorageExecuteContext{
+				Query: &stmt.Query{},
+			},
+		},
+		WithoutGroupingSeriesAgg: &GroupingSeriesAgg{Aggregator: agg},
+		Counters:                 aggregation.NewCounterSeries(),
+	}
+	getter := encoding.NewMockTSDValueGetter(ctrl)
+	getter.EXPECT().GetValue(uint16(9)).Return(10.0, true)
+	getter.EXPECT().GetValue(uint16(0)).Return(15.0, true)
+	getter.EXPECT().GetValue(uint16(1)).Return(3.0, true)
+	target := timeutil.SlotRange{Start: 0, End: 20}
+	// load time segments out of order
+	ctx.Counters.Collect(0, 0, 10*timeutil.OneSecond, timeutil.Interval(timeutil.OneSecond),
+		timeutil.SlotRange{Start: 0, End: 1}, target, 1, 10, getter)
+	ctx.Counters.Collect(0, 0, 0, timeutil.Interval(timeutil.OneSecond),
+		timeutil.SlotRange{Start: 9, End: 9}, target, 1, 0, getter)
+	agg.EXPECT().GetAggregator(int64(10*timeutil.OneSecond)).Return(fAgg, true).Times(2)
+	// increase between time segments
+	fAgg.EXPECT().AggregateBySlot(10, 5.0)
+	// counter reset
+	fAgg.EXPECT().AggregateBySlot(11, 3.0)
+	ctx.Reduce(func(it series.GroupedIterator) {})
+
+	// time segment not found
+	getter.EXPECT().GetValue(gomock.Any()).Return(1.0, true).Times(2)
+	ctx.Counters.Collect(0, 0, 0, timeutil.Interval(timeutil.OneSecond),
+		timeutil.SlotRange{Start: 0, End: 1}, target, 1, 0, getter)
+	agg.EXPECT().GetAggregator(int64(0)).Return(nil, false)
+	ctx.Reduce(func(it series.GroupedIterator) {})
+}
@@ -0,0 +1,169 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/errcode"
+)
+
+const (
+	// ContentEncodingIdentity represents request body without compression.
+	ContentEncodingIdentity = "identity"
+	// ContentEncodingGzip represents request body compressed by gzip.
+	ContentEncodingGzip = "gzip"
+	// ContentEncodingZstd represents request body compressed by zstd.
+	ContentEncodingZstd = "zstd"
+)
+
+var decompressStatistics = metrics.NewDecompressStatistics()
+
+// countingReader counts the bytes read from underlying reader, keeps the error of underlying reader.
+type countingReader struct {
+	reader io.Reader
+	n      int64
+	err    error
+}
+
+// Read reads data from underlying reader.
+func (r *countingReader) Read(p []byte) (n int, err error) {
+	n, err = r.reader.Read(p)
+	r.n += int64(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+// DecompressedBody represents the request body decompressed by content encoding in streaming,
+// reading fails if the decompressed size exceeds the limit, so that decompression bomb cannot exhaust memory.
+type DecompressedBody struct {
+	body       io.ReadCloser
+	compressed *countingReader
+	reader     io.Reader
+	release    func()
+	encoding   string
+	maxSize    int64 // no limit if <= 0
+	read       int64
+	err        error
+	closed     bool
+}
+
+// NewDecompressedBody creates a decompressed body based on content encoding(identity/gzip/zstd),
+// maxSize is the max decompressed size of body(no limit if <= 0).
+func NewDecompressedBody(body io.ReadCloser, contentEncoding string, maxSize int64) (*DecompressedBody, error) {
+	encoding := strings.ToLower(strings.TrimSpace(contentEncoding))
+	if encoding == "" {
+		encoding = ContentEncodingIdentity
+	}
+	compressed := &countingReader{reader: body}
+	b := &DecompressedBody{
+		body:       body,
+		compressed: compressed,
+		encoding:   encoding,
+		maxSize:    maxSize,
+		release:    func() {},
+	}
+	switch encoding {
+	case ContentEncodingIdentity:
+		b.reader = compressed
+	case ContentEncodingGzip:
+		gzipReader, err := GetGzipReader(compressed)
+		if err != nil {
+			return nil, b.corrupted(err)
+		}
+		b.reader = gzipReader
+		b.release = func() { PutGzipReader(gzipReader) }
+	case ContentEncodingZstd:
+		zstdReader, err := GetZstdReader(compressed)
+		if err != nil {
+			return nil, b.corrupted(err)
+		}
+		b.reader = zstdReader
+		b.release = func() { PutZstdReader(zstdReader) }
+	default:
+		return nil, errcode.Wrap(errcode.InvalidArgument,
+			fmt.Errorf("not support content encoding: %s, only support %s/%s",
+				contentEncoding, ContentEncodingGzip, ContentEncodingZstd))
+	}
+	return b, nil
+}
+
+// Read reads decompressed data, returns ErrRequestBodyTooLarge if decompressed size exceeds the limit.
+func (b *DecompressedBody) Read(p []byte) (n int, err error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.maxSize > 0 {
+		// read one more byte for checking if body exceeds the limit
+		if remaining := b.maxSize - b.read + 1; int64(len(p)) > remaining {
+			p = p[:remaining]
+		}
+	}
+	n, err = b.reader.Read(p)
+	b.read += int64(n)
+	if b.maxSize > 0 && b.read > b.maxSize {
+		n -= int(b.read - b.maxSize)
+		b.read = b.maxSize
+		decompressStatistics.TooLarge.WithTagValues(b.encoding).Incr()
+		b.err = constants.ErrRequestBodyTooLarge
+		return n, b.err
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		b.err = b.corrupted(err)
+		return n, b.err
+	}
+	return n, err
+}
+
+// Err returns the failure of reading body(exceed limit/corrupted data), parser may stop at broken stream silently.
+func (b *DecompressedBody) Err() error {
+	return b.err
+}
+
+// Encoding returns the content encoding of body.
+func (b *DecompressedBody) Encoding() string {
+	return b.encoding
+}
+
+// Close records the throughput of body, then releases the decompression reader and closes the original body.
+func (b *DecompressedBody) Close() error {
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	decompressStatistics.CompressedBytes.WithTagValues(b.encoding).Add(float64(b.compressed.n))
+	decompressStatistics.DecompressedBytes.WithTagValues(b.encoding).Add(float64(b.read))
+	b.release()
+	return b.body.Close()
+}
+
+// corrupted returns the error of corrupted compressed data, returns err directly if failure of reading request body.
+func (b *DecompressedBody) corrupted(err error) error {
+	if b.compressed.err != nil || b.encoding == ContentEncodingIdentity {
+		return err
+	}
+	decompressStatistics.Corrupted.WithTagValues(b.encoding).Incr()
+	return fmt.Errorf("%w(%s): %v", constants.ErrCorruptedRequestBody, b.encoding, err)
+}
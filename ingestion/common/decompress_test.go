@@ -0,0 +1,235 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/errcode"
+)
+
+func gzipData(data []byte) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, _ = w.Write(data)
+	_ = w.Close()
+	return buf.Bytes()
+}
+
+func zstdData(data []byte) []byte {
+	w, _ := zstd.NewWriter(nil)
+	defer w.Close()
+	return w.EncodeAll(data, nil)
+}
+
+type errReader struct{}
+
+func (r *errReader) Read(_ []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestDecompressedBody_Read(t *testing.T) {
+	defer func() {
+		gzipReaderPool = sync.Pool{}
+		zstdReaderPool = sync.Pool{}
+	}()
+	data := []byte(strings.Repeat("cpu,host=1.1.1.1 load=1 1465839830100400200\n", 100))
+	// zeros are compressed with high ratio
+	bomb := make([]byte, 1024*1024)
+
+	cases := []struct {
+		name     string
+		body     []byte
+		reader   io.Reader
+		encoding string
+		maxSize  int64
+		wantData []byte
+		wantErr  error
+		newErr   bool
+	}{
+		{
+			name:     "no content encoding",
+			body:     data,
+			wantData: data,
+		},
+		{
+			name:     "identity",
+			body:     data,
+			encoding: "Identity",
+			maxSize:  int64(len(data)),
+			wantData: data,
+		},
+		{
+			name:     "gzip",
+			body:     gzipData(data),
+			encoding: " GZIP ",
+			maxSize:  int64(len(data)),
+			wantData: data,
+		},
+		{
+			name:     "zstd",
+			body:     zstdData(data),
+			encoding: "zstd",
+			wantData: data,
+		},
+		{
+			name:     "unsupported encoding",
+			body:     data,
+			encoding: "br",
+			newErr:   true,
+		},
+		{
+			name:     "corrupted gzip header",
+			body:     data,
+			encoding: "gzip",
+			wantErr:  constants.ErrCorruptedRequestBody,
+			newErr:   true,
+		},
+		{
+			name:     "corrupted zstd data",
+			body:     data,
+			encoding: "zstd",
+			wantErr:  constants.ErrCorruptedRequestBody,
+		},
+		{
+			name:     "truncated gzip",
+			body:     gzipData(data)[:50],
+			encoding: "gzip",
+			wantErr:  constants.ErrCorruptedRequestBody,
+		},
+		{
+			name:     "truncated zstd",
+			body:     zstdData(data)[:50],
+			encoding: "zstd",
+			wantErr:  constants.ErrCorruptedRequestBody,
+		},
+		{
+			name:     "identity exceeds limit",
+			body:     data,
+			maxSize:  int64(len(data) - 1),
+			wantErr:  constants.ErrRequestBodyTooLarge,
+			wantData: data[:len(data)-1],
+		},
+		{
+			name:     "gzip bomb",
+			body:     gzipData(bomb),
+			encoding: "gzip",
+			maxSize:  1024,
+			wantErr:  constants.ErrRequestBodyTooLarge,
+			wantData: bomb[:1024],
+		},
+		{
+			name:     "zstd bomb",
+			body:     zstdData(bomb),
+			encoding: "zstd",
+			maxSize:  1024,
+			wantErr:  constants.ErrRequestBodyTooLarge,
+			wantData: bomb[:1024],
+		},
+		{
+			name:     "read body failure",
+			reader:   io.MultiReader(bytes.NewReader(gzipData(data)[:50]), &errReader{}),
+			encoding: "gzip",
+			wantErr:  io.ErrClosedPipe,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			reader := tt.reader
+			if reader == nil {
+				reader = bytes.NewReader(tt.body)
+			}
+			body, err := NewDecompressedBody(io.NopCloser(reader), tt.encoding, tt.maxSize)
+			if tt.newErr {
+				assert.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				return
+			}
+			assert.NoError(t, err)
+			defer func() {
+				assert.NoError(t, body.Close())
+				// close twice
+				assert.NoError(t, body.Close())
+			}()
+			read, err := io.ReadAll(body)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, body.Err(), tt.wantErr)
+				// error is sticky
+				_, err = body.Read(make([]byte, 10))
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.NoError(t, body.Err())
+			}
+			if tt.wantData != nil {
+				assert.Equal(t, tt.wantData, read)
+			}
+		})
+	}
+}
+
+func TestDecompressedBody_ErrorCode(t *testing.T) {
+	_, err := NewDecompressedBody(io.NopCloser(bytes.NewReader(nil)), "br", 0)
+	assert.Equal(t, errcode.InvalidArgument, errcode.CodeOf(err))
+	_, err = NewDecompressedBody(io.NopCloser(bytes.NewReader([]byte("bad"))), "gzip", 0)
+	assert.Equal(t, errcode.InvalidArgument, errcode.CodeOf(err))
+
+	body, err := NewDecompressedBody(io.NopCloser(bytes.NewReader(gzipData(make([]byte, 100)))), "gzip", 10)
+	assert.NoError(t, err)
+	assert.Equal(t, ContentEncodingGzip, body.Encoding())
+	_, err = io.ReadAll(body)
+	assert.Equal(t, errcode.PayloadTooLarge, errcode.CodeOf(err))
+	assert.NoError(t, body.Close())
+}
+
+func Test_GetAndPutZstdReader(t *testing.T) {
+	defer func() {
+		zstdReaderPool = sync.Pool{}
+	}()
+	zstdReaderPool = sync.Pool{}
+	PutZstdReader(nil)
+	data := []byte("zstd")
+	for i := 0; i < 10; i++ {
+		r, err := GetZstdReader(bytes.NewReader(zstdData(data)))
+		assert.NoError(t, err)
+		read, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, data, read)
+		PutZstdReader(r)
+	}
+	// closed decoder cannot be reset
+	r, err := GetZstdReader(bytes.NewReader(nil))
+	assert.NoError(t, err)
+	r.Close()
+	PutZstdReader(r)
+	_, err = GetZstdReader(bytes.NewReader(nil))
+	assert.Error(t, err)
+}
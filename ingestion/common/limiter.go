@@ -0,0 +1,124 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"sync"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// RowLimiter enforces database's ingestion limits(metric name/tag value length, tags per series)
+// on rows in write path for all protocols(flat/proto/influx).
+type RowLimiter struct {
+	option *option.IngestLimitOption
+	limits *metric.RowLimits
+
+	violations map[metric.LimitViolation]*linmetric.BoundCounter
+	statistics *metrics.IngestLimitStatistics
+}
+
+// NewRowLimiter creates the row limiter by database's ingestion limits.
+func NewRowLimiter(database string, opt *option.IngestLimitOption) (*RowLimiter, error) {
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+	statistics := metrics.NewIngestLimitStatistics(database)
+	l := &RowLimiter{
+		option: opt,
+		limits: &metric.RowLimits{
+			MaxMetricNameLength: opt.MaxMetricNameLength,
+			MaxTagValueLength:   opt.MaxTagValueLength,
+			MaxTagsPerSeries:    opt.MaxTagsPerSeries,
+			Reject:              opt.GetAction() == option.IngestLimitReject,
+		},
+		violations: make(map[metric.LimitViolation]*linmetric.BoundCounter),
+		statistics: statistics,
+	}
+	for _, v := range []metric.LimitViolation{
+		metric.MetricNameTooLong, metric.TagValueTooLong, metric.TooManyTags,
+	} {
+		l.violations[v] = statistics.Violations.WithTagValues(v.String())
+	}
+	return l, nil
+}
+
+// Option returns the ingestion limits which limiter created by.
+func (l *RowLimiter) Option() *option.IngestLimitOption {
+	return l.option
+}
+
+// Limit checks rows against the ingestion limits, drops the violated rows in reject mode,
+// else truncates the violated rows.
+func (l *RowLimiter) Limit(rows *metric.BrokerBatchRows) error {
+	before := rows.Len()
+	if err := rows.EnforceLimits(l.limits, l.onViolation); err != nil {
+		return err
+	}
+	if rejected := before - rows.Len(); rejected > 0 {
+		l.statistics.RejectedRows.Add(float64(rejected))
+	}
+	return nil
+}
+
+// onViolation records the violation of rows.
+func (l *RowLimiter) onViolation(v metric.LimitViolation) {
+	l.violations[v].Incr()
+}
+
+// RowLimiterCache caches the row limiter of each database,
+// re-creates the limiter when database's ingestion limits changed(hot reload).
+type RowLimiterCache struct {
+	limiters map[string]*RowLimiter
+	mutex    sync.RWMutex
+}
+
+// NewRowLimiterCache creates the row limiter cache.
+func NewRowLimiterCache() *RowLimiterCache {
+	return &RowLimiterCache{limiters: make(map[string]*RowLimiter)}
+}
+
+// GetLimiter returns the row limiter of database, returns nil if database has no ingestion limits.
+func (c *RowLimiterCache) GetLimiter(database string, opt *option.IngestLimitOption) (*RowLimiter, error) {
+	c.mutex.RLock()
+	limiter, ok := c.limiters[database]
+	c.mutex.RUnlock()
+	if opt == nil {
+		if ok {
+			// ingestion limits removed
+			c.mutex.Lock()
+			delete(c.limiters, database)
+			c.mutex.Unlock()
+		}
+		return nil, nil
+	}
+	if ok && limiter.Option() == opt {
+		return limiter, nil
+	}
+	limiter, err := NewRowLimiter(database, opt)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	c.limiters[database] = limiter
+	c.mutex.Unlock()
+	return limiter, nil
+}
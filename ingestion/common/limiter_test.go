@@ -0,0 +1,88 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func TestRowLimiter_Limit(t *testing.T) {
+	// within limits
+	limiter, err := NewRowLimiter("db", &option.IngestLimitOption{MaxMetricNameLength: 4, MaxTagsPerSeries: 1})
+	assert.NoError(t, err)
+	rows := newSchemaTestRows(t, flatMetricsV1.SimpleFieldTypeLast)
+	assert.NoError(t, limiter.Limit(rows))
+	assert.Equal(t, 1, rows.Len())
+	m := rows.Rows()[0].Metric()
+	assert.Equal(t, "cpu", string(m.Name()))
+	rows.Release()
+
+	// reject row
+	limiter, err = NewRowLimiter("db", &option.IngestLimitOption{MaxMetricNameLength: 2, Action: option.IngestLimitReject})
+	assert.NoError(t, err)
+	rows = newSchemaTestRows(t, flatMetricsV1.SimpleFieldTypeLast)
+	assert.NoError(t, limiter.Limit(rows))
+	assert.Zero(t, rows.Len())
+	assert.Equal(t, float64(1), limiter.statistics.RejectedRows.Get())
+	assert.Equal(t, float64(1), limiter.violations[metric.MetricNameTooLong].Get())
+	rows.Release()
+
+	// invalid limits
+	limiter, err = NewRowLimiter("db", &option.IngestLimitOption{MaxTagValueLength: -1})
+	assert.Error(t, err)
+	assert.Nil(t, limiter)
+}
+
+func TestRowLimiterCache_GetLimiter(t *testing.T) {
+	cache := NewRowLimiterCache()
+	// no limits
+	limiter, err := cache.GetLimiter("db", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+	// invalid limits
+	limiter, err = cache.GetLimiter("db", &option.IngestLimitOption{Action: "abc"})
+	assert.Error(t, err)
+	assert.Nil(t, limiter)
+
+	opt := &option.IngestLimitOption{MaxTagValueLength: 256}
+	limiter, err = cache.GetLimiter("db", opt)
+	assert.NoError(t, err)
+	assert.Equal(t, opt, limiter.Option())
+	// cached
+	limiter2, err := cache.GetLimiter("db", opt)
+	assert.NoError(t, err)
+	assert.Same(t, limiter, limiter2)
+	// limits changed, reload
+	opt2 := &option.IngestLimitOption{MaxTagValueLength: 128}
+	limiter2, err = cache.GetLimiter("db", opt2)
+	assert.NoError(t, err)
+	assert.NotSame(t, limiter, limiter2)
+	assert.Equal(t, opt2, limiter2.Option())
+	// limits removed
+	limiter, err = cache.GetLimiter("db", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+	assert.Empty(t, cache.limiters)
+}
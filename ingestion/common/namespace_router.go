@@ -0,0 +1,127 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"sync"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// defaultRule represents the rule label of rows routed to default database(fallback mode).
+const defaultRule = "default"
+
+var routingStatistics = metrics.NewNamespaceRoutingStatistics()
+
+// NamespaceRouter routes rows written without database to databases by namespace of row.
+type NamespaceRouter struct {
+	routing *models.NamespaceRouting
+	rules   map[string]struct{}
+}
+
+// NewNamespaceRouter creates the namespace router by routing table.
+func NewNamespaceRouter(routing *models.NamespaceRouting) *NamespaceRouter {
+	rules := make(map[string]struct{}, len(routing.Routes))
+	for idx := range routing.Routes {
+		rules[routing.Routes[idx].Namespace] = struct{}{}
+	}
+	return &NamespaceRouter{
+		routing: routing,
+		rules:   rules,
+	}
+}
+
+// Routing returns the routing table which router created by.
+func (r *NamespaceRouter) Routing() *models.NamespaceRouting {
+	return r.routing
+}
+
+// Route splits rows into batches of each database, rejects the whole rows
+// if any row's namespace has no routing rule in reject mode.
+func (r *NamespaceRouter) Route(rows *metric.BrokerBatchRows) (map[string]*metric.BrokerBatchRows, error) {
+	type routed struct{ rule, database string }
+	counts := make(map[routed]int)
+	rejected := 0
+	batches := rows.Split(func(row *metric.BrokerRow) string {
+		namespace := string(row.Namespace())
+		database, ok := r.routing.Route(namespace)
+		if !ok {
+			rejected++
+			return ""
+		}
+		rule := defaultRule
+		if _, explicit := r.rules[namespace]; explicit {
+			rule = namespace
+		}
+		counts[routed{rule: rule, database: database}]++
+		return database
+	})
+	if rejected > 0 {
+		for _, batch := range batches {
+			if batch != rows {
+				batch.Release()
+			}
+		}
+		routingStatistics.RejectedRows.Add(float64(rejected))
+		routingStatistics.RejectedRequests.Incr()
+		return nil, constants.ErrNamespaceNotRouted
+	}
+	for key, count := range counts {
+		routingStatistics.RoutedRows.WithTagValues(key.rule, key.database).Add(float64(count))
+	}
+	return batches, nil
+}
+
+// NamespaceRouterCache caches the namespace router,
+// re-creates the router when routing table changed(hot reload).
+type NamespaceRouterCache struct {
+	router *NamespaceRouter
+	mutex  sync.RWMutex
+}
+
+// NewNamespaceRouterCache creates the namespace router cache.
+func NewNamespaceRouterCache() *NamespaceRouterCache {
+	return &NamespaceRouterCache{}
+}
+
+// GetRouter returns the namespace router of routing table, returns nil if routing table not set.
+func (c *NamespaceRouterCache) GetRouter(routing *models.NamespaceRouting) *NamespaceRouter {
+	c.mutex.RLock()
+	router := c.router
+	c.mutex.RUnlock()
+	if routing == nil {
+		if router != nil {
+			// routing table removed
+			c.mutex.Lock()
+			c.router = nil
+			c.mutex.Unlock()
+		}
+		return nil
+	}
+	if router != nil && router.Routing() == routing {
+		return router
+	}
+	router = NewNamespaceRouter(routing)
+	c.mutex.Lock()
+	c.router = router
+	c.mutex.Unlock()
+	return router
+}
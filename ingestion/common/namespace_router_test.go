@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	commonseries "github.com/lindb/common/series"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func newNamespaceTestRows(namespaces ...string) *metric.BrokerBatchRows {
+	rows := metric.NewBrokerBatchRows()
+	for _, namespace := range namespaces {
+		ns := namespace
+		_ = rows.TryAppend(func(row *metric.BrokerRow) error {
+			builder, releaseFunc := commonseries.NewRowBuilder()
+			defer releaseFunc(builder)
+			builder.AddNameSpace([]byte(ns))
+			builder.AddMetricName([]byte("cpu"))
+			_ = builder.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeLast, 1)
+			data, err := builder.Build()
+			if err != nil {
+				return err
+			}
+			row.FromBlock(data)
+			return nil
+		})
+	}
+	return rows
+}
+
+func TestNamespaceRouter_Route(t *testing.T) {
+	router := NewNamespaceRouter(&models.NamespaceRouting{
+		Routes:          []models.NamespaceRoute{{Namespace: "app", Database: "db1"}},
+		DefaultDatabase: "db2",
+	})
+	// fallback to default database
+	rows := newNamespaceTestRows("app", "other", "", "app")
+	routed := routingStatistics.RoutedRows.WithTagValues("app", "db1").Get()
+	batches, err := router.Route(rows)
+	assert.NoError(t, err)
+	assert.Len(t, batches, 2)
+	assert.Equal(t, 2, batches["db1"].Len())
+	assert.Equal(t, 2, batches["db2"].Len())
+	assert.Equal(t, routed+2, routingStatistics.RoutedRows.WithTagValues("app", "db1").Get())
+	rows.Release()
+
+	// reject unknown namespace
+	router = NewNamespaceRouter(&models.NamespaceRouting{
+		Routes:           []models.NamespaceRoute{{Namespace: "app", Database: "db1"}},
+		UnknownNamespace: models.UnknownNamespaceReject,
+	})
+	rows = newNamespaceTestRows("app", "other")
+	rejected := routingStatistics.RejectedRows.Get()
+	batches, err = router.Route(rows)
+	assert.ErrorIs(t, err, constants.ErrNamespaceNotRouted)
+	assert.Nil(t, batches)
+	assert.Equal(t, rejected+1, routingStatistics.RejectedRows.Get())
+	rows.Release()
+
+	// all rows routed to same database
+	rows = newNamespaceTestRows("app", "app")
+	batches, err = router.Route(rows)
+	assert.NoError(t, err)
+	assert.Same(t, rows, batches["db1"])
+	rows.Release()
+}
+
+func TestNamespaceRouterCache_GetRouter(t *testing.T) {
+	cache := NewNamespaceRouterCache()
+	assert.Nil(t, cache.GetRouter(nil))
+
+	routing := &models.NamespaceRouting{DefaultDatabase: "db"}
+	router := cache.GetRouter(routing)
+	assert.Same(t, routing, router.Routing())
+	// cached
+	assert.Same(t, router, cache.GetRouter(routing))
+	// routing changed, reload
+	routing2 := &models.NamespaceRouting{DefaultDatabase: "db2"}
+	router2 := cache.GetRouter(routing2)
+	assert.NotSame(t, router, router2)
+	assert.Same(t, routing2, router2.Routing())
+	// routing removed
+	assert.Nil(t, cache.GetRouter(nil))
+	assert.Nil(t, cache.router)
+}
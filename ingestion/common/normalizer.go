@@ -0,0 +1,224 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"bytes"
+	"regexp"
+	"sync"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/option"
+)
+
+// Normalizer normalizes metric name/tag keys of rows based on database's normalization rules,
+// applied in write path for all protocols(flat/proto/influx).
+type Normalizer struct {
+	option *option.NormalizeOption
+
+	lowercaseMetricNameHits *linmetric.BoundCounter
+	lowercaseTagKeyHits     *linmetric.BoundCounter
+	rewrites                []*metricNameRewrite
+	tagKeyRenames           map[string]*tagKeyRename
+	tagFilter               *tagFilter
+	namespaceTagFilters     map[string]*tagFilter
+}
+
+type metricNameRewrite struct {
+	pattern     *regexp.Regexp
+	replacement []byte
+	hits        *linmetric.BoundCounter
+}
+
+type tagKeyRename struct {
+	newKey []byte
+	hits   *linmetric.BoundCounter
+}
+
+type tagFilter struct {
+	allow     map[string]struct{}
+	deny      map[string]struct{}
+	allowHits *linmetric.BoundCounter
+	denyHits  *linmetric.BoundCounter
+}
+
+// NewNormalizer creates the normalizer by database's normalization rules.
+func NewNormalizer(database string, opt *option.NormalizeOption) (*Normalizer, error) {
+	if err := opt.Validate(); err != nil {
+		return nil, err
+	}
+	ruleHits := metrics.NewNormalizeStatistics(database).RuleHits
+	n := &Normalizer{
+		option:                  opt,
+		lowercaseMetricNameHits: ruleHits.WithTagValues("lowercase_metric_name"),
+		lowercaseTagKeyHits:     ruleHits.WithTagValues("lowercase_tag_key"),
+		tagKeyRenames:           make(map[string]*tagKeyRename),
+		tagFilter:               newTagFilter(opt.TagFilter, ruleHits, ""),
+		namespaceTagFilters:     make(map[string]*tagFilter),
+	}
+	for _, rewrite := range opt.MetricNameRewrites {
+		n.rewrites = append(n.rewrites, &metricNameRewrite{
+			pattern:     regexp.MustCompile(rewrite.Pattern),
+			replacement: []byte(rewrite.Replacement),
+			hits:        ruleHits.WithTagValues("metric_name_rewrite:" + rewrite.Pattern),
+		})
+	}
+	for oldKey, newKey := range opt.TagKeyRenames {
+		n.tagKeyRenames[oldKey] = &tagKeyRename{
+			newKey: []byte(newKey),
+			hits:   ruleHits.WithTagValues("tag_key_rename:" + oldKey),
+		}
+	}
+	for ns, filter := range opt.NamespaceTagFilters {
+		if filter := newTagFilter(filter, ruleHits, ns); filter != nil {
+			n.namespaceTagFilters[ns] = filter
+		}
+	}
+	return n, nil
+}
+
+// newTagFilter creates the tag filter, returns nil if no tag filter rule.
+func newTagFilter(opt *option.TagFilterOption, ruleHits *linmetric.DeltaCounterVec, namespace string) *tagFilter {
+	if opt == nil || (len(opt.Allow) == 0 && len(opt.Deny) == 0) {
+		return nil
+	}
+	suffix := ""
+	if namespace != "" {
+		suffix = ":" + namespace
+	}
+	filter := &tagFilter{
+		allowHits: ruleHits.WithTagValues("tag_filter_allow" + suffix),
+		denyHits:  ruleHits.WithTagValues("tag_filter_deny" + suffix),
+	}
+	if len(opt.Allow) > 0 {
+		filter.allow = make(map[string]struct{})
+		for _, tagKey := range opt.Allow {
+			filter.allow[tagKey] = struct{}{}
+		}
+	}
+	filter.deny = make(map[string]struct{})
+	for _, tagKey := range opt.Deny {
+		filter.deny[tagKey] = struct{}{}
+	}
+	return filter
+}
+
+// Option returns the normalization rules which normalizer created by.
+func (n *Normalizer) Option() *option.NormalizeOption {
+	return n.option
+}
+
+// NormalizeMetricName returns the normalized metric name, rewrites first, then lowercase.
+func (n *Normalizer) NormalizeMetricName(_, metricName []byte) []byte {
+	for _, rewrite := range n.rewrites {
+		if !rewrite.pattern.Match(metricName) {
+			continue
+		}
+		newName := rewrite.pattern.ReplaceAll(metricName, rewrite.replacement)
+		if len(newName) > 0 && !bytes.Equal(newName, metricName) {
+			metricName = newName
+			rewrite.hits.Incr()
+		}
+	}
+	if n.option.LowercaseMetricName && hasUpper(metricName) {
+		metricName = bytes.ToLower(metricName)
+		n.lowercaseMetricNameHits.Incr()
+	}
+	return metricName
+}
+
+// NormalizeTagKey returns the normalized tag key(lowercase, then rename),
+// returns false if tag key is dropped by tag filter.
+func (n *Normalizer) NormalizeTagKey(namespace, tagKey []byte) ([]byte, bool) {
+	if n.option.LowercaseTagKey && hasUpper(tagKey) {
+		tagKey = bytes.ToLower(tagKey)
+		n.lowercaseTagKeyHits.Incr()
+	}
+	if rename, ok := n.tagKeyRenames[string(tagKey)]; ok {
+		tagKey = rename.newKey
+		rename.hits.Incr()
+	}
+	filter, ok := n.namespaceTagFilters[string(namespace)]
+	if !ok {
+		filter = n.tagFilter
+	}
+	if filter == nil {
+		return tagKey, true
+	}
+	if filter.allow != nil {
+		if _, ok := filter.allow[string(tagKey)]; !ok {
+			filter.allowHits.Incr()
+			return nil, false
+		}
+	}
+	if _, ok := filter.deny[string(tagKey)]; ok {
+		filter.denyHits.Incr()
+		return nil, false
+	}
+	return tagKey, true
+}
+
+// hasUpper checks if value contains ASCII upper case letter.
+func hasUpper(value []byte) bool {
+	for _, c := range value {
+		if 'A' <= c && c <= 'Z' {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizerCache caches the normalizer of each database,
+// re-creates the normalizer when database's normalization rules changed(hot reload).
+type NormalizerCache struct {
+	normalizers map[string]*Normalizer
+	mutex       sync.RWMutex
+}
+
+// NewNormalizerCache creates the normalizer cache.
+func NewNormalizerCache() *NormalizerCache {
+	return &NormalizerCache{normalizers: make(map[string]*Normalizer)}
+}
+
+// GetNormalizer returns the normalizer of database, returns nil if database has no normalization rules.
+func (c *NormalizerCache) GetNormalizer(database string, opt *option.NormalizeOption) (*Normalizer, error) {
+	c.mutex.RLock()
+	normalizer, ok := c.normalizers[database]
+	c.mutex.RUnlock()
+	if opt == nil {
+		if ok {
+			// normalization rules removed
+			c.mutex.Lock()
+			delete(c.normalizers, database)
+			c.mutex.Unlock()
+		}
+		return nil, nil
+	}
+	if ok && normalizer.Option() == opt {
+		return normalizer, nil
+	}
+	normalizer, err := NewNormalizer(database, opt)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	c.normalizers[database] = normalizer
+	c.mutex.Unlock()
+	return normalizer, nil
+}
@@ -0,0 +1,121 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/option"
+)
+
+func TestNormalizer_NormalizeMetricName(t *testing.T) {
+	normalizer, err := NewNormalizer("db", &option.NormalizeOption{
+		LowercaseMetricName: true,
+		MetricNameRewrites: []option.MetricNameRewrite{
+			{Pattern: "^System_(.*)$", Replacement: "sys_$1"},
+			{Pattern: "^drop$", Replacement: ""},
+		},
+	})
+	assert.NoError(t, err)
+	cases := []struct {
+		in, out string
+	}{
+		{in: "cpu", out: "cpu"},
+		{in: "CPU", out: "cpu"},
+		{in: "System_CPU", out: "sys_cpu"},
+		{in: "drop", out: "drop"}, // empty metric name after rewrite, keep it
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.out, string(normalizer.NormalizeMetricName(nil, []byte(tt.in))))
+	}
+}
+
+func TestNormalizer_NormalizeTagKey(t *testing.T) {
+	normalizer, err := NewNormalizer("db", &option.NormalizeOption{
+		LowercaseTagKey: true,
+		TagKeyRenames:   map[string]string{"hostname": "host"},
+		TagFilter:       &option.TagFilterOption{Deny: []string{"pid"}},
+		NamespaceTagFilters: map[string]*option.TagFilterOption{
+			"ns":    {Allow: []string{"host", "pid"}, Deny: []string{"pid"}},
+			"empty": {},
+		},
+	})
+	assert.NoError(t, err)
+	cases := []struct {
+		namespace, in, out string
+		keep               bool
+	}{
+		{in: "ip", out: "ip", keep: true},
+		{in: "IP", out: "ip", keep: true},
+		{in: "HostName", out: "host", keep: true},
+		{in: "pid", keep: false},
+		{namespace: "empty", in: "pid", keep: false},
+		{namespace: "ns", in: "hostname", out: "host", keep: true},
+		{namespace: "ns", in: "ip", keep: false},
+		{namespace: "ns", in: "pid", keep: false},
+	}
+	for _, tt := range cases {
+		tagKey, keep := normalizer.NormalizeTagKey([]byte(tt.namespace), []byte(tt.in))
+		assert.Equal(t, tt.keep, keep, tt.in)
+		if keep {
+			assert.Equal(t, tt.out, string(tagKey))
+		}
+	}
+
+	normalizer, err = NewNormalizer("db", &option.NormalizeOption{})
+	assert.NoError(t, err)
+	tagKey, keep := normalizer.NormalizeTagKey(nil, []byte("IP"))
+	assert.True(t, keep)
+	assert.Equal(t, "IP", string(tagKey))
+}
+
+func TestNormalizerCache_GetNormalizer(t *testing.T) {
+	cache := NewNormalizerCache()
+	// no rules
+	normalizer, err := cache.GetNormalizer("db", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, normalizer)
+	// invalid rules
+	normalizer, err = cache.GetNormalizer("db", &option.NormalizeOption{
+		MetricNameRewrites: []option.MetricNameRewrite{{Pattern: "a[b"}},
+	})
+	assert.Error(t, err)
+	assert.Nil(t, normalizer)
+
+	opt := &option.NormalizeOption{LowercaseTagKey: true}
+	normalizer, err = cache.GetNormalizer("db", opt)
+	assert.NoError(t, err)
+	assert.Equal(t, opt, normalizer.Option())
+	// cached
+	normalizer2, err := cache.GetNormalizer("db", opt)
+	assert.NoError(t, err)
+	assert.Same(t, normalizer, normalizer2)
+	// rules changed, reload
+	opt2 := &option.NormalizeOption{LowercaseMetricName: true}
+	normalizer2, err = cache.GetNormalizer("db", opt2)
+	assert.NoError(t, err)
+	assert.NotSame(t, normalizer, normalizer2)
+	assert.Equal(t, opt2, normalizer2.Option())
+	// rules removed
+	normalizer, err = cache.GetNormalizer("db", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, normalizer)
+	assert.Empty(t, cache.normalizers)
+}
@@ -0,0 +1,154 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"sync"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// fieldTypes represents the field type of schema => simple field type of row.
+var fieldTypes = map[string]flatMetricsV1.SimpleFieldType{
+	"sum":   flatMetricsV1.SimpleFieldTypeDeltaSum,
+	"min":   flatMetricsV1.SimpleFieldTypeMin,
+	"max":   flatMetricsV1.SimpleFieldTypeMax,
+	"last":  flatMetricsV1.SimpleFieldTypeLast,
+	"first": flatMetricsV1.SimpleFieldTypeFirst,
+}
+
+// SchemaEnforcer enforces database's metric schema registry on rows in write path,
+// rows of unregistered metrics are written as is.
+type SchemaEnforcer struct {
+	schema *models.DatabaseSchema
+	// namespace => metric name => schema of metric
+	metrics map[string]map[string]*metric.RowSchema
+
+	violations map[metric.SchemaViolation]*linmetric.BoundCounter
+	statistics *metrics.SchemaStatistics
+}
+
+// NewSchemaEnforcer creates the schema enforcer by database's metric schema registry.
+func NewSchemaEnforcer(schema *models.DatabaseSchema) (*SchemaEnforcer, error) {
+	if err := schema.Validate(); err != nil {
+		return nil, err
+	}
+	statistics := metrics.NewSchemaStatistics(schema.Database)
+	e := &SchemaEnforcer{
+		schema:     schema,
+		metrics:    make(map[string]map[string]*metric.RowSchema),
+		violations: make(map[metric.SchemaViolation]*linmetric.BoundCounter),
+		statistics: statistics,
+	}
+	for _, v := range []metric.SchemaViolation{
+		metric.UnknownTagKey, metric.UnknownField, metric.FieldTypeMismatch, metric.NoFieldLeft,
+	} {
+		e.violations[v] = statistics.Violations.WithTagValues(v.String())
+	}
+	for idx := range schema.Metrics {
+		metricSchema := &schema.Metrics[idx]
+		rowSchema := &metric.RowSchema{}
+		if len(metricSchema.Fields) > 0 {
+			rowSchema.Fields = make(map[string]flatMetricsV1.SimpleFieldType)
+			for _, f := range metricSchema.Fields {
+				rowSchema.Fields[f.Name] = fieldTypes[f.Type]
+			}
+		}
+		if len(metricSchema.TagKeys) > 0 {
+			rowSchema.TagKeys = make(map[string]struct{})
+			for _, tagKey := range metricSchema.TagKeys {
+				rowSchema.TagKeys[tagKey] = struct{}{}
+			}
+		}
+		namespace := metricSchema.GetNamespace()
+		if _, ok := e.metrics[namespace]; !ok {
+			e.metrics[namespace] = make(map[string]*metric.RowSchema)
+		}
+		e.metrics[namespace][metricSchema.Name] = rowSchema
+	}
+	return e, nil
+}
+
+// Schema returns the metric schema registry which enforcer created by.
+func (e *SchemaEnforcer) Schema() *models.DatabaseSchema {
+	return e.schema
+}
+
+// Enforce checks rows against the metric schema registry, rejects the rows in strict mode,
+// else strips/coerces the violated rows.
+func (e *SchemaEnforcer) Enforce(rows *metric.BrokerBatchRows) error {
+	err := rows.EnforceSchema(e.lookup, e.schema.GetMode() == models.SchemaModeStrict, e.onViolation)
+	if err != nil {
+		e.statistics.RejectedRequests.Incr()
+	}
+	return err
+}
+
+// lookup returns the schema of metric, returns nil if metric is not registered.
+func (e *SchemaEnforcer) lookup(namespace, metricName []byte) *metric.RowSchema {
+	return e.metrics[string(namespace)][string(metricName)]
+}
+
+// onViolation records the violation of rows.
+func (e *SchemaEnforcer) onViolation(v metric.SchemaViolation) {
+	e.violations[v].Incr()
+}
+
+// SchemaEnforcerCache caches the schema enforcer of each database,
+// re-creates the enforcer when database's metric schema registry changed.
+type SchemaEnforcerCache struct {
+	enforcers map[string]*SchemaEnforcer
+	mutex     sync.RWMutex
+}
+
+// NewSchemaEnforcerCache creates the schema enforcer cache.
+func NewSchemaEnforcerCache() *SchemaEnforcerCache {
+	return &SchemaEnforcerCache{enforcers: make(map[string]*SchemaEnforcer)}
+}
+
+// GetEnforcer returns the schema enforcer of database, returns nil if database has no metric schema registry.
+func (c *SchemaEnforcerCache) GetEnforcer(database string, schema *models.DatabaseSchema) (*SchemaEnforcer, error) {
+	c.mutex.RLock()
+	enforcer, ok := c.enforcers[database]
+	c.mutex.RUnlock()
+	if schema == nil {
+		if ok {
+			// metric schema registry removed
+			c.mutex.Lock()
+			delete(c.enforcers, database)
+			c.mutex.Unlock()
+		}
+		return nil, nil
+	}
+	if ok && enforcer.Schema() == schema {
+		return enforcer, nil
+	}
+	enforcer, err := NewSchemaEnforcer(schema)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	c.enforcers[database] = enforcer
+	c.mutex.Unlock()
+	return enforcer, nil
+}
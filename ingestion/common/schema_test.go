@@ -0,0 +1,116 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	commonseries "github.com/lindb/common/series"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func newSchemaTestRows(t *testing.T, fieldType flatMetricsV1.SimpleFieldType) *metric.BrokerBatchRows {
+	rows := metric.NewBrokerBatchRows()
+	assert.NoError(t, rows.TryAppend(func(row *metric.BrokerRow) error {
+		builder, releaseFunc := commonseries.NewRowBuilder()
+		defer releaseFunc(builder)
+
+		builder.AddMetricName([]byte("cpu"))
+		builder.AddNameSpace([]byte("default-ns"))
+		_ = builder.AddTag([]byte("host"), []byte("a"))
+		_ = builder.AddSimpleField([]byte("usage"), fieldType, 1)
+		builder.AddTimestamp(1000)
+		data, err := builder.Build()
+		if err != nil {
+			return err
+		}
+		row.FromBlock(data)
+		return nil
+	}))
+	return rows
+}
+
+func TestSchemaEnforcer_Enforce(t *testing.T) {
+	schema := &models.DatabaseSchema{
+		Database: "db",
+		Metrics: []models.MetricSchema{{
+			Name:    "cpu",
+			Fields:  []models.FieldSchema{{Name: "usage", Type: "last"}},
+			TagKeys: []string{"host"},
+		}},
+	}
+	// lenient, coerce field type
+	enforcer, err := NewSchemaEnforcer(schema)
+	assert.NoError(t, err)
+	rows := newSchemaTestRows(t, flatMetricsV1.SimpleFieldTypeDeltaSum)
+	assert.NoError(t, enforcer.Enforce(rows))
+	m := rows.Rows()[0].Metric()
+	var f flatMetricsV1.SimpleField
+	m.SimpleFields(&f, 0)
+	assert.Equal(t, flatMetricsV1.SimpleFieldTypeLast, f.Type())
+	rows.Release()
+
+	// strict, reject rows
+	schema.Mode = models.SchemaModeStrict
+	enforcer, err = NewSchemaEnforcer(schema)
+	assert.NoError(t, err)
+	rows = newSchemaTestRows(t, flatMetricsV1.SimpleFieldTypeDeltaSum)
+	assert.ErrorIs(t, enforcer.Enforce(rows), metric.ErrSchemaViolation)
+	rows.Release()
+	// strict, valid rows
+	rows = newSchemaTestRows(t, flatMetricsV1.SimpleFieldTypeLast)
+	assert.NoError(t, enforcer.Enforce(rows))
+	rows.Release()
+}
+
+func TestSchemaEnforcerCache_GetEnforcer(t *testing.T) {
+	cache := NewSchemaEnforcerCache()
+	// no schema
+	enforcer, err := cache.GetEnforcer("db", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, enforcer)
+	// invalid schema
+	enforcer, err = cache.GetEnforcer("db", &models.DatabaseSchema{Database: "db", Mode: "abc"})
+	assert.Error(t, err)
+	assert.Nil(t, enforcer)
+
+	schema := &models.DatabaseSchema{Database: "db"}
+	enforcer, err = cache.GetEnforcer("db", schema)
+	assert.NoError(t, err)
+	assert.Equal(t, schema, enforcer.Schema())
+	// cached
+	enforcer2, err := cache.GetEnforcer("db", schema)
+	assert.NoError(t, err)
+	assert.Same(t, enforcer, enforcer2)
+	// schema changed, reload
+	schema2 := &models.DatabaseSchema{Database: "db", Mode: models.SchemaModeStrict}
+	enforcer2, err = cache.GetEnforcer("db", schema2)
+	assert.NoError(t, err)
+	assert.NotSame(t, enforcer, enforcer2)
+	assert.Equal(t, schema2, enforcer2.Schema())
+	// schema removed
+	enforcer, err = cache.GetEnforcer("db", nil)
+	assert.NoError(t, err)
+	assert.Nil(t, enforcer)
+	assert.Empty(t, cache.enforcers)
+}
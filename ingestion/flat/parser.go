@@ -18,6 +18,7 @@
 package flat
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"net/http"
@@ -37,6 +38,29 @@ var (
 
 var flatLogger = logger.GetLogger("Ingestion", "Flat")
 
+// RejectedRows represents the rows dropped when decoding batch.
+type RejectedRows struct {
+	Count   int
+	Reasons []string // distinct reasons, at most maxRejectedReasons
+}
+
+const maxRejectedReasons = 8
+
+// add records a dropped row with reason.
+func (r *RejectedRows) add(err error) {
+	r.Count++
+	if len(r.Reasons) >= maxRejectedReasons {
+		return
+	}
+	reason := err.Error()
+	for _, exist := range r.Reasons {
+		if exist == reason {
+			return
+		}
+	}
+	r.Reasons = append(r.Reasons, reason)
+}
+
 func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.BrokerBatchRows, error) {
 	var reader = req.Body
 	if strings.EqualFold(req.Header.Get("Content-Encoding"), "gzip") {
@@ -51,7 +75,7 @@ func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.
 	bufioReader, releaseBufioReaderFunc := ingestCommon.NewBufioReader(reader)
 	defer releaseBufioReaderFunc(bufioReader)
 
-	batch, err := parseFlatMetric(reader, enrichedTags, namespace)
+	batch, err := parseFlatMetric(reader, enrichedTags, namespace, nil)
 	if err != nil {
 		flatIngestionStatistics.CorruptedData.Incr()
 		return nil, err
@@ -63,10 +87,23 @@ func Parse(req *http.Request, enrichedTags tag.Tags, namespace string) (*metric.
 	return batch, nil
 }
 
+// ParseRows parses flat buffer encoded rows(not compressed), returns the rows dropped when decoding.
+func ParseRows(data []byte, enrichedTags tag.Tags, namespace string) (*metric.BrokerBatchRows, *RejectedRows, error) {
+	rejected := &RejectedRows{}
+	batch, err := parseFlatMetric(bytes.NewReader(data), enrichedTags, namespace, rejected)
+	if err != nil {
+		flatIngestionStatistics.CorruptedData.Incr()
+		return nil, nil, err
+	}
+	flatIngestionStatistics.IngestedMetrics.Add(float64(batch.Len()))
+	return batch, rejected, nil
+}
+
 func parseFlatMetric(
 	reader io.Reader,
 	enrichedTags tag.Tags,
 	namespace string,
+	rejected *RejectedRows,
 ) (
 	batch *metric.BrokerBatchRows, err error,
 ) {
@@ -89,6 +126,9 @@ func parseFlatMetric(
 		if err := batch.TryAppend(decoder.DecodeTo); err != nil {
 			flatLogger.Warn("failed ingesting flat metric", logger.Error(err))
 			flatIngestionStatistics.DroppedMetric.Incr()
+			if rejected != nil {
+				rejected.add(err)
+			}
 		}
 	}
 
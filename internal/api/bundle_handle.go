@@ -0,0 +1,219 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	httppkg "github.com/lindb/lindb/pkg/http"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+var (
+	BundlePath = "/debug/bundle"
+)
+
+const (
+	// bundleInterval represents the min interval of generating diagnostics bundle,
+	// generating bundle is expensive(profiles/log files), so it's rate limited.
+	bundleInterval = time.Minute
+	// bundleLogTailSize represents the tail size of each log file in diagnostics bundle.
+	bundleLogTailSize = 1024 * 1024
+)
+
+// BundleItem represents the diagnostics item which is written into bundle as json file.
+type BundleItem struct {
+	Name    string                      // file name(without extension) in bundle
+	Collect func() (interface{}, error) // collects the diagnostics data of item
+}
+
+// DiagnosticsAPI represents diagnostics bundle rest api,
+// which captures profiles/config/state/log tail of current node into a single zip file.
+type DiagnosticsAPI struct {
+	node    models.Node
+	cfg     config.Configuration
+	logDir  string
+	items   []BundleItem
+	limiter *rate.Limiter
+	logger  *logger.Logger
+}
+
+// NewDiagnosticsAPI creates a DiagnosticsAPI instance.
+func NewDiagnosticsAPI(node models.Node, cfg config.Configuration, logDir string, items ...BundleItem) *DiagnosticsAPI {
+	return &DiagnosticsAPI{
+		node:    node,
+		cfg:     cfg,
+		logDir:  logDir,
+		items:   items,
+		limiter: rate.NewLimiter(rate.Every(bundleInterval), 1),
+		logger:  logger.GetLogger("Monitoring", "DiagnosticsAPI"),
+	}
+}
+
+// Register adds diagnostics bundle url route.
+func (d *DiagnosticsAPI) Register(route gin.IRoutes) {
+	route.GET(BundlePath, d.Bundle)
+}
+
+// Bundle returns the diagnostics bundle of current node as zip file.
+
+// @Summary diagnostics bundle
+// @Description return diagnostics bundle(goroutine/heap profiles, config, state, slow queries and log tail) as zip file,
+// @Description at most one bundle is generated per minute.
+// @Tags State
+// @Produce application/zip
+// @Success 200 {file} file
+// @Failure 429 {string} string "too many requests"
+// @Failure 500 {string} string "internal error"
+// @Router /debug/bundle [get]
+func (d *DiagnosticsAPI) Bundle(c *gin.Context) {
+	if !d.limiter.Allow() {
+		httppkg.TooManyRequests(c, fmt.Sprintf("diagnostics bundle can be generated at most once per %s", bundleInterval))
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err := d.writeBundle(buf); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	fileName := fmt.Sprintf("lindb-bundle-%s-%s.zip",
+		strings.ReplaceAll(d.node.Indicator(), ":", "_"),
+		timeutil.FormatTimestamp(timeutil.Now(), "20060102150405"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// writeBundle writes all diagnostics into zip.
+func (d *DiagnosticsAPI) writeBundle(w io.Writer) error {
+	zw := zip.NewWriter(w)
+	// goroutine stacks as text, heap as pprof format
+	if err := writeBundleFile(zw, "profile/goroutine.txt", func(w io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(w, 2)
+	}); err != nil {
+		return err
+	}
+	if err := writeBundleFile(zw, "profile/heap.pprof", func(w io.Writer) error {
+		return pprof.Lookup("heap").WriteTo(w, 0)
+	}); err != nil {
+		return err
+	}
+	if err := writeBundleJSON(zw, "node.json", d.node); err != nil {
+		return err
+	}
+	if err := writeBundleFile(zw, "config.toml", func(w io.Writer) error {
+		_, err := io.WriteString(w, config.Redact(d.cfg))
+		return err
+	}); err != nil {
+		return err
+	}
+	for _, item := range d.items {
+		data, err := item.Collect()
+		if err != nil {
+			// keep generating bundle, record the failure of item
+			data = map[string]string{"error": err.Error()}
+		}
+		if err := writeBundleJSON(zw, item.Name+".json", data); err != nil {
+			return err
+		}
+	}
+	if err := d.writeLogTail(zw); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeLogTail writes the tail of log files into zip, ignores the log file which cannot be read.
+func (d *DiagnosticsAPI) writeLogTail(zw *zip.Writer) error {
+	files, err := readDirFn(d.logDir)
+	if err != nil {
+		d.logger.Warn("read log dir failure when generating diagnostics bundle",
+			logger.String("dir", d.logDir), logger.Error(err))
+		return nil
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, ".log") {
+			continue
+		}
+		if err := d.writeLogFileTail(zw, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLogFileTail writes the tail of log file into zip.
+func (d *DiagnosticsAPI) writeLogFileTail(zw *zip.Writer, name string) error {
+	f, err := openFn(filepath.Join(d.logDir, name))
+	if err != nil {
+		d.logger.Warn("open log file failure when generating diagnostics bundle",
+			logger.String("file", name), logger.Error(err))
+		return nil
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	stat, err := f.Stat()
+	if err != nil {
+		d.logger.Warn("get log file stat failure when generating diagnostics bundle",
+			logger.String("file", name), logger.Error(err))
+		return nil
+	}
+	if stat.Size() > bundleLogTailSize {
+		if _, err := f.Seek(stat.Size()-bundleLogTailSize, io.SeekStart); err != nil {
+			return err
+		}
+	}
+	return writeBundleFile(zw, "log/"+name, func(w io.Writer) error {
+		_, err := io.Copy(w, f)
+		return err
+	})
+}
+
+// writeBundleJSON writes the data as json file into zip.
+func writeBundleJSON(zw *zip.Writer, name string, data interface{}) error {
+	return writeBundleFile(zw, name, func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(data)
+	})
+}
+
+// writeBundleFile creates the file in zip, then writes the content.
+func writeBundleFile(zw *zip.Writer, name string, write func(w io.Writer) error) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	return write(w)
+}
@@ -0,0 +1,115 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/mock"
+	"github.com/lindb/lindb/models"
+)
+
+func TestDiagnosticsAPI_Bundle(t *testing.T) {
+	logDir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(logDir, "lind-broker.log"), []byte("line1\nline2\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(logDir, "other.txt"), []byte("ignore"), 0644))
+	assert.NoError(t, os.Mkdir(filepath.Join(logDir, "dir.log"), 0755))
+	cfg := &config.Broker{Coordinator: config.RepoState{Namespace: "ns", Password: "etcd-pwd"}}
+
+	api := NewDiagnosticsAPI(&models.StatelessNode{HostIP: "1.1.1.1", HTTPPort: 9000}, cfg, logDir,
+		BundleItem{Name: "state", Collect: func() (interface{}, error) {
+			return map[string]string{"key": "value"}, nil
+		}},
+		BundleItem{Name: "failure", Collect: func() (interface{}, error) {
+			return nil, fmt.Errorf("collect failure")
+		}},
+	)
+	r := gin.New()
+	api.Register(r)
+	resp := mock.DoRequest(t, r, http.MethodGet, BundlePath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "application/zip", resp.Header().Get("Content-Type"))
+	assert.Contains(t, resp.Header().Get("Content-Disposition"), "lindb-bundle-1.1.1.1_9000-")
+
+	zr, err := zip.NewReader(bytes.NewReader(resp.Body.Bytes()), int64(resp.Body.Len()))
+	assert.NoError(t, err)
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		data, err := io.ReadAll(r)
+		assert.NoError(t, err)
+		files[f.Name] = string(data)
+	}
+	assert.Len(t, files, 7)
+	assert.Contains(t, files["profile/goroutine.txt"], "goroutine")
+	assert.NotEmpty(t, files["profile/heap.pprof"])
+	assert.Contains(t, files["node.json"], "1.1.1.1")
+	assert.Contains(t, files["config.toml"], "ns")
+	assert.NotContains(t, files["config.toml"], "etcd-pwd")
+	assert.Contains(t, files["state.json"], "value")
+	assert.Contains(t, files["failure.json"], "collect failure")
+	assert.Equal(t, "line1\nline2\n", files["log/lind-broker.log"])
+
+	// rate limited
+	resp = mock.DoRequest(t, r, http.MethodGet, BundlePath, "")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+}
+
+func TestDiagnosticsAPI_writeLogTail(t *testing.T) {
+	defer func() {
+		readDirFn = os.ReadDir
+		openFn = os.Open
+	}()
+	logDir := t.TempDir()
+	content := strings.Repeat("a", bundleLogTailSize) + "tail"
+	assert.NoError(t, os.WriteFile(filepath.Join(logDir, "lind.log"), []byte(content), 0644))
+	api := NewDiagnosticsAPI(&models.StatelessNode{}, &config.Broker{}, logDir)
+
+	buf := &bytes.Buffer{}
+	zw := zip.NewWriter(buf)
+	assert.NoError(t, api.writeLogTail(zw))
+	assert.NoError(t, zw.Close())
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, zr.File, 1)
+	assert.Equal(t, uint64(bundleLogTailSize), zr.File[0].UncompressedSize64)
+
+	// open log file failure, ignore it
+	openFn = func(_ string) (*os.File, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.NoError(t, api.writeLogTail(zip.NewWriter(&bytes.Buffer{})))
+	// read log dir failure, ignore it
+	readDirFn = func(_ string) ([]os.DirEntry, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.NoError(t, api.writeLogTail(zip.NewWriter(&bytes.Buffer{})))
+}
@@ -48,7 +48,7 @@ func (h *ConfigAPI) Register(route gin.IRoutes) {
 	route.GET(ConfigPath, h.Configuration)
 }
 
-// Configuration returns current node's configuration.
+// Configuration returns current node's configuration, sensitive values are redacted.
 
 // @Summary current node's configuration
 // @Description return current node's configuration.
@@ -58,5 +58,5 @@ func (h *ConfigAPI) Register(route gin.IRoutes) {
 // @Success 200 {object} object
 // @Router /config [get]
 func (h *ConfigAPI) Configuration(c *gin.Context) {
-	http.OK(c, map[string]interface{}{"node": h.node, "config": h.cfg.TOML()})
+	http.OK(c, map[string]interface{}{"node": h.node, "config": config.Redact(h.cfg)})
 }
@@ -0,0 +1,64 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package api
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/http"
+)
+
+var (
+	LivenessPath  = "/health/live"
+	ReadinessPath = "/health/ready"
+)
+
+// HealthAPI represents the liveness/readiness probe api.
+type HealthAPI struct {
+	readiness func() *models.Readiness
+}
+
+// NewHealthAPI creates a HealthAPI instance, readiness evaluates the readiness of current node.
+func NewHealthAPI(readiness func() *models.Readiness) *HealthAPI {
+	return &HealthAPI{
+		readiness: readiness,
+	}
+}
+
+// Register adds health check url route.
+func (api *HealthAPI) Register(route gin.IRoutes) {
+	route.GET(LivenessPath, api.Live)
+	route.GET(ReadinessPath, api.Ready)
+}
+
+// Live returns ok if the process is alive, no dependency check.
+func (api *HealthAPI) Live(c *gin.Context) {
+	http.OK(c, "ok")
+}
+
+// Ready returns the result of each readiness check,
+// responses with status code 503 if any check fails.
+func (api *HealthAPI) Ready(c *gin.Context) {
+	readiness := api.readiness()
+	if !readiness.Ready {
+		http.ServiceUnavailable(c, readiness)
+		return
+	}
+	http.OK(c, readiness)
+}
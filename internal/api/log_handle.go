@@ -19,6 +19,7 @@ package api
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -30,6 +31,7 @@ import (
 	"github.com/lindb/lindb/constants"
 	httppkg "github.com/lindb/lindb/pkg/http"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/ltoml"
 )
 
 // for testing
@@ -47,8 +49,9 @@ type FileInfo struct {
 }
 
 var (
-	LogListPath = "/log/list"
-	LogViewPath = "/log/view"
+	LogListPath  = "/log/list"
+	LogViewPath  = "/log/view"
+	LogLevelPath = "/log/level"
 )
 
 // LoggerAPI represents view log file rest api.
@@ -69,6 +72,8 @@ func NewLoggerAPI(logDir string) *LoggerAPI {
 func (d *LoggerAPI) Register(route gin.IRoutes) {
 	route.GET(LogListPath, d.List)
 	route.GET(LogViewPath, d.View)
+	route.GET(LogLevelPath, d.ListLevels)
+	route.PUT(LogLevelPath, d.SetLevel)
 }
 
 // List returns all log files in log dir.
@@ -186,6 +191,53 @@ func (d *LoggerAPI) View(c *gin.Context) {
 	})
 }
 
+// ListLevels returns the running levels of all logger modules.
+// @Summary list logger levels
+// @Description return the running levels of all logger modules.
+// @Tags State
+// @Accept json
+// @Produce json
+// @Success 200 {object} []logger.ModuleLevel
+// @Router /log/level [get]
+func (d *LoggerAPI) ListLevels(c *gin.Context) {
+	httppkg.OK(c, logger.ModuleLevels())
+}
+
+// SetLevel sets the level of logger module at runtime, empty level reverts module to the running level.
+// @Summary set logger level
+// @Description set the level of logger module at runtime, reverts automatically after duration if set.
+// @Tags State
+// @Accept json
+// @Produce json
+// @Success 204 {string} string
+// @Failure 404 {string} string "not found"
+// @Failure 500 {string} string "internal error"
+// @Router /log/level [put]
+func (d *LoggerAPI) SetLevel(c *gin.Context) {
+	var param struct {
+		Module string `json:"module" binding:"required"`
+		Level  string `json:"level"`
+		// revert to the running level after duration, not revert if empty
+		Duration ltoml.Duration `json:"duration"`
+	}
+	if err := c.ShouldBindJSON(&param); err != nil {
+		httppkg.Error(c, err)
+		return
+	}
+	if err := logger.SetModuleLevel(param.Module, param.Level, param.Duration.Duration()); err != nil {
+		if errors.Is(err, logger.ErrModuleNotFound) {
+			httppkg.NotFound(c)
+			return
+		}
+		httppkg.Error(c, err)
+		return
+	}
+	d.logger.Info("set logger level",
+		logger.String("module", param.Module), logger.String("level", param.Level),
+		logger.String("duration", param.Duration.String()))
+	httppkg.NoContent(c)
+}
+
 // writeLine writes a line into stream.
 func writeLine(w io.Writer, data [][]byte) error {
 	for _, d := range data {
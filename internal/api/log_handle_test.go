@@ -31,6 +31,7 @@ import (
 
 	"github.com/lindb/lindb/internal/mock"
 	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
 )
 
 type mockDirEntry struct{}
@@ -128,3 +129,33 @@ func TestLoggerAPI_View(t *testing.T) {
 	resp = mock.DoRequest(t, r, http.MethodGet, LogViewPath+"?file=../client/base.go", "")
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
 }
+
+func TestLoggerAPI_Level(t *testing.T) {
+	log := logger.GetLogger("API", "LogLevel")
+	api := NewLoggerAPI(".")
+	r := gin.New()
+	api.Register(r)
+
+	resp := mock.DoRequest(t, r, http.MethodGet, LogLevelPath, "")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"module":"API/LogLevel"`)
+
+	// bad request
+	resp = mock.DoRequest(t, r, http.MethodPut, LogLevelPath, `{"level":"debug"}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// module not found
+	resp = mock.DoRequest(t, r, http.MethodPut, LogLevelPath, `{"module":"API/Unknown","level":"debug"}`)
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	// invalid level
+	resp = mock.DoRequest(t, r, http.MethodPut, LogLevelPath, `{"module":"API/LogLevel","level":"xxx"}`)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	// set level with auto revert
+	resp = mock.DoRequest(t, r, http.MethodPut, LogLevelPath, `{"module":"API/LogLevel","level":"error","duration":"1m"}`)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+	log.Info("info is disabled")
+	resp = mock.DoRequest(t, r, http.MethodGet, LogLevelPath, "")
+	assert.Contains(t, resp.Body.String(), `"module":"API/LogLevel","level":"error","overridden":true`)
+	// revert to running level
+	resp = mock.DoRequest(t, r, http.MethodPut, LogLevelPath, `{"module":"API/LogLevel"}`)
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+}
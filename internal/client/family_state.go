@@ -0,0 +1,60 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"fmt"
+
+	resty "github.com/go-resty/resty/v2"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+)
+
+//go:generate mockgen -source=./family_state.go -destination=./family_state_mock.go -package=client
+
+// FamilyStateCli represents the client which fetches data family state from storage node.
+type FamilyStateCli interface {
+	// FetchFamilyState fetches the state of database's data families from storage node.
+	FetchFamilyState(database string, node models.Node) ([]models.DataFamilyState, error)
+}
+
+// familyStateCli implements FamilyStateCli interface.
+type familyStateCli struct{}
+
+// NewFamilyStateCli creates a FamilyStateCli instance.
+func NewFamilyStateCli() FamilyStateCli {
+	return &familyStateCli{}
+}
+
+// FetchFamilyState fetches the state of database's data families from storage node.
+func (cli *familyStateCli) FetchFamilyState(database string, node models.Node) ([]models.DataFamilyState, error) {
+	var state []models.DataFamilyState
+	resp, err := resty.New().R().
+		SetQueryParams(map[string]string{"db": database}).
+		SetHeader("Accept", "application/json").
+		SetResult(&state).
+		Get(node.HTTPAddress() + constants.APIVersion1CliPath + "/state/tsdb/memory")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("fetch family state failure, status: %d", resp.StatusCode())
+	}
+	return state, nil
+}
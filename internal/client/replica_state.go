@@ -0,0 +1,60 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"fmt"
+
+	resty "github.com/go-resty/resty/v2"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+)
+
+//go:generate mockgen -source=./replica_state.go -destination=./replica_state_mock.go -package=client
+
+// ReplicaStateCli represents the client which fetches write ahead log replica state from storage node.
+type ReplicaStateCli interface {
+	// FetchReplicaState fetches the replica state of database's shards from storage node.
+	FetchReplicaState(database string, node models.Node) ([]models.FamilyLogReplicaState, error)
+}
+
+// replicaStateCli implements ReplicaStateCli interface.
+type replicaStateCli struct{}
+
+// NewReplicaStateCli creates a ReplicaStateCli instance.
+func NewReplicaStateCli() ReplicaStateCli {
+	return &replicaStateCli{}
+}
+
+// FetchReplicaState fetches the replica state of database's shards from storage node.
+func (cli *replicaStateCli) FetchReplicaState(database string, node models.Node) ([]models.FamilyLogReplicaState, error) {
+	var state []models.FamilyLogReplicaState
+	resp, err := resty.New().R().
+		SetQueryParams(map[string]string{"db": database}).
+		SetHeader("Accept", "application/json").
+		SetResult(&state).
+		Get(node.HTTPAddress() + constants.APIVersion1CliPath + "/state/replica")
+	if err != nil {
+		return nil, err
+	}
+	if resp.IsError() {
+		return nil, fmt.Errorf("fetch replica state failure, status: %d", resp.StatusCode())
+	}
+	return state, nil
+}
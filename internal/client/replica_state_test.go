@@ -0,0 +1,86 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+func TestReplicaStateCli_FetchReplicaState(t *testing.T) {
+	cases := []struct {
+		name    string
+		port    int
+		prepare func(rw http.ResponseWriter)
+		wantErr bool
+	}{
+		{
+			name: "fetch failure",
+			prepare: func(rw http.ResponseWriter) {
+				rw.WriteHeader(http.StatusInternalServerError)
+			},
+			wantErr: true,
+		},
+		{
+			name: "fetch successfully",
+			prepare: func(rw http.ResponseWriter) {
+				rw.Header().Set("Content-Type", "application/json")
+				_, _ = rw.Write(encoding.JSONMarshal([]models.FamilyLogReplicaState{{ShardID: 1}}))
+			},
+		},
+		{
+			name:    "url wrong",
+			port:    30001,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				assert.Equal(t, "test", req.URL.Query().Get("db"))
+				if tt.prepare != nil {
+					tt.prepare(rw)
+				}
+			}))
+			defer server.Close()
+			port := strings.Split(server.URL, ":")[2]
+			cli := NewReplicaStateCli()
+			p, _ := strconv.Atoi(port)
+			if tt.port > 0 {
+				p = tt.port
+			}
+			state, err := cli.FetchReplicaState("test", &models.StatelessNode{HostIP: "127.0.0.1", HTTPPort: uint16(p)})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, state, 1)
+		})
+	}
+}
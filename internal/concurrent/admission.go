@@ -0,0 +1,199 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+)
+
+// AdmissionController controls the admission of heavy tasks(e.g. leaf query of storage)
+// by max concurrency and max in-flight bytes, excess tasks wait in a bounded FIFO queue
+// until admitted, or are rejected as node busy when the queue is full or wait timeout.
+type AdmissionController struct {
+	maxConcurrency   int
+	maxInflightBytes int64
+	maxQueueSize     int
+	timeout          time.Duration
+
+	executing     int
+	inflightBytes int64
+	waiters       *list.List // ready channel of waiting tasks
+
+	statistics *metrics.QueryAdmissionStatistics
+	mutex      sync.Mutex
+}
+
+// NewAdmissionController creates an admission controller, all tasks are admitted if max concurrency <= 0.
+func NewAdmissionController(
+	maxConcurrency int,
+	maxInflightBytes int64,
+	maxQueueSize int,
+	timeout time.Duration,
+	statistics *metrics.QueryAdmissionStatistics,
+) *AdmissionController {
+	return &AdmissionController{
+		maxConcurrency:   maxConcurrency,
+		maxInflightBytes: maxInflightBytes,
+		maxQueueSize:     maxQueueSize,
+		timeout:          timeout,
+		waiters:          list.New(),
+		statistics:       statistics,
+	}
+}
+
+// Admit admits a task, waits in queue if it reaches the limit.
+// Returns constants.ErrNodeBusy if the queue is full or wait timeout, the ticket must be released after task completed.
+func (ac *AdmissionController) Admit(ctx context.Context) (*Ticket, error) {
+	if ac.maxConcurrency <= 0 {
+		// admission control disabled
+		return &Ticket{}, nil
+	}
+	ticket := &Ticket{ac: ac}
+
+	ac.mutex.Lock()
+	if ac.waiters.Len() == 0 && ac.admissible() {
+		ac.admit()
+		ac.mutex.Unlock()
+		return ticket, nil
+	}
+	if ac.waiters.Len() >= ac.maxQueueSize {
+		ac.mutex.Unlock()
+		ac.statistics.QueueFull.Incr()
+		return nil, fmt.Errorf("%w, admission queue is full", constants.ErrNodeBusy)
+	}
+	ready := make(chan struct{})
+	waiter := ac.waiters.PushBack(ready)
+	ac.statistics.QueueDepth.Incr()
+	ac.mutex.Unlock()
+
+	start := time.Now()
+	timer := acquireTimer(ac.timeout)
+	var err error
+	select {
+	case <-ready:
+		releaseTimer(timer)
+		ac.statistics.WaitDuration.UpdateSince(start)
+		return ticket, nil
+	case <-ctx.Done():
+		releaseTimer(timer)
+		err = ctx.Err()
+	case <-timer.C:
+		releaseTimer(timer)
+		err = fmt.Errorf("%w, wait admission timeout", constants.ErrNodeBusy)
+	}
+
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+	select {
+	case <-ready:
+		// admitted when waiting timeout
+		ac.statistics.WaitDuration.UpdateSince(start)
+		return ticket, nil
+	default:
+	}
+	ac.waiters.Remove(waiter)
+	ac.statistics.QueueDepth.Decr()
+	ac.statistics.QueueTimeout.Incr()
+	return nil, err
+}
+
+// Executing returns the number of executing tasks.
+func (ac *AdmissionController) Executing() int {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+	return ac.executing
+}
+
+// admissible returns if a new task can be admitted, must hold lock.
+func (ac *AdmissionController) admissible() bool {
+	return ac.executing < ac.maxConcurrency &&
+		(ac.maxInflightBytes <= 0 || ac.inflightBytes < ac.maxInflightBytes)
+}
+
+// admit admits a new task, must hold lock.
+func (ac *AdmissionController) admit() {
+	ac.executing++
+	ac.statistics.Admitted.Incr()
+	ac.statistics.Executing.Incr()
+}
+
+// addBytes adds the in-flight bytes of executing task.
+func (ac *AdmissionController) addBytes(t *Ticket, bytes int64) {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	if t.released {
+		return
+	}
+	t.bytes += bytes
+	ac.inflightBytes += bytes
+	ac.statistics.InflightBytes.Add(float64(bytes))
+}
+
+// release releases the resource of completed task, then admits the waiting tasks in order.
+func (ac *AdmissionController) release(t *Ticket) {
+	ac.mutex.Lock()
+	defer ac.mutex.Unlock()
+
+	if t.released {
+		return
+	}
+	t.released = true
+	ac.executing--
+	ac.inflightBytes -= t.bytes
+	ac.statistics.Executing.Decr()
+	ac.statistics.InflightBytes.Sub(float64(t.bytes))
+
+	for ac.waiters.Len() > 0 && ac.admissible() {
+		ready := ac.waiters.Remove(ac.waiters.Front()).(chan struct{})
+		ac.statistics.QueueDepth.Decr()
+		ac.admit()
+		close(ready)
+	}
+}
+
+// Ticket represents an admitted task of admission controller.
+type Ticket struct {
+	ac *AdmissionController
+	// guarded by the lock of admission controller
+	bytes    int64
+	released bool
+}
+
+// AddBytes records the bytes held(e.g. scanned data) by the task.
+func (t *Ticket) AddBytes(bytes int) {
+	if t.ac == nil {
+		return
+	}
+	t.ac.addBytes(t, int64(bytes))
+}
+
+// Release releases the ticket after task completed, it is safe to release multiple times.
+func (t *Ticket) Release() {
+	if t.ac == nil {
+		return
+	}
+	t.ac.release(t)
+}
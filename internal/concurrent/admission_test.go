@@ -0,0 +1,161 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package concurrent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+)
+
+func TestAdmissionController_Disabled(t *testing.T) {
+	ac := NewAdmissionController(0, 0, 0, time.Millisecond, metrics.NewQueryAdmissionStatistics())
+	for i := 0; i < 10; i++ {
+		ticket, err := ac.Admit(context.TODO())
+		assert.NoError(t, err)
+		ticket.AddBytes(100)
+		ticket.Release()
+	}
+	assert.Zero(t, ac.executing)
+	assert.Zero(t, ac.inflightBytes)
+}
+
+func TestAdmissionController_Concurrency(t *testing.T) {
+	ac := NewAdmissionController(4, 0, 100, time.Second, metrics.NewQueryAdmissionStatistics())
+	var (
+		wg         sync.WaitGroup
+		mutex      sync.Mutex
+		executing  int
+		maxRunning int
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticket, err := ac.Admit(context.TODO())
+			assert.NoError(t, err)
+			mutex.Lock()
+			executing++
+			if executing > maxRunning {
+				maxRunning = executing
+			}
+			mutex.Unlock()
+			time.Sleep(time.Millisecond)
+			mutex.Lock()
+			executing--
+			mutex.Unlock()
+			ticket.Release()
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, maxRunning, 4)
+	assert.Zero(t, ac.executing)
+	assert.Zero(t, ac.waiters.Len())
+}
+
+func TestAdmissionController_Reject(t *testing.T) {
+	cases := []struct {
+		name    string
+		prepare func(ac *AdmissionController) (*Ticket, context.Context)
+		wantErr error
+	}{
+		{
+			name: "queue full",
+			prepare: func(ac *AdmissionController) (*Ticket, context.Context) {
+				ticket, _ := ac.Admit(context.TODO())
+				ac.waiters.PushBack(make(chan struct{}))
+				return ticket, context.TODO()
+			},
+			wantErr: constants.ErrNodeBusy,
+		},
+		{
+			name: "wait timeout",
+			prepare: func(ac *AdmissionController) (*Ticket, context.Context) {
+				ticket, _ := ac.Admit(context.TODO())
+				return ticket, context.TODO()
+			},
+			wantErr: constants.ErrNodeBusy,
+		},
+		{
+			name: "in-flight bytes exceed",
+			prepare: func(ac *AdmissionController) (*Ticket, context.Context) {
+				ac.maxConcurrency = 10
+				ticket, _ := ac.Admit(context.TODO())
+				ticket.AddBytes(1024)
+				return ticket, context.TODO()
+			},
+			wantErr: constants.ErrNodeBusy,
+		},
+		{
+			name: "context canceled",
+			prepare: func(ac *AdmissionController) (*Ticket, context.Context) {
+				ticket, _ := ac.Admit(context.TODO())
+				ctx, cancel := context.WithCancel(context.TODO())
+				cancel()
+				return ticket, ctx
+			},
+			wantErr: context.Canceled,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ac := NewAdmissionController(1, 1024, 1, 10*time.Millisecond, metrics.NewQueryAdmissionStatistics())
+			ticket, ctx := tt.prepare(ac)
+			rs, err := ac.Admit(ctx)
+			assert.Nil(t, rs)
+			assert.True(t, errors.Is(err, tt.wantErr))
+			ticket.Release()
+		})
+	}
+}
+
+func TestAdmissionController_WaitAdmitted(t *testing.T) {
+	ac := NewAdmissionController(1, 1024, 10, time.Second, metrics.NewQueryAdmissionStatistics())
+	ticket, err := ac.Admit(context.TODO())
+	assert.NoError(t, err)
+	ticket.AddBytes(2048)
+
+	admitted := make(chan *Ticket)
+	go func() {
+		t2, err2 := ac.Admit(context.TODO())
+		assert.NoError(t, err2)
+		admitted <- t2
+	}()
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case <-admitted:
+		assert.Fail(t, "admitted before ticket released")
+	default:
+	}
+	ticket.Release()
+	ticket.Release()
+	ticket.AddBytes(100) // ignore bytes after released
+	t2 := <-admitted
+	assert.Equal(t, 1, ac.Executing())
+	assert.Zero(t, ac.inflightBytes)
+	t2.Release()
+	assert.Zero(t, ac.executing)
+}
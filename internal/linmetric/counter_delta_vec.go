@@ -64,13 +64,27 @@ func (dcv *DeltaCounterVec) WithTagValues(tagValues ...string) *BoundCounter {
 	if ok {
 		return c
 	}
-	var tagsMap = dcv.tags.Map()
-	for i := range dcv.tagKeys {
-		tagsMap[dcv.tagKeys[i]] = tagValues[i]
-	}
-	series := newTaggedSeries(dcv.r, dcv.metricName, tag.TagsFromMap(tagsMap))
+	series := newTaggedSeries(dcv.r, dcv.metricName, vecTags(dcv.tags, dcv.tagKeys, tagValues))
 	c = series.NewCounter(dcv.fieldName)
 
 	dcv.deltaCounters[id] = c
 	return c
 }
+
+// DeleteTagValues removes the bound counter of given tag values, and unregisters it from registry.
+func (dcv *DeltaCounterVec) DeleteTagValues(tagValues ...string) {
+	if len(tagValues) != len(dcv.tagKeys) {
+		panic("count of tagKey and tagValue not match")
+	}
+	id := strings.Join(tagValues, ",")
+	dcv.mu.Lock()
+	defer dcv.mu.Unlock()
+
+	if _, ok := dcv.deltaCounters[id]; !ok {
+		return
+	}
+	delete(dcv.deltaCounters, id)
+	dcv.r.unregister(dcv.metricName, vecTags(dcv.tags, dcv.tagKeys, tagValues), func(payload *fieldPayload) {
+		payload.removeSimpleField(dcv.fieldName)
+	})
+}
@@ -37,6 +37,21 @@ func Test_DeltaCounterVec(t *testing.T) {
 	vec.WithTagValues("a", "b").Incr()
 }
 
+func Test_DeltaCounterVec_DeleteTagValues(t *testing.T) {
+	r := &Registry{
+		series: make(map[uint64]*taggedSeries),
+	}
+	vec := r.NewScope("vec").NewCounterVec("count", "1")
+	assert.Panics(t, func() {
+		vec.DeleteTagValues()
+	})
+	vec.WithTagValues("a").Incr()
+	assert.Len(t, r.series, 2)
+	vec.DeleteTagValues("a")
+	vec.DeleteTagValues("a")
+	assert.Len(t, r.series, 1)
+}
+
 func Benchmark_DeltaCounterVec(b *testing.B) {
 	scope := BrokerRegistry.NewScope("vec_test")
 	vec := scope.NewCounterVec("counter", "1", "2")
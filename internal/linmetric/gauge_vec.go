@@ -64,13 +64,27 @@ func (gv *GaugeVec) WithTagValues(tagValues ...string) *BoundGauge {
 	if ok {
 		return c
 	}
-	var tagsMap = gv.tags.Map()
-	for i := range gv.tagKeys {
-		tagsMap[gv.tagKeys[i]] = tagValues[i]
-	}
-	series := newTaggedSeries(gv.r, gv.metricName, tag.TagsFromMap(tagsMap))
+	series := newTaggedSeries(gv.r, gv.metricName, vecTags(gv.tags, gv.tagKeys, tagValues))
 	c = series.NewGauge(gv.fieldName)
 
 	gv.gauges[id] = c
 	return c
 }
+
+// DeleteTagValues removes the bound gauge of given tag values, and unregisters it from registry.
+func (gv *GaugeVec) DeleteTagValues(tagValues ...string) {
+	if len(tagValues) != len(gv.tagKeys) {
+		panic("count of tagKey and tagValue not match")
+	}
+	id := strings.Join(tagValues, ",")
+	gv.mu.Lock()
+	defer gv.mu.Unlock()
+
+	if _, ok := gv.gauges[id]; !ok {
+		return
+	}
+	delete(gv.gauges, id)
+	gv.r.unregister(gv.metricName, vecTags(gv.tags, gv.tagKeys, tagValues), func(payload *fieldPayload) {
+		payload.removeSimpleField(gv.fieldName)
+	})
+}
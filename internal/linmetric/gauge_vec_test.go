@@ -37,6 +37,35 @@ func Test_GaugeVec(t *testing.T) {
 	vec.WithTagValues("a", "b").Incr()
 }
 
+func Test_GaugeVec_DeleteTagValues(t *testing.T) {
+	r := &Registry{
+		series: make(map[uint64]*taggedSeries),
+	}
+	scope := r.NewScope("vecg")
+	vec := scope.NewGaugeVec("gauge", "1")
+	vec2 := scope.NewGaugeVec("gauge2", "1")
+	assert.Panics(t, func() {
+		vec.DeleteTagValues("1", "2")
+	})
+	vec.WithTagValues("a").Incr()
+	vec2.WithTagValues("a").Incr()
+	// delete not exist
+	vec.DeleteTagValues("b")
+	assert.Len(t, r.series, 2)
+	// series has other field
+	vec.DeleteTagValues("a")
+	assert.Len(t, r.series, 2)
+	rs := r.FindMetricList([]string{"vecg"}, nil)
+	assert.Len(t, rs["vecg"], 1)
+	assert.Len(t, rs["vecg"][0].Fields, 1)
+	// no field left
+	vec2.DeleteTagValues("a")
+	assert.Len(t, r.series, 1)
+	// register again
+	vec.WithTagValues("a").Incr()
+	assert.Len(t, r.series, 2)
+}
+
 func Benchmark_GaugeVec(b *testing.B) {
 	scope := BrokerRegistry.NewScope("vec_test")
 	vec := scope.NewGaugeVec("gauge", "1", "2")
@@ -84,11 +84,7 @@ func (hv *DeltaHistogramVec) WithTagValues(tagValues ...string) *BoundHistogram
 	if ok {
 		return h
 	}
-	var tagsMap = hv.tags.Map()
-	for i := range hv.tagKeys {
-		tagsMap[hv.tagKeys[i]] = tagValues[i]
-	}
-	series := newTaggedSeries(hv.r, hv.metricName, tag.TagsFromMap(tagsMap))
+	series := newTaggedSeries(hv.r, hv.metricName, vecTags(hv.tags, hv.tagKeys, tagValues))
 	h = series.NewHistogram()
 	if hv.setBucketsFunc != nil {
 		hv.setBucketsFunc(h)
@@ -96,3 +92,21 @@ func (hv *DeltaHistogramVec) WithTagValues(tagValues ...string) *BoundHistogram
 	hv.deltaHistograms[id] = h
 	return h
 }
+
+// DeleteTagValues removes the bound histogram of given tag values, and unregisters it from registry.
+func (hv *DeltaHistogramVec) DeleteTagValues(tagValues ...string) {
+	if len(tagValues) != len(hv.tagKeys) {
+		panic("count of tagKey and tagValue not match")
+	}
+	id := strings.Join(tagValues, ",")
+	hv.mu.Lock()
+	defer hv.mu.Unlock()
+
+	if _, ok := hv.deltaHistograms[id]; !ok {
+		return
+	}
+	delete(hv.deltaHistograms, id)
+	hv.r.unregister(hv.metricName, vecTags(hv.tags, hv.tagKeys, tagValues), func(payload *fieldPayload) {
+		payload.removeHistogram()
+	})
+}
@@ -40,6 +40,21 @@ func Test_HistogramDeltaVec(t *testing.T) {
 	vec.WithTagValues("a", "b").UpdateSeconds(1)
 }
 
+func Test_HistogramDeltaVec_DeleteTagValues(t *testing.T) {
+	r := &Registry{
+		series: make(map[uint64]*taggedSeries),
+	}
+	vec := r.NewScope("44").NewHistogramVec("1")
+	assert.Panics(t, func() {
+		vec.DeleteTagValues()
+	})
+	vec.WithTagValues("a").UpdateSeconds(1)
+	assert.Len(t, r.series, 2)
+	vec.DeleteTagValues("a")
+	vec.DeleteTagValues("a")
+	assert.Len(t, r.series, 1)
+}
+
 func Benchmark_HistogramVec(b *testing.B) {
 	scope := BrokerRegistry.NewScope("vec_test")
 	vec := scope.NewHistogramVec("1", "2").
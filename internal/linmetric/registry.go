@@ -24,6 +24,7 @@ import (
 	commonseries "github.com/lindb/common/series"
 
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/series/tag"
 )
 
 var (
@@ -110,6 +111,23 @@ func (r *Registry) register(seriesID uint64, series *taggedSeries) *taggedSeries
 	return series
 }
 
+// unregister removes fields of the series via removeFn,
+// the series is unregistered if no field left.
+func (r *Registry) unregister(metricName string, tags tag.Tags, removeFn func(payload *fieldPayload)) {
+	seriesID := seriesIDOf(metricName, tags)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, exist := r.series[seriesID]
+	if !exist {
+		return
+	}
+	if s.removeFields(removeFn) {
+		delete(r.series, seriesID)
+	}
+}
+
 // gatherMetricList transforms event-metrics to native LinDB dto-proto format
 func (r *Registry) gatherMetricList(
 	writer io.Writer, merger func(builder *commonseries.RowBuilder),
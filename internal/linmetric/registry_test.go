@@ -18,8 +18,12 @@
 package linmetric
 
 import (
+	"bytes"
+	"sync"
 	"testing"
 
+	commonseries "github.com/lindb/common/series"
+
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,3 +41,36 @@ func TestRegistry_FindMetricList(t *testing.T) {
 	rs = r.FindMetricList([]string{"test-1"}, map[string]string{"a": "a-1"})
 	assert.Len(t, rs["test-1"], 1)
 }
+
+func TestRegistry_unregister_concurrent(t *testing.T) {
+	r := &Registry{
+		series: make(map[uint64]*taggedSeries),
+	}
+	vec := r.NewScope("test").NewGaugeVec("g", "a")
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			vec.WithTagValues("a").Incr()
+			vec.DeleteTagValues("a")
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			r.gatherMetricList(&bytes.Buffer{}, func(_ *commonseries.RowBuilder) {})
+			r.FindMetricList([]string{"test"}, nil)
+		}
+	}()
+	wg.Wait()
+	assert.Len(t, r.series, 1)
+	// removed series is skipped by gather
+	s := newTaggedSeries(r, "test", tagList2Tags("a", "b"))
+	s.NewGauge("g")
+	assert.True(t, s.removeFields(func(payload *fieldPayload) {
+		payload.removeSimpleField("g")
+	}))
+	assert.False(t, s.removeFields(func(_ *fieldPayload) {}))
+	assert.Zero(t, r.gatherMetricList(&bytes.Buffer{}, func(_ *commonseries.RowBuilder) {}))
+}
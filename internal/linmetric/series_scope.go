@@ -19,6 +19,7 @@ package linmetric
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	xxhash "github.com/cespare/xxhash/v2"
@@ -80,18 +81,57 @@ func newTaggedSeries(r *Registry, metricName string, tags tag.Tags) *taggedSerie
 		metricName: metricName,
 		tags:       tags,
 	}
-	ts.seriesID = xxhash.Sum64String(ts.metricName + string(ts.tags.AppendHashKey(nil)))
+	ts.seriesID = seriesIDOf(ts.metricName, ts.tags)
 	// registered or replaced
 	ts = r.register(ts.seriesID, ts)
 	return ts
 }
 
+// seriesIDOf returns the series id of metric-name + tags,
+// tags are sorted by key in place, so that same tags built from map always get same series id.
+func seriesIDOf(metricName string, tags tag.Tags) uint64 {
+	sort.Sort(tags)
+	return xxhash.Sum64String(metricName + string(tags.AppendHashKey(nil)))
+}
+
 func (s *taggedSeries) ensurePayload() {
 	if s.payload == nil {
 		s.payload = &fieldPayload{}
 	}
 }
 
+// removeFields removes fields from payload via removeFn,
+// returns true if no field left(payload is cleared, so that gather skips it).
+func (s *taggedSeries) removeFields(removeFn func(payload *fieldPayload)) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.payload == nil {
+		return false
+	}
+	removeFn(s.payload)
+	if len(s.payload.simpleFields) == 0 && s.payload.histogramDelta == nil {
+		s.payload = nil
+		return true
+	}
+	return false
+}
+
+// removeSimpleField removes the simple field by name.
+func (p *fieldPayload) removeSimpleField(fieldName string) {
+	for i, sf := range p.simpleFields {
+		if sf.name() == fieldName {
+			p.simpleFields = append(p.simpleFields[:i], p.simpleFields[i+1:]...)
+			return
+		}
+	}
+}
+
+// removeHistogram removes the histogram field.
+func (p *fieldPayload) removeHistogram() {
+	p.histogramDelta = nil
+}
+
 func assertMetricName(metricName string) {
 	if metricName == "" {
 		panic("metric-name cannot be empty string")
@@ -131,6 +171,15 @@ func (s *taggedSeries) Scope(metricName string, tagList ...string) Scope {
 	return newTaggedSeries(s.r, nextMetricName, nextScopeKeyValues(s.tags, tagList...))
 }
 
+// vecTags returns the tags of vec with given tag values.
+func vecTags(tags tag.Tags, tagKeys, tagValues []string) tag.Tags {
+	var tagsMap = tags.Map()
+	for i := range tagKeys {
+		tagsMap[tagKeys[i]] = tagValues[i]
+	}
+	return tag.TagsFromMap(tagsMap)
+}
+
 func tagList2Tags(tagList ...string) tag.Tags {
 	if len(tagList)%2 != 0 {
 		panic("bad tags length ")
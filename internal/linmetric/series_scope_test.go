@@ -103,3 +103,9 @@ func Test_MetricScope_Scope(t *testing.T) {
 		scope3.NewGauge("")
 	})
 }
+
+func Test_seriesIDOf(t *testing.T) {
+	assert.Equal(t,
+		seriesIDOf("test", tagList2Tags("a", "1", "b", "2")),
+		seriesIDOf("test", tagList2Tags("b", "2", "a", "1")))
+}
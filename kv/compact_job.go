@@ -20,6 +20,7 @@ package kv
 import (
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/lindb/lindb/kv/table"
@@ -130,9 +131,12 @@ func (c *compactJob) doMerge() error {
 	if err != nil {
 		return err
 	}
+	params := c.family.getStore().getMergerParams()
+	params[FamilyNameContext] = c.family.Name()
 	if c.rollup != nil {
-		merger.Init(map[string]interface{}{RollupContext: c.rollup})
+		params[RollupContext] = c.rollup
 	}
+	merger.Init(params)
 
 	var needMerge [][]byte
 	var previousKey uint32
@@ -200,8 +204,15 @@ func (c *compactJob) installCompactionResults() {
 // makeInputIterator makes a merged iterator by compaction pick input files
 func (c *compactJob) makeInputIterator() (table.Iterator, error) {
 	var its []table.Iterator
-	for which := 0; which < 2; which++ {
-		files := c.state.compaction.GetInputs()[which]
+	// iterators are ordered from old to new(up level files first, then level0 files by file number),
+	// so that merger gets the values of same key in write order.
+	inputs := c.state.compaction.GetInputs()
+	for which := len(inputs) - 1; which >= 0; which-- {
+		files := make([]*version.FileMeta, len(inputs[which]))
+		copy(files, inputs[which])
+		sort.Slice(files, func(i, j int) bool {
+			return files[i].GetFileNumber() < files[j].GetFileNumber()
+		})
 		if len(files) > 0 {
 			for _, fileMeta := range files {
 				reader, err := c.state.snapshot.GetReader(fileMeta.GetFileNumber())
@@ -343,10 +354,18 @@ func (cf *compactFlusher) Sequence(_ int32, _ int64) {
 	// do nothing
 }
 
+func (cf *compactFlusher) MemDBCreatedTime(_ int64) {
+	// do nothing
+}
+
 func (cf *compactFlusher) Commit() error {
 	panic("Commit is not allowed to call for CompactFlusher")
 }
 
+func (cf *compactFlusher) Outputs() []table.FileNumber {
+	panic("Outputs is not allowed to call for CompactFlusher")
+}
+
 func (cf *compactFlusher) Release() {
 	panic("Release is not allowed to call for CompactFlusher")
 }
@@ -53,6 +53,7 @@ func TestCompactJob_move_compact(t *testing.T) {
 
 	snapshot := version.NewMockSnapshot(ctrl)
 	merge := NewMockMerger(ctrl)
+	merge.EXPECT().Init(gomock.Any()).AnyTimes()
 	family := generateMockFamily(ctrl, func(flusher Flusher) (Merger, error) {
 		return merge, nil
 	})
@@ -80,6 +81,7 @@ func TestCompactJob_merge_compact_get_read_fail(t *testing.T) {
 	snapshot := version.NewMockSnapshot(ctrl)
 	snapshot.EXPECT().GetReader(gomock.Any()).Return(nil, fmt.Errorf("err"))
 	merge := NewMockMerger(ctrl)
+	merge.EXPECT().Init(gomock.Any()).AnyTimes()
 	family := generateMockFamily(ctrl, func(flusher Flusher) (Merger, error) {
 		return merge, nil
 	})
@@ -109,6 +111,7 @@ func TestCompactJob_merge_compact_merge_fail(t *testing.T) {
 	)
 	snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil).MaxTimes(2)
 	merge := NewMockMerger(ctrl)
+	merge.EXPECT().Init(gomock.Any()).AnyTimes()
 	merge.EXPECT().Merge(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
 	family := generateMockFamily(ctrl, func(flusher Flusher) (Merger, error) {
 		return merge, nil
@@ -144,15 +147,17 @@ func TestCompactJob_merge_doMerge_fail(t *testing.T) {
 	reader1 := table.NewMockReader(ctrl)
 	reader2 := table.NewMockReader(ctrl)
 	merge := NewMockMerger(ctrl)
+	merge.EXPECT().Init(gomock.Any()).AnyTimes()
 
 	// test new store build fail
+	// up level files are iterated before level0 files
 	gomock.InOrder(
-		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
-			1: []byte("value1"),
-		})),
 		reader2.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
 			2: []byte("value2"),
 		})),
+		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
+			1: []byte("value1"),
+		})),
 	)
 	gomock.InOrder(
 		merge.EXPECT().Merge(gomock.Any(), gomock.Any()).Return(nil),
@@ -182,15 +187,17 @@ func TestCompactJob_output_fail(t *testing.T) {
 	reader1 := table.NewMockReader(ctrl)
 	reader2 := table.NewMockReader(ctrl)
 	merge := NewMockMerger(ctrl)
+	merge.EXPECT().Init(gomock.Any()).AnyTimes()
 
 	// test store build is empty
+	// up level files are iterated before level0 files
 	gomock.InOrder(
-		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
-			1: []byte("value1"),
-		})),
 		reader2.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
 			1: []byte("value2"),
 		})),
+		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
+			1: []byte("value1"),
+		})),
 	)
 	snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader1, nil)
 	snapshot.EXPECT().GetReader(table.FileNumber(4)).Return(reader2, nil)
@@ -223,13 +230,14 @@ func TestCompactJob_output_fail(t *testing.T) {
 	assert.Equal(t, 0, len(state.outputs))
 
 	// test finish output fail
+	// up level files are iterated before level0 files
 	gomock.InOrder(
-		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
-			1: []byte("value1"),
-		})),
 		reader2.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
 			1: []byte("value2"),
 		})),
+		reader1.EXPECT().Iterator().Return(generateIterator(ctrl, map[uint32][]byte{
+			1: []byte("value1"),
+		})),
 	)
 	snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader1, nil)
 	snapshot.EXPECT().GetReader(table.FileNumber(4)).Return(reader2, nil)
@@ -359,6 +367,11 @@ func generateMockFamily(ctrl *gomock.Controller, merger NewMerger) *MockFamily {
 	family.EXPECT().getNewMerger().Return(merger).AnyTimes()
 	family.EXPECT().Name().Return("test-family").AnyTimes()
 	family.EXPECT().commitEditLog(gomock.Any()).Return(true).AnyTimes()
+	store := NewMockStore(ctrl)
+	store.EXPECT().getMergerParams().DoAndReturn(func() map[string]interface{} {
+		return make(map[string]interface{})
+	}).AnyTimes()
+	family.EXPECT().getStore().Return(store).AnyTimes()
 	return family
 }
 
@@ -17,14 +17,47 @@
 
 package kv
 
-import "github.com/lindb/lindb/pkg/logger"
+import (
+	"errors"
+
+	"github.com/lindb/lindb/pkg/logger"
+)
 
 const (
-	dummy                   = ""
-	RollupContext           = "RollupContext"
+	dummy         = ""
+	RollupContext = "RollupContext"
+	// FamilyNameContext represents the name of family which is compacting, passed to merger.
+	FamilyNameContext = "FamilyNameContext"
+	// FieldRetentionContext represents the field level retention checker of store, passed to merger.
+	FieldRetentionContext = "FieldRetentionContext"
+	// FieldPrecisionContext represents the field value precision provider of store, passed to merger.
+	FieldPrecisionContext = "FieldPrecisionContext"
+	// WriteSemanticsContext represents the write semantics checker of store, passed to merger.
+	WriteSemanticsContext = "WriteSemanticsContext"
+	// CompressionContext represents the codec provider of metric block compression, passed to merger.
+	CompressionContext      = "CompressionContext"
 	defaultMaxFileSize      = uint32(256 * 1024 * 1024)
 	defaultCompactThreshold = 4
 	defaultRollupThreshold  = 3
+	// up level files not larger than total size of level0 files * ratio are merged for size-tiered compaction.
+	sizeTieredRatio = 2.0
+	// quarantineDir is the dir under family path which orphan files are moved into.
+	quarantineDir = "quarantine"
+)
+
+// ErrVersionCorruption represents the version of family references the file which is missing.
+var ErrVersionCorruption = errors.New("version corruption")
+
+var (
+	// ErrTieringDisabled represents the object storage of tiered families is not set.
+	ErrTieringDisabled = errors.New("tiering is disabled")
+	// ErrTierNotNodeScoped represents the object keys of tiered files are not scoped by node,
+	// which may collide with the keys of other nodes sharing the object storage.
+	ErrTierNotNodeScoped = errors.New("object key of tiered file is not scoped by node")
+	// ErrFamilyTiered represents new files cannot be added into tiered family(flush/compaction).
+	ErrFamilyTiered = errors.New("family is tiered")
+	// ErrFamilyBusy represents family has compaction/tiering job running.
+	ErrFamilyBusy = errors.New("family has background job running")
 )
 
 var (
@@ -18,7 +18,9 @@
 package kv
 
 import (
+	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sync"
 
@@ -26,8 +28,11 @@ import (
 
 	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 )
 
@@ -37,8 +42,28 @@ import (
 var (
 	newCompactJobFunc = newCompactJob
 	removeDirFunc     = fileutil.RemoveDir
+	renameFunc        = os.Rename
 )
 
+// ExternalFile represents the sst file outside of family, which can be ingested into family.
+type ExternalFile struct {
+	Path   string // path of sst file
+	MinKey uint32 // min key of sst file
+	MaxKey uint32 // max key of sst file
+	Size   uint32 // size of sst file
+}
+
+// VersionChange represents the files of family changed by new version(flush/compaction/rollup/ingest committed).
+type VersionChange struct {
+	AddedFiles   []*version.FileMeta // files added into new version
+	RemovedFiles []string            // path of files removed from new version
+	DiskSize     int64               // total size of files in new version
+	NumOfFiles   int                 // num. of files in new version
+}
+
+// VersionListener represents the listener which is notified after new version of family installed.
+type VersionListener func(change VersionChange)
+
 // Family implements column family for data isolation each family.
 type Family interface {
 	// ID return family's id.
@@ -51,6 +76,36 @@ type Family interface {
 	GetSnapshot() version.Snapshot
 	// Compact compacts all files of level0.
 	Compact()
+	// CompactAll rewrites all files of family by merger in background, merger can drop the expired data,
+	// returns false if compaction job is running or paused.
+	CompactAll() bool
+	// SetCompactionPolicy sets the compaction policy, which takes effect for new compactions.
+	SetCompactionPolicy(policy option.CompactionPolicy)
+	// CompactionState returns the active compaction policy and compaction statistics of each policy.
+	CompactionState() models.CompactionState
+	// FilePath returns the path of sst file under family.
+	FilePath(fileNumber table.FileNumber) string
+	// IngestFiles moves the external sst files into level0 of family, then commits the write sequences,
+	// used for installing the family files transferred from other node.
+	// NOTICE: external files must be under the same file system with family.
+	IngestFiles(files []ExternalFile, sequences map[int32]int64) error
+	// AddVersionListener adds a listener which is notified after new version of family installed,
+	// returns the function which removes the listener.
+	// NOTICE: listener is invoked serially, cannot add/remove listener in it.
+	AddVersionListener(listener VersionListener) (remove func())
+	// BackfillRollup rolls up all files of family into the family of target interval,
+	// which are not rolled up by live rollup job, used for building the history data of new rollup interval.
+	BackfillRollup(targetInterval timeutil.Interval) error
+	// Tier moves the files of family to object storage, local files are replaced by stubs,
+	// flush/compaction never target tiered family, throttle is invoked with the bytes uploaded.
+	Tier(ctx context.Context, throttle Throttle) error
+	// Untier downloads the tiered files of family back to local, then removes them from object storage.
+	Untier(ctx context.Context) error
+	// IsTiered returns if any file of family is moved to object storage.
+	IsTiered() bool
+	// CommitMemDBCreatedTime persists the created time of memory database not flushed,
+	// restored after restart for continuing the ttl of memory database.
+	CommitMemDBCreatedTime(timestamp int64) error
 
 	getStore() Store
 	// familyInfo return family info
@@ -80,6 +135,10 @@ type Family interface {
 	doRollupWork(sourceFamily Family, rollup Rollup, sourceFiles []table.FileNumber) (err error)
 	// deleteObsoleteFiles deletes obsolete files.
 	deleteObsoleteFiles()
+	// reconcileFiles reconciles the files of family with recovered version when opening store.
+	reconcileFiles(action OrphanFileAction) error
+	// locateFile returns the local path of sst file, fetches the local copy if the file is moved to object storage.
+	locateFile(fileName string) (string, error)
 	// close family, need wait background job completed then releases resource.
 	close()
 }
@@ -101,7 +160,19 @@ type family struct {
 	lastRollupTime *atomic.Int64
 	compacting     atomic.Bool
 
+	policy          atomic.String
+	compactionStats map[option.CompactionPolicy]*models.CompactionStat
+	statsLock       sync.Mutex
+
 	condition sync.WaitGroup // compact/rollup job if it's doing
+
+	listeners     map[int64]VersionListener
+	listenerSeq   int64
+	notifiedFiles map[table.FileNumber]*version.FileMeta // files of version which listeners notified
+	listenerLock  sync.Mutex
+
+	stubs    map[table.FileNumber]*stub // stubs of files moved to object storage
+	stubLock sync.RWMutex
 }
 
 // newFamily creates new family or open existed family.
@@ -135,6 +206,10 @@ func newFamily(store Store, option FamilyOption) (Family, error) {
 		familyVersion:     store.createFamilyVersion(name, version.FamilyID(option.ID)),
 		lastRollupTime:    atomic.NewInt64(timeutil.Now()),
 	}
+	f.SetCompactionPolicy(option.CompactionPolicy)
+	if err := f.loadStubs(); err != nil {
+		return nil, fmt.Errorf("load stubs of family error:%w", err)
+	}
 
 	kvLogger.Info("create new family successfully", logger.String("family", f.familyInfo()))
 	return f, nil
@@ -179,6 +254,60 @@ func (f *family) newTableBuilder() (table.Builder, error) {
 	return table.NewStoreBuilder(fileNumber, fileName)
 }
 
+// FilePath returns the path of sst file under family.
+func (f *family) FilePath(fileNumber table.FileNumber) string {
+	return filepath.Join(f.familyPath, version.Table(fileNumber))
+}
+
+// IngestFiles moves the external sst files into level0 of family, then commits the write sequences,
+// used for installing the family files transferred from other node.
+func (f *family) IngestFiles(files []ExternalFile, sequences map[int32]int64) error {
+	editLog := version.NewEditLog(f.ID())
+	var outputs []table.FileNumber
+	defer func() {
+		// remove pending output after committed/failed, obsolete files are deleted if commit failure
+		for _, fileNumber := range outputs {
+			f.removePendingOutput(fileNumber)
+		}
+	}()
+	for _, file := range files {
+		fileNumber := f.store.nextFileNumber()
+		// keep file from being deleted as obsolete file before committed
+		f.addPendingOutput(fileNumber)
+		outputs = append(outputs, fileNumber)
+		if err := renameFunc(file.Path, f.FilePath(fileNumber)); err != nil {
+			return fmt.Errorf("move external file[%s] into family error:%w", file.Path, err)
+		}
+		editLog.Add(version.CreateNewFile(0, version.NewFileMeta(fileNumber, file.MinKey, file.MaxKey, file.Size)))
+	}
+	for leader, seq := range sequences {
+		editLog.Add(version.CreateSequence(leader, seq))
+	}
+	// add rollup files edit log in source version, same as flush
+	for _, interval := range f.store.Option().Rollup {
+		for _, output := range outputs {
+			editLog.Add(version.CreateNewRollupFile(output, interval))
+		}
+	}
+	if !f.commitEditLog(editLog) {
+		return fmt.Errorf("commit edit log failure")
+	}
+	kvLogger.Info("ingest external files successfully",
+		logger.String("family", f.familyInfo()), logger.Int("files", len(files)), logger.Any("sequences", sequences))
+	return nil
+}
+
+// CommitMemDBCreatedTime persists the created time of memory database not flushed,
+// restored after restart for continuing the ttl of memory database.
+func (f *family) CommitMemDBCreatedTime(timestamp int64) error {
+	editLog := version.NewEditLog(f.ID())
+	editLog.Add(version.CreateMemDBCreatedTime(timestamp))
+	if !f.commitEditLog(editLog) {
+		return fmt.Errorf("commit edit log failure")
+	}
+	return nil
+}
+
 // commitEditLog persists edit logs into manifest file.
 // returns true on committing successfully and false on failure
 func (f *family) commitEditLog(editLog version.EditLog) bool {
@@ -190,9 +319,80 @@ func (f *family) commitEditLog(editLog version.EditLog) bool {
 		kvLogger.Error("commit edit log error:", logger.String("family", f.familyInfo()), logger.Error(err))
 		return false
 	}
+	f.notifyVersionChanged()
 	return true
 }
 
+// AddVersionListener adds a listener which is notified after new version of family installed,
+// returns the function which removes the listener.
+func (f *family) AddVersionListener(listener VersionListener) (remove func()) {
+	f.listenerLock.Lock()
+	defer f.listenerLock.Unlock()
+
+	if f.listeners == nil {
+		f.listeners = make(map[int64]VersionListener)
+	}
+	if len(f.listeners) == 0 {
+		// changes are based on the version when first listener added
+		f.notifiedFiles = f.currentFiles()
+	}
+	f.listenerSeq++
+	id := f.listenerSeq
+	f.listeners[id] = listener
+	return func() {
+		f.listenerLock.Lock()
+		defer f.listenerLock.Unlock()
+
+		delete(f.listeners, id)
+		if len(f.listeners) == 0 {
+			f.notifiedFiles = nil
+		}
+	}
+}
+
+// notifyVersionChanged notifies listeners the files changed between current version and the version notified.
+func (f *family) notifyVersionChanged() {
+	f.listenerLock.Lock()
+	defer f.listenerLock.Unlock()
+
+	if len(f.listeners) == 0 {
+		return
+	}
+	files := f.currentFiles()
+	change := VersionChange{NumOfFiles: len(files)}
+	for fileNumber, file := range files {
+		change.DiskSize += int64(file.GetFileSize())
+		if _, ok := f.notifiedFiles[fileNumber]; !ok {
+			change.AddedFiles = append(change.AddedFiles, file)
+		}
+	}
+	for fileNumber := range f.notifiedFiles {
+		if _, ok := files[fileNumber]; !ok {
+			change.RemovedFiles = append(change.RemovedFiles, f.FilePath(fileNumber))
+		}
+	}
+	f.notifiedFiles = files
+	if len(change.AddedFiles) == 0 && len(change.RemovedFiles) == 0 {
+		// only sequences changed
+		return
+	}
+	for _, listener := range f.listeners {
+		listener(change)
+	}
+}
+
+// currentFiles returns the files of current version.
+func (f *family) currentFiles() map[table.FileNumber]*version.FileMeta {
+	snapshot := f.GetSnapshot()
+	defer snapshot.Close()
+
+	files := make(map[table.FileNumber]*version.FileMeta)
+	for _, file := range snapshot.GetCurrent().GetAllFiles() {
+		files[file.GetFileNumber()] = file
+	}
+	return files
+}
+
 // Compact compacts all files of level0.
 func (f *family) Compact() {
 	// has compaction job doing
@@ -209,10 +409,34 @@ func (f *family) Compact() {
 	}
 }
 
+// CompactAll rewrites all files of family by merger in background, merger can drop the expired data,
+// returns false if compaction job is running or paused.
+func (f *family) CompactAll() bool {
+	if IsCompactionPaused() || f.IsTiered() {
+		return false
+	}
+	if f.compacting.CAS(false, true) {
+		f.condition.Add(1)
+		go func() {
+			defer func() {
+				f.condition.Done()
+				f.compacting.Store(false)
+			}()
+
+			if err := f.fullCompactionJob(); err != nil {
+				kvLogger.Error("do full compact job error",
+					logger.String("family", f.familyInfo()), logger.Error(err), logger.Stack())
+			}
+		}()
+		return true
+	}
+	return false
+}
+
 // needCompact returns level0 files if it needs to do compact job
 func (f *family) needCompact() bool {
-	// has compaction job doing
-	if f.compacting.Load() {
+	// has compaction job doing, or family is tiered
+	if f.compacting.Load() || f.IsTiered() {
 		return false
 	}
 
@@ -234,6 +458,9 @@ func (f *family) needCompact() bool {
 
 // compact does compact job if it hasn't compact job running.
 func (f *family) compact() {
+	if IsCompactionPaused() || f.IsTiered() {
+		return
+	}
 	if f.compacting.CAS(false, true) {
 		f.condition.Add(1)
 		go func() {
@@ -259,7 +486,26 @@ func (f *family) backgroundCompactionJob() error {
 		f.deleteObsoleteFiles()
 	}()
 
-	compaction := snapshot.GetCurrent().PickL0Compaction(f.option.CompactThreshold)
+	// policy is picked for each compaction, so that policy changing takes effect for new compactions only
+	policy := f.getCompactionPolicy()
+	compaction := f.pickCompaction(snapshot.GetCurrent(), policy)
+	return f.runCompactionJob(snapshot, policy, compaction)
+}
+
+// fullCompactionJob rewrites all files of family in background goroutine.
+func (f *family) fullCompactionJob() error {
+	snapshot := f.GetSnapshot()
+	defer func() {
+		snapshot.Close()
+		// clean up unused files, maybe some file not used
+		f.deleteObsoleteFiles()
+	}()
+
+	return f.runCompactionJob(snapshot, f.getCompactionPolicy(), snapshot.GetCurrent().PickFullCompaction())
+}
+
+// runCompactionJob runs the compaction job picked from snapshot, records the compaction statistics of policy.
+func (f *family) runCompactionJob(snapshot version.Snapshot, policy option.CompactionPolicy, compaction *version.Compaction) error {
 	if compaction == nil {
 		// no compaction job need to do
 		return nil
@@ -267,11 +513,91 @@ func (f *family) backgroundCompactionJob() error {
 	compactionState := newCompactionState(f.maxFileSize, snapshot, compaction)
 	compactJob := f.newCompactJobFunc(f, compactionState, nil)
 	if err := compactJob.Run(); err != nil {
+		f.recordCompaction(policy, compactionState, err)
 		return err
 	}
+	f.recordCompaction(policy, compactionState, nil)
 	return nil
 }
 
+// pickCompaction picks level0 compaction context based on compaction policy.
+func (f *family) pickCompaction(v version.Version, policy option.CompactionPolicy) *version.Compaction {
+	switch policy {
+	case option.CompactionPolicyLeveled:
+		return v.PickLeveledCompaction(f.option.CompactThreshold)
+	case option.CompactionPolicySizeTiered:
+		return v.PickSizeTieredCompaction(f.option.CompactThreshold, sizeTieredRatio)
+	default:
+		return v.PickL0Compaction(f.option.CompactThreshold)
+	}
+}
+
+// SetCompactionPolicy sets the compaction policy, which takes effect for new compactions,
+// unknown policy falls back to default.
+func (f *family) SetCompactionPolicy(policy option.CompactionPolicy) {
+	p, err := option.ParseCompactionPolicy(string(policy))
+	if err != nil {
+		kvLogger.Warn("unknown compaction policy, use default policy",
+			logger.String("family", f.familyInfo()), logger.Any("policy", policy))
+		p = option.CompactionPolicyDefault
+	}
+	f.policy.Store(string(p))
+}
+
+// getCompactionPolicy returns the active compaction policy.
+func (f *family) getCompactionPolicy() option.CompactionPolicy {
+	return option.CompactionPolicy(f.policy.Load())
+}
+
+// CompactionState returns the active compaction policy and compaction statistics of each policy.
+func (f *family) CompactionState() models.CompactionState {
+	f.statsLock.Lock()
+	defer f.statsLock.Unlock()
+
+	state := models.CompactionState{Policy: string(f.getCompactionPolicy())}
+	if len(f.compactionStats) > 0 {
+		state.Stats = make(map[string]models.CompactionStat, len(f.compactionStats))
+		for policy, stat := range f.compactionStats {
+			state.Stats[string(policy)] = *stat
+		}
+	}
+	return state
+}
+
+// recordCompaction records the statistics of compaction job under given policy.
+func (f *family) recordCompaction(policy option.CompactionPolicy, state *compactionState, err error) {
+	f.statsLock.Lock()
+	defer f.statsLock.Unlock()
+
+	if f.compactionStats == nil {
+		f.compactionStats = make(map[option.CompactionPolicy]*models.CompactionStat)
+	}
+	stat, ok := f.compactionStats[policy]
+	if !ok {
+		stat = &models.CompactionStat{}
+		f.compactionStats[policy] = stat
+	}
+	if err != nil {
+		stat.Failures++
+		return
+	}
+	stat.Compactions++
+	for _, inputs := range state.compaction.GetInputs() {
+		for _, input := range inputs {
+			stat.InputFiles++
+			stat.InputBytes += int64(input.GetFileSize())
+		}
+	}
+	var outputBytes int64
+	for _, output := range state.outputs {
+		stat.OutputFiles++
+		outputBytes += int64(output.GetFileSize())
+	}
+	stat.OutputBytes += outputBytes
+	metrics.CompactStatistics.Policy.WithTagValues(string(policy)).Incr()
+	metrics.CompactStatistics.WriteBytes.WithTagValues(string(policy)).Add(float64(outputBytes))
+}
+
 // addPendingOutput add a file which current writing file number
 func (f *family) addPendingOutput(fileNumber table.FileNumber) {
 	f.pendingOutputs.Store(fileNumber, dummy)
@@ -307,25 +633,7 @@ func (f *family) deleteObsoleteFiles() {
 		kvLogger.Error("list sst file fail when delete obsolete files", logger.String("family", f.familyInfo()))
 		return
 	}
-	// make a map for all live files
-	liveFiles := make(map[table.FileNumber]string)
-	f.pendingOutputs.Range(func(key, _ interface{}) bool {
-		if k, ok := key.(table.FileNumber); ok {
-			liveFiles[k] = dummy
-		}
-		return true
-	})
-	// add live files
-	allLiveSSTFiles := f.familyVersion.GetAllActiveFiles()
-	for idx := range allLiveSSTFiles {
-		liveFiles[allLiveSSTFiles[idx].GetFileNumber()] = dummy
-	}
-	// add live rollup files, maybe some rollup files is not alive in current family version,
-	// but those files cannot delete, because need read those files when do rollup job
-	rollupFiles := f.familyVersion.GetLiveRollupFiles()
-	for file := range rollupFiles {
-		liveFiles[file] = dummy
-	}
+	liveFiles := f.liveFiles()
 	for _, fileName := range sstFiles {
 		fileDesc := version.ParseFileName(fileName)
 		if fileDesc == nil {
@@ -349,6 +657,89 @@ func (f *family) deleteObsoleteFiles() {
 	}
 }
 
+// reconcileFiles reconciles the files of family with recovered version when opening store,
+// 1. returns corruption error if any file referenced by version is missing, instead of failure in first query;
+// 2. deletes or quarantines the orphan sst files not referenced by any version(crash before committed).
+func (f *family) reconcileFiles(action OrphanFileAction) error {
+	fileNames, err := listDirFunc(f.familyPath)
+	if err != nil {
+		return fmt.Errorf("list files of family[%s] error:%w", f.familyInfo(), err)
+	}
+	existFiles := make(map[table.FileNumber]struct{})
+	for _, fileName := range fileNames {
+		fileDesc := version.ParseFileName(fileName)
+		if fileDesc != nil && fileDesc.FileType == version.TypeTable {
+			existFiles[fileDesc.FileNumber] = struct{}{}
+		}
+	}
+	activeFiles := f.familyVersion.GetAllActiveFiles()
+	for idx := range activeFiles {
+		fileNumber := activeFiles[idx].GetFileNumber()
+		if _, ok := existFiles[fileNumber]; !ok {
+			if _, tiered := f.getStub(fileNumber); tiered {
+				// file is moved to object storage
+				continue
+			}
+			metrics.ReconcileStatistics.MissingFiles.Incr()
+			return fmt.Errorf("%w, file[%s] referenced by version of family[%s] is missing",
+				ErrVersionCorruption, f.FilePath(fileNumber), f.familyInfo())
+		}
+	}
+	liveFiles := f.liveFiles()
+	for fileNumber := range existFiles {
+		if _, ok := liveFiles[fileNumber]; ok {
+			continue
+		}
+		if err := f.handleOrphanFile(fileNumber, action); err != nil {
+			// retry when opening store next time
+			kvLogger.Error("handle orphan file fail",
+				logger.String("family", f.familyInfo()), logger.Any("fileNumber", fileNumber),
+				logger.String("action", string(action)), logger.Error(err))
+			continue
+		}
+		metrics.ReconcileStatistics.OrphanFiles.WithTagValues(string(action)).Incr()
+		kvLogger.Warn("found orphan file not referenced by any version",
+			logger.String("family", f.familyInfo()), logger.Any("fileNumber", fileNumber),
+			logger.String("action", string(action)))
+	}
+	return nil
+}
+
+// handleOrphanFile deletes the orphan file, or moves it into quarantine dir of family.
+func (f *family) handleOrphanFile(fileNumber table.FileNumber, action OrphanFileAction) error {
+	if action != OrphanFileQuarantine {
+		return f.deleteSST(fileNumber)
+	}
+	dir := filepath.Join(f.familyPath, quarantineDir)
+	if err := mkDirFunc(dir); err != nil {
+		return err
+	}
+	return renameFunc(f.FilePath(fileNumber), filepath.Join(dir, version.Table(fileNumber)))
+}
+
+// liveFiles returns the files which cannot be deleted, includes pending outputs/active files/live rollup files.
+func (f *family) liveFiles() map[table.FileNumber]string {
+	liveFiles := make(map[table.FileNumber]string)
+	f.pendingOutputs.Range(func(key, _ interface{}) bool {
+		if k, ok := key.(table.FileNumber); ok {
+			liveFiles[k] = dummy
+		}
+		return true
+	})
+	// add live files
+	allLiveSSTFiles := f.familyVersion.GetAllActiveFiles()
+	for idx := range allLiveSSTFiles {
+		liveFiles[allLiveSSTFiles[idx].GetFileNumber()] = dummy
+	}
+	// add live rollup files, maybe some rollup files is not alive in current family version,
+	// but those files cannot delete, because need read those files when do rollup job
+	rollupFiles := f.familyVersion.GetLiveRollupFiles()
+	for file := range rollupFiles {
+		liveFiles[file] = dummy
+	}
+	return liveFiles
+}
+
 // close family, need wait background job completed then releases resource.
 func (f *family) close() {
 	// wait background job completed.
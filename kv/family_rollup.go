@@ -18,6 +18,7 @@
 package kv
 
 import (
+	"fmt"
 	"math/rand"
 	"path"
 	"path/filepath"
@@ -127,6 +128,9 @@ func (f *family) needRollup() bool {
 func (f *family) rollup() {
 	// check if it has background rollup job running already,
 	// has rollup job, return it, else do rollup job.
+	if IsCompactionPaused() {
+		return
+	}
 	if f.rolluping.CAS(false, true) {
 		f.condition.Add(1)
 		go func() {
@@ -260,7 +264,12 @@ func (f *family) doRollupWork(sourceFamily Family, rollup Rollup, sourceFiles []
 	var inputFiles []*version.FileMeta
 	var logs []version.Log
 	for fileNumber := range targetFiles {
-		if fm, ok := v.GetFile(0, fileNumber); ok {
+		fm, ok := v.GetFile(0, fileNumber)
+		if !ok {
+			// backfill job rolls up the files which are compacted into up level
+			fm, ok = findFile(v.GetAllFiles(), fileNumber)
+		}
+		if ok {
 			inputFiles = append(inputFiles, fm)
 			logs = append(logs, version.CreateNewReferenceFile(sourceFamilyID, fileNumber))
 		}
@@ -276,3 +285,88 @@ func (f *family) doRollupWork(sourceFamily Family, rollup Rollup, sourceFiles []
 	}
 	return nil
 }
+
+// BackfillRollup rolls up all files of source family into the family of target interval,
+// used for building the history data after the target interval added into database.
+// The files which wait for live rollup job of target interval and already rolled up are skipped.
+// NOTICE: the files compacted from the files which already rolled up by live rollup job will be rolled up again,
+// so the backfill job should be done for the families before the target interval added.
+func (f *family) BackfillRollup(targetInterval timeutil.Interval) error {
+	if IsCompactionPaused() {
+		return fmt.Errorf("compaction/rollup job paused, family: %s", f.familyInfo())
+	}
+	// blocks live rollup/compact job, because the backfill job reads the files of all levels
+	if !f.rolluping.CAS(false, true) {
+		return fmt.Errorf("rollup job running, family: %s", f.familyInfo())
+	}
+	defer f.rolluping.Store(false)
+	if !f.compacting.CAS(false, true) {
+		return fmt.Errorf("compact job running, family: %s", f.familyInfo())
+	}
+	defer f.compacting.Store(false)
+
+	sourceInterval := f.store.Option().Source
+	calc := sourceInterval.Calculator()
+	storeName := f.store.Name()
+	_, segmentName := filepath.Split(storeName)
+	segmentTime, err := calc.ParseSegmentTime(segmentName)
+	if err != nil {
+		return err
+	}
+	fTime, err := strconv.Atoi(f.Name())
+	if err != nil {
+		return err
+	}
+	familyStartTime := calc.CalcFamilyStartTime(segmentTime, fTime)
+	baseDir := strings.Replace(storeName, path.Join(sourceInterval.Type().String(), segmentName), "", 1)
+	targetCalc := targetInterval.Calculator()
+	targetStoreName := path.Join(baseDir, targetInterval.Type().String(), targetCalc.GetSegment(familyStartTime))
+	targetStore, ok := GetStoreManager().GetStoreByName(targetStoreName)
+	if !ok {
+		return fmt.Errorf("cannot get target store: %s when backfill rollup, family: %s", targetStoreName, f.familyInfo())
+	}
+	tSegmentTime := targetCalc.CalcSegmentTime(familyStartTime)
+	tFamilyTime := targetCalc.CalcFamily(familyStartTime, tSegmentTime)
+	fSTime := targetCalc.CalcFamilyStartTime(tSegmentTime, tFamilyTime)
+	// re-use source family option
+	targetFamily, err := targetStore.CreateFamily(strconv.Itoa(tFamilyTime), f.option)
+	if err != nil {
+		return err
+	}
+
+	liveRollupFiles := f.familyVersion.GetLiveRollupFiles()
+	snapshot := f.GetSnapshot()
+	var files []table.FileNumber
+	for _, file := range snapshot.GetCurrent().GetAllFiles() {
+		fileNumber := file.GetFileNumber()
+		if containsInterval(liveRollupFiles[fileNumber], targetInterval) {
+			// wait for live rollup job
+			continue
+		}
+		files = append(files, fileNumber)
+	}
+	snapshot.Close()
+
+	rollup := newRollup(sourceInterval, targetInterval, familyStartTime, fSTime)
+	return targetFamily.doRollupWork(f, rollup, files)
+}
+
+// findFile returns the file meta by file number.
+func findFile(files []*version.FileMeta, fileNumber table.FileNumber) (*version.FileMeta, bool) {
+	for _, file := range files {
+		if file.GetFileNumber() == fileNumber {
+			return file, true
+		}
+	}
+	return nil, false
+}
+
+// containsInterval checks if intervals contain the target interval.
+func containsInterval(intervals []timeutil.Interval, target timeutil.Interval) bool {
+	for _, interval := range intervals {
+		if interval == target {
+			return true
+		}
+	}
+	return false
+}
@@ -403,6 +403,153 @@ func TestFamily_doRollupWork(t *testing.T) {
 	}
 }
 
+func TestFamily_BackfillRollup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		InitStoreManager(nil)
+		ResumeCompaction()
+		ctrl.Finish()
+	}()
+
+	targetStore := NewMockStore(ctrl)
+	targetFamily := NewMockFamily(ctrl)
+	storeMgr := NewMockStoreManager(ctrl)
+	InitStoreManager(storeMgr)
+	f, store := mockFamily(t, ctrl)
+	fv := f.familyVersion.(*version.MockFamilyVersion)
+	target := timeutil.Interval(5 * 60 * 1000)
+	cases := []struct {
+		name    string
+		prepare func()
+		wantErr bool
+	}{
+		{
+			name:    "compaction paused",
+			prepare: PauseCompaction,
+			wantErr: true,
+		},
+		{
+			name: "rollup job running",
+			prepare: func() {
+				f.rolluping.Store(true)
+			},
+			wantErr: true,
+		},
+		{
+			name: "compact job running",
+			prepare: func() {
+				f.compacting.Store(true)
+			},
+			wantErr: true,
+		},
+		{
+			name: "parse segment name failure",
+			prepare: func() {
+				store.EXPECT().Option().Return(StoreOption{Source: timeutil.Interval(10000)})
+				store.EXPECT().Name().Return("xxx")
+			},
+			wantErr: true,
+		},
+		{
+			name: "parse family name failure",
+			prepare: func() {
+				f.name = "aa"
+				store.EXPECT().Option().Return(StoreOption{Source: timeutil.Interval(10000)})
+				store.EXPECT().Name().Return("db/shard/1/segment/day/20190703")
+			},
+			wantErr: true,
+		},
+		{
+			name: "target store not found",
+			prepare: func() {
+				store.EXPECT().Option().Return(StoreOption{Source: timeutil.Interval(10000)})
+				store.EXPECT().Name().Return("db/shard/1/segment/day/20190703")
+				storeMgr.EXPECT().GetStoreByName("db/shard/1/segment/month/201907").Return(nil, false)
+			},
+			wantErr: true,
+		},
+		{
+			name: "create target family failure",
+			prepare: func() {
+				store.EXPECT().Option().Return(StoreOption{Source: timeutil.Interval(10000)})
+				store.EXPECT().Name().Return("db/shard/1/segment/day/20190703")
+				storeMgr.EXPECT().GetStoreByName("db/shard/1/segment/month/201907").Return(targetStore, true)
+				targetStore.EXPECT().CreateFamily("3", gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "backfill files which are not waiting for live rollup",
+			prepare: func() {
+				snapshot := version.NewMockSnapshot(ctrl)
+				v := version.NewMockVersion(ctrl)
+				store.EXPECT().Option().Return(StoreOption{Source: timeutil.Interval(10000)})
+				store.EXPECT().Name().Return("db/shard/1/segment/day/20190703")
+				storeMgr.EXPECT().GetStoreByName("db/shard/1/segment/month/201907").Return(targetStore, true)
+				targetStore.EXPECT().CreateFamily("3", gomock.Any()).Return(targetFamily, nil)
+				fv.EXPECT().GetLiveRollupFiles().Return(map[table.FileNumber][]timeutil.Interval{10: {target}, 11: {10}})
+				fv.EXPECT().GetSnapshot().Return(snapshot)
+				snapshot.EXPECT().GetCurrent().Return(v)
+				v.EXPECT().GetAllFiles().Return([]*version.FileMeta{
+					version.NewFileMeta(10, 1, 10, 100),
+					version.NewFileMeta(11, 1, 10, 100),
+					version.NewFileMeta(12, 1, 10, 100),
+				})
+				snapshot.EXPECT().Close()
+				targetFamily.EXPECT().doRollupWork(f, gomock.Any(), []table.FileNumber{11, 12}).Return(nil)
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				f.name = "13"
+				f.rolluping.Store(false)
+				f.compacting.Store(false)
+				ResumeCompaction()
+			}()
+			f.name = "13"
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			err := f.BackfillRollup(target)
+			assert.Equal(t, tt.wantErr, err != nil)
+		})
+	}
+}
+
+func TestFamily_doRollupWork_upLevelFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newCompactJobFunc = newCompactJob
+		ctrl.Finish()
+	}()
+	f, _ := mockFamily(t, ctrl)
+	sourceFamily := NewMockFamily(ctrl)
+	fv := f.familyVersion.(*version.MockFamilyVersion)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	compactJob := NewMockCompactJob(ctrl)
+	var inputs []*version.FileMeta
+	newCompactJobFunc = func(family Family, state *compactionState, rollup Rollup) CompactJob {
+		inputs = state.compaction.GetLevelFiles()
+		return compactJob
+	}
+	file := version.NewFileMeta(20, 1, 10, 100)
+	sourceFamily.EXPECT().ID().Return(version.FamilyID(10))
+	fv.EXPECT().GetLiveReferenceFiles().Return(nil)
+	sourceFamily.EXPECT().GetSnapshot().Return(snapshot)
+	snapshot.EXPECT().GetCurrent().Return(v)
+	v.EXPECT().GetFile(0, table.FileNumber(20)).Return(nil, false)
+	v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(10, 1, 10, 100), file})
+	compactJob.EXPECT().Run().Return(nil)
+	snapshot.EXPECT().Close()
+	assert.NoError(t, f.doRollupWork(sourceFamily, NewMockRollup(ctrl), []table.FileNumber{20}))
+	assert.Equal(t, []*version.FileMeta{file}, inputs)
+}
+
 func mockFamily(t *testing.T, ctrl *gomock.Controller) (*family, *MockStore) {
 	path := filepath.Join(t.TempDir(), "need_rollup")
 	store := NewMockStore(ctrl)
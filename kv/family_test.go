@@ -19,6 +19,7 @@ package kv
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"testing"
 	"time"
@@ -29,7 +30,9 @@ import (
 
 	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 )
 
@@ -130,6 +133,12 @@ func TestFamily_commitEditLog(t *testing.T) {
 	// case 4: commit edit log success
 	store.EXPECT().commitFamilyEditLog(gomock.Any(), gomock.Any()).Return(nil)
 	assert.True(t, f.commitEditLog(editLog))
+	// case 5: commit created time of memory database
+	fv.EXPECT().GetID().Return(version.FamilyID(1)).AnyTimes()
+	store.EXPECT().commitFamilyEditLog(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, f.CommitMemDBCreatedTime(100))
+	store.EXPECT().commitFamilyEditLog(gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, f.CommitMemDBCreatedTime(100))
 }
 
 func TestFamily_needCompact(t *testing.T) {
@@ -234,6 +243,67 @@ func TestFamily_compact_background(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestFamily_compactionPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := NewMockStore(ctrl)
+	store.EXPECT().Option().Return(DefaultStoreOption()).AnyTimes()
+	store.EXPECT().Path().Return(t.TempDir())
+	fv := version.NewMockFamilyVersion(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	snapshot.EXPECT().Close().AnyTimes()
+	snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+	fv.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	fv.EXPECT().GetAllActiveFiles().Return(nil).AnyTimes()
+	fv.EXPECT().GetLiveRollupFiles().Return(nil).AnyTimes()
+	store.EXPECT().createFamilyVersion(gomock.Any(), gomock.Any()).Return(fv)
+	f, err := newFamily(store, FamilyOption{
+		Merger:           "mockMerger",
+		Name:             "compaction_policy",
+		CompactionPolicy: option.CompactionPolicyLeveled,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, models.CompactionState{Policy: "leveled"}, f.CompactionState())
+
+	f1 := f.(*family)
+	compactJob := NewMockCompactJob(ctrl)
+	f1.newCompactJobFunc = func(family Family, state *compactionState, rollup Rollup) CompactJob {
+		state.addOutputFile(version.NewFileMeta(12, 1, 100, 2048))
+		return compactJob
+	}
+	// leveled compaction
+	v.EXPECT().PickLeveledCompaction(gomock.Any()).Return(version.NewCompaction(1, 0,
+		[]*version.FileMeta{version.NewFileMeta(10, 1, 50, 1024)},
+		[]*version.FileMeta{version.NewFileMeta(11, 40, 100, 1024)}))
+	compactJob.EXPECT().Run().Return(nil)
+	assert.NoError(t, f1.backgroundCompactionJob())
+	// policy changed, takes effect for new compaction
+	f.SetCompactionPolicy(option.CompactionPolicySizeTiered)
+	v.EXPECT().PickSizeTieredCompaction(gomock.Any(), sizeTieredRatio).Return(version.NewCompaction(1, 0, nil, nil))
+	compactJob.EXPECT().Run().Return(fmt.Errorf("err"))
+	assert.Error(t, f1.backgroundCompactionJob())
+	// unknown policy, fallback to default
+	f.SetCompactionPolicy("unknown")
+	v.EXPECT().PickL0Compaction(gomock.Any()).Return(nil)
+	assert.NoError(t, f1.backgroundCompactionJob())
+
+	assert.Equal(t, models.CompactionState{
+		Policy: "default",
+		Stats: map[string]models.CompactionStat{
+			"leveled": {
+				Compactions: 1,
+				InputFiles:  2,
+				InputBytes:  2048,
+				OutputFiles: 1,
+				OutputBytes: 2048,
+			},
+			"size-tiered": {Failures: 1},
+		},
+	}, f.CompactionState())
+}
+
 func TestFamily_deleteObsoleteFiles(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -278,6 +348,44 @@ func TestFamily_deleteObsoleteFiles(t *testing.T) {
 	f1.deleteObsoleteFiles()
 }
 
+func TestFamily_reconcileFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		listDirFunc = fileutil.ListDir
+		mkDirFunc = fileutil.MkDirIfNotExist
+		ctrl.Finish()
+	}()
+	store := NewMockStore(ctrl)
+	store.EXPECT().Option().Return(DefaultStoreOption()).AnyTimes()
+	store.EXPECT().Path().Return(t.TempDir())
+	fv := version.NewMockFamilyVersion(ctrl)
+	store.EXPECT().createFamilyVersion(gomock.Any(), gomock.Any()).Return(fv)
+	f, err := newFamily(store, FamilyOption{Merger: "mockMerger", Name: "reconcileFiles"})
+	assert.NoError(t, err)
+	f1 := f.(*family)
+	// case 1: list dir err
+	listDirFunc = func(path string) (strings []string, err error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, f1.reconcileFiles(OrphanFileQuarantine))
+	// case 2: quarantine dir create err, keep orphan file
+	listDirFunc = func(path string) (strings []string, err error) {
+		return []string{"000001.sst", "000002.sst", quarantineDir}, nil
+	}
+	mkDirFunc = func(path string) error {
+		return fmt.Errorf("err")
+	}
+	fv.EXPECT().GetAllActiveFiles().Return([]*version.FileMeta{version.NewFileMeta(2, 0, 0, 0)}).AnyTimes()
+	fv.EXPECT().GetLiveRollupFiles().Return(nil).AnyTimes()
+	assert.NoError(t, f1.reconcileFiles(OrphanFileQuarantine))
+	// case 3: file missing
+	listDirFunc = func(path string) (strings []string, err error) {
+		return []string{"000001.sst"}, nil
+	}
+	err = f1.reconcileFiles(OrphanFileDelete)
+	assert.ErrorIs(t, err, ErrVersionCorruption)
+}
+
 func TestFamily_close(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -329,3 +437,112 @@ func TestFamily_Comapct(t *testing.T) {
 	f.Compact()
 	time.Sleep(100 * time.Millisecond)
 }
+
+func TestFamily_IngestFiles(t *testing.T) {
+	defer func() {
+		renameFunc = os.Rename
+	}()
+	option := DefaultStoreOption()
+	source, err := newStore("source_kv", filepath.Join(t.TempDir(), "source"), option)
+	assert.NoError(t, err)
+	defer func() {
+		_ = source.close()
+	}()
+	target, err := newStore("target_kv", filepath.Join(t.TempDir(), "target"), option)
+	assert.NoError(t, err)
+	defer func() {
+		_ = target.close()
+	}()
+	sourceFamily, err := source.CreateFamily("f", FamilyOption{Merger: "mockMerger"})
+	assert.NoError(t, err)
+	targetFamily, err := target.CreateFamily("f", FamilyOption{Merger: "mockMerger"})
+	assert.NoError(t, err)
+
+	flusher := sourceFamily.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	_ = flusher.Add(10, []byte("test10"))
+	assert.NoError(t, flusher.Commit())
+	flusher.Release()
+
+	snapshot := sourceFamily.GetSnapshot()
+	files := snapshot.GetCurrent().GetAllFiles()
+	snapshot.Close()
+	assert.Len(t, files, 1)
+	data, err := os.ReadFile(sourceFamily.FilePath(files[0].GetFileNumber()))
+	assert.NoError(t, err)
+	externalFile := ExternalFile{
+		Path:   filepath.Join(t.TempDir(), "external.sst"),
+		MinKey: files[0].GetMinKey(),
+		MaxKey: files[0].GetMaxKey(),
+		Size:   files[0].GetFileSize(),
+	}
+	assert.NoError(t, os.WriteFile(externalFile.Path, data, 0644))
+
+	// case 1: move file failure
+	renameFunc = func(_, _ string) error {
+		return fmt.Errorf("err")
+	}
+	assert.Error(t, targetFamily.IngestFiles([]ExternalFile{externalFile}, map[int32]int64{1: 10}))
+	// case 2: ingest successfully
+	renameFunc = os.Rename
+	assert.NoError(t, targetFamily.IngestFiles([]ExternalFile{externalFile}, map[int32]int64{1: 10}))
+	assert.False(t, fileutil.Exist(externalFile.Path))
+
+	snapshot = targetFamily.GetSnapshot()
+	defer snapshot.Close()
+	assert.Equal(t, map[int32]int64{1: 10}, snapshot.GetCurrent().GetSequences())
+	readers, err := snapshot.FindReaders(10)
+	assert.NoError(t, err)
+	assert.Len(t, readers, 1)
+	value, _ := readers[0].Get(10)
+	assert.Equal(t, []byte("test10"), value)
+}
+
+func TestFamily_VersionListener(t *testing.T) {
+	kv, err := newStore("version_listener", filepath.Join(t.TempDir(), "kv"), DefaultStoreOption())
+	assert.NoError(t, err)
+	defer func() {
+		_ = kv.close()
+	}()
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockMerger", CompactThreshold: 2})
+	assert.NoError(t, err)
+	flush := func(key uint32) {
+		flusher := f.NewFlusher()
+		defer flusher.Release()
+		assert.NoError(t, flusher.Add(key, []byte("test")))
+		assert.NoError(t, flusher.Commit())
+	}
+	flush(1)
+
+	changes := make(chan VersionChange, 10)
+	remove := f.AddVersionListener(func(change VersionChange) {
+		changes <- change
+	})
+	// case 1: flush new file
+	flush(2)
+	change := <-changes
+	assert.Len(t, change.AddedFiles, 1)
+	assert.Empty(t, change.RemovedFiles)
+	assert.Equal(t, 2, change.NumOfFiles)
+	// case 2: compact files
+	snapshot := f.GetSnapshot()
+	files := snapshot.GetCurrent().GetAllFiles()
+	snapshot.Close()
+	f.Compact()
+	select {
+	case change = <-changes:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "wait compaction timeout")
+		return
+	}
+	assert.Len(t, change.AddedFiles, 1)
+	assert.ElementsMatch(t, []string{
+		f.FilePath(files[0].GetFileNumber()), f.FilePath(files[1].GetFileNumber()),
+	}, change.RemovedFiles)
+	assert.Equal(t, 1, change.NumOfFiles)
+	assert.Equal(t, int64(change.AddedFiles[0].GetFileSize()), change.DiskSize)
+	// case 3: listener removed
+	remove()
+	flush(3)
+	assert.Empty(t, changes)
+}
@@ -0,0 +1,251 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+// stub represents the reference of sst file moved to object storage, which replaces the local file.
+type stub struct {
+	Key  string `json:"key"`  // key of object
+	Size int64  `json:"size"` // size of file
+}
+
+// Tier moves the files of family to object storage, local files are replaced by stubs,
+// flush/compaction never target tiered family, throttle is invoked with the bytes uploaded.
+func (f *family) Tier(ctx context.Context, throttle Throttle) error {
+	storage := GetTierStorage()
+	if storage == nil {
+		return ErrTieringDisabled
+	}
+	if storage.NodeScope() == "" {
+		// nodes sharing the object storage have same layout of families
+		return ErrTierNotNodeScoped
+	}
+	// exclude compaction/un-tiering when tiering
+	if !f.compacting.CAS(false, true) {
+		return ErrFamilyBusy
+	}
+	f.condition.Add(1)
+	defer func() {
+		f.condition.Done()
+		f.compacting.Store(false)
+	}()
+
+	snapshot := f.GetSnapshot()
+	defer snapshot.Close()
+
+	files := snapshot.GetCurrent().GetAllFiles()
+	for _, file := range files {
+		if err := f.tierFile(ctx, storage, file.GetFileNumber(), throttle); err != nil {
+			return err
+		}
+	}
+	kvLogger.Info("tier family successfully",
+		logger.String("family", f.familyInfo()), logger.Int("files", len(files)))
+	return nil
+}
+
+// tierFile uploads the file into object storage, then persists the stub before removing local file,
+// so that the file is always readable from local file or object storage.
+func (f *family) tierFile(ctx context.Context, storage TierStorage, fileNumber table.FileNumber, throttle Throttle) error {
+	filePath := f.FilePath(fileNumber)
+	if _, ok := f.getStub(fileNumber); !ok {
+		info, err := os.Stat(filePath)
+		if err != nil {
+			return err
+		}
+		s := &stub{Key: f.objectKey(storage.NodeScope(), fileNumber), Size: info.Size()}
+		if err := storage.Upload(ctx, s.Key, filePath, throttle); err != nil {
+			return fmt.Errorf("upload file[%s] of family error:%w", filePath, err)
+		}
+		if err := f.writeStub(fileNumber, s); err != nil {
+			return err
+		}
+		f.putStub(fileNumber, s)
+		metrics.TierStatistics.TieredFiles.Incr()
+	}
+	// readers opened already keep reading the mapped file
+	if err := removeFunc(filePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Untier downloads the tiered files of family back to local, then removes them from object storage.
+func (f *family) Untier(ctx context.Context) error {
+	if !f.IsTiered() {
+		return nil
+	}
+	storage := GetTierStorage()
+	if storage == nil {
+		return ErrTieringDisabled
+	}
+	// exclude compaction/tiering when un-tiering
+	if !f.compacting.CAS(false, true) {
+		return ErrFamilyBusy
+	}
+	f.condition.Add(1)
+	defer func() {
+		f.condition.Done()
+		f.compacting.Store(false)
+	}()
+
+	stubs := f.getStubs()
+	for fileNumber, s := range stubs {
+		filePath := f.FilePath(fileNumber)
+		if !fileutil.Exist(filePath) {
+			if err := storage.Download(ctx, s.Key, filePath); err != nil {
+				return fmt.Errorf("download file[%s] of family error:%w", filePath, err)
+			}
+		}
+		if err := removeFunc(f.stubPath(fileNumber)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		f.removeStub(fileNumber)
+		metrics.TierStatistics.UntieredFiles.Incr()
+		if err := storage.Delete(ctx, s.Key); err != nil {
+			// object is left in object storage, which is not referenced by any stub
+			kvLogger.Warn("delete tiered file from object storage failure",
+				logger.String("family", f.familyInfo()), logger.String("key", s.Key), logger.Error(err))
+		}
+	}
+	kvLogger.Info("untier family successfully",
+		logger.String("family", f.familyInfo()), logger.Int("files", len(stubs)))
+	return nil
+}
+
+// IsTiered returns if any file of family is moved to object storage.
+func (f *family) IsTiered() bool {
+	f.stubLock.RLock()
+	defer f.stubLock.RUnlock()
+
+	return len(f.stubs) > 0
+}
+
+// locateFile returns the local path of sst file, fetches the local copy if the file is moved to object storage.
+func (f *family) locateFile(fileName string) (string, error) {
+	filePath := filepath.Join(f.familyPath, fileName)
+	fileDesc := version.ParseFileName(fileName)
+	if fileDesc == nil {
+		return filePath, nil
+	}
+	s, ok := f.getStub(fileDesc.FileNumber)
+	if !ok || fileutil.Exist(filePath) {
+		// local file is removed after stub persisted
+		return filePath, nil
+	}
+	storage := GetTierStorage()
+	if storage == nil {
+		return "", fmt.Errorf("%w, cannot read tiered file[%s]", ErrTieringDisabled, filePath)
+	}
+	return storage.Fetch(context.TODO(), s.Key)
+}
+
+// loadStubs loads the stubs of tiered files under family dir.
+func (f *family) loadStubs() error {
+	fileNames, err := listDirFunc(f.familyPath)
+	if err != nil {
+		return err
+	}
+	for _, fileName := range fileNames {
+		fileDesc := version.ParseFileName(fileName)
+		if fileDesc == nil || fileDesc.FileType != version.TypeStub {
+			continue
+		}
+		data, err := os.ReadFile(f.stubPath(fileDesc.FileNumber))
+		if err != nil {
+			return err
+		}
+		s := &stub{}
+		if err := encoding.JSONUnmarshal(data, s); err != nil {
+			return fmt.Errorf("unmarshal stub[%s] error:%w", fileName, err)
+		}
+		f.putStub(fileDesc.FileNumber, s)
+	}
+	return nil
+}
+
+// writeStub writes the stub into temp file then renames it.
+func (f *family) writeStub(fileNumber table.FileNumber, s *stub) error {
+	stubPath := f.stubPath(fileNumber)
+	tmp := stubPath + "." + version.TmpSuffix
+	if err := os.WriteFile(tmp, encoding.JSONMarshal(s), 0644); err != nil {
+		return err
+	}
+	return renameFunc(tmp, stubPath)
+}
+
+// objectKey returns the object key of sst file, which is unique in object storage
+// shared by nodes(node scope + store name + family name + file).
+func (f *family) objectKey(nodeScope string, fileNumber table.FileNumber) string {
+	return path.Join(nodeScope, filepath.ToSlash(f.store.Name()), f.name, version.Table(fileNumber))
+}
+
+// stubPath returns the path of stub file.
+func (f *family) stubPath(fileNumber table.FileNumber) string {
+	return filepath.Join(f.familyPath, version.Stub(fileNumber))
+}
+
+func (f *family) getStub(fileNumber table.FileNumber) (*stub, bool) {
+	f.stubLock.RLock()
+	defer f.stubLock.RUnlock()
+
+	s, ok := f.stubs[fileNumber]
+	return s, ok
+}
+
+func (f *family) getStubs() map[table.FileNumber]*stub {
+	f.stubLock.RLock()
+	defer f.stubLock.RUnlock()
+
+	stubs := make(map[table.FileNumber]*stub, len(f.stubs))
+	for fileNumber, s := range f.stubs {
+		stubs[fileNumber] = s
+	}
+	return stubs
+}
+
+func (f *family) putStub(fileNumber table.FileNumber, s *stub) {
+	f.stubLock.Lock()
+	defer f.stubLock.Unlock()
+
+	if f.stubs == nil {
+		f.stubs = make(map[table.FileNumber]*stub)
+	}
+	f.stubs[fileNumber] = s
+}
+
+func (f *family) removeStub(fileNumber table.FileNumber) {
+	f.stubLock.Lock()
+	defer f.stubLock.Unlock()
+
+	delete(f.stubs, fileNumber)
+}
@@ -0,0 +1,210 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/objstore"
+)
+
+func TestFamily_Tier(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := objstore.NewArchiveStore(filepath.Join(dir, "archive"))
+	assert.NoError(t, err)
+	storage, err := NewTierStorage(archive, "1", filepath.Join(dir, "cache"), 1024*1024)
+	assert.NoError(t, err)
+	defer SetTierStorage(nil)
+
+	storePath := filepath.Join(dir, "kv")
+	kv, err := newStore("db/shard/segment", storePath, DefaultStoreOption())
+	assert.NoError(t, err)
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockMerger", CompactThreshold: 2})
+	assert.NoError(t, err)
+	flusher := f.NewFlusher()
+	assert.NoError(t, flusher.Add(1, []byte("test")))
+	assert.NoError(t, flusher.Commit())
+	flusher.Release()
+	assert.False(t, f.IsTiered())
+
+	// tiering disabled
+	assert.ErrorIs(t, f.Tier(context.TODO(), nil), ErrTieringDisabled)
+	// object keys not scoped by node
+	notScoped, err := NewTierStorage(archive, "", filepath.Join(dir, "cache"), 1024*1024)
+	assert.NoError(t, err)
+	SetTierStorage(notScoped)
+	assert.ErrorIs(t, f.Tier(context.TODO(), nil), ErrTierNotNodeScoped)
+	assert.False(t, f.IsTiered())
+	SetTierStorage(storage)
+	// throttle failure
+	assert.Error(t, f.Tier(context.TODO(), func(_ context.Context, _ int) error {
+		return errors.New("err")
+	}))
+	assert.False(t, f.IsTiered())
+
+	uploaded := 0
+	assert.NoError(t, f.Tier(context.TODO(), func(_ context.Context, bytes int) error {
+		uploaded += bytes
+		return nil
+	}))
+	assert.True(t, f.IsTiered())
+	assert.NotZero(t, uploaded)
+	familyPath := filepath.Join(storePath, "f")
+	assert.False(t, fileutil.Exist(filepath.Join(familyPath, version.Table(2))))
+	assert.True(t, fileutil.Exist(filepath.Join(familyPath, version.Stub(2))))
+	assert.True(t, fileutil.Exist(filepath.Join(dir, "archive", "1", "db", "shard", "segment", "f", version.Table(2))))
+	// tier again, nothing to do
+	assert.NoError(t, f.Tier(context.TODO(), nil))
+
+	// flush/compaction never target tiered family
+	flusher = f.NewFlusher()
+	assert.NoError(t, flusher.Add(1, []byte("test")))
+	assert.ErrorIs(t, flusher.Commit(), ErrFamilyTiered)
+	flusher.Release()
+	assert.False(t, f.needCompact())
+	assert.False(t, f.CompactAll())
+
+	// close store, then read tiered file after reopen
+	assert.NoError(t, kv.close())
+	kv, err = newStore("db/shard/segment", storePath, DefaultStoreOption())
+	assert.NoError(t, err)
+	assert.NoError(t, kv.(*store).reconcileFamilies())
+	f = kv.GetFamily("f")
+	assert.True(t, f.IsTiered())
+	assertFamilyValue := func() {
+		snapshot := f.GetSnapshot()
+		defer snapshot.Close()
+		readers, err := snapshot.FindReaders(1)
+		assert.NoError(t, err)
+		assert.Len(t, readers, 1)
+		value, err := readers[0].Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("test"), value)
+	}
+	assertFamilyValue()
+	assert.True(t, fileutil.Exist(filepath.Join(dir, "cache", "1", "db", "shard", "segment", "f", version.Table(2))))
+
+	// untier family
+	assert.NoError(t, f.Untier(context.TODO()))
+	assert.False(t, f.IsTiered())
+	assert.True(t, fileutil.Exist(filepath.Join(familyPath, version.Table(2))))
+	assert.False(t, fileutil.Exist(filepath.Join(familyPath, version.Stub(2))))
+	assert.False(t, fileutil.Exist(filepath.Join(dir, "archive", "1", "db", "shard", "segment", "f", version.Table(2))))
+	assert.False(t, fileutil.Exist(filepath.Join(dir, "cache", "1", "db", "shard", "segment", "f", version.Table(2))))
+	// untier again, nothing to do
+	assert.NoError(t, f.Untier(context.TODO()))
+	assertFamilyValue()
+	assert.NoError(t, kv.close())
+}
+
+func TestFamily_Tier_SharedObjectStorage(t *testing.T) {
+	dir := t.TempDir()
+	// nodes share one archive dir, and have same layout of families
+	archive, err := objstore.NewArchiveStore(filepath.Join(dir, "archive"))
+	assert.NoError(t, err)
+	defer SetTierStorage(nil)
+
+	type node struct {
+		storage TierStorage
+		kv      Store
+		family  Family
+	}
+	nodes := make([]*node, 2)
+	for idx := range nodes {
+		nodeDir := filepath.Join(dir, strconv.Itoa(idx+1))
+		storage, err := NewTierStorage(archive, strconv.Itoa(idx+1), filepath.Join(nodeDir, "cache"), 1024*1024)
+		assert.NoError(t, err)
+		kv, err := newStore("db/shard/segment", filepath.Join(nodeDir, "kv"), DefaultStoreOption())
+		assert.NoError(t, err)
+		f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockMerger"})
+		assert.NoError(t, err)
+		flusher := f.NewFlusher()
+		assert.NoError(t, flusher.Add(1, []byte("node-"+strconv.Itoa(idx+1))))
+		assert.NoError(t, flusher.Commit())
+		flusher.Release()
+		SetTierStorage(storage)
+		assert.NoError(t, f.Tier(context.TODO(), nil))
+		assert.True(t, f.IsTiered())
+		nodes[idx] = &node{storage: storage, kv: kv, family: f}
+	}
+	for idx := range nodes {
+		assert.True(t, fileutil.Exist(filepath.Join(dir, "archive", strconv.Itoa(idx+1),
+			"db", "shard", "segment", "f", version.Table(2))))
+	}
+	assertFamilyValue := func(n *node, expect string) {
+		SetTierStorage(n.storage)
+		snapshot := n.family.GetSnapshot()
+		defer snapshot.Close()
+		readers, err := snapshot.FindReaders(1)
+		assert.NoError(t, err)
+		assert.Len(t, readers, 1)
+		value, err := readers[0].Get(1)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(expect), value)
+	}
+	assertFamilyValue(nodes[0], "node-1")
+	assertFamilyValue(nodes[1], "node-2")
+
+	// untier of one node never removes the objects of other node
+	SetTierStorage(nodes[0].storage)
+	assert.NoError(t, nodes[0].family.Untier(context.TODO()))
+	assertFamilyValue(nodes[0], "node-1")
+	assert.False(t, fileutil.Exist(filepath.Join(dir, "archive", "1", "db", "shard", "segment", "f", version.Table(2))))
+	assert.True(t, fileutil.Exist(filepath.Join(dir, "archive", "2", "db", "shard", "segment", "f", version.Table(2))))
+	assertFamilyValue(nodes[1], "node-2")
+	for _, n := range nodes {
+		assert.NoError(t, n.kv.close())
+	}
+}
+
+func TestFamily_Tier_Busy(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := objstore.NewArchiveStore(filepath.Join(dir, "archive"))
+	assert.NoError(t, err)
+	storage, err := NewTierStorage(archive, "1", filepath.Join(dir, "cache"), 1024*1024)
+	assert.NoError(t, err)
+	SetTierStorage(storage)
+	defer SetTierStorage(nil)
+
+	kv, err := newStore("test_kv", filepath.Join(dir, "kv"), DefaultStoreOption())
+	assert.NoError(t, err)
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: "mockMerger"})
+	assert.NoError(t, err)
+	f1 := f.(*family)
+	f1.putStub(10, &stub{Key: "key"})
+	f1.compacting.Store(true)
+	assert.ErrorIs(t, f.Tier(context.TODO(), nil), ErrFamilyBusy)
+	assert.ErrorIs(t, f.Untier(context.TODO()), ErrFamilyBusy)
+	f1.compacting.Store(false)
+	// object not found
+	assert.ErrorIs(t, f.Untier(context.TODO()), objstore.ErrObjectNotFound)
+	// read tiered file when tiering disabled
+	SetTierStorage(nil)
+	_, err = f1.locateFile(version.Table(10))
+	assert.ErrorIs(t, err, ErrTieringDisabled)
+	assert.ErrorIs(t, f.Untier(context.TODO()), ErrTieringDisabled)
+	assert.NoError(t, kv.close())
+}
@@ -27,6 +27,7 @@ import (
 	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/kv/version"
 	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/fault"
 )
 
 //go:generate mockgen -source ./flusher.go -destination=./flusher_mock.go -package kv
@@ -39,8 +40,12 @@ type Flusher interface {
 	Add(key uint32, value []byte) error
 	// Sequence sets write sequence number.
 	Sequence(leader int32, seq int64)
+	// MemDBCreatedTime sets the created time of memory database not flushed, 0 means all data flushed.
+	MemDBCreatedTime(timestamp int64)
 	// Commit flushes data and commits metadata.
 	Commit() error
+	// Outputs returns the file numbers of files written by flusher.
+	Outputs() []table.FileNumber
 	// Release releases the resource of flusher.
 	// NOTICE: MUST invoke Release() after new fluster instance.
 	Release()
@@ -100,6 +105,11 @@ func (sf *storeFlusher) Sequence(leader int32, seq int64) {
 	sf.sequences[leader] = seq
 }
 
+// MemDBCreatedTime sets the created time of memory database not flushed, 0 means all data flushed.
+func (sf *storeFlusher) MemDBCreatedTime(timestamp int64) {
+	sf.editLog.Add(version.CreateMemDBCreatedTime(timestamp))
+}
+
 func (sf *storeFlusher) StreamWriter() (table.StreamWriter, error) {
 	if err := sf.checkBuilder(); err != nil {
 		metrics.FlushStatistics.Failure.Incr()
@@ -126,6 +136,11 @@ func (sf *storeFlusher) Commit() (err error) {
 		if err != nil {
 			return fmt.Errorf("close table builder error when flush commit, error:%s", err)
 		}
+		if sf.family.IsTiered() {
+			// flushed file is deleted as obsolete file
+			err = fmt.Errorf("%w, cannot flush into family:%s", ErrFamilyTiered, sf.family.Name())
+			return err
+		}
 
 		fileMeta := version.NewFileMeta(builder.FileNumber(), builder.MinKey(), builder.MaxKey(), builder.Size())
 		sf.editLog.Add(version.CreateNewFile(0, fileMeta))
@@ -147,6 +162,9 @@ func (sf *storeFlusher) Commit() (err error) {
 		}
 	}
 
+	if err = fault.Inject(fault.KVFlusherCommit); err != nil {
+		return err
+	}
 	if flag := sf.family.commitEditLog(sf.editLog); !flag {
 		err = fmt.Errorf("commit edit log failure")
 		return err
@@ -154,6 +172,11 @@ func (sf *storeFlusher) Commit() (err error) {
 	return nil
 }
 
+// Outputs returns the file numbers of files written by flusher.
+func (sf *storeFlusher) Outputs() []table.FileNumber {
+	return sf.outputs
+}
+
 // Release releases the resource of flusher.
 func (sf *storeFlusher) Release() {
 	metrics.FlushStatistics.Flushing.Decr()
@@ -190,12 +213,17 @@ func (nf *NopFlusher) Add(_ uint32, value []byte) error {
 
 func (nf *NopFlusher) Sequence(_ int32, _ int64) {}
 
+func (nf *NopFlusher) MemDBCreatedTime(_ int64) {}
+
 // Commit always return nil
 func (nf *NopFlusher) Commit() error {
 	nf.buffer.Reset()
 	return nil
 }
 
+// Outputs always return nil
+func (nf *NopFlusher) Outputs() []table.FileNumber { return nil }
+
 func (nf *NopFlusher) Release() {}
 
 type nopStreamWriter struct {
@@ -68,6 +68,7 @@ func TestFlusher_Add(t *testing.T) {
 	defer flusher.Release()
 	err = flusher.Add(uint32(10), []byte("value10"))
 	assert.NoError(t, err)
+	assert.Equal(t, []table.FileNumber{100}, flusher.Outputs())
 }
 
 func TestStoreFlusher_Commit(t *testing.T) {
@@ -95,6 +96,7 @@ func TestStoreFlusher_Commit(t *testing.T) {
 	defer flusher.Release()
 	flusher.Sequence(1, 10)
 	flusher.Sequence(2, 20)
+	flusher.MemDBCreatedTime(0)
 	err = flusher.Commit()
 	assert.NoError(t, err)
 
@@ -112,9 +114,26 @@ func TestStoreFlusher_Commit(t *testing.T) {
 	err = flusher.Commit()
 	assert.Error(t, err)
 
+	// family is tiered
 	gomock.InOrder(
 		family.EXPECT().ID().Return(version.FamilyID(10)),
 		builder.EXPECT().Close().Return(nil),
+		family.EXPECT().IsTiered().Return(true),
+		family.EXPECT().Name().Return("f"),
+		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
+		family.EXPECT().removePendingOutput(table.FileNumber(10)),
+	)
+	flusher = newStoreFlusher(family, func() {})
+	defer flusher.Release()
+	f = flusher.(*storeFlusher)
+	f.builder = builder
+	err = flusher.Commit()
+	assert.ErrorIs(t, err, ErrFamilyTiered)
+
+	gomock.InOrder(
+		family.EXPECT().ID().Return(version.FamilyID(10)),
+		builder.EXPECT().Close().Return(nil),
+		family.EXPECT().IsTiered().Return(false),
 		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
 		builder.EXPECT().MinKey().Return(uint32(1)),
 		builder.EXPECT().MaxKey().Return(uint32(10)),
@@ -133,6 +152,7 @@ func TestStoreFlusher_Commit(t *testing.T) {
 	gomock.InOrder(
 		family.EXPECT().ID().Return(version.FamilyID(10)),
 		builder.EXPECT().Close().Return(nil),
+		family.EXPECT().IsTiered().Return(false),
 		builder.EXPECT().FileNumber().Return(table.FileNumber(10)),
 		builder.EXPECT().MinKey().Return(uint32(1)),
 		builder.EXPECT().MaxKey().Return(uint32(10)),
@@ -204,7 +224,9 @@ func TestStoreFlusher_StreamWriter(t *testing.T) {
 func Test_NopFlusher(t *testing.T) {
 	nf := NewNopFlusher()
 	nf.Sequence(1, 10)
+	nf.MemDBCreatedTime(10)
 	assert.Nil(t, nf.Commit())
+	assert.Nil(t, nf.Outputs())
 	assert.Nil(t, nf.Add(1, nil))
 	assert.Nil(t, nf.Bytes())
 
@@ -26,6 +26,24 @@ import (
 	"github.com/lindb/lindb/pkg/logger"
 )
 
+// compactionPaused represents if compaction/rollup jobs are paused(such as disk space low).
+var compactionPaused atomic.Bool
+
+// PauseCompaction pauses new compaction/rollup jobs, the running jobs are not interrupted.
+func PauseCompaction() {
+	compactionPaused.Store(true)
+}
+
+// ResumeCompaction resumes compaction/rollup jobs.
+func ResumeCompaction() {
+	compactionPaused.Store(false)
+}
+
+// IsCompactionPaused returns if compaction/rollup jobs are paused.
+func IsCompactionPaused() bool {
+	return compactionPaused.Load()
+}
+
 // JobScheduler represents a background compaction job scheduler.
 type JobScheduler interface {
 	// Startup starts the job scheduler.
@@ -91,6 +109,10 @@ func (js *jobScheduler) schedule() {
 		for {
 			select {
 			case <-ticker.C:
+				if js.ctx.Err() != nil {
+					// scheduler shutdown, store manager maybe reset
+					continue
+				}
 				stores := GetStoreManager().GetStores()
 				for idx := range stores {
 					store := stores[idx]
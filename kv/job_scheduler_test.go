@@ -48,6 +48,7 @@ func TestJobScheduler(t *testing.T) {
 		js.Startup()
 		assert.True(t, js.IsRunning())
 		time.Sleep(1500 * time.Millisecond)
+		js.Shutdown()
 	})
 
 	t.Run("shutdown job", func(t *testing.T) {
@@ -62,3 +63,19 @@ func TestJobScheduler(t *testing.T) {
 		time.Sleep(100 * time.Millisecond)
 	})
 }
+
+func TestPauseCompaction(t *testing.T) {
+	defer ResumeCompaction()
+
+	assert.False(t, IsCompactionPaused())
+	PauseCompaction()
+	assert.True(t, IsCompactionPaused())
+	// compaction/rollup job skipped when paused
+	f := &family{}
+	f.compact()
+	assert.False(t, f.compacting.Load())
+	f.rollup()
+	assert.False(t, f.rolluping.Load())
+	ResumeCompaction()
+	assert.False(t, IsCompactionPaused())
+}
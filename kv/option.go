@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 )
 
@@ -32,8 +33,21 @@ type FamilyOption struct {
 	RollupThreshold  int    `toml:"rollupThreshold"`  // level 0 rollup threshold
 	Merger           string `toml:"merger"`           // merger which need implement Merger interface
 	MaxFileSize      uint32 `toml:"maxFileSize"`      // max file size
+	// compaction policy when family created, can be changed by Family.SetCompactionPolicy for new compactions.
+	CompactionPolicy option.CompactionPolicy `toml:"compactionPolicy"`
 }
 
+// OrphanFileAction represents how to handle the orphan sst files which are not referenced by any version
+// when opening store, orphan files are left by crash after flush/compaction written files but before committed.
+type OrphanFileAction string
+
+const (
+	// OrphanFileDelete deletes the orphan files.
+	OrphanFileDelete OrphanFileAction = "delete"
+	// OrphanFileQuarantine moves the orphan files into quarantine dir of family for investigation.
+	OrphanFileQuarantine OrphanFileAction = "quarantine"
+)
+
 // StoreOption defines config item for store level
 type StoreOption struct {
 	Levels int            `toml:"levels"` // num. of levels
@@ -33,6 +33,8 @@ import (
 	"github.com/lindb/lindb/pkg/lockers"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 //go:generate mockgen -source ./store.go -destination=./store_mock.go -package kv
@@ -67,7 +69,17 @@ type Store interface {
 	Option() StoreOption
 	// ForceRollup does rollup job manual.
 	ForceRollup()
-
+	// SetCompactionPolicy sets the compaction policy of all families under store,
+	// which takes effect for new compactions.
+	SetCompactionPolicy(policy option.CompactionPolicy)
+	// SetMergerParam sets the param which is passed to merger of all families under store,
+	// takes effect for new compactions.
+	SetMergerParam(key string, value interface{})
+	// SetRollup sets the source interval and target intervals of rollup, which takes effect for new flushed files.
+	SetRollup(source timeutil.Interval, rollup []timeutil.Interval)
+
+	// getMergerParams returns the params passed to merger.
+	getMergerParams() map[string]interface{}
 	// compact the families under store.
 	compact()
 	// close store, then release some resource
@@ -88,6 +100,8 @@ type store struct {
 	name   string
 	path   string
 	option StoreOption
+	// RWMutex for accessing option
+	optionMutex sync.RWMutex
 	// file-lock restricts access to store by allowing only one instance
 	lock     lockers.FileLock
 	versions version.StoreVersionSet
@@ -100,6 +114,8 @@ type store struct {
 	storeInfo *storeInfo
 	cache     table.Cache
 
+	mergerParams sync.Map
+
 	ctx    context.Context
 	cancel context.CancelFunc
 }
@@ -157,11 +173,10 @@ func newStore(name, path string, option StoreOption) (s Store, err error) {
 
 		// finally, try delete obsolete files
 		store1.deleteObsoleteFiles()
-		store1.deleteFamilyObsoleteFiles()
 	}()
 
 	// build store reader cache
-	store1.cache = table.NewCache(path, option.TTL.Duration())
+	store1.cache = table.NewCacheWithLocator(path, option.TTL.Duration(), store1.locateFile)
 	// init version set
 	store1.versions = newVersionSetFunc(path, store1.cache, store1.option.Levels)
 
@@ -191,6 +206,10 @@ func newStore(name, path string, option StoreOption) (s Store, err error) {
 	if err != nil {
 		return nil, fmt.Errorf("recover store version set error:%s", err)
 	}
+	// reconcile family files with recovered versions
+	if err = store1.reconcileFamilies(); err != nil {
+		return nil, err
+	}
 
 	return store1, nil
 }
@@ -221,6 +240,10 @@ func (s *store) CreateFamily(familyName string, option FamilyOption) (family Fam
 	s.rwMutex.Lock()
 	defer s.rwMutex.Unlock()
 
+	// double check, family may be created by concurrent creator
+	if family, ok = s.families[familyName]; ok {
+		return family, nil
+	}
 	if !fileutil.Exist(familyPath) {
 		// create new family
 		option.Name = familyName
@@ -251,6 +274,33 @@ func (s *store) GetFamily(familyName string) Family {
 	return family
 }
 
+// SetCompactionPolicy sets the compaction policy of all families under store,
+// which takes effect for new compactions.
+func (s *store) SetCompactionPolicy(policy option.CompactionPolicy) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+
+	for _, family := range s.families {
+		family.SetCompactionPolicy(policy)
+	}
+}
+
+// SetMergerParam sets the param which is passed to merger of all families under store,
+// takes effect for new compactions.
+func (s *store) SetMergerParam(key string, value interface{}) {
+	s.mergerParams.Store(key, value)
+}
+
+// getMergerParams returns the params passed to merger.
+func (s *store) getMergerParams() map[string]interface{} {
+	params := make(map[string]interface{})
+	s.mergerParams.Range(func(key, value any) bool {
+		params[key.(string)] = value
+		return true
+	})
+	return params
+}
+
 // ListFamilyNames returns the all family's name
 func (s *store) ListFamilyNames() []string {
 	var result []string
@@ -264,9 +314,21 @@ func (s *store) ListFamilyNames() []string {
 
 // Option returns the store configuration options
 func (s *store) Option() StoreOption {
+	s.optionMutex.RLock()
+	defer s.optionMutex.RUnlock()
+
 	return s.option
 }
 
+// SetRollup sets the source interval and target intervals of rollup, which takes effect for new flushed files.
+func (s *store) SetRollup(source timeutil.Interval, rollup []timeutil.Interval) {
+	s.optionMutex.Lock()
+	defer s.optionMutex.Unlock()
+
+	s.option.Source = source
+	s.option.Rollup = rollup
+}
+
 // ForceRollup does rollup job manual.
 func (s *store) ForceRollup() {
 	families := s.getCurrentFamilies()
@@ -299,6 +361,14 @@ func (s *store) evictFamilyFile(fileNumber table.FileNumber) {
 	s.cache.Evict(version.Table(fileNumber))
 }
 
+// locateFile returns the local path of sst file under family, which may be the local copy of tiered file.
+func (s *store) locateFile(familyName, fileName string) (string, error) {
+	if family := s.GetFamily(familyName); family != nil {
+		return family.locateFile(fileName)
+	}
+	return filepath.Join(s.path, familyName, fileName), nil
+}
+
 // createFamilyVersion creates family version using family name and family id,
 // if family version exist, return exist one
 func (s *store) createFamilyVersion(name string, familyID version.FamilyID) version.FamilyVersion {
@@ -350,11 +420,15 @@ func (s *store) compact() {
 	s.cache.Cleanup()
 }
 
-// deleteFamilyObsoleteFiles deletes the all families obsolete files when init kv store
-func (s *store) deleteFamilyObsoleteFiles() {
+// reconcileFamilies reconciles the files of all families with recovered versions when init kv store.
+func (s *store) reconcileFamilies() error {
+	action := getOrphanFileAction()
 	for _, family := range s.families {
-		family.deleteObsoleteFiles()
+		if err := family.reconcileFiles(action); err != nil {
+			return err
+		}
 	}
+	return nil
 }
 
 // deleteObsoleteFiles deletes the obsolete files
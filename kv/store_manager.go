@@ -27,34 +27,51 @@ import (
 //go:generate mockgen -source ./store_manager.go -destination=./store_manager_mock.go -package kv
 
 type StoreOptions struct {
-	Dir                  string // store root path
-	CompactCheckInterval int    // compact/rollup job check interval(number of seconds)
+	Dir                  string           // store root path
+	CompactCheckInterval int              // compact/rollup job check interval(number of seconds)
+	OrphanFileAction     OrphanFileAction // how to handle orphan files when opening store, delete if empty
 }
 
 var (
-	sManager          StoreManager
-	once4StoreManager sync.Once
-	Options           atomic.Value
+	sManager     StoreManager
+	sManagerLock sync.RWMutex
+	Options      atomic.Value
 )
 
-// InitStoreManager initializes StoreManager.
+// getOrphanFileAction returns how to handle orphan files when opening store, delete by default.
+func getOrphanFileAction() OrphanFileAction {
+	if options, ok := Options.Load().(*StoreOptions); ok && options.OrphanFileAction == OrphanFileQuarantine {
+		return OrphanFileQuarantine
+	}
+	return OrphanFileDelete
+}
+
+// InitStoreManager initializes StoreManager,
+// nil resets the singleton instance, which is created by current store options on next access(e.g. embedded restart).
 func InitStoreManager(storeMgr StoreManager) {
+	sManagerLock.Lock()
+	defer sManagerLock.Unlock()
 	sManager = storeMgr
 }
 
 // GetStoreManager returns the kv store manager singleton instance.
 func GetStoreManager() StoreManager {
-	if sManager != nil {
-		return sManager
+	sManagerLock.RLock()
+	storeMgr := sManager
+	sManagerLock.RUnlock()
+	if storeMgr != nil {
+		return storeMgr
 	}
-	once4StoreManager.Do(func() {
+	sManagerLock.Lock()
+	defer sManagerLock.Unlock()
+	if sManager == nil {
 		optionsVal := Options.Load()
 		options, ok := optionsVal.(*StoreOptions)
 		if !ok || options.Dir == "" {
 			panic("cannot load store options, please check.")
 		}
-		sManager = newStoreManager(*options)
-	})
+		sManager = NewStoreManager(*options)
+	}
 	return sManager
 }
 
@@ -79,8 +96,9 @@ type storeManager struct {
 	mutex sync.Mutex
 }
 
-// newStoreManager creates a StoreManager instance.
-func newStoreManager(options StoreOptions) StoreManager {
+// NewStoreManager creates a StoreManager instance, stores are created under the root path of options.
+// NOTICE: stores of the instance not registered by InitStoreManager are not compacted by job scheduler.
+func NewStoreManager(options StoreOptions) StoreManager {
 	return &storeManager{
 		stores:  make(map[string]Store),
 		options: options,
@@ -19,7 +19,6 @@ package kv
 
 import (
 	"fmt"
-	"sync"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -29,7 +28,7 @@ import (
 func TestInitStoreManager(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
-		once4StoreManager = sync.Once{}
+		InitStoreManager(nil)
 		ctrl.Finish()
 	}()
 	t.Run("set init store manager", func(t *testing.T) {
@@ -42,7 +41,7 @@ func TestInitStoreManager(t *testing.T) {
 	})
 	t.Run("panic if option invalid", func(t *testing.T) {
 		defer func() {
-			once4StoreManager = sync.Once{}
+			InitStoreManager(nil)
 		}()
 		assert.Panics(t, func() {
 			GetStoreManager()
@@ -50,7 +49,7 @@ func TestInitStoreManager(t *testing.T) {
 	})
 	t.Run("get singleton store manager", func(t *testing.T) {
 		defer func() {
-			once4StoreManager = sync.Once{}
+			InitStoreManager(nil)
 			Options.Store(&StoreOptions{})
 		}()
 		Options.Store(&StoreOptions{
@@ -69,7 +68,7 @@ func TestStoreManager_CreateStore(t *testing.T) {
 		newStoreFunc = newStore
 		ctrl.Finish()
 	}()
-	storeMgr := newStoreManager(StoreOptions{})
+	storeMgr := NewStoreManager(StoreOptions{})
 	cases := []struct {
 		name      string
 		storeName string
@@ -134,7 +133,7 @@ func TestMockStoreManager_CloseStore(t *testing.T) {
 		newStoreFunc = newStore
 		ctrl.Finish()
 	}()
-	storeMgr := newStoreManager(StoreOptions{})
+	storeMgr := NewStoreManager(StoreOptions{})
 	store := NewMockStore(ctrl)
 	newStoreFunc = func(name, path string, option StoreOption) (s Store, err error) {
 		return store, nil
@@ -194,7 +193,7 @@ func TestStoreManager_Get(t *testing.T) {
 		newStoreFunc = newStore
 		ctrl.Finish()
 	}()
-	storeMgr := newStoreManager(StoreOptions{})
+	storeMgr := NewStoreManager(StoreOptions{})
 	store := NewMockStore(ctrl)
 	newStoreFunc = func(name, path string, option StoreOption) (s Store, err error) {
 		return store, nil
@@ -32,6 +32,8 @@ import (
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/lockers"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 var mergerStr = "mockMergerAppend"
@@ -201,7 +203,7 @@ func TestStore_CreateFamily(t *testing.T) {
 	assert.Len(t, names, 1)
 	assert.Equal(t, "f", names[0])
 	s := kv.(*store)
-	s.deleteFamilyObsoleteFiles()
+	assert.NoError(t, s.reconcileFamilies())
 }
 
 func TestStore_deleteObsoleteFiles(t *testing.T) {
@@ -232,6 +234,59 @@ func TestStore_deleteObsoleteFiles(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestStore_reconcileFamilies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reconcile")
+	defer func() {
+		Options.Store(&StoreOptions{})
+		renameFunc = os.Rename
+	}()
+	kv, err := newStore("test_kv", path, DefaultStoreOption())
+	assert.NoError(t, err)
+	f, err := kv.CreateFamily("f", FamilyOption{Merger: mergerStr})
+	assert.NoError(t, err)
+	flusher := f.NewFlusher()
+	assert.NoError(t, flusher.Add(1, []byte("test")))
+	assert.NoError(t, flusher.Commit())
+	flusher.Release()
+	liveFile := f.GetSnapshot().GetCurrent().GetAllFiles()[0].GetFileNumber()
+	familyPath := filepath.Join(path, "f")
+	assert.NoError(t, kv.close())
+
+	writeOrphan := func(fileNumber table.FileNumber) {
+		assert.NoError(t, os.WriteFile(filepath.Join(familyPath, version.Table(fileNumber)), []byte("orphan"), 0o644))
+	}
+	// case 1: quarantine orphan file
+	Options.Store(&StoreOptions{OrphanFileAction: OrphanFileQuarantine})
+	writeOrphan(100)
+	kv, err = newStore("test_kv", path, DefaultStoreOption())
+	assert.NoError(t, err)
+	assert.NoError(t, kv.close())
+	assert.False(t, fileutil.Exist(filepath.Join(familyPath, version.Table(100))))
+	assert.True(t, fileutil.Exist(filepath.Join(familyPath, quarantineDir, version.Table(100))))
+	assert.True(t, fileutil.Exist(filepath.Join(familyPath, version.Table(liveFile))))
+	// case 2: quarantine failure, keep orphan file
+	renameFunc = func(_, _ string) error {
+		return fmt.Errorf("err")
+	}
+	writeOrphan(101)
+	kv, err = newStore("test_kv", path, DefaultStoreOption())
+	assert.NoError(t, err)
+	assert.NoError(t, kv.close())
+	assert.True(t, fileutil.Exist(filepath.Join(familyPath, version.Table(101))))
+	// case 3: delete orphan file
+	Options.Store(&StoreOptions{OrphanFileAction: OrphanFileDelete})
+	kv, err = newStore("test_kv", path, DefaultStoreOption())
+	assert.NoError(t, err)
+	assert.NoError(t, kv.close())
+	assert.False(t, fileutil.Exist(filepath.Join(familyPath, version.Table(101))))
+	// case 4: file referenced by version is missing
+	assert.NoError(t, os.Remove(filepath.Join(familyPath, version.Table(liveFile))))
+	kv, err = newStore("test_kv", path, DefaultStoreOption())
+	assert.ErrorIs(t, err, ErrVersionCorruption)
+	assert.Contains(t, err.Error(), version.Table(liveFile))
+	assert.Nil(t, kv)
+}
+
 func TestStore_Compact(t *testing.T) {
 	path := "compact_test"
 	option := DefaultStoreOption()
@@ -271,6 +326,42 @@ func TestStore_Compact(t *testing.T) {
 	snapshot.Close()
 }
 
+func TestStore_SetCompactionPolicy(t *testing.T) {
+	path := t.TempDir()
+	kv, err := newStore("test_kv", path, DefaultStoreOption())
+	assert.NoError(t, err)
+	f1, err := kv.CreateFamily("f", FamilyOption{
+		CompactThreshold: 2,
+		Merger:           mergerStr,
+		CompactionPolicy: option.CompactionPolicySizeTiered,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "size-tiered", f1.CompactionState().Policy)
+
+	for i := 0; i < 2; i++ {
+		flusher := f1.NewFlusher()
+		_ = flusher.Add(1, []byte("test"))
+		assert.NoError(t, flusher.Commit())
+		flusher.Release()
+	}
+	kv.compact()
+	time.Sleep(time.Second)
+	state := f1.CompactionState()
+	assert.Equal(t, int64(1), state.Stats["size-tiered"].Compactions)
+	assert.Equal(t, int64(2), state.Stats["size-tiered"].InputFiles)
+	assert.Equal(t, int64(1), state.Stats["size-tiered"].OutputFiles)
+
+	kv.SetCompactionPolicy(option.CompactionPolicyLeveled)
+	assert.Equal(t, "leveled", f1.CompactionState().Policy)
+	assert.NoError(t, kv.close())
+
+	// policy of family creation is persisted
+	kv, err = newStore("test_kv", path, DefaultStoreOption())
+	assert.NoError(t, err)
+	assert.Equal(t, "size-tiered", kv.GetFamily("f").CompactionState().Policy)
+	assert.NoError(t, kv.close())
+}
+
 func TestStore_Rollup(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	path := "rollup_test"
@@ -307,6 +398,10 @@ func TestStore_Rollup(t *testing.T) {
 
 	family.EXPECT().rollup()
 	kv.ForceRollup()
+
+	kv.SetRollup(timeutil.Interval(10*timeutil.OneSecond), []timeutil.Interval{timeutil.Interval(timeutil.OneHour)})
+	assert.Equal(t, timeutil.Interval(10*timeutil.OneSecond), kv.Option().Source)
+	assert.Equal(t, []timeutil.Interval{timeutil.Interval(timeutil.OneHour)}, kv.Option().Rollup)
 }
 
 func TestStore_Close(t *testing.T) {
@@ -333,3 +428,52 @@ func TestStore_Close(t *testing.T) {
 	kv1.versions = vs
 	assert.NoError(t, kv.close())
 }
+
+type mockParamsMerger struct {
+	mockAppendMerger
+}
+
+var mergerParams = make(chan map[string]interface{}, 1)
+
+func (m *mockParamsMerger) Init(params map[string]interface{}) {
+	mergerParams <- params
+}
+
+func TestStore_CompactAll(t *testing.T) {
+	RegisterMerger("mockMergerParams", func(flusher Flusher) (Merger, error) {
+		return &mockParamsMerger{mockAppendMerger{flusher: flusher}}, nil
+	})
+	defer delete(mergers, "mockMergerParams")
+
+	kv, err := newStore("test_kv", t.TempDir(), DefaultStoreOption())
+	assert.NoError(t, err)
+	f1, err := kv.CreateFamily("f", FamilyOption{CompactThreshold: 10, Merger: "mockMergerParams"})
+	assert.NoError(t, err)
+	// no file, nothing to do
+	assert.True(t, f1.CompactAll())
+	time.Sleep(100 * time.Millisecond)
+	assert.Empty(t, f1.CompactionState().Stats)
+
+	flusher := f1.NewFlusher()
+	_ = flusher.Add(1, []byte("test"))
+	assert.NoError(t, flusher.Commit())
+	flusher.Release()
+
+	kv.SetMergerParam("param", 10)
+	assert.True(t, f1.CompactAll())
+	params := <-mergerParams
+	assert.Equal(t, map[string]interface{}{"param": 10, FamilyNameContext: "f"}, params)
+	time.Sleep(100 * time.Millisecond)
+	// single level0 file is rewritten, not moved
+	snapshot := f1.GetSnapshot()
+	assert.Zero(t, snapshot.GetCurrent().NumberOfFilesInLevel(0))
+	assert.Equal(t, 1, snapshot.GetCurrent().NumberOfFilesInLevel(1))
+	snapshot.Close()
+	assert.Equal(t, int64(1), f1.CompactionState().Stats["default"].Compactions)
+
+	// compaction paused
+	PauseCompaction()
+	assert.False(t, f1.CompactAll())
+	ResumeCompaction()
+	assert.NoError(t, kv.close())
+}
@@ -37,6 +37,10 @@ var (
 	newMMapStoreReaderFunc = newMMapStoreReader
 )
 
+// FileLocator returns the local path of file under family, which may be a local copy of
+// the file moved to object storage.
+type FileLocator func(family, fileName string) (string, error)
+
 // Cache caches table readers.
 type Cache interface {
 	// GetReader returns store reader from cache, create new reader if not exist.
@@ -55,16 +59,25 @@ type Cache interface {
 type storeCache struct {
 	ttl       time.Duration
 	storePath string
+	locate    FileLocator
 	families  map[string]map[string]struct{} // family name => files
 	cache     *LRUCache
 	mutex     sync.Mutex
 }
 
-// NewCache creates cache for store readers.
+// NewCache creates cache for store readers, files are under store path.
 func NewCache(storePath string, ttl time.Duration) Cache {
+	return NewCacheWithLocator(storePath, ttl, func(family, fileName string) (string, error) {
+		return filepath.Join(storePath, family, fileName), nil
+	})
+}
+
+// NewCacheWithLocator creates cache for store readers, files are located by locator.
+func NewCacheWithLocator(storePath string, ttl time.Duration, locator FileLocator) Cache {
 	return &storeCache{
 		ttl:       ttl,
 		storePath: storePath,
+		locate:    locator,
 		families:  make(map[string]map[string]struct{}),
 		cache:     NewLRUCache(),
 	}
@@ -95,20 +108,28 @@ func (c *storeCache) ReleaseReaders(readers []Reader) {
 
 // GetReader returns store reader from cache, create new reader if not exist.
 func (c *storeCache) GetReader(family, fileName string) (Reader, error) {
+	// find from cache
+	if reader, ok := c.getCachedReader(fileName); ok {
+		return reader, nil
+	}
+	// locate file without holding lock, because locating may download the file from object storage
+	path, err := c.locate(family, fileName)
+	if err != nil {
+		return nil, err
+	}
+
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	// find from cache
+	// find from cache again, reader may be created by concurrent reading when locating
 	if entry, ok := c.cache.Get(fileName); ok {
 		entry.retain()
-		metrics.TableCacheStatistics.Hit.Incr()
 		return entry.reader, nil
 	}
 
 	metrics.TableCacheStatistics.Miss.Incr()
 	metrics.TableCacheStatistics.ActiveReaders.Incr()
 	// create new reader
-	path := filepath.Join(c.storePath, family, fileName)
 	newReader, err := newMMapStoreReaderFunc(path, fileName)
 	if err != nil {
 		return nil, err
@@ -131,6 +152,19 @@ func (c *storeCache) GetReader(family, fileName string) (Reader, error) {
 	return newReader, nil
 }
 
+// getCachedReader returns the store reader if it's in cache.
+func (c *storeCache) getCachedReader(fileName string) (Reader, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if entry, ok := c.cache.Get(fileName); ok {
+		entry.retain()
+		metrics.TableCacheStatistics.Hit.Incr()
+		return entry.reader, true
+	}
+	return nil, false
+}
+
 // Cleanup cleans the expired reader from cache.
 func (c *storeCache) Cleanup() {
 	c.mutex.Lock()
@@ -19,6 +19,7 @@ package table
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -75,6 +76,35 @@ func TestMapCache_GetReader(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestStoreCache_Locator(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newMMapStoreReaderFunc = newMMapStoreReader
+		ctrl.Finish()
+	}()
+
+	locatedPath := ""
+	cache := NewCacheWithLocator(t.TempDir(), time.Hour, func(family, fileName string) (string, error) {
+		if family == "err" {
+			return "", fmt.Errorf("err")
+		}
+		return filepath.Join("/cache", family, fileName), nil
+	})
+	r, err := cache.GetReader("err", "100000.sst")
+	assert.Error(t, err)
+	assert.Nil(t, r)
+
+	mockReader := NewMockReader(ctrl)
+	newMMapStoreReaderFunc = func(path, fileName string) (reader Reader, err error) {
+		locatedPath = path
+		return mockReader, nil
+	}
+	r, err = cache.GetReader("f", "100000.sst")
+	assert.NoError(t, err)
+	assert.Equal(t, mockReader, r)
+	assert.Equal(t, filepath.Join("/cache", "f", "100000.sst"), locatedPath)
+}
+
 func TestStoreCache_Cleanup(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
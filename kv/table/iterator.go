@@ -67,6 +67,7 @@ func (m *mergedIterator) initQueue() {
 				key:   it.Key(),
 				value: it.Value(),
 				index: i,
+				order: i,
 			})
 			i++
 		}
@@ -117,7 +118,8 @@ type item struct {
 	key   uint32
 	value []byte
 
-	index int
+	index int // index of item in priority queue
+	order int // order of iterator, items with same key are popped by iterator order
 }
 
 // priorityQueue implements heap.Interface and holds Items.
@@ -126,8 +128,11 @@ type priorityQueue []*item
 // Len returns the number of elements in priority queue
 func (pq priorityQueue) Len() int { return len(pq) }
 
-// Less compares key of item
+// Less compares key of item, then order of iterator if key is same
 func (pq priorityQueue) Less(i, j int) bool {
+	if pq[i].key == pq[j].key {
+		return pq[i].order < pq[j].order
+	}
 	return pq[i].key < pq[j].key
 }
 
@@ -153,6 +153,26 @@ func TestMergedIterator_complex(t *testing.T) {
 	assert.Equal(t, len(keys), i)
 }
 
+func TestMergedIterator_sameKeyOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var its []Iterator
+	for _, v := range []string{"a", "b", "c", "d", "e"} {
+		its = append(its, generateIterator(ctrl, map[uint32][]byte{
+			1:  []byte("1" + v),
+			10: []byte("10" + v),
+		}))
+	}
+	var values []string
+	mergedIt := NewMergedIterator(its)
+	for mergedIt.HasNext() {
+		values = append(values, string(mergedIt.Value()))
+	}
+	// values of same key are in iterator order
+	assert.Equal(t, []string{"1a", "1b", "1c", "1d", "1e", "10a", "10b", "10c", "10d", "10e"}, values)
+}
+
 func generateIterator(ctrl *gomock.Controller, values map[uint32][]byte) *MockIterator {
 	it1 := NewMockIterator(ctrl)
 	var keys []uint32
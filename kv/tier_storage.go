@@ -0,0 +1,341 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/objstore"
+)
+
+//go:generate mockgen -source ./tier_storage.go -destination=./tier_storage_mock.go -package kv
+
+var (
+	globalTierStorage TierStorage
+	tierStorageLock   sync.RWMutex
+)
+
+// SetTierStorage sets the storage of tiered families, nil disables tiering.
+func SetTierStorage(storage TierStorage) {
+	tierStorageLock.Lock()
+	defer tierStorageLock.Unlock()
+
+	globalTierStorage = storage
+}
+
+// GetTierStorage returns the storage of tiered families, returns nil if tiering is disabled.
+func GetTierStorage() TierStorage {
+	tierStorageLock.RLock()
+	defer tierStorageLock.RUnlock()
+
+	return globalTierStorage
+}
+
+// Throttle limits the rate of moving family files, invoked with the bytes moved, stops moving if it returns err.
+type Throttle func(ctx context.Context, bytes int) error
+
+// TierStorage represents the storage of tiered families, which keeps the family files in object storage,
+// the files are read through local copies, which are cached by LRU with limited size.
+type TierStorage interface {
+	// NodeScope returns the scope of object keys, which is unique for each node sharing the object storage.
+	NodeScope() string
+	// Upload uploads the local file into object storage.
+	Upload(ctx context.Context, key, path string, throttle Throttle) error
+	// Fetch returns the path of local copy of object, downloads the object if not cached.
+	Fetch(ctx context.Context, key string) (string, error)
+	// Download downloads the object into given path(not cached), used by un-tiering.
+	Download(ctx context.Context, key, path string) error
+	// Delete deletes the object and its local copy.
+	Delete(ctx context.Context, key string) error
+}
+
+// localCopy represents the local copy of object in cache.
+type localCopy struct {
+	key  string
+	path string
+	size int64
+}
+
+// tierFetch represents an in-flight downloading of object.
+type tierFetch struct {
+	done chan struct{}
+	path string
+	err  error
+}
+
+// tierStorage implements TierStorage interface.
+type tierStorage struct {
+	store     objstore.ObjectStore
+	nodeScope string
+	dir       string
+	capacity  int64
+
+	size     int64
+	copies   map[string]*list.Element // key => local copy
+	lru      *list.List
+	fetching map[string]*tierFetch // deduplicates concurrent downloading of same object
+	mutex    sync.Mutex
+}
+
+// NewTierStorage creates the storage of tiered families, object keys are scoped by nodeScope(e.g. node id),
+// local copies are cached under dir with capacity bytes,
+// the local copies left by last running are loaded into cache(least recently modified first evicted).
+func NewTierStorage(store objstore.ObjectStore, nodeScope, dir string, capacity int64) (TierStorage, error) {
+	if err := mkDirFunc(dir); err != nil {
+		return nil, err
+	}
+	s := &tierStorage{
+		store:     store,
+		nodeScope: nodeScope,
+		dir:       dir,
+		capacity:  capacity,
+		copies:    make(map[string]*list.Element),
+		lru:       list.New(),
+		fetching:  make(map[string]*tierFetch),
+	}
+	if err := s.load(); err != nil {
+		return nil, fmt.Errorf("load local copies of tiered files error:%w", err)
+	}
+	return s, nil
+}
+
+// NodeScope returns the scope of object keys, which is unique for each node sharing the object storage.
+func (s *tierStorage) NodeScope() string {
+	return s.nodeScope
+}
+
+// load loads the local copies under cache dir, removes the temp files of interrupted downloading.
+func (s *tierStorage) load() error {
+	type fileInfo struct {
+		path    string
+		size    int64
+		modTime int64
+	}
+	var files []fileInfo
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(path, ".tmp") {
+			return removeFunc(path)
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, fileInfo{path: path, size: info.Size(), modTime: info.ModTime().UnixNano()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime < files[j].modTime
+	})
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, f := range files {
+		rel, err := filepath.Rel(s.dir, f.path)
+		if err != nil {
+			return err
+		}
+		s.add(&localCopy{key: filepath.ToSlash(rel), path: f.path, size: f.size})
+	}
+	return nil
+}
+
+// Upload uploads the local file into object storage.
+func (s *tierStorage) Upload(ctx context.Context, key, path string, throttle Throttle) (err error) {
+	defer func() {
+		if err != nil {
+			metrics.TierStatistics.UploadFailures.Incr()
+		}
+	}()
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := s.store.Put(ctx, key, &throttledReader{ctx: ctx, r: f, throttle: throttle}, info.Size()); err != nil {
+		return err
+	}
+	metrics.TierStatistics.UploadBytes.Add(float64(info.Size()))
+	return nil
+}
+
+// Fetch returns the path of local copy of object, downloads the object if not cached,
+// concurrent fetching of same object waits the same downloading.
+func (s *tierStorage) Fetch(ctx context.Context, key string) (string, error) {
+	s.mutex.Lock()
+	if elem, ok := s.copies[key]; ok {
+		s.lru.MoveToFront(elem)
+		s.mutex.Unlock()
+		metrics.TierStatistics.CacheHits.Incr()
+		return elem.Value.(*localCopy).path, nil
+	}
+	if f, ok := s.fetching[key]; ok {
+		s.mutex.Unlock()
+		<-f.done
+		return f.path, f.err
+	}
+	f := &tierFetch{done: make(chan struct{})}
+	s.fetching[key] = f
+	s.mutex.Unlock()
+
+	metrics.TierStatistics.CacheMisses.Incr()
+	path := filepath.Join(s.dir, filepath.FromSlash(key))
+	size, err := s.download(ctx, key, path, false)
+
+	s.mutex.Lock()
+	delete(s.fetching, key)
+	if err == nil {
+		f.path = path
+		s.add(&localCopy{key: key, path: path, size: size})
+	} else {
+		f.err = err
+	}
+	s.mutex.Unlock()
+	close(f.done)
+	return f.path, f.err
+}
+
+// Download downloads the object into given path(not cached), used by un-tiering.
+func (s *tierStorage) Download(ctx context.Context, key, path string) error {
+	_, err := s.download(ctx, key, path, true)
+	return err
+}
+
+// Delete deletes the object and its local copy.
+func (s *tierStorage) Delete(ctx context.Context, key string) error {
+	s.mutex.Lock()
+	if elem, ok := s.copies[key]; ok {
+		s.remove(elem)
+	}
+	s.mutex.Unlock()
+	return s.store.Delete(ctx, key)
+}
+
+// add adds the local copy into cache, then removes the least recently used copies if cache is full,
+// the newest copy is kept even if it's larger than capacity.
+// NOTICE: must hold lock.
+func (s *tierStorage) add(c *localCopy) {
+	s.copies[c.key] = s.lru.PushFront(c)
+	s.size += c.size
+	for s.size > s.capacity && s.lru.Len() > 1 {
+		s.remove(s.lru.Back())
+		metrics.TierStatistics.CacheEvicts.Incr()
+	}
+	metrics.TierStatistics.CacheBytes.Update(float64(s.size))
+}
+
+// remove removes the local copy from cache, the readers opened already keep reading the mapped file.
+// NOTICE: must hold lock.
+func (s *tierStorage) remove(elem *list.Element) {
+	c := elem.Value.(*localCopy)
+	s.lru.Remove(elem)
+	delete(s.copies, c.key)
+	s.size -= c.size
+	metrics.TierStatistics.CacheBytes.Update(float64(s.size))
+	if err := removeFunc(c.path); err != nil && !os.IsNotExist(err) {
+		kvLogger.Warn("remove local copy of tiered file failure",
+			logger.String("path", c.path), logger.Error(err))
+	}
+}
+
+// download downloads the object into temp file then renames it, so that partial file is never visible.
+func (s *tierStorage) download(ctx context.Context, key, path string, sync bool) (size int64, err error) {
+	defer func() {
+		if err != nil {
+			metrics.TierStatistics.DownloadFailures.Incr()
+		}
+	}()
+	if err = mkDirFunc(filepath.Dir(path)); err != nil {
+		return 0, err
+	}
+	r, err := s.store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = fileutil.RemoveFile(tmp)
+		}
+	}()
+	if size, err = io.Copy(f, r); err != nil {
+		return 0, fmt.Errorf("download object[%s] error:%w", key, err)
+	}
+	if sync {
+		if err = f.Sync(); err != nil {
+			return 0, err
+		}
+	}
+	if err = f.Close(); err != nil {
+		return 0, err
+	}
+	if err = renameFunc(tmp, path); err != nil {
+		return 0, err
+	}
+	metrics.TierStatistics.DownloadBytes.Add(float64(size))
+	return size, nil
+}
+
+// throttledReader invokes throttle with the bytes read.
+type throttledReader struct {
+	ctx      context.Context
+	r        io.Reader
+	throttle Throttle
+}
+
+// Read reads data from underlying reader, then waits for throttling.
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 && r.throttle != nil {
+		if err0 := r.throttle(r.ctx, n); err0 != nil {
+			return n, err0
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,112 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package kv
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/objstore"
+)
+
+func TestTierStorage_Fetch(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := objstore.NewArchiveStore(filepath.Join(dir, "archive"))
+	assert.NoError(t, err)
+	for _, key := range []string{"a/1", "a/2", "a/3"} {
+		assert.NoError(t, archive.Put(context.TODO(), key, bytes.NewReader([]byte("0123456789")), 10))
+	}
+	cacheDir := filepath.Join(dir, "cache")
+	storage, err := NewTierStorage(archive, "1", cacheDir, 20)
+	assert.NoError(t, err)
+
+	// concurrent fetching shares one downloading
+	var wait sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			path, err := storage.Fetch(context.TODO(), "a/1")
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join(cacheDir, "a", "1"), path)
+		}()
+	}
+	wait.Wait()
+	_, err = storage.Fetch(context.TODO(), "a/2")
+	assert.NoError(t, err)
+	// hit cache, a/1 becomes the most recently used
+	_, err = storage.Fetch(context.TODO(), "a/1")
+	assert.NoError(t, err)
+	// cache is full, evicts a/2
+	_, err = storage.Fetch(context.TODO(), "a/3")
+	assert.NoError(t, err)
+	assert.True(t, fileutil.Exist(filepath.Join(cacheDir, "a", "1")))
+	assert.False(t, fileutil.Exist(filepath.Join(cacheDir, "a", "2")))
+	assert.True(t, fileutil.Exist(filepath.Join(cacheDir, "a", "3")))
+	assert.Equal(t, int64(20), storage.(*tierStorage).size)
+	// object not found
+	_, err = storage.Fetch(context.TODO(), "a/4")
+	assert.ErrorIs(t, err, objstore.ErrObjectNotFound)
+
+	// delete object and local copy
+	assert.NoError(t, storage.Delete(context.TODO(), "a/3"))
+	assert.False(t, fileutil.Exist(filepath.Join(cacheDir, "a", "3")))
+	assert.Equal(t, int64(10), storage.(*tierStorage).size)
+
+	// load local copies left by last running, temp file is removed
+	assert.NoError(t, os.WriteFile(filepath.Join(cacheDir, "a", "2.tmp"), []byte("01234"), 0644))
+	storage, err = NewTierStorage(archive, "1", cacheDir, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(10), storage.(*tierStorage).size)
+	assert.False(t, fileutil.Exist(filepath.Join(cacheDir, "a", "2.tmp")))
+	path, err := storage.Fetch(context.TODO(), "a/1")
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(cacheDir, "a", "1"), path)
+}
+
+func TestTierStorage_UploadDownload(t *testing.T) {
+	dir := t.TempDir()
+	archive, err := objstore.NewArchiveStore(filepath.Join(dir, "archive"))
+	assert.NoError(t, err)
+	storage, err := NewTierStorage(archive, "1", filepath.Join(dir, "cache"), 20)
+	assert.NoError(t, err)
+
+	file := filepath.Join(dir, "file")
+	assert.Error(t, storage.Upload(context.TODO(), "a/1", file, nil))
+	assert.NoError(t, os.WriteFile(file, []byte("0123456789"), 0644))
+	throttled := 0
+	assert.NoError(t, storage.Upload(context.TODO(), "a/1", file, func(_ context.Context, bytes int) error {
+		throttled += bytes
+		return nil
+	}))
+	assert.Equal(t, 10, throttled)
+
+	target := filepath.Join(dir, "target", "file")
+	assert.NoError(t, storage.Download(context.TODO(), "a/1", target))
+	data, err := os.ReadFile(target)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("0123456789"), data)
+	assert.Error(t, storage.Download(context.TODO(), "a/2", target))
+}
@@ -26,6 +26,7 @@ type Compaction struct {
 	inputs        [][]*FileMeta
 	levelInputs   []*FileMeta
 	levelUpInputs []*FileMeta
+	full          bool // rewrites all files even if only one input file
 
 	editLog EditLog
 }
@@ -41,11 +42,19 @@ func NewCompaction(familyID FamilyID, level int, levelInputs, levelUpInputs []*F
 	}
 }
 
+// NewFullCompaction creates a compaction job context which rewrites all files of level0/level1,
+// the input files are always merged(not moved), so that merger can drop the expired data.
+func NewFullCompaction(familyID FamilyID, levelInputs, levelUpInputs []*FileMeta) *Compaction {
+	compaction := NewCompaction(familyID, 0, levelInputs, levelUpInputs)
+	compaction.full = true
+	return compaction
+}
+
 // IsTrivialMove returns a trivial compaction that can be implemented by just
 // moving a single input file to the next level (no merging or splitting).
 // returns true: can just move file to the next level.
 func (c *Compaction) IsTrivialMove() bool {
-	return len(c.levelInputs) == 1 && len(c.levelUpInputs) == 0
+	return !c.full && len(c.levelInputs) == 1 && len(c.levelUpInputs) == 0
 }
 
 // GetLevelFiles returns low level files.
@@ -47,6 +47,7 @@ func TestCompaction(t *testing.T) {
 		nil,
 	)
 	assert.True(t, compaction.IsTrivialMove())
+	assert.False(t, NewFullCompaction(1, []*FileMeta{&f2}, nil).IsTrivialMove())
 	compaction.DeleteFile(0, 2)
 	assert.False(t, compaction.GetEditLog().IsEmpty())
 }
@@ -27,6 +27,8 @@ import (
 
 const sstSuffix = "sst"
 
+const stubSuffix = "stub"
+
 const TmpSuffix = "tmp"
 
 const Lock = "LOCK"
@@ -45,6 +47,7 @@ const (
 	TypeTable
 	TypeTemp
 	TypeInfo
+	TypeStub
 )
 
 // FileDesc represents file type and file number
@@ -63,6 +66,11 @@ func Table(fileNumber table.FileNumber) string {
 	return fmt.Sprintf("%06d.%s", fileNumber, sstSuffix)
 }
 
+// Stub returns the file name of stub which references the sst file moved to object storage.
+func Stub(fileNumber table.FileNumber) string {
+	return fmt.Sprintf("%06d.%s", fileNumber, stubSuffix)
+}
+
 // ManifestFileName returns manifest file name
 func ManifestFileName(fileNumber table.FileNumber) string {
 	return fmt.Sprintf("%s%06d", ManifestPrefix, fileNumber)
@@ -81,6 +89,16 @@ func ParseFileName(fileName string) *FileDesc {
 			FileNumber: table.FileNumber(n),
 		}
 	}
+	if strings.HasSuffix(fileName, ".stub") {
+		n, err := strconv.ParseInt(removeSuffix(fileName, ".stub"), 10, 64)
+		if err != nil {
+			return nil
+		}
+		return &FileDesc{
+			FileType:   TypeStub,
+			FileNumber: table.FileNumber(n),
+		}
+	}
 	return nil
 }
 
@@ -26,6 +26,7 @@ import (
 func Test_FileName(t *testing.T) {
 	assert.Equal(t, "000001.sst", Table(1))
 	assert.Equal(t, "1234567891011.sst", Table(1234567891011))
+	assert.Equal(t, "000001.stub", Stub(1))
 
 	assert.Equal(t, "MANIFEST-000012", ManifestFileName(12))
 	assert.Equal(t, "MANIFEST-123456789", ManifestFileName(123456789))
@@ -38,4 +39,8 @@ func Test_ParseFileName(t *testing.T) {
 	fileDesc := ParseFileName("000001.sst")
 	assert.Equal(t, TypeTable, fileDesc.FileType)
 	assert.Equal(t, int64(1), fileDesc.FileNumber.Int64())
+	assert.Nil(t, ParseFileName("aaa.stub"))
+	fileDesc = ParseFileName("000002.stub")
+	assert.Equal(t, TypeStub, fileDesc.FileType)
+	assert.Equal(t, int64(2), fileDesc.FileNumber.Int64())
 }
@@ -42,6 +42,7 @@ const (
 	NewReferenceFileLog
 	DeleteReferenceFileLog
 	SequenceNumberLog
+	MemDBCreatedTimeLog
 )
 
 func init() {
@@ -77,6 +78,10 @@ func init() {
 	RegisterLogType(SequenceNumberLog, func() Log {
 		return &sequence{}
 	})
+	// register created time of memory database
+	RegisterLogType(MemDBCreatedTimeLog, func() Log {
+		return &memDBCreatedTime{}
+	})
 }
 
 // NewLogFunc creates specific edit log instance
@@ -452,3 +457,40 @@ func (s *sequence) apply(version Version) {
 func (s *sequence) String() string {
 	return fmt.Sprintf("sequence:{leader:%d,seq:%d}", s.leader, s.seq)
 }
+
+// memDBCreatedTime represents the created time of memory database which is not flushed,
+// restored after restart, so that the ttl of memory database continues rather than resets.
+type memDBCreatedTime struct {
+	timestamp int64
+}
+
+// CreateMemDBCreatedTime creates the created time of memory database, 0 means no memory database.
+func CreateMemDBCreatedTime(timestamp int64) Log {
+	return &memDBCreatedTime{
+		timestamp: timestamp,
+	}
+}
+
+// Encode writes created time of memory database into binary.
+func (m *memDBCreatedTime) Encode() ([]byte, error) {
+	writer := stream.NewBufferWriter(nil)
+	writer.PutVarint64(m.timestamp)
+	return writer.Bytes()
+}
+
+// Decode reads created time of memory database from binary.
+func (m *memDBCreatedTime) Decode(v []byte) error {
+	reader := stream.NewReader(v)
+	m.timestamp = reader.ReadVarint64()
+	return reader.Error()
+}
+
+// apply applies created time of memory database edit log to version.
+func (m *memDBCreatedTime) apply(version Version) {
+	version.SetMemDBCreatedTime(m.timestamp)
+}
+
+// String returns string value of created time of memory database log.
+func (m *memDBCreatedTime) String() string {
+	return fmt.Sprintf("memDBCreatedTime:{timestamp:%d}", m.timestamp)
+}
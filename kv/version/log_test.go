@@ -191,3 +191,21 @@ func TestSequence(t *testing.T) {
 	version.EXPECT().Sequence(int32(1), int64(10))
 	seq2.apply(version)
 }
+
+func TestMemDBCreatedTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	createdTime := CreateMemDBCreatedTime(1000)
+	bytes, err := createdTime.Encode()
+	assert.NoError(t, err)
+
+	fmt.Println(createdTime)
+	createdTime2 := &memDBCreatedTime{}
+
+	err = createdTime2.Decode(bytes)
+	assert.NoError(t, err)
+	assert.Equal(t, createdTime, createdTime2)
+	version := NewMockVersion(ctrl)
+	version.EXPECT().SetMemDBCreatedTime(int64(1000))
+	createdTime2.apply(version)
+}
@@ -21,6 +21,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/pkg/fault"
 )
 
 //go:generate mockgen -source ./snapshot.go -destination=./snapshot_mock.go -package version
@@ -65,6 +66,9 @@ func (s *snapshot) GetCurrent() Version {
 
 // FindReaders finds all files include key
 func (s *snapshot) FindReaders(key uint32) ([]table.Reader, error) {
+	if err := fault.Inject(fault.SnapshotFindReaders); err != nil {
+		return nil, err
+	}
 	// find files related given key
 	// current version is readonly, if modify version will clone a new one, so needn't lock here.
 	files := s.version.FindFiles(key)
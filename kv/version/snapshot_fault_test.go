@@ -0,0 +1,50 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build faultinject
+
+package version
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/pkg/fault"
+)
+
+func TestSnapshot_FindReaders_Fault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	v := NewMockVersion(ctrl)
+	v.EXPECT().Retain().AnyTimes()
+	v.EXPECT().Release()
+	cache := table.NewMockCache(ctrl)
+	cache.EXPECT().ReleaseReaders(gomock.Any())
+	snapshot := newSnapshot("test", v, cache)
+	defer snapshot.Close()
+
+	fault.Enable(fault.SnapshotFindReaders, fault.Error(fmt.Errorf("err")))
+	defer fault.Disable(fault.SnapshotFindReaders)
+	readers, err := snapshot.FindReaders(uint32(80))
+	assert.Error(t, err)
+	assert.Nil(t, readers)
+}
@@ -61,6 +61,15 @@ type Version interface {
 	// PickL0Compaction picks level0 compaction context,
 	// if it hasn't congruent compaction return nil.
 	PickL0Compaction(compactThreshold int) *Compaction
+	// PickLeveledCompaction picks level0 files with all up level files overlapping the key range of level0,
+	// if it hasn't congruent compaction return nil.
+	PickLeveledCompaction(compactThreshold int) *Compaction
+	// PickSizeTieredCompaction picks level0 files with the up level files whose size isn't larger than
+	// total size of level0 files multiplied by size ratio, if it hasn't congruent compaction return nil.
+	PickSizeTieredCompaction(compactThreshold int, sizeRatio float64) *Compaction
+	// PickFullCompaction picks all files of level0/level1 for rewriting,
+	// if it hasn't any file return nil.
+	PickFullCompaction() *Compaction
 
 	// AddRollupFile adds need rollup file and target interval
 	AddRollupFile(fileNumber table.FileNumber, interval timeutil.Interval)
@@ -78,6 +87,11 @@ type Version interface {
 	Sequence(leader int32, seq int64)
 	// GetSequences returns all sequence number.
 	GetSequences() map[int32]int64
+	// SetMemDBCreatedTime sets the created time of memory database which is not flushed.
+	SetMemDBCreatedTime(timestamp int64)
+	// GetMemDBCreatedTime returns the created time of memory database which is not flushed,
+	// returns 0 if all data is flushed.
+	GetMemDBCreatedTime() int64
 }
 
 // version is snapshot for current storage metadata includes levels/sst files
@@ -88,6 +102,8 @@ type version struct {
 	ref         atomic.Int32 // current version ref count for using
 	rollup      *rollup
 	sequences   map[int32]atomic.Int64
+	// created time of memory database not flushed(unix nano)
+	memDBCreatedTime int64
 
 	levels []*level // each level sst files exclude level0
 }
@@ -195,6 +211,66 @@ func (v *version) PickL0Compaction(compactThreshold int) *Compaction {
 	return NewCompaction(v.fv.GetID(), 0, levelInputs, levelUpInputs)
 }
 
+// PickLeveledCompaction picks level0 files with all up level files overlapping the key range of level0,
+// if it hasn't congruent compaction return nil.
+func (v *version) PickLeveledCompaction(compactThreshold int) *Compaction {
+	if v.NumberOfFilesInLevel(0) < compactThreshold {
+		return nil
+	}
+	levelInputs := v.GetFiles(0)
+	if len(levelInputs) == 0 {
+		return nil
+	}
+	minKey, maxKey := levelInputs[0].GetMinKey(), levelInputs[0].GetMaxKey()
+	for _, input := range levelInputs[1:] {
+		if input.GetMinKey() < minKey {
+			minKey = input.GetMinKey()
+		}
+		if input.GetMaxKey() > maxKey {
+			maxKey = input.GetMaxKey()
+		}
+	}
+	// up level files are non-overlapping after merged with the whole key range of level0
+	levelUpInputs := v.getOverlappingInputs(1, minKey, maxKey)
+	return NewCompaction(v.fv.GetID(), 0, levelInputs, levelUpInputs)
+}
+
+// PickSizeTieredCompaction picks level0 files with the up level files whose size isn't larger than
+// total size of level0 files multiplied by size ratio, if it hasn't congruent compaction return nil.
+func (v *version) PickSizeTieredCompaction(compactThreshold int, sizeRatio float64) *Compaction {
+	if v.NumberOfFilesInLevel(0) < compactThreshold {
+		return nil
+	}
+	levelInputs := v.GetFiles(0)
+	if len(levelInputs) == 0 {
+		return nil
+	}
+	var totalSize uint64
+	for _, input := range levelInputs {
+		totalSize += uint64(input.GetFileSize())
+	}
+	// large files in up level are not rewritten until enough small files accumulated
+	maxSize := float64(totalSize) * sizeRatio
+	var levelUpInputs []*FileMeta
+	for _, upInput := range v.GetFiles(1) {
+		if float64(upInput.GetFileSize()) <= maxSize {
+			levelUpInputs = append(levelUpInputs, upInput)
+		}
+	}
+	return NewCompaction(v.fv.GetID(), 0, levelInputs, levelUpInputs)
+}
+
+// PickFullCompaction picks all files of level0/level1 for rewriting,
+// if it hasn't any file return nil.
+func (v *version) PickFullCompaction() *Compaction {
+	levelInputs := v.GetFiles(0)
+	levelUpInputs := v.GetFiles(1)
+	if len(levelInputs) == 0 && len(levelUpInputs) == 0 {
+		return nil
+	}
+	return NewFullCompaction(v.fv.GetID(), levelInputs, levelUpInputs)
+}
+
 // FindFiles finds all files include key from each level
 func (v *version) FindFiles(key uint32) []*FileMeta {
 	var files []*FileMeta
@@ -230,6 +306,7 @@ func (v *version) Clone() Version {
 	for k, v := range v.sequences {
 		nv.sequences[k] = v
 	}
+	nv.memDBCreatedTime = v.memDBCreatedTime
 	for level, value := range v.levels {
 		for _, file := range value.files {
 			newVersion.AddFile(level, file)
@@ -320,6 +397,17 @@ func (v *version) GetSequences() map[int32]int64 {
 	return rs
 }
 
+// SetMemDBCreatedTime sets the created time of memory database which is not flushed.
+func (v *version) SetMemDBCreatedTime(timestamp int64) {
+	v.memDBCreatedTime = timestamp
+}
+
+// GetMemDBCreatedTime returns the created time of memory database which is not flushed,
+// returns 0 if all data is flushed.
+func (v *version) GetMemDBCreatedTime() int64 {
+	return v.memDBCreatedTime
+}
+
 // getOverlappingInputs gets overlapping input based on level and key range,
 // returns the over lapping th given level for key range.
 func (v *version) getOverlappingInputs(level int, minKey, maxKey uint32) []*FileMeta {
@@ -400,6 +400,10 @@ func (vs *storeVersionSet) createFamilySnapshot(familyID FamilyID, familyVersion
 		// leader -> replica sequence
 		editLog.Add(CreateSequence(leader, seq))
 	}
+	// write log if family has memory database not flushed.
+	if createdTime := current.GetMemDBCreatedTime(); createdTime > 0 {
+		editLog.Add(CreateMemDBCreatedTime(createdTime))
+	}
 
 	// write log if family has reference files
 	refFiles := current.GetReferenceFiles()
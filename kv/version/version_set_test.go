@@ -209,6 +209,7 @@ func TestCommitFamilyEditLog(t *testing.T) {
 	editLog.Add(nFile)
 	editLog.Add(NewDeleteFile(1, 123))
 	editLog.Add(CreateSequence(1, 10))
+	editLog.Add(CreateMemDBCreatedTime(1000))
 	editLog.Add(CreateNewRollupFile(1, 10000))
 	editLog.Add(CreateNewReferenceFile(1, 10))
 	err = vs.CommitFamilyEditLog("f", editLog)
@@ -230,6 +231,7 @@ func TestCommitFamilyEditLog(t *testing.T) {
 		assert.Equal(t, nf.file, current.GetAllFiles()[0], "cannot recover family version data")
 		assert.Equal(t, int64(3+i), vs1.nextFileNumber.Load(), "recover file number error")
 		assert.Equal(t, map[int32]int64{1: 10}, current.GetSequences())
+		assert.Equal(t, int64(1000), current.GetMemDBCreatedTime())
 		assert.Equal(t, map[FamilyID][]table.FileNumber{1: {10}}, current.GetReferenceFiles())
 		assert.Equal(t, map[table.FileNumber][]timeutil.Interval{1: {10000}}, current.GetRollupFiles())
 
@@ -191,6 +191,88 @@ func TestVersion_PickL0Compaction(t *testing.T) {
 	assert.Equal(t, 3, len(compaction.levelUpInputs))
 }
 
+func TestVersion_PickLeveledCompaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fv := NewMockFamilyVersion(ctrl)
+	vs := NewMockStoreVersionSet(ctrl)
+	fv.EXPECT().GetVersionSet().Return(vs).AnyTimes()
+	fv.EXPECT().GetID().Return(FamilyID(1)).AnyTimes()
+	vs.EXPECT().numberOfLevels().Return(2).AnyTimes()
+	v := newVersion(1, fv)
+	assert.Nil(t, v.PickLeveledCompaction(0))
+
+	f1 := FileMeta{fileNumber: 1, minKey: 10, maxKey: 100}
+	f2 := FileMeta{fileNumber: 2, minKey: 1000, maxKey: 1001}
+	v.AddFiles(0, []*FileMeta{&f1, &f2})
+	f3 := FileMeta{fileNumber: 3, minKey: 1, maxKey: 5}
+	f4 := FileMeta{fileNumber: 4, minKey: 100, maxKey: 200}
+	f5 := FileMeta{fileNumber: 5, minKey: 400, maxKey: 500}
+	v.AddFiles(1, []*FileMeta{&f3, &f4, &f5})
+
+	assert.Nil(t, v.PickLeveledCompaction(5))
+	compaction := v.PickLeveledCompaction(1)
+	assert.NotNil(t, compaction)
+	assert.Len(t, compaction.levelInputs, 2)
+	// file 5 is in the key range of level0 files, but not overlapping with any level0 file
+	assert.Len(t, compaction.levelUpInputs, 2)
+}
+
+func TestVersion_PickSizeTieredCompaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fv := NewMockFamilyVersion(ctrl)
+	vs := NewMockStoreVersionSet(ctrl)
+	fv.EXPECT().GetVersionSet().Return(vs).AnyTimes()
+	fv.EXPECT().GetID().Return(FamilyID(1)).AnyTimes()
+	vs.EXPECT().numberOfLevels().Return(2).AnyTimes()
+	v := newVersion(1, fv)
+	assert.Nil(t, v.PickSizeTieredCompaction(0, 2))
+
+	f1 := FileMeta{fileNumber: 1, minKey: 10, maxKey: 100, fileSize: 10}
+	f2 := FileMeta{fileNumber: 2, minKey: 1000, maxKey: 1001, fileSize: 10}
+	v.AddFiles(0, []*FileMeta{&f1, &f2})
+	f3 := FileMeta{fileNumber: 3, minKey: 1, maxKey: 5, fileSize: 30}
+	f4 := FileMeta{fileNumber: 4, minKey: 100, maxKey: 200, fileSize: 1000}
+	v.AddFiles(1, []*FileMeta{&f3, &f4})
+
+	assert.Nil(t, v.PickSizeTieredCompaction(5, 2))
+	compaction := v.PickSizeTieredCompaction(1, 2)
+	assert.NotNil(t, compaction)
+	assert.Len(t, compaction.levelInputs, 2)
+	// large file 4 is not rewritten even if it overlaps with level0
+	assert.Equal(t, []*FileMeta{&f3}, compaction.levelUpInputs)
+}
+
+func TestVersion_PickFullCompaction(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fv := NewMockFamilyVersion(ctrl)
+	vs := NewMockStoreVersionSet(ctrl)
+	fv.EXPECT().GetVersionSet().Return(vs).AnyTimes()
+	fv.EXPECT().GetID().Return(FamilyID(1)).AnyTimes()
+	vs.EXPECT().numberOfLevels().Return(2).AnyTimes()
+	v := newVersion(1, fv)
+	assert.Nil(t, v.PickFullCompaction())
+
+	f1 := FileMeta{fileNumber: 1, minKey: 10, maxKey: 100, fileSize: 10}
+	v.AddFiles(1, []*FileMeta{&f1})
+	compaction := v.PickFullCompaction()
+	assert.NotNil(t, compaction)
+	assert.Empty(t, compaction.levelInputs)
+	assert.Equal(t, []*FileMeta{&f1}, compaction.levelUpInputs)
+	assert.False(t, compaction.IsTrivialMove())
+
+	f2 := FileMeta{fileNumber: 2, minKey: 1000, maxKey: 1001, fileSize: 10}
+	v.AddFiles(0, []*FileMeta{&f2})
+	compaction = v.PickFullCompaction()
+	assert.Equal(t, []*FileMeta{&f2}, compaction.levelInputs)
+	assert.Equal(t, []*FileMeta{&f1}, compaction.levelUpInputs)
+}
+
 func TestVersion_RollupJob(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -225,6 +307,24 @@ func TestVersion_Sequence(t *testing.T) {
 	assert.Equal(t, int64(100), v.GetSequences()[1])
 }
 
+func TestVersion_MemDBCreatedTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fv := NewMockFamilyVersion(ctrl)
+	vs := NewMockStoreVersionSet(ctrl)
+	fv.EXPECT().GetVersionSet().Return(vs).AnyTimes()
+	vs.EXPECT().numberOfLevels().Return(2).AnyTimes()
+	vs.EXPECT().newVersionID().Return(int64(2))
+	v := newVersion(1, fv)
+	assert.Zero(t, v.GetMemDBCreatedTime())
+	v.SetMemDBCreatedTime(1000)
+	assert.Equal(t, int64(1000), v.GetMemDBCreatedTime())
+	assert.Equal(t, int64(1000), v.Clone().GetMemDBCreatedTime())
+	v.SetMemDBCreatedTime(0)
+	assert.Zero(t, v.GetMemDBCreatedTime())
+}
+
 func TestVersion_Clone(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
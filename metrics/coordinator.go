@@ -32,6 +32,7 @@ type StateManagerStatistics struct {
 type ShardLeaderStatistics struct {
 	LeaderElections     *linmetric.BoundCounter // shard leader elect successfully
 	LeaderElectFailures *linmetric.BoundCounter // shard leader elect failure
+	DeferredElections   *linmetric.BoundCounter // shard leader elect deferred, leader node is restarting
 }
 
 // MasterStatistics represents master statistics.
@@ -42,6 +43,12 @@ type MasterStatistics struct {
 	ReassignFailures *linmetric.BoundCounter // master reassign failure
 }
 
+// AuditStatistics represents audit log of admin operations statistics.
+type AuditStatistics struct {
+	Records        *linmetric.BoundCounter // audit entries recorded
+	DroppedRecords *linmetric.BoundCounter // audit entries dropped because of sink unavailable
+}
+
 // NewStateManagerStatistics creates a state manager statistics.
 func NewStateManagerStatistics(registry *linmetric.Registry) *StateManagerStatistics {
 	scope := registry.NewScope("lindb.coordinator.state_manager")
@@ -58,6 +65,7 @@ func NewShardLeaderStatistics() *ShardLeaderStatistics {
 	return &ShardLeaderStatistics{
 		LeaderElections:     scope.NewCounter("elections"),
 		LeaderElectFailures: scope.NewCounter("elect_failures"),
+		DeferredElections:   scope.NewCounter("deferred_elections"),
 	}
 }
 
@@ -71,3 +79,12 @@ func NewMasterStatistics() *MasterStatistics {
 		ReassignFailures: scope.NewCounter("reassign_failures"),
 	}
 }
+
+// NewAuditStatistics creates an audit log statistics.
+func NewAuditStatistics(registry *linmetric.Registry) *AuditStatistics {
+	scope := registry.NewScope("lindb.audit")
+	return &AuditStatistics{
+		Records:        scope.NewCounter("records"),
+		DroppedRecords: scope.NewCounter("dropped_records"),
+	}
+}
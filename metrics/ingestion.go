@@ -59,6 +59,55 @@ type CommonIngestionStatistics struct {
 	Duration *linmetric.DeltaHistogramVec // ingest duration(include count)
 }
 
+// DecompressStatistics represents request body decompression statistics of write api.
+type DecompressStatistics struct {
+	CompressedBytes   *linmetric.DeltaCounterVec // compressed bytes read of each encoding
+	DecompressedBytes *linmetric.DeltaCounterVec // decompressed bytes of each encoding
+	Corrupted         *linmetric.DeltaCounterVec // corrupted request body of each encoding
+	TooLarge          *linmetric.DeltaCounterVec // request body exceeds max decompressed size of each encoding
+}
+
+// NormalizeStatistics represents write path normalization statistics.
+type NormalizeStatistics struct {
+	RuleHits *linmetric.DeltaCounterVec // hits of each normalization rule
+}
+
+// IdempotencyStatistics represents idempotent write statistics.
+type IdempotencyStatistics struct {
+	DedupHits *linmetric.BoundCounter // duplicate write requests returned original result
+	Evictions *linmetric.BoundCounter // tokens evicted from window(expired or exceed max tokens)
+}
+
+// MeteringStatistics represents usage metering statistics.
+type MeteringStatistics struct {
+	Flushes       *linmetric.BoundCounter // usage flushed into monitor database
+	FlushFailures *linmetric.BoundCounter // usage flush failure, usage is kept for next flush
+}
+
+// SchemaStatistics represents metric schema enforcement statistics.
+type SchemaStatistics struct {
+	Violations       *linmetric.DeltaCounterVec // violations of each type
+	RejectedRequests *linmetric.BoundCounter    // write requests rejected in strict mode
+}
+
+// NamespaceRoutingStatistics represents namespace routing statistics of write api.
+type NamespaceRoutingStatistics struct {
+	RoutedRows       *linmetric.DeltaCounterVec // rows routed by each rule(namespace, or default for fallback)
+	RejectedRows     *linmetric.BoundCounter    // rows of unknown namespace in reject mode
+	RejectedRequests *linmetric.BoundCounter    // write requests rejected because of unknown namespace
+}
+
+// IngestLimitStatistics represents ingestion limits enforcement statistics.
+type IngestLimitStatistics struct {
+	Violations   *linmetric.DeltaCounterVec // violations of each limit
+	RejectedRows *linmetric.BoundCounter    // rows dropped in reject mode
+}
+
+// FreshnessStatistics represents data freshness statistics of database.
+type FreshnessStatistics struct {
+	IngestionLag *linmetric.BoundGauge // lag(ms) between now and the latest data of the stalest shard
+}
+
 // NewNativeIngestionStatistics creates a native ingestion statistics.
 func NewNativeIngestionStatistics() *NativeIngestionStatistics {
 	influxIngestionScope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.proto")
@@ -111,3 +160,76 @@ func NewFlatIngestionStatistics() *FlatIngestionStatistics {
 		GT10MiBCounter:  flatIngestionBlockScope.WithTagValues(">=10MiB"),
 	}
 }
+
+// NewDecompressStatistics creates a request body decompression statistics.
+func NewDecompressStatistics() *DecompressStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.decompress")
+	return &DecompressStatistics{
+		CompressedBytes:   scope.NewCounterVec("compressed_bytes", "encoding"),
+		DecompressedBytes: scope.NewCounterVec("decompressed_bytes", "encoding"),
+		Corrupted:         scope.NewCounterVec("corrupted", "encoding"),
+		TooLarge:          scope.NewCounterVec("too_large", "encoding"),
+	}
+}
+
+// NewNormalizeStatistics creates a write path normalization statistics.
+func NewNormalizeStatistics(database string) *NormalizeStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.normalize", "db", database)
+	return &NormalizeStatistics{
+		RuleHits: scope.NewCounterVec("rule_hits", "rule"),
+	}
+}
+
+// NewIdempotencyStatistics creates an idempotent write statistics.
+func NewIdempotencyStatistics(database string) *IdempotencyStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.idempotency", "db", database)
+	return &IdempotencyStatistics{
+		DedupHits: scope.NewCounter("dedup_hits"),
+		Evictions: scope.NewCounter("evictions"),
+	}
+}
+
+// NewMeteringStatistics creates a usage metering statistics.
+func NewMeteringStatistics() *MeteringStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.metering")
+	return &MeteringStatistics{
+		Flushes:       scope.NewCounter("flushes"),
+		FlushFailures: scope.NewCounter("flush_failures"),
+	}
+}
+
+// NewSchemaStatistics creates a metric schema enforcement statistics.
+func NewSchemaStatistics(database string) *SchemaStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.schema", "db", database)
+	return &SchemaStatistics{
+		Violations:       scope.NewCounterVec("violations", "type"),
+		RejectedRequests: scope.NewCounter("rejected_requests"),
+	}
+}
+
+// NewNamespaceRoutingStatistics creates a namespace routing statistics.
+func NewNamespaceRoutingStatistics() *NamespaceRoutingStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.routing")
+	return &NamespaceRoutingStatistics{
+		RoutedRows:       scope.NewCounterVec("routed_rows", "rule", "db"),
+		RejectedRows:     scope.NewCounter("rejected_rows"),
+		RejectedRequests: scope.NewCounter("rejected_requests"),
+	}
+}
+
+// NewIngestLimitStatistics creates an ingestion limits enforcement statistics.
+func NewIngestLimitStatistics(database string) *IngestLimitStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.limit", "db", database)
+	return &IngestLimitStatistics{
+		Violations:   scope.NewCounterVec("violations", "type"),
+		RejectedRows: scope.NewCounter("rejected_rows"),
+	}
+}
+
+// NewFreshnessStatistics creates a data freshness statistics.
+func NewFreshnessStatistics(database string) *FreshnessStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.ingestion.freshness", "db", database)
+	return &FreshnessStatistics{
+		IngestionLag: scope.NewGauge("ingestion_lag"),
+	}
+}
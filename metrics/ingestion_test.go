@@ -28,4 +28,11 @@ func TestIngestionStatistics_New(t *testing.T) {
 	assert.NotNil(t, NewCommonIngestionStatistics())
 	assert.NotNil(t, NewInfluxIngestionStatistics())
 	assert.NotNil(t, NewNativeIngestionStatistics())
+	assert.NotNil(t, NewDecompressStatistics())
+	assert.NotNil(t, NewNormalizeStatistics("db"))
+	assert.NotNil(t, NewIdempotencyStatistics("db"))
+	assert.NotNil(t, NewMeteringStatistics())
+	assert.NotNil(t, NewSchemaStatistics("db"))
+	assert.NotNil(t, NewIngestLimitStatistics("db"))
+	assert.NotNil(t, NewFreshnessStatistics("db"))
 }
@@ -81,10 +81,14 @@ var (
 		Compacting *linmetric.GaugeVec          // number of compacting jobs
 		Failure    *linmetric.DeltaCounterVec   // compact failure
 		Duration   *linmetric.DeltaHistogramVec // compact duration(include count)
+		Policy     *linmetric.DeltaCounterVec   // compact job count of each compaction policy
+		WriteBytes *linmetric.DeltaCounterVec   // bytes of compaction output files of each compaction policy
 	}{
 		Compacting: flushScope.NewGaugeVec("compacting", "type"),
 		Failure:    flushScope.NewCounterVec("failure", "type"),
 		Duration:   compactScope.Scope("duration").NewHistogramVec("type"),
+		Policy:     compactScope.NewCounterVec("policy_compactions", "policy"),
+		WriteBytes: compactScope.NewCounterVec("policy_write_bytes", "policy"),
 	}
 
 	// flush job
@@ -99,4 +103,48 @@ var (
 		Failure:  flushScope.NewCounter("failure"),
 		Duration: flushScope.Scope("duration").NewHistogram(),
 	}
+
+	// file reconciliation when opening store
+	reconcileScope = linmetric.StorageRegistry.NewScope("lindb.kv.reconcile")
+	// ReconcileStatistics represents the statistics of reconciling family files with version when opening store.
+	ReconcileStatistics = struct {
+		OrphanFiles  *linmetric.DeltaCounterVec // orphan files not referenced by any version(deleted/quarantined)
+		MissingFiles *linmetric.BoundCounter    // files referenced by version but missing
+	}{
+		OrphanFiles:  reconcileScope.NewCounterVec("orphan_files", "action"),
+		MissingFiles: reconcileScope.NewCounter("missing_files"),
+	}
+
+	// cold storage tiering
+	tierScope = linmetric.StorageRegistry.NewScope("lindb.kv.tier")
+	// TierStatistics represents the statistics of moving family files to object storage and reading them back.
+	TierStatistics = struct {
+		TieredFiles      *linmetric.BoundCounter   // files uploaded then replaced by stubs
+		UntieredFiles    *linmetric.BoundCounter   // files downloaded back when un-tiering
+		UploadBytes      *linmetric.BoundCounter   // bytes uploaded into object storage
+		UploadFailures   *linmetric.BoundCounter   // upload file failure
+		CacheHits        *linmetric.BoundCounter   // read tiered file hit local copy
+		CacheMisses      *linmetric.BoundCounter   // read tiered file need downloading
+		CacheEvicts      *linmetric.BoundCounter   // local copies removed from cache
+		CacheBytes       *linmetric.BoundGauge     // bytes of local copies in cache
+		DownloadBytes    *linmetric.BoundCounter   // bytes downloaded from object storage
+		DownloadFailures *linmetric.BoundCounter   // download file failure
+		Reads            *linmetric.BoundCounter   // reads of tiered family
+		ReadBytes        *linmetric.BoundCounter   // bytes read from tiered family
+		ReadDuration     *linmetric.BoundHistogram // duration of locating files of tiered family(include downloading)
+	}{
+		TieredFiles:      tierScope.NewCounter("tiered_files"),
+		UntieredFiles:    tierScope.NewCounter("untiered_files"),
+		UploadBytes:      tierScope.NewCounter("upload_bytes"),
+		UploadFailures:   tierScope.NewCounter("upload_failures"),
+		CacheHits:        tierScope.NewCounter("cache_hits"),
+		CacheMisses:      tierScope.NewCounter("cache_misses"),
+		CacheEvicts:      tierScope.NewCounter("cache_evicts"),
+		CacheBytes:       tierScope.NewGauge("cache_bytes"),
+		DownloadBytes:    tierScope.NewCounter("download_bytes"),
+		DownloadFailures: tierScope.NewCounter("download_failures"),
+		Reads:            tierScope.NewCounter("reads"),
+		ReadBytes:        tierScope.NewCounter("read_bytes"),
+		ReadDuration:     tierScope.Scope("read_duration").NewHistogram(),
+	}
 )
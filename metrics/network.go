@@ -53,6 +53,13 @@ type GRPCServerStatistics struct {
 	Panics *linmetric.BoundCounter // panic when grpc server handle request
 }
 
+// GRPCTLSStatistics represents transport security of intra-cluster grpc statistics.
+type GRPCTLSStatistics struct {
+	HandshakeFailures *linmetric.DeltaCounterVec // tls handshake failure, tagged by side(client/server) and peer
+	Reloads           *linmetric.BoundCounter    // reload certificates successfully
+	ReloadFailures    *linmetric.BoundCounter    // reload certificates failure
+}
+
 // NewConnStatistics creates tcp connection statistics.
 func NewConnStatistics(r *linmetric.Registry, addr string) *ConnStatistics {
 	tcpScope := r.NewScope("lindb.traffic.tcp", "addr", addr)
@@ -99,6 +106,16 @@ func NewGRPCServerStatistics(registry *linmetric.Registry) *GRPCServerStatistics
 	}
 }
 
+// NewGRPCTLSStatistics creates transport security of grpc statistics.
+func NewGRPCTLSStatistics(registry *linmetric.Registry) *GRPCTLSStatistics {
+	scope := registry.NewScope("lindb.traffic.grpc_tls")
+	return &GRPCTLSStatistics{
+		HandshakeFailures: scope.NewCounterVec("handshake_failures", "side", "peer"),
+		Reloads:           scope.NewCounter("reloads"),
+		ReloadFailures:    scope.NewCounter("reload_failures"),
+	}
+}
+
 // newGPRCStreamStatistics creates grpc client/server stream statistics.
 func newGPRCStreamStatistics(registry *linmetric.Registry, name, grpcType, grpcService, grpcMethod string) *GRPCStreamStatistics {
 	scope := registry.NewScope(name)
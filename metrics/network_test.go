@@ -32,4 +32,5 @@ func TestNewNetworkStatistics(t *testing.T) {
 	assert.NotNil(t, NewGRPCUnaryServerStatistics(linmetric.BrokerRegistry))
 	assert.NotNil(t, NewGRPCStreamServerStatistics(linmetric.BrokerRegistry, "t", "s", "m"))
 	assert.NotNil(t, NewGRPCServerStatistics(linmetric.BrokerRegistry))
+	assert.NotNil(t, NewGRPCTLSStatistics(linmetric.BrokerRegistry))
 }
@@ -45,6 +45,70 @@ type StorageQueryStatistics struct {
 	MetaQuery           *linmetric.BoundCounter // metadata query success
 	MetaQueryFailures   *linmetric.BoundCounter // metadata query failure
 	OmitRequest         *linmetric.BoundCounter // omit request(task no belong to current node, wrong stream etc.)
+	CancelledQuery      *linmetric.BoundCounter // metric query cancelled by broker(loser of hedged request)
+}
+
+// QueryAdmissionStatistics represents admission control statistics of storage query.
+type QueryAdmissionStatistics struct {
+	Admitted      *linmetric.BoundCounter   // queries admitted to execute
+	Executing     *linmetric.BoundGauge     // queries executing
+	QueueDepth    *linmetric.BoundGauge     // queries waiting for admission
+	InflightBytes *linmetric.BoundGauge     // bytes scanned by executing queries
+	QueueFull     *linmetric.BoundCounter   // queries rejected because of queue full
+	QueueTimeout  *linmetric.BoundCounter   // queries rejected because of waiting timeout
+	WaitDuration  *linmetric.BoundHistogram // duration of query waiting for admission
+}
+
+// QueryHedgeStatistics represents hedged leaf request statistics of broker.
+type QueryHedgeStatistics struct {
+	Attempts    *linmetric.BoundCounter // leaf requests hedged to other replica
+	Wins        *linmetric.BoundCounter // hedged requests responded before original requests
+	Wasted      *linmetric.BoundCounter // loser requests cancelled, the work of them is wasted
+	RateLimited *linmetric.BoundCounter // hedges skipped because of exceeding max hedge rate
+}
+
+// RecordingRuleStatistics represents recording rule evaluation statistics.
+type RecordingRuleStatistics struct {
+	Evaluations      *linmetric.BoundCounter   // windows evaluated and written back
+	EvaluateFailures *linmetric.BoundCounter   // evaluation failure(parse/query)
+	WriteFailures    *linmetric.BoundCounter   // write back failure
+	SkippedWindows   *linmetric.BoundCounter   // windows skipped because of exceeding max catch up windows
+	OverlapSkips     *linmetric.BoundCounter   // evaluations skipped because previous evaluation still running
+	Lag              *linmetric.BoundGauge     // lag(ms) between now and the end of last evaluated window
+	Duration         *linmetric.BoundHistogram // evaluation duration
+}
+
+// AlertRuleStatistics represents alert rule evaluation statistics.
+type AlertRuleStatistics struct {
+	Evaluations      *linmetric.BoundCounter    // windows evaluated
+	EvaluateFailures *linmetric.BoundCounter    // evaluation failure(parse/query/persist state)
+	OverlapSkips     *linmetric.BoundCounter    // evaluations skipped because previous evaluation still running
+	Transitions      *linmetric.DeltaCounterVec // state transitions of alert series, tagged by target state
+	Pending          *linmetric.BoundGauge      // alert series in pending state
+	Firing           *linmetric.BoundGauge      // alert series in firing state
+}
+
+// AlertNotifyStatistics represents alert webhook notification statistics.
+type AlertNotifyStatistics struct {
+	Notifications *linmetric.BoundCounter // alerts posted to webhook successfully
+	Failures      *linmetric.BoundCounter // alerts failed to post after all retries
+	Retries       *linmetric.BoundCounter // retried posts
+	Deduplicated  *linmetric.BoundCounter // alerts skipped because already notified
+}
+
+// SlowQueryStatistics represents slow query statistics.
+type SlowQueryStatistics struct {
+	SlowQueries *linmetric.BoundCounter   // query which cost exceeds slow query threshold
+	Duration    *linmetric.BoundHistogram // slow query duration
+}
+
+// ResultCacheStatistics represents query result cache statistics.
+type ResultCacheStatistics struct {
+	Hit    *linmetric.BoundCounter // cached result found
+	Miss   *linmetric.BoundCounter // cached result not found
+	Bypass *linmetric.BoundCounter // result cannot be cached(mutable time range/disabled by request/too large etc.)
+	Evict  *linmetric.BoundCounter // cached result evicted(expired/exceed max size)
+	Size   *linmetric.BoundGauge   // total size of cached results
 }
 
 // NewTransportStatistics creates a transport statistics.
@@ -70,6 +134,76 @@ func NewQueryStatistics(registry *linmetric.Registry) *QueryStatistics {
 	}
 }
 
+// NewSlowQueryStatistics creates a slow query statistics.
+func NewSlowQueryStatistics(registry *linmetric.Registry) *SlowQueryStatistics {
+	scope := registry.NewScope("lindb.query.slow")
+	return &SlowQueryStatistics{
+		SlowQueries: scope.NewCounter("slow_queries"),
+		Duration:    scope.Scope("duration").NewHistogram(),
+	}
+}
+
+// NewResultCacheStatistics creates a query result cache statistics.
+func NewResultCacheStatistics(registry *linmetric.Registry) *ResultCacheStatistics {
+	scope := registry.NewScope("lindb.query.result_cache")
+	return &ResultCacheStatistics{
+		Hit:    scope.NewCounter("hit"),
+		Miss:   scope.NewCounter("miss"),
+		Bypass: scope.NewCounter("bypass"),
+		Evict:  scope.NewCounter("evict"),
+		Size:   scope.NewGauge("size"),
+	}
+}
+
+// NewQueryHedgeStatistics creates a hedged leaf request statistics.
+func NewQueryHedgeStatistics(registry *linmetric.Registry) *QueryHedgeStatistics {
+	scope := registry.NewScope("lindb.query.hedge")
+	return &QueryHedgeStatistics{
+		Attempts:    scope.NewCounter("attempts"),
+		Wins:        scope.NewCounter("wins"),
+		Wasted:      scope.NewCounter("wasted"),
+		RateLimited: scope.NewCounter("rate_limited"),
+	}
+}
+
+// NewRecordingRuleStatistics creates a recording rule evaluation statistics.
+func NewRecordingRuleStatistics(database, rule string) *RecordingRuleStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.query.recording_rule", "db", database, "rule", rule)
+	return &RecordingRuleStatistics{
+		Evaluations:      scope.NewCounter("evaluations"),
+		EvaluateFailures: scope.NewCounter("evaluate_failures"),
+		WriteFailures:    scope.NewCounter("write_failures"),
+		SkippedWindows:   scope.NewCounter("skipped_windows"),
+		OverlapSkips:     scope.NewCounter("overlap_skips"),
+		Lag:              scope.NewGauge("lag"),
+		Duration:         scope.Scope("duration").NewHistogram(),
+	}
+}
+
+// NewAlertRuleStatistics creates an alert rule evaluation statistics.
+func NewAlertRuleStatistics(database, rule string) *AlertRuleStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.query.alert_rule", "db", database, "rule", rule)
+	return &AlertRuleStatistics{
+		Evaluations:      scope.NewCounter("evaluations"),
+		EvaluateFailures: scope.NewCounter("evaluate_failures"),
+		OverlapSkips:     scope.NewCounter("overlap_skips"),
+		Transitions:      scope.NewCounterVec("transitions", "state"),
+		Pending:          scope.NewGauge("pending"),
+		Firing:           scope.NewGauge("firing"),
+	}
+}
+
+// NewAlertNotifyStatistics creates an alert webhook notification statistics.
+func NewAlertNotifyStatistics(registry *linmetric.Registry) *AlertNotifyStatistics {
+	scope := registry.NewScope("lindb.query.alert_notify")
+	return &AlertNotifyStatistics{
+		Notifications: scope.NewCounter("notifications"),
+		Failures:      scope.NewCounter("failures"),
+		Retries:       scope.NewCounter("retries"),
+		Deduplicated:  scope.NewCounter("deduplicated"),
+	}
+}
+
 // NewStorageQueryStatistics creates a storage query statistics.
 func NewStorageQueryStatistics() *StorageQueryStatistics {
 	scope := linmetric.StorageRegistry.NewScope("lindb.storage.query")
@@ -79,5 +213,20 @@ func NewStorageQueryStatistics() *StorageQueryStatistics {
 		MetaQuery:           scope.NewCounter("meta_queries"),
 		MetaQueryFailures:   scope.NewCounter("meta_query_failures"),
 		OmitRequest:         scope.NewCounter("omitted_requests"),
+		CancelledQuery:      scope.NewCounter("cancelled_queries"),
+	}
+}
+
+// NewQueryAdmissionStatistics creates a storage query admission control statistics.
+func NewQueryAdmissionStatistics() *QueryAdmissionStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.storage.query.admission")
+	return &QueryAdmissionStatistics{
+		Admitted:      scope.NewCounter("admitted"),
+		Executing:     scope.NewGauge("executing"),
+		QueueDepth:    scope.NewGauge("queue_depth"),
+		InflightBytes: scope.NewGauge("inflight_bytes"),
+		QueueFull:     scope.NewCounter("queue_full_rejections"),
+		QueueTimeout:  scope.NewCounter("queue_timeout_rejections"),
+		WaitDuration:  scope.Scope("wait_duration").NewHistogram(),
 	}
 }
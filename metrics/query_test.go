@@ -29,4 +29,9 @@ func TestQueryStatistics(t *testing.T) {
 	assert.NotNil(t, NewQueryStatistics(linmetric.RootRegistry))
 	assert.NotNil(t, NewTransportStatistics(linmetric.RootRegistry))
 	assert.NotNil(t, NewStorageQueryStatistics())
+	assert.NotNil(t, NewSlowQueryStatistics(linmetric.RootRegistry))
+	assert.NotNil(t, NewResultCacheStatistics(linmetric.RootRegistry))
+	assert.NotNil(t, NewRecordingRuleStatistics("db", "rule"))
+	assert.NotNil(t, NewAlertRuleStatistics("db", "rule"))
+	assert.NotNil(t, NewAlertNotifyStatistics(linmetric.RootRegistry))
 }
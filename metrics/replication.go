@@ -22,9 +22,32 @@ import "github.com/lindb/lindb/internal/linmetric"
 // BrokerDatabaseWriteStatistics represents database channel write statistics.
 type BrokerDatabaseWriteStatistics struct {
 	OutOfTimeRange *linmetric.BoundCounter // timestamp of metrics out of acceptable write time range
+	LateAccepted   *linmetric.BoundCounter // metrics of older family accepted within out-of-order window
+	TooLateDropped *linmetric.BoundCounter // metrics of older family dropped because exceeding out-of-order window
 	ShardNotFound  *linmetric.BoundCounter // shard not found count
 }
 
+// BrokerWALStatistics represents write ahead log statistics of database channel.
+type BrokerWALStatistics struct {
+	PendingSize    *linmetric.BoundGauge     // bytes of entries not acknowledged by storage
+	PendingEntries *linmetric.BoundGauge     // number of entries not acknowledged by storage
+	Append         *linmetric.BoundCounter   // append entry success count
+	AppendFailures *linmetric.BoundCounter   // append entry failure count
+	FsyncDuration  *linmetric.BoundHistogram // fsync duration(group commit)
+	Replay         *linmetric.BoundCounter   // entries replayed after broker restarted
+	Redeliver      *linmetric.BoundCounter   // entries re-delivered after chunk lost before acknowledged
+	Truncate       *linmetric.BoundCounter   // truncate acknowledged entries count
+}
+
+// BrokerPreAggregationStatistics represents pre-aggregation statistics of database channel.
+type BrokerPreAggregationStatistics struct {
+	BufferedRows  *linmetric.BoundGauge   // num. of folded rows buffered in window
+	InputRows     *linmetric.BoundCounter // rows moved into pre-aggregation window
+	OutputRows    *linmetric.BoundCounter // folded rows flushed into family channels
+	Flush         *linmetric.BoundCounter // flush window success count
+	FlushFailures *linmetric.BoundCounter // flush window failure count
+}
+
 // BrokerFamilyWriteStatistics represents family channel write statistics.
 type BrokerFamilyWriteStatistics struct {
 	ActiveWriteFamilies  *linmetric.BoundGauge   // number of current active replica family channel
@@ -36,11 +59,26 @@ type BrokerFamilyWriteStatistics struct {
 	SendSize             *linmetric.BoundCounter // bytes of send message
 	Retry                *linmetric.BoundCounter // retry count
 	RetryDrop            *linmetric.BoundCounter // number of drop message after too many retry
+	RetryExpire          *linmetric.BoundCounter // number of drop message after buffered too long
 	CreateStream         *linmetric.BoundCounter // create replica stream success count
 	CreateStreamFailures *linmetric.BoundCounter // create replica stream failure count
 	CloseStream          *linmetric.BoundCounter // close replica stream success count
 	CloseStreamFailures  *linmetric.BoundCounter // close replica stream failure count
 	LeaderChanged        *linmetric.BoundCounter // shard leader changed
+	// batching/compression of chunks, batch size = raw size / chunks, compression ratio = raw size / compressed size
+	Chunks              *linmetric.BoundCounter   // number of chunks compressed
+	ChunkRawSize        *linmetric.BoundCounter   // bytes of chunks before compression
+	ChunkCompressedSize *linmetric.BoundCounter   // bytes of chunks after compression
+	CompressionRatio    *linmetric.BoundGauge     // compression ratio of the latest chunk
+	CompressDuration    *linmetric.BoundHistogram // latency added by compressing chunk
+	BatchDuration       *linmetric.BoundHistogram // latency added by batching, from first row buffered to chunk compressed
+	CompressionFallback *linmetric.BoundCounter   // streams sending snappy chunks because storage doesn't support the codec
+}
+
+// BrokerShardWriteStatistics represents shard channel write statistics.
+type BrokerShardWriteStatistics struct {
+	RetryBufferSize   *linmetric.BoundGauge // bytes of messages buffered for retrying
+	RetryBufferChunks *linmetric.BoundGauge // number of messages buffered for retrying
 }
 
 // StorageLocalReplicatorStatistics represents local replicator statistics.
@@ -50,6 +88,7 @@ type StorageLocalReplicatorStatistics struct {
 	ReplicaRows        *linmetric.BoundCounter // row number of replica
 	AckSequence        *linmetric.BoundCounter // ack persist sequence count
 	InvalidSequence    *linmetric.BoundCounter // invalid replica sequence count
+	DuplicateRows      *linmetric.BoundCounter // rows skipped by duplicate idempotency token
 }
 
 // StorageRemoteReplicatorStatistics represents remote replicator statistics.
@@ -85,6 +124,8 @@ type StorageReplicatorRunnerStatistics struct {
 	ReplicaLag             *linmetric.BoundGauge   // replica lag message count
 	ReplicaBytes           *linmetric.BoundCounter // bytes of replica data
 	Replica                *linmetric.BoundCounter // replica success count
+	Backpressure           *linmetric.BoundCounter // replica slowed down by write backpressure count
+	BackpressureMs         *linmetric.BoundCounter // milliseconds of replica slowed down by write backpressure
 }
 
 // StorageWriteAheadLogStatistics represents storage write ahead log statistics.
@@ -97,15 +138,64 @@ type StorageWriteAheadLogStatistics struct {
 	ReplicaWALFailures *linmetric.BoundCounter // replica wal failure(storage leader->follower)
 }
 
+// StorageSnapshotStatistics represents statistics of shard snapshot transferred by leader for bootstrapping replica.
+type StorageSnapshotStatistics struct {
+	ActiveSnapshots        *linmetric.BoundGauge   // number of current active snapshot
+	CreateSnapshot         *linmetric.BoundCounter // create snapshot success count
+	CreateSnapshotFailures *linmetric.BoundCounter // create snapshot failure count
+	ExpireSnapshot         *linmetric.BoundCounter // idle snapshot expired count
+	SendChunk              *linmetric.BoundCounter // send snapshot chunk count
+	SendBytes              *linmetric.BoundCounter // send snapshot bytes
+}
+
+// StorageReplicaBootstrapStatistics represents statistics of replica bootstrapping from leader's snapshot.
+type StorageReplicaBootstrapStatistics struct {
+	Bootstrap         *linmetric.BoundCounter // bootstrap replica success count
+	BootstrapFailures *linmetric.BoundCounter // bootstrap replica failure count
+	ReceiveChunk      *linmetric.BoundCounter // receive snapshot chunk count
+	ReceiveBytes      *linmetric.BoundCounter // receive snapshot bytes
+	ChecksumFailures  *linmetric.BoundCounter // checksum of chunk/file mismatch count
+	InstallFamily     *linmetric.BoundCounter // install family snapshot count
+}
+
 // NewBrokerDatabaseWriteStatistics creates a database channel write statistics.
 func NewBrokerDatabaseWriteStatistics(database string) *BrokerDatabaseWriteStatistics {
 	scope := linmetric.BrokerRegistry.NewScope("lindb.broker.database.write")
 	return &BrokerDatabaseWriteStatistics{
 		OutOfTimeRange: scope.NewCounterVec("out_of_time_range", "db").WithTagValues(database),
+		LateAccepted:   scope.NewCounterVec("late_accepted", "db").WithTagValues(database),
+		TooLateDropped: scope.NewCounterVec("too_late_dropped", "db").WithTagValues(database),
 		ShardNotFound:  scope.NewCounterVec("shard_not_found", "db").WithTagValues(database),
 	}
 }
 
+// NewBrokerWALStatistics creates a write ahead log statistics of database channel.
+func NewBrokerWALStatistics(database string) *BrokerWALStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.broker.database.wal")
+	return &BrokerWALStatistics{
+		PendingSize:    scope.NewGaugeVec("pending_size", "db").WithTagValues(database),
+		PendingEntries: scope.NewGaugeVec("pending_entries", "db").WithTagValues(database),
+		Append:         scope.NewCounterVec("append", "db").WithTagValues(database),
+		AppendFailures: scope.NewCounterVec("append_failures", "db").WithTagValues(database),
+		FsyncDuration:  scope.Scope("fsync_duration").NewHistogramVec("db").WithTagValues(database),
+		Replay:         scope.NewCounterVec("replay", "db").WithTagValues(database),
+		Redeliver:      scope.NewCounterVec("redeliver", "db").WithTagValues(database),
+		Truncate:       scope.NewCounterVec("truncate", "db").WithTagValues(database),
+	}
+}
+
+// NewBrokerPreAggregationStatistics creates a pre-aggregation statistics of database channel.
+func NewBrokerPreAggregationStatistics(database string) *BrokerPreAggregationStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.broker.database.pre_aggregation")
+	return &BrokerPreAggregationStatistics{
+		BufferedRows:  scope.NewGaugeVec("buffered_rows", "db").WithTagValues(database),
+		InputRows:     scope.NewCounterVec("input_rows", "db").WithTagValues(database),
+		OutputRows:    scope.NewCounterVec("output_rows", "db").WithTagValues(database),
+		Flush:         scope.NewCounterVec("flush", "db").WithTagValues(database),
+		FlushFailures: scope.NewCounterVec("flush_failures", "db").WithTagValues(database),
+	}
+}
+
 // NewBrokerFamilyWriteStatistics creates a family channel write statistics.
 func NewBrokerFamilyWriteStatistics(database string) *BrokerFamilyWriteStatistics {
 	scope := linmetric.BrokerRegistry.NewScope("lindb.broker.family.write")
@@ -119,11 +209,28 @@ func NewBrokerFamilyWriteStatistics(database string) *BrokerFamilyWriteStatistic
 		SendSize:             scope.NewCounterVec("send_size", "db").WithTagValues(database),
 		Retry:                scope.NewCounterVec("retry", "db").WithTagValues(database),
 		RetryDrop:            scope.NewCounterVec("retry_drop", "db").WithTagValues(database),
+		RetryExpire:          scope.NewCounterVec("retry_expire", "db").WithTagValues(database),
 		CreateStream:         scope.NewCounterVec("create_stream", "db").WithTagValues(database),
 		CreateStreamFailures: scope.NewCounterVec("create_stream_failures", "db").WithTagValues(database),
 		CloseStream:          scope.NewCounterVec("close_stream", "db").WithTagValues(database),
 		CloseStreamFailures:  scope.NewCounterVec("close_stream_failures", "db").WithTagValues(database),
 		LeaderChanged:        scope.NewCounterVec("leader_changed", "db").WithTagValues(database),
+		Chunks:               scope.NewCounterVec("chunks", "db").WithTagValues(database),
+		ChunkRawSize:         scope.NewCounterVec("chunk_raw_size", "db").WithTagValues(database),
+		ChunkCompressedSize:  scope.NewCounterVec("chunk_compressed_size", "db").WithTagValues(database),
+		CompressionRatio:     scope.NewGaugeVec("compression_ratio", "db").WithTagValues(database),
+		CompressDuration:     scope.Scope("compress_duration").NewHistogramVec("db").WithTagValues(database),
+		BatchDuration:        scope.Scope("batch_duration").NewHistogramVec("db").WithTagValues(database),
+		CompressionFallback:  scope.NewCounterVec("compression_fallback", "db").WithTagValues(database),
+	}
+}
+
+// NewBrokerShardWriteStatistics creates a shard channel write statistics.
+func NewBrokerShardWriteStatistics(database, shard string) *BrokerShardWriteStatistics {
+	scope := linmetric.BrokerRegistry.NewScope("lindb.broker.shard.write")
+	return &BrokerShardWriteStatistics{
+		RetryBufferSize:   scope.NewGaugeVec("retry_buffer_size", "db", "shard").WithTagValues(database, shard),
+		RetryBufferChunks: scope.NewGaugeVec("retry_buffer_chunks", "db", "shard").WithTagValues(database, shard),
 	}
 }
 
@@ -136,6 +243,7 @@ func NewStorageLocalReplicatorStatistics(database, shard string) *StorageLocalRe
 		ReplicaRows:        scope.NewCounterVec("replica_rows", "db", "shard").WithTagValues(database, shard),
 		AckSequence:        scope.NewCounterVec("ack_sequence", "db", "shard").WithTagValues(database, shard),
 		InvalidSequence:    scope.NewCounterVec("invalid_sequence", "db", "shard").WithTagValues(database, shard),
+		DuplicateRows:      scope.NewCounterVec("duplicate_rows", "db", "shard").WithTagValues(database, shard),
 	}
 }
 
@@ -157,6 +265,10 @@ func NewStorageReplicatorRunnerStatistics(replicatorType, database, shard string
 			WithTagValues(replicatorType, database, shard),
 		Replica: scope.NewCounterVec("replicas", "type", "db", "shard").
 			WithTagValues(replicatorType, database, shard),
+		Backpressure: scope.NewCounterVec("backpressure", "type", "db", "shard").
+			WithTagValues(replicatorType, database, shard),
+		BackpressureMs: scope.NewCounterVec("backpressure_ms", "type", "db", "shard").
+			WithTagValues(replicatorType, database, shard),
 	}
 }
 
@@ -225,3 +337,35 @@ func NewStorageWriteAheadLogStatistics(database, shard string) *StorageWriteAhea
 			WithTagValues(database, shard),
 	}
 }
+
+// NewStorageSnapshotStatistics creates a storage shard snapshot statistics.
+func NewStorageSnapshotStatistics() *StorageSnapshotStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.storage.replica.snapshot")
+	return &StorageSnapshotStatistics{
+		ActiveSnapshots:        scope.NewGauge("active_snapshots"),
+		CreateSnapshot:         scope.NewCounter("create_snapshot"),
+		CreateSnapshotFailures: scope.NewCounter("create_snapshot_failures"),
+		ExpireSnapshot:         scope.NewCounter("expire_snapshot"),
+		SendChunk:              scope.NewCounter("send_chunk"),
+		SendBytes:              scope.NewCounter("send_bytes"),
+	}
+}
+
+// NewStorageReplicaBootstrapStatistics creates a storage replica bootstrap statistics.
+func NewStorageReplicaBootstrapStatistics(database, shard string) *StorageReplicaBootstrapStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.storage.replica.bootstrap")
+	return &StorageReplicaBootstrapStatistics{
+		Bootstrap: scope.NewCounterVec("bootstrap", "db", "shard").
+			WithTagValues(database, shard),
+		BootstrapFailures: scope.NewCounterVec("bootstrap_failures", "db", "shard").
+			WithTagValues(database, shard),
+		ReceiveChunk: scope.NewCounterVec("receive_chunk", "db", "shard").
+			WithTagValues(database, shard),
+		ReceiveBytes: scope.NewCounterVec("receive_bytes", "db", "shard").
+			WithTagValues(database, shard),
+		ChecksumFailures: scope.NewCounterVec("checksum_failures", "db", "shard").
+			WithTagValues(database, shard),
+		InstallFamily: scope.NewCounterVec("install_family", "db", "shard").
+			WithTagValues(database, shard),
+	}
+}
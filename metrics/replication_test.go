@@ -26,8 +26,12 @@ import (
 func TestReplication_New(t *testing.T) {
 	assert.NotNil(t, NewBrokerFamilyWriteStatistics("db"))
 	assert.NotNil(t, NewBrokerDatabaseWriteStatistics("db"))
+	assert.NotNil(t, NewBrokerWALStatistics("db"))
+	assert.NotNil(t, NewBrokerShardWriteStatistics("db", "shard"))
 	assert.NotNil(t, NewStorageReplicatorRunnerStatistics("type", "db", "shard"))
 	assert.NotNil(t, NewStorageLocalReplicatorStatistics("db", "shard"))
 	assert.NotNil(t, NewStorageRemoteReplicatorStatistics("db", "shard"))
 	assert.NotNil(t, NewStorageWriteAheadLogStatistics("db", "shard"))
+	assert.NotNil(t, NewStorageSnapshotStatistics())
+	assert.NotNil(t, NewStorageReplicaBootstrapStatistics("db", "shard"))
 }
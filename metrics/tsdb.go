@@ -17,25 +17,62 @@
 
 package metrics
 
-import "github.com/lindb/lindb/internal/linmetric"
+import (
+	"sync"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/internal/linmetric"
+)
 
 var (
 	// mete database metric
 	metaDBScope = linmetric.StorageRegistry.NewScope("lindb.tsdb.metadb")
 	// shard metric
 	shardScope = linmetric.StorageRegistry.NewScope("lindb.tsdb.shard")
+	// node-level memory governor metric
+	memoryGovernorScope = linmetric.StorageRegistry.NewScope("lindb.tsdb.memory_governor")
 
 	// FlushCheckerStatistics represents flush checker statistics.
 	FlushCheckerStatistics = struct {
-		FlushInFlight *linmetric.GaugeVec // number of family flushing
+		FlushInFlight *linmetric.GaugeVec        // number of family flushing
+		FlushPaused   *linmetric.GaugeVec        // 1 if flushing of database is paused for maintenance
+		ForcedFlushes *linmetric.DeltaCounterVec // flushes forced by hard memory ceiling while paused
 	}{
 		FlushInFlight: shardScope.NewGaugeVec("flush_inflight", "db", "shard"),
+		FlushPaused:   shardScope.NewGaugeVec("flush_paused", "db"),
+		ForcedFlushes: shardScope.NewCounterVec("forced_flushes_while_paused", "db"),
+	}
+
+	// ShardHibernationStatistics represents idle shard hibernation statistics.
+	ShardHibernationStatistics = struct {
+		ActiveShards     *linmetric.BoundGauge   // number of active shards
+		HibernatedShards *linmetric.BoundGauge   // number of hibernated shards
+		Hibernations     *linmetric.BoundCounter // times of shard hibernated after idle period
+		Wakeups          *linmetric.BoundCounter // times of hibernated shard woken up by write/query
+	}{
+		ActiveShards:     shardScope.NewGauge("active_shards"),
+		HibernatedShards: shardScope.NewGauge("hibernated_shards"),
+		Hibernations:     shardScope.NewCounter("hibernations"),
+		Wakeups:          shardScope.NewCounter("wakeups"),
+	}
+
+	// MemoryGovernorStatistics represents node-level memory governor statistics.
+	MemoryGovernorStatistics = struct {
+		MemDBTotalSize *linmetric.BoundGauge      // total memory database size of all families
+		Triggers       *linmetric.BoundCounter    // times of total memdb size above high watermark
+		PickedFamilies *linmetric.DeltaCounterVec // families picked to flush
+	}{
+		MemDBTotalSize: memoryGovernorScope.NewGauge("memdb_total_size"),
+		Triggers:       memoryGovernorScope.NewCounter("triggers"),
+		PickedFamilies: memoryGovernorScope.NewCounterVec("picked_families", "db"),
 	}
 )
 
 // IndexDBStatistics represents index database statistics.
 type IndexDBStatistics = struct {
 	BuildInvertedIndex *linmetric.BoundCounter // build inverted index count
+	ReclaimedSeries    *linmetric.BoundCounter // series reclaimed by series gc
 }
 
 // MemDBStatistics represents memory database statistics.
@@ -52,8 +89,12 @@ type DatabaseStatistics struct {
 
 // TagMetaStatistics represents tag metadata statistics.
 type TagMetaStatistics struct {
-	GenTagValueIDs        *linmetric.BoundCounter // generate tag value id success
-	GenTagValueIDFailures *linmetric.BoundCounter // generate tag value id failure
+	GenTagValueIDs        *linmetric.BoundCounter   // generate tag value id success
+	GenTagValueIDFailures *linmetric.BoundCounter   // generate tag value id failure
+	FindTagValues         *linmetric.BoundCounter   // find tag values by prefix/filter count
+	FindTagValueFailures  *linmetric.BoundCounter   // find tag values by prefix/filter failure
+	ScanTagValues         *linmetric.BoundCounter   // number of tag values scanned when find tag values
+	FindTagValuesDuration *linmetric.BoundHistogram // find tag values duration(include count)
 }
 
 // MetaDBStatistics represents metadata database statistics.
@@ -62,6 +103,8 @@ type MetaDBStatistics struct {
 	GenMetricIDFailures *linmetric.BoundCounter // generate metric id failure
 	GenFieldIDs         *linmetric.BoundCounter // generate field id success
 	GenFieldIDFailures  *linmetric.BoundCounter // generate field id failure
+	FieldTypeConflicts  *linmetric.BoundCounter // write field with type different from stored schema rejected
+	FieldTypeChanges    *linmetric.BoundCounter // field type changed by writing if allowed
 	GenTagKeyIDs        *linmetric.BoundCounter // generate tag key id success
 	GenTagKeyIDFailures *linmetric.BoundCounter // generate tag key id failure
 }
@@ -71,6 +114,12 @@ type ShardStatistics struct {
 	LookupMetricMetaFailures *linmetric.BoundCounter   // lookup meta of metric failure
 	IndexDBFlushDuration     *linmetric.BoundHistogram // flush index database duration(include count)
 	IndexDBFlushFailures     *linmetric.BoundCounter   // flush index database failure
+	IngestionLag             *linmetric.BoundGauge     // lag(ms) between now and the latest data written
+	FamilyRegressed          *linmetric.BoundCounter   // creation of family earlier than the latest family routed to the latest family
+
+	database, shard string
+	vecs            []statisticsVec
+	closed          atomic.Bool
 }
 
 // FamilyStatistics represents family statistics.
@@ -80,45 +129,220 @@ type FamilyStatistics struct {
 	WriteMetrics        *linmetric.BoundCounter   // write metric success count
 	WriteFields         *linmetric.BoundCounter   // write field data point success count
 	WriteMetricFailures *linmetric.BoundCounter   // write metric failures
+	LateAccepted        *linmetric.BoundCounter   // write metric success count of older family within out-of-order window
+	TooLateDropped      *linmetric.BoundCounter   // drop metric count of older family out of out-of-order window
 	MemDBTotalSize      *linmetric.BoundGauge     // total memory size of memory database
 	ActiveMemDBs        *linmetric.BoundGauge     // number of current active memory database
 	MemDBFlushFailures  *linmetric.BoundCounter   // flush memory database failure
 	MemDBFlushDuration  *linmetric.BoundHistogram // flush memory database duration(include count)
+	MemDBFlushDeferred  *linmetric.BoundCounter   // flush memory database deferred because of insufficient disk space
+	FlushVerifyPoints   *linmetric.BoundCounter   // num. of points verified after flush
+	FlushVerifyFailures *linmetric.BoundCounter   // num. of flushes which flushed data mismatches memory database
+	FlushVerifyDuration *linmetric.BoundHistogram // verify flushed data duration(overhead of each flush)
+	DiskSize            *linmetric.BoundGauge     // total size of files of families(refreshed after flush/compaction)
+	NumOfFiles          *linmetric.BoundGauge     // num. of files of families(refreshed after flush/compaction)
+
+	database, shard, family string
+	vecs                    []statisticsVec
+	closed                  atomic.Bool
+}
+
+// statisticsVec represents the vec which shard/family statistics bound from.
+type statisticsVec interface {
+	DeleteTagValues(tagValues ...string)
+}
+
+var (
+	familyRefsLock sync.Mutex
+	// families of shard which opened statistics, database/shard => family indicator => ref count,
+	// statistics of shard are unregistered after the last family closed.
+	familyRefs = make(map[string]map[string]int)
+
+	shardRefsLock sync.Mutex
+	// shards which opened statistics, database/shard => ref count,
+	// re-opened shard(re-created database with same name) reuses the registered statistics,
+	// statistics are unregistered after the last opened shard closed.
+	shardRefs = make(map[string]int)
+)
+
+// DeadLetterStatistics represents dead-letter sink statistics.
+type DeadLetterStatistics struct {
+	Sent        *linmetric.BoundCounter // num. of dead-letters sent into sink
+	RateLimited *linmetric.BoundCounter // num. of dead-letters dropped by rate limit
+	Dropped     *linmetric.BoundCounter // num. of dead-letters dropped by queue full/forward failure
+}
+
+// DiskWatchdogStatistics represents disk space watchdog statistics.
+type DiskWatchdogStatistics struct {
+	FreePercent *linmetric.GaugeVec     // free percent of disk, tagged by path
+	LowSpace    *linmetric.BoundGauge   // 1 if free space below low watermark(writes rejected/compaction paused), else 0
+	Pauses      *linmetric.BoundCounter // num. of pauses triggered by low disk space
+}
+
+// ConfigReloadStatistics represents tsdb config hot reload statistics.
+type ConfigReloadStatistics struct {
+	Version        *linmetric.BoundGauge   // version of tsdb config, increased after each successful reload
+	Reloads        *linmetric.BoundCounter // num. of successful reloads
+	ReloadFailures *linmetric.BoundCounter // num. of reload failures(invalid config etc.)
+}
+
+// ConsistencyCheckStatistics represents consistency check statistics of shard replicas on storage node.
+type ConsistencyCheckStatistics struct {
+	Checks        *linmetric.BoundCounter // num. of digests computed for shard replica
+	CheckFailures *linmetric.BoundCounter // num. of failures when computing/reporting digest
+	ScanBytes     *linmetric.BoundCounter // bytes of family data scanned
+	Throttles     *linmetric.BoundCounter // num. of pauses because of foreground queries
+	CheckDuration *linmetric.BoundHistogram
+}
+
+// IndexWarmupStatistics represents index warm-up statistics after storage node restart.
+type IndexWarmupStatistics struct {
+	WarmedMetrics   *linmetric.BoundCounter // num. of metrics warmed up
+	WarmFailures    *linmetric.BoundCounter // num. of failures when warming up metric
+	ReadBytes       *linmetric.BoundCounter // bytes of index/blocks touched
+	Interrupts      *linmetric.BoundCounter // num. of warm-up stopped because of foreground queries
+	PersistFailures *linmetric.BoundCounter // num. of failures when persisting most queried metrics
 }
 
 // NewFamilyStatistics creates a family statistics.
-func NewFamilyStatistics(database, shard string) *FamilyStatistics {
-	return &FamilyStatistics{
-		ActiveFamilies: shardScope.NewGaugeVec("active_families", "db", "shard").
-			WithTagValues(database, shard),
-		WriteBatches: shardScope.NewCounterVec("write_batches", "db", "shard").
-			WithTagValues(database, shard),
-		WriteMetrics: shardScope.NewCounterVec("write_metrics", "db", "shard").
-			WithTagValues(database, shard),
-		WriteFields: shardScope.NewCounterVec("write_fields", "db", "shard").
-			WithTagValues(database, shard),
-		WriteMetricFailures: shardScope.NewCounterVec("write_metrics_failures", "db", "shard").
-			WithTagValues(database, shard),
-		MemDBTotalSize: shardScope.NewGaugeVec("memdb_total_size", "db", "shard").
-			WithTagValues(database, shard),
-		ActiveMemDBs: shardScope.NewGaugeVec("active_memdbs", "db", "shard").
-			WithTagValues(database, shard),
-		MemDBFlushFailures: shardScope.NewCounterVec("memdb_flush_failures", "db", "shard").
-			WithTagValues(database, shard),
-		MemDBFlushDuration: shardScope.Scope("memdb_flush_duration").NewHistogramVec("db", "shard").
-			WithTagValues(database, shard),
+// Reopening the same family is de-duplicated, so that it is counted once in active families.
+func NewFamilyStatistics(database, shard, family string) *FamilyStatistics {
+	activeFamilies := shardScope.NewGaugeVec("active_families", "db", "shard")
+	writeBatches := shardScope.NewCounterVec("write_batches", "db", "shard")
+	writeMetrics := shardScope.NewCounterVec("write_metrics", "db", "shard")
+	writeFields := shardScope.NewCounterVec("write_fields", "db", "shard")
+	writeMetricFailures := shardScope.NewCounterVec("write_metrics_failures", "db", "shard")
+	lateAccepted := shardScope.NewCounterVec("late_accepted", "db", "shard")
+	tooLateDropped := shardScope.NewCounterVec("too_late_dropped", "db", "shard")
+	memDBTotalSize := shardScope.NewGaugeVec("memdb_total_size", "db", "shard")
+	activeMemDBs := shardScope.NewGaugeVec("active_memdbs", "db", "shard")
+	memDBFlushFailures := shardScope.NewCounterVec("memdb_flush_failures", "db", "shard")
+	memDBFlushDuration := shardScope.Scope("memdb_flush_duration").NewHistogramVec("db", "shard")
+	memDBFlushDeferred := shardScope.NewCounterVec("memdb_flush_deferred", "db", "shard")
+	flushVerifyPoints := shardScope.NewCounterVec("flush_verify_points", "db", "shard")
+	flushVerifyFailures := shardScope.NewCounterVec("flush_verify_failures", "db", "shard")
+	flushVerifyDuration := shardScope.Scope("flush_verify_duration").NewHistogramVec("db", "shard")
+	diskSize := shardScope.NewGaugeVec("disk_size", "db", "shard")
+	numOfFiles := shardScope.NewGaugeVec("num_of_files", "db", "shard")
+
+	familyRefsLock.Lock()
+	defer familyRefsLock.Unlock()
+
+	statistics := &FamilyStatistics{
+		ActiveFamilies:      activeFamilies.WithTagValues(database, shard),
+		WriteBatches:        writeBatches.WithTagValues(database, shard),
+		WriteMetrics:        writeMetrics.WithTagValues(database, shard),
+		WriteFields:         writeFields.WithTagValues(database, shard),
+		WriteMetricFailures: writeMetricFailures.WithTagValues(database, shard),
+		LateAccepted:        lateAccepted.WithTagValues(database, shard),
+		TooLateDropped:      tooLateDropped.WithTagValues(database, shard),
+		MemDBTotalSize:      memDBTotalSize.WithTagValues(database, shard),
+		ActiveMemDBs:        activeMemDBs.WithTagValues(database, shard),
+		MemDBFlushFailures:  memDBFlushFailures.WithTagValues(database, shard),
+		MemDBFlushDuration:  memDBFlushDuration.WithTagValues(database, shard),
+		MemDBFlushDeferred:  memDBFlushDeferred.WithTagValues(database, shard),
+		FlushVerifyPoints:   flushVerifyPoints.WithTagValues(database, shard),
+		FlushVerifyFailures: flushVerifyFailures.WithTagValues(database, shard),
+		FlushVerifyDuration: flushVerifyDuration.WithTagValues(database, shard),
+		DiskSize:            diskSize.WithTagValues(database, shard),
+		NumOfFiles:          numOfFiles.WithTagValues(database, shard),
+
+		database: database,
+		shard:    shard,
+		family:   family,
+		vecs: []statisticsVec{
+			activeFamilies, writeBatches, writeMetrics, writeFields, writeMetricFailures,
+			lateAccepted, tooLateDropped, memDBTotalSize, activeMemDBs, memDBFlushFailures, memDBFlushDuration, memDBFlushDeferred,
+			flushVerifyPoints, flushVerifyFailures, flushVerifyDuration, diskSize, numOfFiles,
+		},
+	}
+	key := database + "/" + shard
+	families, ok := familyRefs[key]
+	if !ok {
+		families = make(map[string]int)
+		familyRefs[key] = families
+	}
+	families[family]++
+	statistics.ActiveFamilies.Update(float64(len(families)))
+	return statistics
+}
+
+// Close removes the family from active families, unregisters the statistics of shard
+// from registry after the last family of shard closed.
+func (s *FamilyStatistics) Close() {
+	if !s.closed.CAS(false, true) {
+		return
+	}
+	familyRefsLock.Lock()
+	defer familyRefsLock.Unlock()
+
+	key := s.database + "/" + s.shard
+	families, ok := familyRefs[key]
+	if !ok {
+		return
+	}
+	families[s.family]--
+	if families[s.family] <= 0 {
+		delete(families, s.family)
+	}
+	if len(families) > 0 {
+		s.ActiveFamilies.Update(float64(len(families)))
+		return
+	}
+	delete(familyRefs, key)
+	for _, vec := range s.vecs {
+		vec.DeleteTagValues(s.database, s.shard)
 	}
 }
 
 // NewShardStatistics creates a shard statistics.
+// Reopening the same shard reuses the registered statistics.
 func NewShardStatistics(database, shard string) *ShardStatistics {
-	return &ShardStatistics{
-		LookupMetricMetaFailures: shardScope.NewCounterVec("lookup_metric_meta_failures", "db", "shard").
-			WithTagValues(database, shard),
-		IndexDBFlushFailures: shardScope.NewCounterVec("indexdb_flush_failures", "db", "shard").
-			WithTagValues(database, shard),
-		IndexDBFlushDuration: shardScope.Scope("indexdb_flush_duration").NewHistogramVec("db", "shard").
-			WithTagValues(database, shard),
+	lookupMetricMetaFailures := shardScope.NewCounterVec("lookup_metric_meta_failures", "db", "shard")
+	indexDBFlushFailures := shardScope.NewCounterVec("indexdb_flush_failures", "db", "shard")
+	indexDBFlushDuration := shardScope.Scope("indexdb_flush_duration").NewHistogramVec("db", "shard")
+	ingestionLag := shardScope.NewGaugeVec("ingestion_lag", "db", "shard")
+	familyRegressed := shardScope.NewCounterVec("family_regressed", "db", "shard")
+
+	shardRefsLock.Lock()
+	defer shardRefsLock.Unlock()
+
+	statistics := &ShardStatistics{
+		LookupMetricMetaFailures: lookupMetricMetaFailures.WithTagValues(database, shard),
+		IndexDBFlushFailures:     indexDBFlushFailures.WithTagValues(database, shard),
+		IndexDBFlushDuration:     indexDBFlushDuration.WithTagValues(database, shard),
+		IngestionLag:             ingestionLag.WithTagValues(database, shard),
+		FamilyRegressed:          familyRegressed.WithTagValues(database, shard),
+
+		database: database,
+		shard:    shard,
+		vecs: []statisticsVec{lookupMetricMetaFailures, indexDBFlushFailures, indexDBFlushDuration,
+			ingestionLag, familyRegressed},
+	}
+	shardRefs[database+"/"+shard]++
+	return statistics
+}
+
+// Close unregisters the statistics of shard from registry after the last opened shard closed.
+func (s *ShardStatistics) Close() {
+	if !s.closed.CAS(false, true) {
+		return
+	}
+	shardRefsLock.Lock()
+	defer shardRefsLock.Unlock()
+
+	key := s.database + "/" + s.shard
+	refs, ok := shardRefs[key]
+	if !ok {
+		return
+	}
+	if refs > 1 {
+		shardRefs[key] = refs - 1
+		return
+	}
+	delete(shardRefs, key)
+	for _, vec := range s.vecs {
+		vec.DeleteTagValues(s.database, s.shard)
 	}
 }
 
@@ -131,6 +355,8 @@ func NewMetaDBStatistics(database string) *MetaDBStatistics {
 		GenTagKeyIDFailures: metaDBScope.NewCounterVec("gen_tag_key_id_failures", "db").WithTagValues(database),
 		GenFieldIDs:         metaDBScope.NewCounterVec("gen_field_ids", "db").WithTagValues(database),
 		GenFieldIDFailures:  metaDBScope.NewCounterVec("gen_field_id_failures", "db").WithTagValues(database),
+		FieldTypeConflicts:  metaDBScope.NewCounterVec("field_type_conflicts", "db").WithTagValues(database),
+		FieldTypeChanges:    metaDBScope.NewCounterVec("field_type_changes", "db").WithTagValues(database),
 	}
 }
 
@@ -139,6 +365,10 @@ func NewTagMetaStatistics(database string) *TagMetaStatistics {
 	return &TagMetaStatistics{
 		GenTagValueIDs:        metaDBScope.NewCounterVec("gen_tag_value_ids", "db").WithTagValues(database),
 		GenTagValueIDFailures: metaDBScope.NewCounterVec("gen_tag_value_id_failures", "db").WithTagValues(database),
+		FindTagValues:         metaDBScope.NewCounterVec("find_tag_values", "db").WithTagValues(database),
+		FindTagValueFailures:  metaDBScope.NewCounterVec("find_tag_value_failures", "db").WithTagValues(database),
+		ScanTagValues:         metaDBScope.NewCounterVec("scan_tag_values", "db").WithTagValues(database),
+		FindTagValuesDuration: metaDBScope.Scope("find_tag_values_duration").NewHistogramVec("db").WithTagValues(database),
 	}
 }
 
@@ -165,5 +395,60 @@ func NewIndexDBStatistics(database string) *IndexDBStatistics {
 	scope := linmetric.StorageRegistry.NewScope("lindb.tsdb.indexdb")
 	return &IndexDBStatistics{
 		BuildInvertedIndex: scope.NewCounterVec("build_inverted_index", "db").WithTagValues(database),
+		ReclaimedSeries:    scope.NewCounterVec("reclaimed_series", "db").WithTagValues(database),
+	}
+}
+
+// NewDeadLetterStatistics creates a dead-letter sink statistics.
+func NewDeadLetterStatistics() *DeadLetterStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.tsdb.dead_letter")
+	return &DeadLetterStatistics{
+		Sent:        scope.NewCounter("sent"),
+		RateLimited: scope.NewCounter("rate_limited"),
+		Dropped:     scope.NewCounter("dropped"),
+	}
+}
+
+// NewDiskWatchdogStatistics creates a disk space watchdog statistics.
+func NewDiskWatchdogStatistics() *DiskWatchdogStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.tsdb.disk_watchdog")
+	return &DiskWatchdogStatistics{
+		FreePercent: scope.NewGaugeVec("free_percent", "path"),
+		LowSpace:    scope.NewGauge("low_space"),
+		Pauses:      scope.NewCounter("pauses"),
+	}
+}
+
+// NewConfigReloadStatistics creates a tsdb config hot reload statistics.
+func NewConfigReloadStatistics() *ConfigReloadStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.tsdb.config")
+	return &ConfigReloadStatistics{
+		Version:        scope.NewGauge("version"),
+		Reloads:        scope.NewCounter("reloads"),
+		ReloadFailures: scope.NewCounter("reload_failures"),
+	}
+}
+
+// NewConsistencyCheckStatistics creates a consistency check statistics.
+func NewConsistencyCheckStatistics() *ConsistencyCheckStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.tsdb.consistency_check")
+	return &ConsistencyCheckStatistics{
+		Checks:        scope.NewCounter("checks"),
+		CheckFailures: scope.NewCounter("check_failures"),
+		ScanBytes:     scope.NewCounter("scan_bytes"),
+		Throttles:     scope.NewCounter("throttles"),
+		CheckDuration: scope.Scope("check_duration").NewHistogram(),
+	}
+}
+
+// NewIndexWarmupStatistics creates an index warm-up statistics.
+func NewIndexWarmupStatistics() *IndexWarmupStatistics {
+	scope := linmetric.StorageRegistry.NewScope("lindb.tsdb.index_warmup")
+	return &IndexWarmupStatistics{
+		WarmedMetrics:   scope.NewCounter("warmed_metrics"),
+		WarmFailures:    scope.NewCounter("warm_failures"),
+		ReadBytes:       scope.NewCounter("read_bytes"),
+		Interrupts:      scope.NewCounter("interrupts"),
+		PersistFailures: scope.NewCounter("persist_failures"),
 	}
 }
@@ -21,6 +21,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
 )
 
 func TestTSDBStatistics_New(t *testing.T) {
@@ -28,7 +31,89 @@ func TestTSDBStatistics_New(t *testing.T) {
 	assert.NotNil(t, NewMemDBStatistics("test"))
 	assert.NotNil(t, NewDatabaseStatistics("test"))
 	assert.NotNil(t, NewShardStatistics("test", "shard"))
-	assert.NotNil(t, NewFamilyStatistics("test", "shard"))
+	assert.NotNil(t, NewFamilyStatistics("test", "shard", "family"))
 	assert.NotNil(t, NewTagMetaStatistics("test"))
 	assert.NotNil(t, NewMetaDBStatistics("test"))
+	assert.NotNil(t, NewDeadLetterStatistics())
+	assert.NotNil(t, NewDiskWatchdogStatistics())
+	assert.NotNil(t, NewConfigReloadStatistics())
+	assert.NotNil(t, NewConsistencyCheckStatistics())
+	assert.NotNil(t, NewIndexWarmupStatistics())
+}
+
+func TestFamilyStatistics_Close(t *testing.T) {
+	fieldValues := func(fieldName string) (rs []float64) {
+		metrics := linmetric.StorageRegistry.FindMetricList([]string{"lindb.tsdb.shard"},
+			map[string]string{"db": "close", "shard": "1"})
+		for _, m := range metrics["lindb.tsdb.shard"] {
+			for _, f := range m.Fields {
+				if f.Name == fieldName {
+					rs = append(rs, f.Value)
+				}
+			}
+		}
+		return rs
+	}
+	f1 := NewFamilyStatistics("close", "1", "f1")
+	// reopen same family
+	f1Reopen := NewFamilyStatistics("close", "1", "f1")
+	f2 := NewFamilyStatistics("close", "1", "f2")
+	assert.Equal(t, []float64{2}, fieldValues("active_families"))
+	f1.WriteBatches.Incr()
+	f1Reopen.WriteBatches.Incr()
+	assert.Equal(t, []float64{2}, fieldValues("write_batches"))
+
+	f1.Close()
+	f1.Close()
+	assert.Equal(t, []float64{2}, fieldValues("active_families"))
+	f1Reopen.Close()
+	assert.Equal(t, []float64{1}, fieldValues("active_families"))
+	f2.Close()
+	assert.Empty(t, fieldValues("active_families"))
+	assert.Empty(t, fieldValues("write_batches"))
+
+	f3 := NewFamilyStatistics("close", "1", "f3")
+	f3.WriteBatches.Incr()
+	assert.Equal(t, []float64{1}, fieldValues("active_families"))
+	assert.Equal(t, []float64{1}, fieldValues("write_batches"))
+	f3.Close()
+	assert.Empty(t, fieldValues("active_families"))
+}
+
+func TestShardStatistics_Close(t *testing.T) {
+	series := func() []*models.StateMetric {
+		return linmetric.StorageRegistry.FindMetricList([]string{"lindb.tsdb.shard"},
+			map[string]string{"db": "shard-close", "shard": "1"})["lindb.tsdb.shard"]
+	}
+	lookupFailures := func() (rs []float64) {
+		for _, m := range series() {
+			for _, f := range m.Fields {
+				if f.Name == "lookup_metric_meta_failures" {
+					rs = append(rs, f.Value)
+				}
+			}
+		}
+		return rs
+	}
+	s1 := NewShardStatistics("shard-close", "1")
+	// reopen same shard, reuses the registered statistics
+	s1Reopen := NewShardStatistics("shard-close", "1")
+	s1.LookupMetricMetaFailures.Incr()
+	s1Reopen.LookupMetricMetaFailures.Incr()
+	assert.Len(t, series(), 1)
+	assert.Equal(t, []float64{2}, lookupFailures())
+
+	s1.Close()
+	s1.Close()
+	assert.Equal(t, []float64{2}, lookupFailures())
+	s1Reopen.Close()
+	assert.Empty(t, series())
+
+	// open -> close -> open
+	s2 := NewShardStatistics("shard-close", "1")
+	s2.LookupMetricMetaFailures.Incr()
+	assert.Len(t, series(), 1)
+	assert.Equal(t, []float64{1}, lookupFailures())
+	s2.Close()
+	assert.Empty(t, series())
 }
@@ -0,0 +1,175 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// minAlertRuleInterval is the minimum evaluation interval of alert rule.
+const minAlertRuleInterval = time.Second
+
+// AlertState represents the state of alert series.
+type AlertState string
+
+// Defines all states of alert series, inactive series is not kept.
+const (
+	AlertInactive AlertState = "inactive"
+	AlertPending  AlertState = "pending"
+	AlertFiring   AlertState = "firing"
+)
+
+// AlertRule represents the threshold rule which evaluates query periodically,
+// the series group matched condition for duration is firing, then notifies webhook.
+type AlertRule struct {
+	Name        string            `json:"name" validate:"required"`
+	SQL         string            `json:"sql" validate:"required"`       // query of rule, time range is set by each evaluation window
+	Field       string            `json:"field,omitempty"`               // field to check, first field of result if empty
+	Condition   string            `json:"condition" validate:"required"` // >, >=, <, <=, ==, !=
+	Threshold   float64           `json:"threshold"`
+	For         string            `json:"for,omitempty"`                // duration of condition matched before firing, like: 5m
+	Interval    string            `json:"interval" validate:"required"` // evaluation interval, like: 1m
+	Labels      map[string]string `json:"labels,omitempty"`             // labels attached to alerts
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Webhook     string            `json:"webhook" validate:"required"` // Alertmanager compatible webhook, like: http://alertmanager:9093/api/v2/alerts
+}
+
+// AlertRules represents the alert rules of database.
+type AlertRules struct {
+	Database string       `json:"database" validate:"required"`
+	Rules    []*AlertRule `json:"rules"`
+}
+
+// AlertSeriesState represents the state of series group which matches the condition of alert rule.
+type AlertSeriesState struct {
+	Tags     map[string]string `json:"tags,omitempty"`
+	State    AlertState        `json:"state"`
+	ActiveAt int64             `json:"activeAt"` // time of condition matched first
+	Value    float64           `json:"value"`
+}
+
+// AlertRuleState represents the evaluation state of alert rule, persisted for resuming after fail-over.
+type AlertRuleState struct {
+	LastEvaluated int64                        `json:"lastEvaluated"` // end time of last evaluated window
+	Series        map[string]*AlertSeriesState `json:"series,omitempty"`
+}
+
+// AlertTransition represents the state transition of alert series.
+type AlertTransition struct {
+	Timestamp int64             `json:"timestamp"`
+	ActiveAt  int64             `json:"activeAt"` // time of condition matched first
+	Tags      map[string]string `json:"tags,omitempty"`
+	From      AlertState        `json:"from"`
+	To        AlertState        `json:"to"`
+	Value     float64           `json:"value"`
+}
+
+// Alert represents the alert posted to webhook, compatible with Alertmanager api.
+type Alert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"` // zero if alert not resolved
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// AlertRuleDryRun represents the param of evaluating alert rule against historical data.
+type AlertRuleDryRun struct {
+	Database string     `json:"database" validate:"required"`
+	Rule     *AlertRule `json:"rule" validate:"required"`
+	Start    int64      `json:"start" validate:"required"`
+	End      int64      `json:"end" validate:"required"`
+}
+
+// Validate checks if the alert rules of database are valid.
+func (r *AlertRules) Validate() error {
+	names := make(map[string]struct{})
+	for _, rule := range r.Rules {
+		if rule == nil || rule.Name == "" {
+			return fmt.Errorf("alert rule name of database[%s] cannot be empty", r.Database)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return fmt.Errorf("duplicate alert rule[%s] of database[%s]", rule.Name, r.Database)
+		}
+		names[rule.Name] = struct{}{}
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks if the alert rule is valid.
+func (r *AlertRule) Validate() error {
+	if r.SQL == "" {
+		return fmt.Errorf("sql of alert rule[%s] cannot be empty", r.Name)
+	}
+	if _, err := r.Match(0); err != nil {
+		return err
+	}
+	interval, err := time.ParseDuration(r.Interval)
+	if err != nil || interval < minAlertRuleInterval || interval%time.Second != 0 {
+		return fmt.Errorf("interval of alert rule[%s] must be whole seconds and >= %s, but got: %s",
+			r.Name, minAlertRuleInterval, r.Interval)
+	}
+	if r.For != "" {
+		if duration, err := time.ParseDuration(r.For); err != nil || duration < 0 {
+			return fmt.Errorf("for duration of alert rule[%s] is invalid: %s", r.Name, r.For)
+		}
+	}
+	webhook, err := url.Parse(r.Webhook)
+	if err != nil || (webhook.Scheme != "http" && webhook.Scheme != "https") || webhook.Host == "" {
+		return fmt.Errorf("webhook of alert rule[%s] must be http(s) url, but got: %s", r.Name, r.Webhook)
+	}
+	return nil
+}
+
+// Match returns if the value matches the condition of rule.
+func (r *AlertRule) Match(value float64) (bool, error) {
+	switch r.Condition {
+	case ">":
+		return value > r.Threshold, nil
+	case ">=":
+		return value >= r.Threshold, nil
+	case "<":
+		return value < r.Threshold, nil
+	case "<=":
+		return value <= r.Threshold, nil
+	case "==":
+		return value == r.Threshold, nil
+	case "!=":
+		return value != r.Threshold, nil
+	default:
+		return false, fmt.Errorf("condition of alert rule[%s] must be one of >,>=,<,<=,==,!=, but got: %s",
+			r.Name, r.Condition)
+	}
+}
+
+// GetInterval returns the evaluation interval of rule, 0 if invalid.
+func (r *AlertRule) GetInterval() time.Duration {
+	interval, _ := time.ParseDuration(r.Interval)
+	return interval
+}
+
+// GetFor returns the duration of condition matched before firing, 0 if not set.
+func (r *AlertRule) GetFor() time.Duration {
+	duration, _ := time.ParseDuration(r.For)
+	return duration
+}
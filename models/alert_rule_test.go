@@ -0,0 +1,97 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertRules_Validate(t *testing.T) {
+	newRule := func(name string) *AlertRule {
+		return &AlertRule{Name: name, SQL: "select f from cpu", Condition: ">", Threshold: 10,
+			Interval: "1m", Webhook: "http://127.0.0.1:9093/api/v2/alerts"}
+	}
+	cases := []struct {
+		name    string
+		rule    func(rule *AlertRule)
+		rules   []*AlertRule
+		wantErr bool
+	}{
+		{name: "empty rules"},
+		{name: "valid rules", rules: []*AlertRule{newRule("r1"), newRule("r2")}},
+		{name: "empty name", rules: []*AlertRule{newRule("")}, wantErr: true},
+		{name: "nil rule", rules: []*AlertRule{nil}, wantErr: true},
+		{name: "duplicate name", rules: []*AlertRule{newRule("r1"), newRule("r1")}, wantErr: true},
+		{name: "empty sql", rule: func(rule *AlertRule) { rule.SQL = "" }, wantErr: true},
+		{name: "unknown condition", rule: func(rule *AlertRule) { rule.Condition = "=>" }, wantErr: true},
+		{name: "interval too small", rule: func(rule *AlertRule) { rule.Interval = "100ms" }, wantErr: true},
+		{name: "invalid for", rule: func(rule *AlertRule) { rule.For = "abc" }, wantErr: true},
+		{name: "negative for", rule: func(rule *AlertRule) { rule.For = "-1m" }, wantErr: true},
+		{name: "valid for", rule: func(rule *AlertRule) { rule.For = "5m" }},
+		{name: "invalid webhook", rule: func(rule *AlertRule) { rule.Webhook = "127.0.0.1:9093" }, wantErr: true},
+		{name: "empty webhook", rule: func(rule *AlertRule) { rule.Webhook = "" }, wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rules := &AlertRules{Database: "db", Rules: tt.rules}
+			if tt.rule != nil {
+				rule := newRule("r1")
+				tt.rule(rule)
+				rules.Rules = []*AlertRule{rule}
+			}
+			assert.Equal(t, tt.wantErr, rules.Validate() != nil)
+		})
+	}
+}
+
+func TestAlertRule_Match(t *testing.T) {
+	cases := []struct {
+		condition string
+		value     float64
+		match     bool
+	}{
+		{condition: ">", value: 11, match: true},
+		{condition: ">", value: 10},
+		{condition: ">=", value: 10, match: true},
+		{condition: "<", value: 9, match: true},
+		{condition: "<", value: 10},
+		{condition: "<=", value: 10, match: true},
+		{condition: "==", value: 10, match: true},
+		{condition: "!=", value: 10},
+	}
+	for _, tt := range cases {
+		rule := &AlertRule{Condition: tt.condition, Threshold: 10}
+		match, err := rule.Match(tt.value)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.match, match, tt.condition)
+	}
+	_, err := (&AlertRule{Condition: "x"}).Match(1)
+	assert.Error(t, err)
+}
+
+func TestAlertRule(t *testing.T) {
+	rule := &AlertRule{Interval: "1m"}
+	assert.Equal(t, time.Minute, rule.GetInterval())
+	assert.Zero(t, rule.GetFor())
+	rule.For = "5m"
+	assert.Equal(t, 5*time.Minute, rule.GetFor())
+}
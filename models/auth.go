@@ -0,0 +1,81 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import "fmt"
+
+// Permission represents the permission of principal on database.
+type Permission string
+
+const (
+	// PermissionRead represents query data/metadata of database.
+	PermissionRead Permission = "read"
+	// PermissionWrite represents write data into database.
+	PermissionWrite Permission = "write"
+	// PermissionAdmin represents manage database/cluster, implies read and write.
+	PermissionAdmin Permission = "admin"
+)
+
+// AllDatabases represents the permissions apply to all databases and cluster level operations.
+const AllDatabases = "*"
+
+// Principal represents the identity of authenticated request with the per-database permissions.
+type Principal struct {
+	Name string `json:"name" validate:"required"`
+	// database name(or * for all databases) => permissions
+	Permissions map[string][]Permission `json:"permissions"`
+}
+
+// Validate checks if the permissions of principal are valid.
+func (p *Principal) Validate() error {
+	for database, permissions := range p.Permissions {
+		if database == "" {
+			return fmt.Errorf("database of principal[%s]'s permission cannot be empty", p.Name)
+		}
+		for _, permission := range permissions {
+			switch permission {
+			case PermissionRead, PermissionWrite, PermissionAdmin:
+			default:
+				return fmt.Errorf("unknown permission[%s] of principal[%s] on database[%s]", permission, p.Name, database)
+			}
+		}
+	}
+	return nil
+}
+
+// HasPermission checks if principal has the permission on database,
+// empty database means cluster level operation which only granted by all databases(*) permissions.
+func (p *Principal) HasPermission(database string, permission Permission) bool {
+	if p.grant(AllDatabases, permission) {
+		return true
+	}
+	if database == "" || database == AllDatabases {
+		return false
+	}
+	return p.grant(database, permission)
+}
+
+// grant checks if the permission is granted on database.
+func (p *Principal) grant(database string, permission Permission) bool {
+	for _, granted := range p.Permissions[database] {
+		if granted == permission || granted == PermissionAdmin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_Validate(t *testing.T) {
+	assert.NoError(t, (&Principal{Name: "ops"}).Validate())
+	assert.NoError(t, (&Principal{Name: "ops", Permissions: map[string][]Permission{
+		"db":         {PermissionRead, PermissionWrite},
+		AllDatabases: {PermissionAdmin},
+	}}).Validate())
+	assert.Error(t, (&Principal{Name: "ops", Permissions: map[string][]Permission{
+		"": {PermissionRead},
+	}}).Validate())
+	assert.Error(t, (&Principal{Name: "ops", Permissions: map[string][]Permission{
+		"db": {"drop"},
+	}}).Validate())
+}
+
+func TestPrincipal_HasPermission(t *testing.T) {
+	reader := &Principal{Name: "reader", Permissions: map[string][]Permission{
+		"db": {PermissionRead},
+	}}
+	assert.True(t, reader.HasPermission("db", PermissionRead))
+	assert.False(t, reader.HasPermission("db", PermissionWrite))
+	assert.False(t, reader.HasPermission("db", PermissionAdmin))
+	assert.False(t, reader.HasPermission("other", PermissionRead))
+	assert.False(t, reader.HasPermission("", PermissionRead))
+
+	dbAdmin := &Principal{Name: "db-admin", Permissions: map[string][]Permission{
+		"db": {PermissionAdmin},
+	}}
+	assert.True(t, dbAdmin.HasPermission("db", PermissionRead))
+	assert.True(t, dbAdmin.HasPermission("db", PermissionWrite))
+	assert.True(t, dbAdmin.HasPermission("db", PermissionAdmin))
+	assert.False(t, dbAdmin.HasPermission("", PermissionAdmin))
+	assert.False(t, dbAdmin.HasPermission(AllDatabases, PermissionAdmin))
+
+	globalReader := &Principal{Name: "global-reader", Permissions: map[string][]Permission{
+		AllDatabases: {PermissionRead},
+	}}
+	assert.True(t, globalReader.HasPermission("db", PermissionRead))
+	assert.True(t, globalReader.HasPermission("", PermissionRead))
+	assert.False(t, globalReader.HasPermission("db", PermissionWrite))
+}
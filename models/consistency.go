@@ -0,0 +1,196 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"math"
+	"sort"
+)
+
+// digestSumTolerance is the relative tolerance of sum comparison, sum order of float values may differ between replicas.
+const digestSumTolerance = 1e-9
+
+// ConsistencyCheck represents the consistency check task of database submitted by master,
+// storage nodes compute the digests of closed family for each local shard replica.
+type ConsistencyCheck struct {
+	ID         string `json:"id"`
+	Database   string `json:"database"`
+	FamilyTime int64  `json:"familyTime"` // start time of family
+	CreatedAt  int64  `json:"createdAt"`
+}
+
+// MetricDigest represents the digest of metric data in family,
+// metric is identified by name because metric id is generated by each storage node.
+type MetricDigest struct {
+	Namespace string  `json:"namespace"`
+	Metric    string  `json:"metric"`
+	Series    uint64  `json:"series"`
+	Points    uint64  `json:"points"`
+	Sum       float64 `json:"sum"`
+	Hash      uint64  `json:"hash"` // hash of encoded field blocks
+}
+
+// ReplicaDigest represents the metric digests of family computed by storage node for a shard replica.
+type ReplicaDigest struct {
+	CheckID    string         `json:"checkId"`
+	Database   string         `json:"database"`
+	ShardID    ShardID        `json:"shardId"`
+	NodeID     NodeID         `json:"nodeId"`
+	FamilyTime int64          `json:"familyTime"`
+	Metrics    []MetricDigest `json:"metrics,omitempty"`
+	Error      string         `json:"error,omitempty"`
+	ComputedAt int64          `json:"computedAt"`
+}
+
+// MetricMismatch represents the metric whose digests diverge between replicas of shard.
+type MetricMismatch struct {
+	Namespace string                  `json:"namespace"`
+	Metric    string                  `json:"metric"`
+	Digests   map[NodeID]MetricDigest `json:"digests"` // missing if metric not found in replica
+}
+
+// ShardConsistency represents the consistency check result of shard.
+type ShardConsistency struct {
+	ShardID    ShardID           `json:"shardId"`
+	Replicas   []NodeID          `json:"replicas"`
+	Pending    []NodeID          `json:"pending,omitempty"` // replicas not reported digest
+	Failures   map[NodeID]string `json:"failures,omitempty"`
+	Mismatches []*MetricMismatch `json:"mismatches,omitempty"`
+}
+
+// ConsistencyReport represents the consistency check report of database.
+type ConsistencyReport struct {
+	Check      ConsistencyCheck    `json:"check"`
+	Completed  bool                `json:"completed"` // all replicas reported digest
+	Consistent bool                `json:"consistent"`
+	Shards     []*ShardConsistency `json:"shards"`
+}
+
+// BuildConsistencyReport compares the metric digests reported by replicas of each shard,
+// flags the metrics which diverge between replicas, digests not belong to check are ignored.
+func BuildConsistencyReport(check *ConsistencyCheck, assignment *ShardAssignment, digests []*ReplicaDigest) *ConsistencyReport {
+	report := &ConsistencyReport{Check: *check, Completed: true, Consistent: true}
+	shardDigests := make(map[ShardID]map[NodeID]*ReplicaDigest)
+	for _, digest := range digests {
+		if digest == nil || digest.CheckID != check.ID {
+			continue
+		}
+		replicas, ok := shardDigests[digest.ShardID]
+		if !ok {
+			replicas = make(map[NodeID]*ReplicaDigest)
+			shardDigests[digest.ShardID] = replicas
+		}
+		replicas[digest.NodeID] = digest
+	}
+	var shardIDs []ShardID
+	for shardID := range assignment.Shards {
+		shardIDs = append(shardIDs, shardID)
+	}
+	sort.Slice(shardIDs, func(i, j int) bool { return shardIDs[i] < shardIDs[j] })
+
+	for _, shardID := range shardIDs {
+		shard := &ShardConsistency{ShardID: shardID, Replicas: assignment.Shards[shardID].Replicas}
+		reported := make(map[NodeID]map[string]MetricDigest)
+		for _, nodeID := range shard.Replicas {
+			digest, ok := shardDigests[shardID][nodeID]
+			switch {
+			case !ok:
+				shard.Pending = append(shard.Pending, nodeID)
+			case digest.Error != "":
+				if shard.Failures == nil {
+					shard.Failures = make(map[NodeID]string)
+				}
+				shard.Failures[nodeID] = digest.Error
+			default:
+				metrics := make(map[string]MetricDigest, len(digest.Metrics))
+				for _, m := range digest.Metrics {
+					metrics[metricDigestKey(m.Namespace, m.Metric)] = m
+				}
+				reported[nodeID] = metrics
+			}
+		}
+		shard.Mismatches = compareMetricDigests(shard.Replicas, reported)
+		if len(shard.Pending) > 0 {
+			report.Completed = false
+		}
+		if len(shard.Failures) > 0 || len(shard.Mismatches) > 0 {
+			report.Consistent = false
+		}
+		report.Shards = append(report.Shards, shard)
+	}
+	return report
+}
+
+// compareMetricDigests compares the metric digests between replicas which reported digest.
+func compareMetricDigests(replicas []NodeID, reported map[NodeID]map[string]MetricDigest) (mismatches []*MetricMismatch) {
+	if len(reported) < 2 {
+		return nil
+	}
+	keys := make(map[string]MetricDigest)
+	for _, metrics := range reported {
+		for key, m := range metrics {
+			keys[key] = m
+		}
+	}
+	for key, m := range keys {
+		var (
+			first   *MetricDigest
+			diverge bool
+		)
+		digests := make(map[NodeID]MetricDigest)
+		for _, nodeID := range replicas {
+			metrics, ok := reported[nodeID]
+			if !ok {
+				continue
+			}
+			digest, ok := metrics[key]
+			if !ok {
+				// metric missing in replica
+				diverge = true
+				continue
+			}
+			digests[nodeID] = digest
+			if first == nil {
+				first = &digest
+			} else if !digest.equals(first) {
+				diverge = true
+			}
+		}
+		if diverge {
+			mismatches = append(mismatches, &MetricMismatch{Namespace: m.Namespace, Metric: m.Metric, Digests: digests})
+		}
+	}
+	sort.Slice(mismatches, func(i, j int) bool {
+		return metricDigestKey(mismatches[i].Namespace, mismatches[i].Metric) <
+			metricDigestKey(mismatches[j].Namespace, mismatches[j].Metric)
+	})
+	return mismatches
+}
+
+// equals returns if the data digest is same.
+func (d *MetricDigest) equals(o *MetricDigest) bool {
+	if d.Series != o.Series || d.Points != o.Points || d.Hash != o.Hash {
+		return false
+	}
+	return math.Abs(d.Sum-o.Sum) <= digestSumTolerance*math.Max(math.Abs(d.Sum), math.Abs(o.Sum))
+}
+
+// metricDigestKey returns the key of metric digest.
+func metricDigestKey(namespace, metricName string) string {
+	return namespace + "/" + metricName
+}
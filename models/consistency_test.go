@@ -0,0 +1,107 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildConsistencyReport(t *testing.T) {
+	check := &ConsistencyCheck{ID: "check", Database: "db", FamilyTime: 10}
+	assignment := &ShardAssignment{Name: "db", Shards: map[ShardID]*Replica{
+		1: {Replicas: []NodeID{1, 2}},
+		2: {Replicas: []NodeID{1, 2, 3}},
+	}}
+	cpu := MetricDigest{Namespace: "ns", Metric: "cpu", Series: 2, Points: 10, Sum: 1000, Hash: 100}
+	mem := MetricDigest{Namespace: "ns", Metric: "mem", Series: 1, Points: 5, Sum: 50, Hash: 200}
+	cpuWithError := cpu
+	cpuWithError.Sum = 1000 + 1e-10 // float sum tolerance
+	cpuDiff := cpu
+	cpuDiff.Hash = 101
+
+	cases := []struct {
+		name    string
+		digests []*ReplicaDigest
+		assert  func(report *ConsistencyReport)
+	}{
+		{
+			name: "no digest reported",
+			assert: func(report *ConsistencyReport) {
+				assert.False(t, report.Completed)
+				assert.True(t, report.Consistent)
+				assert.Len(t, report.Shards, 2)
+				assert.Equal(t, ShardID(1), report.Shards[0].ShardID)
+				assert.Equal(t, []NodeID{1, 2}, report.Shards[0].Pending)
+				assert.Equal(t, []NodeID{1, 2, 3}, report.Shards[1].Pending)
+			},
+		},
+		{
+			name: "replicas consistent",
+			digests: []*ReplicaDigest{
+				nil,
+				{CheckID: "old", ShardID: 1, NodeID: 1, Metrics: []MetricDigest{cpuDiff}},
+				{CheckID: "check", ShardID: 1, NodeID: 1, Metrics: []MetricDigest{cpu, mem}},
+				{CheckID: "check", ShardID: 1, NodeID: 2, Metrics: []MetricDigest{mem, cpuWithError}},
+				{CheckID: "check", ShardID: 2, NodeID: 1},
+				{CheckID: "check", ShardID: 2, NodeID: 2},
+				{CheckID: "check", ShardID: 2, NodeID: 3},
+			},
+			assert: func(report *ConsistencyReport) {
+				assert.True(t, report.Completed)
+				assert.True(t, report.Consistent)
+				for _, shard := range report.Shards {
+					assert.Empty(t, shard.Pending)
+					assert.Empty(t, shard.Failures)
+					assert.Empty(t, shard.Mismatches)
+				}
+			},
+		},
+		{
+			name: "replicas diverge",
+			digests: []*ReplicaDigest{
+				{CheckID: "check", ShardID: 1, NodeID: 1, Metrics: []MetricDigest{cpu, mem}},
+				{CheckID: "check", ShardID: 1, NodeID: 2, Metrics: []MetricDigest{cpuDiff}},
+				{CheckID: "check", ShardID: 2, NodeID: 1, Metrics: []MetricDigest{cpu}},
+				{CheckID: "check", ShardID: 2, NodeID: 2, Error: "family not closed"},
+			},
+			assert: func(report *ConsistencyReport) {
+				assert.False(t, report.Completed)
+				assert.False(t, report.Consistent)
+				mismatches := report.Shards[0].Mismatches
+				assert.Len(t, mismatches, 2)
+				assert.Equal(t, "cpu", mismatches[0].Metric)
+				assert.Equal(t, map[NodeID]MetricDigest{1: cpu, 2: cpuDiff}, mismatches[0].Digests)
+				assert.Equal(t, "mem", mismatches[1].Metric)
+				assert.Equal(t, map[NodeID]MetricDigest{1: mem}, mismatches[1].Digests)
+				assert.Equal(t, map[NodeID]string{2: "family not closed"}, report.Shards[1].Failures)
+				assert.Equal(t, []NodeID{3}, report.Shards[1].Pending)
+				assert.Empty(t, report.Shards[1].Mismatches)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			report := BuildConsistencyReport(check, assignment, tt.digests)
+			assert.Equal(t, *check, report.Check)
+			tt.assert(report)
+		})
+	}
+}
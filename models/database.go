@@ -20,10 +20,12 @@ package models
 import (
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 
 	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 // DatabaseNames represents the database name list.
@@ -101,6 +103,7 @@ type Database struct {
 	NumOfShard    int                    `json:"numOfShard" validate:"gt=0"`    // num. of shard
 	ReplicaFactor int                    `json:"replicaFactor" validate:"gt=0"` // replica refactor
 	Option        *option.DatabaseOption `json:"option"`                        // time series database option
+	Deletion      *DatabaseDeletion      `json:"deletion,omitempty"`            // pending deletion state if dropped
 	Desc          string                 `json:"desc,omitempty"`
 }
 
@@ -109,9 +112,40 @@ func (db *Database) String() string {
 	result := "create database " + db.Name + " with "
 	result += "shard " + fmt.Sprintf("%d", db.NumOfShard) + ", replica " + fmt.Sprintf("%d", db.ReplicaFactor)
 	result += ", intervals " + db.Option.Intervals.String()
+	if db.IsDeleted() {
+		result += ", scheduled for deletion at " + timeutil.FormatTimestamp(db.Deletion.PurgeAt, timeutil.DataTimeFormat2)
+	}
 	return result
 }
 
+// IsDeleted returns if database is dropped, which is pending deletion within grace period.
+func (db *Database) IsDeleted() bool {
+	return db.Deletion != nil
+}
+
+// SetGraceRemaining sets the remaining grace time of dropped database for display.
+func (db *Database) SetGraceRemaining(now int64) {
+	if db.IsDeleted() {
+		db.Deletion.GraceRemaining = db.Deletion.Remaining(now).String()
+	}
+}
+
+// DatabaseDeletion represents the pending deletion state of dropped database,
+// data of database is kept and can be restored before purged.
+type DatabaseDeletion struct {
+	DeletedAt      int64  `json:"deletedAt"`                // timestamp(ms) when database is dropped
+	PurgeAt        int64  `json:"purgeAt"`                  // timestamp(ms) when data of database is removed
+	GraceRemaining string `json:"graceRemaining,omitempty"` // remaining grace time before purged, only for display
+}
+
+// Remaining returns the remaining grace time before purged.
+func (d *DatabaseDeletion) Remaining(now int64) time.Duration {
+	if now >= d.PurgeAt {
+		return 0
+	}
+	return time.Duration(d.PurgeAt-now) * time.Millisecond
+}
+
 type DatabaseAssignment struct {
 	ShardAssignment *ShardAssignment       `json:"shardAssignment"`
 	Option          *option.DatabaseOption `json:"option"`
@@ -132,10 +166,20 @@ func (r Replica) Contain(nodeID NodeID) bool {
 	return false
 }
 
+// ShardSplit defines the split of hot shard, part of source shard's key space is routed to target shard
+// for future writes, historical data stays queryable on source shard.
+type ShardSplit struct {
+	Source    ShardID `json:"source"`
+	Target    ShardID `json:"target"`
+	CreatedAt int64   `json:"createdAt"`
+}
+
 // ShardAssignment defines shard assignment for database.
 type ShardAssignment struct {
 	Name   string               `json:"name"` // database's name
 	Shards map[ShardID]*Replica `json:"shards"`
+	// Splits are the splits of hot shards in creation order, target shards are not picked by jump consistent hash.
+	Splits []ShardSplit `json:"splits,omitempty"`
 
 	replicaFactor int // for storage recover
 }
@@ -164,6 +208,32 @@ func (s *ShardAssignment) AddReplica(shardID ShardID, replicaID NodeID) {
 	}
 }
 
+// NumOfBaseShards returns the num. of shards picked by jump consistent hash, excludes the target shards of splits.
+func (s *ShardAssignment) NumOfBaseShards() int {
+	return len(s.Shards) - len(s.Splits)
+}
+
+// GetSplit returns the split which creates the target shard.
+func (s *ShardAssignment) GetSplit(shardID ShardID) (*ShardSplit, bool) {
+	for idx := range s.Splits {
+		if s.Splits[idx].Target == shardID {
+			return &s.Splits[idx], true
+		}
+	}
+	return nil, false
+}
+
+// NextShardID returns the id for new shard.
+func (s *ShardAssignment) NextShardID() ShardID {
+	next := ShardID(0)
+	for shardID := range s.Shards {
+		if shardID >= next {
+			next = shardID + 1
+		}
+	}
+	return next
+}
+
 // GetReplicaFactor returns the factor of replica.
 func (s *ShardAssignment) GetReplicaFactor() int {
 	return s.replicaFactor
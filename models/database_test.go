@@ -19,6 +19,7 @@ package models
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -39,6 +40,24 @@ func TestNewShardAssignment(t *testing.T) {
 	assert.Equal(t, 3, shardAssign.GetReplicaFactor())
 }
 
+func TestShardAssignment_Splits(t *testing.T) {
+	shardAssign := NewShardAssignment("test")
+	shardAssign.AddReplica(0, 1)
+	shardAssign.AddReplica(1, 2)
+	assert.Equal(t, 2, shardAssign.NumOfBaseShards())
+	assert.Equal(t, ShardID(2), shardAssign.NextShardID())
+	_, ok := shardAssign.GetSplit(2)
+	assert.False(t, ok)
+
+	shardAssign.AddReplica(2, 1)
+	shardAssign.Splits = append(shardAssign.Splits, ShardSplit{Source: 1, Target: 2})
+	assert.Equal(t, 2, shardAssign.NumOfBaseShards())
+	assert.Equal(t, ShardID(3), shardAssign.NextShardID())
+	split, ok := shardAssign.GetSplit(2)
+	assert.True(t, ok)
+	assert.Equal(t, ShardID(1), split.Source)
+}
+
 func TestDatabase_String(t *testing.T) {
 	database := Database{
 		Name:          "test",
@@ -51,6 +70,28 @@ func TestDatabase_String(t *testing.T) {
 			}},
 	}
 	assert.Equal(t, "create database test with shard 10, replica 1, intervals [10s->1M,10m->1M]", database.String())
+	assert.False(t, database.IsDeleted())
+
+	now, _ := timeutil.ParseTimestamp("2023-01-01 00:00:00")
+	database.Deletion = &DatabaseDeletion{DeletedAt: now, PurgeAt: now + timeutil.OneHour}
+	assert.True(t, database.IsDeleted())
+	assert.Equal(t, "create database test with shard 10, replica 1, intervals [10s->1M,10m->1M], "+
+		"scheduled for deletion at 2023-01-01 01:00:00", database.String())
+}
+
+func TestDatabaseDeletion_Remaining(t *testing.T) {
+	deletion := &DatabaseDeletion{DeletedAt: 1000, PurgeAt: 1000 + timeutil.OneMinute}
+	assert.Equal(t, time.Minute, deletion.Remaining(1000))
+	assert.Equal(t, 30*time.Second, deletion.Remaining(1000+30*timeutil.OneSecond))
+	assert.Zero(t, deletion.Remaining(1000+timeutil.OneMinute))
+	assert.Zero(t, deletion.Remaining(1000+timeutil.OneHour))
+
+	database := &Database{}
+	database.SetGraceRemaining(1000)
+	assert.Nil(t, database.Deletion)
+	database.Deletion = deletion
+	database.SetGraceRemaining(1000 + 30*timeutil.OneSecond)
+	assert.Equal(t, "30s", deletion.GraceRemaining)
 }
 
 func TestParseShardID(t *testing.T) {
@@ -0,0 +1,28 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+// DeadLetter represents the row rejected by storage write path, kept for inspection and replay.
+type DeadLetter struct {
+	Seq        int64  `json:"seq,omitempty"` // sequence in dead-letter queue
+	Database   string `json:"database"`
+	Family     string `json:"family"`
+	Reason     string `json:"reason"`
+	RejectedAt int64  `json:"rejectedAt"`
+	Row        []byte `json:"row"` // flat metric row
+}
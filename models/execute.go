@@ -17,8 +17,92 @@
 
 package models
 
+import (
+	"strings"
+
+	"github.com/lindb/lindb/pkg/option"
+)
+
 // ExecuteParam represents lin query language executor's param.
 type ExecuteParam struct {
 	Database string `form:"db" json:"db"`
 	SQL      string `form:"sql" json:"sql" binding:"required"`
+	// Databases queries multiple databases in one statement(split by comma), overrides Database if set.
+	Databases string `form:"databases" json:"databases,omitempty"`
+	// MergeDatabases aggregates series across databases, else keeps series of each database with _db tag.
+	MergeDatabases bool `form:"mergeDatabases" json:"mergeDatabases,omitempty"`
+	// MergeMetrics aggregates series across metrics matched by metric name pattern, else keeps series of each metric with _metric tag.
+	MergeMetrics bool `form:"mergeMetrics" json:"mergeMetrics,omitempty"`
+	// Limit overrides the result limit of metadata query if set.
+	Limit int `form:"limit" json:"limit"`
+	// Cursor queries the next page of metadata query(show metrics/tag keys), returned by previous page.
+	Cursor string `form:"cursor" json:"cursor,omitempty"`
+	// Timeout overrides the default query timeout of database if set(like 10s/1m).
+	Timeout string `form:"timeout" json:"timeout"`
+	// Cache=false bypasses the query result cache of broker.
+	Cache *bool `form:"cache" json:"cache,omitempty"`
+	// ReplicaPolicy overrides the replica selection policy of database if set(leader-only/failover/hedged).
+	ReplicaPolicy string `form:"replicaPolicy" json:"replicaPolicy,omitempty"`
+	// ReadConsistency=leader queries the leader of shard only and bypasses result cache(read-your-writes),
+	// reports the max data timestamp of each shard in stats of result set.
+	ReadConsistency string `form:"readConsistency" json:"readConsistency,omitempty"`
+	// TimeZone computes group by time buckets in the time zone if set(like Asia/Shanghai),
+	// the tz clause of statement takes precedence over it.
+	TimeZone string `form:"timezone" json:"timezone,omitempty"`
+	// ClampRetention=false disables clamping the time range of query to the retention of database,
+	// the time range out of retention is queried as empty instead.
+	ClampRetention *bool `form:"clampRetention" json:"clampRetention,omitempty"`
+	// Partial returns the data of succeeded storage nodes with partial flag if some nodes fail(after retrying replicas),
+	// instead of failing the whole query.
+	Partial bool `form:"partial" json:"partial,omitempty"`
+	// MemoryBudget overrides the memory budget of grouped series buffered by broker if set(like 512MiB).
+	MemoryBudget string `form:"memoryBudget" json:"memoryBudget,omitempty"`
+	// Strictness overrides the query strictness of database if set(lenient/strict),
+	// strict fails the query referencing unknown metric/fields with close-match suggestions.
+	Strictness string `form:"strictness" json:"strictness,omitempty"`
+
+	// Client is the address of client which sends the query(set by http layer).
+	Client string `form:"-" json:"-"`
+
+	// Stream emits series of metric data query one by one if set(negotiated by http layer).
+	Stream ResultStream `form:"-" json:"-"`
+}
+
+// DatabaseNames returns the names of databases which the query executes against(deduped, in order).
+func (p *ExecuteParam) DatabaseNames() (names []string) {
+	if strings.TrimSpace(p.Databases) == "" {
+		if p.Database == "" {
+			return nil
+		}
+		return []string{p.Database}
+	}
+	exist := make(map[string]struct{})
+	for _, name := range strings.Split(p.Databases, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if _, ok := exist[name]; ok {
+			continue
+		}
+		exist[name] = struct{}{}
+		names = append(names, name)
+	}
+	return names
+}
+
+// UseCache returns if the query result cache can be used, default true,
+// result cache is bypassed for leader read consistency.
+func (p *ExecuteParam) UseCache() bool {
+	return (p.Cache == nil || *p.Cache) && !p.ReadLeader()
+}
+
+// ClampTimeRange returns if the time range of query is clamped to the retention of database, default true.
+func (p *ExecuteParam) ClampTimeRange() bool {
+	return p.ClampRetention == nil || *p.ClampRetention
+}
+
+// ReadLeader returns if the query requires leader read consistency.
+func (p *ExecuteParam) ReadLeader() bool {
+	return option.ReadConsistency(p.ReadConsistency) == option.ReadConsistencyLeader
 }
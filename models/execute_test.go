@@ -0,0 +1,44 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecuteParam_DatabaseNames(t *testing.T) {
+	assert.Nil(t, (&ExecuteParam{}).DatabaseNames())
+	assert.Equal(t, []string{"db"}, (&ExecuteParam{Database: "db"}).DatabaseNames())
+	assert.Equal(t, []string{"db1", "db2"}, (&ExecuteParam{Database: "db", Databases: "db1, db2,,db1"}).DatabaseNames())
+}
+
+func TestExecuteParam_ClampTimeRange(t *testing.T) {
+	noClamp := false
+	assert.True(t, (&ExecuteParam{}).ClampTimeRange())
+	assert.False(t, (&ExecuteParam{ClampRetention: &noClamp}).ClampTimeRange())
+}
+
+func TestExecuteParam_UseCache(t *testing.T) {
+	noCache := false
+	assert.True(t, (&ExecuteParam{}).UseCache())
+	assert.False(t, (&ExecuteParam{Cache: &noCache}).UseCache())
+	assert.False(t, (&ExecuteParam{ReadConsistency: "leader"}).UseCache())
+	assert.True(t, (&ExecuteParam{ReadConsistency: "default"}).UseCache())
+}
@@ -0,0 +1,165 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+
+	commonconstants "github.com/lindb/common/constants"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// FieldAlias represents the rename of metric's field, the query for old field name
+// reads the new field for time range after renamed and the old field before it.
+type FieldAlias struct {
+	Namespace string `json:"namespace,omitempty"` // default-ns if empty
+	Metric    string `json:"metric" validate:"required"`
+	From      string `json:"from" validate:"required"` // old field name
+	To        string `json:"to" validate:"required"`   // new field name
+	Since     int64  `json:"since"`                    // rename timestamp(ms)
+}
+
+// GetNamespace returns the namespace of metric, default-ns if empty.
+func (a *FieldAlias) GetNamespace() string {
+	if a.Namespace == "" {
+		return commonconstants.DefaultNamespace
+	}
+	return a.Namespace
+}
+
+// String returns the description of field alias, like: usage->used(since 2022-01-01 00:00:00).
+func (a *FieldAlias) String() string {
+	return fmt.Sprintf("%s->%s(since %s)", a.From, a.To, timeutil.FormatTimestamp(a.Since, timeutil.DataTimeFormat2))
+}
+
+// DatabaseFieldAlias represents the field aliases of database, writes are not affected by aliases.
+type DatabaseFieldAlias struct {
+	Database string       `json:"database" validate:"required"`
+	Aliases  []FieldAlias `json:"aliases"`
+}
+
+// FieldSegment represents the field which stores the data of spec time range for queried field.
+type FieldSegment struct {
+	Field     string             `json:"field"`
+	TimeRange timeutil.TimeRange `json:"timeRange"`
+	Alias     *FieldAlias        `json:"alias,omitempty"` // alias applied for the last rename, nil if not renamed
+}
+
+// Validate checks if the field aliases of database are valid, rejects circular alias definitions.
+func (d *DatabaseFieldAlias) Validate() error {
+	renames := make(map[string]string) // ns:metric:from => to
+	for idx := range d.Aliases {
+		alias := &d.Aliases[idx]
+		if alias.Metric == "" {
+			return fmt.Errorf("metric name of database[%s]'s field alias cannot be empty", d.Database)
+		}
+		if alias.From == "" || alias.To == "" {
+			return fmt.Errorf("field name of metric[%s]'s alias cannot be empty", alias.Metric)
+		}
+		if alias.From == alias.To {
+			return fmt.Errorf("field[%s] of metric[%s] cannot alias to itself", alias.From, alias.Metric)
+		}
+		key := fieldAliasKey(alias.GetNamespace(), alias.Metric, alias.From)
+		if _, ok := renames[key]; ok {
+			return fmt.Errorf("duplicate alias of field[%s], metric[%s]", alias.From, alias.Metric)
+		}
+		renames[key] = alias.To
+	}
+	for idx := range d.Aliases {
+		alias := &d.Aliases[idx]
+		prefix := fieldAliasKey(alias.GetNamespace(), alias.Metric, "")
+		visited := map[string]struct{}{alias.From: {}}
+		for name, ok := renames[prefix+alias.From]; ok; name, ok = renames[prefix+name] {
+			if _, circular := visited[name]; circular {
+				return fmt.Errorf("circular alias of field[%s], metric[%s]", alias.From, alias.Metric)
+			}
+			visited[name] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// Resolve returns the fields which store the data of queried field in time order,
+// returns the field itself for whole time range if it is not renamed.
+func (d *DatabaseFieldAlias) Resolve(namespace, metricName, fieldName string, timeRange timeutil.TimeRange) []FieldSegment {
+	if namespace == "" {
+		namespace = commonconstants.DefaultNamespace
+	}
+	aliases := make(map[string]*FieldAlias)
+	for idx := range d.Aliases {
+		alias := &d.Aliases[idx]
+		if alias.GetNamespace() == namespace && alias.Metric == metricName {
+			aliases[alias.From] = alias
+		}
+	}
+	// collect rename timestamps of alias chain in time range
+	var boundaries []int64
+	visited := make(map[string]struct{})
+	for alias, ok := aliases[fieldName]; ok; alias, ok = aliases[alias.To] {
+		if _, circular := visited[alias.From]; circular {
+			break
+		}
+		visited[alias.From] = struct{}{}
+		if alias.Since > timeRange.Start && alias.Since <= timeRange.End {
+			boundaries = append(boundaries, alias.Since)
+		}
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+	boundaries = append(boundaries, timeRange.End+1)
+
+	var segments []FieldSegment
+	start := timeRange.Start
+	for _, end := range boundaries {
+		if end <= start {
+			continue
+		}
+		name, alias := resolveFieldAlias(aliases, fieldName, start)
+		if n := len(segments); n > 0 && segments[n-1].Field == name {
+			segments[n-1].TimeRange.End = end - 1
+		} else {
+			segments = append(segments, FieldSegment{
+				Field:     name,
+				TimeRange: timeutil.TimeRange{Start: start, End: end - 1},
+				Alias:     alias,
+			})
+		}
+		start = end
+	}
+	return segments
+}
+
+// resolveFieldAlias returns the field which stores the data of queried field at spec timestamp.
+func resolveFieldAlias(aliases map[string]*FieldAlias, fieldName string, timestamp int64) (name string, applied *FieldAlias) {
+	name = fieldName
+	for i := 0; i <= len(aliases); i++ {
+		alias, ok := aliases[name]
+		if !ok || timestamp < alias.Since {
+			break
+		}
+		name = alias.To
+		applied = alias
+	}
+	return name, applied
+}
+
+// fieldAliasKey returns the key of field alias.
+func fieldAliasKey(namespace, metricName, fieldName string) string {
+	return namespace + ":" + metricName + ":" + fieldName
+}
@@ -0,0 +1,161 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+func TestDatabaseFieldAlias_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		aliases []FieldAlias
+		wantErr bool
+	}{
+		{
+			name: "empty aliases",
+		},
+		{
+			name: "valid aliases",
+			aliases: []FieldAlias{
+				{Metric: "cpu", From: "usage", To: "used", Since: 10},
+				{Metric: "cpu", From: "used", To: "util", Since: 20},
+				{Namespace: "ns", Metric: "cpu", From: "util", To: "usage", Since: 20},
+			},
+		},
+		{
+			name:    "empty metric name",
+			aliases: []FieldAlias{{From: "usage", To: "used"}},
+			wantErr: true,
+		},
+		{
+			name:    "empty field name",
+			aliases: []FieldAlias{{Metric: "cpu", From: "usage"}},
+			wantErr: true,
+		},
+		{
+			name:    "alias to itself",
+			aliases: []FieldAlias{{Metric: "cpu", From: "usage", To: "usage"}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate alias",
+			aliases: []FieldAlias{
+				{Metric: "cpu", From: "usage", To: "used"},
+				{Namespace: "default-ns", Metric: "cpu", From: "usage", To: "util"},
+			},
+			wantErr: true,
+		},
+		{
+			name: "circular alias",
+			aliases: []FieldAlias{
+				{Metric: "cpu", From: "usage", To: "used"},
+				{Metric: "cpu", From: "used", To: "util"},
+				{Metric: "cpu", From: "util", To: "usage"},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			alias := &DatabaseFieldAlias{Database: "db", Aliases: tt.aliases}
+			err := alias.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatabaseFieldAlias_Resolve(t *testing.T) {
+	alias := &DatabaseFieldAlias{Database: "db", Aliases: []FieldAlias{
+		{Metric: "cpu", From: "usage", To: "used", Since: 100},
+		{Metric: "cpu", From: "used", To: "util", Since: 200},
+		{Namespace: "ns", Metric: "cpu", From: "load", To: "load1", Since: 100},
+	}}
+	usage, used := &alias.Aliases[0], &alias.Aliases[1]
+	cases := []struct {
+		name      string
+		namespace string
+		field     string
+		timeRange timeutil.TimeRange
+		segments  []FieldSegment
+	}{
+		{
+			name:      "field not renamed",
+			field:     "idle",
+			timeRange: timeutil.TimeRange{Start: 0, End: 300},
+			segments:  []FieldSegment{{Field: "idle", TimeRange: timeutil.TimeRange{Start: 0, End: 300}}},
+		},
+		{
+			name:      "alias of other namespace",
+			field:     "load",
+			timeRange: timeutil.TimeRange{Start: 0, End: 300},
+			segments:  []FieldSegment{{Field: "load", TimeRange: timeutil.TimeRange{Start: 0, End: 300}}},
+		},
+		{
+			name:      "before renamed",
+			field:     "usage",
+			timeRange: timeutil.TimeRange{Start: 0, End: 99},
+			segments:  []FieldSegment{{Field: "usage", TimeRange: timeutil.TimeRange{Start: 0, End: 99}}},
+		},
+		{
+			name:      "after renamed",
+			namespace: "default-ns",
+			field:     "usage",
+			timeRange: timeutil.TimeRange{Start: 100, End: 150},
+			segments:  []FieldSegment{{Field: "used", TimeRange: timeutil.TimeRange{Start: 100, End: 150}, Alias: usage}},
+		},
+		{
+			name:      "across alias chain",
+			field:     "usage",
+			timeRange: timeutil.TimeRange{Start: 50, End: 300},
+			segments: []FieldSegment{
+				{Field: "usage", TimeRange: timeutil.TimeRange{Start: 50, End: 99}},
+				{Field: "used", TimeRange: timeutil.TimeRange{Start: 100, End: 199}, Alias: usage},
+				{Field: "util", TimeRange: timeutil.TimeRange{Start: 200, End: 300}, Alias: used},
+			},
+		},
+		{
+			name:      "query middle field of chain",
+			field:     "used",
+			timeRange: timeutil.TimeRange{Start: 50, End: 300},
+			segments: []FieldSegment{
+				{Field: "used", TimeRange: timeutil.TimeRange{Start: 50, End: 199}},
+				{Field: "util", TimeRange: timeutil.TimeRange{Start: 200, End: 300}, Alias: used},
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.segments, alias.Resolve(tt.namespace, "cpu", tt.field, tt.timeRange))
+		})
+	}
+}
+
+func TestFieldAlias_String(t *testing.T) {
+	alias := &FieldAlias{Metric: "cpu", From: "usage", To: "used"}
+	assert.Contains(t, alias.String(), "usage->used(since ")
+	assert.Equal(t, "default-ns", alias.GetNamespace())
+}
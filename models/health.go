@@ -0,0 +1,97 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+// ReadinessCheck represents the result of one readiness check.
+type ReadinessCheck struct {
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+// Readiness represents the readiness of node, includes the result of each check.
+// Warm is true if the index of node warmed up after restart, load balancer can prefer warm replicas,
+// it does not affect the readiness of node.
+type Readiness struct {
+	Ready  bool             `json:"ready"`
+	Warm   bool             `json:"warm"`
+	WarmUp *WarmUpProgress  `json:"warmUp,omitempty"`
+	Checks []ReadinessCheck `json:"checks"`
+}
+
+// NewReadiness creates the readiness based on the result of checks.
+func NewReadiness(checks []ReadinessCheck) *Readiness {
+	r := &Readiness{Ready: true, Checks: checks}
+	for _, check := range checks {
+		if !check.Ready {
+			r.Ready = false
+			break
+		}
+	}
+	return r
+}
+
+// Defines the phases of storage node recovery after startup.
+const (
+	// RecoveryPhaseShard opens the shards of all databases.
+	RecoveryPhaseShard = "shard"
+	// RecoveryPhaseFamily opens the segments and the families of writable interval.
+	RecoveryPhaseFamily = "family"
+	// RecoveryPhaseDone means recovery completed.
+	RecoveryPhaseDone = "done"
+)
+
+// RecoveryProgress represents the progress of storage node recovery after startup,
+// Opened/Total counts the shards in shard phase and the segments in family phase.
+type RecoveryProgress struct {
+	Phase       string   `json:"phase"`
+	Opened      int      `json:"opened"`
+	Total       int      `json:"total"`
+	Families    int      `json:"families"`
+	Quarantined []string `json:"quarantined,omitempty"`
+	Err         string   `json:"err,omitempty"`
+}
+
+// Defines the states of index warm-up after storage node restart.
+const (
+	// WarmUpDisabled means index warm-up not enabled.
+	WarmUpDisabled = "disabled"
+	// WarmUpPending means waiting for recovery completed.
+	WarmUpPending = "pending"
+	// WarmUpRunning means warming up the index of most queried metrics.
+	WarmUpRunning = "running"
+	// WarmUpDone means warm-up completed.
+	WarmUpDone = "done"
+	// WarmUpInterrupted means warm-up stopped because of foreground queries.
+	WarmUpInterrupted = "interrupted"
+)
+
+// WarmUpProgress represents the progress of index warm-up after storage node restart,
+// Warmed/Total counts the most queried metrics, Bytes is the size of index/blocks touched.
+type WarmUpProgress struct {
+	State  string `json:"state"`
+	Warmed int    `json:"warmed"`
+	Total  int    `json:"total"`
+	Bytes  int64  `json:"bytes"`
+}
+
+// IsWarm returns if warm-up is not in progress, the caches are warmed by foreground queries
+// if interrupted, and there is nothing to wait for if disabled.
+func (p *WarmUpProgress) IsWarm() bool {
+	return p.State != WarmUpPending && p.State != WarmUpRunning
+}
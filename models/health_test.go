@@ -0,0 +1,45 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewReadiness(t *testing.T) {
+	assert.True(t, NewReadiness(nil).Ready)
+	assert.True(t, NewReadiness([]ReadinessCheck{{Name: "a", Ready: true}}).Ready)
+	r := NewReadiness([]ReadinessCheck{{Name: "a", Ready: true}, {Name: "b", Message: "err"}})
+	assert.False(t, r.Ready)
+	assert.Len(t, r.Checks, 2)
+}
+
+func TestWarmUpProgress_IsWarm(t *testing.T) {
+	for state, warm := range map[string]bool{
+		WarmUpDisabled:    true,
+		WarmUpPending:     false,
+		WarmUpRunning:     false,
+		WarmUpDone:        true,
+		WarmUpInterrupted: true,
+	} {
+		p := &WarmUpProgress{State: state}
+		assert.Equal(t, warm, p.IsWarm(), state)
+	}
+}
@@ -18,15 +18,59 @@
 package models
 
 import (
+	"encoding/base64"
+	"fmt"
+
 	"github.com/jedib0t/go-pretty/v6/table"
 
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
 // Metadata represents metadata query result model
 type Metadata struct {
-	Type   string      `json:"type"`
-	Values interface{} `json:"values"`
+	Type      string      `json:"type"`
+	Values    interface{} `json:"values"`
+	Truncated bool        `json:"truncated,omitempty"` // true if values reach limit, but more values exist
+	Cursor    string      `json:"cursor,omitempty"`    // opaque cursor for querying next page if more values exist
+}
+
+// MetadataCursor represents the resume position of paginated metadata query(metric names/tag keys),
+// it is encoded as an opaque cursor for client.
+// Values are returned in order, each node resumes after the last value of previous page,
+// the nodes without more values are recorded as exhausted and skipped by next page.
+type MetadataCursor struct {
+	After     string   `json:"after"`               // last value of previous page
+	Exhausted []string `json:"exhausted,omitempty"` // nodes without more values after last value
+	Returned  int      `json:"returned"`            // num. of values returned by all previous pages
+}
+
+// Encode returns the opaque cursor of resume position.
+func (c *MetadataCursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString(encoding.JSONMarshal(c))
+}
+
+// IsExhausted returns if the node has no more values after last value.
+func (c *MetadataCursor) IsExhausted(node string) bool {
+	for _, exhausted := range c.Exhausted {
+		if exhausted == node {
+			return true
+		}
+	}
+	return false
+}
+
+// DecodeMetadataCursor decodes the resume position from opaque cursor.
+func DecodeMetadataCursor(cursor string) (*MetadataCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor of metadata query: %w", err)
+	}
+	rs := &MetadataCursor{}
+	if err := encoding.JSONUnmarshal(data, rs); err != nil {
+		return nil, fmt.Errorf("invalid cursor of metadata query: %w", err)
+	}
+	return rs, nil
 }
 
 // ToTable returns metadata list as table if it has value, else return empty string.
@@ -0,0 +1,120 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/encoding"
+)
+
+// MetadataArchiveVersion represents the current version of metadata archive format.
+const MetadataArchiveVersion = 1
+
+// MetadataEntry represents the key/value of metadata in state repository.
+type MetadataEntry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// MetadataArchive represents the backup of metadata owned by LinDB in state repository,
+// includes databases/shard assignments/storage cluster configs/schemas/rules etc.
+type MetadataArchive struct {
+	Version   int             `json:"version"`
+	CreatedAt int64           `json:"createdAt"`
+	Entries   []MetadataEntry `json:"entries"`
+}
+
+// Validate checks if the metadata archive can be imported.
+func (a *MetadataArchive) Validate() error {
+	if a.Version != MetadataArchiveVersion {
+		return fmt.Errorf("unsupported metadata archive version: %d, expect: %d", a.Version, MetadataArchiveVersion)
+	}
+	keys := make(map[string]struct{})
+	for idx := range a.Entries {
+		entry := &a.Entries[idx]
+		prefix, ok := MetadataPathOf(entry.Key)
+		if !ok {
+			return fmt.Errorf("key[%s] of metadata archive is not owned by LinDB", entry.Key)
+		}
+		if _, ok := keys[entry.Key]; ok {
+			return fmt.Errorf("key[%s] of metadata archive is duplicated", entry.Key)
+		}
+		keys[entry.Key] = struct{}{}
+		if !json.Valid(entry.Value) {
+			return fmt.Errorf("value of key[%s] in metadata archive is not valid json", entry.Key)
+		}
+		if prefix == constants.DatabaseConfigPath {
+			cfg := &Database{}
+			if err := encoding.JSONUnmarshal(entry.Value, cfg); err != nil {
+				return fmt.Errorf("database config of key[%s] is invalid, err: %s", entry.Key, err)
+			}
+			if constants.GetDatabaseConfigPath(cfg.Name) != entry.Key {
+				return fmt.Errorf("database config of key[%s] not match database name[%s]", entry.Key, cfg.Name)
+			}
+			if cfg.Option != nil {
+				if err := cfg.Option.Validate(); err != nil {
+					return fmt.Errorf("database option of key[%s] is invalid, err: %s", entry.Key, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// MetadataPathOf returns the prefix path of metadata which key belongs to, false if key is not owned by LinDB.
+func MetadataPathOf(key string) (string, bool) {
+	for _, path := range constants.MetadataPaths {
+		if strings.HasPrefix(key, path+constants.StatePathSeparator) && len(key) > len(path)+1 {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// MetadataDiff represents the difference between metadata archive and current state.
+type MetadataDiff struct {
+	Added   []string `json:"added,omitempty"`
+	Updated []string `json:"updated,omitempty"`
+	Deleted []string `json:"deleted,omitempty"`
+	// Applied is true if metadata archive is imported.
+	Applied bool `json:"applied"`
+}
+
+// IsEmpty returns true if metadata archive is same as current state.
+func (d *MetadataDiff) IsEmpty() bool {
+	return len(d.Added) == 0 && len(d.Updated) == 0 && len(d.Deleted) == 0
+}
+
+// String returns the description of difference, one key each line with +(added)/~(updated)/-(deleted) mark.
+func (d *MetadataDiff) String() string {
+	var sb strings.Builder
+	for _, key := range d.Added {
+		sb.WriteString("+ " + key + "\n")
+	}
+	for _, key := range d.Updated {
+		sb.WriteString("~ " + key + "\n")
+	}
+	for _, key := range d.Deleted {
+		sb.WriteString("- " + key + "\n")
+	}
+	return sb.String()
+}
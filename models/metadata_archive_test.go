@@ -0,0 +1,117 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataArchive_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		entries []MetadataEntry
+		version int
+		wantErr bool
+	}{
+		{
+			name:    "version not match",
+			version: 100,
+			wantErr: true,
+		},
+		{
+			name:    "key not owned",
+			entries: []MetadataEntry{{Key: "/live/nodes/1", Value: []byte(`{}`)}},
+			wantErr: true,
+		},
+		{
+			name:    "key is prefix",
+			entries: []MetadataEntry{{Key: "/database/config/", Value: []byte(`{}`)}},
+			wantErr: true,
+		},
+		{
+			name: "key duplicated",
+			entries: []MetadataEntry{
+				{Key: "/database/schema/db", Value: []byte(`{}`)},
+				{Key: "/database/schema/db", Value: []byte(`{}`)},
+			},
+			wantErr: true,
+		},
+		{
+			name:    "invalid json",
+			entries: []MetadataEntry{{Key: "/database/schema/db", Value: []byte(`{`)}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid database config",
+			entries: []MetadataEntry{{Key: "/database/config/db", Value: []byte(`[]`)}},
+			wantErr: true,
+		},
+		{
+			name:    "database name not match",
+			entries: []MetadataEntry{{Key: "/database/config/db", Value: []byte(`{"name":"db2"}`)}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid database option",
+			entries: []MetadataEntry{{Key: "/database/config/db", Value: []byte(`{"name":"db","option":{}}`)}},
+			wantErr: true,
+		},
+		{
+			name: "valid archive",
+			entries: []MetadataEntry{
+				{Key: "/storage/config/s1", Value: []byte(`{"config":{"namespace":"s1"}}`)},
+				{Key: "/database/config/db", Value: []byte(`{"name":"db","storage":"s1"}`)},
+				{Key: "/database/assign/db", Value: []byte(`{"name":"db"}`)},
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			archive := &MetadataArchive{Version: MetadataArchiveVersion, Entries: tt.entries}
+			if tt.version > 0 {
+				archive.Version = tt.version
+			}
+			err := archive.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMetadataPathOf(t *testing.T) {
+	path, ok := MetadataPathOf("/database/assign/db")
+	assert.True(t, ok)
+	assert.Equal(t, "/database/assign", path)
+	_, ok = MetadataPathOf("/database/assignx")
+	assert.False(t, ok)
+	_, ok = MetadataPathOf("/storage/state/s1")
+	assert.False(t, ok)
+}
+
+func TestMetadataDiff(t *testing.T) {
+	diff := &MetadataDiff{}
+	assert.True(t, diff.IsEmpty())
+	assert.Empty(t, diff.String())
+	diff.Deleted = []string{"/database/config/db"}
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, "- /database/config/db\n", diff.String())
+}
@@ -59,3 +59,19 @@ func TestDatabaseNames_ToTable(t *testing.T) {
 	assert.Equal(t, rows, 1)
 	assert.NotEmpty(t, rs)
 }
+
+func TestMetadataCursor(t *testing.T) {
+	cursor := &MetadataCursor{After: "cpu", Exhausted: []string{"node-1"}, Returned: 10}
+	rs, err := DecodeMetadataCursor(cursor.Encode())
+	assert.NoError(t, err)
+	assert.Equal(t, cursor, rs)
+	assert.True(t, rs.IsExhausted("node-1"))
+	assert.False(t, rs.IsExhausted("node-2"))
+
+	rs, err = DecodeMetadataCursor("!!!")
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	rs, err = DecodeMetadataCursor("YWJj")
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+}
@@ -0,0 +1,115 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MetricUsageSortBy represents the dimension which top metrics report is sorted by.
+type MetricUsageSortBy string
+
+const (
+	SortByPoints     MetricUsageSortBy = "points"
+	SortByFlushBytes MetricUsageSortBy = "flushBytes"
+	SortByQueries    MetricUsageSortBy = "queries"
+	SortByScanBytes  MetricUsageSortBy = "scanBytes"
+)
+
+// MetricUsage represents the (decayed) write/query usage of metric, for capacity attribution.
+type MetricUsage struct {
+	Database   string `json:"database"`
+	Metric     string `json:"metric"`
+	Points     int64  `json:"points"`     // data points written
+	FlushBytes int64  `json:"flushBytes"` // bytes of metric block on disk after flush
+	Queries    int64  `json:"queries"`    // queries touching metric
+	ScanBytes  int64  `json:"scanBytes"`  // bytes of data scanned by queries
+}
+
+// Add adds the counters of other metric usage.
+func (u *MetricUsage) Add(other *MetricUsage) {
+	u.Points += other.Points
+	u.FlushBytes += other.FlushBytes
+	u.Queries += other.Queries
+	u.ScanBytes += other.ScanBytes
+}
+
+// value returns the counter of given dimension.
+func (u *MetricUsage) value(sortBy MetricUsageSortBy) int64 {
+	switch sortBy {
+	case SortByFlushBytes:
+		return u.FlushBytes
+	case SortByQueries:
+		return u.Queries
+	case SortByScanBytes:
+		return u.ScanBytes
+	default:
+		return u.Points
+	}
+}
+
+// ParseMetricUsageSortBy parses the sort dimension, points is used if empty.
+func ParseMetricUsageSortBy(sortBy string) (MetricUsageSortBy, error) {
+	switch MetricUsageSortBy(sortBy) {
+	case "":
+		return SortByPoints, nil
+	case SortByPoints, SortByFlushBytes, SortByQueries, SortByScanBytes:
+		return MetricUsageSortBy(sortBy), nil
+	default:
+		return "", fmt.Errorf("unknown sort dimension: %s, support: points/flushBytes/queries/scanBytes", sortBy)
+	}
+}
+
+// TopMetricUsages merges the usages of same database/metric(reported by different nodes),
+// then returns the top n usages sorted by given dimension(desc), returns all if n <= 0.
+func TopMetricUsages(usages []*MetricUsage, sortBy MetricUsageSortBy, n int) []*MetricUsage {
+	type usageKey struct {
+		database string
+		metric   string
+	}
+	merged := make(map[usageKey]*MetricUsage)
+	rs := make([]*MetricUsage, 0, len(usages))
+	for _, usage := range usages {
+		if usage == nil {
+			continue
+		}
+		key := usageKey{database: usage.Database, metric: usage.Metric}
+		if m, ok := merged[key]; ok {
+			m.Add(usage)
+			continue
+		}
+		m := *usage
+		merged[key] = &m
+		rs = append(rs, &m)
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		vi, vj := rs[i].value(sortBy), rs[j].value(sortBy)
+		if vi != vj {
+			return vi > vj
+		}
+		if rs[i].Database != rs[j].Database {
+			return rs[i].Database < rs[j].Database
+		}
+		return rs[i].Metric < rs[j].Metric
+	})
+	if n > 0 && len(rs) > n {
+		rs = rs[:n]
+	}
+	return rs
+}
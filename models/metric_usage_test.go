@@ -0,0 +1,75 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMetricUsageSortBy(t *testing.T) {
+	cases := []struct {
+		in      string
+		out     MetricUsageSortBy
+		wantErr bool
+	}{
+		{in: "", out: SortByPoints},
+		{in: "points", out: SortByPoints},
+		{in: "flushBytes", out: SortByFlushBytes},
+		{in: "queries", out: SortByQueries},
+		{in: "scanBytes", out: SortByScanBytes},
+		{in: "abc", wantErr: true},
+	}
+	for _, tt := range cases {
+		sortBy, err := ParseMetricUsageSortBy(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.out, sortBy, tt.in)
+	}
+}
+
+func TestTopMetricUsages(t *testing.T) {
+	usages := []*MetricUsage{
+		{Database: "db", Metric: "cpu", Points: 10, FlushBytes: 1, Queries: 1, ScanBytes: 100},
+		{Database: "db", Metric: "mem", Points: 5, FlushBytes: 20, Queries: 3, ScanBytes: 1},
+		nil,
+		{Database: "db", Metric: "cpu", Points: 10, FlushBytes: 1, Queries: 1, ScanBytes: 100},
+		{Database: "db2", Metric: "cpu", Points: 1},
+	}
+	rs := TopMetricUsages(usages, SortByPoints, 0)
+	assert.Len(t, rs, 3)
+	assert.Equal(t, &MetricUsage{Database: "db", Metric: "cpu", Points: 20, FlushBytes: 2, Queries: 2, ScanBytes: 200}, rs[0])
+	// input not changed
+	assert.Equal(t, int64(10), usages[0].Points)
+
+	rs = TopMetricUsages(usages, SortByFlushBytes, 1)
+	assert.Len(t, rs, 1)
+	assert.Equal(t, "mem", rs[0].Metric)
+	rs = TopMetricUsages(usages, SortByQueries, 2)
+	assert.Equal(t, []string{"mem", "cpu"}, []string{rs[0].Metric, rs[1].Metric})
+	rs = TopMetricUsages(usages, SortByScanBytes, 0)
+	assert.Equal(t, "cpu", rs[0].Metric)
+	// tie broken by database/metric
+	rs = TopMetricUsages([]*MetricUsage{{Database: "b"}, {Database: "a", Metric: "y"}, {Database: "a", Metric: "x"}}, SortByPoints, 0)
+	assert.Equal(t, []string{"a", "a", "b"}, []string{rs[0].Database, rs[1].Database, rs[2].Database})
+	assert.Equal(t, "x", rs[0].Metric)
+}
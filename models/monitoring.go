@@ -85,13 +85,14 @@ type FamilyLogReplicaState struct {
 
 // ReplicaPeerState represents current wal replica peer state.
 type ReplicaPeerState struct {
-	Replicator     string          `json:"replicator"`
-	ReplicatorType string          `json:"replicatorType"`
-	Consume        int64           `json:"consume"`
-	ACK            int64           `json:"ack"`
-	Pending        int64           `json:"pending"`
-	State          ReplicatorState `json:"state"`
-	StateErrMsg    string          `json:"stateErrMsg"`
+	Replicator     string            `json:"replicator"`
+	ReplicatorType string            `json:"replicatorType"`
+	Consume        int64             `json:"consume"`
+	ACK            int64             `json:"ack"`
+	Pending        int64             `json:"pending"`
+	State          ReplicatorState   `json:"state"`
+	StateErrMsg    string            `json:"stateErrMsg"`
+	WriteState     *LeaderWriteState `json:"writeState,omitempty"` // write state of local replicator
 }
 
 // SystemStat represents the system statistics
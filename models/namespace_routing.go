@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+
+	commonconstants "github.com/lindb/common/constants"
+)
+
+// UnknownNamespaceMode represents how broker handles the rows of namespace without routing rule.
+type UnknownNamespaceMode string
+
+const (
+	// UnknownNamespaceFallback routes the rows of unknown namespace to default database.
+	UnknownNamespaceFallback UnknownNamespaceMode = "fallback"
+	// UnknownNamespaceReject rejects the write request which contains rows of unknown namespace.
+	UnknownNamespaceReject UnknownNamespaceMode = "reject"
+)
+
+// NamespaceRoute represents the rule which routes the rows of namespace to database.
+type NamespaceRoute struct {
+	Namespace string `json:"namespace" validate:"required"`
+	Database  string `json:"database" validate:"required"`
+}
+
+// NamespaceRouting represents the namespace=>database routing table of write api,
+// applied only if write request doesn't specify database.
+type NamespaceRouting struct {
+	Routes           []NamespaceRoute     `json:"routes"`
+	DefaultDatabase  string               `json:"defaultDatabase,omitempty"`  // database of unknown namespace in fallback mode
+	UnknownNamespace UnknownNamespaceMode `json:"unknownNamespace,omitempty"` // fallback if empty
+}
+
+// GetUnknownNamespace returns the mode of handling unknown namespace, fallback by default.
+func (r *NamespaceRouting) GetUnknownNamespace() UnknownNamespaceMode {
+	if r.UnknownNamespace == "" {
+		return UnknownNamespaceFallback
+	}
+	return r.UnknownNamespace
+}
+
+// Validate checks if the routing table is valid, rejects duplicate rules of same namespace.
+func (r *NamespaceRouting) Validate() error {
+	switch r.GetUnknownNamespace() {
+	case UnknownNamespaceFallback:
+		if r.DefaultDatabase == "" {
+			return fmt.Errorf("default database of namespace routing cannot be empty in fallback mode")
+		}
+	case UnknownNamespaceReject:
+	default:
+		return fmt.Errorf("unknown mode[%s] of namespace routing", r.UnknownNamespace)
+	}
+	namespaces := make(map[string]struct{})
+	for idx := range r.Routes {
+		route := &r.Routes[idx]
+		if route.Namespace == "" || route.Database == "" {
+			return fmt.Errorf("namespace/database of routing rule cannot be empty")
+		}
+		if _, ok := namespaces[route.Namespace]; ok {
+			return fmt.Errorf("duplicate routing rule of namespace[%s]", route.Namespace)
+		}
+		namespaces[route.Namespace] = struct{}{}
+	}
+	return nil
+}
+
+// Route returns the database of namespace, returns default database if namespace has no rule in fallback mode,
+// returns false if namespace has no rule in reject mode.
+func (r *NamespaceRouting) Route(namespace string) (database string, ok bool) {
+	if namespace == "" {
+		namespace = commonconstants.DefaultNamespace
+	}
+	for idx := range r.Routes {
+		if r.Routes[idx].Namespace == namespace {
+			return r.Routes[idx].Database, true
+		}
+	}
+	if r.GetUnknownNamespace() == UnknownNamespaceReject {
+		return "", false
+	}
+	return r.DefaultDatabase, true
+}
@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	commonconstants "github.com/lindb/common/constants"
+)
+
+func TestNamespaceRouting_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		routing NamespaceRouting
+		wantErr bool
+	}{
+		{
+			name:    "fallback without default database",
+			routing: NamespaceRouting{},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			routing: NamespaceRouting{UnknownNamespace: "abc"},
+			wantErr: true,
+		},
+		{
+			name:    "empty database of rule",
+			routing: NamespaceRouting{UnknownNamespace: UnknownNamespaceReject, Routes: []NamespaceRoute{{Namespace: "infra"}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate rule",
+			routing: NamespaceRouting{
+				DefaultDatabase: "db",
+				Routes:          []NamespaceRoute{{Namespace: "infra", Database: "a"}, {Namespace: "infra", Database: "b"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid routing",
+			routing: NamespaceRouting{
+				UnknownNamespace: UnknownNamespaceReject,
+				Routes:           []NamespaceRoute{{Namespace: "infra", Database: "a"}, {Namespace: "app", Database: "a"}},
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.routing.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNamespaceRouting_Route(t *testing.T) {
+	routing := &NamespaceRouting{
+		DefaultDatabase: "default_metrics",
+		Routes: []NamespaceRoute{
+			{Namespace: "infra", Database: "infra_metrics"},
+			{Namespace: commonconstants.DefaultNamespace, Database: "app_metrics"},
+		},
+	}
+	assert.Equal(t, UnknownNamespaceFallback, routing.GetUnknownNamespace())
+	database, ok := routing.Route("infra")
+	assert.True(t, ok)
+	assert.Equal(t, "infra_metrics", database)
+	database, ok = routing.Route("")
+	assert.True(t, ok)
+	assert.Equal(t, "app_metrics", database)
+	database, ok = routing.Route("unknown")
+	assert.True(t, ok)
+	assert.Equal(t, "default_metrics", database)
+
+	routing.UnknownNamespace = UnknownNamespaceReject
+	_, ok = routing.Route("unknown")
+	assert.False(t, ok)
+}
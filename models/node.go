@@ -56,6 +56,34 @@ type StatefulNode struct {
 	StatelessNode
 
 	ID NodeID `json:"id"`
+	// NotReady marks the node cannot serve traffic(readiness check failure),
+	// keep the zero value as ready for compatibility with old nodes.
+	NotReady bool `json:"notReady,omitempty"`
+	// MaintenanceUntil is the deadline(ms) announced by node before graceful restart,
+	// 0 means node is not restarting.
+	MaintenanceUntil int64 `json:"maintenanceUntil,omitempty"`
+	// Load is the latest load of node reported periodically, nil if node not reported yet.
+	Load *NodeLoad `json:"load,omitempty"`
+	// WriteCompressions are the codecs of write stream supported by node(like none,snappy,zstd),
+	// empty means snappy only(old node), so that broker negotiates the codec with node.
+	WriteCompressions string `json:"writeCompressions,omitempty"`
+}
+
+// SameRegistration returns if the registration info is same as other node's, excludes the load of node.
+func (n *StatefulNode) SameRegistration(other *StatefulNode) bool {
+	left, right := *n, *other
+	left.Load, right.Load = nil, nil
+	return left == right
+}
+
+// NodeLoad represents the load of storage node, which is collected from family manager stats.
+type NodeLoad struct {
+	MemDBTotalSize      int64  `json:"memDBTotalSize"`      // total heap size of memory databases
+	FamiliesNeedFlush   int    `json:"familiesNeedFlush"`   // families whose memory database need to flush
+	FamiliesFlushFailed int    `json:"familiesFlushFailed"` // families whose flush job fails persistently
+	DiskFree            uint64 `json:"diskFree"`            // free space of data disk
+	WriteRejected       int64  `json:"writeRejected"`       // rows rejected since node started(disk space low)
+	ReportTime          int64  `json:"reportTime"`          // report time(millisecond)
 }
 
 // StatelessNodes represents stateless node list.
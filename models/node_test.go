@@ -86,3 +86,14 @@ func TestNodeID(t *testing.T) {
 	assert.Equal(t, "1", NodeID(1).String())
 	assert.Equal(t, NodeID(1), ParseNodeID("1"))
 }
+
+func TestStatefulNode_SameRegistration(t *testing.T) {
+	node := StatefulNode{ID: 1, StatelessNode: StatelessNode{HostIP: "1.1.1.1", GRPCPort: 2000}}
+	node2 := node
+	node2.Load = &NodeLoad{MemDBTotalSize: 100}
+	assert.True(t, node.SameRegistration(&node2))
+	node2.NotReady = true
+	assert.False(t, node.SameRegistration(&node2))
+	// load not changed
+	assert.Nil(t, node.Load)
+}
@@ -24,6 +24,12 @@ type PhysicalPlan struct {
 	Database  string    `json:"database"` // database name
 	Targets   []*Target `json:"targets"`
 	Receivers []string  `json:"receivers"`
+	// remaining timeout(ns) of query when sending plan, 0 means using node's default timeout.
+	Timeout int64 `json:"timeout,omitempty"`
+	// trace context(W3C traceparent etc.) of query for correlating the spans of broker and storage.
+	TraceContext map[string]string `json:"traceContext,omitempty"`
+	// Cancel cancels the running task of request on targets instead of executing it(loser of hedged request).
+	Cancel bool `json:"cancel,omitempty"`
 }
 
 // AddReceiver adds a receiver.
@@ -55,4 +61,7 @@ type Target struct {
 	ReceiveOnly bool      `json:"receiverOnly"`
 	Indicator   string    `json:"indicator"` // current node's indicator
 	ShardIDs    []ShardID `json:"shardIDs"`
+	// MaxSeries is the max num. of series matched on the shards of target for one query,
+	// split from the series limit per query of database, 0 means no limit.
+	MaxSeries int `json:"maxSeries,omitempty"`
 }
@@ -18,7 +18,10 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,9 +33,26 @@ import (
 
 // SeriesStats represents the stats for series.
 type SeriesStats struct {
+	NumOfSeries uint64               `json:"numOfSeries"`
+	Filters     []*SeriesFilterStats `json:"filters,omitempty"`
+}
+
+// SeriesFilterStats represents the stats of series filtering for each tag filter clause.
+type SeriesFilterStats struct {
+	Expr        string `json:"expr"`
+	Cost        int64  `json:"cost"`
 	NumOfSeries uint64 `json:"numOfSeries"`
 }
 
+// DataFamilyFilterStats represents the stats of data family filtering(memory database/file).
+type DataFamilyFilterStats struct {
+	MemoryFilterCost int64  `json:"memoryFilterCost"`
+	FileFilterCost   int64  `json:"fileFilterCost"`
+	NumOfMemoryRS    int    `json:"numOfMemoryRS"`
+	NumOfFileRS      int    `json:"numOfFileRS"`
+	NumOfSeries      uint64 `json:"numOfSeries"`
+}
+
 // OperatorStats represents the stats of operator.
 type OperatorStats struct {
 	Identifier string      `json:"identifier"`
@@ -68,6 +88,32 @@ type NodeStats struct {
 	Start      int64         `json:"start"`
 	End        int64         `json:"end"`
 	Stages     []*StageStats `json:"stages,omitempty"`
+	TimeZone   string        `json:"timeZone,omitempty"` // effective time zone of group by time buckets
+	// FieldAliases are the field aliases applied when resolving fields, like: usage->used(since 2022-01-01 00:00:00).
+	FieldAliases []string `json:"fieldAliases,omitempty"`
+	// ExpiredFields are the fields whose data is dropped by field level retention in query time range,
+	// like: histogram(expired before 2022-01-01 00:00:00).
+	ExpiredFields []string `json:"expiredFields,omitempty"`
+	// RangeClipped is set if the time range of query is clamped to the retention of database,
+	// like: range clipped from 2022-01-01 00:00:00 to 2022-03-01 00:00:00.
+	RangeClipped string `json:"rangeClipped,omitempty"`
+	// UnknownNames are the unknown metric/fields referenced by query in lenient strictness,
+	// like: field usge(did you mean: usage).
+	UnknownNames []string `json:"unknownNames,omitempty"`
+	// Metrics are the metrics resolved by metric name pattern of query, like: cpu.system, cpu.user.
+	Metrics []string `json:"metrics,omitempty"`
+	// WriteSemantics is the write semantics of database if rewriting same time slot isn't aggregated, like: last-write-wins.
+	WriteSemantics string `json:"writeSemantics,omitempty"`
+	// QueriedShards/PrunedShards are the num. of shards queried/pruned by routing tags of database.
+	QueriedShards int `json:"queriedShards,omitempty"`
+	PrunedShards  int `json:"prunedShards,omitempty"`
+	// PeakMemory is the peak estimated memory of grouped series buffered by broker,
+	// SpilledBytes is the bytes of grouped series spilled to temporary files if memory budget exceeded.
+	PeakMemory   int64 `json:"peakMemory,omitempty"`
+	SpilledBytes int64 `json:"spilledBytes,omitempty"`
+	// ShardMaxTimestamps are the max timestamp of data in query time range of each shard queried,
+	// only reported for leader read consistency, so that clients can detect staleness.
+	ShardMaxTimestamps map[ShardID]int64 `json:"shardMaxTimestamps,omitempty"`
 
 	Children []*NodeStats `json:"children,omitempty"`
 }
@@ -110,6 +156,21 @@ func (s *NodeStats) ToTable() (rows int, tableStr string) {
 	return 1, rs
 }
 
+// StagesSummary returns the brief of stages' execution(state and cost) in execution order,
+// used for describing the partial progress of failure query.
+func StagesSummary(stages []*StageStats) string {
+	var rs []string
+	var walk func(stages []*StageStats)
+	walk = func(stages []*StageStats) {
+		for _, stage := range stages {
+			rs = append(rs, fmt.Sprintf("%s(%s, %s)", stage.Identifier, stage.State, time.Duration(stage.Cost)))
+			walk(stage.Children)
+		}
+	}
+	walk(stages)
+	return fmt.Sprintf("[%s]", strings.Join(rs, ", "))
+}
+
 // nodeToTable returns node info.
 func nodeToTable(tree treeprint.Tree, node *NodeStats) {
 	sub := tree.AddBranch(nodeTitle(node))
@@ -130,6 +191,33 @@ func nodeTitle(node *NodeStats) string {
 	if node.NetPayload > 0 {
 		costs = append(costs, fmt.Sprintf("Network: %s", ltoml.Size(node.NetPayload)))
 	}
+	if node.TimeZone != "" {
+		costs = append(costs, fmt.Sprintf("Time Zone: %s", node.TimeZone))
+	}
+	if len(node.FieldAliases) > 0 {
+		costs = append(costs, fmt.Sprintf("Field Alias: %s", strings.Join(node.FieldAliases, ", ")))
+	}
+	if len(node.ExpiredFields) > 0 {
+		costs = append(costs, fmt.Sprintf("Field Expired: %s", strings.Join(node.ExpiredFields, ", ")))
+	}
+	if node.RangeClipped != "" {
+		costs = append(costs, fmt.Sprintf("Retention: %s", node.RangeClipped))
+	}
+	if len(node.UnknownNames) > 0 {
+		costs = append(costs, fmt.Sprintf("Unknown: %s", strings.Join(node.UnknownNames, ", ")))
+	}
+	if len(node.Metrics) > 0 {
+		costs = append(costs, fmt.Sprintf("Metrics: %s", strings.Join(node.Metrics, ", ")))
+	}
+	if node.WriteSemantics != "" {
+		costs = append(costs, fmt.Sprintf("Write Semantics: %s", node.WriteSemantics))
+	}
+	if node.QueriedShards > 0 || node.PrunedShards > 0 {
+		costs = append(costs, fmt.Sprintf("Shards: %d queried, %d pruned", node.QueriedShards, node.PrunedShards))
+	}
+	if node.PeakMemory > 0 || node.SpilledBytes > 0 {
+		costs = append(costs, fmt.Sprintf("Memory: %s peak, %s spilled", ltoml.Size(node.PeakMemory), ltoml.Size(node.SpilledBytes)))
+	}
 	return fmt.Sprintf("%s: [%s]",
 		node.Node, strings.Join(costs, ", "),
 	)
@@ -139,9 +227,52 @@ func nodeTitle(node *NodeStats) string {
 func stageToTable(tree treeprint.Tree, stage *StageStats) {
 	stageNode := tree.AddBranch(fmt.Sprintf("Stage(%s), [Cost:%s]", stage.Identifier, time.Duration(stage.Cost)))
 	for _, op := range stage.Operators {
-		stageNode.AddNode(fmt.Sprintf("Operator(%s), [Cost:%s]", op.Identifier, time.Duration(op.Cost)))
+		stageNode.AddNode(operatorTitle(op))
 	}
 	for _, child := range stage.Children {
 		stageToTable(stageNode, child)
 	}
 }
+
+// operatorTitle returns the title of operator, includes the stats of operator if it has.
+func operatorTitle(op *OperatorStats) string {
+	items := []string{fmt.Sprintf("Cost:%s", time.Duration(op.Cost))}
+	items = append(items, statsItems(op.Stats)...)
+	return fmt.Sprintf("Operator(%s), [%s]", op.Identifier, strings.Join(items, ", "))
+}
+
+// statsItems returns the key/value items of operator's stats in key order,
+// the value of key with "Cost" suffix is formatted as duration.
+func statsItems(stats interface{}) (items []string) {
+	if stats == nil {
+		return nil
+	}
+	// stats from storage node is map after unmarshal, convert to map for both cases
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return nil
+	}
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := values[key]
+		switch v := value.(type) {
+		case float64:
+			if strings.HasSuffix(key, "Cost") {
+				items = append(items, fmt.Sprintf("%s:%s", key, time.Duration(v)))
+			} else {
+				items = append(items, fmt.Sprintf("%s:%s", key, strconv.FormatFloat(v, 'f', -1, 64)))
+			}
+		default:
+			items = append(items, fmt.Sprintf("%s:%v", key, v))
+		}
+	}
+	return items
+}
@@ -0,0 +1,75 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeStats_ToTable(t *testing.T) {
+	stats := &NodeStats{
+		Node:           "broker",
+		TotalCost:      1000,
+		FieldAliases:   []string{"usage->used(since 2023-01-27 00:00:00)"},
+		ExpiredFields:  []string{"histogram(expired before 2023-01-20 00:00:00)"},
+		RangeClipped:   "range clipped from 2023-01-01 00:00:00 to 2023-01-20 00:00:00",
+		WriteSemantics: "last-write-wins",
+		QueriedShards:  1,
+		PrunedShards:   3,
+		PeakMemory:     2048,
+		SpilledBytes:   1024,
+		Children: []*NodeStats{{
+			Node:       "storage",
+			NetPayload: 100,
+			WaitStart:  10,
+			Stages: []*StageStats{{
+				Identifier: "Shard Scan[Shard(1)]",
+				Operators: []*OperatorStats{
+					{
+						Identifier: "Data Family Read[db/1/20230127/5m]",
+						Cost:       2000,
+						Stats:      &DataFamilyFilterStats{MemoryFilterCost: 1000, NumOfSeries: 10},
+					},
+					{Identifier: "Series Filtering", Cost: 3000},
+				},
+			}},
+		}},
+	}
+	rows, rs := stats.ToTable()
+	assert.Equal(t, 1, rows)
+	assert.Contains(t, rs, "memoryFilterCost:1µs")
+	assert.Contains(t, rs, "numOfSeries:10")
+	assert.Contains(t, rs, "Operator(Series Filtering), [Cost:3µs]")
+	assert.Contains(t, rs, "Field Alias: usage->used(since 2023-01-27 00:00:00)")
+	assert.Contains(t, rs, "Field Expired: histogram(expired before 2023-01-20 00:00:00)")
+	assert.Contains(t, rs, "Retention: range clipped from 2023-01-01 00:00:00 to 2023-01-20 00:00:00")
+	assert.Contains(t, rs, "Write Semantics: last-write-wins")
+	assert.Contains(t, rs, "Shards: 1 queried, 3 pruned")
+	assert.Contains(t, rs, "Memory: 2.0 KiB peak, 1.0 KiB spilled")
+}
+
+func TestStatsItems(t *testing.T) {
+	assert.Nil(t, statsItems(nil))
+	assert.Nil(t, statsItems(func() {}))
+	assert.Nil(t, statsItems("abc"))
+	// stats unmarshal from storage node
+	assert.Equal(t, []string{"fileFilterCost:1ms", "name:a", "numOfSeries:1000000"},
+		statsItems(map[string]interface{}{"fileFilterCost": float64(1000000), "numOfSeries": float64(1000000), "name": "a"}))
+}
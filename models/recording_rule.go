@@ -0,0 +1,85 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"time"
+
+	commonconstants "github.com/lindb/common/constants"
+)
+
+// minRecordingRuleInterval is the minimum evaluation interval of recording rule.
+const minRecordingRuleInterval = time.Second
+
+// RecordingRule represents the rule which evaluates query periodically, then writes the result as new metric.
+type RecordingRule struct {
+	Name      string `json:"name" validate:"required"`
+	SQL       string `json:"sql" validate:"required"`      // query of rule, time range is set by each evaluation window
+	Metric    string `json:"metric" validate:"required"`   // destination metric name
+	Namespace string `json:"namespace,omitempty"`          // default-ns if empty
+	Interval  string `json:"interval" validate:"required"` // evaluation interval, like: 1m
+}
+
+// RecordingRules represents the recording rules of database.
+type RecordingRules struct {
+	Database string           `json:"database" validate:"required"`
+	Rules    []*RecordingRule `json:"rules"`
+}
+
+// RecordingRuleState represents the evaluation state of recording rule, persisted for resuming after fail-over.
+type RecordingRuleState struct {
+	LastEvaluated int64 `json:"lastEvaluated"` // end time of last evaluated window
+}
+
+// Validate checks if the recording rules of database are valid.
+func (r *RecordingRules) Validate() error {
+	names := make(map[string]struct{})
+	for _, rule := range r.Rules {
+		if rule == nil || rule.Name == "" {
+			return fmt.Errorf("recording rule name of database[%s] cannot be empty", r.Database)
+		}
+		if _, ok := names[rule.Name]; ok {
+			return fmt.Errorf("duplicate recording rule[%s] of database[%s]", rule.Name, r.Database)
+		}
+		names[rule.Name] = struct{}{}
+		if rule.SQL == "" || rule.Metric == "" {
+			return fmt.Errorf("sql/metric of recording rule[%s] cannot be empty", rule.Name)
+		}
+		interval, err := time.ParseDuration(rule.Interval)
+		if err != nil || interval < minRecordingRuleInterval || interval%time.Second != 0 {
+			return fmt.Errorf("interval of recording rule[%s] must be whole seconds and >= %s, but got: %s",
+				rule.Name, minRecordingRuleInterval, rule.Interval)
+		}
+	}
+	return nil
+}
+
+// GetInterval returns the evaluation interval of rule, 0 if invalid.
+func (r *RecordingRule) GetInterval() time.Duration {
+	interval, _ := time.ParseDuration(r.Interval)
+	return interval
+}
+
+// GetNamespace returns the namespace of destination metric, default-ns if empty.
+func (r *RecordingRule) GetNamespace() string {
+	if r.Namespace == "" {
+		return commonconstants.DefaultNamespace
+	}
+	return r.Namespace
+}
@@ -0,0 +1,63 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	commonconstants "github.com/lindb/common/constants"
+)
+
+func TestRecordingRules_Validate(t *testing.T) {
+	newRule := func(name, interval string) *RecordingRule {
+		return &RecordingRule{Name: name, SQL: "select f from cpu", Metric: "cpu_1m", Interval: interval}
+	}
+	cases := []struct {
+		name    string
+		rules   []*RecordingRule
+		wantErr bool
+	}{
+		{name: "empty rules"},
+		{name: "valid rules", rules: []*RecordingRule{newRule("r1", "1m"), newRule("r2", "10s")}},
+		{name: "empty name", rules: []*RecordingRule{newRule("", "1m")}, wantErr: true},
+		{name: "nil rule", rules: []*RecordingRule{nil}, wantErr: true},
+		{name: "duplicate name", rules: []*RecordingRule{newRule("r1", "1m"), newRule("r1", "10s")}, wantErr: true},
+		{name: "empty metric", rules: []*RecordingRule{{Name: "r1", SQL: "select f from cpu", Interval: "1m"}}, wantErr: true},
+		{name: "invalid interval", rules: []*RecordingRule{newRule("r1", "abc")}, wantErr: true},
+		{name: "interval too small", rules: []*RecordingRule{newRule("r1", "100ms")}, wantErr: true},
+		{name: "interval not whole seconds", rules: []*RecordingRule{newRule("r1", "1500ms")}, wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rules := &RecordingRules{Database: "db", Rules: tt.rules}
+			assert.Equal(t, tt.wantErr, rules.Validate() != nil)
+		})
+	}
+}
+
+func TestRecordingRule(t *testing.T) {
+	rule := &RecordingRule{Interval: "1m"}
+	assert.Equal(t, time.Minute, rule.GetInterval())
+	assert.Equal(t, commonconstants.DefaultNamespace, rule.GetNamespace())
+	rule.Namespace = "ns"
+	assert.Equal(t, "ns", rule.GetNamespace())
+}
@@ -0,0 +1,52 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+// ReplicaBootstrapState represents the state of replica bootstrapping.
+type ReplicaBootstrapState string
+
+// Defines all states of replica bootstrapping.
+const (
+	ReplicaBootstrapPending      ReplicaBootstrapState = "pending"
+	ReplicaBootstrapTransferring ReplicaBootstrapState = "transferring"
+	ReplicaBootstrapCompleted    ReplicaBootstrapState = "completed"
+	ReplicaBootstrapFailed       ReplicaBootstrapState = "failed" // last attempt failed, retry later
+)
+
+// ReplicaBootstrap represents the bootstrap task of new shard replica submitted by master,
+// new replica node transfers the family snapshots from leader, then switches to tail replication.
+type ReplicaBootstrap struct {
+	ID        string  `json:"id"`
+	Database  string  `json:"database"`
+	ShardID   ShardID `json:"shardId"`
+	Leader    NodeID  `json:"leader"`
+	Replica   NodeID  `json:"replica"`
+	CreatedAt int64   `json:"createdAt"`
+}
+
+// ReplicaBootstrapProgress represents the bootstrap progress of shard replica reported by storage node.
+type ReplicaBootstrapProgress struct {
+	TaskID            string                `json:"taskId"`
+	State             ReplicaBootstrapState `json:"state"`
+	TotalFamilies     int                   `json:"totalFamilies"`
+	InstalledFamilies int                   `json:"installedFamilies"`
+	TotalBytes        int64                 `json:"totalBytes"`
+	TransferredBytes  int64                 `json:"transferredBytes"`
+	Error             string                `json:"error,omitempty"`
+	UpdatedAt         int64                 `json:"updatedAt"`
+}
@@ -35,6 +35,11 @@ type Request struct {
 	DB        string `json:"db"`
 	SQL       string `json:"sql"`
 	Start     int64  `json:"start"`
+	Client    string `json:"client,omitempty"`
+	// Stage/ScanBytes/MemoryBytes are the progress and resource usage of running request, only set when listing requests.
+	Stage       string `json:"stage,omitempty"`
+	ScanBytes   int64  `json:"scanBytes,omitempty"`
+	MemoryBytes int64  `json:"memoryBytes,omitempty"`
 }
 
 // NewRequest creates a request instance.
@@ -46,3 +51,17 @@ func NewRequest(entry, db, sql string) *Request {
 		Start: time.Now().UnixNano(),
 	}
 }
+
+// SlowQuery represents the query which execution cost exceeds the slow query threshold.
+type SlowQuery struct {
+	RequestID   string        `json:"requestId"`
+	DB          string        `json:"db"`
+	SQL         string        `json:"sql"`
+	Client      string        `json:"client,omitempty"`
+	Start       int64         `json:"start"`
+	Cost        int64         `json:"cost"`
+	NumOfSeries int           `json:"numOfSeries"`
+	ErrMsg      string        `json:"errMsg,omitempty"`
+	Killed      bool          `json:"killed,omitempty"` // killed by admin, recorded regardless of cost
+	Stages      []*StageStats `json:"stages,omitempty"`
+}
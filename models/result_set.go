@@ -29,7 +29,25 @@ import (
 
 // SuggestResult represents the suggest result set
 type SuggestResult struct {
-	Values []string `json:"values"`
+	Values    []string `json:"values"`
+	Truncated bool     `json:"truncated,omitempty"`
+	Cursor    string   `json:"cursor,omitempty"` // opaque cursor of next page for paginated query
+}
+
+// ResultStream represents the writer which emits the series of result set one by one,
+// so that the broker does not need to accumulate the whole result set in memory.
+type ResultStream interface {
+	// WriteSeries writes a completed series into the stream.
+	WriteSeries(series *Series) error
+}
+
+// StreamRecord represents one line of the line-delimited(ndjson) query result stream.
+// Series records are emitted first, the trailer(result set without series, includes stats)
+// is always the final record, error record means the stream is aborted.
+type StreamRecord struct {
+	Series  *Series    `json:"series,omitempty"`
+	Trailer *ResultSet `json:"trailer,omitempty"`
+	Error   string     `json:"error,omitempty"`
 }
 
 // ResultSet represents the query result set
@@ -42,6 +60,77 @@ type ResultSet struct {
 	Interval   int64      `json:"interval,omitempty"`
 	Series     []*Series  `json:"series,omitempty"`
 	Stats      *NodeStats `json:"stats,omitempty"`
+	// Stale is set if some shards are queried on follower replicas, recent data may be missing.
+	Stale *StaleResult `json:"stale,omitempty"`
+	// TopN is set if top-n query is pushed down to storage nodes.
+	TopN *TopNResult `json:"topN,omitempty"`
+	// QuantileOverflows is set if histogram quantile of fields is beyond the largest bucket boundary,
+	// the largest bucket boundary is returned for these fields.
+	QuantileOverflows []string `json:"quantileOverflows,omitempty"`
+	// Partial is set if some storage nodes fail for partial results query, only the data of succeeded nodes
+	// is included, the failed nodes/shards are reported in failures.
+	Partial  bool              `json:"partial,omitempty"`
+	Failures []*PartialFailure `json:"failures,omitempty"`
+
+	streamed int // num. of series emitted by result stream
+}
+
+// StaleResult represents the result of shards queried on follower replicas,
+// data not replicated from leader may be missing.
+type StaleResult struct {
+	Shards []ShardID `json:"shards"` // shards queried on follower replicas
+	// data written in the last N seconds may be missing, -1 means the replication lag is unknown.
+	MissingRecentSeconds int64 `json:"missingRecentSeconds"`
+}
+
+// Merge merges other stale result, keeps the max missing duration.
+func (s *StaleResult) Merge(other *StaleResult) *StaleResult {
+	if s == nil {
+		return other
+	}
+	if other == nil {
+		return s
+	}
+	merged := &StaleResult{
+		Shards:               append(append([]ShardID{}, s.Shards...), other.Shards...),
+		MissingRecentSeconds: s.MissingRecentSeconds,
+	}
+	if s.MissingRecentSeconds >= 0 && (other.MissingRecentSeconds < 0 || other.MissingRecentSeconds > s.MissingRecentSeconds) {
+		merged.MissingRecentSeconds = other.MissingRecentSeconds
+	}
+	return merged
+}
+
+// PartialFailure represents the failure of storage node skipped by partial results query.
+type PartialFailure struct {
+	Node   string    `json:"node"`
+	Shards []ShardID `json:"shards,omitempty"` // shards queried on failed node, empty means unknown(compute node)
+	Error  string    `json:"error"`
+}
+
+// MergePartial merges the partial state of other result set.
+func (rs *ResultSet) MergePartial(other *ResultSet) {
+	if other == nil || !other.Partial {
+		return
+	}
+	rs.Partial = true
+	rs.Failures = append(rs.Failures, other.Failures...)
+}
+
+// Defines the accuracy of top-n query result.
+const (
+	// TopNExact means the values of result are exact.
+	TopNExact = "exact"
+	// TopNApproximate means the values of result are merged from the candidates of storage nodes,
+	// the partial aggregates of group pruned by some storage node are missing.
+	TopNApproximate = "approximate"
+)
+
+// TopNResult represents how top-n query is executed with pushdown.
+type TopNResult struct {
+	Candidates int    `json:"candidates"` // num. of candidate groups kept by each storage node
+	SecondPass bool   `json:"secondPass"` // query candidate groups again for exact values
+	Accuracy   string `json:"accuracy"`   // exact/approximate
 }
 
 // NewResultSet creates a new result set
@@ -54,6 +143,16 @@ func (rs *ResultSet) AddSeries(series *Series) {
 	rs.Series = append(rs.Series, series)
 }
 
+// IncStreamedSeries increases the num. of series emitted by result stream.
+func (rs *ResultSet) IncStreamedSeries() {
+	rs.streamed++
+}
+
+// NumOfSeries returns the num. of series, includes the series emitted by result stream.
+func (rs *ResultSet) NumOfSeries() int {
+	return len(rs.Series) + rs.streamed
+}
+
 // row represents a record in table.
 type row struct {
 	timestamp int64
@@ -124,6 +223,8 @@ func (rs *ResultSet) ToTable() (rows int, tableStr string) {
 type Series struct {
 	Tags   map[string]string            `json:"tags,omitempty"`
 	Fields map[string]map[int64]float64 `json:"fields,omitempty"`
+	// Partial is set if the aggregates of series may miss the data of failed shards for partial results query.
+	Partial bool `json:"partial,omitempty"`
 
 	TagValues string `json:"-"` // return series in order by tag values
 }
@@ -20,6 +20,7 @@ package models
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -45,6 +46,9 @@ func TestResultSet(t *testing.T) {
 		int64(10): 10.0,
 		int64(20): 10.0},
 		s.Fields["f1"])
+	assert.Equal(t, 1, rs.NumOfSeries())
+	rs.IncStreamedSeries()
+	assert.Equal(t, 2, rs.NumOfSeries())
 }
 
 func TestResultSet_ToTable(t *testing.T) {
@@ -78,3 +82,73 @@ func TestResultSet_Stats_ToTable(t *testing.T) {
 	fmt.Println(rows)
 	fmt.Println(table)
 }
+
+func TestStagesSummary(t *testing.T) {
+	assert.Equal(t, "[]", StagesSummary(nil))
+	assert.Equal(t, "[Metadata Lookup(Complete, 1ms), Shard Scan[Shard(0)](Executing, 0s)]",
+		StagesSummary([]*StageStats{{
+			Identifier: "Metadata Lookup",
+			State:      "Complete",
+			Cost:       int64(time.Millisecond),
+			Children:   []*StageStats{{Identifier: "Shard Scan[Shard(0)]", State: "Executing"}},
+		}}))
+}
+
+func TestStaleResult_Merge(t *testing.T) {
+	cases := []struct {
+		name  string
+		s     *StaleResult
+		other *StaleResult
+		want  *StaleResult
+	}{
+		{
+			name: "both nil",
+		},
+		{
+			name:  "self nil",
+			other: &StaleResult{Shards: []ShardID{1}, MissingRecentSeconds: 10},
+			want:  &StaleResult{Shards: []ShardID{1}, MissingRecentSeconds: 10},
+		},
+		{
+			name: "other nil",
+			s:    &StaleResult{Shards: []ShardID{1}, MissingRecentSeconds: 10},
+			want: &StaleResult{Shards: []ShardID{1}, MissingRecentSeconds: 10},
+		},
+		{
+			name:  "keep max",
+			s:     &StaleResult{Shards: []ShardID{1}, MissingRecentSeconds: 10},
+			other: &StaleResult{Shards: []ShardID{2}, MissingRecentSeconds: 20},
+			want:  &StaleResult{Shards: []ShardID{1, 2}, MissingRecentSeconds: 20},
+		},
+		{
+			name:  "unknown lag",
+			s:     &StaleResult{Shards: []ShardID{1}, MissingRecentSeconds: 10},
+			other: &StaleResult{Shards: []ShardID{2}, MissingRecentSeconds: -1},
+			want:  &StaleResult{Shards: []ShardID{1, 2}, MissingRecentSeconds: -1},
+		},
+		{
+			name:  "keep unknown lag",
+			s:     &StaleResult{Shards: []ShardID{1}, MissingRecentSeconds: -1},
+			other: &StaleResult{Shards: []ShardID{2}, MissingRecentSeconds: 20},
+			want:  &StaleResult{Shards: []ShardID{1, 2}, MissingRecentSeconds: -1},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.s.Merge(tt.other))
+		})
+	}
+}
+
+func TestResultSet_MergePartial(t *testing.T) {
+	rs := NewResultSet()
+	rs.MergePartial(nil)
+	rs.MergePartial(&ResultSet{})
+	assert.False(t, rs.Partial)
+
+	rs.MergePartial(&ResultSet{Partial: true, Failures: []*PartialFailure{{Node: "1.1.1.1:9000", Error: "err"}}})
+	rs.MergePartial(&ResultSet{Partial: true, Failures: []*PartialFailure{{Node: "1.1.1.2:9000", Error: "err"}}})
+	assert.True(t, rs.Partial)
+	assert.Len(t, rs.Failures, 2)
+}
@@ -0,0 +1,70 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import "github.com/lindb/lindb/pkg/option"
+
+// RollupBackfillState represents the state of rollup backfilling.
+type RollupBackfillState string
+
+// Defines all states of rollup backfilling.
+const (
+	RollupBackfillPending   RollupBackfillState = "pending"
+	RollupBackfillRunning   RollupBackfillState = "running"
+	RollupBackfillCompleted RollupBackfillState = "completed"
+	RollupBackfillFailed    RollupBackfillState = "failed" // last attempt failed, retry later
+)
+
+// RollupBackfill represents the backfill task of added rollup intervals submitted by master,
+// storage node rolls up the history families of writable interval into the families of added intervals.
+type RollupBackfill struct {
+	ID       string  `json:"id"`
+	Database string  `json:"database"`
+	ShardID  ShardID `json:"shardId"`
+	Replica  NodeID  `json:"replica"`
+	// added rollup intervals, the history data before available time of interval need to be backfilled
+	Intervals option.Intervals `json:"intervals"`
+	CreatedAt int64            `json:"createdAt"`
+}
+
+// RollupBackfillProgress represents the rollup backfill progress of shard replica reported by storage node,
+// families are backfilled from newest to oldest, so the history data after completed time is complete.
+type RollupBackfillProgress struct {
+	TaskID            string              `json:"taskId"`
+	State             RollupBackfillState `json:"state"`
+	TotalFamilies     int                 `json:"totalFamilies"`
+	CompletedFamilies int                 `json:"completedFamilies"`
+	CompletedFrom     int64               `json:"completedFrom"`
+	Error             string              `json:"error,omitempty"`
+	UpdatedAt         int64               `json:"updatedAt"`
+}
+
+// RollupBackfillReplica represents the rollup backfill progress of shard replica.
+type RollupBackfillReplica struct {
+	ShardID  ShardID                 `json:"shardId"`
+	NodeID   NodeID                  `json:"nodeId"`
+	Progress *RollupBackfillProgress `json:"progress"`
+}
+
+// RollupBackfillStatus represents the rollup backfill status of database,
+// includes the available time of intervals and the progress of each shard replica.
+type RollupBackfillStatus struct {
+	Database  string                  `json:"database"`
+	Intervals option.Intervals        `json:"intervals"`
+	Replicas  []RollupBackfillReplica `json:"replicas"`
+}
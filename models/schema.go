@@ -0,0 +1,209 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+
+	commonconstants "github.com/lindb/common/constants"
+
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// SchemaMode represents how broker handles the rows which violate the registered metric schema.
+type SchemaMode string
+
+const (
+	// SchemaModeLenient strips the unknown tags/fields and coerces the field type of violated rows.
+	SchemaModeLenient SchemaMode = "lenient"
+	// SchemaModeStrict rejects the write request which contains violated rows.
+	SchemaModeStrict SchemaMode = "strict"
+)
+
+// FieldSchema represents the pinned name/type of field, type is one of sum/min/max/last/first.
+// Retention(like 7d) overrides the retention of database for field, empty means same as database.
+// Precision(significant decimal digits) overrides the precision of metric for field, 0 means full precision,
+// it's the only way to reduce the precision of sum field.
+type FieldSchema struct {
+	Name      string `json:"name" validate:"required"`
+	Type      string `json:"type" validate:"required"`
+	Retention string `json:"retention,omitempty"`
+	Precision *int   `json:"precision,omitempty"`
+}
+
+// MetricSchema represents the expected fields/tag keys of metric,
+// empty fields/tag keys means fields/tag keys are not checked.
+// Precision is the default significant decimal digits of fields except sum/histogram fields, 0 means full precision.
+type MetricSchema struct {
+	Namespace string        `json:"namespace,omitempty"` // default-ns if empty
+	Name      string        `json:"name" validate:"required"`
+	Fields    []FieldSchema `json:"fields,omitempty"`
+	TagKeys   []string      `json:"tagKeys,omitempty"`
+	Precision int           `json:"precision,omitempty"`
+}
+
+// DatabaseSchema represents the metric schema registry of database,
+// metrics not registered are written without checking.
+type DatabaseSchema struct {
+	Database string         `json:"database" validate:"required"`
+	Mode     SchemaMode     `json:"mode,omitempty"` // lenient if empty
+	Metrics  []MetricSchema `json:"metrics"`
+}
+
+// GetMode returns the enforcement mode of schema, lenient by default.
+func (s *DatabaseSchema) GetMode() SchemaMode {
+	if s.Mode == "" {
+		return SchemaModeLenient
+	}
+	return s.Mode
+}
+
+// Validate checks if the metric schemas of database are valid.
+func (s *DatabaseSchema) Validate() error {
+	switch s.GetMode() {
+	case SchemaModeLenient, SchemaModeStrict:
+	default:
+		return fmt.Errorf("unknown schema mode[%s] of database[%s]", s.Mode, s.Database)
+	}
+	metrics := make(map[string]struct{})
+	for idx := range s.Metrics {
+		metric := &s.Metrics[idx]
+		if metric.Name == "" {
+			return fmt.Errorf("metric name of database[%s]'s schema cannot be empty", s.Database)
+		}
+		key := metric.GetNamespace() + ":" + metric.Name
+		if _, ok := metrics[key]; ok {
+			return fmt.Errorf("duplicate schema of metric[%s] in namespace[%s]", metric.Name, metric.GetNamespace())
+		}
+		metrics[key] = struct{}{}
+		if !validPrecision(metric.Precision) {
+			return fmt.Errorf("invalid precision[%d] of metric[%s], range: [0,%d]",
+				metric.Precision, metric.Name, option.MaxFieldPrecision)
+		}
+		fields := make(map[string]struct{})
+		for _, f := range metric.Fields {
+			if f.Name == "" {
+				return fmt.Errorf("field name of metric[%s] cannot be empty", metric.Name)
+			}
+			if _, ok := fields[f.Name]; ok {
+				return fmt.Errorf("duplicate field[%s] of metric[%s]", f.Name, metric.Name)
+			}
+			fields[f.Name] = struct{}{}
+			switch f.Type {
+			case "sum", "min", "max", "last", "first":
+			default:
+				return fmt.Errorf("unknown type[%s] of field[%s], metric[%s]", f.Type, f.Name, metric.Name)
+			}
+			if f.Retention != "" {
+				var retention timeutil.Interval
+				if err := retention.ValueOf(f.Retention); err != nil || retention <= 0 {
+					return fmt.Errorf("invalid retention[%s] of field[%s], metric[%s]", f.Retention, f.Name, metric.Name)
+				}
+			}
+			if f.Precision != nil && !validPrecision(*f.Precision) {
+				return fmt.Errorf("invalid precision[%d] of field[%s], metric[%s], range: [0,%d]",
+					*f.Precision, f.Name, metric.Name, option.MaxFieldPrecision)
+			}
+		}
+		for _, tagKey := range metric.TagKeys {
+			if tagKey == "" {
+				return fmt.Errorf("tag key of metric[%s] cannot be empty", metric.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// FieldRetentions returns the retention overrides of fields in schema.
+func (s *DatabaseSchema) FieldRetentions() (rs []option.FieldRetention) {
+	for idx := range s.Metrics {
+		metric := &s.Metrics[idx]
+		for _, f := range metric.Fields {
+			if f.Retention == "" {
+				continue
+			}
+			rs = append(rs, option.FieldRetention{
+				Namespace: metric.GetNamespace(),
+				Metric:    metric.Name,
+				Field:     f.Name,
+				Retention: f.Retention,
+			})
+		}
+	}
+	return rs
+}
+
+// FieldPrecisions returns the value precisions of metrics/fields in schema,
+// metric level precision is followed by the overrides of its fields.
+func (s *DatabaseSchema) FieldPrecisions() (rs []option.FieldPrecision) {
+	for idx := range s.Metrics {
+		metric := &s.Metrics[idx]
+		if metric.Precision > 0 {
+			rs = append(rs, option.FieldPrecision{
+				Namespace: metric.GetNamespace(),
+				Metric:    metric.Name,
+				Precision: metric.Precision,
+			})
+		}
+		for _, f := range metric.Fields {
+			if f.Precision == nil {
+				continue
+			}
+			rs = append(rs, option.FieldPrecision{
+				Namespace: metric.GetNamespace(),
+				Metric:    metric.Name,
+				Field:     f.Name,
+				Precision: *f.Precision,
+			})
+		}
+	}
+	return rs
+}
+
+// GetFieldRetention returns the retention override of metric field if set.
+func (s *DatabaseSchema) GetFieldRetention(namespace, metricName, fieldName string) (string, bool) {
+	if namespace == "" {
+		namespace = commonconstants.DefaultNamespace
+	}
+	for idx := range s.Metrics {
+		metric := &s.Metrics[idx]
+		if metric.Name != metricName || metric.GetNamespace() != namespace {
+			continue
+		}
+		for _, f := range metric.Fields {
+			if f.Name == fieldName && f.Retention != "" {
+				return f.Retention, true
+			}
+		}
+	}
+	return "", false
+}
+
+// GetNamespace returns the namespace of metric, default-ns if empty.
+func (m *MetricSchema) GetNamespace() string {
+	if m.Namespace == "" {
+		return commonconstants.DefaultNamespace
+	}
+	return m.Namespace
+}
+
+// validPrecision returns true if the precision(significant decimal digits) is valid.
+func validPrecision(precision int) bool {
+	return precision >= 0 && precision <= option.MaxFieldPrecision
+}
@@ -0,0 +1,160 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/option"
+)
+
+func TestDatabaseSchema_Validate(t *testing.T) {
+	cases := []struct {
+		name    string
+		schema  DatabaseSchema
+		wantErr bool
+	}{
+		{
+			name:   "empty metrics",
+			schema: DatabaseSchema{Database: "db"},
+		},
+		{
+			name: "valid schema",
+			schema: DatabaseSchema{Database: "db", Mode: SchemaModeStrict, Metrics: []MetricSchema{
+				{Name: "cpu", Fields: []FieldSchema{{Name: "usage", Type: "last"}}, TagKeys: []string{"host"}},
+				{Namespace: "ns", Name: "cpu"},
+			}},
+		},
+		{
+			name:    "invalid metric precision",
+			schema:  DatabaseSchema{Database: "db", Metrics: []MetricSchema{{Name: "cpu", Precision: 16}}},
+			wantErr: true,
+		},
+		{
+			name: "invalid field precision",
+			schema: DatabaseSchema{Database: "db", Metrics: []MetricSchema{
+				{Name: "cpu", Fields: []FieldSchema{{Name: "usage", Type: "last", Precision: precision(-1)}}},
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mode",
+			schema:  DatabaseSchema{Database: "db", Mode: "abc"},
+			wantErr: true,
+		},
+		{
+			name:    "empty metric name",
+			schema:  DatabaseSchema{Database: "db", Metrics: []MetricSchema{{}}},
+			wantErr: true,
+		},
+		{
+			name:    "duplicate metric",
+			schema:  DatabaseSchema{Database: "db", Metrics: []MetricSchema{{Name: "cpu"}, {Namespace: "default-ns", Name: "cpu"}}},
+			wantErr: true,
+		},
+		{
+			name:    "empty field name",
+			schema:  DatabaseSchema{Database: "db", Metrics: []MetricSchema{{Name: "cpu", Fields: []FieldSchema{{Type: "sum"}}}}},
+			wantErr: true,
+		},
+		{
+			name: "duplicate field",
+			schema: DatabaseSchema{Database: "db", Metrics: []MetricSchema{{Name: "cpu", Fields: []FieldSchema{
+				{Name: "f", Type: "sum"}, {Name: "f", Type: "max"}}}}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown field type",
+			schema:  DatabaseSchema{Database: "db", Metrics: []MetricSchema{{Name: "cpu", Fields: []FieldSchema{{Name: "f", Type: "gauge"}}}}},
+			wantErr: true,
+		},
+		{
+			name: "invalid field retention",
+			schema: DatabaseSchema{Database: "db", Metrics: []MetricSchema{{Name: "cpu", Fields: []FieldSchema{
+				{Name: "f", Type: "sum", Retention: "7x"}}}}},
+			wantErr: true,
+		},
+		{
+			name:    "empty tag key",
+			schema:  DatabaseSchema{Database: "db", Metrics: []MetricSchema{{Name: "cpu", TagKeys: []string{""}}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schema.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDatabaseSchema_GetMode(t *testing.T) {
+	assert.Equal(t, SchemaModeLenient, (&DatabaseSchema{}).GetMode())
+	assert.Equal(t, SchemaModeStrict, (&DatabaseSchema{Mode: SchemaModeStrict}).GetMode())
+	assert.Equal(t, "default-ns", (&MetricSchema{}).GetNamespace())
+	assert.Equal(t, "ns", (&MetricSchema{Namespace: "ns"}).GetNamespace())
+}
+
+func TestDatabaseSchema_FieldRetentions(t *testing.T) {
+	schema := &DatabaseSchema{Database: "db", Metrics: []MetricSchema{
+		{Name: "cpu", Fields: []FieldSchema{{Name: "usage", Type: "last"}, {Name: "debug", Type: "sum", Retention: "7d"}}},
+		{Namespace: "ns", Name: "mem", Fields: []FieldSchema{{Name: "used", Type: "last", Retention: "30d"}}},
+	}}
+	assert.Equal(t, []option.FieldRetention{
+		{Namespace: "default-ns", Metric: "cpu", Field: "debug", Retention: "7d"},
+		{Namespace: "ns", Metric: "mem", Field: "used", Retention: "30d"},
+	}, schema.FieldRetentions())
+	assert.Empty(t, (&DatabaseSchema{}).FieldRetentions())
+
+	retention, ok := schema.GetFieldRetention("", "cpu", "debug")
+	assert.True(t, ok)
+	assert.Equal(t, "7d", retention)
+	_, ok = schema.GetFieldRetention("", "cpu", "usage")
+	assert.False(t, ok)
+	_, ok = schema.GetFieldRetention("", "mem", "used")
+	assert.False(t, ok)
+	retention, ok = schema.GetFieldRetention("ns", "mem", "used")
+	assert.True(t, ok)
+	assert.Equal(t, "30d", retention)
+}
+
+func TestDatabaseSchema_FieldPrecisions(t *testing.T) {
+	schema := &DatabaseSchema{Database: "db", Metrics: []MetricSchema{
+		{Name: "cpu", Precision: 3, Fields: []FieldSchema{
+			{Name: "usage", Type: "last"},
+			{Name: "idle", Type: "last", Precision: precision(0)},
+			{Name: "count", Type: "sum", Precision: precision(5)},
+		}},
+		{Namespace: "ns", Name: "mem"},
+	}}
+	assert.Equal(t, []option.FieldPrecision{
+		{Namespace: "default-ns", Metric: "cpu", Precision: 3},
+		{Namespace: "default-ns", Metric: "cpu", Field: "idle", Precision: 0},
+		{Namespace: "default-ns", Metric: "cpu", Field: "count", Precision: 5},
+	}, schema.FieldPrecisions())
+	assert.Empty(t, (&DatabaseSchema{}).FieldPrecisions())
+}
+
+func precision(p int) *int {
+	return &p
+}
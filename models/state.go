@@ -171,6 +171,8 @@ type ShardState struct {
 	State   ShardStateType `json:"state"`
 	Leader  NodeID         `json:"leader"`
 	Replica Replica        `json:"replica"`
+	// Split is the split which creates the shard, nil if shard is picked by jump consistent hash.
+	Split *ShardSplit `json:"split,omitempty"`
 }
 
 // FamilyState represents current state of shard's family.
@@ -178,6 +180,9 @@ type FamilyState struct {
 	Database   string     `json:"database"`
 	Shard      ShardState `json:"shard"`
 	FamilyTime int64      `json:"familyTime"`
+	// Compression is the codec of chunks sent via write stream(none/snappy/zstd:<level>),
+	// empty means snappy(sent by old broker).
+	Compression string `json:"compression,omitempty"`
 }
 
 // BrokerState represents broker cluster state.
@@ -224,6 +229,10 @@ type StorageState struct {
 	// TODO remove??
 	ShardAssignments map[string]*ShardAssignment       `json:"shardAssignments"` // database's name => shard assignment
 	ShardStates      map[string]map[ShardID]ShardState `json:"shardStates"`      // database's name => shard state
+
+	// MaintenanceNodes are the nodes restarting gracefully(node id => deadline of restart),
+	// node is offline but the shard leaders on it are kept until deadline.
+	MaintenanceNodes map[NodeID]int64 `json:"maintenanceNodes,omitempty"`
 }
 
 // NewStorageState creates storage cluster state
@@ -279,6 +288,25 @@ func (s *StorageState) NodeOffline(nodeID NodeID) {
 	delete(s.LiveNodes, nodeID)
 }
 
+// StartMaintenance marks the node is restarting gracefully, which will be back before deadline.
+func (s *StorageState) StartMaintenance(nodeID NodeID, until int64) {
+	if s.MaintenanceNodes == nil {
+		s.MaintenanceNodes = make(map[NodeID]int64)
+	}
+	s.MaintenanceNodes[nodeID] = until
+}
+
+// MaintenanceDeadline returns the deadline of restart if node is in maintenance.
+func (s *StorageState) MaintenanceDeadline(nodeID NodeID) (int64, bool) {
+	until, ok := s.MaintenanceNodes[nodeID]
+	return until, ok
+}
+
+// EndMaintenance removes the maintenance mark of node.
+func (s *StorageState) EndMaintenance(nodeID NodeID) {
+	delete(s.MaintenanceNodes, nodeID)
+}
+
 // Stringer returns a human readable string
 func (s *StorageState) String() string {
 	return string(encoding.JSONMarshal(s))
@@ -305,11 +333,43 @@ type StateField struct {
 
 // DataFamilyState represents the state of data family.
 type DataFamilyState struct {
-	ShardID          ShardID               `json:"shardId"`
-	FamilyTime       string                `json:"familyTime"`
-	AckSequences     map[int32]int64       `json:"ackSequences"`
-	ReplicaSequences map[int32]int64       `json:"replicaSequences"`
-	MemoryDatabases  []MemoryDatabaseState `json:"memoryDatabases"`
+	ShardID          ShardID                    `json:"shardId"`
+	FamilyTime       string                     `json:"familyTime"`
+	AckSequences     map[int32]int64            `json:"ackSequences"`
+	ReplicaSequences map[int32]int64            `json:"replicaSequences"`
+	LatestTimestamps map[int32]int64            `json:"latestTimestamps,omitempty"` // leader => timestamp of the highest slot written
+	IngestionLag     int64                      `json:"ingestionLag"`               // lag(ms) between now and the latest data written
+	FlushPaused      bool                       `json:"flushPaused,omitempty"`      // flushing of database paused for maintenance
+	Tiered           bool                       `json:"tiered,omitempty"`           // files moved to object storage
+	VerifyFailed     bool                       `json:"verifyFailed,omitempty"`     // flushed data mismatches memory database
+	LeaderWrites     map[int32]LeaderWriteState `json:"leaderWrites,omitempty"`     // leader => write state, only leaders with failures
+	MemoryDatabases  []MemoryDatabaseState      `json:"memoryDatabases"`
+	Compaction       CompactionState            `json:"compaction"`
+}
+
+// LeaderWriteState represents the write state of rows replicated from one leader into data family,
+// write failures of one leader are isolated from other leaders.
+type LeaderWriteState struct {
+	Failures  int64  `json:"failures"`            // num. of failed write batches
+	Panics    int64  `json:"panics"`              // num. of write batches recovered from panic
+	LastErr   string `json:"lastErr,omitempty"`   // error of last failed write batch
+	LastErrAt int64  `json:"lastErrAt,omitempty"` // timestamp(ms) of last failed write batch
+}
+
+// CompactionState represents the active compaction policy and compaction statistics of family.
+type CompactionState struct {
+	Policy string                    `json:"policy"`
+	Stats  map[string]CompactionStat `json:"stats,omitempty"` // compaction policy => statistics
+}
+
+// CompactionStat represents the statistics of compaction jobs done under one compaction policy.
+type CompactionStat struct {
+	Compactions int64 `json:"compactions"`
+	Failures    int64 `json:"failures"`
+	InputFiles  int64 `json:"inputFiles"`
+	InputBytes  int64 `json:"inputBytes"`
+	OutputFiles int64 `json:"outputFiles"`
+	OutputBytes int64 `json:"outputBytes"`
 }
 
 // MemoryDatabaseState represents the state of memory database.
@@ -97,6 +97,17 @@ func TestStorageState(t *testing.T) {
 	assert.False(t, ok)
 	_, ok = storageState.ShardStates["test"]
 	assert.False(t, ok)
+
+	_, ok = storageState.MaintenanceDeadline(2)
+	assert.False(t, ok)
+	storageState.StartMaintenance(2, 100)
+	until, ok := storageState.MaintenanceDeadline(2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(100), until)
+	assert.Contains(t, storageState.String(), `"maintenanceNodes":{"2":100}`)
+	storageState.EndMaintenance(2)
+	_, ok = storageState.MaintenanceDeadline(2)
+	assert.False(t, ok)
 }
 
 func TestReplicaState_String(t *testing.T) {
@@ -0,0 +1,74 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lindb/lindb/constants"
+)
+
+const (
+	// UnknownMetric represents the type of unknown metric referenced by query.
+	UnknownMetric = "metric"
+	// UnknownField represents the type of unknown field referenced by query.
+	UnknownField = "field"
+)
+
+// UnknownName represents the unknown metric/field referenced by query with close-match suggestions.
+type UnknownName struct {
+	Type        string   `json:"type"` // metric/field
+	Name        string   `json:"name"`
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// String returns the note of unknown name, like: field usge(did you mean: usage, used).
+func (n UnknownName) String() string {
+	if len(n.Suggestions) == 0 {
+		return fmt.Sprintf("%s %s", n.Type, n.Name)
+	}
+	return fmt.Sprintf("%s %s(did you mean: %s)", n.Type, n.Name, strings.Join(n.Suggestions, ", "))
+}
+
+// NewUnknownNameError returns the error of unknown metric/fields, names are carried in error message as json,
+// so that they can be parsed from the error message of remote node.
+func NewUnknownNameError(names []UnknownName) error {
+	data, _ := json.Marshal(names)
+	return fmt.Errorf("%w: %s", constants.ErrUnknownName, data)
+}
+
+// ParseUnknownNames returns the unknown metric/fields carried by error, returns false if it's not unknown name error.
+func ParseUnknownNames(err error) ([]UnknownName, bool) {
+	if err == nil || !errors.Is(err, constants.ErrUnknownName) {
+		return nil, false
+	}
+	msg := err.Error()
+	prefix := constants.ErrUnknownName.Error() + ": "
+	idx := strings.Index(msg, prefix)
+	if idx < 0 {
+		return nil, false
+	}
+	var names []UnknownName
+	if err := json.Unmarshal([]byte(msg[idx+len(prefix):]), &names); err != nil {
+		return nil, false
+	}
+	return names, true
+}
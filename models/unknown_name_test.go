@@ -0,0 +1,63 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/errcode"
+)
+
+func TestUnknownName_String(t *testing.T) {
+	assert.Equal(t, "metric cpu", UnknownName{Type: UnknownMetric, Name: "cpu"}.String())
+	assert.Equal(t, "field usge(did you mean: usage, used)",
+		UnknownName{Type: UnknownField, Name: "usge", Suggestions: []string{"usage", "used"}}.String())
+}
+
+func TestParseUnknownNames(t *testing.T) {
+	names := []UnknownName{
+		{Type: UnknownField, Name: "usge", Suggestions: []string{"usage"}},
+		{Type: UnknownField, Name: "idel"},
+	}
+	err := NewUnknownNameError(names)
+	rs, ok := ParseUnknownNames(err)
+	assert.True(t, ok)
+	assert.Equal(t, names, rs)
+	assert.Equal(t, errcode.NotFound, errcode.CodeOf(err))
+	// parse from error message of remote node
+	rs, ok = ParseUnknownNames(errcode.Parse(err.Error()))
+	assert.True(t, ok)
+	assert.Equal(t, names, rs)
+
+	rs, ok = ParseUnknownNames(nil)
+	assert.False(t, ok)
+	assert.Nil(t, rs)
+	rs, ok = ParseUnknownNames(constants.ErrMetricIDNotFound)
+	assert.False(t, ok)
+	assert.Nil(t, rs)
+	rs, ok = ParseUnknownNames(constants.ErrUnknownName)
+	assert.False(t, ok)
+	assert.Nil(t, rs)
+	rs, ok = ParseUnknownNames(fmt.Errorf("%w: [", constants.ErrUnknownName))
+	assert.False(t, ok)
+	assert.Nil(t, rs)
+}
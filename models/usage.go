@@ -0,0 +1,38 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+// Usage represents the usage of namespace in database within metering interval.
+type Usage struct {
+	Database      string `json:"database"`
+	Namespace     string `json:"namespace"`
+	PointsWritten int64  `json:"pointsWritten"` // fields of rows written
+	BytesWritten  int64  `json:"bytesWritten"`  // bytes of rows written(after parsing)
+	SeriesTouched int64  `json:"seriesTouched"` // distinct series written
+	Queries       int64  `json:"queries"`       // queries executed(sub query of each metric/database)
+	ScanBytes     int64  `json:"scanBytes"`     // bytes of data returned by storage nodes for queries
+}
+
+// Add adds the counters of other usage.
+func (u *Usage) Add(other *Usage) {
+	u.PointsWritten += other.PointsWritten
+	u.BytesWritten += other.BytesWritten
+	u.SeriesTouched += other.SeriesTouched
+	u.Queries += other.Queries
+	u.ScanBytes += other.ScanBytes
+}
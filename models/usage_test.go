@@ -0,0 +1,30 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsage_Add(t *testing.T) {
+	usage := &Usage{PointsWritten: 1, BytesWritten: 2, SeriesTouched: 3, Queries: 4, ScanBytes: 5}
+	usage.Add(&Usage{PointsWritten: 1, BytesWritten: 1, SeriesTouched: 1, Queries: 1, ScanBytes: 1})
+	assert.Equal(t, &Usage{PointsWritten: 2, BytesWritten: 3, SeriesTouched: 4, Queries: 5, ScanBytes: 6}, usage)
+}
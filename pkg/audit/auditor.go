@@ -0,0 +1,277 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+//go:generate mockgen -source=./auditor.go -destination=./auditor_mock.go -package=audit
+
+var (
+	auditor      Auditor = &nopAuditor{}
+	auditorMutex sync.RWMutex
+)
+
+const (
+	// num. of recent audit entries kept in memory.
+	recentEntries = 1000
+	// default num. of audit entries of each node kept in repo.
+	defaultMaxEntriesInRepo = 1000
+	repoWriteTimeout        = 3 * time.Second
+	// anonymous represents the actor of operation without authentication.
+	anonymous = "anonymous"
+)
+
+// Audit operation results.
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Admin operations which are audited.
+const (
+	OpFlushDatabase    = "flush_database"
+	OpPauseFlush       = "pause_flush"
+	OpResumeFlush      = "resume_flush"
+	OpTierFamily       = "tier_family"
+	OpUntierFamily     = "untier_family"
+	OpCheckConsistency = "check_consistency"
+	OpSplitShard       = "split_shard"
+	OpAddReplica       = "add_replica"
+	OpSaveDatabase     = "save_database"
+	OpDropDatabase     = "drop_database"
+	OpRestoreDatabase  = "restore_database"
+	OpCreateStorage    = "create_storage"
+	OpRecoverStorage   = "recover_storage"
+	OpDeleteStorage    = "delete_storage"
+	OpAckDeadLetter    = "ack_dead_letter"
+	OpSavePrincipal    = "save_principal"
+	OpDropPrincipal    = "drop_principal"
+	OpSaveSchema       = "save_schema"
+	OpDropSchema       = "drop_schema"
+	OpSaveFieldAlias   = "save_field_alias"
+	OpDropFieldAlias   = "drop_field_alias"
+	OpSaveRouting      = "save_namespace_routing"
+	OpDropRouting      = "drop_namespace_routing"
+	OpSaveRule         = "save_recording_rule"
+	OpDropRule         = "drop_recording_rule"
+	OpSaveAlert        = "save_alert_rule"
+	OpDropAlert        = "drop_alert_rule"
+	OpImportMetadata   = "import_metadata"
+	OpKillQuery        = "kill_query"
+)
+
+type actorKey struct{}
+
+// WithActor returns a new context with the actor of operation.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor of operation from context, returns anonymous if not authenticated.
+func ActorFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+			return actor
+		}
+	}
+	return anonymous
+}
+
+// Sink represents the destination which audit entries are persisted into.
+type Sink interface {
+	// Write writes the audit entry into sink.
+	Write(entry *models.AuditEntry) error
+	// Close closes the sink.
+	Close() error
+}
+
+// Auditor represents the audit log of admin operations.
+type Auditor interface {
+	// Record records the audit entry of admin operation, never fails the operation.
+	Record(ctx context.Context, operation string, params map[string]string, startTime time.Time, err error)
+	// List returns the audit entries since given time(newest first), limit <= 0 means no limit.
+	List(ctx context.Context, since time.Time, limit int) ([]*models.AuditEntry, error)
+	// Close closes the sinks of auditor.
+	Close()
+}
+
+// GetAuditor returns the auditor of current node.
+func GetAuditor() Auditor {
+	auditorMutex.RLock()
+	defer auditorMutex.RUnlock()
+	return auditor
+}
+
+// InitAuditor initializes the auditor of current node if not initialized, one auditor per process,
+// so in standalone mode broker and storage share the auditor initialized first.
+// Audit entries are written into rolling file(audit.log) under log dir,
+// persisted into state repository for cluster-wide querying if enabled.
+func InitAuditor(node string, cfg *config.Logging, repo state.Repository, registry *linmetric.Registry) {
+	auditorMutex.Lock()
+	defer auditorMutex.Unlock()
+
+	if _, ok := auditor.(*nopAuditor); !ok {
+		return
+	}
+	a := &auditorImpl{
+		node:       node,
+		sinks:      []Sink{newFileSink(cfg)},
+		statistics: metrics.NewAuditStatistics(registry),
+		logger:     logger.GetLogger("Audit", "Auditor"),
+	}
+	if cfg.Audit.PersistToRepo && repo != nil {
+		a.repo = repo
+		a.sinks = append(a.sinks, newRepoSink(node, cfg.Audit.MaxEntriesInRepo, repo))
+	}
+	auditor = a
+}
+
+// CloseAuditor closes the auditor of current node, resets it as nop auditor.
+func CloseAuditor() {
+	auditorMutex.Lock()
+	prev := auditor
+	auditor = &nopAuditor{}
+	auditorMutex.Unlock()
+	prev.Close()
+}
+
+// auditorImpl implements Auditor interface.
+type auditorImpl struct {
+	node  string
+	sinks []Sink
+	repo  state.Repository // nil if not persisting into repo
+
+	recent    []*models.AuditEntry // ring buffer of recent audit entries
+	recentIdx int
+	mutex     sync.Mutex
+
+	statistics *metrics.AuditStatistics
+	logger     *logger.Logger
+}
+
+// Record records the audit entry of admin operation, never fails the operation.
+func (a *auditorImpl) Record(ctx context.Context, operation string, params map[string]string, startTime time.Time, err error) {
+	entry := &models.AuditEntry{
+		Timestamp: startTime.UnixMilli(),
+		Node:      a.node,
+		Actor:     ActorFromContext(ctx),
+		Operation: operation,
+		Params:    params,
+		Result:    ResultSuccess,
+		Duration:  time.Since(startTime).Nanoseconds(),
+	}
+	if err != nil {
+		entry.Result = ResultFailure
+		entry.Error = err.Error()
+	}
+	a.mutex.Lock()
+	if len(a.recent) < recentEntries {
+		a.recent = append(a.recent, entry)
+	} else {
+		a.recent[a.recentIdx] = entry
+	}
+	a.recentIdx = (a.recentIdx + 1) % recentEntries
+	a.mutex.Unlock()
+
+	a.statistics.Records.Incr()
+	for _, sink := range a.sinks {
+		if err0 := sink.Write(entry); err0 != nil {
+			a.statistics.DroppedRecords.Incr()
+			a.logger.Warn("write audit entry failure, drop it",
+				logger.String("operation", operation), logger.Error(err0))
+		}
+	}
+}
+
+// List returns the audit entries since given time(newest first), limit <= 0 means no limit.
+// Reads the entries of all nodes from repo if persisting into repo, else reads recent entries in memory.
+func (a *auditorImpl) List(ctx context.Context, since time.Time, limit int) ([]*models.AuditEntry, error) {
+	var entries []*models.AuditEntry
+	if a.repo != nil {
+		kvs, err := a.repo.List(ctx, constants.AuditLogPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, kv := range kvs {
+			entry := &models.AuditEntry{}
+			if err0 := encoding.JSONUnmarshal(kv.Value, entry); err0 != nil {
+				a.logger.Warn("unmarshal audit entry failure, ignore it",
+					logger.String("key", kv.Key), logger.Error(err0))
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	} else {
+		a.mutex.Lock()
+		entries = append(entries, a.recent...)
+		a.mutex.Unlock()
+	}
+	return filterEntries(entries, since, limit), nil
+}
+
+// Close closes the sinks of auditor.
+func (a *auditorImpl) Close() {
+	for _, sink := range a.sinks {
+		if err := sink.Close(); err != nil {
+			a.logger.Warn("close audit sink failure", logger.Error(err))
+		}
+	}
+}
+
+// filterEntries returns the entries since given time(newest first), limit <= 0 means no limit.
+func filterEntries(entries []*models.AuditEntry, since time.Time, limit int) []*models.AuditEntry {
+	sinceMillis := since.UnixMilli()
+	rs := make([]*models.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Timestamp >= sinceMillis {
+			rs = append(rs, entry)
+		}
+	}
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].Timestamp > rs[j].Timestamp
+	})
+	if limit > 0 && len(rs) > limit {
+		rs = rs[:limit]
+	}
+	return rs
+}
+
+// nopAuditor represents the auditor which drops all entries, used before auditor initialized.
+type nopAuditor struct{}
+
+func (a *nopAuditor) Record(_ context.Context, _ string, _ map[string]string, _ time.Time, _ error) {}
+
+func (a *nopAuditor) List(_ context.Context, _ time.Time, _ int) ([]*models.AuditEntry, error) {
+	return nil, nil
+}
+
+func (a *nopAuditor) Close() {}
@@ -0,0 +1,156 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+func TestActorFromContext(t *testing.T) {
+	var ctx context.Context
+	assert.Equal(t, anonymous, ActorFromContext(ctx))
+	assert.Equal(t, anonymous, ActorFromContext(context.TODO()))
+	assert.Equal(t, anonymous, ActorFromContext(WithActor(context.TODO(), "")))
+	assert.Equal(t, "admin", ActorFromContext(WithActor(context.TODO(), "admin")))
+}
+
+func TestInitAuditor(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		CloseAuditor()
+		ctrl.Finish()
+	}()
+
+	_, ok := GetAuditor().(*nopAuditor)
+	assert.True(t, ok)
+	GetAuditor().Record(context.TODO(), OpFlushDatabase, nil, time.Now(), nil)
+	entries, err := GetAuditor().List(context.TODO(), time.Time{}, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	cfg := config.NewDefaultLogging()
+	cfg.Dir = t.TempDir()
+	cfg.Audit.PersistToRepo = true
+	repo := state.NewMockRepository(ctrl)
+	InitAuditor("node", cfg, repo, linmetric.BrokerRegistry)
+	a, ok := GetAuditor().(*auditorImpl)
+	assert.True(t, ok)
+	assert.Len(t, a.sinks, 2)
+	assert.Equal(t, repo, a.repo)
+	// initialized, ignore
+	InitAuditor("node2", cfg, repo, linmetric.BrokerRegistry)
+	assert.Equal(t, a, GetAuditor())
+
+	CloseAuditor()
+	_, ok = GetAuditor().(*nopAuditor)
+	assert.True(t, ok)
+}
+
+func TestAuditor_Record(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sink := NewMockSink(ctrl)
+	a := &auditorImpl{
+		node:       "node",
+		sinks:      []Sink{sink},
+		statistics: metrics.NewAuditStatistics(linmetric.BrokerRegistry),
+		logger:     logger.GetLogger("Audit", "Test"),
+	}
+	now := time.Now()
+	// sink unavailable, operation not fail
+	sink.EXPECT().Write(gomock.Any()).Return(fmt.Errorf("err"))
+	a.Record(WithActor(context.TODO(), "admin"), OpDropDatabase,
+		map[string]string{"database": "db"}, now.Add(-time.Minute), fmt.Errorf("drop failure"))
+	sink.EXPECT().Write(gomock.Any()).Return(nil)
+	a.Record(context.TODO(), OpFlushDatabase, nil, now, nil)
+
+	entries, err := a.List(context.TODO(), time.Time{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, OpFlushDatabase, entries[0].Operation)
+	assert.Equal(t, ResultSuccess, entries[0].Result)
+	assert.Equal(t, anonymous, entries[0].Actor)
+	assert.Equal(t, OpDropDatabase, entries[1].Operation)
+	assert.Equal(t, ResultFailure, entries[1].Result)
+	assert.Equal(t, "drop failure", entries[1].Error)
+	assert.Equal(t, "admin", entries[1].Actor)
+	assert.Equal(t, "node", entries[1].Node)
+
+	entries, err = a.List(context.TODO(), now.Add(-time.Second), 0)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	entries, err = a.List(context.TODO(), time.Time{}, 1)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// overwrite the oldest entry
+	sink.EXPECT().Write(gomock.Any()).Return(nil).AnyTimes()
+	for i := 0; i < recentEntries; i++ {
+		a.Record(context.TODO(), OpFlushDatabase, nil, now, nil)
+	}
+	entries, err = a.List(context.TODO(), time.Time{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, entries, recentEntries)
+	for _, entry := range entries {
+		assert.Equal(t, OpFlushDatabase, entry.Operation)
+	}
+
+	sink.EXPECT().Close().Return(fmt.Errorf("err"))
+	a.Close()
+}
+
+func TestAuditor_List_Repo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	a := &auditorImpl{
+		repo:   repo,
+		logger: logger.GetLogger("Audit", "Test"),
+	}
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+	entries, err := a.List(context.TODO(), time.Time{}, 0)
+	assert.Error(t, err)
+	assert.Empty(t, entries)
+
+	repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{
+		{Key: "1", Value: []byte("abc")},
+		{Key: "2", Value: encoding.JSONMarshal(&models.AuditEntry{Timestamp: 1, Node: "node1"})},
+		{Key: "3", Value: encoding.JSONMarshal(&models.AuditEntry{Timestamp: 2, Node: "node2"})},
+	}, nil)
+	entries, err = a.List(context.TODO(), time.Time{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "node2", entries[0].Node)
+	assert.Equal(t, "node1", entries[1].Node)
+}
@@ -0,0 +1,96 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"context"
+	"path/filepath"
+
+	"go.uber.org/atomic"
+	lumberjack "gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+const auditLogFileName = "audit.log"
+
+// fileSink implements Sink interface, writes audit entries into rolling file as json lines.
+type fileSink struct {
+	w *lumberjack.Logger
+}
+
+// newFileSink creates a rolling file sink under log dir, shares the rotation settings of logging.
+func newFileSink(cfg *config.Logging) Sink {
+	return &fileSink{
+		w: &lumberjack.Logger{
+			Filename:   filepath.Join(cfg.Dir, auditLogFileName),
+			MaxSize:    int(cfg.MaxSize / 1024 / 1024), // because in lumberjack will * megabyte
+			MaxBackups: int(cfg.MaxBackups),
+			MaxAge:     int(cfg.MaxAge),
+		},
+	}
+}
+
+// Write writes the audit entry as json line.
+func (s *fileSink) Write(entry *models.AuditEntry) error {
+	_, err := s.w.Write(append(encoding.JSONMarshal(entry), '\n'))
+	return err
+}
+
+// Close closes the rolling file.
+func (s *fileSink) Close() error {
+	return s.w.Close()
+}
+
+// repoSink implements Sink interface, persists audit entries into state repository,
+// each node keeps at most maxEntries entries(ring buffer of slots), overwrites the oldest one if full.
+type repoSink struct {
+	node       string
+	maxEntries int
+	seq        atomic.Int64
+	repo       state.Repository
+}
+
+// newRepoSink creates a state repository sink.
+func newRepoSink(node string, maxEntries int, repo state.Repository) Sink {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxEntriesInRepo
+	}
+	return &repoSink{
+		node:       node,
+		maxEntries: maxEntries,
+		repo:       repo,
+	}
+}
+
+// Write puts the audit entry into the next slot of node.
+func (s *repoSink) Write(entry *models.AuditEntry) error {
+	slot := int((s.seq.Inc() - 1) % int64(s.maxEntries))
+	ctx, cancel := context.WithTimeout(context.TODO(), repoWriteTimeout)
+	defer cancel()
+	return s.repo.Put(ctx, constants.GetAuditLogPath(s.node, slot), encoding.JSONMarshal(entry))
+}
+
+// Close does nothing, repo is closed by its owner.
+func (s *repoSink) Close() error {
+	return nil
+}
@@ -0,0 +1,76 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package audit
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+func TestFileSink(t *testing.T) {
+	cfg := config.NewDefaultLogging()
+	cfg.Dir = t.TempDir()
+	sink := newFileSink(cfg)
+	assert.NoError(t, sink.Write(&models.AuditEntry{Operation: OpFlushDatabase}))
+	assert.NoError(t, sink.Write(&models.AuditEntry{Operation: OpDropDatabase}))
+	assert.NoError(t, sink.Close())
+
+	f, err := os.Open(filepath.Join(cfg.Dir, auditLogFileName))
+	assert.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	var ops []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		entry := &models.AuditEntry{}
+		assert.NoError(t, encoding.JSONUnmarshal(scanner.Bytes(), entry))
+		ops = append(ops, entry.Operation)
+	}
+	assert.Equal(t, []string{OpFlushDatabase, OpDropDatabase}, ops)
+}
+
+func TestRepoSink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := state.NewMockRepository(ctrl)
+	sink := newRepoSink("node", 0, repo)
+	assert.Equal(t, defaultMaxEntriesInRepo, sink.(*repoSink).maxEntries)
+
+	sink = newRepoSink("node", 2, repo)
+	repo.EXPECT().Put(gomock.Any(), constants.GetAuditLogPath("node", 0), gomock.Any()).Return(nil)
+	repo.EXPECT().Put(gomock.Any(), constants.GetAuditLogPath("node", 1), gomock.Any()).Return(fmt.Errorf("err"))
+	repo.EXPECT().Put(gomock.Any(), constants.GetAuditLogPath("node", 0), gomock.Any()).Return(nil)
+	assert.NoError(t, sink.Write(&models.AuditEntry{}))
+	assert.Error(t, sink.Write(&models.AuditEntry{}))
+	assert.NoError(t, sink.Write(&models.AuditEntry{}))
+	assert.NoError(t, sink.Close())
+}
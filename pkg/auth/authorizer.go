@@ -0,0 +1,145 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+)
+
+//go:generate mockgen -source=./authorizer.go -destination=./authorizer_mock.go -package=auth
+
+type principalKey struct{}
+
+// WithPrincipal returns a new context with the authenticated principal of request.
+func WithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal of request from context, returns empty if not authenticated.
+func PrincipalFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if principal, ok := ctx.Value(principalKey{}).(string); ok {
+			return principal
+		}
+	}
+	return ""
+}
+
+type tokenKey struct{}
+
+// WithToken returns a new context with the token of request, which is used when forwarding request to other node.
+func WithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the token of request from context, returns empty if not authenticated.
+func TokenFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if token, ok := ctx.Value(tokenKey{}).(string); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// TokenValidator represents the validator of request token, which can be plugged into authorizer.
+type TokenValidator interface {
+	// Validate returns the principal which the token belongs to, returns error if token invalid.
+	Validate(token string) (principal string, err error)
+}
+
+// PrincipalGetter represents the getter of principal's per-database permissions(stored in state repository).
+type PrincipalGetter interface {
+	// GetPrincipal returns the per-database permissions of principal by name.
+	GetPrincipal(name string) (models.Principal, bool)
+}
+
+// Authorizer represents authenticate the token of request and check the permission of principal.
+type Authorizer interface {
+	// Authenticate returns the principal of token, returns ErrUnauthenticated if token invalid.
+	Authenticate(token string) (string, error)
+	// CheckPermission returns ErrForbidden if principal has no permission on database,
+	// empty database means cluster level operation.
+	CheckPermission(principal, database string, permission models.Permission) error
+	// Authorize checks if the principal carried by context can read the database for query.
+	Authorize(ctx context.Context, param *models.ExecuteParam, database string) error
+}
+
+// authorizer implements Authorizer interface.
+type authorizer struct {
+	validators []TokenValidator
+	principals PrincipalGetter
+	admins     map[string]struct{}
+}
+
+// NewAuthorizer creates an authorizer, validators are consulted in order until one of them accepts the token.
+func NewAuthorizer(cfg config.Auth, principals PrincipalGetter, validators ...TokenValidator) Authorizer {
+	admins := make(map[string]struct{})
+	for _, admin := range cfg.Admins {
+		admins[admin] = struct{}{}
+	}
+	return &authorizer{
+		validators: validators,
+		principals: principals,
+		admins:     admins,
+	}
+}
+
+// Authenticate returns the principal of token, returns ErrUnauthenticated if token invalid.
+func (a *authorizer) Authenticate(token string) (string, error) {
+	if token == "" {
+		return "", fmt.Errorf("%w: missing token", constants.ErrUnauthenticated)
+	}
+	for _, validator := range a.validators {
+		if principal, err := validator.Validate(token); err == nil && principal != "" {
+			return principal, nil
+		}
+	}
+	return "", fmt.Errorf("%w: invalid token", constants.ErrUnauthenticated)
+}
+
+// CheckPermission returns ErrForbidden if principal has no permission on database,
+// empty database means cluster level operation.
+func (a *authorizer) CheckPermission(principal, database string, permission models.Permission) error {
+	if principal == "" {
+		return fmt.Errorf("%w: missing token", constants.ErrUnauthenticated)
+	}
+	if _, ok := a.admins[principal]; ok {
+		return nil
+	}
+	if a.principals != nil {
+		if p, ok := a.principals.GetPrincipal(principal); ok && p.HasPermission(database, permission) {
+			return nil
+		}
+	}
+	if database == "" {
+		return fmt.Errorf("%w: principal[%s] has no %s permission on cluster", constants.ErrForbidden, principal, permission)
+	}
+	return fmt.Errorf("%w: principal[%s] has no %s permission on database[%s]",
+		constants.ErrForbidden, principal, permission, database)
+}
+
+// Authorize checks if the principal carried by context can read the database for query.
+func (a *authorizer) Authorize(ctx context.Context, _ *models.ExecuteParam, database string) error {
+	return a.CheckPermission(PrincipalFromContext(ctx), database, models.PermissionRead)
+}
@@ -0,0 +1,151 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+)
+
+func TestPrincipalFromContext(t *testing.T) {
+	var ctx context.Context
+	assert.Empty(t, PrincipalFromContext(ctx))
+	assert.Empty(t, PrincipalFromContext(context.TODO()))
+	assert.Equal(t, "ops", PrincipalFromContext(WithPrincipal(context.TODO(), "ops")))
+}
+
+func TestTokenFromContext(t *testing.T) {
+	var ctx context.Context
+	assert.Empty(t, TokenFromContext(ctx))
+	assert.Empty(t, TokenFromContext(context.TODO()))
+	assert.Equal(t, "abc", TokenFromContext(WithToken(context.TODO(), "abc")))
+}
+
+func TestAuthorizer_Authenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	validator := NewMockTokenValidator(ctrl)
+	authorizer := NewAuthorizer(config.Auth{}, nil,
+		validator, NewStaticTokenValidator(map[string]string{"abc": "ops"}))
+
+	// missing token
+	_, err := authorizer.Authenticate("")
+	assert.True(t, errors.Is(err, constants.ErrUnauthenticated))
+	// validated by static tokens
+	validator.EXPECT().Validate("abc").Return("", fmt.Errorf("err"))
+	principal, err := authorizer.Authenticate("abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "ops", principal)
+	// validated by pluggable validator
+	validator.EXPECT().Validate("jwt").Return("reader", nil)
+	principal, err = authorizer.Authenticate("jwt")
+	assert.NoError(t, err)
+	assert.Equal(t, "reader", principal)
+	// invalid token
+	validator.EXPECT().Validate("bad").Return("", fmt.Errorf("err"))
+	_, err = authorizer.Authenticate("bad")
+	assert.True(t, errors.Is(err, constants.ErrUnauthenticated))
+}
+
+func TestAuthorizer_CheckPermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	principals := NewMockPrincipalGetter(ctrl)
+	principals.EXPECT().GetPrincipal("reader").Return(models.Principal{
+		Name:        "reader",
+		Permissions: map[string][]models.Permission{"db": {models.PermissionRead}},
+	}, true).AnyTimes()
+	principals.EXPECT().GetPrincipal(gomock.Any()).Return(models.Principal{}, false).AnyTimes()
+	authorizer := NewAuthorizer(config.Auth{Admins: []string{"root"}}, principals)
+
+	cases := []struct {
+		name       string
+		principal  string
+		database   string
+		permission models.Permission
+		wantErr    error
+	}{
+		{
+			name:       "not authenticated",
+			database:   "db",
+			permission: models.PermissionRead,
+			wantErr:    constants.ErrUnauthenticated,
+		},
+		{
+			name:       "admin in config",
+			principal:  "root",
+			permission: models.PermissionAdmin,
+		},
+		{
+			name:       "read database",
+			principal:  "reader",
+			database:   "db",
+			permission: models.PermissionRead,
+		},
+		{
+			name:       "write database without permission",
+			principal:  "reader",
+			database:   "db",
+			permission: models.PermissionWrite,
+			wantErr:    constants.ErrForbidden,
+		},
+		{
+			name:       "cluster level without permission",
+			principal:  "reader",
+			permission: models.PermissionRead,
+			wantErr:    constants.ErrForbidden,
+		},
+		{
+			name:       "principal not found",
+			principal:  "unknown",
+			database:   "db",
+			permission: models.PermissionRead,
+			wantErr:    constants.ErrForbidden,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizer.CheckPermission(tt.principal, tt.database, tt.permission)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errors.Is(err, tt.wantErr))
+			}
+		})
+	}
+
+	// authorize query
+	assert.NoError(t, authorizer.Authorize(WithPrincipal(context.TODO(), "reader"), &models.ExecuteParam{}, "db"))
+	assert.Error(t, authorizer.Authorize(WithPrincipal(context.TODO(), "reader"), &models.ExecuteParam{}, "other"))
+	assert.Error(t, authorizer.Authorize(context.TODO(), &models.ExecuteParam{}, "db"))
+	// without principal getter
+	assert.True(t, errors.Is(NewAuthorizer(config.Auth{}, nil).CheckPermission("reader", "db", models.PermissionRead),
+		constants.ErrForbidden))
+}
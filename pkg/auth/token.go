@@ -0,0 +1,50 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+)
+
+// staticTokenValidator validates token by the static tokens in config.
+type staticTokenValidator struct {
+	tokens map[string]string // token => principal
+}
+
+// NewStaticTokenValidator creates a token validator with static tokens(token => principal).
+func NewStaticTokenValidator(tokens map[string]string) TokenValidator {
+	return &staticTokenValidator{
+		tokens: tokens,
+	}
+}
+
+// Validate returns the principal which the token belongs to, returns error if token not found.
+func (v *staticTokenValidator) Validate(token string) (string, error) {
+	principal := ""
+	// compare all tokens in constant time, avoid leaking token by timing
+	for t, p := range v.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			principal = p
+		}
+	}
+	if principal == "" {
+		return "", errors.New("token not found")
+	}
+	return principal, nil
+}
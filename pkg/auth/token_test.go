@@ -0,0 +1,37 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticTokenValidator_Validate(t *testing.T) {
+	validator := NewStaticTokenValidator(map[string]string{"abc": "ops", "def": "reader"})
+	principal, err := validator.Validate("abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "ops", principal)
+	principal, err = validator.Validate("def")
+	assert.NoError(t, err)
+	assert.Equal(t, "reader", principal)
+	principal, err = validator.Validate("ab")
+	assert.Error(t, err)
+	assert.Empty(t, principal)
+}
@@ -0,0 +1,200 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package errcode provides the stable error codes of query/write api,
+// clients decide whether to retry based on code/retriable flag instead of parsing error message.
+package errcode
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Code represents the stable error code returned by api.
+type Code string
+
+// Defines all error codes, NOTE: codes are part of api, cannot be renamed.
+const (
+	Internal            Code = "Internal"
+	InvalidArgument     Code = "InvalidArgument"
+	NotFound            Code = "NotFound"
+	Timeout             Code = "Timeout"
+	NotMaster           Code = "NotMaster"
+	Unavailable         Code = "Unavailable"
+	StorageUnavailable  Code = "StorageUnavailable"
+	NodeBusy            Code = "NodeBusy"
+	SeriesLimitExceeded Code = "SeriesLimitExceeded"
+	MemoryLimitExceeded Code = "MemoryLimitExceeded"
+	OutOfTimeRange      Code = "OutOfTimeRange"
+	WriteRejected       Code = "WriteRejected"
+	ReadOnly            Code = "ReadOnly"
+	QueryKilled         Code = "QueryKilled"
+	InvalidSequence     Code = "InvalidSequence"
+	Unauthenticated     Code = "Unauthenticated"
+	Forbidden           Code = "Forbidden"
+	PayloadTooLarge     Code = "PayloadTooLarge"
+)
+
+// retriableCodes represents the codes which can be retried by client(on other node or later).
+var retriableCodes = map[Code]bool{
+	Timeout:            true,
+	NotMaster:          true,
+	Unavailable:        true,
+	StorageUnavailable: true,
+	NodeBusy:           true,
+}
+
+// Retriable returns if the error with this code can be retried.
+func (c Code) Retriable() bool {
+	return retriableCodes[c]
+}
+
+// HTTPStatus returns the http status code of error code.
+func (c Code) HTTPStatus() int {
+	switch c {
+	case InvalidArgument:
+		return http.StatusBadRequest
+	case Unauthenticated:
+		return http.StatusUnauthorized
+	case Forbidden:
+		return http.StatusForbidden
+	case PayloadTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case NotMaster, Unavailable, StorageUnavailable, NodeBusy:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// Error represents the error with stable code.
+type Error struct {
+	Code      Code
+	Retriable bool
+	Message   string
+	// Details carries the structured details of error for client if set(e.g. suggestions of unknown names).
+	Details interface{}
+
+	cause error
+}
+
+// Error returns the human message of error.
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the cause of error.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// sentinels keeps the well-known errors created by New, used for converting the error message
+// received from remote node(e.g. error message of query task response) back to typed error.
+var sentinels sync.Map
+
+// New creates a well-known error with code, used for defining sentinel errors.
+func New(code Code, message string) error {
+	err := &Error{Code: code, Retriable: code.Retriable(), Message: message}
+	sentinels.Store(message, err)
+	return err
+}
+
+// Wrap wraps the err with code, returns nil if err is nil.
+func Wrap(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Retriable: code.Retriable(), Message: err.Error(), cause: err}
+}
+
+// WithDetails wraps the err with code and structured details, returns nil if err is nil.
+func WithDetails(code Code, err error, details interface{}) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Retriable: code.Retriable(), Message: err.Error(), Details: details, cause: err}
+}
+
+// Of converts err to coded error, returns nil if err is nil.
+// The code of err is picked by the first coded error in chain, context deadline is mapped to Timeout,
+// else the code is Internal.
+func Of(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	switch {
+	case errors.As(err, &e):
+		if e == err {
+			return e
+		}
+		return &Error{Code: e.Code, Retriable: e.Retriable, Message: err.Error(), Details: e.Details, cause: err}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Code: Timeout, Retriable: true, Message: err.Error(), cause: err}
+	default:
+		if e := fromGRPC(err); e != nil {
+			return e
+		}
+		return &Error{Code: Internal, Message: err.Error(), cause: err}
+	}
+}
+
+// CodeOf returns the code of err, returns empty if err is nil.
+func CodeOf(err error) Code {
+	if e := Of(err); e != nil {
+		return e.Code
+	}
+	return ""
+}
+
+// IsRetriable returns if err can be retried.
+func IsRetriable(err error) bool {
+	if e := Of(err); e != nil {
+		return e.Retriable
+	}
+	return false
+}
+
+// Parse converts the error message received from remote node to error,
+// the cause is the well-known error if message contains the message of it.
+func Parse(message string) error {
+	if message == "" {
+		return nil
+	}
+	var cause *Error
+	sentinels.Range(func(key, value interface{}) bool {
+		if strings.Contains(message, key.(string)) {
+			// prefer the most specific(longest) message
+			if cause == nil || len(key.(string)) > len(cause.Message) {
+				cause = value.(*Error)
+			}
+		}
+		return true
+	})
+	if cause == nil {
+		return errors.New(message)
+	}
+	if cause.Message == message {
+		return cause
+	}
+	return &Error{Code: cause.Code, Retriable: cause.Retriable, Message: message, cause: cause}
+}
@@ -0,0 +1,154 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package errcode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errTestBusy    = New(NodeBusy, "test node busy")
+	errTestLimit   = New(SeriesLimitExceeded, "test too many series")
+	errTestLimitEx = New(MemoryLimitExceeded, "test too many series, memory")
+)
+
+func TestOf(t *testing.T) {
+	assert.Nil(t, Of(nil))
+	assert.Equal(t, Code(""), CodeOf(nil))
+	assert.False(t, IsRetriable(nil))
+
+	e := Of(errTestBusy)
+	assert.Equal(t, errTestBusy, e)
+	assert.Equal(t, NodeBusy, e.Code)
+	assert.True(t, e.Retriable)
+
+	wrapped := fmt.Errorf("%w, admission queue is full", errTestBusy)
+	e = Of(wrapped)
+	assert.Equal(t, NodeBusy, e.Code)
+	assert.Equal(t, "test node busy, admission queue is full", e.Error())
+	assert.True(t, errors.Is(e, errTestBusy))
+
+	assert.Equal(t, Timeout, CodeOf(fmt.Errorf("query: %w", context.DeadlineExceeded)))
+	assert.True(t, IsRetriable(context.DeadlineExceeded))
+	assert.Equal(t, Internal, CodeOf(errors.New("err")))
+	assert.False(t, IsRetriable(errors.New("err")))
+	assert.Equal(t, Internal, CodeOf(status.Error(codes.Internal, "err")))
+}
+
+func TestWrap(t *testing.T) {
+	assert.Nil(t, Wrap(InvalidArgument, nil))
+	cause := errors.New("bad param")
+	err := Wrap(InvalidArgument, cause)
+	assert.Equal(t, InvalidArgument, CodeOf(err))
+	assert.Equal(t, "bad param", err.Error())
+	assert.True(t, errors.Is(err, cause))
+}
+
+func TestWithDetails(t *testing.T) {
+	assert.Nil(t, WithDetails(InvalidArgument, nil, "details"))
+	cause := errors.New("unknown field")
+	err := WithDetails(InvalidArgument, cause, []string{"usage"})
+	assert.Equal(t, InvalidArgument, CodeOf(err))
+	assert.Equal(t, "unknown field", err.Error())
+	assert.True(t, errors.Is(err, cause))
+	// details kept if wrapped
+	assert.Equal(t, []string{"usage"}, Of(fmt.Errorf("query failure: %w", err)).Details)
+}
+
+func TestCode(t *testing.T) {
+	cases := []struct {
+		code       Code
+		retriable  bool
+		httpStatus int
+		grpcCode   codes.Code
+	}{
+		{Internal, false, http.StatusInternalServerError, codes.Internal},
+		{InvalidArgument, false, http.StatusBadRequest, codes.InvalidArgument},
+		{NotFound, false, http.StatusInternalServerError, codes.NotFound},
+		{Timeout, true, http.StatusGatewayTimeout, codes.DeadlineExceeded},
+		{NotMaster, true, http.StatusServiceUnavailable, codes.Unavailable},
+		{Unavailable, true, http.StatusServiceUnavailable, codes.Unavailable},
+		{StorageUnavailable, true, http.StatusServiceUnavailable, codes.Unavailable},
+		{NodeBusy, true, http.StatusServiceUnavailable, codes.Unavailable},
+		{SeriesLimitExceeded, false, http.StatusInternalServerError, codes.ResourceExhausted},
+		{MemoryLimitExceeded, false, http.StatusInternalServerError, codes.ResourceExhausted},
+		{OutOfTimeRange, false, http.StatusInternalServerError, codes.OutOfRange},
+		{WriteRejected, false, http.StatusInternalServerError, codes.FailedPrecondition},
+		{ReadOnly, false, http.StatusInternalServerError, codes.FailedPrecondition},
+		{InvalidSequence, false, http.StatusInternalServerError, codes.FailedPrecondition},
+		{QueryKilled, false, http.StatusInternalServerError, codes.Aborted},
+		{Unauthenticated, false, http.StatusUnauthorized, codes.Unauthenticated},
+		{Forbidden, false, http.StatusForbidden, codes.PermissionDenied},
+		{PayloadTooLarge, false, http.StatusRequestEntityTooLarge, codes.ResourceExhausted},
+	}
+	for _, tt := range cases {
+		t.Run(string(tt.code), func(t *testing.T) {
+			assert.Equal(t, tt.retriable, tt.code.Retriable())
+			assert.Equal(t, tt.httpStatus, tt.code.HTTPStatus())
+			assert.Equal(t, tt.grpcCode, tt.code.GRPCCode())
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	assert.Nil(t, Parse(""))
+	assert.Equal(t, errTestBusy, Parse("test node busy"))
+
+	err := Parse("test node busy, wait admission timeout")
+	assert.Equal(t, NodeBusy, CodeOf(err))
+	assert.True(t, errors.Is(err, errTestBusy))
+	assert.Equal(t, "test node busy, wait admission timeout", err.Error())
+	// longest message matched
+	assert.Equal(t, MemoryLimitExceeded, CodeOf(Parse("test too many series, memory exceeded")))
+	assert.Equal(t, SeriesLimitExceeded, CodeOf(Parse("shard 1: test too many series")))
+
+	err = Parse("unknown err")
+	assert.Equal(t, Internal, CodeOf(err))
+	assert.Equal(t, "unknown err", err.Error())
+
+	assert.True(t, errors.Is(Parse(errTestLimit.Error()), errTestLimit))
+	assert.True(t, errors.Is(Parse(errTestLimitEx.Error()), errTestLimitEx))
+}
+
+func TestGRPCError(t *testing.T) {
+	assert.Nil(t, GRPCError(nil))
+
+	err := GRPCError(fmt.Errorf("%w, admission queue is full", errTestBusy))
+	st, ok := status.FromError(err)
+	assert.True(t, ok)
+	assert.Equal(t, codes.Unavailable, st.Code())
+	assert.Equal(t, "test node busy, admission queue is full", st.Message())
+
+	e := Of(err)
+	assert.Equal(t, NodeBusy, e.Code)
+	assert.True(t, e.Retriable)
+	assert.Equal(t, "test node busy, admission queue is full", e.Error())
+
+	err = GRPCError(Wrap(InvalidArgument, errors.New("bad param")))
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, InvalidArgument, CodeOf(err))
+	assert.False(t, IsRetriable(err))
+}
@@ -0,0 +1,95 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package errcode
+
+import (
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// grpcDomain represents the domain of error info in grpc status details.
+	grpcDomain = "lindb.io"
+	// grpcRetriableKey represents the metadata key of retriable flag in error info.
+	grpcRetriableKey = "retriable"
+)
+
+// GRPCCode returns the grpc status code of error code.
+func (c Code) GRPCCode() codes.Code {
+	switch c {
+	case InvalidArgument:
+		return codes.InvalidArgument
+	case NotFound:
+		return codes.NotFound
+	case Timeout:
+		return codes.DeadlineExceeded
+	case NotMaster, Unavailable, StorageUnavailable, NodeBusy:
+		return codes.Unavailable
+	case SeriesLimitExceeded, MemoryLimitExceeded, PayloadTooLarge:
+		return codes.ResourceExhausted
+	case OutOfTimeRange:
+		return codes.OutOfRange
+	case WriteRejected, ReadOnly, InvalidSequence:
+		return codes.FailedPrecondition
+	case QueryKilled:
+		return codes.Aborted
+	case Unauthenticated:
+		return codes.Unauthenticated
+	case Forbidden:
+		return codes.PermissionDenied
+	default:
+		return codes.Internal
+	}
+}
+
+// GRPCError converts err to grpc status error, the code/retriable flag is carried by error info details.
+func GRPCError(err error) error {
+	e := Of(err)
+	if e == nil {
+		return nil
+	}
+	st := status.New(e.Code.GRPCCode(), e.Message)
+	if withDetails, err0 := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   string(e.Code),
+		Domain:   grpcDomain,
+		Metadata: map[string]string{grpcRetriableKey: strconv.FormatBool(e.Retriable)},
+	}); err0 == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// fromGRPC converts the grpc status error created by GRPCError to coded error, returns nil if not.
+func fromGRPC(err error) *Error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return nil
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != grpcDomain {
+			continue
+		}
+		retriable, _ := strconv.ParseBool(info.Metadata[grpcRetriableKey])
+		return &Error{Code: Code(info.Reason), Retriable: retriable, Message: st.Message(), cause: err}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package fault provides the named fault injection points in critical paths(flush/close/replication),
+// tests enable the points to inject errors, delays or panics for reproducing failures deterministically.
+// Points only take effect in the binary built with tag "faultinject"(go test -tags=faultinject),
+// Inject is a no-op in normal builds, so that production code cannot be affected by fault injection.
+package fault
+
+import (
+	"sync"
+	"time"
+)
+
+// Point represents the name of fault injection point.
+type Point string
+
+// Defines all fault injection points.
+const (
+	// FlushBeforeSwapMemDB is before switching mutable memory database to immutable in flush job.
+	FlushBeforeSwapMemDB Point = "tsdb/flush/before-swap-memdb"
+	// FlushAfterSwapMemDB is after switching mutable memory database to immutable in flush job.
+	FlushAfterSwapMemDB Point = "tsdb/flush/after-swap-memdb"
+	// FlushBeforeCloseMemDB is after kv flusher committed and sequence acknowledged, before memory database closed.
+	FlushBeforeCloseMemDB Point = "tsdb/flush/before-close-memdb"
+	// CommitSequence is before committing written sequence of data family, error is ignored.
+	CommitSequence Point = "tsdb/commit-sequence"
+	// KVFlusherCommit is before committing edit log of kv flusher.
+	KVFlusherCommit Point = "kv/flusher/commit"
+	// SnapshotFindReaders is before finding file readers in kv snapshot.
+	SnapshotFindReaders Point = "kv/snapshot/find-readers"
+)
+
+// Action represents the fault injected into point, returns the error injected.
+type Action func() error
+
+// Error returns the action which injects err.
+func Error(err error) Action {
+	return func() error {
+		return err
+	}
+}
+
+// Delay returns the action which sleeps duration.
+func Delay(duration time.Duration) Action {
+	return func() error {
+		time.Sleep(duration)
+		return nil
+	}
+}
+
+// Panic returns the action which panics with v.
+func Panic(v interface{}) Action {
+	return func() error {
+		panic(v)
+	}
+}
+
+// Once returns the action which executes action only for the first time.
+func Once(action Action) Action {
+	var once sync.Once
+	return func() (err error) {
+		once.Do(func() {
+			err = action()
+		})
+		return err
+	}
+}
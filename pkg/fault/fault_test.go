@@ -0,0 +1,62 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build faultinject
+
+package fault
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPoint Point = "test/point"
+
+func TestInject(t *testing.T) {
+	defer DisableAll()
+
+	assert.NoError(t, Inject(testPoint))
+
+	Enable(testPoint, Error(fmt.Errorf("err")))
+	assert.Error(t, Inject(testPoint))
+	assert.NoError(t, Inject(FlushBeforeSwapMemDB))
+	// replace action
+	Enable(testPoint, Once(Error(fmt.Errorf("err"))))
+	assert.Equal(t, int32(1), enabled.Load())
+	assert.Error(t, Inject(testPoint))
+	assert.NoError(t, Inject(testPoint))
+
+	Disable(testPoint)
+	Disable(testPoint)
+	assert.Equal(t, int32(0), enabled.Load())
+	assert.NoError(t, Inject(testPoint))
+
+	Enable(testPoint, Delay(10*time.Millisecond))
+	start := time.Now()
+	assert.NoError(t, Inject(testPoint))
+	assert.GreaterOrEqual(t, time.Since(start), 10*time.Millisecond)
+
+	Enable(FlushBeforeSwapMemDB, Panic("panic"))
+	assert.Panics(t, func() {
+		_ = Inject(FlushBeforeSwapMemDB)
+	})
+	DisableAll()
+	assert.NoError(t, Inject(FlushBeforeSwapMemDB))
+}
@@ -0,0 +1,77 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build faultinject
+
+package fault
+
+import (
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+var (
+	enabled atomic.Int32
+	mutex   sync.RWMutex
+	actions = make(map[Point]Action)
+)
+
+// Enable enables the fault injection point with action, replaces the action if enabled.
+func Enable(point Point, action Action) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := actions[point]; !ok {
+		enabled.Inc()
+	}
+	actions[point] = action
+}
+
+// Disable disables the fault injection point.
+func Disable(point Point) {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if _, ok := actions[point]; ok {
+		delete(actions, point)
+		enabled.Dec()
+	}
+}
+
+// DisableAll disables all fault injection points.
+func DisableAll() {
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	actions = make(map[Point]Action)
+	enabled.Store(0)
+}
+
+// Inject executes the action of point if enabled, returns the error injected.
+func Inject(point Point) error {
+	if enabled.Load() == 0 {
+		return nil
+	}
+	mutex.RLock()
+	action, ok := actions[point]
+	mutex.RUnlock()
+	if !ok {
+		return nil
+	}
+	return action()
+}
@@ -47,6 +47,8 @@ import (
 type Server interface {
 	// GetAPIRouter returns api router.
 	GetAPIRouter() *gin.RouterGroup
+	// GetRootRouter returns root router, used for the routes outside api root(such as health check).
+	GetRootRouter() *gin.RouterGroup
 	// Run runs the HTTP server.
 	Run() error
 	// Close closes the server.
@@ -132,6 +134,11 @@ func (s *server) GetAPIRouter() *gin.RouterGroup {
 	return s.gin.Group(constants.APIRoot)
 }
 
+// GetRootRouter returns root router, used for the routes outside api root(such as health check).
+func (s *server) GetRootRouter() *gin.RouterGroup {
+	return &s.gin.RouterGroup
+}
+
 // Run runs the HTTP server.
 func (s *server) Run() error {
 	s.logger.Info("starting http server", logger.String("addr", s.server.Addr))
@@ -37,6 +37,7 @@ func TestNewHTTPServer(t *testing.T) {
 	config.Doc = true
 	s := NewServer(config.HTTP{Port: 9999}, true, linmetric.BrokerRegistry)
 	assert.NotNil(t, s.GetAPIRouter())
+	assert.NotNil(t, s.GetRootRouter())
 	go func() {
 		_ = s.Run()
 	}()
@@ -24,6 +24,7 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/audit"
 	"github.com/lindb/lindb/pkg/encoding"
 )
 
@@ -55,7 +56,8 @@ func (u *userAuthentication) Validate(next http.Handler) http.Handler {
 		if len(token) > 0 {
 			claims := parseToken(token, u.user)
 			if claims.UserName == u.user.UserName && claims.Password == u.user.Password {
-				next.ServeHTTP(w, r)
+				// carry the actor of request for auditing admin operations
+				next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), claims.UserName)))
 				return
 			}
 		}
@@ -28,6 +28,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/audit"
 )
 
 func Test_ParseToken(t *testing.T) {
@@ -48,7 +49,9 @@ func TestUserAuthentication_Validate(t *testing.T) {
 	user := config.User{UserName: "admin", Password: "admin123"}
 	auth := NewAuthentication(user)
 
+	actor := ""
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		actor = audit.ActorFromContext(r.Context())
 		w.WriteHeader(http.StatusOK)
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = io.WriteString(w, "ok")
@@ -68,4 +71,5 @@ func TestUserAuthentication_Validate(t *testing.T) {
 
 	assert.Equal(t, http.StatusOK, rr.Code)
 	assert.Equal(t, "ok", rr.Body.String())
+	assert.Equal(t, "admin", actor)
 }
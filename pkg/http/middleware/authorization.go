@@ -0,0 +1,100 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/auth"
+)
+
+const bearerPrefix = "Bearer "
+
+// AuthErrorResponse represents the structured error of authentication/authorization failure.
+type AuthErrorResponse struct {
+	Code    string `json:"code"` // unauthenticated/forbidden
+	Message string `json:"message"`
+}
+
+// TokenAuthentication authenticates the request by header "Authorization: Bearer <token>",
+// carries the principal in request context if success, else aborts request with 401.
+func TokenAuthentication(authorizer auth.Authorizer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader("Authorization")
+		if len(token) >= len(bearerPrefix) && strings.EqualFold(token[:len(bearerPrefix)], bearerPrefix) {
+			token = token[len(bearerPrefix):]
+		}
+		token = strings.TrimSpace(token)
+		principal, err := authorizer.Authenticate(token)
+		if err != nil {
+			AbortWithAuthError(c, err)
+			return
+		}
+		// carry the principal for authorization and the actor for auditing admin operations
+		ctx := auth.WithPrincipal(auth.WithToken(c.Request.Context(), token), principal)
+		c.Request = c.Request.WithContext(audit.WithActor(ctx, principal))
+		c.Next()
+	}
+}
+
+// RequirePermission checks the permission of principal on the database extracted by databaseFn,
+// nil databaseFn means cluster level operation, aborts request with 403 if denied.
+func RequirePermission(authorizer auth.Authorizer, permission models.Permission, databaseFn func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		database := ""
+		if databaseFn != nil {
+			database = databaseFn(c)
+		}
+		if err := authorizer.CheckPermission(auth.PrincipalFromContext(c.Request.Context()), database, permission); err != nil {
+			AbortWithAuthError(c, err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// DatabaseFromQuery returns the function which extracts database name from query param.
+func DatabaseFromQuery(key string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		return c.Query(key)
+	}
+}
+
+// AbortWithAuthError aborts request with 401/403 and structured error if err is authentication/authorization failure,
+// returns false if err is not authentication/authorization failure.
+func AbortWithAuthError(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, constants.ErrUnauthenticated):
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, &AuthErrorResponse{Code: "unauthenticated", Message: err.Error()})
+		return true
+	case errors.Is(err, constants.ErrForbidden):
+		_ = c.Error(err)
+		c.AbortWithStatusJSON(http.StatusForbidden, &AuthErrorResponse{Code: "forbidden", Message: err.Error()})
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,149 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/audit"
+	"github.com/lindb/lindb/pkg/auth"
+)
+
+func TestTokenAuthentication(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authorizer := auth.NewMockAuthorizer(ctrl)
+	principal, actor, token := "", "", ""
+	r := gin.New()
+	r.Use(TokenAuthentication(authorizer))
+	r.GET("/test", func(c *gin.Context) {
+		principal = auth.PrincipalFromContext(c.Request.Context())
+		token = auth.TokenFromContext(c.Request.Context())
+		actor = audit.ActorFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	cases := []struct {
+		name    string
+		header  string
+		prepare func()
+		code    int
+	}{
+		{
+			name: "missing token",
+			prepare: func() {
+				authorizer.EXPECT().Authenticate("").Return("", fmt.Errorf("%w: missing token", constants.ErrUnauthenticated))
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name:   "invalid token",
+			header: "Bearer bad",
+			prepare: func() {
+				authorizer.EXPECT().Authenticate("bad").Return("", fmt.Errorf("%w: invalid token", constants.ErrUnauthenticated))
+			},
+			code: http.StatusUnauthorized,
+		},
+		{
+			name:   "bearer token",
+			header: "bearer abc",
+			prepare: func() {
+				authorizer.EXPECT().Authenticate("abc").Return("ops", nil)
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "raw token",
+			header: "abc",
+			prepare: func() {
+				authorizer.EXPECT().Authenticate("abc").Return("ops", nil)
+			},
+			code: http.StatusOK,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			principal, actor, token = "", "", ""
+			tt.prepare()
+			req := httptest.NewRequest(http.MethodGet, "/test", http.NoBody)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp := httptest.NewRecorder()
+			r.ServeHTTP(resp, req)
+			assert.Equal(t, tt.code, resp.Code)
+			if tt.code == http.StatusOK {
+				assert.Equal(t, "ops", principal)
+				assert.Equal(t, "ops", actor)
+				assert.Equal(t, "abc", token)
+			} else {
+				assert.Contains(t, resp.Body.String(), `"code":"unauthenticated"`)
+			}
+		})
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	authorizer := auth.NewMockAuthorizer(ctrl)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), "ops"))
+	})
+	r.PUT("/write", RequirePermission(authorizer, models.PermissionWrite, DatabaseFromQuery("db")), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	r.GET("/admin", RequirePermission(authorizer, models.PermissionAdmin, nil), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	authorizer.EXPECT().CheckPermission("ops", "db", models.PermissionWrite).Return(nil)
+	resp := httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/write?db=db", http.NoBody))
+	assert.Equal(t, http.StatusNoContent, resp.Code)
+
+	authorizer.EXPECT().CheckPermission("ops", "db2", models.PermissionWrite).
+		Return(fmt.Errorf("%w: no write permission", constants.ErrForbidden))
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodPut, "/write?db=db2", http.NoBody))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"forbidden"`)
+
+	authorizer.EXPECT().CheckPermission("ops", "", models.PermissionAdmin).Return(nil)
+	resp = httptest.NewRecorder()
+	r.ServeHTTP(resp, httptest.NewRequest(http.MethodGet, "/admin", http.NoBody))
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestAbortWithAuthError(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	assert.False(t, AbortWithAuthError(c, fmt.Errorf("err")))
+}
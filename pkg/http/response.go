@@ -22,6 +22,9 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/lindb/lindb/pkg/errcode"
+	"github.com/lindb/lindb/pkg/http/middleware"
 )
 
 // OK responses with content and set the http status code 200.
@@ -40,10 +43,41 @@ func NotFound(c *gin.Context) {
 	response(c, http.StatusNotFound, nil)
 }
 
-// Error responses error message and set the http status code 500.
+// ServiceUnavailable responses with content and set the http status code 503.
+func ServiceUnavailable(c *gin.Context, content interface{}) {
+	response(c, http.StatusServiceUnavailable, content)
+}
+
+// TooManyRequests responses with content and set the http status code 429.
+func TooManyRequests(c *gin.Context, content interface{}) {
+	response(c, http.StatusTooManyRequests, content)
+}
+
+// ErrorResponse represents the structured error response, error keeps the message for compatibility.
+type ErrorResponse struct {
+	Error     string       `json:"error"`
+	Code      errcode.Code `json:"code"`
+	Retriable bool         `json:"retriable"`
+	Message   string       `json:"message"`
+	// Details carries the structured details of error if any, like the suggestions of unknown metric/fields.
+	Details interface{} `json:"details,omitempty"`
+}
+
+// Error responses structured error with stable code, the http status code is picked by error code(default 500),
+// responses 401/403 with structured error if authentication/authorization failure.
 func Error(c *gin.Context, err error) {
+	if middleware.AbortWithAuthError(c, err) {
+		return
+	}
 	_ = c.Error(err)
-	response(c, http.StatusInternalServerError, err.Error())
+	e := errcode.Of(err)
+	response(c, e.Code.HTTPStatus(), &ErrorResponse{
+		Error:     e.Message,
+		Code:      e.Code,
+		Retriable: e.Retriable,
+		Message:   e.Message,
+		Details:   e.Details,
+	})
 }
 
 // response responses json body for http restful api
@@ -25,6 +25,9 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/errcode"
 )
 
 func TestOK(t *testing.T) {
@@ -51,10 +54,65 @@ func TestNotFound(t *testing.T) {
 	assert.Equal(t, 4, resp.Body.Len())
 }
 
+func TestServiceUnavailable(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	ServiceUnavailable(c, "not ready")
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t, `"not ready"`, resp.Body.String())
+}
+
+func TestTooManyRequests(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	TooManyRequests(c, "rate limited")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, `"rate limited"`, resp.Body.String())
+}
+
 func TestError(t *testing.T) {
 	resp := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(resp)
 	Error(c, fmt.Errorf("err"))
 	assert.Equal(t, http.StatusInternalServerError, resp.Code)
-	assert.Equal(t, `"err"`, resp.Body.String())
+	assert.Equal(t, `{"error":"err","code":"Internal","retriable":false,"message":"err"}`, resp.Body.String())
+}
+
+func TestError_Code(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	Error(c, fmt.Errorf("%w, admission queue is full", constants.ErrNodeBusy))
+	assert.Equal(t, http.StatusServiceUnavailable, resp.Code)
+	assert.Equal(t,
+		`{"error":"node busy, admission queue is full","code":"NodeBusy","retriable":true,"message":"node busy, admission queue is full"}`,
+		resp.Body.String())
+
+	resp = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(resp)
+	Error(c, constants.ErrTooManySeries)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Contains(t, resp.Body.String(), `"code":"SeriesLimitExceeded","retriable":false`)
+}
+
+func TestError_Details(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	Error(c, errcode.WithDetails(errcode.InvalidArgument, fmt.Errorf("unknown field"), []string{"usage"}))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t,
+		`{"error":"unknown field","code":"InvalidArgument","retriable":false,"message":"unknown field","details":["usage"]}`,
+		resp.Body.String())
+}
+
+func TestError_Auth(t *testing.T) {
+	resp := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(resp)
+	Error(c, fmt.Errorf("%w: invalid token", constants.ErrUnauthenticated))
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+
+	resp = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(resp)
+	Error(c, fmt.Errorf("%w: no permission", constants.ErrForbidden))
+	assert.Equal(t, http.StatusForbidden, resp.Code)
+	assert.Equal(t, `{"code":"forbidden","message":"permission denied: no permission"}`, resp.Body.String())
 }
@@ -0,0 +1,174 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelUnset represents the level of module is not set, module follows the running level.
+const levelUnset = int32(zapcore.FatalLevel + 1)
+
+// ErrModuleNotFound represents the logger module not registered.
+var ErrModuleNotFound = errors.New("logger module not found")
+
+var (
+	// modules of all created loggers, module name => *moduleLevel
+	modules sync.Map
+	// modulesLock protects the revert timers of modules and minModuleLevel
+	modulesLock sync.Mutex
+	// minimum level set to modules, the core needs to enable it
+	minModuleLevel = levelUnset
+	// levelEnabler enables the running level and the levels set to modules,
+	// the level of each module is checked by logger before writing.
+	levelEnabler = zap.LevelEnablerFunc(func(l zapcore.Level) bool {
+		return RunningAtomicLevel.Enabled(l) || int32(l) >= atomic.LoadInt32(&minModuleLevel)
+	})
+)
+
+// ModuleLevel represents the running level of logger module.
+type ModuleLevel struct {
+	Module string `json:"module"`
+	Level  string `json:"level"`
+	// Overridden is true if level is set to module, else module follows the running level.
+	Overridden bool `json:"overridden"`
+	// RevertAt is the timestamp(ms) of reverting to the running level, 0 if not revert automatically.
+	RevertAt int64 `json:"revertAt,omitempty"`
+}
+
+// moduleLevel represents the level of logger module, shared by all logger instances of module,
+// so that the changed level applies to created loggers.
+type moduleLevel struct {
+	level    int32 // levelUnset if module follows the running level
+	revert   *time.Timer
+	revertAt int64
+}
+
+// enabled returns true if given level is enabled for module.
+func (m *moduleLevel) enabled(l zapcore.Level) bool {
+	level := atomic.LoadInt32(&m.level)
+	if level == levelUnset {
+		return RunningAtomicLevel.Enabled(l)
+	}
+	return int32(l) >= level
+}
+
+// moduleName returns the module name of logger, like: TSDB/Family.
+func moduleName(module, role string) string {
+	if role == "" {
+		return module
+	}
+	return module + "/" + role
+}
+
+// registerModule returns the level of module, registers it if not exist.
+func registerModule(name string) *moduleLevel {
+	level, _ := modules.LoadOrStore(name, &moduleLevel{level: levelUnset})
+	return level.(*moduleLevel)
+}
+
+// ModuleLevels returns the running levels of all registered logger modules, sorted by module name.
+func ModuleLevels() (levels []ModuleLevel) {
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	modules.Range(func(key, value interface{}) bool {
+		m := value.(*moduleLevel)
+		level := atomic.LoadInt32(&m.level)
+		item := ModuleLevel{Module: key.(string), Overridden: level != levelUnset, RevertAt: m.revertAt}
+		if item.Overridden {
+			item.Level = zapcore.Level(level).String()
+		} else {
+			item.Level = RunningAtomicLevel.Level().String()
+		}
+		levels = append(levels, item)
+		return true
+	})
+	sort.Slice(levels, func(i, j int) bool {
+		return levels[i].Module < levels[j].Module
+	})
+	return levels
+}
+
+// SetModuleLevel sets the level of logger module at runtime, the level applies to all created loggers of module.
+// Empty level reverts the module to the running level, reverts automatically after revertAfter if it > 0.
+func SetModuleLevel(module, level string, revertAfter time.Duration) error {
+	value, ok := modules.Load(module)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrModuleNotFound, module)
+	}
+	newLevel := levelUnset
+	if level != "" {
+		var zapLevel zapcore.Level
+		if err := zapLevel.UnmarshalText([]byte(level)); err != nil {
+			return err
+		}
+		newLevel = int32(zapLevel)
+	}
+	m := value.(*moduleLevel)
+
+	modulesLock.Lock()
+	defer modulesLock.Unlock()
+
+	if m.revert != nil {
+		m.revert.Stop()
+		m.revert = nil
+		m.revertAt = 0
+	}
+	atomic.StoreInt32(&m.level, newLevel)
+	if newLevel != levelUnset && revertAfter > 0 {
+		var timer *time.Timer
+		timer = time.AfterFunc(revertAfter, func() {
+			modulesLock.Lock()
+			defer modulesLock.Unlock()
+
+			if m.revert != timer {
+				// level changed again
+				return
+			}
+			m.revert = nil
+			m.revertAt = 0
+			atomic.StoreInt32(&m.level, levelUnset)
+			resetMinModuleLevel()
+		})
+		m.revert = timer
+		m.revertAt = time.Now().Add(revertAfter).UnixMilli()
+	}
+	resetMinModuleLevel()
+	return nil
+}
+
+// resetMinModuleLevel calculates the minimum level set to modules, must be called with lock.
+func resetMinModuleLevel() {
+	minLevel := levelUnset
+	modules.Range(func(_, value interface{}) bool {
+		if level := atomic.LoadInt32(&value.(*moduleLevel).level); level < minLevel {
+			minLevel = level
+		}
+		return true
+	})
+	atomic.StoreInt32(&minModuleLevel, minLevel)
+}
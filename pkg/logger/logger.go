@@ -83,6 +83,7 @@ func IsTerminal(f *os.File) bool {
 type Logger struct {
 	module string
 	role   string
+	level  *moduleLevel // shared level of module
 }
 
 // GetLogger returns under logger impl.
@@ -109,24 +110,36 @@ func (l *Logger) getInitializedOrDefaultLogger() *zap.Logger {
 // Debug logs a message at DebugLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Debug(msg string, fields ...zap.Field) {
+	if !l.level.enabled(zapcore.DebugLevel) {
+		return
+	}
 	l.getInitializedOrDefaultLogger().Debug(l.formatMsg(msg), fields...)
 }
 
 // Info logs a message at InfoLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Info(msg string, fields ...zap.Field) {
+	if !l.level.enabled(zapcore.InfoLevel) {
+		return
+	}
 	l.getInitializedOrDefaultLogger().Info(l.formatMsg(msg), fields...)
 }
 
 // Warn logs a message at WarnLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Warn(msg string, fields ...zap.Field) {
+	if !l.level.enabled(zapcore.WarnLevel) {
+		return
+	}
 	l.getInitializedOrDefaultLogger().Warn(l.formatMsg(msg), fields...)
 }
 
 // Error logs a message at ErrorLevel. The message includes any fields passed
 // at the log site, as well as any fields accumulated on the logger.
 func (l *Logger) Error(msg string, fields ...zap.Field) {
+	if !l.level.enabled(zapcore.ErrorLevel) {
+		return
+	}
 	l.getInitializedOrDefaultLogger().Error(l.formatMsg(msg), fields...)
 }
 
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap/zapcore"
@@ -118,3 +119,52 @@ func Test_InitLogger(t *testing.T) {
 	}()
 	assert.Nil(t, InitLogger(cfg4, "test.log"))
 }
+
+func Test_ModuleLevel(t *testing.T) {
+	RunningAtomicLevel.SetLevel(zapcore.InfoLevel)
+	defer RunningAtomicLevel.SetLevel(zapcore.InfoLevel)
+
+	logger1 := GetLogger("Level", "Family")
+	logger2 := GetLogger("Level", "Family")
+	assert.False(t, logger1.level.enabled(zapcore.DebugLevel))
+	assert.False(t, levelEnabler.Enabled(zapcore.DebugLevel))
+
+	assert.ErrorIs(t, SetModuleLevel("Level/Unknown", "debug", 0), ErrModuleNotFound)
+	assert.Error(t, SetModuleLevel("Level/Family", "xxx", 0))
+
+	// level applies to created loggers
+	assert.NoError(t, SetModuleLevel("Level/Family", "debug", 0))
+	assert.True(t, logger1.level.enabled(zapcore.DebugLevel))
+	assert.True(t, logger2.level.enabled(zapcore.DebugLevel))
+	assert.True(t, levelEnabler.Enabled(zapcore.DebugLevel))
+	assert.False(t, GetLogger("Level", "Shard").level.enabled(zapcore.DebugLevel))
+	logger1.Debug("debug for module")
+	assert.Contains(t, ModuleLevels(), ModuleLevel{Module: "Level/Family", Level: "debug", Overridden: true})
+	assert.Contains(t, ModuleLevels(), ModuleLevel{Module: "Level/Shard", Level: "info"})
+
+	// higher level than running level
+	assert.NoError(t, SetModuleLevel("Level/Family", "error", 0))
+	assert.False(t, logger1.level.enabled(zapcore.WarnLevel))
+	assert.False(t, levelEnabler.Enabled(zapcore.DebugLevel))
+	logger1.Warn("warn is disabled")
+
+	// revert to running level
+	assert.NoError(t, SetModuleLevel("Level/Family", "", 0))
+	assert.True(t, logger1.level.enabled(zapcore.WarnLevel))
+	assert.False(t, logger1.level.enabled(zapcore.DebugLevel))
+
+	// revert automatically
+	assert.NoError(t, SetModuleLevel("Level/Family", "debug", 10*time.Millisecond))
+	assert.True(t, logger1.level.enabled(zapcore.DebugLevel))
+	assert.Eventually(t, func() bool {
+		return !logger1.level.enabled(zapcore.DebugLevel)
+	}, time.Second, 5*time.Millisecond)
+	assert.False(t, levelEnabler.Enabled(zapcore.DebugLevel))
+
+	// changed level cancels revert
+	assert.NoError(t, SetModuleLevel("Level/Family", "debug", 10*time.Millisecond))
+	assert.NoError(t, SetModuleLevel("Level/Family", "warn", 0))
+	time.Sleep(30 * time.Millisecond)
+	assert.False(t, logger1.level.enabled(zapcore.InfoLevel))
+	assert.NoError(t, SetModuleLevel("Level/Family", "", 0))
+}
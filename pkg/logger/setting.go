@@ -77,6 +77,7 @@ func GetLogger(module, role string) *Logger {
 	return &Logger{
 		module: module,
 		role:   role,
+		level:  registerModule(moduleName(module, role)),
 	}
 }
 
@@ -88,7 +89,7 @@ func newDefaultLogger() *zap.Logger {
 	core := zapcore.NewCore(
 		zapcore.NewConsoleEncoder(encoderConfig),
 		os.Stdout,
-		RunningAtomicLevel)
+		levelEnabler)
 	return zap.New(core, zap.AddCaller(), zap.AddCallerSkip(2))
 }
 
@@ -131,7 +132,7 @@ func initLogger(logFilename string, cfg config.Logging) error {
 	core := zapcore.NewCore(
 		zapcore.NewConsoleEncoder(encoderConfig),
 		w,
-		RunningAtomicLevel)
+		levelEnabler)
 	switch logFilename {
 	case accessLogFileName:
 		accessLogger.Store(zap.New(core))
@@ -0,0 +1,295 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metering
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	commonconstants "github.com/lindb/common/constants"
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/series/metric"
+)
+
+//go:generate mockgen -source=./meter.go -destination=./meter_mock.go -package=metering
+
+// for testing
+var (
+	nowFn = time.Now
+)
+
+const (
+	// UsageMetricName is the metric name of usage written into monitor database.
+	UsageMetricName = "lindb.broker.usage"
+	// flushTimeout is the timeout of writing usage into monitor database.
+	flushTimeout = 10 * time.Second
+)
+
+// RowsWriter represents the writer which writes rows into database through ingestion path.
+type RowsWriter interface {
+	// WriteRows writes rows into database.
+	WriteRows(ctx context.Context, database string, rows *metric.BrokerBatchRows) error
+}
+
+// Meter represents the usage meter of ingestion and query, usage is keyed by database/namespace,
+// accumulated in memory then flushed into monitor database periodically.
+type Meter interface {
+	// RecordWrite records the usage of rows written into database successfully.
+	RecordWrite(database string, rows *metric.BrokerBatchRows)
+	// RecordQuery records the usage of query executed.
+	RecordQuery(database, namespace string, scanBytes int64)
+	// Usage returns the usage of current interval(not flushed).
+	Usage() []*models.Usage
+	// Start starts flushing usage periodically.
+	Start()
+	// Stop stops flushing, flushes the usage of current interval before returning.
+	Stop()
+}
+
+// usageKey represents the key of usage.
+type usageKey struct {
+	database  string
+	namespace string
+}
+
+// usageCounter represents the usage counters of database/namespace.
+type usageCounter struct {
+	usage  models.Usage
+	series map[uint64]struct{} // hash of series written, for counting distinct series
+}
+
+// meter implements Meter interface.
+type meter struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	interval time.Duration
+	database string // database which usage is written into
+	node     string
+	writer   RowsWriter
+
+	usages map[usageKey]*usageCounter
+	lock   sync.Mutex
+	done   chan struct{}
+
+	statistics *metrics.MeteringStatistics
+	logger     *logger.Logger
+}
+
+// NewMeter creates a Meter instance.
+func NewMeter(ctx context.Context, interval time.Duration, database, node string, writer RowsWriter) Meter {
+	c, cancel := context.WithCancel(ctx)
+	return &meter{
+		ctx:        c,
+		cancel:     cancel,
+		interval:   interval,
+		database:   database,
+		node:       node,
+		writer:     writer,
+		usages:     make(map[usageKey]*usageCounter),
+		done:       make(chan struct{}),
+		statistics: metrics.NewMeteringStatistics(),
+		logger:     logger.GetLogger("Metering", "Meter"),
+	}
+}
+
+// RecordWrite records the usage of rows written into database successfully.
+func (m *meter) RecordWrite(database string, rows *metric.BrokerBatchRows) {
+	if rows == nil || rows.Len() == 0 {
+		return
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var counter *usageCounter
+	for _, row := range rows.Rows() {
+		if row.IsOutOfTimeRange {
+			// row is dropped, not written into storage
+			continue
+		}
+		flatMetric := row.Metric()
+		namespace := string(flatMetric.Namespace())
+		if counter == nil || counter.usage.Namespace != namespace {
+			counter = m.getCounter(database, namespace)
+		}
+		points := flatMetric.SimpleFieldsLength()
+		if flatMetric.CompoundField(nil) != nil {
+			points++
+		}
+		counter.usage.PointsWritten += int64(points)
+		counter.usage.BytesWritten += int64(row.Size())
+		counter.series[xxhash.Sum64(flatMetric.Name())^flatMetric.Hash()] = struct{}{}
+	}
+}
+
+// RecordQuery records the usage of query executed.
+func (m *meter) RecordQuery(database, namespace string, scanBytes int64) {
+	if namespace == "" {
+		namespace = commonconstants.DefaultNamespace
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	counter := m.getCounter(database, namespace)
+	counter.usage.Queries++
+	counter.usage.ScanBytes += scanBytes
+}
+
+// Usage returns the usage of current interval(not flushed).
+func (m *meter) Usage() []*models.Usage {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return collectUsage(m.usages)
+}
+
+// Start starts flushing usage periodically.
+func (m *meter) Start() {
+	go func() {
+		defer close(m.done)
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.flush()
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops flushing, flushes the usage of current interval before returning.
+func (m *meter) Stop() {
+	m.cancel()
+	<-m.done
+	// flush the usage of current interval, avoid losing usage when broker restarts
+	m.flush()
+}
+
+// flush writes the usage of current interval into monitor database,
+// usage is merged back for next flush if writing fails.
+func (m *meter) flush() {
+	m.lock.Lock()
+	usages := m.usages
+	m.usages = make(map[usageKey]*usageCounter)
+	m.lock.Unlock()
+
+	if len(usages) == 0 {
+		return
+	}
+	result := collectUsage(usages)
+	rows, err := m.toRows(result)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+		err = m.writer.WriteRows(ctx, m.database, rows)
+		cancel()
+	}
+	if err != nil {
+		m.statistics.FlushFailures.Incr()
+		m.logger.Error("flush usage failure, retry next interval", logger.Error(err))
+		m.mergeBack(result)
+		return
+	}
+	m.statistics.Flushes.Incr()
+}
+
+// mergeBack merges the usage failed to flush into current interval.
+func (m *meter) mergeBack(usages []*models.Usage) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, usage := range usages {
+		// distinct series of different intervals are counted separately
+		m.getCounter(usage.Database, usage.Namespace).usage.Add(usage)
+	}
+}
+
+// toRows converts the usage into rows of usage metric.
+func (m *meter) toRows(usages []*models.Usage) (*metric.BrokerBatchRows, error) {
+	rows := metric.NewBrokerBatchRows()
+	converter, releaseFunc := metric.NewBrokerRowProtoConverter(nil, nil)
+	defer releaseFunc(converter)
+
+	timestamp := nowFn().UnixMilli()
+	for _, usage := range usages {
+		fields := []*protoMetricsV1.SimpleField{
+			{Name: "points_written", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: float64(usage.PointsWritten)},
+			{Name: "bytes_written", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: float64(usage.BytesWritten)},
+			{Name: "series_touched", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: float64(usage.SeriesTouched)},
+			{Name: "queries", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: float64(usage.Queries)},
+			{Name: "scan_bytes", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: float64(usage.ScanBytes)},
+		}
+		usageMetric := &protoMetricsV1.Metric{
+			Namespace: commonconstants.DefaultNamespace,
+			Name:      UsageMetricName,
+			Timestamp: timestamp,
+			Tags: []*protoMetricsV1.KeyValue{
+				{Key: "db", Value: usage.Database},
+				{Key: "namespace", Value: usage.Namespace},
+				{Key: "node", Value: m.node},
+			},
+			SimpleFields: fields,
+		}
+		if err := rows.TryAppend(func(row *metric.BrokerRow) error {
+			return converter.ConvertTo(usageMetric, row)
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+}
+
+// getCounter returns the usage counter of database/namespace, creates it if not exist.
+func (m *meter) getCounter(database, namespace string) *usageCounter {
+	key := usageKey{database: database, namespace: namespace}
+	counter, ok := m.usages[key]
+	if !ok {
+		counter = &usageCounter{
+			usage:  models.Usage{Database: database, Namespace: namespace},
+			series: make(map[uint64]struct{}),
+		}
+		m.usages[key] = counter
+	}
+	return counter
+}
+
+// collectUsage returns the usage of counters, sorted by database/namespace.
+func collectUsage(usages map[usageKey]*usageCounter) []*models.Usage {
+	result := make([]*models.Usage, 0, len(usages))
+	for _, counter := range usages {
+		usage := counter.usage
+		usage.SeriesTouched += int64(len(counter.series))
+		result = append(result, &usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Database != result[j].Database {
+			return result[i].Database < result[j].Database
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result
+}
@@ -0,0 +1,149 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metering
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/series/metric"
+)
+
+type mockRowsWriter struct {
+	lock     sync.Mutex
+	database string
+	rows     int
+	err      error
+}
+
+func (w *mockRowsWriter) WriteRows(_ context.Context, database string, rows *metric.BrokerBatchRows) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.database = database
+	w.rows += rows.Len()
+	return nil
+}
+
+func newRows(t *testing.T, metrics ...*protoMetricsV1.Metric) *metric.BrokerBatchRows {
+	rows := metric.NewBrokerBatchRows()
+	converter, releaseFunc := metric.NewBrokerRowProtoConverter(nil, nil)
+	defer releaseFunc(converter)
+	for _, m := range metrics {
+		m := m
+		assert.NoError(t, rows.TryAppend(func(row *metric.BrokerRow) error {
+			return converter.ConvertTo(m, row)
+		}))
+	}
+	return rows
+}
+
+func newMetric(namespace, name, host string) *protoMetricsV1.Metric {
+	return &protoMetricsV1.Metric{
+		Namespace: namespace,
+		Name:      name,
+		Timestamp: time.Now().UnixMilli(),
+		Tags:      []*protoMetricsV1.KeyValue{{Key: "host", Value: host}},
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1},
+			{Name: "f2", Type: protoMetricsV1.SimpleFieldType_LAST, Value: 1},
+		},
+	}
+}
+
+func TestMeter_Record(t *testing.T) {
+	m := NewMeter(context.TODO(), time.Minute, "_internal", "node", &mockRowsWriter{})
+	m.RecordWrite("db", nil)
+	m.RecordWrite("db", newRows(t,
+		newMetric("ns", "cpu", "1.1.1.1"),
+		newMetric("ns", "cpu", "1.1.1.1"),
+		newMetric("ns", "cpu", "1.1.1.2"),
+		newMetric("ns2", "memory", "1.1.1.1"),
+	))
+	m.RecordQuery("db", "ns", 100)
+	m.RecordQuery("db", "", 10)
+	m.RecordQuery("db", "ns", 0)
+
+	usages := m.Usage()
+	assert.Len(t, usages, 3)
+	assert.Equal(t, "default-ns", usages[0].Namespace)
+	assert.Equal(t, int64(1), usages[0].Queries)
+	assert.Equal(t, int64(10), usages[0].ScanBytes)
+	assert.Equal(t, "ns", usages[1].Namespace)
+	assert.Equal(t, int64(6), usages[1].PointsWritten)
+	assert.Equal(t, int64(2), usages[1].SeriesTouched)
+	assert.True(t, usages[1].BytesWritten > 0)
+	assert.Equal(t, int64(2), usages[1].Queries)
+	assert.Equal(t, int64(100), usages[1].ScanBytes)
+	assert.Equal(t, "ns2", usages[2].Namespace)
+	assert.Equal(t, int64(1), usages[2].SeriesTouched)
+
+	// different database
+	m.RecordQuery("a", "ns", 0)
+	assert.Equal(t, "a", m.Usage()[0].Database)
+}
+
+func TestMeter_Flush(t *testing.T) {
+	writer := &mockRowsWriter{}
+	m := NewMeter(context.TODO(), time.Minute, "_internal", "node", writer).(*meter)
+	// nothing to flush
+	m.flush()
+	assert.Zero(t, writer.rows)
+
+	m.RecordWrite("db", newRows(t, newMetric("ns", "cpu", "1.1.1.1")))
+	m.RecordQuery("db", "ns2", 10)
+	// flush failure, usage merged back
+	writer.err = fmt.Errorf("err")
+	m.flush()
+	m.RecordWrite("db", newRows(t, newMetric("ns", "cpu", "1.1.1.1")))
+	usages := m.Usage()
+	assert.Len(t, usages, 2)
+	assert.Equal(t, int64(4), usages[0].PointsWritten)
+	assert.Equal(t, int64(2), usages[0].SeriesTouched)
+	assert.Equal(t, int64(1), usages[1].Queries)
+
+	// flush successfully
+	writer.err = nil
+	m.flush()
+	assert.Equal(t, "_internal", writer.database)
+	assert.Equal(t, 2, writer.rows)
+	assert.Empty(t, m.Usage())
+}
+
+func TestMeter_StartStop(t *testing.T) {
+	writer := &mockRowsWriter{}
+	m := NewMeter(context.TODO(), time.Millisecond, "_internal", "node", writer)
+	m.Start()
+	m.RecordQuery("db", "ns", 10)
+	time.Sleep(20 * time.Millisecond)
+	// flush on stop
+	m.RecordQuery("db", "ns", 10)
+	m.Stop()
+	assert.Equal(t, 2, writer.rows)
+	assert.Equal(t, []*models.Usage{}, m.Usage())
+}
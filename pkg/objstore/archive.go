@@ -0,0 +1,131 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lindb/lindb/pkg/fileutil"
+)
+
+// archiveStore implements ObjectStore interface, keeps objects as files under archive dir(like NFS mount),
+// object key is mapped to the relative path of file.
+type archiveStore struct {
+	dir string
+}
+
+// NewArchiveStore creates the object storage based on plain file system dir.
+func NewArchiveStore(dir string) (ObjectStore, error) {
+	if dir == "" {
+		return nil, errors.New("archive dir cannot be empty")
+	}
+	if err := fileutil.MkDirIfNotExist(dir); err != nil {
+		return nil, fmt.Errorf("create archive dir error:%w", err)
+	}
+	return &archiveStore{dir: dir}, nil
+}
+
+// Put writes the object into temp file then renames it, so that partial object is never visible.
+func (s *archiveStore) Put(ctx context.Context, key string, r io.Reader, size int64) (err error) {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err = fileutil.MkDirIfNotExist(filepath.Dir(path)); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = f.Close()
+			_ = os.Remove(tmp)
+		}
+	}()
+	n, err := io.Copy(f, &contextReader{ctx: ctx, r: r})
+	if err != nil {
+		return err
+	}
+	if n != size {
+		return fmt.Errorf("size of object[%s] not match, expect:%d, actual:%d", key, size, n)
+	}
+	if err = f.Sync(); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Get opens the file of object.
+func (s *archiveStore) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrObjectNotFound, key)
+	}
+	return f, err
+}
+
+// Delete removes the file of object.
+func (s *archiveStore) Delete(_ context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path returns the file path of object, rejects the key escaping archive dir.
+func (s *archiveStore) path(key string) (string, error) {
+	rel := filepath.Clean(filepath.FromSlash(key))
+	if key == "" || filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid object key: %s", key)
+	}
+	return filepath.Join(s.dir, rel), nil
+}
+
+// contextReader stops reading when context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+// Read reads data from underlying reader if context is not done.
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
@@ -0,0 +1,68 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package objstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArchiveStore(t *testing.T) {
+	_, err := NewArchiveStore("")
+	assert.Error(t, err)
+
+	dir := t.TempDir()
+	store, err := NewArchiveStore(dir)
+	assert.NoError(t, err)
+	ctx := context.TODO()
+
+	// invalid key
+	for _, key := range []string{"", "../a", "/a"} {
+		assert.Error(t, store.Put(ctx, key, bytes.NewReader([]byte("a")), 1))
+		_, err = store.Get(ctx, key)
+		assert.Error(t, err)
+		assert.Error(t, store.Delete(ctx, key))
+	}
+	// size not match
+	assert.Error(t, store.Put(ctx, "db/f/1.sst", bytes.NewReader([]byte("abc")), 1))
+	_, err = store.Get(ctx, "db/f/1.sst")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+	// context canceled
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.Error(t, store.Put(canceledCtx, "db/f/1.sst", bytes.NewReader([]byte("abc")), 3))
+
+	assert.NoError(t, store.Put(ctx, "db/f/1.sst", bytes.NewReader([]byte("abc")), 3))
+	assert.FileExists(t, filepath.Join(dir, "db", "f", "1.sst"))
+	r, err := store.Get(ctx, "db/f/1.sst")
+	assert.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+	assert.Equal(t, []byte("abc"), data)
+
+	assert.NoError(t, store.Delete(ctx, "db/f/1.sst"))
+	assert.NoError(t, store.Delete(ctx, "db/f/1.sst"))
+	_, err = store.Get(ctx, "db/f/1.sst")
+	assert.ErrorIs(t, err, ErrObjectNotFound)
+}
@@ -0,0 +1,62 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/lindb/lindb/config"
+)
+
+//go:generate mockgen -source=./objstore.go -destination=./objstore_mock.go -package=objstore
+
+// backends of object storage
+const (
+	BackendArchive = "archive" // plain file system dir
+	BackendS3      = "s3"      // S3-compatible service
+)
+
+// ErrObjectNotFound represents the object not found in object storage.
+var ErrObjectNotFound = errors.New("object not found")
+
+// ObjectStore represents the object storage which keeps the objects by key,
+// key is a slash separated path(like db/shard/segment/family/000001.sst).
+type ObjectStore interface {
+	// Put uploads the object with given size from reader, overwrites the object if exists.
+	Put(ctx context.Context, key string, r io.Reader, size int64) error
+	// Get returns the reader of object content, returns ErrObjectNotFound if not exists.
+	// NOTICE: MUST close the reader after read completed.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete deletes the object, does nothing if not exists.
+	Delete(ctx context.Context, key string) error
+}
+
+// New creates the object storage based on backend of tiering config.
+func New(cfg config.Tiering) (ObjectStore, error) {
+	switch cfg.Backend {
+	case BackendArchive:
+		return NewArchiveStore(cfg.ArchiveDir)
+	case BackendS3:
+		return NewS3Store(cfg.S3), nil
+	default:
+		return nil, fmt.Errorf("unknown backend of object storage: %s", cfg.Backend)
+	}
+}
@@ -0,0 +1,38 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package objstore
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+)
+
+func TestNew(t *testing.T) {
+	store, err := New(config.Tiering{Backend: BackendArchive, ArchiveDir: filepath.Join(t.TempDir(), "archive")})
+	assert.NoError(t, err)
+	assert.IsType(t, &archiveStore{}, store)
+	store, err = New(config.Tiering{Backend: BackendS3, S3: config.S3{Endpoint: "http://127.0.0.1:9000", Bucket: "b"}})
+	assert.NoError(t, err)
+	assert.IsType(t, &s3Store{}, store)
+	_, err = New(config.Tiering{Backend: "abc"})
+	assert.Error(t, err)
+}
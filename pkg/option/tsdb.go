@@ -20,13 +20,172 @@ package option
 import (
 	"errors"
 	"fmt"
+	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
 
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/pkg/timeutil"
 )
 
+// ReplicaPolicy represents the replica selection policy of query fan-out.
+type ReplicaPolicy string
+
+const (
+	// ReplicaPolicyLeaderOnly queries the leader of shard only, query fails if leader fails.
+	ReplicaPolicyLeaderOnly ReplicaPolicy = "leader-only"
+	// ReplicaPolicyFailover queries the leader of shard, falls back to the most caught-up follower when leader fails.
+	ReplicaPolicyFailover ReplicaPolicy = "failover"
+	// ReplicaPolicyHedged queries the leader of shard, also queries the most caught-up follower
+	// when leader fails or does not respond in hedge timeout, uses the first response.
+	ReplicaPolicyHedged ReplicaPolicy = "hedged"
+)
+
+// ParseReplicaPolicy parses replica policy by name, returns failover if name is empty.
+func ParseReplicaPolicy(name string) (ReplicaPolicy, error) {
+	switch ReplicaPolicy(name) {
+	case "":
+		return ReplicaPolicyFailover, nil
+	case ReplicaPolicyLeaderOnly, ReplicaPolicyFailover, ReplicaPolicyHedged:
+		return ReplicaPolicy(name), nil
+	default:
+		return "", fmt.Errorf("unknown replica policy: %s", name)
+	}
+}
+
+// ReadConsistency represents the read consistency of query.
+type ReadConsistency string
+
+const (
+	// ReadConsistencyDefault queries the replicas based on replica policy, recent data may be missing on followers.
+	ReadConsistencyDefault ReadConsistency = "default"
+	// ReadConsistencyLeader queries the leader of shard only and bypasses result cache,
+	// so that the data written is visible to query immediately(read-your-writes).
+	ReadConsistencyLeader ReadConsistency = "leader"
+)
+
+// ParseReadConsistency parses read consistency by name, returns default if name is empty.
+func ParseReadConsistency(name string) (ReadConsistency, error) {
+	switch ReadConsistency(name) {
+	case "":
+		return ReadConsistencyDefault, nil
+	case ReadConsistencyDefault, ReadConsistencyLeader:
+		return ReadConsistency(name), nil
+	default:
+		return "", fmt.Errorf("unknown read consistency: %s", name)
+	}
+}
+
+// CompactionPolicy represents the compaction policy of kv family.
+type CompactionPolicy string
+
+const (
+	// CompactionPolicyDefault merges level0 files with the overlapping files of each level0 file in up level.
+	CompactionPolicyDefault CompactionPolicy = "default"
+	// CompactionPolicyLeveled merges level0 files with all up level files overlapping the key range of level0,
+	// keeps up level files non-overlapping, so that fewer files are read per lookup.
+	CompactionPolicyLeveled CompactionPolicy = "leveled"
+	// CompactionPolicySizeTiered merges level0 files with the up level files of similar size only,
+	// large files are not rewritten, so that write amplification is less.
+	CompactionPolicySizeTiered CompactionPolicy = "size-tiered"
+)
+
+// ParseCompactionPolicy parses compaction policy by name, returns default if name is empty.
+func ParseCompactionPolicy(name string) (CompactionPolicy, error) {
+	switch CompactionPolicy(name) {
+	case "":
+		return CompactionPolicyDefault, nil
+	case CompactionPolicyDefault, CompactionPolicyLeveled, CompactionPolicySizeTiered:
+		return CompactionPolicy(name), nil
+	default:
+		return "", fmt.Errorf("unknown compaction policy: %s", name)
+	}
+}
+
+// WriteSemantics represents how the rewrite of same (series, time slot, field) is applied.
+type WriteSemantics string
+
+const (
+	// WriteSemanticsAggregate aggregates the rewritten value with the old value of same time slot by field type.
+	WriteSemanticsAggregate WriteSemantics = "aggregate"
+	// WriteSemanticsLastWriteWins replaces the old value of same time slot with the rewritten value for sum fields,
+	// so that the data resent by at-least-once pipelines isn't counted twice.
+	WriteSemanticsLastWriteWins WriteSemantics = "last-write-wins"
+)
+
+// ParseWriteSemantics parses write semantics by name, returns aggregate if name is empty.
+func ParseWriteSemantics(name string) (WriteSemantics, error) {
+	switch WriteSemantics(name) {
+	case "":
+		return WriteSemanticsAggregate, nil
+	case WriteSemanticsAggregate, WriteSemanticsLastWriteWins:
+		return WriteSemantics(name), nil
+	default:
+		return "", fmt.Errorf("unknown write semantics: %s", name)
+	}
+}
+
+// IngestLimitAction represents the action applied on the row which exceeds the ingestion limits.
+type IngestLimitAction string
+
+const (
+	// IngestLimitTruncate truncates the too long metric name/tag value with TruncatedMarker suffix,
+	// drops the tags exceeding max tags per series.
+	IngestLimitTruncate IngestLimitAction = "truncate"
+	// IngestLimitReject drops the row which exceeds any ingestion limit.
+	IngestLimitReject IngestLimitAction = "reject"
+
+	// TruncatedMarker represents the suffix of truncated metric name/tag value.
+	TruncatedMarker = "..."
+)
+
+// Compression represents the codec compressing the metric blocks flushed to disk.
+type Compression string
+
+const (
+	// CompressionNone writes metric blocks without compression.
+	CompressionNone Compression = "none"
+	// CompressionSnappy compresses metric blocks with snappy, fast but lower ratio.
+	CompressionSnappy Compression = "snappy"
+	// CompressionZstd compresses metric blocks with zstd, level is specified by "zstd:<level>".
+	CompressionZstd Compression = "zstd"
+
+	// DefaultZstdLevel represents the default compression level of zstd.
+	DefaultZstdLevel = 3
+	// MaxZstdLevel represents the max compression level of zstd.
+	MaxZstdLevel = 22
+)
+
+// ParseCompression parses compression codec and level by name(none/snappy/zstd/zstd:<level>),
+// returns none if name is empty.
+func ParseCompression(name string) (codec Compression, level int, err error) {
+	codecName, levelStr, hasLevel := strings.Cut(name, ":")
+	switch Compression(codecName) {
+	case "":
+		return CompressionNone, 0, nil
+	case CompressionNone, CompressionSnappy:
+		if hasLevel {
+			return "", 0, fmt.Errorf("compression level not supported by codec: %s", name)
+		}
+		return Compression(codecName), 0, nil
+	case CompressionZstd:
+		if !hasLevel {
+			return CompressionZstd, DefaultZstdLevel, nil
+		}
+		level, err = strconv.Atoi(levelStr)
+		if err != nil || level < 1 || level > MaxZstdLevel {
+			return "", 0, fmt.Errorf("invalid zstd compression level: %s, must be in [1, %d]", name, MaxZstdLevel)
+		}
+		return CompressionZstd, level, nil
+	default:
+		return "", 0, fmt.Errorf("unknown compression codec: %s", name)
+	}
+}
+
 // Intervals represents the list of Interval.
 type Intervals []Interval
 
@@ -49,6 +208,9 @@ func (m Intervals) String() string {
 type Interval struct {
 	Interval  timeutil.Interval `toml:"interval" json:"interval,omitempty" validate:"required"`
 	Retention timeutil.Interval `toml:"retention" json:"retention,omitempty" validate:"required"`
+	// data of interval is complete from this time, the history data before it is being backfilled,
+	// 0 means the data of interval is complete.
+	AvailableFrom int64 `toml:"availableFrom" json:"availableFrom,omitempty"`
 }
 
 // String returns the string representation of the Interval.
@@ -56,12 +218,40 @@ func (m Interval) String() string {
 	return fmt.Sprintf("%s->%s", m.Interval, m.Retention)
 }
 
+// IsAvailable returns true if the data of interval is complete from start time.
+func (m Interval) IsAvailable(startTime int64) bool {
+	return m.AvailableFrom <= 0 || startTime >= m.AvailableFrom
+}
+
 // FlusherOption represents a flusher configuration for index and memory db
 type FlusherOption struct {
 	TimeThreshold int64 `toml:"timeThreshold" json:"timeThreshold"` // time level flush threshold
 	SizeThreshold int64 `toml:"sizeThreshold" json:"sizeThreshold"` // size level flush threshold, unit(MB)
 }
 
+// QueryStrictness represents how query handles the unknown metric/fields referenced by query.
+type QueryStrictness string
+
+const (
+	// QueryStrictnessLenient returns empty result for unknown metric/fields, notes them in stats of result set.
+	QueryStrictnessLenient QueryStrictness = "lenient"
+	// QueryStrictnessStrict fails the query which references unknown metric/fields,
+	// error lists the close-match suggestions of each unknown name.
+	QueryStrictnessStrict QueryStrictness = "strict"
+)
+
+// ParseQueryStrictness parses query strictness by name, returns lenient if name is empty.
+func ParseQueryStrictness(name string) (QueryStrictness, error) {
+	switch QueryStrictness(name) {
+	case "":
+		return QueryStrictnessLenient, nil
+	case QueryStrictnessLenient, QueryStrictnessStrict:
+		return QueryStrictness(name), nil
+	default:
+		return "", fmt.Errorf("unknown query strictness: %s", name)
+	}
+}
+
 // DatabaseOption represents a database option include shard ids and shard's option
 type DatabaseOption struct {
 	// write interval(the number of second) => TTL
@@ -73,36 +263,391 @@ type DatabaseOption struct {
 
 	Behind string `toml:"behind" json:"behind,omitempty"` // allowed timestamp write behind
 	Ahead  string `toml:"ahead" json:"ahead,omitempty"`   // allowed timestamp write ahead
+	// allowed lateness of rows which belong to the families older than current family(like 6h),
+	// late rows are written into the older family, empty means only limited by behind.
+	OutOfOrderWindow string `toml:"outOfOrderWindow" json:"outOfOrderWindow,omitempty"`
 
 	Index FlusherOption `toml:"index" json:"index,omitempty"` // index flusher option
 	Data  FlusherOption `toml:"data" json:"data,omitempty"`   // data flusher data
 
+	// max num. of series matched by one query across all nodes, 0 means no limit,
+	// it's split evenly across the queried shards, each storage node checks the share of its shards.
+	MaxSeriesPerQuery int `toml:"maxSeriesPerQuery" json:"maxSeriesPerQuery,omitempty"`
+	// default timeout of query(like 30s/1m), can be overridden by query request, empty means using broker's timeout.
+	QueryTimeout string `toml:"queryTimeout" json:"queryTimeout,omitempty"`
+	// replica selection policy of query(leader-only/failover/hedged), can be overridden by query request,
+	// empty means failover.
+	ReplicaPolicy ReplicaPolicy `toml:"replicaPolicy" json:"replicaPolicy,omitempty"`
+	// query is also sent to follower if leader does not respond in this duration(like 500ms) for hedged policy,
+	// empty means using the percentile of recent leaf response latencies of broker.
+	HedgeTimeout string `toml:"hedgeTimeout" json:"hedgeTimeout,omitempty"`
+	// strictness of query referencing unknown metric/fields(lenient/strict), can be overridden by query request,
+	// empty means lenient.
+	QueryStrictness QueryStrictness `toml:"queryStrictness" json:"queryStrictness,omitempty"`
+
+	// compaction policy of data families(default/leveled/size-tiered), empty means default.
+	// Changing policy takes effect for the next compaction of each family.
+	CompactionPolicy CompactionPolicy `toml:"compactionPolicy" json:"compactionPolicy,omitempty"`
+
+	// normalization rules of metric name/tag key applied by broker before writing.
+	Normalize *NormalizeOption `toml:"normalize" json:"normalize,omitempty"`
+	// limits of metric name/tags of row applied by broker before writing, changed limits take effect without restart.
+	IngestLimits *IngestLimitOption `toml:"ingestLimits" json:"ingestLimits,omitempty"`
+
+	// broker persists batches into local write ahead log before acknowledged to client(adds fsync latency),
+	// batches not acknowledged by storage are replayed after broker restarted.
+	// Takes effect when broker creates write channel of database.
+	BrokerWAL bool `toml:"brokerWAL" json:"brokerWAL,omitempty"`
+
+	// tag keys which determine the shard of series instead of all tags of series,
+	// query pinning all routing tags to exact values is only sent to related shards.
+	// NOTE: must be set when creating database, changing it makes old data unreachable for pruned queries.
+	RoutingTags []string `toml:"routingTags" json:"routingTags,omitempty"`
+
+	// retention overrides of metric fields(shorter than the retention of intervals), synced from schema registry,
+	// expired field data is dropped when compacting families.
+	FieldRetentions []FieldRetention `toml:"fieldRetentions" json:"fieldRetentions,omitempty"`
+
+	// value precision(significant decimal digits) of metric fields, synced from schema registry,
+	// field data is rounded when compacting families and the applied precision is recorded in metric block.
+	FieldPrecisions []FieldPrecision `toml:"fieldPrecisions" json:"fieldPrecisions,omitempty"`
+
+	// write semantics of rewriting same time slot of series field(aggregate/last-write-wins), empty means aggregate.
+	// Takes effect for the memory database created after changed and the following compactions of families.
+	WriteSemantics WriteSemantics `toml:"writeSemantics" json:"writeSemantics,omitempty"`
+
+	// codec compressing metric blocks of data families(none/snappy/zstd/zstd:<level>), empty means none.
+	// Takes effect for the following flushes and compactions, blocks written before are readable anyway.
+	Compression string `toml:"compression" json:"compression,omitempty"`
+
+	// field type of metric is allowed to be changed by writing(like sum to gauge), the change point is recorded,
+	// otherwise the rows with changed field type are rejected. Changed option takes effect without restart.
+	AllowFieldTypeChange bool `toml:"allowFieldTypeChange" json:"allowFieldTypeChange,omitempty"`
+
+	// broker folds the points of same series and time slot within a short window before writing to storage,
+	// disabled for last-write-wins semantics or broker wal. Takes effect when broker creates write channel of database.
+	PreAggregation *PreAggregationOption `toml:"preAggregation" json:"preAggregation,omitempty"`
+
+	// files of the families older than this(like 90d) are moved to object storage of storage node(if tiering enabled),
+	// empty means never tiered.
+	TierAfter string `toml:"tierAfter" json:"tierAfter,omitempty"`
+
+	// flushed data is verified by re-reading the points sampled from memory database in new files,
+	// memory database is retained and family becomes unhealthy if any mismatch found. Takes effect for the next flush.
+	FlushVerify *FlushVerifyOption `toml:"flushVerify" json:"flushVerify,omitempty"`
+
+	// batching and compression of replication channel from broker to storage.
+	// Takes effect for the family channels created by broker after changed.
+	Replication *ReplicationOption `toml:"replication" json:"replication,omitempty"`
+
 	ahead, behind int64
 }
 
-// FindMatchSmallestInterval returns the smallest interval which match query interval.
-func (e *DatabaseOption) FindMatchSmallestInterval(interval timeutil.Interval) timeutil.Interval {
-	storageIntervals := make([]timeutil.Interval, len(e.Intervals))
-	idx := 0
-	for k := range e.Intervals {
-		storageIntervals[idx] = e.Intervals[k].Interval
-		idx++
+// ReplicationOption represents the batching and compression of replication channel from broker to storage.
+type ReplicationOption struct {
+	// max bytes of rows buffered in chunk before sending(like 1MiB), empty means using batch block size of broker.
+	BatchSize string `toml:"batchSize" json:"batchSize,omitempty"`
+	// max duration of rows buffered in chunk before sending(like 5s), empty means using batch timeout of broker.
+	FlushInterval string `toml:"flushInterval" json:"flushInterval,omitempty"`
+	// codec compressing chunks sent to storage(none/snappy/zstd/zstd:<level>), empty means snappy.
+	// Storage node not supporting the codec(old version) receives snappy chunks instead.
+	Compression string `toml:"compression" json:"compression,omitempty"`
+}
+
+// Validate validates replication option if valid.
+func (o *ReplicationOption) Validate() error {
+	if o.BatchSize != "" {
+		if size, err := humanize.ParseBytes(o.BatchSize); err != nil || size == 0 {
+			return fmt.Errorf("invalid replication batch size: %s", o.BatchSize)
+		}
+	}
+	if o.FlushInterval != "" {
+		if t, err := time.ParseDuration(o.FlushInterval); err != nil || t <= 0 {
+			return fmt.Errorf("invalid replication flush interval: %s", o.FlushInterval)
+		}
+	}
+	if _, _, err := ParseCompression(o.Compression); err != nil {
+		return fmt.Errorf("invalid replication compression: %w", err)
+	}
+	return nil
+}
+
+// GetBatchSize returns the max bytes of rows buffered in chunk, returns 0 if not set.
+func (o *ReplicationOption) GetBatchSize() int64 {
+	if o == nil || o.BatchSize == "" {
+		return 0
+	}
+	size, err := humanize.ParseBytes(o.BatchSize)
+	if err != nil {
+		return 0
+	}
+	return int64(size)
+}
+
+// GetFlushInterval returns the max duration of rows buffered in chunk, returns 0 if not set.
+func (o *ReplicationOption) GetFlushInterval() time.Duration {
+	if o == nil {
+		return 0
+	}
+	if t, err := time.ParseDuration(o.FlushInterval); err == nil && t > 0 {
+		return t
+	}
+	return 0
+}
+
+// GetCompression returns the codec and level compressing chunks sent to storage, returns snappy if not set.
+func (o *ReplicationOption) GetCompression() (codec Compression, level int) {
+	if o == nil || o.Compression == "" {
+		return CompressionSnappy, 0
+	}
+	codec, level, err := ParseCompression(o.Compression)
+	if err != nil {
+		return CompressionSnappy, 0
+	}
+	return codec, level
+}
+
+// DefaultFlushVerifySampleRate represents the default fraction of points verified after flush.
+const DefaultFlushVerifySampleRate = 0.01
+
+// FlushVerifyOption represents the verification of flushed data against memory database.
+type FlushVerifyOption struct {
+	Enabled bool `toml:"enabled" json:"enabled,omitempty"`
+	// fraction(0~1] of (metric, series, slot) triples verified, 0 means 0.01.
+	SampleRate float64 `toml:"sampleRate" json:"sampleRate,omitempty"`
+}
+
+// Validate validates flush verify option if valid.
+func (o *FlushVerifyOption) Validate() error {
+	if o.SampleRate < 0 || o.SampleRate > 1 {
+		return errors.New("sample rate of flush verify must be in [0, 1]")
+	}
+	return nil
+}
+
+// DefaultPreAggregationMaxRows represents the default max num. of folded rows buffered in pre-aggregation window.
+const DefaultPreAggregationMaxRows = 10000
+
+// PreAggregationOption represents the broker side pre-aggregation of rows sent by high-frequency producers.
+type PreAggregationOption struct {
+	// window(like 5s) of folding points, bounded by the smallest interval of database, empty means disabled.
+	Window string `toml:"window" json:"window,omitempty"`
+	// max num. of folded rows buffered in window, rows are flushed when exceeded, 0 means 10000.
+	MaxRows int `toml:"maxRows" json:"maxRows,omitempty"`
+}
+
+// Validate validates pre-aggregation option if valid.
+func (o *PreAggregationOption) Validate() error {
+	if err := validateInterval(o.Window, false); err != nil {
+		return fmt.Errorf("invalid pre-aggregation window: %w", err)
+	}
+	if o.MaxRows < 0 {
+		return errors.New("max rows of pre-aggregation cannot be negative")
 	}
+	return nil
+}
+
+// FieldRetention represents the retention override of metric field.
+type FieldRetention struct {
+	Namespace string `toml:"namespace" json:"namespace,omitempty"`
+	Metric    string `toml:"metric" json:"metric"`
+	Field     string `toml:"field" json:"field"`
+	Retention string `toml:"retention" json:"retention"` // like 7d
+}
+
+// GetRetention returns the retention(ms) of field, returns 0 if retention is invalid.
+func (r *FieldRetention) GetRetention() int64 {
+	var retention timeutil.Interval
+	if err := retention.ValueOf(r.Retention); err != nil {
+		return 0
+	}
+	return retention.Int64()
+}
+
+// validateFieldRetentions checks if the retention overrides of fields are valid.
+func validateFieldRetentions(retentions []FieldRetention) error {
+	for _, r := range retentions {
+		if r.Metric == "" || r.Field == "" {
+			return errors.New("metric/field of field retention cannot be empty")
+		}
+		if err := validateInterval(r.Retention, true); err != nil {
+			return fmt.Errorf("invalid retention of field[%s], metric[%s]: %w", r.Field, r.Metric, err)
+		}
+	}
+	return nil
+}
+
+// MaxFieldPrecision represents the max significant decimal digits of field value precision,
+// float64 cannot represent more than 15 significant decimal digits exactly.
+const MaxFieldPrecision = 15
+
+// FieldPrecision represents the value precision of metric field, keeps the significant decimal digits of value.
+// Empty field means the default precision of all fields of metric, which excludes sum/histogram fields
+// because rounding accumulated values causes drift, sum field is reduced only if it's set explicitly.
+type FieldPrecision struct {
+	Namespace string `toml:"namespace" json:"namespace,omitempty"`
+	Metric    string `toml:"metric" json:"metric"`
+	Field     string `toml:"field" json:"field,omitempty"`
+	Precision int    `toml:"precision" json:"precision"` // 0 means full precision
+}
+
+// validateFieldPrecisions checks if the value precisions of fields are valid.
+func validateFieldPrecisions(precisions []FieldPrecision) error {
+	for _, p := range precisions {
+		if p.Metric == "" {
+			return errors.New("metric of field precision cannot be empty")
+		}
+		if p.Precision < 0 || p.Precision > MaxFieldPrecision {
+			return fmt.Errorf("invalid precision[%d] of field[%s], metric[%s], range: [0,%d]",
+				p.Precision, p.Field, p.Metric, MaxFieldPrecision)
+		}
+	}
+	return nil
+}
+
+// TagFilterOption represents the whitelist/blacklist of tag keys, tags not in whitelist or in blacklist will be dropped.
+type TagFilterOption struct {
+	Allow []string `toml:"allow" json:"allow,omitempty"` // whitelist of tag keys, empty means allow all
+	Deny  []string `toml:"deny" json:"deny,omitempty"`   // blacklist of tag keys
+}
+
+// MetricNameRewrite represents the regex rule which rewrites the metric name.
+type MetricNameRewrite struct {
+	Pattern     string `toml:"pattern" json:"pattern"`
+	Replacement string `toml:"replacement" json:"replacement"` // support $1 style group reference
+}
+
+// NormalizeOption represents the normalization rules of metric name/tag key in write path.
+// Rules are applied in order: metric name rewrites => lowercase => tag key renames => tag filters.
+type NormalizeOption struct {
+	LowercaseMetricName bool `toml:"lowercaseMetricName" json:"lowercaseMetricName,omitempty"`
+	LowercaseTagKey     bool `toml:"lowercaseTagKey" json:"lowercaseTagKey,omitempty"`
+	// tag key renames, old tag key => new tag key
+	TagKeyRenames map[string]string `toml:"tagKeyRenames" json:"tagKeyRenames,omitempty"`
+	// tag filter for all namespaces of database
+	TagFilter *TagFilterOption `toml:"tagFilter" json:"tagFilter,omitempty"`
+	// tag filter for the specific namespace, overrides database level tag filter
+	NamespaceTagFilters map[string]*TagFilterOption `toml:"namespaceTagFilters" json:"namespaceTagFilters,omitempty"`
+	MetricNameRewrites  []MetricNameRewrite         `toml:"metricNameRewrites" json:"metricNameRewrites,omitempty"`
+}
+
+// Validate validates normalization rules if valid.
+func (n *NormalizeOption) Validate() error {
+	for oldKey, newKey := range n.TagKeyRenames {
+		if oldKey == "" || newKey == "" {
+			return errors.New("tag key of rename rule cannot be empty")
+		}
+	}
+	for _, rewrite := range n.MetricNameRewrites {
+		if rewrite.Pattern == "" {
+			return errors.New("pattern of metric name rewrite rule cannot be empty")
+		}
+		if _, err := regexp.Compile(rewrite.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern of metric name rewrite rule: %w", err)
+		}
+	}
+	return nil
+}
+
+// IngestLimitOption represents the limits of metric name/tags of row in write path, 0 means no limit.
+type IngestLimitOption struct {
+	MaxMetricNameLength int `toml:"maxMetricNameLength" json:"maxMetricNameLength,omitempty"` // max bytes of metric name
+	MaxTagValueLength   int `toml:"maxTagValueLength" json:"maxTagValueLength,omitempty"`     // max bytes of tag value
+	MaxTagsPerSeries    int `toml:"maxTagsPerSeries" json:"maxTagsPerSeries,omitempty"`       // max num. of tags of series
+	// action applied on the row which exceeds the limits(truncate/reject), empty means truncate.
+	Action IngestLimitAction `toml:"action" json:"action,omitempty"`
+}
+
+// GetAction returns the action applied on the row which exceeds the limits, returns truncate if not set.
+func (o *IngestLimitOption) GetAction() IngestLimitAction {
+	if o.Action == "" {
+		return IngestLimitTruncate
+	}
+	return o.Action
+}
+
+// Validate validates ingestion limits if valid.
+func (o *IngestLimitOption) Validate() error {
+	if o.MaxMetricNameLength < 0 || o.MaxTagValueLength < 0 || o.MaxTagsPerSeries < 0 {
+		return errors.New("ingestion limit cannot be negative")
+	}
+	switch o.GetAction() {
+	case IngestLimitReject:
+		return nil
+	case IngestLimitTruncate:
+		// truncated value keeps at least one byte before marker
+		for _, limit := range []int{o.MaxMetricNameLength, o.MaxTagValueLength} {
+			if limit > 0 && limit <= len(TruncatedMarker) {
+				return fmt.Errorf("length limit must be greater than %d when truncating", len(TruncatedMarker))
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown ingestion limit action: %s", o.Action)
+	}
+}
+
+// FindMatchSmallestInterval returns the smallest interval which match query interval,
+// the interval whose data is incomplete from start time is ignored.
+func (e *DatabaseOption) FindMatchSmallestInterval(interval timeutil.Interval, startTime int64) timeutil.Interval {
+	storageIntervals := make(Intervals, len(e.Intervals))
+	copy(storageIntervals, e.Intervals)
 	// desc order
-	sort.Slice(storageIntervals, func(i, j int) bool {
-		return storageIntervals[i] > storageIntervals[j]
-	})
+	sort.Sort(sort.Reverse(storageIntervals))
 
 	storageInterval := e.Intervals[0].Interval // init using the smallest interval
 	for _, sInterval := range storageIntervals {
-		if interval >= sInterval {
-			storageInterval = sInterval
+		if interval >= sInterval.Interval && sInterval.IsAvailable(startTime) {
+			storageInterval = sInterval.Interval
 			break
 		}
 	}
 	return storageInterval
 }
 
+// AddedIntervals returns the intervals added based on old option, returns err if intervals changed unexpectedly:
+// 1. the writable(smallest) interval cannot be changed;
+// 2. the existing interval cannot be removed;
+// 3. the added interval must be a multiple of writable interval.
+func (e *DatabaseOption) AddedIntervals(old *DatabaseOption) (Intervals, error) {
+	if old == nil || len(old.Intervals) == 0 {
+		return nil, nil
+	}
+	newIntervals := make(Intervals, len(e.Intervals))
+	copy(newIntervals, e.Intervals)
+	sort.Sort(newIntervals)
+	oldIntervals := make(Intervals, len(old.Intervals))
+	copy(oldIntervals, old.Intervals)
+	sort.Sort(oldIntervals)
+
+	writable := oldIntervals[0].Interval
+	if len(newIntervals) == 0 || newIntervals[0].Interval != writable {
+		return nil, fmt.Errorf("writable interval %s cannot be changed, intervals: %s", writable, newIntervals)
+	}
+	exist := make(map[timeutil.Interval]struct{})
+	for _, i := range newIntervals {
+		exist[i.Interval] = struct{}{}
+	}
+	existing := make(map[timeutil.Interval]struct{})
+	for _, i := range oldIntervals {
+		if _, ok := exist[i.Interval]; !ok {
+			return nil, fmt.Errorf("interval %s cannot be removed, intervals: %s", i.Interval, newIntervals)
+		}
+		existing[i.Interval] = struct{}{}
+	}
+	var added Intervals
+	for _, i := range newIntervals {
+		if _, ok := existing[i.Interval]; ok {
+			continue
+		}
+		if i.Interval%writable != 0 {
+			return nil, fmt.Errorf("added interval %s must be a multiple of writable interval %s", i.Interval, writable)
+		}
+		added = append(added, i)
+	}
+	return added, nil
+}
+
 // Validate validates engine option if valid
 func (e *DatabaseOption) Validate() error {
 	if len(e.Intervals) == 0 {
@@ -115,6 +660,85 @@ func (e *DatabaseOption) Validate() error {
 	if err := validateInterval(e.Behind, false); err != nil {
 		return err
 	}
+	if err := validateInterval(e.OutOfOrderWindow, false); err != nil {
+		return err
+	}
+	if e.MaxSeriesPerQuery < 0 {
+		return errors.New("max series per query cannot be negative")
+	}
+	if err := validateInterval(e.QueryTimeout, false); err != nil {
+		return err
+	}
+	if err := validateInterval(e.TierAfter, false); err != nil {
+		return err
+	}
+	if _, err := ParseReplicaPolicy(string(e.ReplicaPolicy)); err != nil {
+		return err
+	}
+	if _, err := ParseQueryStrictness(string(e.QueryStrictness)); err != nil {
+		return err
+	}
+	if _, err := ParseCompactionPolicy(string(e.CompactionPolicy)); err != nil {
+		return err
+	}
+	if _, err := ParseWriteSemantics(string(e.WriteSemantics)); err != nil {
+		return err
+	}
+	if _, _, err := ParseCompression(e.Compression); err != nil {
+		return err
+	}
+	if e.HedgeTimeout != "" {
+		if t, err := time.ParseDuration(e.HedgeTimeout); err != nil || t <= 0 {
+			return fmt.Errorf("invalid hedge timeout: %s", e.HedgeTimeout)
+		}
+	}
+	if err := validateRoutingTags(e.RoutingTags); err != nil {
+		return err
+	}
+	if err := validateFieldRetentions(e.FieldRetentions); err != nil {
+		return err
+	}
+	if err := validateFieldPrecisions(e.FieldPrecisions); err != nil {
+		return err
+	}
+	if e.Normalize != nil {
+		if err := e.Normalize.Validate(); err != nil {
+			return err
+		}
+	}
+	if e.PreAggregation != nil {
+		if err := e.PreAggregation.Validate(); err != nil {
+			return err
+		}
+	}
+	if e.FlushVerify != nil {
+		if err := e.FlushVerify.Validate(); err != nil {
+			return err
+		}
+	}
+	if e.Replication != nil {
+		if err := e.Replication.Validate(); err != nil {
+			return err
+		}
+	}
+	if e.IngestLimits != nil {
+		return e.IngestLimits.Validate()
+	}
+	return nil
+}
+
+// validateRoutingTags checks if routing tags are not empty and not duplicated.
+func validateRoutingTags(routingTags []string) error {
+	tags := make(map[string]struct{})
+	for _, tag := range routingTags {
+		if tag == "" {
+			return errors.New("routing tag cannot be empty")
+		}
+		if _, ok := tags[tag]; ok {
+			return fmt.Errorf("duplicate routing tag: %s", tag)
+		}
+		tags[tag] = struct{}{}
+	}
 	return nil
 }
 
@@ -129,6 +753,119 @@ func (e *DatabaseOption) GetAcceptWritableRange() (ahead, behind int64) {
 	return e.ahead, e.behind
 }
 
+// GetOutOfOrderWindow returns the allowed lateness of rows which belong to older families, 0 means not set.
+func (e *DatabaseOption) GetOutOfOrderWindow() int64 {
+	if e.OutOfOrderWindow == "" {
+		return 0
+	}
+	return e.getIntervalVal(e.OutOfOrderWindow)
+}
+
+// GetTierAfter returns the age after which families are tiered, 0 means never tiered.
+func (e *DatabaseOption) GetTierAfter() int64 {
+	if e.TierAfter == "" {
+		return 0
+	}
+	return e.getIntervalVal(e.TierAfter)
+}
+
+// GetQueryTimeout returns the default timeout of query, 0 means not set.
+func (e *DatabaseOption) GetQueryTimeout() time.Duration {
+	if e.QueryTimeout == "" {
+		return 0
+	}
+	return time.Duration(e.getIntervalVal(e.QueryTimeout)) * time.Millisecond
+}
+
+// GetReplicaPolicy returns the replica selection policy of query, returns failover if not set.
+func (e *DatabaseOption) GetReplicaPolicy() ReplicaPolicy {
+	policy, err := ParseReplicaPolicy(string(e.ReplicaPolicy))
+	if err != nil {
+		return ReplicaPolicyFailover
+	}
+	return policy
+}
+
+// GetQueryStrictness returns the strictness of query referencing unknown metric/fields, returns lenient if not set.
+func (e *DatabaseOption) GetQueryStrictness() QueryStrictness {
+	strictness, err := ParseQueryStrictness(string(e.QueryStrictness))
+	if err != nil {
+		return QueryStrictnessLenient
+	}
+	return strictness
+}
+
+// GetCompactionPolicy returns the compaction policy of data families, returns default if not set.
+func (e *DatabaseOption) GetCompactionPolicy() CompactionPolicy {
+	policy, err := ParseCompactionPolicy(string(e.CompactionPolicy))
+	if err != nil {
+		return CompactionPolicyDefault
+	}
+	return policy
+}
+
+// GetWriteSemantics returns the write semantics of rewriting same time slot, returns aggregate if not set.
+func (e *DatabaseOption) GetWriteSemantics() WriteSemantics {
+	semantics, err := ParseWriteSemantics(string(e.WriteSemantics))
+	if err != nil {
+		return WriteSemanticsAggregate
+	}
+	return semantics
+}
+
+// IsLastWriteWins returns true if rewriting same time slot replaces the old value of sum fields.
+func (e *DatabaseOption) IsLastWriteWins() bool {
+	return e.GetWriteSemantics() == WriteSemanticsLastWriteWins
+}
+
+// GetPreAggregation returns the window(ms) bounded by the smallest interval and max buffered rows of pre-aggregation,
+// window is 0 if disabled or database uses last-write-wins semantics(folded points cannot be replaced one by one).
+func (e *DatabaseOption) GetPreAggregation() (window int64, maxRows int) {
+	if e.PreAggregation == nil || e.PreAggregation.Window == "" || e.IsLastWriteWins() {
+		return 0, 0
+	}
+	window = e.getIntervalVal(e.PreAggregation.Window)
+	for _, interval := range e.Intervals {
+		if interval.Interval > 0 && interval.Interval.Int64() < window {
+			window = interval.Interval.Int64()
+		}
+	}
+	maxRows = e.PreAggregation.MaxRows
+	if maxRows <= 0 {
+		maxRows = DefaultPreAggregationMaxRows
+	}
+	return window, maxRows
+}
+
+// GetFlushVerifySampleRate returns the fraction of points verified after flush, 0 means disabled.
+func (e *DatabaseOption) GetFlushVerifySampleRate() float64 {
+	if e.FlushVerify == nil || !e.FlushVerify.Enabled {
+		return 0
+	}
+	if e.FlushVerify.SampleRate <= 0 {
+		return DefaultFlushVerifySampleRate
+	}
+	return e.FlushVerify.SampleRate
+}
+
+// GetCompression returns the codec and level compressing metric blocks, returns none if not set.
+func (e *DatabaseOption) GetCompression() (codec Compression, level int) {
+	codec, level, err := ParseCompression(e.Compression)
+	if err != nil {
+		return CompressionNone, 0
+	}
+	return codec, level
+}
+
+// GetHedgeTimeout returns the duration of waiting leader before sending hedged request to follower,
+// returns 0 if not set.
+func (e *DatabaseOption) GetHedgeTimeout() time.Duration {
+	if t, err := time.ParseDuration(e.HedgeTimeout); err == nil && t > 0 {
+		return t
+	}
+	return 0
+}
+
 // getIntervalVal returns interval value.
 func (e *DatabaseOption) getIntervalVal(interval string) int64 {
 	var intervalVal timeutil.Interval
@@ -20,6 +20,7 @@ package option
 import (
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -53,6 +54,193 @@ func TestDatabaseOption_Validate(t *testing.T) {
 			DatabaseOption{Intervals: Intervals{{}}, Behind: "0h"},
 			true,
 		},
+		{
+			"tier after invalid",
+			DatabaseOption{Intervals: Intervals{{}}, TierAfter: "aa"},
+			true,
+		},
+		{
+			"out of order window invalid",
+			DatabaseOption{Intervals: Intervals{{}}, OutOfOrderWindow: "-6h"},
+			true,
+		},
+		{
+			"max series per query cannot be negative",
+			DatabaseOption{Intervals: Intervals{{}}, MaxSeriesPerQuery: -1},
+			true,
+		},
+		{
+			"query timeout invalid",
+			DatabaseOption{Intervals: Intervals{{}}, QueryTimeout: "aa"},
+			true,
+		},
+		{
+			"replica policy invalid",
+			DatabaseOption{Intervals: Intervals{{}}, ReplicaPolicy: "follower-only"},
+			true,
+		},
+		{
+			"query strictness invalid",
+			DatabaseOption{Intervals: Intervals{{}}, QueryStrictness: "loose"},
+			true,
+		},
+		{
+			"compaction policy invalid",
+			DatabaseOption{Intervals: Intervals{{}}, CompactionPolicy: "tiered"},
+			true,
+		},
+		{
+			"write semantics invalid",
+			DatabaseOption{Intervals: Intervals{{}}, WriteSemantics: "first-write-wins"},
+			true,
+		},
+		{
+			"compression invalid",
+			DatabaseOption{Intervals: Intervals{{}}, Compression: "lz4"},
+			true,
+		},
+		{
+			"hedge timeout invalid",
+			DatabaseOption{Intervals: Intervals{{}}, HedgeTimeout: "-1s"},
+			true,
+		},
+		{
+			"routing tag empty",
+			DatabaseOption{Intervals: Intervals{{}}, RoutingTags: []string{"host", ""}},
+			true,
+		},
+		{
+			"routing tag duplicate",
+			DatabaseOption{Intervals: Intervals{{}}, RoutingTags: []string{"host", "host"}},
+			true,
+		},
+		{
+			"routing tags pass",
+			DatabaseOption{Intervals: Intervals{{}}, RoutingTags: []string{"host", "region"}},
+			false,
+		},
+		{
+			"field retention field empty",
+			DatabaseOption{Intervals: Intervals{{}}, FieldRetentions: []FieldRetention{{Metric: "cpu", Retention: "7d"}}},
+			true,
+		},
+		{
+			"field retention invalid",
+			DatabaseOption{Intervals: Intervals{{}}, FieldRetentions: []FieldRetention{{Metric: "cpu", Field: "f", Retention: "7x"}}},
+			true,
+		},
+		{
+			"field retention pass",
+			DatabaseOption{Intervals: Intervals{{}}, FieldRetentions: []FieldRetention{{Metric: "cpu", Field: "f", Retention: "7d"}}},
+			false,
+		},
+		{
+			"field precision metric empty",
+			DatabaseOption{Intervals: Intervals{{}}, FieldPrecisions: []FieldPrecision{{Field: "f", Precision: 3}}},
+			true,
+		},
+		{
+			"field precision out of range",
+			DatabaseOption{Intervals: Intervals{{}}, FieldPrecisions: []FieldPrecision{{Metric: "cpu", Precision: 16}}},
+			true,
+		},
+		{
+			"field precision pass",
+			DatabaseOption{Intervals: Intervals{{}}, FieldPrecisions: []FieldPrecision{{Metric: "cpu", Precision: 3}}},
+			false,
+		},
+		{
+			"replica policy pass",
+			DatabaseOption{Intervals: Intervals{{}}, ReplicaPolicy: ReplicaPolicyHedged, HedgeTimeout: "500ms"},
+			false,
+		},
+		{
+			"tag key rename invalid",
+			DatabaseOption{Intervals: Intervals{{}}, Normalize: &NormalizeOption{TagKeyRenames: map[string]string{"a": ""}}},
+			true,
+		},
+		{
+			"metric name rewrite pattern empty",
+			DatabaseOption{Intervals: Intervals{{}}, Normalize: &NormalizeOption{MetricNameRewrites: []MetricNameRewrite{{}}}},
+			true,
+		},
+		{
+			"metric name rewrite pattern invalid",
+			DatabaseOption{Intervals: Intervals{{}}, Normalize: &NormalizeOption{MetricNameRewrites: []MetricNameRewrite{{Pattern: "a[b"}}}},
+			true,
+		},
+		{
+			"normalize option pass",
+			DatabaseOption{Intervals: Intervals{{}}, Normalize: &NormalizeOption{
+				TagKeyRenames:      map[string]string{"a": "b"},
+				MetricNameRewrites: []MetricNameRewrite{{Pattern: "^system_(.*)$", Replacement: "$1"}},
+			}},
+			false,
+		},
+		{
+			"ingestion limit negative",
+			DatabaseOption{Intervals: Intervals{{}}, IngestLimits: &IngestLimitOption{MaxTagsPerSeries: -1}},
+			true,
+		},
+		{
+			"ingestion limit action unknown",
+			DatabaseOption{Intervals: Intervals{{}}, IngestLimits: &IngestLimitOption{Action: "abc"}},
+			true,
+		},
+		{
+			"ingestion limit too small for truncating",
+			DatabaseOption{Intervals: Intervals{{}}, IngestLimits: &IngestLimitOption{MaxTagValueLength: 3}},
+			true,
+		},
+		{
+			"ingestion limit small for rejecting",
+			DatabaseOption{Intervals: Intervals{{}}, IngestLimits: &IngestLimitOption{MaxTagValueLength: 3, Action: IngestLimitReject}},
+			false,
+		},
+		{
+			"ingestion limit pass",
+			DatabaseOption{Intervals: Intervals{{}}, IngestLimits: &IngestLimitOption{
+				MaxMetricNameLength: 128, MaxTagValueLength: 256, MaxTagsPerSeries: 32,
+			}},
+			false,
+		},
+		{
+			"pre-aggregation window invalid",
+			DatabaseOption{Intervals: Intervals{{}}, PreAggregation: &PreAggregationOption{Window: "abc"}},
+			true,
+		},
+		{
+			"pre-aggregation max rows negative",
+			DatabaseOption{Intervals: Intervals{{}}, PreAggregation: &PreAggregationOption{Window: "5s", MaxRows: -1}},
+			true,
+		},
+		{
+			"flush verify sample rate invalid",
+			DatabaseOption{Intervals: Intervals{{}}, FlushVerify: &FlushVerifyOption{Enabled: true, SampleRate: 1.5}},
+			true,
+		},
+		{
+			"replication batch size invalid",
+			DatabaseOption{Intervals: Intervals{{}}, Replication: &ReplicationOption{BatchSize: "abc"}},
+			true,
+		},
+		{
+			"replication flush interval invalid",
+			DatabaseOption{Intervals: Intervals{{}}, Replication: &ReplicationOption{FlushInterval: "-1s"}},
+			true,
+		},
+		{
+			"replication compression invalid",
+			DatabaseOption{Intervals: Intervals{{}}, Replication: &ReplicationOption{Compression: "lz4"}},
+			true,
+		},
+		{
+			"replication option pass",
+			DatabaseOption{Intervals: Intervals{{}}, Replication: &ReplicationOption{
+				BatchSize: "1MiB", FlushInterval: "500ms", Compression: "zstd:3",
+			}},
+			false,
+		},
 		{
 			"validation pass",
 			DatabaseOption{Intervals: Intervals{{}}, Behind: "1h", Ahead: "1h"},
@@ -122,6 +310,277 @@ func TestDatabaseOption_GetAcceptWritableRange(t *testing.T) {
 	}
 }
 
+func TestDatabaseOption_GetOutOfOrderWindow(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Zero(t, opt.GetOutOfOrderWindow())
+	opt.OutOfOrderWindow = "6h"
+	assert.Equal(t, 6*timeutil.OneHour, opt.GetOutOfOrderWindow())
+}
+
+func TestDatabaseOption_GetTierAfter(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Zero(t, opt.GetTierAfter())
+	opt.TierAfter = "90d"
+	assert.Equal(t, 90*timeutil.OneDay, opt.GetTierAfter())
+}
+
+func TestDatabaseOption_GetFlushVerifySampleRate(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Zero(t, opt.GetFlushVerifySampleRate())
+	opt.FlushVerify = &FlushVerifyOption{SampleRate: 0.5}
+	assert.Zero(t, opt.GetFlushVerifySampleRate())
+	opt.FlushVerify.Enabled = true
+	assert.Equal(t, 0.5, opt.GetFlushVerifySampleRate())
+	opt.FlushVerify.SampleRate = 0
+	assert.Equal(t, DefaultFlushVerifySampleRate, opt.GetFlushVerifySampleRate())
+}
+
+func TestReplicationOption(t *testing.T) {
+	var opt *ReplicationOption
+	assert.Zero(t, opt.GetBatchSize())
+	assert.Zero(t, opt.GetFlushInterval())
+	codec, level := opt.GetCompression()
+	assert.Equal(t, CompressionSnappy, codec)
+	assert.Zero(t, level)
+
+	opt = &ReplicationOption{BatchSize: "abc", FlushInterval: "abc", Compression: "abc"}
+	assert.Zero(t, opt.GetBatchSize())
+	assert.Zero(t, opt.GetFlushInterval())
+	codec, _ = opt.GetCompression()
+	assert.Equal(t, CompressionSnappy, codec)
+
+	opt = &ReplicationOption{BatchSize: "1MiB", FlushInterval: "500ms", Compression: "zstd:5"}
+	assert.Equal(t, int64(1024*1024), opt.GetBatchSize())
+	assert.Equal(t, 500*time.Millisecond, opt.GetFlushInterval())
+	codec, level = opt.GetCompression()
+	assert.Equal(t, CompressionZstd, codec)
+	assert.Equal(t, 5, level)
+}
+
+func TestDatabaseOption_GetPreAggregation(t *testing.T) {
+	opt := &DatabaseOption{Intervals: Intervals{
+		{Interval: timeutil.Interval(10 * timeutil.OneSecond)},
+		{Interval: timeutil.Interval(5 * timeutil.OneMinute)},
+	}}
+	window, _ := opt.GetPreAggregation()
+	assert.Zero(t, window)
+	opt.PreAggregation = &PreAggregationOption{}
+	window, _ = opt.GetPreAggregation()
+	assert.Zero(t, window)
+
+	opt.PreAggregation.Window = "5s"
+	window, maxRows := opt.GetPreAggregation()
+	assert.Equal(t, 5*timeutil.OneSecond, window)
+	assert.Equal(t, DefaultPreAggregationMaxRows, maxRows)
+	// bounded by the smallest interval
+	opt.PreAggregation = &PreAggregationOption{Window: "1m", MaxRows: 100}
+	window, maxRows = opt.GetPreAggregation()
+	assert.Equal(t, 10*timeutil.OneSecond, window)
+	assert.Equal(t, 100, maxRows)
+	// disabled for last-write-wins
+	opt.WriteSemantics = WriteSemanticsLastWriteWins
+	window, _ = opt.GetPreAggregation()
+	assert.Zero(t, window)
+}
+
+func TestDatabaseOption_GetQueryTimeout(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Zero(t, opt.GetQueryTimeout())
+	opt.QueryTimeout = "30s"
+	assert.Equal(t, 30*time.Second, opt.GetQueryTimeout())
+}
+
+func TestDatabaseOption_GetReplicaPolicy(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Equal(t, ReplicaPolicyFailover, opt.GetReplicaPolicy())
+	assert.Zero(t, opt.GetHedgeTimeout())
+	opt.ReplicaPolicy = "unknown"
+	assert.Equal(t, ReplicaPolicyFailover, opt.GetReplicaPolicy())
+	opt.ReplicaPolicy = ReplicaPolicyLeaderOnly
+	opt.HedgeTimeout = "200ms"
+	assert.Equal(t, ReplicaPolicyLeaderOnly, opt.GetReplicaPolicy())
+	assert.Equal(t, 200*time.Millisecond, opt.GetHedgeTimeout())
+}
+
+func TestParseReplicaPolicy(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  ReplicaPolicy
+		wantErr bool
+	}{
+		{name: "", policy: ReplicaPolicyFailover},
+		{name: "leader-only", policy: ReplicaPolicyLeaderOnly},
+		{name: "failover", policy: ReplicaPolicyFailover},
+		{name: "hedged", policy: ReplicaPolicyHedged},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseReplicaPolicy(tt.name)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.policy, policy)
+		})
+	}
+}
+
+func TestParseReadConsistency(t *testing.T) {
+	cases := []struct {
+		name        string
+		consistency ReadConsistency
+		wantErr     bool
+	}{
+		{name: "", consistency: ReadConsistencyDefault},
+		{name: "default", consistency: ReadConsistencyDefault},
+		{name: "leader", consistency: ReadConsistencyLeader},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			consistency, err := ParseReadConsistency(tt.name)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.consistency, consistency)
+		})
+	}
+}
+
+func TestParseQueryStrictness(t *testing.T) {
+	cases := []struct {
+		name       string
+		strictness QueryStrictness
+		wantErr    bool
+	}{
+		{name: "", strictness: QueryStrictnessLenient},
+		{name: "lenient", strictness: QueryStrictnessLenient},
+		{name: "strict", strictness: QueryStrictnessStrict},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			strictness, err := ParseQueryStrictness(tt.name)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.strictness, strictness)
+		})
+	}
+}
+
+func TestDatabaseOption_GetQueryStrictness(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Equal(t, QueryStrictnessLenient, opt.GetQueryStrictness())
+	opt.QueryStrictness = "unknown"
+	assert.Equal(t, QueryStrictnessLenient, opt.GetQueryStrictness())
+	opt.QueryStrictness = QueryStrictnessStrict
+	assert.Equal(t, QueryStrictnessStrict, opt.GetQueryStrictness())
+}
+
+func TestParseCompactionPolicy(t *testing.T) {
+	cases := []struct {
+		name    string
+		policy  CompactionPolicy
+		wantErr bool
+	}{
+		{name: "", policy: CompactionPolicyDefault},
+		{name: "default", policy: CompactionPolicyDefault},
+		{name: "leveled", policy: CompactionPolicyLeveled},
+		{name: "size-tiered", policy: CompactionPolicySizeTiered},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseCompactionPolicy(tt.name)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.policy, policy)
+		})
+	}
+}
+
+func TestDatabaseOption_GetCompactionPolicy(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Equal(t, CompactionPolicyDefault, opt.GetCompactionPolicy())
+	opt.CompactionPolicy = "unknown"
+	assert.Equal(t, CompactionPolicyDefault, opt.GetCompactionPolicy())
+	opt.CompactionPolicy = CompactionPolicySizeTiered
+	assert.Equal(t, CompactionPolicySizeTiered, opt.GetCompactionPolicy())
+}
+
+func TestParseWriteSemantics(t *testing.T) {
+	cases := []struct {
+		name      string
+		semantics WriteSemantics
+		wantErr   bool
+	}{
+		{name: "", semantics: WriteSemanticsAggregate},
+		{name: "aggregate", semantics: WriteSemanticsAggregate},
+		{name: "last-write-wins", semantics: WriteSemanticsLastWriteWins},
+		{name: "unknown", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			semantics, err := ParseWriteSemantics(tt.name)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.semantics, semantics)
+		})
+	}
+}
+
+func TestDatabaseOption_GetWriteSemantics(t *testing.T) {
+	opt := &DatabaseOption{}
+	assert.Equal(t, WriteSemanticsAggregate, opt.GetWriteSemantics())
+	assert.False(t, opt.IsLastWriteWins())
+	opt.WriteSemantics = "unknown"
+	assert.Equal(t, WriteSemanticsAggregate, opt.GetWriteSemantics())
+	opt.WriteSemantics = WriteSemanticsLastWriteWins
+	assert.Equal(t, WriteSemanticsLastWriteWins, opt.GetWriteSemantics())
+	assert.True(t, opt.IsLastWriteWins())
+}
+
+func TestParseCompression(t *testing.T) {
+	cases := []struct {
+		name    string
+		codec   Compression
+		level   int
+		wantErr bool
+	}{
+		{name: "", codec: CompressionNone},
+		{name: "none", codec: CompressionNone},
+		{name: "snappy", codec: CompressionSnappy},
+		{name: "zstd", codec: CompressionZstd, level: DefaultZstdLevel},
+		{name: "zstd:9", codec: CompressionZstd, level: 9},
+		{name: "zstd:0", wantErr: true},
+		{name: "zstd:23", wantErr: true},
+		{name: "zstd:abc", wantErr: true},
+		{name: "snappy:1", wantErr: true},
+		{name: "lz4", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			codec, level, err := ParseCompression(tt.name)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.codec, codec)
+			assert.Equal(t, tt.level, level)
+		})
+	}
+}
+
+func TestDatabaseOption_GetCompression(t *testing.T) {
+	opt := &DatabaseOption{}
+	codec, level := opt.GetCompression()
+	assert.Equal(t, CompressionNone, codec)
+	assert.Zero(t, level)
+	opt.Compression = "unknown"
+	codec, _ = opt.GetCompression()
+	assert.Equal(t, CompressionNone, codec)
+	opt.Compression = "zstd:5"
+	codec, level = opt.GetCompression()
+	assert.Equal(t, CompressionZstd, codec)
+	assert.Equal(t, 5, level)
+}
+
 func TestInterval_String(t *testing.T) {
 	assert.Equal(t, "10s->1M",
 		Interval{
@@ -133,15 +592,15 @@ func TestInterval_String(t *testing.T) {
 
 func TestIntervals_Sort(t *testing.T) {
 	intervals := Intervals{
-		{timeutil.Interval(timeutil.OneMinute), timeutil.Interval(timeutil.OneMonth)},
-		{timeutil.Interval(timeutil.OneHour), timeutil.Interval(timeutil.OneMonth)},
-		{timeutil.Interval(timeutil.OneSecond), timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneMinute), Retention: timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneHour), Retention: timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)},
 	}
 	sort.Sort(intervals)
 	assert.Equal(t, Intervals{
-		{timeutil.Interval(timeutil.OneSecond), timeutil.Interval(timeutil.OneMonth)},
-		{timeutil.Interval(timeutil.OneMinute), timeutil.Interval(timeutil.OneMonth)},
-		{timeutil.Interval(timeutil.OneHour), timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneMinute), Retention: timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneHour), Retention: timeutil.Interval(timeutil.OneMonth)},
 	}, intervals)
 
 	assert.Equal(t, "[1s->1M,1m->1M,1h->1M]", intervals.String())
@@ -149,10 +608,102 @@ func TestIntervals_Sort(t *testing.T) {
 
 func TestDatabaseOption_FindMatchSmallestInterval(t *testing.T) {
 	opt := DatabaseOption{Intervals: Intervals{
-		{timeutil.Interval(timeutil.OneSecond), timeutil.Interval(timeutil.OneMonth)},
-		{timeutil.Interval(timeutil.OneMinute), timeutil.Interval(timeutil.OneMonth)},
-		{timeutil.Interval(timeutil.OneHour), timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneMinute), Retention: timeutil.Interval(timeutil.OneMonth)},
+		{Interval: timeutil.Interval(timeutil.OneHour), Retention: timeutil.Interval(timeutil.OneMonth)},
 	}}
-	interval := opt.FindMatchSmallestInterval(timeutil.Interval(timeutil.OneMinute * 3))
+	interval := opt.FindMatchSmallestInterval(timeutil.Interval(timeutil.OneMinute*3), 0)
 	assert.Equal(t, timeutil.Interval(timeutil.OneMinute), interval)
+	interval = opt.FindMatchSmallestInterval(timeutil.Interval(timeutil.OneHour*3), 0)
+	assert.Equal(t, timeutil.Interval(timeutil.OneHour), interval)
+
+	// hour interval is being backfilled, data is incomplete before available time
+	opt.Intervals[2].AvailableFrom = 1000
+	interval = opt.FindMatchSmallestInterval(timeutil.Interval(timeutil.OneHour*3), 100)
+	assert.Equal(t, timeutil.Interval(timeutil.OneMinute), interval)
+	interval = opt.FindMatchSmallestInterval(timeutil.Interval(timeutil.OneHour*3), 1000)
+	assert.Equal(t, timeutil.Interval(timeutil.OneHour), interval)
+}
+
+func TestInterval_IsAvailable(t *testing.T) {
+	assert.True(t, Interval{}.IsAvailable(10))
+	assert.True(t, Interval{AvailableFrom: 10}.IsAvailable(10))
+	assert.False(t, Interval{AvailableFrom: 10}.IsAvailable(9))
+}
+
+func TestDatabaseOption_AddedIntervals(t *testing.T) {
+	second := Interval{Interval: timeutil.Interval(10 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)}
+	minute := Interval{Interval: timeutil.Interval(5 * timeutil.OneMinute), Retention: timeutil.Interval(timeutil.OneMonth)}
+	hour := Interval{Interval: timeutil.Interval(timeutil.OneHour), Retention: timeutil.Interval(timeutil.OneYear)}
+	old := &DatabaseOption{Intervals: Intervals{second, minute}}
+
+	cases := []struct {
+		name      string
+		old       *DatabaseOption
+		intervals Intervals
+		added     Intervals
+		wantErr   bool
+	}{
+		{
+			name:      "new database",
+			intervals: Intervals{second, minute},
+		},
+		{
+			name:      "intervals not changed",
+			old:       old,
+			intervals: Intervals{minute, second},
+		},
+		{
+			name:      "add interval",
+			old:       old,
+			intervals: Intervals{hour, second, minute},
+			added:     Intervals{hour},
+		},
+		{
+			name:      "writable interval changed",
+			old:       old,
+			intervals: Intervals{minute, hour},
+			wantErr:   true,
+		},
+		{
+			name:      "remove interval",
+			old:       old,
+			intervals: Intervals{second, hour},
+			wantErr:   true,
+		},
+		{
+			name: "added interval not multiple of writable interval",
+			old:  old,
+			intervals: Intervals{second, minute, {
+				Interval: timeutil.Interval(15 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth),
+			}},
+			wantErr: true,
+		},
+		{
+			name:    "intervals empty",
+			old:     old,
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			opt := &DatabaseOption{Intervals: tt.intervals}
+			added, err := opt.AddedIntervals(tt.old)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.added, added)
+		})
+	}
+}
+
+func TestFieldRetention_GetRetention(t *testing.T) {
+	r := &FieldRetention{Retention: "7d"}
+	assert.Equal(t, 7*timeutil.OneDay, r.GetRetention())
+	r = &FieldRetention{Retention: "7x"}
+	assert.Zero(t, r.GetRetention())
+}
+
+func TestIngestLimitOption_GetAction(t *testing.T) {
+	assert.Equal(t, IngestLimitTruncate, (&IngestLimitOption{}).GetAction())
+	assert.Equal(t, IngestLimitReject, (&IngestLimitOption{Action: IngestLimitReject}).GetAction())
 }
@@ -71,6 +71,8 @@ type Queue interface {
 	SetAcknowledgedSeq(seq int64)
 	// GC removes all message which sequence <= acknowledged sequence.
 	GC()
+	// Sync flushes the data/index/meta of appended messages to disk.
+	Sync() error
 	// Close closes the queue.
 	Close()
 }
@@ -282,6 +284,23 @@ func (q *queue) SetAcknowledgedSeq(seq int64) {
 	}
 }
 
+// Sync flushes the data/index/meta of appended messages to disk.
+func (q *queue) Sync() error {
+	q.rwMutex.RLock()
+	pages := []page.MappedPage{q.dataPage, q.indexPage, q.metaPage}
+	q.rwMutex.RUnlock()
+
+	for _, p := range pages {
+		if p == nil {
+			continue
+		}
+		if err := p.Sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close closes the queue.
 func (q *queue) Close() {
 	if q.closed.CAS(false, true) {
@@ -478,6 +478,28 @@ func TestQueue_Ack_err(t *testing.T) {
 	q.Close()
 }
 
+func TestQueue_Sync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := path.Join(t.TempDir(), t.Name())
+	q, err := NewQueue(dir, 1024)
+	assert.NoError(t, err)
+	defer q.Close()
+
+	assert.NoError(t, q.Put([]byte("123")))
+	assert.NoError(t, q.Sync())
+
+	// sync data page err
+	q1 := q.(*queue)
+	dataPage := q1.dataPage
+	mockDataPage := page.NewMockMappedPage(ctrl)
+	q1.dataPage = mockDataPage
+	mockDataPage.EXPECT().Sync().Return(fmt.Errorf("err"))
+	assert.Error(t, q.Sync())
+	q1.dataPage = dataPage
+}
+
 func TestQueue_data_limit(t *testing.T) {
 	dir := path.Join(t.TempDir(), t.Name())
 
@@ -138,6 +138,20 @@ func (r *etcdRepository) Put(ctx context.Context, key string, val []byte) error
 	return err
 }
 
+// Update updates the value of an existing key, keeps the lease attached to the key.
+func (r *etcdRepository) Update(ctx context.Context, key string, val []byte) error {
+	thisCtx, cancelFunc := context.WithTimeout(ctx, r.timeout)
+	defer cancelFunc()
+
+	_, err := r.client.Put(thisCtx, r.keyPath(key), string(val), etcdcliv3.WithIgnoreLease())
+	if err != nil {
+		r.logger.Error("update error", logger.String("path", key),
+			logger.String("namespace", r.namespace),
+			logger.Error(err))
+	}
+	return err
+}
+
 func (r *etcdRepository) PutWithTX(ctx context.Context, key string, val []byte, check func(oldVal []byte) error) (bool, error) {
 	thisCtx, cancelFunc := context.WithTimeout(ctx, r.timeout)
 	defer cancelFunc()
@@ -342,6 +342,41 @@ func TestElect(t *testing.T) {
 	cancel3()
 }
 
+func TestUpdate(t *testing.T) {
+	cluster := mock.StartEtcdCluster(t, "http://localhost:8710")
+	defer cluster.Terminate(t)
+
+	cfg := &config.RepoState{
+		Endpoints: cluster.Endpoints,
+	}
+	b, _ := newEtcdRepository(cfg, "nobody")
+	repo := b.(*etcdRepository)
+	repo.timeout = time.Second * 10
+
+	// key not exist
+	assert.Error(t, b.Update(context.TODO(), "/lindb/storage/live/1", []byte("test")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	success, ch, err := b.Elect(ctx, "/lindb/storage/live/1", []byte("test"), 1)
+	assert.NoError(t, err)
+	assert.True(t, success)
+	assert.NoError(t, b.Update(context.TODO(), "/lindb/storage/live/1", []byte("test2")))
+	bytes, err := b.Get(context.TODO(), "/lindb/storage/live/1")
+	assert.NoError(t, err)
+	assert.Equal(t, "test2", string(bytes))
+
+	// lease is kept after update, key will be deleted when session closed
+	cancel()
+	select {
+	case <-ch:
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("cancel heartbeat timeout")
+	}
+	time.Sleep(3 * time.Second)
+	_, err = b.Get(context.TODO(), "/lindb/storage/live/1")
+	assert.Error(t, err)
+}
+
 func TestBatch(t *testing.T) {
 	cluster := mock.StartEtcdCluster(t, "http://localhost:8706")
 	defer cluster.Terminate(t)
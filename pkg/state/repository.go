@@ -52,6 +52,8 @@ type Repository interface {
 	// Put puts a key-value pair into repository.
 	Put(ctx context.Context, key string, val []byte) error
 	PutWithTX(ctx context.Context, key string, val []byte, check func(oldVal []byte) error) (bool, error)
+	// Update updates the value of an existing key, keeps the lease attached to the key(such as elected key).
+	Update(ctx context.Context, key string, val []byte) error
 	// Delete deletes value for given key from repository.
 	Delete(ctx context.Context, key string) error
 	// Heartbeat does heartbeat on the key with a value and ttl.
@@ -0,0 +1,145 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package strutil
+
+import (
+	"strings"
+)
+
+// maxFuzzyDistance represents the max edit distance of close-match candidates.
+const maxFuzzyDistance = 3
+
+// fuzzyMatch represents a candidate close to target with edit distance.
+type fuzzyMatch struct {
+	value    string
+	distance int
+}
+
+// FuzzyMatcher keeps the candidates closest to target by edit distance(case-insensitive),
+// candidates farther than max distance are dropped without computing the whole distance.
+type FuzzyMatcher struct {
+	target      string
+	maxDistance int
+	limit       int
+	matches     []fuzzyMatch
+	prev, cur   []int
+}
+
+// NewFuzzyMatcher creates a fuzzy matcher which keeps at most limit closest candidates,
+// max distance is 1/3 of target's length(at least 1, at most 3).
+func NewFuzzyMatcher(target string, limit int) *FuzzyMatcher {
+	maxDistance := len(target) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+	if maxDistance > maxFuzzyDistance {
+		maxDistance = maxFuzzyDistance
+	}
+	return &FuzzyMatcher{
+		target:      strings.ToLower(target),
+		maxDistance: maxDistance,
+		limit:       limit,
+		prev:        make([]int, len(target)+1),
+		cur:         make([]int, len(target)+1),
+	}
+}
+
+// Add adds the candidate, keeps it if it's closer than the kept candidates.
+func (m *FuzzyMatcher) Add(candidate string) {
+	if m.limit <= 0 {
+		return
+	}
+	bound := m.maxDistance
+	if len(m.matches) == m.limit {
+		// candidate must be not farther than the farthest kept candidate
+		bound = m.matches[len(m.matches)-1].distance
+	}
+	distance := m.distance(strings.ToLower(candidate), bound)
+	if distance > bound {
+		return
+	}
+	// insert by (distance, value) in order
+	pos := len(m.matches)
+	for pos > 0 {
+		last := m.matches[pos-1]
+		if last.distance < distance || (last.distance == distance && last.value <= candidate) {
+			break
+		}
+		pos--
+	}
+	if pos >= m.limit {
+		return
+	}
+	if len(m.matches) < m.limit {
+		m.matches = append(m.matches, fuzzyMatch{})
+	}
+	copy(m.matches[pos+1:], m.matches[pos:])
+	m.matches[pos] = fuzzyMatch{value: candidate, distance: distance}
+}
+
+// Matches returns the kept candidates ordered by edit distance, then by value.
+func (m *FuzzyMatcher) Matches() []string {
+	if len(m.matches) == 0 {
+		return nil
+	}
+	rs := make([]string, len(m.matches))
+	for idx := range m.matches {
+		rs[idx] = m.matches[idx].value
+	}
+	return rs
+}
+
+// distance returns the levenshtein distance between target and candidate,
+// returns bound+1 as soon as the distance must exceed bound.
+func (m *FuzzyMatcher) distance(candidate string, bound int) int {
+	target := m.target
+	if diff := len(target) - len(candidate); diff > bound || -diff > bound {
+		return bound + 1
+	}
+	prev, cur := m.prev, m.cur
+	for i := range prev {
+		prev[i] = i
+	}
+	for j := 1; j <= len(candidate); j++ {
+		cur[0] = j
+		rowMin := cur[0]
+		for i := 1; i <= len(target); i++ {
+			cost := 1
+			if target[i-1] == candidate[j-1] {
+				cost = 0
+			}
+			d := prev[i-1] + cost
+			if prev[i]+1 < d {
+				d = prev[i] + 1
+			}
+			if cur[i-1]+1 < d {
+				d = cur[i-1] + 1
+			}
+			cur[i] = d
+			if d < rowMin {
+				rowMin = d
+			}
+		}
+		if rowMin > bound {
+			// distance never decreases in following rows
+			return bound + 1
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(target)]
+}
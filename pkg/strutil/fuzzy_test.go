@@ -0,0 +1,92 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package strutil
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzyMatcher(t *testing.T) {
+	m := NewFuzzyMatcher("cpu_usge", 3)
+	for _, candidate := range []string{"cpu_usage", "cpu_user", "memory_usage", "CPU_USGE", "cpu_idle", "cpu_usage_total", "cpu"} {
+		m.Add(candidate)
+	}
+	assert.Equal(t, []string{"CPU_USGE", "cpu_usage", "cpu_user"}, m.Matches())
+
+	// keeps the closest candidates
+	m = NewFuzzyMatcher("cpu_usge", 1)
+	m.Add("cpu_user")
+	m.Add("cpu_usage")
+	assert.Equal(t, []string{"cpu_usage"}, m.Matches())
+	// same distance, ordered by value
+	m = NewFuzzyMatcher("load", 2)
+	m.Add("loaf")
+	m.Add("lead")
+	m.Add("loads")
+	assert.Equal(t, []string{"lead", "loads"}, m.Matches())
+
+	// no match
+	m = NewFuzzyMatcher("cpu", 3)
+	m.Add("memory")
+	m.Add("cp_")
+	m.Add("cpu__")
+	assert.Equal(t, []string{"cp_"}, m.Matches())
+	m = NewFuzzyMatcher("disk", 0)
+	m.Add("disk")
+	assert.Nil(t, m.Matches())
+}
+
+func TestFuzzyMatcher_distance(t *testing.T) {
+	cases := []struct {
+		target, candidate string
+		bound, distance   int
+	}{
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 2, 3},
+		{"", "abc", 3, 3},
+		{"abc", "", 3, 3},
+		{"abc", "abc", 1, 0},
+		{"abcdef", "ab", 2, 3},
+		{"flaw", "lawn", 2, 2},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(fmt.Sprintf("%s_%s_%d", tt.target, tt.candidate, tt.bound), func(t *testing.T) {
+			m := NewFuzzyMatcher(tt.target, 1)
+			assert.Equal(t, tt.distance, m.distance(tt.candidate, tt.bound))
+		})
+	}
+}
+
+func BenchmarkFuzzyMatcher_Add(b *testing.B) {
+	candidates := make([]string, 10000)
+	for i := range candidates {
+		candidates[i] = fmt.Sprintf("service_%d_request_latency", i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := NewFuzzyMatcher("service_50_request_latncy", 5)
+		for _, candidate := range candidates {
+			m.Add(candidate)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package strutil
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+)
+
+// RegexLiteralPrefix returns the literal prefix which all values matched by regex pattern must begin with,
+// supports anchored pattern(like: ^web.*) which literal prefix cannot be found by regexp.LiteralPrefix.
+func RegexLiteralPrefix(pattern *regexp.Regexp) string {
+	if prefix, _ := pattern.LiteralPrefix(); prefix != "" {
+		return prefix
+	}
+	re, err := syntax.Parse(pattern.String(), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+	re = re.Simplify()
+	// ^web.* => concat(begin text, literal(web), star(any char))
+	if re.Op != syntax.OpConcat || len(re.Sub) < 2 || re.Sub[0].Op != syntax.OpBeginText {
+		return ""
+	}
+	var prefix strings.Builder
+	for _, sub := range re.Sub[1:] {
+		if sub.Op != syntax.OpLiteral || sub.Flags&syntax.FoldCase != 0 {
+			break
+		}
+		prefix.WriteString(string(sub.Rune))
+	}
+	return prefix.String()
+}
@@ -0,0 +1,49 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package strutil
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegexLiteralPrefix(t *testing.T) {
+	cases := []struct {
+		pattern string
+		prefix  string
+	}{
+		{pattern: "web", prefix: "web"},
+		{pattern: "web.*", prefix: "web"},
+		{pattern: "^web", prefix: "web"},
+		{pattern: "^web.*", prefix: "web"},
+		{pattern: "^web-.*-01$", prefix: "web-"},
+		{pattern: "^web\\d+.*", prefix: "web"},
+		{pattern: "^(?i)web.*", prefix: ""},
+		{pattern: "^(web|db).*", prefix: ""},
+		{pattern: ".*web", prefix: ""},
+		{pattern: "^.*", prefix: ""},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.pattern, func(t *testing.T) {
+			assert.Equal(t, tt.prefix, RegexLiteralPrefix(regexp.MustCompile(tt.pattern)))
+		})
+	}
+}
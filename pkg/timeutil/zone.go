@@ -0,0 +1,141 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // embeds time zone database, not depends on the zoneinfo of host
+)
+
+// ZoneBuckets calculates the boundaries of group by time buckets in the time zone instead of UTC:
+// 1. buckets of whole days interval start at the midnight of zone, the day may have 23/25 hours because of DST;
+// 2. buckets of other interval align with the wall clock of zone, keep fixed length.
+type ZoneBuckets struct {
+	location *time.Location
+	interval int64
+}
+
+// NewZoneBuckets creates the buckets of interval in the time zone, like: Asia/Shanghai.
+func NewZoneBuckets(zone string, interval Interval) (*ZoneBuckets, error) {
+	location, err := LoadLocation(zone)
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid interval of time zone buckets: %d", interval)
+	}
+	return &ZoneBuckets{
+		location: location,
+		interval: interval.Int64(),
+	}, nil
+}
+
+// LoadLocation returns the time zone with given name, like: Asia/Shanghai.
+func LoadLocation(zone string) (*time.Location, error) {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time zone: %s", zone)
+	}
+	return location, nil
+}
+
+// Location returns the time zone of buckets.
+func (b *ZoneBuckets) Location() *time.Location {
+	return b.location
+}
+
+// Truncate returns the start time of bucket which the timestamp belongs to.
+func (b *ZoneBuckets) Truncate(timestamp int64) int64 {
+	t := time.UnixMilli(timestamp).In(b.location)
+	if days := b.days(); days > 0 {
+		year, month, day := t.Date()
+		// days since epoch on the calendar of zone
+		epochDay := time.Date(year, month, day, 0, 0, 0, 0, time.UTC).UnixMilli() / OneDay
+		epochDay -= floorMod(epochDay, days)
+		startDay := time.UnixMilli(epochDay * OneDay).UTC()
+		return time.Date(startDay.Year(), startDay.Month(), startDay.Day(), 0, 0, 0, 0, b.location).UnixMilli()
+	}
+	_, offset := t.Zone()
+	wallClock := timestamp + int64(offset)*OneSecond
+	return wallClock - floorMod(wallClock, b.interval) - int64(offset)*OneSecond
+}
+
+// Next returns the start time of next bucket.
+func (b *ZoneBuckets) Next(bucketStart int64) int64 {
+	if days := b.days(); days > 0 {
+		t := time.UnixMilli(bucketStart).In(b.location)
+		return time.Date(t.Year(), t.Month(), t.Day()+int(days), 0, 0, 0, 0, b.location).UnixMilli()
+	}
+	next := b.Truncate(bucketStart + b.interval)
+	if next <= bucketStart {
+		// wall clock turns back because of DST
+		next = bucketStart + b.interval
+	}
+	return next
+}
+
+// Boundaries returns the start times of buckets which overlap the time range.
+func (b *ZoneBuckets) Boundaries(timeRange TimeRange) []int64 {
+	var boundaries []int64
+	for start := b.Truncate(timeRange.Start); start <= timeRange.End; start = b.Next(start) {
+		boundaries = append(boundaries, start)
+	}
+	return boundaries
+}
+
+// CommonInterval returns the biggest fixed interval which all boundaries align with,
+// so that the buckets can be merged from the data of fixed interval.
+func (b *ZoneBuckets) CommonInterval(boundaries []int64) int64 {
+	interval := b.interval
+	for _, boundary := range boundaries {
+		interval = gcd(interval, boundary)
+	}
+	return interval
+}
+
+// days returns the num. of days of bucket, 0 if interval is not whole days.
+func (b *ZoneBuckets) days() int64 {
+	if b.interval%OneDay != 0 {
+		return 0
+	}
+	return b.interval / OneDay
+}
+
+// floorMod returns the non-negative modulus of a and b.
+func floorMod(a, b int64) int64 {
+	m := a % b
+	if m < 0 {
+		m += b
+	}
+	return m
+}
+
+// gcd returns the greatest common divisor of a and b.
+func gcd(a, b int64) int64 {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
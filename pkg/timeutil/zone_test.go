@@ -0,0 +1,147 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package timeutil
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewZoneBuckets(t *testing.T) {
+	_, err := NewZoneBuckets("Unknown/Zone", Interval(OneDay))
+	assert.Error(t, err)
+	_, err = NewZoneBuckets("Asia/Shanghai", 0)
+	assert.Error(t, err)
+	buckets, err := NewZoneBuckets("Asia/Shanghai", Interval(OneDay))
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Shanghai", buckets.Location().String())
+}
+
+func TestZoneBuckets_Boundaries(t *testing.T) {
+	date := func(zone string, year int, month time.Month, day, hour, minute int) int64 {
+		location, err := time.LoadLocation(zone)
+		assert.NoError(t, err)
+		return time.Date(year, month, day, hour, minute, 0, 0, location).UnixMilli()
+	}
+	cases := []struct {
+		name       string
+		zone       string
+		interval   int64
+		timeRange  TimeRange
+		boundaries []int64
+		common     int64
+	}{
+		{
+			name:     "day buckets on midnight of zone",
+			zone:     "Asia/Shanghai",
+			interval: OneDay,
+			timeRange: TimeRange{
+				Start: date("Asia/Shanghai", 2023, 5, 1, 10, 0),
+				End:   date("Asia/Shanghai", 2023, 5, 3, 1, 0),
+			},
+			boundaries: []int64{
+				date("Asia/Shanghai", 2023, 5, 1, 0, 0),
+				date("Asia/Shanghai", 2023, 5, 2, 0, 0),
+				date("Asia/Shanghai", 2023, 5, 3, 0, 0),
+			},
+			common: 8 * OneHour,
+		},
+		{
+			name:     "23 hours day of DST",
+			zone:     "America/New_York",
+			interval: OneDay,
+			timeRange: TimeRange{
+				Start: date("America/New_York", 2023, 3, 11, 10, 0),
+				End:   date("America/New_York", 2023, 3, 13, 1, 0),
+			},
+			boundaries: []int64{
+				date("America/New_York", 2023, 3, 11, 0, 0),
+				date("America/New_York", 2023, 3, 12, 0, 0),
+				date("America/New_York", 2023, 3, 13, 0, 0),
+			},
+			common: OneHour,
+		},
+		{
+			name:     "25 hours day of DST",
+			zone:     "America/New_York",
+			interval: OneDay,
+			timeRange: TimeRange{
+				Start: date("America/New_York", 2023, 11, 5, 10, 0),
+				End:   date("America/New_York", 2023, 11, 6, 1, 0),
+			},
+			boundaries: []int64{
+				date("America/New_York", 2023, 11, 5, 0, 0),
+				date("America/New_York", 2023, 11, 6, 0, 0),
+			},
+			common: OneHour,
+		},
+		{
+			name:     "multiple days",
+			zone:     "UTC",
+			interval: 2 * OneDay,
+			timeRange: TimeRange{
+				Start: date("UTC", 1970, 1, 2, 10, 0),
+				End:   date("UTC", 1970, 1, 4, 1, 0),
+			},
+			boundaries: []int64{0, 2 * OneDay},
+			common:     2 * OneDay,
+		},
+		{
+			name:     "hour buckets on wall clock of zone",
+			zone:     "Asia/Kolkata",
+			interval: OneHour,
+			timeRange: TimeRange{
+				Start: date("Asia/Kolkata", 2023, 5, 1, 10, 20),
+				End:   date("Asia/Kolkata", 2023, 5, 1, 11, 0),
+			},
+			boundaries: []int64{
+				date("Asia/Kolkata", 2023, 5, 1, 10, 0),
+				date("Asia/Kolkata", 2023, 5, 1, 11, 0),
+			},
+			common: 30 * OneMinute,
+		},
+		{
+			name:     "hour buckets when wall clock turns back",
+			zone:     "America/New_York",
+			interval: OneHour,
+			timeRange: TimeRange{
+				Start: date("America/New_York", 2023, 11, 5, 0, 30),
+				End:   date("America/New_York", 2023, 11, 5, 2, 0),
+			},
+			boundaries: []int64{
+				date("America/New_York", 2023, 11, 5, 0, 0),
+				date("America/New_York", 2023, 11, 5, 1, 0),
+				date("America/New_York", 2023, 11, 5, 1, 0) + OneHour,
+				date("America/New_York", 2023, 11, 5, 2, 0),
+			},
+			common: OneHour,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			buckets, err := NewZoneBuckets(tt.zone, Interval(tt.interval))
+			assert.NoError(t, err)
+			boundaries := buckets.Boundaries(tt.timeRange)
+			assert.Equal(t, tt.boundaries, boundaries)
+			assert.Equal(t, tt.common, buckets.CommonInterval(boundaries))
+		})
+	}
+}
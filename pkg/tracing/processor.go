@@ -0,0 +1,161 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tracing
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// for testing
+var (
+	nowFn = time.Now
+)
+
+const (
+	// minStaleTraceTimeout represents the min timeout of buffered trace whose local root span not ended.
+	minStaleTraceTimeout = time.Minute
+)
+
+// bufferedTrace represents the recorded(not sampled) spans of a trace, waiting for local root span ended.
+type bufferedTrace struct {
+	spans   []sdktrace.ReadOnlySpan
+	created time.Time
+}
+
+// slowQuerySpanProcessor implements sdktrace.SpanProcessor interface, which defers the sampling decision of
+// recorded(not sampled) spans until the local root span ended, only exports the spans of slow query
+// whose local root span takes longer than threshold, the sampled spans are forwarded to next processor directly.
+type slowQuerySpanProcessor struct {
+	next      sdktrace.SpanProcessor
+	threshold time.Duration
+	maxSpans  int
+
+	traces    map[trace.TraceID]*bufferedTrace
+	lastSweep time.Time
+	mutex     sync.Mutex
+}
+
+// newSlowQuerySpanProcessor creates the span processor for slow query sampling.
+func newSlowQuerySpanProcessor(next sdktrace.SpanProcessor, threshold time.Duration, maxSpans int) sdktrace.SpanProcessor {
+	return &slowQuerySpanProcessor{
+		next:      next,
+		threshold: threshold,
+		maxSpans:  maxSpans,
+		traces:    make(map[trace.TraceID]*bufferedTrace),
+		lastSweep: nowFn(),
+	}
+}
+
+// OnStart is called when a span is started.
+func (p *slowQuerySpanProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+// OnEnd is called when span is finished, buffers the recorded span until local root span ended.
+func (p *slowQuerySpanProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	sc := s.SpanContext()
+	if sc.IsSampled() {
+		p.next.OnEnd(s)
+		return
+	}
+	parent := s.Parent()
+	isLocalRoot := !parent.IsValid() || parent.IsRemote()
+	traceID := sc.TraceID()
+
+	p.mutex.Lock()
+	buffered, ok := p.traces[traceID]
+	if !isLocalRoot {
+		if !ok {
+			buffered = &bufferedTrace{created: nowFn()}
+			p.traces[traceID] = buffered
+		}
+		if len(buffered.spans) < p.maxSpans {
+			buffered.spans = append(buffered.spans, s)
+		}
+		p.mutex.Unlock()
+		return
+	}
+	delete(p.traces, traceID)
+	p.sweep()
+	p.mutex.Unlock()
+
+	if s.EndTime().Sub(s.StartTime()) < p.threshold {
+		// not slow query, drop all spans of trace
+		return
+	}
+	if buffered != nil {
+		for _, span := range buffered.spans {
+			p.next.OnEnd(&sampledSpan{ReadOnlySpan: span})
+		}
+	}
+	p.next.OnEnd(&sampledSpan{ReadOnlySpan: s})
+}
+
+// sweep removes the stale buffered traces whose local root span not ended(maybe lost), must be called with lock.
+func (p *slowQuerySpanProcessor) sweep() {
+	timeout := 10 * p.threshold
+	if timeout < minStaleTraceTimeout {
+		timeout = minStaleTraceTimeout
+	}
+	now := nowFn()
+	if now.Sub(p.lastSweep) < timeout {
+		return
+	}
+	p.lastSweep = now
+	for traceID, buffered := range p.traces {
+		if now.Sub(buffered.created) > timeout {
+			delete(p.traces, traceID)
+		}
+	}
+}
+
+// Shutdown shutdowns the next processor.
+func (p *slowQuerySpanProcessor) Shutdown(ctx context.Context) error {
+	p.mutex.Lock()
+	p.traces = make(map[trace.TraceID]*bufferedTrace)
+	p.mutex.Unlock()
+	return p.next.Shutdown(ctx)
+}
+
+// ForceFlush exports all ended spans of next processor.
+func (p *slowQuerySpanProcessor) ForceFlush(ctx context.Context) error {
+	return p.next.ForceFlush(ctx)
+}
+
+// sampledSpan represents the recorded span which is sampled by slow query.
+type sampledSpan struct {
+	sdktrace.ReadOnlySpan
+}
+
+// SpanContext returns the span context with sampled flag.
+func (s *sampledSpan) SpanContext() trace.SpanContext {
+	sc := s.ReadOnlySpan.SpanContext()
+	return sc.WithTraceFlags(sc.TraceFlags().WithSampled(true))
+}
+
+// Snapshot returns the snapshot of span with sampled flag.
+func (s *sampledSpan) Snapshot() *sdktrace.SpanSnapshot {
+	snapshot := s.ReadOnlySpan.Snapshot()
+	snapshot.SpanContext = s.SpanContext()
+	return snapshot
+}
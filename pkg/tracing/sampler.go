@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type deferredSamplingKeyType struct{}
+
+// withDeferredSampling returns a new context which marks the sampling decision of remote parent is deferred.
+func withDeferredSampling(ctx context.Context) context.Context {
+	return context.WithValue(ctx, deferredSamplingKeyType{}, true)
+}
+
+// isDeferredSampling returns if the sampling decision of remote parent is deferred.
+func isDeferredSampling(ctx context.Context) bool {
+	deferred, ok := ctx.Value(deferredSamplingKeyType{}).(bool)
+	return ok && deferred
+}
+
+// querySampler represents the sampler of query spans:
+// 1. the sampling decision of parent(trace header/remote node) is respected;
+// 2. root span without parent is sampled by ratio;
+// 3. if slow query sampling enabled, the spans not sampled are still recorded,
+// and the decision is deferred until local root span ended(see slowQuerySpanProcessor).
+type querySampler struct {
+	root     sdktrace.Sampler
+	deferred bool
+}
+
+// newQuerySampler creates the sampler of query spans.
+func newQuerySampler(ratio float64, deferred bool) sdktrace.Sampler {
+	return &querySampler{
+		root:     sdktrace.TraceIDRatioBased(ratio),
+		deferred: deferred,
+	}
+}
+
+// ShouldSample returns the sampling decision of span.
+func (s *querySampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	parentSpan := trace.SpanFromContext(p.ParentContext)
+	psc := parentSpan.SpanContext()
+	if psc.IsValid() {
+		if psc.IsSampled() {
+			return sdktrace.SamplingResult{Decision: sdktrace.RecordAndSample, Tracestate: psc.TraceState()}
+		}
+		if s.deferred && (parentSpan.IsRecording() || isDeferredSampling(p.ParentContext)) {
+			return sdktrace.SamplingResult{Decision: sdktrace.RecordOnly, Tracestate: psc.TraceState()}
+		}
+		return sdktrace.SamplingResult{Decision: sdktrace.Drop, Tracestate: psc.TraceState()}
+	}
+	result := s.root.ShouldSample(p)
+	if result.Decision == sdktrace.Drop && s.deferred {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+// Description returns information describing the sampler.
+func (s *querySampler) Description() string {
+	return fmt.Sprintf("QuerySampler{root:%s,deferred:%v}", s.root.Description(), s.deferred)
+}
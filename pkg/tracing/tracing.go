@@ -0,0 +1,182 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tracing
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpgrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+// for testing
+var (
+	newExporterFn = newOTLPExporter
+)
+
+const (
+	// instrumentationName represents the name of tracer which creates the spans of query.
+	instrumentationName = "github.com/lindb/lindb"
+	// deferredSamplingKey represents the key of carrier which marks the trace is recorded,
+	// but the sampling decision is deferred until query completed(slow query sampling).
+	deferredSamplingKey = "lindb-deferred-sampling"
+)
+
+// propagator propagates trace context as W3C trace context(traceparent/tracestate).
+var propagator = propagation.TraceContext{}
+
+// Shutdown flushes the pending spans and stops exporting.
+type Shutdown func(ctx context.Context) error
+
+// Init initializes the global tracer provider based on tracing config,
+// spans are exported via OTLP grpc exporter if tracing enabled, else tracer is noop.
+// slowQueryThreshold is used to decide if the recorded spans of query need to be exported when slow query sampling enabled.
+func Init(ctx context.Context, cfg config.Tracing, slowQueryThreshold time.Duration, serviceName, instance string) (Shutdown, error) {
+	if !cfg.Enabled {
+		return func(_ context.Context) error { return nil }, nil
+	}
+	exporter, err := newExporterFn(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	var processor sdktrace.SpanProcessor = sdktrace.NewBatchSpanProcessor(exporter)
+	if cfg.SlowQuerySampling {
+		processor = newSlowQuerySpanProcessor(processor, slowQueryThreshold, cfg.MaxBufferedSpans)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(newQuerySampler(cfg.SampleRatio, cfg.SlowQuerySampling)),
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(sdkresource.NewWithAttributes(
+			semconv.ServiceNameKey.String(serviceName),
+			semconv.ServiceInstanceIDKey.String(instance),
+		)),
+	)
+	otel.SetErrorHandler(&errorHandler{logger: logger.GetLogger("Tracing", "Exporter")})
+	otel.SetTracerProvider(provider)
+	return func(ctx context.Context) error {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		return provider.Shutdown(ctx)
+	}, nil
+}
+
+// newOTLPExporter creates the OTLP grpc exporter.
+func newOTLPExporter(ctx context.Context, cfg config.Tracing) (sdktrace.SpanExporter, error) {
+	opts := []otlpgrpc.Option{otlpgrpc.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlpgrpc.WithInsecure())
+	}
+	return otlp.NewExporter(ctx, otlpgrpc.NewDriver(opts...))
+}
+
+// StartSpan creates a span as the child of the span in context(if exists).
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// EndSpan records the error(if not nil) of span, then ends the span.
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the trace context of span in context for propagating to remote node, returns nil if no span.
+func Inject(ctx context.Context) map[string]string {
+	span := trace.SpanFromContext(ctx)
+	sc := span.SpanContext()
+	if !sc.IsValid() {
+		return nil
+	}
+	carrier := make(mapCarrier)
+	propagator.Inject(ctx, carrier)
+	if !sc.IsSampled() && span.IsRecording() {
+		// sampling decision is deferred, let remote node record the spans too
+		carrier[deferredSamplingKey] = "1"
+	}
+	return carrier
+}
+
+// Extract returns a new context with the remote trace context which is propagated by Inject.
+func Extract(ctx context.Context, carrier map[string]string) context.Context {
+	if len(carrier) == 0 {
+		return ctx
+	}
+	ctx = propagator.Extract(ctx, mapCarrier(carrier))
+	if carrier[deferredSamplingKey] != "" {
+		ctx = withDeferredSampling(ctx)
+	}
+	return ctx
+}
+
+// ExtractHTTP returns a new context with the trace context of http request header(traceparent/tracestate).
+func ExtractHTTP(ctx context.Context, header http.Header) context.Context {
+	return propagator.Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+// errorHandler implements otel.ErrorHandler interface, logs the error of exporting spans.
+type errorHandler struct {
+	logger *logger.Logger
+}
+
+// Handle logs the error of exporting spans.
+func (h *errorHandler) Handle(err error) {
+	if err == nil {
+		return
+	}
+	h.logger.Warn("export trace spans failure", logger.Error(err))
+}
+
+// mapCarrier implements propagation.TextMapCarrier interface using map.
+type mapCarrier map[string]string
+
+// Get returns the value associated with the passed key.
+func (c mapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set stores the key-value pair.
+func (c mapCarrier) Set(key, value string) {
+	c[key] = value
+}
+
+// Keys lists the keys stored in this carrier.
+func (c mapCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,217 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+func TestInit(t *testing.T) {
+	defer func() {
+		newExporterFn = newOTLPExporter
+	}()
+	// tracing disabled
+	shutdown, err := Init(context.TODO(), config.Tracing{}, time.Second, "broker", "node")
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.TODO()))
+
+	newExporterFn = func(_ context.Context, _ config.Tracing) (sdktrace.SpanExporter, error) {
+		return nil, fmt.Errorf("err")
+	}
+	shutdown, err = Init(context.TODO(), config.Tracing{Enabled: true}, time.Second, "broker", "node")
+	assert.Error(t, err)
+	assert.Nil(t, shutdown)
+}
+
+func TestTracing_SlowQuerySampling(t *testing.T) {
+	exporter := &memoryExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	shutdown := initWithExporter(t, exporter, config.Tracing{Enabled: true, SlowQuerySampling: true, MaxBufferedSpans: 10})
+	tracer := otel.Tracer(instrumentationName)
+	now := time.Now()
+
+	// fast query, drop it
+	ctx, root := tracer.Start(context.TODO(), "fast", trace.WithTimestamp(now))
+	_, child := StartSpan(ctx, "fast-child", attribute.Int("series", 1))
+	child.End()
+	root.End(trace.WithTimestamp(now.Add(time.Millisecond)))
+
+	// slow query, export all spans
+	ctx, root = tracer.Start(context.TODO(), "slow", trace.WithTimestamp(now))
+	_, child = StartSpan(ctx, "slow-child")
+	EndSpan(child, fmt.Errorf("err"))
+	root.End(trace.WithTimestamp(now.Add(2 * time.Second)))
+
+	assert.NoError(t, shutdown(context.TODO()))
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 2)
+	for _, span := range spans {
+		assert.True(t, span.SpanContext.IsSampled())
+		assert.Contains(t, []string{"slow", "slow-child"}, span.Name)
+	}
+}
+
+func TestTracing_TraceHeader(t *testing.T) {
+	exporter := &memoryExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	shutdown := initWithExporter(t, exporter, config.Tracing{Enabled: true, SlowQuerySampling: true, MaxBufferedSpans: 10})
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := ExtractHTTP(context.TODO(), header)
+	_, span := StartSpan(ctx, "sampled-by-header")
+	span.End()
+
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4737-00f067aa0ba902b7-00")
+	ctx = ExtractHTTP(context.TODO(), header)
+	_, span = StartSpan(ctx, "not-sampled-by-header")
+	assert.False(t, span.IsRecording())
+	span.End()
+
+	assert.NoError(t, shutdown(context.TODO()))
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 1)
+	assert.Equal(t, "sampled-by-header", spans[0].Name)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext.TraceID().String())
+}
+
+func TestTracing_InjectExtract(t *testing.T) {
+	exporter := &memoryExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	shutdown := initWithExporter(t, exporter, config.Tracing{Enabled: true, SlowQuerySampling: true, MaxBufferedSpans: 10})
+	defer func() {
+		assert.NoError(t, shutdown(context.TODO()))
+	}()
+
+	// no span
+	assert.Nil(t, Inject(context.TODO()))
+	assert.Equal(t, context.TODO(), Extract(context.TODO(), nil))
+
+	ctx, root := StartSpan(context.TODO(), "root")
+	defer root.End()
+	assert.True(t, root.IsRecording())
+	assert.False(t, root.SpanContext().IsSampled())
+	carrier := Inject(ctx)
+	assert.NotEmpty(t, carrier["traceparent"])
+	assert.Equal(t, "1", carrier[deferredSamplingKey])
+
+	// remote node records the spans of deferred trace
+	remoteCtx := Extract(context.TODO(), carrier)
+	_, remote := StartSpan(remoteCtx, "remote")
+	assert.True(t, remote.IsRecording())
+	assert.Equal(t, root.SpanContext().TraceID(), remote.SpanContext().TraceID())
+	remote.End()
+
+	// remote node drops the spans of not sampled trace
+	delete(carrier, deferredSamplingKey)
+	_, remote = StartSpan(Extract(context.TODO(), carrier), "remote")
+	assert.False(t, remote.IsRecording())
+	remote.End()
+}
+
+func TestQuerySampler(t *testing.T) {
+	sampler := newQuerySampler(1, false)
+	assert.NotEmpty(t, sampler.Description())
+	result := sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.TODO(), TraceID: trace.TraceID{1}})
+	assert.Equal(t, sdktrace.RecordAndSample, result.Decision)
+
+	sampler = newQuerySampler(0, false)
+	result = sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.TODO(), TraceID: trace.TraceID{1}})
+	assert.Equal(t, sdktrace.Drop, result.Decision)
+
+	sampler = newQuerySampler(0, true)
+	result = sampler.ShouldSample(sdktrace.SamplingParameters{ParentContext: context.TODO(), TraceID: trace.TraceID{1}})
+	assert.Equal(t, sdktrace.RecordOnly, result.Decision)
+}
+
+func TestSlowQuerySpanProcessor(t *testing.T) {
+	defer func() {
+		nowFn = time.Now
+	}()
+	exporter := tracetest.NewInMemoryExporter()
+	p := newSlowQuerySpanProcessor(sdktrace.NewSimpleSpanProcessor(exporter), time.Second, 1)
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(newQuerySampler(0, true)),
+		sdktrace.WithSpanProcessor(p),
+	)
+	tracer := provider.Tracer(instrumentationName)
+	now := time.Now()
+
+	// too many spans buffered, drop the spans exceed max spans
+	ctx, root := tracer.Start(context.TODO(), "root", trace.WithTimestamp(now))
+	for i := 0; i < 3; i++ {
+		_, child := tracer.Start(ctx, "child")
+		child.End()
+	}
+	root.End(trace.WithTimestamp(now.Add(2 * time.Second)))
+	assert.Len(t, exporter.GetSpans(), 2)
+
+	// local root span not ended, sweep stale trace
+	ctx, root = tracer.Start(context.TODO(), "lost-root")
+	_, child := tracer.Start(ctx, "child")
+	child.End()
+	assert.Len(t, p.(*slowQuerySpanProcessor).traces, 1)
+	nowFn = func() time.Time {
+		return time.Now().Add(time.Hour)
+	}
+	_, other := tracer.Start(context.TODO(), "other")
+	other.End()
+	assert.Empty(t, p.(*slowQuerySpanProcessor).traces)
+	_ = root
+
+	assert.NoError(t, provider.ForceFlush(context.TODO()))
+	assert.NoError(t, provider.Shutdown(context.TODO()))
+}
+
+func initWithExporter(t *testing.T, exporter sdktrace.SpanExporter, cfg config.Tracing) Shutdown {
+	newExporterFn = func(_ context.Context, _ config.Tracing) (sdktrace.SpanExporter, error) {
+		return exporter, nil
+	}
+	t.Cleanup(func() {
+		newExporterFn = newOTLPExporter
+	})
+	shutdown, err := Init(context.TODO(), cfg, time.Second, "broker", "node")
+	assert.NoError(t, err)
+	return shutdown
+}
+
+// memoryExporter keeps the exported spans after shutdown.
+type memoryExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (e *memoryExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestErrorHandler_Handle(t *testing.T) {
+	h := &errorHandler{logger: logger.GetLogger("Tracing", "Test")}
+	h.Handle(nil)
+	h.Handle(fmt.Errorf("err"))
+}
@@ -45,8 +45,9 @@ type IDStore interface {
 	Merge(key, val []byte) error
 	// Delete deletes the value by given key.
 	Delete(key []byte) error
-	// IterKeys iterates the key list by given prefix, returns the key list.
-	IterKeys(prefix []byte, limit int) (rs [][]byte, err error)
+	// IterKeys iterates the key list by given prefix in order, starts after the given key if not empty,
+	// returns the key list.
+	IterKeys(prefix, after []byte, limit int) (rs [][]byte, err error)
 	// Flush flushes the memory table data under pebble db.
 	Flush() error
 }
@@ -112,10 +113,15 @@ func (s *idStore) Delete(key []byte) error {
 	return s.db.Delete(key, &pebble.WriteOptions{Sync: false})
 }
 
-// IterKeys iterates the key list by given prefix, returns the key list.
-func (s *idStore) IterKeys(prefix []byte, limit int) (rs [][]byte, err error) {
+// IterKeys iterates the key list by given prefix in order, starts after the given key if not empty,
+// returns the key list.
+func (s *idStore) IterKeys(prefix, after []byte, limit int) (rs [][]byte, err error) {
+	lowerBound := prefix
+	if bytes.Compare(after, prefix) > 0 {
+		lowerBound = after
+	}
 	it := s.db.NewIter(&pebble.IterOptions{
-		LowerBound: prefix,
+		LowerBound: lowerBound,
 	})
 	defer func() {
 		if err0 := it.Close(); err0 != nil {
@@ -133,6 +139,10 @@ func (s *idStore) IterKeys(prefix []byte, limit int) (rs [][]byte, err error) {
 		if !bytes.HasPrefix(key, prefix) {
 			break
 		}
+		if bytes.Equal(key, after) {
+			// exclusive
+			continue
+		}
 		// copy it
 		dst := make([]byte, len(key))
 		copy(dst, key)
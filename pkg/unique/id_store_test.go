@@ -170,6 +170,7 @@ func TestIdStore_IterIDKeys(t *testing.T) {
 	cases := []struct {
 		name   string
 		prefix string
+		after  string
 		limit  int
 		length int
 	}{
@@ -203,12 +204,37 @@ func TestIdStore_IterIDKeys(t *testing.T) {
 			limit:  0,
 			length: 0,
 		},
+		{
+			name:   "start after key",
+			prefix: "ns",
+			after:  "ns-4",
+			limit:  100,
+			length: 5,
+		},
+		{
+			name:   "start after key less than prefix",
+			prefix: "ns",
+			after:  "a",
+			limit:  100,
+			length: 10,
+		},
+		{
+			name:   "start after last key",
+			prefix: "ns",
+			after:  "ns-9",
+			limit:  100,
+			length: 0,
+		},
 	}
 
 	for _, tt := range cases {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			keys, err := store.IterKeys([]byte(tt.prefix), tt.limit)
+			var after []byte
+			if tt.after != "" {
+				after = []byte(tt.after)
+			}
+			keys, err := store.IterKeys([]byte(tt.prefix), after, tt.limit)
 			assert.NoError(t, err)
 			assert.Len(t, keys, tt.length)
 		})
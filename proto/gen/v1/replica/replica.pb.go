@@ -429,649 +429,2343 @@ func (m *ReplicaResponse) GetErr() string {
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*ResetIndexRequest)(nil), "protoReplicaV1.ResetIndexRequest")
-	proto.RegisterType((*ResetIndexResponse)(nil), "protoReplicaV1.ResetIndexResponse")
-	proto.RegisterType((*GetReplicaAckIndexRequest)(nil), "protoReplicaV1.GetReplicaAckIndexRequest")
-	proto.RegisterType((*GetReplicaAckIndexResponse)(nil), "protoReplicaV1.GetReplicaAckIndexResponse")
-	proto.RegisterType((*ReplicaRequest)(nil), "protoReplicaV1.ReplicaRequest")
-	proto.RegisterType((*ReplicaResponse)(nil), "protoReplicaV1.ReplicaResponse")
+// SnapshotFile represents the sst file of family snapshot.
+type SnapshotFile struct {
+	FileNumber int64  `protobuf:"varint,1,opt,name=fileNumber,proto3" json:"fileNumber,omitempty"`
+	MinKey     uint32 `protobuf:"varint,2,opt,name=minKey,proto3" json:"minKey,omitempty"`
+	MaxKey     uint32 `protobuf:"varint,3,opt,name=maxKey,proto3" json:"maxKey,omitempty"`
+	FileSize   int64  `protobuf:"varint,4,opt,name=fileSize,proto3" json:"fileSize,omitempty"`
+	// crc32 checksum of whole file
+	Checksum             uint32   `protobuf:"varint,5,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func init() { proto.RegisterFile("replica.proto", fileDescriptor_1e84aa831fb48ea1) }
+func (m *SnapshotFile) Reset()         { *m = SnapshotFile{} }
+func (m *SnapshotFile) String() string { return proto.CompactTextString(m) }
+func (*SnapshotFile) ProtoMessage()    {}
+func (*SnapshotFile) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{6}
+}
+func (m *SnapshotFile) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SnapshotFile) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SnapshotFile.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SnapshotFile) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotFile.Merge(m, src)
+}
+func (m *SnapshotFile) XXX_Size() int {
+	return m.Size()
+}
+func (m *SnapshotFile) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotFile.DiscardUnknown(m)
+}
 
-var fileDescriptor_1e84aa831fb48ea1 = []byte{
-	// 381 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x92, 0x3f, 0x4e, 0xc3, 0x30,
-	0x14, 0xc6, 0xeb, 0x96, 0xb4, 0xf0, 0x28, 0xa5, 0x58, 0x15, 0x0a, 0x19, 0x42, 0xf0, 0x14, 0x18,
-	0x2a, 0xfe, 0x2c, 0xac, 0xb0, 0x20, 0x24, 0x86, 0x2a, 0x20, 0x76, 0x37, 0x79, 0x88, 0x88, 0xb6,
-	0x09, 0x4e, 0x40, 0x70, 0x00, 0xee, 0xc0, 0xc6, 0xc0, 0x65, 0x18, 0xe1, 0x06, 0xa8, 0x5c, 0x04,
-	0xd5, 0x76, 0xd3, 0x94, 0xd2, 0xaa, 0x03, 0x12, 0x53, 0xf2, 0x3e, 0xfb, 0xf3, 0xfb, 0xf9, 0xf9,
-	0x83, 0x15, 0x81, 0x71, 0x27, 0xf4, 0x79, 0x33, 0x16, 0x51, 0x1a, 0xd1, 0x9a, 0xfc, 0x78, 0x4a,
-	0xbb, 0xdc, 0x63, 0x2f, 0x04, 0xd6, 0x3c, 0x4c, 0x30, 0x3d, 0xed, 0x05, 0xf8, 0xe0, 0xe1, 0xed,
-	0x1d, 0x26, 0x29, 0xb5, 0x60, 0x31, 0xe0, 0x29, 0x6f, 0xf3, 0x04, 0x4d, 0xe2, 0x10, 0x77, 0xc9,
-	0xcb, 0x6a, 0xda, 0x00, 0x23, 0xb9, 0xe6, 0x22, 0x30, 0x8b, 0x0e, 0x71, 0x0d, 0x4f, 0x15, 0x74,
-	0x1d, 0xca, 0x1d, 0xe4, 0x01, 0x0a, 0xb3, 0x24, 0x65, 0x5d, 0x51, 0x1b, 0xe0, 0x8a, 0x77, 0xc3,
-	0xce, 0xe3, 0x45, 0xd8, 0x45, 0x73, 0xc1, 0x21, 0x6e, 0xc9, 0xcb, 0x29, 0xd4, 0x81, 0x65, 0x1e,
-	0xc7, 0xd8, 0x0b, 0x64, 0x7f, 0xd3, 0x90, 0x1b, 0xf2, 0x12, 0x6b, 0x00, 0xcd, 0x03, 0x26, 0x71,
-	0xd4, 0x4b, 0x90, 0x3d, 0x11, 0xd8, 0x38, 0xc1, 0x54, 0x5f, 0xe4, 0xc8, 0xbf, 0xf9, 0x1f, 0x7e,
-	0x76, 0x08, 0xd6, 0x6f, 0x18, 0x8a, 0x72, 0xc0, 0xc1, 0xb5, 0xa6, 0xaf, 0x96, 0xd5, 0xec, 0x0c,
-	0x6a, 0xda, 0x36, 0xa4, 0x66, 0x50, 0xd5, 0x8f, 0xa5, 0x1c, 0xaa, 0xdb, 0x98, 0x36, 0xe0, 0x14,
-	0xe8, 0x47, 0x22, 0x90, 0xe7, 0x55, 0x3d, 0x5d, 0xb1, 0x0f, 0x02, 0xab, 0xd9, 0x71, 0xa3, 0xee,
-	0x7f, 0x34, 0x85, 0x79, 0xc8, 0x66, 0xdc, 0x55, 0xf9, 0x15, 0x95, 0x9c, 0x63, 0x79, 0xe8, 0x1f,
-	0x69, 0xb4, 0x0e, 0x25, 0x14, 0xc2, 0xac, 0x48, 0xd0, 0xc1, 0xef, 0xfe, 0x6b, 0x31, 0x1b, 0xd1,
-	0x39, 0x8a, 0xfb, 0xd0, 0x47, 0xda, 0x02, 0x43, 0x86, 0x81, 0x6e, 0x35, 0xc7, 0x83, 0xdc, 0x9c,
-	0x08, 0xb1, 0xc5, 0x66, 0x6d, 0xd1, 0x31, 0x2a, 0xd0, 0x2e, 0xd0, 0xc9, 0x07, 0xa4, 0xdb, 0x3f,
-	0xbd, 0x53, 0xb3, 0x66, 0xed, 0xcc, 0xb3, 0x35, 0x6b, 0xd7, 0x82, 0x8a, 0x5e, 0xa4, 0xf6, 0x24,
-	0x5f, 0x3e, 0x0e, 0xd6, 0xe6, 0xd4, 0xf5, 0xe1, 0x69, 0x2e, 0xd9, 0x25, 0xc7, 0xf5, 0xb7, 0xbe,
-	0x4d, 0xde, 0xfb, 0x36, 0xf9, 0xec, 0xdb, 0xe4, 0xf9, 0xcb, 0x2e, 0xb4, 0xcb, 0xd2, 0x77, 0xf0,
-	0x1d, 0x00, 0x00, 0xff, 0xff, 0xe0, 0x4f, 0xf5, 0x68, 0xfb, 0x03, 0x00, 0x00,
+var xxx_messageInfo_SnapshotFile proto.InternalMessageInfo
+
+func (m *SnapshotFile) GetFileNumber() int64 {
+	if m != nil {
+		return m.FileNumber
+	}
+	return 0
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (m *SnapshotFile) GetMinKey() uint32 {
+	if m != nil {
+		return m.MinKey
+	}
+	return 0
+}
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *SnapshotFile) GetMaxKey() uint32 {
+	if m != nil {
+		return m.MaxKey
+	}
+	return 0
+}
 
-// ReplicaServiceClient is the client API for ReplicaService service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type ReplicaServiceClient interface {
-	Reset(ctx context.Context, in *ResetIndexRequest, opts ...grpc.CallOption) (*ResetIndexResponse, error)
-	GetReplicaAckIndex(ctx context.Context, in *GetReplicaAckIndexRequest, opts ...grpc.CallOption) (*GetReplicaAckIndexResponse, error)
-	Replica(ctx context.Context, opts ...grpc.CallOption) (ReplicaService_ReplicaClient, error)
+func (m *SnapshotFile) GetFileSize() int64 {
+	if m != nil {
+		return m.FileSize
+	}
+	return 0
 }
 
-type replicaServiceClient struct {
-	cc *grpc.ClientConn
+func (m *SnapshotFile) GetChecksum() uint32 {
+	if m != nil {
+		return m.Checksum
+	}
+	return 0
 }
 
-func NewReplicaServiceClient(cc *grpc.ClientConn) ReplicaServiceClient {
-	return &replicaServiceClient{cc}
+// FamilySnapshot represents the sst files of family and the write sequences(sequence cut) of leaders which files contain.
+type FamilySnapshot struct {
+	FamilyTime           int64           `protobuf:"varint,1,opt,name=familyTime,proto3" json:"familyTime,omitempty"`
+	Sequences            map[int32]int64 `protobuf:"bytes,2,rep,name=sequences,proto3" json:"sequences,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	Files                []*SnapshotFile `protobuf:"bytes,3,rep,name=files,proto3" json:"files,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
 }
 
-func (c *replicaServiceClient) Reset(ctx context.Context, in *ResetIndexRequest, opts ...grpc.CallOption) (*ResetIndexResponse, error) {
-	out := new(ResetIndexResponse)
-	err := c.cc.Invoke(ctx, "/protoReplicaV1.ReplicaService/Reset", in, out, opts...)
-	if err != nil {
-		return nil, err
+func (m *FamilySnapshot) Reset()         { *m = FamilySnapshot{} }
+func (m *FamilySnapshot) String() string { return proto.CompactTextString(m) }
+func (*FamilySnapshot) ProtoMessage()    {}
+func (*FamilySnapshot) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{7}
+}
+func (m *FamilySnapshot) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FamilySnapshot) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FamilySnapshot.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return out, nil
+}
+func (m *FamilySnapshot) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FamilySnapshot.Merge(m, src)
+}
+func (m *FamilySnapshot) XXX_Size() int {
+	return m.Size()
+}
+func (m *FamilySnapshot) XXX_DiscardUnknown() {
+	xxx_messageInfo_FamilySnapshot.DiscardUnknown(m)
 }
 
-func (c *replicaServiceClient) GetReplicaAckIndex(ctx context.Context, in *GetReplicaAckIndexRequest, opts ...grpc.CallOption) (*GetReplicaAckIndexResponse, error) {
-	out := new(GetReplicaAckIndexResponse)
-	err := c.cc.Invoke(ctx, "/protoReplicaV1.ReplicaService/GetReplicaAckIndex", in, out, opts...)
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_FamilySnapshot proto.InternalMessageInfo
+
+func (m *FamilySnapshot) GetFamilyTime() int64 {
+	if m != nil {
+		return m.FamilyTime
 	}
-	return out, nil
+	return 0
 }
 
-func (c *replicaServiceClient) Replica(ctx context.Context, opts ...grpc.CallOption) (ReplicaService_ReplicaClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_ReplicaService_serviceDesc.Streams[0], "/protoReplicaV1.ReplicaService/Replica", opts...)
-	if err != nil {
-		return nil, err
+func (m *FamilySnapshot) GetSequences() map[int32]int64 {
+	if m != nil {
+		return m.Sequences
 	}
-	x := &replicaServiceReplicaClient{stream}
-	return x, nil
+	return nil
 }
 
-type ReplicaService_ReplicaClient interface {
-	Send(*ReplicaRequest) error
-	Recv() (*ReplicaResponse, error)
-	grpc.ClientStream
+func (m *FamilySnapshot) GetFiles() []*SnapshotFile {
+	if m != nil {
+		return m.Files
+	}
+	return nil
 }
 
-type replicaServiceReplicaClient struct {
-	grpc.ClientStream
+type GetSnapshotRequest struct {
+	Database             string   `protobuf:"bytes,1,opt,name=database,proto3" json:"database,omitempty"`
+	Shard                int32    `protobuf:"varint,2,opt,name=shard,proto3" json:"shard,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (x *replicaServiceReplicaClient) Send(m *ReplicaRequest) error {
-	return x.ClientStream.SendMsg(m)
+func (m *GetSnapshotRequest) Reset()         { *m = GetSnapshotRequest{} }
+func (m *GetSnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSnapshotRequest) ProtoMessage()    {}
+func (*GetSnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{8}
+}
+func (m *GetSnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetSnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetSnapshotRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetSnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSnapshotRequest.Merge(m, src)
+}
+func (m *GetSnapshotRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetSnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSnapshotRequest.DiscardUnknown(m)
 }
 
-func (x *replicaServiceReplicaClient) Recv() (*ReplicaResponse, error) {
-	m := new(ReplicaResponse)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+var xxx_messageInfo_GetSnapshotRequest proto.InternalMessageInfo
+
+func (m *GetSnapshotRequest) GetDatabase() string {
+	if m != nil {
+		return m.Database
 	}
-	return m, nil
+	return ""
 }
 
-// ReplicaServiceServer is the server API for ReplicaService service.
-type ReplicaServiceServer interface {
-	Reset(context.Context, *ResetIndexRequest) (*ResetIndexResponse, error)
-	GetReplicaAckIndex(context.Context, *GetReplicaAckIndexRequest) (*GetReplicaAckIndexResponse, error)
-	Replica(ReplicaService_ReplicaServer) error
+func (m *GetSnapshotRequest) GetShard() int32 {
+	if m != nil {
+		return m.Shard
+	}
+	return 0
 }
 
-// UnimplementedReplicaServiceServer can be embedded to have forward compatible implementations.
-type UnimplementedReplicaServiceServer struct {
+type GetSnapshotResponse struct {
+	SnapshotID           string            `protobuf:"bytes,1,opt,name=snapshotID,proto3" json:"snapshotID,omitempty"`
+	Families             []*FamilySnapshot `protobuf:"bytes,2,rep,name=families,proto3" json:"families,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
-func (*UnimplementedReplicaServiceServer) Reset(ctx context.Context, req *ResetIndexRequest) (*ResetIndexResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method Reset not implemented")
+func (m *GetSnapshotResponse) Reset()         { *m = GetSnapshotResponse{} }
+func (m *GetSnapshotResponse) String() string { return proto.CompactTextString(m) }
+func (*GetSnapshotResponse) ProtoMessage()    {}
+func (*GetSnapshotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{9}
 }
-func (*UnimplementedReplicaServiceServer) GetReplicaAckIndex(ctx context.Context, req *GetReplicaAckIndexRequest) (*GetReplicaAckIndexResponse, error) {
-	return nil, status.Errorf(codes.Unimplemented, "method GetReplicaAckIndex not implemented")
+func (m *GetSnapshotResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
 }
-func (*UnimplementedReplicaServiceServer) Replica(srv ReplicaService_ReplicaServer) error {
-	return status.Errorf(codes.Unimplemented, "method Replica not implemented")
+func (m *GetSnapshotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetSnapshotResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
 }
-
-func RegisterReplicaServiceServer(s *grpc.Server, srv ReplicaServiceServer) {
-	s.RegisterService(&_ReplicaService_serviceDesc, srv)
+func (m *GetSnapshotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSnapshotResponse.Merge(m, src)
+}
+func (m *GetSnapshotResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetSnapshotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSnapshotResponse.DiscardUnknown(m)
 }
 
-func _ReplicaService_Reset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(ResetIndexRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(ReplicaServiceServer).Reset(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/protoReplicaV1.ReplicaService/Reset",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ReplicaServiceServer).Reset(ctx, req.(*ResetIndexRequest))
+var xxx_messageInfo_GetSnapshotResponse proto.InternalMessageInfo
+
+func (m *GetSnapshotResponse) GetSnapshotID() string {
+	if m != nil {
+		return m.SnapshotID
 	}
-	return interceptor(ctx, in, info, handler)
+	return ""
 }
 
-func _ReplicaService_GetReplicaAckIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
-	in := new(GetReplicaAckIndexRequest)
-	if err := dec(in); err != nil {
-		return nil, err
-	}
-	if interceptor == nil {
-		return srv.(ReplicaServiceServer).GetReplicaAckIndex(ctx, in)
-	}
-	info := &grpc.UnaryServerInfo{
-		Server:     srv,
-		FullMethod: "/protoReplicaV1.ReplicaService/GetReplicaAckIndex",
-	}
-	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
-		return srv.(ReplicaServiceServer).GetReplicaAckIndex(ctx, req.(*GetReplicaAckIndexRequest))
+func (m *GetSnapshotResponse) GetFamilies() []*FamilySnapshot {
+	if m != nil {
+		return m.Families
 	}
-	return interceptor(ctx, in, info, handler)
+	return nil
 }
 
-func _ReplicaService_Replica_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(ReplicaServiceServer).Replica(&replicaServiceReplicaServer{stream})
+type FetchSnapshotRequest struct {
+	SnapshotID string `protobuf:"bytes,1,opt,name=snapshotID,proto3" json:"snapshotID,omitempty"`
+	FamilyTime int64  `protobuf:"varint,2,opt,name=familyTime,proto3" json:"familyTime,omitempty"`
+	FileNumber int64  `protobuf:"varint,3,opt,name=fileNumber,proto3" json:"fileNumber,omitempty"`
+	// offset of file which transfer starts from
+	Offset               int64    `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-type ReplicaService_ReplicaServer interface {
-	Send(*ReplicaResponse) error
-	Recv() (*ReplicaRequest, error)
-	grpc.ServerStream
+func (m *FetchSnapshotRequest) Reset()         { *m = FetchSnapshotRequest{} }
+func (m *FetchSnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*FetchSnapshotRequest) ProtoMessage()    {}
+func (*FetchSnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{10}
+}
+func (m *FetchSnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *FetchSnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_FetchSnapshotRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *FetchSnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_FetchSnapshotRequest.Merge(m, src)
+}
+func (m *FetchSnapshotRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *FetchSnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_FetchSnapshotRequest.DiscardUnknown(m)
 }
 
-type replicaServiceReplicaServer struct {
-	grpc.ServerStream
+var xxx_messageInfo_FetchSnapshotRequest proto.InternalMessageInfo
+
+func (m *FetchSnapshotRequest) GetSnapshotID() string {
+	if m != nil {
+		return m.SnapshotID
+	}
+	return ""
 }
 
-func (x *replicaServiceReplicaServer) Send(m *ReplicaResponse) error {
-	return x.ServerStream.SendMsg(m)
+func (m *FetchSnapshotRequest) GetFamilyTime() int64 {
+	if m != nil {
+		return m.FamilyTime
+	}
+	return 0
 }
 
-func (x *replicaServiceReplicaServer) Recv() (*ReplicaRequest, error) {
-	m := new(ReplicaRequest)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *FetchSnapshotRequest) GetFileNumber() int64 {
+	if m != nil {
+		return m.FileNumber
 	}
-	return m, nil
+	return 0
 }
 
-var _ReplicaService_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "protoReplicaV1.ReplicaService",
-	HandlerType: (*ReplicaServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "Reset",
-			Handler:    _ReplicaService_Reset_Handler,
-		},
-		{
-			MethodName: "GetReplicaAckIndex",
-			Handler:    _ReplicaService_GetReplicaAckIndex_Handler,
-		},
-	},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "Replica",
-			Handler:       _ReplicaService_Replica_Handler,
-			ServerStreams: true,
-			ClientStreams: true,
-		},
-	},
-	Metadata: "replica.proto",
-}
-
-func (m *ResetIndexRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *FetchSnapshotRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *ResetIndexRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+// SnapshotChunk represents a chunk of snapshot file.
+type SnapshotChunk struct {
+	Offset int64  `protobuf:"varint,1,opt,name=offset,proto3" json:"offset,omitempty"`
+	Data   []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// crc32 checksum of chunk data
+	Checksum             uint32   `protobuf:"varint,3,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ResetIndexRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
-	}
-	if m.AppendIndex != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.AppendIndex))
-		i--
-		dAtA[i] = 0x28
-	}
-	if m.FamilyTime != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.FamilyTime))
-		i--
-		dAtA[i] = 0x20
-	}
-	if m.Leader != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.Leader))
-		i--
-		dAtA[i] = 0x18
-	}
-	if m.Shard != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.Shard))
-		i--
-		dAtA[i] = 0x10
-	}
-	if len(m.Database) > 0 {
-		i -= len(m.Database)
-		copy(dAtA[i:], m.Database)
-		i = encodeVarintReplica(dAtA, i, uint64(len(m.Database)))
-		i--
-		dAtA[i] = 0xa
-	}
-	return len(dAtA) - i, nil
+func (m *SnapshotChunk) Reset()         { *m = SnapshotChunk{} }
+func (m *SnapshotChunk) String() string { return proto.CompactTextString(m) }
+func (*SnapshotChunk) ProtoMessage()    {}
+func (*SnapshotChunk) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{11}
 }
-
-func (m *ResetIndexResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *SnapshotChunk) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SnapshotChunk) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SnapshotChunk.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return dAtA[:n], nil
+}
+func (m *SnapshotChunk) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SnapshotChunk.Merge(m, src)
+}
+func (m *SnapshotChunk) XXX_Size() int {
+	return m.Size()
+}
+func (m *SnapshotChunk) XXX_DiscardUnknown() {
+	xxx_messageInfo_SnapshotChunk.DiscardUnknown(m)
 }
 
-func (m *ResetIndexResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_SnapshotChunk proto.InternalMessageInfo
+
+func (m *SnapshotChunk) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
 }
 
-func (m *ResetIndexResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
+func (m *SnapshotChunk) GetData() []byte {
+	if m != nil {
+		return m.Data
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *GetReplicaAckIndexRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *SnapshotChunk) GetChecksum() uint32 {
+	if m != nil {
+		return m.Checksum
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *GetReplicaAckIndexRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ReleaseSnapshotRequest struct {
+	SnapshotID           string   `protobuf:"bytes,1,opt,name=snapshotID,proto3" json:"snapshotID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetReplicaAckIndexRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
-	}
-	if m.FamilyTime != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.FamilyTime))
-		i--
-		dAtA[i] = 0x20
-	}
-	if m.Leader != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.Leader))
-		i--
-		dAtA[i] = 0x18
-	}
-	if m.Shard != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.Shard))
-		i--
-		dAtA[i] = 0x10
-	}
-	if len(m.Database) > 0 {
-		i -= len(m.Database)
-		copy(dAtA[i:], m.Database)
-		i = encodeVarintReplica(dAtA, i, uint64(len(m.Database)))
-		i--
-		dAtA[i] = 0xa
+func (m *ReleaseSnapshotRequest) Reset()         { *m = ReleaseSnapshotRequest{} }
+func (m *ReleaseSnapshotRequest) String() string { return proto.CompactTextString(m) }
+func (*ReleaseSnapshotRequest) ProtoMessage()    {}
+func (*ReleaseSnapshotRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{12}
+}
+func (m *ReleaseSnapshotRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReleaseSnapshotRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReleaseSnapshotRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *ReleaseSnapshotRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReleaseSnapshotRequest.Merge(m, src)
+}
+func (m *ReleaseSnapshotRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReleaseSnapshotRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReleaseSnapshotRequest.DiscardUnknown(m)
 }
 
-func (m *GetReplicaAckIndexResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+var xxx_messageInfo_ReleaseSnapshotRequest proto.InternalMessageInfo
+
+func (m *ReleaseSnapshotRequest) GetSnapshotID() string {
+	if m != nil {
+		return m.SnapshotID
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *GetReplicaAckIndexResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ReleaseSnapshotResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *GetReplicaAckIndexResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
-	}
-	if m.AckIndex != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.AckIndex))
-		i--
-		dAtA[i] = 0x28
+func (m *ReleaseSnapshotResponse) Reset()         { *m = ReleaseSnapshotResponse{} }
+func (m *ReleaseSnapshotResponse) String() string { return proto.CompactTextString(m) }
+func (*ReleaseSnapshotResponse) ProtoMessage()    {}
+func (*ReleaseSnapshotResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1e84aa831fb48ea1, []int{13}
+}
+func (m *ReleaseSnapshotResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ReleaseSnapshotResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ReleaseSnapshotResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return len(dAtA) - i, nil
+}
+func (m *ReleaseSnapshotResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReleaseSnapshotResponse.Merge(m, src)
+}
+func (m *ReleaseSnapshotResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *ReleaseSnapshotResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReleaseSnapshotResponse.DiscardUnknown(m)
 }
 
-func (m *ReplicaRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+var xxx_messageInfo_ReleaseSnapshotResponse proto.InternalMessageInfo
+
+func init() {
+	proto.RegisterType((*ResetIndexRequest)(nil), "protoReplicaV1.ResetIndexRequest")
+	proto.RegisterType((*ResetIndexResponse)(nil), "protoReplicaV1.ResetIndexResponse")
+	proto.RegisterType((*GetReplicaAckIndexRequest)(nil), "protoReplicaV1.GetReplicaAckIndexRequest")
+	proto.RegisterType((*GetReplicaAckIndexResponse)(nil), "protoReplicaV1.GetReplicaAckIndexResponse")
+	proto.RegisterType((*ReplicaRequest)(nil), "protoReplicaV1.ReplicaRequest")
+	proto.RegisterType((*ReplicaResponse)(nil), "protoReplicaV1.ReplicaResponse")
+	proto.RegisterType((*SnapshotFile)(nil), "protoReplicaV1.SnapshotFile")
+	proto.RegisterType((*FamilySnapshot)(nil), "protoReplicaV1.FamilySnapshot")
+	proto.RegisterMapType((map[int32]int64)(nil), "protoReplicaV1.FamilySnapshot.SequencesEntry")
+	proto.RegisterType((*GetSnapshotRequest)(nil), "protoReplicaV1.GetSnapshotRequest")
+	proto.RegisterType((*GetSnapshotResponse)(nil), "protoReplicaV1.GetSnapshotResponse")
+	proto.RegisterType((*FetchSnapshotRequest)(nil), "protoReplicaV1.FetchSnapshotRequest")
+	proto.RegisterType((*SnapshotChunk)(nil), "protoReplicaV1.SnapshotChunk")
+	proto.RegisterType((*ReleaseSnapshotRequest)(nil), "protoReplicaV1.ReleaseSnapshotRequest")
+	proto.RegisterType((*ReleaseSnapshotResponse)(nil), "protoReplicaV1.ReleaseSnapshotResponse")
 }
 
-func (m *ReplicaRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func init() { proto.RegisterFile("replica.proto", fileDescriptor_1e84aa831fb48ea1) }
+
+var fileDescriptor_1e84aa831fb48ea1 = []byte{
+	// 736 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x55, 0xcd, 0x6e, 0xd3, 0x4a,
+	0x14, 0xee, 0xc4, 0x4d, 0x7f, 0x4e, 0x93, 0xb4, 0x77, 0x6e, 0xd5, 0xeb, 0x5a, 0xf7, 0xfa, 0x86,
+	0x01, 0x41, 0x40, 0x22, 0x2a, 0x65, 0x53, 0x55, 0x6c, 0xf8, 0x4b, 0x55, 0x15, 0xa1, 0x6a, 0x82,
+	0xa0, 0x5b, 0xd7, 0x39, 0x55, 0xac, 0x24, 0x4e, 0xea, 0x71, 0xaa, 0x86, 0x3d, 0x5b, 0x96, 0x88,
+	0x1d, 0x4f, 0xc2, 0x9e, 0x25, 0xbc, 0x01, 0x2a, 0x0f, 0xc0, 0x2b, 0xa0, 0x19, 0x8f, 0x5d, 0xdb,
+	0x49, 0xd3, 0x82, 0x90, 0x58, 0xc5, 0xe7, 0xf3, 0xf9, 0xf9, 0xce, 0x39, 0x9f, 0x4f, 0xa0, 0x1c,
+	0xe0, 0xa0, 0xeb, 0xb9, 0x4e, 0x7d, 0x10, 0xf4, 0xc3, 0x3e, 0xad, 0xa8, 0x1f, 0x1e, 0x61, 0x2f,
+	0xef, 0xb1, 0x0f, 0x04, 0xfe, 0xe2, 0x28, 0x30, 0xdc, 0xf5, 0x5b, 0x78, 0xca, 0xf1, 0x78, 0x88,
+	0x22, 0xa4, 0x16, 0x2c, 0xb4, 0x9c, 0xd0, 0x39, 0x74, 0x04, 0x9a, 0xa4, 0x4a, 0x6a, 0x8b, 0x3c,
+	0xb1, 0xe9, 0x2a, 0x14, 0x45, 0xdb, 0x09, 0x5a, 0x66, 0xa1, 0x4a, 0x6a, 0x45, 0x1e, 0x19, 0x74,
+	0x0d, 0xe6, 0xba, 0xe8, 0xb4, 0x30, 0x30, 0x0d, 0x05, 0x6b, 0x8b, 0xda, 0x00, 0x47, 0x4e, 0xcf,
+	0xeb, 0x8e, 0x5e, 0x78, 0x3d, 0x34, 0x67, 0xab, 0xa4, 0x66, 0xf0, 0x14, 0x42, 0xab, 0xb0, 0xe4,
+	0x0c, 0x06, 0xe8, 0xb7, 0x54, 0x7d, 0xb3, 0xa8, 0x1c, 0xd2, 0x10, 0x5b, 0x05, 0x9a, 0x26, 0x28,
+	0x06, 0x7d, 0x5f, 0x20, 0x7b, 0x43, 0x60, 0x7d, 0x07, 0x43, 0xdd, 0xc8, 0x43, 0xb7, 0xf3, 0x67,
+	0xf8, 0xb3, 0x2d, 0xb0, 0x26, 0xd1, 0x88, 0x58, 0x4a, 0x1e, 0x8e, 0xdb, 0x49, 0xb7, 0x96, 0xd8,
+	0xec, 0x19, 0x54, 0x74, 0x58, 0xcc, 0x9a, 0x41, 0x49, 0x2f, 0x2b, 0x8a, 0x88, 0xaa, 0x65, 0x30,
+	0xc9, 0x33, 0x40, 0xb7, 0x1f, 0xb4, 0x54, 0xbe, 0x12, 0xd7, 0x16, 0xfb, 0x42, 0x60, 0x39, 0x49,
+	0x77, 0x5e, 0xfd, 0x37, 0x4d, 0xe1, 0x2a, 0xcc, 0xa6, 0xf4, 0x1a, 0xc5, 0x47, 0xac, 0xd4, 0x1c,
+	0xe7, 0xe2, 0xf8, 0x73, 0x8c, 0xae, 0x80, 0x81, 0x41, 0x60, 0xce, 0x2b, 0xa2, 0xf2, 0x91, 0xbd,
+	0x23, 0x50, 0x6a, 0xfa, 0xce, 0x40, 0xb4, 0xfb, 0x61, 0xc3, 0xeb, 0xa2, 0x5a, 0x86, 0xd7, 0xc5,
+	0xe7, 0xc3, 0xde, 0x21, 0x06, 0x26, 0xd1, 0xcb, 0x48, 0x10, 0x49, 0xbf, 0xe7, 0xf9, 0x7b, 0x38,
+	0x52, 0x5d, 0x95, 0xb9, 0xb6, 0x14, 0xee, 0x9c, 0x4a, 0xdc, 0xd0, 0xb8, 0xb2, 0x24, 0x65, 0x19,
+	0xdd, 0xf4, 0x5e, 0xc7, 0xab, 0x4d, 0x6c, 0xf9, 0xce, 0x6d, 0xa3, 0xdb, 0x11, 0xc3, 0x9e, 0x6a,
+	0xa7, 0xcc, 0x13, 0x9b, 0x7d, 0x27, 0x50, 0x69, 0x28, 0x0d, 0xc4, 0xf4, 0x72, 0x3a, 0x21, 0x63,
+	0x3a, 0xdf, 0x83, 0x45, 0x21, 0xd7, 0xec, 0xbb, 0x28, 0xcc, 0x42, 0xd5, 0xa8, 0x2d, 0x6d, 0xde,
+	0xad, 0x67, 0xbf, 0xc5, 0x7a, 0x36, 0x65, 0xbd, 0x19, 0xfb, 0x3f, 0xf5, 0xc3, 0x60, 0xc4, 0xcf,
+	0xe3, 0xe9, 0x26, 0x14, 0x25, 0x4f, 0x61, 0x1a, 0x2a, 0xd1, 0xbf, 0xf9, 0x44, 0xe9, 0xa1, 0xf1,
+	0xc8, 0xd5, 0x7a, 0x00, 0x95, 0x6c, 0x42, 0x39, 0xf0, 0x0e, 0x8e, 0x14, 0xd7, 0x22, 0x97, 0x8f,
+	0x52, 0x14, 0x27, 0x4e, 0x77, 0x88, 0x6a, 0x7c, 0x06, 0x8f, 0x8c, 0xed, 0xc2, 0x16, 0x61, 0x0d,
+	0xa0, 0x3b, 0x18, 0xc6, 0x79, 0x7f, 0xf9, 0x33, 0x63, 0xc7, 0xf0, 0x77, 0x26, 0x8f, 0x56, 0xaa,
+	0x0d, 0x20, 0x34, 0xb6, 0xfb, 0x44, 0xa7, 0x4a, 0x21, 0x74, 0x1b, 0x16, 0xd4, 0x2c, 0xbd, 0x64,
+	0x78, 0xf6, 0xf4, 0xe1, 0xf1, 0xc4, 0x9f, 0xbd, 0x25, 0xb0, 0xda, 0xc0, 0xd0, 0x6d, 0xe7, 0xd9,
+	0x5f, 0x56, 0x34, 0xbb, 0xd2, 0xc2, 0xd8, 0x4a, 0xb3, 0x6a, 0x34, 0x26, 0xa9, 0xb1, 0x7f, 0x74,
+	0x24, 0x30, 0xd4, 0xda, 0xd2, 0x16, 0x7b, 0x05, 0xe5, 0x98, 0xca, 0xe3, 0xf6, 0xd0, 0xef, 0xa4,
+	0x1c, 0x49, 0xda, 0x91, 0x52, 0x98, 0x95, 0xe3, 0x54, 0xa5, 0x4b, 0x5c, 0x3d, 0x67, 0x64, 0x69,
+	0xe4, 0x64, 0xb9, 0x05, 0x6b, 0x1c, 0xbb, 0xe8, 0x08, 0xfc, 0xc9, 0x56, 0xd9, 0x3a, 0xfc, 0x33,
+	0x16, 0x19, 0xad, 0x66, 0xf3, 0xe3, 0x6c, 0x72, 0xa7, 0x9a, 0x18, 0x9c, 0x78, 0x2e, 0xd2, 0x7d,
+	0x28, 0xaa, 0x8b, 0x4c, 0xaf, 0xe5, 0x97, 0x30, 0xf6, 0x4f, 0x62, 0xb1, 0x69, 0x2e, 0xfa, 0x96,
+	0xcf, 0xd0, 0x9e, 0x92, 0x57, 0xee, 0x8a, 0xd2, 0xdb, 0xf9, 0xd8, 0x0b, 0x0f, 0xbe, 0x75, 0xe7,
+	0x2a, 0xae, 0x49, 0xb9, 0x7d, 0x98, 0xd7, 0x2f, 0xa9, 0x3d, 0xce, 0x2f, 0x7d, 0x93, 0xad, 0xff,
+	0x2f, 0x7c, 0x1f, 0x67, 0xab, 0x91, 0x0d, 0x42, 0x0f, 0x60, 0x29, 0xa5, 0x6b, 0xca, 0x26, 0xd0,
+	0xc9, 0xed, 0xc4, 0xba, 0x3e, 0xd5, 0x27, 0xe1, 0x7a, 0x00, 0xe5, 0x8c, 0x7a, 0xe9, 0x8d, 0x31,
+	0xe5, 0x4f, 0x10, 0xb7, 0xf5, 0xdf, 0x45, 0x37, 0x41, 0x49, 0x8e, 0xcd, 0x6c, 0x10, 0xda, 0x82,
+	0xe5, 0xdc, 0xd2, 0xe9, 0xcd, 0xf1, 0x6e, 0x27, 0xe9, 0xc9, 0xba, 0x75, 0xa9, 0x5f, 0xcc, 0xff,
+	0xd1, 0xca, 0xa7, 0x33, 0x9b, 0x7c, 0x3e, 0xb3, 0xc9, 0xd7, 0x33, 0x9b, 0xbc, 0xff, 0x66, 0xcf,
+	0x1c, 0xce, 0xa9, 0xd8, 0xfb, 0x3f, 0x06, 0x00, 0x47, 0x2f, 0x67, 0x7c, 0x9a, 0x08, 0x00, 0x00,
 }
 
-func (m *ReplicaRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
-	}
-	if len(m.Record) > 0 {
-		i -= len(m.Record)
-		copy(dAtA[i:], m.Record)
-		i = encodeVarintReplica(dAtA, i, uint64(len(m.Record)))
-		i--
-		dAtA[i] = 0x2a
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// ReplicaServiceClient is the client API for ReplicaService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type ReplicaServiceClient interface {
+	Reset(ctx context.Context, in *ResetIndexRequest, opts ...grpc.CallOption) (*ResetIndexResponse, error)
+	GetReplicaAckIndex(ctx context.Context, in *GetReplicaAckIndexRequest, opts ...grpc.CallOption) (*GetReplicaAckIndexResponse, error)
+	Replica(ctx context.Context, opts ...grpc.CallOption) (ReplicaService_ReplicaClient, error)
+	GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*GetSnapshotResponse, error)
+	FetchSnapshot(ctx context.Context, in *FetchSnapshotRequest, opts ...grpc.CallOption) (ReplicaService_FetchSnapshotClient, error)
+	ReleaseSnapshot(ctx context.Context, in *ReleaseSnapshotRequest, opts ...grpc.CallOption) (*ReleaseSnapshotResponse, error)
+}
+
+type replicaServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewReplicaServiceClient(cc *grpc.ClientConn) ReplicaServiceClient {
+	return &replicaServiceClient{cc}
+}
+
+func (c *replicaServiceClient) Reset(ctx context.Context, in *ResetIndexRequest, opts ...grpc.CallOption) (*ResetIndexResponse, error) {
+	out := new(ResetIndexResponse)
+	err := c.cc.Invoke(ctx, "/protoReplicaV1.ReplicaService/Reset", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.ReplicaIndex != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.ReplicaIndex))
-		i--
-		dAtA[i] = 0x20
+	return out, nil
+}
+
+func (c *replicaServiceClient) GetReplicaAckIndex(ctx context.Context, in *GetReplicaAckIndexRequest, opts ...grpc.CallOption) (*GetReplicaAckIndexResponse, error) {
+	out := new(GetReplicaAckIndexResponse)
+	err := c.cc.Invoke(ctx, "/protoReplicaV1.ReplicaService/GetReplicaAckIndex", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	return len(dAtA) - i, nil
+	return out, nil
 }
 
-func (m *ReplicaResponse) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+func (c *replicaServiceClient) Replica(ctx context.Context, opts ...grpc.CallOption) (ReplicaService_ReplicaClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ReplicaService_serviceDesc.Streams[0], "/protoReplicaV1.ReplicaService/Replica", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &replicaServiceReplicaClient{stream}
+	return x, nil
 }
 
-func (m *ReplicaResponse) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+type ReplicaService_ReplicaClient interface {
+	Send(*ReplicaRequest) error
+	Recv() (*ReplicaResponse, error)
+	grpc.ClientStream
 }
 
-func (m *ReplicaResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
-	}
-	if len(m.Err) > 0 {
-		i -= len(m.Err)
-		copy(dAtA[i:], m.Err)
-		i = encodeVarintReplica(dAtA, i, uint64(len(m.Err)))
-		i--
-		dAtA[i] = 0x3a
-	}
-	if m.ResponseTime != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.ResponseTime))
-		i--
-		dAtA[i] = 0x30
-	}
-	if m.AckIndex != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.AckIndex))
-		i--
-		dAtA[i] = 0x28
-	}
-	if m.ReplicaIndex != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.ReplicaIndex))
-		i--
-		dAtA[i] = 0x20
-	}
-	if m.Leader != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.Leader))
-		i--
-		dAtA[i] = 0x18
-	}
-	if m.Shard != 0 {
-		i = encodeVarintReplica(dAtA, i, uint64(m.Shard))
-		i--
-		dAtA[i] = 0x10
-	}
-	if len(m.Database) > 0 {
-		i -= len(m.Database)
-		copy(dAtA[i:], m.Database)
-		i = encodeVarintReplica(dAtA, i, uint64(len(m.Database)))
-		i--
-		dAtA[i] = 0xa
-	}
-	return len(dAtA) - i, nil
+type replicaServiceReplicaClient struct {
+	grpc.ClientStream
 }
 
-func encodeVarintReplica(dAtA []byte, offset int, v uint64) int {
-	offset -= sovReplica(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
-	}
-	dAtA[offset] = uint8(v)
-	return base
+func (x *replicaServiceReplicaClient) Send(m *ReplicaRequest) error {
+	return x.ClientStream.SendMsg(m)
 }
-func (m *ResetIndexRequest) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	l = len(m.Database)
-	if l > 0 {
-		n += 1 + l + sovReplica(uint64(l))
-	}
-	if m.Shard != 0 {
-		n += 1 + sovReplica(uint64(m.Shard))
+
+func (x *replicaServiceReplicaClient) Recv() (*ReplicaResponse, error) {
+	m := new(ReplicaResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	if m.Leader != 0 {
-		n += 1 + sovReplica(uint64(m.Leader))
+	return m, nil
+}
+
+func (c *replicaServiceClient) GetSnapshot(ctx context.Context, in *GetSnapshotRequest, opts ...grpc.CallOption) (*GetSnapshotResponse, error) {
+	out := new(GetSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/protoReplicaV1.ReplicaService/GetSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.FamilyTime != 0 {
-		n += 1 + sovReplica(uint64(m.FamilyTime))
+	return out, nil
+}
+
+func (c *replicaServiceClient) FetchSnapshot(ctx context.Context, in *FetchSnapshotRequest, opts ...grpc.CallOption) (ReplicaService_FetchSnapshotClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_ReplicaService_serviceDesc.Streams[1], "/protoReplicaV1.ReplicaService/FetchSnapshot", opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.AppendIndex != 0 {
-		n += 1 + sovReplica(uint64(m.AppendIndex))
+	x := &replicaServiceFetchSnapshotClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	return n
+	return x, nil
 }
 
-func (m *ResetIndexResponse) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
-	}
-	return n
+type ReplicaService_FetchSnapshotClient interface {
+	Recv() (*SnapshotChunk, error)
+	grpc.ClientStream
 }
 
-func (m *GetReplicaAckIndexRequest) Size() (n int) {
-	if m == nil {
-		return 0
+type replicaServiceFetchSnapshotClient struct {
+	grpc.ClientStream
+}
+
+func (x *replicaServiceFetchSnapshotClient) Recv() (*SnapshotChunk, error) {
+	m := new(SnapshotChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	l = len(m.Database)
-	if l > 0 {
-		n += 1 + l + sovReplica(uint64(l))
+	return m, nil
+}
+
+func (c *replicaServiceClient) ReleaseSnapshot(ctx context.Context, in *ReleaseSnapshotRequest, opts ...grpc.CallOption) (*ReleaseSnapshotResponse, error) {
+	out := new(ReleaseSnapshotResponse)
+	err := c.cc.Invoke(ctx, "/protoReplicaV1.ReplicaService/ReleaseSnapshot", in, out, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if m.Shard != 0 {
-		n += 1 + sovReplica(uint64(m.Shard))
+	return out, nil
+}
+
+// ReplicaServiceServer is the server API for ReplicaService service.
+type ReplicaServiceServer interface {
+	Reset(context.Context, *ResetIndexRequest) (*ResetIndexResponse, error)
+	GetReplicaAckIndex(context.Context, *GetReplicaAckIndexRequest) (*GetReplicaAckIndexResponse, error)
+	Replica(ReplicaService_ReplicaServer) error
+	GetSnapshot(context.Context, *GetSnapshotRequest) (*GetSnapshotResponse, error)
+	FetchSnapshot(*FetchSnapshotRequest, ReplicaService_FetchSnapshotServer) error
+	ReleaseSnapshot(context.Context, *ReleaseSnapshotRequest) (*ReleaseSnapshotResponse, error)
+}
+
+// UnimplementedReplicaServiceServer can be embedded to have forward compatible implementations.
+type UnimplementedReplicaServiceServer struct {
+}
+
+func (*UnimplementedReplicaServiceServer) Reset(ctx context.Context, req *ResetIndexRequest) (*ResetIndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reset not implemented")
+}
+func (*UnimplementedReplicaServiceServer) GetReplicaAckIndex(ctx context.Context, req *GetReplicaAckIndexRequest) (*GetReplicaAckIndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReplicaAckIndex not implemented")
+}
+func (*UnimplementedReplicaServiceServer) Replica(srv ReplicaService_ReplicaServer) error {
+	return status.Errorf(codes.Unimplemented, "method Replica not implemented")
+}
+func (*UnimplementedReplicaServiceServer) GetSnapshot(ctx context.Context, req *GetSnapshotRequest) (*GetSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSnapshot not implemented")
+}
+func (*UnimplementedReplicaServiceServer) FetchSnapshot(req *FetchSnapshotRequest, srv ReplicaService_FetchSnapshotServer) error {
+	return status.Errorf(codes.Unimplemented, "method FetchSnapshot not implemented")
+}
+func (*UnimplementedReplicaServiceServer) ReleaseSnapshot(ctx context.Context, req *ReleaseSnapshotRequest) (*ReleaseSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReleaseSnapshot not implemented")
+}
+
+func RegisterReplicaServiceServer(s *grpc.Server, srv ReplicaServiceServer) {
+	s.RegisterService(&_ReplicaService_serviceDesc, srv)
+}
+
+func _ReplicaService_Reset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	if m.Leader != 0 {
-		n += 1 + sovReplica(uint64(m.Leader))
+	if interceptor == nil {
+		return srv.(ReplicaServiceServer).Reset(ctx, in)
 	}
-	if m.FamilyTime != 0 {
-		n += 1 + sovReplica(uint64(m.FamilyTime))
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protoReplicaV1.ReplicaService/Reset",
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServiceServer).Reset(ctx, req.(*ResetIndexRequest))
 	}
-	return n
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *GetReplicaAckIndexResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func _ReplicaService_GetReplicaAckIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReplicaAckIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.AckIndex != 0 {
-		n += 1 + sovReplica(uint64(m.AckIndex))
+	if interceptor == nil {
+		return srv.(ReplicaServiceServer).GetReplicaAckIndex(ctx, in)
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protoReplicaV1.ReplicaService/GetReplicaAckIndex",
 	}
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServiceServer).GetReplicaAckIndex(ctx, req.(*GetReplicaAckIndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ReplicaRequest) Size() (n int) {
-	if m == nil {
-		return 0
+func _ReplicaService_Replica_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(ReplicaServiceServer).Replica(&replicaServiceReplicaServer{stream})
+}
+
+type ReplicaService_ReplicaServer interface {
+	Send(*ReplicaResponse) error
+	Recv() (*ReplicaRequest, error)
+	grpc.ServerStream
+}
+
+type replicaServiceReplicaServer struct {
+	grpc.ServerStream
+}
+
+func (x *replicaServiceReplicaServer) Send(m *ReplicaResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *replicaServiceReplicaServer) Recv() (*ReplicaRequest, error) {
+	m := new(ReplicaRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	var l int
-	_ = l
-	if m.ReplicaIndex != 0 {
-		n += 1 + sovReplica(uint64(m.ReplicaIndex))
+	return m, nil
+}
+
+func _ReplicaService_GetSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
 	}
-	l = len(m.Record)
-	if l > 0 {
-		n += 1 + l + sovReplica(uint64(l))
+	if interceptor == nil {
+		return srv.(ReplicaServiceServer).GetSnapshot(ctx, in)
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protoReplicaV1.ReplicaService/GetSnapshot",
 	}
-	return n
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServiceServer).GetSnapshot(ctx, req.(*GetSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
 }
 
-func (m *ReplicaResponse) Size() (n int) {
-	if m == nil {
-		return 0
+func _ReplicaService_FetchSnapshot_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(FetchSnapshotRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReplicaServiceServer).FetchSnapshot(m, &replicaServiceFetchSnapshotServer{stream})
+}
+
+type ReplicaService_FetchSnapshotServer interface {
+	Send(*SnapshotChunk) error
+	grpc.ServerStream
+}
+
+type replicaServiceFetchSnapshotServer struct {
+	grpc.ServerStream
+}
+
+func (x *replicaServiceFetchSnapshotServer) Send(m *SnapshotChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _ReplicaService_ReleaseSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReleaseSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReplicaServiceServer).ReleaseSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protoReplicaV1.ReplicaService/ReleaseSnapshot",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReplicaServiceServer).ReleaseSnapshot(ctx, req.(*ReleaseSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _ReplicaService_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "protoReplicaV1.ReplicaService",
+	HandlerType: (*ReplicaServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Reset",
+			Handler:    _ReplicaService_Reset_Handler,
+		},
+		{
+			MethodName: "GetReplicaAckIndex",
+			Handler:    _ReplicaService_GetReplicaAckIndex_Handler,
+		},
+		{
+			MethodName: "GetSnapshot",
+			Handler:    _ReplicaService_GetSnapshot_Handler,
+		},
+		{
+			MethodName: "ReleaseSnapshot",
+			Handler:    _ReplicaService_ReleaseSnapshot_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Replica",
+			Handler:       _ReplicaService_Replica_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "FetchSnapshot",
+			Handler:       _ReplicaService_FetchSnapshot_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "replica.proto",
+}
+
+func (m *ResetIndexRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ResetIndexRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ResetIndexRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
 	var l int
 	_ = l
-	l = len(m.Database)
-	if l > 0 {
-		n += 1 + l + sovReplica(uint64(l))
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	if m.Shard != 0 {
-		n += 1 + sovReplica(uint64(m.Shard))
+	if m.AppendIndex != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.AppendIndex))
+		i--
+		dAtA[i] = 0x28
 	}
-	if m.Leader != 0 {
-		n += 1 + sovReplica(uint64(m.Leader))
+	if m.FamilyTime != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.FamilyTime))
+		i--
+		dAtA[i] = 0x20
 	}
-	if m.ReplicaIndex != 0 {
-		n += 1 + sovReplica(uint64(m.ReplicaIndex))
+	if m.Leader != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Leader))
+		i--
+		dAtA[i] = 0x18
 	}
-	if m.AckIndex != 0 {
-		n += 1 + sovReplica(uint64(m.AckIndex))
+	if m.Shard != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Shard))
+		i--
+		dAtA[i] = 0x10
 	}
-	if m.ResponseTime != 0 {
-		n += 1 + sovReplica(uint64(m.ResponseTime))
+	if len(m.Database) > 0 {
+		i -= len(m.Database)
+		copy(dAtA[i:], m.Database)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.Database)))
+		i--
+		dAtA[i] = 0xa
 	}
-	l = len(m.Err)
-	if l > 0 {
-		n += 1 + l + sovReplica(uint64(l))
+	return len(dAtA) - i, nil
+}
+
+func (m *ResetIndexResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
 	}
+	return dAtA[:n], nil
+}
+
+func (m *ResetIndexResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ResetIndexResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
 	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
 	}
-	return n
+	return len(dAtA) - i, nil
 }
 
-func sovReplica(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
+func (m *GetReplicaAckIndexRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
 }
-func sozReplica(x uint64) (n int) {
-	return sovReplica(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+
+func (m *GetReplicaAckIndexRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetReplicaAckIndexRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.FamilyTime != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.FamilyTime))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Leader != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Leader))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Shard != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Shard))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Database) > 0 {
+		i -= len(m.Database)
+		copy(dAtA[i:], m.Database)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.Database)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetReplicaAckIndexResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetReplicaAckIndexResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetReplicaAckIndexResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.AckIndex != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.AckIndex))
+		i--
+		dAtA[i] = 0x28
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicaRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicaRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicaRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Record) > 0 {
+		i -= len(m.Record)
+		copy(dAtA[i:], m.Record)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.Record)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.ReplicaIndex != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.ReplicaIndex))
+		i--
+		dAtA[i] = 0x20
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReplicaResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReplicaResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReplicaResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Err) > 0 {
+		i -= len(m.Err)
+		copy(dAtA[i:], m.Err)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.Err)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if m.ResponseTime != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.ResponseTime))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.AckIndex != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.AckIndex))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.ReplicaIndex != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.ReplicaIndex))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.Leader != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Leader))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.Shard != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Shard))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Database) > 0 {
+		i -= len(m.Database)
+		copy(dAtA[i:], m.Database)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.Database)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SnapshotFile) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SnapshotFile) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SnapshotFile) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Checksum != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Checksum))
+		i--
+		dAtA[i] = 0x28
+	}
+	if m.FileSize != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.FileSize))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.MaxKey != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.MaxKey))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.MinKey != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.MinKey))
+		i--
+		dAtA[i] = 0x10
+	}
+	if m.FileNumber != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.FileNumber))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FamilySnapshot) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FamilySnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FamilySnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Files) > 0 {
+		for iNdEx := len(m.Files) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Files[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintReplica(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x1a
+		}
+	}
+	if len(m.Sequences) > 0 {
+		for k := range m.Sequences {
+			v := m.Sequences[k]
+			baseI := i
+			i = encodeVarintReplica(dAtA, i, uint64(v))
+			i--
+			dAtA[i] = 0x10
+			i = encodeVarintReplica(dAtA, i, uint64(k))
+			i--
+			dAtA[i] = 0x8
+			i = encodeVarintReplica(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if m.FamilyTime != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.FamilyTime))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetSnapshotRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetSnapshotRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetSnapshotRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Shard != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Shard))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Database) > 0 {
+		i -= len(m.Database)
+		copy(dAtA[i:], m.Database)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.Database)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetSnapshotResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetSnapshotResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetSnapshotResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Families) > 0 {
+		for iNdEx := len(m.Families) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Families[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintReplica(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x12
+		}
+	}
+	if len(m.SnapshotID) > 0 {
+		i -= len(m.SnapshotID)
+		copy(dAtA[i:], m.SnapshotID)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.SnapshotID)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *FetchSnapshotRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FetchSnapshotRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *FetchSnapshotRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Offset != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x20
+	}
+	if m.FileNumber != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.FileNumber))
+		i--
+		dAtA[i] = 0x18
+	}
+	if m.FamilyTime != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.FamilyTime))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.SnapshotID) > 0 {
+		i -= len(m.SnapshotID)
+		copy(dAtA[i:], m.SnapshotID)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.SnapshotID)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *SnapshotChunk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SnapshotChunk) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SnapshotChunk) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Checksum != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Checksum))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Data) > 0 {
+		i -= len(m.Data)
+		copy(dAtA[i:], m.Data)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.Data)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Offset != 0 {
+		i = encodeVarintReplica(dAtA, i, uint64(m.Offset))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReleaseSnapshotRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReleaseSnapshotRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReleaseSnapshotRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.SnapshotID) > 0 {
+		i -= len(m.SnapshotID)
+		copy(dAtA[i:], m.SnapshotID)
+		i = encodeVarintReplica(dAtA, i, uint64(len(m.SnapshotID)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ReleaseSnapshotResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReleaseSnapshotResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ReleaseSnapshotResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintReplica(dAtA []byte, offset int, v uint64) int {
+	offset -= sovReplica(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *ResetIndexRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Database)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.Shard != 0 {
+		n += 1 + sovReplica(uint64(m.Shard))
+	}
+	if m.Leader != 0 {
+		n += 1 + sovReplica(uint64(m.Leader))
+	}
+	if m.FamilyTime != 0 {
+		n += 1 + sovReplica(uint64(m.FamilyTime))
+	}
+	if m.AppendIndex != 0 {
+		n += 1 + sovReplica(uint64(m.AppendIndex))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ResetIndexResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *GetReplicaAckIndexRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Database)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.Shard != 0 {
+		n += 1 + sovReplica(uint64(m.Shard))
+	}
+	if m.Leader != 0 {
+		n += 1 + sovReplica(uint64(m.Leader))
+	}
+	if m.FamilyTime != 0 {
+		n += 1 + sovReplica(uint64(m.FamilyTime))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *GetReplicaAckIndexResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.AckIndex != 0 {
+		n += 1 + sovReplica(uint64(m.AckIndex))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ReplicaRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ReplicaIndex != 0 {
+		n += 1 + sovReplica(uint64(m.ReplicaIndex))
+	}
+	l = len(m.Record)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ReplicaResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Database)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.Shard != 0 {
+		n += 1 + sovReplica(uint64(m.Shard))
+	}
+	if m.Leader != 0 {
+		n += 1 + sovReplica(uint64(m.Leader))
+	}
+	if m.ReplicaIndex != 0 {
+		n += 1 + sovReplica(uint64(m.ReplicaIndex))
+	}
+	if m.AckIndex != 0 {
+		n += 1 + sovReplica(uint64(m.AckIndex))
+	}
+	if m.ResponseTime != 0 {
+		n += 1 + sovReplica(uint64(m.ResponseTime))
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SnapshotFile) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FileNumber != 0 {
+		n += 1 + sovReplica(uint64(m.FileNumber))
+	}
+	if m.MinKey != 0 {
+		n += 1 + sovReplica(uint64(m.MinKey))
+	}
+	if m.MaxKey != 0 {
+		n += 1 + sovReplica(uint64(m.MaxKey))
+	}
+	if m.FileSize != 0 {
+		n += 1 + sovReplica(uint64(m.FileSize))
+	}
+	if m.Checksum != 0 {
+		n += 1 + sovReplica(uint64(m.Checksum))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *FamilySnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.FamilyTime != 0 {
+		n += 1 + sovReplica(uint64(m.FamilyTime))
+	}
+	if len(m.Sequences) > 0 {
+		for k, v := range m.Sequences {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + sovReplica(uint64(k)) + 1 + sovReplica(uint64(v))
+			n += mapEntrySize + 1 + sovReplica(uint64(mapEntrySize))
+		}
+	}
+	if len(m.Files) > 0 {
+		for _, e := range m.Files {
+			l = e.Size()
+			n += 1 + l + sovReplica(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *GetSnapshotRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Database)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.Shard != 0 {
+		n += 1 + sovReplica(uint64(m.Shard))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *GetSnapshotResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.SnapshotID)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if len(m.Families) > 0 {
+		for _, e := range m.Families {
+			l = e.Size()
+			n += 1 + l + sovReplica(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *FetchSnapshotRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.SnapshotID)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.FamilyTime != 0 {
+		n += 1 + sovReplica(uint64(m.FamilyTime))
+	}
+	if m.FileNumber != 0 {
+		n += 1 + sovReplica(uint64(m.FileNumber))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovReplica(uint64(m.Offset))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *SnapshotChunk) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Offset != 0 {
+		n += 1 + sovReplica(uint64(m.Offset))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.Checksum != 0 {
+		n += 1 + sovReplica(uint64(m.Checksum))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ReleaseSnapshotRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.SnapshotID)
+	if l > 0 {
+		n += 1 + l + sovReplica(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ReleaseSnapshotResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovReplica(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozReplica(x uint64) (n int) {
+	return sovReplica(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *ResetIndexRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ResetIndexRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ResetIndexRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Database", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Database = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
+			}
+			m.Shard = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Shard |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Leader", wireType)
+			}
+			m.Leader = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Leader |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FamilyTime", wireType)
+			}
+			m.FamilyTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FamilyTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AppendIndex", wireType)
+			}
+			m.AppendIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AppendIndex |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ResetIndexResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ResetIndexResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ResetIndexResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetReplicaAckIndexRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetReplicaAckIndexRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetReplicaAckIndexRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Database", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Database = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
+			}
+			m.Shard = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Shard |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Leader", wireType)
+			}
+			m.Leader = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Leader |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FamilyTime", wireType)
+			}
+			m.FamilyTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FamilyTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetReplicaAckIndexResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetReplicaAckIndexResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetReplicaAckIndexResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AckIndex", wireType)
+			}
+			m.AckIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AckIndex |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReplicaRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReplicaRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReplicaRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaIndex", wireType)
+			}
+			m.ReplicaIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ReplicaIndex |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Record = append(m.Record[:0], dAtA[iNdEx:postIndex]...)
+			if m.Record == nil {
+				m.Record = []byte{}
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *ResetIndexRequest) Unmarshal(dAtA []byte) error {
+func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1094,10 +2788,10 @@ func (m *ResetIndexRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ResetIndexRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReplicaResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ResetIndexRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReplicaResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -1172,9 +2866,9 @@ func (m *ResetIndexRequest) Unmarshal(dAtA []byte) error {
 			}
 		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FamilyTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaIndex", wireType)
 			}
-			m.FamilyTime = 0
+			m.ReplicaIndex = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1184,16 +2878,16 @@ func (m *ResetIndexRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.FamilyTime |= int64(b&0x7F) << shift
+				m.ReplicaIndex |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AppendIndex", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field AckIndex", wireType)
 			}
-			m.AppendIndex = 0
+			m.AckIndex = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1203,11 +2897,62 @@ func (m *ResetIndexRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.AppendIndex |= int64(b&0x7F) << shift
+				m.AckIndex |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResponseTime", wireType)
+			}
+			m.ResponseTime = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResponseTime |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Err = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipReplica(dAtA[iNdEx:])
@@ -1230,7 +2975,7 @@ func (m *ResetIndexRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ResetIndexResponse) Unmarshal(dAtA []byte) error {
+func (m *SnapshotFile) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1253,12 +2998,107 @@ func (m *ResetIndexResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ResetIndexResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: SnapshotFile: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ResetIndexResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SnapshotFile: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FileNumber", wireType)
+			}
+			m.FileNumber = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FileNumber |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinKey", wireType)
+			}
+			m.MinKey = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MinKey |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxKey", wireType)
+			}
+			m.MaxKey = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxKey |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FileSize", wireType)
+			}
+			m.FileSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FileSize |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Checksum", wireType)
+			}
+			m.Checksum = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Checksum |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipReplica(dAtA[iNdEx:])
@@ -1281,7 +3121,7 @@ func (m *ResetIndexResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetReplicaAckIndexRequest) Unmarshal(dAtA []byte) error {
+func (m *FamilySnapshot) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1304,17 +3144,17 @@ func (m *GetReplicaAckIndexRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetReplicaAckIndexRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: FamilySnapshot: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetReplicaAckIndexRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FamilySnapshot: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Database", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FamilyTime", wireType)
 			}
-			var stringLen uint64
+			m.FamilyTime = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1324,29 +3164,16 @@ func (m *GetReplicaAckIndexRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				stringLen |= uint64(b&0x7F) << shift
+				m.FamilyTime |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			intStringLen := int(stringLen)
-			if intStringLen < 0 {
-				return ErrInvalidLengthReplica
-			}
-			postIndex := iNdEx + intStringLen
-			if postIndex < 0 {
-				return ErrInvalidLengthReplica
-			}
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.Database = string(dAtA[iNdEx:postIndex])
-			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sequences", wireType)
 			}
-			m.Shard = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1356,35 +3183,96 @@ func (m *GetReplicaAckIndexRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Shard |= int32(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Leader", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthReplica
 			}
-			m.Leader = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowReplica
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Sequences == nil {
+				m.Sequences = make(map[int32]int64)
+			}
+			var mapkey int32
+			var mapvalue int64
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowReplica
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
 				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Leader |= int32(b&0x7F) << shift
-				if b < 0x80 {
-					break
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowReplica
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapkey |= int32(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else if fieldNum == 2 {
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowReplica
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						mapvalue |= int64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipReplica(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthReplica
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
 				}
 			}
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FamilyTime", wireType)
+			m.Sequences[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Files", wireType)
 			}
-			m.FamilyTime = 0
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1394,11 +3282,26 @@ func (m *GetReplicaAckIndexRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.FamilyTime |= int64(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Files = append(m.Files, &SnapshotFile{})
+			if err := m.Files[len(m.Files)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipReplica(dAtA[iNdEx:])
@@ -1421,7 +3324,7 @@ func (m *GetReplicaAckIndexRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *GetReplicaAckIndexResponse) Unmarshal(dAtA []byte) error {
+func (m *GetSnapshotRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1444,17 +3347,49 @@ func (m *GetReplicaAckIndexResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: GetReplicaAckIndexResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetSnapshotRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: GetReplicaAckIndexResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetSnapshotRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 5:
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Database", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Database = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AckIndex", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
 			}
-			m.AckIndex = 0
+			m.Shard = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1464,7 +3399,7 @@ func (m *GetReplicaAckIndexResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.AckIndex |= int64(b&0x7F) << shift
+				m.Shard |= int32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
@@ -1491,7 +3426,7 @@ func (m *GetReplicaAckIndexResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ReplicaRequest) Unmarshal(dAtA []byte) error {
+func (m *GetSnapshotResponse) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1514,17 +3449,17 @@ func (m *ReplicaRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReplicaRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: GetSnapshotResponse: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReplicaRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: GetSnapshotResponse: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaIndex", wireType)
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotID", wireType)
 			}
-			m.ReplicaIndex = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1534,16 +3469,29 @@ func (m *ReplicaRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReplicaIndex |= int64(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SnapshotID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Record", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Families", wireType)
 			}
-			var byteLen int
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1553,24 +3501,24 @@ func (m *ReplicaRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= int(b&0x7F) << shift
+				msglen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			if msglen < 0 {
 				return ErrInvalidLengthReplica
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + msglen
 			if postIndex < 0 {
 				return ErrInvalidLengthReplica
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Record = append(m.Record[:0], dAtA[iNdEx:postIndex]...)
-			if m.Record == nil {
-				m.Record = []byte{}
+			m.Families = append(m.Families, &FamilySnapshot{})
+			if err := m.Families[len(m.Families)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
 			}
 			iNdEx = postIndex
 		default:
@@ -1595,7 +3543,7 @@ func (m *ReplicaRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
+func (m *FetchSnapshotRequest) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1618,15 +3566,15 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: ReplicaResponse: wiretype end group for non-group")
+			return fmt.Errorf("proto: FetchSnapshotRequest: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ReplicaResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: FetchSnapshotRequest: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Database", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotID", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1654,13 +3602,13 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Database = string(dAtA[iNdEx:postIndex])
+			m.SnapshotID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Shard", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FamilyTime", wireType)
 			}
-			m.Shard = 0
+			m.FamilyTime = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1670,16 +3618,16 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Shard |= int32(b&0x7F) << shift
+				m.FamilyTime |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Leader", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FileNumber", wireType)
 			}
-			m.Leader = 0
+			m.FileNumber = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1689,16 +3637,16 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Leader |= int32(b&0x7F) << shift
+				m.FileNumber |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 4:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ReplicaIndex", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			m.ReplicaIndex = 0
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1708,16 +3656,67 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ReplicaIndex |= int64(b&0x7F) << shift
+				m.Offset |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SnapshotChunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SnapshotChunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SnapshotChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field AckIndex", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
 			}
-			m.AckIndex = 0
+			m.Offset = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1727,16 +3726,50 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.AckIndex |= int64(b&0x7F) << shift
+				m.Offset |= int64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 6:
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowReplica
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthReplica
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ResponseTime", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Checksum", wireType)
 			}
-			m.ResponseTime = 0
+			m.Checksum = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowReplica
@@ -1746,14 +3779,65 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.ResponseTime |= int64(b&0x7F) << shift
+				m.Checksum |= uint32(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 7:
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ReleaseSnapshotRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReleaseSnapshotRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReleaseSnapshotRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotID", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1781,7 +3865,7 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Err = string(dAtA[iNdEx:postIndex])
+			m.SnapshotID = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
@@ -1805,6 +3889,57 @@ func (m *ReplicaResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *ReleaseSnapshotResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowReplica
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ReleaseSnapshotResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ReleaseSnapshotResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipReplica(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthReplica
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipReplica(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,182 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+// for testing
+var (
+	notifyRetryBackoff = time.Second
+)
+
+const (
+	// notifyRetries is the max retries of posting alerts to webhook.
+	notifyRetries = 3
+	// notifyTimeout is the timeout of posting alerts to webhook.
+	notifyTimeout = 10 * time.Second
+	// notifyResendDelay is the min delay of re-sending same firing alert, resolved alert is sent only once.
+	notifyResendDelay = time.Minute
+)
+
+// AlertNotifier represents the notifier which posts alerts to webhook.
+type AlertNotifier interface {
+	// Notify posts alerts to webhook with retry, alerts already notified are skipped.
+	Notify(ctx context.Context, webhook string, alerts []*models.Alert) error
+}
+
+// notifiedAlert represents the alert notified recently.
+type notifiedAlert struct {
+	resolved bool
+	startsAt time.Time
+	sentAt   time.Time
+}
+
+// webhookNotifier implements AlertNotifier interface, posts Alertmanager compatible json to webhook.
+type webhookNotifier struct {
+	client   *http.Client
+	notified map[string]*notifiedAlert // webhook/alert fingerprint => last notified alert
+	lock     sync.Mutex
+
+	statistics *metrics.AlertNotifyStatistics
+	logger     *logger.Logger
+}
+
+// NewAlertNotifier creates an AlertNotifier instance.
+func NewAlertNotifier(registry *linmetric.Registry) AlertNotifier {
+	return &webhookNotifier{
+		client:     &http.Client{Timeout: notifyTimeout},
+		notified:   make(map[string]*notifiedAlert),
+		statistics: metrics.NewAlertNotifyStatistics(registry),
+		logger:     logger.GetLogger("Query", "AlertNotifier"),
+	}
+}
+
+// Notify posts alerts to webhook with retry, alerts already notified are skipped.
+func (n *webhookNotifier) Notify(ctx context.Context, webhook string, alerts []*models.Alert) error {
+	now := time.Now()
+	alerts = n.deduplicate(webhook, alerts, now)
+	if len(alerts) == 0 {
+		return nil
+	}
+	body := encoding.JSONMarshal(alerts)
+	var err error
+	for i := 0; i < notifyRetries; i++ {
+		if i > 0 {
+			n.statistics.Retries.Incr()
+			select {
+			case <-time.After(notifyRetryBackoff * time.Duration(i)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = n.post(ctx, webhook, body); err == nil {
+			n.statistics.Notifications.Add(float64(len(alerts)))
+			n.markNotified(webhook, alerts, now)
+			return nil
+		}
+		n.logger.Warn("post alerts to webhook failure",
+			logger.String("webhook", webhook), logger.Int("retry", i), logger.Error(err))
+	}
+	n.statistics.Failures.Add(float64(len(alerts)))
+	return err
+}
+
+// post posts alerts to webhook, returns error if response status is not 2xx.
+func (n *webhookNotifier) post(ctx context.Context, webhook string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// deduplicate removes the alerts already notified, firing alert is re-sent after resend delay.
+func (n *webhookNotifier) deduplicate(webhook string, alerts []*models.Alert, now time.Time) []*models.Alert {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	result := alerts[:0:0]
+	for _, alert := range alerts {
+		resolved := !alert.EndsAt.IsZero() && !alert.EndsAt.After(now)
+		notified, ok := n.notified[webhook+"/"+alertFingerprint(alert.Labels)]
+		if ok && notified.resolved == resolved && notified.startsAt.Equal(alert.StartsAt) &&
+			(resolved || now.Sub(notified.sentAt) < notifyResendDelay) {
+			n.statistics.Deduplicated.Incr()
+			continue
+		}
+		result = append(result, alert)
+	}
+	return result
+}
+
+// markNotified records the alerts notified, expired records(exceed resend delay) are removed.
+func (n *webhookNotifier) markNotified(webhook string, alerts []*models.Alert, now time.Time) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+
+	for key, notified := range n.notified {
+		if now.Sub(notified.sentAt) >= notifyResendDelay {
+			delete(n.notified, key)
+		}
+	}
+	for _, alert := range alerts {
+		n.notified[webhook+"/"+alertFingerprint(alert.Labels)] = &notifiedAlert{
+			resolved: !alert.EndsAt.IsZero() && !alert.EndsAt.After(now),
+			startsAt: alert.StartsAt,
+			sentAt:   now,
+		}
+	}
+}
+
+// alertFingerprint returns the fingerprint of labels, which is sorted key=value pairs.
+func alertFingerprint(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
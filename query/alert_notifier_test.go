@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	defer func() {
+		notifyRetryBackoff = time.Second
+	}()
+	notifyRetryBackoff = time.Millisecond
+	var (
+		requests atomic.Int32
+		failures atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Inc()
+		if failures.Load() > 0 {
+			failures.Dec()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	firing := &models.Alert{Labels: map[string]string{"alertname": "a"}, StartsAt: now, EndsAt: now.Add(time.Hour)}
+	resolved := &models.Alert{Labels: map[string]string{"alertname": "b"}, StartsAt: now, EndsAt: now}
+	notifier := NewAlertNotifier(linmetric.BrokerRegistry)
+
+	// retry then success
+	failures.Store(1)
+	assert.NoError(t, notifier.Notify(context.TODO(), server.URL, []*models.Alert{firing, resolved}))
+	assert.Equal(t, int32(2), requests.Load())
+	// deduplicate alerts already notified
+	assert.NoError(t, notifier.Notify(context.TODO(), server.URL, []*models.Alert{firing, resolved}))
+	assert.Equal(t, int32(2), requests.Load())
+	// new alert
+	firing2 := &models.Alert{Labels: map[string]string{"alertname": "c"}, StartsAt: now, EndsAt: now.Add(time.Hour)}
+	assert.NoError(t, notifier.Notify(context.TODO(), server.URL, []*models.Alert{firing, firing2}))
+	assert.Equal(t, int32(3), requests.Load())
+	// failure after all retries
+	failures.Store(notifyRetries)
+	firing3 := &models.Alert{Labels: map[string]string{"alertname": "d"}, StartsAt: now, EndsAt: now.Add(time.Hour)}
+	assert.Error(t, notifier.Notify(context.TODO(), server.URL, []*models.Alert{firing3}))
+	assert.Equal(t, int32(3+notifyRetries), requests.Load())
+	// bad webhook
+	assert.Error(t, notifier.Notify(context.TODO(), "http://\x7f", []*models.Alert{firing3}))
+	// context cancelled while waiting retry
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	assert.Error(t, notifier.Notify(ctx, server.URL, []*models.Alert{firing3}))
+}
+
+func TestWebhookNotifier_ResendFiring(t *testing.T) {
+	now := time.Now()
+	notifier := NewAlertNotifier(linmetric.BrokerRegistry).(*webhookNotifier)
+	firing := &models.Alert{Labels: map[string]string{"alertname": "a"}, StartsAt: now, EndsAt: now.Add(time.Hour)}
+	notifier.markNotified("w", []*models.Alert{firing}, now)
+	assert.Empty(t, notifier.deduplicate("w", []*models.Alert{firing}, now.Add(time.Second)))
+	assert.Len(t, notifier.deduplicate("w", []*models.Alert{firing}, now.Add(notifyResendDelay)), 1)
+	// expired record removed
+	notifier.markNotified("w", nil, now.Add(notifyResendDelay))
+	assert.Empty(t, notifier.notified)
+}
+
+func TestAlertFingerprint(t *testing.T) {
+	assert.Equal(t, "a=1,b=2", alertFingerprint(map[string]string{"b": "2", "a": "1"}))
+	assert.Equal(t, "", alertFingerprint(nil))
+}
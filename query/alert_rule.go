@@ -0,0 +1,497 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+)
+
+const (
+	// maxDryRunWindows is the max windows evaluated for dry-run alert rule.
+	maxDryRunWindows = 1000
+	// firingAlertTTLFactor is the factor of evaluation interval for the end time of firing alert,
+	// the alert is resolved by webhook(Alertmanager) automatically if not re-sent before end time.
+	firingAlertTTLFactor = 4
+)
+
+// AlertRuleEvaluator represents the evaluator of alert rules,
+// which evaluates rules periodically only when current node is master.
+type AlertRuleEvaluator interface {
+	// Start starts evaluating alert rules in background.
+	Start()
+	// Stop stops evaluating alert rules.
+	Stop()
+}
+
+// alertEvaluation represents the evaluation context of alert rule.
+type alertEvaluation struct {
+	running    bool                   // prevents overlapping evaluation of same rule
+	state      *models.AlertRuleState // nil if not loaded from repo
+	statistics *metrics.AlertRuleStatistics
+}
+
+// alertRuleEvaluator implements AlertRuleEvaluator interface.
+type alertRuleEvaluator struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	repo     state.Repository
+	isMaster func() bool
+	mgr      *SearchMgr
+	notifier AlertNotifier
+
+	rules map[string]*alertEvaluation // database/rule => evaluation
+	wait  sync.WaitGroup
+	lock  sync.Mutex
+
+	logger *logger.Logger
+}
+
+// NewAlertRuleEvaluator creates an AlertRuleEvaluator instance.
+func NewAlertRuleEvaluator(
+	ctx context.Context,
+	repo state.Repository,
+	isMaster func() bool,
+	mgr *SearchMgr,
+	notifier AlertNotifier,
+) AlertRuleEvaluator {
+	c, cancel := context.WithCancel(ctx)
+	return &alertRuleEvaluator{
+		ctx:      c,
+		cancel:   cancel,
+		repo:     repo,
+		isMaster: isMaster,
+		mgr:      mgr,
+		notifier: notifier,
+		rules:    make(map[string]*alertEvaluation),
+		logger:   logger.GetLogger("Query", "AlertRule"),
+	}
+}
+
+// Start starts evaluating alert rules in background.
+func (e *alertRuleEvaluator) Start() {
+	go func() {
+		ticker := time.NewTicker(ruleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.evaluate()
+			case <-e.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops evaluating alert rules, waits running evaluations completed.
+func (e *alertRuleEvaluator) Stop() {
+	e.cancel()
+	e.wait.Wait()
+}
+
+// evaluate schedules the evaluation of all rules which are due.
+func (e *alertRuleEvaluator) evaluate() {
+	if !e.isMaster() {
+		// alert state may be changed by other master, reload it after becoming master again
+		e.reset()
+		return
+	}
+	kvs, err := e.repo.List(e.ctx, constants.AlertRulePath)
+	if err != nil {
+		e.logger.Error("list alert rules failure", logger.Error(err))
+		return
+	}
+	now := ruleNowFn()
+	for _, kv := range kvs {
+		rules := &models.AlertRules{}
+		if err := encoding.JSONUnmarshal(kv.Value, rules); err != nil {
+			e.logger.Warn("unmarshal alert rules failure, ignore it",
+				logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		for _, rule := range rules.Rules {
+			interval := rule.GetInterval().Milliseconds()
+			if interval <= 0 {
+				continue
+			}
+			e.schedule(rules.Database, rule, now-now%interval)
+		}
+	}
+}
+
+// schedule evaluates the latest window of rule in background,
+// skips it if previous evaluation of the rule still running.
+func (e *alertRuleEvaluator) schedule(database string, rule *models.AlertRule, end int64) {
+	key := database + "/" + rule.Name
+	e.lock.Lock()
+	evaluation, ok := e.rules[key]
+	if !ok {
+		evaluation = &alertEvaluation{statistics: metrics.NewAlertRuleStatistics(database, rule.Name)}
+		e.rules[key] = evaluation
+	}
+	if evaluation.state != nil && evaluation.state.LastEvaluated >= end {
+		// not due
+		e.lock.Unlock()
+		return
+	}
+	if evaluation.running {
+		evaluation.statistics.OverlapSkips.Incr()
+		e.lock.Unlock()
+		return
+	}
+	evaluation.running = true
+	ruleState := evaluation.state
+	e.lock.Unlock()
+
+	e.wait.Add(1)
+	go func() {
+		defer func() {
+			e.lock.Lock()
+			evaluation.running = false
+			e.lock.Unlock()
+			e.wait.Done()
+		}()
+		e.evaluateRule(database, rule, evaluation, ruleState, end)
+	}()
+}
+
+// evaluateRule evaluates the window of rule before end time, persists the alert state,
+// then notifies firing/resolved alerts to webhook.
+func (e *alertRuleEvaluator) evaluateRule(database string, rule *models.AlertRule,
+	evaluation *alertEvaluation, ruleState *models.AlertRuleState, end int64,
+) {
+	statistics := evaluation.statistics
+	statePath := constants.GetAlertRuleStatePath(database, rule.Name)
+	if ruleState == nil {
+		loaded, err := e.loadState(statePath)
+		if err != nil {
+			statistics.EvaluateFailures.Incr()
+			e.logger.Error("load alert rule state failure",
+				logger.String("db", database), logger.String("rule", rule.Name), logger.Error(err))
+			return
+		}
+		ruleState = loaded
+	}
+	if ruleState.LastEvaluated >= end {
+		// already evaluated by other master
+		e.setState(evaluation, ruleState)
+		return
+	}
+	if !e.isMaster() {
+		return
+	}
+	start := end - rule.GetInterval().Milliseconds()
+	resultSet, err := searchRuleWindow(e.ctx, e.mgr, database, rule.SQL, start, end)
+	if err != nil {
+		statistics.EvaluateFailures.Incr()
+		e.logger.Error("evaluate alert rule failure",
+			logger.String("db", database), logger.String("rule", rule.Name), logger.Error(err))
+		return
+	}
+	newState := copyAlertRuleState(ruleState)
+	transitions, err := transitAlertState(rule, newState, resultSet, end)
+	if err != nil {
+		statistics.EvaluateFailures.Incr()
+		e.logger.Error("evaluate alert rule failure",
+			logger.String("db", database), logger.String("rule", rule.Name), logger.Error(err))
+		return
+	}
+	newState.LastEvaluated = end
+	if err := e.repo.Put(e.ctx, statePath, encoding.JSONMarshal(newState)); err != nil {
+		statistics.EvaluateFailures.Incr()
+		e.logger.Error("save alert rule state failure",
+			logger.String("db", database), logger.String("rule", rule.Name), logger.Error(err))
+		return
+	}
+	e.setState(evaluation, newState)
+	statistics.Evaluations.Incr()
+	pending, firing := 0, 0
+	for _, series := range newState.Series {
+		if series.State == models.AlertFiring {
+			firing++
+		} else {
+			pending++
+		}
+	}
+	statistics.Pending.Update(float64(pending))
+	statistics.Firing.Update(float64(firing))
+	for _, transition := range transitions {
+		statistics.Transitions.WithTagValues(string(transition.To)).Incr()
+		e.logger.Info("alert state changed",
+			logger.String("db", database), logger.String("rule", rule.Name),
+			logger.Any("tags", transition.Tags), logger.String("from", string(transition.From)),
+			logger.String("to", string(transition.To)), logger.Any("value", transition.Value))
+	}
+
+	alerts := buildAlerts(database, rule, newState, transitions, end)
+	if len(alerts) == 0 {
+		return
+	}
+	if err := e.notifier.Notify(e.ctx, rule.Webhook, alerts); err != nil {
+		e.logger.Error("notify alerts failure",
+			logger.String("db", database), logger.String("rule", rule.Name),
+			logger.String("webhook", rule.Webhook), logger.Error(err))
+	}
+}
+
+// loadState loads the alert state of rule, returns empty state if not exist.
+func (e *alertRuleEvaluator) loadState(statePath string) (*models.AlertRuleState, error) {
+	ruleState := &models.AlertRuleState{}
+	data, err := e.repo.Get(e.ctx, statePath)
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return ruleState, nil
+		}
+		return nil, err
+	}
+	if err := encoding.JSONUnmarshal(data, ruleState); err != nil {
+		return nil, err
+	}
+	return ruleState, nil
+}
+
+// setState caches the alert state of rule.
+func (e *alertRuleEvaluator) setState(evaluation *alertEvaluation, ruleState *models.AlertRuleState) {
+	e.lock.Lock()
+	evaluation.state = ruleState
+	e.lock.Unlock()
+}
+
+// reset cleans the alert state cached in memory.
+func (e *alertRuleEvaluator) reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, evaluation := range e.rules {
+		evaluation.state = nil
+	}
+}
+
+// DryRunAlertRule evaluates alert rule against historical data in time range[start, end),
+// returns the state transitions without persisting state and notifying.
+func DryRunAlertRule(ctx context.Context, mgr *SearchMgr, database string,
+	rule *models.AlertRule, start, end int64,
+) ([]*models.AlertTransition, error) {
+	interval := rule.GetInterval().Milliseconds()
+	if interval <= 0 {
+		return nil, fmt.Errorf("interval of alert rule[%s] is invalid: %s", rule.Name, rule.Interval)
+	}
+	start -= start % interval
+	if end <= start {
+		return nil, fmt.Errorf("end time must be after start time")
+	}
+	if windows := (end - start) / interval; windows > maxDryRunWindows {
+		return nil, fmt.Errorf("too many windows to dry-run alert rule: %d, max: %d", windows, maxDryRunWindows)
+	}
+	ruleState := &models.AlertRuleState{}
+	var result []*models.AlertTransition
+	for windowStart := start; windowStart+interval <= end; windowStart += interval {
+		windowEnd := windowStart + interval
+		resultSet, err := searchRuleWindow(ctx, mgr, database, rule.SQL, windowStart, windowEnd)
+		if err != nil {
+			return nil, err
+		}
+		transitions, err := transitAlertState(rule, ruleState, resultSet, windowEnd)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, transitions...)
+	}
+	return result, nil
+}
+
+// transitAlertState applies the result of one window on alert state, returns the state transitions.
+func transitAlertState(rule *models.AlertRule, ruleState *models.AlertRuleState,
+	rs *models.ResultSet, timestamp int64,
+) (transitions []*models.AlertTransition, err error) {
+	if ruleState.Series == nil {
+		ruleState.Series = make(map[string]*models.AlertSeriesState)
+	}
+	forDuration := rule.GetFor().Milliseconds()
+	matched := make(map[string]struct{})
+	for _, series := range rs.Series {
+		value, ok := latestValue(rule, series)
+		if !ok {
+			continue
+		}
+		match, err := rule.Match(value)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			continue
+		}
+		key := alertFingerprint(series.Tags)
+		matched[key] = struct{}{}
+		seriesState, ok := ruleState.Series[key]
+		if !ok {
+			seriesState = &models.AlertSeriesState{Tags: series.Tags, State: models.AlertPending, ActiveAt: timestamp}
+			ruleState.Series[key] = seriesState
+			if forDuration > 0 {
+				transitions = append(transitions, newAlertTransition(seriesState, models.AlertInactive, value, timestamp))
+			}
+		}
+		seriesState.Value = value
+		if seriesState.State == models.AlertPending && timestamp-seriesState.ActiveAt >= forDuration {
+			from := models.AlertPending
+			if forDuration == 0 && !ok {
+				from = models.AlertInactive
+			}
+			seriesState.State = models.AlertFiring
+			transitions = append(transitions, newAlertTransition(seriesState, from, value, timestamp))
+		}
+	}
+	keys := make([]string, 0, len(ruleState.Series))
+	for key := range ruleState.Series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if _, ok := matched[key]; ok {
+			continue
+		}
+		// condition not matched(or series absent), pending is cancelled and firing is resolved
+		seriesState := ruleState.Series[key]
+		delete(ruleState.Series, key)
+		transition := newAlertTransition(seriesState, seriesState.State, seriesState.Value, timestamp)
+		transition.To = models.AlertInactive
+		transitions = append(transitions, transition)
+	}
+	return transitions, nil
+}
+
+// newAlertTransition creates the transition from given state to current state of series.
+func newAlertTransition(seriesState *models.AlertSeriesState, from models.AlertState,
+	value float64, timestamp int64,
+) *models.AlertTransition {
+	return &models.AlertTransition{
+		Timestamp: timestamp,
+		ActiveAt:  seriesState.ActiveAt,
+		Tags:      seriesState.Tags,
+		From:      from,
+		To:        seriesState.State,
+		Value:     value,
+	}
+}
+
+// latestValue returns the value of the latest point of checked field in series.
+func latestValue(rule *models.AlertRule, series *models.Series) (float64, bool) {
+	if series == nil || len(series.Fields) == 0 {
+		return 0, false
+	}
+	fieldName := rule.Field
+	if fieldName == "" {
+		fieldNames := make([]string, 0, len(series.Fields))
+		for name := range series.Fields {
+			fieldNames = append(fieldNames, name)
+		}
+		sort.Strings(fieldNames)
+		fieldName = fieldNames[0]
+	}
+	points, ok := series.Fields[fieldName]
+	if !ok || len(points) == 0 {
+		return 0, false
+	}
+	var (
+		latest int64
+		value  float64
+		found  bool
+	)
+	for timestamp, v := range points {
+		if !found || timestamp > latest {
+			latest, value, found = timestamp, v, true
+		}
+	}
+	return value, true
+}
+
+// buildAlerts builds firing alerts and resolved alerts(transited from firing) for notifying.
+func buildAlerts(database string, rule *models.AlertRule, ruleState *models.AlertRuleState,
+	transitions []*models.AlertTransition, timestamp int64,
+) []*models.Alert {
+	var alerts []*models.Alert
+	newAlert := func(tags map[string]string, activeAt int64, value float64) *models.Alert {
+		labels := make(map[string]string, len(rule.Labels)+len(tags)+2)
+		for k, v := range tags {
+			labels[k] = v
+		}
+		for k, v := range rule.Labels {
+			labels[k] = v
+		}
+		labels["alertname"] = rule.Name
+		labels["database"] = database
+		annotations := make(map[string]string, len(rule.Annotations)+1)
+		for k, v := range rule.Annotations {
+			annotations[k] = v
+		}
+		annotations["value"] = strconv.FormatFloat(value, 'f', -1, 64)
+		return &models.Alert{
+			Labels:      labels,
+			Annotations: annotations,
+			StartsAt:    time.UnixMilli(activeAt),
+		}
+	}
+	keys := make([]string, 0, len(ruleState.Series))
+	for key := range ruleState.Series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		seriesState := ruleState.Series[key]
+		if seriesState.State != models.AlertFiring {
+			continue
+		}
+		alert := newAlert(seriesState.Tags, seriesState.ActiveAt, seriesState.Value)
+		alert.EndsAt = time.UnixMilli(timestamp + firingAlertTTLFactor*rule.GetInterval().Milliseconds())
+		alerts = append(alerts, alert)
+	}
+	for _, transition := range transitions {
+		if transition.From != models.AlertFiring {
+			continue
+		}
+		alert := newAlert(transition.Tags, transition.ActiveAt, transition.Value)
+		alert.EndsAt = time.UnixMilli(transition.Timestamp)
+		alerts = append(alerts, alert)
+	}
+	return alerts
+}
+
+// copyAlertRuleState returns a copy of alert state, the cached state is not changed if evaluation failure.
+func copyAlertRuleState(ruleState *models.AlertRuleState) *models.AlertRuleState {
+	result := &models.AlertRuleState{
+		LastEvaluated: ruleState.LastEvaluated,
+		Series:        make(map[string]*models.AlertSeriesState, len(ruleState.Series)),
+	}
+	for key, seriesState := range ruleState.Series {
+		s := *seriesState
+		result.Series[key] = &s
+	}
+	return result
+}
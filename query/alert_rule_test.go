@@ -0,0 +1,354 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/state"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+type mockAlertNotifier struct {
+	lock   sync.Mutex
+	alerts []*models.Alert
+	err    error
+}
+
+func (n *mockAlertNotifier) Notify(_ context.Context, _ string, alerts []*models.Alert) error {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.alerts = append(n.alerts, alerts...)
+	return n.err
+}
+
+func newAlertResultSet(values map[string]float64) *models.ResultSet {
+	rs := &models.ResultSet{}
+	for host, value := range values {
+		series := models.NewSeries(map[string]string{"host": host}, host)
+		series.Fields["f"] = map[int64]float64{10: 0, 20: value}
+		rs.Series = append(rs.Series, series)
+	}
+	return rs
+}
+
+func TestTransitAlertState(t *testing.T) {
+	rule := &models.AlertRule{Name: "r", Condition: ">", Threshold: 10, For: "2m", Interval: "1m"}
+	minute := time.Minute.Milliseconds()
+	ruleState := &models.AlertRuleState{}
+	steps := []struct {
+		values      map[string]float64
+		transitions []string
+		firing      int
+	}{
+		{values: map[string]float64{"a": 11, "b": 1}, transitions: []string{"inactive->pending"}},
+		{values: map[string]float64{"a": 12, "b": 11}, transitions: []string{"inactive->pending"}},
+		{values: map[string]float64{"a": 12, "b": 1}, transitions: []string{"pending->firing", "pending->inactive"}, firing: 1},
+		{values: map[string]float64{"a": 13}, firing: 1},
+		{values: map[string]float64{}, transitions: []string{"firing->inactive"}},
+	}
+	for i, step := range steps {
+		transitions, err := transitAlertState(rule, ruleState, newAlertResultSet(step.values), int64(i)*minute)
+		assert.NoError(t, err)
+		var result []string
+		for _, transition := range transitions {
+			result = append(result, fmt.Sprintf("%s->%s", transition.From, transition.To))
+		}
+		assert.Equal(t, step.transitions, result, "step %d", i)
+		firing := 0
+		for _, series := range ruleState.Series {
+			if series.State == models.AlertFiring {
+				firing++
+			}
+		}
+		assert.Equal(t, step.firing, firing, "step %d", i)
+	}
+
+	// fire directly without for duration
+	rule.For = ""
+	transitions, err := transitAlertState(rule, &models.AlertRuleState{}, newAlertResultSet(map[string]float64{"a": 11}), 0)
+	assert.NoError(t, err)
+	assert.Len(t, transitions, 1)
+	assert.Equal(t, models.AlertInactive, transitions[0].From)
+	assert.Equal(t, models.AlertFiring, transitions[0].To)
+	// unknown condition
+	rule.Condition = "x"
+	_, err = transitAlertState(rule, &models.AlertRuleState{}, newAlertResultSet(map[string]float64{"a": 11}), 0)
+	assert.Error(t, err)
+}
+
+func TestLatestValue(t *testing.T) {
+	series := models.NewSeries(nil, "")
+	_, ok := latestValue(&models.AlertRule{}, series)
+	assert.False(t, ok)
+	_, ok = latestValue(&models.AlertRule{}, nil)
+	assert.False(t, ok)
+	series.Fields["b"] = map[int64]float64{10: 1, 30: 3, 20: 2}
+	series.Fields["a"] = map[int64]float64{10: 4}
+	value, ok := latestValue(&models.AlertRule{}, series)
+	assert.True(t, ok)
+	assert.Equal(t, 4.0, value)
+	value, ok = latestValue(&models.AlertRule{Field: "b"}, series)
+	assert.True(t, ok)
+	assert.Equal(t, 3.0, value)
+	_, ok = latestValue(&models.AlertRule{Field: "c"}, series)
+	assert.False(t, ok)
+}
+
+func TestBuildAlerts(t *testing.T) {
+	rule := &models.AlertRule{Name: "r", Interval: "1m",
+		Labels: map[string]string{"severity": "critical"}, Annotations: map[string]string{"summary": "cpu high"}}
+	ruleState := &models.AlertRuleState{Series: map[string]*models.AlertSeriesState{
+		"host=a": {Tags: map[string]string{"host": "a"}, State: models.AlertFiring, ActiveAt: 1000, Value: 11},
+		"host=b": {Tags: map[string]string{"host": "b"}, State: models.AlertPending, ActiveAt: 1000, Value: 11},
+	}}
+	transitions := []*models.AlertTransition{
+		{Timestamp: 60000, ActiveAt: 1000, Tags: map[string]string{"host": "c"}, From: models.AlertFiring, To: models.AlertInactive},
+		{Timestamp: 60000, ActiveAt: 1000, Tags: map[string]string{"host": "d"}, From: models.AlertPending, To: models.AlertInactive},
+	}
+	alerts := buildAlerts("db", rule, ruleState, transitions, 60000)
+	assert.Len(t, alerts, 2)
+	assert.Equal(t, map[string]string{"alertname": "r", "database": "db", "host": "a", "severity": "critical"}, alerts[0].Labels)
+	assert.Equal(t, "11", alerts[0].Annotations["value"])
+	assert.Equal(t, "cpu high", alerts[0].Annotations["summary"])
+	assert.Equal(t, time.UnixMilli(1000), alerts[0].StartsAt)
+	assert.Equal(t, time.UnixMilli(60000+4*60000), alerts[0].EndsAt)
+	assert.Equal(t, "c", alerts[1].Labels["host"])
+	assert.Equal(t, time.UnixMilli(60000), alerts[1].EndsAt)
+}
+
+func TestAlertRuleEvaluator_Evaluate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ruleSearchFn = MetricDataSearch
+		ruleNowFn = func() int64 { return time.Now().UnixMilli() }
+		ctrl.Finish()
+	}()
+	minute := time.Minute.Milliseconds()
+	rules := encoding.JSONMarshal(&models.AlertRules{
+		Database: "db",
+		Rules: []*models.AlertRule{
+			{Name: "rule", SQL: "select f from cpu group by host", Condition: ">", Threshold: 10,
+				Interval: "1m", Webhook: "http://127.0.0.1:9093"},
+		},
+	})
+	ruleNowFn = func() int64 { return 30*minute + 10 }
+	searchErr := fmt.Errorf("err")
+	var searchResult *models.ResultSet
+	ruleSearchFn = func(_ context.Context, _ *models.ExecuteParam, _ *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		if searchResult == nil {
+			return nil, searchErr
+		}
+		return searchResult, nil
+	}
+	statePath := constants.GetAlertRuleStatePath("db", "rule")
+	firingState := encoding.JSONMarshal(&models.AlertRuleState{LastEvaluated: 29 * minute,
+		Series: map[string]*models.AlertSeriesState{
+			"host=a": {Tags: map[string]string{"host": "a"}, State: models.AlertFiring, ActiveAt: minute, Value: 11},
+		}})
+	listRules := func(repo *state.MockRepository) {
+		repo.EXPECT().List(gomock.Any(), constants.AlertRulePath).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+	}
+
+	cases := []struct {
+		name    string
+		master  bool
+		result  *models.ResultSet
+		prepare func(repo *state.MockRepository, notifier *mockAlertNotifier)
+		alerts  int
+	}{
+		{
+			name: "not master",
+		},
+		{
+			name:   "list rules failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				repo.EXPECT().List(gomock.Any(), constants.AlertRulePath).Return(nil, fmt.Errorf("err"))
+			},
+		},
+		{
+			name:   "unmarshal rules failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: []byte("xx")}}, nil)
+			},
+		},
+		{
+			name:   "load state failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				listRules(repo)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, fmt.Errorf("err"))
+			},
+		},
+		{
+			name:   "already evaluated by other master",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				listRules(repo)
+				repo.EXPECT().Get(gomock.Any(), statePath).
+					Return(encoding.JSONMarshal(&models.AlertRuleState{LastEvaluated: 30 * minute}), nil)
+			},
+		},
+		{
+			name:   "search failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				listRules(repo)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, state.ErrNotExist)
+			},
+		},
+		{
+			name:   "save state failure",
+			master: true,
+			result: newAlertResultSet(map[string]float64{"a": 11}),
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				listRules(repo)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, state.ErrNotExist)
+				repo.EXPECT().Put(gomock.Any(), statePath, gomock.Any()).Return(fmt.Errorf("err"))
+			},
+		},
+		{
+			name:   "fire alert",
+			master: true,
+			result: newAlertResultSet(map[string]float64{"a": 11, "b": 1}),
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				listRules(repo)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, state.ErrNotExist)
+				repo.EXPECT().Put(gomock.Any(), statePath, gomock.Any()).Return(nil)
+			},
+			alerts: 1,
+		},
+		{
+			name:   "resolve alert after fail-over, notify failure",
+			master: true,
+			result: newAlertResultSet(map[string]float64{"a": 1}),
+			prepare: func(repo *state.MockRepository, notifier *mockAlertNotifier) {
+				listRules(repo)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(firingState, nil)
+				repo.EXPECT().Put(gomock.Any(), statePath, gomock.Any()).Return(nil)
+				notifier.err = fmt.Errorf("err")
+			},
+			alerts: 1,
+		},
+		{
+			name:   "no alert",
+			master: true,
+			result: newAlertResultSet(map[string]float64{"a": 1}),
+			prepare: func(repo *state.MockRepository, _ *mockAlertNotifier) {
+				listRules(repo)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, state.ErrNotExist)
+				repo.EXPECT().Put(gomock.Any(), statePath, gomock.Any()).Return(nil)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(_ *testing.T) {
+			searchResult = tt.result
+			repo := state.NewMockRepository(ctrl)
+			notifier := &mockAlertNotifier{}
+			if tt.prepare != nil {
+				tt.prepare(repo, notifier)
+			}
+			e := NewAlertRuleEvaluator(context.TODO(), repo, func() bool { return tt.master }, &SearchMgr{}, notifier)
+			evaluator := e.(*alertRuleEvaluator)
+			evaluator.evaluate()
+			evaluator.wait.Wait()
+			e.Stop()
+			assert.Len(t, notifier.alerts, tt.alerts)
+		})
+	}
+}
+
+func TestAlertRuleEvaluator_Schedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ruleCheckInterval = time.Second
+		ctrl.Finish()
+	}()
+	e := NewAlertRuleEvaluator(context.TODO(), state.NewMockRepository(ctrl), func() bool { return false },
+		&SearchMgr{}, &mockAlertNotifier{}).(*alertRuleEvaluator)
+	evaluation := &alertEvaluation{
+		state:      &models.AlertRuleState{LastEvaluated: 100},
+		statistics: metrics.NewAlertRuleStatistics("db", "rule"),
+	}
+	e.rules["db/rule"] = evaluation
+	rule := &models.AlertRule{Name: "rule", Interval: "1m"}
+	// not due
+	e.schedule("db", rule, 100)
+	assert.False(t, evaluation.running)
+	// previous evaluation still running
+	evaluation.running = true
+	overlaps := evaluation.statistics.OverlapSkips.Get()
+	e.schedule("db", rule, 200)
+	assert.Equal(t, overlaps+1, evaluation.statistics.OverlapSkips.Get())
+	// reset after losing master
+	e.reset()
+	assert.Nil(t, evaluation.state)
+
+	ruleCheckInterval = time.Millisecond
+	e.Start()
+	time.Sleep(10 * time.Millisecond)
+	e.Stop()
+}
+
+func TestDryRunAlertRule(t *testing.T) {
+	defer func() {
+		ruleSearchFn = MetricDataSearch
+	}()
+	minute := time.Minute.Milliseconds()
+	values := []float64{1, 11, 12, 13, 1}
+	ruleSearchFn = func(_ context.Context, _ *models.ExecuteParam, statement *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		return newAlertResultSet(map[string]float64{"a": values[statement.TimeRange.Start/minute]}), nil
+	}
+	rule := &models.AlertRule{Name: "r", SQL: "select f from cpu", Condition: ">", Threshold: 10, For: "1m", Interval: "1m"}
+	transitions, err := DryRunAlertRule(context.TODO(), &SearchMgr{}, "db", rule, 10, 5*minute)
+	assert.NoError(t, err)
+	assert.Len(t, transitions, 3)
+	assert.Equal(t, models.AlertPending, transitions[0].To)
+	assert.Equal(t, models.AlertFiring, transitions[1].To)
+	assert.Equal(t, models.AlertInactive, transitions[2].To)
+
+	_, err = DryRunAlertRule(context.TODO(), &SearchMgr{}, "db", &models.AlertRule{Interval: "x"}, 0, minute)
+	assert.Error(t, err)
+	_, err = DryRunAlertRule(context.TODO(), &SearchMgr{}, "db", rule, minute, minute)
+	assert.Error(t, err)
+	_, err = DryRunAlertRule(context.TODO(), &SearchMgr{}, "db", rule, 0, (maxDryRunWindows+1)*minute)
+	assert.Error(t, err)
+	rule.Condition = "x"
+	_, err = DryRunAlertRule(context.TODO(), &SearchMgr{}, "db", rule, 0, minute)
+	assert.Error(t, err)
+	ruleSearchFn = func(_ context.Context, _ *models.ExecuteParam, _ *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		return nil, fmt.Errorf("err")
+	}
+	_, err = DryRunAlertRule(context.TODO(), &SearchMgr{}, "db", rule, 0, minute)
+	assert.Error(t, err)
+}
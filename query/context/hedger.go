@@ -0,0 +1,132 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+)
+
+const (
+	// defaultHedgeDelay is the delay of hedging request before collecting enough leaf latencies.
+	defaultHedgeDelay = time.Second
+	// minLatencySamples is the minimum num. of leaf latencies for calculating percentile delay.
+	minLatencySamples = 20
+	// maxLatencySamples is the maximum num. of recent leaf latencies kept.
+	maxLatencySamples = 1024
+	// maxHedgeBurst is the maximum num. of hedges allowed in burst.
+	maxHedgeBurst = 10
+)
+
+// Hedger decides when and whether the slow leaf request can be hedged to other replica,
+// 1. the delay of hedging is the percentile of recent leaf response latencies;
+// 2. the hedge rate is capped by a token bucket, each leaf request adds max-rate tokens, each hedge takes one token.
+type Hedger struct {
+	cfg config.Hedge
+
+	latencies []time.Duration // ring buffer of recent leaf latencies
+	pos       int
+	tokens    float64
+	mutex     sync.Mutex
+
+	statistics *metrics.QueryHedgeStatistics
+}
+
+// NewHedger creates a Hedger instance.
+func NewHedger(cfg config.Hedge, registry *linmetric.Registry) *Hedger {
+	return &Hedger{
+		cfg:        cfg,
+		statistics: metrics.NewQueryHedgeStatistics(registry),
+	}
+}
+
+// Delay returns the delay of hedging leaf request.
+func (h *Hedger) Delay() time.Duration {
+	h.mutex.Lock()
+	if len(h.latencies) < minLatencySamples {
+		h.mutex.Unlock()
+		return defaultHedgeDelay
+	}
+	latencies := make([]time.Duration, len(h.latencies))
+	copy(latencies, h.latencies)
+	h.mutex.Unlock()
+
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	idx := int(math.Ceil(h.cfg.Percentile/100*float64(len(latencies)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	delay := latencies[idx]
+	if minDelay := h.cfg.MinDelay.Duration(); delay < minDelay {
+		return minDelay
+	}
+	return delay
+}
+
+// Observe records the latency of leaf response.
+func (h *Hedger) Observe(latency time.Duration) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if len(h.latencies) < maxLatencySamples {
+		h.latencies = append(h.latencies, latency)
+		return
+	}
+	h.latencies[h.pos] = latency
+	h.pos = (h.pos + 1) % maxLatencySamples
+}
+
+// OnRequest adds hedge tokens for each leaf request.
+func (h *Hedger) OnRequest() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.tokens = math.Min(h.tokens+h.cfg.MaxRate, maxHedgeBurst)
+}
+
+// TryHedge returns true if the leaf request can be hedged under max hedge rate.
+func (h *Hedger) TryHedge() bool {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.tokens < 1 {
+		h.statistics.RateLimited.Incr()
+		return false
+	}
+	h.tokens--
+	h.statistics.Attempts.Incr()
+	return true
+}
+
+// OnWin records the hedged request responded before original request.
+func (h *Hedger) OnWin() {
+	h.statistics.Wins.Incr()
+}
+
+// OnWaste records the loser request cancelled.
+func (h *Hedger) OnWaste() {
+	h.statistics.Wasted.Incr()
+}
@@ -0,0 +1,67 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func TestHedger_Delay(t *testing.T) {
+	h := NewHedger(config.Hedge{Percentile: 90, MinDelay: ltoml.Duration(5 * time.Millisecond), MaxRate: 0.1},
+		linmetric.BrokerRegistry)
+	// not enough samples
+	assert.Equal(t, defaultHedgeDelay, h.Delay())
+	for i := 1; i <= 100; i++ {
+		h.Observe(time.Duration(i) * time.Millisecond)
+	}
+	assert.Equal(t, 90*time.Millisecond, h.Delay())
+	// keep recent latencies
+	for i := 0; i < maxLatencySamples; i++ {
+		h.Observe(time.Millisecond)
+	}
+	assert.Len(t, h.latencies, maxLatencySamples)
+	// min delay
+	assert.Equal(t, 5*time.Millisecond, h.Delay())
+}
+
+func TestHedger_TryHedge(t *testing.T) {
+	h := NewHedger(config.Hedge{Percentile: 90, MaxRate: 0.5}, linmetric.BrokerRegistry)
+	assert.False(t, h.TryHedge())
+	h.OnRequest()
+	assert.False(t, h.TryHedge())
+	h.OnRequest()
+	assert.True(t, h.TryHedge())
+	assert.False(t, h.TryHedge())
+	// max burst
+	for i := 0; i < 100; i++ {
+		h.OnRequest()
+	}
+	for i := 0; i < maxHedgeBurst; i++ {
+		assert.True(t, h.TryHedge())
+	}
+	assert.False(t, h.TryHedge())
+	h.OnWin()
+	h.OnWaste()
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/sql/stmt"
@@ -50,7 +51,7 @@ func NewIntermediateMetricContext(ctx context.Context,
 	req *protoCommonV1.TaskRequest, curNode models.StatelessNode,
 	physicalPlan *models.PhysicalPlan, statement *stmt.Query, receivers []string,
 ) *IntermediateMetricContext {
-	return &IntermediateMetricContext{
+	metricCtx := &IntermediateMetricContext{
 		MetricContext:   newMetricContext(ctx, transportMgr),
 		stateMgr:        stateMgr,
 		req:             req,
@@ -60,6 +61,8 @@ func NewIntermediateMetricContext(ctx context.Context,
 		receivers:       receivers,
 		responseCh:      make(chan *protoCommonV1.TaskResponse),
 	}
+	metricCtx.explain = statement != nil && statement.Explain
+	return metricCtx
 }
 
 // WaitResponse waits the task completed, then returns the result set.
@@ -87,7 +90,11 @@ func (ctx *IntermediateMetricContext) MakePlan() error {
 		return constants.ErrDatabaseNotExist
 	}
 
-	calcTimeRangeAndInterval(ctx.statement, databaseCfg)
+	if err := CalcTimeRangeAndInterval(ctx.statement, databaseCfg); err != nil {
+		return err
+	}
+	ctx.pruneShardsByRouting(physicalPlans, ctx.statement.Condition, databaseCfg)
+	ctx.splitSeriesLimit(physicalPlans, databaseCfg)
 
 	payload, _ := ctx.statement.MarshalJSON()
 	for _, physicalPlan := range physicalPlans {
@@ -97,6 +104,8 @@ func (ctx *IntermediateMetricContext) MakePlan() error {
 		if err := physicalPlan.Validate(); err != nil {
 			return err
 		}
+		physicalPlan.Timeout = remainingTimeout(ctx.ctx)
+		physicalPlan.TraceContext = tracing.Inject(ctx.ctx)
 		ctx.addRequests(
 			&protoCommonV1.TaskRequest{
 				RequestID:    ctx.req.RequestID,
@@ -111,11 +120,16 @@ func (ctx *IntermediateMetricContext) MakePlan() error {
 // makeTaskResponse builds task response.
 func (ctx *IntermediateMetricContext) makeTaskResponse() *protoCommonV1.TaskResponse {
 	var stats []byte
-	if ctx.stats != nil {
+	switch {
+	case ctx.stats != nil:
 		end := time.Now()
 		ctx.stats.End = end.UnixNano()
 		ctx.stats.TotalCost = end.Sub(ctx.startTime).Nanoseconds()
+		ctx.stats.ShardMaxTimestamps = ctx.shardMaxTimestamps
 		stats = encoding.JSONMarshal(ctx.stats)
+	case len(ctx.shardMaxTimestamps) > 0:
+		// forward max data timestamp of shards to root
+		stats = encoding.JSONMarshal(&models.NodeStats{ShardMaxTimestamps: ctx.shardMaxTimestamps})
 	}
 	var timeSeriesList []*protoCommonV1.TimeSeries
 	if ctx.groupAgg != nil {
@@ -29,6 +29,7 @@ import (
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
@@ -160,3 +161,19 @@ func TestIntermediateMetricContext_makeTaskResponse(t *testing.T) {
 	resp := metricCtx.makeTaskResponse()
 	assert.NotNil(t, resp)
 }
+
+func TestIntermediateMetricContext_makeTaskResponse_shardMaxTimestamps(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	metricCtx := NewIntermediateMetricContext(context.TODO(), nil, nil,
+		&protoCommonV1.TaskRequest{}, models.StatelessNode{}, &models.PhysicalPlan{},
+		&stmt.Query{}, []string{"root"})
+	metricCtx.shardMaxTimestamps = map[models.ShardID]int64{1: 10}
+	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	groupAgg.EXPECT().ResultSet().Return(nil)
+	metricCtx.groupAgg = groupAgg
+	resp := metricCtx.makeTaskResponse()
+	stats := &models.NodeStats{}
+	assert.NoError(t, encoding.JSONUnmarshal(resp.Stats, stats))
+	assert.Equal(t, map[models.ShardID]int64{1: 10}, stats.ShardMaxTimestamps)
+}
@@ -18,10 +18,13 @@
 package context
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
@@ -70,6 +73,10 @@ func NewLeafExecuteContext(taskCtx *flow.TaskContext,
 		TaskCtx:  taskCtx,
 		Query:    queryStmt,
 		ShardIDs: leafNode.ShardIDs,
+		// only collect operators' stats for explain query
+		CollectStats: queryStmt != nil && queryStmt.Explain,
+		// share of current node split from the series limit per query by broker
+		MaxSeries: leafNode.MaxSeries,
 	}
 	ctx := &LeafExecuteContext{
 		TaskCtx:           taskCtx,
@@ -121,6 +128,10 @@ func (ctx *LeafExecuteContext) SendResponse(err error) {
 		defer ctx.StorageExecuteCtx.Release()
 
 		if err != nil {
+			if errors.Is(err, constants.ErrTimeout) {
+				// attach partial stage timings for diagnosing which stage is slow
+				err = fmt.Errorf("%w, stages: %s", err, models.StagesSummary(ctx.Tracker.GetStages()))
+			}
 			// send error msg
 			ctx.sendResponse(nil, err)
 			return
@@ -144,8 +155,16 @@ func (ctx *LeafExecuteContext) SendResponse(err error) {
 func (ctx *LeafExecuteContext) sendResponse(resultData [][]byte, err error) {
 	var stats []byte
 	var errMsg string
-	if ctx.StorageExecuteCtx.Query.Explain {
-		stats = encoding.JSONMarshal(ctx.Tracker.GetStats())
+	shardMaxTimestamps := ctx.StorageExecuteCtx.ShardMaxTimestamps()
+	switch {
+	case (ctx.StorageExecuteCtx.Query.Explain || ctx.StorageExecuteCtx.Query.ReturnStats) && ctx.Tracker.GetStats() != nil:
+		// stats is nil if leaf task failed before completed
+		nodeStats := ctx.Tracker.GetStats()
+		nodeStats.ShardMaxTimestamps = shardMaxTimestamps
+		stats = encoding.JSONMarshal(nodeStats)
+	case len(shardMaxTimestamps) > 0:
+		// only max data timestamp of shards for leader read consistency
+		stats = encoding.JSONMarshal(&models.NodeStats{ShardMaxTimestamps: shardMaxTimestamps})
 	}
 	if err != nil {
 		errMsg = err.Error()
@@ -24,9 +24,11 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/roaring"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
@@ -75,6 +77,19 @@ func TestLeafExecuteContext_SendResponse(t *testing.T) {
 				stream.EXPECT().Send(gomock.Any()).Return(fmt.Errorf("err"))
 			},
 		},
+		{
+			name:      "send timeout response with stage timings",
+			in:        constants.ErrTimeout,
+			receivers: []string{""},
+			prepare: func(ctx *LeafExecuteContext) {
+				ctx.Tracker.AddStage(&models.StageStats{Identifier: "Shard Scan[Shard(1)]", State: "Executing"})
+				taskServerFct.EXPECT().GetStream(gomock.Any()).Return(stream)
+				stream.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoCommonV1.TaskResponse) error {
+					assert.Equal(t, "exceed timeout, stages: [Shard Scan[Shard(1)](Executing, 0s)]", resp.ErrMsg)
+					return nil
+				})
+			},
+		},
 		{
 			name:      "send response with grouping",
 			in:        nil,
@@ -91,6 +106,19 @@ func TestLeafExecuteContext_SendResponse(t *testing.T) {
 				})
 			},
 		},
+		{
+			name:      "send response with stats for local query",
+			in:        nil,
+			receivers: []string{""},
+			prepare: func(ctx *LeafExecuteContext) {
+				ctx.StorageExecuteCtx.Query.ReturnStats = true
+				taskServerFct.EXPECT().GetStream(gomock.Any()).Return(stream)
+				stream.EXPECT().Send(gomock.Any()).DoAndReturn(func(resp *protoCommonV1.TaskResponse) error {
+					assert.NotEmpty(t, resp.Stats)
+					return nil
+				})
+			},
+		},
 		{
 			name:      "time out",
 			in:        nil,
@@ -131,3 +159,23 @@ func TestLeafExecuteContext_SendResponse(t *testing.T) {
 		})
 	}
 }
+
+func TestLeafExecuteContext_CollectStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := tsdb.NewMockDatabase(ctrl)
+	db.EXPECT().GetOption().Return(nil).AnyTimes()
+	taskCtx := flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)
+	defer taskCtx.Release()
+
+	ctx := NewLeafExecuteContext(taskCtx, tracker.NewStageTracker(taskCtx),
+		&stmtpkg.Query{}, &protoCommonV1.TaskRequest{}, nil, &models.Target{}, nil, db)
+	assert.False(t, ctx.StorageExecuteCtx.CollectStats)
+	ctx = NewLeafExecuteContext(taskCtx, tracker.NewStageTracker(taskCtx),
+		&stmtpkg.Query{Explain: true}, &protoCommonV1.TaskRequest{}, nil, &models.Target{}, nil, db)
+	assert.True(t, ctx.StorageExecuteCtx.CollectStats)
+	// local query returns stats without collecting operators' stats
+	ctx = NewLeafExecuteContext(taskCtx, tracker.NewStageTracker(taskCtx),
+		&stmtpkg.Query{ReturnStats: true}, &protoCommonV1.TaskRequest{}, nil, &models.Target{}, nil, db)
+	assert.False(t, ctx.StorageExecuteCtx.CollectStats)
+}
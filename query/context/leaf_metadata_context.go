@@ -18,7 +18,8 @@
 package context
 
 import (
-	"github.com/lindb/lindb/constants"
+	"sync"
+
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/series/tag"
@@ -35,9 +36,13 @@ type LeafMetadataContext struct {
 	StorageExecuteCtx *flow.StorageExecuteContext
 
 	ResultSet []string
+	Truncated bool      // true if result set reaches limit, but more values exist
 	TagKeyID  tag.KeyID // for tag values suggest
 
 	Limit int
+
+	values map[string]struct{} // for dedupe values
+	mutex  sync.Mutex
 }
 
 // NewLeafMetadataContext creates a LeafMetadataContext instance.
@@ -47,25 +52,26 @@ func NewLeafMetadataContext(request *stmt.MetricMetadata, database tsdb.Database
 		Database: database,
 		ShardIDs: shardIDs,
 	}
-	ctx.Limit = ctx.getLimit()
+	ctx.Limit = suggestionLimit(request)
 	return ctx
 }
 
-// getLimit returns result limit.
-func (ctx *LeafMetadataContext) getLimit() int {
-	req := ctx.Request
-	limit := req.Limit
-	if limit == 0 || limit > constants.MaxSuggestions {
-		// if limit = 0 or > max suggestion items, need reset limit
-		limit = constants.MaxSuggestions
-	}
-	return limit
-}
+// AddValue adds value into result set if not exist, returns false if result set is full.
+func (ctx *LeafMetadataContext) AddValue(val string) bool {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
 
-// AddValue adds value into result set.
-func (ctx *LeafMetadataContext) AddValue(val string) {
+	if ctx.values == nil {
+		ctx.values = make(map[string]struct{})
+	}
+	if _, ok := ctx.values[val]; ok {
+		return !ctx.Truncated
+	}
 	if len(ctx.ResultSet) >= ctx.Limit {
-		return
+		ctx.Truncated = true
+		return false
 	}
+	ctx.values[val] = struct{}{}
 	ctx.ResultSet = append(ctx.ResultSet, val)
+	return true
 }
@@ -34,6 +34,17 @@ func TestLeafMetadataContext(t *testing.T) {
 		ctx.AddValue(fmt.Sprintf("value-%d", i))
 	}
 	assert.Len(t, ctx.ResultSet, constants.MaxSuggestions)
+	assert.True(t, ctx.Truncated)
+	assert.False(t, ctx.AddValue("value-1"))
+
+	ctx = NewLeafMetadataContext(&stmtpkg.MetricMetadata{Limit: 2}, nil, nil)
+	assert.True(t, ctx.AddValue("value-1"))
+	assert.True(t, ctx.AddValue("value-1"))
+	assert.True(t, ctx.AddValue("value-2"))
+	assert.Equal(t, []string{"value-1", "value-2"}, ctx.ResultSet)
+	assert.False(t, ctx.Truncated)
+	assert.False(t, ctx.AddValue("value-3"))
+	assert.True(t, ctx.Truncated)
 
 	ctx = NewLeafMetadataContext(&stmtpkg.MetricMetadata{Limit: 50}, nil, nil)
 	assert.Equal(t, 50, ctx.Limit)
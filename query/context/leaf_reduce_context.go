@@ -122,11 +122,18 @@ func (ctx *LeafReduceContext) makeTimeSeriesList() []*protoCommonV1.TimeSeries {
 	}
 
 	hasGroupBy := ctx.storageExecuteCtx.Query.HasGroupBy()
-	// 1. get reduce aggregator result set
+	// 1. pick candidate groups for top-n query, prune the other groups ranked by partial aggregates
+	candidates := ctx.topNCandidates()
+	// 2. get reduce aggregator result set
 	groupedSeriesList := ctx.reduceAgg.ResultSet()
-	// 2. build rpc response data
+	// 3. build rpc response data
 	var timeSeriesList []*protoCommonV1.TimeSeries
 	for _, groupedSeriesItr := range groupedSeriesList {
+		if candidates != nil {
+			if _, ok := candidates[groupedSeriesItr.Tags()]; !ok {
+				continue
+			}
+		}
 		fields := make(map[string][]byte)
 		for groupedSeriesItr.HasNext() {
 			seriesItr := groupedSeriesItr.Next()
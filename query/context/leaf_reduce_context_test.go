@@ -28,6 +28,8 @@ import (
 	"github.com/lindb/lindb/aggregation/function"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/collections"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	stmtpkg "github.com/lindb/lindb/sql/stmt"
@@ -129,3 +131,65 @@ func TestLeafReduceContext_BuildResultSet(t *testing.T) {
 		})
 	}
 }
+
+func TestLeafReduceContext_topNCandidates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newExpressionFn = aggregation.NewExpression
+		ctrl.Finish()
+	}()
+
+	spec := aggregation.NewAggregatorSpec("f", field.SumField)
+	spec.AddFunctionType(function.Sum)
+	query := &stmtpkg.Query{
+		GroupBy:     []string{"host"},
+		SelectItems: []stmtpkg.Expr{&stmtpkg.SelectItem{Expr: &stmtpkg.FieldExpr{Name: "f"}}},
+		OrderByItems: []stmtpkg.Expr{&stmtpkg.OrderByExpr{
+			Expr: &stmtpkg.CallExpr{FuncType: function.Sum, Params: []stmtpkg.Expr{&stmtpkg.FieldExpr{Name: "f"}}},
+			Desc: true,
+		}},
+		Limit:          1,
+		TopNCandidates: 2,
+	}
+	ctx := NewLeafReduceContext(&flow.StorageExecuteContext{
+		Query:           query,
+		AggregatorSpecs: aggregation.AggregatorSpecs{spec},
+	}, &LeafGroupingContext{})
+	agg := aggregation.NewMockGroupingAggregator(ctrl)
+	ctx.reduceAgg = agg
+
+	values := map[string]float64{"a": 1, "b": 3, "c": 2}
+	var groupIts series.GroupedIterators
+	for _, tags := range []string{"a", "b", "c"} {
+		gIt := series.NewMockGroupedIterator(ctrl)
+		gIt.EXPECT().Tags().Return(tags).AnyTimes()
+		groupIts = append(groupIts, gIt)
+	}
+	newExpressionFn = func(_ timeutil.TimeRange, _ int64, _ []stmtpkg.Expr) aggregation.Expression {
+		expression := aggregation.NewMockExpression(ctrl)
+		var tags string
+		expression.EXPECT().Eval(gomock.Any()).Do(func(it series.GroupedIterator) {
+			tags = it.Tags()
+		})
+		expression.EXPECT().ResultSet().DoAndReturn(func() map[string]*collections.FloatArray {
+			array := collections.NewFloatArray(1)
+			array.SetValue(0, values[tags])
+			return map[string]*collections.FloatArray{"f": array}
+		})
+		return expression
+	}
+	// keep top 2 groups ranked by partial aggregates
+	agg.EXPECT().NumOfGroups().Return(3)
+	agg.EXPECT().ResultSet().Return(groupIts)
+	assert.Equal(t, map[string]struct{}{"b": {}, "c": {}}, ctx.topNCandidates())
+	// num. of groups not exceeds candidates
+	agg.EXPECT().NumOfGroups().Return(2)
+	assert.Nil(t, ctx.topNCandidates())
+	// cannot rank groups
+	query.OrderByItems = []stmtpkg.Expr{&stmtpkg.OrderByExpr{Expr: &stmtpkg.FieldExpr{Name: "unknown"}}}
+	agg.EXPECT().NumOfGroups().Return(3)
+	assert.Nil(t, ctx.topNCandidates())
+	// not top-n query
+	query.TopNCandidates = 0
+	assert.Nil(t, ctx.topNCandidates())
+}
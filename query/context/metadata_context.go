@@ -19,11 +19,14 @@ package context
 
 import (
 	"context"
+	"sort"
 
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/strutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/sql/stmt"
@@ -39,6 +42,7 @@ type MetadataDeps struct {
 	CurrentNode  models.StatelessNode
 	Choose       flow.NodeChoose
 	TransportMgr rpc.TransportManager
+	Cursor       *models.MetadataCursor // resume position of paginated query, nil for first page
 }
 
 // MetadataContext represents metric metadata search context.
@@ -47,27 +51,32 @@ type MetadataContext struct {
 
 	Deps *MetadataDeps
 	// handle response
-	results []string
+	results     []string
+	truncated   bool
+	nodeResults map[string]*models.SuggestResult // result of each node for paginated query
 }
 
 // NewMetadataContext creates metric metadata search context.
 func NewMetadataContext(deps *MetadataDeps) *MetadataContext {
-	if deps.Statement.Limit == 0 || deps.Statement.Limit > constants.MaxSuggestions {
-		// if limit =0 or > max suggestion items, need reset limit
-		deps.Statement.Limit = constants.MaxSuggestions
-	}
+	deps.Statement.Limit = suggestionLimit(deps.Statement)
 	return &MetadataContext{
 		baseTaskContext: newBaseTaskContext(deps.Ctx, deps.TransportMgr),
 		Deps:            deps,
 	}
 }
 
-// WaitResponse waits metric metadata search task completed and returns metric data.
+// WaitResponse waits metric metadata search task completed and returns suggest result.
 func (ctx *MetadataContext) WaitResponse() (any, error) {
 	select {
 	case <-ctx.doneCh:
 		// received all data, break for loop
-		return ctx.results, ctx.err
+		if ctx.isKilled() {
+			return nil, constants.ErrQueryKilled
+		}
+		if ctx.err != nil {
+			return nil, ctx.err
+		}
+		return ctx.buildResult(), nil
 	case <-ctx.Deps.Ctx.Done():
 		return nil, constants.ErrTimeout
 	}
@@ -89,12 +98,18 @@ func (ctx *MetadataContext) MakePlan() error {
 		return constants.ErrTargetNodesNotFound
 	}
 
+	if cursor := ctx.Deps.Cursor; cursor != nil {
+		ctx.Deps.Statement.After = cursor.After
+	}
 	suggestMarshalData, _ := ctx.Deps.Statement.MarshalJSON()
 	for _, physicalPlan := range physicalPlans {
+		physicalPlan.Targets = ctx.skipExhausted(physicalPlan.Targets)
 		physicalPlan.AddReceiver(ctx.Deps.CurrentNode.Indicator())
 		if err := physicalPlan.Validate(); err != nil {
 			return err
 		}
+		physicalPlan.Timeout = remainingTimeout(ctx.ctx)
+		physicalPlan.TraceContext = tracing.Inject(ctx.ctx)
 		ctx.addRequests(
 			&protoCommonV1.TaskRequest{
 				RequestID:    ctx.Deps.Request.RequestID,
@@ -120,4 +135,89 @@ func (ctx *MetadataContext) handleResponse(resp *protoCommonV1.TaskResponse, fro
 		ctx.err = err
 	}
 	ctx.results = append(ctx.results, result.Values...)
+	// if any node truncated, the final result is truncated
+	ctx.truncated = ctx.truncated || result.Truncated
+	if ctx.Deps.Statement.Paginated() {
+		if ctx.nodeResults == nil {
+			ctx.nodeResults = make(map[string]*models.SuggestResult)
+		}
+		ctx.nodeResults[fromNode] = result
+	}
+}
+
+// skipExhausted removes the targets without more values for paginated query,
+// keeps all targets if all exhausted(nodes changed after previous page).
+func (ctx *MetadataContext) skipExhausted(targets []*models.Target) []*models.Target {
+	cursor := ctx.Deps.Cursor
+	if cursor == nil || len(cursor.Exhausted) == 0 {
+		return targets
+	}
+	var rs []*models.Target
+	for _, target := range targets {
+		if !cursor.IsExhausted(target.Indicator) {
+			rs = append(rs, target)
+		}
+	}
+	if len(rs) == 0 {
+		return targets
+	}
+	return rs
+}
+
+// buildResult builds the suggest result, for paginated query merges the values of all nodes into one page
+// in order, then encodes the resume position into cursor if more values exist.
+func (ctx *MetadataContext) buildResult() *models.SuggestResult {
+	statement := ctx.Deps.Statement
+	if !statement.Paginated() {
+		return &models.SuggestResult{Values: ctx.results, Truncated: ctx.truncated}
+	}
+	cursor := ctx.Deps.Cursor
+	if cursor == nil {
+		cursor = &models.MetadataCursor{}
+	}
+	values := strutil.DeDupStringSlice(ctx.results)
+	sort.Strings(values)
+	more := ctx.truncated
+	if len(values) > statement.Limit {
+		values = values[:statement.Limit]
+		more = true
+	}
+	remaining := constants.MaxPaginatedSuggestions - cursor.Returned
+	if remaining < 0 {
+		remaining = 0
+	}
+	if len(values) >= remaining {
+		// reach the max number of values returned by all pages, no more page
+		more = more || len(values) > remaining
+		return &models.SuggestResult{Values: values[:remaining], Truncated: more}
+	}
+	rs := &models.SuggestResult{Values: values, Truncated: more}
+	if !more || len(values) == 0 {
+		return rs
+	}
+	last := values[len(values)-1]
+	next := &models.MetadataCursor{
+		After:     last,
+		Exhausted: append([]string(nil), cursor.Exhausted...),
+		Returned:  cursor.Returned + len(values),
+	}
+	for node, result := range ctx.nodeResults {
+		if result.Truncated {
+			continue
+		}
+		exhausted := true
+		for _, value := range result.Values {
+			if value > last {
+				// values after last not returned by this page
+				exhausted = false
+				break
+			}
+		}
+		if exhausted {
+			next.Exhausted = append(next.Exhausted, node)
+		}
+	}
+	sort.Strings(next.Exhausted)
+	rs.Cursor = next.Encode()
+	return rs
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/tracker"
 	"github.com/lindb/lindb/sql/stmt"
@@ -128,4 +129,77 @@ func TestMetadataContext_HandleResponse(t *testing.T) {
 	})
 	ctx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
 	ctx.HandleResponse(&protoCommonV1.TaskResponse{}, "leaf")
+
+	t.Run("merge truncated result", func(t *testing.T) {
+		ctx := NewMetadataContext(&MetadataDeps{
+			Ctx:       context.TODO(),
+			Statement: &stmt.MetricMetadata{},
+		})
+		ctx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+		ctx.expectResults = 2
+		ctx.HandleResponse(&protoCommonV1.TaskResponse{
+			Payload: encoding.JSONMarshal(&models.SuggestResult{Values: []string{"a", "b"}, Truncated: true}),
+		}, "leaf-1")
+		ctx.HandleResponse(&protoCommonV1.TaskResponse{
+			Payload: encoding.JSONMarshal(&models.SuggestResult{Values: []string{"b", "c"}}),
+		}, "leaf-2")
+		rs, err := ctx.WaitResponse()
+		assert.NoError(t, err)
+		assert.Equal(t, &models.SuggestResult{Values: []string{"a", "b", "b", "c"}, Truncated: true}, rs)
+	})
+}
+
+func TestMetadataContext_Paginated(t *testing.T) {
+	handle := func(ctx *MetadataContext, node string, result *models.SuggestResult) {
+		ctx.HandleResponse(&protoCommonV1.TaskResponse{Payload: encoding.JSONMarshal(result)}, node)
+	}
+	newCtx := func(cursor *models.MetadataCursor) *MetadataContext {
+		ctx := NewMetadataContext(&MetadataDeps{
+			Ctx:       context.TODO(),
+			Statement: &stmt.MetricMetadata{Type: stmt.Metric, Limit: 2},
+			Cursor:    cursor,
+		})
+		ctx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+		ctx.expectResults = 2
+		return ctx
+	}
+
+	t.Run("merge page and build cursor", func(t *testing.T) {
+		ctx := newCtx(nil)
+		handle(ctx, "leaf-1", &models.SuggestResult{Values: []string{"b", "a"}})
+		handle(ctx, "leaf-2", &models.SuggestResult{Values: []string{"a", "c"}, Truncated: true})
+		rs, err := ctx.WaitResponse()
+		assert.NoError(t, err)
+		result := rs.(*models.SuggestResult)
+		assert.Equal(t, []string{"a", "b"}, result.Values)
+		assert.True(t, result.Truncated)
+		cursor, err := models.DecodeMetadataCursor(result.Cursor)
+		assert.NoError(t, err)
+		assert.Equal(t, &models.MetadataCursor{After: "b", Exhausted: []string{"leaf-1"}, Returned: 2}, cursor)
+	})
+	t.Run("last page", func(t *testing.T) {
+		ctx := newCtx(&models.MetadataCursor{After: "b", Returned: 2})
+		handle(ctx, "leaf-1", &models.SuggestResult{Values: []string{"c"}})
+		handle(ctx, "leaf-2", &models.SuggestResult{})
+		rs, err := ctx.WaitResponse()
+		assert.NoError(t, err)
+		assert.Equal(t, &models.SuggestResult{Values: []string{"c"}}, rs)
+	})
+	t.Run("reach max returned values", func(t *testing.T) {
+		ctx := newCtx(&models.MetadataCursor{After: "b", Returned: constants.MaxPaginatedSuggestions - 1})
+		handle(ctx, "leaf-1", &models.SuggestResult{Values: []string{"c", "d"}})
+		handle(ctx, "leaf-2", &models.SuggestResult{})
+		rs, err := ctx.WaitResponse()
+		assert.NoError(t, err)
+		assert.Equal(t, &models.SuggestResult{Values: []string{"c"}, Truncated: true}, rs)
+	})
+	t.Run("skip exhausted nodes", func(t *testing.T) {
+		targets := []*models.Target{{Indicator: "leaf-1"}, {Indicator: "leaf-2"}}
+		ctx := newCtx(&models.MetadataCursor{After: "b", Exhausted: []string{"leaf-1"}})
+		assert.Equal(t, []*models.Target{{Indicator: "leaf-2"}}, ctx.skipExhausted(targets))
+		ctx = newCtx(&models.MetadataCursor{After: "b", Exhausted: []string{"leaf-1", "leaf-2"}})
+		assert.Equal(t, targets, ctx.skipExhausted(targets))
+		ctx = newCtx(nil)
+		assert.Equal(t, targets, ctx.skipExhausted(targets))
+	})
 }
@@ -19,8 +19,7 @@ package context
 
 import (
 	"context"
-	"errors"
-	"strings"
+	"fmt"
 	"time"
 
 	"github.com/lindb/lindb/aggregation"
@@ -28,6 +27,8 @@ import (
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
+	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/rpc"
@@ -41,12 +42,27 @@ type MetricContext struct {
 
 	groupAgg aggregation.GroupingAggregator
 	stats    *models.NodeStats
+	explain  bool // collects the node stats of query task for explain query
+	// max data timestamp of each shard reported by leaf nodes for leader read consistency
+	shardMaxTimestamps map[models.ShardID]int64
 	// field name -> aggregator spec
 	// we will use it during intermediate tasks
 	aggregatorSpecs map[string]*protoCommonV1.AggregatorSpec
 	timeRange       timeutil.TimeRange
 	interval        int64
 	startTime       time.Time // task start time
+
+	maxBufferedSeries int    // max grouped series buffered for merging, 0 means no limit
+	memoryBudget      int64  // max estimated memory of grouped series buffered for merging, 0 means no limit
+	spillDir          string // directory which grouped series are spilled to if memory budget exceeded, empty means disabled
+	// spills grouped series instead of failing if max buffered series exceeded
+	spillBufferedSeries bool
+
+	keepBlocks bool     // keep time series blocks of responses for caching result
+	blocks     [][]byte // time series blocks of responses
+
+	queriedShards, prunedShards int // num. of shards queried/pruned by routing tags
+	maxSeriesPerShard           int // share of each queried shard split from series limit per query, 0 means no limit
 }
 
 // newMetricContext creates metric data search context.
@@ -68,6 +84,9 @@ func (ctx *MetricContext) HandleResponse(resp *protoCommonV1.TaskResponse, fromN
 func (ctx *MetricContext) waitResponse() error {
 	select {
 	case <-ctx.doneCh:
+		if ctx.isKilled() {
+			return constants.ErrQueryKilled
+		}
 		if ctx.err != nil {
 			return ctx.err
 		}
@@ -96,12 +115,19 @@ func (ctx *MetricContext) handleResponse(resp *protoCommonV1.TaskResponse, fromN
 	if ignoreResponse {
 		return
 	}
+	if ctx.err != nil {
+		// query already failed, need not merge response
+		return
+	}
 
 	tsList := &protoCommonV1.TimeSeriesList{}
 	if err := tsList.Unmarshal(resp.Payload); err != nil {
 		ctx.err = err
 		return
 	}
+	if ctx.keepBlocks {
+		ctx.blocks = append(ctx.blocks, resp.Payload)
+	}
 
 	if len(tsList.FieldAggSpecs) == 0 {
 		// if it gets empty aggregator spec(empty response), need ignore response.
@@ -129,12 +155,22 @@ func (ctx *MetricContext) handleResponse(resp *protoCommonV1.TaskResponse, fromN
 				AggregatorSpecs[idx].AddFunctionType(function.FuncType(funcType))
 			}
 		}
-		ctx.groupAgg = newGroupingAgg(
-			timeutil.Interval(ctx.interval),
-			1, // interval ratio is 1 when do merge result.
-			ctx.timeRange,
-			AggregatorSpecs,
-		)
+		if ctx.memoryBudget > 0 || ctx.spillBufferedSeries {
+			ctx.groupAgg = newSpillableGroupingAgg(
+				timeutil.Interval(ctx.interval),
+				1, // interval ratio is 1 when do merge result.
+				ctx.timeRange,
+				AggregatorSpecs,
+				ctx.spillDir,
+			)
+		} else {
+			ctx.groupAgg = newGroupingAgg(
+				timeutil.Interval(ctx.interval),
+				1, // interval ratio is 1 when do merge result.
+				ctx.timeRange,
+				AggregatorSpecs,
+			)
+		}
 	}
 
 	for _, ts := range tsList.TimeSeriesList {
@@ -147,6 +183,54 @@ func (ctx *MetricContext) handleResponse(resp *protoCommonV1.TaskResponse, fromN
 			fields[field.Name(k)] = v
 		}
 		ctx.groupAgg.Aggregate(series.NewGroupedIterator(ts.Tags, fields))
+		if err := ctx.checkMemoryBudget(); err != nil {
+			ctx.err = err
+			return
+		}
+	}
+	if ctx.maxBufferedSeries > 0 && ctx.groupAgg.NumOfGroups() > ctx.maxBufferedSeries {
+		// grouping aggregator need all responses before emitting result, bound memory usage
+		if agg, ok := ctx.groupAgg.(aggregation.SpillableGroupingAggregator); ok && ctx.spillBufferedSeries {
+			if err := agg.Spill(); err != nil {
+				ctx.err = err
+			}
+			return
+		}
+		ctx.err = fmt.Errorf("%w, limit: %d, please add more filter conditions",
+			constants.ErrTooManyBufferedSeries, ctx.maxBufferedSeries)
+	}
+}
+
+// checkMemoryBudget spills the grouped series buffered in memory if memory budget exceeded,
+// returns error if spilling is disabled.
+func (ctx *MetricContext) checkMemoryBudget() error {
+	agg, ok := ctx.groupAgg.(aggregation.SpillableGroupingAggregator)
+	if !ok || ctx.memoryBudget <= 0 || agg.MemoryUsage() <= ctx.memoryBudget {
+		return nil
+	}
+	if ctx.spillDir == "" {
+		return fmt.Errorf("%w, budget: %s, please add more filter conditions or enable spilling",
+			constants.ErrMemoryBudgetExceeded, ltoml.Size(ctx.memoryBudget))
+	}
+	return agg.Spill()
+}
+
+// memoryStats returns the peak estimated memory and spilled bytes of grouped series.
+func (ctx *MetricContext) memoryStats() (peakMemory, spilledBytes int64) {
+	agg, ok := ctx.groupAgg.(aggregation.SpillableGroupingAggregator)
+	if !ok {
+		return 0, 0
+	}
+	return agg.PeakMemory(), agg.SpilledBytes()
+}
+
+// closeGroupAgg removes the temporary files which grouped series are spilled to.
+func (ctx *MetricContext) closeGroupAgg() {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if agg, ok := ctx.groupAgg.(aggregation.SpillableGroupingAggregator); ok {
+		_ = agg.Close()
 	}
 }
 
@@ -158,7 +242,7 @@ func (ctx *MetricContext) checkError(errMsg string) (ignoreResponse bool, err er
 		return false, nil
 	}
 	// real error
-	if !strings.Contains(errMsg, "not found") {
+	if !isNotFound(errMsg) {
 		goto ReturnError
 	}
 	ctx.tolerantNotFounds--
@@ -168,7 +252,14 @@ func (ctx *MetricContext) checkError(errMsg string) (ignoreResponse bool, err er
 	}
 	// fallthrough, all node returns not found errors
 ReturnError:
-	return true, errors.New(errMsg)
+	// converts to typed error, so that the error code of remote node is kept
+	return true, errcode.Parse(errMsg)
+}
+
+// isNotFound returns if the error of remote node means no data matched(e.g. metric/series not found),
+// other errors mean the node fails.
+func isNotFound(errMsg string) bool {
+	return errcode.CodeOf(errcode.Parse(errMsg)) == errcode.NotFound
 }
 
 // handleStats handles the node stats of query task.
@@ -176,6 +267,13 @@ func (ctx *MetricContext) handleStats(resp *protoCommonV1.TaskResponse, fromNode
 	if len(resp.Stats) == 0 {
 		return
 	}
+	nodeStats := &models.NodeStats{}
+	_ = encoding.JSONUnmarshal(resp.Stats, nodeStats)
+	ctx.mergeShardMaxTimestamps(nodeStats.ShardMaxTimestamps)
+	if !ctx.explain {
+		// only max data timestamp of shards reported for normal query
+		return
+	}
 	// if has query stats, need merge task query stats
 	if ctx.stats == nil {
 		ctx.stats = &models.NodeStats{}
@@ -184,10 +282,22 @@ func (ctx *MetricContext) handleStats(resp *protoCommonV1.TaskResponse, fromNode
 		ctx.stats.WaitEnd = time.Now().UnixNano()
 		ctx.stats.WaitStart = ctx.sendTime.UnixNano()
 		ctx.stats.WaitCost = ctx.stats.WaitEnd - ctx.stats.WaitStart
+		ctx.stats.QueriedShards = ctx.queriedShards
+		ctx.stats.PrunedShards = ctx.prunedShards
 	}
-	nodeStats := &models.NodeStats{}
-	_ = encoding.JSONUnmarshal(resp.Stats, nodeStats)
 	nodeStats.Node = fromNode
 	nodeStats.NetPayload = int64(len(resp.Stats) + len(resp.Payload))
 	ctx.stats.Children = append(ctx.stats.Children, nodeStats)
 }
+
+// mergeShardMaxTimestamps merges the max data timestamp of shards, keeps the bigger one.
+func (ctx *MetricContext) mergeShardMaxTimestamps(timestamps map[models.ShardID]int64) {
+	for shardID, timestamp := range timestamps {
+		if ctx.shardMaxTimestamps == nil {
+			ctx.shardMaxTimestamps = make(map[models.ShardID]int64)
+		}
+		if timestamp > ctx.shardMaxTimestamps[shardID] {
+			ctx.shardMaxTimestamps[shardID] = timestamp
+		}
+	}
+}
@@ -19,16 +19,19 @@ package context
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/aggregation"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/tracker"
 	"github.com/lindb/lindb/series/field"
@@ -93,6 +96,72 @@ func TestMetricContext_HandleResponse(t *testing.T) {
 			name: "handle task response with field data",
 			resp: &protoCommonV1.TaskResponse{Payload: payloadWithField, Stats: stats},
 		},
+		{
+			name: "merge shard max timestamps without explain",
+			prepare: func(metricCtx *MetricContext) {
+				metricCtx.HandleResponse(&protoCommonV1.TaskResponse{
+					Payload: emptyPayload,
+					Stats:   encoding.JSONMarshal(&models.NodeStats{ShardMaxTimestamps: map[models.ShardID]int64{1: 20, 2: 5}}),
+				}, "leaf-1")
+				metricCtx.HandleResponse(&protoCommonV1.TaskResponse{
+					Payload: emptyPayload,
+					Stats:   encoding.JSONMarshal(&models.NodeStats{ShardMaxTimestamps: map[models.ShardID]int64{1: 10, 2: 15}}),
+				}, "leaf-2")
+				assert.Nil(t, metricCtx.stats)
+				assert.Equal(t, map[models.ShardID]int64{1: 20, 2: 15}, metricCtx.shardMaxTimestamps)
+			},
+			resp: &protoCommonV1.TaskResponse{Payload: emptyPayload},
+		},
+		{
+			name: "too many grouped series buffered",
+			prepare: func(metricCtx *MetricContext) {
+				metricCtx.maxBufferedSeries = 1
+				payload, _ := (&protoCommonV1.TimeSeriesList{
+					FieldAggSpecs: []*protoCommonV1.AggregatorSpec{
+						{
+							FieldName:    "test",
+							FieldType:    uint32(field.Sum),
+							FuncTypeList: []uint32{uint32(field.Sum)},
+						},
+					},
+					TimeSeriesList: []*protoCommonV1.TimeSeries{
+						{Tags: "a", Fields: map[string][]byte{"test": nil}},
+						{Tags: "b", Fields: map[string][]byte{"test": nil}},
+					},
+				}).Marshal()
+				metricCtx.HandleResponse(&protoCommonV1.TaskResponse{Payload: payload}, "leaf")
+				assert.True(t, errors.Is(metricCtx.err, constants.ErrTooManyBufferedSeries))
+			},
+			resp:    &protoCommonV1.TaskResponse{Payload: payloadWithField},
+			wantErr: true,
+		},
+		{
+			name: "spill grouped series if too many buffered",
+			prepare: func(metricCtx *MetricContext) {
+				metricCtx.maxBufferedSeries = 1
+				metricCtx.spillBufferedSeries = true
+				metricCtx.spillDir = t.TempDir()
+				payload, _ := (&protoCommonV1.TimeSeriesList{
+					FieldAggSpecs: []*protoCommonV1.AggregatorSpec{
+						{
+							FieldName:    "test",
+							FieldType:    uint32(field.Sum),
+							FuncTypeList: []uint32{uint32(field.Sum)},
+						},
+					},
+					TimeSeriesList: []*protoCommonV1.TimeSeries{
+						{Tags: "a", Fields: map[string][]byte{"test": nil}},
+						{Tags: "b", Fields: map[string][]byte{"test": nil}},
+					},
+				}).Marshal()
+				metricCtx.HandleResponse(&protoCommonV1.TaskResponse{Payload: payload}, "leaf")
+				assert.NoError(t, metricCtx.err)
+				assert.Zero(t, metricCtx.groupAgg.NumOfGroups())
+				assert.Positive(t, metricCtx.groupAgg.(aggregation.SpillableGroupingAggregator).SpilledBytes())
+				metricCtx.closeGroupAgg()
+			},
+			resp: &protoCommonV1.TaskResponse{Payload: payloadWithField},
+		},
 	}
 
 	for _, tt := range cases {
@@ -164,6 +233,15 @@ func TestMetricContext_checkErr(t *testing.T) {
 				assert.Error(t, err)
 			},
 		},
+		{
+			name:   "keep error code of remote node",
+			errMsg: constants.ErrNodeBusy.Error() + ", admission queue is full",
+			assertFn: func(ignore bool, err error) {
+				assert.True(t, ignore)
+				assert.True(t, errors.Is(err, constants.ErrNodeBusy))
+				assert.Equal(t, errcode.NodeBusy, errcode.CodeOf(err))
+			},
+		},
 		{
 			name:   "ignore not found",
 			errMsg: "not found",
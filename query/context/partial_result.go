@@ -0,0 +1,125 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"sort"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/errcode"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/series/tag"
+)
+
+// recordFailure records the failure of node for partial results query,
+// returns the response without error and data, so that the results of other nodes are kept.
+func (ctx *RootMetricContext) recordFailure(resp *protoCommonV1.TaskResponse, fromNode string) *protoCommonV1.TaskResponse {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	failure := &models.PartialFailure{Node: fromNode, Error: resp.ErrMsg}
+	if target, ok := ctx.targets[fromNode]; ok {
+		failure.Shards = append(failure.Shards, target.ShardIDs...)
+	}
+	ctx.failures = append(ctx.failures, failure)
+	return &protoCommonV1.TaskResponse{
+		RequestID:   resp.RequestID,
+		RequestType: resp.RequestType,
+		Completed:   resp.Completed,
+		SendTime:    resp.SendTime,
+		Stats:       resp.Stats,
+	}
+}
+
+// checkPartial returns the error of first failed node if all nodes fail for partial results query.
+func (ctx *RootMetricContext) checkPartial() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if len(ctx.failures) > 0 && ctx.succeeded == 0 {
+		return errcode.Parse(ctx.failures[0].Error)
+	}
+	return nil
+}
+
+// partialFailures returns the failed nodes in order, nil if no node fails.
+func (ctx *RootMetricContext) partialFailures() []*models.PartialFailure {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if len(ctx.failures) == 0 {
+		return nil
+	}
+	failures := append([]*models.PartialFailure{}, ctx.failures...)
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].Node < failures[j].Node
+	})
+	return failures
+}
+
+// affectedGroups returns the func checking if the group may miss the data of failed shards, nil if no node fails.
+// The shard of group is known only if the query groups by all routing tags of database,
+// else all groups are affected.
+// NOTE: must match the sharding of write path(metric.BrokerBatchRows).
+func (ctx *RootMetricContext) affectedGroups() func(tags map[string]string) bool {
+	failures := ctx.partialFailures()
+	if len(failures) == 0 {
+		return nil
+	}
+	all := func(_ map[string]string) bool { return true }
+	cfg := ctx.databaseCfg
+	if cfg.Option == nil || len(cfg.Option.RoutingTags) == 0 || cfg.NumOfShard <= 0 {
+		return all
+	}
+	failedShards := make(map[models.ShardID]struct{})
+	for _, failure := range failures {
+		if len(failure.Shards) == 0 {
+			// shards of compute node are unknown
+			return all
+		}
+		for _, shardID := range failure.Shards {
+			if shardID.Int() >= cfg.NumOfShard {
+				// target shard of split holds the rows of any source shard
+				return all
+			}
+			failedShards[shardID] = struct{}{}
+		}
+	}
+	groupBy := make(map[string]struct{}, len(ctx.Deps.Statement.GroupBy))
+	for _, key := range ctx.Deps.Statement.GroupBy {
+		groupBy[key] = struct{}{}
+	}
+	routingTags := cfg.Option.RoutingTags
+	for _, key := range routingTags {
+		if _, ok := groupBy[key]; !ok {
+			return all
+		}
+	}
+	return func(tags map[string]string) bool {
+		kvs := make([]*protoMetricsV1.KeyValue, 0, len(routingTags))
+		for _, key := range routingTags {
+			kvs = append(kvs, &protoMetricsV1.KeyValue{Key: key, Value: tags[key]})
+		}
+		shardIdx := metric.ShardIndex(metric.RoutingHash(tag.KeyValues(kvs)), int32(cfg.NumOfShard))
+		_, ok := failedShards[models.ShardID(shardIdx)]
+		return ok
+	}
+}
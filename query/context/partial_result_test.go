@@ -0,0 +1,196 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/series/tag"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestRootMetricContext_Partial(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	transportMgr := rpc.NewMockTransportManager(ctrl)
+	newMetricCtx := func(partial bool) *RootMetricContext {
+		metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+			Ctx:           context.TODO(),
+			Database:      "test",
+			TransportMgr:  transportMgr,
+			Request:       &models.Request{RequestID: "req"},
+			Statement:     &stmt.Query{},
+			ReplicaPolicy: option.ReplicaPolicyLeaderOnly,
+			Partial:       partial,
+		})
+		metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+		physicalPlan := &models.PhysicalPlan{
+			Database: "test",
+			Targets: []*models.Target{
+				{Indicator: "1.1.1.2:9000", ShardIDs: []models.ShardID{3}},
+				{Indicator: "1.1.1.1:9000", ShardIDs: []models.ShardID{1, 2}},
+			},
+		}
+		metricCtx.addRequests(&protoCommonV1.TaskRequest{}, physicalPlan)
+		metricCtx.addTargets(physicalPlan)
+		return metricCtx
+	}
+
+	t.Run("all or nothing", func(t *testing.T) {
+		metricCtx := newMetricCtx(false)
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{ErrMsg: "err", Completed: true}, "1.1.1.1:9000")
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{Completed: true}, "1.1.1.2:9000")
+		rs, err := metricCtx.WaitResponse()
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+	t.Run("partial results", func(t *testing.T) {
+		metricCtx := newMetricCtx(true)
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{ErrMsg: "err", Completed: true}, "1.1.1.1:9000")
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{Completed: true}, "1.1.1.2:9000")
+		rs, err := metricCtx.WaitResponse()
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.True(t, resultSet.Partial)
+		assert.Equal(t, []*models.PartialFailure{
+			{Node: "1.1.1.1:9000", Shards: []models.ShardID{1, 2}, Error: "err"},
+		}, resultSet.Failures)
+	})
+	t.Run("not found isn't failure", func(t *testing.T) {
+		metricCtx := newMetricCtx(true)
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{ErrMsg: constants.ErrNotFound.Error(), Completed: true}, "1.1.1.1:9000")
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{Completed: true}, "1.1.1.2:9000")
+		rs, err := metricCtx.WaitResponse()
+		assert.NoError(t, err)
+		assert.False(t, rs.(*models.ResultSet).Partial)
+	})
+	t.Run("all nodes fail", func(t *testing.T) {
+		metricCtx := newMetricCtx(true)
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{ErrMsg: "err1", Completed: true}, "1.1.1.1:9000")
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{ErrMsg: "err2", Completed: true}, "1.1.1.2:9000")
+		rs, err := metricCtx.WaitResponse()
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+	t.Run("send request failure", func(t *testing.T) {
+		metricCtx := newMetricCtx(true)
+		transportMgr.EXPECT().SendRequest("1.1.1.1:9000", gomock.Any()).Return(fmt.Errorf("err"))
+		assert.NoError(t, metricCtx.SendRequest("1.1.1.1:9000", &protoCommonV1.TaskRequest{}))
+		metricCtx.HandleResponse(&protoCommonV1.TaskResponse{Completed: true}, "1.1.1.2:9000")
+		rs, err := metricCtx.WaitResponse()
+		assert.NoError(t, err)
+		assert.Len(t, rs.(*models.ResultSet).Failures, 1)
+	})
+}
+
+func TestRootMetricContext_affectedGroups(t *testing.T) {
+	shardOf := func(host string) models.ShardID {
+		kvs := tag.KeyValues{&protoMetricsV1.KeyValue{Key: "host", Value: host}}
+		return models.ShardID(metric.ShardIndex(metric.RoutingHash(kvs), 2))
+	}
+	// find two hosts located in different shards
+	host1, host2 := "host-0", ""
+	for i := 1; host2 == ""; i++ {
+		if host := fmt.Sprintf("host-%d", i); shardOf(host) != shardOf(host1) {
+			host2 = host
+		}
+	}
+	routingCfg := models.Database{NumOfShard: 2, Option: &option.DatabaseOption{RoutingTags: []string{"host"}}}
+
+	cases := []struct {
+		name     string
+		cfg      models.Database
+		groupBy  []string
+		failures []*models.PartialFailure
+		affected map[string]bool
+	}{
+		{
+			name:     "no failure",
+			cfg:      routingCfg,
+			groupBy:  []string{"host"},
+			affected: map[string]bool{host1: false, host2: false},
+		},
+		{
+			name:     "without routing tags",
+			cfg:      models.Database{NumOfShard: 2, Option: &option.DatabaseOption{}},
+			groupBy:  []string{"host"},
+			failures: []*models.PartialFailure{{Node: "1.1.1.1:9000", Shards: []models.ShardID{shardOf(host1)}}},
+			affected: map[string]bool{host1: true, host2: true},
+		},
+		{
+			name:     "not group by routing tags",
+			cfg:      routingCfg,
+			groupBy:  []string{"ip"},
+			failures: []*models.PartialFailure{{Node: "1.1.1.1:9000", Shards: []models.ShardID{shardOf(host1)}}},
+			affected: map[string]bool{host1: true, host2: true},
+		},
+		{
+			name:     "shards of failed node unknown",
+			cfg:      routingCfg,
+			groupBy:  []string{"host"},
+			failures: []*models.PartialFailure{{Node: "1.1.1.1:9000"}},
+			affected: map[string]bool{host1: true, host2: true},
+		},
+		{
+			name:     "target shard of split failed",
+			cfg:      routingCfg,
+			groupBy:  []string{"host"},
+			failures: []*models.PartialFailure{{Node: "1.1.1.1:9000", Shards: []models.ShardID{2}}},
+			affected: map[string]bool{host1: true, host2: true},
+		},
+		{
+			name:     "group by routing tags",
+			cfg:      routingCfg,
+			groupBy:  []string{"host", "ip"},
+			failures: []*models.PartialFailure{{Node: "1.1.1.1:9000", Shards: []models.ShardID{shardOf(host1)}}},
+			affected: map[string]bool{host1: true, host2: false},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+				Ctx:       context.TODO(),
+				Statement: &stmt.Query{GroupBy: tt.groupBy},
+			})
+			metricCtx.databaseCfg = tt.cfg
+			metricCtx.failures = tt.failures
+			isAffected := metricCtx.affectedGroups()
+			for host, affected := range tt.affected {
+				assert.Equal(t, affected, isAffected != nil && isAffected(map[string]string{"host": host, "ip": "1.1.1.1"}))
+			}
+		})
+	}
+}
@@ -25,25 +25,33 @@ import (
 	"time"
 
 	"github.com/lindb/lindb/aggregation"
-	"github.com/lindb/lindb/aggregation/function"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/collections"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/tracker"
 	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/tag"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
+// errStreamLimitReached stops evaluating groups of stream query if the limit of result reached.
+var errStreamLimitReached = errors.New("limit of stream result reached")
+
 var (
-	newExpressionFn    = aggregation.NewExpression
-	newGroupingAgg     = aggregation.NewGroupingAggregator
-	newResultLimiterFn = aggregation.NewResultLimiter
+	newExpressionFn         = aggregation.NewExpression
+	newGroupingAgg          = aggregation.NewGroupingAggregator
+	newSpillableGroupingAgg = aggregation.NewSpillableGroupingAggregator
+	newResultLimiterFn      = aggregation.NewResultLimiter
 )
 
 // RootMetricContextDeps represents root metric data search dependency.
@@ -55,6 +63,28 @@ type RootMetricContextDeps struct {
 	Statement    *stmt.Query
 	Choose       flow.NodeChoose
 	TransportMgr rpc.TransportManager
+	// Stream emits series one by one if set, else accumulates all series into result set.
+	Stream models.ResultStream
+	// MaxBufferedSeries limits the number of grouped series buffered for merging.
+	MaxBufferedSeries int
+	// MemoryBudget limits the estimated memory of grouped series buffered for merging, 0 means no limit.
+	MemoryBudget int64
+	// SpillDir is the directory which grouped series are spilled to if memory budget exceeded,
+	// empty means query fails if memory budget exceeded.
+	SpillDir string
+	// KeepBlocks keeps the time series blocks of responses for caching result if set.
+	KeepBlocks bool
+	// ReplicaPolicy controls how to fall back to follower replicas when leader fails or is slow, empty means failover.
+	ReplicaPolicy option.ReplicaPolicy
+	// HedgeTimeout is the duration of waiting leader before hedging request to follower for hedged policy.
+	HedgeTimeout time.Duration
+	// ReplicaLag provides the replication lag for choosing the most caught-up follower, nil means unknown.
+	ReplicaLag broker.ReplicaLagTracker
+	// Hedger decides the hedge delay based on recent leaf latencies and caps the hedge rate, nil means no limit.
+	Hedger *Hedger
+	// Partial keeps the results of other nodes if some nodes fail(after retrying replicas),
+	// the failed nodes/shards are reported in result set, else one failed node fails the whole query.
+	Partial bool
 }
 
 // RootMetricContext represents root metric data search context.
@@ -62,14 +92,85 @@ type RootMetricContext struct {
 	MetricContext
 
 	Deps *RootMetricContextDeps
+
+	// for retrying request on other replica when leaf node fails or is slow
+	payload      []byte                    // statement of request
+	targets      map[string]*models.Target // leaf node => target(shards)
+	queriedNodes map[string]struct{}       // nodes which request sent to
+	hedges       map[string]*hedgedRequest // original/hedged node => hedged request
+	sentAt       map[string]time.Time      // leaf node => time of sending request
+	staleShards  map[models.ShardID]int64  // shards queried on follower => recent seconds of data may be missing
+	scanBytes    int64                     // bytes of data returned by leaf nodes
+	replaying    bool                      // replays the cached blocks, no data scanned
+	hedgeTimer   *time.Timer
+
+	databaseCfg models.Database          // config of database for checking the groups affected by failed shards
+	failures    []*models.PartialFailure // failed nodes skipped by partial results query
+	succeeded   int                      // num. of responses without replica error
 }
 
 // NewRootMetricContext creates the root metric data search context.
 func NewRootMetricContext(deps *RootMetricContextDeps) *RootMetricContext {
-	return &RootMetricContext{
+	ctx := &RootMetricContext{
 		MetricContext: newMetricContext(deps.Ctx, deps.TransportMgr),
 		Deps:          deps,
+		targets:       make(map[string]*models.Target),
+		queriedNodes:  make(map[string]struct{}),
+		hedges:        make(map[string]*hedgedRequest),
+		sentAt:        make(map[string]time.Time),
+		staleShards:   make(map[models.ShardID]int64),
 	}
+	ctx.maxBufferedSeries = deps.MaxBufferedSeries
+	ctx.memoryBudget = deps.MemoryBudget
+	ctx.spillDir = deps.SpillDir
+	// stream query need not keep all series of result in memory, so spills grouped series if too many
+	ctx.spillBufferedSeries = deps.Stream != nil && deps.SpillDir != ""
+	ctx.keepBlocks = deps.KeepBlocks
+	ctx.explain = deps.Statement != nil && deps.Statement.Explain
+	return ctx
+}
+
+// Blocks returns the time series blocks of responses, only kept if KeepBlocks is set.
+func (ctx *RootMetricContext) Blocks() [][]byte {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.blocks
+}
+
+// ScanBytes returns the bytes of data returned by leaf nodes(responses of hedged loser are excluded).
+func (ctx *RootMetricContext) ScanBytes() int64 {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	return ctx.scanBytes
+}
+
+// MemoryUsage returns the estimated memory of grouped series buffered for merging(only tracked if memory budget set).
+func (ctx *RootMetricContext) MemoryUsage() int64 {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if agg, ok := ctx.groupAgg.(aggregation.SpillableGroupingAggregator); ok {
+		return agg.MemoryUsage()
+	}
+	return 0
+}
+
+// Replay replays the cached time series blocks as responses, then returns the result set.
+func (ctx *RootMetricContext) Replay(blocks [][]byte) (any, error) {
+	ctx.mutex.Lock()
+	ctx.expectResults = len(blocks)
+	ctx.replaying = true
+	ctx.mutex.Unlock()
+
+	if len(blocks) == 0 {
+		ctx.tryClose()
+	}
+	for _, block := range blocks {
+		ctx.HandleResponse(&protoCommonV1.TaskResponse{Payload: block, Completed: true}, ctx.Deps.CurrentNode.Indicator())
+	}
+	return ctx.WaitResponse()
 }
 
 // MakePlan makes the metric data physical plan.
@@ -94,15 +195,23 @@ func (ctx *RootMetricContext) MakePlan() error {
 		if !ok {
 			return constants.ErrDatabaseNotExist
 		}
-		calcTimeRangeAndInterval(ctx.Deps.Statement, databaseCfg)
+		if err := CalcTimeRangeAndInterval(ctx.Deps.Statement, databaseCfg); err != nil {
+			return err
+		}
+		ctx.databaseCfg = databaseCfg
+		ctx.pruneShardsByRouting(physicalPlans, ctx.Deps.Statement.Condition, databaseCfg)
+		ctx.splitSeriesLimit(physicalPlans, databaseCfg)
 	}
 	payload, _ := ctx.Deps.Statement.MarshalJSON()
+	ctx.payload = payload
 	for _, physicalPlan := range physicalPlans {
 		//FIXME:
 		physicalPlan.AddReceiver(ctx.Deps.CurrentNode.Indicator())
 		if err := physicalPlan.Validate(); err != nil {
 			return err
 		}
+		physicalPlan.Timeout = remainingTimeout(ctx.ctx)
+		physicalPlan.TraceContext = tracing.Inject(ctx.ctx)
 		ctx.addRequests(
 			&protoCommonV1.TaskRequest{
 				RequestID:    ctx.Deps.Request.RequestID,
@@ -110,22 +219,277 @@ func (ctx *RootMetricContext) MakePlan() error {
 				PhysicalPlan: encoding.JSONMarshal(physicalPlan),
 				Payload:      payload,
 			}, physicalPlan)
+		ctx.addTargets(physicalPlan)
+	}
+	if ctx.Deps.ReplicaPolicy == option.ReplicaPolicyHedged {
+		ctx.mutex.Lock()
+		ctx.hedgeTimer = time.AfterFunc(ctx.hedgeDelay(), ctx.hedge)
+		ctx.mutex.Unlock()
 	}
 	return nil
 }
 
+// HandleResponse handles metric data search task response,
+// retries the request on other replicas if leaf node fails(except leader-only policy).
+func (ctx *RootMetricContext) HandleResponse(resp *protoCommonV1.TaskResponse, fromNode string) {
+	accepted, firstResponse, loser := ctx.acceptResponse(resp, fromNode)
+	if loser != "" {
+		ctx.cancelRequest(loser)
+	}
+	if !accepted {
+		// the other side of hedged request wins or is still running
+		return
+	}
+	if firstResponse && ctx.canFailover(resp.ErrMsg) && ctx.retryOnReplicas(resp, fromNode) {
+		return
+	}
+	if ctx.Deps.Partial && isReplicaError(resp.ErrMsg) {
+		// keep the results of other nodes, report the failure of node in result set
+		resp = ctx.recordFailure(resp, fromNode)
+	}
+	ctx.MetricContext.HandleResponse(resp, fromNode)
+}
+
+// SendRequest sends the task request to target node,
+// retries the request on other replicas if sending fails(except leader-only policy).
+func (ctx *RootMetricContext) SendRequest(targetNodeID string, req *protoCommonV1.TaskRequest) error {
+	ctx.mutex.Lock()
+	if _, ok := ctx.targets[targetNodeID]; ok {
+		ctx.sentAt[targetNodeID] = time.Now()
+		if ctx.Deps.Hedger != nil {
+			ctx.Deps.Hedger.OnRequest()
+		}
+	}
+	ctx.mutex.Unlock()
+	err := ctx.MetricContext.SendRequest(targetNodeID, req)
+	if err != nil && ctx.Deps.Partial && isReplicaError(err.Error()) {
+		// handle as the failure response of node, retry on other replicas or report it in result set
+		ctx.HandleResponse(&protoCommonV1.TaskResponse{ErrMsg: err.Error(), Completed: true}, targetNodeID)
+		return nil
+	}
+	if err != nil && ctx.canFailover(err.Error()) &&
+		ctx.retryOnReplicas(&protoCommonV1.TaskResponse{ErrMsg: err.Error(), Completed: true}, targetNodeID) {
+		return nil
+	}
+	return err
+}
+
+// acceptResponse returns false if the response of node loses the race of hedged request,
+// else marks the node responded so that its request will not be hedged,
+// firstResponse returns true if it is the first response of node,
+// loser returns the node of hedged request which needs to be cancelled.
+func (ctx *RootMetricContext) acceptResponse(resp *protoCommonV1.TaskResponse,
+	fromNode string,
+) (accepted, firstResponse bool, loser string) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if hedged, ok := ctx.hedges[fromNode]; ok {
+		decided := hedged.winner == ""
+		accepted, loser = hedged.accept(fromNode, isReplicaError(resp.ErrMsg))
+		if !accepted {
+			return false, false, ""
+		}
+		if decided && fromNode == hedged.hedgedNode {
+			if ctx.Deps.Hedger != nil {
+				ctx.Deps.Hedger.OnWin()
+			}
+			for _, shardID := range hedged.shardIDs {
+				ctx.staleShards[shardID] = ctx.missingRecentSeconds(shardID, fromNode)
+			}
+		}
+	}
+	if !ctx.replaying {
+		ctx.scanBytes += int64(len(resp.Payload))
+	}
+	if !isReplicaError(resp.ErrMsg) {
+		ctx.succeeded++
+	}
+	firstResponse = ctx.state[fromNode] != models.Receive
+	if sentAt, ok := ctx.sentAt[fromNode]; ok && firstResponse && resp.ErrMsg == "" && ctx.Deps.Hedger != nil {
+		ctx.Deps.Hedger.Observe(time.Since(sentAt))
+	}
+	ctx.state[fromNode] = models.Receive
+	return true, firstResponse, loser
+}
+
+// canFailover returns if the request can be retried on other replicas based on replica policy and error.
+func (ctx *RootMetricContext) canFailover(errMsg string) bool {
+	return ctx.Deps.ReplicaPolicy != option.ReplicaPolicyLeaderOnly && isReplicaError(errMsg)
+}
+
+// isReplicaError returns if the error is caused by the replica,
+// not found/too many series errors are returned by all replicas, so need not retry.
+func isReplicaError(errMsg string) bool {
+	if errMsg == "" {
+		return false
+	}
+	switch errcode.CodeOf(errcode.Parse(errMsg)) {
+	case errcode.NotFound, errcode.SeriesLimitExceeded:
+		return false
+	default:
+		return true
+	}
+}
+
+// addTargets adds the targets of physical plan for retrying request.
+func (ctx *RootMetricContext) addTargets(physicalPlan *models.PhysicalPlan) {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	ctx.addTargetsLocked(physicalPlan)
+}
+
+// addTargetsLocked adds the targets of physical plan, must hold lock.
+func (ctx *RootMetricContext) addTargetsLocked(physicalPlan *models.PhysicalPlan) {
+	for _, target := range physicalPlan.Targets {
+		ctx.targets[target.Indicator] = target
+		ctx.queriedNodes[target.Indicator] = struct{}{}
+	}
+}
+
+// rankReplica ranks the replica by replication lag, the most caught-up replica is preferred.
+func (ctx *RootMetricContext) rankReplica(shardID models.ShardID, node string) int64 {
+	lag, ok := ctx.Deps.ReplicaLag.GetLag(ctx.Deps.Database, shardID, node)
+	if !ok {
+		return math.MaxInt64
+	}
+	return lag.Pending
+}
+
+// missingRecentSeconds returns the recent seconds of data may be missing on the replica, -1 means unknown.
+func (ctx *RootMetricContext) missingRecentSeconds(shardID models.ShardID, node string) int64 {
+	if ctx.Deps.ReplicaLag == nil {
+		return -1
+	}
+	lag, ok := ctx.Deps.ReplicaLag.GetLag(ctx.Deps.Database, shardID, node)
+	if !ok || lag.CaughtUpAt.IsZero() {
+		return -1
+	}
+	return int64(math.Ceil(time.Since(lag.CaughtUpAt).Seconds()))
+}
+
+// retryOnReplicas re-sends the request of failed leaf node to other replicas of its shards,
+// excludes all nodes which request sent to, avoid merging duplicate data.
+// Returns false if it cannot retry(intermediate node/no available replica/task completed).
+func (ctx *RootMetricContext) retryOnReplicas(resp *protoCommonV1.TaskResponse, fromNode string) bool {
+	stateMgr, ok := ctx.Deps.Choose.(broker.StateManager)
+	if !ok {
+		return false
+	}
+	ctx.mutex.Lock()
+	target, ok := ctx.targets[fromNode]
+	if !ok || len(target.ShardIDs) == 0 || ctx.err != nil || ctx.completed.Load() {
+		ctx.mutex.Unlock()
+		return false
+	}
+	replicas, err := stateMgr.GetAlternativeReplicas(ctx.Deps.Database, target.ShardIDs, ctx.queriedNodes, ctx.replicaRank())
+	if err != nil {
+		ctx.mutex.Unlock()
+		return false
+	}
+	for node, shardIDs := range replicas {
+		for _, shardID := range shardIDs {
+			ctx.staleShards[shardID] = ctx.missingRecentSeconds(shardID, node)
+		}
+	}
+	physicalPlan, req := ctx.newReplicaRequest(replicas)
+	// replace the response of failed node with the responses of other replicas
+	ctx.handleTaskState(resp, fromNode)
+	delete(ctx.targets, fromNode)
+	ctx.expectResults--
+	ctx.tolerantNotFounds--
+	ctx.addTargetRequests(req, physicalPlan)
+	ctx.addTargetsLocked(physicalPlan)
+	ctx.mutex.Unlock()
+
+	for _, t := range physicalPlan.Targets {
+		if err := ctx.SendRequest(t.Indicator, req); err != nil {
+			ctx.Complete(err)
+			break
+		}
+	}
+	return true
+}
+
+// replicaRank returns the rank of replicas by replication lag, nil if lag is unknown.
+func (ctx *RootMetricContext) replicaRank() broker.ReplicaRank {
+	if ctx.Deps.ReplicaLag == nil {
+		return nil
+	}
+	return ctx.rankReplica
+}
+
+// newReplicaRequest builds the request of querying shards on other replicas.
+func (ctx *RootMetricContext) newReplicaRequest(
+	replicas map[string][]models.ShardID,
+) (*models.PhysicalPlan, *protoCommonV1.TaskRequest) {
+	physicalPlan := &models.PhysicalPlan{
+		Database: ctx.Deps.Database,
+		Timeout:  remainingTimeout(ctx.ctx),
+	}
+	for node, shardIDs := range replicas {
+		physicalPlan.AddTarget(&models.Target{
+			Indicator: node,
+			ShardIDs:  shardIDs,
+			MaxSeries: ctx.maxSeriesPerShard * len(shardIDs),
+		})
+	}
+	physicalPlan.AddReceiver(ctx.Deps.CurrentNode.Indicator())
+	physicalPlan.TraceContext = tracing.Inject(ctx.ctx)
+	return physicalPlan, &protoCommonV1.TaskRequest{
+		RequestID:    ctx.Deps.Request.RequestID,
+		RequestType:  protoCommonV1.RequestType_Data,
+		PhysicalPlan: encoding.JSONMarshal(physicalPlan),
+		Payload:      ctx.payload,
+	}
+}
+
+// staleResult returns the shards queried on follower replicas, nil if all shards queried on leader.
+func (ctx *RootMetricContext) staleResult() *models.StaleResult {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	var stale *models.StaleResult
+	for shardID, missingRecentSeconds := range ctx.staleShards {
+		stale = stale.Merge(&models.StaleResult{Shards: []models.ShardID{shardID}, MissingRecentSeconds: missingRecentSeconds})
+	}
+	if stale != nil {
+		sort.Slice(stale.Shards, func(i, j int) bool {
+			return stale.Shards[i] < stale.Shards[j]
+		})
+	}
+	return stale
+}
+
 // WaitResponse waits metric data search task completed, then returns the result set,
 func (ctx *RootMetricContext) WaitResponse() (any, error) {
 	err := ctx.waitResponse()
+	ctx.mutex.Lock()
+	if ctx.hedgeTimer != nil {
+		ctx.hedgeTimer.Stop()
+	}
+	ctx.mutex.Unlock()
+	// remove the spilled files after result set is built
+	defer ctx.closeGroupAgg()
 	if err != nil {
 		return nil, err
 	}
+	if err := ctx.checkPartial(); err != nil {
+		return nil, err
+	}
 
 	return ctx.makeResultSet()
 }
 
 // makeResultSet makes final result set from time series event(GroupedIterators).
-// TODO: can opt use stream, leaf node need return grouping if completed.
+// If stream is set, each series is emitted after it is built and not kept in result set,
+// the returned result set only includes the metadata/stats as trailer.
+//
+// NOTICE: grouping aggregator needs all responses of leaf/intermediate nodes before emitting, so that
+// the grouped series are buffered until all responses received, stream query spills them to disk if
+// max buffered series exceeded(spill dir required), else query fails. Without order by, stream query
+// emits each group once it is merged, order by(top n) still keeps offset+limit rows before emitting.
 func (ctx *RootMetricContext) makeResultSet() (resultSet *models.ResultSet, err error) {
 	makeResultStartTime := time.Now()
 	orderBy, err := ctx.buildOrderBy()
@@ -139,33 +503,46 @@ func (ctx *RootMetricContext) makeResultSet() (resultSet *models.ResultSet, err
 	groupByKeys := statement.GroupBy
 	groupByKeysLength := len(groupByKeys)
 	fieldsMap := make(map[string]struct{})
+	quantileOverflows := make(map[string]struct{})
 	timeRange := ctx.timeRange
 	interval := ctx.interval
+	boundaries, lastBucketEnd, err := ctx.zoneBoundaries()
+	if err != nil {
+		return nil, err
+	}
+	if len(boundaries) > 0 {
+		// evaluate expression on the buckets of time zone, time slot is the index of bucket
+		timeRange = timeutil.TimeRange{Start: boundaries[0], End: boundaries[len(boundaries)-1]}
+		interval = statement.ZoneInterval.Int64()
+	}
+	// slotTimestamp returns the timestamp of time slot in result, aligned by the start/end/middle of bucket.
+	slotTimestamp := func(slot int) int64 {
+		if len(boundaries) > 0 {
+			bucketEnd := lastBucketEnd
+			if slot+1 < len(boundaries) {
+				bucketEnd = boundaries[slot+1]
+			}
+			return statement.Align.Timestamp(boundaries[slot], bucketEnd)
+		}
+		bucketStart := timeutil.CalcTimestamp(timeRange.Start, slot, timeutil.Interval(interval))
+		return statement.Align.Timestamp(bucketStart, bucketStart+interval)
+	}
 	if ctx.groupAgg != nil {
-		groupIts := ctx.groupAgg.ResultSet()
-		for _, it := range groupIts {
-			// TODO: reuse expression??
-			expression := newExpressionFn(
-				timeRange,
-				interval,
-				statement.SelectItems,
-			)
-			// do expression eval
-			expression.Eval(it)
-
-			// result order by/limit
-			orderBy.Push(aggregation.NewOrderByRow(it.Tags(), expression.ResultSet()))
+		// fill the missing slots of group by time query after down sampling
+		slots := len(boundaries)
+		if slots == 0 && interval > 0 {
+			slots = int((timeRange.End-timeRange.Start)/interval) + 1
 		}
-
-		rows := orderBy.ResultSet()
-		for _, row := range rows {
+		gapFiller := aggregation.NewGapFiller(statement.Fill, statement.FillValue, slots, statement.SelectItems)
+		isAffected := ctx.affectedGroups()
+		// emitRow builds the series of result row, then emits it to stream or adds it into result set.
+		emitRow := func(tagValues string, fields map[string]*collections.FloatArray) error {
 			var tags map[string]string
-			tagValues, fields := row.ResultSet()
 			if groupByKeysLength > 0 {
 				tagValues := tag.SplitTagValues(tagValues)
 				if groupByKeysLength != len(tagValues) {
 					// if tag values not match group by tag keys, ignore this time series
-					continue
+					return nil
 				}
 				// build group by tags for final result
 				tags = make(map[string]string)
@@ -174,7 +551,7 @@ func (ctx *RootMetricContext) makeResultSet() (resultSet *models.ResultSet, err
 				}
 			}
 			timeSeries := models.NewSeries(tags, tagValues)
-			resultSet.AddSeries(timeSeries)
+			timeSeries.Partial = isAffected != nil && isAffected(tags)
 			for fieldName, values := range fields {
 				if values == nil {
 					continue
@@ -188,32 +565,138 @@ func (ctx *RootMetricContext) makeResultSet() (resultSet *models.ResultSet, err
 						// TODO: need check
 						continue
 					}
-					points.AddPoint(timeutil.CalcTimestamp(timeRange.Start, slot, timeutil.Interval(interval)), val)
+					points.AddPoint(slotTimestamp(slot), val)
 				}
 				timeSeries.AddField(fieldName, points)
 				fieldsMap[fieldName] = struct{}{}
 			}
+			if ctx.Deps.Stream != nil {
+				// emit series directly, need not keep it in memory
+				if err := ctx.Deps.Stream.WriteSeries(timeSeries); err != nil {
+					return err
+				}
+				resultSet.IncStreamedSeries()
+				return nil
+			}
+			resultSet.AddSeries(timeSeries)
+			return nil
 		}
-	}
+		// stream query without order by emits each group as soon as it completes(groups are evaluated
+		// in order of tag values), so that the rows are not buffered until all groups evaluated.
+		streamGroups := ctx.Deps.Stream != nil && len(statement.OrderByItems) == 0
+		evaluated, emitted := 0, 0
+		evalGroup := func(it series.GroupedIterator) error {
+			if streamGroups && emitted >= statement.Limit {
+				return errStreamLimitReached
+			}
+			if len(boundaries) > 0 {
+				it = aggregation.NewBucketGroupedIterator(it, ctx.interval, boundaries)
+			}
+			// TODO: reuse expression??
+			expression := newExpressionFn(
+				timeRange,
+				interval,
+				statement.SelectItems,
+			)
+			// do expression eval
+			expression.Eval(it)
+			fields := expression.ResultSet()
+			gapFiller.Fill(fields)
+			for _, fieldName := range expression.QuantileOverflows() {
+				quantileOverflows[fieldName] = struct{}{}
+			}
 
-	sort.Slice(resultSet.Series, func(i, j int) bool {
-		return resultSet.Series[i].TagValues < resultSet.Series[j].TagValues
-	})
+			if streamGroups {
+				// result offset/limit
+				evaluated++
+				if evaluated <= statement.Offset {
+					return nil
+				}
+				emitted++
+				return emitRow(it.Tags(), fields)
+			}
+			// result order by/limit
+			orderBy.Push(aggregation.NewOrderByRow(it.Tags(), fields))
+			return nil
+		}
+		if spillableAgg, ok := ctx.groupAgg.(aggregation.SpillableGroupingAggregator); ok {
+			// merge the grouped series in memory and spilled files one by one in order of tag values
+			if err := spillableAgg.ForEach(evalGroup); err != nil && !errors.Is(err, errStreamLimitReached) {
+				return nil, err
+			}
+		} else {
+			groupIts := ctx.groupAgg.ResultSet()
+			if len(statement.OrderByItems) == 0 {
+				// limit/offset without order by, make sure the result is deterministic by tag values
+				sort.SliceStable(groupIts, func(i, j int) bool {
+					return groupIts[i].Tags() < groupIts[j].Tags()
+				})
+			}
+			for _, it := range groupIts {
+				if err := evalGroup(it); err != nil {
+					if errors.Is(err, errStreamLimitReached) {
+						break
+					}
+					return nil, err
+				}
+			}
+		}
+
+		for _, row := range ctx.sortRows(orderBy.ResultSet()) {
+			if err := emitRow(row.tags, row.fields); err != nil {
+				return nil, err
+			}
+		}
+	}
 
 	resultSet.MetricName = statement.MetricName
 	resultSet.GroupBy = statement.GroupBy
-	for fName := range fieldsMap {
-		resultSet.Fields = append(resultSet.Fields, fName)
-	}
+	resultSet.Fields = selectFieldNames(statement.SelectItems, fieldsMap)
 	resultSet.StartTime = timeRange.Start
 	resultSet.EndTime = timeRange.End
+	if statement.Align != stmt.AlignStart && interval > 0 {
+		// keep time range consistent with the timestamps of points
+		lastSlot := int((timeRange.End - timeRange.Start) / interval)
+		if len(boundaries) > 0 {
+			lastSlot = len(boundaries) - 1
+		}
+		resultSet.StartTime = slotTimestamp(0)
+		resultSet.EndTime = slotTimestamp(lastSlot)
+	}
 	resultSet.Interval = interval
+	resultSet.Stale = ctx.staleResult()
+	resultSet.Failures = ctx.partialFailures()
+	resultSet.Partial = len(resultSet.Failures) > 0
+	for fieldName := range quantileOverflows {
+		resultSet.QuantileOverflows = append(resultSet.QuantileOverflows, fieldName)
+	}
+	sort.Strings(resultSet.QuantileOverflows)
 
+	peakMemory, spilledBytes := ctx.memoryStats()
+	if ctx.stats == nil && (spilledBytes > 0 || len(ctx.shardMaxTimestamps) > 0) {
+		// report spilling, so that users can tune the memory budget or filter conditions of query,
+		// report max data timestamp of shards, so that clients can detect staleness.
+		resultSet.Stats = &models.NodeStats{
+			Node:               ctx.Deps.CurrentNode.Indicator(),
+			ShardMaxTimestamps: ctx.shardMaxTimestamps,
+		}
+		if spilledBytes > 0 {
+			resultSet.Stats.PeakMemory = peakMemory
+			resultSet.Stats.SpilledBytes = spilledBytes
+		}
+	}
 	if ctx.stats != nil {
 		now := time.Now()
 		ctx.stats.Node = ctx.Deps.CurrentNode.Indicator()
+		ctx.stats.PeakMemory = peakMemory
+		ctx.stats.SpilledBytes = spilledBytes
+		ctx.stats.ShardMaxTimestamps = ctx.shardMaxTimestamps
 		ctx.stats.End = now.UnixNano()
 		ctx.stats.TotalCost = now.Sub(ctx.startTime).Nanoseconds()
+		ctx.stats.TimeZone = time.UTC.String()
+		if statement.TimeZone != "" {
+			ctx.stats.TimeZone = statement.TimeZone
+		}
 
 		ctx.stats.Stages = append(ctx.stats.Stages, &models.StageStats{
 			Identifier: "Expression",
@@ -228,6 +711,60 @@ func (ctx *RootMetricContext) makeResultSet() (resultSet *models.ResultSet, err
 	return resultSet, nil
 }
 
+// selectFieldNames returns the field names of result set in the order of select list.
+func selectFieldNames(selectItems []stmt.Expr, fieldsMap map[string]struct{}) (names []string) {
+	for _, item := range selectItems {
+		name := item.Rewrite()
+		if selectItem, ok := item.(*stmt.SelectItem); ok && selectItem.Alias != "" {
+			name = selectItem.Alias
+		}
+		if _, ok := fieldsMap[name]; ok {
+			names = append(names, name)
+			// skip duplicate select item
+			delete(fieldsMap, name)
+		}
+	}
+	return names
+}
+
+// zoneBoundaries returns the start times of time zone buckets and the end time of last bucket,
+// nil if query not in time zone.
+func (ctx *RootMetricContext) zoneBoundaries() (boundaries []int64, lastBucketEnd int64, err error) {
+	statement := ctx.Deps.Statement
+	if statement.TimeZone == "" || statement.ZoneInterval <= 0 {
+		return nil, 0, nil
+	}
+	buckets, err := timeutil.NewZoneBuckets(statement.TimeZone, statement.ZoneInterval)
+	if err != nil {
+		return nil, 0, err
+	}
+	boundaries = buckets.Boundaries(statement.TimeRange)
+	if len(boundaries) > 0 {
+		lastBucketEnd = buckets.Next(boundaries[len(boundaries)-1])
+	}
+	return boundaries, lastBucketEnd, nil
+}
+
+// resultRow represents the row of result set before building series.
+type resultRow struct {
+	tags   string
+	fields map[string]*collections.FloatArray
+}
+
+// sortRows returns result rows in order by tag values,
+// so that the series are always emitted in deterministic order.
+func (ctx *RootMetricContext) sortRows(rows []aggregation.Row) []resultRow {
+	resultRows := make([]resultRow, len(rows))
+	for idx, row := range rows {
+		tags, fields := row.ResultSet()
+		resultRows[idx] = resultRow{tags: tags, fields: fields}
+	}
+	sort.SliceStable(resultRows, func(i, j int) bool {
+		return resultRows[i].tags < resultRows[j].tags
+	})
+	return resultRows
+}
+
 // buildOrderBy builds order by container.
 func (ctx *RootMetricContext) buildOrderBy() (aggregation.OrderBy, error) {
 	statement := ctx.Deps.Statement
@@ -235,34 +772,17 @@ func (ctx *RootMetricContext) buildOrderBy() (aggregation.OrderBy, error) {
 	orderByExprs := statement.OrderByItems
 	if len(orderByExprs) == 0 {
 		// use default limiter
-		return newResultLimiterFn(statement.Limit), nil
-	}
-	var orderByItems []*aggregation.OrderByItem
-	fields := ctx.aggregatorSpecs
-	for _, orderBy := range orderByExprs {
-		expr := orderBy.(*stmt.OrderByExpr)
-		funcType := function.Unknown
-		var fieldName string
-		switch e := expr.Expr.(type) {
-		case *stmt.FieldExpr:
-			aggSpec, ok := fields[e.Name]
-			if ok {
-				funcType = field.Type(aggSpec.FieldType).GetOrderByFunc()
-				fieldName = e.Name
-			}
-		case *stmt.CallExpr:
-			funcType = e.FuncType
-			fieldName = e.Params[0].Rewrite()
-		}
-		if funcType == function.Unknown {
-			return nil, errors.New("cannot parse order by function")
+		return newResultLimiterFn(statement.Offset, statement.Limit), nil
+	}
+	orderByItems, err := newOrderByItems(orderByExprs, func(fieldName string) (field.Type, bool) {
+		aggSpec, ok := ctx.aggregatorSpecs[fieldName]
+		if !ok {
+			return field.Unknown, false
 		}
-		orderByItems = append(orderByItems, &aggregation.OrderByItem{
-			Expr:     expr,
-			Name:     fieldName,
-			FuncType: funcType,
-			Desc:     expr.Desc,
-		})
+		return field.Type(aggSpec.FieldType), true
+	})
+	if err != nil {
+		return nil, err
 	}
-	return aggregation.NewTopNOrderBy(orderByItems, statement.Limit), nil
+	return aggregation.NewTopNOrderBy(orderByItems, statement.Offset, statement.Limit), nil
 }
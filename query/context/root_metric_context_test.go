@@ -21,7 +21,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -30,11 +33,14 @@ import (
 	"github.com/lindb/lindb/aggregation/function"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/collections"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/sql/stmt"
@@ -65,6 +71,17 @@ func TestRootMetricContext_WaitResponse(t *testing.T) {
 		assert.NotNil(t, resp)
 		assert.NoError(t, err)
 	})
+	t.Run("killed by admin", func(t *testing.T) {
+		metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+			Ctx:       context.TODO(),
+			Statement: &stmt.Query{},
+		})
+		metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+		metricCtx.Kill()
+		resp, err := metricCtx.WaitResponse()
+		assert.Nil(t, resp)
+		assert.ErrorIs(t, err, constants.ErrQueryKilled)
+	})
 }
 
 func TestRootMetricDataContext_MakPlan(t *testing.T) {
@@ -159,10 +176,11 @@ func TestRootMetricDataContext_makeResultSet(t *testing.T) {
 	newExpressionFn = func(_ timeutil.TimeRange, _ int64, _ []stmt.Expr) aggregation.Expression {
 		return expr
 	}
-	newResultLimiterFn = func(_ int) aggregation.OrderBy {
+	newResultLimiterFn = func(_, _ int) aggregation.OrderBy {
 		return orderBy
 	}
 	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	stream := &fakeResultStream{}
 
 	cases := []struct {
 		name    string
@@ -212,6 +230,7 @@ func TestRootMetricDataContext_makeResultSet(t *testing.T) {
 				expr.EXPECT().Eval(gomock.Any())
 				groupIt.EXPECT().Tags().Return("tags")
 				expr.EXPECT().ResultSet().Return(map[string]*collections.FloatArray{"f": collections.NewFloatArray(10)})
+				expr.EXPECT().QuantileOverflows().Return([]string{"f"})
 				orderBy.EXPECT().Push(gomock.Any())
 				row := aggregation.NewMockRow(ctrl)
 				row.EXPECT().ResultSet().Return("a,c", nil)                                        // group by not match
@@ -225,6 +244,75 @@ func TestRootMetricDataContext_makeResultSet(t *testing.T) {
 			assert: func(rs *models.ResultSet, err error) {
 				assert.NotNil(t, rs)
 				assert.NoError(t, err)
+				assert.Equal(t, []string{"f"}, rs.QuantileOverflows)
+			},
+		},
+		{
+			name: "build result set with stream",
+			prepare: func(ctx *RootMetricContext) {
+				ctx.Deps.Statement.GroupBy = []string{"a"}
+				ctx.Deps.Statement.SelectItems = []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}}
+				ctx.Deps.Stream = &fakeResultStream{}
+				ctx.groupAgg = groupAgg
+				groupAgg.EXPECT().ResultSet().Return(nil)
+				row := aggregation.NewMockRow(ctrl)
+				values := collections.NewFloatArray(10)
+				values.SetValue(0, 1.1)
+				row.EXPECT().ResultSet().Return("b", map[string]*collections.FloatArray{"f": values})
+				row2 := aggregation.NewMockRow(ctrl)
+				row2.EXPECT().ResultSet().Return("a", map[string]*collections.FloatArray{"f": values})
+				orderBy.EXPECT().ResultSet().Return([]aggregation.Row{row, row2})
+			},
+			assert: func(rs *models.ResultSet, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, rs.Series)
+				assert.Equal(t, []string{"f"}, rs.Fields)
+			},
+		},
+		{
+			name: "write series into stream failure",
+			prepare: func(ctx *RootMetricContext) {
+				ctx.Deps.Statement.GroupBy = []string{"a"}
+				ctx.Deps.Stream = &fakeResultStream{err: fmt.Errorf("err")}
+				ctx.groupAgg = groupAgg
+				groupAgg.EXPECT().ResultSet().Return(nil)
+				row := aggregation.NewMockRow(ctrl)
+				row.EXPECT().ResultSet().Return("b", nil)
+				orderBy.EXPECT().ResultSet().Return([]aggregation.Row{row})
+			},
+			assert: func(rs *models.ResultSet, err error) {
+				assert.Error(t, err)
+				assert.Nil(t, rs)
+			},
+		},
+		{
+			name: "stream groups once evaluated with offset/limit",
+			prepare: func(ctx *RootMetricContext) {
+				ctx.Deps.Statement.GroupBy = []string{"a"}
+				ctx.Deps.Statement.Offset = 1
+				ctx.Deps.Statement.Limit = 1
+				ctx.Deps.Stream = stream
+				ctx.groupAgg = groupAgg
+				var groupIts series.GroupedIterators
+				for _, tags := range []string{"c", "a", "b"} {
+					groupIt := series.NewMockGroupedIterator(ctrl)
+					groupIt.EXPECT().Tags().Return(tags).AnyTimes()
+					groupIts = append(groupIts, groupIt)
+				}
+				groupAgg.EXPECT().ResultSet().Return(groupIts)
+				values := collections.NewFloatArray(10)
+				values.SetValue(0, 1.1)
+				// group c is not evaluated after limit reached
+				expr.EXPECT().Eval(gomock.Any()).Times(2)
+				expr.EXPECT().ResultSet().Return(map[string]*collections.FloatArray{"f": values}).Times(2)
+				expr.EXPECT().QuantileOverflows().Return(nil).Times(2)
+				orderBy.EXPECT().ResultSet().Return(nil)
+			},
+			assert: func(rs *models.ResultSet, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, rs.Series)
+				assert.Len(t, stream.series, 1)
+				assert.Equal(t, map[string]string{"a": "b"}, stream.series[0].Tags)
 			},
 		},
 	}
@@ -251,3 +339,753 @@ func TestRootMetricDataContext_makeResultSet(t *testing.T) {
 		})
 	}
 }
+
+func TestRootMetricDataContext_selectFieldNames(t *testing.T) {
+	cases := []struct {
+		name        string
+		selectItems []stmt.Expr
+		fields      []string
+		expect      []string
+	}{
+		{
+			name:   "empty select items",
+			fields: []string{"f"},
+		},
+		{
+			name: "order by select items",
+			selectItems: []stmt.Expr{
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "b"}},
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "a"}},
+			},
+			fields: []string{"a", "b"},
+			expect: []string{"b", "a"},
+		},
+		{
+			name: "select item with alias",
+			selectItems: []stmt.Expr{
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}, Alias: "max_f"},
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}},
+			},
+			fields: []string{"f", "max_f"},
+			expect: []string{"max_f", "f"},
+		},
+		{
+			name: "duplicate select items",
+			selectItems: []stmt.Expr{
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}},
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}},
+			},
+			fields: []string{"f"},
+			expect: []string{"f"},
+		},
+		{
+			name: "field not in result",
+			selectItems: []stmt.Expr{
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "a"}},
+				&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "b"}},
+			},
+			fields: []string{"b"},
+			expect: []string{"b"},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fieldsMap := make(map[string]struct{})
+			for _, f := range tt.fields {
+				fieldsMap[f] = struct{}{}
+			}
+			assert.Equal(t, tt.expect, selectFieldNames(tt.selectItems, fieldsMap))
+		})
+	}
+}
+
+func TestRootMetricDataContext_makeResultSet_timeZone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	// 25 hours day of DST
+	day1 := time.Date(2023, 11, 5, 0, 0, 0, 0, newYork).UnixMilli()
+	day2 := time.Date(2023, 11, 6, 0, 0, 0, 0, newYork).UnixMilli()
+
+	primitiveIt := series.NewMockPrimitiveIterator(ctrl)
+	primitiveIt.EXPECT().AggType().Return(field.Sum).AnyTimes()
+	gomock.InOrder(
+		primitiveIt.EXPECT().HasNext().Return(true),
+		primitiveIt.EXPECT().Next().Return(0, 1.0),
+		primitiveIt.EXPECT().HasNext().Return(true),
+		primitiveIt.EXPECT().Next().Return(24, 2.0),
+		primitiveIt.EXPECT().HasNext().Return(true),
+		primitiveIt.EXPECT().Next().Return(25, 3.0),
+		primitiveIt.EXPECT().HasNext().Return(false),
+	)
+	fieldIt := series.NewMockFieldIterator(ctrl)
+	fieldIt.EXPECT().HasNext().Return(true)
+	fieldIt.EXPECT().Next().Return(primitiveIt)
+	fieldIt.EXPECT().HasNext().Return(false)
+	fieldSeries := series.NewMockIterator(ctrl)
+	fieldSeries.EXPECT().FieldName().Return(field.Name("f")).AnyTimes()
+	fieldSeries.EXPECT().FieldType().Return(field.SumField).AnyTimes()
+	fieldSeries.EXPECT().HasNext().Return(true)
+	fieldSeries.EXPECT().Next().Return(day1, fieldIt)
+	fieldSeries.EXPECT().HasNext().Return(false)
+	groupIt := series.NewMockGroupedIterator(ctrl)
+	groupIt.EXPECT().Tags().Return("").AnyTimes()
+	groupIt.EXPECT().HasNext().Return(true)
+	groupIt.EXPECT().Next().Return(fieldSeries)
+	groupIt.EXPECT().HasNext().Return(false)
+	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	groupAgg.EXPECT().ResultSet().Return(series.GroupedIterators{groupIt})
+
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:     context.TODO(),
+		Request: &models.Request{},
+		Statement: &stmt.Query{
+			SelectItems:  []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}},
+			TimeZone:     "America/New_York",
+			ZoneInterval: timeutil.Interval(timeutil.OneDay),
+			Interval:     timeutil.Interval(timeutil.OneHour),
+			TimeRange:    timeutil.TimeRange{Start: day1, End: day2 + 10*timeutil.OneHour},
+			Limit:        10,
+		},
+	})
+	metricCtx.stats = &models.NodeStats{}
+	metricCtx.groupAgg = groupAgg
+	metricCtx.interval = timeutil.OneHour
+	rs, err := metricCtx.makeResultSet()
+	assert.NoError(t, err)
+	assert.Equal(t, day1, rs.StartTime)
+	assert.Equal(t, day2, rs.EndTime)
+	assert.Equal(t, timeutil.OneDay, rs.Interval)
+	assert.Equal(t, "America/New_York", rs.Stats.TimeZone)
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, map[int64]float64{day1: 3, day2: 3}, rs.Series[0].Fields["f"])
+}
+
+func TestRootMetricDataContext_makeResultSet_fill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := timeutil.Now() / timeutil.OneHour * timeutil.OneHour
+	interval := timeutil.OneMinute
+	cases := []struct {
+		name      string
+		fill      stmt.FillType
+		fillValue float64
+		points    map[int64]float64
+	}{
+		{
+			name:   "no fill",
+			fill:   stmt.FillDrop,
+			points: map[int64]float64{start + interval: 1, start + 4*interval: 4},
+		},
+		{
+			name:   "fill null",
+			fill:   stmt.FillNull,
+			points: map[int64]float64{start + interval: 1, start + 4*interval: 4},
+		},
+		{
+			name: "fill previous",
+			fill: stmt.FillPrevious,
+			points: map[int64]float64{
+				start + interval: 1, start + 2*interval: 1, start + 3*interval: 1,
+				start + 4*interval: 4, start + 5*interval: 4,
+			},
+		},
+		{
+			name: "fill linear",
+			fill: stmt.FillLinear,
+			points: map[int64]float64{
+				start + interval: 1, start + 2*interval: 2, start + 3*interval: 3, start + 4*interval: 4,
+			},
+		},
+		{
+			name:      "fill value",
+			fill:      stmt.FillValue,
+			fillValue: 0,
+			points: map[int64]float64{
+				start: 0, start + interval: 1, start + 2*interval: 0, start + 3*interval: 0,
+				start + 4*interval: 4, start + 5*interval: 0,
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			primitiveIt := series.NewMockPrimitiveIterator(ctrl)
+			primitiveIt.EXPECT().AggType().Return(field.Sum).AnyTimes()
+			gomock.InOrder(
+				primitiveIt.EXPECT().HasNext().Return(true),
+				primitiveIt.EXPECT().Next().Return(1, 1.0),
+				primitiveIt.EXPECT().HasNext().Return(true),
+				primitiveIt.EXPECT().Next().Return(4, 4.0),
+				primitiveIt.EXPECT().HasNext().Return(false),
+			)
+			fieldIt := series.NewMockFieldIterator(ctrl)
+			fieldIt.EXPECT().HasNext().Return(true)
+			fieldIt.EXPECT().Next().Return(primitiveIt)
+			fieldIt.EXPECT().HasNext().Return(false)
+			fieldSeries := series.NewMockIterator(ctrl)
+			fieldSeries.EXPECT().FieldName().Return(field.Name("f")).AnyTimes()
+			fieldSeries.EXPECT().FieldType().Return(field.SumField).AnyTimes()
+			fieldSeries.EXPECT().HasNext().Return(true)
+			fieldSeries.EXPECT().Next().Return(start, fieldIt)
+			fieldSeries.EXPECT().HasNext().Return(false)
+			groupIt := series.NewMockGroupedIterator(ctrl)
+			groupIt.EXPECT().Tags().Return("").AnyTimes()
+			groupIt.EXPECT().HasNext().Return(true)
+			groupIt.EXPECT().Next().Return(fieldSeries)
+			groupIt.EXPECT().HasNext().Return(false)
+			groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+			groupAgg.EXPECT().ResultSet().Return(series.GroupedIterators{groupIt})
+
+			metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+				Ctx:     context.TODO(),
+				Request: &models.Request{},
+				Statement: &stmt.Query{
+					SelectItems: []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}},
+					Interval:    timeutil.Interval(interval),
+					Limit:       10,
+					Fill:        tt.fill,
+					FillValue:   tt.fillValue,
+				},
+			})
+			metricCtx.groupAgg = groupAgg
+			metricCtx.interval = interval
+			metricCtx.timeRange = timeutil.TimeRange{Start: start, End: start + 5*interval}
+			rs, err := metricCtx.makeResultSet()
+			assert.NoError(t, err)
+			assert.Len(t, rs.Series, 1)
+			assert.Equal(t, tt.points, rs.Series[0].Fields["f"])
+		})
+	}
+}
+
+func TestRootMetricDataContext_makeResultSet_align(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := timeutil.Now() / timeutil.OneHour * timeutil.OneHour
+	interval := timeutil.OneMinute
+	makeResultSet := func(align stmt.AlignType) *models.ResultSet {
+		primitiveIt := series.NewMockPrimitiveIterator(ctrl)
+		primitiveIt.EXPECT().AggType().Return(field.Sum).AnyTimes()
+		gomock.InOrder(
+			primitiveIt.EXPECT().HasNext().Return(true),
+			primitiveIt.EXPECT().Next().Return(1, 1.0),
+			primitiveIt.EXPECT().HasNext().Return(true),
+			primitiveIt.EXPECT().Next().Return(4, 4.0),
+			primitiveIt.EXPECT().HasNext().Return(false),
+		)
+		fieldIt := series.NewMockFieldIterator(ctrl)
+		fieldIt.EXPECT().HasNext().Return(true)
+		fieldIt.EXPECT().Next().Return(primitiveIt)
+		fieldIt.EXPECT().HasNext().Return(false)
+		fieldSeries := series.NewMockIterator(ctrl)
+		fieldSeries.EXPECT().FieldName().Return(field.Name("f")).AnyTimes()
+		fieldSeries.EXPECT().FieldType().Return(field.SumField).AnyTimes()
+		fieldSeries.EXPECT().HasNext().Return(true)
+		fieldSeries.EXPECT().Next().Return(start, fieldIt)
+		fieldSeries.EXPECT().HasNext().Return(false)
+		groupIt := series.NewMockGroupedIterator(ctrl)
+		groupIt.EXPECT().Tags().Return("").AnyTimes()
+		groupIt.EXPECT().HasNext().Return(true)
+		groupIt.EXPECT().Next().Return(fieldSeries)
+		groupIt.EXPECT().HasNext().Return(false)
+		groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+		groupAgg.EXPECT().ResultSet().Return(series.GroupedIterators{groupIt})
+
+		metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+			Ctx:     context.TODO(),
+			Request: &models.Request{},
+			Statement: &stmt.Query{
+				SelectItems: []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}},
+				Interval:    timeutil.Interval(interval),
+				Limit:       10,
+				Fill:        stmt.FillPrevious,
+				Align:       align,
+			},
+		})
+		metricCtx.groupAgg = groupAgg
+		metricCtx.interval = interval
+		metricCtx.timeRange = timeutil.TimeRange{Start: start, End: start + 5*interval}
+		rs, err := metricCtx.makeResultSet()
+		assert.NoError(t, err)
+		assert.Len(t, rs.Series, 1)
+		return rs
+	}
+	expect := makeResultSet(stmt.AlignStart)
+	assert.Equal(t, map[int64]float64{
+		start + interval: 1, start + 2*interval: 1, start + 3*interval: 1,
+		start + 4*interval: 4, start + 5*interval: 4,
+	}, expect.Series[0].Fields["f"])
+	for align, shift := range map[stmt.AlignType]int64{stmt.AlignEnd: interval, stmt.AlignMiddle: interval / 2} {
+		rs := makeResultSet(align)
+		// same values(include filled values) at shifted timestamps
+		points := make(map[int64]float64)
+		for timestamp, val := range expect.Series[0].Fields["f"] {
+			points[timestamp+shift] = val
+		}
+		assert.Equal(t, points, rs.Series[0].Fields["f"], align.String())
+		assert.Equal(t, expect.StartTime+shift, rs.StartTime, align.String())
+		assert.Equal(t, expect.EndTime+shift, rs.EndTime, align.String())
+		assert.Equal(t, expect.Interval, rs.Interval, align.String())
+	}
+}
+
+func TestRootMetricDataContext_makeResultSet_timeZone_align(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+	// 25 hours day of DST
+	day1 := time.Date(2023, 11, 5, 0, 0, 0, 0, newYork).UnixMilli()
+	day2 := time.Date(2023, 11, 6, 0, 0, 0, 0, newYork).UnixMilli()
+	day3 := time.Date(2023, 11, 7, 0, 0, 0, 0, newYork).UnixMilli()
+
+	primitiveIt := series.NewMockPrimitiveIterator(ctrl)
+	primitiveIt.EXPECT().AggType().Return(field.Sum).AnyTimes()
+	gomock.InOrder(
+		primitiveIt.EXPECT().HasNext().Return(true),
+		primitiveIt.EXPECT().Next().Return(0, 1.0),
+		primitiveIt.EXPECT().HasNext().Return(true),
+		primitiveIt.EXPECT().Next().Return(25, 3.0),
+		primitiveIt.EXPECT().HasNext().Return(false),
+	)
+	fieldIt := series.NewMockFieldIterator(ctrl)
+	fieldIt.EXPECT().HasNext().Return(true)
+	fieldIt.EXPECT().Next().Return(primitiveIt)
+	fieldIt.EXPECT().HasNext().Return(false)
+	fieldSeries := series.NewMockIterator(ctrl)
+	fieldSeries.EXPECT().FieldName().Return(field.Name("f")).AnyTimes()
+	fieldSeries.EXPECT().FieldType().Return(field.SumField).AnyTimes()
+	fieldSeries.EXPECT().HasNext().Return(true)
+	fieldSeries.EXPECT().Next().Return(day1, fieldIt)
+	fieldSeries.EXPECT().HasNext().Return(false)
+	groupIt := series.NewMockGroupedIterator(ctrl)
+	groupIt.EXPECT().Tags().Return("").AnyTimes()
+	groupIt.EXPECT().HasNext().Return(true)
+	groupIt.EXPECT().Next().Return(fieldSeries)
+	groupIt.EXPECT().HasNext().Return(false)
+	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	groupAgg.EXPECT().ResultSet().Return(series.GroupedIterators{groupIt})
+
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:     context.TODO(),
+		Request: &models.Request{},
+		Statement: &stmt.Query{
+			SelectItems:  []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}},
+			TimeZone:     "America/New_York",
+			ZoneInterval: timeutil.Interval(timeutil.OneDay),
+			Interval:     timeutil.Interval(timeutil.OneHour),
+			TimeRange:    timeutil.TimeRange{Start: day1, End: day2 + 10*timeutil.OneHour},
+			Limit:        10,
+			Align:        stmt.AlignEnd,
+		},
+	})
+	metricCtx.groupAgg = groupAgg
+	metricCtx.interval = timeutil.OneHour
+	rs, err := metricCtx.makeResultSet()
+	assert.NoError(t, err)
+	// end of bucket is the start of next bucket(25 hours for DST day)
+	assert.Equal(t, day2, rs.StartTime)
+	assert.Equal(t, day3, rs.EndTime)
+	assert.Len(t, rs.Series, 1)
+	assert.Equal(t, map[int64]float64{day2: 1, day3: 3}, rs.Series[0].Fields["f"])
+}
+
+func TestRootMetricDataContext_streamOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stream := &fakeResultStream{}
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:       context.TODO(),
+		Request:   &models.Request{},
+		Statement: &stmt.Query{},
+		Stream:    stream,
+	})
+	rows := []aggregation.Row{}
+	for _, tags := range []string{"c", "a", "b"} {
+		row := aggregation.NewMockRow(ctrl)
+		row.EXPECT().ResultSet().Return(tags, nil)
+		rows = append(rows, row)
+	}
+	resultRows := metricCtx.sortRows(rows)
+	assert.Equal(t, "a", resultRows[0].tags)
+	assert.Equal(t, "b", resultRows[1].tags)
+	assert.Equal(t, "c", resultRows[2].tags)
+}
+
+func TestRootMetricDataContext_limitOffset(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newExpressionFn = aggregation.NewExpression
+		ctrl.Finish()
+	}()
+	expr := aggregation.NewMockExpression(ctrl)
+	newExpressionFn = func(_ timeutil.TimeRange, _ int64, _ []stmt.Expr) aggregation.Expression {
+		return expr
+	}
+	values := collections.NewFloatArray(10)
+	values.SetValue(0, 1.1)
+	expr.EXPECT().Eval(gomock.Any()).AnyTimes()
+	expr.EXPECT().ResultSet().Return(map[string]*collections.FloatArray{"f": values}).AnyTimes()
+	expr.EXPECT().QuantileOverflows().Return(nil).AnyTimes()
+
+	groupAgg := aggregation.NewMockGroupingAggregator(ctrl)
+	var groupIts series.GroupedIterators
+	for _, tags := range []string{"d", "b", "a", "c"} {
+		groupIt := series.NewMockGroupedIterator(ctrl)
+		groupIt.EXPECT().Tags().Return(tags).AnyTimes()
+		groupIts = append(groupIts, groupIt)
+	}
+	groupAgg.EXPECT().ResultSet().Return(groupIts)
+
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:     context.TODO(),
+		Request: &models.Request{},
+		Statement: &stmt.Query{
+			GroupBy: []string{"host"},
+			Limit:   2,
+			Offset:  1,
+		},
+	})
+	metricCtx.groupAgg = groupAgg
+	rs, err := metricCtx.makeResultSet()
+	assert.NoError(t, err)
+	assert.Len(t, rs.Series, 2)
+	assert.Equal(t, "b", rs.Series[0].Tags["host"])
+	assert.Equal(t, "c", rs.Series[1].Tags["host"])
+}
+
+// fakeResultStream collects the series written into stream.
+type fakeResultStream struct {
+	series []*models.Series
+	err    error
+}
+
+func (s *fakeResultStream) WriteSeries(series *models.Series) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.series = append(s.series, series)
+	return nil
+}
+
+func TestRootMetricDataContext_Replay(t *testing.T) {
+	emptyPayload, _ := (&protoCommonV1.TimeSeriesList{}).Marshal()
+	newCtx := func() *RootMetricContext {
+		metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+			Ctx:         context.TODO(),
+			Request:     &models.Request{},
+			Statement:   &stmt.Query{},
+			CurrentNode: models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000},
+			KeepBlocks:  true,
+		})
+		metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+		return metricCtx
+	}
+	t.Run("replay empty blocks", func(t *testing.T) {
+		rs, err := newCtx().Replay(nil)
+		assert.NoError(t, err)
+		assert.NotNil(t, rs)
+	})
+	t.Run("replay blocks", func(t *testing.T) {
+		metricCtx := newCtx()
+		rs, err := metricCtx.Replay([][]byte{emptyPayload, emptyPayload})
+		assert.NoError(t, err)
+		assert.NotNil(t, rs)
+		assert.Equal(t, [][]byte{emptyPayload, emptyPayload}, metricCtx.Blocks())
+		assert.Zero(t, metricCtx.ScanBytes())
+	})
+	t.Run("replay bad block", func(t *testing.T) {
+		rs, err := newCtx().Replay([][]byte{[]byte("abc")})
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+}
+
+func TestRootMetricDataContext_MemoryBudget(t *testing.T) {
+	now, _ := timeutil.ParseTimestamp("20190702 19:10:00", "20060102 15:04:05")
+	familyTime, _ := timeutil.ParseTimestamp("20190702 19:00:00", "20060102 15:04:05")
+	interval := timeutil.Interval(10 * timeutil.OneSecond)
+	timeRange := timeutil.TimeRange{Start: now, End: now + timeutil.OneHour}
+	aggSpec := aggregation.NewAggregatorSpec("f", field.SumField)
+	aggSpec.AddFunctionType(function.Sum)
+	// builds the response of leaf node, tags => value of 19:10:10
+	newBlock := func(values map[string]float64) []byte {
+		tsList := &protoCommonV1.TimeSeriesList{
+			Start:    timeRange.Start,
+			End:      timeRange.End,
+			Interval: interval.Int64(),
+			FieldAggSpecs: []*protoCommonV1.AggregatorSpec{{
+				FieldName:    "f",
+				FieldType:    uint32(field.SumField),
+				FuncTypeList: []uint32{uint32(function.Sum)},
+			}},
+		}
+		for tags, value := range values {
+			seriesAgg := aggregation.NewSeriesAggregator(interval, 1, timeRange, aggSpec)
+			agg, ok := seriesAgg.GetAggregator(familyTime)
+			assert.True(t, ok)
+			agg.AggregateBySlot(61, value)
+			data, err := seriesAgg.ResultSet().MarshalBinary()
+			assert.NoError(t, err)
+			tsList.TimeSeriesList = append(tsList.TimeSeriesList, &protoCommonV1.TimeSeries{
+				Tags:   tags,
+				Fields: map[string][]byte{"f": data},
+			})
+		}
+		block, err := tsList.Marshal()
+		assert.NoError(t, err)
+		return block
+	}
+	blocks := [][]byte{newBlock(map[string]float64{"a": 1, "b": 2}), newBlock(map[string]float64{"a": 3})}
+	newCtx := func(spillDir string) *RootMetricContext {
+		metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+			Ctx:     context.TODO(),
+			Request: &models.Request{},
+			Statement: &stmt.Query{
+				SelectItems: []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}},
+				GroupBy:     []string{"host"},
+				TimeRange:   timeRange,
+				Interval:    interval,
+				Limit:       10,
+			},
+			CurrentNode:  models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000},
+			MemoryBudget: 1,
+			SpillDir:     spillDir,
+		})
+		metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+		return metricCtx
+	}
+	t.Run("spill grouped series if budget exceeded", func(t *testing.T) {
+		spillDir := t.TempDir()
+		rs, err := newCtx(spillDir).Replay(blocks)
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.Len(t, resultSet.Series, 2)
+		assert.Equal(t, map[string]string{"host": "a"}, resultSet.Series[0].Tags)
+		assert.Equal(t, map[int64]float64{now + 10*timeutil.OneSecond: 4}, resultSet.Series[0].Fields["f"])
+		assert.Equal(t, map[string]string{"host": "b"}, resultSet.Series[1].Tags)
+		assert.Equal(t, map[int64]float64{now + 10*timeutil.OneSecond: 2}, resultSet.Series[1].Fields["f"])
+		assert.True(t, resultSet.Stats.PeakMemory > 0)
+		assert.True(t, resultSet.Stats.SpilledBytes > 0)
+		// spilled files removed
+		files, err := os.ReadDir(spillDir)
+		assert.NoError(t, err)
+		assert.Empty(t, files)
+	})
+	t.Run("budget exceeded without spilling", func(t *testing.T) {
+		rs, err := newCtx("").Replay(blocks)
+		assert.ErrorIs(t, err, constants.ErrMemoryBudgetExceeded)
+		assert.Nil(t, rs)
+	})
+	t.Run("spill failure", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "file")
+		assert.NoError(t, os.WriteFile(file, []byte("abc"), 0o644))
+		rs, err := newCtx(file).Replay(blocks)
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+}
+
+func TestRootMetricDataContext_RetryOnReplicas(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	transportMgr := rpc.NewMockTransportManager(ctrl)
+	replicaLag := broker.NewMockReplicaLagTracker(ctrl)
+	busyResp := &protoCommonV1.TaskResponse{ErrMsg: "node busy, admission queue is full", Completed: true}
+
+	newMetricCtx := func(choose flow.NodeChoose, policy option.ReplicaPolicy, lag broker.ReplicaLagTracker) *RootMetricContext {
+		metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+			Ctx:           context.TODO(),
+			Database:      "test",
+			Choose:        choose,
+			TransportMgr:  transportMgr,
+			Request:       &models.Request{RequestID: "req"},
+			Statement:     &stmt.Query{},
+			ReplicaPolicy: policy,
+			ReplicaLag:    lag,
+		})
+		metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+		physicalPlan := &models.PhysicalPlan{
+			Database: "test",
+			Targets: []*models.Target{
+				{Indicator: "1.1.1.1:9000", ShardIDs: []models.ShardID{1, 2}},
+				{Indicator: "1.1.1.2:9000", ShardIDs: []models.ShardID{3}},
+			},
+		}
+		metricCtx.addRequests(&protoCommonV1.TaskRequest{}, physicalPlan)
+		metricCtx.addTargets(physicalPlan)
+		return metricCtx
+	}
+
+	cases := []struct {
+		name          string
+		choose        func() flow.NodeChoose
+		policy        option.ReplicaPolicy
+		lag           broker.ReplicaLagTracker
+		resp          *protoCommonV1.TaskResponse
+		prepare       func()
+		retried       bool
+		wantErr       bool
+		expectResults int
+		stale         map[models.ShardID]int64
+	}{
+		{
+			name: "not state manager",
+			choose: func() flow.NodeChoose {
+				return flow.NewMockNodeChoose(ctrl)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "leader only",
+			policy:  option.ReplicaPolicyLeaderOnly,
+			wantErr: true,
+		},
+		{
+			name:          "not found error need not retry",
+			resp:          &protoCommonV1.TaskResponse{ErrMsg: "metric not found", Completed: true},
+			expectResults: 1,
+		},
+		{
+			name: "no alternative replica",
+			prepare: func() {
+				stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1, 2}, gomock.Any(), gomock.Any()).
+					Return(nil, constants.ErrNoLiveReplica)
+			},
+			wantErr: true,
+		},
+		{
+			name: "send request failure",
+			prepare: func() {
+				stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1, 2}, gomock.Any(), gomock.Any()).
+					Return(map[string][]models.ShardID{"1.1.1.3:9000": {1, 2}}, nil)
+				transportMgr.EXPECT().SendRequest("1.1.1.3:9000", gomock.Any()).Return(fmt.Errorf("err"))
+				// fail over again, but no more replica
+				stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1, 2}, gomock.Any(), gomock.Any()).
+					Return(nil, constants.ErrNoLiveReplica)
+			},
+			retried: true,
+			wantErr: true,
+		},
+		{
+			name: "retry on other replica when node busy",
+			prepare: func() {
+				stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1, 2}, gomock.Any(), nil).
+					DoAndReturn(func(_ string, _ []models.ShardID,
+						excludeNodes map[string]struct{}, _ broker.ReplicaRank,
+					) (map[string][]models.ShardID, error) {
+						assert.Len(t, excludeNodes, 2)
+						return map[string][]models.ShardID{"1.1.1.3:9000": {1, 2}}, nil
+					})
+				transportMgr.EXPECT().SendRequest("1.1.1.3:9000", gomock.Any()).Return(nil)
+			},
+			retried:       true,
+			expectResults: 2,
+			stale:         map[models.ShardID]int64{1: -1, 2: -1},
+		},
+		{
+			name: "fail over to the most caught-up replica",
+			resp: &protoCommonV1.TaskResponse{ErrMsg: "err", Completed: true},
+			lag:  replicaLag,
+			prepare: func() {
+				replicaLag.EXPECT().GetLag("test", models.ShardID(1), "1.1.1.3:9000").
+					Return(broker.ReplicaLag{Pending: 10, CaughtUpAt: time.Now().Add(-1500 * time.Millisecond)}, true).AnyTimes()
+				replicaLag.EXPECT().GetLag("test", models.ShardID(2), "1.1.1.3:9000").
+					Return(broker.ReplicaLag{Pending: 10}, true).AnyTimes()
+				replicaLag.EXPECT().GetLag("test", models.ShardID(2), "1.1.1.4:9000").
+					Return(broker.ReplicaLag{}, false).AnyTimes()
+				stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1, 2}, gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ string, _ []models.ShardID,
+						_ map[string]struct{}, rank broker.ReplicaRank,
+					) (map[string][]models.ShardID, error) {
+						assert.Equal(t, int64(10), rank(1, "1.1.1.3:9000"))
+						assert.Equal(t, int64(math.MaxInt64), rank(2, "1.1.1.4:9000"))
+						return map[string][]models.ShardID{"1.1.1.3:9000": {1, 2}}, nil
+					})
+				transportMgr.EXPECT().SendRequest("1.1.1.3:9000", gomock.Any()).Return(nil)
+			},
+			retried:       true,
+			expectResults: 2,
+			stale:         map[models.ShardID]int64{1: 2, 2: -1},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var choose flow.NodeChoose = stateMgr
+			if tt.choose != nil {
+				choose = tt.choose()
+			}
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			resp := busyResp
+			if tt.resp != nil {
+				resp = tt.resp
+			}
+			metricCtx := newMetricCtx(choose, tt.policy, tt.lag)
+			metricCtx.HandleResponse(resp, "1.1.1.1:9000")
+			assert.Equal(t, tt.wantErr, metricCtx.err != nil)
+			if tt.retried {
+				assert.Contains(t, metricCtx.requests, "1.1.1.3:9000")
+				assert.NotContains(t, metricCtx.targets, "1.1.1.1:9000")
+				assert.Equal(t, models.Complete, metricCtx.state["1.1.1.1:9000"])
+			}
+			if !tt.wantErr {
+				assert.Equal(t, tt.expectResults, metricCtx.expectResults)
+				assert.False(t, metricCtx.completed.Load())
+			}
+			if tt.stale != nil {
+				assert.Equal(t, tt.stale, metricCtx.staleShards)
+			}
+		})
+	}
+}
+
+func TestRootMetricDataContext_SendRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	transportMgr := rpc.NewMockTransportManager(ctrl)
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:          context.TODO(),
+		Database:     "test",
+		Choose:       stateMgr,
+		TransportMgr: transportMgr,
+		Request:      &models.Request{RequestID: "req"},
+		Statement:    &stmt.Query{},
+	})
+	metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+	physicalPlan := &models.PhysicalPlan{
+		Database: "test",
+		Targets:  []*models.Target{{Indicator: "1.1.1.1:9000", ShardIDs: []models.ShardID{1}}},
+	}
+	metricCtx.addRequests(&protoCommonV1.TaskRequest{}, physicalPlan)
+	metricCtx.addTargets(physicalPlan)
+
+	// send to leader failure, fail over to follower
+	transportMgr.EXPECT().SendRequest("1.1.1.1:9000", gomock.Any()).Return(fmt.Errorf("err"))
+	stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1}, gomock.Any(), gomock.Any()).
+		Return(map[string][]models.ShardID{"1.1.1.2:9000": {1}}, nil)
+	transportMgr.EXPECT().SendRequest("1.1.1.2:9000", gomock.Any()).Return(nil)
+	assert.NoError(t, metricCtx.SendRequest("1.1.1.1:9000", &protoCommonV1.TaskRequest{}))
+	assert.Equal(t, 1, metricCtx.expectResults)
+	assert.Equal(t, &models.StaleResult{Shards: []models.ShardID{1}, MissingRecentSeconds: -1}, metricCtx.staleResult())
+
+	// no more replica
+	transportMgr.EXPECT().SendRequest("1.1.1.2:9000", gomock.Any()).Return(fmt.Errorf("err"))
+	stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1}, gomock.Any(), gomock.Any()).
+		Return(nil, constants.ErrNoLiveReplica)
+	assert.Error(t, metricCtx.SendRequest("1.1.1.2:9000", &protoCommonV1.TaskRequest{}))
+}
@@ -0,0 +1,152 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"sort"
+	"time"
+
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+)
+
+// hedgedRequest represents the request of slow leaf node duplicated to other replica,
+// the first response wins, the other one is cancelled.
+type hedgedRequest struct {
+	originalNode string
+	hedgedNode   string
+	shardIDs     []models.ShardID
+	winner       string // node which wins the race
+	failed       string // node which fails first
+}
+
+// accept returns if the response of node is accepted, and the node of request which needs to be cancelled.
+// The first failure waits for the response of the other side, unless both sides fail.
+func (r *hedgedRequest) accept(node string, failed bool) (accepted bool, loser string) {
+	switch {
+	case r.winner != "":
+		return r.winner == node, ""
+	case failed && r.failed == "":
+		r.failed = node
+		return false, ""
+	}
+	r.winner = node
+	if r.failed == "" {
+		// the other side is still running
+		loser = r.originalNode
+		if node == r.originalNode {
+			loser = r.hedgedNode
+		}
+	}
+	return true, loser
+}
+
+// hedgeDelay returns the duration of waiting leaf nodes before hedging request,
+// uses the percentile of recent leaf latencies if hedge timeout not set.
+func (ctx *RootMetricContext) hedgeDelay() time.Duration {
+	switch {
+	case ctx.Deps.HedgeTimeout > 0:
+		return ctx.Deps.HedgeTimeout
+	case ctx.Deps.Hedger != nil:
+		return ctx.Deps.Hedger.Delay()
+	default:
+		return defaultHedgeDelay
+	}
+}
+
+// hedge duplicates the requests of leaf nodes which have not responded in hedge delay to other replicas.
+func (ctx *RootMetricContext) hedge() {
+	stateMgr, ok := ctx.Deps.Choose.(broker.StateManager)
+	if !ok {
+		return
+	}
+	ctx.mutex.Lock()
+	var slowNodes []string
+	for node := range ctx.targets {
+		if ctx.state[node] == models.Send {
+			slowNodes = append(slowNodes, node)
+		}
+	}
+	ctx.mutex.Unlock()
+
+	sort.Strings(slowNodes)
+	for _, node := range slowNodes {
+		ctx.hedgeRequest(stateMgr, node)
+	}
+}
+
+// hedgeRequest duplicates the request of slow leaf node to the most caught-up replica which has all its shards,
+// skips if the shards are spread over multiple replicas or hedge rate exceeds the limit.
+func (ctx *RootMetricContext) hedgeRequest(stateMgr broker.StateManager, node string) {
+	ctx.mutex.Lock()
+	target, ok := ctx.targets[node]
+	if !ok || len(target.ShardIDs) == 0 || ctx.err != nil || ctx.completed.Load() ||
+		ctx.state[node] != models.Send || ctx.hedges[node] != nil {
+		ctx.mutex.Unlock()
+		return
+	}
+	replicas, err := stateMgr.GetAlternativeReplicas(ctx.Deps.Database, target.ShardIDs, ctx.queriedNodes, ctx.replicaRank())
+	if err != nil || len(replicas) != 1 {
+		ctx.mutex.Unlock()
+		return
+	}
+	if ctx.Deps.Hedger != nil && !ctx.Deps.Hedger.TryHedge() {
+		ctx.mutex.Unlock()
+		return
+	}
+	hedged := &hedgedRequest{originalNode: node, shardIDs: target.ShardIDs}
+	for replica := range replicas {
+		hedged.hedgedNode = replica
+	}
+	_, req := ctx.newReplicaRequest(replicas)
+	ctx.hedges[node] = hedged
+	ctx.hedges[hedged.hedgedNode] = hedged
+	ctx.requests[hedged.hedgedNode] = req
+	ctx.queriedNodes[hedged.hedgedNode] = struct{}{}
+	ctx.sentAt[hedged.hedgedNode] = time.Now()
+	ctx.mutex.Unlock()
+
+	if err := ctx.MetricContext.SendRequest(hedged.hedgedNode, req); err != nil {
+		// waits for the original request
+		ctx.mutex.Lock()
+		if hedged.winner == "" && hedged.failed == "" {
+			hedged.failed = hedged.hedgedNode
+		}
+		ctx.mutex.Unlock()
+	}
+}
+
+// cancelRequest cancels the running request of hedged request loser on leaf node,
+// cancel is best-effort, the late response of loser is ignored anyway.
+func (ctx *RootMetricContext) cancelRequest(node string) {
+	if ctx.Deps.Hedger != nil {
+		ctx.Deps.Hedger.OnWaste()
+	}
+	physicalPlan := &models.PhysicalPlan{
+		Database: ctx.Deps.Database,
+		Targets:  []*models.Target{{Indicator: node}},
+		Cancel:   true,
+	}
+	_ = ctx.transportMgr.SendRequest(node, &protoCommonV1.TaskRequest{
+		RequestID:    ctx.Deps.Request.RequestID,
+		RequestType:  protoCommonV1.RequestType_Data,
+		PhysicalPlan: encoding.JSONMarshal(physicalPlan),
+	})
+}
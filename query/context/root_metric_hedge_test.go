@@ -0,0 +1,259 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestHedgedRequest_accept(t *testing.T) {
+	cases := []struct {
+		name     string
+		prepare  func(r *hedgedRequest)
+		node     string
+		failed   bool
+		accepted bool
+		loser    string
+	}{
+		{
+			name:     "original wins",
+			node:     "original",
+			accepted: true,
+			loser:    "hedged",
+		},
+		{
+			name:     "hedged wins",
+			node:     "hedged",
+			accepted: true,
+			loser:    "original",
+		},
+		{
+			name: "loser ignored",
+			prepare: func(r *hedgedRequest) {
+				r.winner = "hedged"
+			},
+			node: "original",
+		},
+		{
+			name: "winner accepted",
+			prepare: func(r *hedgedRequest) {
+				r.winner = "hedged"
+			},
+			node:     "hedged",
+			accepted: true,
+		},
+		{
+			name:   "first failure waits for other side",
+			node:   "original",
+			failed: true,
+		},
+		{
+			name: "other side failed, nothing to cancel",
+			prepare: func(r *hedgedRequest) {
+				r.failed = "original"
+			},
+			node:     "hedged",
+			failed:   true,
+			accepted: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r := &hedgedRequest{originalNode: "original", hedgedNode: "hedged"}
+			if tt.prepare != nil {
+				tt.prepare(r)
+			}
+			accepted, loser := r.accept(tt.node, tt.failed)
+			assert.Equal(t, tt.accepted, accepted)
+			assert.Equal(t, tt.loser, loser)
+		})
+	}
+}
+
+func TestRootMetricDataContext_hedgeDelay(t *testing.T) {
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{Ctx: context.TODO()})
+	assert.Equal(t, defaultHedgeDelay, metricCtx.hedgeDelay())
+	metricCtx.Deps.Hedger = NewHedger(config.Hedge{Percentile: 50}, linmetric.BrokerRegistry)
+	for i := 0; i < minLatencySamples; i++ {
+		metricCtx.Deps.Hedger.Observe(time.Millisecond)
+	}
+	assert.Equal(t, time.Millisecond, metricCtx.hedgeDelay())
+	metricCtx.Deps.HedgeTimeout = time.Second * 3
+	assert.Equal(t, time.Second*3, metricCtx.hedgeDelay())
+}
+
+func TestRootMetricDataContext_Hedge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	transportMgr := rpc.NewMockTransportManager(ctrl)
+	hedger := NewHedger(config.Hedge{Percentile: 90, MaxRate: 1}, linmetric.BrokerRegistry)
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:           context.TODO(),
+		Database:      "test",
+		Choose:        stateMgr,
+		TransportMgr:  transportMgr,
+		Request:       &models.Request{RequestID: "req"},
+		Statement:     &stmt.Query{},
+		ReplicaPolicy: option.ReplicaPolicyHedged,
+		HedgeTimeout:  100 * time.Millisecond,
+		Hedger:        hedger,
+	})
+	metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+	stateMgr.EXPECT().Choose(gomock.Any(), gomock.Any()).Return([]*models.PhysicalPlan{{
+		Database: "test",
+		Targets: []*models.Target{
+			{Indicator: "1.1.1.1:9000", ShardIDs: []models.ShardID{1}},
+			{Indicator: "1.1.1.2:9000", ShardIDs: []models.ShardID{2}},
+			{Indicator: "1.1.1.3:9000", ShardIDs: []models.ShardID{3, 4}},
+			{Indicator: "1.1.1.5:9000", ShardIDs: []models.ShardID{5}},
+		},
+	}}, nil)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(models.Database{
+		Option: &option.DatabaseOption{Intervals: option.Intervals{{Interval: timeutil.Interval(timeutil.OneSecond)}}},
+	}, true)
+	done := make(chan struct{})
+	// slow node1 is hedged to node4
+	stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1}, gomock.Any(), gomock.Any()).
+		Return(map[string][]models.ShardID{"1.1.1.4:9000": {1}}, nil)
+	// shards of slow node3 spread over multiple replicas, keep waiting
+	stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{3, 4}, gomock.Any(), gomock.Any()).
+		Return(map[string][]models.ShardID{"1.1.1.4:9000": {3}, "1.1.1.6:9000": {4}}, nil)
+	// slow node5 has no alternative replica, keep waiting
+	stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{5}, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ string, _ []models.ShardID, _ map[string]struct{}, _ broker.ReplicaRank,
+		) (map[string][]models.ShardID, error) {
+			close(done)
+			return nil, constants.ErrNoLiveReplica
+		})
+	transportMgr.EXPECT().SendRequest("1.1.1.4:9000", gomock.Any()).Return(nil)
+	transportMgr.EXPECT().SendRequest(gomock.Any(), gomock.Any()).Return(nil).Times(4)
+
+	assert.NoError(t, metricCtx.MakePlan())
+	for _, node := range []string{"1.1.1.1:9000", "1.1.1.2:9000", "1.1.1.3:9000", "1.1.1.5:9000"} {
+		assert.NoError(t, metricCtx.SendRequest(node, &protoCommonV1.TaskRequest{}))
+	}
+	// node2 responded, need not hedge
+	accepted, first, loser := metricCtx.acceptResponse(&protoCommonV1.TaskResponse{Payload: []byte("abc")}, "1.1.1.2:9000")
+	assert.True(t, accepted)
+	assert.True(t, first)
+	assert.Empty(t, loser)
+	<-done
+
+	metricCtx.mutex.Lock()
+	assert.Equal(t, 4, metricCtx.expectResults)
+	assert.Contains(t, metricCtx.hedges, "1.1.1.1:9000")
+	assert.Contains(t, metricCtx.hedges, "1.1.1.4:9000")
+	assert.NotContains(t, metricCtx.hedges, "1.1.1.3:9000")
+	assert.Empty(t, metricCtx.staleShards)
+	metricCtx.mutex.Unlock()
+
+	// hedged node4 wins, cancel node1
+	transportMgr.EXPECT().SendRequest("1.1.1.1:9000", gomock.Any()).DoAndReturn(
+		func(_ string, req *protoCommonV1.TaskRequest) error {
+			physicalPlan := &models.PhysicalPlan{}
+			assert.NoError(t, encoding.JSONUnmarshal(req.PhysicalPlan, physicalPlan))
+			assert.True(t, physicalPlan.Cancel)
+			return fmt.Errorf("err")
+		})
+	accepted, first, loser = metricCtx.acceptResponse(&protoCommonV1.TaskResponse{Payload: []byte("ab")}, "1.1.1.4:9000")
+	assert.True(t, accepted)
+	assert.True(t, first)
+	assert.Equal(t, "1.1.1.1:9000", loser)
+	metricCtx.cancelRequest(loser)
+	assert.Equal(t, map[models.ShardID]int64{1: -1}, metricCtx.staleShards)
+
+	// late response of loser is ignored
+	metricCtx.HandleResponse(&protoCommonV1.TaskResponse{Completed: true, Payload: []byte("abcd")}, "1.1.1.1:9000")
+	metricCtx.mutex.Lock()
+	assert.Equal(t, 4, metricCtx.expectResults)
+	metricCtx.mutex.Unlock()
+	assert.Equal(t, int64(5), metricCtx.ScanBytes())
+	metricCtx.Complete(fmt.Errorf("err"))
+	_, err := metricCtx.WaitResponse()
+	assert.Error(t, err)
+}
+
+func TestRootMetricDataContext_hedgeRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	transportMgr := rpc.NewMockTransportManager(ctrl)
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:           context.TODO(),
+		Database:      "test",
+		Choose:        stateMgr,
+		TransportMgr:  transportMgr,
+		Request:       &models.Request{RequestID: "req"},
+		Statement:     &stmt.Query{},
+		ReplicaPolicy: option.ReplicaPolicyHedged,
+		Hedger:        NewHedger(config.Hedge{Percentile: 90, MaxRate: 1}, linmetric.BrokerRegistry),
+	})
+	metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+	physicalPlan := &models.PhysicalPlan{
+		Database: "test",
+		Targets:  []*models.Target{{Indicator: "1.1.1.1:9000", ShardIDs: []models.ShardID{1}}},
+	}
+	metricCtx.addRequests(&protoCommonV1.TaskRequest{}, physicalPlan)
+	metricCtx.addTargets(physicalPlan)
+	// not sent
+	metricCtx.hedgeRequest(stateMgr, "1.1.1.1:9000")
+
+	transportMgr.EXPECT().SendRequest("1.1.1.1:9000", gomock.Any()).Return(nil)
+	assert.NoError(t, metricCtx.SendRequest("1.1.1.1:9000", &protoCommonV1.TaskRequest{}))
+	// rate limited
+	metricCtx.Deps.Hedger.TryHedge()
+	stateMgr.EXPECT().GetAlternativeReplicas("test", []models.ShardID{1}, gomock.Any(), gomock.Any()).
+		Return(map[string][]models.ShardID{"1.1.1.2:9000": {1}}, nil).Times(2)
+	metricCtx.hedgeRequest(stateMgr, "1.1.1.1:9000")
+	assert.Empty(t, metricCtx.hedges)
+
+	// send hedged request failure, wait for original request
+	metricCtx.Deps.Hedger.OnRequest()
+	transportMgr.EXPECT().SendRequest("1.1.1.2:9000", gomock.Any()).Return(fmt.Errorf("err"))
+	metricCtx.hedgeRequest(stateMgr, "1.1.1.1:9000")
+	assert.Equal(t, "1.1.1.2:9000", metricCtx.hedges["1.1.1.1:9000"].failed)
+	// original fails, accept the error response
+	accepted, first, loser := metricCtx.acceptResponse(&protoCommonV1.TaskResponse{ErrMsg: "err"}, "1.1.1.1:9000")
+	assert.True(t, accepted)
+	assert.True(t, first)
+	assert.Empty(t, loser)
+}
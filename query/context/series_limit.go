@@ -0,0 +1,48 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"github.com/lindb/lindb/models"
+)
+
+// splitSeriesLimit splits the series limit per query of database evenly across the queried shards,
+// sets the share of shards on each leaf target, so that the series matched by all nodes don't exceed the limit.
+func (ctx *MetricContext) splitSeriesLimit(physicalPlans []*models.PhysicalPlan, cfg models.Database) {
+	if cfg.Option == nil || cfg.Option.MaxSeriesPerQuery <= 0 {
+		return
+	}
+	numOfShards := 0
+	for _, physicalPlan := range physicalPlans {
+		for _, target := range physicalPlan.Targets {
+			numOfShards += len(target.ShardIDs)
+		}
+	}
+	if numOfShards == 0 {
+		// no leaf target(compute nodes), split by intermediate node
+		return
+	}
+	limit := cfg.Option.MaxSeriesPerQuery
+	// round up, each shard can match at least one series
+	ctx.maxSeriesPerShard = (limit + numOfShards - 1) / numOfShards
+	for _, physicalPlan := range physicalPlans {
+		for _, target := range physicalPlan.Targets {
+			target.MaxSeries = ctx.maxSeriesPerShard * len(target.ShardIDs)
+		}
+	}
+}
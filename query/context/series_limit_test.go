@@ -0,0 +1,98 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/sql"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestMetricContext_splitSeriesLimit(t *testing.T) {
+	newPlans := func() []*models.PhysicalPlan {
+		return []*models.PhysicalPlan{{
+			Targets: []*models.Target{
+				{Indicator: "1", ShardIDs: []models.ShardID{0, 1}},
+				{Indicator: "2", ShardIDs: []models.ShardID{2}},
+			},
+		}}
+	}
+	ctx := &MetricContext{}
+	// no limit
+	plans := newPlans()
+	ctx.splitSeriesLimit(plans, models.Database{})
+	ctx.splitSeriesLimit(plans, models.Database{Option: &option.DatabaseOption{}})
+	assert.Zero(t, plans[0].Targets[0].MaxSeries)
+	assert.Zero(t, plans[0].Targets[1].MaxSeries)
+	// compute nodes
+	computePlans := []*models.PhysicalPlan{{Targets: []*models.Target{{Indicator: "1"}}}}
+	ctx.splitSeriesLimit(computePlans, models.Database{Option: &option.DatabaseOption{MaxSeriesPerQuery: 10}})
+	assert.Zero(t, computePlans[0].Targets[0].MaxSeries)
+	assert.Zero(t, ctx.maxSeriesPerShard)
+	// split by shards, round up
+	ctx.splitSeriesLimit(plans, models.Database{Option: &option.DatabaseOption{MaxSeriesPerQuery: 10}})
+	assert.Equal(t, 4, ctx.maxSeriesPerShard)
+	assert.Equal(t, 8, plans[0].Targets[0].MaxSeries)
+	assert.Equal(t, 4, plans[0].Targets[1].MaxSeries)
+}
+
+func TestRootMetricDataContext_MakePlan_SplitSeriesLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := models.Database{
+		NumOfShard: 2,
+		Option: &option.DatabaseOption{
+			Intervals:         option.Intervals{{Interval: timeutil.Interval(timeutil.OneSecond)}},
+			MaxSeriesPerQuery: 100,
+		},
+	}
+	q, err := sql.Parse("select f from cpu")
+	assert.NoError(t, err)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:       context.TODO(),
+		Choose:    stateMgr,
+		Request:   &models.Request{},
+		Statement: q.(*stmt.Query),
+	})
+	stateMgr.EXPECT().Choose(gomock.Any(), gomock.Any()).Return([]*models.PhysicalPlan{{
+		Database: "test",
+		Targets: []*models.Target{
+			{Indicator: "1", ShardIDs: []models.ShardID{0}},
+			{Indicator: "2", ShardIDs: []models.ShardID{1}},
+		},
+	}}, nil)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(cfg, true)
+	assert.NoError(t, metricCtx.MakePlan())
+	for _, target := range metricCtx.targets {
+		assert.Equal(t, 50, target.MaxSeries)
+	}
+	// retry on other replica keeps the share of shards
+	physicalPlan, _ := metricCtx.newReplicaRequest(map[string][]models.ShardID{"3": {0}})
+	assert.Equal(t, 50, physicalPlan.Targets[0].MaxSeries)
+}
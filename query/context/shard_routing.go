@@ -0,0 +1,221 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"sort"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/series/tag"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+// maxRoutingCombinations represents the max num. of routing tag value combinations for shard pruning,
+// query fans out to all shards if above it.
+const maxRoutingCombinations = 1024
+
+// routingShards returns the shards which store the series matched by condition based on routing tags of database,
+// returns false if condition doesn't pin all routing tags to exact values(need fan out to all shards).
+// NOTE: must match the sharding of write path(metric.BrokerBatchRows).
+func routingShards(condition stmt.Expr, cfg models.Database) (map[models.ShardID]struct{}, bool) {
+	if cfg.Option == nil || len(cfg.Option.RoutingTags) == 0 || cfg.NumOfShard <= 0 || condition == nil {
+		return nil, false
+	}
+	routingTags := cfg.Option.RoutingTags
+	keys := make(map[string]struct{}, len(routingTags))
+	for _, key := range routingTags {
+		keys[key] = struct{}{}
+	}
+	if hasNonExactFilter(condition, keys, false) {
+		// mixed exact/regex filters on routing tag
+		return nil, false
+	}
+	combinations := [][]*protoMetricsV1.KeyValue{nil}
+	for _, key := range routingTags {
+		values, ok := exactTagValues(condition, key)
+		if !ok || len(values)*len(combinations) > maxRoutingCombinations {
+			return nil, false
+		}
+		var next [][]*protoMetricsV1.KeyValue
+		for _, kvs := range combinations {
+			for _, value := range values {
+				combination := append(append([]*protoMetricsV1.KeyValue{}, kvs...), &protoMetricsV1.KeyValue{Key: key, Value: value})
+				next = append(next, combination)
+			}
+		}
+		combinations = next
+	}
+	shards := make(map[models.ShardID]struct{})
+	for _, kvs := range combinations {
+		shardIdx := metric.ShardIndex(metric.RoutingHash(tag.KeyValues(kvs)), int32(cfg.NumOfShard))
+		shards[models.ShardID(shardIdx)] = struct{}{}
+	}
+	return shards, true
+}
+
+// hasNonExactFilter checks if condition has like/regex/not filter on routing tags.
+func hasNonExactFilter(expr stmt.Expr, keys map[string]struct{}, inNot bool) bool {
+	switch e := expr.(type) {
+	case *stmt.EqualsExpr:
+		_, ok := keys[e.Key]
+		return ok && inNot
+	case *stmt.InExpr:
+		_, ok := keys[e.Key]
+		return ok && inNot
+	case *stmt.LikeExpr:
+		_, ok := keys[e.Key]
+		return ok
+	case *stmt.RegexExpr:
+		_, ok := keys[e.Key]
+		return ok
+	case *stmt.NotExpr:
+		return hasNonExactFilter(e.Expr, keys, true)
+	case *stmt.ParenExpr:
+		return hasNonExactFilter(e.Expr, keys, inNot)
+	case *stmt.BinaryExpr:
+		return hasNonExactFilter(e.Left, keys, inNot) || hasNonExactFilter(e.Right, keys, inNot)
+	default:
+		return false
+	}
+}
+
+// exactTagValues returns the exact values of tag key pinned by condition, returns false if tag key is not pinned.
+func exactTagValues(expr stmt.Expr, key string) ([]string, bool) {
+	switch e := expr.(type) {
+	case *stmt.EqualsExpr:
+		if e.Key == key {
+			return []string{e.Value}, true
+		}
+	case *stmt.InExpr:
+		if e.Key == key && len(e.Values) > 0 {
+			return dedupValues(e.Values), true
+		}
+	case *stmt.ParenExpr:
+		return exactTagValues(e.Expr, key)
+	case *stmt.BinaryExpr:
+		left, leftOK := exactTagValues(e.Left, key)
+		right, rightOK := exactTagValues(e.Right, key)
+		switch {
+		case e.Operator == stmt.AND && leftOK && rightOK:
+			values := intersectValues(left, right)
+			// no series matched, keep fan-out for returning empty result normally
+			return values, len(values) > 0
+		case e.Operator == stmt.AND && leftOK:
+			return left, true
+		case e.Operator == stmt.AND && rightOK:
+			return right, true
+		case e.Operator == stmt.OR && leftOK && rightOK:
+			return dedupValues(append(append([]string{}, left...), right...)), true
+		}
+	}
+	return nil, false
+}
+
+// dedupValues returns the sorted values without duplicates.
+func dedupValues(values []string) []string {
+	rs := append([]string{}, values...)
+	sort.Strings(rs)
+	idx := 0
+	for i := range rs {
+		if i == 0 || rs[i] != rs[idx-1] {
+			rs[idx] = rs[i]
+			idx++
+		}
+	}
+	return rs[:idx]
+}
+
+// intersectValues returns the values in both left and right.
+func intersectValues(left, right []string) (rs []string) {
+	set := make(map[string]struct{}, len(right))
+	for _, value := range right {
+		set[value] = struct{}{}
+	}
+	for _, value := range dedupValues(left) {
+		if _, ok := set[value]; ok {
+			rs = append(rs, value)
+		}
+	}
+	return rs
+}
+
+// pruneShardsByRouting prunes the shards of physical plans by routing tags of database,
+// records the num. of queried/pruned shards for explain.
+func (ctx *MetricContext) pruneShardsByRouting(physicalPlans []*models.PhysicalPlan, condition stmt.Expr, cfg models.Database) {
+	shards, ok := routingShards(condition, cfg)
+	if !ok {
+		return
+	}
+	for _, physicalPlan := range physicalPlans {
+		for _, target := range physicalPlan.Targets {
+			for _, shardID := range target.ShardIDs {
+				if shardID.Int() >= cfg.NumOfShard {
+					// target shard of split holds the rows of any source shard written after split, cannot be pruned
+					shards[shardID] = struct{}{}
+				}
+			}
+		}
+	}
+	for _, physicalPlan := range physicalPlans {
+		queried, pruned := pruneShards(physicalPlan, shards)
+		ctx.queriedShards += queried
+		ctx.prunedShards += pruned
+	}
+}
+
+// pruneShards removes the shards not in routing shards from the leaf targets of physical plan,
+// removes the leaf target if all its shards are pruned, returns the num. of queried/pruned shards.
+// If all leaf targets are pruned(routing shards have no queryable replica), keeps physical plan unchanged.
+func pruneShards(physicalPlan *models.PhysicalPlan, shards map[models.ShardID]struct{}) (queried, pruned int) {
+	var (
+		targets    []*models.Target
+		shardIDMap = make(map[*models.Target][]models.ShardID)
+		leafs      int
+	)
+	for _, target := range physicalPlan.Targets {
+		if len(target.ShardIDs) == 0 {
+			// compute node
+			targets = append(targets, target)
+			continue
+		}
+		var shardIDs []models.ShardID
+		for _, shardID := range target.ShardIDs {
+			if _, ok := shards[shardID]; ok {
+				shardIDs = append(shardIDs, shardID)
+			}
+		}
+		queried += len(shardIDs)
+		pruned += len(target.ShardIDs) - len(shardIDs)
+		if len(shardIDs) > 0 {
+			shardIDMap[target] = shardIDs
+			targets = append(targets, target)
+			leafs++
+		}
+	}
+	if leafs == 0 {
+		return queried + pruned, 0
+	}
+	for target, shardIDs := range shardIDMap {
+		target.ShardIDs = shardIDs
+	}
+	physicalPlan.Targets = targets
+	return queried, pruned
+}
@@ -0,0 +1,319 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/series/tag"
+	"github.com/lindb/lindb/sql"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func shardOf(numOfShard int, kvs ...string) models.ShardID {
+	var keyValues tag.KeyValues
+	for i := 0; i < len(kvs); i += 2 {
+		keyValues = append(keyValues, &protoMetricsV1.KeyValue{Key: kvs[i], Value: kvs[i+1]})
+	}
+	return models.ShardID(metric.ShardIndex(metric.RoutingHash(keyValues), int32(numOfShard)))
+}
+
+func shardSet(shardIDs ...models.ShardID) map[models.ShardID]struct{} {
+	rs := make(map[models.ShardID]struct{})
+	for _, shardID := range shardIDs {
+		rs[shardID] = struct{}{}
+	}
+	return rs
+}
+
+func TestRoutingShards(t *testing.T) {
+	cfg := func(routingTags ...string) models.Database {
+		return models.Database{
+			NumOfShard: 64,
+			Option:     &option.DatabaseOption{RoutingTags: routingTags},
+		}
+	}
+	cases := []struct {
+		name   string
+		sql    string
+		cfg    models.Database
+		shards map[models.ShardID]struct{}
+		ok     bool
+	}{
+		{
+			name: "database option not set",
+			sql:  "select f from cpu where host='a'",
+			cfg:  models.Database{NumOfShard: 64},
+		},
+		{
+			name: "routing tags not set",
+			sql:  "select f from cpu where host='a'",
+			cfg:  cfg(),
+		},
+		{
+			name: "without condition",
+			sql:  "select f from cpu",
+			cfg:  cfg("host"),
+		},
+		{
+			name:   "equals",
+			sql:    "select f from cpu where host='a' and ip='1.1.1.1'",
+			cfg:    cfg("host"),
+			shards: shardSet(shardOf(64, "host", "a")),
+			ok:     true,
+		},
+		{
+			name:   "in",
+			sql:    "select f from cpu where host in ('a','b')",
+			cfg:    cfg("host"),
+			shards: shardSet(shardOf(64, "host", "a"), shardOf(64, "host", "b")),
+			ok:     true,
+		},
+		{
+			name:   "or",
+			sql:    "select f from cpu where (host='a' or host='b')",
+			cfg:    cfg("host"),
+			shards: shardSet(shardOf(64, "host", "a"), shardOf(64, "host", "b")),
+			ok:     true,
+		},
+		{
+			name:   "and intersection",
+			sql:    "select f from cpu where host in ('a','b') and host='b'",
+			cfg:    cfg("host"),
+			shards: shardSet(shardOf(64, "host", "b")),
+			ok:     true,
+		},
+		{
+			name: "and without intersection",
+			sql:  "select f from cpu where host='a' and host='b'",
+			cfg:  cfg("host"),
+		},
+		{
+			name: "or with other tag",
+			sql:  "select f from cpu where host='a' or ip='1.1.1.1'",
+			cfg:  cfg("host"),
+		},
+		{
+			name: "mixed exact/regex filters",
+			sql:  "select f from cpu where host='a' and host=~'a.*'",
+			cfg:  cfg("host"),
+		},
+		{
+			name: "like filter",
+			sql:  "select f from cpu where host like 'a*'",
+			cfg:  cfg("host"),
+		},
+		{
+			name: "not filter",
+			sql:  "select f from cpu where ip='1.1.1.1' and host!='a'",
+			cfg:  cfg("host"),
+		},
+		{
+			name: "not all routing tags pinned",
+			sql:  "select f from cpu where host='a'",
+			cfg:  cfg("host", "region"),
+		},
+		{
+			name: "multi routing tags",
+			sql:  "select f from cpu where host in ('a','b') and region='sh'",
+			cfg:  cfg("host", "region"),
+			shards: shardSet(
+				shardOf(64, "host", "a", "region", "sh"),
+				shardOf(64, "host", "b", "region", "sh"),
+			),
+			ok: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := sql.Parse(tt.sql)
+			assert.NoError(t, err)
+			shards, ok := routingShards(q.(*stmt.Query).Condition, tt.cfg)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.shards, shards)
+		})
+	}
+}
+
+func TestRoutingShards_TooManyCombinations(t *testing.T) {
+	var values []string
+	for i := 0; i < 40; i++ {
+		values = append(values, string(rune('a'+i)))
+	}
+	condition := &stmt.BinaryExpr{
+		Left:     &stmt.InExpr{Key: "host", Values: values},
+		Operator: stmt.AND,
+		Right:    &stmt.InExpr{Key: "region", Values: values},
+	}
+	shards, ok := routingShards(condition, models.Database{
+		NumOfShard: 64,
+		Option:     &option.DatabaseOption{RoutingTags: []string{"host", "region"}},
+	})
+	assert.False(t, ok)
+	assert.Nil(t, shards)
+}
+
+func TestPruneShards(t *testing.T) {
+	cases := []struct {
+		name            string
+		plan            *models.PhysicalPlan
+		shards          map[models.ShardID]struct{}
+		queried, pruned int
+		expectTargets   []*models.Target
+	}{
+		{
+			name: "prune shards and leaf target",
+			plan: &models.PhysicalPlan{Targets: []*models.Target{
+				{Indicator: "1", ShardIDs: []models.ShardID{1, 2}},
+				{Indicator: "2", ShardIDs: []models.ShardID{3, 4}},
+			}},
+			shards:  shardSet(2),
+			queried: 1,
+			pruned:  3,
+			expectTargets: []*models.Target{
+				{Indicator: "1", ShardIDs: []models.ShardID{2}},
+			},
+		},
+		{
+			name: "keep compute target",
+			plan: &models.PhysicalPlan{Targets: []*models.Target{
+				{Indicator: "1"},
+			}},
+			shards:        shardSet(2),
+			expectTargets: []*models.Target{{Indicator: "1"}},
+		},
+		{
+			name: "routing shards not queryable, keep plan",
+			plan: &models.PhysicalPlan{Targets: []*models.Target{
+				{Indicator: "1", ShardIDs: []models.ShardID{1, 2}},
+			}},
+			shards:        shardSet(3),
+			queried:       2,
+			expectTargets: []*models.Target{{Indicator: "1", ShardIDs: []models.ShardID{1, 2}}},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			queried, pruned := pruneShards(tt.plan, tt.shards)
+			assert.Equal(t, tt.queried, queried)
+			assert.Equal(t, tt.pruned, pruned)
+			assert.Equal(t, tt.expectTargets, tt.plan.Targets)
+		})
+	}
+}
+
+func TestRootMetricDataContext_MakePlan_RoutingShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := models.Database{
+		NumOfShard: 2,
+		Option: &option.DatabaseOption{
+			Intervals:   option.Intervals{{Interval: timeutil.Interval(timeutil.OneSecond)}},
+			RoutingTags: []string{"host"},
+		},
+	}
+	q, err := sql.Parse("select f from cpu where host='a'")
+	assert.NoError(t, err)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:       context.TODO(),
+		Choose:    stateMgr,
+		Request:   &models.Request{},
+		Statement: q.(*stmt.Query),
+	})
+	stateMgr.EXPECT().Choose(gomock.Any(), gomock.Any()).Return([]*models.PhysicalPlan{{
+		Database: "test",
+		Targets: []*models.Target{
+			{Indicator: "1", ShardIDs: []models.ShardID{0}},
+			{Indicator: "2", ShardIDs: []models.ShardID{1}},
+		},
+	}}, nil)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(cfg, true)
+	assert.NoError(t, metricCtx.MakePlan())
+	assert.Equal(t, 1, metricCtx.queriedShards)
+	assert.Equal(t, 1, metricCtx.prunedShards)
+	targets := metricCtx.targets
+	assert.Len(t, targets, 1)
+	for _, target := range targets {
+		assert.Equal(t, []models.ShardID{shardOf(2, "host", "a")}, target.ShardIDs)
+	}
+	// explain shows pruned/queried shards
+	metricCtx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+	metricCtx.explain = true
+	metricCtx.handleStats(&protoCommonV1.TaskResponse{Stats: []byte("{}")}, "1")
+	assert.Equal(t, 1, metricCtx.stats.QueriedShards)
+	assert.Equal(t, 1, metricCtx.stats.PrunedShards)
+}
+
+func TestRootMetricDataContext_MakePlan_RoutingShards_Split(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cfg := models.Database{
+		NumOfShard: 2,
+		Option: &option.DatabaseOption{
+			Intervals:   option.Intervals{{Interval: timeutil.Interval(timeutil.OneSecond)}},
+			RoutingTags: []string{"host"},
+		},
+	}
+	q, err := sql.Parse("select f from cpu where host='a'")
+	assert.NoError(t, err)
+	stateMgr := broker.NewMockStateManager(ctrl)
+	metricCtx := NewRootMetricContext(&RootMetricContextDeps{
+		Ctx:       context.TODO(),
+		Choose:    stateMgr,
+		Request:   &models.Request{},
+		Statement: q.(*stmt.Query),
+	})
+	// shard 2 is the target shard of split
+	stateMgr.EXPECT().Choose(gomock.Any(), gomock.Any()).Return([]*models.PhysicalPlan{{
+		Database: "test",
+		Targets: []*models.Target{
+			{Indicator: "1", ShardIDs: []models.ShardID{0, 2}},
+			{Indicator: "2", ShardIDs: []models.ShardID{1}},
+		},
+	}}, nil)
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).Return(cfg, true)
+	assert.NoError(t, metricCtx.MakePlan())
+	assert.Equal(t, 2, metricCtx.queriedShards)
+	assert.Equal(t, 1, metricCtx.prunedShards)
+	var shardIDs []models.ShardID
+	for _, target := range metricCtx.targets {
+		shardIDs = append(shardIDs, target.ShardIDs...)
+	}
+	assert.Contains(t, shardIDs, shardOf(2, "host", "a"))
+	assert.Contains(t, shardIDs, models.ShardID(2))
+}
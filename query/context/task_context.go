@@ -24,7 +24,9 @@ import (
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/tracker"
 	"github.com/lindb/lindb/rpc"
@@ -32,6 +34,9 @@ import (
 
 //go:generate mockgen -source=./task_context.go -destination=./task_context_mock.go -package=context
 
+// waitResponseStage represents the task is waiting the responses of other nodes after all stages completed.
+const waitResponseStage = "Wait Response"
+
 // TaskContext represents the task context for distribution query and computing.
 type TaskContext interface {
 	// Context returns the context.
@@ -52,6 +57,10 @@ type TaskContext interface {
 	WaitResponse() (any, error)
 	// SetTracker sets stage tracker.
 	SetTracker(stageTracker *tracker.StageTracker)
+	// Stage returns the current stage of task.
+	Stage() string
+	// Kill kills the running task, cancels the requests sent to other nodes, then completes the task with killed error.
+	Kill()
 }
 
 // baseTaskContext implements TaskContext interface, implements some common logic.
@@ -69,6 +78,7 @@ type baseTaskContext struct {
 	doneCh        chan struct{}
 	expectResults int
 	completed     atomic.Bool
+	killed        atomic.Bool
 	err           error
 	mutex         sync.Mutex
 	// tolerantNotFounds keeps the number of how many not found errors can be returned
@@ -119,6 +129,11 @@ func (ctx *baseTaskContext) addRequests(req *protoCommonV1.TaskRequest, physical
 	ctx.mutex.Lock()
 	defer ctx.mutex.Unlock()
 
+	ctx.addTargetRequests(req, physicalPlan)
+}
+
+// addTargetRequests adds the task requests of targets, must hold lock.
+func (ctx *baseTaskContext) addTargetRequests(req *protoCommonV1.TaskRequest, physicalPlan *models.PhysicalPlan) {
 	for _, target := range physicalPlan.Targets {
 		ctx.expectResults++
 		ctx.tolerantNotFounds++
@@ -138,6 +153,65 @@ func (ctx *baseTaskContext) Complete(err error) {
 	ctx.tryClose()
 }
 
+// Stage returns the current stage of task, returns waiting response if all stages completed after sending requests.
+func (ctx *baseTaskContext) Stage() string {
+	if ctx.stageTracker != nil {
+		if stage := ctx.stageTracker.CurrentStage(); stage != "" {
+			return stage
+		}
+	}
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.sent > 0 {
+		return waitResponseStage
+	}
+	return ""
+}
+
+// Kill kills the running task, cancels the requests sent to other nodes, then completes the task with killed error.
+// NOTICE: cancel is best-effort, the late responses are ignored anyway.
+func (ctx *baseTaskContext) Kill() {
+	if !ctx.killed.CAS(false, true) {
+		return
+	}
+	ctx.mutex.Lock()
+	requests := make(map[string]*protoCommonV1.TaskRequest, len(ctx.requests))
+	for node, req := range ctx.requests {
+		requests[node] = req
+	}
+	ctx.mutex.Unlock()
+
+	for node, req := range requests {
+		ctx.sendCancelRequest(node, req)
+	}
+	ctx.Complete(constants.ErrQueryKilled)
+}
+
+// sendCancelRequest sends the request which cancels the running task of request on target node.
+func (ctx *baseTaskContext) sendCancelRequest(node string, req *protoCommonV1.TaskRequest) {
+	if ctx.transportMgr == nil {
+		return
+	}
+	physicalPlan := &models.PhysicalPlan{}
+	_ = encoding.JSONUnmarshal(req.PhysicalPlan, physicalPlan)
+	cancelPlan := &models.PhysicalPlan{
+		Database: physicalPlan.Database,
+		Targets:  []*models.Target{{Indicator: node}},
+		Cancel:   true,
+	}
+	_ = ctx.transportMgr.SendRequest(node, &protoCommonV1.TaskRequest{
+		RequestID:    req.RequestID,
+		RequestType:  req.RequestType,
+		PhysicalPlan: encoding.JSONMarshal(cancelPlan),
+	})
+}
+
+// isKilled returns if the task is killed by admin.
+func (ctx *baseTaskContext) isKilled() bool {
+	return ctx.killed.Load()
+}
+
 // SetTracker sets stage tracker.
 func (ctx *baseTaskContext) SetTracker(stageTracker *tracker.StageTracker) {
 	ctx.stageTracker = stageTracker
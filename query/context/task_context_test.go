@@ -26,8 +26,10 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/tracker"
 	"github.com/lindb/lindb/rpc"
@@ -60,3 +62,58 @@ func TestTaskContext(t *testing.T) {
 	ctx.handleTaskState(&protoCommonV1.TaskResponse{Completed: false}, "leaf")
 	ctx.handleTaskState(&protoCommonV1.TaskResponse{Completed: true}, "leaf")
 }
+
+func TestTaskContext_Stage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	transportMgr := rpc.NewMockTransportManager(ctrl)
+	transportMgr.EXPECT().SendRequest(gomock.Any(), gomock.Any()).Return(nil)
+	ctx := newBaseTaskContext(context.TODO(), transportMgr)
+	assert.Empty(t, ctx.Stage())
+
+	stageTracker := tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute))
+	ctx.SetTracker(stageTracker)
+	assert.Empty(t, ctx.Stage())
+
+	assert.NoError(t, ctx.SendRequest("target", &protoCommonV1.TaskRequest{}))
+	assert.Equal(t, waitResponseStage, ctx.Stage())
+}
+
+func TestTaskContext_Kill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	transportMgr := rpc.NewMockTransportManager(ctrl)
+	ctx := newBaseTaskContext(context.TODO(), transportMgr)
+	ctx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+	ctx.addRequests(&protoCommonV1.TaskRequest{
+		RequestID:    "req",
+		PhysicalPlan: encoding.JSONMarshal(&models.PhysicalPlan{Database: "db"}),
+	}, &models.PhysicalPlan{
+		Targets: []*models.Target{{Indicator: "target-1"}},
+	})
+	transportMgr.EXPECT().SendRequest("target-1", gomock.Any()).DoAndReturn(
+		func(_ string, req *protoCommonV1.TaskRequest) error {
+			plan := &models.PhysicalPlan{}
+			assert.NoError(t, encoding.JSONUnmarshal(req.PhysicalPlan, plan))
+			assert.True(t, plan.Cancel)
+			assert.Equal(t, "db", plan.Database)
+			assert.Equal(t, "req", req.RequestID)
+			return fmt.Errorf("err")
+		})
+	ctx.Kill()
+	// kill again, ignore
+	ctx.Kill()
+	assert.True(t, ctx.isKilled())
+	assert.Equal(t, constants.ErrQueryKilled, ctx.err)
+
+	// no transport
+	ctx = newBaseTaskContext(context.TODO(), nil)
+	ctx.SetTracker(tracker.NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)))
+	ctx.addRequests(&protoCommonV1.TaskRequest{}, &models.PhysicalPlan{
+		Targets: []*models.Target{{Indicator: "target-1"}},
+	})
+	ctx.Kill()
+	assert.True(t, ctx.isKilled())
+}
@@ -0,0 +1,93 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package context
+
+import (
+	"errors"
+
+	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+// newOrderByItems builds the order by items of query, fieldTypeOf returns the type of field by field name.
+func newOrderByItems(orderByExprs []stmt.Expr, fieldTypeOf func(fieldName string) (field.Type, bool),
+) ([]*aggregation.OrderByItem, error) {
+	var orderByItems []*aggregation.OrderByItem
+	for _, orderBy := range orderByExprs {
+		expr := orderBy.(*stmt.OrderByExpr)
+		funcType := function.Unknown
+		var fieldName string
+		switch e := expr.Expr.(type) {
+		case *stmt.FieldExpr:
+			fieldType, ok := fieldTypeOf(e.Name)
+			if ok {
+				funcType = fieldType.GetOrderByFunc()
+				fieldName = e.Name
+			}
+		case *stmt.CallExpr:
+			funcType = e.FuncType
+			fieldName = e.Params[0].Rewrite()
+		}
+		if funcType == function.Unknown {
+			return nil, errors.New("cannot parse order by function")
+		}
+		orderByItems = append(orderByItems, &aggregation.OrderByItem{
+			Expr:     expr,
+			Name:     fieldName,
+			FuncType: funcType,
+			Desc:     expr.Desc,
+		})
+	}
+	return orderByItems, nil
+}
+
+// topNCandidates returns the tags of candidate groups ranked by partial aggregates of current leaf node for top-n query,
+// returns nil if all groups need to be returned(not top-n query or num. of groups not exceeds candidates).
+func (ctx *LeafReduceContext) topNCandidates() map[string]struct{} {
+	query := ctx.storageExecuteCtx.Query
+	if query.TopNCandidates <= 0 || len(query.OrderByItems) == 0 || ctx.reduceAgg.NumOfGroups() <= query.TopNCandidates {
+		return nil
+	}
+	fieldTypes := make(map[string]field.Type)
+	for _, spec := range ctx.storageExecuteCtx.AggregatorSpecs {
+		fieldTypes[string(spec.FieldName())] = spec.GetFieldType()
+	}
+	orderByItems, err := newOrderByItems(query.OrderByItems, func(fieldName string) (field.Type, bool) {
+		fieldType, ok := fieldTypes[fieldName]
+		return fieldType, ok
+	})
+	if err != nil {
+		// cannot rank groups on leaf node, return all groups
+		return nil
+	}
+	orderBy := aggregation.NewTopNOrderBy(orderByItems, 0, query.TopNCandidates)
+	for _, it := range ctx.reduceAgg.ResultSet() {
+		expression := newExpressionFn(query.TimeRange, query.Interval.Int64(), query.SelectItems)
+		expression.Eval(it)
+		orderBy.Push(aggregation.NewOrderByRow(it.Tags(), expression.ResultSet()))
+	}
+	rows := orderBy.ResultSet()
+	candidates := make(map[string]struct{}, len(rows))
+	for _, row := range rows {
+		tags, _ := row.ResultSet()
+		candidates[tags] = struct{}{}
+	}
+	return candidates
+}
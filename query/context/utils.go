@@ -18,14 +18,25 @@
 package context
 
 import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
-// calcTimeRangeAndInterval calculates the query time range and interval based on input params and database config.
-func calcTimeRangeAndInterval(statement *stmt.Query, cfg models.Database) {
+// CalcTimeRangeAndInterval calculates the query time range and interval based on input params and database config.
+func CalcTimeRangeAndInterval(statement *stmt.Query, cfg models.Database) error {
 	option := cfg.Option
+	if statement.ZoneInterval > 0 {
+		// buckets of time zone calculated by root node, keep them same on intermediate node
+		return calcZoneTimeRangeAndInterval(statement, option, statement.ZoneInterval)
+	}
 	interval := statement.Interval
 	if interval <= 0 {
 		// if query interval not set, first set it using the smallest interval in storage option.
@@ -37,7 +48,13 @@ func calcTimeRangeAndInterval(statement *stmt.Query, cfg models.Database) {
 	if interval < statement.Interval {
 		interval = statement.Interval
 	}
-	storageInterval := option.FindMatchSmallestInterval(interval)
+	if statement.TimeZone != "" {
+		return calcZoneTimeRangeAndInterval(statement, option, interval)
+	}
+	storageInterval, err := selectStorageInterval(statement, option, interval)
+	if err != nil {
+		return err
+	}
 	intervalRatio := timeutil.CalIntervalRatio(interval.Int64(), storageInterval.Int64())
 	// truncate query interval
 	interval = timeutil.Interval(storageInterval.Int64() * int64(intervalRatio))
@@ -49,4 +66,159 @@ func calcTimeRangeAndInterval(statement *stmt.Query, cfg models.Database) {
 	statement.IntervalRatio = intervalRatio
 	statement.TimeRange.Start = timeutil.Truncate(statement.TimeRange.Start, intervalVal)
 	statement.TimeRange.End = timeutil.Truncate(statement.TimeRange.End, intervalVal)
+	return nil
+}
+
+// ClampTimeRangeByRetention clamps the start of query time range to the retention of storage interval selected by query,
+// storage interval is re-selected after clamped because shorter time range may select smaller interval,
+// returns true if the time range is clamped(start > end means the whole time range is out of retention).
+func ClampTimeRangeByRetention(statement *stmt.Query, cfg models.Database) (clamped bool) {
+	if cfg.Option == nil || len(cfg.Option.Intervals) == 0 {
+		return false
+	}
+	now := timeutil.Now()
+	for range cfg.Option.Intervals {
+		planned := *statement
+		if err := CalcTimeRangeAndInterval(&planned, cfg); err != nil {
+			// error is returned when making plan
+			return clamped
+		}
+		var retention int64
+		for _, interval := range cfg.Option.Intervals {
+			if interval.Interval == planned.StorageInterval {
+				retention = interval.Retention.Int64()
+			}
+		}
+		if retention <= 0 || statement.TimeRange.Start >= now-retention {
+			return clamped
+		}
+		statement.TimeRange.Start = now - retention
+		clamped = true
+		if statement.TimeRange.Start > statement.TimeRange.End {
+			return clamped
+		}
+	}
+	return clamped
+}
+
+// calcZoneTimeRangeAndInterval calculates the buckets in time zone, then queries the data with the common interval
+// of bucket boundaries, which is merged into buckets when making result set.
+func calcZoneTimeRangeAndInterval(statement *stmt.Query, opt *option.DatabaseOption,
+	zoneInterval timeutil.Interval,
+) error {
+	buckets, err := timeutil.NewZoneBuckets(statement.TimeZone, zoneInterval)
+	if err != nil {
+		return err
+	}
+	boundaries := buckets.Boundaries(statement.TimeRange)
+	if len(boundaries) == 0 {
+		return fmt.Errorf("invalid time range of query, start: %d, end: %d",
+			statement.TimeRange.Start, statement.TimeRange.End)
+	}
+	interval := timeutil.Interval(buckets.CommonInterval(boundaries))
+	storageInterval, err := selectStorageInterval(statement, opt, interval)
+	if err != nil {
+		return err
+	}
+	if interval.Int64()%storageInterval.Int64() != 0 {
+		return fmt.Errorf("storage interval %s cannot align the buckets of time zone %s",
+			storageInterval, statement.TimeZone)
+	}
+	statement.StorageInterval = storageInterval
+	statement.Interval = interval
+	statement.IntervalRatio = int(interval.Int64() / storageInterval.Int64())
+	statement.ZoneInterval = zoneInterval
+	statement.TimeRange.Start = boundaries[0]
+	statement.TimeRange.End = timeutil.Truncate(statement.TimeRange.End, interval.Int64())
+	return nil
+}
+
+// selectStorageInterval selects the storage interval based on the interval hint of query,
+// the retention of hint interval must cover the query time range,
+// the rollup interval whose history data is being backfilled is preferred only if data is complete in time range.
+func selectStorageInterval(statement *stmt.Query, opt *option.DatabaseOption,
+	interval timeutil.Interval,
+) (timeutil.Interval, error) {
+	if statement.IntervalHint == stmt.AutoInterval {
+		return opt.FindMatchSmallestInterval(interval, statement.TimeRange.Start), nil
+	}
+	intervals := make(option.Intervals, len(opt.Intervals))
+	copy(intervals, opt.Intervals)
+	sort.Sort(intervals)
+
+	covers := func(i option.Interval) bool {
+		if statement.IntervalHint == stmt.RollupInterval && !i.IsAvailable(statement.TimeRange.Start) {
+			return false
+		}
+		return i.Retention <= 0 || statement.TimeRange.Start >= timeutil.Now()-i.Retention.Int64()
+	}
+	var candidates option.Intervals
+	switch statement.IntervalHint {
+	case stmt.RawInterval:
+		candidates = intervals[:1]
+	case stmt.RollupInterval:
+		if len(intervals) < 2 {
+			return 0, fmt.Errorf("no rollup interval configured in database, intervals: %s", intervals)
+		}
+		// prefer the biggest rollup interval which not larger than query interval
+		rollups := intervals[1:]
+		for idx := len(rollups) - 1; idx >= 0; idx-- {
+			if rollups[idx].Interval <= interval {
+				candidates = append(candidates, rollups[idx])
+			}
+		}
+		for idx := range rollups {
+			if rollups[idx].Interval > interval {
+				candidates = append(candidates, rollups[idx])
+			}
+		}
+	case stmt.SpecInterval:
+		for idx := range intervals {
+			if intervals[idx].Interval == statement.HintInterval {
+				candidates = append(candidates, intervals[idx])
+			}
+		}
+		if len(candidates) == 0 {
+			return 0, fmt.Errorf("interval %s not configured in database, intervals: %s",
+				statement.HintInterval, intervals)
+		}
+	default:
+		return 0, fmt.Errorf("unknown interval hint: %s", statement.IntervalHint)
+	}
+	for _, candidate := range candidates {
+		if covers(candidate) {
+			return candidate.Interval, nil
+		}
+	}
+	return 0, fmt.Errorf("query time range exceeds the retention of %s interval, intervals: %s",
+		statement.IntervalHint, candidates)
+}
+
+// remainingTimeout returns the remaining time(ns) before the deadline of query context, 0 means no deadline.
+func remainingTimeout(ctx context.Context) int64 {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		// deadline exceeded, target node will return timeout immediately
+		return int64(time.Nanosecond)
+	}
+	return int64(remaining)
+}
+
+// suggestionLimit returns the result limit of metadata query, paginated query allows larger page size.
+func suggestionLimit(statement *stmt.MetricMetadata) int {
+	if statement.Limit <= 0 {
+		return constants.MaxSuggestions
+	}
+	maxLimit := constants.MaxSuggestions
+	if statement.Paginated() {
+		maxLimit = constants.MaxSuggestionPageSize
+	}
+	if statement.Limit > maxLimit {
+		return maxLimit
+	}
+	return statement.Limit
 }
@@ -18,7 +18,9 @@
 package context
 
 import (
+	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -28,7 +30,7 @@ import (
 	"github.com/lindb/lindb/sql/stmt"
 )
 
-func Test_calcTimeRangeAndInterval(t *testing.T) {
+func TestCalcTimeRangeAndInterval(t *testing.T) {
 	cfg := models.Database{
 		Option: &option.DatabaseOption{
 			Intervals: option.Intervals{
@@ -38,11 +40,279 @@ func Test_calcTimeRangeAndInterval(t *testing.T) {
 		},
 	}
 	statement := &stmt.Query{}
-	calcTimeRangeAndInterval(statement, cfg)
+	assert.NoError(t, CalcTimeRangeAndInterval(statement, cfg))
 	assert.Equal(t, timeutil.Interval(timeutil.OneSecond), statement.Interval)
 
 	statement.Interval = timeutil.Interval(timeutil.OneHour)
 	statement.TimeRange = timeutil.TimeRange{Start: timeutil.Now(), End: timeutil.Now() + 6*timeutil.OneHour}
-	calcTimeRangeAndInterval(statement, cfg)
+	assert.NoError(t, CalcTimeRangeAndInterval(statement, cfg))
 	assert.Equal(t, timeutil.Interval(timeutil.OneHour), statement.Interval)
 }
+
+func TestCalcTimeRangeAndInterval_zone(t *testing.T) {
+	cfg := models.Database{
+		Option: &option.DatabaseOption{
+			Intervals: option.Intervals{
+				{Interval: timeutil.Interval(10 * timeutil.OneSecond)},
+				{Interval: timeutil.Interval(timeutil.OneHour)},
+				{Interval: timeutil.Interval(timeutil.OneDay)},
+			},
+		},
+	}
+	shanghai, err := time.LoadLocation("Asia/Shanghai")
+	assert.NoError(t, err)
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, shanghai).UnixMilli()
+	end := time.Date(2023, 5, 3, 1, 0, 0, 0, shanghai).UnixMilli()
+	newStatement := func() *stmt.Query {
+		return &stmt.Query{
+			TimeZone:  "Asia/Shanghai",
+			Interval:  timeutil.Interval(timeutil.OneDay),
+			TimeRange: timeutil.TimeRange{Start: start, End: end},
+		}
+	}
+	statement := newStatement()
+	assert.NoError(t, CalcTimeRangeAndInterval(statement, cfg))
+	// midnight of Shanghai aligns with 8h
+	assert.Equal(t, timeutil.Interval(8*timeutil.OneHour), statement.Interval)
+	assert.Equal(t, timeutil.Interval(timeutil.OneHour), statement.StorageInterval)
+	assert.Equal(t, 8, statement.IntervalRatio)
+	assert.Equal(t, timeutil.Interval(timeutil.OneDay), statement.ZoneInterval)
+	assert.Equal(t, time.Date(2023, 5, 1, 0, 0, 0, 0, shanghai).UnixMilli(), statement.TimeRange.Start)
+	// re-calc on intermediate node
+	expect := *statement
+	assert.NoError(t, CalcTimeRangeAndInterval(statement, cfg))
+	assert.Equal(t, expect, *statement)
+
+	// storage interval cannot align buckets
+	statement = newStatement()
+	statement.IntervalHint = stmt.SpecInterval
+	statement.HintInterval = timeutil.Interval(timeutil.OneDay)
+	assert.Error(t, CalcTimeRangeAndInterval(statement, cfg))
+	// invalid time zone
+	statement = newStatement()
+	statement.TimeZone = "Unknown/Zone"
+	assert.Error(t, CalcTimeRangeAndInterval(statement, cfg))
+	// invalid time range
+	statement = newStatement()
+	statement.TimeRange.End = statement.TimeRange.Start - timeutil.OneDay
+	assert.Error(t, CalcTimeRangeAndInterval(statement, cfg))
+	// storage interval not found
+	statement = newStatement()
+	statement.IntervalHint = stmt.SpecInterval
+	statement.HintInterval = timeutil.Interval(timeutil.OneMinute)
+	assert.Error(t, CalcTimeRangeAndInterval(statement, cfg))
+}
+
+func TestCalcTimeRangeAndInterval_hint(t *testing.T) {
+	cfg := models.Database{
+		Option: &option.DatabaseOption{
+			Intervals: option.Intervals{
+				{Interval: timeutil.Interval(5 * timeutil.OneMinute), Retention: timeutil.Interval(timeutil.OneMonth)},
+				{Interval: timeutil.Interval(10 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneDay)},
+				{Interval: timeutil.Interval(timeutil.OneHour), Retention: timeutil.Interval(timeutil.OneYear)},
+			},
+		},
+	}
+	now := timeutil.Now()
+	cases := []struct {
+		name            string
+		statement       *stmt.Query
+		storageInterval timeutil.Interval
+		wantErr         bool
+	}{
+		{
+			name: "auto select",
+			statement: &stmt.Query{
+				TimeRange: timeutil.TimeRange{Start: now - timeutil.OneHour, End: now},
+			},
+			storageInterval: timeutil.Interval(10 * timeutil.OneSecond),
+		},
+		{
+			name: "raw interval",
+			statement: &stmt.Query{
+				Interval:     timeutil.Interval(timeutil.OneHour),
+				IntervalHint: stmt.RawInterval,
+				TimeRange:    timeutil.TimeRange{Start: now - timeutil.OneHour, End: now},
+			},
+			storageInterval: timeutil.Interval(10 * timeutil.OneSecond),
+		},
+		{
+			name: "raw interval out of retention",
+			statement: &stmt.Query{
+				IntervalHint: stmt.RawInterval,
+				TimeRange:    timeutil.TimeRange{Start: now - 2*timeutil.OneDay, End: now},
+			},
+			wantErr: true,
+		},
+		{
+			name: "rollup interval",
+			statement: &stmt.Query{
+				IntervalHint: stmt.RollupInterval,
+				TimeRange:    timeutil.TimeRange{Start: now - timeutil.OneHour, End: now},
+			},
+			storageInterval: timeutil.Interval(5 * timeutil.OneMinute),
+		},
+		{
+			name: "rollup interval, biggest one not larger than query interval",
+			statement: &stmt.Query{
+				Interval:     timeutil.Interval(2 * timeutil.OneHour),
+				IntervalHint: stmt.RollupInterval,
+				TimeRange:    timeutil.TimeRange{Start: now - timeutil.OneDay, End: now},
+			},
+			storageInterval: timeutil.Interval(timeutil.OneHour),
+		},
+		{
+			name: "rollup interval, fallback to the interval which retention covers time range",
+			statement: &stmt.Query{
+				IntervalHint: stmt.RollupInterval,
+				TimeRange:    timeutil.TimeRange{Start: now - 2*timeutil.OneMonth, End: now},
+			},
+			storageInterval: timeutil.Interval(timeutil.OneHour),
+		},
+		{
+			name: "spec interval",
+			statement: &stmt.Query{
+				IntervalHint: stmt.SpecInterval,
+				HintInterval: timeutil.Interval(5 * timeutil.OneMinute),
+				TimeRange:    timeutil.TimeRange{Start: now - timeutil.OneHour, End: now},
+			},
+			storageInterval: timeutil.Interval(5 * timeutil.OneMinute),
+		},
+		{
+			name: "spec interval not configured",
+			statement: &stmt.Query{
+				IntervalHint: stmt.SpecInterval,
+				HintInterval: timeutil.Interval(timeutil.OneMinute),
+				TimeRange:    timeutil.TimeRange{Start: now - timeutil.OneHour, End: now},
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown hint",
+			statement: &stmt.Query{
+				IntervalHint: stmt.IntervalHintType(100),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := CalcTimeRangeAndInterval(tt.statement, cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CalcTimeRangeAndInterval() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.Equal(t, tt.storageInterval, tt.statement.StorageInterval)
+			}
+		})
+	}
+
+	t.Run("no rollup interval", func(t *testing.T) {
+		err := CalcTimeRangeAndInterval(&stmt.Query{IntervalHint: stmt.RollupInterval}, models.Database{
+			Option: &option.DatabaseOption{
+				Intervals: option.Intervals{{Interval: timeutil.Interval(timeutil.OneMinute)}},
+			},
+		})
+		assert.Error(t, err)
+	})
+
+	t.Run("rollup interval being backfilled", func(t *testing.T) {
+		opt := &option.DatabaseOption{
+			Intervals: option.Intervals{
+				{Interval: timeutil.Interval(10 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)},
+				{Interval: timeutil.Interval(5 * timeutil.OneMinute), Retention: timeutil.Interval(timeutil.OneMonth)},
+				{Interval: timeutil.Interval(timeutil.OneHour), Retention: timeutil.Interval(timeutil.OneYear),
+					AvailableFrom: now - timeutil.OneDay},
+			},
+		}
+		for _, hint := range []stmt.IntervalHintType{stmt.AutoInterval, stmt.RollupInterval} {
+			// history data of hour interval is incomplete
+			q := &stmt.Query{
+				Interval:     timeutil.Interval(2 * timeutil.OneHour),
+				IntervalHint: hint,
+				TimeRange:    timeutil.TimeRange{Start: now - 2*timeutil.OneDay, End: now},
+			}
+			assert.NoError(t, CalcTimeRangeAndInterval(q, models.Database{Option: opt}))
+			assert.Equal(t, timeutil.Interval(5*timeutil.OneMinute), q.StorageInterval)
+			// data of hour interval is complete in time range
+			q = &stmt.Query{
+				Interval:     timeutil.Interval(2 * timeutil.OneHour),
+				IntervalHint: hint,
+				TimeRange:    timeutil.TimeRange{Start: now - timeutil.OneHour, End: now},
+			}
+			assert.NoError(t, CalcTimeRangeAndInterval(q, models.Database{Option: opt}))
+			assert.Equal(t, timeutil.Interval(timeutil.OneHour), q.StorageInterval)
+		}
+	})
+}
+
+func TestClampTimeRangeByRetention(t *testing.T) {
+	now := timeutil.Now()
+	assert.False(t, ClampTimeRangeByRetention(&stmt.Query{
+		TimeRange: timeutil.TimeRange{Start: now - 90*timeutil.OneDay, End: now},
+	}, models.Database{}))
+
+	cfg := models.Database{
+		Option: &option.DatabaseOption{
+			Intervals: option.Intervals{
+				{Interval: timeutil.Interval(10 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneDay)},
+				{Interval: timeutil.Interval(5 * timeutil.OneMinute), Retention: timeutil.Interval(30 * timeutil.OneDay)},
+			},
+		},
+	}
+	cases := []struct {
+		name      string
+		statement *stmt.Query
+		clamped   bool
+		start     int64
+	}{
+		{
+			name:      "in retention",
+			statement: &stmt.Query{TimeRange: timeutil.TimeRange{Start: now - timeutil.OneHour, End: now}},
+			start:     now - timeutil.OneHour,
+		},
+		{
+			name:      "clamp to retention of rollup interval",
+			statement: &stmt.Query{TimeRange: timeutil.TimeRange{Start: now - 90*timeutil.OneDay, End: now}},
+			clamped:   true,
+			start:     now - 30*timeutil.OneDay,
+		},
+		{
+			name: "whole time range out of retention",
+			statement: &stmt.Query{
+				TimeRange: timeutil.TimeRange{Start: now - 90*timeutil.OneDay, End: now - 60*timeutil.OneDay},
+			},
+			clamped: true,
+			start:   now - 30*timeutil.OneDay,
+		},
+		{
+			name: "hint interval out of retention",
+			statement: &stmt.Query{
+				IntervalHint: stmt.RawInterval,
+				TimeRange:    timeutil.TimeRange{Start: now - 2*timeutil.OneDay, End: now},
+			},
+			start: now - 2*timeutil.OneDay,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.clamped, ClampTimeRangeByRetention(tt.statement, cfg))
+			// now may be changed when clamping
+			assert.InDelta(t, tt.start, tt.statement.TimeRange.Start, float64(timeutil.OneMinute))
+		})
+	}
+}
+
+func Test_remainingTimeout(t *testing.T) {
+	assert.Zero(t, remainingTimeout(context.TODO()))
+	ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+	defer cancel()
+	remaining := remainingTimeout(ctx)
+	assert.True(t, remaining > 0 && remaining <= int64(time.Minute))
+	ctx1, cancel1 := context.WithTimeout(context.TODO(), time.Nanosecond)
+	defer cancel1()
+	<-ctx1.Done()
+	assert.Equal(t, int64(time.Nanosecond), remainingTimeout(ctx1))
+}
@@ -0,0 +1,500 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/models"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// crossMetricSubQueryLimit represents the limit of sub query, all groups are needed for aligning.
+const crossMetricSubQueryLimit = math.MaxInt32
+
+// searchFunc represents the metric data search function.
+type searchFunc func(ctx context.Context,
+	param *models.ExecuteParam, statement *stmtpkg.Query,
+	mgr *SearchMgr,
+) (any, error)
+
+// crossMetricPlan represents the plan of cross metric query, like: select cpu.used/mem.total*100 from cpu group by host.
+// 1. each metric is planned as a sub query, field name is qualified with metric name(split by the last dot);
+// 2. the result set of sub queries is aligned on the group by tag values and time slots;
+// 3. the aligned operands are combined point-wise based on select items, fill policy for missing series/slots.
+type crossMetricPlan struct {
+	statement  *stmtpkg.Query
+	metrics    []string                  // metric names in order of appearance
+	subQueries map[string]*stmtpkg.Query // metric name => sub query
+	operands   map[string]string         // operand(qualified expr) => metric name
+	groupBy    []string
+}
+
+// newCrossMetricPlan creates the cross metric plan if query references fields qualified with metric name,
+// returns nil if it is not a cross metric query.
+func newCrossMetricPlan(statement *stmtpkg.Query) (*crossMetricPlan, error) {
+	if !isCrossMetricQuery(statement) {
+		return nil, nil
+	}
+	if len(statement.OrderByItems) > 0 {
+		return nil, fmt.Errorf("%w, order by is not supported", ErrCrossMetricQuery)
+	}
+	p := &crossMetricPlan{
+		statement:  statement,
+		subQueries: make(map[string]*stmtpkg.Query),
+		operands:   make(map[string]string),
+	}
+	for _, item := range statement.SelectItems {
+		if err := p.planExpr(item); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.planGroupBy(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// isCrossMetricQuery returns if any field of select items is qualified with the metric name of from clause.
+func isCrossMetricQuery(statement *stmtpkg.Query) bool {
+	prefix := statement.MetricName + "."
+	found := false
+	var walk func(expr stmtpkg.Expr)
+	walk = func(expr stmtpkg.Expr) {
+		switch e := expr.(type) {
+		case *stmtpkg.SelectItem:
+			walk(e.Expr)
+		case *stmtpkg.ParenExpr:
+			walk(e.Expr)
+		case *stmtpkg.BinaryExpr:
+			walk(e.Left)
+			walk(e.Right)
+		case *stmtpkg.CallExpr:
+			for _, param := range e.Params {
+				walk(param)
+			}
+		case *stmtpkg.FieldExpr:
+			if strings.HasPrefix(e.Name, prefix) {
+				found = true
+			}
+		}
+	}
+	for _, item := range statement.SelectItems {
+		walk(item)
+	}
+	return found
+}
+
+// splitQualifiedName splits qualified name into metric name and field/tag key by the last dot.
+func splitQualifiedName(name string) (metricName, key string, ok bool) {
+	idx := strings.LastIndex(name, ".")
+	if idx <= 0 || idx == len(name)-1 {
+		return "", "", false
+	}
+	return name[:idx], name[idx+1:], true
+}
+
+// planExpr plans the expression of select item, finds all operands which belong to one metric.
+func (p *crossMetricPlan) planExpr(expr stmtpkg.Expr) error {
+	switch e := expr.(type) {
+	case *stmtpkg.SelectItem:
+		return p.planExpr(e.Expr)
+	case *stmtpkg.ParenExpr:
+		return p.planExpr(e.Expr)
+	case *stmtpkg.BinaryExpr:
+		if err := p.planExpr(e.Left); err != nil {
+			return err
+		}
+		return p.planExpr(e.Right)
+	case *stmtpkg.NumberLiteral:
+		return nil
+	case *stmtpkg.FieldExpr, *stmtpkg.CallExpr:
+		return p.planOperand(expr)
+	default:
+		return fmt.Errorf("%w, not support expr: %s", ErrCrossMetricQuery, expr.Rewrite())
+	}
+}
+
+// planOperand adds the operand into the sub query of its metric, operand's result is named by qualified expr.
+func (p *crossMetricPlan) planOperand(expr stmtpkg.Expr) error {
+	key := expr.Rewrite()
+	if _, ok := p.operands[key]; ok {
+		return nil
+	}
+	metricName := ""
+	operand, err := unqualifyExpr(expr, &metricName)
+	if err != nil {
+		return err
+	}
+	if metricName == "" {
+		return fmt.Errorf("%w, cannot find metric of expr: %s", ErrCrossMetricQuery, key)
+	}
+	subQuery, ok := p.subQueries[metricName]
+	if !ok {
+		q := *p.statement
+		q.MetricName = metricName
+		q.SelectItems = nil
+		q.OrderByItems = nil
+		q.Limit = crossMetricSubQueryLimit
+		q.Offset = 0
+		q.Fill = stmtpkg.FillDrop
+		q.FillValue = 0
+		if p.statement.Fill == stmtpkg.FillLinear {
+			// interpolate each metric before combining, which needs the real points of metric
+			q.Fill = stmtpkg.FillLinear
+		}
+		subQuery = &q
+		p.subQueries[metricName] = subQuery
+		p.metrics = append(p.metrics, metricName)
+	}
+	subQuery.SelectItems = append(subQuery.SelectItems, &stmtpkg.SelectItem{Expr: operand, Alias: key})
+	p.operands[key] = metricName
+	return nil
+}
+
+// unqualifyExpr strips the metric name from field names, all fields of expr must belong to the same metric.
+func unqualifyExpr(expr stmtpkg.Expr, metricName *string) (stmtpkg.Expr, error) {
+	switch e := expr.(type) {
+	case *stmtpkg.FieldExpr:
+		name, fieldName, ok := splitQualifiedName(e.Name)
+		if !ok {
+			return nil, fmt.Errorf("%w, field must be qualified with metric name, like: metric.field, field: %s",
+				ErrCrossMetricQuery, e.Name)
+		}
+		if *metricName != "" && *metricName != name {
+			return nil, fmt.Errorf("%w, function cannot be applied across metrics: %s and %s",
+				ErrCrossMetricQuery, *metricName, name)
+		}
+		*metricName = name
+		return &stmtpkg.FieldExpr{Name: fieldName}, nil
+	case *stmtpkg.CallExpr:
+		call := &stmtpkg.CallExpr{FuncType: e.FuncType}
+		for _, param := range e.Params {
+			operand, err := unqualifyExpr(param, metricName)
+			if err != nil {
+				return nil, err
+			}
+			call.Params = append(call.Params, operand)
+		}
+		return call, nil
+	case *stmtpkg.ParenExpr:
+		operand, err := unqualifyExpr(e.Expr, metricName)
+		if err != nil {
+			return nil, err
+		}
+		return &stmtpkg.ParenExpr{Expr: operand}, nil
+	case *stmtpkg.BinaryExpr:
+		left, err := unqualifyExpr(e.Left, metricName)
+		if err != nil {
+			return nil, err
+		}
+		right, err := unqualifyExpr(e.Right, metricName)
+		if err != nil {
+			return nil, err
+		}
+		return &stmtpkg.BinaryExpr{Left: left, Right: right, Operator: e.Operator}, nil
+	default:
+		return expr, nil
+	}
+}
+
+// planGroupBy plans the group by tag keys for each sub query,
+// tag key can be qualified with metric name, all metrics must group by same tag keys.
+func (p *crossMetricPlan) planGroupBy() error {
+	groupBy := make(map[string][]string)
+	for _, key := range p.statement.GroupBy {
+		metricName, tagKey, ok := splitQualifiedName(key)
+		if _, exist := p.subQueries[metricName]; ok && exist {
+			groupBy[metricName] = append(groupBy[metricName], tagKey)
+			continue
+		}
+		for _, name := range p.metrics {
+			groupBy[name] = append(groupBy[name], key)
+		}
+	}
+	p.groupBy = groupBy[p.metrics[0]]
+	for _, metricName := range p.metrics[1:] {
+		tagKeys := groupBy[metricName]
+		if strings.Join(tagKeys, ",") != strings.Join(p.groupBy, ",") {
+			return fmt.Errorf("%w, group by tag keys not match, metric: %s group by %v, metric: %s group by %v, "+
+				"all metrics must group by same tag keys",
+				ErrCrossMetricQuery, p.metrics[0], p.groupBy, metricName, tagKeys)
+		}
+	}
+	for _, metricName := range p.metrics {
+		p.subQueries[metricName].GroupBy = groupBy[metricName]
+	}
+	return nil
+}
+
+// execute executes all sub queries concurrently, then combines the result set of sub queries.
+func (p *crossMetricPlan) execute(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, search searchFunc,
+) (any, error) {
+	startTime := time.Now()
+	resultSets := make([]*models.ResultSet, len(p.metrics))
+	errs := make([]error, len(p.metrics))
+	var wg sync.WaitGroup
+	for idx := range p.metrics {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			subParam := *param
+			subParam.Stream = nil
+			subMgr := *mgr
+			subMgr.RequestID = "" // each sub query is an independent request
+			rs, err := search(ctx, &subParam, p.subQueries[p.metrics[idx]], &subMgr)
+			if err != nil {
+				errs[idx] = err
+				return
+			}
+			resultSet, ok := rs.(*models.ResultSet)
+			if !ok || resultSet == nil {
+				resultSet = models.NewResultSet()
+			}
+			resultSets[idx] = resultSet
+		}(idx)
+	}
+	wg.Wait()
+	for idx, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("sub query of metric: %s failure, %w", p.metrics[idx], err)
+		}
+	}
+	resultSet, err := p.combine(resultSets, param.Stream)
+	if err != nil {
+		return nil, err
+	}
+	for _, rs := range resultSets {
+		resultSet.Stale = resultSet.Stale.Merge(rs.Stale)
+		resultSet.MergePartial(rs)
+	}
+	if p.statement.Explain {
+		now := time.Now()
+		stats := &models.NodeStats{
+			Node:      mgr.CurNode.Indicator(),
+			Start:     startTime.UnixNano(),
+			End:       now.UnixNano(),
+			TotalCost: now.Sub(startTime).Nanoseconds(),
+		}
+		for _, rs := range resultSets {
+			if rs.Stats != nil {
+				stats.Children = append(stats.Children, rs.Stats)
+			}
+		}
+		resultSet.Stats = stats
+	}
+	return resultSet, nil
+}
+
+// combine aligns the result set of sub queries on group by tag values/timestamps, then evaluates select items.
+func (p *crossMetricPlan) combine(resultSets []*models.ResultSet, stream models.ResultStream) (*models.ResultSet, error) {
+	statement := p.statement
+	resultSet := models.NewResultSet()
+	resultSet.MetricName = statement.MetricName
+	resultSet.GroupBy = p.groupBy
+	for _, item := range statement.SelectItems {
+		resultSet.Fields = append(resultSet.Fields, SelectItemName(item))
+	}
+	for _, rs := range resultSets {
+		if rs.Interval > 0 {
+			resultSet.StartTime = rs.StartTime
+			resultSet.EndTime = rs.EndTime
+			resultSet.Interval = rs.Interval
+			break
+		}
+	}
+
+	// align series by group by tag values
+	rows := make(map[string]map[string]*models.Series) // tag values => metric name => series
+	var groups []string
+	for idx, rs := range resultSets {
+		metricName := p.metrics[idx]
+		for _, s := range rs.Series {
+			row, ok := rows[s.TagValues]
+			if !ok {
+				row = make(map[string]*models.Series)
+				rows[s.TagValues] = row
+				groups = append(groups, s.TagValues)
+			}
+			row[metricName] = s
+		}
+	}
+	sort.Strings(groups)
+
+	offset := 0
+	count := 0
+	for _, tagValues := range groups {
+		if count >= statement.Limit {
+			break
+		}
+		row := rows[tagValues]
+		if statement.Fill == stmtpkg.FillDrop && len(row) != len(p.metrics) {
+			// series missing in some metric, drop it
+			continue
+		}
+		timeSeries, ok := p.evalSeries(tagValues, row)
+		if !ok {
+			continue
+		}
+		if offset < statement.Offset {
+			offset++
+			continue
+		}
+		count++
+		if stream != nil {
+			if err := stream.WriteSeries(timeSeries); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		resultSet.AddSeries(timeSeries)
+	}
+	return resultSet, nil
+}
+
+// evalSeries evaluates the select items point-wise for aligned series of one group,
+// returns false if no point for fill drop policy.
+func (p *crossMetricPlan) evalSeries(tagValues string, row map[string]*models.Series) (*models.Series, bool) {
+	var tags map[string]string
+	timestamps := make(map[int64]struct{})
+	for operand, metricName := range p.operands {
+		s, ok := row[metricName]
+		if !ok {
+			continue
+		}
+		tags = s.Tags
+		for timestamp := range s.Fields[operand] {
+			timestamps[timestamp] = struct{}{}
+		}
+	}
+	sortedTimestamps := make([]int64, 0, len(timestamps))
+	for timestamp := range timestamps {
+		sortedTimestamps = append(sortedTimestamps, timestamp)
+	}
+	sort.Slice(sortedTimestamps, func(i, j int) bool {
+		return sortedTimestamps[i] < sortedTimestamps[j]
+	})
+
+	eval := &pointEvaluator{
+		plan:     p,
+		row:      row,
+		previous: make(map[string]float64),
+	}
+	points := make([]*models.Points, len(p.statement.SelectItems))
+	for idx := range points {
+		points[idx] = models.NewPoints()
+	}
+	hasPoint := false
+	for _, timestamp := range sortedTimestamps {
+		eval.timestamp = timestamp
+		for idx, item := range p.statement.SelectItems {
+			if val, ok := eval.eval(item); ok {
+				points[idx].AddPoint(timestamp, val)
+				hasPoint = true
+			}
+		}
+	}
+	if !hasPoint && p.statement.Fill == stmtpkg.FillDrop {
+		return nil, false
+	}
+	timeSeries := models.NewSeries(tags, tagValues)
+	for idx, item := range p.statement.SelectItems {
+		timeSeries.AddField(SelectItemName(item), points[idx])
+	}
+	return timeSeries, true
+}
+
+// pointEvaluator evaluates the expression for one timestamp of aligned series.
+type pointEvaluator struct {
+	plan      *crossMetricPlan
+	row       map[string]*models.Series
+	previous  map[string]float64 // operand => previous value, for fill previous
+	timestamp int64
+}
+
+// eval evaluates the expression, returns false if value is null.
+func (e *pointEvaluator) eval(expr stmtpkg.Expr) (float64, bool) {
+	switch ex := expr.(type) {
+	case *stmtpkg.SelectItem:
+		return e.eval(ex.Expr)
+	case *stmtpkg.ParenExpr:
+		return e.eval(ex.Expr)
+	case *stmtpkg.NumberLiteral:
+		return ex.Val, true
+	case *stmtpkg.BinaryExpr:
+		left, ok := e.eval(ex.Left)
+		if !ok {
+			return 0, false
+		}
+		right, ok := e.eval(ex.Right)
+		if !ok {
+			return 0, false
+		}
+		switch ex.Operator {
+		case stmtpkg.ADD:
+			return left + right, true
+		case stmtpkg.SUB:
+			return left - right, true
+		case stmtpkg.MUL:
+			return left * right, true
+		case stmtpkg.DIV:
+			if right == 0 {
+				// division by zero is null
+				return 0, false
+			}
+			return left / right, true
+		}
+		return 0, false
+	default:
+		return e.operand(expr.Rewrite())
+	}
+}
+
+// operand returns the value of operand at current timestamp, fills the missing value based on fill policy.
+func (e *pointEvaluator) operand(key string) (float64, bool) {
+	if s, ok := e.row[e.plan.operands[key]]; ok {
+		if val, ok := s.Fields[key][e.timestamp]; ok && !math.IsNaN(val) {
+			e.previous[key] = val
+			return val, true
+		}
+	}
+	switch e.plan.statement.Fill {
+	case stmtpkg.FillValue:
+		return e.plan.statement.FillValue, true
+	case stmtpkg.FillPrevious:
+		val, ok := e.previous[key]
+		return val, ok
+	default:
+		return 0, false
+	}
+}
+
+// SelectItemName returns the field name of select item in result set, alias first.
+func SelectItemName(item stmtpkg.Expr) string {
+	if selectItem, ok := item.(*stmtpkg.SelectItem); ok && len(selectItem.Alias) > 0 {
+		return selectItem.Alias
+	}
+	return item.Rewrite()
+}
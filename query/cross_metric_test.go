@@ -0,0 +1,309 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/sql"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+func parseQuery(t *testing.T, sqlStr string) *stmtpkg.Query {
+	q, err := sql.Parse(sqlStr)
+	assert.NoError(t, err)
+	return q.(*stmtpkg.Query)
+}
+
+func TestNewCrossMetricPlan(t *testing.T) {
+	cases := []struct {
+		name    string
+		sql     string
+		wantErr bool
+		assert  func(p *crossMetricPlan)
+	}{
+		{
+			name: "not cross metric query",
+			sql:  "select used/total from cpu group by host",
+			assert: func(p *crossMetricPlan) {
+				assert.Nil(t, p)
+			},
+		},
+		{
+			name: "cross metric query",
+			sql:  "select cpu.used/sum(system.mem.total)*100 as usage, max(cpu.used) from cpu group by host",
+			assert: func(p *crossMetricPlan) {
+				assert.Equal(t, []string{"cpu", "system.mem"}, p.metrics)
+				assert.Equal(t, []string{"host"}, p.groupBy)
+				cpu := p.subQueries["cpu"]
+				assert.Len(t, cpu.SelectItems, 2)
+				assert.Equal(t, "used as cpu.used", cpu.SelectItems[0].Rewrite())
+				assert.Equal(t, "max(used) as max(cpu.used)", cpu.SelectItems[1].Rewrite())
+				assert.Equal(t, []string{"host"}, cpu.GroupBy)
+				assert.Equal(t, crossMetricSubQueryLimit, cpu.Limit)
+				mem := p.subQueries["system.mem"]
+				assert.Equal(t, "system.mem", mem.MetricName)
+				assert.Equal(t, "sum(total) as sum(system.mem.total)", mem.SelectItems[0].Rewrite())
+			},
+		},
+		{
+			name: "qualified group by tag keys",
+			sql:  "select cpu.used/mem.total from cpu group by dc, cpu.host, mem.host",
+			assert: func(p *crossMetricPlan) {
+				assert.Equal(t, []string{"dc", "host"}, p.subQueries["cpu"].GroupBy)
+				assert.Equal(t, []string{"dc", "host"}, p.subQueries["mem"].GroupBy)
+			},
+		},
+		{
+			name: "fill linear interpolates each metric",
+			sql:  "select cpu.used/mem.total from cpu group by host, time(1m) fill(linear)",
+			assert: func(p *crossMetricPlan) {
+				assert.Equal(t, stmtpkg.FillLinear, p.subQueries["cpu"].Fill)
+				assert.Equal(t, stmtpkg.FillLinear, p.subQueries["mem"].Fill)
+			},
+		},
+		{
+			name: "fill previous applies on combining",
+			sql:  "select cpu.used/mem.total from cpu group by host fill(previous)",
+			assert: func(p *crossMetricPlan) {
+				assert.Equal(t, stmtpkg.FillDrop, p.subQueries["cpu"].Fill)
+				assert.Equal(t, stmtpkg.FillDrop, p.subQueries["mem"].Fill)
+			},
+		},
+		{
+			name: "sub queries use same alignment",
+			sql:  "select cpu.used/mem.total from cpu group by host, time(1m) fill(0) align(end)",
+			assert: func(p *crossMetricPlan) {
+				assert.Equal(t, stmtpkg.AlignEnd, p.subQueries["cpu"].Align)
+				assert.Equal(t, stmtpkg.AlignEnd, p.subQueries["mem"].Align)
+			},
+		},
+		{
+			name:    "group by not match",
+			sql:     "select cpu.used/mem.total from cpu group by cpu.host, mem.ip",
+			wantErr: true,
+		},
+		{
+			name:    "group by missing for some metric",
+			sql:     "select cpu.used/mem.total from cpu group by cpu.host",
+			wantErr: true,
+		},
+		{
+			name:    "order by not support",
+			sql:     "select cpu.used/mem.total as f from cpu order by f",
+			wantErr: true,
+		},
+		{
+			name:    "field not qualified",
+			sql:     "select cpu.used/total from cpu",
+			wantErr: true,
+		},
+		{
+			name:    "function across metrics",
+			sql:     "select sum(cpu.used/mem.total) from cpu",
+			wantErr: true,
+		},
+		{
+			name:    "cannot find metric",
+			sql:     "select cpu.used/quantile(0.99) from cpu",
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newCrossMetricPlan(parseQuery(t, tt.sql))
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrCrossMetricQuery))
+				return
+			}
+			assert.NoError(t, err)
+			tt.assert(p)
+		})
+	}
+}
+
+func TestCrossMetricPlan_execute(t *testing.T) {
+	cpu := &models.ResultSet{
+		Interval: 10,
+		Series: []*models.Series{
+			{Tags: map[string]string{"host": "a"}, TagValues: "a", Fields: map[string]map[int64]float64{
+				"cpu.used": {10: 1, 20: 2, 30: 3},
+			}},
+			{Tags: map[string]string{"host": "b"}, TagValues: "b", Fields: map[string]map[int64]float64{
+				"cpu.used": {10: 5},
+			}},
+			{Tags: map[string]string{"host": "c"}, TagValues: "c", Fields: map[string]map[int64]float64{
+				"cpu.used": {10: 6},
+			}},
+		},
+		Stats: &models.NodeStats{Node: "cpu"},
+	}
+	mem := &models.ResultSet{
+		Interval: 10,
+		Series: []*models.Series{
+			{Tags: map[string]string{"host": "a"}, TagValues: "a", Fields: map[string]map[int64]float64{
+				"mem.total": {10: 10, 30: 0},
+			}},
+			{Tags: map[string]string{"host": "b"}, TagValues: "b", Fields: map[string]map[int64]float64{
+				"mem.total": {10: 10},
+			}},
+			{Tags: map[string]string{"host": "d"}, TagValues: "d", Fields: map[string]map[int64]float64{
+				"mem.total": {10: 10},
+			}},
+		},
+		Stale: &models.StaleResult{Shards: []models.ShardID{1}, MissingRecentSeconds: 5},
+	}
+	search := func(_ context.Context, _ *models.ExecuteParam, statement *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		switch statement.MetricName {
+		case "cpu":
+			return cpu, nil
+		case "mem":
+			return mem, nil
+		default:
+			return nil, fmt.Errorf("err")
+		}
+	}
+	mgr := &SearchMgr{RequestID: "req"}
+
+	cases := []struct {
+		name    string
+		sql     string
+		stream  *fakeResultStream
+		wantErr bool
+		assert  func(rs *models.ResultSet)
+	}{
+		{
+			name:    "sub query failure",
+			sql:     "select cpu.used/disk.total from cpu group by host",
+			wantErr: true,
+		},
+		{
+			name: "fill drop",
+			sql:  "select (cpu.used/mem.total)*100 as usage from cpu group by host",
+			assert: func(rs *models.ResultSet) {
+				assert.Equal(t, []string{"usage"}, rs.Fields)
+				assert.Equal(t, []string{"host"}, rs.GroupBy)
+				assert.Equal(t, int64(10), rs.Interval)
+				assert.Len(t, rs.Series, 2)
+				// division by zero is null, slot missing is dropped
+				assert.Equal(t, map[int64]float64{10: 10}, rs.Series[0].Fields["usage"])
+				assert.Equal(t, map[int64]float64{10: 50}, rs.Series[1].Fields["usage"])
+				assert.Nil(t, rs.Stats)
+				assert.Equal(t, &models.StaleResult{Shards: []models.ShardID{1}, MissingRecentSeconds: 5}, rs.Stale)
+			},
+		},
+		{
+			name: "fill null",
+			sql:  "select cpu.used/mem.total as usage from cpu group by host fill(NULL)",
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, rs.Series, 4)
+				assert.Equal(t, "c", rs.Series[2].Tags["host"])
+				assert.Empty(t, rs.Series[2].Fields["usage"])
+				assert.Empty(t, rs.Series[3].Fields["usage"])
+			},
+		},
+		{
+			name: "fill value",
+			sql:  "select cpu.used+mem.total as f from cpu group by host fill(0)",
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, rs.Series, 4)
+				assert.Equal(t, map[int64]float64{10: 11, 20: 2, 30: 3}, rs.Series[0].Fields["f"])
+				assert.Equal(t, map[int64]float64{10: 6}, rs.Series[2].Fields["f"])
+				assert.Equal(t, map[int64]float64{10: 10}, rs.Series[3].Fields["f"])
+			},
+		},
+		{
+			name: "fill previous",
+			sql:  "select cpu.used-mem.total as f from cpu group by host fill(previous)",
+			assert: func(rs *models.ResultSet) {
+				assert.Equal(t, map[int64]float64{10: -9, 20: -8, 30: 3}, rs.Series[0].Fields["f"])
+			},
+		},
+		{
+			name: "limit/offset",
+			sql:  "select cpu.used+mem.total as f from cpu group by host fill(0) limit 2 offset 1",
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, rs.Series, 2)
+				assert.Equal(t, "b", rs.Series[0].Tags["host"])
+				assert.Equal(t, "c", rs.Series[1].Tags["host"])
+			},
+		},
+		{
+			name: "explain",
+			sql:  "explain select cpu.used/mem.total from cpu group by host",
+			assert: func(rs *models.ResultSet) {
+				assert.NotNil(t, rs.Stats)
+				assert.Len(t, rs.Stats.Children, 1)
+			},
+		},
+		{
+			name:   "stream",
+			sql:    "select cpu.used/mem.total from cpu group by host",
+			stream: &fakeResultStream{},
+			assert: func(rs *models.ResultSet) {
+				assert.Empty(t, rs.Series)
+				assert.Equal(t, []string{"cpu.used/mem.total"}, rs.Fields)
+			},
+		},
+		{
+			name:    "stream failure",
+			sql:     "select cpu.used/mem.total from cpu group by host",
+			stream:  &fakeResultStream{err: fmt.Errorf("err")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newCrossMetricPlan(parseQuery(t, tt.sql))
+			assert.NoError(t, err)
+			param := &models.ExecuteParam{}
+			if tt.stream != nil {
+				param.Stream = tt.stream
+			}
+			rs, err := p.execute(context.TODO(), param, mgr, search)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.assert(rs.(*models.ResultSet))
+		})
+	}
+}
+
+// fakeResultStream collects the series written into stream.
+type fakeResultStream struct {
+	series []*models.Series
+	err    error
+}
+
+func (s *fakeResultStream) WriteSeries(series *models.Series) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.series = append(s.series, series)
+	return nil
+}
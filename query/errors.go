@@ -33,4 +33,8 @@ var (
 	ErrTaskSend                    = errors.New("send task request error")
 	ErrResponseSend                = errors.New("send response error")
 	ErrNoDatabase                  = errors.New("not found database")
+	ErrCrossMetricQuery            = errors.New("invalid cross metric query")
+	ErrFieldAliasQuery             = errors.New("invalid query with field alias")
+	ErrSubQuery                    = errors.New("invalid sub query")
+	ErrLocalQuery                  = errors.New("invalid local query")
 )
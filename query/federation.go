@@ -0,0 +1,293 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	queryctx "github.com/lindb/lindb/query/context"
+	trackerpkg "github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/series/tag"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// DatabaseTagKey represents the implicit tag key of database for the series of multiple databases query.
+const DatabaseTagKey = "_db"
+
+// ErrFederatedQuery represents the error of multiple databases query.
+var ErrFederatedQuery = errors.New("multiple databases query failure")
+
+// subQueryFunc represents the function which executes sub query of database,
+// returns the time series blocks and the result set(only includes stats/stale trailer) of sub query.
+type subQueryFunc func(ctx context.Context,
+	param *models.ExecuteParam, database string, mgr *SearchMgr,
+) ([][]byte, *models.ResultSet, error)
+
+// federatedPlan represents the plan of multiple databases query, like: select f from cpu with databases=db1,db2.
+// 1. an identical sub query is planned for each database, executes concurrently;
+// 2. the time series blocks of sub queries are tagged with database(_db tag key) if it keeps series of each database;
+// 3. all blocks are merged by root context before final aggregation, so aggregation across databases also works.
+type federatedPlan struct {
+	statement *stmtpkg.Query // final statement, group by includes _db if not merge databases
+	subQuery  *stmtpkg.Query // sub query for each database, group by excludes _db
+	databases []string
+	dbTagIdx  int // index of _db in group by tag keys, -1 if merge databases
+}
+
+// newFederatedPlan creates the plan of multiple databases query.
+func newFederatedPlan(statement *stmtpkg.Query, databases []string, mergeDatabases bool) (*federatedPlan, error) {
+	if isCrossMetricQuery(statement) {
+		return nil, fmt.Errorf("%w, cross metric query is not supported", ErrFederatedQuery)
+	}
+	if isMetricPattern(statement.MetricName) {
+		return nil, fmt.Errorf("%w, metric name pattern is not supported", ErrFederatedQuery)
+	}
+	p := &federatedPlan{
+		databases: databases,
+		dbTagIdx:  -1,
+	}
+	var subGroupBy []string
+	for idx, tagKey := range statement.GroupBy {
+		if tagKey == DatabaseTagKey {
+			// group by _db explicitly
+			p.dbTagIdx = idx
+			continue
+		}
+		subGroupBy = append(subGroupBy, tagKey)
+	}
+	q := *statement
+	if p.dbTagIdx < 0 && !mergeDatabases {
+		// keep series of each database, group by _db implicitly
+		p.dbTagIdx = 0
+		q.GroupBy = append([]string{DatabaseTagKey}, statement.GroupBy...)
+	}
+	p.statement = &q
+
+	subQuery := *statement
+	subQuery.GroupBy = subGroupBy
+	subQuery.OrderByItems = nil
+	subQuery.Limit = crossMetricSubQueryLimit // all groups are needed for merging
+	subQuery.Offset = 0
+	p.subQuery = &subQuery
+	return p, nil
+}
+
+// execute executes sub query of each database concurrently, then merges the time series blocks of sub queries.
+func (p *federatedPlan) execute(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, subQuery subQueryFunc,
+) (any, error) {
+	startTime := time.Now()
+	blocks := make([][][]byte, len(p.databases))
+	trailers := make([]*models.ResultSet, len(p.databases))
+	errs := make([]error, len(p.databases))
+	var wg sync.WaitGroup
+	for idx := range p.databases {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			database := p.databases[idx]
+			subParam := *param
+			subParam.Database = database
+			subParam.Databases = ""
+			subParam.Stream = nil
+			if err := authorize(ctx, &subParam, database, mgr); err != nil {
+				errs[idx] = err
+				return
+			}
+			blocks[idx], trailers[idx], errs[idx] = subQuery(ctx, &subParam, database, mgr)
+		}(idx)
+	}
+	wg.Wait()
+	for idx, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("sub query of database: %s failure, %w", p.databases[idx], err)
+		}
+	}
+
+	var allBlocks [][]byte
+	for idx, dbBlocks := range blocks {
+		for _, block := range dbBlocks {
+			taggedBlock, err := p.tagBlock(block, p.databases[idx])
+			if err != nil {
+				return nil, err
+			}
+			allBlocks = append(allBlocks, taggedBlock)
+		}
+	}
+	return mergeSubQueryBlocks(ctx, param, mgr, &subQueryBlocks{
+		database:  param.Databases,
+		statement: p.statement,
+		startTime: startTime,
+		blocks:    allBlocks,
+		trailers:  trailers,
+		names:     p.databases,
+	})
+}
+
+// subQueryBlocks represents the time series blocks and result sets of sub queries which are merged as one result set.
+type subQueryBlocks struct {
+	database  string
+	statement *stmtpkg.Query // final statement
+	startTime time.Time
+	blocks    [][]byte
+	trailers  []*models.ResultSet // result set(only includes stats/stale trailer) of each sub query
+	names     []string            // name of each sub query, used as the node name prefix of stats
+}
+
+// mergeSubQueryBlocks merges the time series blocks of sub queries by root context, then does final aggregation/expression,
+// the stale/partial trailers and stats of sub queries are merged into final result set.
+func mergeSubQueryBlocks(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, subQueries *subQueryBlocks,
+) (any, error) {
+	memoryBudget, spillDir, _ := memoryBudgetOf(param, mgr)
+	taskCtx := queryctx.NewRootMetricContext(
+		&queryctx.RootMetricContextDeps{
+			Ctx:               ctx,
+			Request:           models.NewRequest(mgr.CurNode.Indicator(), subQueries.database, param.SQL),
+			Database:          subQueries.database,
+			CurrentNode:       mgr.CurNode,
+			Statement:         subQueries.statement,
+			Stream:            param.Stream,
+			MaxBufferedSeries: mgr.MaxBufferedSeries,
+			MemoryBudget:      memoryBudget,
+			SpillDir:          spillDir,
+		})
+	taskCtx.SetTracker(trackerpkg.NewStageTracker(&flow.TaskContext{Start: subQueries.startTime}))
+	rs, err := taskCtx.Replay(subQueries.blocks)
+	if err != nil {
+		return nil, err
+	}
+	resultSet, ok := rs.(*models.ResultSet)
+	if !ok {
+		return rs, nil
+	}
+	for _, trailer := range subQueries.trailers {
+		if trailer != nil {
+			resultSet.Stale = resultSet.Stale.Merge(trailer.Stale)
+			resultSet.MergePartial(trailer)
+		}
+	}
+	if resultSet.Partial {
+		// affected groups of each sub query are lost after merging blocks, mark all series as partial
+		for _, series := range resultSet.Series {
+			series.Partial = true
+		}
+	}
+	if subQueries.statement.Explain {
+		now := time.Now()
+		nodeStats := &models.NodeStats{
+			Node:      mgr.CurNode.Indicator(),
+			Start:     subQueries.startTime.UnixNano(),
+			End:       now.UnixNano(),
+			TotalCost: now.Sub(subQueries.startTime).Nanoseconds(),
+		}
+		for idx, trailer := range subQueries.trailers {
+			if trailer != nil && trailer.Stats != nil {
+				s := trailer.Stats
+				s.Node = fmt.Sprintf("%s[%s]", subQueries.names[idx], s.Node)
+				nodeStats.Children = append(nodeStats.Children, s)
+			}
+		}
+		resultSet.Stats = nodeStats
+	}
+	return rs, nil
+}
+
+// executeSubQuery executes the sub query of database, returns the time series blocks and result set of sub query.
+func (p *federatedPlan) executeSubQuery(ctx context.Context,
+	param *models.ExecuteParam, database string, mgr *SearchMgr,
+) (blocks [][]byte, trailer *models.ResultSet, err error) {
+	subParam := *param
+	subParam.Database = database
+	return executeBlocksQuery(ctx, &subParam, p.subQuery, mgr)
+}
+
+// executeBlocksQuery executes the query of database as an independent request,
+// returns the time series blocks before final aggregation and the result set(only includes stats/stale trailer).
+func executeBlocksQuery(ctx context.Context,
+	param *models.ExecuteParam, statement *stmtpkg.Query, mgr *SearchMgr,
+) (blocks [][]byte, trailer *models.ResultSet, err error) {
+	subMgr := *mgr
+	subMgr.RequestID = "" // each sub query is an independent request
+
+	database := param.Database
+	req := models.NewRequest(mgr.CurNode.Indicator(), database, param.SQL)
+	req.Client = param.Client
+	replicaPolicy, hedgeTimeout := replicaPolicyOf(param, database, mgr)
+	memoryBudget, spillDir, _ := memoryBudgetOf(param, mgr)
+	taskCtx := queryctx.NewRootMetricContext(
+		&queryctx.RootMetricContextDeps{
+			Ctx:               ctx,
+			Request:           req,
+			Database:          database,
+			CurrentNode:       mgr.CurNode,
+			Statement:         statement,
+			Choose:            mgr.Choose,
+			TransportMgr:      mgr.TransportMgr,
+			MaxBufferedSeries: mgr.MaxBufferedSeries,
+			MemoryBudget:      memoryBudget,
+			SpillDir:          spillDir,
+			KeepBlocks:        true,
+			ReplicaPolicy:     replicaPolicy,
+			HedgeTimeout:      hedgeTimeout,
+			ReplicaLag:        mgr.ReplicaLag,
+			Hedger:            mgr.Hedger,
+			Partial:           param.Partial,
+		})
+	rs, err := exec(taskCtx, req, &subMgr)
+	recordQuery(mgr, database, statement.Namespace, taskCtx, err)
+	if err != nil {
+		return nil, nil, err
+	}
+	trailer, _ = rs.(*models.ResultSet)
+	return taskCtx.Blocks(), trailer, nil
+}
+
+// tagBlock adds the database name into tag values of each time series in block if it keeps series of each database.
+func (p *federatedPlan) tagBlock(block []byte, database string) ([]byte, error) {
+	return insertTagValue(block, p.dbTagIdx, database)
+}
+
+// insertTagValue inserts the tag value of implicit group by tag key at index into tag values of each time series in block,
+// returns the block as it is if index < 0.
+func insertTagValue(block []byte, idx int, tagValue string) ([]byte, error) {
+	if idx < 0 {
+		return block, nil
+	}
+	tsList := &protoCommonV1.TimeSeriesList{}
+	if err := tsList.Unmarshal(block); err != nil {
+		return nil, err
+	}
+	for _, ts := range tsList.TimeSeriesList {
+		tagValues := tag.SplitTagValues(ts.Tags)
+		if idx > len(tagValues) {
+			// tag values not match group by tag keys, ignore it when building result set
+			continue
+		}
+		tagValues = append(tagValues[:idx], append([]string{tagValue}, tagValues[idx:]...)...)
+		ts.Tags = tag.ConcatTagValues(tagValues)
+	}
+	return tsList.Marshal()
+}
@@ -0,0 +1,228 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestNewFederatedPlan(t *testing.T) {
+	cases := []struct {
+		name       string
+		statement  *stmt.Query
+		merge      bool
+		groupBy    []string
+		subGroupBy []string
+		dbTagIdx   int
+		wantErr    bool
+	}{
+		{
+			name: "cross metric query",
+			statement: &stmt.Query{
+				MetricName:  "cpu",
+				SelectItems: []stmt.Expr{&stmt.FieldExpr{Name: "cpu.f"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "metric name pattern",
+			statement: &stmt.Query{MetricName: `cpu\..*`},
+			wantErr:   true,
+		},
+		{
+			name:       "group by database implicitly",
+			statement:  &stmt.Query{MetricName: "cpu", GroupBy: []string{"host"}},
+			groupBy:    []string{DatabaseTagKey, "host"},
+			subGroupBy: []string{"host"},
+			dbTagIdx:   0,
+		},
+		{
+			name:       "group by database explicitly",
+			statement:  &stmt.Query{MetricName: "cpu", GroupBy: []string{"host", DatabaseTagKey}},
+			merge:      true,
+			groupBy:    []string{"host", DatabaseTagKey},
+			subGroupBy: []string{"host"},
+			dbTagIdx:   1,
+		},
+		{
+			name:       "merge databases",
+			statement:  &stmt.Query{MetricName: "cpu", GroupBy: []string{"host"}, Limit: 10, Offset: 1},
+			merge:      true,
+			groupBy:    []string{"host"},
+			subGroupBy: []string{"host"},
+			dbTagIdx:   -1,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newFederatedPlan(tt.statement, []string{"db1", "db2"}, tt.merge)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrFederatedQuery)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.groupBy, p.statement.GroupBy)
+			assert.Equal(t, tt.subGroupBy, p.subQuery.GroupBy)
+			assert.Equal(t, tt.dbTagIdx, p.dbTagIdx)
+			assert.Equal(t, tt.statement.Limit, p.statement.Limit)
+			assert.Equal(t, crossMetricSubQueryLimit, p.subQuery.Limit)
+			assert.Zero(t, p.subQuery.Offset)
+		})
+	}
+}
+
+func TestFederatedPlan_tagBlock(t *testing.T) {
+	block, _ := (&protoCommonV1.TimeSeriesList{
+		TimeSeriesList: []*protoCommonV1.TimeSeries{{Tags: "a,b"}, {Tags: "c"}},
+	}).Marshal()
+	p := &federatedPlan{dbTagIdx: 1}
+	taggedBlock, err := p.tagBlock(block, "db")
+	assert.NoError(t, err)
+	tsList := &protoCommonV1.TimeSeriesList{}
+	assert.NoError(t, tsList.Unmarshal(taggedBlock))
+	assert.Equal(t, "a,db,b", tsList.TimeSeriesList[0].Tags)
+	assert.Equal(t, "c,db", tsList.TimeSeriesList[1].Tags)
+
+	p.dbTagIdx = 2
+	taggedBlock, err = p.tagBlock(block, "db")
+	assert.NoError(t, err)
+	tsList = &protoCommonV1.TimeSeriesList{}
+	assert.NoError(t, tsList.Unmarshal(taggedBlock))
+	assert.Equal(t, "a,b,db", tsList.TimeSeriesList[0].Tags)
+	assert.Equal(t, "c", tsList.TimeSeriesList[1].Tags) // not match, ignore it
+
+	_, err = p.tagBlock([]byte("abc"), "db")
+	assert.Error(t, err)
+
+	p.dbTagIdx = -1
+	taggedBlock, err = p.tagBlock(block, "db")
+	assert.NoError(t, err)
+	assert.Equal(t, block, taggedBlock)
+}
+
+type mockAuthorizer struct {
+	denied string
+}
+
+func (m *mockAuthorizer) Authorize(_ context.Context, _ *models.ExecuteParam, database string) error {
+	if database == m.denied {
+		return fmt.Errorf("access denied, database: %s", database)
+	}
+	return nil
+}
+
+func TestFederatedPlan_execute(t *testing.T) {
+	block := func(tags ...string) []byte {
+		tsList := &protoCommonV1.TimeSeriesList{
+			Start:    0,
+			End:      10 * timeutil.OneMinute,
+			Interval: timeutil.OneMinute,
+			FieldAggSpecs: []*protoCommonV1.AggregatorSpec{{
+				FieldName:    "f",
+				FieldType:    uint32(field.Sum),
+				FuncTypeList: []uint32{uint32(field.Sum)},
+			}},
+		}
+		for _, t := range tags {
+			tsList.TimeSeriesList = append(tsList.TimeSeriesList,
+				&protoCommonV1.TimeSeries{Tags: t, Fields: map[string][]byte{"f": nil}})
+		}
+		data, _ := tsList.Marshal()
+		return data
+	}
+	subQuery := func(_ context.Context, param *models.ExecuteParam, database string, _ *SearchMgr,
+	) ([][]byte, *models.ResultSet, error) {
+		assert.Equal(t, database, param.Database)
+		assert.Empty(t, param.Databases)
+		switch database {
+		case "db1":
+			return [][]byte{block("a", "b")}, &models.ResultSet{Stats: &models.NodeStats{Node: "broker"}}, nil
+		case "db2":
+			return [][]byte{block("a")}, &models.ResultSet{Stale: &models.StaleResult{Shards: []models.ShardID{1}}}, nil
+		default:
+			return nil, nil, fmt.Errorf("err")
+		}
+	}
+	statement := &stmt.Query{
+		MetricName:  "cpu",
+		SelectItems: []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}},
+		GroupBy:     []string{"host"},
+		Limit:       100,
+		Explain:     true,
+	}
+	param := &models.ExecuteParam{Databases: "db1,db2", SQL: "select f from cpu group by host"}
+
+	t.Run("keep series of each database", func(t *testing.T) {
+		p, err := newFederatedPlan(statement, []string{"db1", "db2"}, false)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, subQuery)
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.Equal(t, []string{DatabaseTagKey, "host"}, resultSet.GroupBy)
+		assert.Len(t, resultSet.Series, 3)
+		assert.Equal(t, map[string]string{DatabaseTagKey: "db1", "host": "a"}, resultSet.Series[0].Tags)
+		assert.Equal(t, map[string]string{DatabaseTagKey: "db2", "host": "a"}, resultSet.Series[2].Tags)
+		assert.Len(t, resultSet.Stats.Children, 1)
+		assert.Equal(t, "db1[broker]", resultSet.Stats.Children[0].Node)
+		assert.Equal(t, &models.StaleResult{Shards: []models.ShardID{1}}, resultSet.Stale)
+	})
+	t.Run("merge databases", func(t *testing.T) {
+		p, err := newFederatedPlan(statement, []string{"db1", "db2"}, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, subQuery)
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.Equal(t, []string{"host"}, resultSet.GroupBy)
+		assert.Len(t, resultSet.Series, 2)
+	})
+	t.Run("sub query failure", func(t *testing.T) {
+		p, err := newFederatedPlan(statement, []string{"db1", "db3"}, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, subQuery)
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+	t.Run("access denied", func(t *testing.T) {
+		p, err := newFederatedPlan(statement, []string{"db1", "db2"}, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{Authorizer: &mockAuthorizer{denied: "db2"}}, subQuery)
+		assert.ErrorContains(t, err, "access denied, database: db2")
+		assert.Nil(t, rs)
+	})
+	t.Run("bad block", func(t *testing.T) {
+		p, err := newFederatedPlan(statement, []string{"db1", "db2"}, false)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{},
+			func(_ context.Context, _ *models.ExecuteParam, _ string, _ *SearchMgr) ([][]byte, *models.ResultSet, error) {
+				return [][]byte{[]byte("abc")}, nil, nil
+			})
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+}
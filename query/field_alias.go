@@ -0,0 +1,328 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/models"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// fieldAliasPlan represents the plan of query which references renamed fields(field aliases of database),
+// 1. the query time range is split at the rename timestamps of referenced fields;
+// 2. each time range is planned as a sub query which reads the fields storing data in that time range,
+// the result name of select item keeps the name in original query;
+// 3. the result sets of sub queries are merged by group by tag values, the later time range wins on same slot.
+type fieldAliasPlan struct {
+	statement  *stmtpkg.Query
+	subQueries []*stmtpkg.Query // sub queries in time order
+	applied    []string         // applied field aliases for explain
+}
+
+// newFieldAliasPlan creates the field alias plan if query references renamed fields in query time range,
+// returns nil if no field alias is applied.
+func newFieldAliasPlan(statement *stmtpkg.Query, fieldAlias *models.DatabaseFieldAlias) (*fieldAliasPlan, error) {
+	if fieldAlias == nil || len(fieldAlias.Aliases) == 0 {
+		return nil, nil
+	}
+	segments := make(map[string][]models.FieldSegment)
+	applied := make(map[string]struct{})
+	p := &fieldAliasPlan{statement: statement}
+	boundaries := []int64{statement.TimeRange.Start}
+	for _, fieldName := range queryFieldNames(statement) {
+		fieldSegments := fieldAlias.Resolve(statement.Namespace, statement.MetricName, fieldName, statement.TimeRange)
+		if len(fieldSegments) == 1 && fieldSegments[0].Field == fieldName {
+			continue
+		}
+		segments[fieldName] = fieldSegments
+		for _, segment := range fieldSegments {
+			boundaries = append(boundaries, segment.TimeRange.Start)
+			if segment.Alias == nil {
+				continue
+			}
+			desc := segment.Alias.String()
+			if _, ok := applied[desc]; !ok {
+				applied[desc] = struct{}{}
+				p.applied = append(p.applied, desc)
+			}
+		}
+	}
+	if len(segments) == 0 {
+		return nil, nil
+	}
+	sort.Slice(boundaries, func(i, j int) bool { return boundaries[i] < boundaries[j] })
+	var starts []int64
+	for _, boundary := range boundaries {
+		if len(starts) == 0 || starts[len(starts)-1] != boundary {
+			starts = append(starts, boundary)
+		}
+	}
+	if len(starts) > 1 && len(statement.OrderByItems) > 0 {
+		return nil, fmt.Errorf("%w, order by is not supported when query time range spans field rename", ErrFieldAliasQuery)
+	}
+	for idx, start := range starts {
+		subQuery := *statement
+		subQuery.TimeRange.Start = start
+		if idx < len(starts)-1 {
+			subQuery.TimeRange.End = starts[idx+1] - 1
+		}
+		names := make(map[string]string)
+		for fieldName, fieldSegments := range segments {
+			for _, segment := range fieldSegments {
+				if segment.TimeRange.Contains(start) {
+					names[fieldName] = segment.Field
+					break
+				}
+			}
+		}
+		subQuery.SelectItems = make([]stmtpkg.Expr, len(statement.SelectItems))
+		for i, item := range statement.SelectItems {
+			subQuery.SelectItems[i] = renameSelectItem(item, names)
+		}
+		subQuery.OrderByItems = make([]stmtpkg.Expr, len(statement.OrderByItems))
+		for i, item := range statement.OrderByItems {
+			subQuery.OrderByItems[i] = renameFields(item, names)
+		}
+		if len(starts) > 1 {
+			subQuery.Limit = crossMetricSubQueryLimit // all groups are needed for merging
+			subQuery.Offset = 0
+		}
+		p.subQueries = append(p.subQueries, &subQuery)
+	}
+	return p, nil
+}
+
+// queryFieldNames returns the field names referenced by select items/order by items in order of appearance.
+func queryFieldNames(statement *stmtpkg.Query) (names []string) {
+	exists := make(map[string]struct{})
+	var walk func(expr stmtpkg.Expr)
+	walk = func(expr stmtpkg.Expr) {
+		switch e := expr.(type) {
+		case *stmtpkg.SelectItem:
+			walk(e.Expr)
+		case *stmtpkg.OrderByExpr:
+			walk(e.Expr)
+		case *stmtpkg.ParenExpr:
+			walk(e.Expr)
+		case *stmtpkg.BinaryExpr:
+			walk(e.Left)
+			walk(e.Right)
+		case *stmtpkg.CallExpr:
+			for _, param := range e.Params {
+				walk(param)
+			}
+		case *stmtpkg.FieldExpr:
+			if _, ok := exists[e.Name]; !ok {
+				exists[e.Name] = struct{}{}
+				names = append(names, e.Name)
+			}
+		}
+	}
+	for _, item := range statement.SelectItems {
+		walk(item)
+	}
+	for _, item := range statement.OrderByItems {
+		walk(item)
+	}
+	return names
+}
+
+// renameSelectItem renames the fields of select item, keeps the result name of select item.
+func renameSelectItem(item stmtpkg.Expr, names map[string]string) stmtpkg.Expr {
+	renamed := renameFields(item, names)
+	name := SelectItemName(item)
+	if SelectItemName(renamed) == name {
+		return renamed
+	}
+	if selectItem, ok := renamed.(*stmtpkg.SelectItem); ok {
+		return &stmtpkg.SelectItem{Expr: selectItem.Expr, Alias: name}
+	}
+	return &stmtpkg.SelectItem{Expr: renamed, Alias: name}
+}
+
+// renameFields returns a copy of expr whose fields are renamed, the expr without renamed field is returned directly.
+func renameFields(expr stmtpkg.Expr, names map[string]string) stmtpkg.Expr {
+	switch e := expr.(type) {
+	case *stmtpkg.SelectItem:
+		return &stmtpkg.SelectItem{Expr: renameFields(e.Expr, names), Alias: e.Alias}
+	case *stmtpkg.OrderByExpr:
+		return &stmtpkg.OrderByExpr{Expr: renameFields(e.Expr, names), Desc: e.Desc}
+	case *stmtpkg.ParenExpr:
+		return &stmtpkg.ParenExpr{Expr: renameFields(e.Expr, names)}
+	case *stmtpkg.BinaryExpr:
+		return &stmtpkg.BinaryExpr{Left: renameFields(e.Left, names), Right: renameFields(e.Right, names), Operator: e.Operator}
+	case *stmtpkg.CallExpr:
+		params := make([]stmtpkg.Expr, len(e.Params))
+		for idx, param := range e.Params {
+			params[idx] = renameFields(param, names)
+		}
+		return &stmtpkg.CallExpr{FuncType: e.FuncType, Params: params}
+	case *stmtpkg.FieldExpr:
+		if name, ok := names[e.Name]; ok {
+			return &stmtpkg.FieldExpr{Name: name}
+		}
+		return e
+	default:
+		return expr
+	}
+}
+
+// execute executes the sub queries concurrently, then merges the result set of sub queries.
+func (p *fieldAliasPlan) execute(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, search searchFunc,
+) (any, error) {
+	if len(p.subQueries) == 1 {
+		// query time range not spans field rename, just read the renamed fields
+		rs, err := search(ctx, param, p.subQueries[0], mgr)
+		if err != nil {
+			return nil, err
+		}
+		if resultSet, ok := rs.(*models.ResultSet); ok && resultSet != nil && resultSet.Stats != nil {
+			resultSet.Stats.FieldAliases = p.applied
+		}
+		return rs, nil
+	}
+	startTime := time.Now()
+	resultSets := make([]*models.ResultSet, len(p.subQueries))
+	errs := make([]error, len(p.subQueries))
+	var wg sync.WaitGroup
+	for idx := range p.subQueries {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			subParam := *param
+			subParam.Stream = nil
+			subMgr := *mgr
+			subMgr.RequestID = "" // each sub query is an independent request
+			rs, err := search(ctx, &subParam, p.subQueries[idx], &subMgr)
+			if err != nil {
+				errs[idx] = err
+				return
+			}
+			resultSet, ok := rs.(*models.ResultSet)
+			if !ok || resultSet == nil {
+				resultSet = models.NewResultSet()
+			}
+			resultSets[idx] = resultSet
+		}(idx)
+	}
+	wg.Wait()
+	for idx, err := range errs {
+		if err != nil {
+			timeRange := p.subQueries[idx].TimeRange
+			return nil, fmt.Errorf("sub query of time range: [%d, %d] failure, %w", timeRange.Start, timeRange.End, err)
+		}
+	}
+	resultSet, err := p.merge(resultSets, param.Stream)
+	if err != nil {
+		return nil, err
+	}
+	quantileOverflows := make(map[string]struct{})
+	for _, rs := range resultSets {
+		resultSet.Stale = resultSet.Stale.Merge(rs.Stale)
+		resultSet.MergePartial(rs)
+		for _, fieldName := range rs.QuantileOverflows {
+			quantileOverflows[fieldName] = struct{}{}
+		}
+	}
+	for fieldName := range quantileOverflows {
+		resultSet.QuantileOverflows = append(resultSet.QuantileOverflows, fieldName)
+	}
+	sort.Strings(resultSet.QuantileOverflows)
+	if p.statement.Explain {
+		now := time.Now()
+		stats := &models.NodeStats{
+			Node:         mgr.CurNode.Indicator(),
+			Start:        startTime.UnixNano(),
+			End:          now.UnixNano(),
+			TotalCost:    now.Sub(startTime).Nanoseconds(),
+			FieldAliases: p.applied,
+		}
+		for _, rs := range resultSets {
+			if rs.Stats != nil {
+				stats.Children = append(stats.Children, rs.Stats)
+			}
+		}
+		resultSet.Stats = stats
+	}
+	return resultSet, nil
+}
+
+// merge merges the series of sub queries by group by tag values, points of later sub query win on same timestamp.
+func (p *fieldAliasPlan) merge(resultSets []*models.ResultSet, stream models.ResultStream) (*models.ResultSet, error) {
+	statement := p.statement
+	resultSet := models.NewResultSet()
+	resultSet.MetricName = statement.MetricName
+	resultSet.GroupBy = statement.GroupBy
+	for _, item := range statement.SelectItems {
+		resultSet.Fields = append(resultSet.Fields, SelectItemName(item))
+	}
+	rows := make(map[string]*models.Series) // tag values => merged series
+	var groups []string
+	for _, rs := range resultSets {
+		if rs.Interval > 0 {
+			if resultSet.Interval == 0 {
+				resultSet.StartTime = rs.StartTime
+				resultSet.Interval = rs.Interval
+			}
+			resultSet.EndTime = rs.EndTime
+		}
+		for _, s := range rs.Series {
+			row, ok := rows[s.TagValues]
+			if !ok {
+				row = models.NewSeries(s.Tags, s.TagValues)
+				rows[s.TagValues] = row
+				groups = append(groups, s.TagValues)
+			}
+			for fieldName, points := range s.Fields {
+				merged, ok := row.Fields[fieldName]
+				if !ok {
+					merged = make(map[int64]float64, len(points))
+					row.Fields[fieldName] = merged
+				}
+				for timestamp, value := range points {
+					merged[timestamp] = value
+				}
+			}
+		}
+	}
+	sort.Strings(groups)
+
+	for idx, tagValues := range groups {
+		if idx < statement.Offset {
+			continue
+		}
+		if idx-statement.Offset >= statement.Limit {
+			break
+		}
+		timeSeries := rows[tagValues]
+		if stream != nil {
+			if err := stream.WriteSeries(timeSeries); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		resultSet.AddSeries(timeSeries)
+	}
+	return resultSet, nil
+}
@@ -0,0 +1,326 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+var testFieldAlias = &models.DatabaseFieldAlias{Database: "db", Aliases: []models.FieldAlias{
+	{Metric: "cpu", From: "usage", To: "used", Since: 100},
+	{Metric: "cpu", From: "load", To: "load1", Since: 200},
+}}
+
+func parseAliasQuery(t *testing.T, sqlStr string, start, end int64) *stmtpkg.Query {
+	q := parseQuery(t, sqlStr)
+	q.TimeRange = timeutil.TimeRange{Start: start, End: end}
+	return q
+}
+
+func TestNewFieldAliasPlan(t *testing.T) {
+	cases := []struct {
+		name       string
+		sql        string
+		start, end int64
+		alias      *models.DatabaseFieldAlias
+		wantErr    bool
+		assert     func(p *fieldAliasPlan)
+	}{
+		{
+			name: "no field alias",
+			sql:  "select usage from cpu",
+			assert: func(p *fieldAliasPlan) {
+				assert.Nil(t, p)
+			},
+		},
+		{
+			name:  "field not renamed",
+			sql:   "select idle from cpu",
+			start: 0, end: 300,
+			alias: testFieldAlias,
+			assert: func(p *fieldAliasPlan) {
+				assert.Nil(t, p)
+			},
+		},
+		{
+			name:  "before renamed",
+			sql:   "select usage from cpu",
+			start: 0, end: 99,
+			alias: testFieldAlias,
+			assert: func(p *fieldAliasPlan) {
+				assert.Nil(t, p)
+			},
+		},
+		{
+			name:  "after renamed",
+			sql:   "select usage, max(usage) as m from cpu group by host order by usage desc",
+			start: 100, end: 300,
+			alias: testFieldAlias,
+			assert: func(p *fieldAliasPlan) {
+				assert.Len(t, p.subQueries, 1)
+				assert.Equal(t, []string{"usage->used(since 1970-01-01 00:00:00)"}, p.applied)
+				q := p.subQueries[0]
+				assert.Equal(t, timeutil.TimeRange{Start: 100, End: 300}, q.TimeRange)
+				assert.Equal(t, "used", q.SelectItems[0].(*stmtpkg.SelectItem).Expr.Rewrite())
+				assert.Equal(t, "usage", SelectItemName(q.SelectItems[0]))
+				assert.Equal(t, "max(used)", q.SelectItems[1].(*stmtpkg.SelectItem).Expr.Rewrite())
+				assert.Equal(t, "m", SelectItemName(q.SelectItems[1]))
+				assert.Equal(t, "used desc", q.OrderByItems[0].Rewrite())
+				assert.Equal(t, []string{"host"}, q.GroupBy)
+			},
+		},
+		{
+			name:  "spans field rename",
+			sql:   "select usage+load from cpu limit 10",
+			start: 50, end: 300,
+			alias: testFieldAlias,
+			assert: func(p *fieldAliasPlan) {
+				assert.Len(t, p.subQueries, 3)
+				assert.Len(t, p.applied, 2)
+				expects := []struct {
+					timeRange timeutil.TimeRange
+					expr      string
+				}{
+					{timeRange: timeutil.TimeRange{Start: 50, End: 99}, expr: "usage+load"},
+					{timeRange: timeutil.TimeRange{Start: 100, End: 199}, expr: "used+load"},
+					{timeRange: timeutil.TimeRange{Start: 200, End: 300}, expr: "used+load1"},
+				}
+				for idx, expect := range expects {
+					q := p.subQueries[idx]
+					assert.Equal(t, expect.timeRange, q.TimeRange)
+					assert.Equal(t, "usage+load", SelectItemName(q.SelectItems[0]))
+					assert.Equal(t, expect.expr, q.SelectItems[0].(*stmtpkg.SelectItem).Expr.Rewrite())
+					assert.Equal(t, crossMetricSubQueryLimit, q.Limit)
+				}
+			},
+		},
+		{
+			name:  "order by when spans field rename",
+			sql:   "select usage from cpu group by host order by usage",
+			start: 50, end: 300,
+			alias:   testFieldAlias,
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newFieldAliasPlan(parseAliasQuery(t, tt.sql, tt.start, tt.end), tt.alias)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.assert(p)
+		})
+	}
+}
+
+func TestRenameFields(t *testing.T) {
+	names := map[string]string{"usage": "used"}
+	expr := &stmtpkg.BinaryExpr{
+		Left:     &stmtpkg.ParenExpr{Expr: &stmtpkg.FieldExpr{Name: "usage"}},
+		Right:    &stmtpkg.NumberLiteral{Val: 100},
+		Operator: stmtpkg.MUL,
+	}
+	assert.Equal(t, "(used)*100.00", renameFields(expr, names).Rewrite())
+	// origin expr not changed
+	assert.Equal(t, "(usage)*100.00", expr.Rewrite())
+	// keep name of select item
+	item := renameSelectItem(expr, names)
+	assert.Equal(t, "(usage)*100.00", SelectItemName(item))
+	item = renameSelectItem(&stmtpkg.FieldExpr{Name: "idle"}, names)
+	assert.Equal(t, &stmtpkg.FieldExpr{Name: "idle"}, item)
+}
+
+func TestFieldAliasPlan_execute(t *testing.T) {
+	before := &models.ResultSet{
+		StartTime: 0,
+		EndTime:   90,
+		Interval:  10,
+		Series: []*models.Series{
+			{Tags: map[string]string{"host": "b"}, TagValues: "b", Fields: map[string]map[int64]float64{
+				"usage": {80: 1, 90: 2},
+			}},
+			{Tags: map[string]string{"host": "a"}, TagValues: "a", Fields: map[string]map[int64]float64{
+				"usage": {90: 3},
+			}},
+		},
+		Stats: &models.NodeStats{Node: "before"},
+	}
+	after := &models.ResultSet{
+		StartTime: 100,
+		EndTime:   200,
+		Interval:  10,
+		Series: []*models.Series{
+			{Tags: map[string]string{"host": "a"}, TagValues: "a", Fields: map[string]map[int64]float64{
+				"usage": {90: 4, 100: 5},
+			}},
+			{Tags: map[string]string{"host": "c"}, TagValues: "c", Fields: map[string]map[int64]float64{
+				"usage": {100: 6},
+			}},
+		},
+		Stale:             &models.StaleResult{Shards: []models.ShardID{1}, MissingRecentSeconds: 5},
+		Stats:             &models.NodeStats{Node: "after"},
+		QuantileOverflows: []string{"usage"},
+	}
+	search := func(_ context.Context, _ *models.ExecuteParam, statement *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		if statement.MetricName != "cpu" {
+			return nil, fmt.Errorf("err")
+		}
+		if statement.TimeRange.Start < 100 {
+			return before, nil
+		}
+		return after, nil
+	}
+	mgr := &SearchMgr{RequestID: "req"}
+
+	cases := []struct {
+		name       string
+		sql        string
+		start, end int64
+		stream     *fakeResultStream
+		wantErr    bool
+		assert     func(rs *models.ResultSet)
+	}{
+		{
+			name:  "sub query failure",
+			sql:   "select usage from disk",
+			start: 0, end: 200,
+			wantErr: true,
+		},
+		{
+			name:  "not spans field rename",
+			sql:   "explain select usage from cpu group by host",
+			start: 100, end: 200,
+			assert: func(rs *models.ResultSet) {
+				assert.Equal(t, after, rs)
+				assert.Equal(t, []string{"usage->used(since 1970-01-01 00:00:00)"}, rs.Stats.FieldAliases)
+			},
+		},
+		{
+			name:  "not spans field rename, query failure",
+			sql:   "select usage from disk",
+			start: 100, end: 200,
+			wantErr: true,
+		},
+		{
+			name:  "merge result sets",
+			sql:   "select usage from cpu group by host",
+			start: 0, end: 200,
+			assert: func(rs *models.ResultSet) {
+				assert.Equal(t, []string{"usage"}, rs.Fields)
+				assert.Equal(t, []string{"host"}, rs.GroupBy)
+				assert.Equal(t, int64(0), rs.StartTime)
+				assert.Equal(t, int64(200), rs.EndTime)
+				assert.Equal(t, int64(10), rs.Interval)
+				assert.Len(t, rs.Series, 3)
+				assert.Equal(t, "a", rs.Series[0].Tags["host"])
+				// later time range wins on same slot
+				assert.Equal(t, map[int64]float64{90: 4, 100: 5}, rs.Series[0].Fields["usage"])
+				assert.Equal(t, map[int64]float64{80: 1, 90: 2}, rs.Series[1].Fields["usage"])
+				assert.Equal(t, map[int64]float64{100: 6}, rs.Series[2].Fields["usage"])
+				assert.Nil(t, rs.Stats)
+				assert.Equal(t, after.Stale, rs.Stale)
+				assert.Equal(t, []string{"usage"}, rs.QuantileOverflows)
+			},
+		},
+		{
+			name:  "limit/offset",
+			sql:   "select usage from cpu group by host limit 1 offset 1",
+			start: 0, end: 200,
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, rs.Series, 1)
+				assert.Equal(t, "b", rs.Series[0].Tags["host"])
+			},
+		},
+		{
+			name:  "explain",
+			sql:   "explain select usage from cpu group by host",
+			start: 0, end: 200,
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, rs.Stats.Children, 2)
+				assert.Len(t, rs.Stats.FieldAliases, 1)
+			},
+		},
+		{
+			name:  "stream",
+			sql:   "select usage from cpu group by host",
+			start: 0, end: 200,
+			stream: &fakeResultStream{},
+			assert: func(rs *models.ResultSet) {
+				assert.Empty(t, rs.Series)
+				assert.Equal(t, []string{"usage"}, rs.Fields)
+			},
+		},
+		{
+			name:  "stream failure",
+			sql:   "select usage from cpu group by host",
+			start: 0, end: 200,
+			stream:  &fakeResultStream{err: fmt.Errorf("err")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			alias := &models.DatabaseFieldAlias{Database: "db", Aliases: []models.FieldAlias{
+				{Metric: "cpu", From: "usage", To: "used", Since: 100},
+				{Metric: "disk", From: "usage", To: "used", Since: 100},
+			}}
+			p, err := newFieldAliasPlan(parseAliasQuery(t, tt.sql, tt.start, tt.end), alias)
+			assert.NoError(t, err)
+			param := &models.ExecuteParam{}
+			if tt.stream != nil {
+				param.Stream = tt.stream
+			}
+			rs, err := p.execute(context.TODO(), param, mgr, search)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.assert(rs.(*models.ResultSet))
+			if tt.stream != nil {
+				assert.Len(t, tt.stream.series, 3)
+			}
+		})
+	}
+}
+
+func TestFieldAliasOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert.Nil(t, fieldAliasOf("db", &SearchMgr{}))
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetFieldAlias("db").Return(nil, false)
+	assert.Nil(t, fieldAliasOf("db", &SearchMgr{Choose: stateMgr}))
+	stateMgr.EXPECT().GetFieldAlias("db").Return(testFieldAlias, true)
+	assert.Equal(t, testFieldAlias, fieldAliasOf("db", &SearchMgr{Choose: stateMgr}))
+}
@@ -21,12 +21,16 @@ import (
 	"fmt"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/context"
 	"github.com/lindb/lindb/rpc"
@@ -74,11 +78,26 @@ func NewIntermediateTaskProcessor(
 // Process processes the intermediate task request.
 // if current node is only receive task response need ignore search execute.
 func (p *intermediateTaskProcessor) Process(ctx *flow.TaskContext,
-	stream protoCommonV1.TaskService_HandleServer, req *protoCommonV1.TaskRequest) error {
+	stream protoCommonV1.TaskService_HandleServer, req *protoCommonV1.TaskRequest) (err error) {
 	physicalPlan := &models.PhysicalPlan{}
 	if err := encoding.JSONUnmarshal(req.PhysicalPlan, physicalPlan); err != nil {
 		return fmt.Errorf("%w: %s", ErrUnmarshalPlan, err)
 	}
+	if physicalPlan.Cancel {
+		// kill the running intermediate task, cancellation is propagated to its leaf tasks
+		GetRequestManager().KillRequest(req.RequestID)
+		return nil
+	}
+	// shrink task deadline using the remaining timeout of query
+	ctx.WithTimeout(time.Duration(physicalPlan.Timeout))
+	// continue the trace of query from parent node
+	var span trace.Span
+	ctx.Ctx, span = tracing.StartSpan(tracing.Extract(ctx.Ctx, physicalPlan.TraceContext), "query.intermediate",
+		attribute.String("database", physicalPlan.Database),
+		attribute.String("node", p.curNode.Indicator()))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
 	foundTask := false
 	var curTarget *models.Target
 	for _, target := range physicalPlan.Targets {
@@ -165,7 +184,7 @@ func (p *intermediateTaskProcessor) processMetadataSearch(
 	if err != nil {
 		return err
 	}
-	payload := encoding.JSONMarshal(&models.SuggestResult{Values: rs.([]string)})
+	payload := encoding.JSONMarshal(rs.(*models.SuggestResult))
 	// send result to upstream
 	p.sendResponse(stream, req, &protoCommonV1.TaskResponse{
 		RequestID: req.RequestID,
@@ -40,27 +40,35 @@ func TestProcess_Fail(t *testing.T) {
 	assert.Error(t, err)
 
 	ip := NewIntermediateTaskProcessor(models.StatelessNode{HostIP: "1.1.1.1", GRPCPort: 9000}, time.Second, nil, nil, nil)
-	err = ip.Process(nil, nil, &protoCommonV1.TaskRequest{
+	taskCtx := flow.NewTaskContextWithTimeout(context.TODO(), time.Second)
+	defer taskCtx.Release()
+	err = ip.Process(taskCtx, nil, &protoCommonV1.TaskRequest{
 		PhysicalPlan: encoding.JSONMarshal(&models.PhysicalPlan{
 			Targets: []*models.Target{{Indicator: "1.1.1.1:8000"}},
 		}),
 	})
 	assert.Error(t, err)
 
-	err = ip.Process(nil, nil, &protoCommonV1.TaskRequest{
+	err = ip.Process(taskCtx, nil, &protoCommonV1.TaskRequest{
 		PhysicalPlan: encoding.JSONMarshal(&models.PhysicalPlan{
 			Targets: []*models.Target{{Indicator: "1.1.1.1:9000", ReceiveOnly: true}},
 		}),
 	})
 	assert.NoError(t, err)
 
-	err = ip.Process(nil, nil, &protoCommonV1.TaskRequest{
+	err = ip.Process(taskCtx, nil, &protoCommonV1.TaskRequest{
 		RequestType: protoCommonV1.RequestType(10),
 		PhysicalPlan: encoding.JSONMarshal(&models.PhysicalPlan{
 			Targets: []*models.Target{{Indicator: "1.1.1.1:9000"}},
 		}),
 	})
 	assert.NoError(t, err)
+	// cancel request not found
+	err = ip.Process(taskCtx, nil, &protoCommonV1.TaskRequest{
+		RequestID:    "not-found",
+		PhysicalPlan: encoding.JSONMarshal(&models.PhysicalPlan{Cancel: true}),
+	})
+	assert.NoError(t, err)
 }
 
 func TestProcessMetricDataSearch(t *testing.T) {
@@ -138,7 +146,7 @@ func TestProcessMetricMetadataSearch(t *testing.T) {
 
 	metricMetadataSearchFn = func(ctx context.Context, param *models.ExecuteParam,
 		statement *stmt.MetricMetadata, mgr *SearchMgr) (any, error) {
-		return []string{}, nil
+		return &models.SuggestResult{}, nil
 	}
 	stream := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
 	stream.EXPECT().Send(gomock.Any()).Return(nil)
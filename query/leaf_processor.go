@@ -20,14 +20,22 @@ package query
 import (
 	"errors"
 	"fmt"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/context"
 	"github.com/lindb/lindb/query/stage"
@@ -45,11 +53,19 @@ var (
 // leafTaskProcessor represents the leaf node's task, the leaf node is always storage node
 // 1. receives the task request, and searches the data from time seres engine
 // 2. sends the result to the parent node(root or intermediate)
+//
+// NOTICE: leaf task is admitted by admission controller before executing, avoid heavy queries starve ingestion,
+// write path of storage does not pass through it.
 type leafTaskProcessor struct {
 	currentNode       models.Node
 	currentNodeID     string
 	engine            tsdb.Engine
 	taskServerFactory rpc.TaskServerFactory
+	admission         *concurrent.AdmissionController
+
+	// running data search tasks, request id => cancel func
+	tasks map[string]func()
+	mutex sync.Mutex
 
 	statistics *metrics.StorageQueryStatistics
 	logger     *logger.Logger
@@ -60,12 +76,15 @@ func NewLeafTaskProcessor(
 	currentNode models.Node,
 	engine tsdb.Engine,
 	taskServerFactory rpc.TaskServerFactory,
+	admission *concurrent.AdmissionController,
 ) TaskProcessor {
 	return &leafTaskProcessor{
 		currentNode:       currentNode,
 		currentNodeID:     currentNode.Indicator(),
 		engine:            engine,
 		taskServerFactory: taskServerFactory,
+		admission:         admission,
+		tasks:             make(map[string]func()),
 		statistics:        metrics.NewStorageQueryStatistics(),
 		logger:            logger.GetLogger("Query", "leafTaskProcessor"),
 	}
@@ -76,11 +95,29 @@ func (p *leafTaskProcessor) Process(
 	ctx *flow.TaskContext,
 	stream protoCommonV1.TaskService_HandleServer,
 	req *protoCommonV1.TaskRequest,
-) error {
+) (err error) {
 	physicalPlan := models.PhysicalPlan{}
 	if err := encoding.JSONUnmarshal(req.PhysicalPlan, &physicalPlan); err != nil {
 		return fmt.Errorf("%w: %s", ErrUnmarshalPlan, err)
 	}
+	if physicalPlan.Cancel {
+		// cancel the running task, the response is sent by the cancelled task
+		p.cancelTask(req.RequestID)
+		return nil
+	}
+	// shrink task deadline using the remaining timeout of query
+	ctx.WithTimeout(time.Duration(physicalPlan.Timeout))
+	// continue the trace of query from parent node, span is ended when task pipeline completed
+	var span trace.Span
+	ctx.Ctx, span = tracing.StartSpan(tracing.Extract(ctx.Ctx, physicalPlan.TraceContext), "storage.task",
+		attribute.String("database", physicalPlan.Database),
+		attribute.String("node", p.currentNodeID),
+		attribute.String("requestType", req.RequestType.String()))
+	defer func() {
+		if err != nil {
+			tracing.EndSpan(span, err)
+		}
+	}()
 
 	foundTask := false
 	var curLeaf *models.Target
@@ -100,22 +137,31 @@ func (p *leafTaskProcessor) Process(
 		p.statistics.OmitRequest.Incr()
 		return fmt.Errorf("%w: %s", ErrNoDatabase, physicalPlan.Database)
 	}
+	// wait admission before executing, ticket is released when task pipeline completed
+	ticket, err := p.admission.Admit(ctx.Ctx)
+	if err != nil {
+		return err
+	}
 
 	switch req.RequestType {
 	case protoCommonV1.RequestType_Data:
-		if err := p.processDataSearch(ctx, db, req, curLeaf, physicalPlan.Receivers); err != nil {
+		if err := p.processDataSearch(ctx, db, req, curLeaf, physicalPlan.Receivers, ticket); err != nil {
+			ticket.Release()
 			p.statistics.MetricQueryFailures.Incr()
 			return err
 		}
 		p.statistics.MetricQuery.Incr()
 	case protoCommonV1.RequestType_Metadata:
-		if err := p.processMetadataSuggest(ctx, db, curLeaf.ShardIDs, req, stream); err != nil {
+		if err := p.processMetadataSuggest(ctx, db, curLeaf.ShardIDs, req, stream, ticket); err != nil {
+			ticket.Release()
 			p.statistics.MetaQueryFailures.Incr()
 			return err
 		}
 		p.statistics.MetaQuery.Incr()
 	default:
+		ticket.Release()
 		p.statistics.OmitRequest.Incr()
+		span.End()
 		return nil
 	}
 	return nil
@@ -127,6 +173,7 @@ func (p *leafTaskProcessor) processMetadataSuggest(
 	shardIDs []models.ShardID,
 	req *protoCommonV1.TaskRequest,
 	stream protoCommonV1.TaskService_HandleServer,
+	ticket *concurrent.Ticket,
 ) error {
 	defer ctx.Release()
 	var stmtQuery = &stmt.MetricMetadata{}
@@ -135,13 +182,19 @@ func (p *leafTaskProcessor) processMetadataSuggest(
 	}
 	leafExecuteCtx := context.NewLeafMetadataContext(stmtQuery, db, shardIDs)
 	pipeline := newExecutePipelineFn(trackerpkg.NewStageTracker(ctx), func(err error) {
+		defer ticket.Release()
+		defer tracing.EndSpan(trace.SpanFromContext(ctx.Ctx), err)
+
 		var errMsg string
 		var payload []byte
 		if err != nil && !errors.Is(err, constants.ErrNotFound) {
 			errMsg = err.Error()
 			p.statistics.MetaQueryFailures.Incr()
 		} else {
-			payload = encoding.JSONMarshal(&models.SuggestResult{Values: leafExecuteCtx.ResultSet})
+			payload = encoding.JSONMarshal(&models.SuggestResult{
+				Values:    leafExecuteCtx.ResultSet,
+				Truncated: leafExecuteCtx.Truncated,
+			})
 		}
 		// send result to upstream
 		if err := stream.Send(&protoCommonV1.TaskResponse{
@@ -169,6 +222,7 @@ func (p *leafTaskProcessor) processDataSearch(
 	req *protoCommonV1.TaskRequest,
 	leafNode *models.Target,
 	receivers []string,
+	ticket *concurrent.Ticket,
 ) error {
 	stmtQuery := stmt.Query{}
 	if err := stmtQuery.UnmarshalJSON(req.Payload); err != nil {
@@ -178,15 +232,58 @@ func (p *leafTaskProcessor) processDataSearch(
 	// execute leaf pipeline
 	tracker := trackerpkg.NewStageTracker(ctx)
 	leafExecuteCtx := context.NewLeafExecuteContext(ctx, tracker, &stmtQuery, req, p.taskServerFactory, leafNode, receivers, db)
+	// track the bytes scanned by query, new queries wait if in-flight scan bytes exceed the limit
+	scanBytes := atomic.NewInt64(0)
+	leafExecuteCtx.StorageExecuteCtx.OnScan = func(bytes int) {
+		scanBytes.Add(int64(bytes))
+		ticket.AddBytes(bytes)
+	}
 
 	pipeline := newExecutePipelineFn(tracker, func(err error) {
+		defer ticket.Release()
+		if metricID := leafExecuteCtx.StorageExecuteCtx.MetricID; metricID > 0 {
+			// metric found, record query usage for capacity attribution
+			tsdb.GetMetricUsageTracker().RecordQuery(db.Name(), metricID, stmtQuery.MetricName, int(scanBytes.Load()))
+		}
+		defer p.removeTask(req.RequestID)
 		// remove pipeline from cache after execute completed
 		defer GetPipelineManager().RemovePipeline(req.RequestID)
+		defer tracing.EndSpan(trace.SpanFromContext(ctx.Ctx), err)
 
 		leafExecuteCtx.SendResponse(err)
 	})
 	// cache pipeline
 	GetPipelineManager().AddPipeline(req.RequestID, pipeline)
+	p.addTask(req.RequestID, ctx.Cancel)
 	pipeline.Execute(stage.NewMetadataLookupStage(leafExecuteCtx))
 	return nil
 }
+
+// addTask adds the running task which can be cancelled by broker.
+func (p *leafTaskProcessor) addTask(requestID string, cancel func()) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.tasks[requestID] = cancel
+}
+
+// removeTask removes the task after completed.
+func (p *leafTaskProcessor) removeTask(requestID string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.tasks, requestID)
+}
+
+// cancelTask cancels the running task, the stages of task stop executing when context cancelled.
+// NOTICE: cancel request is ignored if task completed or not started yet.
+func (p *leafTaskProcessor) cancelTask(requestID string) {
+	p.mutex.Lock()
+	cancel, ok := p.tasks[requestID]
+	p.mutex.Unlock()
+
+	if ok {
+		cancel()
+		p.statistics.CancelledQuery.Incr()
+	}
+}
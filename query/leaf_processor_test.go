@@ -28,7 +28,10 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
@@ -46,9 +49,10 @@ func TestLeafTask_Process_Fail(t *testing.T) {
 	engine := tsdb.NewMockEngine(ctrl)
 	serverStream := protoCommonV1.NewMockTaskService_HandleServer(ctrl)
 	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().GetOption().Return(nil).AnyTimes()
 
 	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
-	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory)
+	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory, newTestAdmissionController(1024))
 	processor := processorI.(*leafTaskProcessor)
 
 	cases := []struct {
@@ -86,6 +90,21 @@ func TestLeafTask_Process_Fail(t *testing.T) {
 				assert.True(t, errors.Is(err, ErrNoDatabase))
 			},
 		},
+		{
+			name: "node busy",
+			req: &protoCommonV1.TaskRequest{PhysicalPlan: encoding.JSONMarshal(&models.PhysicalPlan{
+				Database: "test_db",
+				Targets:  []*models.Target{{Indicator: "1.1.1.3:8000"}},
+			}), Payload: encoding.JSONMarshal(&stmt.Query{MetricName: "cpu"})},
+			prepare: func() {
+				processor.admission = newTestAdmissionController(1)
+				_, _ = processor.admission.Admit(context.TODO())
+				engine.EXPECT().GetDatabase(gomock.Any()).Return(mockDatabase, true)
+			},
+			assert: func(err error) {
+				assert.True(t, errors.Is(err, constants.ErrNodeBusy))
+			},
+		},
 		{
 			name: "unmarshal query err",
 			req: &protoCommonV1.TaskRequest{PhysicalPlan: encoding.JSONMarshal(&models.PhysicalPlan{
@@ -139,6 +158,7 @@ func TestLeafTask_Process_Fail(t *testing.T) {
 		t.Run(tt.name, func(_ *testing.T) {
 			defer func() {
 				newExecutePipelineFn = NewExecutePipeline
+				processor.admission = newTestAdmissionController(1024)
 			}()
 			if tt.prepare != nil {
 				tt.prepare()
@@ -161,9 +181,10 @@ func TestLeafProcessor_Process(t *testing.T) {
 	engine := tsdb.NewMockEngine(ctrl)
 
 	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
-	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory)
+	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory, newTestAdmissionController(1024))
 	processor := processorI.(*leafTaskProcessor)
 	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().GetOption().Return(nil).AnyTimes()
 	plan := encoding.JSONMarshal(&models.PhysicalPlan{
 		Database: "test_db",
 		Targets:  []*models.Target{{Indicator: "1.1.1.3:8000"}},
@@ -187,6 +208,32 @@ func TestLeafProcessor_Process(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestLeafProcessor_CancelTask(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
+	processor := NewLeafTaskProcessor(&currentNode, tsdb.NewMockEngine(ctrl),
+		rpc.NewMockTaskServerFactory(ctrl), newTestAdmissionController(1024)).(*leafTaskProcessor)
+	plan := encoding.JSONMarshal(&models.PhysicalPlan{
+		Database: "test_db",
+		Targets:  []*models.Target{{Indicator: "1.1.1.3:8000"}},
+		Cancel:   true,
+	})
+	taskCtx := flow.NewTaskContextWithTimeout(context.Background(), time.Minute)
+	processor.addTask("req", taskCtx.Cancel)
+	// task not found
+	assert.NoError(t, processor.Process(flow.NewTaskContextWithTimeout(context.Background(), time.Second),
+		nil, &protoCommonV1.TaskRequest{RequestID: "req-2", PhysicalPlan: plan}))
+	assert.NoError(t, taskCtx.Ctx.Err())
+	// cancel running task
+	assert.NoError(t, processor.Process(flow.NewTaskContextWithTimeout(context.Background(), time.Second),
+		nil, &protoCommonV1.TaskRequest{RequestID: "req", PhysicalPlan: plan}))
+	assert.ErrorIs(t, taskCtx.Ctx.Err(), context.Canceled)
+	processor.removeTask("req")
+	assert.Empty(t, processor.tasks)
+}
+
 func TestLeafTask_Suggest_Process(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -195,9 +242,10 @@ func TestLeafTask_Suggest_Process(t *testing.T) {
 	engine := tsdb.NewMockEngine(ctrl)
 
 	currentNode := models.StatelessNode{HostIP: "1.1.1.3", GRPCPort: 8000}
-	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory)
+	processorI := NewLeafTaskProcessor(&currentNode, engine, taskServerFactory, newTestAdmissionController(1024))
 	processor := processorI.(*leafTaskProcessor)
 	mockDatabase := tsdb.NewMockDatabase(ctrl)
+	mockDatabase.EXPECT().GetOption().Return(nil).AnyTimes()
 	plan := encoding.JSONMarshal(&models.PhysicalPlan{
 		Database: "test_db",
 		Targets:  []*models.Target{{Indicator: "1.1.1.3:8000"}},
@@ -278,3 +326,7 @@ func TestLeafTask_Suggest_Process(t *testing.T) {
 		})
 	}
 }
+
+func newTestAdmissionController(maxConcurrency int) *concurrent.AdmissionController {
+	return concurrent.NewAdmissionController(maxConcurrency, 0, 0, time.Millisecond, metrics.NewQueryAdmissionStatistics())
+}
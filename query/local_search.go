@@ -0,0 +1,290 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/internal/concurrent"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	queryctx "github.com/lindb/lindb/query/context"
+	"github.com/lindb/lindb/query/stage"
+	trackerpkg "github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/sql"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+	"github.com/lindb/lindb/tsdb"
+)
+
+// localNode represents the node of local search, leaf and root tasks are both executed in process.
+var localNode = models.StatelessNode{HostIP: "localhost"}
+
+// SearchLocal executes the data query against all shards of database in process without broker/replica,
+// e.g. query the database of read-only engine which is opened on a copied data directory.
+// Leaf task searches the shards, then root task merges the time series and builds the result set.
+func SearchLocal(ctx context.Context, db tsdb.Database, sqlText string, timeout time.Duration) (*models.ResultSet, error) {
+	stmt, err := sql.Parse(sqlText)
+	if err != nil {
+		return nil, err
+	}
+	statement, ok := stmt.(*stmtpkg.Query)
+	if !ok {
+		return nil, fmt.Errorf("local search only support data query, but got: %s", sqlText)
+	}
+	opt := db.GetOption()
+	if opt == nil || len(opt.Intervals) == 0 {
+		return nil, fmt.Errorf("%w: option of database: %s", constants.ErrNotFound, db.Name())
+	}
+	if err0 := queryctx.CalcTimeRangeAndInterval(statement, models.Database{Name: db.Name(), Option: opt}); err0 != nil {
+		return nil, err0
+	}
+	payload, _ := statement.MarshalJSON()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := searchLocalShards(ctx, db, payload, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrMsg != "" {
+		return nil, errcode.Parse(resp.ErrMsg)
+	}
+	rootCtx := queryctx.NewRootMetricContext(&queryctx.RootMetricContextDeps{
+		Ctx:         ctx,
+		Request:     models.NewRequest(localNode.Indicator(), db.Name(), sqlText),
+		Database:    db.Name(),
+		CurrentNode: localNode,
+		Statement:   statement,
+	})
+	rootCtx.SetTracker(trackerpkg.NewStageTracker(&flow.TaskContext{Ctx: ctx, Start: time.Now()}))
+	rs, err := rootCtx.Replay([][]byte{resp.Payload})
+	if err != nil {
+		return nil, err
+	}
+	resultSet, ok := rs.(*models.ResultSet)
+	if !ok {
+		return nil, fmt.Errorf("unexpected result of local search: %T", rs)
+	}
+	return resultSet, nil
+}
+
+// searchLocalShards executes the leaf task of all shards, returns the response which sent to root task.
+// The ticket of admission(nil if no admission control) tracks the bytes scanned, released after leaf task completed.
+// NOTICE: task context of leaf is released after response sent, so waits response using the context of query.
+func searchLocalShards(ctx context.Context,
+	db tsdb.Database, payload []byte, ticket *concurrent.Ticket,
+) (*protoCommonV1.TaskResponse, error) {
+	// leaf task uses its own statement like remote leaf node
+	leafStmt := &stmtpkg.Query{}
+	if err := leafStmt.UnmarshalJSON(payload); err != nil {
+		return nil, ErrUnmarshalQuery
+	}
+	stream := &localTaskStream{responses: make(chan *protoCommonV1.TaskResponse, 1)}
+	receiver := localNode.Indicator()
+	req := &protoCommonV1.TaskRequest{
+		RequestType: protoCommonV1.RequestType_Data,
+		Payload:     payload,
+	}
+	leafNode := &models.Target{Indicator: receiver}
+	if cfg := db.GetConfig(); cfg != nil {
+		leafNode.ShardIDs = cfg.ShardIDs
+	}
+	if opt := db.GetOption(); opt != nil {
+		// all shards are queried on current node
+		leafNode.MaxSeries = opt.MaxSeriesPerQuery
+	}
+	c, cancel := context.WithCancel(ctx)
+	taskCtx := &flow.TaskContext{Ctx: c, Cancel: cancel, Start: time.Now()}
+	tracker := trackerpkg.NewStageTracker(taskCtx)
+	leafCtx := queryctx.NewLeafExecuteContext(taskCtx, tracker, leafStmt, req,
+		&localTaskServerFactory{stream: stream}, leafNode, []string{receiver}, db)
+	if ticket != nil {
+		leafCtx.StorageExecuteCtx.OnScan = ticket.AddBytes
+	}
+	pipeline := newExecutePipelineFn(tracker, func(err error) {
+		if ticket != nil {
+			defer ticket.Release()
+		}
+		leafCtx.SendResponse(err)
+	})
+	pipeline.Execute(stage.NewMetadataLookupStage(leafCtx))
+
+	select {
+	case resp := <-stream.responses:
+		return resp, nil
+	case <-ctx.Done():
+		return nil, constants.ErrTimeout
+	}
+}
+
+// LocalQueryEngine represents the in-process query API of storage node, storage-side consumers(like sidecar
+// for cache warming and consistency checks) query the data of local shards without broker round trip.
+type LocalQueryEngine interface {
+	// ExecuteLocalQuery executes the data query against the local shards of database,
+	// returns the intermediate time series(partial aggregates before broker merging) and the stats of leaf task.
+	ExecuteLocalQuery(ctx context.Context, db string, statement *stmtpkg.Query) (*LocalResultSet, error)
+}
+
+// LocalResultSet represents the result of local query.
+type LocalResultSet struct {
+	Series *protoCommonV1.TimeSeriesList // intermediate time series of local shards
+	Stats  *models.NodeStats             // stats of leaf task
+}
+
+// localQueryEngine implements LocalQueryEngine.
+type localQueryEngine struct {
+	engine    tsdb.Engine
+	admission *concurrent.AdmissionController
+	timeout   time.Duration
+}
+
+// NewLocalQueryEngine creates the in-process query API of storage engine,
+// local query is admitted and timed out same as the leaf task of remote query.
+func NewLocalQueryEngine(engine tsdb.Engine, admission *concurrent.AdmissionController, timeout time.Duration) LocalQueryEngine {
+	return &localQueryEngine{
+		engine:    engine,
+		admission: admission,
+		timeout:   timeout,
+	}
+}
+
+// ExecuteLocalQuery executes the data query against the local shards of database.
+func (e *localQueryEngine) ExecuteLocalQuery(ctx context.Context,
+	database string, statement *stmtpkg.Query,
+) (*LocalResultSet, error) {
+	if err := validateLocalQuery(statement); err != nil {
+		return nil, err
+	}
+	db, ok := e.engine.GetDatabase(database)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoDatabase, database)
+	}
+	opt := db.GetOption()
+	if opt == nil || len(opt.Intervals) == 0 {
+		return nil, fmt.Errorf("%w: option of database: %s", constants.ErrNotFound, database)
+	}
+	// plan on a copy, keeps the statement of caller unchanged
+	leafStmt := *statement
+	if err := queryctx.CalcTimeRangeAndInterval(&leafStmt, models.Database{Name: database, Option: opt}); err != nil {
+		return nil, err
+	}
+	// leaf task returns stats of node, but doesn't collect operators' stats like explain query
+	leafStmt.ReturnStats = true
+	payload, _ := leafStmt.MarshalJSON()
+
+	if e.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.timeout)
+		defer cancel()
+	}
+	// wait admission before executing, ticket is released when leaf task completed
+	ticket, err := e.admission.Admit(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := searchLocalShards(ctx, db, payload, ticket)
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrMsg != "" {
+		return nil, errcode.Parse(resp.ErrMsg)
+	}
+	rs := &LocalResultSet{Series: &protoCommonV1.TimeSeriesList{}}
+	if err := rs.Series.Unmarshal(resp.Payload); err != nil {
+		return nil, err
+	}
+	if len(resp.Stats) > 0 {
+		rs.Stats = &models.NodeStats{}
+		if err := encoding.JSONUnmarshal(resp.Stats, rs.Stats); err != nil {
+			return nil, err
+		}
+	}
+	return rs, nil
+}
+
+// validateLocalQuery refuses the query which requires the merge semantics of broker,
+// local query only returns the partial aggregates of local shards.
+func validateLocalQuery(statement *stmtpkg.Query) error {
+	switch {
+	case statement.SubQuery != nil:
+		return fmt.Errorf("%w, sub query requires broker-side aggregation", ErrLocalQuery)
+	case isMetricPattern(statement.MetricName):
+		return fmt.Errorf("%w, metric name pattern requires broker-side merging", ErrLocalQuery)
+	case isCrossMetricQuery(statement):
+		return fmt.Errorf("%w, cross metric query requires broker-side merging", ErrLocalQuery)
+	case len(statement.OrderByItems) > 0 || statement.Offset > 0:
+		return fmt.Errorf("%w, order by/offset requires final aggregates of all shards", ErrLocalQuery)
+	case statement.Fill != stmtpkg.FillDrop:
+		return fmt.Errorf("%w, fill is applied after broker-side merging", ErrLocalQuery)
+	}
+	return nil
+}
+
+// localTaskServerFactory represents the task server factory which only holds the stream of local root task.
+type localTaskServerFactory struct {
+	stream *localTaskStream
+}
+
+// GetStream returns the stream of local root task.
+func (f *localTaskServerFactory) GetStream(_ string) protoCommonV1.TaskService_HandleServer {
+	return f.stream
+}
+
+// Register does nothing, only local root task can receive response.
+func (f *localTaskServerFactory) Register(_ string, _ protoCommonV1.TaskService_HandleServer) (epoch int64) {
+	return 0
+}
+
+// Deregister does nothing, only local root task can receive response.
+func (f *localTaskServerFactory) Deregister(_ int64, _ string) bool {
+	return false
+}
+
+// Nodes returns the local node.
+func (f *localTaskServerFactory) Nodes() []models.Node {
+	return []models.Node{&localNode}
+}
+
+// localTaskStream represents the stream which passes the response of leaf task to local root task.
+type localTaskStream struct {
+	grpc.ServerStream
+	responses chan *protoCommonV1.TaskResponse
+}
+
+// Send sends the response of leaf task, only the first response is accepted.
+func (s *localTaskStream) Send(resp *protoCommonV1.TaskResponse) error {
+	select {
+	case s.responses <- resp:
+		return nil
+	default:
+		return fmt.Errorf("response of local task already sent")
+	}
+}
+
+// Recv does not support receiving request, leaf task is triggered in process.
+func (s *localTaskStream) Recv() (*protoCommonV1.TaskRequest, error) {
+	return nil, fmt.Errorf("local task stream does not support receiving request")
+}
@@ -0,0 +1,430 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	trackerpkg "github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/sql/stmt"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestSearchLocal(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newExecutePipelineFn = NewExecutePipeline
+		ctrl.Finish()
+	}()
+
+	db := tsdb.NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db").AnyTimes()
+	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: timeutil.Interval(10 * timeutil.OneSecond)}}}
+	// mock pipeline completes leaf task with given error
+	completeWith := func(err error) {
+		db.EXPECT().GetOption().Return(opt).AnyTimes()
+		db.EXPECT().GetConfig().Return(&models.DatabaseConfig{ShardIDs: []models.ShardID{1}})
+		newExecutePipelineFn = func(_ *trackerpkg.StageTracker, completeCallback func(err error)) Pipeline {
+			pipeline := NewMockPipeline(ctrl)
+			pipeline.EXPECT().Execute(gomock.Any()).Do(func(_ any) {
+				completeCallback(err)
+			})
+			return pipeline
+		}
+	}
+
+	cases := []struct {
+		name    string
+		sql     string
+		timeout time.Duration
+		prepare func()
+		wantErr error
+	}{
+		{
+			name:    "parse sql failure",
+			sql:     "select f from",
+			wantErr: errors.New("parse err"),
+		},
+		{
+			name:    "not data query",
+			sql:     "show databases",
+			wantErr: errors.New("not data query"),
+		},
+		{
+			name: "database option not found",
+			sql:  "select f from cpu",
+			prepare: func() {
+				db.EXPECT().GetOption().Return(nil)
+			},
+			wantErr: constants.ErrNotFound,
+		},
+		{
+			name: "calc time range failure",
+			sql:  "select f from cpu group by time(5m) using rollup",
+			prepare: func() {
+				db.EXPECT().GetOption().Return(opt)
+			},
+			wantErr: errors.New("calc time range err"),
+		},
+		{
+			name: "leaf task failure",
+			sql:  "select f from cpu",
+			prepare: func() {
+				completeWith(fmt.Errorf("metric not found"))
+			},
+			wantErr: errors.New("metric not found"),
+		},
+		{
+			name:    "leaf task timeout",
+			sql:     "select f from cpu",
+			timeout: 10 * time.Millisecond,
+			prepare: func() {
+				db.EXPECT().GetOption().Return(opt).AnyTimes()
+				db.EXPECT().GetConfig().Return(nil)
+				newExecutePipelineFn = func(_ *trackerpkg.StageTracker, _ func(err error)) Pipeline {
+					pipeline := NewMockPipeline(ctrl)
+					pipeline.EXPECT().Execute(gomock.Any())
+					return pipeline
+				}
+			},
+			wantErr: constants.ErrTimeout,
+		},
+		{
+			name: "search successfully",
+			sql:  "select f from cpu",
+			prepare: func() {
+				completeWith(nil)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				newExecutePipelineFn = NewExecutePipeline
+			}()
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			timeout := tt.timeout
+			if timeout == 0 {
+				timeout = time.Second
+			}
+			rs, err := SearchLocal(context.TODO(), db, tt.sql, timeout)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+				assert.NotNil(t, rs)
+				assert.Equal(t, "cpu", rs.MetricName)
+				assert.Empty(t, rs.Series)
+				return
+			}
+			assert.Error(t, err)
+			assert.Nil(t, rs)
+			if errors.Is(tt.wantErr, constants.ErrNotFound) || errors.Is(tt.wantErr, constants.ErrTimeout) {
+				assert.True(t, errors.Is(err, tt.wantErr))
+			}
+		})
+	}
+}
+
+func TestLocalTaskStream(t *testing.T) {
+	stream := &localTaskStream{responses: make(chan *protoCommonV1.TaskResponse, 1)}
+	factory := &localTaskServerFactory{stream: stream}
+	assert.Equal(t, stream, factory.GetStream("node"))
+	assert.Zero(t, factory.Register("node", stream))
+	assert.False(t, factory.Deregister(0, "node"))
+	assert.Equal(t, []models.Node{&localNode}, factory.Nodes())
+
+	assert.NoError(t, stream.Send(&protoCommonV1.TaskResponse{}))
+	assert.Error(t, stream.Send(&protoCommonV1.TaskResponse{}))
+	req, err := stream.Recv()
+	assert.Error(t, err)
+	assert.Nil(t, req)
+}
+
+func TestSearchLocal_ReadOnlyEngine(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.GlobalStorageConfig()
+	config.SetGlobalStorageConfig(&config.StorageBase{TSDB: config.TSDB{Dir: dir}})
+	kv.InitStoreManager(kv.NewStoreManager(kv.StoreOptions{Dir: dir}))
+	defer func() {
+		config.SetGlobalStorageConfig(cfg)
+		kv.InitStoreManager(nil)
+	}()
+
+	// writes data by writable engine, then closes it for releasing the lock of data directory
+	e, err := tsdb.NewEngine()
+	assert.NoError(t, err)
+	opt := &option.DatabaseOption{Intervals: option.Intervals{
+		{Interval: timeutil.Interval(10 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)},
+	}}
+	assert.NoError(t, e.CreateShards("db", opt, 1))
+	db, _ := e.GetDatabase("db")
+	shard, _ := e.GetShard("db", 1)
+	now := timeutil.Now()
+	family, err := shard.GetOrCrateDataFamily(now)
+	assert.NoError(t, err)
+	var ml = protoMetricsV1.MetricList{Metrics: []*protoMetricsV1.Metric{{
+		Name:         "cpu",
+		Timestamp:    now,
+		Tags:         []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+		SimpleFields: []*protoMetricsV1.SimpleField{{Name: "f1", Value: 10, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM}},
+	}}}
+	var buf bytes.Buffer
+	_, _ = metric.NewProtoConverter().MarshalProtoMetricListV1To(ml, &buf)
+	var br metric.StorageBatchRows
+	br.UnmarshalRows(buf.Bytes())
+	rows := br.Rows()
+	assert.NoError(t, shard.LookupRowMetricMeta(rows))
+	_, err = family.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.NoError(t, family.Flush())
+	assert.NoError(t, db.FlushMeta())
+	assert.NoError(t, shard.FlushIndex())
+	e.Close()
+
+	roEngine, err := tsdb.NewEngineWithOption(tsdb.EngineOption{Dir: dir, ReadOnly: true})
+	assert.NoError(t, err)
+	defer roEngine.Close()
+	roDB, ok := roEngine.GetDatabase("db")
+	assert.True(t, ok)
+	rs, err := SearchLocal(context.TODO(), roDB, "select f1 from cpu", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f1"}, rs.Fields)
+	assert.Len(t, rs.Series, 1)
+	points := rs.Series[0].Fields["f1"]
+	assert.Len(t, points, 1)
+	for _, v := range points {
+		assert.Equal(t, 10.0, v)
+	}
+	// writes are rejected by read-only engine
+	assert.ErrorIs(t, roEngine.CreateShards("db", opt, 2), constants.ErrReadOnly)
+}
+
+func TestValidateLocalQuery(t *testing.T) {
+	cases := []struct {
+		name      string
+		statement *stmt.Query
+		wantErr   bool
+	}{
+		{
+			name:      "leaf query",
+			statement: &stmt.Query{MetricName: "cpu", GroupBy: []string{"host"}, Limit: 20},
+		},
+		{
+			name:      "sub query",
+			statement: &stmt.Query{SubQuery: &stmt.Query{MetricName: "cpu"}},
+			wantErr:   true,
+		},
+		{
+			name:      "metric name pattern",
+			statement: &stmt.Query{MetricName: `cpu\..*`},
+			wantErr:   true,
+		},
+		{
+			name: "cross metric query",
+			statement: &stmt.Query{
+				MetricName:  "cpu",
+				SelectItems: []stmt.Expr{&stmt.FieldExpr{Name: "cpu.f"}},
+			},
+			wantErr: true,
+		},
+		{
+			name:      "order by",
+			statement: &stmt.Query{MetricName: "cpu", OrderByItems: []stmt.Expr{&stmt.FieldExpr{Name: "f"}}},
+			wantErr:   true,
+		},
+		{
+			name:      "offset",
+			statement: &stmt.Query{MetricName: "cpu", Offset: 10},
+			wantErr:   true,
+		},
+		{
+			name:      "fill",
+			statement: &stmt.Query{MetricName: "cpu", Fill: stmt.FillNull},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateLocalQuery(tt.statement)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrLocalQuery)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLocalQueryEngine_ExecuteLocalQuery_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newExecutePipelineFn = NewExecutePipeline
+		ctrl.Finish()
+	}()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	db := tsdb.NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db").AnyTimes()
+	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: timeutil.Interval(10 * timeutil.OneSecond)}}}
+	statement := &stmt.Query{MetricName: "cpu", SelectItems: []stmt.Expr{&stmt.FieldExpr{Name: "f"}}}
+
+	t.Run("refuse query requires broker merging", func(t *testing.T) {
+		e := NewLocalQueryEngine(engine, newTestAdmissionController(0), time.Second)
+		rs, err := e.ExecuteLocalQuery(context.TODO(), "db", &stmt.Query{MetricName: "cpu", Offset: 1})
+		assert.ErrorIs(t, err, ErrLocalQuery)
+		assert.Nil(t, rs)
+	})
+	t.Run("database not found", func(t *testing.T) {
+		engine.EXPECT().GetDatabase("db").Return(nil, false)
+		e := NewLocalQueryEngine(engine, newTestAdmissionController(0), time.Second)
+		rs, err := e.ExecuteLocalQuery(context.TODO(), "db", statement)
+		assert.ErrorIs(t, err, ErrNoDatabase)
+		assert.Nil(t, rs)
+	})
+	t.Run("database option not found", func(t *testing.T) {
+		engine.EXPECT().GetDatabase("db").Return(db, true)
+		db.EXPECT().GetOption().Return(nil)
+		e := NewLocalQueryEngine(engine, newTestAdmissionController(0), time.Second)
+		rs, err := e.ExecuteLocalQuery(context.TODO(), "db", statement)
+		assert.ErrorIs(t, err, constants.ErrNotFound)
+		assert.Nil(t, rs)
+	})
+	t.Run("node busy", func(t *testing.T) {
+		engine.EXPECT().GetDatabase("db").Return(db, true)
+		db.EXPECT().GetOption().Return(opt)
+		admission := newTestAdmissionController(1)
+		ticket, err := admission.Admit(context.TODO())
+		assert.NoError(t, err)
+		defer ticket.Release()
+		e := NewLocalQueryEngine(engine, admission, time.Second)
+		rs, err := e.ExecuteLocalQuery(context.TODO(), "db", statement)
+		assert.ErrorIs(t, err, constants.ErrNodeBusy)
+		assert.Nil(t, rs)
+	})
+	t.Run("leaf task timeout", func(t *testing.T) {
+		engine.EXPECT().GetDatabase("db").Return(db, true)
+		db.EXPECT().GetOption().Return(opt).AnyTimes()
+		db.EXPECT().GetConfig().Return(nil)
+		admission := newTestAdmissionController(1)
+		newExecutePipelineFn = func(_ *trackerpkg.StageTracker, _ func(err error)) Pipeline {
+			pipeline := NewMockPipeline(ctrl)
+			pipeline.EXPECT().Execute(gomock.Any())
+			return pipeline
+		}
+		e := NewLocalQueryEngine(engine, admission, 10*time.Millisecond)
+		rs, err := e.ExecuteLocalQuery(context.TODO(), "db", statement)
+		assert.ErrorIs(t, err, constants.ErrTimeout)
+		assert.Nil(t, rs)
+		// ticket is held until leaf task completed
+		assert.Equal(t, 1, admission.Executing())
+	})
+	t.Run("leaf task failure", func(t *testing.T) {
+		engine.EXPECT().GetDatabase("db").Return(db, true)
+		db.EXPECT().GetOption().Return(opt).AnyTimes()
+		db.EXPECT().GetConfig().Return(&models.DatabaseConfig{ShardIDs: []models.ShardID{1}})
+		admission := newTestAdmissionController(1)
+		newExecutePipelineFn = func(_ *trackerpkg.StageTracker, completeCallback func(err error)) Pipeline {
+			pipeline := NewMockPipeline(ctrl)
+			pipeline.EXPECT().Execute(gomock.Any()).Do(func(_ any) {
+				completeCallback(fmt.Errorf("metric not found"))
+			})
+			return pipeline
+		}
+		e := NewLocalQueryEngine(engine, admission, time.Second)
+		rs, err := e.ExecuteLocalQuery(context.TODO(), "db", statement)
+		assert.ErrorContains(t, err, "metric not found")
+		assert.Nil(t, rs)
+		assert.Zero(t, admission.Executing())
+	})
+}
+
+func TestLocalQueryEngine_ExecuteLocalQuery(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.GlobalStorageConfig()
+	config.SetGlobalStorageConfig(&config.StorageBase{TSDB: config.TSDB{
+		Dir:                dir,
+		MaxTagKeysNumber:   32,
+		MaxSeriesIDsNumber: 1000,
+	}})
+	kv.InitStoreManager(kv.NewStoreManager(kv.StoreOptions{Dir: dir}))
+	defer func() {
+		config.SetGlobalStorageConfig(cfg)
+		kv.InitStoreManager(nil)
+	}()
+
+	e, err := tsdb.NewEngine()
+	assert.NoError(t, err)
+	defer e.Close()
+	opt := &option.DatabaseOption{Intervals: option.Intervals{
+		{Interval: timeutil.Interval(10 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)},
+	}}
+	assert.NoError(t, e.CreateShards("db", opt, 1, 2))
+	now := timeutil.Now()
+	for _, shardID := range []models.ShardID{1, 2} {
+		shard, _ := e.GetShard("db", shardID)
+		family, err := shard.GetOrCrateDataFamily(now)
+		assert.NoError(t, err)
+		var ml = protoMetricsV1.MetricList{Metrics: []*protoMetricsV1.Metric{{
+			Name:         "cpu",
+			Timestamp:    now,
+			Tags:         []*protoMetricsV1.KeyValue{{Key: "host", Value: fmt.Sprintf("host-%d", shardID)}},
+			SimpleFields: []*protoMetricsV1.SimpleField{{Name: "f1", Value: 10, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM}},
+		}}}
+		var buf bytes.Buffer
+		_, _ = metric.NewProtoConverter().MarshalProtoMetricListV1To(ml, &buf)
+		var br metric.StorageBatchRows
+		br.UnmarshalRows(buf.Bytes())
+		rows := br.Rows()
+		assert.NoError(t, shard.LookupRowMetricMeta(rows))
+		_, err = family.WriteRows(1, rows)
+		assert.NoError(t, err)
+	}
+
+	admission := newTestAdmissionController(1)
+	localQuery := NewLocalQueryEngine(e, admission, time.Minute)
+	statement := parseQuery(t, "select sum(f1) from cpu group by host")
+	rs, err := localQuery.ExecuteLocalQuery(context.TODO(), "db", statement)
+	assert.NoError(t, err)
+	// partial aggregates of each group from local shards
+	assert.Len(t, rs.Series.TimeSeriesList, 2)
+	assert.NotNil(t, rs.Stats)
+	assert.Zero(t, admission.Executing())
+	// statement of caller is unchanged
+	assert.False(t, statement.ReturnStats)
+}
@@ -0,0 +1,262 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// MetricTagKey represents the implicit tag key of metric name for the series of metric name pattern query.
+const MetricTagKey = "_metric"
+
+// metricPatternChars represents the regex meta characters, metric name including any of them is a pattern.
+// NOTICE: '.' isn't included, because it's the common separator of metric name.
+const metricPatternChars = `\*+?[]()|^$`
+
+// ErrMetricPattern represents the error of metric name pattern query.
+var ErrMetricPattern = errors.New("metric name pattern query failure")
+
+// metadataSearchFunc represents the function which executes metadata query, used to resolve metric names.
+type metadataSearchFunc func(ctx context.Context,
+	param *models.ExecuteParam, statement *stmtpkg.MetricMetadata, mgr *SearchMgr,
+) (any, error)
+
+// blocksQueryFunc represents the function which executes query of single metric,
+// returns the time series blocks and the result set(only includes stats/stale trailer) of query.
+type blocksQueryFunc func(ctx context.Context,
+	param *models.ExecuteParam, statement *stmtpkg.Query, mgr *SearchMgr,
+) ([][]byte, *models.ResultSet, error)
+
+// metricPatternPlan represents the plan of metric name pattern query, like: select sum(usage) from 'cpu\..*' group by host.
+// 1. the metric names matched by pattern are resolved by metric name index of database;
+// 2. an identical sub query is planned for each matched metric, executes concurrently;
+// 3. the time series blocks of sub queries are tagged with metric name(_metric tag key) if it keeps series of each metric;
+// 4. all blocks are merged by root context before final aggregation, so aggregation across metrics also works.
+type metricPatternPlan struct {
+	statement    *stmtpkg.Query // final statement, group by includes _metric if not merge metrics
+	subQuery     *stmtpkg.Query // sub query template for each metric, group by excludes _metric
+	pattern      *regexp.Regexp
+	metricTagIdx int // index of _metric in group by tag keys, -1 if merge metrics
+}
+
+// isMetricPattern checks if metric name of query is a regex pattern.
+func isMetricPattern(metricName string) bool {
+	return strings.ContainsAny(metricName, metricPatternChars)
+}
+
+// newMetricPatternPlan creates the plan of metric name pattern query, returns nil if metric name isn't a pattern.
+func newMetricPatternPlan(statement *stmtpkg.Query, mergeMetrics bool) (*metricPatternPlan, error) {
+	if !isMetricPattern(statement.MetricName) {
+		return nil, nil
+	}
+	// pattern must match whole metric name
+	pattern, err := regexp.Compile("^(?:" + statement.MetricName + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("%w, invalid pattern: %s", ErrMetricPattern, err)
+	}
+	p := &metricPatternPlan{
+		pattern:      pattern,
+		metricTagIdx: -1,
+	}
+	var subGroupBy []string
+	for idx, tagKey := range statement.GroupBy {
+		if tagKey == MetricTagKey {
+			// group by _metric explicitly
+			p.metricTagIdx = idx
+			continue
+		}
+		subGroupBy = append(subGroupBy, tagKey)
+	}
+	q := *statement
+	if p.metricTagIdx < 0 && !mergeMetrics {
+		// keep series of each metric, group by _metric implicitly
+		p.metricTagIdx = 0
+		q.GroupBy = append([]string{MetricTagKey}, statement.GroupBy...)
+	}
+	p.statement = &q
+
+	subQuery := *statement
+	subQuery.GroupBy = subGroupBy
+	subQuery.OrderByItems = nil
+	subQuery.Limit = crossMetricSubQueryLimit // all groups are needed for merging
+	subQuery.Offset = 0
+	p.subQuery = &subQuery
+	return p, nil
+}
+
+// resolveMetrics returns the metric names matched by pattern in order,
+// scans the metric names starting with the literal prefix of pattern page by page.
+func (p *metricPatternPlan) resolveMetrics(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, search metadataSearchFunc,
+) ([]string, error) {
+	prefix, _ := p.pattern.LiteralPrefix()
+	subParam := *param
+	subParam.Limit = 0
+	subParam.Cursor = ""
+	var metrics []string
+	for {
+		rs, err := search(ctx, &subParam, &stmtpkg.MetricMetadata{
+			Namespace: p.statement.Namespace,
+			Type:      stmtpkg.Metric,
+			Prefix:    prefix,
+			Limit:     constants.MaxSuggestionPageSize,
+		}, mgr)
+		if err != nil {
+			return nil, err
+		}
+		suggest, ok := rs.(*models.SuggestResult)
+		if !ok {
+			return nil, fmt.Errorf("%w, unexpected result of resolving metric names", ErrMetricPattern)
+		}
+		for _, metricName := range suggest.Values {
+			if !p.pattern.MatchString(metricName) {
+				continue
+			}
+			if len(metrics) >= constants.MaxPatternMatchedMetrics {
+				return nil, fmt.Errorf("%w, pattern: %s matches more than %d metrics, please narrow it",
+					ErrMetricPattern, p.statement.MetricName, constants.MaxPatternMatchedMetrics)
+			}
+			metrics = append(metrics, metricName)
+		}
+		if suggest.Cursor == "" {
+			return metrics, nil
+		}
+		subParam.Cursor = suggest.Cursor
+	}
+}
+
+// execute resolves the metrics matched by pattern, executes sub query of each metric concurrently,
+// then merges the time series blocks of sub queries.
+// The metrics without the fields referenced by query are skipped in lenient strictness, else fails the query.
+func (p *metricPatternPlan) execute(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, search metadataSearchFunc, subQuery blocksQueryFunc,
+) (any, error) {
+	startTime := time.Now()
+	metrics, err := p.resolveMetrics(ctx, param, mgr, search)
+	if err != nil {
+		return nil, err
+	}
+	strict := queryStrictnessOf(param, param.Database, mgr) == option.QueryStrictnessStrict
+	if len(metrics) == 0 {
+		names := []models.UnknownName{{Type: models.UnknownMetric, Name: p.statement.MetricName}}
+		if strict {
+			return nil, unknownNameErr(names)
+		}
+		return p.emptyResultSet(mgr, startTime, nil, names), nil
+	}
+
+	blocks := make([][][]byte, len(metrics))
+	trailers := make([]*models.ResultSet, len(metrics))
+	errs := make([]error, len(metrics))
+	var wg sync.WaitGroup
+	for idx := range metrics {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			q := *p.subQuery
+			q.MetricName = metrics[idx]
+			subParam := *param
+			subParam.Stream = nil
+			blocks[idx], trailers[idx], errs[idx] = subQuery(ctx, &subParam, &q, mgr)
+		}(idx)
+	}
+	wg.Wait()
+
+	var unknownNames []models.UnknownName
+	var allBlocks [][]byte
+	for idx, err := range errs {
+		if err != nil {
+			names, ok := models.ParseUnknownNames(err)
+			if !ok || strict {
+				return nil, fmt.Errorf("sub query of metric: %s failure, %w", metrics[idx], err)
+			}
+			for _, name := range names {
+				name.Name = fmt.Sprintf("%s(metric: %s)", name.Name, metrics[idx])
+				unknownNames = append(unknownNames, name)
+			}
+			continue
+		}
+		for _, block := range blocks[idx] {
+			taggedBlock, err := insertTagValue(block, p.metricTagIdx, metrics[idx])
+			if err != nil {
+				return nil, err
+			}
+			allBlocks = append(allBlocks, taggedBlock)
+		}
+	}
+	if len(unknownNames) == len(metrics) {
+		// all matched metrics are skipped
+		return p.emptyResultSet(mgr, startTime, metrics, unknownNames), nil
+	}
+	rs, err := mergeSubQueryBlocks(ctx, param, mgr, &subQueryBlocks{
+		database:  param.Database,
+		statement: p.statement,
+		startTime: startTime,
+		blocks:    allBlocks,
+		trailers:  trailers,
+		names:     metrics,
+	})
+	if err != nil {
+		return nil, err
+	}
+	resultSet, ok := rs.(*models.ResultSet)
+	if !ok {
+		return rs, nil
+	}
+	if resultSet.Stats != nil {
+		resultSet.Stats.Metrics = metrics
+	}
+	if len(unknownNames) > 0 {
+		if resultSet.Stats == nil {
+			resultSet.Stats = &models.NodeStats{Node: mgr.CurNode.Indicator()}
+		}
+		for _, name := range unknownNames {
+			resultSet.Stats.UnknownNames = append(resultSet.Stats.UnknownNames, name.String())
+		}
+	}
+	return resultSet, nil
+}
+
+// emptyResultSet returns the empty result set with the matched metrics and unknown names noted in stats.
+func (p *metricPatternPlan) emptyResultSet(mgr *SearchMgr,
+	startTime time.Time, metrics []string, unknownNames []models.UnknownName,
+) *models.ResultSet {
+	now := time.Now()
+	stats := &models.NodeStats{
+		Node:      mgr.CurNode.Indicator(),
+		Start:     startTime.UnixNano(),
+		End:       now.UnixNano(),
+		TotalCost: now.Sub(startTime).Nanoseconds(),
+		Metrics:   metrics,
+	}
+	for _, name := range unknownNames {
+		stats.UnknownNames = append(stats.UnknownNames, name.String())
+	}
+	return &models.ResultSet{MetricName: p.statement.MetricName, Stats: stats}
+}
@@ -0,0 +1,290 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestNewMetricPatternPlan(t *testing.T) {
+	cases := []struct {
+		name         string
+		statement    *stmt.Query
+		merge        bool
+		nilPlan      bool
+		groupBy      []string
+		subGroupBy   []string
+		metricTagIdx int
+		wantErr      bool
+	}{
+		{
+			name:      "not pattern",
+			statement: &stmt.Query{MetricName: "cpu.load"},
+			nilPlan:   true,
+		},
+		{
+			name:      "invalid pattern",
+			statement: &stmt.Query{MetricName: `cpu(`},
+			wantErr:   true,
+		},
+		{
+			name:         "group by metric implicitly",
+			statement:    &stmt.Query{MetricName: `cpu\..*`, GroupBy: []string{"host"}},
+			groupBy:      []string{MetricTagKey, "host"},
+			subGroupBy:   []string{"host"},
+			metricTagIdx: 0,
+		},
+		{
+			name:         "group by metric explicitly",
+			statement:    &stmt.Query{MetricName: `cpu\..*`, GroupBy: []string{"host", MetricTagKey}},
+			merge:        true,
+			groupBy:      []string{"host", MetricTagKey},
+			subGroupBy:   []string{"host"},
+			metricTagIdx: 1,
+		},
+		{
+			name:         "merge metrics",
+			statement:    &stmt.Query{MetricName: `cpu\..*`, GroupBy: []string{"host"}, Limit: 10, Offset: 1},
+			merge:        true,
+			groupBy:      []string{"host"},
+			subGroupBy:   []string{"host"},
+			metricTagIdx: -1,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newMetricPatternPlan(tt.statement, tt.merge)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrMetricPattern)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.nilPlan {
+				assert.Nil(t, p)
+				return
+			}
+			assert.Equal(t, tt.groupBy, p.statement.GroupBy)
+			assert.Equal(t, tt.subGroupBy, p.subQuery.GroupBy)
+			assert.Equal(t, tt.metricTagIdx, p.metricTagIdx)
+			assert.Equal(t, crossMetricSubQueryLimit, p.subQuery.Limit)
+			assert.Zero(t, p.subQuery.Offset)
+		})
+	}
+}
+
+func TestMetricPatternPlan_resolveMetrics(t *testing.T) {
+	p, err := newMetricPatternPlan(&stmt.Query{Namespace: "ns", MetricName: `cpu\.(user|system)`}, false)
+	assert.NoError(t, err)
+	param := &models.ExecuteParam{Database: "db", Limit: 10, Cursor: "abc"}
+
+	t.Run("paginated", func(t *testing.T) {
+		metrics, err := p.resolveMetrics(context.TODO(), param, &SearchMgr{},
+			func(_ context.Context, param *models.ExecuteParam, statement *stmt.MetricMetadata, _ *SearchMgr) (any, error) {
+				assert.Equal(t, "ns", statement.Namespace)
+				assert.Equal(t, "cpu.", statement.Prefix)
+				assert.Equal(t, stmt.Metric, statement.Type)
+				assert.Zero(t, param.Limit)
+				if param.Cursor == "" {
+					return &models.SuggestResult{Values: []string{"cpu.idle", "cpu.system"}, Cursor: "next"}, nil
+				}
+				return &models.SuggestResult{Values: []string{"cpu.user", "cpu.users"}}, nil
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"cpu.system", "cpu.user"}, metrics)
+	})
+	t.Run("search failure", func(t *testing.T) {
+		metrics, err := p.resolveMetrics(context.TODO(), param, &SearchMgr{},
+			func(_ context.Context, _ *models.ExecuteParam, _ *stmt.MetricMetadata, _ *SearchMgr) (any, error) {
+				return nil, fmt.Errorf("err")
+			})
+		assert.Error(t, err)
+		assert.Nil(t, metrics)
+	})
+	t.Run("unexpected result", func(t *testing.T) {
+		metrics, err := p.resolveMetrics(context.TODO(), param, &SearchMgr{},
+			func(_ context.Context, _ *models.ExecuteParam, _ *stmt.MetricMetadata, _ *SearchMgr) (any, error) {
+				return &models.ResultSet{}, nil
+			})
+		assert.ErrorIs(t, err, ErrMetricPattern)
+		assert.Nil(t, metrics)
+	})
+	t.Run("too many matched metrics", func(t *testing.T) {
+		p, err := newMetricPatternPlan(&stmt.Query{MetricName: `cpu\..*`}, false)
+		assert.NoError(t, err)
+		metrics, err := p.resolveMetrics(context.TODO(), param, &SearchMgr{},
+			func(_ context.Context, _ *models.ExecuteParam, _ *stmt.MetricMetadata, _ *SearchMgr) (any, error) {
+				rs := &models.SuggestResult{}
+				for i := 0; i <= constants.MaxPatternMatchedMetrics; i++ {
+					rs.Values = append(rs.Values, fmt.Sprintf("cpu.%d", i))
+				}
+				return rs, nil
+			})
+		assert.ErrorIs(t, err, ErrMetricPattern)
+		assert.Nil(t, metrics)
+	})
+}
+
+func TestMetricPatternPlan_execute(t *testing.T) {
+	block := func(tags ...string) []byte {
+		tsList := &protoCommonV1.TimeSeriesList{
+			Start:    0,
+			End:      10 * timeutil.OneMinute,
+			Interval: timeutil.OneMinute,
+			FieldAggSpecs: []*protoCommonV1.AggregatorSpec{{
+				FieldName:    "f",
+				FieldType:    uint32(field.Sum),
+				FuncTypeList: []uint32{uint32(field.Sum)},
+			}},
+		}
+		for _, t := range tags {
+			tsList.TimeSeriesList = append(tsList.TimeSeriesList,
+				&protoCommonV1.TimeSeries{Tags: t, Fields: map[string][]byte{"f": nil}})
+		}
+		data, _ := tsList.Marshal()
+		return data
+	}
+	search := func(values ...string) metadataSearchFunc {
+		return func(_ context.Context, _ *models.ExecuteParam, _ *stmt.MetricMetadata, _ *SearchMgr) (any, error) {
+			return &models.SuggestResult{Values: values}, nil
+		}
+	}
+	unknownField := func() error {
+		return models.NewUnknownNameError([]models.UnknownName{{Type: models.UnknownField, Name: "f"}})
+	}
+	subQuery := func(_ context.Context, param *models.ExecuteParam, statement *stmt.Query, _ *SearchMgr,
+	) ([][]byte, *models.ResultSet, error) {
+		assert.Equal(t, "db", param.Database)
+		assert.Equal(t, []string{"host"}, statement.GroupBy)
+		switch statement.MetricName {
+		case "cpu.user":
+			return [][]byte{block("a", "b")}, &models.ResultSet{Stats: &models.NodeStats{Node: "broker"}}, nil
+		case "cpu.system":
+			return [][]byte{block("a")}, &models.ResultSet{Stale: &models.StaleResult{Shards: []models.ShardID{1}}}, nil
+		case "cpu.idle":
+			return nil, nil, unknownField()
+		default:
+			return nil, nil, fmt.Errorf("err")
+		}
+	}
+	statement := &stmt.Query{
+		MetricName:  `cpu\..*`,
+		SelectItems: []stmt.Expr{&stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "f"}}},
+		GroupBy:     []string{"host"},
+		Limit:       100,
+		Explain:     true,
+	}
+	param := &models.ExecuteParam{Database: "db", SQL: `select f from 'cpu\..*' group by host`}
+	strictParam := *param
+	strictParam.Strictness = "strict"
+
+	t.Run("keep series of each metric", func(t *testing.T) {
+		p, err := newMetricPatternPlan(statement, false)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, search("cpu.system", "cpu.user"), subQuery)
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.Equal(t, []string{MetricTagKey, "host"}, resultSet.GroupBy)
+		assert.Len(t, resultSet.Series, 3)
+		assert.Equal(t, map[string]string{MetricTagKey: "cpu.system", "host": "a"}, resultSet.Series[0].Tags)
+		assert.Equal(t, map[string]string{MetricTagKey: "cpu.user", "host": "a"}, resultSet.Series[1].Tags)
+		assert.Equal(t, []string{"cpu.system", "cpu.user"}, resultSet.Stats.Metrics)
+		assert.Len(t, resultSet.Stats.Children, 1)
+		assert.Equal(t, "cpu.user[broker]", resultSet.Stats.Children[0].Node)
+		assert.Equal(t, &models.StaleResult{Shards: []models.ShardID{1}}, resultSet.Stale)
+	})
+	t.Run("merge metrics", func(t *testing.T) {
+		p, err := newMetricPatternPlan(statement, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, search("cpu.system", "cpu.user"), subQuery)
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.Equal(t, []string{"host"}, resultSet.GroupBy)
+		assert.Len(t, resultSet.Series, 2)
+	})
+	t.Run("no matched metric", func(t *testing.T) {
+		p, err := newMetricPatternPlan(statement, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, search("cpu", "mem.used"), subQuery)
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.Empty(t, resultSet.Series)
+		assert.Equal(t, []string{`metric cpu\..*`}, resultSet.Stats.UnknownNames)
+
+		rs, err = p.execute(context.TODO(), &strictParam, &SearchMgr{}, search("cpu"), subQuery)
+		assert.ErrorIs(t, err, constants.ErrUnknownName)
+		assert.Nil(t, rs)
+	})
+	t.Run("skip metric without field", func(t *testing.T) {
+		p, err := newMetricPatternPlan(statement, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, search("cpu.idle", "cpu.user"), subQuery)
+		assert.NoError(t, err)
+		resultSet := rs.(*models.ResultSet)
+		assert.Len(t, resultSet.Series, 2)
+		assert.Equal(t, []string{"field f(metric: cpu.idle)"}, resultSet.Stats.UnknownNames)
+
+		rs, err = p.execute(context.TODO(), param, &SearchMgr{}, search("cpu.idle"), subQuery)
+		assert.NoError(t, err)
+		resultSet = rs.(*models.ResultSet)
+		assert.Empty(t, resultSet.Series)
+		assert.Equal(t, []string{"cpu.idle"}, resultSet.Stats.Metrics)
+
+		rs, err = p.execute(context.TODO(), &strictParam, &SearchMgr{}, search("cpu.idle", "cpu.user"), subQuery)
+		assert.ErrorIs(t, err, constants.ErrUnknownName)
+		assert.Nil(t, rs)
+	})
+	t.Run("resolve failure", func(t *testing.T) {
+		p, err := newMetricPatternPlan(statement, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{},
+			func(_ context.Context, _ *models.ExecuteParam, _ *stmt.MetricMetadata, _ *SearchMgr) (any, error) {
+				return nil, fmt.Errorf("err")
+			}, subQuery)
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+	t.Run("sub query failure", func(t *testing.T) {
+		p, err := newMetricPatternPlan(statement, true)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, search("cpu.user", "cpu.other"), subQuery)
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+	t.Run("bad block", func(t *testing.T) {
+		p, err := newMetricPatternPlan(statement, false)
+		assert.NoError(t, err)
+		rs, err := p.execute(context.TODO(), param, &SearchMgr{}, search("cpu.user"),
+			func(_ context.Context, _ *models.ExecuteParam, _ *stmt.Query, _ *SearchMgr) ([][]byte, *models.ResultSet, error) {
+				return [][]byte{[]byte("abc")}, nil, nil
+			})
+		assert.Error(t, err)
+		assert.Nil(t, rs)
+	})
+}
@@ -18,7 +18,13 @@
 package operator
 
 import (
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/tracing"
 	"github.com/lindb/lindb/tsdb"
 )
 
@@ -26,6 +32,8 @@ import (
 type dataFamilyRead struct {
 	executeCtx *flow.ShardExecuteContext
 	family     tsdb.DataFamily
+
+	stats *models.DataFamilyFilterStats
 }
 
 // NewDataFamilyRead creates a dataFamilyRead instance.
@@ -37,19 +45,54 @@ func NewDataFamilyRead(executeCtx *flow.ShardExecuteContext, family tsdb.DataFam
 }
 
 // Execute executes data family(file/memory) based on series ids, then add result set into time segment context.
-func (op *dataFamilyRead) Execute() error {
+func (op *dataFamilyRead) Execute() (err error) {
 	family := op.family
+	_, span := tracing.StartSpan(op.executeCtx.StorageExecuteCtx.Context(), "storage.family_filter")
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+	if op.executeCtx.StorageExecuteCtx.CollectStats {
+		// data families of shard are filtered one by one, so share the stats field of shard context
+		op.stats = &models.DataFamilyFilterStats{}
+		op.executeCtx.FamilyFilterStats = op.stats
+		defer func() {
+			op.executeCtx.FamilyFilterStats = nil
+		}()
+	}
 	resultSet, err := family.Filter(op.executeCtx)
 	if err != nil {
 		return err
 	}
+	recording := span.IsRecording()
+	numOfSeries := uint64(0)
 	for _, rs := range resultSet {
 		op.executeCtx.TimeSegmentContext.AddFilterResultSet(family.Interval(), rs)
+		if op.stats != nil {
+			op.stats.NumOfSeries += rs.SeriesIDs().GetCardinality()
+		}
+		if recording {
+			numOfSeries += rs.SeriesIDs().GetCardinality()
+		}
+	}
+	if recording {
+		span.SetAttributes(
+			attribute.String("family", family.Indicator()),
+			attribute.Int("resultSets", len(resultSet)),
+			attribute.Int64("series", int64(numOfSeries)),
+		)
 	}
 	return nil
 }
 
 // Identifier returns identifier string value of data family reader operator.
 func (op *dataFamilyRead) Identifier() string {
-	return "Data Family Read"
+	return fmt.Sprintf("Data Family Read[%s]", op.family.Indicator())
+}
+
+// Stats returns the stats of data family reader operator.
+func (op *dataFamilyRead) Stats() interface{} {
+	if op.stats == nil {
+		return nil
+	}
+	return op.stats
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/tsdb"
 )
@@ -37,6 +38,7 @@ func TestDataFamilyRead_Execute(t *testing.T) {
 
 	family := tsdb.NewMockDataFamily(ctrl)
 	shardCtx := &flow.ShardExecuteContext{
+		StorageExecuteCtx:  &flow.StorageExecuteContext{},
 		TimeSegmentContext: flow.NewTimeSegmentContext(),
 	}
 
@@ -55,8 +57,30 @@ func TestDataFamilyRead_Execute(t *testing.T) {
 		family.EXPECT().Interval().Return(timeutil.Interval(10))
 		family.EXPECT().Filter(gomock.Any()).Return([]flow.FilterResultSet{rs}, nil)
 		assert.NoError(t, op.Execute())
+		assert.Nil(t, op.(TrackableOperator).Stats())
 	})
 
-	op := NewDataFamilyRead(nil, nil)
-	assert.NotEmpty(t, op.Identifier())
+	t.Run("filter data with stats", func(t *testing.T) {
+		shardCtx.StorageExecuteCtx.CollectStats = true
+		defer func() {
+			shardCtx.StorageExecuteCtx.CollectStats = false
+		}()
+		rs := flow.NewMockFilterResultSet(ctrl)
+		rs.EXPECT().FamilyTime().Return(int64(1010))
+		rs.EXPECT().SlotRange().Return(timeutil.SlotRange{})
+		rs.EXPECT().SeriesIDs().Return(roaring.BitmapOf(1, 2, 3)).Times(2)
+		op := NewDataFamilyRead(shardCtx, family)
+		family.EXPECT().Interval().Return(timeutil.Interval(10))
+		family.EXPECT().Filter(gomock.Any()).DoAndReturn(func(ctx *flow.ShardExecuteContext) ([]flow.FilterResultSet, error) {
+			assert.NotNil(t, ctx.FamilyFilterStats)
+			return []flow.FilterResultSet{rs}, nil
+		})
+		assert.NoError(t, op.Execute())
+		assert.Nil(t, shardCtx.FamilyFilterStats)
+		assert.Equal(t, &models.DataFamilyFilterStats{NumOfSeries: 3}, op.(TrackableOperator).Stats())
+	})
+
+	op := NewDataFamilyRead(nil, family)
+	family.EXPECT().Indicator().Return("db/1/20230127/5m")
+	assert.Equal(t, "Data Family Read[db/1/20230127/5m]", op.Identifier())
 }
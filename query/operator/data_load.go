@@ -71,6 +71,12 @@ func (op *dataLoad) Execute() error {
 	queryIntervalRatio := op.segmentRS.IntervalRatio
 	baseSlot := op.segmentRS.BaseTime
 
+	// cumulative counter need to be converted into increase per series
+	specs := op.executeCtx.ShardExecuteCtx.StorageExecuteCtx.DownSamplingSpecs
+	counters := make([]bool, len(specs))
+	for idx := range specs {
+		counters[idx] = aggregation.IsCounterSpec(specs[idx])
+	}
 	// load field series data by series ids
 	op.executeCtx.Decoder = encoding.GetTSDDecoder()
 	op.executeCtx.DownSampling = func(slotRange timeutil.SlotRange, lowSeriesIdx uint16, fieldIdx int, getter encoding.TSDValueGetter) {
@@ -83,6 +89,15 @@ func (op *dataLoad) Execute() error {
 			return
 		}
 		op.foundSeries++
+		if fieldIdx < len(counters) && counters[fieldIdx] {
+			// counter is converted into increase after all time segments of series loaded
+			op.executeCtx.Counters.Collect(
+				lowSeriesIdx, fieldIdx, familyTime, op.segmentRS.Interval,
+				slotRange, targetSlotRange, queryIntervalRatio, baseSlot,
+				getter,
+			)
+			return
+		}
 		aggregation.DownSampling(
 			slotRange, targetSlotRange, queryIntervalRatio, baseSlot,
 			getter,
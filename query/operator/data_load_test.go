@@ -27,6 +27,7 @@ import (
 	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/aggregation"
+	"github.com/lindb/lindb/aggregation/function"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -85,6 +86,36 @@ func TestDataLoader_Execute(t *testing.T) {
 		op := NewDataLoad(ctx, segment, rs)
 		assert.NoError(t, op.Execute())
 	})
+	t.Run("load counter data", func(t *testing.T) {
+		defer func() {
+			ctx.ShardExecuteCtx.StorageExecuteCtx.DownSamplingSpecs[0] = aggregation.NewAggregatorSpec("f", field.SumField)
+		}()
+		spec := aggregation.NewAggregatorSpec("f", field.LastField)
+		spec.AddFunctionType(function.Increase)
+		ctx.ShardExecuteCtx.StorageExecuteCtx.DownSamplingSpecs[0] = spec
+		loader := flow.NewMockDataLoader(ctrl)
+		rs.EXPECT().SeriesIDs().Return(roaring.BitmapOf(1, 2))
+		rs.EXPECT().Load(gomock.Any()).Return(loader)
+		fAgg := aggregation.NewMockFieldAggregator(ctrl)
+		agg.EXPECT().GetAggregator(gomock.Any()).Return(fAgg, true)
+		getter := encoding.NewMockTSDValueGetter(ctrl)
+		getter.EXPECT().GetValue(uint16(5)).Return(5.0, true)
+		getter.EXPECT().GetValue(uint16(6)).Return(8.0, true)
+		loader.EXPECT().Load(gomock.Any()).Do(func(ctx *flow.DataLoadContext) {
+			ctx.DownSampling(timeutil.SlotRange{Start: 5, End: 6}, 0, 0, getter)
+		})
+		ctx.Counters = aggregation.NewCounterSeries()
+		segment.Interval = timeutil.Interval(timeutil.OneSecond)
+		segment.TargetRange = timeutil.SlotRange{Start: 0, End: 10}
+		op := NewDataLoad(ctx, segment, rs)
+		assert.NoError(t, op.Execute())
+		// counter converted into increase after all time segments loaded
+		rs := make(map[int]float64)
+		ctx.Counters.Emit(func(_ uint16, _ int, _ int64, targetPos int, value float64) {
+			rs[targetPos] = value
+		})
+		assert.Equal(t, map[int]float64{6: 3.0}, rs)
+	})
 }
 
 func TestDataLoad_Stats(t *testing.T) {
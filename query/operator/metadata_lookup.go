@@ -18,6 +18,7 @@
 package operator
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 
@@ -27,7 +28,10 @@ import (
 	"github.com/lindb/lindb/aggregation/function"
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/strutil"
 	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
 	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb"
@@ -41,6 +45,8 @@ type metadataLookup struct {
 	executeCtx *flow.StorageExecuteContext
 
 	fields map[field.ID]*aggregation.Aggregator
+	// unknown fields referenced by select list
+	unknownFields []string
 
 	err error
 }
@@ -61,6 +67,9 @@ func (op *metadataLookup) Execute() error {
 	query := op.executeCtx.Query
 	metricID, err := op.metadata.GetMetricID(query.Namespace, query.MetricName)
 	if err != nil {
+		if errors.Is(err, constants.ErrMetricIDNotFound) {
+			return op.unknownMetricErr(err)
+		}
 		return err
 	}
 
@@ -147,9 +156,44 @@ func (op *metadataLookup) selectList() error {
 			return op.err
 		}
 	}
+	if len(op.unknownFields) > 0 {
+		return op.unknownFieldErr()
+	}
 	return nil
 }
 
+// unknownMetricErr returns the error of unknown metric with close-match suggestions,
+// returns the not found error if suggesting fails.
+func (op *metadataLookup) unknownMetricErr(notFound error) error {
+	queryStmt := op.executeCtx.Query
+	suggestions, err := op.metadata.SuggestCloseMetrics(queryStmt.Namespace, queryStmt.MetricName,
+		constants.MaxCloseMatchSuggestions)
+	if err != nil {
+		return notFound
+	}
+	return models.NewUnknownNameError([]models.UnknownName{
+		{Type: models.UnknownMetric, Name: queryStmt.MetricName, Suggestions: suggestions},
+	})
+}
+
+// unknownFieldErr returns the error of unknown fields referenced by select list with close-match suggestions of each field.
+func (op *metadataLookup) unknownFieldErr() error {
+	queryStmt := op.executeCtx.Query
+	allFields, err := op.metadata.GetAllFields(queryStmt.Namespace, queryStmt.MetricName)
+	if err != nil {
+		return err
+	}
+	names := make([]models.UnknownName, len(op.unknownFields))
+	for idx, fieldName := range op.unknownFields {
+		matcher := strutil.NewFuzzyMatcher(fieldName, constants.MaxCloseMatchSuggestions)
+		for _, f := range allFields {
+			matcher.Add(f.Name.String())
+		}
+		names[idx] = models.UnknownName{Type: models.UnknownField, Name: fieldName, Suggestions: matcher.Matches()}
+	}
+	return models.NewUnknownNameError(names)
+}
+
 // field plans the field expr from select list
 func (op *metadataLookup) field(parentFunc *stmt.CallExpr, expr stmt.Expr) {
 	if op.err != nil {
@@ -175,6 +219,11 @@ func (op *metadataLookup) field(parentFunc *stmt.CallExpr, expr stmt.Expr) {
 		queryStmt := op.executeCtx.Query
 		fieldMeta, err := op.metadata.GetField(queryStmt.Namespace, queryStmt.MetricName, field.Name(e.Name))
 		if err != nil {
+			if errors.Is(err, constants.ErrFieldNotFound) {
+				// collects all unknown fields, so that all of them are reported
+				op.addUnknownField(e.Name)
+				return
+			}
 			op.err = err
 			return
 		}
@@ -210,12 +259,40 @@ func (op *metadataLookup) field(parentFunc *stmt.CallExpr, expr stmt.Expr) {
 			aggregator.Aggregator.AddFunctionType(parentFunc.FuncType)
 		}
 		aggregator.DownSampling.AddFunctionType(funcType)
+		if !isCounterFuncsValid(aggregator.DownSampling) {
+			op.err = fmt.Errorf("field[%s] cannot mix counter function(rate/increase) with other functions", e.Name)
+			return
+		}
+	}
+}
+
+// addUnknownField adds the unknown field referenced by select list if not added.
+func (op *metadataLookup) addUnknownField(fieldName string) {
+	for _, name := range op.unknownFields {
+		if name == fieldName {
+			return
+		}
+	}
+	op.unknownFields = append(op.unknownFields, fieldName)
+}
+
+// isCounterFuncsValid checks if counter functions(rate/increase) of last field are mixed with other functions,
+// because values of last field are converted into increase for counter functions when loading data.
+func isCounterFuncsValid(spec aggregation.AggregatorSpec) bool {
+	if !aggregation.IsCounterSpec(spec) {
+		return true
 	}
+	for funcType := range spec.Functions() {
+		if !function.IsCounterFunc(funcType) {
+			return false
+		}
+	}
+	return true
 }
 
 func (op *metadataLookup) planHistogramFields(e *stmt.CallExpr) {
-	if len(e.Params) != 1 {
-		op.err = fmt.Errorf("qunantile params more than one")
+	if len(e.Params) == 0 || len(e.Params) > 2 {
+		op.err = fmt.Errorf("quantile params must be quantile value and optional histogram field")
 		return
 	}
 	if v, err := strconv.ParseFloat(e.Params[0].Rewrite(), 64); err != nil {
@@ -226,7 +303,15 @@ func (op *metadataLookup) planHistogramFields(e *stmt.CallExpr) {
 		return
 	}
 	queryStmt := op.executeCtx.Query
-	fieldMetas, err := op.metadata.GetAllHistogramFields(queryStmt.Namespace, queryStmt.MetricName)
+	var (
+		fieldMetas field.Metas
+		err        error
+	)
+	if len(e.Params) == 2 {
+		fieldMetas, err = op.getHistogramFamilyFields(e.Params[1])
+	} else {
+		fieldMetas, err = op.metadata.GetAllHistogramFields(queryStmt.Namespace, queryStmt.MetricName)
+	}
 	if err != nil {
 		op.err = err
 		return
@@ -244,6 +329,36 @@ func (op *metadataLookup) planHistogramFields(e *stmt.CallExpr) {
 	}
 }
 
+// getHistogramFamilyFields returns the bucket fields of histogram family(${family}__bucket_${boundary}),
+// buckets are summed across series, so that bucket field must be histogram or sum field.
+func (op *metadataLookup) getHistogramFamilyFields(familyExpr stmt.Expr) (field.Metas, error) {
+	family, ok := familyExpr.(*stmt.FieldExpr)
+	if !ok {
+		return nil, fmt.Errorf("quantile param: %s is not histogram field", familyExpr.Rewrite())
+	}
+	queryStmt := op.executeCtx.Query
+	allFields, err := op.metadata.GetAllFields(queryStmt.Namespace, queryStmt.MetricName)
+	if err != nil {
+		return nil, err
+	}
+	var rs field.Metas
+	for idx := range allFields {
+		name, _, err := metric.HistogramFamilyBucket(allFields[idx].Name.String())
+		if err != nil || name != family.Name {
+			continue
+		}
+		if fieldType := allFields[idx].Type; fieldType != field.HistogramField && fieldType != field.SumField {
+			return nil, fmt.Errorf("histogram bucket field[%s] with type[%s] cannot be summed",
+				allFields[idx].Name, fieldType)
+		}
+		rs = append(rs, allFields[idx])
+	}
+	if len(rs) == 0 {
+		return nil, fmt.Errorf("%w, histogram field: %s", constants.ErrFieldNotFound, family.Name)
+	}
+	return rs, nil
+}
+
 // Identifier returns identifier string value of metadata lookup operator.
 func (op *metadataLookup) Identifier() string {
 	return "Metadata Lookup"
@@ -18,6 +18,7 @@
 package operator
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
@@ -26,7 +27,9 @@ import (
 
 	"github.com/lindb/lindb/aggregation"
 	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/series/tag"
@@ -53,6 +56,25 @@ func TestMetadataLookup_Execute(t *testing.T) {
 		metaDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(metric.ID(0), fmt.Errorf("err"))
 		assert.Error(t, op.Execute())
 	})
+	t.Run("unknown metric with suggestions", func(t *testing.T) {
+		op := NewMetadataLookup(ctx, db)
+		metaDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).
+			Return(metric.ID(0), fmt.Errorf("%w, metric: cpu", constants.ErrMetricIDNotFound))
+		metaDB.EXPECT().SuggestCloseMetrics(gomock.Any(), gomock.Any(), constants.MaxCloseMatchSuggestions).
+			Return([]string{"cpu"}, nil)
+		err := op.Execute()
+		assert.True(t, errors.Is(err, constants.ErrUnknownName))
+		names, ok := models.ParseUnknownNames(err)
+		assert.True(t, ok)
+		assert.Equal(t, []models.UnknownName{{Type: models.UnknownMetric, Suggestions: []string{"cpu"}}}, names)
+	})
+	t.Run("suggest close metrics failure", func(t *testing.T) {
+		op := NewMetadataLookup(ctx, db)
+		metaDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).
+			Return(metric.ID(0), fmt.Errorf("%w, metric: cpu", constants.ErrMetricIDNotFound))
+		metaDB.EXPECT().SuggestCloseMetrics(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+		assert.True(t, errors.Is(op.Execute(), constants.ErrMetricIDNotFound))
+	})
 	t.Run("get group tag failure", func(t *testing.T) {
 		defer func() {
 			ctx.Query.GroupBy = nil
@@ -75,6 +97,40 @@ func TestMetadataLookup_Execute(t *testing.T) {
 		metaDB.EXPECT().GetField(gomock.Any(), gomock.Any(), gomock.Any()).Return(field.Meta{}, fmt.Errorf("err"))
 		assert.Error(t, op.Execute())
 	})
+	t.Run("unknown fields with suggestions", func(t *testing.T) {
+		ctx.Query.SelectItems = []stmtpkg.Expr{
+			&stmtpkg.FieldExpr{Name: "usge"},
+			&stmtpkg.FieldExpr{Name: "f"},
+			&stmtpkg.CallExpr{FuncType: function.Max, Params: []stmtpkg.Expr{&stmtpkg.FieldExpr{Name: "usge"}}},
+			&stmtpkg.FieldExpr{Name: "xyz"},
+		}
+		op := NewMetadataLookup(ctx, db)
+		metaDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(metric.ID(10), nil)
+		metaDB.EXPECT().GetField(gomock.Any(), gomock.Any(), field.Name("f")).
+			Return(field.Meta{ID: 10, Type: field.SumField, Name: "f"}, nil)
+		metaDB.EXPECT().GetField(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(field.Meta{}, fmt.Errorf("%w, field: usge", constants.ErrFieldNotFound)).Times(3)
+		metaDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).
+			Return(field.Metas{{Name: "usage"}, {Name: "used"}, {Name: "f"}}, nil)
+		err := op.Execute()
+		names, ok := models.ParseUnknownNames(err)
+		assert.True(t, ok)
+		assert.Equal(t, []models.UnknownName{
+			{Type: models.UnknownField, Name: "usge", Suggestions: []string{"usage"}},
+			{Type: models.UnknownField, Name: "xyz"},
+		}, names)
+	})
+	t.Run("get all fields failure", func(t *testing.T) {
+		ctx.Query.SelectItems = []stmtpkg.Expr{&stmtpkg.FieldExpr{Name: "usge"}}
+		op := NewMetadataLookup(ctx, db)
+		metaDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(metric.ID(10), nil)
+		metaDB.EXPECT().GetField(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(field.Meta{}, fmt.Errorf("%w, field: usge", constants.ErrFieldNotFound))
+		metaDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+		err := op.Execute()
+		assert.Error(t, err)
+		assert.False(t, errors.Is(err, constants.ErrUnknownName))
+	})
 	t.Run("execute successfully", func(t *testing.T) {
 		ctx.Query.SelectItems = []stmtpkg.Expr{&stmtpkg.FieldExpr{Name: "f"}}
 		op := NewMetadataLookup(ctx, db)
@@ -165,6 +221,30 @@ func TestMetadataLookup_field(t *testing.T) {
 		})
 		assert.Error(t, op.err)
 	})
+	t.Run("mix counter function with other functions", func(t *testing.T) {
+		metaDB2 := metadb.NewMockMetadataDatabase(ctrl)
+		metaDB2.EXPECT().GetField(gomock.Any(), gomock.Any(), gomock.Any()).Return(field.Meta{
+			ID:   field.ID(10),
+			Type: field.LastField,
+			Name: "f",
+		}, nil).AnyTimes()
+		op := &metadataLookup{
+			executeCtx: ctx,
+			metadata:   metaDB2,
+			fields:     make(map[field.ID]*aggregation.Aggregator),
+		}
+		op.field(nil, &stmtpkg.CallExpr{
+			FuncType: function.Increase,
+			Params:   []stmtpkg.Expr{&stmtpkg.FieldExpr{Name: "f"}},
+		})
+		op.field(nil, &stmtpkg.CallExpr{
+			FuncType: function.Rate,
+			Params:   []stmtpkg.Expr{&stmtpkg.FieldExpr{Name: "f"}},
+		})
+		assert.NoError(t, op.err)
+		op.field(nil, &stmtpkg.FieldExpr{Name: "f"})
+		assert.Error(t, op.err)
+	})
 
 	cases := []struct {
 		name    string
@@ -245,6 +325,13 @@ func TestMetadataLookup_planHistogramFields(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "too many params",
+			in: &stmtpkg.CallExpr{
+				Params: []stmtpkg.Expr{&stmtpkg.NumberLiteral{Val: 0.95}, &stmtpkg.FieldExpr{Name: "a"}, &stmtpkg.FieldExpr{Name: "b"}},
+			},
+			wantErr: true,
+		},
 		{
 			name: "parse params failure",
 			in: &stmtpkg.CallExpr{
@@ -283,6 +370,60 @@ func TestMetadataLookup_planHistogramFields(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "histogram field not field expr",
+			in: &stmtpkg.CallExpr{
+				Params: []stmtpkg.Expr{&stmtpkg.NumberLiteral{Val: 0.95}, &stmtpkg.NumberLiteral{Val: 1}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "get histogram family fields failure",
+			in: &stmtpkg.CallExpr{
+				Params: []stmtpkg.Expr{&stmtpkg.NumberLiteral{Val: 0.95}, &stmtpkg.FieldExpr{Name: "latency"}},
+			},
+			prepare: func() {
+				metaDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "histogram family not found",
+			in: &stmtpkg.CallExpr{
+				Params: []stmtpkg.Expr{&stmtpkg.NumberLiteral{Val: 0.95}, &stmtpkg.FieldExpr{Name: "latency"}},
+			},
+			prepare: func() {
+				metaDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).
+					Return(field.Metas{{Type: field.HistogramField, Name: "__bucket_1"}, {Type: field.SumField, Name: "latency"}}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "histogram bucket field cannot be summed",
+			in: &stmtpkg.CallExpr{
+				Params: []stmtpkg.Expr{&stmtpkg.NumberLiteral{Val: 0.95}, &stmtpkg.FieldExpr{Name: "latency"}},
+			},
+			prepare: func() {
+				metaDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).
+					Return(field.Metas{{Type: field.LastField, Name: "latency__bucket_1"}}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "find histogram family fields successfully",
+			in: &stmtpkg.CallExpr{
+				Params: []stmtpkg.Expr{&stmtpkg.NumberLiteral{Val: 0.95}, &stmtpkg.FieldExpr{Name: "latency"}},
+			},
+			prepare: func() {
+				metaDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).
+					Return(field.Metas{
+						{ID: 1, Type: field.SumField, Name: "latency__bucket_1"},
+						{ID: 2, Type: field.SumField, Name: "latency__bucket_+Inf"},
+						{ID: 3, Type: field.HistogramField, Name: "__bucket_1"},
+					}, nil)
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range cases {
@@ -52,7 +52,8 @@ func (op *metricAllSeries) Execute() error {
 		seriesIDs.Add(series.IDWithoutTags)
 	}
 	op.executeCtx.SeriesIDsAfterFiltering.Or(seriesIDs)
-	return nil
+	// abort query early if too many series matched
+	return op.executeCtx.CheckSeriesLimit()
 }
 
 // Identifier returns identifier string value of all series operator.
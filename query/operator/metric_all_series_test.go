@@ -71,6 +71,16 @@ func TestMetricAllSeries_Execute(t *testing.T) {
 		assert.NoError(t, op.Execute())
 		assert.Equal(t, roaring.BitmapOf(0, 3, 5), ctx.SeriesIDsAfterFiltering)
 	})
+	t.Run("too many series", func(t *testing.T) {
+		ctx.SeriesIDsAfterFiltering = roaring.New()
+		ctx.StorageExecuteCtx.MaxSeries = 2
+		defer func() {
+			ctx.StorageExecuteCtx.MaxSeries = 0
+		}()
+		op := NewMetricAllSeries(ctx, shard)
+		indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(3, 5), nil)
+		assert.ErrorIs(t, op.Execute(), constants.ErrTooManySeries)
+	})
 }
 
 func TestMetricAllSeries_Stats(t *testing.T) {
@@ -31,14 +31,19 @@ func NewMetricSuggest(ctx *context.LeafMetadataContext) Operator {
 	}
 }
 
-// Execute returns metric list by given namespace/prefix.
+// Execute returns metric list by given namespace/prefix in order, starts after the resume position of paginated query.
 func (op *metricSuggest) Execute() error {
 	req := op.ctx.Request
 	limit := op.ctx.Limit
-	rs, err := op.ctx.Database.Metadata().MetadataDatabase().SuggestMetrics(req.Namespace, req.Prefix, limit)
+	// suggest one more for checking if more metrics exist
+	rs, err := op.ctx.Database.Metadata().MetadataDatabase().SuggestMetrics(req.Namespace, req.Prefix, req.After, limit+1)
 	if err != nil {
 		return err
 	}
+	if len(rs) > limit {
+		rs = rs[:limit]
+		op.ctx.Truncated = true
+	}
 	op.ctx.ResultSet = rs
 	return nil
 }
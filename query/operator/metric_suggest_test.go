@@ -52,7 +52,7 @@ func TestMetricSuggest_Execute(t *testing.T) {
 		{
 			name: "metric suggest failure",
 			prepare: func() {
-				metaDB.EXPECT().SuggestMetrics(gomock.Any(), gomock.Any(), gomock.Any()).
+				metaDB.EXPECT().SuggestMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(nil, fmt.Errorf("err"))
 			},
 			wantErr: true,
@@ -60,7 +60,7 @@ func TestMetricSuggest_Execute(t *testing.T) {
 		{
 			name: "metric suggest successfully",
 			prepare: func() {
-				metaDB.EXPECT().SuggestMetrics(gomock.Any(), gomock.Any(), gomock.Any()).
+				metaDB.EXPECT().SuggestMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 					Return([]string{"name"}, nil)
 			},
 		},
@@ -81,6 +81,33 @@ func TestMetricSuggest_Execute(t *testing.T) {
 	}
 }
 
+func TestMetricSuggest_Execute_paginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := tsdb.NewMockDatabase(ctrl)
+	meta := metadb.NewMockMetadata(ctrl)
+	metaDB := metadb.NewMockMetadataDatabase(ctrl)
+	meta.EXPECT().MetadataDatabase().Return(metaDB).AnyTimes()
+	db.EXPECT().Metadata().Return(meta).AnyTimes()
+
+	ctx := context.NewLeafMetadataContext(&stmtpkg.MetricMetadata{
+		Type: stmtpkg.Metric, Namespace: "ns", Prefix: "cpu", After: "cpu.a", Limit: 2,
+	}, db, nil)
+	metaDB.EXPECT().SuggestMetrics("ns", "cpu", "cpu.a", 3).Return([]string{"cpu.b", "cpu.c", "cpu.d"}, nil)
+	assert.NoError(t, NewMetricSuggest(ctx).Execute())
+	assert.Equal(t, []string{"cpu.b", "cpu.c"}, ctx.ResultSet)
+	assert.True(t, ctx.Truncated)
+
+	ctx = context.NewLeafMetadataContext(&stmtpkg.MetricMetadata{
+		Type: stmtpkg.Metric, Namespace: "ns", After: "cpu.c", Limit: 2,
+	}, db, nil)
+	metaDB.EXPECT().SuggestMetrics("ns", "", "cpu.c", 3).Return([]string{"cpu.d"}, nil)
+	assert.NoError(t, NewMetricSuggest(ctx).Execute())
+	assert.Equal(t, []string{"cpu.d"}, ctx.ResultSet)
+	assert.False(t, ctx.Truncated)
+}
+
 func TestMetricSuggest_Identifier(t *testing.T) {
 	assert.Equal(t, "Metric Suggest", NewMetricSuggest(nil).Identifier())
 }
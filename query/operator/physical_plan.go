@@ -18,6 +18,7 @@
 package operator
 
 import (
+	"github.com/lindb/lindb/pkg/tracing"
 	"github.com/lindb/lindb/query/context"
 )
 
@@ -32,7 +33,11 @@ func NewPhysicalPlan(ctx context.TaskContext) Operator {
 }
 
 // Execute returns physical plan by given task context.
-func (op *physicalPlan) Execute() error {
+func (op *physicalPlan) Execute() (err error) {
+	_, span := tracing.StartSpan(op.ctx.Context(), "query.plan")
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
 	return op.ctx.MakePlan()
 }
 
@@ -18,19 +18,21 @@
 package operator
 
 import (
+	"context"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
-	"github.com/lindb/lindb/query/context"
+	contextpkg "github.com/lindb/lindb/query/context"
 )
 
 func TestPhysicalPlan(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
-	taskCtx := context.NewMockTaskContext(ctrl)
+	taskCtx := contextpkg.NewMockTaskContext(ctrl)
+	taskCtx.EXPECT().Context().Return(context.TODO())
 	taskCtx.EXPECT().MakePlan().Return(nil)
 	op := NewPhysicalPlan(taskCtx)
 	assert.NoError(t, op.Execute())
@@ -18,14 +18,14 @@
 package operator
 
 import (
-	"fmt"
+	"time"
 
 	"github.com/lindb/roaring"
+	"go.opentelemetry.io/otel/attribute"
 
-	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
-	"github.com/lindb/lindb/series/tag"
+	"github.com/lindb/lindb/pkg/tracing"
 	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb"
 	"github.com/lindb/lindb/tsdb/indexdb"
@@ -36,7 +36,8 @@ type seriesFiltering struct {
 	executeCtx *flow.ShardExecuteContext
 	indexDB    indexdb.IndexDatabase
 
-	err error
+	filterStats []*models.SeriesFilterStats
+	err         error
 }
 
 // NewSeriesFiltering creates a seriesFiltering instance.
@@ -48,71 +49,119 @@ func NewSeriesFiltering(executeCtx *flow.ShardExecuteContext, shard tsdb.Shard)
 }
 
 // Execute executes filtering series ids based on tag values result set.
-func (op *seriesFiltering) Execute() error {
+func (op *seriesFiltering) Execute() (err error) {
+	_, span := tracing.StartSpan(op.executeCtx.StorageExecuteCtx.Context(), "storage.index_lookup")
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+
 	queryStmt := op.executeCtx.StorageExecuteCtx.Query
+	if queryStmt.Condition == nil {
+		return nil
+	}
 	// if it gets tag filter result do series ids searching
-	_, seriesIDs := op.findSeriesIDsByExpr(queryStmt.Condition)
+	seriesIDs, negated := op.findSeriesIDsByExpr(queryStmt.Condition)
 	if op.err != nil {
 		return op.err
 	}
+	if negated {
+		// condition is a negative filter(like: not (a or b)), excludes matched series from all series of metric
+		all, err := op.indexDB.GetSeriesIDsForMetric(queryStmt.Namespace, queryStmt.MetricName)
+		if err != nil {
+			return err
+		}
+		all.AndNot(seriesIDs)
+		seriesIDs = all
+	}
+	if span.IsRecording() {
+		span.SetAttributes(attribute.Int64("series", int64(seriesIDs.GetCardinality())))
+	}
 	op.executeCtx.SeriesIDsAfterFiltering.Or(seriesIDs)
-	return nil
+	// abort query early if too many series matched
+	return op.executeCtx.CheckSeriesLimit()
 }
 
-// findSeriesIDsByExpr finds series ids by expr, recursion filter for expr
-func (op *seriesFiltering) findSeriesIDsByExpr(condition stmt.Expr) (tag.KeyID, *roaring.Bitmap) {
-	if condition == nil {
-		return 0, roaring.New() // create an empty series ids for parent expr
-	}
+// findSeriesIDsByExpr finds series ids by expr, recursion filter for expr.
+// NOT is computed lazily, if negated is true, the result is the complement of returned series ids,
+// so that negative filter under AND is computed by AND-NOT without materializing all series of metric.
+func (op *seriesFiltering) findSeriesIDsByExpr(condition stmt.Expr) (seriesIDs *roaring.Bitmap, negated bool) {
 	if op.err != nil {
-		return 0, roaring.New() // create an empty series ids for parent expr
+		return roaring.New(), false // create an empty series ids for parent expr
 	}
 	switch expr := condition.(type) {
 	case stmt.TagFilter:
-		tagKey, seriesIDs, err := op.getSeriesIDsByExpr(expr)
+		seriesIDs, err := op.getSeriesIDsByExpr(expr)
 		if err != nil {
 			op.err = err
-			return tagKey, roaring.New() // create an empty series ids for parent expr
+			return roaring.New(), false // create an empty series ids for parent expr
 		}
-		return tagKey, seriesIDs
+		return seriesIDs, false
 	case *stmt.ParenExpr:
 		return op.findSeriesIDsByExpr(expr.Expr)
 	case *stmt.NotExpr:
-		// get filter series ids
-		tagKey, matchResult := op.findSeriesIDsByExpr(expr.Expr)
-		// get all series ids for tag key
-		all, err := op.indexDB.GetSeriesIDsForTag(tagKey)
-		if err != nil {
-			op.err = err
-			return tagKey, roaring.New() // create an empty series ids for parent expr
-		}
-		// do and not got series ids not in 'a' list
-		all.AndNot(matchResult)
-		return 0, all
+		seriesIDs, negated := op.findSeriesIDsByExpr(expr.Expr)
+		return seriesIDs, !negated
 	case *stmt.BinaryExpr:
-		_, left := op.findSeriesIDsByExpr(expr.Left)
-		_, right := op.findSeriesIDsByExpr(expr.Right)
+		left, leftNegated := op.findSeriesIDsByExpr(expr.Left)
+		right, rightNegated := op.findSeriesIDsByExpr(expr.Right)
 		if expr.Operator == stmt.AND {
-			left.And(right)
-		} else {
+			switch {
+			case !leftNegated && !rightNegated:
+				left.And(right)
+			case !leftNegated && rightNegated:
+				left.AndNot(right)
+			case leftNegated && !rightNegated:
+				right.AndNot(left)
+				return right, false
+			default:
+				// not a and not b => not (a or b)
+				left.Or(right)
+				return left, true
+			}
+			return left, false
+		}
+		switch {
+		case !leftNegated && !rightNegated:
 			left.Or(right)
+		case !leftNegated && rightNegated:
+			// a or not b => not (b and not a)
+			right.AndNot(left)
+			return right, true
+		case leftNegated && !rightNegated:
+			// not a or b => not (a and not b)
+			left.AndNot(right)
+			return left, true
+		default:
+			// not a or not b => not (a and b)
+			left.And(right)
+			return left, true
 		}
-		return 0, left
+		return left, false
 	}
-	return 0, roaring.New() // create an empty series ids for parent expr
+	return roaring.New(), false // create an empty series ids for parent expr
 }
 
-// getTagKeyID returns the tag key id by tag key
-func (op *seriesFiltering) getSeriesIDsByExpr(expr stmt.Expr) (tag.KeyID, *roaring.Bitmap, error) {
+// getSeriesIDsByExpr returns the series ids by tag filter expr,
+// if tag filter result not found in context, it means no tag value matched, returns empty series ids.
+func (op *seriesFiltering) getSeriesIDsByExpr(expr stmt.Expr) (*roaring.Bitmap, error) {
+	startTime := time.Now()
+	seriesIDs := roaring.New()
 	tagValues, ok := op.executeCtx.StorageExecuteCtx.TagFilterResult[expr.Rewrite()]
-	if !ok {
-		return 0, nil, fmt.Errorf("%w, expr: %s", constants.ErrTagValueFilterResultNotFound, expr.Rewrite())
+	if ok {
+		var err error
+		seriesIDs, err = op.indexDB.GetSeriesIDsByTagValueIDs(tagValues.TagKeyID, tagValues.TagValueIDs)
+		if err != nil {
+			return nil, err
+		}
 	}
-	seriesIDs, err := op.indexDB.GetSeriesIDsByTagValueIDs(tagValues.TagKeyID, tagValues.TagValueIDs)
-	if err != nil {
-		return 0, nil, err
+	if op.executeCtx.StorageExecuteCtx.Query.Explain {
+		op.filterStats = append(op.filterStats, &models.SeriesFilterStats{
+			Expr:        expr.Rewrite(),
+			Cost:        time.Since(startTime).Nanoseconds(),
+			NumOfSeries: seriesIDs.GetCardinality(),
+		})
 	}
-	return tagValues.TagKeyID, seriesIDs, nil
+	return seriesIDs, nil
 }
 
 // Identifier returns identifier value of series filtering operator.
@@ -124,5 +173,6 @@ func (op *seriesFiltering) Identifier() string {
 func (op *seriesFiltering) Stats() interface{} {
 	return &models.SeriesStats{
 		NumOfSeries: op.executeCtx.SeriesIDsAfterFiltering.GetCardinality(),
+		Filters:     op.filterStats,
 	}
 }
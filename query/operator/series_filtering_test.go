@@ -27,6 +27,7 @@ import (
 	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/series/tag"
 	stmtpkg "github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb"
@@ -47,13 +48,24 @@ func TestSeriesFiltering_Execute(t *testing.T) {
 				TagKeyID:    tag.KeyID(1),
 				TagValueIDs: roaring.BitmapOf(1, 2, 3),
 			},
+			"key2=value2": {
+				TagKeyID:    tag.KeyID(2),
+				TagValueIDs: roaring.BitmapOf(1),
+			},
 		},
 	}
+	key1 := &stmtpkg.EqualsExpr{Key: "key1", Value: "value1"}
+	key2 := &stmtpkg.EqualsExpr{Key: "key2", Value: "value2"}
+	mockFilter := func() {
+		indexDB.EXPECT().GetSeriesIDsByTagValueIDs(tag.KeyID(1), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
+		indexDB.EXPECT().GetSeriesIDsByTagValueIDs(tag.KeyID(2), gomock.Any()).Return(roaring.BitmapOf(3, 4), nil)
+	}
 	shardCtx := flow.NewShardExecuteContext(storageCtx)
 	cases := []struct {
 		name    string
 		in      stmtpkg.Expr
 		prepare func()
+		expect  *roaring.Bitmap
 		wantErr bool
 	}{
 		{
@@ -62,10 +74,23 @@ func TestSeriesFiltering_Execute(t *testing.T) {
 		{
 			name: "tag values not found from context",
 			in: &stmtpkg.EqualsExpr{
-				Key:   "key2",
-				Value: "value2",
+				Key:   "key3",
+				Value: "value3",
 			},
-			wantErr: true,
+			expect: roaring.New(),
+		},
+		{
+			name: "not expr, tag values not found from context",
+			in: &stmtpkg.NotExpr{
+				Expr: &stmtpkg.EqualsExpr{
+					Key:   "key3",
+					Value: "value3",
+				},
+			},
+			prepare: func() {
+				indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
+			},
+			expect: roaring.BitmapOf(1, 2, 3),
 		},
 		{
 			name: "find series failure",
@@ -109,9 +134,10 @@ func TestSeriesFiltering_Execute(t *testing.T) {
 				},
 			},
 			prepare: func() {
-				indexDB.EXPECT().GetSeriesIDsForTag(gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
+				indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
 				indexDB.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
 			},
+			expect: roaring.BitmapOf(3),
 		},
 		{
 			name: "not expr failure",
@@ -122,7 +148,7 @@ func TestSeriesFiltering_Execute(t *testing.T) {
 				},
 			},
 			prepare: func() {
-				indexDB.EXPECT().GetSeriesIDsForTag(gomock.Any()).Return(nil, fmt.Errorf("err"))
+				indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
 				indexDB.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
 			},
 			wantErr: true,
@@ -180,17 +206,88 @@ func TestSeriesFiltering_Execute(t *testing.T) {
 					Return(roaring.BitmapOf(1, 2), nil).MaxTimes(2)
 			},
 		},
+		{
+			name:    "a and not b",
+			in:      &stmtpkg.BinaryExpr{Left: key1, Operator: stmtpkg.AND, Right: &stmtpkg.NotExpr{Expr: key2}},
+			prepare: mockFilter,
+			expect:  roaring.BitmapOf(1, 2),
+		},
+		{
+			name:    "not a and b",
+			in:      &stmtpkg.BinaryExpr{Left: &stmtpkg.NotExpr{Expr: key1}, Operator: stmtpkg.AND, Right: key2},
+			prepare: mockFilter,
+			expect:  roaring.BitmapOf(4),
+		},
+		{
+			name: "not a and not b",
+			in: &stmtpkg.BinaryExpr{
+				Left: &stmtpkg.NotExpr{Expr: key1}, Operator: stmtpkg.AND, Right: &stmtpkg.ParenExpr{Expr: &stmtpkg.NotExpr{Expr: key2}},
+			},
+			prepare: func() {
+				mockFilter()
+				indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3, 4, 5), nil)
+			},
+			expect: roaring.BitmapOf(5),
+		},
+		{
+			name: "a or not b",
+			in:   &stmtpkg.BinaryExpr{Left: key1, Operator: stmtpkg.OR, Right: &stmtpkg.NotExpr{Expr: key2}},
+			prepare: func() {
+				mockFilter()
+				indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3, 4, 5), nil)
+			},
+			expect: roaring.BitmapOf(1, 2, 3, 5),
+		},
+		{
+			name: "not a or b",
+			in:   &stmtpkg.BinaryExpr{Left: &stmtpkg.NotExpr{Expr: key1}, Operator: stmtpkg.OR, Right: key2},
+			prepare: func() {
+				mockFilter()
+				indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3, 4, 5), nil)
+			},
+			expect: roaring.BitmapOf(3, 4, 5),
+		},
+		{
+			name: "not a or not b",
+			in:   &stmtpkg.BinaryExpr{Left: &stmtpkg.NotExpr{Expr: key1}, Operator: stmtpkg.OR, Right: &stmtpkg.NotExpr{Expr: key2}},
+			prepare: func() {
+				mockFilter()
+				indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3, 4, 5), nil)
+			},
+			expect: roaring.BitmapOf(1, 2, 4, 5),
+		},
+		{
+			name: "not (not a)",
+			in:   &stmtpkg.NotExpr{Expr: &stmtpkg.ParenExpr{Expr: &stmtpkg.NotExpr{Expr: key1}}},
+			prepare: func() {
+				indexDB.EXPECT().GetSeriesIDsByTagValueIDs(tag.KeyID(1), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
+			},
+			expect: roaring.BitmapOf(1, 2, 3),
+		},
 		{
 			name: "unknown condition expr",
 			in: &stmtpkg.FieldExpr{
 				Name: "f",
 			},
 		},
+		{
+			name: "too many series",
+			in: &stmtpkg.EqualsExpr{
+				Key:   "key1",
+				Value: "value1",
+			},
+			prepare: func() {
+				storageCtx.MaxSeries = 1
+				indexDB.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range cases {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
+			shardCtx.SeriesIDsAfterFiltering = roaring.New()
 			op := NewSeriesFiltering(shardCtx, shard)
 			storageCtx.Query.Condition = tt.in
 			if tt.prepare != nil {
@@ -200,6 +297,9 @@ func TestSeriesFiltering_Execute(t *testing.T) {
 			if (err != nil) != tt.wantErr {
 				t.Fatal(tt.name)
 			}
+			if tt.expect != nil {
+				assert.Equal(t, tt.expect.ToArray(), shardCtx.SeriesIDsAfterFiltering.ToArray())
+			}
 		})
 	}
 }
@@ -218,4 +318,103 @@ func TestSeriesFiltering_Stats(t *testing.T) {
 	assert.Equal(t, "Series Filtering", op.Identifier())
 	op1 := op.(TrackableOperator)
 	assert.NotNil(t, op1.Stats())
+
+	// explain query collects stats of each tag filter clause
+	shardCtx = flow.NewShardExecuteContext(&flow.StorageExecuteContext{
+		Query: &stmtpkg.Query{
+			Explain: true,
+			Condition: &stmtpkg.NotExpr{
+				Expr: &stmtpkg.EqualsExpr{Key: "key1", Value: "value1"},
+			},
+		},
+		TagFilterResult: map[string]*flow.TagFilterResult{
+			"key1=value1": {TagKeyID: tag.KeyID(1), TagValueIDs: roaring.BitmapOf(1)},
+		},
+	})
+	indexDB.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+	indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).Return(roaring.BitmapOf(1, 2, 3), nil)
+	op = NewSeriesFiltering(shardCtx, shard)
+	assert.NoError(t, op.Execute())
+	stats := op.(TrackableOperator).Stats().(*models.SeriesStats)
+	assert.Equal(t, uint64(1), stats.NumOfSeries)
+	assert.Len(t, stats.Filters, 1)
+	assert.Equal(t, "key1=value1", stats.Filters[0].Expr)
+	assert.Equal(t, uint64(2), stats.Filters[0].NumOfSeries)
+}
+
+// newBenchmarkSeriesFiltering prepares a metric with 1M series, region='cn' matches 500K series,
+// the 10 negative host values match 10K series(1K series per value).
+func newBenchmarkSeriesFiltering(b *testing.B, condition stmtpkg.Expr) (*flow.ShardExecuteContext, tsdb.Shard) {
+	ctrl := gomock.NewController(b)
+	b.Cleanup(ctrl.Finish)
+
+	const numOfSeries = 1000000
+	all := roaring.New()
+	all.AddRange(0, numOfSeries)
+	region := roaring.New()
+	region.AddRange(0, numOfSeries/2)
+	hosts := roaring.New()
+	hosts.AddRange(0, 10*1000)
+	shard := tsdb.NewMockShard(ctrl)
+	indexDB := indexdb.NewMockIndexDatabase(ctrl)
+	shard.EXPECT().IndexDatabase().Return(indexDB).AnyTimes()
+	indexDB.EXPECT().GetSeriesIDsForMetric(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_, _ string) (*roaring.Bitmap, error) {
+			return all.Clone(), nil
+		}).AnyTimes()
+	indexDB.EXPECT().GetSeriesIDsByTagValueIDs(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(tagKeyID tag.KeyID, _ *roaring.Bitmap) (*roaring.Bitmap, error) {
+			if tagKeyID == 1 {
+				return region.Clone(), nil
+			}
+			return hosts.Clone(), nil
+		}).AnyTimes()
+	storageCtx := &flow.StorageExecuteContext{
+		Query: &stmtpkg.Query{Condition: condition},
+		TagFilterResult: map[string]*flow.TagFilterResult{
+			"region=cn": {TagKeyID: tag.KeyID(1), TagValueIDs: roaring.BitmapOf(1)},
+			(&stmtpkg.InExpr{Key: "host", Values: benchmarkHostValues()}).Rewrite(): {
+				TagKeyID: tag.KeyID(2), TagValueIDs: roaring.BitmapOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10),
+			},
+		},
+	}
+	return flow.NewShardExecuteContext(storageCtx), shard
+}
+
+func benchmarkHostValues() []string {
+	hostValues := make([]string, 10)
+	for i := range hostValues {
+		hostValues[i] = fmt.Sprintf("host-%d", i)
+	}
+	return hostValues
+}
+
+func BenchmarkSeriesFiltering_Not(b *testing.B) {
+	// host not in (10 values)
+	condition := &stmtpkg.NotExpr{Expr: &stmtpkg.InExpr{Key: "host", Values: benchmarkHostValues()}}
+	shardCtx, shard := newBenchmarkSeriesFiltering(b, condition)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		shardCtx.SeriesIDsAfterFiltering = roaring.New()
+		if err := NewSeriesFiltering(shardCtx, shard).Execute(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkSeriesFiltering_AndNot(b *testing.B) {
+	// region='cn' and host not in (10 values)
+	condition := &stmtpkg.BinaryExpr{
+		Left:     &stmtpkg.EqualsExpr{Key: "region", Value: "cn"},
+		Operator: stmtpkg.AND,
+		Right:    &stmtpkg.NotExpr{Expr: &stmtpkg.InExpr{Key: "host", Values: benchmarkHostValues()}},
+	}
+	shardCtx, shard := newBenchmarkSeriesFiltering(b, condition)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		shardCtx.SeriesIDsAfterFiltering = roaring.New()
+		if err := NewSeriesFiltering(shardCtx, shard).Execute(); err != nil {
+			b.Fatal(err)
+		}
+	}
 }
@@ -17,7 +17,11 @@
 
 package operator
 
-import "github.com/lindb/lindb/query/context"
+import (
+	"sort"
+
+	"github.com/lindb/lindb/query/context"
+)
 
 // tagKeySuggest represents tag key suggest operator.
 type tagKeySuggest struct {
@@ -31,7 +35,7 @@ func NewTagKeySuggest(ctx *context.LeafMetadataContext) Operator {
 	}
 }
 
-// Execute returns tag key list by given namespace/metric name.
+// Execute returns tag key list by given namespace/metric name in order, starts after the resume position of paginated query.
 func (op *tagKeySuggest) Execute() error {
 	req := op.ctx.Request
 	tagKeys, err := op.ctx.Database.Metadata().MetadataDatabase().GetAllTagKeys(req.Namespace, req.MetricName)
@@ -40,7 +44,14 @@ func (op *tagKeySuggest) Execute() error {
 	}
 	var result []string
 	for _, tagKey := range tagKeys {
-		result = append(result, tagKey.Key)
+		if req.After == "" || tagKey.Key > req.After {
+			result = append(result, tagKey.Key)
+		}
+	}
+	sort.Strings(result)
+	if len(result) > op.ctx.Limit {
+		result = result[:op.ctx.Limit]
+		op.ctx.Truncated = true
 	}
 	op.ctx.ResultSet = result
 	return nil
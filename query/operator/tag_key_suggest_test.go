@@ -82,6 +82,33 @@ func TestTagKeySuggest_Execute(t *testing.T) {
 	}
 }
 
+func TestTagKeySuggest_Execute_paginated(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := tsdb.NewMockDatabase(ctrl)
+	meta := metadb.NewMockMetadata(ctrl)
+	metaDB := metadb.NewMockMetadataDatabase(ctrl)
+	meta.EXPECT().MetadataDatabase().Return(metaDB).AnyTimes()
+	db.EXPECT().Metadata().Return(meta).AnyTimes()
+	metaDB.EXPECT().GetAllTagKeys("ns", "cpu").
+		Return(tag.Metas{{Key: "zone"}, {Key: "host"}, {Key: "app"}, {Key: "ip"}}, nil).AnyTimes()
+
+	ctx := context.NewLeafMetadataContext(&stmtpkg.MetricMetadata{
+		Type: stmtpkg.TagKey, Namespace: "ns", MetricName: "cpu", Limit: 2,
+	}, db, nil)
+	assert.NoError(t, NewTagKeySuggest(ctx).Execute())
+	assert.Equal(t, []string{"app", "host"}, ctx.ResultSet)
+	assert.True(t, ctx.Truncated)
+
+	ctx = context.NewLeafMetadataContext(&stmtpkg.MetricMetadata{
+		Type: stmtpkg.TagKey, Namespace: "ns", MetricName: "cpu", After: "host", Limit: 2,
+	}, db, nil)
+	assert.NoError(t, NewTagKeySuggest(ctx).Execute())
+	assert.Equal(t, []string{"ip", "zone"}, ctx.ResultSet)
+	assert.False(t, ctx.Truncated)
+}
+
 func TestTagKeySuggest_Identifier(t *testing.T) {
 	assert.Equal(t, "Tag Key Suggest", NewTagKeySuggest(nil).Identifier())
 }
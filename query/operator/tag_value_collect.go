@@ -76,7 +76,10 @@ func (op *tagValueCollect) execute() error {
 			return err
 		}
 		for _, tagValue := range tagValues {
-			op.executeCtx.AddValue(tagValue)
+			if !op.executeCtx.AddValue(tagValue) {
+				// result set is full, stop scanning
+				return nil
+			}
 		}
 	}
 	return nil
@@ -17,9 +17,13 @@
 
 package operator
 
-import "github.com/lindb/lindb/query/context"
+import (
+	"github.com/lindb/lindb/query/context"
+	"github.com/lindb/lindb/sql/stmt"
+)
 
-// tagValueSuggest represent tag value suggest without condition operator.
+// tagValueSuggest represent tag value suggest operator, which finds tag values from tag metadata index directly,
+// if it has condition, the condition must be a tag filter of the suggested tag key.
 type tagValueSuggest struct {
 	ctx *context.LeafMetadataContext
 }
@@ -31,11 +35,20 @@ func NewTagValueSuggest(ctx *context.LeafMetadataContext) Operator {
 	}
 }
 
-// Execute returns tag value list by given tag key/prefix.
+// Execute returns tag value list by given tag key/prefix/tag filter.
 func (op *tagValueSuggest) Execute() error {
 	req := op.ctx.Request
-	limit := op.ctx.Limit
-	op.ctx.ResultSet = op.ctx.Database.Metadata().TagMetadata().SuggestTagValues(op.ctx.TagKeyID, req.Prefix, limit)
+	var tagFilter stmt.TagFilter
+	if req.Condition != nil {
+		tagFilter, _ = req.Condition.(stmt.TagFilter)
+	}
+	tagValues, truncated, err := op.ctx.Database.Metadata().TagMetadata().
+		FindTagValues(op.ctx.TagKeyID, req.Prefix, tagFilter, op.ctx.Limit)
+	if err != nil {
+		return err
+	}
+	op.ctx.ResultSet = tagValues
+	op.ctx.Truncated = truncated
 	return nil
 }
 
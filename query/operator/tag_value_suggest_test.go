@@ -18,6 +18,7 @@
 package operator
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -44,8 +45,15 @@ func TestTagValueSuggest_Execute(t *testing.T) {
 		Request:  &stmtpkg.MetricMetadata{},
 	}
 	op := NewTagValueSuggest(ctx)
-	tagMeta.EXPECT().SuggestTagValues(gomock.Any(), gomock.Any(), gomock.Any()).Return([]string{"name"})
+	tagMeta.EXPECT().FindTagValues(gomock.Any(), gomock.Any(), nil, gomock.Any()).Return([]string{"name"}, true, nil)
 	assert.NoError(t, op.Execute())
+	assert.Equal(t, []string{"name"}, ctx.ResultSet)
+	assert.True(t, ctx.Truncated)
+
+	ctx.Request = &stmtpkg.MetricMetadata{Condition: &stmtpkg.RegexExpr{Key: "host", Regexp: "web-1.*"}}
+	tagMeta.EXPECT().FindTagValues(gomock.Any(), gomock.Any(), ctx.Request.Condition, gomock.Any()).
+		Return(nil, false, fmt.Errorf("err"))
+	assert.Error(t, op.Execute())
 }
 
 func TestTagValueSuggest_Identifier(t *testing.T) {
@@ -18,6 +18,9 @@
 package operator
 
 import (
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/lindb/lindb/pkg/tracing"
 	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	"github.com/lindb/lindb/query/context"
 )
@@ -39,7 +42,11 @@ func NewTaskSender(taskCtx context.TaskContext, target string, req *protoCommonV
 }
 
 // Execute returns send task request by given task context.
-func (op *taskSender) Execute() error {
+func (op *taskSender) Execute() (err error) {
+	_, span := tracing.StartSpan(op.taskCtx.Context(), "query.fanout", attribute.String("target", op.target))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
 	return op.taskCtx.SendRequest(op.target, op.req)
 }
 
@@ -0,0 +1,375 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/sql"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// for testing
+var (
+	ruleCheckInterval = time.Second
+	ruleSearchFn      = MetricDataSearch
+	ruleNowFn         = timeutil.Now
+)
+
+// maxCatchUpWindows is the max windows evaluated for catching up one rule,
+// older windows are skipped(such as master unavailable for long time).
+const maxCatchUpWindows = 10
+
+// RowsWriter represents the writer which writes rows into database through ingestion path.
+type RowsWriter interface {
+	// WriteRows writes rows into database.
+	WriteRows(ctx context.Context, database string, rows *metric.BrokerBatchRows) error
+}
+
+// RecordingRuleEvaluator represents the evaluator of recording rules,
+// which evaluates rules periodically only when current node is master.
+type RecordingRuleEvaluator interface {
+	// Start starts evaluating recording rules in background.
+	Start()
+	// Stop stops evaluating recording rules.
+	Stop()
+}
+
+// ruleEvaluation represents the evaluation context of recording rule.
+type ruleEvaluation struct {
+	running       bool  // prevents overlapping evaluation of same rule
+	lastEvaluated int64 // end time of last evaluated window, 0 if not loaded from repo
+	statistics    *metrics.RecordingRuleStatistics
+}
+
+// recordingRuleEvaluator implements RecordingRuleEvaluator interface.
+type recordingRuleEvaluator struct {
+	ctx      context.Context
+	cancel   context.CancelFunc
+	repo     state.Repository
+	isMaster func() bool
+	mgr      *SearchMgr
+	writer   RowsWriter
+
+	rules map[string]*ruleEvaluation // database/rule => evaluation
+	wait  sync.WaitGroup
+	lock  sync.Mutex
+
+	logger *logger.Logger
+}
+
+// NewRecordingRuleEvaluator creates a RecordingRuleEvaluator instance.
+func NewRecordingRuleEvaluator(
+	ctx context.Context,
+	repo state.Repository,
+	isMaster func() bool,
+	mgr *SearchMgr,
+	writer RowsWriter,
+) RecordingRuleEvaluator {
+	c, cancel := context.WithCancel(ctx)
+	return &recordingRuleEvaluator{
+		ctx:      c,
+		cancel:   cancel,
+		repo:     repo,
+		isMaster: isMaster,
+		mgr:      mgr,
+		writer:   writer,
+		rules:    make(map[string]*ruleEvaluation),
+		logger:   logger.GetLogger("Query", "RecordingRule"),
+	}
+}
+
+// Start starts evaluating recording rules in background.
+func (e *recordingRuleEvaluator) Start() {
+	go func() {
+		ticker := time.NewTicker(ruleCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.evaluate()
+			case <-e.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops evaluating recording rules, waits running evaluations completed.
+func (e *recordingRuleEvaluator) Stop() {
+	e.cancel()
+	e.wait.Wait()
+}
+
+// evaluate schedules the evaluation of all rules which are due.
+func (e *recordingRuleEvaluator) evaluate() {
+	if !e.isMaster() {
+		// evaluation state may be changed by other master, reload it after becoming master again
+		e.reset()
+		return
+	}
+	kvs, err := e.repo.List(e.ctx, constants.RecordingRulePath)
+	if err != nil {
+		e.logger.Error("list recording rules failure", logger.Error(err))
+		return
+	}
+	now := ruleNowFn()
+	for _, kv := range kvs {
+		rules := &models.RecordingRules{}
+		if err := encoding.JSONUnmarshal(kv.Value, rules); err != nil {
+			e.logger.Warn("unmarshal recording rules failure, ignore it",
+				logger.String("key", kv.Key), logger.Error(err))
+			continue
+		}
+		for _, rule := range rules.Rules {
+			interval := rule.GetInterval().Milliseconds()
+			if interval <= 0 {
+				continue
+			}
+			e.schedule(rules.Database, rule, now-now%interval, now)
+		}
+	}
+}
+
+// schedule evaluates the windows of rule before end time in background,
+// skips it if previous evaluation of the rule still running.
+func (e *recordingRuleEvaluator) schedule(database string, rule *models.RecordingRule, end, now int64) {
+	key := database + "/" + rule.Name
+	e.lock.Lock()
+	evaluation, ok := e.rules[key]
+	if !ok {
+		evaluation = &ruleEvaluation{statistics: metrics.NewRecordingRuleStatistics(database, rule.Name)}
+		e.rules[key] = evaluation
+	}
+	if evaluation.lastEvaluated > 0 {
+		evaluation.statistics.Lag.Update(float64(now - evaluation.lastEvaluated))
+		if evaluation.lastEvaluated >= end {
+			// not due
+			e.lock.Unlock()
+			return
+		}
+	}
+	if evaluation.running {
+		evaluation.statistics.OverlapSkips.Incr()
+		e.lock.Unlock()
+		return
+	}
+	evaluation.running = true
+	lastEvaluated := evaluation.lastEvaluated
+	e.lock.Unlock()
+
+	e.wait.Add(1)
+	go func() {
+		defer func() {
+			e.lock.Lock()
+			evaluation.running = false
+			e.lock.Unlock()
+			e.wait.Done()
+		}()
+		e.evaluateRule(database, rule, evaluation, lastEvaluated, end)
+	}()
+}
+
+// evaluateRule evaluates the windows of rule from last evaluated time to end time,
+// persists the evaluation state after each window written back.
+func (e *recordingRuleEvaluator) evaluateRule(database string, rule *models.RecordingRule,
+	evaluation *ruleEvaluation, lastEvaluated, end int64,
+) {
+	statePath := constants.GetRecordingRuleStatePath(database, rule.Name)
+	if lastEvaluated <= 0 {
+		ruleState, err := e.loadState(statePath)
+		if err != nil {
+			evaluation.statistics.EvaluateFailures.Incr()
+			e.logger.Error("load recording rule state failure",
+				logger.String("db", database), logger.String("rule", rule.Name), logger.Error(err))
+			return
+		}
+		lastEvaluated = ruleState.LastEvaluated
+	}
+	interval := rule.GetInterval().Milliseconds()
+	start := end - interval // evaluate latest window for new rule
+	if lastEvaluated > 0 {
+		start = lastEvaluated
+		if windows := (end - lastEvaluated) / interval; windows > maxCatchUpWindows {
+			evaluation.statistics.SkippedWindows.Add(float64(windows - maxCatchUpWindows))
+			start = end - maxCatchUpWindows*interval
+		}
+	}
+	for windowStart := start; windowStart+interval <= end; windowStart += interval {
+		if e.ctx.Err() != nil || !e.isMaster() {
+			return
+		}
+		windowEnd := windowStart + interval
+		if err := e.evaluateWindow(database, rule, evaluation.statistics, windowStart, windowEnd); err != nil {
+			e.logger.Error("evaluate recording rule failure",
+				logger.String("db", database), logger.String("rule", rule.Name),
+				logger.Int64("start", windowStart), logger.Error(err))
+			return
+		}
+		if err := e.saveState(statePath, &models.RecordingRuleState{LastEvaluated: windowEnd}); err != nil {
+			evaluation.statistics.WriteFailures.Incr()
+			e.logger.Error("save recording rule state failure",
+				logger.String("db", database), logger.String("rule", rule.Name), logger.Error(err))
+			return
+		}
+		evaluation.statistics.Evaluations.Incr()
+		e.lock.Lock()
+		evaluation.lastEvaluated = windowEnd
+		e.lock.Unlock()
+	}
+	if lastEvaluated >= end {
+		// already evaluated by other master
+		e.lock.Lock()
+		evaluation.lastEvaluated = lastEvaluated
+		e.lock.Unlock()
+	}
+}
+
+// evaluateWindow executes the query of rule in window[start, end), then writes the result back as destination metric.
+func (e *recordingRuleEvaluator) evaluateWindow(database string, rule *models.RecordingRule,
+	statistics *metrics.RecordingRuleStatistics, start, end int64,
+) error {
+	startTime := time.Now()
+	defer statistics.Duration.UpdateSince(startTime)
+
+	resultSet, err := searchRuleWindow(e.ctx, e.mgr, database, rule.SQL, start, end)
+	if err != nil {
+		statistics.EvaluateFailures.Incr()
+		return err
+	}
+	rows, err := toRecordingRows(rule, resultSet)
+	if err != nil {
+		statistics.EvaluateFailures.Incr()
+		return err
+	}
+	if rows.Len() == 0 {
+		return nil
+	}
+	if err := e.writer.WriteRows(e.ctx, database, rows); err != nil {
+		statistics.WriteFailures.Incr()
+		return err
+	}
+	return nil
+}
+
+// searchRuleWindow executes the query of rule in window[start, end), the window is the down sampling interval.
+func searchRuleWindow(ctx context.Context, mgr *SearchMgr, database, ruleSQL string, start, end int64) (*models.ResultSet, error) {
+	stmt, err := sql.Parse(ruleSQL)
+	if err != nil {
+		return nil, err
+	}
+	queryStmt, ok := stmt.(*stmtpkg.Query)
+	if !ok {
+		return nil, fmt.Errorf("rule only support data query, but got: %s", ruleSQL)
+	}
+	queryStmt.TimeRange = timeutil.TimeRange{Start: start, End: end - 1}
+	queryStmt.Interval = timeutil.Interval(end - start)
+	rs, err := ruleSearchFn(ctx, &models.ExecuteParam{Database: database, SQL: ruleSQL}, queryStmt, mgr)
+	if err != nil {
+		return nil, err
+	}
+	resultSet, ok := rs.(*models.ResultSet)
+	if !ok || resultSet == nil {
+		return &models.ResultSet{}, nil
+	}
+	return resultSet, nil
+}
+
+// loadState loads the evaluation state of rule, returns empty state if not exist.
+func (e *recordingRuleEvaluator) loadState(statePath string) (*models.RecordingRuleState, error) {
+	ruleState := &models.RecordingRuleState{}
+	data, err := e.repo.Get(e.ctx, statePath)
+	if err != nil {
+		if errors.Is(err, state.ErrNotExist) {
+			return ruleState, nil
+		}
+		return nil, err
+	}
+	if err := encoding.JSONUnmarshal(data, ruleState); err != nil {
+		return nil, err
+	}
+	return ruleState, nil
+}
+
+// saveState persists the evaluation state of rule.
+func (e *recordingRuleEvaluator) saveState(statePath string, ruleState *models.RecordingRuleState) error {
+	return e.repo.Put(e.ctx, statePath, encoding.JSONMarshal(ruleState))
+}
+
+// reset cleans the evaluation state cached in memory.
+func (e *recordingRuleEvaluator) reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	for _, evaluation := range e.rules {
+		evaluation.lastEvaluated = 0
+	}
+}
+
+// toRecordingRows converts the result set into rows of destination metric.
+func toRecordingRows(rule *models.RecordingRule, rs *models.ResultSet) (*metric.BrokerBatchRows, error) {
+	rows := metric.NewBrokerBatchRows()
+	converter, releaseFunc := metric.NewBrokerRowProtoConverter(nil, nil)
+	defer releaseFunc(converter)
+
+	for _, series := range rs.Series {
+		if series == nil {
+			continue
+		}
+		tags := make([]*protoMetricsV1.KeyValue, 0, len(series.Tags))
+		for k, v := range series.Tags {
+			tags = append(tags, &protoMetricsV1.KeyValue{Key: k, Value: v})
+		}
+		sort.Slice(tags, func(i, j int) bool { return tags[i].Key < tags[j].Key })
+		for fieldName, points := range series.Fields {
+			for timestamp, value := range points {
+				m := &protoMetricsV1.Metric{
+					Namespace: rule.GetNamespace(),
+					Name:      rule.Metric,
+					Timestamp: timestamp,
+					Tags:      tags,
+					SimpleFields: []*protoMetricsV1.SimpleField{{
+						Name:  fieldName,
+						Type:  protoMetricsV1.SimpleFieldType_LAST,
+						Value: value,
+					}},
+				}
+				if err := rows.TryAppend(func(row *metric.BrokerRow) error {
+					return converter.ConvertTo(m, row)
+				}); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return rows, nil
+}
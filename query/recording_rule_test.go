@@ -0,0 +1,273 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/state"
+	"github.com/lindb/lindb/series/metric"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+type mockRowsWriter struct {
+	lock sync.Mutex
+	rows int
+	err  error
+}
+
+func (w *mockRowsWriter) WriteRows(_ context.Context, _ string, rows *metric.BrokerBatchRows) error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.rows += rows.Len()
+	return w.err
+}
+
+func TestRecordingRuleEvaluator_Evaluate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ruleSearchFn = MetricDataSearch
+		ruleNowFn = func() int64 { return time.Now().UnixMilli() }
+		ctrl.Finish()
+	}()
+	rules := encoding.JSONMarshal(&models.RecordingRules{
+		Database: "db",
+		Rules: []*models.RecordingRule{
+			{Name: "rule", SQL: "select f from cpu group by host", Metric: "cpu_by_host", Interval: "1m"},
+		},
+	})
+	ruleNowFn = func() int64 { return 30*time.Minute.Milliseconds() + 10 }
+	var windows []int64
+	ruleSearchFn = func(_ context.Context, _ *models.ExecuteParam, statement *stmtpkg.Query,
+		_ *SearchMgr) (any, error) {
+		windows = append(windows, statement.TimeRange.Start)
+		series := models.NewSeries(map[string]string{"host": "1.1.1.1"}, "1.1.1.1")
+		series.Fields["f"] = map[int64]float64{statement.TimeRange.Start: 1}
+		return &models.ResultSet{Series: []*models.Series{series}}, nil
+	}
+	stateOf := func(lastEvaluated int64) []byte {
+		return encoding.JSONMarshal(&models.RecordingRuleState{LastEvaluated: lastEvaluated})
+	}
+	statePath := constants.GetRecordingRuleStatePath("db", "rule")
+
+	cases := []struct {
+		name    string
+		master  bool
+		prepare func(repo *state.MockRepository, writer *mockRowsWriter)
+		windows []int64
+		rows    int
+	}{
+		{
+			name:   "not master",
+			master: false,
+		},
+		{
+			name:   "list rules failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), constants.RecordingRulePath).Return(nil, fmt.Errorf("err"))
+			},
+		},
+		{
+			name:   "unmarshal rules failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: []byte("xx")}}, nil)
+			},
+		},
+		{
+			name:   "load state failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, fmt.Errorf("err"))
+			},
+		},
+		{
+			name:   "evaluate latest window of new rule",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, state.ErrNotExist)
+				repo.EXPECT().Put(gomock.Any(), statePath, stateOf(30*time.Minute.Milliseconds())).Return(nil)
+			},
+			windows: []int64{29 * time.Minute.Milliseconds()},
+			rows:    1,
+		},
+		{
+			name:   "already evaluated by other master",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(stateOf(30*time.Minute.Milliseconds()), nil)
+			},
+		},
+		{
+			name:   "catch up windows after fail-over",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(stateOf(28*time.Minute.Milliseconds()), nil)
+				repo.EXPECT().Put(gomock.Any(), statePath, gomock.Any()).Return(nil).Times(2)
+			},
+			windows: []int64{28 * time.Minute.Milliseconds(), 29 * time.Minute.Milliseconds()},
+			rows:    2,
+		},
+		{
+			name:   "skip windows exceeding max catch up windows",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(stateOf(time.Minute.Milliseconds()), nil)
+				repo.EXPECT().Put(gomock.Any(), statePath, gomock.Any()).Return(nil).Times(maxCatchUpWindows)
+			},
+			windows: func() (windows []int64) {
+				for i := 20; i < 30; i++ {
+					windows = append(windows, int64(i)*time.Minute.Milliseconds())
+				}
+				return
+			}(),
+			rows: maxCatchUpWindows,
+		},
+		{
+			name:   "write rows failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, writer *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(nil, state.ErrNotExist)
+				writer.err = fmt.Errorf("err")
+			},
+			windows: []int64{29 * time.Minute.Milliseconds()},
+			rows:    1,
+		},
+		{
+			name:   "save state failure",
+			master: true,
+			prepare: func(repo *state.MockRepository, _ *mockRowsWriter) {
+				repo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]state.KeyValue{{Key: "db", Value: rules}}, nil)
+				repo.EXPECT().Get(gomock.Any(), statePath).Return(stateOf(28*time.Minute.Milliseconds()), nil)
+				repo.EXPECT().Put(gomock.Any(), statePath, gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			windows: []int64{28 * time.Minute.Milliseconds()},
+			rows:    1,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(_ *testing.T) {
+			windows = nil
+			repo := state.NewMockRepository(ctrl)
+			writer := &mockRowsWriter{}
+			if tt.prepare != nil {
+				tt.prepare(repo, writer)
+			}
+			e := NewRecordingRuleEvaluator(context.TODO(), repo, func() bool { return tt.master }, &SearchMgr{}, writer)
+			evaluator := e.(*recordingRuleEvaluator)
+			evaluator.evaluate()
+			evaluator.wait.Wait()
+			e.Stop()
+			assert.Equal(t, tt.windows, windows)
+			assert.Equal(t, tt.rows, writer.rows)
+		})
+	}
+}
+
+func TestRecordingRuleEvaluator_Schedule(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e := NewRecordingRuleEvaluator(context.TODO(), state.NewMockRepository(ctrl), func() bool { return true },
+		&SearchMgr{}, &mockRowsWriter{}).(*recordingRuleEvaluator)
+	rule := &models.RecordingRule{Name: "rule", SQL: "select f from cpu", Metric: "m", Interval: "1m"}
+	minute := time.Minute.Milliseconds()
+	evaluation := &ruleEvaluation{lastEvaluated: 2 * minute, statistics: metrics.NewRecordingRuleStatistics("db", "rule")}
+	e.rules["db/rule"] = evaluation
+
+	// not due
+	e.schedule("db", rule, 2*minute, 2*minute+10)
+	assert.Equal(t, float64(10), evaluation.statistics.Lag.Get())
+	// previous evaluation still running
+	evaluation.lastEvaluated = minute
+	evaluation.running = true
+	overlaps := evaluation.statistics.OverlapSkips.Get()
+	e.schedule("db", rule, 2*minute, 2*minute)
+	assert.Equal(t, overlaps+1, evaluation.statistics.OverlapSkips.Get())
+	// reset after losing master
+	e.reset()
+	assert.Zero(t, evaluation.lastEvaluated)
+	e.Stop()
+}
+
+func TestRecordingRuleEvaluator_EvaluateWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ruleSearchFn = MetricDataSearch
+		ctrl.Finish()
+	}()
+	e := NewRecordingRuleEvaluator(context.TODO(), state.NewMockRepository(ctrl), func() bool { return true },
+		&SearchMgr{}, &mockRowsWriter{}).(*recordingRuleEvaluator)
+	statistics := metrics.NewRecordingRuleStatistics("db", "window")
+	evaluate := func(sql string) error {
+		return e.evaluateWindow("db", &models.RecordingRule{Name: "window", SQL: sql, Metric: "m", Interval: "1m"},
+			statistics, 0, time.Minute.Milliseconds())
+	}
+	assert.Error(t, evaluate("select"))
+	assert.Error(t, evaluate("show databases"))
+	ruleSearchFn = func(_ context.Context, _ *models.ExecuteParam, _ *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, evaluate("select f from cpu"))
+	ruleSearchFn = func(_ context.Context, _ *models.ExecuteParam, _ *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		return &models.ResultSet{}, nil
+	}
+	assert.NoError(t, evaluate("select f from cpu"))
+}
+
+func TestRecordingRuleEvaluator_StartStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ruleCheckInterval = time.Second
+		ctrl.Finish()
+	}()
+	ruleCheckInterval = time.Millisecond
+	e := NewRecordingRuleEvaluator(context.TODO(), state.NewMockRepository(ctrl), func() bool { return false },
+		&SearchMgr{}, &mockRowsWriter{})
+	e.Start()
+	time.Sleep(10 * time.Millisecond)
+	e.Stop()
+}
+
+func TestToRecordingRows(t *testing.T) {
+	series := models.NewSeries(map[string]string{"host": "1.1.1.1", "app": "lindb"}, "")
+	series.Fields["f"] = map[int64]float64{10: 1, 20: 2}
+	rows, err := toRecordingRows(&models.RecordingRule{Metric: "m"},
+		&models.ResultSet{Series: []*models.Series{nil, series}})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, rows.Len())
+}
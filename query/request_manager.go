@@ -18,11 +18,13 @@
 package query
 
 import (
+	"sort"
 	"sync"
 
 	"github.com/google/uuid"
 
 	"github.com/lindb/lindb/models"
+	queryctx "github.com/lindb/lindb/query/context"
 )
 
 var (
@@ -36,8 +38,27 @@ type RequestManager interface {
 	NewRequest(req *models.Request) string
 	// CompleteRequest completes a request by given request id.
 	CompleteRequest(requestID string)
-	// GetAliveRequests returns all alive request.
+	// GetAliveRequests returns all alive request(with progress and resource usage), the oldest first.
 	GetAliveRequests() []*models.Request
+	// AttachTask attaches the running task of request, so that the request can be tracked and killed.
+	AttachTask(requestID string, task queryctx.TaskContext)
+	// KillRequest kills the running request by given request id, returns false if request not found.
+	KillRequest(requestID string) bool
+}
+
+// resourceUsage represents the task which reports the resource usage of request.
+type resourceUsage interface {
+	// ScanBytes returns the bytes of data returned by leaf nodes.
+	ScanBytes() int64
+	// MemoryUsage returns the estimated memory of grouped series buffered.
+	MemoryUsage() int64
+}
+
+// aliveRequest represents the alive request with its running task.
+type aliveRequest struct {
+	req    *models.Request
+	task   queryctx.TaskContext
+	killed bool // killed before task attached, task is killed when attaching
 }
 
 // GetRequestManager returns a singleton RequestManager instance.
@@ -53,7 +74,7 @@ func GetRequestManager() RequestManager {
 
 // requestManager implements RequestManager interface.
 type requestManager struct {
-	requests map[string]*models.Request
+	requests map[string]*aliveRequest
 
 	mutex sync.RWMutex
 }
@@ -61,7 +82,7 @@ type requestManager struct {
 // newRequestManager creates a RequestManager instance.
 func newRequestManager() RequestManager {
 	return &requestManager{
-		requests: make(map[string]*models.Request),
+		requests: make(map[string]*aliveRequest),
 	}
 }
 
@@ -77,7 +98,7 @@ func (r *requestManager) NewRequest(req *models.Request) string {
 	defer r.mutex.Unlock()
 
 	// TODO: check if dup?
-	r.requests[req.RequestID] = req
+	r.requests[req.RequestID] = &aliveRequest{req: req}
 	return req.RequestID
 }
 
@@ -89,13 +110,60 @@ func (r *requestManager) CompleteRequest(requestID string) {
 	delete(r.requests, requestID)
 }
 
-// GetAliveRequests returns all alive request.
+// GetAliveRequests returns all alive request(with progress and resource usage), the oldest first.
 func (r *requestManager) GetAliveRequests() (rs []*models.Request) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	for _, v := range r.requests {
-		rs = append(rs, v)
+		req := *v.req
+		if v.task != nil {
+			req.Stage = v.task.Stage()
+			if usage, ok := v.task.(resourceUsage); ok {
+				req.ScanBytes = usage.ScanBytes()
+				req.MemoryBytes = usage.MemoryUsage()
+			}
+		}
+		rs = append(rs, &req)
 	}
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Start < rs[j].Start
+	})
 	return
 }
+
+// AttachTask attaches the running task of request, so that the request can be tracked and killed.
+func (r *requestManager) AttachTask(requestID string, task queryctx.TaskContext) {
+	r.mutex.Lock()
+	killed := false
+	if req, ok := r.requests[requestID]; ok {
+		req.task = task
+		killed = req.killed
+	}
+	r.mutex.Unlock()
+
+	if killed && task != nil {
+		task.Kill()
+	}
+}
+
+// KillRequest kills the running request by given request id, returns false if request not found.
+// If task of request is not attached yet, the task is killed when attaching.
+func (r *requestManager) KillRequest(requestID string) bool {
+	r.mutex.Lock()
+	req, ok := r.requests[requestID]
+	var task queryctx.TaskContext
+	if ok {
+		req.killed = true
+		task = req.task
+	}
+	r.mutex.Unlock()
+
+	if !ok {
+		return false
+	}
+	if task != nil {
+		task.Kill()
+	}
+	return true
+}
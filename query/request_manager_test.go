@@ -18,11 +18,14 @@
 package query
 
 import (
+	"sync"
 	"testing"
 
+	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/models"
+	queryctx "github.com/lindb/lindb/query/context"
 )
 
 func TestGetRequestManager(t *testing.T) {
@@ -40,3 +43,61 @@ func TestRequestManager(t *testing.T) {
 	mgr.CompleteRequest(req)
 	assert.Empty(t, mgr.GetAliveRequests())
 }
+
+func TestRequestManager_Kill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr := newRequestManager()
+	assert.False(t, mgr.KillRequest("not-found"))
+	mgr.AttachTask("not-found", nil)
+
+	req2 := mgr.NewRequest(&models.Request{RequestID: "2", Start: 2})
+	req1 := mgr.NewRequest(&models.Request{RequestID: "1", Start: 1})
+	task := queryctx.NewMockTaskContext(ctrl)
+	task.EXPECT().Stage().Return("Physical Plan").AnyTimes()
+	mgr.AttachTask(req1, task)
+	rs := mgr.GetAliveRequests()
+	assert.Len(t, rs, 2)
+	assert.Equal(t, req1, rs[0].RequestID)
+	assert.Equal(t, "Physical Plan", rs[0].Stage)
+	assert.Equal(t, req2, rs[1].RequestID)
+
+	task.EXPECT().Kill()
+	assert.True(t, mgr.KillRequest(req1))
+
+	rootCtx := queryctx.NewRootMetricContext(&queryctx.RootMetricContextDeps{})
+	mgr.AttachTask(req2, rootCtx)
+	rs = mgr.GetAliveRequests()
+	assert.Equal(t, int64(0), rs[1].ScanBytes)
+	assert.Equal(t, int64(0), rs[1].MemoryBytes)
+
+	// kill before task attached, task is killed when attaching
+	req3 := mgr.NewRequest(&models.Request{RequestID: "3", Start: 3})
+	assert.True(t, mgr.KillRequest(req3))
+	task3 := queryctx.NewMockTaskContext(ctrl)
+	task3.EXPECT().Kill()
+	mgr.AttachTask(req3, task3)
+}
+
+func TestRequestManager_Kill_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mgr := newRequestManager()
+	req := mgr.NewRequest(&models.Request{RequestID: "1", Start: 1})
+	task := queryctx.NewMockTaskContext(ctrl)
+	// killed exactly once, no matter kill before or after attaching
+	task.EXPECT().Kill()
+	var wait sync.WaitGroup
+	wait.Add(2)
+	go func() {
+		defer wait.Done()
+		mgr.AttachTask(req, task)
+	}()
+	go func() {
+		defer wait.Done()
+		assert.True(t, mgr.KillRequest(req))
+	}()
+	wait.Wait()
+}
@@ -0,0 +1,174 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+// ResultCache represents the broker side cache for the result of query whose time range is immutable.
+// It caches the time series blocks(task response payloads) instead of the final result,
+// so that different output formats(json/line-delimited json) can share the cached entries.
+type ResultCache interface {
+	// Get returns the cached time series blocks by key.
+	Get(key string) (blocks [][]byte, ok bool)
+	// Put caches the time series blocks by key.
+	Put(key string, blocks [][]byte)
+	// CacheKey returns the cache key of query, returns false if the result of query cannot be cached.
+	CacheKey(param *models.ExecuteParam, statement *stmt.Query, cfg models.Database) (key string, ok bool)
+}
+
+// resultCacheEntry represents the entry of result cache.
+type resultCacheEntry struct {
+	key      string
+	blocks   [][]byte
+	size     int64
+	expireAt int64
+}
+
+// resultCache implements ResultCache interface based on lru list with ttl and max size limit.
+type resultCache struct {
+	ttl     time.Duration
+	maxSize int64
+	size    int64
+	entries map[string]*list.Element
+	lru     *list.List // front is the most recently used
+
+	statistics *metrics.ResultCacheStatistics
+	mutex      sync.Mutex
+}
+
+// NewResultCache creates a ResultCache instance.
+func NewResultCache(ttl time.Duration, maxSize int64, registry *linmetric.Registry) ResultCache {
+	return &resultCache{
+		ttl:        ttl,
+		maxSize:    maxSize,
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		statistics: metrics.NewResultCacheStatistics(registry),
+	}
+}
+
+// Get returns the cached time series blocks by key.
+func (c *resultCache) Get(key string) (blocks [][]byte, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		c.statistics.Miss.Incr()
+		return nil, false
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if timeutil.Now() > entry.expireAt {
+		c.remove(elem)
+		c.statistics.Miss.Incr()
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	c.statistics.Hit.Incr()
+	return entry.blocks, true
+}
+
+// Put caches the time series blocks by key, ignores the result which is bigger than max size.
+func (c *resultCache) Put(key string, blocks [][]byte) {
+	var size int64
+	for _, block := range blocks {
+		size += int64(len(block))
+	}
+	if size > c.maxSize {
+		c.statistics.Bypass.Incr()
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &resultCacheEntry{
+		key:      key,
+		blocks:   blocks,
+		size:     size,
+		expireAt: timeutil.Now() + c.ttl.Milliseconds(),
+	}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += size
+	c.statistics.Size.Add(float64(size))
+	// evict the least recently used entries if exceed max size
+	for c.size > c.maxSize {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes the entry from cache without lock.
+func (c *resultCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*resultCacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+	c.statistics.Size.Sub(float64(entry.size))
+	c.statistics.Evict.Incr()
+}
+
+// CacheKey returns the cache key of query(database + normalized query + time range bucket),
+// returns false if the result of query cannot be cached(disabled by request/explain/mutable time range).
+func (c *resultCache) CacheKey(param *models.ExecuteParam, statement *stmt.Query, cfg models.Database) (string, bool) {
+	key, ok := resultCacheKey(param, statement, cfg, timeutil.Now())
+	if !ok {
+		c.statistics.Bypass.Incr()
+	}
+	return key, ok
+}
+
+// resultCacheKey returns the cache key of query, the data in time range of query must be immutable.
+func resultCacheKey(param *models.ExecuteParam, statement *stmt.Query, cfg models.Database, now int64) (string, bool) {
+	if !param.UseCache() || statement.Explain || cfg.Option == nil || len(cfg.Option.Intervals) == 0 {
+		return "", false
+	}
+	if statement.TimeZone != "" {
+		// buckets of time zone not align with the cache bucket
+		return "", false
+	}
+	// data in [now-behind, now] can still be written
+	_, behind := cfg.Option.GetAcceptWritableRange()
+	if statement.TimeRange.End <= 0 || statement.TimeRange.End >= now-behind {
+		return "", false
+	}
+	// time range aligned by the smallest storage interval of database, so that queries
+	// with relative time range(like now()-2d) in the same bucket can share the result.
+	bucket := cfg.Option.Intervals[0].Interval.Int64()
+	if statement.Interval.Int64() > bucket {
+		bucket = statement.Interval.Int64()
+	}
+	// normalize query without time range, because sub queries of cross metric query share the same sql.
+	normalized := *statement
+	normalized.TimeRange = timeutil.TimeRange{}
+	data, _ := normalized.MarshalJSON()
+	return fmt.Sprintf("%s|%s|%d|%d", param.Database, data,
+		statement.TimeRange.Start/bucket, statement.TimeRange.End/bucket), true
+}
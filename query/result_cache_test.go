@@ -0,0 +1,152 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestResultCache_GetPut(t *testing.T) {
+	cache := NewResultCache(time.Minute, 10, linmetric.BrokerRegistry)
+	blocks, ok := cache.Get("k1")
+	assert.False(t, ok)
+	assert.Nil(t, blocks)
+
+	cache.Put("k1", [][]byte{{1, 2}, {3}})
+	blocks, ok = cache.Get("k1")
+	assert.True(t, ok)
+	assert.Equal(t, [][]byte{{1, 2}, {3}}, blocks)
+	// replace
+	cache.Put("k1", [][]byte{{1, 2, 3, 4}})
+	blocks, ok = cache.Get("k1")
+	assert.True(t, ok)
+	assert.Equal(t, [][]byte{{1, 2, 3, 4}}, blocks)
+
+	// too large
+	cache.Put("k2", [][]byte{make([]byte, 11)})
+	_, ok = cache.Get("k2")
+	assert.False(t, ok)
+
+	// evict the least recently used
+	cache.Put("k2", [][]byte{make([]byte, 5)})
+	_, ok = cache.Get("k1")
+	assert.True(t, ok)
+	cache.Put("k3", [][]byte{make([]byte, 5)})
+	_, ok = cache.Get("k2")
+	assert.False(t, ok)
+	_, ok = cache.Get("k1")
+	assert.True(t, ok)
+	_, ok = cache.Get("k3")
+	assert.True(t, ok)
+}
+
+func TestResultCache_Expire(t *testing.T) {
+	cache := NewResultCache(time.Millisecond, 10, linmetric.BrokerRegistry)
+	cache.Put("k1", [][]byte{{1}})
+	time.Sleep(5 * time.Millisecond)
+	_, ok := cache.Get("k1")
+	assert.False(t, ok)
+}
+
+func TestResultCache_CacheKey(t *testing.T) {
+	now := timeutil.Now()
+	day := timeutil.OneDay
+	cfg := models.Database{
+		Option: &option.DatabaseOption{
+			Intervals: option.Intervals{{Interval: timeutil.Interval(10 * timeutil.OneSecond)}},
+			Behind:    "1d",
+		},
+	}
+	noCache := false
+	immutable := timeutil.TimeRange{Start: now - 3*day, End: now - 2*day}
+	cases := []struct {
+		name      string
+		param     *models.ExecuteParam
+		statement *stmt.Query
+		cfg       models.Database
+		cacheable bool
+	}{
+		{
+			name:      "disabled by request",
+			param:     &models.ExecuteParam{Cache: &noCache},
+			statement: &stmt.Query{TimeRange: immutable},
+			cfg:       cfg,
+		},
+		{
+			name:      "explain query",
+			param:     &models.ExecuteParam{},
+			statement: &stmt.Query{Explain: true, TimeRange: immutable},
+			cfg:       cfg,
+		},
+		{
+			name:      "time zone query",
+			param:     &models.ExecuteParam{},
+			statement: &stmt.Query{TimeZone: "Asia/Shanghai", TimeRange: immutable},
+			cfg:       cfg,
+		},
+		{
+			name:      "database option not found",
+			param:     &models.ExecuteParam{},
+			statement: &stmt.Query{TimeRange: immutable},
+		},
+		{
+			name:      "time range is mutable",
+			param:     &models.ExecuteParam{},
+			statement: &stmt.Query{TimeRange: timeutil.TimeRange{Start: now - day, End: now}},
+			cfg:       cfg,
+		},
+		{
+			name:      "time range is immutable",
+			param:     &models.ExecuteParam{},
+			statement: &stmt.Query{TimeRange: immutable, Interval: timeutil.Interval(timeutil.OneMinute)},
+			cfg:       cfg,
+			cacheable: true,
+		},
+	}
+	cache := NewResultCache(time.Minute, 10, linmetric.BrokerRegistry)
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			key, ok := cache.CacheKey(tt.param, tt.statement, tt.cfg)
+			assert.Equal(t, tt.cacheable, ok)
+			assert.Equal(t, tt.cacheable, key != "")
+		})
+	}
+
+	// queries in same time range bucket share the key
+	param := &models.ExecuteParam{Database: "db"}
+	start := timeutil.Truncate(now-3*day, timeutil.OneMinute)
+	key1, _ := resultCacheKey(param, &stmt.Query{MetricName: "cpu",
+		TimeRange: timeutil.TimeRange{Start: start, End: start + day}}, cfg, now)
+	key2, _ := resultCacheKey(param, &stmt.Query{MetricName: "cpu",
+		TimeRange: timeutil.TimeRange{Start: start + 1000, End: start + day + 1000}}, cfg, now)
+	assert.Equal(t, key1, key2)
+	// different query
+	key3, _ := resultCacheKey(param, &stmt.Query{MetricName: "mem",
+		TimeRange: timeutil.TimeRange{Start: start, End: start + day}}, cfg, now)
+	assert.NotEqual(t, key1, key3)
+}
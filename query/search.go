@@ -19,15 +19,27 @@ package query
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"sort"
 	"strings"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/strutil"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	queryctx "github.com/lindb/lindb/query/context"
 	"github.com/lindb/lindb/query/stage"
 	trackerpkg "github.com/lindb/lindb/query/tracker"
@@ -36,6 +48,19 @@ import (
 	stmtpkg "github.com/lindb/lindb/sql/stmt"
 )
 
+// DatabaseAuthorizer represents the hook which checks if the query can access the database,
+// it is consulted for each database before executing query.
+type DatabaseAuthorizer interface {
+	// Authorize returns error if the query is not allowed to access the database.
+	Authorize(ctx context.Context, param *models.ExecuteParam, database string) error
+}
+
+// QueryMeter records the usage of data query for metering.
+type QueryMeter interface {
+	// RecordQuery records a data query of namespace and the bytes of data scanned by it.
+	RecordQuery(database, namespace string, scanBytes int64)
+}
+
 // SearchMgr represents the dependencies for searching.
 type SearchMgr struct {
 	// for intermediate processor set reqeust id, must keep using same request id
@@ -45,6 +70,24 @@ type SearchMgr struct {
 	Choose       flow.NodeChoose
 	TaskMgr      TaskManager
 	TransportMgr rpc.TransportManager
+	// MaxBufferedSeries limits grouped series buffered for one query.
+	MaxBufferedSeries int
+	// SlowQueryLog records the query which cost exceeds slow query threshold, nil means disabled.
+	SlowQueryLog SlowQueryLog
+	// ResultCache caches the result of query whose time range is immutable, nil means disabled.
+	ResultCache ResultCache
+	// Authorizer checks the access of database for each query, nil means all databases are accessible.
+	Authorizer DatabaseAuthorizer
+	// ReplicaLag provides the replication lag of replicas for failing over to follower, nil means unknown.
+	ReplicaLag broker.ReplicaLagTracker
+	// Hedger decides the delay and rate of hedging slow leaf request, nil means no limit.
+	Hedger *queryctx.Hedger
+	// Meter records the usage of data query, nil means disabled.
+	Meter QueryMeter
+	// TopN controls pushing down top-n query to storage nodes, zero value means disabled.
+	TopN config.TopN
+	// Memory controls the memory budget of grouped series buffered for one query, zero value means no limit.
+	Memory config.QueryMemory
 }
 
 // MetricMetadataSearchWithResult represents the metadata query executor and retruns the final result set.
@@ -56,7 +99,7 @@ func MetricMetadataSearchWithResult(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
-	return buildMetadataResultSet(statement, rs.([]string))
+	return buildMetadataResultSet(statement, rs.(*models.SuggestResult))
 }
 
 // MetricMetadata represents the metadata query executor, includes:
@@ -67,8 +110,37 @@ func MetricMetadataSearchWithResult(ctx context.Context,
 func MetricMetadataSearch(ctx context.Context,
 	param *models.ExecuteParam, statement *stmtpkg.MetricMetadata,
 	mgr *SearchMgr,
-) (any, error) {
+) (rs any, err error) {
+	ctx, cancel, err := withQueryTimeout(ctx, param, mgr)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	if err := authorize(ctx, param, param.Database, mgr); err != nil {
+		return nil, err
+	}
+	if err := checkDatabaseDeleted(param.Database, mgr); err != nil {
+		return nil, err
+	}
+	ctx, span := tracing.StartSpan(ctx, "query.execute",
+		attribute.String("database", param.Database),
+		attribute.String("type", "metadata"))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+
+	var cursor *models.MetadataCursor
+	if param.Cursor != "" {
+		if !statement.Paginated() {
+			return nil, fmt.Errorf("cursor not supported for metadata query: %s", statement.Type.String())
+		}
+		if cursor, err = models.DecodeMetadataCursor(param.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
 	req := models.NewRequest(mgr.CurNode.Indicator(), param.Database, param.SQL)
+	req.Client = param.Client
 	taskCtx := queryctx.NewMetadataContext(&queryctx.MetadataDeps{
 		Ctx:          ctx,
 		Request:      req,
@@ -77,6 +149,7 @@ func MetricMetadataSearch(ctx context.Context,
 		CurrentNode:  mgr.CurNode,
 		Choose:       mgr.Choose,
 		TransportMgr: mgr.TransportMgr,
+		Cursor:       cursor,
 	})
 	return exec(taskCtx, req, mgr)
 }
@@ -110,18 +183,379 @@ func MetricDataSearch(ctx context.Context,
 	param *models.ExecuteParam, statement *stmtpkg.Query,
 	mgr *SearchMgr,
 ) (any, error) {
+	ctx, cancel, err := withQueryTimeout(ctx, param, mgr)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	if _, err := option.ParseReplicaPolicy(param.ReplicaPolicy); err != nil {
+		return nil, err
+	}
+	if _, err := option.ParseReadConsistency(param.ReadConsistency); err != nil {
+		return nil, err
+	}
+	if _, err := option.ParseQueryStrictness(param.Strictness); err != nil {
+		return nil, err
+	}
+	if param.ReadLeader() {
+		// leaf nodes report max data timestamp of shards, so that clients can detect staleness
+		statement.TrackTimestamp = true
+	}
+	if _, _, err := memoryBudgetOf(param, mgr); err != nil {
+		return nil, err
+	}
+	if statement.TimeZone == "" && param.TimeZone != "" {
+		if _, err := timeutil.LoadLocation(param.TimeZone); err != nil {
+			return nil, err
+		}
+		statement.TimeZone = param.TimeZone
+	}
+
+	subQuery, err := newSubQueryPlan(statement)
+	if err != nil {
+		return nil, err
+	}
+	if subQuery != nil {
+		// outer aggregation consumes the result set of sub query in broker
+		return subQuery.execute(ctx, param, mgr, MetricDataSearch)
+	}
+
+	databases := param.DatabaseNames()
+	if len(databases) > 1 {
+		// query multiple databases, execute each database as sub query, then merge the results
+		plan, err := newFederatedPlan(statement, databases, param.MergeDatabases)
+		if err != nil {
+			return nil, err
+		}
+		return plan.execute(ctx, param, mgr, plan.executeSubQuery)
+	}
+	if len(databases) == 1 && databases[0] != param.Database {
+		singleParam := *param
+		singleParam.Database = databases[0]
+		singleParam.Databases = ""
+		param = &singleParam
+	}
+	if err := authorize(ctx, param, param.Database, mgr); err != nil {
+		return nil, err
+	}
+	if err := checkDatabaseDeleted(param.Database, mgr); err != nil {
+		return nil, err
+	}
+
+	pattern, err := newMetricPatternPlan(statement, param.MergeMetrics)
+	if err != nil {
+		return nil, err
+	}
+	if pattern != nil {
+		// metric name is a pattern, execute each matched metric as sub query
+		return pattern.execute(ctx, param, mgr, MetricMetadataSearch, executeBlocksQuery)
+	}
+	plan, err := newCrossMetricPlan(statement)
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		// binary expression between metrics, execute each metric as sub query
+		return plan.execute(ctx, param, mgr, metricDataSearch)
+	}
+	return metricDataSearch(ctx, param, statement, mgr)
+}
+
+// metricDataSearch executes the query of single metric, applies the field aliases of database if query references renamed fields,
+// clamps the time range of query to the retention of database,
+// notes the fields whose data is dropped by field level retention in query time range and the write semantics of database,
+// fails the query referencing unknown metric/fields in strict mode, else notes them with empty result.
+func metricDataSearch(ctx context.Context,
+	param *models.ExecuteParam, statement *stmtpkg.Query,
+	mgr *SearchMgr,
+) (rs any, err error) {
+	var unknownNames []models.UnknownName
+	rangeClipped, outOfRetention := clampTimeRange(param, statement, mgr)
+	if outOfRetention {
+		// whole time range is out of retention, need not create leaf tasks
+		rs = &models.ResultSet{MetricName: statement.MetricName}
+	} else if rs, err = aliasedMetricSearch(ctx, param, statement, mgr); err != nil {
+		names, ok := models.ParseUnknownNames(err)
+		if !ok {
+			return nil, err
+		}
+		if queryStrictnessOf(param, param.Database, mgr) == option.QueryStrictnessStrict {
+			return nil, unknownNameErr(names)
+		}
+		unknownNames = names
+		rs = &models.ResultSet{MetricName: statement.MetricName}
+	}
+	resultSet, ok := rs.(*models.ResultSet)
+	if !ok || resultSet == nil {
+		return rs, nil
+	}
+	stats := func() *models.NodeStats {
+		if resultSet.Stats == nil {
+			resultSet.Stats = &models.NodeStats{Node: mgr.CurNode.Indicator()}
+		}
+		return resultSet.Stats
+	}
+	if rangeClipped != "" {
+		stats().RangeClipped = rangeClipped
+	}
+	if len(unknownNames) > 0 {
+		stats().UnknownNames = make([]string, len(unknownNames))
+		for idx, name := range unknownNames {
+			stats().UnknownNames[idx] = name.String()
+		}
+	}
+	if expiredFields := expiredFieldsOf(param.Database, statement, mgr); len(expiredFields) > 0 {
+		stats().ExpiredFields = expiredFields
+	}
+	if semantics := writeSemanticsOf(param.Database, mgr); semantics != option.WriteSemanticsAggregate {
+		stats().WriteSemantics = string(semantics)
+	}
+	return rs, nil
+}
+
+// aliasedMetricSearch executes the query of single metric, applies the field aliases of database if query references renamed fields.
+func aliasedMetricSearch(ctx context.Context,
+	param *models.ExecuteParam, statement *stmtpkg.Query,
+	mgr *SearchMgr,
+) (any, error) {
+	plan, err := newFieldAliasPlan(statement, fieldAliasOf(param.Database, mgr))
+	if err != nil {
+		return nil, err
+	}
+	if plan != nil {
+		// renamed fields referenced, execute the query of each rename time range as sub query
+		return plan.execute(ctx, param, mgr, metricSearch)
+	}
+	if topN := newTopNPlan(statement, mgr.TopN); topN != nil {
+		// group by with order by/limit, push down top-n to storage nodes
+		return topN.execute(ctx, param, mgr, metricSearch)
+	}
+	return metricSearch(ctx, param, statement, mgr)
+}
+
+// metricSearch executes the query of single metric without field aliasing.
+func metricSearch(ctx context.Context,
+	param *models.ExecuteParam, statement *stmtpkg.Query,
+	mgr *SearchMgr,
+) (rs any, err error) {
+	ctx, span := tracing.StartSpan(ctx, "query.execute",
+		attribute.String("database", param.Database),
+		attribute.String("type", "data"),
+		attribute.String("metric", statement.MetricName))
+	defer func() {
+		tracing.EndSpan(span, err)
+	}()
+	cacheKey, cacheable := resultCacheKeyOf(param, statement, mgr)
 	req := models.NewRequest(mgr.CurNode.Indicator(), param.Database, param.SQL)
+	req.Client = param.Client
+	replicaPolicy, hedgeTimeout := replicaPolicyOf(param, param.Database, mgr)
+	// memory budget of request param is validated before executing query
+	memoryBudget, spillDir, _ := memoryBudgetOf(param, mgr)
 	taskCtx := queryctx.NewRootMetricContext(
 		&queryctx.RootMetricContextDeps{
-			Ctx:          ctx,
-			Request:      req,
-			Database:     param.Database,
-			CurrentNode:  mgr.CurNode,
-			Statement:    statement,
-			Choose:       mgr.Choose,
-			TransportMgr: mgr.TransportMgr,
+			Ctx:               ctx,
+			Request:           req,
+			Database:          param.Database,
+			CurrentNode:       mgr.CurNode,
+			Statement:         statement,
+			Choose:            mgr.Choose,
+			TransportMgr:      mgr.TransportMgr,
+			Stream:            param.Stream,
+			MaxBufferedSeries: mgr.MaxBufferedSeries,
+			MemoryBudget:      memoryBudget,
+			SpillDir:          spillDir,
+			KeepBlocks:        cacheable,
+			ReplicaPolicy:     replicaPolicy,
+			HedgeTimeout:      hedgeTimeout,
+			ReplicaLag:        mgr.ReplicaLag,
+			Hedger:            mgr.Hedger,
+			Partial:           param.Partial,
 		})
-	return exec(taskCtx, req, mgr)
+	if cacheable {
+		if blocks, ok := mgr.ResultCache.Get(cacheKey); ok {
+			// build result set from cached time series blocks
+			taskCtx.SetTracker(trackerpkg.NewStageTracker(&flow.TaskContext{Start: time.Now()}))
+			span.SetAttributes(attribute.Bool("resultCache", true))
+			rs, err = taskCtx.Replay(blocks)
+			recordQuery(mgr, param.Database, statement.Namespace, taskCtx, err)
+			return rs, err
+		}
+	}
+	rs, err = exec(taskCtx, req, mgr)
+	recordQuery(mgr, param.Database, statement.Namespace, taskCtx, err)
+	if err == nil && cacheable {
+		// result queried on follower replicas may miss recent data, partial result misses data of failed nodes,
+		// need not cache them
+		if resultSet, ok := rs.(*models.ResultSet); !ok || (resultSet.Stale == nil && !resultSet.Partial) {
+			mgr.ResultCache.Put(cacheKey, taskCtx.Blocks())
+		}
+	}
+	return rs, err
+}
+
+// recordQuery records the usage of data query if metering is enabled.
+func recordQuery(mgr *SearchMgr, database, namespace string, taskCtx *queryctx.RootMetricContext, err error) {
+	if mgr.Meter == nil || err != nil {
+		return
+	}
+	mgr.Meter.RecordQuery(database, namespace, taskCtx.ScanBytes())
+}
+
+// fieldAliasOf returns the field aliases of database, nil if database has no field alias.
+func fieldAliasOf(database string, mgr *SearchMgr) *models.DatabaseFieldAlias {
+	stateMgr, ok := mgr.Choose.(broker.StateManager)
+	if !ok {
+		return nil
+	}
+	fieldAlias, ok := stateMgr.GetFieldAlias(database)
+	if !ok {
+		return nil
+	}
+	return fieldAlias
+}
+
+// clampTimeRange clamps the time range of query to the retention of database if clamping is enabled,
+// returns the note of clipped range, and if the whole time range is out of retention(queried as empty).
+func clampTimeRange(param *models.ExecuteParam, statement *stmtpkg.Query, mgr *SearchMgr) (rangeClipped string, outOfRetention bool) {
+	stateMgr, ok := mgr.Choose.(broker.StateManager)
+	if !ok {
+		return "", false
+	}
+	databaseCfg, ok := stateMgr.GetDatabaseCfg(param.Database)
+	if !ok {
+		return "", false
+	}
+	if !param.ClampTimeRange() {
+		// keeps the time range of query, only checks if the whole time range is out of retention
+		planned := *statement
+		queryctx.ClampTimeRangeByRetention(&planned, databaseCfg)
+		return "", planned.TimeRange.Start > planned.TimeRange.End
+	}
+	start := statement.TimeRange.Start
+	if !queryctx.ClampTimeRangeByRetention(statement, databaseCfg) {
+		return "", false
+	}
+	rangeClipped = fmt.Sprintf("range clipped from %s to %s",
+		timeutil.FormatTimestamp(start, timeutil.DataTimeFormat2),
+		timeutil.FormatTimestamp(statement.TimeRange.Start, timeutil.DataTimeFormat2))
+	return rangeClipped, statement.TimeRange.Start > statement.TimeRange.End
+}
+
+// expiredFieldsOf returns the fields referenced by query whose data is dropped by field level retention in query time range,
+// the dropped data is queried as empty.
+func expiredFieldsOf(database string, statement *stmtpkg.Query, mgr *SearchMgr) (expiredFields []string) {
+	stateMgr, ok := mgr.Choose.(broker.StateManager)
+	if !ok {
+		return nil
+	}
+	schema, ok := stateMgr.GetDatabaseSchema(database)
+	if !ok || schema == nil {
+		return nil
+	}
+	now := timeutil.Now()
+	for _, fieldName := range queryFieldNames(statement) {
+		retention, ok := schema.GetFieldRetention(statement.Namespace, statement.MetricName, fieldName)
+		if !ok {
+			continue
+		}
+		var interval timeutil.Interval
+		if err := interval.ValueOf(retention); err != nil || interval <= 0 {
+			continue
+		}
+		expiredBefore := now - interval.Int64()
+		if statement.TimeRange.Start < expiredBefore {
+			expiredFields = append(expiredFields, fmt.Sprintf("%s(expired before %s)",
+				fieldName, timeutil.FormatTimestamp(expiredBefore, timeutil.DataTimeFormat2)))
+		}
+	}
+	return expiredFields
+}
+
+// writeSemanticsOf returns the write semantics of database, returns aggregate if database config not found.
+func writeSemanticsOf(database string, mgr *SearchMgr) option.WriteSemantics {
+	if stateMgr, ok := mgr.Choose.(broker.StateManager); ok {
+		if databaseCfg, ok := stateMgr.GetDatabaseCfg(database); ok && databaseCfg.Option != nil {
+			return databaseCfg.Option.GetWriteSemantics()
+		}
+	}
+	return option.WriteSemanticsAggregate
+}
+
+// queryStrictnessOf returns the query strictness of request param first, then the query strictness of database.
+func queryStrictnessOf(param *models.ExecuteParam, database string, mgr *SearchMgr) option.QueryStrictness {
+	if param.Strictness != "" {
+		// query strictness of request param is validated before executing query
+		return option.QueryStrictness(param.Strictness)
+	}
+	if stateMgr, ok := mgr.Choose.(broker.StateManager); ok {
+		if databaseCfg, ok := stateMgr.GetDatabaseCfg(database); ok && databaseCfg.Option != nil {
+			return databaseCfg.Option.GetQueryStrictness()
+		}
+	}
+	return option.QueryStrictnessLenient
+}
+
+// unknownNameErr returns the structured error of unknown metric/fields with close-match suggestions as details.
+func unknownNameErr(names []models.UnknownName) error {
+	notes := make([]string, len(names))
+	for idx, name := range names {
+		notes[idx] = name.String()
+	}
+	return errcode.WithDetails(errcode.NotFound,
+		fmt.Errorf("%w: %s", constants.ErrUnknownName, strings.Join(notes, "; ")), names)
+}
+
+// replicaPolicyOf returns the replica policy and hedge timeout of query, leader only for leader read consistency,
+// else the replica policy of request param first, then the replica policy of database.
+func replicaPolicyOf(param *models.ExecuteParam, database string, mgr *SearchMgr) (option.ReplicaPolicy, time.Duration) {
+	databaseOption := &option.DatabaseOption{}
+	if stateMgr, ok := mgr.Choose.(broker.StateManager); ok {
+		if databaseCfg, ok := stateMgr.GetDatabaseCfg(database); ok && databaseCfg.Option != nil {
+			databaseOption = databaseCfg.Option
+		}
+	}
+	if param.ReadLeader() {
+		// read-your-writes, data written may be still in memory database of leader
+		return option.ReplicaPolicyLeaderOnly, databaseOption.GetHedgeTimeout()
+	}
+	if param.ReplicaPolicy != "" {
+		// replica policy of request param is validated before executing query
+		return option.ReplicaPolicy(param.ReplicaPolicy), databaseOption.GetHedgeTimeout()
+	}
+	return databaseOption.GetReplicaPolicy(), databaseOption.GetHedgeTimeout()
+}
+
+// memoryBudgetOf returns the memory budget of grouped series buffered for query and the directory of spilling,
+// the memory budget of request param first, then the budget of config, empty spill dir means spilling is disabled.
+func memoryBudgetOf(param *models.ExecuteParam, mgr *SearchMgr) (budget int64, spillDir string, err error) {
+	budget = int64(mgr.Memory.Budget)
+	if param.MemoryBudget != "" {
+		var size ltoml.Size
+		if err := size.UnmarshalText([]byte(param.MemoryBudget)); err != nil || size == 0 {
+			return 0, "", fmt.Errorf("invalid query memory budget: %s", param.MemoryBudget)
+		}
+		budget = int64(size)
+	}
+	if mgr.Memory.EnableSpill {
+		spillDir = mgr.Memory.SpillDir
+	}
+	return budget, spillDir, nil
+}
+
+// resultCacheKeyOf returns the result cache key of query, returns false if the result cannot be cached.
+func resultCacheKeyOf(param *models.ExecuteParam, statement *stmtpkg.Query, mgr *SearchMgr) (string, bool) {
+	if mgr.ResultCache == nil {
+		return "", false
+	}
+	stateMgr, ok := mgr.Choose.(broker.StateManager)
+	if !ok {
+		return "", false
+	}
+	databaseCfg, ok := stateMgr.GetDatabaseCfg(param.Database)
+	if !ok {
+		return "", false
+	}
+	return mgr.ResultCache.CacheKey(param, statement, databaseCfg)
 }
 
 // exec executes the query pipeline.
@@ -134,10 +568,13 @@ func exec(ctx queryctx.TaskContext, req *models.Request, mgr *SearchMgr) (any, e
 	}
 	// set request id
 	GetRequestManager().NewRequest(req)
+	span := trace.SpanFromContext(ctx.Context())
+	span.SetAttributes(attribute.String("requestID", req.RequestID))
 	// execute metadata query pipeline
 	tracker := trackerpkg.NewStageTracker(flow.NewTaskContextWithTimeout(ctx.Context(), mgr.Timeout))
 	ctx.SetTracker(tracker)
 	mgr.TaskMgr.AddTask(req.RequestID, ctx)
+	GetRequestManager().AttachTask(req.RequestID, ctx)
 
 	defer func() {
 		mgr.TaskMgr.RemoveTask(req.RequestID)
@@ -153,12 +590,101 @@ func exec(ctx queryctx.TaskContext, req *models.Request, mgr *SearchMgr) (any, e
 	// cache pipeline
 	GetPipelineManager().AddPipeline(req.RequestID, pipeline)
 	pipeline.Execute(stage.NewPhysicalPlanStage(ctx))
-	return ctx.WaitResponse()
+	rs, err := ctx.WaitResponse()
+	if span.IsRecording() {
+		span.SetAttributes(attribute.Int64("series", int64(numOfSeries(rs))))
+	}
+	if errors.Is(err, constants.ErrTimeout) {
+		// attach partial stage timings for diagnosing which stage is slow
+		err = fmt.Errorf("%w, stages: %s", err, models.StagesSummary(tracker.GetStages()))
+	}
+	if mgr.SlowQueryLog != nil {
+		slowQuery := &models.SlowQuery{
+			RequestID:   req.RequestID,
+			DB:          req.DB,
+			SQL:         req.SQL,
+			Client:      req.Client,
+			Start:       req.Start,
+			Cost:        time.Now().UnixNano() - req.Start,
+			NumOfSeries: numOfSeries(rs),
+			Stages:      tracker.GetStages(),
+			Killed:      errors.Is(err, constants.ErrQueryKilled),
+		}
+		if err != nil {
+			slowQuery.ErrMsg = err.Error()
+		}
+		mgr.SlowQueryLog.Record(slowQuery)
+	}
+	return rs, err
 }
 
-// buildMetadataResultSet builds metric metadata result set.
-func buildMetadataResultSet(statement *stmtpkg.MetricMetadata, result []string) (*models.Metadata, error) {
-	values := strutil.DeDupStringSlice(result)
+// authorize checks if the query can access the database via authorizer hook.
+func authorize(ctx context.Context, param *models.ExecuteParam, database string, mgr *SearchMgr) error {
+	if mgr.Authorizer == nil {
+		return nil
+	}
+	return mgr.Authorizer.Authorize(ctx, param, database)
+}
+
+// checkDatabaseDeleted rejects the query of dropped database, which is pending deletion.
+func checkDatabaseDeleted(database string, mgr *SearchMgr) error {
+	stateMgr, ok := mgr.Choose.(broker.StateManager)
+	if !ok {
+		return nil
+	}
+	if databaseCfg, ok := stateMgr.GetDatabaseCfg(database); ok && databaseCfg.IsDeleted() {
+		return constants.ErrDatabaseDeleted
+	}
+	return nil
+}
+
+// withQueryTimeout returns the context with query timeout, the timeout of request param first,
+// then the default query timeout of database(the minimum one for multiple databases),
+// if both not set, returns the context with cancel only.
+func withQueryTimeout(ctx context.Context, param *models.ExecuteParam, mgr *SearchMgr) (context.Context, context.CancelFunc, error) {
+	var timeout time.Duration
+	if param.Timeout != "" {
+		t, err := time.ParseDuration(param.Timeout)
+		if err != nil || t <= 0 {
+			return nil, nil, fmt.Errorf("invalid query timeout: %s", param.Timeout)
+		}
+		timeout = t
+	} else if stateMgr, ok := mgr.Choose.(broker.StateManager); ok {
+		// use the minimum default timeout if query multiple databases
+		for _, database := range param.DatabaseNames() {
+			databaseCfg, ok := stateMgr.GetDatabaseCfg(database)
+			if !ok || databaseCfg.Option == nil {
+				continue
+			}
+			if t := databaseCfg.Option.GetQueryTimeout(); t > 0 && (timeout <= 0 || t < timeout) {
+				timeout = t
+			}
+		}
+	}
+	if timeout <= 0 {
+		c, cancel := context.WithCancel(ctx)
+		return c, cancel, nil
+	}
+	c, cancel := context.WithTimeout(ctx, timeout)
+	return c, cancel, nil
+}
+
+// numOfSeries returns the num. of series(metadata values) in result.
+func numOfSeries(rs any) int {
+	switch result := rs.(type) {
+	case *models.ResultSet:
+		return result.NumOfSeries()
+	case *models.SuggestResult:
+		return len(result.Values)
+	default:
+		return 0
+	}
+}
+
+// buildMetadataResultSet builds metric metadata result set,
+// merges the partial results from each node with dedupe, then apply the limit.
+func buildMetadataResultSet(statement *stmtpkg.MetricMetadata, rs *models.SuggestResult) (*models.Metadata, error) {
+	values := strutil.DeDupStringSlice(rs.Values)
 	sort.Strings(values)
 	switch statement.Type {
 	case stmtpkg.Field:
@@ -208,9 +734,16 @@ func buildMetadataResultSet(statement *stmtpkg.MetricMetadata, result []string)
 			Values: resultFields,
 		}, nil
 	default:
+		truncated := rs.Truncated
+		if statement.Limit > 0 && len(values) > statement.Limit {
+			values = values[:statement.Limit]
+			truncated = true
+		}
 		return &models.Metadata{
-			Type:   statement.Type.String(),
-			Values: values,
+			Type:      statement.Type.String(),
+			Values:    values,
+			Truncated: truncated,
+			Cursor:    rs.Cursor,
 		}, nil
 	}
 }
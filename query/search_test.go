@@ -20,12 +20,23 @@ package query
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/broker"
+	"github.com/lindb/lindb/flow"
+	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/errcode"
+	"github.com/lindb/lindb/pkg/metering"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoCommonV1 "github.com/lindb/lindb/proto/gen/v1/common"
 	trackerpkg "github.com/lindb/lindb/query/tracker"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/sql/stmt"
@@ -41,6 +52,71 @@ func TestSearchFail(t *testing.T) {
 	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{}, &stmt.Query{}, &SearchMgr{})
 	assert.Error(t, err)
 	assert.Nil(t, rs)
+	// bad cross metric query
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{}, &stmt.Query{
+		MetricName:  "cpu",
+		SelectItems: []stmt.Expr{&stmt.BinaryExpr{Left: &stmt.FieldExpr{Name: "cpu.f"}, Right: &stmt.FieldExpr{Name: "f"}}},
+	}, &SearchMgr{})
+	assert.ErrorIs(t, err, ErrCrossMetricQuery)
+	assert.Nil(t, rs)
+	// bad sub query
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{}, &stmt.Query{
+		MetricName: "cpu",
+		SubQuery:   &stmt.Query{MetricName: "cpu"},
+	}, &SearchMgr{})
+	assert.ErrorIs(t, err, ErrSubQuery)
+	assert.Nil(t, rs)
+	// invalid query timeout
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{Timeout: "abc"}, &stmt.Query{}, &SearchMgr{})
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	rs, err = MetricMetadataSearch(context.TODO(), &models.ExecuteParam{Timeout: "-1s"}, &stmt.MetricMetadata{}, &SearchMgr{})
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	// invalid replica policy
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{ReplicaPolicy: "abc"}, &stmt.Query{}, &SearchMgr{})
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	// invalid read consistency
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{ReadConsistency: "abc"}, &stmt.Query{}, &SearchMgr{})
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	// invalid query strictness
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{Strictness: "abc"}, &stmt.Query{}, &SearchMgr{})
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	// invalid memory budget
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{MemoryBudget: "abc"}, &stmt.Query{}, &SearchMgr{})
+	assert.ErrorContains(t, err, "invalid query memory budget")
+	assert.Nil(t, rs)
+	// invalid time zone
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{TimeZone: "abc"}, &stmt.Query{}, &SearchMgr{})
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	// cross metric query without database
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{}, &stmt.Query{
+		MetricName:  "cpu",
+		SelectItems: []stmt.Expr{&stmt.FieldExpr{Name: "cpu.f"}},
+	}, &SearchMgr{})
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	// cross metric query for multiple databases
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{Databases: "db1,db2"}, &stmt.Query{
+		MetricName:  "cpu",
+		SelectItems: []stmt.Expr{&stmt.FieldExpr{Name: "cpu.f"}},
+	}, &SearchMgr{})
+	assert.ErrorIs(t, err, ErrFederatedQuery)
+	assert.Nil(t, rs)
+	// access denied
+	authorizer := &mockAuthorizer{denied: "db"}
+	rs, err = MetricDataSearch(context.TODO(), &models.ExecuteParam{Databases: "db"}, &stmt.Query{},
+		&SearchMgr{Authorizer: authorizer})
+	assert.ErrorContains(t, err, "access denied")
+	assert.Nil(t, rs)
+	rs, err = MetricMetadataSearch(context.TODO(), &models.ExecuteParam{Database: "db"}, &stmt.MetricMetadata{},
+		&SearchMgr{Authorizer: authorizer})
+	assert.ErrorContains(t, err, "access denied")
+	assert.Nil(t, rs)
 }
 
 func TestMetricMetadataSearch(t *testing.T) {
@@ -60,27 +136,563 @@ func TestMetricMetadataSearch(t *testing.T) {
 	taskMgr := NewMockTaskManager(ctrl)
 	taskMgr.EXPECT().AddTask(gomock.Any(), gomock.Any())
 	taskMgr.EXPECT().RemoveTask(gomock.Any())
-	rs, err := MetricMetadataSearchWithResult(context.TODO(), &models.ExecuteParam{Database: "test"}, &stmt.MetricMetadata{}, &SearchMgr{
-		RequestID: "xxxx-1bc",
-		TaskMgr:   taskMgr,
-	})
+	slowQueryLog := NewSlowQueryLog(time.Nanosecond, 10, linmetric.BrokerRegistry)
+	rs, err := MetricMetadataSearchWithResult(context.TODO(),
+		&models.ExecuteParam{Database: "test", Client: "127.0.0.1"}, &stmt.MetricMetadata{}, &SearchMgr{
+			RequestID:    "xxxx-1bc",
+			TaskMgr:      taskMgr,
+			SlowQueryLog: slowQueryLog,
+		})
 	assert.NoError(t, err)
 	assert.NotNil(t, rs)
+	slowQueries := slowQueryLog.GetSlowQueries()
+	assert.Len(t, slowQueries, 1)
+	assert.Equal(t, "test", slowQueries[0].DB)
+	assert.Equal(t, "127.0.0.1", slowQueries[0].Client)
+}
+
+func TestMetricMetadataSearch_Cursor(t *testing.T) {
+	mgr := &SearchMgr{RequestID: "xxxx-1bc"}
+	rs, err := MetricMetadataSearch(context.TODO(),
+		&models.ExecuteParam{Database: "test", Cursor: "abc"}, &stmt.MetricMetadata{Type: stmt.TagValue}, mgr)
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+	rs, err = MetricMetadataSearch(context.TODO(),
+		&models.ExecuteParam{Database: "test", Cursor: "!!!"}, &stmt.MetricMetadata{Type: stmt.Metric}, mgr)
+	assert.Error(t, err)
+	assert.Nil(t, rs)
+}
+
+func TestWithQueryTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	cases := []struct {
+		name    string
+		param   *models.ExecuteParam
+		prepare func()
+		timeout time.Duration
+		wantErr bool
+	}{
+		{
+			name:  "no timeout",
+			param: &models.ExecuteParam{Database: "test"},
+			prepare: func() {
+				stateMgr.EXPECT().GetDatabaseCfg("test").Return(models.Database{}, false)
+			},
+		},
+		{
+			name:    "invalid timeout of request",
+			param:   &models.ExecuteParam{Timeout: "0s"},
+			wantErr: true,
+		},
+		{
+			name:    "timeout of request",
+			param:   &models.ExecuteParam{Database: "test", Timeout: "10s"},
+			timeout: 10 * time.Second,
+		},
+		{
+			name:  "default timeout of database",
+			param: &models.ExecuteParam{Database: "test"},
+			prepare: func() {
+				stateMgr.EXPECT().GetDatabaseCfg("test").Return(models.Database{
+					Option: &option.DatabaseOption{QueryTimeout: "20s"},
+				}, true)
+			},
+			timeout: 20 * time.Second,
+		},
+		{
+			name:  "minimum default timeout of databases",
+			param: &models.ExecuteParam{Databases: "test1,test2,test3"},
+			prepare: func() {
+				stateMgr.EXPECT().GetDatabaseCfg("test1").Return(models.Database{
+					Option: &option.DatabaseOption{QueryTimeout: "20s"},
+				}, true)
+				stateMgr.EXPECT().GetDatabaseCfg("test2").Return(models.Database{
+					Option: &option.DatabaseOption{QueryTimeout: "10s"},
+				}, true)
+				stateMgr.EXPECT().GetDatabaseCfg("test3").Return(models.Database{}, false)
+			},
+			timeout: 10 * time.Second,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			ctx, cancel, err := withQueryTimeout(context.TODO(), tt.param, &SearchMgr{Choose: stateMgr})
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			defer cancel()
+			assert.NoError(t, err)
+			deadline, ok := ctx.Deadline()
+			assert.Equal(t, tt.timeout > 0, ok)
+			if ok {
+				assert.True(t, time.Until(deadline) <= tt.timeout)
+			}
+		})
+	}
+}
+
+func TestReplicaPolicyOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	cases := []struct {
+		name         string
+		param        *models.ExecuteParam
+		choose       flow.NodeChoose
+		prepare      func()
+		policy       option.ReplicaPolicy
+		hedgeTimeout time.Duration
+	}{
+		{
+			name:   "not state manager",
+			param:  &models.ExecuteParam{},
+			choose: flow.NewMockNodeChoose(ctrl),
+			policy: option.ReplicaPolicyFailover,
+		},
+		{
+			name:  "database not found",
+			param: &models.ExecuteParam{},
+			prepare: func() {
+				stateMgr.EXPECT().GetDatabaseCfg("test").Return(models.Database{}, false)
+			},
+			policy: option.ReplicaPolicyFailover,
+		},
+		{
+			name:  "replica policy of database",
+			param: &models.ExecuteParam{},
+			prepare: func() {
+				stateMgr.EXPECT().GetDatabaseCfg("test").Return(models.Database{
+					Option: &option.DatabaseOption{ReplicaPolicy: option.ReplicaPolicyHedged, HedgeTimeout: "100ms"},
+				}, true)
+			},
+			policy:       option.ReplicaPolicyHedged,
+			hedgeTimeout: 100 * time.Millisecond,
+		},
+		{
+			name:  "replica policy of request",
+			param: &models.ExecuteParam{ReplicaPolicy: "leader-only"},
+			prepare: func() {
+				stateMgr.EXPECT().GetDatabaseCfg("test").Return(models.Database{
+					Option: &option.DatabaseOption{ReplicaPolicy: option.ReplicaPolicyHedged},
+				}, true)
+			},
+			policy: option.ReplicaPolicyLeaderOnly,
+		},
+		{
+			name:  "leader read consistency",
+			param: &models.ExecuteParam{ReplicaPolicy: "hedged", ReadConsistency: "leader"},
+			prepare: func() {
+				stateMgr.EXPECT().GetDatabaseCfg("test").Return(models.Database{
+					Option: &option.DatabaseOption{ReplicaPolicy: option.ReplicaPolicyHedged},
+				}, true)
+			},
+			policy: option.ReplicaPolicyLeaderOnly,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			var choose flow.NodeChoose = stateMgr
+			if tt.choose != nil {
+				choose = tt.choose
+			}
+			policy, hedgeTimeout := replicaPolicyOf(tt.param, "test", &SearchMgr{Choose: choose})
+			assert.Equal(t, tt.policy, policy)
+			assert.Equal(t, tt.hedgeTimeout, hedgeTimeout)
+		})
+	}
+}
+
+func TestNumOfSeries(t *testing.T) {
+	assert.Equal(t, 0, numOfSeries(nil))
+	assert.Equal(t, 1, numOfSeries(&models.ResultSet{Series: []*models.Series{{}}}))
+	assert.Equal(t, 2, numOfSeries(&models.SuggestResult{Values: []string{"a", "b"}}))
 }
 
 func TestBuildMetadataResultSet(t *testing.T) {
-	rs, err := buildMetadataResultSet(&stmt.MetricMetadata{Type: stmt.Field}, []string{"avc"})
+	rs, err := buildMetadataResultSet(&stmt.MetricMetadata{Type: stmt.Field}, &models.SuggestResult{Values: []string{"avc"}})
 	assert.Error(t, err)
 	assert.Nil(t, rs)
 
 	rs, err = buildMetadataResultSet(
 		&stmt.MetricMetadata{Type: stmt.Field},
-		[]string{
+		&models.SuggestResult{Values: []string{
 			string(encoding.JSONMarshal(
 				&field.Metas{{Name: "f", Type: field.FirstField}, {Name: "1", Type: field.HistogramField}},
 			)),
-		},
+		}},
 	)
 	assert.NoError(t, err)
 	assert.NotNil(t, rs)
+
+	cases := []struct {
+		name      string
+		limit     int
+		rs        *models.SuggestResult
+		values    []string
+		truncated bool
+	}{
+		{
+			name:   "dedupe values",
+			limit:  3,
+			rs:     &models.SuggestResult{Values: []string{"b", "a", "b"}},
+			values: []string{"a", "b"},
+		},
+		{
+			name:      "truncated by node",
+			limit:     3,
+			rs:        &models.SuggestResult{Values: []string{"b", "a"}, Truncated: true},
+			values:    []string{"a", "b"},
+			truncated: true,
+		},
+		{
+			name:      "truncated by limit",
+			limit:     2,
+			rs:        &models.SuggestResult{Values: []string{"c", "b", "a", "b"}},
+			values:    []string{"a", "b"},
+			truncated: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rs, err := buildMetadataResultSet(&stmt.MetricMetadata{Type: stmt.TagValue, Limit: tt.limit}, tt.rs)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.values, rs.Values)
+			assert.Equal(t, tt.truncated, rs.Truncated)
+		})
+	}
+
+	rs, err = buildMetadataResultSet(&stmt.MetricMetadata{Type: stmt.Metric, Limit: 2},
+		&models.SuggestResult{Values: []string{"a", "b"}, Truncated: true, Cursor: "next"})
+	assert.NoError(t, err)
+	assert.Equal(t, "next", rs.Cursor)
+}
+
+func TestMetricDataSearch_ResultCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	cache := NewResultCache(time.Minute, 1024, linmetric.BrokerRegistry)
+	mgr := &SearchMgr{Choose: stateMgr, ResultCache: cache}
+	now := timeutil.Now()
+	param := &models.ExecuteParam{Database: "test"}
+	statement := &stmt.Query{
+		MetricName: "cpu",
+		TimeRange:  timeutil.TimeRange{Start: now - 3*timeutil.OneDay, End: now - 2*timeutil.OneDay},
+	}
+	cfg := models.Database{
+		Option: &option.DatabaseOption{
+			Intervals: option.Intervals{{Interval: timeutil.Interval(10 * timeutil.OneSecond)}},
+			Behind:    "1d",
+		},
+	}
+
+	// database not found
+	stateMgr.EXPECT().GetDatabaseCfg("test").Return(models.Database{}, false)
+	_, ok := resultCacheKeyOf(param, statement, mgr)
+	assert.False(t, ok)
+	// cache disabled
+	_, ok = resultCacheKeyOf(param, statement, &SearchMgr{Choose: stateMgr})
+	assert.False(t, ok)
+
+	stateMgr.EXPECT().GetDatabaseCfg("test").Return(cfg, true).AnyTimes()
+	key, ok := resultCacheKeyOf(param, statement, mgr)
+	assert.True(t, ok)
+	emptyPayload, _ := (&protoCommonV1.TimeSeriesList{}).Marshal()
+	cache.Put(key, [][]byte{emptyPayload})
+	// hit cache, replay cached blocks, no data scanned
+	meter := metering.NewMockMeter(ctrl)
+	meter.EXPECT().RecordQuery("test", "", int64(0))
+	mgr.Meter = meter
+	stateMgr.EXPECT().GetFieldAlias("test").Return(nil, false)
+	stateMgr.EXPECT().GetDatabaseSchema("test").Return(nil, false)
+	rs, err := metricDataSearch(context.TODO(), param, statement, mgr)
+	assert.NoError(t, err)
+	assert.NotNil(t, rs)
+}
+
+func TestMetricDataSearch_UnknownNames(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newExecutePipelineFn = NewExecutePipeline
+		ctrl.Finish()
+	}()
+
+	names := []models.UnknownName{{Type: models.UnknownField, Name: "usge", Suggestions: []string{"usage"}}}
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().GetFieldAlias(gomock.Any()).Return(nil, false).AnyTimes()
+	stateMgr.EXPECT().GetDatabaseSchema(gomock.Any()).Return(nil, false).AnyTimes()
+	databaseOption := &option.DatabaseOption{}
+	stateMgr.EXPECT().GetDatabaseCfg(gomock.Any()).DoAndReturn(func(_ string) (models.Database, bool) {
+		return models.Database{Option: databaseOption}, true
+	}).AnyTimes()
+	taskMgr := NewMockTaskManager(ctrl)
+	taskMgr.EXPECT().AddTask(gomock.Any(), gomock.Any()).AnyTimes()
+	taskMgr.EXPECT().RemoveTask(gomock.Any()).AnyTimes()
+	mgr := &SearchMgr{Choose: stateMgr, TaskMgr: taskMgr, CurNode: models.StatelessNode{HostIP: "1.1.1.1"}}
+	completeWith := func(err error) {
+		newExecutePipelineFn = func(_ *trackerpkg.StageTracker, completeCallback func(err error)) Pipeline {
+			pipeline := NewMockPipeline(ctrl)
+			pipeline.EXPECT().Execute(gomock.Any()).Do(func(_ any) {
+				completeCallback(err)
+			})
+			return pipeline
+		}
+	}
+	search := func(param *models.ExecuteParam) (any, error) {
+		return metricDataSearch(context.TODO(), param, &stmt.Query{MetricName: "cpu"}, mgr)
+	}
+
+	cases := []struct {
+		name   string
+		param  *models.ExecuteParam
+		option *option.DatabaseOption
+		err    error
+		assert func(rs any, err error)
+	}{
+		{
+			name:   "lenient by default",
+			param:  &models.ExecuteParam{Database: "db"},
+			option: &option.DatabaseOption{},
+			err:    models.NewUnknownNameError(names),
+			assert: func(rs any, err error) {
+				assert.NoError(t, err)
+				resultSet := rs.(*models.ResultSet)
+				assert.Equal(t, "cpu", resultSet.MetricName)
+				assert.Empty(t, resultSet.Series)
+				assert.Equal(t, []string{"field usge(did you mean: usage)"}, resultSet.Stats.UnknownNames)
+			},
+		},
+		{
+			name:   "strict of database",
+			param:  &models.ExecuteParam{Database: "db"},
+			option: &option.DatabaseOption{QueryStrictness: option.QueryStrictnessStrict},
+			err:    models.NewUnknownNameError(names),
+			assert: func(rs any, err error) {
+				assert.Nil(t, rs)
+				assert.ErrorIs(t, err, constants.ErrUnknownName)
+				assert.ErrorContains(t, err, "field usge(did you mean: usage)")
+				e := errcode.Of(err)
+				assert.Equal(t, errcode.NotFound, e.Code)
+				assert.Equal(t, names, e.Details)
+			},
+		},
+		{
+			name:   "request param overrides strictness of database",
+			param:  &models.ExecuteParam{Database: "db", Strictness: "lenient"},
+			option: &option.DatabaseOption{QueryStrictness: option.QueryStrictnessStrict},
+			err:    models.NewUnknownNameError(names),
+			assert: func(rs any, err error) {
+				assert.NoError(t, err)
+				assert.Len(t, rs.(*models.ResultSet).Stats.UnknownNames, 1)
+			},
+		},
+		{
+			name:   "other error",
+			param:  &models.ExecuteParam{Database: "db"},
+			option: &option.DatabaseOption{},
+			err:    constants.ErrMetricIDNotFound,
+			assert: func(rs any, err error) {
+				assert.Nil(t, rs)
+				assert.ErrorIs(t, err, constants.ErrMetricIDNotFound)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			databaseOption = tt.option
+			completeWith(tt.err)
+			tt.assert(search(tt.param))
+		})
+	}
+}
+
+func TestQueryStrictnessOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert.Equal(t, option.QueryStrictnessLenient, queryStrictnessOf(&models.ExecuteParam{}, "db", &SearchMgr{}))
+	assert.Equal(t, option.QueryStrictnessStrict,
+		queryStrictnessOf(&models.ExecuteParam{Strictness: "strict"}, "db", &SearchMgr{}))
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	mgr := &SearchMgr{Choose: stateMgr}
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{}, false)
+	assert.Equal(t, option.QueryStrictnessLenient, queryStrictnessOf(&models.ExecuteParam{}, "db", mgr))
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{
+		Option: &option.DatabaseOption{QueryStrictness: option.QueryStrictnessStrict},
+	}, true)
+	assert.Equal(t, option.QueryStrictnessStrict, queryStrictnessOf(&models.ExecuteParam{}, "db", mgr))
+}
+
+func TestExpiredFieldsOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := timeutil.Now()
+	statement := parseQuery(t, "select idle,usage,load from cpu")
+	statement.TimeRange = timeutil.TimeRange{Start: now - 10*timeutil.OneDay, End: now}
+	assert.Nil(t, expiredFieldsOf("db", statement, &SearchMgr{}))
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	mgr := &SearchMgr{Choose: stateMgr}
+	stateMgr.EXPECT().GetDatabaseSchema("db").Return(nil, false)
+	assert.Nil(t, expiredFieldsOf("db", statement, mgr))
+
+	stateMgr.EXPECT().GetDatabaseSchema("db").Return(&models.DatabaseSchema{
+		Database: "db",
+		Metrics: []models.MetricSchema{{Name: "cpu", Fields: []models.FieldSchema{
+			{Name: "idle", Type: "gauge", Retention: "7d"},
+			{Name: "usage", Type: "gauge", Retention: "30d"},
+			{Name: "load", Type: "gauge", Retention: "abc"},
+		}}},
+	}, true).AnyTimes()
+	expiredFields := expiredFieldsOf("db", statement, mgr)
+	assert.Len(t, expiredFields, 1)
+	assert.Contains(t, expiredFields[0], "idle(expired before ")
+
+	// query time range in retention
+	statement.TimeRange.Start = now - timeutil.OneDay
+	assert.Nil(t, expiredFieldsOf("db", statement, mgr))
+}
+
+func TestCheckDatabaseDeleted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert.NoError(t, checkDatabaseDeleted("db", &SearchMgr{}))
+	stateMgr := broker.NewMockStateManager(ctrl)
+	mgr := &SearchMgr{Choose: stateMgr}
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{}, false)
+	assert.NoError(t, checkDatabaseDeleted("db", mgr))
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{}, true)
+	assert.NoError(t, checkDatabaseDeleted("db", mgr))
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{
+		Deletion: &models.DatabaseDeletion{DeletedAt: 1, PurgeAt: 2},
+	}, true).AnyTimes()
+	assert.ErrorIs(t, checkDatabaseDeleted("db", mgr), constants.ErrDatabaseDeleted)
+	// metadata query of dropped database is rejected
+	_, err := MetricMetadataSearch(context.TODO(), &models.ExecuteParam{Database: "db"},
+		&stmt.MetricMetadata{Type: stmt.Metric}, mgr)
+	assert.ErrorIs(t, err, constants.ErrDatabaseDeleted)
+}
+
+func TestClampTimeRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := timeutil.Now()
+	param := &models.ExecuteParam{Database: "db"}
+	newStatement := func(start int64) *stmt.Query {
+		return &stmt.Query{MetricName: "cpu", TimeRange: timeutil.TimeRange{Start: start, End: now}}
+	}
+	rangeClipped, outOfRetention := clampTimeRange(param, newStatement(now-90*timeutil.OneDay), &SearchMgr{})
+	assert.Empty(t, rangeClipped)
+	assert.False(t, outOfRetention)
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	mgr := &SearchMgr{Choose: stateMgr}
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{}, false)
+	rangeClipped, outOfRetention = clampTimeRange(param, newStatement(now-90*timeutil.OneDay), mgr)
+	assert.Empty(t, rangeClipped)
+	assert.False(t, outOfRetention)
+
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{
+		Option: &option.DatabaseOption{
+			Intervals: option.Intervals{{
+				Interval:  timeutil.Interval(10 * timeutil.OneSecond),
+				Retention: timeutil.Interval(30 * timeutil.OneDay),
+			}},
+		},
+	}, true).AnyTimes()
+	// in retention
+	statement := newStatement(now - timeutil.OneDay)
+	rangeClipped, outOfRetention = clampTimeRange(param, statement, mgr)
+	assert.Empty(t, rangeClipped)
+	assert.False(t, outOfRetention)
+	assert.Equal(t, now-timeutil.OneDay, statement.TimeRange.Start)
+	// clamped
+	statement = newStatement(now - 90*timeutil.OneDay)
+	rangeClipped, outOfRetention = clampTimeRange(param, statement, mgr)
+	assert.Contains(t, rangeClipped, "range clipped from ")
+	assert.False(t, outOfRetention)
+	assert.True(t, statement.TimeRange.Start >= now-30*timeutil.OneDay)
+	// clamping disabled
+	noClamp := false
+	statement = newStatement(now - 90*timeutil.OneDay)
+	rangeClipped, outOfRetention = clampTimeRange(&models.ExecuteParam{Database: "db", ClampRetention: &noClamp}, statement, mgr)
+	assert.Empty(t, rangeClipped)
+	assert.False(t, outOfRetention)
+	assert.Equal(t, now-90*timeutil.OneDay, statement.TimeRange.Start)
+	// whole time range out of retention
+	statement = &stmt.Query{
+		MetricName: "cpu",
+		TimeRange:  timeutil.TimeRange{Start: now - 90*timeutil.OneDay, End: now - 60*timeutil.OneDay},
+	}
+	_, outOfRetention = clampTimeRange(&models.ExecuteParam{Database: "db", ClampRetention: &noClamp}, statement, mgr)
+	assert.True(t, outOfRetention)
+	assert.Equal(t, now-90*timeutil.OneDay, statement.TimeRange.Start)
+
+	// no leaf task created, returns empty result with clipped note
+	stateMgr.EXPECT().GetDatabaseSchema("db").Return(nil, false)
+	rs, err := metricDataSearch(context.TODO(), param, statement, mgr)
+	assert.NoError(t, err)
+	resultSet := rs.(*models.ResultSet)
+	assert.Empty(t, resultSet.Series)
+	assert.Equal(t, "cpu", resultSet.MetricName)
+	assert.Contains(t, resultSet.Stats.RangeClipped, "range clipped from ")
+}
+
+func TestWriteSemanticsOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	assert.Equal(t, option.WriteSemanticsAggregate, writeSemanticsOf("db", &SearchMgr{}))
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	mgr := &SearchMgr{Choose: stateMgr}
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{}, false)
+	assert.Equal(t, option.WriteSemanticsAggregate, writeSemanticsOf("db", mgr))
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{
+		Option: &option.DatabaseOption{WriteSemantics: option.WriteSemanticsLastWriteWins},
+	}, true)
+	assert.Equal(t, option.WriteSemanticsLastWriteWins, writeSemanticsOf("db", mgr))
+}
+
+func Test_memoryBudgetOf(t *testing.T) {
+	mgr := &SearchMgr{Memory: config.QueryMemory{Budget: 1024, EnableSpill: true, SpillDir: "spill"}}
+	budget, spillDir, err := memoryBudgetOf(&models.ExecuteParam{}, mgr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), budget)
+	assert.Equal(t, "spill", spillDir)
+	budget, _, err = memoryBudgetOf(&models.ExecuteParam{MemoryBudget: "2KiB"}, mgr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2048), budget)
+	_, _, err = memoryBudgetOf(&models.ExecuteParam{MemoryBudget: "0"}, mgr)
+	assert.Error(t, err)
+	// spilling disabled
+	mgr.Memory.EnableSpill = false
+	budget, spillDir, err = memoryBudgetOf(&models.ExecuteParam{}, mgr)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1024), budget)
+	assert.Empty(t, spillDir)
+	// no budget
+	budget, _, err = memoryBudgetOf(&models.ExecuteParam{}, &SearchMgr{})
+	assert.NoError(t, err)
+	assert.Zero(t, budget)
 }
@@ -0,0 +1,93 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+)
+
+// SlowQueryLog represents the slow query log which keeps the latest slow queries in memory.
+type SlowQueryLog interface {
+	// Record records the query if its cost exceeds the slow query threshold.
+	Record(query *models.SlowQuery)
+	// GetSlowQueries returns the recorded slow queries, the latest first.
+	GetSlowQueries() []*models.SlowQuery
+}
+
+// slowQueryLog implements SlowQueryLog interface, stores slow queries in a ring buffer.
+type slowQueryLog struct {
+	threshold time.Duration
+	queries   []*models.SlowQuery
+	pos       int
+
+	statistics *metrics.SlowQueryStatistics
+	mutex      sync.RWMutex
+}
+
+// NewSlowQueryLog creates a SlowQueryLog instance which keeps at most size slow queries,
+// threshold <= 0 means slow query log disabled.
+func NewSlowQueryLog(threshold time.Duration, size int, registry *linmetric.Registry) SlowQueryLog {
+	if size <= 0 {
+		size = 1
+	}
+	return &slowQueryLog{
+		threshold:  threshold,
+		queries:    make([]*models.SlowQuery, 0, size),
+		statistics: metrics.NewSlowQueryStatistics(registry),
+	}
+}
+
+// Record records the query if its cost exceeds the slow query threshold,
+// the query killed by admin is always recorded.
+func (l *slowQueryLog) Record(query *models.SlowQuery) {
+	if query == nil || l.threshold <= 0 || (!query.Killed && time.Duration(query.Cost) < l.threshold) {
+		return
+	}
+	l.statistics.SlowQueries.Incr()
+	l.statistics.Duration.UpdateDuration(time.Duration(query.Cost))
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if len(l.queries) < cap(l.queries) {
+		l.queries = append(l.queries, query)
+		return
+	}
+	// overwrite the oldest slow query
+	l.queries[l.pos] = query
+	l.pos = (l.pos + 1) % len(l.queries)
+}
+
+// GetSlowQueries returns the recorded slow queries, the latest first.
+func (l *slowQueryLog) GetSlowQueries() []*models.SlowQuery {
+	l.mutex.RLock()
+	rs := make([]*models.SlowQuery, len(l.queries))
+	copy(rs, l.queries)
+	l.mutex.RUnlock()
+
+	sort.SliceStable(rs, func(i, j int) bool {
+		return rs[i].Start > rs[j].Start
+	})
+	return rs
+}
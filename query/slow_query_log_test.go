@@ -0,0 +1,62 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+)
+
+func TestSlowQueryLog_Record(t *testing.T) {
+	// disabled
+	log := NewSlowQueryLog(0, 2, linmetric.BrokerRegistry)
+	log.Record(&models.SlowQuery{Cost: int64(time.Minute)})
+	assert.Empty(t, log.GetSlowQueries())
+
+	log = NewSlowQueryLog(time.Second, 2, linmetric.BrokerRegistry)
+	log.Record(nil)
+	// fast query
+	log.Record(&models.SlowQuery{SQL: "fast", Cost: int64(time.Millisecond)})
+	assert.Empty(t, log.GetSlowQueries())
+	// killed query
+	log.Record(&models.SlowQuery{SQL: "killed", Cost: int64(time.Millisecond), Killed: true})
+	assert.Len(t, log.GetSlowQueries(), 1)
+
+	log.Record(&models.SlowQuery{SQL: "1", Start: 1, Cost: int64(time.Second)})
+	log.Record(&models.SlowQuery{SQL: "2", Start: 2, Cost: int64(time.Minute)})
+	log.Record(&models.SlowQuery{SQL: "3", Start: 3, Cost: int64(time.Minute)})
+	log.Record(&models.SlowQuery{SQL: "4", Start: 4, Cost: int64(time.Minute)})
+	// keep latest slow queries
+	rs := log.GetSlowQueries()
+	assert.Len(t, rs, 2)
+	assert.Equal(t, "4", rs[0].SQL)
+	assert.Equal(t, "3", rs[1].SQL)
+
+	// invalid size
+	log = NewSlowQueryLog(time.Second, 0, linmetric.BrokerRegistry)
+	log.Record(&models.SlowQuery{SQL: "1", Start: 1, Cost: int64(time.Second)})
+	log.Record(&models.SlowQuery{SQL: "2", Start: 2, Cost: int64(time.Second)})
+	rs = log.GetSlowQueries()
+	assert.Len(t, rs, 1)
+	assert.Equal(t, "2", rs[0].SQL)
+}
@@ -30,8 +30,9 @@ import (
 type baseStage struct {
 	ctx context.Context
 
-	stageType Type
-	execPool  concurrent.Pool
+	stageType    Type
+	execPool     concurrent.Pool
+	collectStats bool // collect the stats of operators
 
 	operators []*models.OperatorStats
 }
@@ -75,12 +76,25 @@ func (stage *baseStage) execute(node PlanNode) (err error) {
 	if node == nil {
 		return nil
 	}
+	// check query deadline before executing each plan node
+	if stage.ctx != nil {
+		if err := stage.ctx.Err(); err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				return constants.ErrTimeout
+			}
+			return err
+		}
+	}
 
-	var stats *models.OperatorStats
 	// execute current plan node logic
-	stats, err = node.ExecuteWithStats()
-	if stats != nil {
-		stage.operators = append(stage.operators, stats)
+	if stage.collectStats {
+		var stats *models.OperatorStats
+		stats, err = node.ExecuteWithStats()
+		if stats != nil {
+			stage.operators = append(stage.operators, stats)
+		}
+	} else {
+		err = node.Execute()
 	}
 	if err != nil {
 		if node.IgnoreNotFound() && errors.Is(err, constants.ErrNotFound) {
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -48,9 +49,10 @@ func TestBaseStage_Execute(t *testing.T) {
 
 	pool := &mockPool{}
 	s := &baseStage{
-		ctx:       context.TODO(),
-		stageType: Grouping,
-		execPool:  pool,
+		ctx:          context.TODO(),
+		stageType:    Grouping,
+		execPool:     pool,
+		collectStats: true,
 	}
 	s.Complete()
 	assert.Equal(t, Grouping, s.Type())
@@ -70,6 +72,31 @@ func TestBaseStage_Execute(t *testing.T) {
 				assert.True(t, true)
 			},
 		},
+		{
+			name: "query timeout",
+			plan: NewMockPlanNode(ctrl),
+			prepare: func(_ *MockPlanNode) {
+				ctx, cancel := context.WithTimeout(context.TODO(), time.Nanosecond)
+				defer cancel()
+				<-ctx.Done()
+				s.ctx = ctx
+			},
+			errHandler: func(err error) {
+				assert.Equal(t, constants.ErrTimeout, err)
+			},
+		},
+		{
+			name: "query canceled",
+			plan: NewMockPlanNode(ctrl),
+			prepare: func(_ *MockPlanNode) {
+				ctx, cancel := context.WithCancel(context.TODO())
+				cancel()
+				s.ctx = ctx
+			},
+			errHandler: func(err error) {
+				assert.Equal(t, context.Canceled, err)
+			},
+		},
 		{
 			name: "execute plan failure",
 			plan: NewMockPlanNode(ctrl),
@@ -154,9 +181,10 @@ func TestBaseStage_Track(t *testing.T) {
 
 	pool := &mockPool{}
 	s := &baseStage{
-		ctx:       context.TODO(),
-		stageType: Grouping,
-		execPool:  pool,
+		ctx:          context.TODO(),
+		stageType:    Grouping,
+		execPool:     pool,
+		collectStats: true,
 	}
 
 	p := NewMockPlanNode(ctrl)
@@ -167,4 +195,17 @@ func TestBaseStage_Track(t *testing.T) {
 	})
 	assert.NotNil(t, s.Stats())
 	assert.True(t, s.IsAsync())
+
+	// not collect stats
+	s = &baseStage{
+		ctx:       context.TODO(),
+		stageType: Grouping,
+		execPool:  pool,
+	}
+	p.EXPECT().Execute().Return(nil)
+	p.EXPECT().Children().Return(nil)
+	s.Execute(p, func() {
+	}, func(err error) {
+	})
+	assert.Nil(t, s.Stats())
 }
@@ -40,9 +40,10 @@ func NewDataLoadStage(leafExecuteCtx *context.LeafExecuteContext,
 ) Stage {
 	return &dataLoadStage{
 		baseStage: baseStage{
-			ctx:       leafExecuteCtx.TaskCtx.Ctx,
-			execPool:  leafExecuteCtx.Database.ExecutorPool().Scanner,
-			stageType: DataLoad,
+			ctx:          leafExecuteCtx.TaskCtx.Ctx,
+			execPool:     leafExecuteCtx.Database.ExecutorPool().Scanner,
+			stageType:    DataLoad,
+			collectStats: leafExecuteCtx.StorageExecuteCtx.CollectStats,
 		},
 		leafExecuteCtx: leafExecuteCtx,
 		executeCtx:     executeCtx,
@@ -42,8 +42,9 @@ func TestDataLoadStage_Plan(t *testing.T) {
 	now := timeutil.Now()
 	stage := NewDataLoadStage(
 		&context.LeafExecuteContext{
-			TaskCtx:  &flow.TaskContext{},
-			Database: db,
+			TaskCtx:           &flow.TaskContext{},
+			Database:          db,
+			StorageExecuteCtx: &flow.StorageExecuteContext{},
 		},
 		&flow.DataLoadContext{
 			ShardExecuteCtx: &flow.ShardExecuteContext{
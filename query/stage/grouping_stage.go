@@ -39,9 +39,10 @@ func NewGroupingStage(leafExecuteCtx *context.LeafExecuteContext, executeCtx *fl
 	leafExecuteCtx.GroupingCtx.ForkGroupingTask()
 	return &groupingStage{
 		baseStage: baseStage{
-			ctx:       leafExecuteCtx.TaskCtx.Ctx,
-			execPool:  leafExecuteCtx.Database.ExecutorPool().Grouping,
-			stageType: Grouping,
+			ctx:          leafExecuteCtx.TaskCtx.Ctx,
+			execPool:     leafExecuteCtx.Database.ExecutorPool().Grouping,
+			stageType:    Grouping,
+			collectStats: leafExecuteCtx.StorageExecuteCtx.CollectStats,
 		},
 		leafExecuteCtx: leafExecuteCtx,
 		executeCtx:     executeCtx,
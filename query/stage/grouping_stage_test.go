@@ -40,8 +40,9 @@ func TestGroupingStage(t *testing.T) {
 	dataLoadCtx := &flow.DataLoadContext{}
 	shard := tsdb.NewMockShard(ctrl)
 	stage := NewGroupingStage(&context.LeafExecuteContext{
-		TaskCtx:  &flow.TaskContext{},
-		Database: db,
+		TaskCtx:           &flow.TaskContext{},
+		Database:          db,
+		StorageExecuteCtx: &flow.StorageExecuteContext{},
 		GroupingCtx: context.NewLeafGroupingContext(&context.LeafExecuteContext{
 			StorageExecuteCtx: &flow.StorageExecuteContext{Query: &stmtpkg.Query{}},
 			Database:          db,
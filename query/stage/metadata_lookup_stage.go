@@ -33,7 +33,8 @@ type metadataLookupStage struct {
 func NewMetadataLookupStage(leafExecuteCtx *context.LeafExecuteContext) Stage {
 	return &metadataLookupStage{
 		baseStage: baseStage{
-			stageType: MetadataLookup,
+			stageType:    MetadataLookup,
+			collectStats: leafExecuteCtx.StorageExecuteCtx.CollectStats,
 		},
 		leafExecuteCtx: leafExecuteCtx,
 	}
@@ -35,7 +35,8 @@ type metadataSuggestStage struct {
 func NewMetadataSuggestStage(ctx *context.LeafMetadataContext) Stage {
 	return &metadataSuggestStage{
 		baseStage: baseStage{
-			stageType: MetadataSuggest,
+			stageType:    MetadataSuggest,
+			collectStats: true,
 		},
 		ctx: ctx,
 	}
@@ -64,8 +65,8 @@ func (stage *metadataSuggestStage) Plan() PlanNode {
 			},
 			TagKeys: make(map[string]tag.KeyID),
 		}
-		if req.Condition == nil {
-			// if not tag filter condition, just get tag value by tag key
+		if isTagValueFilter(req) {
+			// if not tag filter condition or only filter suggested tag key, just get tag value from tag index
 			execPlan.AddChild(NewPlanNode(operator.NewTagValueSuggest(stage.ctx)))
 		} else {
 			// 1. do tag values lookup
@@ -76,6 +77,16 @@ func (stage *metadataSuggestStage) Plan() PlanNode {
 	return nil
 }
 
+// isTagValueFilter checks if the condition of tag value suggest is empty or a tag filter of suggested tag key,
+// if true, tag values can be found from tag index without scanning series.
+func isTagValueFilter(req *stmt.MetricMetadata) bool {
+	if req.Condition == nil {
+		return true
+	}
+	tagFilter, ok := req.Condition.(stmt.TagFilter)
+	return ok && tagFilter.TagKey() == req.TagKey
+}
+
 // NextStages returns the next stages.
 func (stage *metadataSuggestStage) NextStages() (stages []Stage) {
 	req := stage.ctx.Request
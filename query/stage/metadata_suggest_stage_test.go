@@ -69,7 +69,16 @@ func TestMetadataSuggestStage_Plan(t *testing.T) {
 			name: "tag value suggest with condition",
 			in: &stmtpkg.MetricMetadata{
 				Type:      stmtpkg.TagValue,
-				Condition: &stmtpkg.EqualsExpr{},
+				TagKey:    "host",
+				Condition: &stmtpkg.EqualsExpr{Key: "region"},
+			},
+		},
+		{
+			name: "tag value suggest with tag filter of suggested tag key",
+			in: &stmtpkg.MetricMetadata{
+				Type:      stmtpkg.TagValue,
+				TagKey:    "host",
+				Condition: &stmtpkg.RegexExpr{Key: "host", Regexp: "web-1.*"},
 			},
 		},
 	}
@@ -93,6 +102,26 @@ func TestMetadataSuggestStage_Plan(t *testing.T) {
 	})
 }
 
+func TestIsTagValueFilter(t *testing.T) {
+	assert.True(t, isTagValueFilter(&stmtpkg.MetricMetadata{TagKey: "host"}))
+	assert.True(t, isTagValueFilter(&stmtpkg.MetricMetadata{
+		TagKey:    "host",
+		Condition: &stmtpkg.LikeExpr{Key: "host", Value: "web*"},
+	}))
+	assert.False(t, isTagValueFilter(&stmtpkg.MetricMetadata{
+		TagKey:    "host",
+		Condition: &stmtpkg.LikeExpr{Key: "region", Value: "sh*"},
+	}))
+	assert.False(t, isTagValueFilter(&stmtpkg.MetricMetadata{
+		TagKey: "host",
+		Condition: &stmtpkg.BinaryExpr{
+			Left:     &stmtpkg.EqualsExpr{Key: "host", Value: "a"},
+			Operator: stmtpkg.AND,
+			Right:    &stmtpkg.EqualsExpr{Key: "region", Value: "b"},
+		},
+	}))
+}
+
 func TestMetadataSuggestStage_NextStages(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -31,7 +31,8 @@ type physicalPlanStage struct {
 func NewPhysicalPlanStage(taskCtx context.TaskContext) Stage {
 	return &physicalPlanStage{
 		baseStage: baseStage{
-			stageType: PhysicalPlan,
+			stageType:    PhysicalPlan,
+			collectStats: true,
 		},
 		taskCtx: taskCtx,
 	}
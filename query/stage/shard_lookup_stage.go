@@ -38,7 +38,8 @@ type shardLookupStage struct {
 func NewShardLookupStage(executeCtx *context.LeafMetadataContext, shardExecuteCtx *flow.ShardExecuteContext, shard tsdb.Shard) Stage {
 	return &shardLookupStage{
 		baseStage: baseStage{
-			stageType: ShardLookup,
+			stageType:    ShardLookup,
+			collectStats: true,
 		},
 		executeCtx:      executeCtx,
 		shardExecuteCtx: shardExecuteCtx,
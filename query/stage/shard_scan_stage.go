@@ -19,9 +19,11 @@ package stage
 
 import (
 	"fmt"
+	"strings"
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/aggregation"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/query/context"
 	"github.com/lindb/lindb/query/operator"
@@ -45,9 +47,10 @@ func NewShardScanStage(leafExecuteCtx *context.LeafExecuteContext,
 	leafExecuteCtx.GroupingCtx.ForkGroupingTask()
 	return &shardScanStage{
 		baseStage: baseStage{
-			ctx:       leafExecuteCtx.TaskCtx.Ctx,
-			execPool:  leafExecuteCtx.Database.ExecutorPool().Filtering,
-			stageType: ShardScan,
+			ctx:          leafExecuteCtx.TaskCtx.Ctx,
+			execPool:     leafExecuteCtx.Database.ExecutorPool().Filtering,
+			stageType:    ShardScan,
+			collectStats: leafExecuteCtx.StorageExecuteCtx.CollectStats,
 		},
 		leafExecuteCtx:  leafExecuteCtx,
 		shardExecuteCtx: shardExecuteCtx,
@@ -66,6 +69,18 @@ func (stage *shardScanStage) Plan() PlanNode {
 		// no data family found
 		return nil
 	}
+	if queryStmt.TrackTimestamp {
+		// max data timestamp in query time range, for detecting staleness by client
+		for _, family := range families {
+			timestamp := family.MaxDataTimestamp()
+			if timestamp > queryStmt.TimeRange.End {
+				timestamp = queryStmt.TimeRange.End
+			}
+			if timestamp > 0 {
+				shardExecuteCtx.StorageExecuteCtx.RecordMaxTimestamp(shard.ShardID(), timestamp)
+			}
+		}
+	}
 	execPlan := NewEmptyPlanNode()
 	if queryStmt.Condition != nil {
 		// add shard level series filtering node
@@ -110,6 +125,7 @@ func (stage *shardScanStage) NextStages() (stages []Stage) {
 			IsMultiField:          len(shardExecuteContext.StorageExecuteCtx.Fields) > 1,
 			IsGrouping:            shardExecuteContext.StorageExecuteCtx.Query.HasGroupBy(),
 			PendingDataLoadTasks:  atomic.NewInt32(0),
+			Counters:              aggregation.NewCounterSeries(),
 		}
 
 		stages = append(stages, NewGroupingStage(stage.leafExecuteCtx, dataLoadCtx, stage.shard))
@@ -122,7 +138,13 @@ func (stage *shardScanStage) Complete() {
 	stage.leafExecuteCtx.GroupingCtx.CompleteGroupingTask()
 }
 
-// Identifier returns identifier value of shard scan stage.
+// Identifier returns identifier value of shard scan stage, all fields of query are loaded by one scan.
 func (stage *shardScanStage) Identifier() string {
-	return fmt.Sprintf("Shard Scan[Shard(%d)]", stage.shard.ShardID())
+	storageCtx := stage.shardExecuteCtx.StorageExecuteCtx
+	fieldNames := make([]string, len(storageCtx.Fields))
+	for idx := range storageCtx.Fields {
+		fieldNames[idx] = storageCtx.Fields[idx].Name.String()
+	}
+	return fmt.Sprintf("Shard Scan[Shard(%d), Interval(%s), Fields(%s)]", stage.shard.ShardID(),
+		storageCtx.Query.StorageInterval, strings.Join(fieldNames, ","))
 }
@@ -29,8 +29,10 @@ import (
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
 	contextpkg "github.com/lindb/lindb/query/context"
 	trackerpkg "github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb"
 	"github.com/lindb/lindb/tsdb/indexdb"
@@ -46,8 +48,9 @@ func TestShardScanStage(t *testing.T) {
 	db.EXPECT().Metadata().Return(meta).AnyTimes()
 	storageCtx := &flow.StorageExecuteContext{
 		Query: &stmt.Query{
-			Condition: &stmt.EqualsExpr{},
-			GroupBy:   []string{"key"},
+			Condition:       &stmt.EqualsExpr{},
+			GroupBy:         []string{"key"},
+			StorageInterval: timeutil.Interval(5 * timeutil.OneMinute),
 		},
 		ShardIDs: []models.ShardID{1, 2},
 	}
@@ -81,11 +84,33 @@ func TestShardScanStage(t *testing.T) {
 			Return([]tsdb.DataFamily{tsdb.NewMockDataFamily(ctrl)})
 		assert.NotNil(t, s.Plan())
 	})
+	t.Run("track max data timestamp", func(t *testing.T) {
+		storageCtx.Query.TrackTimestamp = true
+		storageCtx.Query.TimeRange = timeutil.TimeRange{Start: 10, End: 100}
+		defer func() {
+			storageCtx.Query.TrackTimestamp = false
+		}()
+		family1 := tsdb.NewMockDataFamily(ctrl)
+		family2 := tsdb.NewMockDataFamily(ctrl)
+		family3 := tsdb.NewMockDataFamily(ctrl)
+		family1.EXPECT().MaxDataTimestamp().Return(int64(50))
+		family2.EXPECT().MaxDataTimestamp().Return(int64(0))
+		family3.EXPECT().MaxDataTimestamp().Return(int64(200))
+		shard.EXPECT().ShardID().Return(models.ShardID(1)).Times(2)
+		shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).
+			Return([]tsdb.DataFamily{family1, family2, family3})
+		assert.NotNil(t, s.Plan())
+		// capped by end of query time range
+		assert.Equal(t, map[models.ShardID]int64{1: 100}, storageCtx.ShardMaxTimestamps())
+	})
 
 	shardExecuteCtx.SeriesIDsAfterFiltering = roaring.BitmapOf(1, 2, 3)
 	assert.NotEmpty(t, s.NextStages())
 	s.Complete()
 
 	shard.EXPECT().ShardID().Return(models.ShardID(19))
-	assert.Equal(t, "Shard Scan[Shard(19)]", s.Identifier())
+	assert.Equal(t, "Shard Scan[Shard(19), Interval(5m), Fields()]", s.Identifier())
+	storageCtx.Fields = field.Metas{{Name: "success"}, {Name: "latency"}}
+	shard.EXPECT().ShardID().Return(models.ShardID(19))
+	assert.Equal(t, "Shard Scan[Shard(19), Interval(5m), Fields(success,latency)]", s.Identifier())
 }
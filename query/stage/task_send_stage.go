@@ -37,6 +37,7 @@ func NewTaskSendStage(taskCtx context.TaskContext, target string, req *protoComm
 		baseStage: baseStage{
 			stageType: PhysicalPlan,
 			//TODO: add async pool?
+			collectStats: true,
 		},
 		taskCtx: taskCtx,
 		target:  target,
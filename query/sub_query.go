@@ -0,0 +1,447 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	trackerpkg "github.com/lindb/lindb/query/tracker"
+	"github.com/lindb/lindb/series/tag"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// subQueryPlan represents the plan of query with sub query,
+// like: select max(v) from (select sum(f) as v from cpu group by host,time(1m)) group by time(10m).
+// 1. sub query executes through the pipeline of single metric query, produces the intermediate result set;
+// 2. the series of sub query are grouped by outer group by tag keys, points are bucketed by outer interval;
+// 3. outer aggregation is evaluated for each bucket in broker, storage is not queried again.
+type subQueryPlan struct {
+	statement *stmtpkg.Query
+	buckets   *timeutil.ZoneBuckets
+	operands  map[string]*stmtpkg.CallExpr // operand(aggregation of sub query field) => call expr
+}
+
+// newSubQueryPlan creates the sub query plan, returns nil if query has no sub query.
+func newSubQueryPlan(statement *stmtpkg.Query) (*subQueryPlan, error) {
+	subQuery := statement.SubQuery
+	if subQuery == nil {
+		return nil, nil
+	}
+	switch {
+	case subQuery.SubQuery != nil:
+		return nil, fmt.Errorf("%w, nested sub query is not supported", ErrSubQuery)
+	case len(statement.OrderByItems) > 0:
+		return nil, fmt.Errorf("%w, order by of outer query is not supported", ErrSubQuery)
+	case statement.Fill != stmtpkg.FillDrop:
+		return nil, fmt.Errorf("%w, fill of outer query is not supported", ErrSubQuery)
+	case statement.IntervalHint != stmtpkg.AutoInterval:
+		return nil, fmt.Errorf("%w, using clause must be in sub query", ErrSubQuery)
+	case subQuery.Align != stmtpkg.AlignStart:
+		return nil, fmt.Errorf("%w, align clause must be in outer query", ErrSubQuery)
+	case subQuery.Interval <= 0:
+		return nil, fmt.Errorf("%w, sub query must group by time", ErrSubQuery)
+	case statement.Interval <= 0:
+		return nil, fmt.Errorf("%w, outer query must group by time", ErrSubQuery)
+	case statement.Interval%subQuery.Interval != 0:
+		return nil, fmt.Errorf("%w, outer interval(%s) must be a multiple of sub query interval(%s)",
+			ErrSubQuery, statement.Interval, subQuery.Interval)
+	}
+	// buckets of outer query must align with the buckets of sub query, so both use same time zone
+	switch {
+	case subQuery.TimeZone == "":
+		subQuery.TimeZone = statement.TimeZone
+	case statement.TimeZone == "":
+		statement.TimeZone = subQuery.TimeZone
+	case subQuery.TimeZone != statement.TimeZone:
+		return nil, fmt.Errorf("%w, time zone of outer query(%s) and sub query(%s) not match",
+			ErrSubQuery, statement.TimeZone, subQuery.TimeZone)
+	}
+	groupBy := make(map[string]struct{})
+	for _, tagKey := range subQuery.GroupBy {
+		groupBy[tagKey] = struct{}{}
+	}
+	for _, tagKey := range statement.GroupBy {
+		if _, ok := groupBy[tagKey]; !ok {
+			return nil, fmt.Errorf("%w, group by tag key(%s) of outer query not in group by of sub query", ErrSubQuery, tagKey)
+		}
+	}
+	zone := statement.TimeZone
+	if zone == "" {
+		zone = time.UTC.String()
+	}
+	buckets, err := timeutil.NewZoneBuckets(zone, statement.Interval)
+	if err != nil {
+		return nil, err
+	}
+	p := &subQueryPlan{
+		statement: statement,
+		buckets:   buckets,
+		operands:  make(map[string]*stmtpkg.CallExpr),
+	}
+	fields := make(map[string]struct{})
+	for _, item := range subQuery.SelectItems {
+		fields[SelectItemName(item)] = struct{}{}
+	}
+	for _, item := range statement.SelectItems {
+		if err := p.planExpr(item, fields); err != nil {
+			return nil, err
+		}
+	}
+	if len(subQuery.OrderByItems) == 0 {
+		// all groups are needed for outer aggregation if sub query isn't top-n query
+		q := *subQuery
+		q.Limit = crossMetricSubQueryLimit
+		q.Offset = 0
+		statement.SubQuery = &q
+	}
+	return p, nil
+}
+
+// planExpr plans the expression of outer select item, field of sub query must be aggregated by outer query.
+func (p *subQueryPlan) planExpr(expr stmtpkg.Expr, fields map[string]struct{}) error {
+	switch e := expr.(type) {
+	case *stmtpkg.SelectItem:
+		return p.planExpr(e.Expr, fields)
+	case *stmtpkg.ParenExpr:
+		return p.planExpr(e.Expr, fields)
+	case *stmtpkg.BinaryExpr:
+		if err := p.planExpr(e.Left, fields); err != nil {
+			return err
+		}
+		return p.planExpr(e.Right, fields)
+	case *stmtpkg.NumberLiteral:
+		return nil
+	case *stmtpkg.CallExpr:
+		switch e.FuncType {
+		case function.Sum, function.Min, function.Max, function.Count, function.Avg, function.First, function.Last:
+		default:
+			return fmt.Errorf("%w, function(%s) is not supported by outer query", ErrSubQuery, e.FuncType)
+		}
+		if len(e.Params) != 1 {
+			return fmt.Errorf("%w, function(%s) of outer query requires one field of sub query", ErrSubQuery, e.FuncType)
+		}
+		field, ok := e.Params[0].(*stmtpkg.FieldExpr)
+		if !ok {
+			return fmt.Errorf("%w, function(%s) of outer query requires one field of sub query", ErrSubQuery, e.FuncType)
+		}
+		if _, ok := fields[field.Name]; !ok {
+			return fmt.Errorf("%w, field(%s) not in select list of sub query", ErrSubQuery, field.Name)
+		}
+		p.operands[e.Rewrite()] = e
+		return nil
+	default:
+		return fmt.Errorf("%w, field of sub query must be aggregated by outer query: %s", ErrSubQuery, expr.Rewrite())
+	}
+}
+
+// execute executes sub query, then aggregates the result set of sub query by outer query.
+func (p *subQueryPlan) execute(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, search searchFunc,
+) (any, error) {
+	startTime := time.Now()
+	subParam := *param
+	subParam.Stream = nil
+	rs, err := search(ctx, &subParam, p.statement.SubQuery, mgr)
+	if err != nil {
+		return nil, fmt.Errorf("sub query failure, %w", err)
+	}
+	subResultSet, ok := rs.(*models.ResultSet)
+	if !ok || subResultSet == nil {
+		subResultSet = models.NewResultSet()
+	}
+	if subResultSet.Interval > 0 && p.statement.Interval.Int64()%subResultSet.Interval != 0 {
+		// interval of sub query may be adjusted by storage interval
+		return nil, fmt.Errorf("%w, outer interval(%s) must be a multiple of sub query interval(%s)",
+			ErrSubQuery, p.statement.Interval, timeutil.Interval(subResultSet.Interval))
+	}
+	aggregateStartTime := time.Now()
+	resultSet, err := p.aggregate(subResultSet, param.Stream)
+	if err != nil {
+		return nil, err
+	}
+	resultSet.Stale = subResultSet.Stale
+	resultSet.MergePartial(subResultSet)
+	if p.statement.Explain {
+		now := time.Now()
+		resultSet.Stats = &models.NodeStats{
+			Node:      mgr.CurNode.Indicator(),
+			Start:     startTime.UnixNano(),
+			End:       now.UnixNano(),
+			TotalCost: now.Sub(startTime).Nanoseconds(),
+			TimeZone:  p.buckets.Location().String(),
+			Stages: []*models.StageStats{
+				{
+					Identifier: "Sub Query",
+					Start:      startTime.UnixNano(),
+					End:        aggregateStartTime.UnixNano(),
+					Cost:       aggregateStartTime.Sub(startTime).Nanoseconds(),
+					State:      trackerpkg.CompleteState.String(),
+				},
+				{
+					Identifier: "Outer Aggregation",
+					Start:      aggregateStartTime.UnixNano(),
+					End:        now.UnixNano(),
+					Cost:       now.Sub(aggregateStartTime).Nanoseconds(),
+					State:      trackerpkg.CompleteState.String(),
+				},
+			},
+		}
+		if subResultSet.Stats != nil {
+			resultSet.Stats.Children = append(resultSet.Stats.Children, subResultSet.Stats)
+		}
+	}
+	return resultSet, nil
+}
+
+// aggregate groups the series of sub query by outer group by tag keys, then evaluates select items for each bucket.
+func (p *subQueryPlan) aggregate(subResultSet *models.ResultSet, stream models.ResultStream) (*models.ResultSet, error) {
+	statement := p.statement
+	resultSet := models.NewResultSet()
+	resultSet.MetricName = statement.MetricName
+	resultSet.GroupBy = statement.GroupBy
+	resultSet.StartTime = p.buckets.Truncate(statement.TimeRange.Start)
+	resultSet.EndTime = statement.TimeRange.End
+	resultSet.Interval = statement.Interval.Int64()
+	for _, item := range statement.SelectItems {
+		resultSet.Fields = append(resultSet.Fields, SelectItemName(item))
+	}
+
+	groups := make(map[string]*subQueryGroup)
+	var groupTagValues []string
+	for _, s := range subResultSet.Series {
+		tagValues := make([]string, len(statement.GroupBy))
+		for idx, tagKey := range statement.GroupBy {
+			tagValues[idx] = s.Tags[tagKey]
+		}
+		key := tag.ConcatTagValues(tagValues)
+		group, ok := groups[key]
+		if !ok {
+			group = &subQueryGroup{
+				tagValues: key,
+				values:    make(map[string]map[int64]*bucketAggregator),
+			}
+			if len(statement.GroupBy) > 0 {
+				group.tags = make(map[string]string)
+				for idx, tagKey := range statement.GroupBy {
+					group.tags[tagKey] = tagValues[idx]
+				}
+			}
+			groups[key] = group
+			groupTagValues = append(groupTagValues, key)
+		}
+		for operand, call := range p.operands {
+			fieldName := call.Params[0].(*stmtpkg.FieldExpr).Name
+			for timestamp, val := range s.Fields[fieldName] {
+				if math.IsNaN(val) {
+					continue
+				}
+				group.add(operand, p.buckets.Truncate(timestamp), timestamp, val)
+			}
+		}
+	}
+	sort.Strings(groupTagValues)
+
+	offset := 0
+	count := 0
+	for _, tagValues := range groupTagValues {
+		if count >= statement.Limit {
+			break
+		}
+		timeSeries, ok := p.evalSeries(groups[tagValues])
+		if !ok {
+			continue
+		}
+		if offset < statement.Offset {
+			offset++
+			continue
+		}
+		count++
+		if stream != nil {
+			if err := stream.WriteSeries(timeSeries); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		resultSet.AddSeries(timeSeries)
+	}
+	return resultSet, nil
+}
+
+// evalSeries evaluates the select items for each bucket of group, returns false if no point.
+func (p *subQueryPlan) evalSeries(group *subQueryGroup) (*models.Series, bool) {
+	bucketStarts := make(map[int64]struct{})
+	for _, buckets := range group.values {
+		for bucketStart := range buckets {
+			bucketStarts[bucketStart] = struct{}{}
+		}
+	}
+	sortedBucketStarts := make([]int64, 0, len(bucketStarts))
+	for bucketStart := range bucketStarts {
+		sortedBucketStarts = append(sortedBucketStarts, bucketStart)
+	}
+	sort.Slice(sortedBucketStarts, func(i, j int) bool {
+		return sortedBucketStarts[i] < sortedBucketStarts[j]
+	})
+
+	points := make([]*models.Points, len(p.statement.SelectItems))
+	for idx := range points {
+		points[idx] = models.NewPoints()
+	}
+	hasPoint := false
+	for _, bucketStart := range sortedBucketStarts {
+		timestamp := p.statement.Align.Timestamp(bucketStart, p.buckets.Next(bucketStart))
+		for idx, item := range p.statement.SelectItems {
+			if val, ok := p.eval(item, group, bucketStart); ok {
+				points[idx].AddPoint(timestamp, val)
+				hasPoint = true
+			}
+		}
+	}
+	if !hasPoint {
+		return nil, false
+	}
+	timeSeries := models.NewSeries(group.tags, group.tagValues)
+	for idx, item := range p.statement.SelectItems {
+		timeSeries.AddField(SelectItemName(item), points[idx])
+	}
+	return timeSeries, true
+}
+
+// eval evaluates the expression for one bucket of group, returns false if value is null.
+func (p *subQueryPlan) eval(expr stmtpkg.Expr, group *subQueryGroup, bucketStart int64) (float64, bool) {
+	switch e := expr.(type) {
+	case *stmtpkg.SelectItem:
+		return p.eval(e.Expr, group, bucketStart)
+	case *stmtpkg.ParenExpr:
+		return p.eval(e.Expr, group, bucketStart)
+	case *stmtpkg.NumberLiteral:
+		return e.Val, true
+	case *stmtpkg.BinaryExpr:
+		left, ok := p.eval(e.Left, group, bucketStart)
+		if !ok {
+			return 0, false
+		}
+		right, ok := p.eval(e.Right, group, bucketStart)
+		if !ok {
+			return 0, false
+		}
+		switch e.Operator {
+		case stmtpkg.ADD:
+			return left + right, true
+		case stmtpkg.SUB:
+			return left - right, true
+		case stmtpkg.MUL:
+			return left * right, true
+		case stmtpkg.DIV:
+			if right == 0 {
+				// division by zero is null
+				return 0, false
+			}
+			return left / right, true
+		}
+		return 0, false
+	case *stmtpkg.CallExpr:
+		agg, ok := group.values[e.Rewrite()][bucketStart]
+		if !ok {
+			return 0, false
+		}
+		return agg.value(e.FuncType), true
+	default:
+		return 0, false
+	}
+}
+
+// subQueryGroup represents the points of sub query series which belong to one group of outer query.
+type subQueryGroup struct {
+	tags      map[string]string
+	tagValues string
+	values    map[string]map[int64]*bucketAggregator // operand => bucket start => aggregator
+}
+
+// add adds the point of sub query into the bucket of operand.
+func (g *subQueryGroup) add(operand string, bucketStart, timestamp int64, val float64) {
+	buckets, ok := g.values[operand]
+	if !ok {
+		buckets = make(map[int64]*bucketAggregator)
+		g.values[operand] = buckets
+	}
+	agg, ok := buckets[bucketStart]
+	if !ok {
+		agg = &bucketAggregator{
+			min:       val,
+			max:       val,
+			firstTime: timestamp,
+			first:     val,
+			lastTime:  timestamp,
+			last:      val,
+		}
+		buckets[bucketStart] = agg
+	}
+	agg.add(timestamp, val)
+}
+
+// bucketAggregator aggregates the points of sub query in one bucket of outer query.
+type bucketAggregator struct {
+	sum, min, max       float64
+	count               int
+	firstTime, lastTime int64
+	first, last         float64
+}
+
+// add adds a point.
+func (a *bucketAggregator) add(timestamp int64, val float64) {
+	a.sum += val
+	a.count++
+	a.min = math.Min(a.min, val)
+	a.max = math.Max(a.max, val)
+	if timestamp < a.firstTime {
+		a.firstTime = timestamp
+		a.first = val
+	}
+	if timestamp >= a.lastTime {
+		a.lastTime = timestamp
+		a.last = val
+	}
+}
+
+// value returns the aggregated value of function.
+func (a *bucketAggregator) value(funcType function.FuncType) float64 {
+	switch funcType {
+	case function.Sum:
+		return a.sum
+	case function.Min:
+		return a.min
+	case function.Max:
+		return a.max
+	case function.Count:
+		return float64(a.count)
+	case function.Avg:
+		return a.sum / float64(a.count)
+	case function.First:
+		return a.first
+	default:
+		return a.last
+	}
+}
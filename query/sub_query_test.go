@@ -0,0 +1,302 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+func TestNewSubQueryPlan(t *testing.T) {
+	cases := []struct {
+		name    string
+		sql     string
+		wantErr bool
+		assert  func(p *subQueryPlan)
+	}{
+		{
+			name: "no sub query",
+			sql:  "select f from cpu",
+			assert: func(p *subQueryPlan) {
+				assert.Nil(t, p)
+			},
+		},
+		{
+			name: "sub query",
+			sql:  "select max(v)/60 as peak, avg(v) from (select sum(f) as v from cpu group by host,time(1m)) group by time(10m)",
+			assert: func(p *subQueryPlan) {
+				assert.Len(t, p.operands, 2)
+				assert.Equal(t, crossMetricSubQueryLimit, p.statement.SubQuery.Limit)
+				assert.Equal(t, "UTC", p.buckets.Location().String())
+			},
+		},
+		{
+			name: "top-n sub query keeps limit",
+			sql:  "select max(v) from (select sum(f) as v from cpu group by host,time(1m) order by v limit 5) group by time(10m)",
+			assert: func(p *subQueryPlan) {
+				assert.Equal(t, 5, p.statement.SubQuery.Limit)
+			},
+		},
+		{
+			name: "time zone of outer query",
+			sql:  "select max(v) from (select sum(f) as v from cpu group by time(1h)) group by time(1d) tz \"Asia/Shanghai\"",
+			assert: func(p *subQueryPlan) {
+				assert.Equal(t, "Asia/Shanghai", p.statement.SubQuery.TimeZone)
+			},
+		},
+		{
+			name: "time zone of sub query",
+			sql:  "select max(v) from (select sum(f) as v from cpu group by time(1h) tz \"Asia/Shanghai\") group by time(1d)",
+			assert: func(p *subQueryPlan) {
+				assert.Equal(t, "Asia/Shanghai", p.statement.TimeZone)
+			},
+		},
+		{
+			name:    "time zone not match",
+			sql:     "select max(v) from (select sum(f) as v from cpu group by time(1h) tz \"Asia/Shanghai\") group by time(1d) tz \"UTC\"",
+			wantErr: true,
+		},
+		{
+			name:    "outer interval not multiple of sub query interval",
+			sql:     "select max(v) from (select sum(f) as v from cpu group by time(3m)) group by time(10m)",
+			wantErr: true,
+		},
+		{
+			name:    "sub query without group by time",
+			sql:     "select max(v) from (select sum(f) as v from cpu) group by time(10m)",
+			wantErr: true,
+		},
+		{
+			name:    "outer query without group by time",
+			sql:     "select max(v) from (select sum(f) as v from cpu group by time(1m))",
+			wantErr: true,
+		},
+		{
+			name:    "outer group by not in sub query",
+			sql:     "select max(v) from (select sum(f) as v from cpu group by host,time(1m)) group by ip,time(10m)",
+			wantErr: true,
+		},
+		{
+			name:    "field not aggregated",
+			sql:     "select v from (select sum(f) as v from cpu group by time(1m)) group by time(10m)",
+			wantErr: true,
+		},
+		{
+			name:    "field not in sub query",
+			sql:     "select max(f) from (select sum(f) as v from cpu group by time(1m)) group by time(10m)",
+			wantErr: true,
+		},
+		{
+			name:    "function not supported",
+			sql:     "select rate(v) from (select sum(f) as v from cpu group by time(1m)) group by time(10m)",
+			wantErr: true,
+		},
+		{
+			name:    "function with expression",
+			sql:     "select max(v+1) from (select sum(f) as v from cpu group by time(1m)) group by time(10m)",
+			wantErr: true,
+		},
+		{
+			name:    "order by of outer query",
+			sql:     "select max(v) as m from (select sum(f) as v from cpu group by time(1m)) group by time(10m) order by m",
+			wantErr: true,
+		},
+		{
+			name:    "fill of outer query",
+			sql:     "select max(v) from (select sum(f) as v from cpu group by time(1m)) group by time(10m) fill(0)",
+			wantErr: true,
+		},
+		{
+			name:    "using of outer query",
+			sql:     "select max(v) from (select sum(f) as v from cpu group by time(1m)) group by time(10m) using raw",
+			wantErr: true,
+		},
+		{
+			name:    "align of sub query",
+			sql:     "select max(v) from (select sum(f) as v from cpu group by time(1m) align(end)) group by time(10m)",
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newSubQueryPlan(parseQuery(t, tt.sql))
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrSubQuery))
+				return
+			}
+			assert.NoError(t, err)
+			tt.assert(p)
+		})
+	}
+	// nested sub query
+	_, err := newSubQueryPlan(&stmtpkg.Query{
+		SubQuery: &stmtpkg.Query{SubQuery: &stmtpkg.Query{}},
+	})
+	assert.True(t, errors.Is(err, ErrSubQuery))
+}
+
+func TestSubQueryPlan_execute(t *testing.T) {
+	minute := timeutil.OneMinute
+	subResultSet := &models.ResultSet{
+		Interval: minute,
+		Series: []*models.Series{
+			{Tags: map[string]string{"host": "a", "dc": "sh"}, TagValues: "a,sh", Fields: map[string]map[int64]float64{
+				"v": {0: 1, minute: 5, 2 * minute: 3, 10 * minute: 7},
+			}},
+			{Tags: map[string]string{"host": "b", "dc": "sh"}, TagValues: "b,sh", Fields: map[string]map[int64]float64{
+				"v": {0: 2, 11 * minute: 4},
+			}},
+			{Tags: map[string]string{"host": "c", "dc": "bj"}, TagValues: "c,bj", Fields: map[string]map[int64]float64{
+				"v": {minute: 8},
+			}},
+		},
+		Stats: &models.NodeStats{Node: "sub"},
+		Stale: &models.StaleResult{Shards: []models.ShardID{1}, MissingRecentSeconds: 5},
+	}
+	search := func(_ context.Context, _ *models.ExecuteParam, statement *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		switch statement.MetricName {
+		case "cpu":
+			return subResultSet, nil
+		case "mem":
+			return &models.ResultSet{Interval: 3 * minute}, nil
+		default:
+			return nil, fmt.Errorf("err")
+		}
+	}
+	mgr := &SearchMgr{}
+	sqlOf := func(metricName, outer string) string {
+		return fmt.Sprintf("select %s from (select sum(f) as v from %s group by host,dc,time(1m)) group by %s",
+			outer, metricName, "dc,time(10m)")
+	}
+
+	cases := []struct {
+		name    string
+		sql     string
+		stream  *fakeResultStream
+		wantErr bool
+		assert  func(rs *models.ResultSet)
+	}{
+		{
+			name:    "sub query failure",
+			sql:     sqlOf("disk", "max(v)"),
+			wantErr: true,
+		},
+		{
+			name:    "interval of sub query adjusted",
+			sql:     sqlOf("mem", "max(v)"),
+			wantErr: true,
+		},
+		{
+			name: "aggregate by outer query",
+			sql: sqlOf("cpu", "max(v) as peak, sum(v), min(v), count(v), avg(v), first(v), last(v), "+
+				"(max(v)-min(v))/2"),
+			assert: func(rs *models.ResultSet) {
+				assert.Equal(t, []string{"dc"}, rs.GroupBy)
+				assert.Equal(t, 10*minute, rs.Interval)
+				assert.Len(t, rs.Fields, 8)
+				assert.Len(t, rs.Series, 2)
+				bj, sh := rs.Series[0], rs.Series[1]
+				assert.Equal(t, map[string]string{"dc": "bj"}, bj.Tags)
+				assert.Equal(t, map[int64]float64{0: 8}, bj.Fields["peak"])
+				assert.Equal(t, map[int64]float64{0: 5, 10 * minute: 7}, sh.Fields["peak"])
+				assert.Equal(t, map[int64]float64{0: 11, 10 * minute: 11}, sh.Fields["sum(v)"])
+				assert.Equal(t, map[int64]float64{0: 1, 10 * minute: 4}, sh.Fields["min(v)"])
+				assert.Equal(t, map[int64]float64{0: 4, 10 * minute: 2}, sh.Fields["count(v)"])
+				assert.Equal(t, map[int64]float64{0: 2.75, 10 * minute: 5.5}, sh.Fields["avg(v)"])
+				assert.Equal(t, map[int64]float64{0: 3, 10 * minute: 4}, sh.Fields["last(v)"])
+				assert.Equal(t, map[int64]float64{0: 2, 10 * minute: 1.5}, sh.Fields["(max(v)-min(v))/2.00"])
+				assert.Nil(t, rs.Stats)
+				assert.Equal(t, subResultSet.Stale, rs.Stale)
+			},
+		},
+		{
+			name: "align end",
+			sql:  sqlOf("cpu", "max(v)") + " align(end)",
+			assert: func(rs *models.ResultSet) {
+				assert.Equal(t, map[int64]float64{10 * minute: 5, 20 * minute: 7}, rs.Series[1].Fields["max(v)"])
+			},
+		},
+		{
+			name: "division by zero",
+			sql:  sqlOf("cpu", "max(v)/0"),
+			assert: func(rs *models.ResultSet) {
+				assert.Empty(t, rs.Series)
+			},
+		},
+		{
+			name: "limit/offset",
+			sql:  sqlOf("cpu", "max(v)") + " limit 1 offset 1",
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, rs.Series, 1)
+				assert.Equal(t, "sh", rs.Series[0].Tags["dc"])
+			},
+		},
+		{
+			name: "explain",
+			sql:  "explain " + sqlOf("cpu", "max(v)"),
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, rs.Stats.Stages, 2)
+				assert.Equal(t, "Sub Query", rs.Stats.Stages[0].Identifier)
+				assert.Equal(t, "Outer Aggregation", rs.Stats.Stages[1].Identifier)
+				assert.Equal(t, []*models.NodeStats{subResultSet.Stats}, rs.Stats.Children)
+			},
+		},
+		{
+			name:   "stream",
+			sql:    sqlOf("cpu", "max(v)"),
+			stream: &fakeResultStream{},
+			assert: func(rs *models.ResultSet) {
+				assert.Empty(t, rs.Series)
+				assert.Equal(t, []string{"max(v)"}, rs.Fields)
+			},
+		},
+		{
+			name:    "stream failure",
+			sql:     sqlOf("cpu", "max(v)"),
+			stream:  &fakeResultStream{err: fmt.Errorf("err")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := newSubQueryPlan(parseQuery(t, tt.sql))
+			assert.NoError(t, err)
+			param := &models.ExecuteParam{}
+			if tt.stream != nil {
+				param.Stream = tt.stream
+			}
+			rs, err := p.execute(context.TODO(), param, mgr, search)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.assert(rs.(*models.ResultSet))
+		})
+	}
+}
@@ -0,0 +1,215 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/lindb/lindb/aggregation/function"
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+// topNPlan represents the plan of top-n query(group by with order by and limit).
+// First pass pushes down top-n to storage nodes, each storage node only returns top (offset+limit)*factor
+// candidate groups ranked by its partial aggregates, broker merges the candidates then does order by/limit.
+// If partial aggregates cannot be merged exactly(like avg), second pass queries the candidate groups again
+// without pushdown, so that the values of result are exact.
+type topNPlan struct {
+	statement  *stmtpkg.Query
+	candidates int
+	secondPass bool
+}
+
+// newTopNPlan creates a top-n plan, returns nil if query is not top-n query or pushdown is disabled.
+func newTopNPlan(statement *stmtpkg.Query, cfg config.TopN) *topNPlan {
+	if cfg.CandidateFactor <= 0 || !statement.HasGroupBy() ||
+		len(statement.OrderByItems) == 0 || statement.Limit <= 0 || statement.TopNCandidates > 0 {
+		return nil
+	}
+	p := &topNPlan{
+		statement:  statement,
+		candidates: (statement.Offset + statement.Limit) * cfg.CandidateFactor,
+	}
+	switch cfg.SecondPass {
+	case config.TopNSecondPassAlways:
+		p.secondPass = true
+	case config.TopNSecondPassNever:
+		p.secondPass = false
+	default:
+		p.secondPass = !isDecomposableOrderBy(statement)
+	}
+	return p
+}
+
+// execute executes the first pass with pushdown, then executes the second pass for candidate groups if need.
+func (p *topNPlan) execute(ctx context.Context,
+	param *models.ExecuteParam, mgr *SearchMgr, search searchFunc,
+) (any, error) {
+	startTime := time.Now()
+	firstPass := *p.statement
+	firstPass.TopNCandidates = p.candidates
+	firstParam := *param
+	if p.secondPass {
+		// result of first pass is only used for picking candidates
+		firstParam.Stream = nil
+	}
+	rs, err := search(ctx, &firstParam, &firstPass, mgr)
+	if err != nil {
+		return nil, err
+	}
+	resultSet, ok := rs.(*models.ResultSet)
+	if !ok || resultSet == nil {
+		return rs, nil
+	}
+	topN := &models.TopNResult{Candidates: p.candidates, Accuracy: models.TopNApproximate}
+	if !p.secondPass || len(resultSet.Series) == 0 {
+		resultSet.TopN = topN
+		return resultSet, nil
+	}
+	secondPass := *p.statement
+	secondPass.Condition = p.candidateCondition(resultSet.Series)
+	subMgr := *mgr
+	subMgr.RequestID = "" // second pass is an independent request
+	rs, err = search(ctx, param, &secondPass, &subMgr)
+	if err != nil {
+		return nil, err
+	}
+	finalResultSet, ok := rs.(*models.ResultSet)
+	if !ok || finalResultSet == nil {
+		return rs, nil
+	}
+	topN.SecondPass = true
+	topN.Accuracy = models.TopNExact
+	finalResultSet.TopN = topN
+	finalResultSet.Stale = finalResultSet.Stale.Merge(resultSet.Stale)
+	finalResultSet.MergePartial(resultSet)
+	if p.statement.Explain {
+		now := time.Now()
+		stats := &models.NodeStats{
+			Node:      mgr.CurNode.Indicator(),
+			Start:     startTime.UnixNano(),
+			End:       now.UnixNano(),
+			TotalCost: now.Sub(startTime).Nanoseconds(),
+		}
+		for _, passResultSet := range []*models.ResultSet{resultSet, finalResultSet} {
+			if passResultSet.Stats != nil {
+				stats.Children = append(stats.Children, passResultSet.Stats)
+			}
+		}
+		finalResultSet.Stats = stats
+	}
+	return finalResultSet, nil
+}
+
+// candidateCondition returns the condition which restricts the query to the candidate groups,
+// the tag values of each group by tag key are filtered by in expr.
+func (p *topNPlan) candidateCondition(candidates []*models.Series) stmtpkg.Expr {
+	condition := p.statement.Condition
+	for _, tagKey := range p.statement.GroupBy {
+		values := make(map[string]struct{})
+		for _, candidate := range candidates {
+			value := candidate.Tags[tagKey]
+			if value == "" {
+				// group without tag value cannot be matched by in expr, don't restrict this tag key
+				values = nil
+				break
+			}
+			values[value] = struct{}{}
+		}
+		if len(values) == 0 {
+			continue
+		}
+		in := &stmtpkg.InExpr{Key: tagKey}
+		for value := range values {
+			in.Values = append(in.Values, value)
+		}
+		sort.Strings(in.Values)
+		if condition == nil {
+			condition = in
+			continue
+		}
+		condition = &stmtpkg.BinaryExpr{
+			Left:     &stmtpkg.ParenExpr{Expr: condition},
+			Operator: stmtpkg.AND,
+			Right:    in,
+		}
+	}
+	return condition
+}
+
+// decomposableFuncs are the aggregations which partial aggregates of storage nodes can be merged exactly.
+var decomposableFuncs = map[function.FuncType]struct{}{
+	function.Sum:   {},
+	function.Min:   {},
+	function.Max:   {},
+	function.Count: {},
+}
+
+// isDecomposableOrderBy checks if all order by items rank on decomposable aggregation of field,
+// like: select sum(f) ... order by sum(f), the ranking of partial aggregates is close to the final ranking.
+func isDecomposableOrderBy(statement *stmtpkg.Query) bool {
+	for _, item := range statement.OrderByItems {
+		orderBy, ok := item.(*stmtpkg.OrderByExpr)
+		if !ok {
+			return false
+		}
+		var name string
+		switch e := orderBy.Expr.(type) {
+		case *stmtpkg.FieldExpr:
+			name = e.Name
+		case *stmtpkg.CallExpr:
+			if _, ok := decomposableFuncs[e.FuncType]; !ok || len(e.Params) != 1 {
+				return false
+			}
+			name = e.Params[0].Rewrite()
+		default:
+			return false
+		}
+		if !isDecomposableSelectItem(statement.SelectItems, name) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDecomposableSelectItem checks if the select item of given name is field or decomposable aggregation of field.
+func isDecomposableSelectItem(selectItems []stmtpkg.Expr, name string) bool {
+	for _, item := range selectItems {
+		selectItem, ok := item.(*stmtpkg.SelectItem)
+		if !ok || (selectItem.Alias != name && (selectItem.Alias != "" || selectItem.Expr.Rewrite() != name)) {
+			continue
+		}
+		switch e := selectItem.Expr.(type) {
+		case *stmtpkg.FieldExpr:
+			return true
+		case *stmtpkg.CallExpr:
+			if _, ok := decomposableFuncs[e.FuncType]; !ok || len(e.Params) != 1 {
+				return false
+			}
+			_, ok := e.Params[0].(*stmtpkg.FieldExpr)
+			return ok
+		default:
+			return false
+		}
+	}
+	return false
+}
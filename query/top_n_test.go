@@ -0,0 +1,224 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package query
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	stmtpkg "github.com/lindb/lindb/sql/stmt"
+)
+
+func TestNewTopNPlan(t *testing.T) {
+	cfg := config.NewDefaultTopN()
+	cases := []struct {
+		name       string
+		sql        string
+		cfg        config.TopN
+		candidates int
+		secondPass bool
+		isNil      bool
+	}{
+		{
+			name:  "pushdown disabled",
+			sql:   "select sum(f) from cpu group by host order by sum(f) desc limit 10",
+			cfg:   config.TopN{},
+			isNil: true,
+		},
+		{
+			name:  "without group by",
+			sql:   "select sum(f) from cpu order by sum(f) desc limit 10",
+			cfg:   cfg,
+			isNil: true,
+		},
+		{
+			name:  "without order by",
+			sql:   "select sum(f) from cpu group by host limit 10",
+			cfg:   cfg,
+			isNil: true,
+		},
+		{
+			name:       "decomposable aggregation",
+			sql:        "select f from cpu group by host order by sum(f) desc limit 10 offset 2",
+			cfg:        cfg,
+			candidates: 36,
+		},
+		{
+			name:       "decomposable aggregation with alias",
+			sql:        "select max(f) as m, g from cpu group by host order by max(m) desc, g limit 10",
+			cfg:        cfg,
+			candidates: 30,
+		},
+		{
+			name:       "avg is not decomposable",
+			sql:        "select avg(f) as a from cpu group by host order by a limit 10",
+			cfg:        cfg,
+			candidates: 30,
+			secondPass: true,
+		},
+		{
+			name:       "order by avg on field",
+			sql:        "select f from cpu group by host order by avg(f) limit 10",
+			cfg:        cfg,
+			candidates: 30,
+			secondPass: true,
+		},
+		{
+			name:       "expression is not decomposable",
+			sql:        "select f/g as r from cpu group by host order by sum(r) limit 10",
+			cfg:        cfg,
+			candidates: 30,
+			secondPass: true,
+		},
+		{
+			name:       "second pass always",
+			sql:        "select f from cpu group by host order by sum(f) desc limit 10",
+			cfg:        config.TopN{CandidateFactor: 2, SecondPass: config.TopNSecondPassAlways},
+			candidates: 20,
+			secondPass: true,
+		},
+		{
+			name:       "second pass never",
+			sql:        "select avg(f) as a from cpu group by host order by a limit 10",
+			cfg:        config.TopN{CandidateFactor: 2, SecondPass: config.TopNSecondPassNever},
+			candidates: 20,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTopNPlan(parseQuery(t, tt.sql), tt.cfg)
+			if tt.isNil {
+				assert.Nil(t, p)
+				return
+			}
+			assert.NotNil(t, p)
+			assert.Equal(t, tt.candidates, p.candidates)
+			assert.Equal(t, tt.secondPass, p.secondPass)
+		})
+	}
+}
+
+func TestTopNPlan_execute(t *testing.T) {
+	var statements []*stmtpkg.Query
+	search := func(_ context.Context, param *models.ExecuteParam, statement *stmtpkg.Query, _ *SearchMgr) (any, error) {
+		statements = append(statements, statement)
+		switch {
+		case param.SQL == "failure":
+			return nil, fmt.Errorf("err")
+		case param.SQL == "empty":
+			return models.NewResultSet(), nil
+		case param.SQL == "second failure" && statement.TopNCandidates == 0:
+			return nil, fmt.Errorf("err")
+		case statement.TopNCandidates > 0:
+			return &models.ResultSet{
+				Series: []*models.Series{
+					{Tags: map[string]string{"host": "b", "dc": "x"}},
+					{Tags: map[string]string{"host": "a", "dc": ""}},
+				},
+				Stats: &models.NodeStats{Node: "first"},
+				Stale: &models.StaleResult{Shards: []models.ShardID{1}},
+			}, nil
+		default:
+			return &models.ResultSet{
+				Series: []*models.Series{{Tags: map[string]string{"host": "a", "dc": ""}}},
+				Stats:  &models.NodeStats{Node: "second"},
+			}, nil
+		}
+	}
+	mgr := &SearchMgr{RequestID: "req"}
+	cases := []struct {
+		name    string
+		sql     string
+		param   string
+		cfg     config.TopN
+		wantErr bool
+		assert  func(rs *models.ResultSet)
+	}{
+		{
+			name:    "first pass failure",
+			sql:     "select avg(f) as a from cpu group by host, dc order by a limit 2",
+			param:   "failure",
+			cfg:     config.NewDefaultTopN(),
+			wantErr: true,
+		},
+		{
+			name:    "second pass failure",
+			sql:     "select avg(f) as a from cpu group by host, dc order by a limit 2",
+			param:   "second failure",
+			cfg:     config.NewDefaultTopN(),
+			wantErr: true,
+		},
+		{
+			name:  "first pass without series",
+			sql:   "select avg(f) as a from cpu group by host, dc order by a limit 2",
+			param: "empty",
+			cfg:   config.NewDefaultTopN(),
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, statements, 1)
+				assert.Equal(t, &models.TopNResult{Candidates: 6, Accuracy: models.TopNApproximate}, rs.TopN)
+			},
+		},
+		{
+			name: "approximate result without second pass",
+			sql:  "select f from cpu group by host, dc order by sum(f) limit 2",
+			cfg:  config.NewDefaultTopN(),
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, statements, 1)
+				assert.Equal(t, 6, statements[0].TopNCandidates)
+				assert.Equal(t, &models.TopNResult{Candidates: 6, Accuracy: models.TopNApproximate}, rs.TopN)
+			},
+		},
+		{
+			name: "exact result with second pass",
+			sql:  "explain select avg(f) as a from cpu where region='cn' group by host, dc order by a limit 2",
+			cfg:  config.NewDefaultTopN(),
+			assert: func(rs *models.ResultSet) {
+				assert.Len(t, statements, 2)
+				assert.Equal(t, 0, statements[1].TopNCandidates)
+				assert.Equal(t, &stmtpkg.BinaryExpr{
+					Left:     &stmtpkg.ParenExpr{Expr: &stmtpkg.EqualsExpr{Key: "region", Value: "cn"}},
+					Operator: stmtpkg.AND,
+					Right:    &stmtpkg.InExpr{Key: "host", Values: []string{"a", "b"}},
+				}, statements[1].Condition)
+				assert.Equal(t, &models.TopNResult{Candidates: 6, SecondPass: true, Accuracy: models.TopNExact}, rs.TopN)
+				assert.Equal(t, &models.StaleResult{Shards: []models.ShardID{1}}, rs.Stale)
+				assert.Len(t, rs.Stats.Children, 2)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			statements = nil
+			p := newTopNPlan(parseQuery(t, tt.sql), tt.cfg)
+			rs, err := p.execute(context.TODO(), &models.ExecuteParam{SQL: tt.param}, mgr, search)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			tt.assert(rs.(*models.ResultSet))
+		})
+	}
+}
@@ -126,6 +126,21 @@ func (s *StageTracker) GetStats() *models.NodeStats {
 	return s.stats
 }
 
+// CurrentStage returns the identifier of the latest executing stage, returns empty string if no stage is executing.
+func (s *StageTracker) CurrentStage() (current string) {
+	var walk func(stages []*models.StageStats)
+	walk = func(stages []*models.StageStats) {
+		for _, stage := range stages {
+			if stage.State == ExecutingState.String() {
+				current = stage.Identifier
+			}
+			walk(stage.Children)
+		}
+	}
+	walk(s.GetStages())
+	return current
+}
+
 // getStages returns all stages' execution stats without lock.
 func (s *StageTracker) getStages() (rs []*models.StageStats) {
 	rs = append(rs, s.stages...)
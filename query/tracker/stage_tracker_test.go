@@ -51,3 +51,19 @@ func TestStageTracker(t *testing.T) {
 	assert.Len(t, tracker.GetStages(), 2)
 	assert.NotNil(t, tracker.GetStats())
 }
+
+func TestStageTracker_CurrentStage(t *testing.T) {
+	tracker := NewStageTracker(flow.NewTaskContextWithTimeout(context.TODO(), time.Minute))
+	assert.Empty(t, tracker.CurrentStage())
+
+	tracker.AddStage(&models.StageStats{
+		Identifier: "Physical Plan",
+		State:      CompleteState.String(),
+		Children: []*models.StageStats{
+			{Identifier: "Shard Scan", State: ExecutingState.String()},
+		},
+	})
+	assert.Equal(t, "Shard Scan", tracker.CurrentStage())
+	tracker.AddStage(&models.StageStats{Identifier: "Grouping", State: ExecutingState.String()})
+	assert.Equal(t, "Grouping", tracker.CurrentStage())
+}
@@ -19,11 +19,15 @@ package replica
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"sync"
+	"time"
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
@@ -36,7 +40,9 @@ import (
 
 // for testing
 var (
-	createChannel = newShardChannel
+	createChannel     = newShardChannel
+	openChannelWALFn  = newChannelWAL
+	redeliverInterval = time.Second
 )
 
 // DatabaseChannel represents the database level replication shardChannel
@@ -45,11 +51,18 @@ type DatabaseChannel interface {
 	Write(ctx context.Context, brokerBatchRows *metric.BrokerBatchRows) error
 	// CreateChannel creates the shard level replication shardChannel by given shard id
 	CreateChannel(numOfShard int32, shardID models.ShardID) (ShardChannel, error)
+	// SyncShardRouting switches the routing of rows to shards atomically, includes the splits of hot shards.
+	SyncShardRouting(routing metric.ShardRouting)
+	// Flush flushes rows buffered by pre-aggregation and shard channels, waits until acknowledged by storage.
+	Flush(ctx context.Context) error
 	// Stop stops current database write shardChannel.
 	Stop()
 
 	// garbageCollect recycles write families which is expired.
 	garbageCollect()
+	// startRedeliver replays the un-acknowledged entries of write ahead log after shard state synced,
+	// then re-delivers the entries lost before acknowledged periodically.
+	startRedeliver()
 }
 
 type (
@@ -59,36 +72,62 @@ type (
 		mu    sync.Mutex   // lock for modifying shard2Channel
 	}
 	databaseChannel struct {
-		databaseCfg   models.Database
-		ahead         *atomic.Int64
-		behind        *atomic.Int64
-		ctx           context.Context
-		cancel        context.CancelFunc
-		fct           rpc.ClientStreamFactory
-		numOfShard    atomic.Int32
-		shardChannels shardChannels
-		interval      timeutil.Interval
+		databaseCfg models.Database
+		ahead       *atomic.Int64
+		behind      *atomic.Int64
+		// allowed lateness of rows which belong to older families, 0 means only limited by behind
+		outOfOrderWindow *atomic.Int64
+		ctx              context.Context
+		cancel           context.CancelFunc
+		fct              rpc.ClientStreamFactory
+		// returns the latest replication option of database, applied to the family channels created after changed
+		replicationOption replicationOptionFn
+		numOfShard        atomic.Int32
+		routing           atomic.Value // metric.ShardRouting
+		shardChannels     shardChannels
+		interval          timeutil.Interval
+
+		wal              *channelWAL    // nil if broker wal disabled
+		preAggregator    *preAggregator // nil if pre-aggregation disabled
+		redeliverStarted atomic.Bool
+		redeliverStop    chan struct{}
+		redeliverDone    chan struct{}
 
 		statistics *metrics.BrokerDatabaseWriteStatistics
 		logger     *logger.Logger
 	}
 )
 
-// newDatabaseChannel creates a new database replication shardChannel
+// newDatabaseChannel creates a new database replication shardChannel,
+// opens write ahead log if broker wal enabled for database.
 func newDatabaseChannel(
 	ctx context.Context,
 	databaseCfg models.Database,
 	numOfShard int32,
 	fct rpc.ClientStreamFactory,
-) DatabaseChannel {
+	replicationOption replicationOptionFn,
+) (DatabaseChannel, error) {
+	var wal *channelWAL
+	if databaseCfg.Option.BrokerWAL {
+		writeCfg := config.GlobalBrokerConfig().Write
+		var err error
+		wal, err = openChannelWALFn(writeCfg.WALDir, int64(writeCfg.WALDataSizeLimit), databaseCfg.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
 	c, cancel := context.WithCancel(ctx)
 	ch := &databaseChannel{
-		databaseCfg: databaseCfg,
-		ctx:         c,
-		cancel:      cancel,
-		fct:         fct,
-		statistics:  metrics.NewBrokerDatabaseWriteStatistics(databaseCfg.Name),
-		logger:      logger.GetLogger("Replica", "DatabaseChannel"),
+		databaseCfg:       databaseCfg,
+		ctx:               c,
+		cancel:            cancel,
+		fct:               fct,
+		wal:               wal,
+		replicationOption: replicationOption,
+		redeliverStop:     make(chan struct{}),
+		redeliverDone:     make(chan struct{}),
+		statistics:        metrics.NewBrokerDatabaseWriteStatistics(databaseCfg.Name),
+		logger:            logger.GetLogger("Replica", "DatabaseChannel"),
 	}
 	ch.shardChannels.value.Store(make(shard2Channel))
 
@@ -96,14 +135,34 @@ func newDatabaseChannel(
 	ahead, behind := opt.GetAcceptWritableRange()
 	ch.ahead = atomic.NewInt64(ahead)
 	ch.behind = atomic.NewInt64(behind)
+	ch.outOfOrderWindow = atomic.NewInt64(opt.GetOutOfOrderWindow())
 
 	// TODO need validation
 	sort.Sort(databaseCfg.Option.Intervals)
 	ch.interval = databaseCfg.Option.Intervals[0].Interval
 
+	if window, maxRows := opt.GetPreAggregation(); window > 0 {
+		if wal != nil {
+			// rows buffered in window are not persisted, which breaks the guarantee of broker wal
+			ch.logger.Warn("pre-aggregation disabled because broker wal enabled",
+				logger.String("database", databaseCfg.Name))
+		} else {
+			ch.preAggregator = newPreAggregator(databaseCfg.Name, time.Duration(window)*time.Millisecond,
+				maxRows, ch.interval.Int64(), func(rows *metric.BrokerBatchRows) error {
+					return ch.write(ch.ctx, rows)
+				})
+		}
+	}
+
 	ch.numOfShard.Store(numOfShard)
+	// base shards by database config, splits of hot shards are synced from shard states
+	numOfBaseShard := numOfShard
+	if databaseCfg.NumOfShard > 0 && int32(databaseCfg.NumOfShard) < numOfShard {
+		numOfBaseShard = int32(databaseCfg.NumOfShard)
+	}
+	ch.routing.Store(metric.ShardRouting{NumOfShards: numOfBaseShard})
 
-	return ch
+	return ch, nil
 }
 
 // garbageCollect recycles write families which is expired.
@@ -119,20 +178,62 @@ func (dc *databaseChannel) garbageCollect() {
 	for _, channel := range channels {
 		channel.garbageCollect(ahead, behind)
 	}
+	if dc.wal != nil {
+		// truncate the entries acknowledged by storage
+		dc.wal.gc()
+	}
 }
 
-// Write writes the metric data into shardChannel's buffer
+// Write writes the metric data into shardChannel's buffer,
+// if broker wal enabled, rows are persisted into write ahead log before written,
+// if pre-aggregation enabled, foldable rows are buffered in window then written after folded.
 func (dc *databaseChannel) Write(ctx context.Context, brokerBatchRows *metric.BrokerBatchRows) error {
-	var err error
-
 	behind := dc.behind.Load()
 	ahead := dc.ahead.Load()
 
 	evicted := brokerBatchRows.EvictOutOfTimeRange(behind, ahead)
 	dc.statistics.OutOfTimeRange.Add(float64(evicted))
+	if evicted > 0 && evicted == brokerBatchRows.Len() {
+		// all rows are dropped, notify the client instead of responding success silently
+		return fmt.Errorf("%w, behind: %dms, ahead: %dms", constants.ErrOutOfTimeRange, behind, ahead)
+	}
+	// late rows are routed to the older families by family iterator
+	accepted, dropped := brokerBatchRows.EvictLateRows(dc.interval, dc.outOfOrderWindow.Load())
+	dc.statistics.LateAccepted.Add(float64(accepted))
+	dc.statistics.TooLateDropped.Add(float64(dropped))
 
-	// sharding metrics to shards
-	shardingIterator := brokerBatchRows.NewShardGroupIterator(dc.numOfShard.Load())
+	// batch with idempotency token isn't folded, so that storage can skip the whole batch if token is duplicated
+	if dc.preAggregator != nil && IdempotencyTokenFromContext(ctx) == "" {
+		if moved := dc.preAggregator.add(brokerBatchRows); moved == brokerBatchRows.Len()-evicted-dropped {
+			// all rows are buffered in pre-aggregation window
+			return nil
+		}
+	}
+	if dc.wal == nil {
+		return dc.write(ctx, brokerBatchRows)
+	}
+	seq, token, err := dc.wal.append(IdempotencyTokenFromContext(ctx), brokerBatchRows.Rows())
+	if err != nil {
+		return err
+	}
+	if err := dc.write(withWALRef(WithIdempotencyToken(ctx, token), walRef{wal: dc.wal, seq: seq}), brokerBatchRows); err != nil {
+		// rows are persisted, re-deliver them in background instead of client retrying
+		dc.logger.Warn("failed writing rows of wal entry, re-deliver it later",
+			logger.String("database", dc.databaseCfg.Name), logger.Int64("seq", seq), logger.Error(err))
+		dc.wal.abandon(seq)
+		return nil
+	}
+	dc.wal.release(seq)
+	return nil
+}
+
+// write writes the metric data into family channels.
+func (dc *databaseChannel) write(ctx context.Context, brokerBatchRows *metric.BrokerBatchRows) error {
+	var err error
+
+	// sharding metrics to shards(by routing tags if database sets them)
+	routing := dc.routing.Load().(metric.ShardRouting)
+	shardingIterator := brokerBatchRows.NewRoutingShardGroupIterator(routing, dc.databaseCfg.Option.RoutingTags...)
 	for shardingIterator.HasRowsForNextShard() {
 		shardIdx, familyIterator := shardingIterator.FamilyRowsForNextShard(dc.interval)
 		shardID := models.ShardID(shardIdx)
@@ -180,25 +281,87 @@ func (dc *databaseChannel) CreateChannel(numOfShard int32, shardID models.ShardI
 	if numOfShard < dc.numOfShard.Load() {
 		return nil, errInvalidShardNum
 	}
-	ch := createChannel(dc.ctx, dc.databaseCfg.Name, shardID, dc.fct)
+	ch := createChannel(dc.ctx, dc.databaseCfg.Name, shardID, dc.fct, dc.replicationOption)
 
 	// cache shard level shardChannel
 	dc.insertShardChannel(shardID, ch)
 	return ch, nil
 }
 
+// SyncShardRouting switches the routing of rows to shards atomically, includes the splits of hot shards.
+func (dc *databaseChannel) SyncShardRouting(routing metric.ShardRouting) {
+	dc.routing.Store(routing)
+}
+
+// Flush flushes rows buffered by pre-aggregation and shard channels, waits until acknowledged by storage.
+func (dc *databaseChannel) Flush(ctx context.Context) error {
+	if dc.preAggregator != nil {
+		dc.preAggregator.flush()
+	}
+	channels := dc.shardChannels.value.Load().(shard2Channel)
+	for _, channel := range channels {
+		if err := channel.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Stop stops current database write shardChannel.
 func (dc *databaseChannel) Stop() {
+	if dc.redeliverStarted.Load() {
+		close(dc.redeliverStop)
+		<-dc.redeliverDone
+	}
 	dc.shardChannels.mu.Lock()
 	defer func() {
 		dc.cancel()
 		dc.shardChannels.mu.Unlock()
 	}()
 
+	if dc.preAggregator != nil {
+		// flush the rows buffered in pre-aggregation window before channels stopped
+		dc.preAggregator.stopAndFlush()
+	}
 	channels := dc.shardChannels.value.Load().(shard2Channel)
 	for _, channel := range channels {
 		channel.Stop()
 	}
+	if dc.wal != nil {
+		// entries not acknowledged are replayed after broker restarted
+		dc.wal.close()
+	}
+}
+
+// startRedeliver replays the un-acknowledged entries of write ahead log after shard state synced,
+// then re-delivers the entries lost before acknowledged periodically.
+func (dc *databaseChannel) startRedeliver() {
+	if dc.wal == nil || !dc.redeliverStarted.CAS(false, true) {
+		return
+	}
+	go func() {
+		defer close(dc.redeliverDone)
+
+		ticker := time.NewTicker(redeliverInterval)
+		defer ticker.Stop()
+		for {
+			dc.wal.redeliver(dc.redeliverEntry)
+			select {
+			case <-ticker.C:
+			case <-dc.redeliverStop:
+				return
+			}
+		}
+	}()
+}
+
+// redeliverEntry re-writes the rows of wal entry into family channels.
+func (dc *databaseChannel) redeliverEntry(ref walRef, token string, rows *metric.BrokerBatchRows) error {
+	ctx, cancel := context.WithTimeout(dc.ctx, redeliverInterval)
+	defer cancel()
+
+	rows.EvictOutOfTimeRange(dc.behind.Load(), dc.ahead.Load())
+	return dc.write(withWALRef(WithIdempotencyToken(ctx, token), ref), rows)
 }
 
 // getChannelByShardID gets the replica shardChannel by shard id
@@ -19,15 +19,20 @@ package replica
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
 	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/errcode"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series/metric"
@@ -38,11 +43,12 @@ func TestDatabaseChannel_Write(t *testing.T) {
 	defer ctrl.Finish()
 
 	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}}
-	ch := newDatabaseChannel(context.TODO(),
+	ch, err := newDatabaseChannel(context.TODO(),
 		models.Database{
 			Name:   "database",
 			Option: opt,
-		}, 1, nil)
+		}, 1, nil, nil)
+	assert.NoError(t, err)
 	assert.NotNil(t, ch)
 
 	converter := metric.NewProtoConverter()
@@ -56,7 +62,7 @@ func TestDatabaseChannel_Write(t *testing.T) {
 			Tags: []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
 		}, row)
 	})
-	err := ch.Write(context.TODO(), batch)
+	err = ch.Write(context.TODO(), batch)
 	assert.Equal(t, errChannelNotFound, err)
 
 	shardCh := NewMockShardChannel(ctrl)
@@ -80,15 +86,65 @@ func TestDatabaseChannel_Write(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestDatabaseChannel_Write_LateRows(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}, OutOfOrderWindow: "6h"}
+	ch, err := newDatabaseChannel(context.TODO(),
+		models.Database{
+			Name:   "late-database",
+			Option: opt,
+		}, 1, nil, nil)
+	assert.NoError(t, err)
+
+	now := timeutil.Now()
+	lateFamilyTime := opt.Intervals[0].Interval.Calculator().CalcFamilyTime(now - 2*timeutil.OneHour)
+	shardCh := NewMockShardChannel(ctrl)
+	ch1 := ch.(*databaseChannel)
+	ch1.insertShardChannel(models.ShardID(0), shardCh)
+	familyChannel := NewMockFamilyChannel(ctrl)
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	shardCh.EXPECT().GetOrCreateFamilyChannel(lateFamilyTime).Return(familyChannel)
+	shardCh.EXPECT().GetOrCreateFamilyChannel(gomock.Any()).Return(familyChannel).AnyTimes()
+
+	converter := metric.NewProtoConverter()
+	batch := metric.NewBrokerBatchRows()
+	for _, timestamp := range []int64{now - 2*timeutil.OneHour, now - 7*timeutil.OneHour} {
+		ts := timestamp
+		_ = batch.TryAppend(func(row *metric.BrokerRow) error {
+			return converter.ConvertTo(&protoMetricsV1.Metric{
+				Name:      "cpu",
+				Timestamp: ts,
+				SimpleFields: []*protoMetricsV1.SimpleField{
+					{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+				Tags: []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+			}, row)
+		})
+	}
+	err = ch.Write(context.TODO(), batch)
+	assert.NoError(t, err)
+	assert.Equal(t, float64(1), ch1.statistics.LateAccepted.Get())
+	assert.Equal(t, float64(1), ch1.statistics.TooLateDropped.Get())
+	evicted := 0
+	for _, row := range batch.Rows() {
+		if row.IsOutOfTimeRange {
+			evicted++
+		}
+	}
+	assert.Equal(t, 1, evicted)
+}
+
 func TestDatabaseChannel_CreateChannel(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}}
-	ch := newDatabaseChannel(context.TODO(),
+	ch, err := newDatabaseChannel(context.TODO(),
 		models.Database{
 			Name:   "database",
 			Option: opt,
-		}, 4, nil)
+		}, 4, nil, nil)
+	assert.NoError(t, err)
 	assert.NotNil(t, ch)
 	shardCh := NewMockShardChannel(ctrl)
 	ch1 := ch.(*databaseChannel)
@@ -108,22 +164,209 @@ func TestDatabaseChannel_CreateChannel(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDatabaseChannel_SyncShardRouting(t *testing.T) {
+	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}}
+	ch, err := newDatabaseChannel(context.TODO(),
+		models.Database{
+			Name:       "database",
+			NumOfShard: 1,
+			Option:     opt,
+		}, 2, nil, nil)
+	assert.NoError(t, err)
+	ch1 := ch.(*databaseChannel)
+	// target shard of split isn't picked by jump consistent hash before routing synced
+	assert.Equal(t, metric.ShardRouting{NumOfShards: 1}, ch1.routing.Load())
+
+	routing := metric.ShardRouting{NumOfShards: 1, Splits: []models.ShardSplit{{Source: 0, Target: 1}}}
+	ch.SyncShardRouting(routing)
+	assert.Equal(t, routing, ch1.routing.Load())
+}
+
 func TestDatabaseChannel_Stop(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}}
-	ch := newDatabaseChannel(context.TODO(),
+	ch, err := newDatabaseChannel(context.TODO(),
 		models.Database{
 			Name:   "database",
 			Option: opt,
-		}, 4, nil)
+		}, 4, nil, nil)
+	assert.NoError(t, err)
+	shardCh := NewMockShardChannel(ctrl)
+	ch1 := ch.(*databaseChannel)
+	ch1.insertShardChannel(models.ShardID(0), shardCh)
+
+	shardCh.EXPECT().garbageCollect(gomock.Any(), gomock.Any())
+	ch.garbageCollect()
+
+	shardCh.EXPECT().Stop()
+	ch.Stop()
+}
+
+func TestDatabaseChannel_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}}
+	ch, err := newDatabaseChannel(context.TODO(),
+		models.Database{
+			Name:   "database",
+			Option: opt,
+		}, 4, nil, nil)
+	assert.NoError(t, err)
+	assert.NoError(t, ch.Flush(context.TODO()))
+
+	shardCh := NewMockShardChannel(ctrl)
+	ch.(*databaseChannel).insertShardChannel(models.ShardID(0), shardCh)
+	shardCh.EXPECT().Flush(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, ch.Flush(context.TODO()))
+	shardCh.EXPECT().Flush(gomock.Any()).Return(nil)
+	assert.NoError(t, ch.Flush(context.TODO()))
+}
+
+func TestDatabaseChannel_PreAggregation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// pre-aggregation disabled for lww database
+	ch, err := newDatabaseChannel(context.TODO(),
+		models.Database{
+			Name: "lww-database",
+			Option: &option.DatabaseOption{
+				Intervals:      option.Intervals{{Interval: 10 * 1000}},
+				WriteSemantics: option.WriteSemanticsLastWriteWins,
+				PreAggregation: &option.PreAggregationOption{Window: "1s"},
+			},
+		}, 1, nil, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ch.(*databaseChannel).preAggregator)
+
+	ch, err = newDatabaseChannel(context.TODO(),
+		models.Database{
+			Name: "pre-agg-database",
+			Option: &option.DatabaseOption{
+				Intervals:      option.Intervals{{Interval: 10 * 1000}},
+				PreAggregation: &option.PreAggregationOption{Window: "1h"},
+			},
+		}, 1, nil, nil)
+	assert.NoError(t, err)
+	ch1 := ch.(*databaseChannel)
+	assert.NotNil(t, ch1.preAggregator)
 	shardCh := NewMockShardChannel(ctrl)
+	ch1.insertShardChannel(models.ShardID(0), shardCh)
+	familyChannel := NewMockFamilyChannel(ctrl)
+	shardCh.EXPECT().GetOrCreateFamilyChannel(gomock.Any()).Return(familyChannel).AnyTimes()
+
+	// batch with idempotency token bypasses pre-aggregation
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, rows []metric.BrokerRow) error {
+			assert.Len(t, rows, 2)
+			return nil
+		})
+	err = ch.Write(WithIdempotencyToken(context.TODO(), "token"), newPreAggregateTestRows(t, "1.1.1.1", "1.1.1.1"))
+	assert.NoError(t, err)
+
+	// rows are buffered in window
+	err = ch.Write(context.TODO(), newPreAggregateTestRows(t, "1.1.1.1", "1.1.1.1"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, ch1.preAggregator.aggregator.Len())
+
+	// buffered rows are flushed after folded when stopping
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, rows []metric.BrokerRow) error {
+			assert.Len(t, rows, 1)
+			m := rows[0].Metric()
+			var sf flatMetricsV1.SimpleField
+			assert.True(t, m.SimpleFields(&sf, 0))
+			assert.Equal(t, float64(2), sf.Value())
+			return nil
+		})
+	shardCh.EXPECT().Stop()
+	ch.Stop()
+}
+
+func TestDatabaseChannel_WAL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	dir := t.TempDir()
+	defer func() {
+		openChannelWALFn = newChannelWAL
+		redeliverInterval = time.Second
+		ctrl.Finish()
+	}()
+	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}, BrokerWAL: true}
+	db := models.Database{Name: "database", Option: opt}
+	openChannelWALFn = func(_ string, _ int64, _ string) (*channelWAL, error) {
+		return nil, fmt.Errorf("err")
+	}
+	ch, err := newDatabaseChannel(context.TODO(), db, 1, nil, nil)
+	assert.Error(t, err)
+	assert.Nil(t, ch)
+
+	openChannelWALFn = func(_ string, dataSizeLimit int64, database string) (*channelWAL, error) {
+		return newChannelWAL(dir, dataSizeLimit, database)
+	}
+	redeliverInterval = 10 * time.Millisecond
+	ch, err = newDatabaseChannel(context.TODO(), db, 1, nil, nil)
+	assert.NoError(t, err)
 	ch1 := ch.(*databaseChannel)
+	shardCh := NewMockShardChannel(ctrl)
 	ch1.insertShardChannel(models.ShardID(0), shardCh)
+	familyChannel := NewMockFamilyChannel(ctrl)
+	shardCh.EXPECT().GetOrCreateFamilyChannel(gomock.Any()).Return(familyChannel).AnyTimes()
+
+	// write successfully
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, _ []metric.BrokerRow) error {
+			ref, ok := walRefFromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, int64(0), ref.seq)
+			assert.Equal(t, "token", IdempotencyTokenFromContext(ctx))
+			return nil
+		})
+	err = ch.Write(WithIdempotencyToken(context.TODO(), "token"), newWALTestRows(t, "cpu"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ch1.wal.ackSeq)
+
+	// write failure, rows are re-delivered
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	err = ch.Write(context.TODO(), newWALTestRows(t, "cpu"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), ch1.wal.ackSeq)
+	familyChannel.EXPECT().Write(gomock.Any(), gomock.Any()).Return(nil)
+	ch.startRedeliver()
+	ch.startRedeliver()
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int64(1), ch1.wal.ackSeq)
 
 	shardCh.EXPECT().garbageCollect(gomock.Any(), gomock.Any())
 	ch.garbageCollect()
+	assert.Equal(t, int64(1), ch1.wal.q.AcknowledgedSeq())
 
 	shardCh.EXPECT().Stop()
 	ch.Stop()
 }
+
+func TestDatabaseChannel_Write_OutOfTimeRange(t *testing.T) {
+	opt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}, Behind: "1h"}
+	ch, err := newDatabaseChannel(context.TODO(),
+		models.Database{
+			Name:   "out-of-range-database",
+			Option: opt,
+		}, 1, nil, nil)
+	assert.NoError(t, err)
+
+	converter := metric.NewProtoConverter()
+	batch := metric.NewBrokerBatchRows()
+	_ = batch.TryAppend(func(row *metric.BrokerRow) error {
+		return converter.ConvertTo(&protoMetricsV1.Metric{
+			Name:      "cpu",
+			Timestamp: timeutil.Now() - 2*timeutil.OneHour,
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+			Tags: []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+		}, row)
+	})
+	err = ch.Write(context.TODO(), batch)
+	assert.True(t, errors.Is(err, constants.ErrOutOfTimeRange))
+	assert.Equal(t, errcode.OutOfTimeRange, errcode.CodeOf(err))
+	assert.Equal(t, float64(1), ch.(*databaseChannel).statistics.OutOfTimeRange.Get())
+}
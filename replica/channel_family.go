@@ -46,6 +46,8 @@ type FamilyChannel interface {
 	// leaderChanged notifies family shardChannel need change leader send stream
 	leaderChanged(shardState models.ShardState,
 		liveNodes map[models.NodeID]models.StatefulNode)
+	// Flush flushes the rows buffered in chunk, then waits until the chunks sent are acknowledged by storage.
+	Flush(ctx context.Context) error
 	// Stop stops the family shardChannel.
 	Stop(timeout int64)
 	// FamilyTime returns the family time of current shardChannel.
@@ -54,6 +56,12 @@ type FamilyChannel interface {
 	isExpire(ahead, behind int64) bool
 }
 
+// flushRequest represents the request of flushing family channel, done receives the result of acknowledgements.
+type flushRequest struct {
+	ctx  context.Context
+	done chan error
+}
+
 // familyChannel implements FamilyChannel interface.
 type familyChannel struct {
 	// context to close shardChannel
@@ -67,7 +75,7 @@ type familyChannel struct {
 		ctx context.Context,
 		target models.Node,
 		database string, shardState *models.ShardState, familyTime int64,
-		fct rpc.ClientStreamFactory,
+		fct rpc.ClientStreamFactory, onAck rpc.AckFn,
 	) (rpc.WriteStream, error)
 
 	fct           rpc.ClientStreamFactory
@@ -78,14 +86,23 @@ type familyChannel struct {
 	// shardChannel to convert multiple goroutine writeTask to single goroutine writeTask to FanOutQueue
 	ch                  chan *compressedChunk
 	leaderChangedSignal chan struct{}
+	flushSignal         chan *flushRequest
 	stoppedSignal       chan struct{}
 	stoppingSignal      chan struct{}
-	chunk               Chunk // buffer current writeTask metric for compress
+	chunk               Chunk    // buffer current writeTask metric for compress
+	walRefs             []walRef // wal references of rows buffered in chunk, guarded by lock4write
+	codec               ChunkCodec
+	batchSize           int       // bytes of rows buffered in chunk, guarded by lock4write
+	batchStart          time.Time // time of first row buffered in chunk, guarded by lock4write
+
+	// wal references of compressed chunks waiting for sending
+	chunkWALRefs map[*compressedChunk][]walRef
+	lock4wal     sync.Mutex
 
 	lastFlushTime      *atomic.Int64 // last flush time
 	checkFlushInterval time.Duration // interval for check flush
 	batchTimeout       time.Duration // interval for flush
-	maxRetryBuf        int
+	retryBuf           *retryBuffer  // bounds messages buffered for retrying, shared by families of shard
 
 	lock4write sync.Mutex
 	lock4meta  sync.Mutex
@@ -97,14 +114,22 @@ type familyChannel struct {
 func newFamilyChannel(
 	ctx context.Context,
 	cfg config.Write,
+	codec ChunkCodec,
 	database string,
 	shardID models.ShardID,
 	familyTime int64,
 	fct rpc.ClientStreamFactory,
 	shardState models.ShardState,
 	liveNodes map[models.NodeID]models.StatefulNode,
+	retryBuf *retryBuffer,
 ) FamilyChannel {
 	c, cancel := context.WithCancel(ctx)
+	// chunks may be sent to any replica after leader changed, compress them by the codec supported by all replicas
+	codec = negotiateCodec(codec, shardState, liveNodes)
+	checkFlushInterval := time.Second
+	if batchTimeout := cfg.BatchTimeout.Duration(); batchTimeout > 0 && batchTimeout < checkFlushInterval {
+		checkFlushInterval = batchTimeout
+	}
 	fc := &familyChannel{
 		ctx:                 c,
 		cancel:              cancel,
@@ -117,12 +142,15 @@ func newFamilyChannel(
 		newWriteStreamFn:    rpc.NewWriteStream,
 		ch:                  make(chan *compressedChunk, 2),
 		leaderChangedSignal: make(chan struct{}, 1),
+		flushSignal:         make(chan *flushRequest),
 		stoppedSignal:       make(chan struct{}, 1),
 		stoppingSignal:      make(chan struct{}, 1),
-		checkFlushInterval:  time.Second,
+		checkFlushInterval:  checkFlushInterval,
 		batchTimeout:        cfg.BatchTimeout.Duration(),
-		maxRetryBuf:         100, // TODO add config
-		chunk:               newChunk(cfg.BatchBlockSize),
+		retryBuf:            retryBuf,
+		codec:               codec,
+		chunk:               newChunk(cfg.BatchBlockSize, codec),
+		chunkWALRefs:        make(map[*compressedChunk][]walRef),
 		lastFlushTime:       atomic.NewInt64(timeutil.Now()),
 		statistics:          metrics.NewBrokerFamilyWriteStatistics(database),
 		logger:              logger.GetLogger("Replica", "FamilyChannel"),
@@ -155,16 +183,44 @@ func (fc *familyChannel) Write(ctx context.Context, rows []metric.BrokerRow) err
 		fc.lock4write.Unlock()
 	}()
 
+	if ref, ok := walRefFromContext(ctx); ok {
+		// rows of wal entry are buffered in chunk, reference is released after chunk acknowledged by storage
+		ref.wal.retain(ref.seq)
+		fc.walRefs = append(fc.walRefs, ref)
+	}
+	token := IdempotencyTokenFromContext(ctx)
+	if token != "" {
+		// token record marks the following rows, rows of batch are kept in the same chunk,
+		// so that storage can skip the whole batch if token is duplicated.
+		written := 0
+		for idx := 0; idx < total; idx++ {
+			if rows[idx].Size() > 0 {
+				written++
+			}
+		}
+		n, err := metric.WriteIdempotencyToken(fc.chunk, token, written)
+		if err != nil {
+			return err
+		}
+		fc.addBatchSize(n)
+	}
 	for idx := 0; idx < total; idx++ {
-		if _, err := rows[idx].WriteTo(fc.chunk); err != nil {
+		n, err := rows[idx].WriteTo(fc.chunk)
+		if err != nil {
 			return err
 		}
+		fc.addBatchSize(n)
 
-		if err := fc.flushChunkOnFull(ctx); err != nil {
-			return err
+		if token == "" {
+			if err := fc.flushChunkOnFull(ctx); err != nil {
+				return err
+			}
 		}
 		success++
 	}
+	if token != "" {
+		return fc.flushChunkOnFull(ctx)
+	}
 
 	return nil
 }
@@ -187,15 +243,17 @@ func (fc *familyChannel) flushChunkOnFull(ctx context.Context) error {
 	if !fc.chunk.IsFull() {
 		return nil
 	}
-	compressed, err := fc.chunk.Compress()
+	compressed, err := fc.compressChunk()
 	if err != nil {
 		return err
 	}
 
 	select {
 	case <-ctx.Done(): // timeout of http ingestion api
+		abandonWALRefs(fc.takeChunkWALRefs(compressed))
 		return ErrIngestTimeout
 	case <-fc.ctx.Done():
+		abandonWALRefs(fc.takeChunkWALRefs(compressed))
 		return ErrFamilyChannelCanceled
 	case fc.ch <- compressed:
 		fc.lastFlushTime.Store(timeutil.Now())
@@ -209,17 +267,33 @@ func (fc *familyChannel) writeTask(_ context.Context) {
 	ticker := time.NewTicker(fc.checkFlushInterval)
 	defer ticker.Stop()
 
-	retryBuffers := make([]*compressedChunk, 0)
+	var retryBuffers []retryEntry
 	retry := func(compressed *compressedChunk) {
-		if len(retryBuffers) > fc.maxRetryBuf {
-			fc.logger.Error("too many retry messages, drop current message")
+		if compressed == nil {
+			return
+		}
+		size := len(*compressed)
+		if !fc.retryBuf.acquire(size) {
+			fc.logger.Error("retry buffer of shard is full, drop current message",
+				logger.String("database", fc.database),
+				logger.Any("shard", fc.shardID))
 			fc.statistics.RetryDrop.Incr()
-		} else {
-			retryBuffers = append(retryBuffers, compressed)
-			fc.statistics.Retry.Incr()
+			// rows of wal entry are re-delivered
+			abandonWALRefs(fc.takeChunkWALRefs(compressed))
+			return
 		}
+		retryBuffers = append(retryBuffers, retryEntry{chunk: compressed, size: size, bufferedAt: timeutil.Now()})
+		fc.statistics.Retry.Incr()
 	}
 	var stream rpc.WriteStream
+	var acks *streamAcks // acks of chunks sent via current stream
+	var transcode bool   // chunks are transcoded into snappy if current leader doesn't support the codec
+	closeAcks := func() {
+		if acks != nil {
+			acks.close()
+			acks = nil
+		}
+	}
 	send := func(compressed *compressedChunk) bool {
 		if compressed == nil {
 			return true
@@ -234,16 +308,47 @@ func (fc *familyChannel) writeTask(_ context.Context) {
 			shardState := fc.shardState
 			fc.currentTarget = &leader
 			fc.lock4meta.Unlock()
-			s, err := fc.newWriteStreamFn(fc.ctx, fc.currentTarget, fc.database, &shardState, fc.familyTime, fc.fct)
+			streamCodec := fc.codec
+			if !fc.codec.SupportedBy(&leader) {
+				// old storage node, degrades to snappy
+				streamCodec = snappyCodec
+			}
+			streamAcks := newStreamAcks()
+			s, err := fc.newWriteStreamFn(rpc.WithWriteCompression(fc.ctx, streamCodec.String()),
+				fc.currentTarget, fc.database, &shardState, fc.familyTime, fc.fct, streamAcks.ack)
 			if err != nil {
 				fc.statistics.CreateStreamFailures.Incr()
-				retry(compressed)
 				return false
 			}
 			fc.statistics.CreateStream.Incr()
+			if streamCodec != fc.codec {
+				fc.statistics.CompressionFallback.Incr()
+				fc.logger.Warn("storage node doesn't support the codec of chunk, send snappy chunk instead",
+					logger.String("target", fc.currentTarget.Indicator()),
+					logger.String("database", fc.database),
+					logger.String("codec", fc.codec.String()))
+			}
 			stream = s
+			acks = streamAcks
+			transcode = streamCodec != fc.codec
 		}
-		if err := stream.Send(*compressed); err != nil {
+		data := *compressed
+		if transcode {
+			var err error
+			if data, err = fc.codec.ToSnappy(data); err != nil {
+				fc.logger.Error("transcode chunk into snappy failure, drop current message",
+					logger.String("database", fc.database),
+					logger.Error(err))
+				abandonWALRefs(fc.takeChunkWALRefs(compressed))
+				compressed.Release()
+				return true
+			}
+		}
+		refs := fc.takeChunkWALRefs(compressed)
+		acks.push(refs)
+		if err := stream.Send(data); err != nil {
+			// keep wal references with chunk for retrying
+			fc.putChunkWALRefs(compressed, acks.cancelLast())
 			fc.statistics.SendFailure.Incr()
 			fc.logger.Error(
 				"failed writing compressed chunk to storage",
@@ -260,17 +365,38 @@ func (fc *familyChannel) writeTask(_ context.Context) {
 					fc.statistics.CloseStream.Incr()
 				}
 				stream = nil
+				closeAcks()
 			}
-			// retry if err
-			retry(compressed)
 			return false
 		}
 		fc.statistics.SendSuccess.Incr()
-		fc.statistics.SendSize.Add(float64(len(*compressed)))
+		fc.statistics.SendSize.Add(float64(len(data)))
 		fc.statistics.PendingSend.Decr()
 		compressed.Release()
 		return true
 	}
+	// retryPending sends buffered messages in order, expired messages are dropped,
+	// returns false if shard is still unavailable.
+	retryPending := func() bool {
+		now := timeutil.Now()
+		for len(retryBuffers) > 0 {
+			entry := retryBuffers[0]
+			if fc.retryBuf.isExpired(entry, now) {
+				fc.statistics.RetryExpire.Incr()
+				// rows of wal entry are re-delivered
+				abandonWALRefs(fc.takeChunkWALRefs(entry.chunk))
+			} else if !send(entry.chunk) {
+				return false
+			}
+			fc.retryBuf.release(entry.size)
+			retryBuffers = retryBuffers[1:]
+		}
+		return true
+	}
+	resetStream := func() {
+		stream = nil
+		closeAcks()
+	}
 
 	defer func() {
 		if stream != nil {
@@ -281,6 +407,13 @@ func (fc *familyChannel) writeTask(_ context.Context) {
 				fc.statistics.CloseStream.Incr()
 			}
 		}
+		closeAcks()
+		// rows of wal entry not sent are re-delivered
+		for _, entry := range retryBuffers {
+			fc.statistics.RetryDrop.Incr()
+			abandonWALRefs(fc.takeChunkWALRefs(entry.chunk))
+			fc.retryBuf.release(entry.size)
+		}
 	}()
 
 	// send pending in buffer before stop channel.
@@ -291,12 +424,16 @@ func (fc *familyChannel) writeTask(_ context.Context) {
 		sendLastMsg := func(compressed *compressedChunk) {
 			if !send(compressed) {
 				fc.logger.Error("send message failure before close channel, message lost")
+				abandonWALRefs(fc.takeChunkWALRefs(compressed))
 			}
 		}
+		if !retryPending() {
+			fc.logger.Error("send buffered messages failure before close channel, messages lost")
+		}
 		// flush chunk pending data if chunk not empty
 		if !fc.chunk.IsEmpty() {
 			// flush chunk pending data if chunk not empty
-			compressed, err0 := fc.chunk.Compress()
+			compressed, err0 := fc.compressChunk()
 			if err0 != nil {
 				fc.logger.Error("compress chunk err when send last chunk data", logger.Error(err0))
 			} else {
@@ -304,6 +441,10 @@ func (fc *familyChannel) writeTask(_ context.Context) {
 			}
 		}
 		fc.sendPendingMessage(sendLastMsg)
+		if acks != nil {
+			// wait storage acknowledges the rows of wal entry before closing stream
+			acks.wait(fc.ctx)
+		}
 	}
 	var err error
 	for {
@@ -323,26 +464,51 @@ func (fc *familyChannel) writeTask(_ context.Context) {
 				if err = stream.Close(); err != nil {
 					fc.logger.Error("close write stream err when leader changed", logger.Error(err))
 				}
-				stream = nil
+				resetStream()
+			}
+			// retry buffered messages via new leader
+			if !retryPending() {
+				resetStream()
 			}
 		case compressed := <-fc.ch:
-			if send(compressed) {
-				// if send ok, retry pending message
-				if len(retryBuffers) > 0 {
-					messages := retryBuffers
-					retryBuffers = make([]*compressedChunk, 0)
-					for _, msg := range messages {
-						if !send(msg) {
-							retry(msg)
-						}
+			// keep the order of messages, new message is buffered if shard is still unavailable
+			if !retryPending() || !send(compressed) {
+				retry(compressed)
+				resetStream()
+			}
+		case req := <-fc.flushSignal:
+			// send the chunks flushed before request, then wait acknowledgements in background
+			for sending := true; sending; {
+				select {
+				case compressed := <-fc.ch:
+					if !retryPending() || !send(compressed) {
+						retry(compressed)
+						resetStream()
 					}
+				default:
+					sending = false
 				}
-			} else {
-				stream = nil
 			}
+			if !retryPending() {
+				// shard is still unavailable, messages are buffered for retrying
+				resetStream()
+				req.done <- errFlushNotAcknowledged
+				continue
+			}
+			if acks == nil {
+				req.done <- nil
+				continue
+			}
+			go func(acks *streamAcks) {
+				req.done <- acks.waitAll(req.ctx)
+			}(acks)
 		case <-ticker.C:
 			// check
 			fc.checkFlush()
+			// retry buffered messages if shard recovered
+			if len(retryBuffers) > 0 && !retryPending() {
+				resetStream()
+			}
 		}
 	}
 }
@@ -369,6 +535,31 @@ func (fc *familyChannel) checkFlush() {
 	}
 }
 
+// Flush flushes the rows buffered in chunk, then waits until the chunks sent are acknowledged by storage.
+func (fc *familyChannel) Flush(ctx context.Context) error {
+	fc.lock4write.Lock()
+	if !fc.chunk.IsEmpty() {
+		fc.flushChunk()
+		fc.lastFlushTime.Store(timeutil.Now())
+	}
+	fc.lock4write.Unlock()
+
+	req := &flushRequest{ctx: ctx, done: make(chan error, 1)}
+	select {
+	case fc.flushSignal <- req:
+	case <-fc.ctx.Done():
+		return ErrFamilyChannelCanceled
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // Stop stops current write family shardChannel.
 func (fc *familyChannel) Stop(timeout int64) {
 	close(fc.stoppingSignal)
@@ -386,7 +577,7 @@ func (fc *familyChannel) Stop(timeout int64) {
 
 // flushChunk flushes the chunk data and appends data into queue
 func (fc *familyChannel) flushChunk() {
-	compressed, err := fc.chunk.Compress()
+	compressed, err := fc.compressChunk()
 	if err != nil {
 		fc.logger.Error("compress chunk err", logger.Error(err))
 		return
@@ -399,7 +590,64 @@ func (fc *familyChannel) flushChunk() {
 		fc.statistics.PendingSend.Incr()
 	case <-fc.ctx.Done():
 		fc.logger.Warn("writer is canceled")
+		abandonWALRefs(fc.takeChunkWALRefs(compressed))
+	}
+}
+
+// addBatchSize adds the bytes of rows buffered in chunk, records the time of first row buffered.
+func (fc *familyChannel) addBatchSize(n int) {
+	if fc.batchSize == 0 {
+		fc.batchStart = time.Now()
+	}
+	fc.batchSize += n
+}
+
+// compressChunk compresses the data of chunk, wal references of rows are moved to compressed chunk.
+func (fc *familyChannel) compressChunk() (*compressedChunk, error) {
+	start := time.Now()
+	compressed, err := fc.chunk.Compress()
+	if err == nil && compressed != nil && len(*compressed) > 0 && fc.batchSize > 0 {
+		fc.statistics.Chunks.Incr()
+		fc.statistics.ChunkRawSize.Add(float64(fc.batchSize))
+		fc.statistics.ChunkCompressedSize.Add(float64(len(*compressed)))
+		fc.statistics.CompressionRatio.Update(float64(fc.batchSize) / float64(len(*compressed)))
+		fc.statistics.CompressDuration.UpdateSince(start)
+		fc.statistics.BatchDuration.UpdateSince(fc.batchStart)
+	}
+	fc.batchSize = 0
+	refs := fc.walRefs
+	fc.walRefs = nil
+	if len(refs) > 0 {
+		if err != nil || compressed == nil || len(*compressed) == 0 {
+			// rows lost, re-deliver them
+			abandonWALRefs(refs)
+		} else {
+			fc.putChunkWALRefs(compressed, refs)
+		}
+	}
+	return compressed, err
+}
+
+// putChunkWALRefs keeps the wal references of compressed chunk.
+func (fc *familyChannel) putChunkWALRefs(compressed *compressedChunk, refs []walRef) {
+	if len(refs) == 0 {
+		return
+	}
+	fc.lock4wal.Lock()
+	fc.chunkWALRefs[compressed] = refs
+	fc.lock4wal.Unlock()
+}
+
+// takeChunkWALRefs takes the wal references of compressed chunk.
+func (fc *familyChannel) takeChunkWALRefs(compressed *compressedChunk) []walRef {
+	fc.lock4wal.Lock()
+	defer fc.lock4wal.Unlock()
+
+	refs, ok := fc.chunkWALRefs[compressed]
+	if ok {
+		delete(fc.chunkWALRefs, compressed)
 	}
+	return refs
 }
 
 // isExpire returns if current family is expired.
@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/golang/snappy"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
 
@@ -35,19 +36,21 @@ import (
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series/metric"
 )
 
 func TestFamilyChannel_new(t *testing.T) {
-	f := newFamilyChannel(context.TODO(), config.Write{}, "db", 1,
-		1, nil, models.ShardState{}, nil)
+	f := newFamilyChannel(context.TODO(), config.Write{}, snappyCodec, "db", 1,
+		1, nil, models.ShardState{}, nil, newRetryBuffer("db", 1, config.Write{}))
 	assert.NotNil(t, f)
 	f.Stop(10)
 
-	f = newFamilyChannel(context.TODO(), config.Write{}, "db", 1,
-		1, nil, models.ShardState{}, nil)
+	f = newFamilyChannel(context.TODO(), config.Write{}, snappyCodec, "db", 1,
+		1, nil, models.ShardState{}, nil, newRetryBuffer("db", 1, config.Write{}))
 	assert.NotNil(t, f)
 	go func() {
 		time.Sleep(100 * time.Millisecond)
@@ -74,6 +77,7 @@ func TestFamilyChannel_Write(t *testing.T) {
 
 	cases := []struct {
 		name    string
+		ctx     context.Context
 		rows    []metric.BrokerRow
 		prepare func()
 		wantErr bool
@@ -109,6 +113,26 @@ func TestFamilyChannel_Write(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "write idempotency token failure",
+			ctx:  WithIdempotencyToken(context.TODO(), "token"),
+			rows: []metric.BrokerRow{brokerRow},
+			prepare: func() {
+				chunk.EXPECT().Write(gomock.Any()).Return(0, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "batch with idempotency token successfully",
+			ctx:  WithIdempotencyToken(context.TODO(), "token"),
+			rows: []metric.BrokerRow{brokerRow, brokerRow},
+			prepare: func() {
+				// token record + rows, check chunk full after whole batch written
+				chunk.EXPECT().Write(gomock.Any()).Return(0, nil).Times(3)
+				chunk.EXPECT().IsFull().Return(false)
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range cases {
@@ -124,7 +148,11 @@ func TestFamilyChannel_Write(t *testing.T) {
 				tt.prepare()
 			}
 
-			err := ch.Write(context.TODO(), tt.rows)
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.TODO()
+			}
+			err := ch.Write(ctx, tt.rows)
 
 			if (err != nil) != tt.wantErr {
 				t.Errorf("Write() error = %v, wantErr %v", err, tt.wantErr)
@@ -205,6 +233,61 @@ func TestFamilyChannel_flushChunkOnFull(t *testing.T) {
 	assert.Equal(t, ErrFamilyChannelCanceled, f.flushChunkOnFull(context.TODO()))
 }
 
+func TestFamilyChannel_walRefs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		ctrl.Finish()
+	}()
+
+	w, err := newChannelWAL(t.TempDir(), 1024*1024, "db")
+	assert.NoError(t, err)
+	defer w.close()
+	rows := newWALTestRows(t, "cpu").Rows()
+	for i := 0; i < 2; i++ {
+		_, _, err = w.append("", rows)
+		assert.NoError(t, err)
+	}
+	chunk := NewMockChunk(ctrl)
+	chunk.EXPECT().IsFull().Return(true).AnyTimes()
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	f := &familyChannel{
+		ctx:           ctx,
+		cancel:        cancel,
+		chunk:         chunk,
+		chunkWALRefs:  make(map[*compressedChunk][]walRef),
+		lastFlushTime: atomic.NewInt64(timeutil.Now()),
+		ch:            make(chan *compressedChunk, 1),
+		statistics:    metrics.NewBrokerFamilyWriteStatistics("db"),
+		logger:        logger.GetLogger("Replica", "Test"),
+	}
+	// compress failure, rows re-delivered
+	f.walRefs = []walRef{{wal: w, seq: 0}}
+	chunk.EXPECT().Compress().Return(nil, fmt.Errorf("err"))
+	_, err = f.compressChunk()
+	assert.Error(t, err)
+	assert.Equal(t, 1, w.entries[0].abandoned)
+	assert.Empty(t, f.walRefs)
+
+	// references moved to compressed chunk
+	f.walRefs = []walRef{{wal: w, seq: 1}}
+	chunk.EXPECT().Compress().Return(&compressedChunk{1, 2, 3}, nil)
+	assert.NoError(t, f.flushChunkOnFull(context.TODO()))
+	compressed := <-f.ch
+	assert.Equal(t, []walRef{{wal: w, seq: 1}}, f.takeChunkWALRefs(compressed))
+	assert.Empty(t, f.takeChunkWALRefs(compressed))
+
+	// ingestion timeout, rows re-delivered
+	ctx1, cancel1 := context.WithCancel(context.TODO())
+	cancel1()
+	f.ch = make(chan *compressedChunk)
+	f.walRefs = []walRef{{wal: w, seq: 1}}
+	chunk.EXPECT().Compress().Return(&compressedChunk{1, 2, 3}, nil)
+	assert.Equal(t, ErrIngestTimeout, f.flushChunkOnFull(ctx1))
+	assert.Equal(t, 1, w.entries[1].abandoned)
+	assert.Empty(t, f.chunkWALRefs)
+}
+
 func TestFamilyChannel_isExpire(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.TODO())
 	f := &familyChannel{
@@ -326,7 +409,7 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				chunk.EXPECT().Compress().Return(&compressedChunk{1, 2, 3}, nil)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return nil, fmt.Errorf("err")
 				}
 				go func() {
@@ -344,7 +427,7 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				stream := rpc.NewMockWriteStream(ctrl)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return stream, nil
 				}
 				stream.EXPECT().Close()
@@ -364,7 +447,7 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				stream := rpc.NewMockWriteStream(ctrl)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return stream, nil
 				}
 				stream.EXPECT().Close().Return(nil)
@@ -384,7 +467,7 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				stream := rpc.NewMockWriteStream(ctrl)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return stream, nil
 				}
 				stream.EXPECT().Close().Return(fmt.Errorf("err"))
@@ -404,7 +487,7 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				stream := rpc.NewMockWriteStream(ctrl)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return stream, nil
 				}
 				stream.EXPECT().Close().Return(fmt.Errorf("err"))
@@ -423,7 +506,7 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				lastCh := make(chan struct{})
 				f.newWriteStreamFn = func(_ context.Context, _ models.Node,
 					_ string, _ *models.ShardState, _ int64,
-					_ rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					_ rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					time.Sleep(100 * time.Millisecond)
 					return nil, fmt.Errorf("err")
 				}
@@ -449,7 +532,7 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				stream := rpc.NewMockWriteStream(ctrl)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return stream, nil
 				}
 				stream.EXPECT().Send(gomock.Any()).Return(nil)
@@ -469,12 +552,12 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 			prepare: func(f *familyChannel) {
 				chunk := NewMockChunk(ctrl)
 				f.chunk = chunk
-				f.maxRetryBuf = 0
+				f.retryBuf = newRetryBuffer("db", 1, config.Write{})
 				chunk.EXPECT().IsEmpty().Return(true).AnyTimes()
 				stream := rpc.NewMockWriteStream(ctrl)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return stream, nil
 				}
 				stream.EXPECT().Send(gomock.Any()).Return(fmt.Errorf("err")).AnyTimes()
@@ -493,12 +576,11 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 			prepare: func(f *familyChannel) {
 				chunk := NewMockChunk(ctrl)
 				f.chunk = chunk
-				f.maxRetryBuf = 1
 				chunk.EXPECT().IsEmpty().Return(true).AnyTimes()
 				stream := rpc.NewMockWriteStream(ctrl)
 				f.newWriteStreamFn = func(ctx context.Context, target models.Node,
 					database string, shardState *models.ShardState, familyTime int64,
-					fct rpc.ClientStreamFactory) (rpc.WriteStream, error) {
+					fct rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
 					return stream, nil
 				}
 				stream.EXPECT().Send(gomock.Any()).Return(fmt.Errorf("err"))
@@ -514,6 +596,103 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 				}()
 			},
 		},
+		{
+			name: "send msg failure, retry buffer full",
+			prepare: func(f *familyChannel) {
+				chunk := NewMockChunk(ctrl)
+				f.chunk = chunk
+				chunk.EXPECT().IsEmpty().Return(true).AnyTimes()
+				f.newWriteStreamFn = func(_ context.Context, _ models.Node,
+					_ string, _ *models.ShardState, _ int64,
+					_ rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
+					return nil, fmt.Errorf("err")
+				}
+				f.ch <- &compressedChunk{1, 2, 3}
+				f.ch <- &compressedChunk{1, 2, 3}
+
+				go func() {
+					time.Sleep(200 * time.Millisecond)
+					assert.Equal(t, int64(3), f.retryBuf.size.Load())
+					f.Stop(10)
+				}()
+			},
+		},
+		{
+			name: "buffered msg expired",
+			prepare: func(f *familyChannel) {
+				chunk := NewMockChunk(ctrl)
+				f.chunk = chunk
+				f.retryBuf.maxAge = 0
+				chunk.EXPECT().IsEmpty().Return(true).AnyTimes()
+				stream := rpc.NewMockWriteStream(ctrl)
+				f.newWriteStreamFn = func(_ context.Context, _ models.Node,
+					_ string, _ *models.ShardState, _ int64,
+					_ rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
+					return stream, nil
+				}
+				stream.EXPECT().Send(gomock.Any()).Return(fmt.Errorf("err"))
+				stream.EXPECT().Close().Return(nil).AnyTimes()
+				f.ch <- &compressedChunk{1, 2, 3}
+
+				go func() {
+					time.Sleep(200 * time.Millisecond)
+					assert.Equal(t, int64(0), f.retryBuf.size.Load())
+					f.Stop(10)
+				}()
+			},
+		},
+		{
+			name: "retry buffered msg after leader changed",
+			prepare: func(f *familyChannel) {
+				chunk := NewMockChunk(ctrl)
+				f.chunk = chunk
+				f.checkFlushInterval = time.Hour
+				chunk.EXPECT().IsEmpty().Return(true).AnyTimes()
+				stream := rpc.NewMockWriteStream(ctrl)
+				f.newWriteStreamFn = func(_ context.Context, _ models.Node,
+					_ string, _ *models.ShardState, _ int64,
+					_ rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
+					return stream, nil
+				}
+				stream.EXPECT().Send(gomock.Any()).Return(io.EOF)
+				stream.EXPECT().Send(gomock.Any()).Return(nil)
+				stream.EXPECT().Close().Return(nil).AnyTimes()
+				f.ch <- &compressedChunk{1, 2, 3}
+
+				go func() {
+					time.Sleep(100 * time.Millisecond)
+					assert.Equal(t, int64(3), f.retryBuf.size.Load())
+					f.leaderChangedSignal <- struct{}{}
+					time.Sleep(100 * time.Millisecond)
+					assert.Equal(t, int64(0), f.retryBuf.size.Load())
+					f.Stop(10)
+				}()
+			},
+		},
+		{
+			name: "retry buffered msg after shard recovered",
+			prepare: func(f *familyChannel) {
+				chunk := NewMockChunk(ctrl)
+				f.chunk = chunk
+				chunk.EXPECT().IsEmpty().Return(true).AnyTimes()
+				stream := rpc.NewMockWriteStream(ctrl)
+				f.newWriteStreamFn = func(_ context.Context, _ models.Node,
+					_ string, _ *models.ShardState, _ int64,
+					_ rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
+					return stream, nil
+				}
+				stream.EXPECT().Send(gomock.Any()).Return(fmt.Errorf("err"))
+				stream.EXPECT().Send(gomock.Any()).Return(nil)
+				stream.EXPECT().Close().Return(nil).AnyTimes()
+				f.ch <- &compressedChunk{1, 2, 3}
+
+				go func() {
+					time.Sleep(300 * time.Millisecond)
+					assert.Equal(t, int64(0), f.retryBuf.size.Load())
+					f.Stop(10)
+				}()
+			},
+		},
 	}
 
 	for _, tt := range cases {
@@ -521,10 +700,13 @@ func TestFamilyChannel_writeTask(t *testing.T) {
 		t.Run(tt.name, func(_ *testing.T) {
 			ctx, cancel := context.WithCancel(context.TODO())
 			f := &familyChannel{
-				cancel:              cancel,
-				ctx:                 ctx,
-				ch:                  make(chan *compressedChunk, 2),
-				maxRetryBuf:         1,
+				cancel: cancel,
+				ctx:    ctx,
+				ch:     make(chan *compressedChunk, 2),
+				retryBuf: newRetryBuffer("db", 1, config.Write{
+					RetryBufferSize: 3,
+					RetryBufferAge:  ltoml.Duration(time.Minute),
+				}),
 				checkFlushInterval:  time.Millisecond * 100,
 				lastFlushTime:       atomic.NewInt64(timeutil.Now()),
 				shardState:          models.ShardState{ID: 0, Leader: 1},
@@ -562,3 +744,202 @@ func TestFamilyChannel_sendingLastMessage(t *testing.T) {
 	close(f.ch)
 	wait.Wait()
 }
+
+func TestFamilyChannel_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	newFamily := func() *familyChannel {
+		ctx, cancel := context.WithCancel(context.TODO())
+		chunk := NewMockChunk(ctrl)
+		chunk.EXPECT().IsEmpty().Return(true).AnyTimes()
+		return &familyChannel{
+			cancel: cancel,
+			ctx:    ctx,
+			ch:     make(chan *compressedChunk, 2),
+			chunk:  chunk,
+			retryBuf: newRetryBuffer("db", 1, config.Write{
+				RetryBufferSize: 10,
+				RetryBufferAge:  ltoml.Duration(time.Minute),
+			}),
+			checkFlushInterval:  time.Hour,
+			lastFlushTime:       atomic.NewInt64(timeutil.Now()),
+			shardState:          models.ShardState{ID: 0, Leader: 1},
+			leaderChangedSignal: make(chan struct{}, 1),
+			flushSignal:         make(chan *flushRequest),
+			stoppedSignal:       make(chan struct{}, 1),
+			stoppingSignal:      make(chan struct{}, 1),
+			currentTarget:       &models.StatefulNode{},
+			liveNodes: map[models.NodeID]models.StatefulNode{
+				1: {},
+			},
+			statistics: metrics.NewBrokerFamilyWriteStatistics("db"),
+			logger:     logger.GetLogger("Replica", "Test"),
+		}
+	}
+	run := func(f *familyChannel) func() {
+		done := make(chan struct{})
+		go func() {
+			f.writeTask(context.TODO())
+			close(done)
+		}()
+		return func() {
+			f.Stop(10)
+			<-done
+		}
+	}
+
+	t.Run("nothing sent", func(t *testing.T) {
+		f := newFamily()
+		stop := run(f)
+		defer stop()
+		assert.NoError(t, f.Flush(context.TODO()))
+	})
+	t.Run("wait acknowledged", func(t *testing.T) {
+		f := newFamily()
+		stream := rpc.NewMockWriteStream(ctrl)
+		var ackFn rpc.AckFn
+		f.newWriteStreamFn = func(_ context.Context, _ models.Node,
+			_ string, _ *models.ShardState, _ int64,
+			_ rpc.ClientStreamFactory, fn rpc.AckFn) (rpc.WriteStream, error) {
+			ackFn = fn
+			return stream, nil
+		}
+		stream.EXPECT().Send(gomock.Any()).DoAndReturn(func(_ []byte) error {
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				ackFn(nil)
+			}()
+			return nil
+		})
+		stream.EXPECT().Close().Return(nil)
+		f.ch <- &compressedChunk{1, 2, 3}
+		stop := run(f)
+		defer stop()
+		assert.NoError(t, f.Flush(context.TODO()))
+	})
+	t.Run("shard unavailable", func(t *testing.T) {
+		f := newFamily()
+		f.newWriteStreamFn = func(_ context.Context, _ models.Node,
+			_ string, _ *models.ShardState, _ int64,
+			_ rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
+			return nil, fmt.Errorf("err")
+		}
+		f.ch <- &compressedChunk{1, 2, 3}
+		stop := run(f)
+		defer stop()
+		assert.ErrorIs(t, f.Flush(context.TODO()), errFlushNotAcknowledged)
+	})
+	t.Run("flush timeout", func(t *testing.T) {
+		f := newFamily()
+		stream := rpc.NewMockWriteStream(ctrl)
+		f.newWriteStreamFn = func(_ context.Context, _ models.Node,
+			_ string, _ *models.ShardState, _ int64,
+			_ rpc.ClientStreamFactory, _ rpc.AckFn) (rpc.WriteStream, error) {
+			return stream, nil
+		}
+		stream.EXPECT().Send(gomock.Any()).Return(nil)
+		stream.EXPECT().Close().Return(nil)
+		f.ch <- &compressedChunk{1, 2, 3}
+		stop := run(f)
+		defer stop()
+		ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, f.Flush(ctx), context.DeadlineExceeded)
+	})
+	t.Run("channel canceled", func(t *testing.T) {
+		f := newFamily()
+		f.cancel()
+		assert.ErrorIs(t, f.Flush(context.TODO()), ErrFamilyChannelCanceled)
+	})
+}
+
+func TestFamilyChannel_WriteCompression(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	zstdCodec := ChunkCodec{Compression: option.CompressionZstd, Level: 3}
+	cases := []struct {
+		name        string
+		leader      models.StatefulNode
+		compression string
+		decode      func(data []byte) ([]byte, error)
+	}{
+		{
+			name:        "storage supports codec",
+			leader:      models.StatefulNode{WriteCompressions: WriteCompressions},
+			compression: "zstd:3",
+			decode: func(data []byte) ([]byte, error) {
+				return zstdCodec.Decode(nil, data)
+			},
+		},
+		{
+			name:        "old storage, degrade to snappy",
+			leader:      models.StatefulNode{},
+			compression: "snappy",
+			decode: func(data []byte) ([]byte, error) {
+				return snappy.Decode(nil, data)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.TODO())
+			f := &familyChannel{
+				cancel:              cancel,
+				ctx:                 ctx,
+				ch:                  make(chan *compressedChunk, 2),
+				codec:               zstdCodec,
+				chunk:               newChunk(ltoml.Size(1024*1024), zstdCodec),
+				retryBuf:            newRetryBuffer("db", 1, config.Write{RetryBufferSize: 10}),
+				checkFlushInterval:  time.Hour,
+				lastFlushTime:       atomic.NewInt64(timeutil.Now()),
+				shardState:          models.ShardState{ID: 0, Leader: 1},
+				leaderChangedSignal: make(chan struct{}, 1),
+				flushSignal:         make(chan *flushRequest),
+				stoppedSignal:       make(chan struct{}, 1),
+				stoppingSignal:      make(chan struct{}, 1),
+				chunkWALRefs:        make(map[*compressedChunk][]walRef),
+				liveNodes:           map[models.NodeID]models.StatefulNode{1: tt.leader},
+				statistics:          metrics.NewBrokerFamilyWriteStatistics("db"),
+				logger:              logger.GetLogger("Replica", "Test"),
+			}
+			stream := rpc.NewMockWriteStream(ctrl)
+			var ackFn rpc.AckFn
+			f.newWriteStreamFn = func(ctx context.Context, _ models.Node,
+				_ string, _ *models.ShardState, _ int64,
+				_ rpc.ClientStreamFactory, fn rpc.AckFn) (rpc.WriteStream, error) {
+				assert.Equal(t, tt.compression, rpc.WriteCompressionFromContext(ctx))
+				ackFn = fn
+				return stream, nil
+			}
+			var sent []byte
+			stream.EXPECT().Send(gomock.Any()).DoAndReturn(func(data []byte) error {
+				sent = append([]byte(nil), data...)
+				ackFn(nil)
+				return nil
+			})
+			stream.EXPECT().Close().Return(nil)
+
+			row := makeTestBrokerRows()
+			assert.NoError(t, f.Write(context.TODO(), []metric.BrokerRow{row}))
+			assert.NotZero(t, f.batchSize)
+			done := make(chan struct{})
+			go func() {
+				f.writeTask(context.TODO())
+				close(done)
+			}()
+			assert.NoError(t, f.Flush(context.TODO()))
+			f.Stop(10)
+			<-done
+
+			assert.Zero(t, f.batchSize)
+			raw, err := tt.decode(sent)
+			assert.NoError(t, err)
+			var batch metric.StorageBatchRows
+			batch.UnmarshalRows(raw)
+			assert.Equal(t, 1, batch.Len())
+		})
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 	"github.com/lindb/lindb/coordinator/broker"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/rpc"
 	"github.com/lindb/lindb/series/metric"
 )
@@ -40,6 +42,8 @@ import (
 type ChannelManager interface {
 	// Write writes a MetricList, the manager handler the database, sharding things.
 	Write(ctx context.Context, database string, brokerBatchRows *metric.BrokerBatchRows) error
+	// Flush flushes buffered rows of all databases, waits until acknowledged by storage.
+	Flush(ctx context.Context) error
 
 	// Close closes all the shardChannel.
 	Close()
@@ -103,6 +107,17 @@ func (cm *channelManager) Write(ctx context.Context, database string, brokerBatc
 	return fmt.Errorf("database [%s] not found", database)
 }
 
+// Flush flushes buffered rows of all databases, waits until acknowledged by storage.
+func (cm *channelManager) Flush(ctx context.Context) error {
+	channels := cm.databaseChannels.value.Load().(database2Channel)
+	for _, ch := range channels {
+		if err := ch.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CreateChannel creates a new shardChannel or returns an existed shardChannel for storage with specific database and shardID,
 // numOfShard should be greater or equal than the origin setting, otherwise error is returned.
 // numOfShard is used eot calculate the shardID for a given hash.
@@ -123,7 +138,10 @@ func (cm *channelManager) CreateChannel(databaseCfg models.Database, numOfShard
 		return ch.CreateChannel(numOfShard, shardID)
 	}
 	// if not exist, create database shardChannel
-	ch := newDatabaseChannel(cm.ctx, databaseCfg, numOfShard, cm.fct)
+	ch, err := newDatabaseChannel(cm.ctx, databaseCfg, numOfShard, cm.fct, cm.replicationOptionOf(database))
+	if err != nil {
+		return nil, err
+	}
 
 	// clone databases and creates a new map to hold database channels
 	cm.insertDatabaseChannel(database, ch)
@@ -136,6 +154,17 @@ func (cm *channelManager) CreateChannel(databaseCfg models.Database, numOfShard
 	return ch.CreateChannel(numOfShard, shardID)
 }
 
+// replicationOptionOf returns the function getting the latest replication option of database from state manager,
+// so that changed option applies to the family channels created later without restarting broker.
+func (cm *channelManager) replicationOptionOf(database string) replicationOptionFn {
+	return func() *option.ReplicationOption {
+		if databaseCfg, ok := cm.stateMgr.GetDatabaseCfg(database); ok && databaseCfg.Option != nil {
+			return databaseCfg.Option.Replication
+		}
+		return nil
+	}
+}
+
 // Close closes all the shardChannel.
 func (cm *channelManager) Close() {
 	cm.cancel()
@@ -174,6 +203,18 @@ func (cm *channelManager) handleShardStateChangeEvent(
 	liveNodes map[models.NodeID]models.StatefulNode,
 ) {
 	numOfShard := len(shards)
+	routing := metric.ShardRouting{NumOfShards: int32(numOfShard)}
+	for _, shardState := range shards {
+		if shardState.Split != nil {
+			// target shard of split isn't picked by jump consistent hash
+			routing.NumOfShards--
+			routing.Splits = append(routing.Splits, *shardState.Split)
+		}
+	}
+	// apply splits in creation order(target shard id is increasing)
+	sort.Slice(routing.Splits, func(i, j int) bool {
+		return routing.Splits[i].Target < routing.Splits[j].Target
+	})
 	for _, shardState := range shards {
 		shardID := shardState.ID
 		ch, err := cm.CreateChannel(databaseCfg, int32(numOfShard), shardID)
@@ -184,6 +225,12 @@ func (cm *channelManager) handleShardStateChangeEvent(
 			ch.SyncShardState(shardState, liveNodes)
 		}
 	}
+	if ch, ok := cm.getDatabaseChannel(databaseCfg.Name); ok {
+		// switch routing after the channels of split shards created
+		ch.SyncShardRouting(routing)
+		// shard channels are ready, replays the entries of write ahead log if enabled
+		ch.startRedeliver()
+	}
 }
 
 // gcWriteFamilies recycles write families which is expired.
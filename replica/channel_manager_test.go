@@ -19,6 +19,7 @@ package replica
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path"
 	"testing"
@@ -109,6 +110,26 @@ func TestChannelManager_Write(t *testing.T) {
 	cm.Close()
 }
 
+func TestChannelManager_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	stateMgr.EXPECT().WatchShardStateChangeEvent(gomock.Any())
+	cm := NewChannelManager(context.TODO(), nil, stateMgr)
+	assert.NoError(t, cm.Flush(context.TODO()))
+
+	dbChannel := NewMockDatabaseChannel(ctrl)
+	cm.(*channelManager).insertDatabaseChannel("database", dbChannel)
+	dbChannel.EXPECT().Flush(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, cm.Flush(context.TODO()))
+	dbChannel.EXPECT().Flush(gomock.Any()).Return(nil)
+	assert.NoError(t, cm.Flush(context.TODO()))
+
+	dbChannel.EXPECT().Stop()
+	cm.Close()
+}
+
 func TestChannelManager_handleShardStateChangeEvent(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -119,6 +140,7 @@ func TestChannelManager_handleShardStateChangeEvent(t *testing.T) {
 	}
 	cm.databaseChannels.value.Store(make(database2Channel))
 	dbChannel := NewMockDatabaseChannel(ctrl)
+	dbChannel.EXPECT().startRedeliver().AnyTimes()
 	cm.insertDatabaseChannel("database", dbChannel)
 
 	cases := []struct {
@@ -137,6 +159,9 @@ func TestChannelManager_handleShardStateChangeEvent(t *testing.T) {
 			shards: map[models.ShardID]models.ShardState{
 				3: {ID: 3},
 			},
+			prepare: func() {
+				dbChannel.EXPECT().SyncShardRouting(metric.ShardRouting{NumOfShards: 1})
+			},
 		},
 		{
 			name: "sync shard state successfully",
@@ -148,6 +173,24 @@ func TestChannelManager_handleShardStateChangeEvent(t *testing.T) {
 				shardCh := NewMockShardChannel(ctrl)
 				dbChannel.EXPECT().CreateChannel(gomock.Any(), gomock.Any()).Return(shardCh, nil)
 				shardCh.EXPECT().SyncShardState(gomock.Any(), gomock.Any())
+				dbChannel.EXPECT().SyncShardRouting(metric.ShardRouting{NumOfShards: 1})
+			},
+		},
+		{
+			name: "sync split shard routing",
+			db:   models.Database{Name: "database"},
+			shards: map[models.ShardID]models.ShardState{
+				0: {ID: 0},
+				1: {ID: 1, Split: &models.ShardSplit{Source: 0, Target: 1}},
+			},
+			prepare: func() {
+				shardCh := NewMockShardChannel(ctrl)
+				dbChannel.EXPECT().CreateChannel(int32(2), gomock.Any()).Return(shardCh, nil).Times(2)
+				shardCh.EXPECT().SyncShardState(gomock.Any(), gomock.Any()).Times(2)
+				dbChannel.EXPECT().SyncShardRouting(metric.ShardRouting{
+					NumOfShards: 1,
+					Splits:      []models.ShardSplit{{Source: 0, Target: 1}},
+				})
 			},
 		},
 	}
@@ -204,3 +247,21 @@ func mockBrokerRows(t *testing.T) *metric.BrokerBatchRows {
 	}))
 	return rows
 }
+
+func TestChannelManager_replicationOptionOf(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	stateMgr := broker.NewMockStateManager(ctrl)
+	cm := &channelManager{stateMgr: stateMgr}
+	optionFn := cm.replicationOptionOf("db")
+
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{}, false)
+	assert.Nil(t, optionFn())
+	// changed option is picked up
+	replication := &option.ReplicationOption{Compression: "zstd"}
+	stateMgr.EXPECT().GetDatabaseCfg("db").Return(models.Database{
+		Option: &option.DatabaseOption{Replication: replication},
+	}, true)
+	assert.Equal(t, replication, optionFn())
+}
@@ -0,0 +1,80 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+)
+
+// retryEntry represents a message which failed sending to storage, waiting for retrying.
+type retryEntry struct {
+	chunk      *compressedChunk
+	size       int
+	bufferedAt int64
+}
+
+// retryBuffer bounds the messages buffered for retrying when shard leader is unavailable,
+// size of buffer is shared by all family channels of shard, each family keeps its own messages in order.
+type retryBuffer struct {
+	maxSize int64
+	maxAge  int64
+	size    atomic.Int64
+
+	statistics *metrics.BrokerShardWriteStatistics
+}
+
+// newRetryBuffer creates a retry buffer of shard.
+func newRetryBuffer(database string, shardID models.ShardID, cfg config.Write) *retryBuffer {
+	return &retryBuffer{
+		maxSize:    int64(cfg.RetryBufferSize),
+		maxAge:     cfg.RetryBufferAge.Duration().Milliseconds(),
+		statistics: metrics.NewBrokerShardWriteStatistics(database, shardID.String()),
+	}
+}
+
+// acquire reserves buffer for message, returns false if buffer is full.
+func (b *retryBuffer) acquire(size int) bool {
+	for {
+		used := b.size.Load()
+		if used+int64(size) > b.maxSize {
+			return false
+		}
+		if b.size.CAS(used, used+int64(size)) {
+			break
+		}
+	}
+	b.statistics.RetryBufferSize.Add(float64(size))
+	b.statistics.RetryBufferChunks.Incr()
+	return true
+}
+
+// release releases buffer of message after message sent or dropped.
+func (b *retryBuffer) release(size int) {
+	b.size.Sub(int64(size))
+	b.statistics.RetryBufferSize.Sub(float64(size))
+	b.statistics.RetryBufferChunks.Decr()
+}
+
+// isExpired returns if message buffered too long.
+func (b *retryBuffer) isExpired(entry retryEntry, now int64) bool {
+	return now-entry.bufferedAt > b.maxAge
+}
@@ -0,0 +1,50 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func TestRetryBuffer(t *testing.T) {
+	buf := newRetryBuffer("db", 1, config.Write{
+		RetryBufferSize: 10,
+		RetryBufferAge:  ltoml.Duration(time.Second),
+	})
+	assert.True(t, buf.acquire(6))
+	assert.False(t, buf.acquire(5))
+	assert.True(t, buf.acquire(4))
+	assert.Equal(t, float64(10), buf.statistics.RetryBufferSize.Get())
+	assert.Equal(t, float64(2), buf.statistics.RetryBufferChunks.Get())
+	buf.release(6)
+	assert.True(t, buf.acquire(5))
+	buf.release(5)
+	buf.release(4)
+	assert.Equal(t, int64(0), buf.size.Load())
+	assert.Equal(t, float64(0), buf.statistics.RetryBufferSize.Get())
+	assert.Equal(t, float64(0), buf.statistics.RetryBufferChunks.Get())
+
+	assert.False(t, buf.isExpired(retryEntry{bufferedAt: 1000}, 2000))
+	assert.True(t, buf.isExpired(retryEntry{bufferedAt: 1000}, 2001))
+}
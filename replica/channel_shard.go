@@ -24,6 +24,8 @@ import (
 	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/rpc"
 )
@@ -35,12 +37,17 @@ var (
 	getFamilyFn = getFamily
 )
 
+// replicationOptionFn returns the latest replication option of database, nil if not set.
+type replicationOptionFn func() *option.ReplicationOption
+
 // ShardChannel represents a place to buffer the data for a specific cluster, database, shardID.
 type ShardChannel interface {
 	// SyncShardState syncs shard state after state event changed.
 	SyncShardState(shardState models.ShardState, liveNodes map[models.NodeID]models.StatefulNode)
 	// GetOrCreateFamilyChannel musts picks the family shardChannel by given family time.
 	GetOrCreateFamilyChannel(familyTime int64) FamilyChannel
+	// Flush flushes buffered rows of all family channels, waits until acknowledged by storage.
+	Flush(ctx context.Context) error
 	// Stop stops shard shardChannel.
 	Stop()
 
@@ -57,8 +64,11 @@ type shardChannel struct {
 	database string
 	shardID  models.ShardID
 	fct      rpc.ClientStreamFactory
+	// replication option is resolved when creating family channel, so that changed option applies to new families
+	replicationOption replicationOptionFn
 
 	families   *familyChannelSet // send shardChannel for each family time
+	retryBuf   *retryBuffer      // buffer of messages failed sending to unavailable leader
 	shardState models.ShardState
 	liveNodes  map[models.NodeID]models.StatefulNode
 
@@ -73,15 +83,19 @@ func newShardChannel(
 	database string,
 	shardID models.ShardID,
 	fct rpc.ClientStreamFactory,
+	replicationOption replicationOptionFn,
 ) ShardChannel {
+	cfg := config.GlobalBrokerConfig().Write
 	return &shardChannel{
-		ctx:      ctx,
-		cfg:      config.GlobalBrokerConfig().Write,
-		database: database,
-		shardID:  shardID,
-		families: newFamilyChannelSet(),
-		fct:      fct,
-		logger:   logger.GetLogger("Replica", "ShardChannel"),
+		ctx:               ctx,
+		cfg:               cfg,
+		database:          database,
+		shardID:           shardID,
+		families:          newFamilyChannelSet(),
+		retryBuf:          newRetryBuffer(database, shardID, cfg),
+		fct:               fct,
+		replicationOption: replicationOption,
+		logger:            logger.GetLogger("Replica", "ShardChannel"),
 	}
 }
 
@@ -89,15 +103,17 @@ func (c *shardChannel) SyncShardState(shardState models.ShardState, liveNodes ma
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
 
-	if c.shardState.Leader != shardState.Leader {
-		// leader change, need notify sender
+	_, leaderWasAlive := c.liveNodes[c.shardState.Leader]
+	_, leaderAlive := liveNodes[shardState.Leader]
+	if c.shardState.Leader != shardState.Leader || (!leaderWasAlive && leaderAlive) {
+		// leader change or leader node recovered, need notify sender
 		c.shardState = shardState
 		c.liveNodes = liveNodes
 		families := c.families.Entries()
 		for _, family := range families {
 			family.leaderChanged(c.shardState, c.liveNodes)
 		}
-		c.logger.Info("shard leader changed, need switch leader sender",
+		c.logger.Info("shard leader changed or recovered, need switch leader sender",
 			logger.String("db", c.database),
 			logger.Any("shardID", c.shardID))
 	}
@@ -118,12 +134,46 @@ func (c *shardChannel) GetOrCreateFamilyChannel(familyTime int64) FamilyChannel
 	if exist {
 		return familyChannel
 	}
-	familyChannel = newFamilyChannel(c.ctx, c.cfg, c.database, c.shardID, familyTime, c.fct, c.shardState, c.liveNodes)
+	cfg, codec := c.writeOptions()
+	familyChannel = newFamilyChannel(c.ctx, cfg, codec, c.database, c.shardID, familyTime, c.fct, c.shardState, c.liveNodes,
+		c.retryBuf)
 	c.families.InsertFamily(familyTime, familyChannel)
 
 	return familyChannel
 }
 
+// writeOptions returns the batching config and the codec of family channel,
+// the replication option of database overrides the write config of broker.
+func (c *shardChannel) writeOptions() (config.Write, ChunkCodec) {
+	cfg := c.cfg
+	var opt *option.ReplicationOption
+	if c.replicationOption != nil {
+		opt = c.replicationOption()
+	}
+	if batchSize := opt.GetBatchSize(); batchSize > 0 {
+		cfg.BatchBlockSize = ltoml.Size(batchSize)
+	}
+	if flushInterval := opt.GetFlushInterval(); flushInterval > 0 {
+		cfg.BatchTimeout = ltoml.Duration(flushInterval)
+	}
+	compression, level := opt.GetCompression()
+	return cfg, ChunkCodec{Compression: compression, Level: level}
+}
+
+// Flush flushes buffered rows of all family channels, waits until acknowledged by storage.
+func (c *shardChannel) Flush(ctx context.Context) error {
+	c.mutex.Lock()
+	families := c.families.Entries()
+	c.mutex.Unlock()
+
+	for _, family := range families {
+		if err := family.Flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Stop stops shard shardChannel.
 func (c *shardChannel) Stop() {
 	c.mutex.Lock()
@@ -19,12 +19,17 @@ package replica
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
 )
 
 func TestShardChannel_SyncShardState(t *testing.T) {
@@ -32,7 +37,7 @@ func TestShardChannel_SyncShardState(t *testing.T) {
 	defer func() {
 		ctrl.Finish()
 	}()
-	ch := newShardChannel(context.TODO(), "database", 1, nil)
+	ch := newShardChannel(context.TODO(), "database", 1, nil, nil)
 
 	familyCh := NewMockFamilyChannel(ctrl)
 	ch1 := ch.(*shardChannel)
@@ -59,6 +64,15 @@ func TestShardChannel_SyncShardState(t *testing.T) {
 		Leader: 2,
 	}, ch1.shardState)
 	ch1.mutex.Unlock()
+	// leader node recovered
+	familyCh.EXPECT().leaderChanged(gomock.Any(), gomock.Any())
+	ch.SyncShardState(models.ShardState{
+		Leader: 2,
+	}, map[models.NodeID]models.StatefulNode{2: {}})
+	// leader node keeps alive
+	ch.SyncShardState(models.ShardState{
+		Leader: 2,
+	}, map[models.NodeID]models.StatefulNode{2: {}})
 }
 
 func TestShardChannel_Stop(t *testing.T) {
@@ -66,7 +80,7 @@ func TestShardChannel_Stop(t *testing.T) {
 	defer func() {
 		ctrl.Finish()
 	}()
-	ch := newShardChannel(context.TODO(), "database", 1, nil)
+	ch := newShardChannel(context.TODO(), "database", 1, nil, nil)
 
 	familyCh := NewMockFamilyChannel(ctrl)
 	ch1 := ch.(*shardChannel)
@@ -96,11 +110,28 @@ func TestShardChannel_Stop(t *testing.T) {
 	ch.Stop()
 }
 
+func TestShardChannel_Flush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ch := newShardChannel(context.TODO(), "database", 1, nil, nil)
+	assert.NoError(t, ch.Flush(context.TODO()))
+
+	familyCh := NewMockFamilyChannel(ctrl)
+	ch1 := ch.(*shardChannel)
+	ch1.mutex.Lock()
+	ch1.families.InsertFamily(1, familyCh)
+	ch1.mutex.Unlock()
+	familyCh.EXPECT().Flush(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, ch.Flush(context.TODO()))
+	familyCh.EXPECT().Flush(gomock.Any()).Return(nil)
+	assert.NoError(t, ch.Flush(context.TODO()))
+}
+
 func TestShardChannel_GetOrCreateFamilyChannel(t *testing.T) {
 	defer func() {
 		getFamilyFn = getFamily
 	}()
-	ch := newShardChannel(context.TODO(), "database", 1, nil)
+	ch := newShardChannel(context.TODO(), "database", 1, nil, nil)
 	f1 := ch.GetOrCreateFamilyChannel(1)
 	assert.NotNil(t, f1)
 	f2 := ch.GetOrCreateFamilyChannel(1)
@@ -113,3 +144,27 @@ func TestShardChannel_GetOrCreateFamilyChannel(t *testing.T) {
 	f3 := ch.GetOrCreateFamilyChannel(3)
 	assert.Equal(t, f1, f3)
 }
+
+func TestShardChannel_writeOptions(t *testing.T) {
+	ch := newShardChannel(context.TODO(), "database", 1, nil, nil).(*shardChannel)
+	ch.cfg = config.Write{BatchBlockSize: 1024, BatchTimeout: ltoml.Duration(time.Second)}
+	cfg, codec := ch.writeOptions()
+	assert.Equal(t, ch.cfg, cfg)
+	assert.Equal(t, snappyCodec, codec)
+
+	replication := &option.ReplicationOption{BatchSize: "1MiB", FlushInterval: "200ms", Compression: "zstd:5"}
+	ch.replicationOption = func() *option.ReplicationOption {
+		return replication
+	}
+	cfg, codec = ch.writeOptions()
+	assert.Equal(t, ltoml.Size(1024*1024), cfg.BatchBlockSize)
+	assert.Equal(t, 200*time.Millisecond, cfg.BatchTimeout.Duration())
+	assert.Equal(t, ChunkCodec{Compression: option.CompressionZstd, Level: 5}, codec)
+
+	// family channel created after option changed applies new option
+	replication.Compression = "none"
+	family := ch.GetOrCreateFamilyChannel(1)
+	assert.Equal(t, ChunkCodec{Compression: option.CompressionNone}, family.(*familyChannel).codec)
+	assert.Equal(t, 200*time.Millisecond, family.(*familyChannel).checkFlushInterval)
+	ch.Stop()
+}
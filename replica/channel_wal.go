@@ -0,0 +1,501 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/google/uuid"
+
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/queue"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// for testing
+var (
+	newQueueFn = queue.NewQueue
+)
+
+// channelWAL represents the write ahead log of database channel on broker.
+// Batch is appended and fsynced(group commit) before acknowledged to client, then written into family channels.
+// Entry is acknowledged after all chunks carrying its rows acknowledged by storage, entries lost before acknowledged
+// are re-delivered, un-acknowledged entries are replayed after broker restarted.
+// Each entry carries an idempotency token, so that storage skips the rows re-written by re-delivery/replay.
+type channelWAL struct {
+	q queue.Queue
+
+	appendMutex sync.Mutex // serializes appending, so that sequence is assigned in order
+	syncMutex   sync.Mutex // fsync covers all entries appended before it(group commit)
+	syncedSeq   int64      // max sequence fsynced, guarded by syncMutex
+
+	mutex       sync.Mutex
+	entries     map[int64]*walEntry // entries not acknowledged by storage
+	appendedSeq int64
+	ackSeq      int64 // all entries <= ack sequence are acknowledged
+	pendingSize int64
+
+	statistics *metrics.BrokerWALStatistics
+	logger     *logger.Logger
+}
+
+// walEntry represents the acknowledgement state of entry.
+type walEntry struct {
+	size      int
+	refs      int // references held by write path/family channels(include abandoned)
+	abandoned int // references of rows lost before acknowledged, entry need be re-delivered
+	replay    bool
+}
+
+// walRef represents the reference of rows which belong to wal entry.
+type walRef struct {
+	wal *channelWAL
+	seq int64
+}
+
+type walRefKey struct{}
+
+// withWALRef returns a new context with the wal entry reference of rows.
+func withWALRef(ctx context.Context, ref walRef) context.Context {
+	return context.WithValue(ctx, walRefKey{}, ref)
+}
+
+// walRefFromContext returns the wal entry reference of rows from context.
+func walRefFromContext(ctx context.Context) (ref walRef, ok bool) {
+	if ctx != nil {
+		ref, ok = ctx.Value(walRefKey{}).(walRef)
+	}
+	return ref, ok
+}
+
+// newChannelWAL opens the write ahead log of database, entries not acknowledged are marked as need replay.
+func newChannelWAL(dir string, dataSizeLimit int64, database string) (*channelWAL, error) {
+	q, err := newQueueFn(filepath.Join(dir, database), dataSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	w := &channelWAL{
+		q:           q,
+		entries:     make(map[int64]*walEntry),
+		appendedSeq: q.AppendedSeq(),
+		ackSeq:      q.AcknowledgedSeq(),
+		statistics:  metrics.NewBrokerWALStatistics(database),
+		logger:      logger.GetLogger("Replica", "ChannelWAL"),
+	}
+	w.syncedSeq = w.appendedSeq
+	for seq := w.ackSeq + 1; seq <= w.appendedSeq; seq++ {
+		data, err := q.Get(seq)
+		if err != nil {
+			w.logger.Error("read un-acknowledged entry failure, skip it",
+				logger.String("database", database), logger.Int64("seq", seq), logger.Error(err))
+			continue
+		}
+		w.entries[seq] = &walEntry{size: len(data), refs: 1, abandoned: 1, replay: true}
+		w.pendingSize += int64(len(data))
+	}
+	w.advance()
+	w.updatePending()
+	if len(w.entries) > 0 {
+		w.logger.Info("found un-acknowledged entries, replay them after shard state synced",
+			logger.String("database", database), logger.Int("entries", len(w.entries)))
+	}
+	return w, nil
+}
+
+// append appends rows as an entry, then waits fsync, returns the sequence/token of entry,
+// generates a new token if client doesn't provide it.
+// Caller holds one reference of entry which must be released(or abandoned) after rows written into family channels.
+func (w *channelWAL) append(token string, rows []metric.BrokerRow) (seq int64, entryToken string, err error) {
+	if token == "" {
+		token = uuid.New().String()
+	}
+	data := encodeWALEntry(token, rows)
+
+	w.appendMutex.Lock()
+	if err = w.q.Put(data); err != nil {
+		w.appendMutex.Unlock()
+		w.statistics.AppendFailures.Incr()
+		return 0, "", err
+	}
+	seq = w.q.AppendedSeq()
+	w.mutex.Lock()
+	w.entries[seq] = &walEntry{size: len(data), refs: 1}
+	w.appendedSeq = seq
+	w.pendingSize += int64(len(data))
+	w.updatePending()
+	w.mutex.Unlock()
+	w.appendMutex.Unlock()
+
+	if err = w.sync(seq); err != nil {
+		w.statistics.AppendFailures.Incr()
+		w.release(seq)
+		return 0, "", err
+	}
+	w.statistics.Append.Incr()
+	return seq, token, nil
+}
+
+// sync fsyncs the entries appended, waits if another fsync in progress, which may cover the sequence.
+func (w *channelWAL) sync(seq int64) error {
+	w.syncMutex.Lock()
+	defer w.syncMutex.Unlock()
+
+	if w.syncedSeq >= seq {
+		// fsynced by the group commit of others
+		return nil
+	}
+	w.appendMutex.Lock()
+	target := w.q.AppendedSeq()
+	w.appendMutex.Unlock()
+
+	start := time.Now()
+	if err := w.q.Sync(); err != nil {
+		return err
+	}
+	w.statistics.FsyncDuration.UpdateSince(start)
+	w.syncedSeq = target
+	return nil
+}
+
+// retain adds a reference of entry.
+func (w *channelWAL) retain(seq int64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if entry, ok := w.entries[seq]; ok {
+		entry.refs++
+	}
+}
+
+// release releases a reference of entry, entry is acknowledged when all references released.
+func (w *channelWAL) release(seq int64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	entry, ok := w.entries[seq]
+	if !ok {
+		return
+	}
+	entry.refs--
+	if entry.refs <= 0 {
+		delete(w.entries, seq)
+		w.pendingSize -= int64(entry.size)
+		w.advance()
+		w.updatePending()
+	}
+}
+
+// abandon marks the reference of entry lost before acknowledged, entry will be re-delivered,
+// the reference is released after re-delivered.
+func (w *channelWAL) abandon(seq int64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if entry, ok := w.entries[seq]; ok {
+		entry.abandoned++
+	}
+}
+
+// redeliver re-writes the entries which need replay/re-delivery via write function,
+// the abandoned references are released after written, else re-delivered next time.
+func (w *channelWAL) redeliver(write func(ref walRef, token string, rows *metric.BrokerBatchRows) error) {
+	w.mutex.Lock()
+	var seqs []int64
+	for seq, entry := range w.entries {
+		if entry.abandoned > 0 {
+			seqs = append(seqs, seq)
+		}
+	}
+	w.mutex.Unlock()
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	for _, seq := range seqs {
+		w.mutex.Lock()
+		entry, ok := w.entries[seq]
+		if !ok || entry.abandoned == 0 {
+			w.mutex.Unlock()
+			continue
+		}
+		abandoned, replay := entry.abandoned, entry.replay
+		entry.abandoned = 0
+		entry.replay = false
+		w.mutex.Unlock()
+
+		if err := w.redeliverEntry(seq, write); err != nil {
+			w.logger.Warn("re-deliver entry failure, retry later", logger.Int64("seq", seq), logger.Error(err))
+			w.mutex.Lock()
+			entry.abandoned += abandoned
+			entry.replay = replay
+			w.mutex.Unlock()
+			return
+		}
+		if replay {
+			w.statistics.Replay.Incr()
+		} else {
+			w.statistics.Redeliver.Incr()
+		}
+		for i := 0; i < abandoned; i++ {
+			w.release(seq)
+		}
+	}
+}
+
+// redeliverEntry reads the entry, then re-writes it via write function.
+func (w *channelWAL) redeliverEntry(seq int64, write func(ref walRef, token string, rows *metric.BrokerBatchRows) error) error {
+	data, err := w.q.Get(seq)
+	var (
+		token string
+		rows  *metric.BrokerBatchRows
+	)
+	if err == nil {
+		token, rows, err = decodeWALEntry(data)
+	}
+	if err != nil {
+		// entry is corrupted, cannot be re-delivered any more
+		w.logger.Error("read entry failure when re-deliver, skip it", logger.Int64("seq", seq), logger.Error(err))
+		return nil
+	}
+	return write(walRef{wal: w, seq: seq}, token, rows)
+}
+
+// gc persists acknowledged sequence, then truncates the acknowledged entries.
+func (w *channelWAL) gc() {
+	w.mutex.Lock()
+	ackSeq := w.ackSeq
+	w.mutex.Unlock()
+
+	if ackSeq > w.q.AcknowledgedSeq() {
+		w.statistics.Truncate.Add(float64(ackSeq - w.q.AcknowledgedSeq()))
+		w.q.SetAcknowledgedSeq(ackSeq)
+		w.q.GC()
+	}
+}
+
+// close persists acknowledged sequence, then closes the queue.
+func (w *channelWAL) close() {
+	w.gc()
+	w.q.Close()
+}
+
+// advance advances acknowledged sequence to the max sequence which all entries before it are acknowledged,
+// without lock.
+func (w *channelWAL) advance() {
+	for w.ackSeq < w.appendedSeq {
+		if _, ok := w.entries[w.ackSeq+1]; ok {
+			return
+		}
+		w.ackSeq++
+	}
+}
+
+// updatePending updates pending statistics, without lock.
+func (w *channelWAL) updatePending() {
+	w.statistics.PendingSize.Update(float64(w.pendingSize))
+	w.statistics.PendingEntries.Update(float64(len(w.entries)))
+}
+
+// encodeWALEntry encodes the rows with idempotency token as wal entry.
+func encodeWALEntry(token string, rows []metric.BrokerRow) []byte {
+	var buf bytes.Buffer
+	var scratch [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(scratch[:], uint64(len(token)))
+	_, _ = buf.Write(scratch[:n])
+	_, _ = buf.WriteString(token)
+	for idx := range rows {
+		_, _ = rows[idx].WriteTo(&buf)
+	}
+	return buf.Bytes()
+}
+
+// decodeWALEntry decodes the rows with idempotency token from wal entry.
+func decodeWALEntry(data []byte) (token string, rows *metric.BrokerBatchRows, err error) {
+	tokenLen, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < tokenLen {
+		return "", nil, fmt.Errorf("bad wal entry")
+	}
+	token = string(data[n : n+int(tokenLen)])
+	data = data[n+int(tokenLen):]
+	rows = metric.NewBrokerBatchRows()
+	for len(data) > 0 {
+		if len(data) < flatbuffers.SizeUOffsetT {
+			return "", nil, fmt.Errorf("bad wal entry")
+		}
+		size := int(flatbuffers.GetSizePrefix(data, 0)) + flatbuffers.SizeUOffsetT
+		if size > len(data) {
+			return "", nil, fmt.Errorf("bad wal entry")
+		}
+		block := data[:size]
+		_ = rows.TryAppend(func(row *metric.BrokerRow) error {
+			row.FromBlock(block)
+			row.IsOutOfTimeRange = false
+			return nil
+		})
+		data = data[size:]
+	}
+	return token, rows, nil
+}
+
+// releaseWALRefs releases the wal references of rows acknowledged by storage.
+func releaseWALRefs(refs []walRef) {
+	for _, ref := range refs {
+		ref.wal.release(ref.seq)
+	}
+}
+
+// abandonWALRefs marks the rows lost before acknowledged, entries will be re-delivered.
+func abandonWALRefs(refs []walRef) {
+	for _, ref := range refs {
+		ref.wal.abandon(ref.seq)
+	}
+}
+
+// streamAcks tracks the wal references of chunks sent via write stream, storage acknowledges chunks in sending order.
+type streamAcks struct {
+	mutex    sync.Mutex
+	inflight [][]walRef
+	pending  int  // number of inflight chunks with wal references
+	closed   bool // closed when stream closed
+	lost     bool // inflight chunks not acknowledged when stream closed
+	changed  chan struct{}
+}
+
+// newStreamAcks creates a stream acks tracker.
+func newStreamAcks() *streamAcks {
+	return &streamAcks{changed: make(chan struct{})}
+}
+
+// notifyChanged wakes up all waiters after inflight chunks changed, must be called with lock.
+func (a *streamAcks) notifyChanged() {
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// push records the wal references of chunk before sending(maybe empty).
+func (a *streamAcks) push(refs []walRef) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	a.inflight = append(a.inflight, refs)
+	if len(refs) > 0 {
+		a.pending++
+	}
+}
+
+// cancelLast removes the last chunk pushed when sending failure, returns its wal references.
+func (a *streamAcks) cancelLast() []walRef {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	n := len(a.inflight)
+	if n == 0 {
+		return nil
+	}
+	refs := a.inflight[n-1]
+	a.inflight = a.inflight[:n-1]
+	if len(refs) > 0 {
+		a.pending--
+	}
+	return refs
+}
+
+// ack acknowledges the oldest inflight chunk, references are released if written successfully,
+// else rows are re-delivered.
+func (a *streamAcks) ack(err error) {
+	a.mutex.Lock()
+	if a.closed || len(a.inflight) == 0 {
+		a.mutex.Unlock()
+		return
+	}
+	refs := a.inflight[0]
+	a.inflight[0] = nil
+	a.inflight = a.inflight[1:]
+	if len(refs) > 0 {
+		a.pending--
+	}
+	a.notifyChanged()
+	a.mutex.Unlock()
+
+	if err != nil {
+		abandonWALRefs(refs)
+	} else {
+		releaseWALRefs(refs)
+	}
+}
+
+// close closes the tracker when stream closed, inflight chunks not acknowledged are re-delivered.
+func (a *streamAcks) close() {
+	a.mutex.Lock()
+	inflight := a.inflight
+	a.inflight = nil
+	a.pending = 0
+	a.closed = true
+	a.lost = a.lost || len(inflight) > 0
+	a.notifyChanged()
+	a.mutex.Unlock()
+
+	for _, refs := range inflight {
+		abandonWALRefs(refs)
+	}
+}
+
+// wait waits until all inflight chunks with wal references acknowledged or ctx done.
+func (a *streamAcks) wait(ctx context.Context) {
+	for {
+		a.mutex.Lock()
+		pending, changed := a.pending, a.changed
+		a.mutex.Unlock()
+		if pending == 0 {
+			return
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// waitAll waits until all inflight chunks(with or without wal references) acknowledged,
+// returns error if stream closed before acknowledged or ctx done.
+func (a *streamAcks) waitAll(ctx context.Context) error {
+	for {
+		a.mutex.Lock()
+		inflight, lost, changed := len(a.inflight), a.lost, a.changed
+		a.mutex.Unlock()
+		switch {
+		case lost:
+			return errFlushNotAcknowledged
+		case inflight == 0:
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,308 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/pkg/queue"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func newWALTestRows(t *testing.T, names ...string) *metric.BrokerBatchRows {
+	converter := metric.NewProtoConverter()
+	batch := metric.NewBrokerBatchRows()
+	for _, name := range names {
+		name := name
+		err := batch.TryAppend(func(row *metric.BrokerRow) error {
+			return converter.ConvertTo(&protoMetricsV1.Metric{
+				Name:      name,
+				Timestamp: timeutil.Now(),
+				SimpleFields: []*protoMetricsV1.SimpleField{
+					{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+				Tags: []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+			}, row)
+		})
+		assert.NoError(t, err)
+	}
+	return batch
+}
+
+func TestChannelWAL_Append(t *testing.T) {
+	w, err := newChannelWAL(t.TempDir(), 1024*1024, "db")
+	assert.NoError(t, err)
+	defer w.close()
+
+	rows := newWALTestRows(t, "cpu", "memory")
+	seq1, token1, err := w.append("", rows.Rows())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), seq1)
+	assert.NotEmpty(t, token1)
+	seq2, token2, err := w.append("token", rows.Rows())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), seq2)
+	assert.Equal(t, "token", token2)
+	assert.Len(t, w.entries, 2)
+
+	// family channel holds reference
+	w.retain(seq2)
+	w.release(seq2)
+	assert.Equal(t, int64(-1), w.ackSeq)
+	w.release(seq1)
+	assert.Equal(t, seq1, w.ackSeq)
+	w.gc()
+	assert.Equal(t, seq1, w.q.AcknowledgedSeq())
+
+	// rows lost before acknowledged
+	w.abandon(seq2)
+	w.release(100)
+	w.retain(100)
+	w.abandon(100)
+	count := 0
+	w.redeliver(func(ref walRef, token string, batch *metric.BrokerBatchRows) error {
+		count++
+		assert.Equal(t, seq2, ref.seq)
+		assert.Equal(t, "token", token)
+		assert.Equal(t, 2, batch.Len())
+		ref.wal.retain(ref.seq)
+		return nil
+	})
+	assert.Equal(t, 1, count)
+	assert.Equal(t, seq1, w.ackSeq)
+	// nothing need re-deliver
+	w.redeliver(func(_ walRef, _ string, _ *metric.BrokerBatchRows) error {
+		count++
+		return nil
+	})
+	assert.Equal(t, 1, count)
+	w.release(seq2)
+	assert.Equal(t, seq2, w.ackSeq)
+	assert.Empty(t, w.entries)
+}
+
+func TestChannelWAL_Replay(t *testing.T) {
+	dir := t.TempDir()
+	w, err := newChannelWAL(dir, 1024*1024, "db")
+	assert.NoError(t, err)
+	rows := newWALTestRows(t, "cpu")
+	for i := 0; i < 3; i++ {
+		_, _, err = w.append(fmt.Sprintf("token-%d", i), rows.Rows())
+		assert.NoError(t, err)
+	}
+	w.release(0)
+	w.close()
+
+	w, err = newChannelWAL(dir, 1024*1024, "db")
+	assert.NoError(t, err)
+	defer w.close()
+	assert.Len(t, w.entries, 2)
+
+	var tokens []string
+	// write failure, retry later
+	w.redeliver(func(_ walRef, token string, _ *metric.BrokerBatchRows) error {
+		tokens = append(tokens, token)
+		return fmt.Errorf("err")
+	})
+	assert.Equal(t, []string{"token-1"}, tokens)
+	assert.True(t, w.entries[2].replay)
+	assert.Equal(t, 1, w.entries[1].abandoned)
+
+	tokens = nil
+	w.redeliver(func(_ walRef, token string, _ *metric.BrokerBatchRows) error {
+		tokens = append(tokens, token)
+		return nil
+	})
+	assert.Equal(t, []string{"token-1", "token-2"}, tokens)
+	assert.Equal(t, int64(2), w.ackSeq)
+	w.gc()
+	assert.Equal(t, int64(2), w.q.AcknowledgedSeq())
+}
+
+func TestChannelWAL_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newQueueFn = queue.NewQueue
+		ctrl.Finish()
+	}()
+	q := queue.NewMockQueue(ctrl)
+	newQueueFn = func(_ string, _ int64) (queue.Queue, error) {
+		return q, nil
+	}
+	// open failure
+	newQueueFn = func(_ string, _ int64) (queue.Queue, error) {
+		return nil, fmt.Errorf("err")
+	}
+	w, err := newChannelWAL(t.TempDir(), 1024, "db")
+	assert.Error(t, err)
+	assert.Nil(t, w)
+
+	// read un-acknowledged entry failure
+	newQueueFn = func(_ string, _ int64) (queue.Queue, error) {
+		return q, nil
+	}
+	q.EXPECT().AppendedSeq().Return(int64(2))
+	q.EXPECT().AcknowledgedSeq().Return(int64(0))
+	q.EXPECT().Get(int64(1)).Return(nil, fmt.Errorf("err"))
+	q.EXPECT().Get(int64(2)).Return([]byte{1, 2, 3}, nil)
+	w, err = newChannelWAL(t.TempDir(), 1024, "db")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), w.ackSeq)
+	// bad entry skipped
+	q.EXPECT().Get(int64(2)).Return([]byte{1, 2, 3}, nil)
+	w.redeliver(func(_ walRef, _ string, _ *metric.BrokerBatchRows) error {
+		panic("bad entry cannot be re-delivered")
+	})
+	assert.Equal(t, int64(2), w.ackSeq)
+
+	rows := newWALTestRows(t, "cpu").Rows()
+	// put failure
+	q.EXPECT().Put(gomock.Any()).Return(fmt.Errorf("err"))
+	_, _, err = w.append("", rows)
+	assert.Error(t, err)
+	// sync failure
+	q.EXPECT().Put(gomock.Any()).Return(nil)
+	q.EXPECT().AppendedSeq().Return(int64(3)).Times(2)
+	q.EXPECT().Sync().Return(fmt.Errorf("err"))
+	_, _, err = w.append("", rows)
+	assert.Error(t, err)
+	assert.Empty(t, w.entries)
+	assert.Equal(t, int64(3), w.ackSeq)
+
+	q.EXPECT().AcknowledgedSeq().Return(int64(0)).Times(2)
+	q.EXPECT().SetAcknowledgedSeq(int64(3))
+	q.EXPECT().GC()
+	q.EXPECT().Close()
+	w.close()
+}
+
+func TestChannelWAL_Sync(t *testing.T) {
+	w, err := newChannelWAL(t.TempDir(), 1024*1024, "db")
+	assert.NoError(t, err)
+	defer w.close()
+
+	// fsynced by group commit of others
+	w.syncedSeq = 10
+	assert.NoError(t, w.sync(5))
+}
+
+func TestChannelWAL_DecodeEntry(t *testing.T) {
+	rows := newWALTestRows(t, "cpu", "memory")
+	token, batch, err := decodeWALEntry(encodeWALEntry("token", rows.Rows()))
+	assert.NoError(t, err)
+	assert.Equal(t, "token", token)
+	assert.Equal(t, 2, batch.Len())
+	m1, m2 := rows.Rows()[1].Metric(), batch.Rows()[1].Metric()
+	assert.Equal(t, m1.Name(), m2.Name())
+
+	_, _, err = decodeWALEntry(nil)
+	assert.Error(t, err)
+	_, _, err = decodeWALEntry([]byte{10, 'a'})
+	assert.Error(t, err)
+	_, _, err = decodeWALEntry([]byte{1, 'a', 1})
+	assert.Error(t, err)
+	_, _, err = decodeWALEntry([]byte{1, 'a', 100, 0, 0, 0, 1})
+	assert.Error(t, err)
+}
+
+func TestStreamAcks(t *testing.T) {
+	w, err := newChannelWAL(t.TempDir(), 1024*1024, "db")
+	assert.NoError(t, err)
+	defer w.close()
+	rows := newWALTestRows(t, "cpu").Rows()
+	for i := 0; i < 4; i++ {
+		_, _, err = w.append("", rows)
+		assert.NoError(t, err)
+	}
+
+	acks := newStreamAcks()
+	assert.Empty(t, acks.cancelLast())
+	acks.push([]walRef{{wal: w, seq: 0}})
+	acks.push(nil)
+	acks.push([]walRef{{wal: w, seq: 1}})
+	acks.push([]walRef{{wal: w, seq: 2}})
+	assert.Equal(t, []walRef{{wal: w, seq: 2}}, acks.cancelLast())
+	w.abandon(2)
+
+	acks.ack(nil)
+	assert.Equal(t, int64(0), w.ackSeq)
+	acks.ack(nil)
+	acks.ack(fmt.Errorf("err"))
+	assert.Equal(t, 1, w.entries[1].abandoned)
+	acks.ack(nil)
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	acks.push([]walRef{{wal: w, seq: 3}})
+	// wait timeout
+	acks.wait(ctx)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		acks.ack(nil)
+	}()
+	acks.wait(context.TODO())
+	_, ok := w.entries[3]
+	assert.False(t, ok)
+
+	_, _, err = w.append("", rows)
+	assert.NoError(t, err)
+	acks.push([]walRef{{wal: w, seq: 4}})
+	acks.close()
+	assert.Equal(t, 1, w.entries[4].abandoned)
+	acks.ack(nil)
+	acks.wait(context.TODO())
+}
+
+func TestStreamAcks_waitAll(t *testing.T) {
+	acks := newStreamAcks()
+	assert.NoError(t, acks.waitAll(context.TODO()))
+
+	acks.push(nil)
+	acks.push(nil)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		acks.ack(nil)
+		acks.ack(nil)
+	}()
+	// multiple waiters are woken up
+	var wait sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			assert.NoError(t, acks.waitAll(context.TODO()))
+		}()
+	}
+	wait.Wait()
+
+	acks.push(nil)
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, acks.waitAll(ctx), context.DeadlineExceeded)
+	acks.close()
+	assert.ErrorIs(t, acks.waitAll(context.TODO()), errFlushNotAcknowledged)
+}
@@ -21,8 +21,6 @@ import (
 	"bytes"
 	"sync"
 
-	"github.com/golang/snappy"
-
 	"github.com/lindb/lindb/pkg/ltoml"
 )
 
@@ -42,16 +40,17 @@ type Chunk interface {
 	Write([]byte) (n int, err error)
 }
 
-// chunk represents the buffer with snappy compress
+// chunk represents the buffer compressed by codec(snappy by default)
 type chunk struct {
 	buffer   bytes.Buffer
 	capacity ltoml.Size // use bytes capacity instead of lines-num
 	size     ltoml.Size // chunk size and append index
+	codec    ChunkCodec
 }
 
-// newChunk creates a new chunk
-func newChunk(capacity ltoml.Size) Chunk {
-	return &chunk{capacity: capacity}
+// newChunk creates a new chunk compressed by codec
+func newChunk(capacity ltoml.Size, codec ChunkCodec) Chunk {
+	return &chunk{capacity: capacity, codec: codec}
 }
 
 // IsEmpty checks the chunk if is empty
@@ -90,9 +89,13 @@ func (c *chunk) Compress() (*compressedChunk, error) {
 		c.buffer.Reset()
 	}()
 
-	// we use snappy block format here
 	ck := newCompressedChunk(len(c.buffer.Bytes()))
-	ck.Encode(c.buffer.Bytes())
+	data, err := c.codec.Encode(*ck, c.buffer.Bytes())
+	if err != nil {
+		ck.Release()
+		return nil, err
+	}
+	*ck = data
 	return ck, nil
 }
 
@@ -108,11 +111,6 @@ func (cc *compressedChunk) Release() {
 	compressedChunkPool.Put(cc)
 }
 
-// Encode compresses source block
-func (cc *compressedChunk) Encode(block []byte) {
-	*cc = snappy.Encode(*cc, block)
-}
-
 // newCompressedChunk picks a fixed sized buffer from pool
 // expected compress ratio for snappy is 0.6 under of test
 func newCompressedChunk(originalSize int) *compressedChunk {
@@ -0,0 +1,183 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+)
+
+// WriteCompressions represents the codecs of write stream supported by storage node,
+// which is registered with node info, so that broker negotiates the codec of write stream with storage node.
+const WriteCompressions = "none,snappy,zstd"
+
+var (
+	zstdEncoders   = make(map[int]*zstd.Encoder) // level => encoder
+	zstdEncoderMux sync.Mutex
+	zstdDecoder    *zstd.Decoder
+	zstdDecoderErr error
+	zstdOnce       sync.Once
+)
+
+// ChunkCodec represents the codec compressing the chunks sent via write stream, zero value means snappy.
+type ChunkCodec struct {
+	Compression option.Compression
+	Level       int // compression level, only for zstd
+}
+
+// snappyCodec represents the codec of write ahead log, which is supported by all storage nodes.
+var snappyCodec = ChunkCodec{Compression: option.CompressionSnappy}
+
+// ParseChunkCodec parses the codec of write stream by name(none/snappy/zstd/zstd:<level>), returns snappy if name is empty.
+func ParseChunkCodec(name string) (ChunkCodec, error) {
+	if name == "" {
+		return snappyCodec, nil
+	}
+	compression, level, err := option.ParseCompression(name)
+	if err != nil {
+		return ChunkCodec{}, err
+	}
+	return ChunkCodec{Compression: compression, Level: level}, nil
+}
+
+// String returns the string value of codec.
+func (c ChunkCodec) String() string {
+	compression := c.compression()
+	if compression == option.CompressionZstd {
+		return string(compression) + ":" + strconv.Itoa(c.Level)
+	}
+	return string(compression)
+}
+
+// compression returns the compression of codec, returns snappy if not set.
+func (c ChunkCodec) compression() option.Compression {
+	if c.Compression == "" {
+		return option.CompressionSnappy
+	}
+	return c.Compression
+}
+
+// SupportedBy returns if the codec is supported by the storage node.
+func (c ChunkCodec) SupportedBy(node *models.StatefulNode) bool {
+	if c.compression() == option.CompressionSnappy {
+		return true
+	}
+	for _, compression := range strings.Split(node.WriteCompressions, ",") {
+		if compression == string(c.compression()) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode compresses src into dst(reused if capacity is enough), returns the compressed data.
+func (c ChunkCodec) Encode(dst, src []byte) ([]byte, error) {
+	switch c.compression() {
+	case option.CompressionNone:
+		return append(dst[:0], src...), nil
+	case option.CompressionSnappy:
+		return snappy.Encode(dst[:cap(dst)], src), nil
+	case option.CompressionZstd:
+		encoder, err := getZstdEncoder(c.Level)
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(src, dst[:0]), nil
+	default:
+		return nil, fmt.Errorf("unknown compression codec: %s", c.compression())
+	}
+}
+
+// Decode decompresses src into dst(reused if capacity is enough), returns the raw data.
+func (c ChunkCodec) Decode(dst, src []byte) ([]byte, error) {
+	switch c.compression() {
+	case option.CompressionNone:
+		return append(dst[:0], src...), nil
+	case option.CompressionSnappy:
+		return snappy.Decode(dst[:cap(dst)], src)
+	case option.CompressionZstd:
+		decoder, err := getZstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(src, dst[:0])
+	default:
+		return nil, fmt.Errorf("unknown compression codec: %s", c.compression())
+	}
+}
+
+// ToSnappy transcodes the chunk compressed by codec into snappy block format(format of write ahead log),
+// returns the chunk directly if it's compressed by snappy.
+func (c ChunkCodec) ToSnappy(chunk []byte) ([]byte, error) {
+	switch c.compression() {
+	case option.CompressionSnappy:
+		return chunk, nil
+	case option.CompressionNone:
+		return snappy.Encode(nil, chunk), nil
+	default:
+		raw, err := c.Decode(nil, chunk)
+		if err != nil {
+			return nil, err
+		}
+		return snappy.Encode(nil, raw), nil
+	}
+}
+
+// negotiateCodec returns the codec supported by all replicas of shard, falls back to snappy if any replica
+// doesn't support it(old storage node), so that the chunks can be sent to the new leader after leader changed.
+func negotiateCodec(codec ChunkCodec, shardState models.ShardState, liveNodes map[models.NodeID]models.StatefulNode) ChunkCodec {
+	for _, replica := range shardState.Replica.Replicas {
+		node, ok := liveNodes[replica]
+		if ok && !codec.SupportedBy(&node) {
+			return snappyCodec
+		}
+	}
+	return codec
+}
+
+// getZstdEncoder returns the shared zstd encoder by level, encoder is safe for concurrent EncodeAll.
+func getZstdEncoder(level int) (*zstd.Encoder, error) {
+	zstdEncoderMux.Lock()
+	defer zstdEncoderMux.Unlock()
+
+	if encoder, ok := zstdEncoders[level]; ok {
+		return encoder, nil
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	if err != nil {
+		return nil, err
+	}
+	zstdEncoders[level] = encoder
+	return encoder, nil
+}
+
+// getZstdDecoder returns the shared zstd decoder, decoder is safe for concurrent DecodeAll.
+func getZstdDecoder() (*zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	return zstdDecoder, zstdDecoderErr
+}
@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+)
+
+func TestParseChunkCodec(t *testing.T) {
+	codec, err := ParseChunkCodec("")
+	assert.NoError(t, err)
+	assert.Equal(t, snappyCodec, codec)
+	codec, err = ParseChunkCodec("zstd:5")
+	assert.NoError(t, err)
+	assert.Equal(t, ChunkCodec{Compression: option.CompressionZstd, Level: 5}, codec)
+	assert.Equal(t, "zstd:5", codec.String())
+	_, err = ParseChunkCodec("lz4")
+	assert.Error(t, err)
+
+	assert.Equal(t, "snappy", ChunkCodec{}.String())
+	assert.Equal(t, "none", ChunkCodec{Compression: option.CompressionNone}.String())
+}
+
+func TestChunkCodec_SupportedBy(t *testing.T) {
+	oldNode := &models.StatefulNode{}
+	newNode := &models.StatefulNode{WriteCompressions: WriteCompressions}
+	zstdCodec := ChunkCodec{Compression: option.CompressionZstd, Level: 3}
+	assert.True(t, ChunkCodec{}.SupportedBy(oldNode))
+	assert.True(t, snappyCodec.SupportedBy(oldNode))
+	assert.False(t, zstdCodec.SupportedBy(oldNode))
+	assert.True(t, zstdCodec.SupportedBy(newNode))
+	assert.True(t, ChunkCodec{Compression: option.CompressionNone}.SupportedBy(newNode))
+}
+
+func TestChunkCodec_Encode_Decode(t *testing.T) {
+	raw := bytes.Repeat([]byte("cpu,host=1.1.1.1 usage=1"), 100)
+	codecs := []ChunkCodec{
+		{},
+		snappyCodec,
+		{Compression: option.CompressionNone},
+		{Compression: option.CompressionZstd, Level: 1},
+		{Compression: option.CompressionZstd, Level: 19},
+	}
+	for _, codec := range codecs {
+		compressed, err := codec.Encode(nil, raw)
+		assert.NoError(t, err)
+		decoded, err := codec.Decode(nil, compressed)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, decoded)
+		// transcode into format of write ahead log
+		block, err := codec.ToSnappy(compressed)
+		assert.NoError(t, err)
+		decoded, err = snappy.Decode(nil, block)
+		assert.NoError(t, err)
+		assert.Equal(t, raw, decoded)
+	}
+	// reuse dst
+	dst := make([]byte, 0, 4096)
+	compressed, err := ChunkCodec{Compression: option.CompressionZstd, Level: 3}.Encode(dst, raw)
+	assert.NoError(t, err)
+	assert.Less(t, len(compressed), len(raw))
+
+	unknown := ChunkCodec{Compression: "lz4"}
+	_, err = unknown.Encode(nil, raw)
+	assert.Error(t, err)
+	_, err = unknown.Decode(nil, raw)
+	assert.Error(t, err)
+	_, err = unknown.ToSnappy(raw)
+	assert.Error(t, err)
+	_, err = ChunkCodec{Compression: option.CompressionZstd}.ToSnappy([]byte("bad-data"))
+	assert.Error(t, err)
+}
+
+func TestNegotiateCodec(t *testing.T) {
+	zstdCodec := ChunkCodec{Compression: option.CompressionZstd, Level: 3}
+	shardState := models.ShardState{Replica: models.Replica{Replicas: []models.NodeID{1, 2, 3}}}
+	liveNodes := map[models.NodeID]models.StatefulNode{
+		1: {WriteCompressions: WriteCompressions},
+		2: {WriteCompressions: WriteCompressions},
+	}
+	// offline replica is ignored
+	assert.Equal(t, zstdCodec, negotiateCodec(zstdCodec, shardState, liveNodes))
+	// old storage node
+	liveNodes[3] = models.StatefulNode{}
+	assert.Equal(t, snappyCodec, negotiateCodec(zstdCodec, shardState, liveNodes))
+}
@@ -45,7 +45,7 @@ func makeTestBrokerRows() metric.BrokerRow {
 }
 
 func TestChunk_Append(t *testing.T) {
-	chunk := newChunk(ltoml.Size(1024))
+	chunk := newChunk(ltoml.Size(1024), snappyCodec)
 	assert.False(t, chunk.IsFull())
 	assert.True(t, chunk.IsEmpty())
 	assert.Equal(t, ltoml.Size(0), chunk.Size())
@@ -65,7 +65,7 @@ func TestChunk_Append(t *testing.T) {
 }
 
 func TestChunk_MarshalBinary(t *testing.T) {
-	c1 := newChunk(ltoml.Size(2))
+	c1 := newChunk(ltoml.Size(2), snappyCodec)
 	compressed, err := c1.Compress()
 	assert.NoError(t, err)
 	assert.Nil(t, compressed)
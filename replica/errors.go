@@ -17,7 +17,11 @@
 
 package replica
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/lindb/lindb/pkg/errcode"
+)
 
 var (
 	// define error types
@@ -26,5 +30,11 @@ var (
 	errInvalidShardNum = errors.New("numOfShard should be equal or greater than original setting")
 	// ErrFamilyChannelCanceled is the error returned when a family channel is closed.
 	ErrFamilyChannelCanceled = errors.New("family Channel is canceled")
-	ErrIngestTimeout         = errors.New("ingest timout")
+	ErrIngestTimeout         = errcode.New(errcode.Timeout, "ingest timout")
+	// ErrSnapshotNotFound is the error returned when shard snapshot is released or expired.
+	ErrSnapshotNotFound      = errcode.New(errcode.NotFound, "shard snapshot not found")
+	errSnapshotFileNotFound  = errors.New("file not found in shard snapshot")
+	errInvalidSnapshotOffset = errors.New("invalid offset of snapshot file")
+	errSnapshotChecksum      = errors.New("checksum of snapshot data mismatch")
+	errFlushNotAcknowledged  = errors.New("rows flushed are not acknowledged by storage")
 )
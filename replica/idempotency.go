@@ -0,0 +1,75 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"container/list"
+	"context"
+)
+
+type idempotencyTokenKey struct{}
+
+// WithIdempotencyToken returns a new context with the idempotency token of write request,
+// the token is carried with the rows through replication channel, so that storage can skip re-written rows.
+func WithIdempotencyToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, idempotencyTokenKey{}, token)
+}
+
+// IdempotencyTokenFromContext returns the idempotency token of write request from context, returns empty if not set.
+func IdempotencyTokenFromContext(ctx context.Context) string {
+	if ctx != nil {
+		if token, ok := ctx.Value(idempotencyTokenKey{}).(string); ok {
+			return token
+		}
+	}
+	return ""
+}
+
+// tokenWindow keeps the recent idempotency tokens replayed by replicator based on lru list,
+// window is bounded by the number of tokens(not time), so that all replicas make the same decision
+// when replaying the same write ahead log.
+type tokenWindow struct {
+	maxTokens int
+	tokens    map[string]*list.Element
+	lru       *list.List // front is the most recently used
+}
+
+// newTokenWindow creates a token window, returns nil if maxTokens <= 0(de-duplication disabled).
+func newTokenWindow(maxTokens int) *tokenWindow {
+	if maxTokens <= 0 {
+		return nil
+	}
+	return &tokenWindow{
+		maxTokens: maxTokens,
+		tokens:    make(map[string]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// isDuplicate returns true if token has been seen in window, else records the token.
+func (w *tokenWindow) isDuplicate(token string) bool {
+	if elem, ok := w.tokens[token]; ok {
+		w.lru.MoveToFront(elem)
+		return true
+	}
+	w.tokens[token] = w.lru.PushFront(token)
+	for w.lru.Len() > w.maxTokens {
+		delete(w.tokens, w.lru.Remove(w.lru.Back()).(string))
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyTokenFromContext(t *testing.T) {
+	assert.Empty(t, IdempotencyTokenFromContext(nil)) //nolint:staticcheck
+	assert.Empty(t, IdempotencyTokenFromContext(context.TODO()))
+	assert.Equal(t, "token", IdempotencyTokenFromContext(WithIdempotencyToken(context.TODO(), "token")))
+}
+
+func TestTokenWindow(t *testing.T) {
+	assert.Nil(t, newTokenWindow(0))
+
+	w := newTokenWindow(2)
+	assert.False(t, w.isDuplicate("a"))
+	assert.True(t, w.isDuplicate("a"))
+	assert.False(t, w.isDuplicate("b"))
+	// a is the most recently used, evicts b
+	assert.True(t, w.isDuplicate("a"))
+	assert.False(t, w.isDuplicate("c"))
+	assert.False(t, w.isDuplicate("b"))
+	assert.Len(t, w.tokens, 2)
+	assert.Equal(t, 2, w.lru.Len())
+}
@@ -296,6 +296,7 @@ func (p *partition) getReplicaState() models.FamilyLogReplicaState {
 			peerState.ReplicatorType = replicatorType
 			peerState.State = replicatorState.state
 			peerState.StateErrMsg = replicatorState.errMsg
+			peerState.WriteState = replicatorState.writeState
 		}
 
 		stateOfReplicators = append(stateOfReplicators, peerState)
@@ -265,7 +265,8 @@ func TestPartition_getReplicaState(t *testing.T) {
 	p := NewPartition(context.TODO(), shard, family, 1, l, nil, nil)
 	p1 := p.(*partition)
 	peer := NewMockReplicatorPeer(ctrl)
-	peer.EXPECT().ReplicatorState().Return("remote", &state{state: models.ReplicatorReadyState}).AnyTimes()
+	writeState := &models.LeaderWriteState{Failures: 1, Panics: 1, LastErr: "err"}
+	peer.EXPECT().ReplicatorState().Return("local", &state{state: models.ReplicatorReadyState, writeState: writeState}).AnyTimes()
 	p1.mutex.Lock()
 	p1.peers[models.NodeID(1)] = peer
 	p1.peers[models.NodeID(2)] = peer
@@ -279,7 +280,8 @@ func TestPartition_getReplicaState(t *testing.T) {
 	fan.EXPECT().Pending().Return(int64(1))
 	q.EXPECT().AppendedSeq().Return(int64(1))
 	state := p.getReplicaState()
-	assert.NotNil(t, state)
+	assert.Len(t, state.Replicators, 1)
+	assert.Equal(t, writeState, state.Replicators[0].WriteState)
 }
 
 func TestPartition_IsExpire(t *testing.T) {
@@ -0,0 +1,142 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"sync"
+	"time"
+
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// preAggregator buffers the rows of database channel in a short window, folds the points of same series and
+// time slot before writing into family channels, so that the replication bandwidth isn't wasted by the rows
+// sent by high-frequency producers(like 1s data into 10s interval database).
+// Window is flushed when buffered rows exceed max rows, window elapsed or database channel stopped.
+type preAggregator struct {
+	window  time.Duration
+	maxRows int
+	write   func(rows *metric.BrokerBatchRows) error
+
+	mutex      sync.Mutex
+	aggregator *metric.BrokerRowAggregator
+	stop       chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
+
+	database   string
+	statistics *metrics.BrokerPreAggregationStatistics
+	logger     *logger.Logger
+}
+
+// newPreAggregator creates a pre-aggregator which folds rows by time slot of interval, then starts flushing window.
+func newPreAggregator(
+	database string,
+	window time.Duration,
+	maxRows int,
+	interval int64,
+	write func(rows *metric.BrokerBatchRows) error,
+) *preAggregator {
+	p := &preAggregator{
+		window:     window,
+		maxRows:    maxRows,
+		write:      write,
+		aggregator: metric.NewBrokerRowAggregator(interval),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+		database:   database,
+		statistics: metrics.NewBrokerPreAggregationStatistics(database),
+		logger:     logger.GetLogger("Replica", "PreAggregator"),
+	}
+	go p.run()
+	return p
+}
+
+// add moves the foldable rows of batch into window, returns the num. of rows moved,
+// the rows not moved(compound field etc.) need be written directly.
+func (p *preAggregator) add(rows *metric.BrokerBatchRows) int {
+	p.mutex.Lock()
+	moved := rows.PreAggregate(p.aggregator)
+	var full *metric.BrokerBatchRows
+	if p.aggregator.Len() >= p.maxRows {
+		full = p.aggregator.Drain()
+	}
+	p.statistics.BufferedRows.Update(float64(p.aggregator.Len()))
+	p.mutex.Unlock()
+
+	p.statistics.InputRows.Add(float64(moved))
+	if full != nil {
+		p.writeRows(full)
+	}
+	return moved
+}
+
+// flush writes the folded rows of current window into family channels.
+func (p *preAggregator) flush() {
+	p.mutex.Lock()
+	if p.aggregator.Len() == 0 {
+		p.mutex.Unlock()
+		return
+	}
+	rows := p.aggregator.Drain()
+	p.statistics.BufferedRows.Update(0)
+	p.mutex.Unlock()
+
+	p.writeRows(rows)
+}
+
+// writeRows writes the folded rows into family channels, rows are released after written.
+func (p *preAggregator) writeRows(rows *metric.BrokerBatchRows) {
+	defer rows.Release()
+
+	if err := p.write(rows); err != nil {
+		p.statistics.FlushFailures.Incr()
+		p.logger.Error("failed writing pre-aggregated rows",
+			logger.String("database", p.database), logger.Int("rows", rows.Len()), logger.Error(err))
+		return
+	}
+	p.statistics.Flush.Incr()
+	p.statistics.OutputRows.Add(float64(rows.Len()))
+}
+
+// run flushes window periodically until stopped.
+func (p *preAggregator) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.flush()
+		case <-p.stop:
+			return
+		}
+	}
+}
+
+// stopAndFlush stops flushing window periodically, then flushes the rows buffered in window.
+func (p *preAggregator) stopAndFlush() {
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.flush()
+	})
+}
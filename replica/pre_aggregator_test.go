@@ -0,0 +1,106 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func newPreAggregateTestRows(t *testing.T, hosts ...string) *metric.BrokerBatchRows {
+	converter := metric.NewProtoConverter()
+	batch := metric.NewBrokerBatchRows()
+	now := timeutil.Now()
+	for _, host := range hosts {
+		host := host
+		assert.NoError(t, batch.TryAppend(func(row *metric.BrokerRow) error {
+			return converter.ConvertTo(&protoMetricsV1.Metric{
+				Name:      "cpu",
+				Timestamp: now,
+				SimpleFields: []*protoMetricsV1.SimpleField{
+					{Name: "f1", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1}},
+				Tags: []*protoMetricsV1.KeyValue{{Key: "host", Value: host}},
+			}, row)
+		}))
+	}
+	return batch
+}
+
+func TestPreAggregator_Flush(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		written []int
+		err     error
+	)
+	write := func(rows *metric.BrokerBatchRows) error {
+		lock.Lock()
+		defer lock.Unlock()
+		written = append(written, rows.Len())
+		return err
+	}
+	getWritten := func() []int {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]int(nil), written...)
+	}
+
+	// flush on size
+	p := newPreAggregator("pre-agg-size", time.Hour, 2, 10*timeutil.OneSecond, write)
+	assert.Equal(t, 2, p.add(newPreAggregateTestRows(t, "1.1.1.1", "1.1.1.1")))
+	assert.Empty(t, getWritten())
+	assert.Equal(t, float64(1), p.statistics.BufferedRows.Get())
+	assert.Equal(t, 1, p.add(newPreAggregateTestRows(t, "1.1.1.2")))
+	assert.Equal(t, []int{2}, getWritten())
+	assert.Equal(t, float64(0), p.statistics.BufferedRows.Get())
+	assert.Equal(t, float64(3), p.statistics.InputRows.Get())
+	assert.Equal(t, float64(2), p.statistics.OutputRows.Get())
+	// flush on shutdown
+	p.add(newPreAggregateTestRows(t, "1.1.1.3"))
+	p.stopAndFlush()
+	p.stopAndFlush()
+	assert.Equal(t, []int{2, 1}, getWritten())
+
+	// flush on time
+	written = nil
+	p = newPreAggregator("pre-agg-time", 10*time.Millisecond, 100, 10*timeutil.OneSecond, write)
+	p.add(newPreAggregateTestRows(t, "1.1.1.1", "1.1.1.2", "1.1.1.1"))
+	assert.Eventually(t, func() bool {
+		return len(getWritten()) == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int{2}, getWritten())
+
+	// flush failure
+	lock.Lock()
+	err = fmt.Errorf("err")
+	lock.Unlock()
+	p.add(newPreAggregateTestRows(t, "1.1.1.1"))
+	p.stopAndFlush()
+	assert.Equal(t, float64(1), p.statistics.FlushFailures.Get())
+	// nothing to flush
+	p.flush()
+	assert.Equal(t, []int{2, 1}, getWritten())
+}
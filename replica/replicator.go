@@ -19,6 +19,7 @@ package replica
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -28,8 +29,9 @@ import (
 
 // state represents the state of replicator.
 type state struct {
-	state  models.ReplicatorState
-	errMsg string
+	state      models.ReplicatorState
+	errMsg     string
+	writeState *models.LeaderWriteState // write state of leader, only for local replicator
 }
 
 // Replicator represents write ahead log replicator.
@@ -65,6 +67,8 @@ type Replicator interface {
 	Pending() int64
 	// IgnoreMessage ignores invalid message.
 	IgnoreMessage(replicaIdx int64)
+	// Backoff returns how long to wait before consuming next message, hint of write backpressure.
+	Backoff() time.Duration
 	// Close closes replicator, releases resource.
 	Close()
 }
@@ -150,6 +154,11 @@ func (r *replicator) IgnoreMessage(replicaIdx int64) {
 	}
 }
 
+// Backoff returns how long to wait before consuming next message, no backoff by default.
+func (r *replicator) Backoff() time.Duration {
+	return 0
+}
+
 // Close closes replicator.
 func (r *replicator) Close() {
 	// do nothing
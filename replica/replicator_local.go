@@ -18,8 +18,12 @@
 package replica
 
 import (
+	"time"
+
 	"github.com/golang/snappy"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
@@ -36,6 +40,8 @@ type localReplicator struct {
 	family    tsdb.DataFamily
 	logger    *logger.Logger
 	batchRows *metric.StorageBatchRows
+	tokens    *tokenWindow  // nil if de-duplication disabled
+	backoff   time.Duration // backoff hint of last write, when family flush falls behind
 
 	block []byte
 
@@ -51,6 +57,7 @@ func NewLocalReplicator(channel *ReplicatorChannel, shard tsdb.Shard, family tsd
 		shard:      shard,
 		family:     family,
 		batchRows:  metric.NewStorageBatchRows(),
+		tokens:     newTokenWindow(config.GlobalStorageConfig().WAL.IdempotencyMaxTokens),
 		statistics: metrics.NewStorageLocalReplicatorStatistics(channel.State.Database, channel.State.ShardID.String()),
 		logger:     logger.GetLogger("Replica", "LocalReplicator"),
 		block:      make([]byte, 256*1024),
@@ -75,22 +82,29 @@ func NewLocalReplicator(channel *ReplicatorChannel, shard tsdb.Shard, family tsd
 	return lr
 }
 
-// State returns the state of local replicator, it's always ready.
+// State returns the state of local replicator, it's always ready,
+// write failures of leader are reported by write state, which never block replica of other leaders.
 func (r *localReplicator) State() *state {
-	return &state{state: models.ReplicatorReadyState}
+	writeState := r.family.GetLeaderWriteState(r.leader)
+	return &state{state: models.ReplicatorReadyState, writeState: &writeState}
 }
 
 // Replica replicas local data,
 // 1. check replica replica if valid
-// 2. un-compress/unmarshal msg
+// 2. un-compress/unmarshal msg, skip rows of duplicate idempotency token
 // 3. lookup metadata
 // 4. write metric data
 // 5. commit sequence in data family
 func (r *localReplicator) Replica(sequence int64, msg []byte) {
 	var err error
+	r.backoff = 0
 
 	if !r.family.ValidateSequence(r.leader, sequence) {
 		r.statistics.InvalidSequence.Incr()
+		r.logger.Debug("skip replica message",
+			logger.Int64("sequence", sequence),
+			logger.String("replicator", r.String()),
+			logger.Error(constants.ErrInvalidSequence))
 		return
 	}
 
@@ -122,7 +136,12 @@ func (r *localReplicator) Replica(sequence int64, msg []byte) {
 		return
 	}
 
-	r.batchRows.UnmarshalRows(r.block)
+	if r.tokens == nil {
+		r.batchRows.UnmarshalRows(r.block)
+	} else if skipped := r.batchRows.UnmarshalRowsWithDedup(r.block, r.tokens.isDuplicate); skipped > 0 {
+		// rows re-written by client retry(maybe via another broker), skip them
+		r.statistics.DuplicateRows.Add(float64(skipped))
+	}
 	rowsLen := r.batchRows.Len()
 	if rowsLen == 0 {
 		return
@@ -140,18 +159,25 @@ func (r *localReplicator) Replica(sequence int64, msg []byte) {
 		return
 	}
 	// write metric data
-	if err := r.family.WriteRows(rows); err != nil {
+	backoff, writeErr := r.family.WriteRows(r.leader, rows)
+	r.backoff = backoff
+	if writeErr != nil {
 		r.statistics.ReplicaFailures.Incr()
 		r.logger.Error("failed writing family rows",
 			logger.Int64("sequence", sequence),
 			logger.Int("rows", r.batchRows.Len()),
 			logger.String("replicator", r.String()),
-			logger.Error(err))
+			logger.Error(writeErr))
 		return
 	}
 	r.statistics.ReplicaRows.Add(float64(rowsLen))
 }
 
+// Backoff returns the backoff hint of last write, slows down consuming when family flush falls behind.
+func (r *localReplicator) Backoff() time.Duration {
+	return r.backoff
+}
+
 // Close closes local replicator.
 func (r *localReplicator) Close() {
 	// mark write data completed.
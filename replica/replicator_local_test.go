@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/klauspost/compress/snappy"
@@ -59,8 +60,10 @@ func TestLocalReplicator_New(t *testing.T) {
 	q.EXPECT().SetConsumedSeq(int64(10))
 	replicator := NewLocalReplicator(&ReplicatorChannel{State: &models.ReplicaState{Leader: 1}, ConsumerGroup: q}, shard, family)
 	assert.NotNil(t, replicator)
+	family.EXPECT().GetLeaderWriteState(int32(1)).Return(models.LeaderWriteState{Failures: 2, LastErr: "err"})
 	s := replicator.State()
-	assert.Equal(t, state{state: models.ReplicatorReadyState}, *s)
+	assert.Equal(t, models.ReplicatorReadyState, s.state)
+	assert.Equal(t, &models.LeaderWriteState{Failures: 2, LastErr: "err"}, s.writeState)
 }
 
 func TestLocalReplicator_Replica(t *testing.T) {
@@ -122,11 +125,32 @@ func TestLocalReplicator_Replica(t *testing.T) {
 
 	// write failure
 	shard.EXPECT().LookupRowMetricMeta(gomock.Any()).Return(nil)
-	family.EXPECT().WriteRows(gomock.Any()).Return(fmt.Errorf("err"))
+	family.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(time.Duration(0), fmt.Errorf("err"))
 	replicator.Replica(1, dst)
 	// write success
 	shard.EXPECT().LookupRowMetricMeta(gomock.Any()).Return(nil)
-	family.EXPECT().WriteRows(gomock.Any()).Return(nil)
+	family.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(time.Duration(0), nil)
+	replicator.Replica(1, dst)
+	assert.Zero(t, replicator.Backoff())
+	// write under backpressure
+	shard.EXPECT().LookupRowMetricMeta(gomock.Any()).Return(nil)
+	family.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(time.Second, nil)
+	replicator.Replica(1, dst)
+	assert.Equal(t, time.Second, replicator.Backoff())
+	// rows with idempotency token
+	buf.Reset()
+	_, _ = metric.WriteIdempotencyToken(buf, "token", 1)
+	_, _ = row.WriteTo(buf)
+	dst = snappy.Encode(dst, buf.Bytes())
+	shard.EXPECT().LookupRowMetricMeta(gomock.Any()).Return(nil)
+	family.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(time.Duration(0), nil)
+	replicator.Replica(1, dst)
+	// duplicate idempotency token, skip rows
+	replicator.Replica(1, dst)
+	// de-duplication disabled
+	replicator.(*localReplicator).tokens = nil
+	shard.EXPECT().LookupRowMetricMeta(gomock.Any()).Return(nil)
+	family.EXPECT().WriteRows(gomock.Any(), gomock.Any()).Return(time.Duration(0), nil)
 	replicator.Replica(1, dst)
 	// bad data
 	dst = snappy.Encode(dst, []byte("bad-data"))
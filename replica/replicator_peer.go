@@ -188,6 +188,7 @@ func (r *replicatorRunner) replica(_ context.Context) {
 				r.replicator.Replica(seq, data)
 
 				r.statistics.ReplicaBytes.Add(float64(len(data)))
+				r.backoff(r.replicator.Backoff())
 			}
 		}
 	} else {
@@ -209,3 +210,25 @@ func (r *replicatorRunner) replica(_ context.Context) {
 		}
 	}
 }
+
+// backoff slows down consuming when storage write is under backpressure(flush falls behind),
+// pending messages are accumulated in wal, which are observed by replica lag.
+func (r *replicatorRunner) backoff(backoff time.Duration) {
+	if backoff <= 0 {
+		return
+	}
+	r.statistics.Backpressure.Incr()
+	r.statistics.BackpressureMs.Add(float64(backoff.Milliseconds()))
+	if !r.sleepFn.Stop() {
+		// drain the expired timer
+		select {
+		case <-r.sleepFn.C:
+		default:
+		}
+	}
+	r.sleepFn.Reset(backoff)
+	select {
+	case <-r.ctx.Done():
+	case <-r.sleepFn.C:
+	}
+}
@@ -116,6 +116,7 @@ func TestNewReplicator_runner(t *testing.T) {
 	replicator.EXPECT().Consume().Return(int64(1))            // has data
 	replicator.EXPECT().GetMessage(int64(1)).Return(nil, nil) // get message
 	replicator.EXPECT().Replica(gomock.Any(), gomock.Any())   // replica
+	replicator.EXPECT().Backoff().Return(time.Duration(0))    // no backpressure
 	// other loop
 	replicator.EXPECT().IsReady().DoAndReturn(func() bool {
 		wait.Done()
@@ -195,3 +196,49 @@ func TestReplicatorPeer_replicaNoData(t *testing.T) {
 		r.replica(context.TODO())
 	}
 }
+
+func TestReplicatorPeer_backpressure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	replicator := NewMockReplicator(ctrl)
+	replicator.EXPECT().String().Return("str").AnyTimes()
+	replicator.EXPECT().ReplicaState().Return(&models.ReplicaState{}).AnyTimes()
+	replicator.EXPECT().Pending().Return(int64(0)).AnyTimes()
+	replicator.EXPECT().IsReady().Return(true).AnyTimes()
+	replicator.EXPECT().Connect().Return(true).AnyTimes()
+	replicator.EXPECT().Consume().Return(int64(1)).AnyTimes()
+	replicator.EXPECT().GetMessage(gomock.Any()).Return([]byte{1}, nil).AnyTimes()
+	replicator.EXPECT().Replica(gomock.Any(), gomock.Any()).AnyTimes()
+	// simulate slow flush: backpressure until flush completed
+	flushing := atomic.NewBool(true)
+	replicator.EXPECT().Backoff().DoAndReturn(func() time.Duration {
+		if flushing.Load() {
+			return 10 * time.Millisecond
+		}
+		return 0
+	}).AnyTimes()
+	r := newReplicatorRunner(replicator)
+	defer r.sleepFn.Stop()
+
+	consume := func(n int) time.Duration {
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			r.replica(context.TODO())
+		}
+		return time.Since(start)
+	}
+	// consumption slows down under backpressure
+	assert.GreaterOrEqual(t, consume(5), 50*time.Millisecond)
+	assert.Equal(t, float64(5), r.statistics.Backpressure.Get())
+	assert.Equal(t, float64(50), r.statistics.BackpressureMs.Get())
+	// consumption recovers after flush completed
+	flushing.Store(false)
+	assert.Less(t, consume(5), 50*time.Millisecond)
+	assert.Equal(t, float64(5), r.statistics.Backpressure.Get())
+
+	// stop waiting when runner shutdown
+	flushing.Store(true)
+	r.cannel()
+	assert.Less(t, consume(1), 10*time.Millisecond)
+}
@@ -0,0 +1,343 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"context"
+	"hash/crc32"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoReplicaV1 "github.com/lindb/lindb/proto/gen/v1/replica"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./snapshot.go -destination=./snapshot_mock.go -package=replica
+
+// for testing
+var (
+	openFileFn     = os.Open
+	checksumFileFn = checksumFile
+)
+
+// SnapshotManager represents the manager of shard snapshots on leader node, which used for bootstrapping new replica.
+// Snapshot retains the files of each family until it is released or expired(no chunk fetched within ttl),
+// so that the files will not be removed by compaction during transferring.
+type SnapshotManager interface {
+	// CreateSnapshot creates the snapshot of shard, returns the files and sequence cut of each family.
+	CreateSnapshot(database string, shardID models.ShardID) (*protoReplicaV1.GetSnapshotResponse, error)
+	// ReadSnapshot reads the file of snapshot from offset, invokes fn for each chunk.
+	ReadSnapshot(snapshotID string, familyTime int64, fileNumber table.FileNumber, offset int64,
+		fn func(chunk *protoReplicaV1.SnapshotChunk) error) error
+	// ReleaseSnapshot releases the snapshot after replica installed it.
+	ReleaseSnapshot(snapshotID string)
+	// Close releases all snapshots.
+	Close()
+}
+
+// familySnapshot represents the retained version snapshot of family.
+type familySnapshot struct {
+	family   kv.Family
+	snapshot version.Snapshot
+	files    map[table.FileNumber]*protoReplicaV1.SnapshotFile
+}
+
+// shardSnapshot represents the family snapshots of shard.
+type shardSnapshot struct {
+	id         string
+	families   map[int64]*familySnapshot
+	lastAccess atomic.Int64
+}
+
+// release releases the version snapshots of all families.
+func (s *shardSnapshot) release() {
+	for _, family := range s.families {
+		family.snapshot.Close()
+	}
+}
+
+// snapshotManager implements SnapshotManager interface.
+type snapshotManager struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	cfg       config.ReplicaBootstrap
+	engine    tsdb.Engine
+	snapshots map[string]*shardSnapshot
+
+	mutex sync.Mutex
+
+	statistics *metrics.StorageSnapshotStatistics
+	logger     *logger.Logger
+}
+
+// NewSnapshotManager creates a SnapshotManager instance, starts the task which releases the idle snapshots.
+func NewSnapshotManager(ctx context.Context, cfg config.ReplicaBootstrap, engine tsdb.Engine) SnapshotManager {
+	c, cancel := context.WithCancel(ctx)
+	mgr := &snapshotManager{
+		ctx:        c,
+		cancel:     cancel,
+		cfg:        cfg,
+		engine:     engine,
+		snapshots:  make(map[string]*shardSnapshot),
+		statistics: metrics.NewStorageSnapshotStatistics(),
+		logger:     logger.GetLogger("Replica", "SnapshotManager"),
+	}
+	mgr.expireTask()
+	return mgr
+}
+
+// CreateSnapshot creates the snapshot of shard, returns the files and sequence cut of each family.
+func (m *snapshotManager) CreateSnapshot(database string, shardID models.ShardID) (*protoReplicaV1.GetSnapshotResponse, error) {
+	shard, ok := m.engine.GetShard(database, shardID)
+	if !ok {
+		m.statistics.CreateSnapshotFailures.Incr()
+		return nil, constants.ErrShardNotFound
+	}
+	snapshot := &shardSnapshot{
+		id:       uuid.New().String(),
+		families: make(map[int64]*familySnapshot),
+	}
+	resp := &protoReplicaV1.GetSnapshotResponse{SnapshotID: snapshot.id}
+	families := shard.GetDataFamilies(shard.CurrentInterval().Type(), timeutil.TimeRange{Start: 0, End: math.MaxInt64})
+	for _, dataFamily := range families {
+		familySnapshot, err := m.snapshotFamily(dataFamily.Family())
+		if err != nil {
+			snapshot.release()
+			m.statistics.CreateSnapshotFailures.Incr()
+			return nil, err
+		}
+		if familySnapshot == nil {
+			// family is empty
+			continue
+		}
+		snapshot.families[dataFamily.FamilyTime()] = familySnapshot
+		current := familySnapshot.snapshot.GetCurrent()
+		fs := &protoReplicaV1.FamilySnapshot{
+			FamilyTime: dataFamily.FamilyTime(),
+			Sequences:  current.GetSequences(),
+		}
+		for _, file := range current.GetAllFiles() {
+			fs.Files = append(fs.Files, familySnapshot.files[file.GetFileNumber()])
+		}
+		resp.Families = append(resp.Families, fs)
+	}
+	snapshot.lastAccess.Store(timeutil.Now())
+
+	m.mutex.Lock()
+	m.snapshots[snapshot.id] = snapshot
+	m.statistics.ActiveSnapshots.Update(float64(len(m.snapshots)))
+	m.mutex.Unlock()
+
+	m.statistics.CreateSnapshot.Incr()
+	m.logger.Info("create shard snapshot successfully",
+		logger.String("database", database), logger.Any("shardID", shardID),
+		logger.String("snapshotID", snapshot.id), logger.Int("families", len(resp.Families)))
+	return resp, nil
+}
+
+// snapshotFamily retains current version of family, computes the checksum of each file,
+// returns nil if family is empty.
+func (m *snapshotManager) snapshotFamily(family kv.Family) (*familySnapshot, error) {
+	snapshot := family.GetSnapshot()
+	files := snapshot.GetCurrent().GetAllFiles()
+	if len(files) == 0 {
+		snapshot.Close()
+		return nil, nil
+	}
+	fs := &familySnapshot{
+		family:   family,
+		snapshot: snapshot,
+		files:    make(map[table.FileNumber]*protoReplicaV1.SnapshotFile),
+	}
+	for _, file := range files {
+		checksum, err := checksumFileFn(family.FilePath(file.GetFileNumber()))
+		if err != nil {
+			snapshot.Close()
+			return nil, err
+		}
+		fs.files[file.GetFileNumber()] = &protoReplicaV1.SnapshotFile{
+			FileNumber: int64(file.GetFileNumber()),
+			MinKey:     file.GetMinKey(),
+			MaxKey:     file.GetMaxKey(),
+			FileSize:   int64(file.GetFileSize()),
+			Checksum:   checksum,
+		}
+	}
+	return fs, nil
+}
+
+// ReadSnapshot reads the file of snapshot from offset, invokes fn for each chunk.
+func (m *snapshotManager) ReadSnapshot(snapshotID string, familyTime int64, fileNumber table.FileNumber, offset int64,
+	fn func(chunk *protoReplicaV1.SnapshotChunk) error,
+) error {
+	m.mutex.Lock()
+	snapshot, ok := m.snapshots[snapshotID]
+	m.mutex.Unlock()
+	if !ok {
+		return ErrSnapshotNotFound
+	}
+	family, ok := snapshot.families[familyTime]
+	if !ok {
+		return errSnapshotFileNotFound
+	}
+	file, ok := family.files[fileNumber]
+	if !ok {
+		return errSnapshotFileNotFound
+	}
+	if offset < 0 || offset > file.FileSize {
+		return errInvalidSnapshotOffset
+	}
+	f, err := openFileFn(family.family.FilePath(fileNumber))
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	buf := make([]byte, m.cfg.ChunkSize)
+	for offset < file.FileSize {
+		if m.ctx.Err() != nil {
+			return m.ctx.Err()
+		}
+		n, err := io.ReadFull(f, buf[:minInt64(int64(len(buf)), file.FileSize-offset)])
+		if err != nil {
+			return err
+		}
+		data := buf[:n]
+		if err := fn(&protoReplicaV1.SnapshotChunk{
+			Offset:   offset,
+			Data:     data,
+			Checksum: crc32.ChecksumIEEE(data),
+		}); err != nil {
+			return err
+		}
+		offset += int64(n)
+		snapshot.lastAccess.Store(timeutil.Now())
+		m.statistics.SendChunk.Incr()
+		m.statistics.SendBytes.Add(float64(n))
+	}
+	return nil
+}
+
+// ReleaseSnapshot releases the snapshot after replica installed it.
+func (m *snapshotManager) ReleaseSnapshot(snapshotID string) {
+	m.mutex.Lock()
+	snapshot, ok := m.snapshots[snapshotID]
+	if ok {
+		delete(m.snapshots, snapshotID)
+		m.statistics.ActiveSnapshots.Update(float64(len(m.snapshots)))
+	}
+	m.mutex.Unlock()
+
+	if ok {
+		snapshot.release()
+		m.logger.Info("release shard snapshot", logger.String("snapshotID", snapshotID))
+	}
+}
+
+// Close releases all snapshots.
+func (m *snapshotManager) Close() {
+	m.cancel()
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for id, snapshot := range m.snapshots {
+		snapshot.release()
+		delete(m.snapshots, id)
+	}
+	m.statistics.ActiveSnapshots.Update(0)
+}
+
+// expireTask starts the task which releases the snapshots not accessed within ttl.
+func (m *snapshotManager) expireTask() {
+	interval := m.cfg.SnapshotTTL.Duration() / 2
+	if interval < time.Second {
+		interval = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.expire()
+			case <-m.ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// expire releases the snapshots not accessed within ttl, replica re-creates snapshot when resuming transfer.
+func (m *snapshotManager) expire() {
+	deadline := timeutil.Now() - m.cfg.SnapshotTTL.Duration().Milliseconds()
+	var expired []string
+	m.mutex.Lock()
+	for id, snapshot := range m.snapshots {
+		if snapshot.lastAccess.Load() < deadline {
+			expired = append(expired, id)
+		}
+	}
+	m.mutex.Unlock()
+
+	for _, id := range expired {
+		m.statistics.ExpireSnapshot.Incr()
+		m.ReleaseSnapshot(id)
+	}
+}
+
+// checksumFile computes the crc32 checksum of whole file.
+func checksumFile(path string) (uint32, error) {
+	f, err := openFileFn(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, f); err != nil {
+		return 0, err
+	}
+	return h.Sum32(), nil
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,466 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/coordinator/storage"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoReplicaV1 "github.com/lindb/lindb/proto/gen/v1/replica"
+	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/tsdb"
+)
+
+//go:generate mockgen -source=./snapshot_receiver.go -destination=./snapshot_receiver_mock.go -package=replica
+
+const bootstrapStateFile = "bootstrap.json"
+
+// for testing
+var (
+	openSnapshotFileFn = os.OpenFile
+	removeBootstrapFn  = fileutil.RemoveDir
+)
+
+// SnapshotReceiver represents the receiver of shard snapshot on new replica node,
+// transfers the family snapshots from leader in chunks, installs them, then switches to tail replication
+// from the sequence cut of snapshot. The verified offset of each file is persisted, so that interrupted
+// transfer resumes from last verified chunk.
+type SnapshotReceiver interface {
+	// Bootstrap bootstraps the shard replica from the snapshot of leader, reports the progress when changed.
+	Bootstrap(ctx context.Context, task *models.ReplicaBootstrap, report func(progress *models.ReplicaBootstrapProgress)) error
+	// IsBootstrapping returns if the shard replica is bootstrapping, the replication from leader is rejected.
+	IsBootstrapping(database string, shardID models.ShardID) bool
+}
+
+// bootstrapState represents the transfer state of snapshot persisted on local disk, used for resuming transfer.
+type bootstrapState struct {
+	TaskID     string                           `json:"taskId"`
+	SnapshotID string                           `json:"snapshotId"`
+	Families   []*protoReplicaV1.FamilySnapshot `json:"families"`
+	Offsets    map[string]int64                 `json:"offsets"`   // verified offset of file, key: familyTime/fileNumber
+	Installed  map[int64]bool                   `json:"installed"` // installed families
+}
+
+// reset resets the state with new snapshot of leader, keeps the verified offset of files not changed.
+func (s *bootstrapState) reset(snapshot *protoReplicaV1.GetSnapshotResponse) {
+	files := make(map[string]*protoReplicaV1.SnapshotFile)
+	for _, family := range s.Families {
+		for _, file := range family.Files {
+			files[fileKey(family.FamilyTime, file.FileNumber)] = file
+		}
+	}
+	offsets := make(map[string]int64)
+	for _, family := range snapshot.Families {
+		for _, file := range family.Files {
+			key := fileKey(family.FamilyTime, file.FileNumber)
+			if old, ok := files[key]; ok && old.FileSize == file.FileSize && old.Checksum == file.Checksum {
+				offsets[key] = s.Offsets[key]
+			}
+		}
+	}
+	s.SnapshotID = snapshot.SnapshotID
+	s.Families = snapshot.Families
+	s.Offsets = offsets
+}
+
+// snapshotReceiver implements SnapshotReceiver interface.
+type snapshotReceiver struct {
+	cfg      config.ReplicaBootstrap
+	engine   tsdb.Engine
+	walMgr   WriteAheadLogManager
+	stateMgr storage.StateManager
+	cliFct   rpc.ClientStreamFactory
+
+	bootstrapping map[string]struct{}
+	mutex         sync.Mutex
+
+	logger *logger.Logger
+}
+
+// NewSnapshotReceiver creates a SnapshotReceiver instance.
+func NewSnapshotReceiver(
+	cfg config.ReplicaBootstrap,
+	engine tsdb.Engine,
+	walMgr WriteAheadLogManager,
+	stateMgr storage.StateManager,
+	cliFct rpc.ClientStreamFactory,
+) SnapshotReceiver {
+	return &snapshotReceiver{
+		cfg:           cfg,
+		engine:        engine,
+		walMgr:        walMgr,
+		stateMgr:      stateMgr,
+		cliFct:        cliFct,
+		bootstrapping: make(map[string]struct{}),
+		logger:        logger.GetLogger("Replica", "SnapshotReceiver"),
+	}
+}
+
+// IsBootstrapping returns if the shard replica is bootstrapping, the replication from leader is rejected.
+func (r *snapshotReceiver) IsBootstrapping(database string, shardID models.ShardID) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := r.bootstrapping[shardKey(database, shardID)]
+	return ok
+}
+
+// Bootstrap bootstraps the shard replica from the snapshot of leader, reports the progress when changed.
+func (r *snapshotReceiver) Bootstrap(ctx context.Context, task *models.ReplicaBootstrap,
+	report func(progress *models.ReplicaBootstrapProgress),
+) (err error) {
+	key := shardKey(task.Database, task.ShardID)
+	r.mutex.Lock()
+	if _, ok := r.bootstrapping[key]; ok {
+		r.mutex.Unlock()
+		return fmt.Errorf("shard replica[%s] is bootstrapping", key)
+	}
+	r.bootstrapping[key] = struct{}{}
+	r.mutex.Unlock()
+
+	statistics := metrics.NewStorageReplicaBootstrapStatistics(task.Database, task.ShardID.String())
+	progress := &models.ReplicaBootstrapProgress{TaskID: task.ID, State: models.ReplicaBootstrapPending}
+	defer func() {
+		r.mutex.Lock()
+		delete(r.bootstrapping, key)
+		r.mutex.Unlock()
+
+		if err != nil {
+			statistics.BootstrapFailures.Incr()
+			progress.State = models.ReplicaBootstrapFailed
+			progress.Error = err.Error()
+			r.logger.Warn("bootstrap shard replica failure, resume later",
+				logger.String("shard", key), logger.String("taskID", task.ID), logger.Error(err))
+		} else {
+			statistics.Bootstrap.Incr()
+			progress.State = models.ReplicaBootstrapCompleted
+			r.logger.Info("bootstrap shard replica successfully",
+				logger.String("shard", key), logger.String("taskID", task.ID))
+		}
+		progress.UpdatedAt = timeutil.Now()
+		report(progress)
+	}()
+
+	shard, ok := r.engine.GetShard(task.Database, task.ShardID)
+	if !ok {
+		return constants.ErrShardNotFound
+	}
+	dir := filepath.Join(r.cfg.Dir, task.Database, task.ShardID.String())
+	state, err := r.loadState(dir, task.ID)
+	if err != nil {
+		return err
+	}
+	leader, ok := r.stateMgr.GetLiveNode(task.Leader)
+	if !ok {
+		return fmt.Errorf("leader node[%d] is not alive", task.Leader)
+	}
+	cli, err := r.cliFct.CreateReplicaServiceClient(&leader)
+	if err != nil {
+		return err
+	}
+	if state.SnapshotID == "" {
+		snapshot, err0 := cli.GetSnapshot(ctx, &protoReplicaV1.GetSnapshotRequest{
+			Database: task.Database,
+			Shard:    int32(task.ShardID),
+		})
+		if err0 != nil {
+			return err0
+		}
+		state.reset(snapshot)
+		if err0 = r.saveState(dir, state); err0 != nil {
+			return err0
+		}
+	}
+
+	progress.State = models.ReplicaBootstrapTransferring
+	progress.TotalFamilies = len(state.Families)
+	for _, family := range state.Families {
+		if state.Installed[family.FamilyTime] {
+			progress.InstalledFamilies++
+		}
+		for _, file := range family.Files {
+			progress.TotalBytes += file.FileSize
+			progress.TransferredBytes += state.Offsets[fileKey(family.FamilyTime, file.FileNumber)]
+		}
+	}
+	r.reportProgress(progress, report)
+
+	for _, family := range state.Families {
+		if state.Installed[family.FamilyTime] {
+			continue
+		}
+		for _, file := range family.Files {
+			if err = r.fetchFile(ctx, cli, dir, state, family.FamilyTime, file, progress, statistics); err != nil {
+				if status.Code(err) == codes.NotFound {
+					// snapshot released by leader(expired/restarted), re-create snapshot when resuming
+					state.SnapshotID = ""
+					_ = r.saveState(dir, state)
+				}
+				return err
+			}
+			r.reportProgress(progress, report)
+		}
+		if err = r.installFamily(shard, task, dir, family); err != nil {
+			return err
+		}
+		statistics.InstallFamily.Incr()
+		state.Installed[family.FamilyTime] = true
+		if err = r.saveState(dir, state); err != nil {
+			return err
+		}
+		progress.InstalledFamilies++
+		r.reportProgress(progress, report)
+	}
+
+	if _, err0 := cli.ReleaseSnapshot(ctx, &protoReplicaV1.ReleaseSnapshotRequest{SnapshotID: state.SnapshotID}); err0 != nil {
+		// snapshot will be released by leader after expired
+		r.logger.Warn("release snapshot of leader failure",
+			logger.String("shard", key), logger.String("snapshotID", state.SnapshotID), logger.Error(err0))
+	}
+	return removeBootstrapFn(dir)
+}
+
+// fetchFile fetches the snapshot file from last verified offset, verifies the checksum of each chunk and whole file.
+func (r *snapshotReceiver) fetchFile(
+	ctx context.Context,
+	cli protoReplicaV1.ReplicaServiceClient,
+	dir string,
+	state *bootstrapState,
+	familyTime int64,
+	file *protoReplicaV1.SnapshotFile,
+	progress *models.ReplicaBootstrapProgress,
+	statistics *metrics.StorageReplicaBootstrapStatistics,
+) error {
+	key := fileKey(familyTime, file.FileNumber)
+	offset := state.Offsets[key]
+	path := snapshotFilePath(dir, familyTime, file.FileNumber)
+	if offset > 0 && !fileutil.Exist(path) {
+		// file moved by interrupted installation, transfer it again
+		progress.TransferredBytes -= offset
+		offset = 0
+	}
+	if offset == file.FileSize {
+		// file is transferred and verified
+		return nil
+	}
+	if err := fileutil.MkDirIfNotExist(filepath.Dir(path)); err != nil {
+		return err
+	}
+	f, err := openSnapshotFileFn(path, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	// drop the data not verified
+	if err = f.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	stream, err := cli.FetchSnapshot(ctx, &protoReplicaV1.FetchSnapshotRequest{
+		SnapshotID: state.SnapshotID,
+		FamilyTime: familyTime,
+		FileNumber: file.FileNumber,
+		Offset:     offset,
+	})
+	if err != nil {
+		return err
+	}
+	for offset < file.FileSize {
+		chunk, err := stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if chunk.Offset != offset || offset+int64(len(chunk.Data)) > file.FileSize {
+			return errInvalidSnapshotOffset
+		}
+		if crc32.ChecksumIEEE(chunk.Data) != chunk.Checksum {
+			statistics.ChecksumFailures.Incr()
+			return errSnapshotChecksum
+		}
+		if _, err = f.Write(chunk.Data); err != nil {
+			return err
+		}
+		if err = f.Sync(); err != nil {
+			return err
+		}
+		offset += int64(len(chunk.Data))
+		statistics.ReceiveChunk.Incr()
+		statistics.ReceiveBytes.Add(float64(len(chunk.Data)))
+		progress.TransferredBytes += int64(len(chunk.Data))
+		if offset == file.FileSize {
+			checksum, err := checksumFileFn(path)
+			if err != nil {
+				return err
+			}
+			if checksum != file.Checksum {
+				// transfer the whole file again
+				statistics.ChecksumFailures.Incr()
+				progress.TransferredBytes -= offset
+				state.Offsets[key] = 0
+				_ = r.saveState(dir, state)
+				return errSnapshotChecksum
+			}
+		}
+		state.Offsets[key] = offset
+		if err = r.saveState(dir, state); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// installFamily installs the transferred files into family, then resets the replica index of
+// each leader's partition to the sequence cut of snapshot, so that tail replication starts from it.
+func (r *snapshotReceiver) installFamily(
+	shard tsdb.Shard,
+	task *models.ReplicaBootstrap,
+	dir string,
+	family *protoReplicaV1.FamilySnapshot,
+) error {
+	files := make([]kv.ExternalFile, len(family.Files))
+	for idx, file := range family.Files {
+		files[idx] = kv.ExternalFile{
+			Path:   snapshotFilePath(dir, family.FamilyTime, file.FileNumber),
+			MinKey: file.MinKey,
+			MaxKey: file.MaxKey,
+			Size:   uint32(file.FileSize),
+		}
+	}
+	dataFamily, err := shard.GetOrCreateBackfillDataFamily(family.FamilyTime)
+	if err != nil {
+		return err
+	}
+	if installed(dataFamily, family.Sequences) {
+		// family installed before interrupted, only resets the replica index
+		r.logger.Info("family snapshot already installed",
+			logger.String("family", dataFamily.Indicator()))
+	} else if err := dataFamily.InstallSnapshot(files, family.Sequences); err != nil {
+		return err
+	}
+	wal := r.walMgr.GetOrCreateLog(task.Database)
+	for leader, seq := range family.Sequences {
+		p, err := wal.GetOrCreatePartition(task.ShardID, family.FamilyTime, models.NodeID(leader))
+		if err != nil {
+			return err
+		}
+		p.ResetReplicaIndex(seq + 1)
+	}
+	return nil
+}
+
+// installed returns if the family contains the data of snapshot, which has same sequence cut as snapshot.
+func installed(dataFamily tsdb.DataFamily, sequences map[int32]int64) bool {
+	snapshot := dataFamily.Family().GetSnapshot()
+	defer snapshot.Close()
+	current := snapshot.GetCurrent()
+	if len(current.GetAllFiles()) == 0 || len(sequences) == 0 {
+		return false
+	}
+	persisted := current.GetSequences()
+	for leader, seq := range sequences {
+		if persisted[leader] != seq {
+			return false
+		}
+	}
+	return true
+}
+
+// loadState loads the transfer state of task from local disk, discards the state of other task.
+func (r *snapshotReceiver) loadState(dir, taskID string) (*bootstrapState, error) {
+	state := &bootstrapState{}
+	data, err := os.ReadFile(filepath.Join(dir, bootstrapStateFile))
+	if err == nil {
+		err = encoding.JSONUnmarshal(data, state)
+	}
+	if err != nil || state.TaskID != taskID {
+		// state not exist or belongs to other task, transfer from scratch
+		if err := removeBootstrapFn(dir); err != nil {
+			return nil, err
+		}
+		state = &bootstrapState{TaskID: taskID}
+	}
+	if state.Offsets == nil {
+		state.Offsets = make(map[string]int64)
+	}
+	if state.Installed == nil {
+		state.Installed = make(map[int64]bool)
+	}
+	return state, nil
+}
+
+// saveState persists the transfer state into local disk.
+func (r *snapshotReceiver) saveState(dir string, state *bootstrapState) error {
+	if err := fileutil.MkDirIfNotExist(dir); err != nil {
+		return err
+	}
+	path := filepath.Join(dir, bootstrapStateFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, encoding.JSONMarshal(state), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// reportProgress reports the progress of bootstrapping.
+func (r *snapshotReceiver) reportProgress(progress *models.ReplicaBootstrapProgress,
+	report func(progress *models.ReplicaBootstrapProgress),
+) {
+	progress.UpdatedAt = timeutil.Now()
+	report(progress)
+}
+
+// shardKey returns the key of shard replica.
+func shardKey(database string, shardID models.ShardID) string {
+	return database + "/" + shardID.String()
+}
+
+// fileKey returns the key of snapshot file.
+func fileKey(familyTime, fileNumber int64) string {
+	return strconv.FormatInt(familyTime, 10) + "/" + strconv.FormatInt(fileNumber, 10)
+}
+
+// snapshotFilePath returns the local path of snapshot file.
+func snapshotFilePath(dir string, familyTime, fileNumber int64) string {
+	return filepath.Join(dir, strconv.FormatInt(familyTime, 10), fmt.Sprintf("%06d.sst", fileNumber))
+}
@@ -0,0 +1,278 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/coordinator/storage"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/fileutil"
+	protoReplicaV1 "github.com/lindb/lindb/proto/gen/v1/replica"
+	"github.com/lindb/lindb/rpc"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestSnapshotReceiver_Bootstrap_Failure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := tsdb.NewMockEngine(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	stateMgr := storage.NewMockStateManager(ctrl)
+	cliFct := rpc.NewMockClientStreamFactory(ctrl)
+	cli := protoReplicaV1.NewMockReplicaServiceClient(ctrl)
+	r := NewSnapshotReceiver(config.ReplicaBootstrap{Dir: t.TempDir()}, engine, nil, stateMgr, cliFct)
+	task := &models.ReplicaBootstrap{ID: "task", Database: "db", ShardID: 1, Leader: 1, Replica: 3}
+	var progress *models.ReplicaBootstrapProgress
+	report := func(p *models.ReplicaBootstrapProgress) {
+		progress = p
+	}
+
+	cases := []struct {
+		name    string
+		prepare func()
+	}{
+		{
+			name: "shard not found",
+			prepare: func() {
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(nil, false)
+			},
+		},
+		{
+			name: "leader not alive",
+			prepare: func() {
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+				stateMgr.EXPECT().GetLiveNode(models.NodeID(1)).Return(models.StatefulNode{}, false)
+			},
+		},
+		{
+			name: "create replica client failure",
+			prepare: func() {
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+				stateMgr.EXPECT().GetLiveNode(models.NodeID(1)).Return(models.StatefulNode{}, true)
+				cliFct.EXPECT().CreateReplicaServiceClient(gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+		},
+		{
+			name: "get snapshot failure",
+			prepare: func() {
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+				stateMgr.EXPECT().GetLiveNode(models.NodeID(1)).Return(models.StatefulNode{}, true)
+				cliFct.EXPECT().CreateReplicaServiceClient(gomock.Any()).Return(cli, nil)
+				cli.EXPECT().GetSnapshot(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+		},
+		{
+			name: "fetch snapshot failure",
+			prepare: func() {
+				engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true)
+				stateMgr.EXPECT().GetLiveNode(models.NodeID(1)).Return(models.StatefulNode{}, true)
+				cliFct.EXPECT().CreateReplicaServiceClient(gomock.Any()).Return(cli, nil)
+				cli.EXPECT().GetSnapshot(gomock.Any(), gomock.Any()).Return(&protoReplicaV1.GetSnapshotResponse{
+					SnapshotID: "id",
+					Families: []*protoReplicaV1.FamilySnapshot{{
+						FamilyTime: 10,
+						Files:      []*protoReplicaV1.SnapshotFile{{FileNumber: 1, FileSize: 10}},
+					}},
+				}, nil)
+				cli.EXPECT().FetchSnapshot(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			progress = nil
+			tt.prepare()
+			assert.Error(t, r.Bootstrap(context.TODO(), task, report))
+			assert.Equal(t, models.ReplicaBootstrapFailed, progress.State)
+			assert.NotEmpty(t, progress.Error)
+			assert.False(t, r.IsBootstrapping("db", 1))
+		})
+	}
+}
+
+func TestSnapshotReceiver_Bootstrap(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir := t.TempDir()
+	data := []byte("hello snapshot")
+	chunks := [][]byte{data[:6], data[6:]}
+	file := &protoReplicaV1.SnapshotFile{
+		FileNumber: 1, MinKey: 5, MaxKey: 10, FileSize: int64(len(data)), Checksum: crc32.ChecksumIEEE(data),
+	}
+	newSnapshot := func(id string) *protoReplicaV1.GetSnapshotResponse {
+		return &protoReplicaV1.GetSnapshotResponse{
+			SnapshotID: id,
+			Families: []*protoReplicaV1.FamilySnapshot{{
+				FamilyTime: 10,
+				Sequences:  map[int32]int64{1: 100},
+				Files:      []*protoReplicaV1.SnapshotFile{file},
+			}},
+		}
+	}
+	// stream returns chunks from offset, corrupts the chunk if checksum is 0
+	newStream := func(offset int64, checksums ...uint32) protoReplicaV1.ReplicaService_FetchSnapshotClient {
+		stream := protoReplicaV1.NewMockReplicaService_FetchSnapshotClient(ctrl)
+		idx := 0
+		if offset > 0 {
+			idx = 1
+		}
+		stream.EXPECT().Recv().DoAndReturn(func() (*protoReplicaV1.SnapshotChunk, error) {
+			if idx >= len(chunks) {
+				return nil, io.EOF
+			}
+			chunk := &protoReplicaV1.SnapshotChunk{Offset: offset, Data: chunks[idx], Checksum: checksums[0]}
+			offset += int64(len(chunks[idx]))
+			idx++
+			checksums = checksums[1:]
+			return chunk, nil
+		}).AnyTimes()
+		return stream
+	}
+
+	engine := tsdb.NewMockEngine(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	dataFamily := tsdb.NewMockDataFamily(ctrl)
+	kvFamily := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	walMgr := NewMockWriteAheadLogManager(ctrl)
+	wal := NewMockWriteAheadLog(ctrl)
+	p := NewMockPartition(ctrl)
+	stateMgr := storage.NewMockStateManager(ctrl)
+	cliFct := rpc.NewMockClientStreamFactory(ctrl)
+	cli := protoReplicaV1.NewMockReplicaServiceClient(ctrl)
+	engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true).AnyTimes()
+	stateMgr.EXPECT().GetLiveNode(models.NodeID(1)).Return(models.StatefulNode{}, true).AnyTimes()
+	cliFct.EXPECT().CreateReplicaServiceClient(gomock.Any()).Return(cli, nil).AnyTimes()
+	dataFamily.EXPECT().Family().Return(kvFamily).AnyTimes()
+	dataFamily.EXPECT().Indicator().Return("db/1/10").AnyTimes()
+	kvFamily.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+	snapshot.EXPECT().Close().AnyTimes()
+	walMgr.EXPECT().GetOrCreateLog("db").Return(wal).AnyTimes()
+
+	r := NewSnapshotReceiver(config.ReplicaBootstrap{Dir: dir}, engine, walMgr, stateMgr, cliFct)
+	task := &models.ReplicaBootstrap{ID: "task", Database: "db", ShardID: 1, Leader: 1, Replica: 3}
+	var progress models.ReplicaBootstrapProgress
+	report := func(p *models.ReplicaBootstrapProgress) {
+		progress = *p
+	}
+	statePath := filepath.Join(dir, "db", "1", bootstrapStateFile)
+	filePath := filepath.Join(dir, "db", "1", "10", "000001.sst")
+
+	// attempt 1: checksum of 2nd chunk mismatch
+	cli.EXPECT().GetSnapshot(gomock.Any(), &protoReplicaV1.GetSnapshotRequest{Database: "db", Shard: 1}).
+		DoAndReturn(func(_ context.Context, _ *protoReplicaV1.GetSnapshotRequest, _ ...interface{}) (*protoReplicaV1.GetSnapshotResponse, error) {
+			assert.True(t, r.IsBootstrapping("db", 1))
+			// bootstrap same shard replica concurrently
+			assert.Error(t, r.Bootstrap(context.TODO(), task, func(_ *models.ReplicaBootstrapProgress) {}))
+			return newSnapshot("id-1"), nil
+		})
+	cli.EXPECT().FetchSnapshot(gomock.Any(), gomock.Any()).Return(newStream(0, crc32.ChecksumIEEE(chunks[0]), 0), nil)
+	assert.Equal(t, errSnapshotChecksum, r.Bootstrap(context.TODO(), task, report))
+	assert.Equal(t, models.ReplicaBootstrapFailed, progress.State)
+	assert.Equal(t, int64(6), progress.TransferredBytes)
+	assert.True(t, fileutil.Exist(statePath))
+
+	// attempt 2: snapshot released by leader
+	cli.EXPECT().FetchSnapshot(gomock.Any(), &protoReplicaV1.FetchSnapshotRequest{
+		SnapshotID: "id-1", FamilyTime: 10, FileNumber: 1, Offset: 6,
+	}).Return(nil, status.Error(codes.NotFound, "not found"))
+	assert.Error(t, r.Bootstrap(context.TODO(), task, report))
+
+	// attempt 3: install family failure after snapshot re-created, resume from last verified chunk
+	cli.EXPECT().GetSnapshot(gomock.Any(), gomock.Any()).Return(newSnapshot("id-2"), nil)
+	cli.EXPECT().FetchSnapshot(gomock.Any(), &protoReplicaV1.FetchSnapshotRequest{
+		SnapshotID: "id-2", FamilyTime: 10, FileNumber: 1, Offset: 6,
+	}).Return(newStream(6, crc32.ChecksumIEEE(chunks[1])), nil)
+	shard.EXPECT().GetOrCreateBackfillDataFamily(int64(10)).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, r.Bootstrap(context.TODO(), task, report))
+	content, err := os.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, data, content)
+	assert.Equal(t, progress.TotalBytes, progress.TransferredBytes)
+
+	// attempt 4: install family successfully
+	shard.EXPECT().GetOrCreateBackfillDataFamily(int64(10)).Return(dataFamily, nil).AnyTimes()
+	v.EXPECT().GetAllFiles().Return(nil)
+	dataFamily.EXPECT().InstallSnapshot([]kv.ExternalFile{{Path: filePath, MinKey: 5, MaxKey: 10, Size: uint32(len(data))}},
+		map[int32]int64{1: 100}).Return(nil)
+	wal.EXPECT().GetOrCreatePartition(models.ShardID(1), int64(10), models.NodeID(1)).Return(p, nil)
+	p.EXPECT().ResetReplicaIndex(int64(101))
+	cli.EXPECT().ReleaseSnapshot(gomock.Any(), &protoReplicaV1.ReleaseSnapshotRequest{SnapshotID: "id-2"}).
+		Return(nil, fmt.Errorf("err"))
+	assert.NoError(t, r.Bootstrap(context.TODO(), task, report))
+	assert.Equal(t, models.ReplicaBootstrapCompleted, progress.State)
+	assert.Equal(t, 1, progress.InstalledFamilies)
+	assert.False(t, fileutil.Exist(filepath.Join(dir, "db", "1")))
+	assert.False(t, r.IsBootstrapping("db", 1))
+
+	// new task, family installed before interrupted
+	task.ID = "task-2"
+	cli.EXPECT().GetSnapshot(gomock.Any(), gomock.Any()).Return(newSnapshot("id-3"), nil)
+	cli.EXPECT().FetchSnapshot(gomock.Any(), gomock.Any()).
+		Return(newStream(0, crc32.ChecksumIEEE(chunks[0]), crc32.ChecksumIEEE(chunks[1])), nil)
+	v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 5, 10, uint32(len(data)))})
+	v.EXPECT().GetSequences().Return(map[int32]int64{1: 100})
+	wal.EXPECT().GetOrCreatePartition(models.ShardID(1), int64(10), models.NodeID(1)).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, r.Bootstrap(context.TODO(), task, report))
+}
+
+func TestBootstrapState_reset(t *testing.T) {
+	state := &bootstrapState{
+		SnapshotID: "old",
+		Families: []*protoReplicaV1.FamilySnapshot{{
+			FamilyTime: 10,
+			Files: []*protoReplicaV1.SnapshotFile{
+				{FileNumber: 1, FileSize: 10, Checksum: 1},
+				{FileNumber: 2, FileSize: 10, Checksum: 2},
+			},
+		}},
+		Offsets: map[string]int64{fileKey(10, 1): 10, fileKey(10, 2): 5},
+	}
+	state.reset(&protoReplicaV1.GetSnapshotResponse{
+		SnapshotID: "new",
+		Families: []*protoReplicaV1.FamilySnapshot{{
+			FamilyTime: 10,
+			Files: []*protoReplicaV1.SnapshotFile{
+				{FileNumber: 1, FileSize: 10, Checksum: 1},
+				{FileNumber: 3, FileSize: 20, Checksum: 3}, // file 2 compacted into file 3
+			},
+		}},
+	})
+	assert.Equal(t, "new", state.SnapshotID)
+	assert.Equal(t, map[string]int64{fileKey(10, 1): 10}, state.Offsets)
+}
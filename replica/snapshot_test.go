@@ -0,0 +1,158 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package replica
+
+import (
+	"context"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
+	protoReplicaV1 "github.com/lindb/lindb/proto/gen/v1/replica"
+	"github.com/lindb/lindb/tsdb"
+)
+
+func TestSnapshotManager_CreateSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		checksumFileFn = checksumFile
+		ctrl.Finish()
+	}()
+
+	data := []byte("hello snapshot")
+	path := filepath.Join(t.TempDir(), "000001.sst")
+	assert.NoError(t, os.WriteFile(path, data, 0o644))
+
+	engine := tsdb.NewMockEngine(ctrl)
+	shard := tsdb.NewMockShard(ctrl)
+	emptyFamily, family := tsdb.NewMockDataFamily(ctrl), tsdb.NewMockDataFamily(ctrl)
+	emptyKVFamily, kvFamily := kv.NewMockFamily(ctrl), kv.NewMockFamily(ctrl)
+	emptySnapshot, snapshot := version.NewMockSnapshot(ctrl), version.NewMockSnapshot(ctrl)
+	emptyVersion, v := version.NewMockVersion(ctrl), version.NewMockVersion(ctrl)
+	shard.EXPECT().CurrentInterval().Return(timeutil.Interval(10 * timeutil.OneSecond)).AnyTimes()
+	shard.EXPECT().GetDataFamilies(gomock.Any(), gomock.Any()).Return([]tsdb.DataFamily{emptyFamily, family}).AnyTimes()
+	emptyFamily.EXPECT().Family().Return(emptyKVFamily).AnyTimes()
+	emptyFamily.EXPECT().FamilyTime().Return(int64(10)).AnyTimes()
+	family.EXPECT().Family().Return(kvFamily).AnyTimes()
+	family.EXPECT().FamilyTime().Return(int64(20)).AnyTimes()
+	emptyKVFamily.EXPECT().GetSnapshot().Return(emptySnapshot).AnyTimes()
+	emptySnapshot.EXPECT().GetCurrent().Return(emptyVersion).AnyTimes()
+	emptySnapshot.EXPECT().Close().AnyTimes()
+	emptyVersion.EXPECT().GetAllFiles().Return(nil).AnyTimes()
+	kvFamily.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	kvFamily.EXPECT().FilePath(table.FileNumber(1)).Return(path).AnyTimes()
+	snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+	v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 5, 10, uint32(len(data)))}).AnyTimes()
+	v.EXPECT().GetSequences().Return(map[int32]int64{1: 100}).AnyTimes()
+
+	m := NewSnapshotManager(context.TODO(), config.ReplicaBootstrap{ChunkSize: 4, SnapshotTTL: ltoml.Duration(time.Minute)}, engine)
+	defer m.Close()
+	// shard not found
+	engine.EXPECT().GetShard("db", models.ShardID(1)).Return(nil, false)
+	_, err := m.CreateSnapshot("db", 1)
+	assert.Equal(t, constants.ErrShardNotFound, err)
+	engine.EXPECT().GetShard("db", models.ShardID(1)).Return(shard, true).AnyTimes()
+	// compute checksum failure
+	checksumFileFn = func(_ string) (uint32, error) {
+		return 0, fmt.Errorf("err")
+	}
+	snapshot.EXPECT().Close()
+	_, err = m.CreateSnapshot("db", 1)
+	assert.Error(t, err)
+	checksumFileFn = checksumFile
+	// create snapshot successfully
+	resp, err := m.CreateSnapshot("db", 1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, resp.SnapshotID)
+	assert.Equal(t, []*protoReplicaV1.FamilySnapshot{{
+		FamilyTime: 20,
+		Sequences:  map[int32]int64{1: 100},
+		Files: []*protoReplicaV1.SnapshotFile{{
+			FileNumber: 1, MinKey: 5, MaxKey: 10, FileSize: int64(len(data)), Checksum: crc32.ChecksumIEEE(data),
+		}},
+	}}, resp.Families)
+
+	// read snapshot
+	var received []byte
+	read := func(chunk *protoReplicaV1.SnapshotChunk) error {
+		assert.Equal(t, int64(len(received)), chunk.Offset)
+		assert.Equal(t, crc32.ChecksumIEEE(chunk.Data), chunk.Checksum)
+		received = append(received, chunk.Data...)
+		return nil
+	}
+	assert.Equal(t, ErrSnapshotNotFound, m.ReadSnapshot("not-found", 20, 1, 0, read))
+	assert.Equal(t, errSnapshotFileNotFound, m.ReadSnapshot(resp.SnapshotID, 10, 1, 0, read))
+	assert.Equal(t, errSnapshotFileNotFound, m.ReadSnapshot(resp.SnapshotID, 20, 2, 0, read))
+	assert.Equal(t, errInvalidSnapshotOffset, m.ReadSnapshot(resp.SnapshotID, 20, 1, 100, read))
+	assert.Error(t, m.ReadSnapshot(resp.SnapshotID, 20, 1, 0, func(_ *protoReplicaV1.SnapshotChunk) error {
+		return fmt.Errorf("err")
+	}))
+	// resume from offset
+	received = data[:6]
+	assert.NoError(t, m.ReadSnapshot(resp.SnapshotID, 20, 1, 6, read))
+	assert.Equal(t, data, received)
+	// open file failure
+	openFileFn = func(_ string) (*os.File, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, m.ReadSnapshot(resp.SnapshotID, 20, 1, 0, read))
+	openFileFn = os.Open
+
+	// release snapshot
+	snapshot.EXPECT().Close()
+	m.ReleaseSnapshot(resp.SnapshotID)
+	m.ReleaseSnapshot(resp.SnapshotID)
+	assert.Equal(t, ErrSnapshotNotFound, m.ReadSnapshot(resp.SnapshotID, 20, 1, 0, read))
+
+	// expire idle snapshot
+	resp, err = m.CreateSnapshot("db", 1)
+	assert.NoError(t, err)
+	mgr := m.(*snapshotManager)
+	mgr.expire()
+	assert.NoError(t, m.ReadSnapshot(resp.SnapshotID, 20, 1, int64(len(data)), read))
+	mgr.snapshots[resp.SnapshotID].lastAccess.Store(timeutil.Now() - time.Hour.Milliseconds())
+	snapshot.EXPECT().Close()
+	mgr.expire()
+	assert.Equal(t, ErrSnapshotNotFound, m.ReadSnapshot(resp.SnapshotID, 20, 1, 0, read))
+
+	// close releases all snapshots
+	_, err = m.CreateSnapshot("db", 1)
+	assert.NoError(t, err)
+	snapshot.EXPECT().Close()
+	m.Close()
+	assert.Empty(t, mgr.snapshots)
+}
+
+func TestChecksumFile(t *testing.T) {
+	_, err := checksumFile(filepath.Join(t.TempDir(), "not-exist"))
+	assert.Error(t, err)
+}
@@ -72,6 +72,9 @@ type ClientConnFactory interface {
 	GetClientConn(target models.Node) (*grpc.ClientConn, error)
 	// CloseClientConn closes client connection for spec target node.
 	CloseClientConn(target models.Node) error
+	// SetTLSProvider sets the transport credentials provider for new connections, nil means plaintext.
+	// It should be set before any connection created.
+	SetTLSProvider(tlsProvider TLSProvider)
 }
 
 // clientConnFactory implements ClientConnFactory.
@@ -81,6 +84,7 @@ type clientConnFactory struct {
 	// lock to protect connMap
 	mu            sync.RWMutex
 	clientTracker *conntrack.GRPCClientTracker
+	tlsProvider   TLSProvider
 }
 
 // GetRootClientConnFactory returns a singleton ClientConnFactory for root side.
@@ -116,9 +120,13 @@ func (fct *clientConnFactory) GetClientConn(target models.Node) (*grpc.ClientCon
 	if conn0, ok := fct.connMap[indicator]; ok {
 		return conn0, nil
 	}
+	creds := insecure.NewCredentials()
+	if fct.tlsProvider != nil {
+		creds = fct.tlsProvider.ClientCredentials()
+	}
 	conn, err := grpcDialFn(
 		target.Indicator(),
-		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithTransportCredentials(creds),
 		grpc.WithStreamInterceptor(fct.clientTracker.StreamClientInterceptor()),
 		grpc.WithUnaryInterceptor(fct.clientTracker.UnaryClientInterceptor()),
 	)
@@ -153,6 +161,14 @@ func (fct *clientConnFactory) CloseClientConn(target models.Node) error {
 	return nil
 }
 
+// SetTLSProvider sets the transport credentials provider for new connections, nil means plaintext.
+func (fct *clientConnFactory) SetTLSProvider(tlsProvider TLSProvider) {
+	fct.mu.Lock()
+	defer fct.mu.Unlock()
+
+	fct.tlsProvider = tlsProvider
+}
+
 // ClientStreamFactory is the factory to get ClientStream.
 type ClientStreamFactory interface {
 	// LogicNode returns the logic Node which will be transferred to the target server for identification.
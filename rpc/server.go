@@ -52,7 +52,8 @@ type grpcServer struct {
 	logger      *logger.Logger
 }
 
-func NewGRPCServer(cfg config.GRPC, r *linmetric.Registry) GRPCServer {
+// NewGRPCServer creates the grpc server, serves with TLS if tls provider not nil.
+func NewGRPCServer(cfg config.GRPC, r *linmetric.Registry, tlsProvider TLSProvider) GRPCServer {
 	log := logger.GetLogger("RPC", "GRPCServer")
 	grpcServerTracker := conntrack.NewGRPCServerTracker(r)
 	statistics := metrics.NewGRPCServerStatistics(r)
@@ -64,22 +65,26 @@ func NewGRPCServer(cfg config.GRPC, r *linmetric.Registry) GRPCServer {
 			return status.Errorf(codes.Internal, "panic triggered: %v", p)
 		}),
 	}
+	serverOpts := []grpc.ServerOption{
+		grpc.ConnectionTimeout(cfg.ConnectTimeout.Duration()),
+		grpc.StreamInterceptor(grpcmiddleware.ChainStreamServer(
+			grpcServerTracker.StreamServerInterceptor(),
+			grpcrecovery.StreamServerInterceptor(opts...),
+		)),
+		grpc.UnaryInterceptor(grpcmiddleware.ChainUnaryServer(
+			grpcServerTracker.UnaryServerInterceptor(),
+			grpcrecovery.UnaryServerInterceptor(opts...),
+		)),
+		grpc.MaxConcurrentStreams(uint32(cfg.MaxConcurrentStreams)),
+	}
+	if tlsProvider != nil {
+		serverOpts = append(serverOpts, grpc.Creds(tlsProvider.ServerCredentials()))
+	}
 	return &grpcServer{
 		logger:      log,
 		statistics:  statistics,
 		bindAddress: fmt.Sprintf(":%d", cfg.Port),
-		gs: grpc.NewServer(
-			grpc.ConnectionTimeout(cfg.ConnectTimeout.Duration()),
-			grpc.StreamInterceptor(grpcmiddleware.ChainStreamServer(
-				grpcServerTracker.StreamServerInterceptor(),
-				grpcrecovery.StreamServerInterceptor(opts...),
-			)),
-			grpc.UnaryInterceptor(grpcmiddleware.ChainUnaryServer(
-				grpcServerTracker.UnaryServerInterceptor(),
-				grpcrecovery.UnaryServerInterceptor(opts...),
-			)),
-			grpc.MaxConcurrentStreams(uint32(cfg.MaxConcurrentStreams)),
-		),
+		gs:          grpc.NewServer(serverOpts...),
 	}
 }
 
@@ -0,0 +1,300 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/atomic"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+//go:generate mockgen -source ./tls.go -destination=./tls_mock.go -package=rpc
+
+const (
+	tlsSideClient = "client"
+	tlsSideServer = "server"
+)
+
+// TLSProvider provides the transport credentials of intra-cluster grpc server/clients.
+// Certificates are reloaded when process receives SIGHUP or certificate files changed,
+// only new handshakes use the reloaded certificates, established connections/streams keep working.
+type TLSProvider interface {
+	// ServerCredentials returns the transport credentials for grpc server.
+	ServerCredentials() credentials.TransportCredentials
+	// ClientCredentials returns the transport credentials for grpc client.
+	ClientCredentials() credentials.TransportCredentials
+	// Reload reloads certificates if files changed, force reload if force is true.
+	Reload(force bool) error
+}
+
+// tlsProvider implements TLSProvider interface.
+type tlsProvider struct {
+	cfg        config.TLS
+	clientAuth tls.ClientAuthType
+
+	cert   atomic.Value // *tls.Certificate
+	caPool atomic.Value // *x509.CertPool
+
+	reloadLock sync.Mutex
+	modTimes   map[string]time.Time // file => last modified time
+	signalCh   chan os.Signal
+
+	statistics *metrics.GRPCTLSStatistics
+	logger     *logger.Logger
+}
+
+// NewTLSProvider creates the tls provider, loads certificates and starts watching changes until ctx done.
+func NewTLSProvider(ctx context.Context, cfg config.TLS, r *linmetric.Registry) (TLSProvider, error) {
+	clientAuth, err := parseClientAuth(cfg.ClientAuth)
+	if err != nil {
+		return nil, err
+	}
+	p := &tlsProvider{
+		cfg:        cfg,
+		clientAuth: clientAuth,
+		modTimes:   make(map[string]time.Time),
+		signalCh:   make(chan os.Signal, 1),
+		statistics: metrics.NewGRPCTLSStatistics(r),
+		logger:     logger.GetLogger("RPC", "TLSProvider"),
+	}
+	if err := p.Reload(true); err != nil {
+		return nil, err
+	}
+	signal.Notify(p.signalCh, syscall.SIGHUP)
+	go p.watch(ctx)
+	return p, nil
+}
+
+// ServerCredentials returns the transport credentials for grpc server.
+func (p *tlsProvider) ServerCredentials() credentials.TransportCredentials {
+	return &trackedCredentials{
+		TransportCredentials: credentials.NewTLS(&tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			GetConfigForClient: p.getConfigForClient,
+		}),
+		side:       tlsSideServer,
+		statistics: p.statistics,
+	}
+}
+
+// ClientCredentials returns the transport credentials for grpc client.
+func (p *tlsProvider) ClientCredentials() credentials.TransportCredentials {
+	return &trackedCredentials{
+		TransportCredentials: credentials.NewTLS(p.clientConfig()),
+		clientConfig:         p.clientConfig,
+		side:                 tlsSideClient,
+		statistics:           p.statistics,
+	}
+}
+
+// Reload reloads certificates if files changed, force reload if force is true.
+// Keeps the current certificates if reload failure.
+func (p *tlsProvider) Reload(force bool) error {
+	p.reloadLock.Lock()
+	defer p.reloadLock.Unlock()
+
+	if p.cfg.CAFile == "" {
+		p.statistics.ReloadFailures.Incr()
+		return fmt.Errorf("grpc tls ca-file cannot be empty")
+	}
+	files := []string{p.cfg.CertFile, p.cfg.KeyFile, p.cfg.CAFile}
+	modTimes := make(map[string]time.Time)
+	changed := force
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			p.statistics.ReloadFailures.Incr()
+			return fmt.Errorf("stat grpc tls file failure: %w", err)
+		}
+		modTimes[file] = stat.ModTime()
+		if !stat.ModTime().Equal(p.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(p.cfg.CertFile, p.cfg.KeyFile)
+	if err != nil {
+		p.statistics.ReloadFailures.Incr()
+		return fmt.Errorf("load grpc tls cert/key failure: %w", err)
+	}
+	caPEM, err := os.ReadFile(p.cfg.CAFile)
+	if err != nil {
+		p.statistics.ReloadFailures.Incr()
+		return fmt.Errorf("read grpc tls ca-file failure: %w", err)
+	}
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		p.statistics.ReloadFailures.Incr()
+		return fmt.Errorf("no valid certificate found in grpc tls ca-file: %s", p.cfg.CAFile)
+	}
+	p.cert.Store(&cert)
+	p.caPool.Store(caPool)
+	p.modTimes = modTimes
+	p.statistics.Reloads.Incr()
+	return nil
+}
+
+// watch reloads certificates when receiving SIGHUP or checks files changed periodically.
+func (p *tlsProvider) watch(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.ReloadInterval.Duration())
+	defer func() {
+		ticker.Stop()
+		signal.Stop(p.signalCh)
+	}()
+	for {
+		force := false
+		select {
+		case <-ctx.Done():
+			return
+		case <-p.signalCh:
+			force = true
+		case <-ticker.C:
+		}
+		if err := p.Reload(force); err != nil {
+			p.logger.Error("reload grpc tls certificates failure, keep using current certificates", logger.Error(err))
+		}
+	}
+}
+
+// getConfigForClient returns the tls config with the latest certificates for each server side handshake.
+func (p *tlsProvider) getConfigForClient(_ *tls.ClientHelloInfo) (*tls.Config, error) {
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{*p.getCertificate()},
+		ClientAuth:   p.clientAuth,
+		ClientCAs:    p.getCAPool(),
+		NextProtos:   []string{"h2"},
+	}, nil
+}
+
+// getClientCertificate returns the latest certificate for client side handshake.
+func (p *tlsProvider) getClientCertificate(_ *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return p.getCertificate(), nil
+}
+
+// clientConfig returns the tls config with the latest certificates for client side handshake,
+// server certificate is verified against ca-file, server name is the dialed host if not set.
+func (p *tlsProvider) clientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:           tls.VersionTLS12,
+		GetClientCertificate: p.getClientCertificate,
+		RootCAs:              p.getCAPool(),
+		ServerName:           p.cfg.ServerName,
+	}
+}
+
+func (p *tlsProvider) getCertificate() *tls.Certificate {
+	return p.cert.Load().(*tls.Certificate)
+}
+
+func (p *tlsProvider) getCAPool() *x509.CertPool {
+	return p.caPool.Load().(*x509.CertPool)
+}
+
+// trackedCredentials wraps the transport credentials, records handshake failures
+// and returns clear error if peer does not use TLS(mixed mode).
+type trackedCredentials struct {
+	credentials.TransportCredentials
+	clientConfig func() *tls.Config // returns the tls config with the latest ca pool for each client handshake
+	side         string
+	statistics   *metrics.GRPCTLSStatistics
+}
+
+// ClientHandshake does the authentication handshake for client side.
+func (c *trackedCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	creds := c.TransportCredentials
+	if c.clientConfig != nil {
+		// server name defaults to the host of authority(dialed address) if not set
+		creds = credentials.NewTLS(c.clientConfig())
+	}
+	tlsConn, authInfo, err := creds.ClientHandshake(ctx, authority, conn)
+	if err != nil {
+		c.statistics.HandshakeFailures.WithTagValues(c.side, authority).Incr()
+		return nil, nil, handshakeError(authority, err)
+	}
+	return tlsConn, authInfo, nil
+}
+
+// ServerHandshake does the authentication handshake for server side.
+func (c *trackedCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	tlsConn, authInfo, err := c.TransportCredentials.ServerHandshake(conn)
+	if err != nil {
+		peer := conn.RemoteAddr().String()
+		if host, _, err0 := net.SplitHostPort(peer); err0 == nil {
+			peer = host
+		}
+		c.statistics.HandshakeFailures.WithTagValues(c.side, peer).Incr()
+		return nil, nil, handshakeError(peer, err)
+	}
+	return tlsConn, authInfo, nil
+}
+
+// Clone makes a copy of this TransportCredentials.
+func (c *trackedCredentials) Clone() credentials.TransportCredentials {
+	return &trackedCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+		clientConfig:         c.clientConfig,
+		side:                 c.side,
+		statistics:           c.statistics,
+	}
+}
+
+// handshakeError returns the clear error if peer does not use TLS.
+func handshakeError(peer string, err error) error {
+	var recordErr tls.RecordHeaderError
+	if errors.As(err, &recordErr) || errors.Is(err, io.EOF) {
+		return fmt.Errorf("tls handshake with %s failure, peer may not enable grpc tls"+
+			"(all nodes of cluster must use the same grpc tls setting): %w", peer, err)
+	}
+	return fmt.Errorf("tls handshake with %s failure: %w", peer, err)
+}
+
+// parseClientAuth parses the client auth mode of grpc server.
+func parseClientAuth(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "", config.TLSClientAuthNone:
+		return tls.NoClientCert, nil
+	case config.TLSClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case config.TLSClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case config.TLSClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown grpc tls client-auth: %s", clientAuth)
+	}
+}
@@ -0,0 +1,441 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package rpc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/internal/linmetric"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+// testCA represents a self-signed ca for testing.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "lindb-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// writeTLSFiles issues a node certificate by ca, writes cert/key/ca files into dir.
+func (ca *testCA) writeTLSFiles(t *testing.T, dir string) config.TLS {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "lindb"},
+		DNSNames:     []string{"lindb"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	cfg := config.TLS{
+		Enabled:        true,
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		ClientAuth:     config.TLSClientAuthRequireAndVerify,
+		ReloadInterval: ltoml.Duration(time.Minute),
+	}
+	assert.NoError(t, os.WriteFile(cfg.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	assert.NoError(t, os.WriteFile(cfg.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+	assert.NoError(t, os.WriteFile(cfg.CAFile, ca.pem, 0600))
+	return cfg
+}
+
+// handshake does tls handshake between client/server credentials over in-memory connection.
+func handshake(clientCreds, serverCreds credentials.TransportCredentials) (clientErr, serverErr error) {
+	clientConn, serverConn := net.Pipe()
+	defer func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	}()
+	serverErrCh := make(chan error, 1)
+	go func() {
+		_, _, err := serverCreds.ServerHandshake(serverConn)
+		if err != nil {
+			_ = serverConn.Close()
+		}
+		serverErrCh <- err
+	}()
+	ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+	defer cancel()
+	_, _, clientErr = clientCreds.ClientHandshake(ctx, "127.0.0.1:2891", clientConn)
+	if clientErr != nil {
+		_ = clientConn.Close()
+	}
+	return clientErr, <-serverErrCh
+}
+
+func TestNewTLSProvider(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ca := newTestCA(t)
+	cfg := ca.writeTLSFiles(t, t.TempDir())
+
+	cases := []struct {
+		name    string
+		cfg     func() config.TLS
+		wantErr bool
+	}{
+		{
+			name: "unknown client auth",
+			cfg: func() config.TLS {
+				c := cfg
+				c.ClientAuth = "abc"
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "cert file not exist",
+			cfg: func() config.TLS {
+				c := cfg
+				c.CertFile = filepath.Join(t.TempDir(), "not-exist.pem")
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid key file",
+			cfg: func() config.TLS {
+				c := cfg
+				c.KeyFile = cfg.CAFile
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid ca file",
+			cfg: func() config.TLS {
+				c := cfg
+				c.CAFile = filepath.Join(t.TempDir(), "ca.pem")
+				assert.NoError(t, os.WriteFile(c.CAFile, []byte("abc"), 0600))
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "ca file not exist",
+			cfg: func() config.TLS {
+				c := cfg
+				c.CAFile = filepath.Join(t.TempDir(), "not-exist.pem")
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "without ca file",
+			cfg: func() config.TLS {
+				c := cfg
+				c.CAFile = ""
+				c.ClientAuth = config.TLSClientAuthNone
+				return c
+			},
+			wantErr: true,
+		},
+		{
+			name: "load certificates successfully",
+			cfg: func() config.TLS {
+				return cfg
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewTLSProvider(ctx, tt.cfg(), linmetric.BrokerRegistry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewTLSProvider() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				assert.NotNil(t, p.ServerCredentials())
+				assert.NotNil(t, p.ClientCredentials())
+			}
+		})
+	}
+}
+
+func TestTLSProvider_Handshake(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	ca := newTestCA(t)
+	cfg := ca.writeTLSFiles(t, t.TempDir())
+	p, err := NewTLSProvider(ctx, cfg, linmetric.BrokerRegistry)
+	assert.NoError(t, err)
+	// certificate issued by another ca
+	otherCfg := newTestCA(t).writeTLSFiles(t, t.TempDir())
+	other, err := NewTLSProvider(ctx, otherCfg, linmetric.BrokerRegistry)
+	assert.NoError(t, err)
+	// verify server name
+	serverNameCfg := cfg
+	serverNameCfg.ServerName = "other-name"
+	serverName, err := NewTLSProvider(ctx, serverNameCfg, linmetric.BrokerRegistry)
+	assert.NoError(t, err)
+
+	statistics := p.(*tlsProvider).statistics
+
+	t.Run("mutual tls", func(t *testing.T) {
+		clientErr, serverErr := handshake(p.ClientCredentials(), p.ServerCredentials())
+		assert.NoError(t, clientErr)
+		assert.NoError(t, serverErr)
+	})
+	t.Run("client certificate issued by untrusted ca", func(t *testing.T) {
+		failures := statistics.HandshakeFailures.WithTagValues(tlsSideServer, "pipe").Get()
+		_, serverErr := handshake(other.ClientCredentials(), p.ServerCredentials())
+		assert.Error(t, serverErr)
+		assert.Equal(t, failures+1, statistics.HandshakeFailures.WithTagValues(tlsSideServer, "pipe").Get())
+	})
+	t.Run("server certificate issued by untrusted ca", func(t *testing.T) {
+		clientErr, _ := handshake(p.ClientCredentials(), other.ServerCredentials())
+		assert.Error(t, clientErr)
+	})
+	t.Run("server name mismatch", func(t *testing.T) {
+		clientErr, _ := handshake(serverName.ClientCredentials(), p.ServerCredentials())
+		assert.Error(t, clientErr)
+	})
+	t.Run("dialed host mismatch", func(t *testing.T) {
+		clientConn, serverConn := net.Pipe()
+		defer func() {
+			_ = clientConn.Close()
+			_ = serverConn.Close()
+		}()
+		go func() {
+			_, _, _ = p.ServerCredentials().ServerHandshake(serverConn)
+			_ = serverConn.Close()
+		}()
+		ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+		defer cancel()
+		// certificate is issued for 127.0.0.1/lindb
+		_, _, err := p.ClientCredentials().ClientHandshake(ctx, "10.0.0.1:2891", clientConn)
+		assert.Error(t, err)
+	})
+	t.Run("server name overrides dialed host", func(t *testing.T) {
+		nameCfg := cfg
+		nameCfg.ServerName = "lindb"
+		name, err := NewTLSProvider(ctx, nameCfg, linmetric.BrokerRegistry)
+		assert.NoError(t, err)
+		clientConn, serverConn := net.Pipe()
+		defer func() {
+			_ = clientConn.Close()
+			_ = serverConn.Close()
+		}()
+		serverErrCh := make(chan error, 1)
+		go func() {
+			_, _, err := p.ServerCredentials().ServerHandshake(serverConn)
+			serverErrCh <- err
+		}()
+		_, _, err = name.ClientCredentials().ClientHandshake(context.TODO(), "10.0.0.1:2891", clientConn)
+		assert.NoError(t, err)
+		assert.NoError(t, <-serverErrCh)
+	})
+	t.Run("plaintext client connects to tls server", func(t *testing.T) {
+		clientConn, serverConn := net.Pipe()
+		go func() {
+			_, _ = clientConn.Write([]byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"))
+			_ = clientConn.Close()
+		}()
+		_, _, err := p.ServerCredentials().ServerHandshake(serverConn)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "peer may not enable grpc tls")
+	})
+	t.Run("tls client connects to plaintext server", func(t *testing.T) {
+		clientConn, serverConn := net.Pipe()
+		go func() {
+			buf := make([]byte, 1024)
+			_, _ = serverConn.Read(buf)
+			_ = serverConn.Close()
+		}()
+		failures := statistics.HandshakeFailures.WithTagValues(tlsSideClient, "127.0.0.1:2891").Get()
+		_, _, err := p.ClientCredentials().ClientHandshake(context.TODO(), "127.0.0.1:2891", clientConn)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "peer may not enable grpc tls")
+		assert.Equal(t, failures+1, statistics.HandshakeFailures.WithTagValues(tlsSideClient, "127.0.0.1:2891").Get())
+	})
+	t.Run("clone credentials", func(t *testing.T) {
+		clientErr, serverErr := handshake(p.ClientCredentials().Clone(), p.ServerCredentials().Clone())
+		assert.NoError(t, clientErr)
+		assert.NoError(t, serverErr)
+	})
+}
+
+func TestTLSProvider_Reload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	cfg := ca.writeTLSFiles(t, dir)
+	p0, err := NewTLSProvider(ctx, cfg, linmetric.BrokerRegistry)
+	assert.NoError(t, err)
+	p := p0.(*tlsProvider)
+	cert := p.getCertificate()
+
+	// files not changed
+	assert.NoError(t, p.Reload(false))
+	assert.Same(t, cert, p.getCertificate())
+	// force reload
+	assert.NoError(t, p.Reload(true))
+	assert.NotSame(t, cert, p.getCertificate())
+
+	// certificate files changed
+	cert = p.getCertificate()
+	_ = ca.writeTLSFiles(t, dir)
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(cfg.CertFile, future, future))
+	assert.NoError(t, p.Reload(false))
+	assert.NotSame(t, cert, p.getCertificate())
+	assert.NotEqual(t, cert.Certificate[0], p.getCertificate().Certificate[0])
+
+	// reload failure, keep current certificate
+	cert = p.getCertificate()
+	assert.NoError(t, os.WriteFile(cfg.CertFile, []byte("abc"), 0600))
+	assert.Error(t, p.Reload(false))
+	assert.Same(t, cert, p.getCertificate())
+	// handshake still works with current certificate
+	clientErr, serverErr := handshake(p.ClientCredentials(), p.ServerCredentials())
+	assert.NoError(t, clientErr)
+	assert.NoError(t, serverErr)
+
+	// reload when receiving SIGHUP
+	_ = ca.writeTLSFiles(t, dir)
+	p.signalCh <- syscall.SIGHUP
+	assert.Eventually(t, func() bool {
+		return p.getCertificate() != cert
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTLSProvider_Watch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	cfg := ca.writeTLSFiles(t, dir)
+	cfg.ReloadInterval = ltoml.Duration(10 * time.Millisecond)
+	p0, err := NewTLSProvider(ctx, cfg, linmetric.BrokerRegistry)
+	assert.NoError(t, err)
+	p := p0.(*tlsProvider)
+	cert := p.getCertificate()
+
+	_ = ca.writeTLSFiles(t, dir)
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(cfg.KeyFile, future, future))
+	assert.Eventually(t, func() bool {
+		return p.getCertificate() != cert
+	}, 5*time.Second, 10*time.Millisecond)
+	// stat failure
+	assert.NoError(t, os.Remove(cfg.CAFile))
+	assert.Error(t, p.Reload(false))
+}
+
+func TestClientConnFactory_TLS(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	cfg := newTestCA(t).writeTLSFiles(t, t.TempDir())
+	p, err := NewTLSProvider(ctx, cfg, linmetric.BrokerRegistry)
+	assert.NoError(t, err)
+
+	var opts []grpc.DialOption
+	grpcDialFn = func(target string, options ...grpc.DialOption) (*grpc.ClientConn, error) {
+		opts = options
+		return grpc.Dial(target, options...)
+	}
+	defer func() {
+		grpcDialFn = grpc.Dial
+	}()
+	fct := &clientConnFactory{
+		connMap:       make(map[string]*grpc.ClientConn),
+		clientTracker: GetBrokerClientConnFactory().(*clientConnFactory).clientTracker,
+	}
+	fct.SetTLSProvider(p)
+	target := &models.StatelessNode{HostIP: "127.0.0.1", GRPCPort: 1234}
+	conn, err := fct.GetClientConn(target)
+	assert.NoError(t, err)
+	assert.NotNil(t, conn)
+	assert.Len(t, opts, 3)
+	assert.NoError(t, fct.CloseClientConn(target))
+}
+
+func Test_parseClientAuth(t *testing.T) {
+	cases := []struct {
+		clientAuth string
+		want       tls.ClientAuthType
+		wantErr    bool
+	}{
+		{clientAuth: "", want: tls.NoClientCert},
+		{clientAuth: config.TLSClientAuthNone, want: tls.NoClientCert},
+		{clientAuth: config.TLSClientAuthRequest, want: tls.RequestClientCert},
+		{clientAuth: config.TLSClientAuthVerifyIfGiven, want: tls.VerifyClientCertIfGiven},
+		{clientAuth: config.TLSClientAuthRequireAndVerify, want: tls.RequireAndVerifyClientCert},
+		{clientAuth: "abc", wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(fmt.Sprintf("client auth %s", tt.clientAuth), func(t *testing.T) {
+			got, err := parseClientAuth(tt.clientAuth)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseClientAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
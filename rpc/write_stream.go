@@ -19,6 +19,7 @@ package rpc
 
 import (
 	"context"
+	"errors"
 	"io"
 
 	"go.uber.org/atomic"
@@ -32,6 +33,10 @@ import (
 
 //go:generate mockgen -source=./write_stream.go -destination=./write_stream_mock.go -package=rpc
 
+// AckFn is invoked when storage acknowledges the data sent via write stream, data is acknowledged in sending order,
+// err is not nil if storage failed to write the data.
+type AckFn func(err error)
+
 // WriteStream represents the channel which writes metric to storage based on grpc stream,
 // and receives write response in background.
 type WriteStream interface {
@@ -51,18 +56,35 @@ type writeStream struct {
 	familyTime int64
 
 	fct    ClientStreamFactory
+	onAck  AckFn // nil if caller doesn't care ack
 	cli    protoWriteV1.WriteService_WriteClient
 	closed *atomic.Bool
 
 	logger *logger.Logger
 }
 
+// writeCompressionKey represents the context key of codec compressing the data sent via write stream.
+type writeCompressionKey struct{}
+
+// WithWriteCompression returns the context carrying the codec(none/snappy/zstd:<level>) of data sent via write stream,
+// which is passed to storage when creating write stream.
+func WithWriteCompression(ctx context.Context, compression string) context.Context {
+	return context.WithValue(ctx, writeCompressionKey{}, compression)
+}
+
+// WriteCompressionFromContext returns the codec of data sent via write stream, returns empty(snappy) if not set.
+func WriteCompressionFromContext(ctx context.Context) string {
+	compression, _ := ctx.Value(writeCompressionKey{}).(string)
+	return compression
+}
+
 // NewWriteStream creates a WriteStream instance, initialize grpc connection(stream) and receive response task.
 func NewWriteStream(
 	ctx context.Context,
 	target models.Node,
 	database string, shardState *models.ShardState, familyTime int64,
 	fct ClientStreamFactory,
+	onAck AckFn,
 ) (WriteStream, error) {
 	c, cancel := context.WithCancel(ctx)
 	s := &writeStream{
@@ -73,6 +95,7 @@ func NewWriteStream(
 		shardState: shardState,
 		familyTime: familyTime,
 		fct:        fct,
+		onAck:      onAck,
 		closed:     atomic.NewBool(false),
 		logger:     logger.GetLogger("RPC", "WriteStream"),
 	}
@@ -96,6 +119,8 @@ func (s *writeStream) initialize() error {
 		Database:   s.database,
 		Shard:      *s.shardState,
 		FamilyTime: s.familyTime,
+		// codec negotiated by broker, old storage ignores it and only receives snappy data
+		Compression: WriteCompressionFromContext(s.ctx),
 	})
 	ctx := CreateOutgoingContextWithPairs(s.ctx, constants.RPCMetaKeyFamilyState, string(familyState))
 	writeCli, err := writeService.Write(ctx)
@@ -171,11 +196,16 @@ func (s *writeStream) recvLoop() {
 				}
 				continue
 			}
+			var ackErr error
 			if resp.Err != "" {
 				// get err from response
 				s.logger.Error("get err write response",
 					logger.String("target", s.target.Indicator()),
 					logger.String("err", resp.Err))
+				ackErr = errors.New(resp.Err)
+			}
+			if s.onAck != nil {
+				s.onAck(ackErr)
 			}
 		}
 	}
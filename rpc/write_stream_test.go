@@ -26,8 +26,12 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
 	protoWriteV1 "github.com/lindb/lindb/proto/gen/v1/write"
 )
@@ -40,7 +44,7 @@ func TestNewWriteStream(t *testing.T) {
 
 	// case 1: create write service cli err
 	fct.EXPECT().CreateWriteServiceClient(gomock.Any()).Return(nil, fmt.Errorf("err"))
-	stream, err := NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, fct)
+	stream, err := NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, fct, nil)
 	assert.Error(t, err)
 	assert.Nil(t, stream)
 
@@ -48,16 +52,25 @@ func TestNewWriteStream(t *testing.T) {
 	writeSrv := protoWriteV1.NewMockWriteServiceClient(ctrl)
 	fct.EXPECT().CreateWriteServiceClient(gomock.Any()).Return(writeSrv, nil).AnyTimes()
 	writeSrv.EXPECT().Write(gomock.Any()).Return(nil, fmt.Errorf("err"))
-	stream, err = NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, fct)
+	stream, err = NewWriteStream(context.TODO(), nil, "test", &models.ShardState{}, 1, fct, nil)
 	assert.Error(t, err)
 	assert.Nil(t, stream)
 
 	// case 3: create instance success
 	cli := protoWriteV1.NewMockWriteService_WriteClient(ctrl)
-	writeSrv.EXPECT().Write(gomock.Any()).Return(cli, nil)
+	writeSrv.EXPECT().Write(gomock.Any()).DoAndReturn(func(ctx context.Context,
+		_ ...grpc.CallOption) (protoWriteV1.WriteService_WriteClient, error) {
+		// codec of write stream is passed to storage with family state
+		md, _ := metadata.FromOutgoingContext(ctx)
+		familyState := &models.FamilyState{}
+		assert.NoError(t, encoding.JSONUnmarshal([]byte(md.Get(constants.RPCMetaKeyFamilyState)[0]), familyState))
+		assert.Equal(t, "zstd:3", familyState.Compression)
+		return cli, nil
+	})
 	cli.EXPECT().Recv().Return(nil, io.EOF).AnyTimes()
 	cli.EXPECT().Context().Return(context.TODO()).AnyTimes()
-	stream, err = NewWriteStream(context.TODO(), &models.StatefulNode{}, "test", &models.ShardState{}, 1, fct)
+	stream, err = NewWriteStream(WithWriteCompression(context.TODO(), "zstd:3"), &models.StatefulNode{},
+		"test", &models.ShardState{}, 1, fct, nil)
 	assert.NoError(t, err)
 	assert.NotNil(t, stream)
 
@@ -117,5 +130,22 @@ func TestWriteStream_Recv(t *testing.T) {
 	cli.EXPECT().Recv().Return(nil, fmt.Errorf("err"))
 	cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{Err: "err"}, nil)
 	cli.EXPECT().Recv().Return(nil, io.EOF)
+	stream.recvLoop() // case 4: ack response
+	var acks []error
+	stream = &writeStream{
+		cli:    cli,
+		closed: atomic.NewBool(false),
+		target: &models.StatefulNode{},
+		onAck: func(err error) {
+			acks = append(acks, err)
+		},
+		logger: logger.GetLogger("RPC", "WriteStream"),
+	}
+	cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{}, nil)
+	cli.EXPECT().Recv().Return(&protoWriteV1.WriteResponse{Err: "err"}, nil)
+	cli.EXPECT().Recv().Return(nil, io.EOF)
 	stream.recvLoop()
+	assert.Len(t, acks, 2)
+	assert.NoError(t, acks[0])
+	assert.EqualError(t, acks[1], "err")
 }
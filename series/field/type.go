@@ -119,6 +119,12 @@ func (t Type) AggType() AggType {
 	}
 }
 
+// ReplaceOnRewrite returns true if the value written into same time slot again replaces the old value
+// under last write wins semantics, only sum aggregation is replaced, other aggregations are idempotent for resent value.
+func (t Type) ReplaceOnRewrite(lastWriteWins bool) bool {
+	return lastWriteWins && t.AggType() == Sum
+}
+
 func (t Type) DownSamplingFunc() function.FuncType {
 	switch t {
 	case SumField:
@@ -142,7 +148,7 @@ func (t Type) IsFuncSupported(funcType function.FuncType) bool {
 	switch t {
 	case SumField:
 		switch funcType {
-		case function.Sum, function.Min, function.Max, function.Rate:
+		case function.Sum, function.Min, function.Max, function.Rate, function.Increase:
 			return true
 		default:
 			return false
@@ -163,7 +169,7 @@ func (t Type) IsFuncSupported(funcType function.FuncType) bool {
 		}
 	case LastField:
 		switch funcType {
-		case function.Sum, function.Min, function.Max, function.Last:
+		case function.Sum, function.Min, function.Max, function.Last, function.Rate, function.Increase:
 			return true
 		default:
 			return false
@@ -294,7 +300,8 @@ func getFieldParamsForLastField(funcType function.FuncType) []AggType {
 		return []AggType{Max}
 	case function.Min:
 		return []AggType{Min}
-	case function.Sum:
+	case function.Sum, function.Rate, function.Increase:
+		// increase of counter per series is aggregated by sum
 		return []AggType{Sum}
 	default:
 		return []AggType{Last}
@@ -54,12 +54,16 @@ func TestIsSupportFunc(t *testing.T) {
 	assert.True(t, SumField.IsFuncSupported(function.Min))
 	assert.True(t, SumField.IsFuncSupported(function.Max))
 	assert.False(t, SumField.IsFuncSupported(function.Quantile))
+	assert.True(t, SumField.IsFuncSupported(function.Rate))
+	assert.True(t, SumField.IsFuncSupported(function.Increase))
 
 	assert.True(t, MaxField.IsFuncSupported(function.Max))
 	assert.False(t, MaxField.IsFuncSupported(function.Quantile))
 
 	assert.True(t, LastField.IsFuncSupported(function.Last))
 	assert.False(t, LastField.IsFuncSupported(function.Quantile))
+	assert.True(t, LastField.IsFuncSupported(function.Rate))
+	assert.True(t, LastField.IsFuncSupported(function.Increase))
 
 	assert.True(t, FirstField.IsFuncSupported(function.First))
 	assert.False(t, FirstField.IsFuncSupported(function.Quantile))
@@ -88,6 +92,14 @@ func TestAggType_Aggregate(t *testing.T) {
 	})
 }
 
+func TestType_ReplaceOnRewrite(t *testing.T) {
+	assert.True(t, SumField.ReplaceOnRewrite(true))
+	assert.True(t, HistogramField.ReplaceOnRewrite(true))
+	assert.False(t, SumField.ReplaceOnRewrite(false))
+	assert.False(t, MaxField.ReplaceOnRewrite(true))
+	assert.False(t, LastField.ReplaceOnRewrite(true))
+}
+
 func TestPanicAgg(t *testing.T) {
 	assert.Panics(t, func() {
 		Type(99).AggType().Aggregate(1, 99.0)
@@ -112,6 +124,8 @@ func TestType_GetFuncFieldParams(t *testing.T) {
 	assert.Equal(t, []AggType{Max}, LastField.GetFuncFieldParams(function.Max))
 	assert.Equal(t, []AggType{Min}, LastField.GetFuncFieldParams(function.Min))
 	assert.Equal(t, []AggType{Last}, LastField.GetFuncFieldParams(function.Last))
+	assert.Equal(t, []AggType{Sum}, LastField.GetFuncFieldParams(function.Rate))
+	assert.Equal(t, []AggType{Sum}, LastField.GetFuncFieldParams(function.Increase))
 
 	assert.Equal(t, []AggType{Sum}, FirstField.GetFuncFieldParams(function.Sum))
 	assert.Equal(t, []AggType{Max}, FirstField.GetFuncFieldParams(function.Max))
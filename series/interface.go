@@ -28,8 +28,9 @@ import (
 // MetricMetaSuggester represents to suggest ability for metricNames and tagKeys.
 // default max limit of suggestions is set in constants
 type MetricMetaSuggester interface {
-	// SuggestMetrics returns suggestions from a given prefix of metricName
-	SuggestMetrics(namespace, metricPrefix string, limit int) ([]string, error)
+	// SuggestMetrics returns suggestions from a given prefix of metricName in order,
+	// starts after the given metric name if not empty(for paginated query).
+	SuggestMetrics(namespace, metricPrefix, after string, limit int) ([]string, error)
 }
 
 // TagValueSuggester represents to suggest ability for tagValues.
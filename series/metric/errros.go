@@ -37,4 +37,6 @@ var (
 	ErrMetricNanField = fmt.Errorf("%w, field is not a number", ErrBadMetricPBFormat)
 	// ErrMetricInfField represents field value is infinity, positive or negative
 	ErrMetricInfField = fmt.Errorf("%w, field is infinity", ErrBadMetricPBFormat)
+	// ErrSchemaViolation represents row violates the registered metric schema
+	ErrSchemaViolation = errors.New("metric schema violation")
 )
@@ -28,10 +28,49 @@ import (
 	"github.com/lindb/common/pkg/encoding"
 	"github.com/lindb/common/pkg/fasttime"
 	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
 
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/tag"
 )
 
+// ShardIndex returns the index of shard for giving sharding hash.
+func ShardIndex(hash uint64, numOfShards int32) int {
+	return int(jump.Hash(hash, numOfShards))
+}
+
+// ShardRouting routes sharding hash to shard, picks the base shard by jump consistent hash,
+// then applies the splits of hot shards in creation order.
+type ShardRouting struct {
+	NumOfShards int32 // num. of base shards
+	Splits      []models.ShardSplit
+}
+
+// ShardIndex returns the index of shard for giving sharding hash,
+// half key space of source shard is moved to target shard for each split.
+func (r ShardRouting) ShardIndex(hash uint64) int {
+	shardIdx := ShardIndex(hash, r.NumOfShards)
+	for _, split := range r.Splits {
+		if split.Source.Int() != shardIdx {
+			continue
+		}
+		// salt hash with target shard, so that split of same source shard picks different key space
+		if jump.Hash(hash^(uint64(split.Target)+1)*splitHashSalt, 2) == 1 {
+			shardIdx = split.Target.Int()
+		}
+	}
+	return shardIdx
+}
+
+// splitHashSalt is the golden ratio constant for mixing the hash of split.
+const splitHashSalt = 0x9E3779B97F4A7C15
+
+// RoutingHash returns the sharding hash of routing tags' key values.
+func RoutingHash(kvs tag.KeyValues) uint64 {
+	return tag.XXHashOfKeyValues(kvs)
+}
+
 type BrokerRow struct {
 	m      flatMetricsV1.Metric
 	buffer []byte
@@ -40,6 +79,8 @@ type BrokerRow struct {
 	// IsOutOfTimeRange marks if this row is out-of time-range
 	// data is not accessible when its set to true
 	IsOutOfTimeRange bool
+	// preAggregated marks if this row is moved into pre-aggregator, skipped when writing
+	preAggregated bool
 }
 
 // FromBlock resets buffer, unmarshal from a new block,
@@ -53,15 +94,44 @@ func (row *BrokerRow) FromBlock(block []byte) {
 
 func (row *BrokerRow) Metric() flatMetricsV1.Metric { return row.m }
 
+// Namespace returns the namespace of row.
+func (row *BrokerRow) Namespace() []byte { return row.m.Namespace() }
+
+// shardingHash returns the hash for sharding, hashes the key values of routing tags if set,
+// else(or row without any routing tag) returns the hash of all tags.
+func (row *BrokerRow) shardingHash(routingTags []string) uint64 {
+	if len(routingTags) == 0 {
+		return row.m.Hash()
+	}
+	var (
+		kvs tag.KeyValues
+		kv  flatMetricsV1.KeyValue
+	)
+	for i := 0; i < row.m.KeyValuesLength(); i++ {
+		row.m.KeyValues(&kv, i)
+		key := string(kv.Key())
+		for _, routingTag := range routingTags {
+			if key == routingTag {
+				kvs = append(kvs, &protoMetricsV1.KeyValue{Key: key, Value: string(kv.Value())})
+				break
+			}
+		}
+	}
+	if len(kvs) == 0 {
+		return row.m.Hash()
+	}
+	return RoutingHash(kvs)
+}
+
 func (row *BrokerRow) Size() int {
-	if row.IsOutOfTimeRange {
+	if row.IsOutOfTimeRange || row.preAggregated {
 		return 0
 	}
 	return len(row.buffer)
 }
 
 func (row *BrokerRow) WriteTo(writer io.Writer) (int, error) {
-	if row.IsOutOfTimeRange {
+	if row.IsOutOfTimeRange || row.preAggregated {
 		return 0, nil
 	}
 	return writer.Write(row.buffer)
@@ -122,10 +192,83 @@ func (br *BrokerBatchRows) EvictOutOfTimeRange(behind, ahead int64) (evicted int
 	return evicted
 }
 
+// EvictLateRows marks the rows which belong to the families older than current family,
+// returns the number of late rows accepted, and the number of late rows dropped because they are
+// later than out-of-order window, window <= 0 means accepting all late rows.
+func (br *BrokerBatchRows) EvictLateRows(interval timeutil.Interval, window int64) (accepted, dropped int) {
+	now := fasttime.UnixMilliseconds()
+	calc := interval.Calculator()
+	currentFamily := calc.CalcFamilyTime(now)
+	for idx := 0; idx < br.Len(); idx++ {
+		row := &br.rows[idx]
+		if row.IsOutOfTimeRange {
+			continue
+		}
+		timestamp := row.m.Timestamp()
+		if calc.CalcFamilyTime(timestamp) >= currentFamily {
+			continue
+		}
+		if window > 0 && timestamp < now-window {
+			row.IsOutOfTimeRange = true
+			dropped++
+			continue
+		}
+		accepted++
+	}
+	return accepted, dropped
+}
+
+// PreAggregate moves the foldable rows into pre-aggregator, moved rows are skipped when writing,
+// returns the num. of rows moved.
+func (br *BrokerBatchRows) PreAggregate(aggregator *BrokerRowAggregator) (moved int) {
+	for idx := 0; idx < br.Len(); idx++ {
+		row := &br.rows[idx]
+		if aggregator.Add(row) {
+			row.preAggregated = true
+			moved++
+		}
+	}
+	return moved
+}
+
+// Split splits rows into batches by key of row, returns itself if all rows have same key.
+func (br *BrokerBatchRows) Split(keyOf func(row *BrokerRow) string) map[string]*BrokerBatchRows {
+	rows := br.Rows()
+	if len(rows) == 0 {
+		return nil
+	}
+	keys := make([]string, len(rows))
+	same := true
+	for idx := range rows {
+		keys[idx] = keyOf(&rows[idx])
+		same = same && keys[idx] == keys[0]
+	}
+	if same {
+		return map[string]*BrokerBatchRows{keys[0]: br}
+	}
+	batches := make(map[string]*BrokerBatchRows)
+	for idx := range rows {
+		batch, ok := batches[keys[idx]]
+		if !ok {
+			batch = NewBrokerBatchRows()
+			batches[keys[idx]] = batch
+		}
+		src := &rows[idx]
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			row.FromBlock(src.buffer)
+			return nil
+		})
+	}
+	return batches
+}
+
 func (br *BrokerBatchRows) TryAppend(appendFunc func(row *BrokerRow) error) error {
 	if len(br.rows) <= br.rowCount {
 		br.rows = append(br.rows, BrokerRow{})
 	}
+	// row may be reused from pool
+	br.rows[br.rowCount].IsOutOfTimeRange = false
+	br.rows[br.rowCount].preAggregated = false
 	if err := appendFunc(&br.rows[br.rowCount]); err != nil {
 		return err
 	}
@@ -134,9 +277,15 @@ func (br *BrokerBatchRows) TryAppend(appendFunc func(row *BrokerRow) error) erro
 	return nil
 }
 
-func (br *BrokerBatchRows) NewShardGroupIterator(numOfShards int32) *BrokerBatchShardIterator {
+// NewShardGroupIterator groups rows by shard, shard of row is picked by routing tags if set, else by all tags.
+func (br *BrokerBatchRows) NewShardGroupIterator(numOfShards int32, routingTags ...string) *BrokerBatchShardIterator {
+	return br.NewRoutingShardGroupIterator(ShardRouting{NumOfShards: numOfShards}, routingTags...)
+}
+
+// NewRoutingShardGroupIterator groups rows by shard routing which includes the splits of hot shards.
+func (br *BrokerBatchRows) NewRoutingShardGroupIterator(routing ShardRouting, routingTags ...string) *BrokerBatchShardIterator {
 	for i := 0; i < br.Len(); i++ {
-		br.rows[i].shardIdx = int(jump.Hash(br.rows[i].m.Hash(), numOfShards))
+		br.rows[i].shardIdx = routing.ShardIndex(br.rows[i].shardingHash(routingTags))
 	}
 	br.shardGroupIterator.batch = br
 	br.shardGroupIterator.Reset()
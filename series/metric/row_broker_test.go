@@ -29,7 +29,9 @@ import (
 	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
 	commonseries "github.com/lindb/common/series"
 
+	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/tag"
 )
 
 func Test_BrokerBatchRows(t *testing.T) {
@@ -102,6 +104,38 @@ func buildRow(row *BrokerRow, timestamp int64) {
 	row.FromBlock(data)
 }
 
+func Test_BrokerBatchRows_EvictLateRows(t *testing.T) {
+	now := fasttime.UnixMilliseconds()
+	batch := NewBrokerBatchRows()
+	defer batch.Release()
+
+	for _, timestamp := range []int64{
+		now,
+		now - 2*timeutil.OneHour,
+		now - 3*timeutil.OneHour,
+		now - 7*timeutil.OneHour,
+	} {
+		ts := timestamp
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			buildRow(row, ts)
+			return nil
+		})
+	}
+	accepted, dropped := batch.EvictLateRows(timeutil.Interval(10*timeutil.OneSecond), 0)
+	assert.Equal(t, 3, accepted)
+	assert.Zero(t, dropped)
+
+	accepted, dropped = batch.EvictLateRows(timeutil.Interval(10*timeutil.OneSecond), 6*timeutil.OneHour)
+	assert.Equal(t, 2, accepted)
+	assert.Equal(t, 1, dropped)
+	assert.True(t, batch.Rows()[3].IsOutOfTimeRange)
+	assert.False(t, batch.Rows()[1].IsOutOfTimeRange)
+	// evicted row is skipped
+	accepted, dropped = batch.EvictLateRows(timeutil.Interval(10*timeutil.OneSecond), 6*timeutil.OneHour)
+	assert.Equal(t, 2, accepted)
+	assert.Zero(t, dropped)
+}
+
 func Test_BrokerBatchRows_AppendError(t *testing.T) {
 	batch := NewBrokerBatchRows()
 	defer batch.Release()
@@ -204,3 +238,105 @@ func Test_BrokerBatchRows_FamilyRowsForNextShard_SameFamily(t *testing.T) {
 	assert.True(t, familyItr.HasNextFamily())
 	assert.False(t, familyItr.HasNextFamily())
 }
+
+func Test_BrokerBatchRows_RoutingTags(t *testing.T) {
+	batch := NewBrokerBatchRows()
+	defer batch.Release()
+
+	now := fasttime.UnixMilliseconds()
+	for i := 0; i < 100; i++ {
+		host := "host-" + strconv.Itoa(i%3)
+		ts := now + int64(i)
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			builder, releaseFunc := commonseries.NewRowBuilder()
+			defer releaseFunc(builder)
+
+			builder.AddMetricName([]byte("test"))
+			_ = builder.AddTag([]byte("host"), []byte(host))
+			_ = builder.AddTag([]byte("ts"), []byte(strconv.FormatInt(ts, 10)))
+			_ = builder.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeDeltaSum, 100)
+			builder.AddTimestamp(ts)
+			data, _ := builder.Build()
+			row.FromBlock(data)
+			return nil
+		})
+	}
+	// row without routing tag
+	_ = batch.TryAppend(func(row *BrokerRow) error {
+		buildRow(row, now)
+		return nil
+	})
+	_ = batch.NewShardGroupIterator(16, "host")
+	for _, row := range batch.Rows() {
+		if row.m.KeyValuesLength() == 1 {
+			// shard by all tags
+			assert.Equal(t, ShardIndex(row.m.Hash(), 16), row.shardIdx)
+			continue
+		}
+		var hostKV flatMetricsV1.KeyValue
+		row.m.KeyValues(&hostKV, 0)
+		assert.Equal(t, "host", string(hostKV.Key()))
+		expect := ShardIndex(RoutingHash(tag.KeyValues{{Key: "host", Value: string(hostKV.Value())}}), 16)
+		assert.Equal(t, expect, row.shardIdx)
+	}
+}
+
+func TestShardRouting_ShardIndex(t *testing.T) {
+	routing := ShardRouting{NumOfShards: 4}
+	assert.Equal(t, ShardIndex(100, 4), routing.ShardIndex(100))
+
+	routing.Splits = []models.ShardSplit{{Source: 1, Target: 4}, {Source: 1, Target: 5}, {Source: 4, Target: 6}}
+	counts := make(map[int]int)
+	for hash := uint64(0); hash < 100000; hash++ {
+		shardIdx := routing.ShardIndex(hash)
+		counts[shardIdx]++
+		base := ShardIndex(hash, 4)
+		if base != 1 {
+			// other shards are not affected
+			assert.Equal(t, base, shardIdx)
+		}
+	}
+	assert.Len(t, counts, 7)
+	// key space of hot shard is divided: 1 => 1/4, 5 => 1/4, 4 => 1/4, 6 => 1/4
+	assert.InDelta(t, counts[1], counts[5], 1000)
+	assert.InDelta(t, counts[4], counts[6], 1000)
+	assert.InDelta(t, counts[0], counts[1]+counts[4]+counts[5]+counts[6], 1500)
+}
+
+func Test_BrokerBatchRows_Split(t *testing.T) {
+	batch := NewBrokerBatchRows()
+	defer batch.Release()
+	namespaceOf := func(row *BrokerRow) string { return string(row.Namespace()) }
+
+	assert.Nil(t, batch.Split(namespaceOf))
+
+	appendRow := func(namespace string) {
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			builder, releaseFunc := commonseries.NewRowBuilder()
+			defer releaseFunc(builder)
+			builder.AddNameSpace([]byte(namespace))
+			builder.AddMetricName([]byte("test"))
+			_ = builder.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeDeltaSum, 100)
+			builder.AddTimestamp(fasttime.UnixMilliseconds())
+			data, _ := builder.Build()
+			row.FromBlock(data)
+			return nil
+		})
+	}
+	appendRow("ns1")
+	appendRow("ns1")
+	// all rows with same key, returns itself
+	batches := batch.Split(namespaceOf)
+	assert.Len(t, batches, 1)
+	assert.True(t, batches["ns1"] == batch)
+
+	appendRow("ns2")
+	batches = batch.Split(namespaceOf)
+	assert.Len(t, batches, 2)
+	assert.Equal(t, 2, batches["ns1"].Len())
+	assert.Equal(t, 1, batches["ns2"].Len())
+	assert.Equal(t, "ns2", string(batches["ns2"].Rows()[0].Namespace()))
+	for _, b := range batches {
+		b.Release()
+	}
+}
@@ -0,0 +1,141 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"unicode/utf8"
+
+	"github.com/lindb/lindb/pkg/option"
+)
+
+// LimitViolation represents the type of violation against the ingestion limits.
+type LimitViolation int
+
+const (
+	// MetricNameTooLong represents the metric name exceeds max length.
+	MetricNameTooLong LimitViolation = iota + 1
+	// TagValueTooLong represents the tag value exceeds max length.
+	TagValueTooLong
+	// TooManyTags represents the num. of tags exceeds max tags per series.
+	TooManyTags
+)
+
+// String returns the string value of LimitViolation.
+func (v LimitViolation) String() string {
+	switch v {
+	case MetricNameTooLong:
+		return "metric_name_too_long"
+	case TagValueTooLong:
+		return "tag_value_too_long"
+	case TooManyTags:
+		return "too_many_tags"
+	default:
+		return "unknown"
+	}
+}
+
+// RowLimits represents the limits of metric name/tags of row, 0 means no limit.
+type RowLimits struct {
+	MaxMetricNameLength int
+	MaxTagValueLength   int
+	MaxTagsPerSeries    int
+	// drops the violated row if true, else truncates the metric name/tag value and drops the exceeded tags.
+	Reject bool
+}
+
+// EnforceLimits checks metric name/tags of rows against the ingestion limits,
+// drops the violated rows in reject mode, else truncates the too long metric name/tag value
+// with truncated marker suffix and keeps the first max tags of row.
+// onViolation is invoked for each violation.
+func (br *BrokerBatchRows) EnforceLimits(limits *RowLimits, onViolation func(v LimitViolation)) error {
+	var (
+		rebuilder rowRebuilder
+		tagKeys   [][]byte // kept tag keys of current row, nil means tag dropped
+		tagValues [][]byte
+	)
+	kept := 0
+	for idx := 0; idx < br.rowCount; idx++ {
+		row := &br.rows[idx]
+		origin := readOnlyRow{m: row.m}
+		changed, rejected := false, false
+
+		metricName := origin.Name()
+		if limits.MaxMetricNameLength > 0 && len(metricName) > limits.MaxMetricNameLength {
+			onViolation(MetricNameTooLong)
+			if limits.Reject {
+				continue
+			}
+			metricName = truncateWithMarker(metricName, limits.MaxMetricNameLength)
+			changed = true
+		}
+		tagKeys, tagValues = tagKeys[:0], tagValues[:0]
+		tags := 0
+		kvItr := origin.NewKeyValueIterator()
+		for kvItr.HasNext() {
+			tagKey, tagValue := kvItr.NextKey(), kvItr.NextValue()
+			tags++
+			if limits.MaxTagsPerSeries > 0 && tags > limits.MaxTagsPerSeries {
+				if tags == limits.MaxTagsPerSeries+1 {
+					onViolation(TooManyTags)
+				}
+				if limits.Reject {
+					rejected = true
+					break
+				}
+				tagKey = nil
+				changed = true
+			} else if limits.MaxTagValueLength > 0 && len(tagValue) > limits.MaxTagValueLength {
+				onViolation(TagValueTooLong)
+				if limits.Reject {
+					rejected = true
+					break
+				}
+				tagValue = truncateWithMarker(tagValue, limits.MaxTagValueLength)
+				changed = true
+			}
+			tagKeys = append(tagKeys, tagKey)
+			tagValues = append(tagValues, tagValue)
+		}
+		if rejected {
+			continue
+		}
+		if changed {
+			if err := rebuilder.rebuild(row, metricName, tagKeys, tagValues, nil); err != nil {
+				return err
+			}
+		}
+		br.keepRow(idx, &kept)
+	}
+	br.rowCount = kept
+	return nil
+}
+
+// truncateWithMarker truncates value to max length with truncated marker suffix,
+// the multi bytes character is not split.
+func truncateWithMarker(value []byte, maxLength int) []byte {
+	n := maxLength - len(option.TruncatedMarker)
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	truncated := make([]byte, 0, n+len(option.TruncatedMarker))
+	truncated = append(truncated, value[:n]...)
+	return append(truncated, option.TruncatedMarker...)
+}
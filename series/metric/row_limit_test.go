@@ -0,0 +1,132 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+func TestBrokerBatchRows_EnforceLimits(t *testing.T) {
+	sum := flatMetricsV1.SimpleFieldTypeDeltaSum
+	fields := map[string]flatMetricsV1.SimpleFieldType{"f1": sum}
+	limits := RowLimits{MaxMetricNameLength: 8, MaxTagValueLength: 8, MaxTagsPerSeries: 2}
+	reject := limits
+	reject.Reject = true
+	tagsOf := func(row BrokerRow) (tags []string) {
+		m := row.Metric()
+		var kv flatMetricsV1.KeyValue
+		for i := 0; i < m.KeyValuesLength(); i++ {
+			m.KeyValues(&kv, i)
+			tags = append(tags, string(kv.Key()), string(kv.Value()))
+		}
+		return tags
+	}
+
+	cases := []struct {
+		name       string
+		rows       []schemaTestRow
+		limits     RowLimits
+		violations map[LimitViolation]int
+		check      func(rows []BrokerRow)
+	}{
+		{
+			name:       "no limit",
+			rows:       []schemaTestRow{{metricName: strings.Repeat("a", 100), tags: []string{"a", "1", "b", "2", "c", "3"}, fields: fields}},
+			violations: map[LimitViolation]int{},
+			check: func(rows []BrokerRow) {
+				assert.Len(t, rows, 1)
+			},
+		},
+		{
+			name:       "within limits",
+			rows:       []schemaTestRow{{metricName: "cpu", tags: []string{"host", "a"}, fields: fields}},
+			limits:     reject,
+			violations: map[LimitViolation]int{},
+			check: func(rows []BrokerRow) {
+				assert.Len(t, rows, 1)
+				m := rows[0].Metric()
+				assert.Equal(t, "cpu", string(m.Name()))
+			},
+		},
+		{
+			name: "truncate",
+			rows: []schemaTestRow{{metricName: "cpu_usage_total",
+				tags: []string{"a", "stack-trace", "b", "中文中文", "c", "3"}, fields: fields, compound: true}},
+			limits:     limits,
+			violations: map[LimitViolation]int{MetricNameTooLong: 1, TagValueTooLong: 2, TooManyTags: 1},
+			check: func(rows []BrokerRow) {
+				assert.Len(t, rows, 1)
+				m := rows[0].Metric()
+				assert.Equal(t, "cpu_u...", string(m.Name()))
+				// multi bytes character is not split
+				assert.Equal(t, []string{"a", "stack...", "b", "中..."}, tagsOf(rows[0]))
+				assert.Equal(t, 1, m.SimpleFieldsLength())
+				assert.NotNil(t, m.CompoundField(nil))
+			},
+		},
+		{
+			name: "reject",
+			rows: []schemaTestRow{
+				{metricName: "cpu_usage_total", fields: fields},
+				{metricName: "cpu", tags: []string{"a", "stack-trace"}, fields: fields},
+				{metricName: "cpu", tags: []string{"a", "1", "b", "2", "c", "3", "d", "4"}, fields: fields},
+				{metricName: "mem", tags: []string{"a", "1"}, fields: fields},
+			},
+			limits:     reject,
+			violations: map[LimitViolation]int{MetricNameTooLong: 1, TagValueTooLong: 1, TooManyTags: 1},
+			check: func(rows []BrokerRow) {
+				assert.Len(t, rows, 1)
+				m := rows[0].Metric()
+				assert.Equal(t, "mem", string(m.Name()))
+				assert.Equal(t, []string{"a", "1"}, tagsOf(rows[0]))
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			batch := newSchemaTestBatch(t, tt.rows...)
+			defer batch.Release()
+
+			violations := make(map[LimitViolation]int)
+			assert.NoError(t, batch.EnforceLimits(&tt.limits, func(v LimitViolation) { violations[v]++ }))
+			assert.Equal(t, tt.violations, violations)
+			if tt.check != nil {
+				tt.check(batch.Rows())
+			}
+		})
+	}
+}
+
+func TestLimitViolation_String(t *testing.T) {
+	assert.Equal(t, "metric_name_too_long", MetricNameTooLong.String())
+	assert.Equal(t, "tag_value_too_long", TagValueTooLong.String())
+	assert.Equal(t, "too_many_tags", TooManyTags.String())
+	assert.Equal(t, "unknown", LimitViolation(0).String())
+}
+
+func TestTruncateWithMarker(t *testing.T) {
+	assert.Equal(t, "abc...", string(truncateWithMarker([]byte("abcdefgh"), 6)))
+	assert.Equal(t, "...", string(truncateWithMarker([]byte("abcdefgh"), 2)))
+	assert.Equal(t, "...", string(truncateWithMarker([]byte("中文"), 4)))
+}
@@ -0,0 +1,150 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"bytes"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	commonseries "github.com/lindb/common/series"
+)
+
+// RowNormalizer represents the normalizer which rewrites metric name/tag keys of row before writing.
+type RowNormalizer interface {
+	// NormalizeMetricName returns the normalized metric name.
+	NormalizeMetricName(namespace, metricName []byte) []byte
+	// NormalizeTagKey returns the normalized tag key, returns false if the tag need to be dropped.
+	NormalizeTagKey(namespace, tagKey []byte) ([]byte, bool)
+}
+
+// Normalize rewrites metric name/tag keys of rows by given normalizer,
+// only rebuilds the row which metric name or tag keys changed.
+func (br *BrokerBatchRows) Normalize(normalizer RowNormalizer) error {
+	var (
+		rebuilder rowRebuilder
+		tagKeys   [][]byte // normalized tag keys of current row, nil means tag dropped
+	)
+	for idx := 0; idx < br.rowCount; idx++ {
+		row := &br.rows[idx]
+		origin := readOnlyRow{m: row.m}
+		namespace := origin.NameSpace()
+		metricName := normalizer.NormalizeMetricName(namespace, origin.Name())
+		changed := !bytes.Equal(metricName, origin.Name())
+
+		tagKeys = tagKeys[:0]
+		kvItr := origin.NewKeyValueIterator()
+		for kvItr.HasNext() {
+			tagKey, ok := normalizer.NormalizeTagKey(namespace, kvItr.NextKey())
+			if !ok {
+				tagKey = nil
+			}
+			changed = changed || !ok || !bytes.Equal(tagKey, kvItr.NextKey())
+			tagKeys = append(tagKeys, tagKey)
+		}
+		if !changed {
+			continue
+		}
+		if err := rebuilder.rebuild(row, metricName, tagKeys, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// droppedFieldType marks the simple field dropped when rebuilding row.
+const droppedFieldType = flatMetricsV1.SimpleFieldType(-1)
+
+// rowRebuilder rebuilds the broker row, reuses the row builder and buffers for multi rows.
+type rowRebuilder struct {
+	builder        *commonseries.RowBuilder
+	compoundBounds []float64
+	compoundValues []float64
+}
+
+// rebuild rebuilds row with new metric name/tag keys/tag values/simple field types, nil tag key means tag dropped,
+// nil tag values keeps the tag values as is, droppedFieldType means simple field dropped,
+// nil field types keeps the simple fields as is.
+func (rb *rowRebuilder) rebuild(row *BrokerRow, metricName []byte, tagKeys, tagValues [][]byte,
+	fieldTypes []flatMetricsV1.SimpleFieldType,
+) error {
+	origin := readOnlyRow{m: row.m}
+	if rb.builder == nil {
+		rb.builder = commonseries.CreateRowBuilder()
+	} else {
+		rb.builder.Reset()
+	}
+	builder := rb.builder
+	kvItr := origin.NewKeyValueIterator()
+	for i := 0; kvItr.HasNext(); i++ {
+		if tagKeys[i] == nil {
+			continue
+		}
+		tagValue := kvItr.NextValue()
+		if tagValues != nil {
+			tagValue = tagValues[i]
+		}
+		if err := builder.AddTag(tagKeys[i], tagValue); err != nil {
+			return err
+		}
+	}
+	simpleFieldItr := origin.NewSimpleFieldIterator()
+	for i := 0; simpleFieldItr.HasNext(); i++ {
+		fieldType := simpleFieldItr.NextRawType()
+		if fieldTypes != nil {
+			fieldType = fieldTypes[i]
+		}
+		if fieldType == droppedFieldType {
+			continue
+		}
+		if err := builder.AddSimpleField(
+			simpleFieldItr.NextRawName(),
+			fieldType,
+			simpleFieldItr.NextValue(),
+		); err != nil {
+			return err
+		}
+	}
+	if compoundFieldItr, ok := origin.NewCompoundFieldIterator(); ok {
+		rb.compoundBounds = rb.compoundBounds[:0]
+		rb.compoundValues = rb.compoundValues[:0]
+		for compoundFieldItr.HasNextBucket() {
+			rb.compoundBounds = append(rb.compoundBounds, compoundFieldItr.NextExplicitBound())
+			rb.compoundValues = append(rb.compoundValues, compoundFieldItr.NextValue())
+		}
+		if err := builder.AddCompoundFieldData(rb.compoundValues, rb.compoundBounds); err != nil {
+			return err
+		}
+		if err := builder.AddCompoundFieldMMSC(
+			compoundFieldItr.Min(),
+			compoundFieldItr.Max(),
+			compoundFieldItr.Sum(),
+			compoundFieldItr.Count(),
+		); err != nil {
+			return err
+		}
+	}
+	builder.AddMetricName(metricName)
+	builder.AddNameSpace(origin.NameSpace())
+	builder.AddTimestamp(origin.Timestamp())
+	data, err := builder.Build()
+	if err != nil {
+		return err
+	}
+	row.FromBlock(data)
+	return nil
+}
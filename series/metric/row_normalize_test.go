@@ -0,0 +1,97 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	commonseries "github.com/lindb/common/series"
+)
+
+type mockRowNormalizer struct{}
+
+func (n *mockRowNormalizer) NormalizeMetricName(_, metricName []byte) []byte {
+	return bytes.ToLower(metricName)
+}
+
+func (n *mockRowNormalizer) NormalizeTagKey(_, tagKey []byte) ([]byte, bool) {
+	if string(tagKey) == "drop" {
+		return nil, false
+	}
+	return bytes.ToLower(tagKey), true
+}
+
+func TestBrokerBatchRows_Normalize(t *testing.T) {
+	batch := NewBrokerBatchRows()
+	defer batch.Release()
+
+	appendRow := func(metricName string, compound bool, tags ...string) {
+		assert.NoError(t, batch.TryAppend(func(row *BrokerRow) error {
+			builder, releaseFunc := commonseries.NewRowBuilder()
+			defer releaseFunc(builder)
+
+			builder.AddMetricName([]byte(metricName))
+			builder.AddNameSpace([]byte("ns"))
+			for i := 0; i < len(tags); i += 2 {
+				_ = builder.AddTag([]byte(tags[i]), []byte(tags[i+1]))
+			}
+			_ = builder.AddSimpleField([]byte("f1"), flatMetricsV1.SimpleFieldTypeDeltaSum, 100)
+			if compound {
+				assert.NoError(t, builder.AddCompoundFieldData([]float64{1, 2}, []float64{10, math.Inf(1)}))
+				assert.NoError(t, builder.AddCompoundFieldMMSC(1, 2, 3, 3))
+			}
+			builder.AddTimestamp(1000)
+			data, err := builder.Build()
+			if err != nil {
+				return err
+			}
+			row.FromBlock(data)
+			return nil
+		}))
+	}
+	appendRow("cpu", false, "host", "b")
+	appendRow("CPU", true, "Host", "b", "drop", "c")
+
+	unchanged := batch.Rows()[0].buffer
+	assert.NoError(t, batch.Normalize(&mockRowNormalizer{}))
+	rows := batch.Rows()
+	assert.Equal(t, unchanged, rows[0].buffer)
+
+	m := rows[1].Metric()
+	assert.Equal(t, "cpu", string(m.Name()))
+	assert.Equal(t, "ns", string(m.Namespace()))
+	assert.Equal(t, int64(1000), m.Timestamp())
+	assert.Equal(t, 1, m.KeyValuesLength())
+	var kv flatMetricsV1.KeyValue
+	assert.True(t, m.KeyValues(&kv, 0))
+	assert.Equal(t, "host", string(kv.Key()))
+	assert.Equal(t, "b", string(kv.Value()))
+	assert.Equal(t, 1, m.SimpleFieldsLength())
+	var compound flatMetricsV1.CompoundField
+	assert.NotNil(t, m.CompoundField(&compound))
+	assert.Equal(t, 2, compound.ValuesLength())
+	assert.Equal(t, float64(3), compound.Count())
+	// same series after normalization
+	m0 := rows[0].Metric()
+	assert.Equal(t, m0.Hash(), m.Hash())
+}
@@ -0,0 +1,219 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"math"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// preAggregateKey represents the key of folding rows, only rows with the same simple fields(name/type) are folded.
+type preAggregateKey struct {
+	namespace string
+	name      string
+	hash      uint64
+	slot      int64
+	fields    string
+}
+
+// preAggregatedRow represents the folded row, keeps the first row and the folded values of simple fields.
+type preAggregatedRow struct {
+	row    BrokerRow
+	values []float64
+	folded bool
+}
+
+// BrokerRowAggregator folds the broker rows of same series and time slot into one row before writing to storage,
+// based on the aggregation semantics of each simple field(sum adds, max keeps max etc.).
+// Rows with compound field or exemplars are not folded.
+// NOTICE: not thread-safe.
+type BrokerRowAggregator struct {
+	interval int64
+	rows     map[preAggregateKey]*preAggregatedRow
+	order    []*preAggregatedRow // keeps the order of first row of each key
+	fields   []byte
+	builder  *flatbuffers.Builder
+}
+
+// NewBrokerRowAggregator creates a broker row aggregator, interval is the size of time slot.
+func NewBrokerRowAggregator(interval int64) *BrokerRowAggregator {
+	return &BrokerRowAggregator{
+		interval: interval,
+		rows:     make(map[preAggregateKey]*preAggregatedRow),
+		builder:  flatbuffers.NewBuilder(1024),
+	}
+}
+
+// Len returns the num. of folded rows.
+func (a *BrokerRowAggregator) Len() int { return len(a.order) }
+
+// Add folds the row into the row of same series and time slot, returns false if row cannot be folded.
+func (a *BrokerRowAggregator) Add(row *BrokerRow) bool {
+	if row.Size() == 0 || !a.foldable(row) {
+		return false
+	}
+	key := a.keyOf(row)
+	aggRow, ok := a.rows[key]
+	if !ok {
+		aggRow = &preAggregatedRow{}
+		aggRow.row.FromBlock(row.buffer)
+		aggRow.values = make([]float64, row.m.SimpleFieldsLength())
+		var sf flatMetricsV1.SimpleField
+		for i := range aggRow.values {
+			row.m.SimpleFields(&sf, i)
+			aggRow.values[i] = sf.Value()
+		}
+		a.rows[key] = aggRow
+		a.order = append(a.order, aggRow)
+		return true
+	}
+	var sf flatMetricsV1.SimpleField
+	for i := range aggRow.values {
+		row.m.SimpleFields(&sf, i)
+		switch sf.Type() {
+		case flatMetricsV1.SimpleFieldTypeDeltaSum:
+			aggRow.values[i] += sf.Value()
+		case flatMetricsV1.SimpleFieldTypeMax:
+			aggRow.values[i] = math.Max(aggRow.values[i], sf.Value())
+		case flatMetricsV1.SimpleFieldTypeMin:
+			aggRow.values[i] = math.Min(aggRow.values[i], sf.Value())
+		case flatMetricsV1.SimpleFieldTypeLast:
+			aggRow.values[i] = sf.Value()
+		}
+		// first keeps the value of first row
+	}
+	aggRow.folded = true
+	return true
+}
+
+// Drain returns the folded rows in the order of first row of each key, then resets the aggregator.
+func (a *BrokerRowAggregator) Drain() *BrokerBatchRows {
+	batch := NewBrokerBatchRows()
+	for _, aggRow := range a.order {
+		_ = batch.TryAppend(func(row *BrokerRow) error {
+			if aggRow.folded {
+				row.FromBlock(a.build(&aggRow.row, aggRow.values))
+			} else {
+				row.FromBlock(aggRow.row.buffer)
+			}
+			return nil
+		})
+	}
+	a.rows = make(map[preAggregateKey]*preAggregatedRow)
+	a.order = a.order[:0]
+	return batch
+}
+
+// foldable checks if the row only has simple fields without exemplars.
+func (a *BrokerRowAggregator) foldable(row *BrokerRow) bool {
+	var cf flatMetricsV1.CompoundField
+	if row.m.SimpleFieldsLength() == 0 || row.m.CompoundField(&cf) != nil {
+		return false
+	}
+	var sf flatMetricsV1.SimpleField
+	for i := 0; i < row.m.SimpleFieldsLength(); i++ {
+		row.m.SimpleFields(&sf, i)
+		if sf.ExemplarsLength() > 0 {
+			return false
+		}
+		switch sf.Type() {
+		case flatMetricsV1.SimpleFieldTypeDeltaSum, flatMetricsV1.SimpleFieldTypeMax, flatMetricsV1.SimpleFieldTypeMin,
+			flatMetricsV1.SimpleFieldTypeLast, flatMetricsV1.SimpleFieldTypeFirst:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// keyOf returns the key of row which is (namespace, metric name, tags hash, time slot, simple fields).
+func (a *BrokerRowAggregator) keyOf(row *BrokerRow) preAggregateKey {
+	a.fields = a.fields[:0]
+	var sf flatMetricsV1.SimpleField
+	for i := 0; i < row.m.SimpleFieldsLength(); i++ {
+		row.m.SimpleFields(&sf, i)
+		a.fields = append(a.fields, sf.Name()...)
+		a.fields = append(a.fields, byte(sf.Type()))
+	}
+	timestamp := row.m.Timestamp()
+	slot := timestamp
+	if a.interval > 0 {
+		slot = timestamp - timestamp%a.interval
+	}
+	return preAggregateKey{
+		namespace: string(row.m.Namespace()),
+		name:      string(row.m.Name()),
+		hash:      row.m.Hash(),
+		slot:      slot,
+		fields:    string(a.fields),
+	}
+}
+
+// build re-builds the flat metric of row with the folded values of simple fields.
+func (a *BrokerRowAggregator) build(row *BrokerRow, values []float64) []byte {
+	builder := a.builder
+	builder.Reset()
+
+	var kv flatMetricsV1.KeyValue
+	kvs := make([]flatbuffers.UOffsetT, row.m.KeyValuesLength())
+	for i := range kvs {
+		row.m.KeyValues(&kv, i)
+		key := builder.CreateByteString(kv.Key())
+		value := builder.CreateByteString(kv.Value())
+		flatMetricsV1.KeyValueStart(builder)
+		flatMetricsV1.KeyValueAddKey(builder, key)
+		flatMetricsV1.KeyValueAddValue(builder, value)
+		kvs[i] = flatMetricsV1.KeyValueEnd(builder)
+	}
+	var sf flatMetricsV1.SimpleField
+	fields := make([]flatbuffers.UOffsetT, len(values))
+	for i := range fields {
+		row.m.SimpleFields(&sf, i)
+		name := builder.CreateByteString(sf.Name())
+		flatMetricsV1.SimpleFieldStart(builder)
+		flatMetricsV1.SimpleFieldAddName(builder, name)
+		flatMetricsV1.SimpleFieldAddType(builder, sf.Type())
+		flatMetricsV1.SimpleFieldAddValue(builder, values[i])
+		fields[i] = flatMetricsV1.SimpleFieldEnd(builder)
+	}
+	flatMetricsV1.MetricStartKeyValuesVector(builder, len(kvs))
+	for i := len(kvs) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(kvs[i])
+	}
+	kvsOffset := builder.EndVector(len(kvs))
+	flatMetricsV1.MetricStartSimpleFieldsVector(builder, len(fields))
+	for i := len(fields) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(fields[i])
+	}
+	fieldsOffset := builder.EndVector(len(fields))
+
+	name := builder.CreateByteString(row.m.Name())
+	namespace := builder.CreateByteString(row.m.Namespace())
+	flatMetricsV1.MetricStart(builder)
+	flatMetricsV1.MetricAddNamespace(builder, namespace)
+	flatMetricsV1.MetricAddName(builder, name)
+	flatMetricsV1.MetricAddTimestamp(builder, row.m.Timestamp())
+	flatMetricsV1.MetricAddKeyValues(builder, kvsOffset)
+	flatMetricsV1.MetricAddHash(builder, row.m.Hash())
+	flatMetricsV1.MetricAddSimpleFields(builder, fieldsOffset)
+	builder.FinishSizePrefixed(flatMetricsV1.MetricEnd(builder))
+	return builder.FinishedBytes()
+}
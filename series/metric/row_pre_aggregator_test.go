@@ -0,0 +1,146 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+)
+
+func newPreAggregateTestRows(t *testing.T, metrics ...*protoMetricsV1.Metric) *BrokerBatchRows {
+	converter := NewProtoConverter()
+	batch := NewBrokerBatchRows()
+	for _, m := range metrics {
+		m := m
+		assert.NoError(t, batch.TryAppend(func(row *BrokerRow) error {
+			return converter.ConvertTo(m, row)
+		}))
+	}
+	return batch
+}
+
+func newPreAggregateTestMetric(host string, timestamp int64, value float64) *protoMetricsV1.Metric {
+	return &protoMetricsV1.Metric{
+		Name:      "cpu",
+		Timestamp: timestamp,
+		Tags:      []*protoMetricsV1.KeyValue{{Key: "host", Value: host}},
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "sum", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: value},
+			{Name: "max", Type: protoMetricsV1.SimpleFieldType_Max, Value: value},
+			{Name: "min", Type: protoMetricsV1.SimpleFieldType_Min, Value: value},
+			{Name: "last", Type: protoMetricsV1.SimpleFieldType_LAST, Value: value},
+			{Name: "first", Type: protoMetricsV1.SimpleFieldType_FIRST, Value: value},
+		},
+	}
+}
+
+func simpleFieldValues(row *BrokerRow) map[string]float64 {
+	values := make(map[string]float64)
+	m := row.Metric()
+	var sf flatMetricsV1.SimpleField
+	for i := 0; i < m.SimpleFieldsLength(); i++ {
+		m.SimpleFields(&sf, i)
+		values[string(sf.Name())] = sf.Value()
+	}
+	return values
+}
+
+func TestBrokerRowAggregator_Fold(t *testing.T) {
+	aggregator := NewBrokerRowAggregator(10 * 1000)
+	batch := newPreAggregateTestRows(t,
+		// first value is zero, which is omitted by flat buffer
+		newPreAggregateTestMetric("1.1.1.1", 10*1000, 0),
+		newPreAggregateTestMetric("1.1.1.1", 11*1000, 3),
+		newPreAggregateTestMetric("1.1.1.1", 12*1000, 2),
+		// other series
+		newPreAggregateTestMetric("1.1.1.2", 12*1000, 5),
+		// next time slot
+		newPreAggregateTestMetric("1.1.1.1", 20*1000, 4),
+	)
+	defer batch.Release()
+	assert.Equal(t, 5, batch.PreAggregate(aggregator))
+	assert.Equal(t, 3, aggregator.Len())
+	for _, row := range batch.Rows() {
+		// moved rows are skipped when writing
+		assert.Zero(t, row.Size())
+	}
+
+	rows := aggregator.Drain()
+	defer rows.Release()
+	assert.Zero(t, aggregator.Len())
+	assert.Equal(t, 3, rows.Len())
+	folded := rows.Rows()
+	assert.Equal(t, map[string]float64{"sum": 5, "max": 3, "min": 0, "last": 2, "first": 0}, simpleFieldValues(&folded[0]))
+	m := folded[0].Metric()
+	assert.Equal(t, "cpu", string(m.Name()))
+	assert.Equal(t, int64(10*1000), m.Timestamp())
+	assert.Equal(t, 1, m.KeyValuesLength())
+	assert.Equal(t, map[string]float64{"sum": 5, "max": 5, "min": 5, "last": 5, "first": 5}, simpleFieldValues(&folded[1]))
+	assert.Equal(t, map[string]float64{"sum": 4, "max": 4, "min": 4, "last": 4, "first": 4}, simpleFieldValues(&folded[2]))
+	// hash of tags is kept for sharding
+	original := newPreAggregateTestRows(t, newPreAggregateTestMetric("1.1.1.1", 10*1000, 0))
+	defer original.Release()
+	assert.Equal(t, original.Rows()[0].m.Hash(), m.Hash())
+}
+
+func TestBrokerRowAggregator_NotFoldable(t *testing.T) {
+	aggregator := NewBrokerRowAggregator(10 * 1000)
+	batch := newPreAggregateTestRows(t,
+		&protoMetricsV1.Metric{
+			Name:      "histogram",
+			Timestamp: 10 * 1000,
+			CompoundField: &protoMetricsV1.CompoundField{
+				ExplicitBounds: []float64{1, 2, 3, 4, math.Inf(1)},
+				Values:         []float64{1, 2, 3, 4, 5},
+				Count:          15,
+				Sum:            15,
+				Min:            1,
+				Max:            5,
+			},
+		},
+		newPreAggregateTestMetric("1.1.1.1", 10*1000, 1),
+		// fields not match
+		&protoMetricsV1.Metric{
+			Name:      "cpu",
+			Timestamp: 10 * 1000,
+			Tags:      []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "sum", Type: protoMetricsV1.SimpleFieldType_DELTA_SUM, Value: 1},
+			},
+		},
+	)
+	defer batch.Release()
+	batch.rows[1].IsOutOfTimeRange = true
+
+	assert.Equal(t, 1, batch.PreAggregate(aggregator))
+	assert.Equal(t, 1, aggregator.Len())
+	assert.NotZero(t, batch.rows[0].Size())
+	assert.Zero(t, batch.rows[2].Size())
+
+	// rows reused from pool are not marked as moved
+	batch.reset()
+	_ = batch.TryAppend(func(row *BrokerRow) error {
+		return NewProtoConverter().ConvertTo(newPreAggregateTestMetric("1.1.1.1", 10*1000, 1), row)
+	})
+	assert.NotZero(t, batch.rows[0].Size())
+}
@@ -213,3 +213,17 @@ func UpperBound(bucketName string) (float64, error) {
 	raw := bucketName[len("__bucket_"):]
 	return strconv.ParseFloat(raw, 64)
 }
+
+// HistogramFamilyBucket returns the family and upper bound of histogram bucket field,
+// bucket field of histogram family is named with format like ${family}__bucket_${boundary}.
+func HistogramFamilyBucket(bucketName string) (family string, upperBound float64, err error) {
+	idx := strings.LastIndex(bucketName, "__bucket_")
+	if idx < 0 {
+		return "", 0, fmt.Errorf("bucketName:%s not contains '__bucket_'", bucketName)
+	}
+	upperBound, err = UpperBound(bucketName[idx:])
+	if err != nil {
+		return "", 0, err
+	}
+	return bucketName[:idx], upperBound, nil
+}
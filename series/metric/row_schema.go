@@ -0,0 +1,154 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"fmt"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+)
+
+// SchemaViolation represents the type of violation against the registered metric schema.
+type SchemaViolation int
+
+const (
+	// UnknownTagKey represents the tag key is not allowed by schema.
+	UnknownTagKey SchemaViolation = iota + 1
+	// UnknownField represents the field is not declared by schema.
+	UnknownField
+	// FieldTypeMismatch represents the type of field is different from the pinned type.
+	FieldTypeMismatch
+	// NoFieldLeft represents all fields of row are stripped, the row is dropped.
+	NoFieldLeft
+)
+
+// String returns the string value of SchemaViolation.
+func (v SchemaViolation) String() string {
+	switch v {
+	case UnknownTagKey:
+		return "unknown_tag_key"
+	case UnknownField:
+		return "unknown_field"
+	case FieldTypeMismatch:
+		return "field_type_mismatch"
+	case NoFieldLeft:
+		return "no_field_left"
+	default:
+		return "unknown"
+	}
+}
+
+// RowSchema represents the pinned simple fields/allowed tag keys of metric,
+// nil fields/tag keys means not checked, compound field(histogram) is not checked.
+type RowSchema struct {
+	Fields  map[string]flatMetricsV1.SimpleFieldType
+	TagKeys map[string]struct{}
+}
+
+// RowSchemaLookup returns the schema of metric, returns nil if metric is not registered.
+type RowSchemaLookup func(namespace, metricName []byte) *RowSchema
+
+// EnforceSchema checks tag keys/simple fields of rows against the registered metric schema,
+// in strict mode returns error on the first violated row, else strips the unknown tags/fields,
+// coerces the field type to the pinned type and drops the row without any field left.
+// onViolation is invoked for each violation.
+func (br *BrokerBatchRows) EnforceSchema(lookup RowSchemaLookup, strict bool, onViolation func(v SchemaViolation)) error {
+	var (
+		rebuilder  rowRebuilder
+		tagKeys    [][]byte // kept tag keys of current row, nil means tag stripped
+		fieldTypes []flatMetricsV1.SimpleFieldType
+	)
+	kept := 0
+	for idx := 0; idx < br.rowCount; idx++ {
+		row := &br.rows[idx]
+		origin := readOnlyRow{m: row.m}
+		schema := lookup(origin.NameSpace(), origin.Name())
+		if schema == nil {
+			br.keepRow(idx, &kept)
+			continue
+		}
+		changed := false
+		tagKeys = tagKeys[:0]
+		kvItr := origin.NewKeyValueIterator()
+		for kvItr.HasNext() {
+			tagKey := kvItr.NextKey()
+			if schema.TagKeys != nil {
+				if _, ok := schema.TagKeys[string(tagKey)]; !ok {
+					onViolation(UnknownTagKey)
+					if strict {
+						return fmt.Errorf("%w, tag key[%s] of metric[%s] is not allowed", ErrSchemaViolation, tagKey, origin.Name())
+					}
+					tagKey = nil
+					changed = true
+				}
+			}
+			tagKeys = append(tagKeys, tagKey)
+		}
+		fieldTypes = fieldTypes[:0]
+		fields := 0
+		simpleFieldItr := origin.NewSimpleFieldIterator()
+		for simpleFieldItr.HasNext() {
+			fieldType := simpleFieldItr.NextRawType()
+			if schema.Fields != nil {
+				pinnedType, ok := schema.Fields[string(simpleFieldItr.NextRawName())]
+				switch {
+				case !ok:
+					onViolation(UnknownField)
+					if strict {
+						return fmt.Errorf("%w, field[%s] of metric[%s] is not declared",
+							ErrSchemaViolation, simpleFieldItr.NextRawName(), origin.Name())
+					}
+					fieldType = droppedFieldType
+					changed = true
+				case pinnedType != fieldType:
+					onViolation(FieldTypeMismatch)
+					if strict {
+						return fmt.Errorf("%w, type of field[%s] of metric[%s] is %s, expected %s",
+							ErrSchemaViolation, simpleFieldItr.NextRawName(), origin.Name(), fieldType, pinnedType)
+					}
+					fieldType = pinnedType
+					changed = true
+				}
+			}
+			if fieldType != droppedFieldType {
+				fields++
+			}
+			fieldTypes = append(fieldTypes, fieldType)
+		}
+		if _, hasCompoundField := origin.NewCompoundFieldIterator(); fields == 0 && !hasCompoundField {
+			onViolation(NoFieldLeft)
+			continue
+		}
+		if changed {
+			if err := rebuilder.rebuild(row, origin.Name(), tagKeys, nil, fieldTypes); err != nil {
+				return err
+			}
+		}
+		br.keepRow(idx, &kept)
+	}
+	br.rowCount = kept
+	return nil
+}
+
+// keepRow moves the kept row to the front of rows, dropped rows are moved to the tail for reusing.
+func (br *BrokerBatchRows) keepRow(idx int, kept *int) {
+	if idx != *kept {
+		br.rows[idx], br.rows[*kept] = br.rows[*kept], br.rows[idx]
+	}
+	*kept++
+}
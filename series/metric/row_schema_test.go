@@ -0,0 +1,180 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/common/proto/gen/v1/flatMetricsV1"
+	commonseries "github.com/lindb/common/series"
+)
+
+type schemaTestRow struct {
+	metricName string
+	tags       []string
+	fields     map[string]flatMetricsV1.SimpleFieldType
+	compound   bool
+}
+
+func newSchemaTestBatch(t *testing.T, testRows ...schemaTestRow) *BrokerBatchRows {
+	batch := NewBrokerBatchRows()
+	for _, testRow := range testRows {
+		testRow := testRow
+		assert.NoError(t, batch.TryAppend(func(row *BrokerRow) error {
+			builder, releaseFunc := commonseries.NewRowBuilder()
+			defer releaseFunc(builder)
+
+			builder.AddMetricName([]byte(testRow.metricName))
+			builder.AddNameSpace([]byte("ns"))
+			for i := 0; i < len(testRow.tags); i += 2 {
+				_ = builder.AddTag([]byte(testRow.tags[i]), []byte(testRow.tags[i+1]))
+			}
+			for name, fieldType := range testRow.fields {
+				_ = builder.AddSimpleField([]byte(name), fieldType, 100)
+			}
+			if testRow.compound {
+				assert.NoError(t, builder.AddCompoundFieldData([]float64{1, 2}, []float64{10, math.Inf(1)}))
+				assert.NoError(t, builder.AddCompoundFieldMMSC(1, 2, 3, 3))
+			}
+			builder.AddTimestamp(1000)
+			data, err := builder.Build()
+			if err != nil {
+				return err
+			}
+			row.FromBlock(data)
+			return nil
+		}))
+	}
+	return batch
+}
+
+func TestBrokerBatchRows_EnforceSchema(t *testing.T) {
+	schema := &RowSchema{
+		Fields:  map[string]flatMetricsV1.SimpleFieldType{"f1": flatMetricsV1.SimpleFieldTypeDeltaSum},
+		TagKeys: map[string]struct{}{"host": {}},
+	}
+	lookup := func(namespace, metricName []byte) *RowSchema {
+		if string(namespace) == "ns" && string(metricName) == "cpu" {
+			return schema
+		}
+		return nil
+	}
+	sum := flatMetricsV1.SimpleFieldTypeDeltaSum
+
+	cases := []struct {
+		name       string
+		rows       []schemaTestRow
+		strict     bool
+		wantErr    bool
+		violations map[SchemaViolation]int
+		check      func(rows []BrokerRow)
+	}{
+		{
+			name: "unregistered metric",
+			rows: []schemaTestRow{{metricName: "mem", tags: []string{"ip", "1"},
+				fields: map[string]flatMetricsV1.SimpleFieldType{"f2": flatMetricsV1.SimpleFieldTypeLast}}},
+			strict:     true,
+			violations: map[SchemaViolation]int{},
+			check: func(rows []BrokerRow) {
+				assert.Len(t, rows, 1)
+			},
+		},
+		{
+			name:       "strict, unknown tag key",
+			rows:       []schemaTestRow{{metricName: "cpu", tags: []string{"ip", "1"}, fields: map[string]flatMetricsV1.SimpleFieldType{"f1": sum}}},
+			strict:     true,
+			wantErr:    true,
+			violations: map[SchemaViolation]int{UnknownTagKey: 1},
+		},
+		{
+			name:       "strict, unknown field",
+			rows:       []schemaTestRow{{metricName: "cpu", fields: map[string]flatMetricsV1.SimpleFieldType{"f2": sum}}},
+			strict:     true,
+			wantErr:    true,
+			violations: map[SchemaViolation]int{UnknownField: 1},
+		},
+		{
+			name: "strict, field type mismatch",
+			rows: []schemaTestRow{{metricName: "cpu",
+				fields: map[string]flatMetricsV1.SimpleFieldType{"f1": flatMetricsV1.SimpleFieldTypeLast}}},
+			strict:     true,
+			wantErr:    true,
+			violations: map[SchemaViolation]int{FieldTypeMismatch: 1},
+		},
+		{
+			name: "lenient, strip and coerce",
+			rows: []schemaTestRow{{metricName: "cpu", tags: []string{"host", "a", "ip", "1"},
+				fields: map[string]flatMetricsV1.SimpleFieldType{"f1": flatMetricsV1.SimpleFieldTypeLast, "f2": sum}}},
+			violations: map[SchemaViolation]int{UnknownTagKey: 1, UnknownField: 1, FieldTypeMismatch: 1},
+			check: func(rows []BrokerRow) {
+				assert.Len(t, rows, 1)
+				m := rows[0].Metric()
+				assert.Equal(t, 1, m.KeyValuesLength())
+				assert.Equal(t, 1, m.SimpleFieldsLength())
+				var f flatMetricsV1.SimpleField
+				m.SimpleFields(&f, 0)
+				assert.Equal(t, "f1", string(f.Name()))
+				assert.Equal(t, sum, f.Type())
+			},
+		},
+		{
+			name: "lenient, drop row without field",
+			rows: []schemaTestRow{
+				{metricName: "cpu", fields: map[string]flatMetricsV1.SimpleFieldType{"f2": sum}},
+				{metricName: "cpu", fields: map[string]flatMetricsV1.SimpleFieldType{"f3": sum}, compound: true},
+				{metricName: "cpu", tags: []string{"host", "a"}, fields: map[string]flatMetricsV1.SimpleFieldType{"f1": sum}},
+			},
+			violations: map[SchemaViolation]int{UnknownField: 2, NoFieldLeft: 1},
+			check: func(rows []BrokerRow) {
+				assert.Len(t, rows, 2)
+				for _, row := range rows {
+					m := row.Metric()
+					assert.Equal(t, "cpu", string(m.Name()))
+				}
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			batch := newSchemaTestBatch(t, tt.rows...)
+			defer batch.Release()
+
+			violations := make(map[SchemaViolation]int)
+			err := batch.EnforceSchema(lookup, tt.strict, func(v SchemaViolation) { violations[v]++ })
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("EnforceSchema() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.violations, violations)
+			if tt.check != nil {
+				tt.check(batch.Rows())
+			}
+		})
+	}
+}
+
+func TestSchemaViolation_String(t *testing.T) {
+	assert.Equal(t, "unknown_tag_key", UnknownTagKey.String())
+	assert.Equal(t, "unknown_field", UnknownField.String())
+	assert.Equal(t, "field_type_mismatch", FieldTypeMismatch.String())
+	assert.Equal(t, "no_field_left", NoFieldLeft.String())
+	assert.Equal(t, "unknown", SchemaViolation(0).String())
+}
@@ -30,7 +30,8 @@ type StorageRow struct {
 	SlotIndex uint16
 	FieldIDs  []field.ID
 
-	Writable bool // Writable symbols if all meta information is set
+	Writable  bool  // Writable symbols if all meta information is set
+	LookupErr error // LookupErr represents the failure reason of looking up meta information
 	readOnlyRow
 }
 
@@ -42,6 +43,12 @@ func (mr *StorageRow) Unmarshal(data []byte) {
 	mr.SlotIndex = 0
 	mr.FieldIDs = mr.FieldIDs[:0]
 	mr.Writable = false
+	mr.LookupErr = nil
+}
+
+// Bytes returns the raw flat data of row.
+func (mr *StorageRow) Bytes() []byte {
+	return mr.m.Table().Bytes
 }
 
 // StorageBatchRows holds multi rows for inserting into memdb
@@ -58,14 +65,35 @@ func NewStorageBatchRows() (ctx *StorageBatchRows) {
 
 func (br *StorageBatchRows) reset() { br.appendIndex = 0 }
 
+// UnmarshalRows unmarshal all rows from rows block, idempotency token records are ignored.
 func (br *StorageBatchRows) UnmarshalRows(rowsBlock []byte) {
+	br.UnmarshalRowsWithDedup(rowsBlock, nil)
+}
+
+// UnmarshalRowsWithDedup unmarshal rows from rows block, skips the rows marked by the idempotency token
+// which isDuplicate returns true, returns the number of skipped rows.
+func (br *StorageBatchRows) UnmarshalRowsWithDedup(rowsBlock []byte, isDuplicate func(token string) bool) (skipped int) {
 	br.reset()
+	skip := 0
 	// uint32 length + block encoding
 	for len(rowsBlock) > 0 {
 		size := flatbuffers.GetSizePrefix(rowsBlock, 0)
-		br.append(rowsBlock[flatbuffers.SizeUOffsetT : flatbuffers.SizeUOffsetT+size])
+		if size&idempotencyTokenFlag != 0 {
+			size &^= idempotencyTokenFlag
+			token, rows := readIdempotencyToken(rowsBlock[flatbuffers.SizeUOffsetT : flatbuffers.SizeUOffsetT+size])
+			skip = 0
+			if isDuplicate != nil && isDuplicate(token) {
+				skip = rows
+			}
+		} else if skip > 0 {
+			skip--
+			skipped++
+		} else {
+			br.append(rowsBlock[flatbuffers.SizeUOffsetT : flatbuffers.SizeUOffsetT+size])
+		}
 		rowsBlock = rowsBlock[flatbuffers.SizeUOffsetT+size:]
 	}
+	return skipped
 }
 
 func (br *StorageBatchRows) append(data []byte) {
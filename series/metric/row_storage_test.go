@@ -134,6 +134,7 @@ func Test_MetricRow_WithSimpleFields(t *testing.T) {
 	mr.Unmarshal(builder.FinishedBytes())
 
 	assert.Equal(t, "hello", string(mr.Name()))
+	assert.Equal(t, builder.FinishedBytes(), mr.Bytes())
 
 	assert.Equal(t, "default-ns", string(mr.NameSpace()))
 	assert.NotZero(t, mr.TagsHash())
@@ -245,6 +246,19 @@ func Test_HistogramConverter(t *testing.T) {
 
 	_, err = UpperBound("__bucket_x")
 	assert.NotNil(t, err)
+
+	family, f, err := HistogramFamilyBucket("latency__bucket_0.5")
+	assert.NoError(t, err)
+	assert.Equal(t, "latency", family)
+	assert.Equal(t, 0.5, f)
+	family, f, err = HistogramFamilyBucket("__bucket_+Inf")
+	assert.NoError(t, err)
+	assert.Empty(t, family)
+	assert.True(t, math.IsInf(f, 1))
+	_, _, err = HistogramFamilyBucket("latency_0.5")
+	assert.Error(t, err)
+	_, _, err = HistogramFamilyBucket("latency__bucket_x")
+	assert.Error(t, err)
 }
 
 func TestStorageBatchRows_Sorts(t *testing.T) {
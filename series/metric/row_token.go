@@ -0,0 +1,46 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"encoding/binary"
+	"io"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+)
+
+// idempotencyTokenFlag marks the size prefix of a record in rows block as idempotency token record,
+// size of flat metric row never reaches it.
+const idempotencyTokenFlag = uint32(1) << 31
+
+// WriteIdempotencyToken writes the idempotency token record of the following rows into writer,
+// format: [uint32 flag|payload size][uint32 rows][token].
+func WriteIdempotencyToken(writer io.Writer, token string, rows int) (int, error) {
+	buf := make([]byte, 2*flatbuffers.SizeUOffsetT+len(token))
+	binary.LittleEndian.PutUint32(buf, idempotencyTokenFlag|uint32(flatbuffers.SizeUOffsetT+len(token)))
+	binary.LittleEndian.PutUint32(buf[flatbuffers.SizeUOffsetT:], uint32(rows))
+	copy(buf[2*flatbuffers.SizeUOffsetT:], token)
+	return writer.Write(buf)
+}
+
+// readIdempotencyToken reads the token and the number of rows marked by it from payload of token record.
+func readIdempotencyToken(payload []byte) (token string, rows int) {
+	rows = int(binary.LittleEndian.Uint32(payload))
+	token = string(payload[flatbuffers.SizeUOffsetT:])
+	return token, rows
+}
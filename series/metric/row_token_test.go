@@ -0,0 +1,88 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metric
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageBatchRows_UnmarshalRowsWithDedup(t *testing.T) {
+	builder := flatbuffers.NewBuilder(1024)
+	buildFlatMetric(builder)
+	data := builder.FinishedBytes()
+	row := make([]byte, flatbuffers.SizeUOffsetT+len(data))
+	binary.LittleEndian.PutUint32(row, uint32(len(data)))
+	copy(row[flatbuffers.SizeUOffsetT:], data)
+
+	var block bytes.Buffer
+	block.Write(row)
+	_, err := WriteIdempotencyToken(&block, "token-1", 2)
+	assert.NoError(t, err)
+	block.Write(row)
+	block.Write(row)
+	_, err = WriteIdempotencyToken(&block, "token-2", 1)
+	assert.NoError(t, err)
+	block.Write(row)
+	block.Write(row)
+
+	cases := []struct {
+		name        string
+		isDuplicate func(token string) bool
+		rows        int
+		skipped     int
+	}{
+		{
+			name: "without dedup",
+			rows: 5,
+		},
+		{
+			name:        "no duplicate token",
+			isDuplicate: func(token string) bool { return false },
+			rows:        5,
+		},
+		{
+			name:        "duplicate token",
+			isDuplicate: func(token string) bool { return token == "token-1" },
+			rows:        3,
+			skipped:     2,
+		},
+		{
+			name:        "all tokens duplicated",
+			isDuplicate: func(token string) bool { return true },
+			rows:        2,
+			skipped:     3,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rows := NewStorageBatchRows()
+			skipped := rows.UnmarshalRowsWithDedup(block.Bytes(), tt.isDuplicate)
+			assert.Equal(t, tt.skipped, skipped)
+			assert.Equal(t, tt.rows, rows.Len())
+			for _, r := range rows.Rows() {
+				assert.Equal(t, "hello", string(r.Name()))
+			}
+		})
+	}
+}
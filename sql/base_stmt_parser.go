@@ -34,22 +34,32 @@ type baseStmtParser struct {
 	exprStack *collections.Stack
 	condition stmt.Expr
 
-	limit int
+	limit  int
+	offset int
 
 	err error
 }
 
 // visitLimit visits when production limit expression is entered
 func (b *baseStmtParser) visitLimit(ctx *grammar.LimitClauseContext) {
-	if ctx.L_INT() == nil {
+	if ctx.L_INT(0) == nil {
 		return
 	}
-	limit, err := strconv.ParseInt(ctx.L_INT().GetText(), 10, 32)
+	limit, err := strconv.ParseInt(ctx.L_INT(0).GetText(), 10, 32)
 	if err != nil {
 		b.err = err
 		return
 	}
 	b.limit = int(limit)
+	if ctx.T_OFFSET() == nil || ctx.L_INT(1) == nil {
+		return
+	}
+	offset, err := strconv.ParseInt(ctx.L_INT(1).GetText(), 10, 32)
+	if err != nil {
+		b.err = err
+		return
+	}
+	b.offset = int(offset)
 }
 
 // visitMetricName visits when production metricName expression is entered
@@ -2,7 +2,8 @@
 // antlr4 SQL.g4 -Dlanguage=Go -package grammar
 grammar SQL;
 
-statement               : showStmt
+statement               : (
+                          showStmt
                         | createStorageStmt
                         | createBrokerStmt
                         | recoverStorageStmt
@@ -11,7 +12,7 @@ statement               : showStmt
                         | createDatabaseStmt
                         | dropDatabaseStmt
                         | ident // just for suggest filtering.
-                        EOF ;
+                        ) EOF ;
 
 useStmt                 : T_USE ident ;
 
@@ -77,7 +78,7 @@ requestID            : ident ;
 source               : (T_STATE_MACHINE|T_STATE_REPO) ;
 
 //data query plan
-queryStmt               : T_EXPLAIN? sourceAndSelect whereClause? groupByClause? orderByClause? limitClause? T_WITH_VALUE?;
+queryStmt               : T_EXPLAIN? sourceAndSelect whereClause? groupByClause? usingClause? orderByClause? limitClause? T_WITH_VALUE?;
 sourceAndSelect         : selectExpr fromClause | fromClause selectExpr ;
 selectExpr              : T_SELECT fields;
 //select fields
@@ -90,7 +91,7 @@ databaseFilter          : T_DATASBAE T_EQUAL ident  ;
 typeFilter              : T_TYPE T_EQUAL ident  ;
 
 //from clause
-fromClause              : T_FROM metricName (T_ON namespace)? ;
+fromClause              : T_FROM ( metricName (T_ON namespace)? | T_OPEN_P queryStmt T_CLOSE_P ) ;
 
 //where clause
 whereClause             : T_WHERE conditionExpr;
@@ -115,10 +116,15 @@ nowExpr                 : nowFunc  durationLit? ;
 nowFunc                 : T_NOW T_OPEN_P exprFuncParams? T_CLOSE_P ;
 
 //group by
-groupByClause          : T_GROUP T_BY groupByKeys (T_FILL T_OPEN_P fillOption T_CLOSE_P)? havingClause? ;
+groupByClause          : T_GROUP T_BY groupByKeys (T_FILL T_OPEN_P fillOption T_CLOSE_P)? timeZoneClause? alignClause? havingClause? ;
 groupByKeys            : groupByKey (T_COMMA groupByKey)* ;
 groupByKey             : ident | T_TIME T_OPEN_P durationLit T_CLOSE_P ;
-fillOption             : T_NULL | T_PREVIOUS | L_INT | L_DEC ;
+alignClause            : T_ALIGN T_OPEN_P ident T_CLOSE_P ;
+fillOption             : T_NULL | T_PREVIOUS | T_LINEAR | L_INT | L_DEC ;
+timeZoneClause         : T_TZ STRING ;
+
+//storage interval hint
+usingClause            : T_USING (T_RAW | T_ROLLUP | durationLit) ;
 
 orderByClause          : T_ORDER T_BY sortFields ;
 sortField               : fieldExpr ( T_ASC | T_DESC )* ;
@@ -166,7 +172,7 @@ intervalItem            :
                          | T_YEAR
                          ;
 exprFunc                : funcName T_OPEN_P exprFuncParams? T_CLOSE_P ;
-funcName                : T_SUM | T_MIN | T_MAX | T_AVG | T_COUNT | T_LAST | T_FIRST | T_STDDEV | T_QUANTILE | T_RATE;
+funcName                : T_SUM | T_MIN | T_MAX | T_AVG | T_COUNT | T_LAST | T_FIRST | T_STDDEV | T_QUANTILE | T_RATE | T_INCREASE;
 exprFuncParams          : funcParam (T_COMMA funcParam)* ;
 funcParam               :
                            fieldExpr
@@ -211,7 +217,7 @@ value
 intNumber               : ('-' | '+')? L_INT ;
 // Decimal number (positive or negative)
 decNumber               : ('-' | '+')? L_DEC ;
-limitClause             : T_LIMIT L_INT ;
+limitClause             : T_LIMIT L_INT (T_OFFSET L_INT)? ;
 metricName              : ident ;
 tagKey                  : ident ;
 tagValue                : ident ;
@@ -315,6 +321,14 @@ nonReservedWords      :
                         | T_REQUESTS
                         | T_REQUEST
                         | T_ID
+                        | T_OFFSET
+                        | T_USING
+                        | T_RAW
+                        | T_ROLLUP
+                        | T_TZ
+                        | T_ALIGN
+                        | T_LINEAR
+                        | T_INCREASE
                         ;
 
 STRING
@@ -396,6 +410,12 @@ T_VALUE              : V A L U E                        ;
 T_FROM               : F R O M                          ;
 T_WHERE              : W H E R E                        ;
 T_LIMIT              : L I M I T                        ;
+T_OFFSET             : O F F S E T                      ;
+T_USING              : U S I N G                        ;
+T_RAW                : R A W                            ;
+T_ROLLUP             : R O L L U P                      ;
+T_TZ                 : T Z                              ;
+T_ALIGN              : A L I G N                        ;
 T_QUERIES            : Q U E R I E S                    ;
 T_QUERY              : Q U E R Y                        ;
 T_EXPLAIN            : E X P L A I N                    ;
@@ -407,6 +427,7 @@ T_OR                 : O R                              ;
 T_FILL               : F I L L                          ;
 T_NULL               : N U L L                          ;
 T_PREVIOUS           : P R E V I O U S                  ;
+T_LINEAR             : L I N E A R                      ;
 T_ORDER              : O R D E R                        ;
 T_ASC                : A S C                            ;
 T_DESC               : D E S C                          ;
@@ -439,6 +460,7 @@ T_AVG                : A V G                            ;
 T_STDDEV             : S T D D E V                      ;
 T_QUANTILE           : Q U A N T I L E                  ;
 T_RATE               : R A T E                          ;
+T_INCREASE           : I N C R E A S E                  ;
 
 //time unit
 T_SECOND             : S                                ;
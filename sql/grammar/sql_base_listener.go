@@ -600,3 +600,21 @@ func (s *BaseSQLListener) EnterNonReservedWords(ctx *NonReservedWordsContext) {}
 
 // ExitNonReservedWords is called when production nonReservedWords is exited.
 func (s *BaseSQLListener) ExitNonReservedWords(ctx *NonReservedWordsContext) {}
+
+// EnterUsingClause is called when production usingClause is entered.
+func (s *BaseSQLListener) EnterUsingClause(ctx *UsingClauseContext) {}
+
+// ExitUsingClause is called when production usingClause is exited.
+func (s *BaseSQLListener) ExitUsingClause(ctx *UsingClauseContext) {}
+
+// EnterTimeZoneClause is called when production timeZoneClause is entered.
+func (s *BaseSQLListener) EnterTimeZoneClause(ctx *TimeZoneClauseContext) {}
+
+// ExitTimeZoneClause is called when production timeZoneClause is exited.
+func (s *BaseSQLListener) ExitTimeZoneClause(ctx *TimeZoneClauseContext) {}
+
+// EnterAlignClause is called when production alignClause is entered.
+func (s *BaseSQLListener) EnterAlignClause(ctx *AlignClauseContext) {}
+
+// ExitAlignClause is called when production alignClause is exited.
+func (s *BaseSQLListener) ExitAlignClause(ctx *AlignClauseContext) {}
@@ -399,3 +399,15 @@ func (v *BaseSQLVisitor) VisitIdent(ctx *IdentContext) interface{} {
 func (v *BaseSQLVisitor) VisitNonReservedWords(ctx *NonReservedWordsContext) interface{} {
 	return v.VisitChildren(ctx)
 }
+
+func (v *BaseSQLVisitor) VisitUsingClause(ctx *UsingClauseContext) interface{} {
+	return v.VisitChildren(ctx)
+}
+
+func (v *BaseSQLVisitor) VisitTimeZoneClause(ctx *TimeZoneClauseContext) interface{} {
+	return v.VisitChildren(ctx)
+}
+
+func (v *BaseSQLVisitor) VisitAlignClause(ctx *AlignClauseContext) interface{} {
+	return v.VisitChildren(ctx)
+}
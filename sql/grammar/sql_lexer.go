@@ -92,6 +92,8 @@ func sqllexerLexerInit() {
 		"T_LESSEQUAL", "T_REGEXP", "T_NEQREGEXP", "T_COMMA", "T_OPEN_B", "T_CLOSE_B",
 		"T_OPEN_SB", "T_CLOSE_SB", "T_OPEN_P", "T_CLOSE_P", "T_ADD", "T_SUB",
 		"T_DIV", "T_MUL", "T_MOD", "T_UNDERLINE", "L_ID", "L_INT", "L_DEC",
+		"T_OFFSET", "T_USING", "T_RAW", "T_ROLLUP", "T_TZ", "T_ALIGN", "T_LINEAR",
+		"T_INCREASE",
 	}
 	staticData.ruleNames = []string{
 		"T__0", "T__1", "T__2", "STRING", "ESC", "UNICODE", "HEX", "SAFECODEPOINT",
@@ -117,539 +119,567 @@ func sqllexerLexerInit() {
 		"T_DIV", "T_MUL", "T_MOD", "T_UNDERLINE", "L_ID", "L_INT", "L_DEC",
 		"BLANK", "L_DIGIT", "L_ID_PART", "A", "B", "C", "D", "E", "F", "G",
 		"H", "I", "J", "K", "L", "M", "N", "O", "P", "Q", "R", "S", "T", "U",
-		"V", "W", "X", "Y", "Z",
+		"V", "W", "X", "Y", "Z", "T_OFFSET", "T_USING", "T_RAW", "T_ROLLUP", "T_TZ",
+		"T_ALIGN", "T_LINEAR", "T_INCREASE",
 	}
 	staticData.predictionContextCache = antlr.NewPredictionContextCache()
 	staticData.serializedATN = []int32{
-		4, 0, 130, 1160, 6, -1, 2, 0, 7, 0, 2, 1, 7, 1, 2, 2, 7, 2, 2, 3, 7, 3,
-		2, 4, 7, 4, 2, 5, 7, 5, 2, 6, 7, 6, 2, 7, 7, 7, 2, 8, 7, 8, 2, 9, 7, 9,
-		2, 10, 7, 10, 2, 11, 7, 11, 2, 12, 7, 12, 2, 13, 7, 13, 2, 14, 7, 14, 2,
-		15, 7, 15, 2, 16, 7, 16, 2, 17, 7, 17, 2, 18, 7, 18, 2, 19, 7, 19, 2, 20,
-		7, 20, 2, 21, 7, 21, 2, 22, 7, 22, 2, 23, 7, 23, 2, 24, 7, 24, 2, 25, 7,
-		25, 2, 26, 7, 26, 2, 27, 7, 27, 2, 28, 7, 28, 2, 29, 7, 29, 2, 30, 7, 30,
-		2, 31, 7, 31, 2, 32, 7, 32, 2, 33, 7, 33, 2, 34, 7, 34, 2, 35, 7, 35, 2,
-		36, 7, 36, 2, 37, 7, 37, 2, 38, 7, 38, 2, 39, 7, 39, 2, 40, 7, 40, 2, 41,
-		7, 41, 2, 42, 7, 42, 2, 43, 7, 43, 2, 44, 7, 44, 2, 45, 7, 45, 2, 46, 7,
-		46, 2, 47, 7, 47, 2, 48, 7, 48, 2, 49, 7, 49, 2, 50, 7, 50, 2, 51, 7, 51,
-		2, 52, 7, 52, 2, 53, 7, 53, 2, 54, 7, 54, 2, 55, 7, 55, 2, 56, 7, 56, 2,
-		57, 7, 57, 2, 58, 7, 58, 2, 59, 7, 59, 2, 60, 7, 60, 2, 61, 7, 61, 2, 62,
-		7, 62, 2, 63, 7, 63, 2, 64, 7, 64, 2, 65, 7, 65, 2, 66, 7, 66, 2, 67, 7,
-		67, 2, 68, 7, 68, 2, 69, 7, 69, 2, 70, 7, 70, 2, 71, 7, 71, 2, 72, 7, 72,
-		2, 73, 7, 73, 2, 74, 7, 74, 2, 75, 7, 75, 2, 76, 7, 76, 2, 77, 7, 77, 2,
-		78, 7, 78, 2, 79, 7, 79, 2, 80, 7, 80, 2, 81, 7, 81, 2, 82, 7, 82, 2, 83,
-		7, 83, 2, 84, 7, 84, 2, 85, 7, 85, 2, 86, 7, 86, 2, 87, 7, 87, 2, 88, 7,
-		88, 2, 89, 7, 89, 2, 90, 7, 90, 2, 91, 7, 91, 2, 92, 7, 92, 2, 93, 7, 93,
-		2, 94, 7, 94, 2, 95, 7, 95, 2, 96, 7, 96, 2, 97, 7, 97, 2, 98, 7, 98, 2,
-		99, 7, 99, 2, 100, 7, 100, 2, 101, 7, 101, 2, 102, 7, 102, 2, 103, 7, 103,
-		2, 104, 7, 104, 2, 105, 7, 105, 2, 106, 7, 106, 2, 107, 7, 107, 2, 108,
-		7, 108, 2, 109, 7, 109, 2, 110, 7, 110, 2, 111, 7, 111, 2, 112, 7, 112,
-		2, 113, 7, 113, 2, 114, 7, 114, 2, 115, 7, 115, 2, 116, 7, 116, 2, 117,
-		7, 117, 2, 118, 7, 118, 2, 119, 7, 119, 2, 120, 7, 120, 2, 121, 7, 121,
-		2, 122, 7, 122, 2, 123, 7, 123, 2, 124, 7, 124, 2, 125, 7, 125, 2, 126,
-		7, 126, 2, 127, 7, 127, 2, 128, 7, 128, 2, 129, 7, 129, 2, 130, 7, 130,
-		2, 131, 7, 131, 2, 132, 7, 132, 2, 133, 7, 133, 2, 134, 7, 134, 2, 135,
-		7, 135, 2, 136, 7, 136, 2, 137, 7, 137, 2, 138, 7, 138, 2, 139, 7, 139,
-		2, 140, 7, 140, 2, 141, 7, 141, 2, 142, 7, 142, 2, 143, 7, 143, 2, 144,
-		7, 144, 2, 145, 7, 145, 2, 146, 7, 146, 2, 147, 7, 147, 2, 148, 7, 148,
-		2, 149, 7, 149, 2, 150, 7, 150, 2, 151, 7, 151, 2, 152, 7, 152, 2, 153,
-		7, 153, 2, 154, 7, 154, 2, 155, 7, 155, 2, 156, 7, 156, 2, 157, 7, 157,
-		2, 158, 7, 158, 2, 159, 7, 159, 2, 160, 7, 160, 2, 161, 7, 161, 2, 162,
-		7, 162, 2, 163, 7, 163, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 1, 1, 1, 1, 1,
-		1, 1, 1, 1, 1, 1, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 3, 1, 3, 1, 3, 5, 3,
-		349, 8, 3, 10, 3, 12, 3, 352, 9, 3, 1, 3, 1, 3, 1, 4, 1, 4, 1, 4, 3, 4,
-		359, 8, 4, 1, 5, 1, 5, 1, 5, 1, 5, 1, 5, 1, 5, 1, 6, 1, 6, 1, 7, 1, 7,
-		1, 8, 1, 8, 3, 8, 373, 8, 8, 1, 8, 1, 8, 1, 9, 4, 9, 378, 8, 9, 11, 9,
-		12, 9, 379, 1, 9, 1, 9, 1, 10, 1, 10, 1, 10, 1, 10, 1, 10, 1, 10, 1, 10,
-		1, 11, 1, 11, 1, 11, 1, 11, 1, 11, 1, 11, 1, 11, 1, 12, 1, 12, 1, 12, 1,
-		12, 1, 13, 1, 13, 1, 13, 1, 13, 1, 13, 1, 14, 1, 14, 1, 14, 1, 14, 1, 14,
-		1, 14, 1, 14, 1, 14, 1, 14, 1, 15, 1, 15, 1, 15, 1, 15, 1, 15, 1, 16, 1,
-		16, 1, 16, 1, 16, 1, 16, 1, 16, 1, 17, 1, 17, 1, 17, 1, 17, 1, 17, 1, 17,
-		1, 17, 1, 17, 1, 17, 1, 17, 1, 17, 1, 17, 1, 18, 1, 18, 1, 18, 1, 18, 1,
-		18, 1, 18, 1, 18, 1, 19, 1, 19, 1, 19, 1, 19, 1, 20, 1, 20, 1, 20, 1, 20,
-		1, 20, 1, 20, 1, 20, 1, 20, 1, 21, 1, 21, 1, 21, 1, 21, 1, 21, 1, 21, 1,
-		21, 1, 21, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22,
-		1, 22, 1, 23, 1, 23, 1, 23, 1, 23, 1, 23, 1, 24, 1, 24, 1, 24, 1, 25, 1,
-		25, 1, 25, 1, 25, 1, 25, 1, 26, 1, 26, 1, 26, 1, 26, 1, 26, 1, 26, 1, 26,
-		1, 26, 1, 27, 1, 27, 1, 27, 1, 27, 1, 28, 1, 28, 1, 28, 1, 28, 1, 28, 1,
-		28, 1, 28, 1, 28, 1, 28, 1, 28, 1, 28, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29,
-		1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 30, 1,
-		30, 1, 30, 1, 30, 1, 30, 1, 30, 1, 30, 1, 31, 1, 31, 1, 31, 1, 31, 1, 31,
-		1, 31, 1, 31, 1, 31, 1, 31, 1, 32, 1, 32, 1, 32, 1, 32, 1, 32, 1, 32, 1,
-		33, 1, 33, 1, 33, 1, 33, 1, 33, 1, 34, 1, 34, 1, 34, 1, 34, 1, 34, 1, 34,
-		1, 34, 1, 34, 1, 34, 1, 35, 1, 35, 1, 35, 1, 35, 1, 35, 1, 35, 1, 35, 1,
-		35, 1, 36, 1, 36, 1, 36, 1, 36, 1, 36, 1, 36, 1, 36, 1, 37, 1, 37, 1, 37,
-		1, 37, 1, 37, 1, 38, 1, 38, 1, 38, 1, 38, 1, 38, 1, 38, 1, 38, 1, 38, 1,
-		39, 1, 39, 1, 39, 1, 39, 1, 39, 1, 39, 1, 40, 1, 40, 1, 40, 1, 40, 1, 40,
-		1, 40, 1, 40, 1, 40, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1,
-		41, 1, 41, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42,
-		1, 42, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1,
-		43, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44,
-		1, 44, 1, 45, 1, 45, 1, 45, 1, 45, 1, 45, 1, 46, 1, 46, 1, 46, 1, 46, 1,
-		46, 1, 46, 1, 46, 1, 46, 1, 47, 1, 47, 1, 47, 1, 47, 1, 47, 1, 47, 1, 47,
-		1, 48, 1, 48, 1, 48, 1, 48, 1, 48, 1, 48, 1, 49, 1, 49, 1, 49, 1, 49, 1,
-		49, 1, 49, 1, 49, 1, 50, 1, 50, 1, 50, 1, 50, 1, 51, 1, 51, 1, 51, 1, 51,
-		1, 51, 1, 52, 1, 52, 1, 52, 1, 52, 1, 52, 1, 53, 1, 53, 1, 53, 1, 53, 1,
-		54, 1, 54, 1, 54, 1, 54, 1, 54, 1, 55, 1, 55, 1, 55, 1, 55, 1, 55, 1, 55,
-		1, 55, 1, 56, 1, 56, 1, 56, 1, 56, 1, 56, 1, 56, 1, 57, 1, 57, 1, 57, 1,
-		57, 1, 57, 1, 58, 1, 58, 1, 58, 1, 58, 1, 58, 1, 58, 1, 59, 1, 59, 1, 59,
-		1, 59, 1, 59, 1, 59, 1, 60, 1, 60, 1, 60, 1, 60, 1, 60, 1, 60, 1, 60, 1,
-		60, 1, 61, 1, 61, 1, 61, 1, 61, 1, 61, 1, 61, 1, 62, 1, 62, 1, 62, 1, 62,
-		1, 62, 1, 62, 1, 62, 1, 62, 1, 63, 1, 63, 1, 63, 1, 63, 1, 63, 1, 63, 1,
-		63, 1, 63, 1, 63, 1, 63, 1, 64, 1, 64, 1, 64, 1, 64, 1, 64, 1, 64, 1, 64,
-		1, 65, 1, 65, 1, 65, 1, 66, 1, 66, 1, 66, 1, 66, 1, 67, 1, 67, 1, 67, 1,
-		68, 1, 68, 1, 68, 1, 68, 1, 68, 1, 69, 1, 69, 1, 69, 1, 69, 1, 69, 1, 70,
-		1, 70, 1, 70, 1, 70, 1, 70, 1, 70, 1, 70, 1, 70, 1, 70, 1, 71, 1, 71, 1,
-		71, 1, 71, 1, 71, 1, 71, 1, 72, 1, 72, 1, 72, 1, 72, 1, 73, 1, 73, 1, 73,
-		1, 73, 1, 73, 1, 74, 1, 74, 1, 74, 1, 74, 1, 74, 1, 75, 1, 75, 1, 75, 1,
-		75, 1, 76, 1, 76, 1, 76, 1, 76, 1, 76, 1, 76, 1, 76, 1, 76, 1, 77, 1, 77,
-		1, 77, 1, 78, 1, 78, 1, 78, 1, 78, 1, 78, 1, 78, 1, 79, 1, 79, 1, 79, 1,
-		79, 1, 79, 1, 79, 1, 79, 1, 80, 1, 80, 1, 80, 1, 81, 1, 81, 1, 81, 1, 81,
-		1, 82, 1, 82, 1, 82, 1, 82, 1, 82, 1, 82, 1, 83, 1, 83, 1, 83, 1, 83, 1,
-		83, 1, 84, 1, 84, 1, 84, 1, 84, 1, 85, 1, 85, 1, 85, 1, 86, 1, 86, 1, 86,
-		1, 86, 1, 87, 1, 87, 1, 87, 1, 87, 1, 87, 1, 87, 1, 87, 1, 87, 1, 88, 1,
-		88, 1, 88, 1, 88, 1, 88, 1, 88, 1, 88, 1, 88, 1, 88, 1, 89, 1, 89, 1, 89,
-		1, 89, 1, 89, 1, 89, 1, 89, 1, 89, 1, 90, 1, 90, 1, 90, 1, 91, 1, 91, 1,
-		91, 1, 91, 1, 92, 1, 92, 1, 92, 1, 92, 1, 93, 1, 93, 1, 93, 1, 93, 1, 94,
-		1, 94, 1, 94, 1, 94, 1, 94, 1, 94, 1, 95, 1, 95, 1, 95, 1, 95, 1, 95, 1,
-		96, 1, 96, 1, 96, 1, 96, 1, 96, 1, 96, 1, 97, 1, 97, 1, 97, 1, 97, 1, 98,
-		1, 98, 1, 98, 1, 98, 1, 98, 1, 98, 1, 98, 1, 99, 1, 99, 1, 99, 1, 99, 1,
-		99, 1, 99, 1, 99, 1, 99, 1, 99, 1, 100, 1, 100, 1, 100, 1, 100, 1, 100,
-		1, 101, 1, 101, 1, 102, 1, 102, 1, 103, 1, 103, 1, 104, 1, 104, 1, 105,
-		1, 105, 1, 106, 1, 106, 1, 107, 1, 107, 1, 108, 1, 108, 1, 109, 1, 109,
-		1, 110, 1, 110, 1, 111, 1, 111, 1, 111, 1, 112, 1, 112, 1, 112, 1, 113,
-		1, 113, 1, 114, 1, 114, 1, 114, 1, 115, 1, 115, 1, 116, 1, 116, 1, 116,
-		1, 117, 1, 117, 1, 117, 1, 118, 1, 118, 1, 118, 1, 119, 1, 119, 1, 120,
-		1, 120, 1, 121, 1, 121, 1, 122, 1, 122, 1, 123, 1, 123, 1, 124, 1, 124,
-		1, 125, 1, 125, 1, 126, 1, 126, 1, 127, 1, 127, 1, 128, 1, 128, 1, 129,
-		1, 129, 1, 130, 1, 130, 1, 131, 1, 131, 1, 132, 1, 132, 1, 133, 4, 133,
-		1028, 8, 133, 11, 133, 12, 133, 1029, 1, 134, 4, 134, 1033, 8, 134, 11,
-		134, 12, 134, 1034, 1, 134, 1, 134, 1, 134, 5, 134, 1040, 8, 134, 10, 134,
-		12, 134, 1043, 9, 134, 1, 134, 1, 134, 4, 134, 1047, 8, 134, 11, 134, 12,
-		134, 1048, 3, 134, 1051, 8, 134, 1, 135, 1, 135, 1, 136, 1, 136, 1, 137,
-		1, 137, 1, 137, 1, 137, 5, 137, 1061, 8, 137, 10, 137, 12, 137, 1064, 9,
-		137, 1, 137, 1, 137, 1, 137, 5, 137, 1069, 8, 137, 10, 137, 12, 137, 1072,
-		9, 137, 1, 137, 1, 137, 1, 137, 1, 137, 1, 137, 4, 137, 1079, 8, 137, 11,
-		137, 12, 137, 1080, 1, 137, 1, 137, 5, 137, 1085, 8, 137, 10, 137, 12,
-		137, 1088, 9, 137, 1, 137, 1, 137, 1, 137, 5, 137, 1093, 8, 137, 10, 137,
-		12, 137, 1096, 9, 137, 1, 137, 1, 137, 1, 137, 5, 137, 1101, 8, 137, 10,
-		137, 12, 137, 1104, 9, 137, 1, 137, 3, 137, 1107, 8, 137, 1, 138, 1, 138,
-		1, 139, 1, 139, 1, 140, 1, 140, 1, 141, 1, 141, 1, 142, 1, 142, 1, 143,
-		1, 143, 1, 144, 1, 144, 1, 145, 1, 145, 1, 146, 1, 146, 1, 147, 1, 147,
-		1, 148, 1, 148, 1, 149, 1, 149, 1, 150, 1, 150, 1, 151, 1, 151, 1, 152,
-		1, 152, 1, 153, 1, 153, 1, 154, 1, 154, 1, 155, 1, 155, 1, 156, 1, 156,
-		1, 157, 1, 157, 1, 158, 1, 158, 1, 159, 1, 159, 1, 160, 1, 160, 1, 161,
-		1, 161, 1, 162, 1, 162, 1, 163, 1, 163, 4, 1070, 1086, 1094, 1102, 0, 164,
-		1, 1, 3, 2, 5, 3, 7, 4, 9, 0, 11, 0, 13, 0, 15, 0, 17, 0, 19, 5, 21, 6,
-		23, 7, 25, 8, 27, 9, 29, 10, 31, 11, 33, 12, 35, 13, 37, 14, 39, 15, 41,
-		16, 43, 17, 45, 18, 47, 19, 49, 20, 51, 21, 53, 22, 55, 23, 57, 24, 59,
-		25, 61, 26, 63, 27, 65, 28, 67, 29, 69, 30, 71, 31, 73, 32, 75, 33, 77,
-		34, 79, 35, 81, 36, 83, 37, 85, 38, 87, 39, 89, 40, 91, 41, 93, 42, 95,
-		43, 97, 44, 99, 45, 101, 46, 103, 47, 105, 48, 107, 49, 109, 50, 111, 51,
-		113, 52, 115, 53, 117, 54, 119, 55, 121, 56, 123, 57, 125, 58, 127, 59,
-		129, 60, 131, 61, 133, 62, 135, 63, 137, 64, 139, 65, 141, 66, 143, 67,
-		145, 68, 147, 69, 149, 70, 151, 71, 153, 72, 155, 73, 157, 74, 159, 75,
-		161, 76, 163, 77, 165, 78, 167, 79, 169, 80, 171, 81, 173, 82, 175, 83,
-		177, 84, 179, 85, 181, 86, 183, 87, 185, 88, 187, 89, 189, 90, 191, 91,
-		193, 92, 195, 93, 197, 94, 199, 95, 201, 96, 203, 97, 205, 98, 207, 99,
-		209, 100, 211, 101, 213, 102, 215, 103, 217, 104, 219, 105, 221, 106, 223,
-		107, 225, 108, 227, 109, 229, 110, 231, 111, 233, 112, 235, 113, 237, 114,
-		239, 115, 241, 116, 243, 117, 245, 118, 247, 119, 249, 120, 251, 121, 253,
-		122, 255, 123, 257, 124, 259, 125, 261, 126, 263, 127, 265, 128, 267, 129,
-		269, 130, 271, 0, 273, 0, 275, 0, 277, 0, 279, 0, 281, 0, 283, 0, 285,
-		0, 287, 0, 289, 0, 291, 0, 293, 0, 295, 0, 297, 0, 299, 0, 301, 0, 303,
-		0, 305, 0, 307, 0, 309, 0, 311, 0, 313, 0, 315, 0, 317, 0, 319, 0, 321,
-		0, 323, 0, 325, 0, 327, 0, 1, 0, 37, 8, 0, 34, 34, 47, 47, 92, 92, 98,
-		98, 102, 102, 110, 110, 114, 114, 116, 116, 3, 0, 48, 57, 65, 70, 97, 102,
-		3, 0, 0, 31, 34, 34, 92, 92, 2, 0, 69, 69, 101, 101, 2, 0, 43, 43, 45,
-		45, 3, 0, 9, 10, 13, 13, 32, 32, 1, 0, 46, 46, 1, 0, 48, 57, 2, 0, 65,
-		90, 97, 122, 2, 0, 46, 46, 95, 95, 3, 0, 35, 36, 64, 64, 95, 95, 4, 0,
-		35, 36, 58, 58, 64, 64, 95, 95, 2, 0, 65, 65, 97, 97, 2, 0, 66, 66, 98,
-		98, 2, 0, 67, 67, 99, 99, 2, 0, 68, 68, 100, 100, 2, 0, 70, 70, 102, 102,
-		2, 0, 71, 71, 103, 103, 2, 0, 72, 72, 104, 104, 2, 0, 73, 73, 105, 105,
-		2, 0, 74, 74, 106, 106, 2, 0, 75, 75, 107, 107, 2, 0, 76, 76, 108, 108,
-		2, 0, 77, 77, 109, 109, 2, 0, 78, 78, 110, 110, 2, 0, 79, 79, 111, 111,
-		2, 0, 80, 80, 112, 112, 2, 0, 81, 81, 113, 113, 2, 0, 82, 82, 114, 114,
-		2, 0, 83, 83, 115, 115, 2, 0, 84, 84, 116, 116, 2, 0, 85, 85, 117, 117,
-		2, 0, 86, 86, 118, 118, 2, 0, 87, 87, 119, 119, 2, 0, 88, 88, 120, 120,
-		2, 0, 89, 89, 121, 121, 2, 0, 90, 90, 122, 122, 1150, 0, 1, 1, 0, 0, 0,
-		0, 3, 1, 0, 0, 0, 0, 5, 1, 0, 0, 0, 0, 7, 1, 0, 0, 0, 0, 19, 1, 0, 0, 0,
-		0, 21, 1, 0, 0, 0, 0, 23, 1, 0, 0, 0, 0, 25, 1, 0, 0, 0, 0, 27, 1, 0, 0,
-		0, 0, 29, 1, 0, 0, 0, 0, 31, 1, 0, 0, 0, 0, 33, 1, 0, 0, 0, 0, 35, 1, 0,
-		0, 0, 0, 37, 1, 0, 0, 0, 0, 39, 1, 0, 0, 0, 0, 41, 1, 0, 0, 0, 0, 43, 1,
-		0, 0, 0, 0, 45, 1, 0, 0, 0, 0, 47, 1, 0, 0, 0, 0, 49, 1, 0, 0, 0, 0, 51,
-		1, 0, 0, 0, 0, 53, 1, 0, 0, 0, 0, 55, 1, 0, 0, 0, 0, 57, 1, 0, 0, 0, 0,
-		59, 1, 0, 0, 0, 0, 61, 1, 0, 0, 0, 0, 63, 1, 0, 0, 0, 0, 65, 1, 0, 0, 0,
-		0, 67, 1, 0, 0, 0, 0, 69, 1, 0, 0, 0, 0, 71, 1, 0, 0, 0, 0, 73, 1, 0, 0,
-		0, 0, 75, 1, 0, 0, 0, 0, 77, 1, 0, 0, 0, 0, 79, 1, 0, 0, 0, 0, 81, 1, 0,
-		0, 0, 0, 83, 1, 0, 0, 0, 0, 85, 1, 0, 0, 0, 0, 87, 1, 0, 0, 0, 0, 89, 1,
-		0, 0, 0, 0, 91, 1, 0, 0, 0, 0, 93, 1, 0, 0, 0, 0, 95, 1, 0, 0, 0, 0, 97,
-		1, 0, 0, 0, 0, 99, 1, 0, 0, 0, 0, 101, 1, 0, 0, 0, 0, 103, 1, 0, 0, 0,
-		0, 105, 1, 0, 0, 0, 0, 107, 1, 0, 0, 0, 0, 109, 1, 0, 0, 0, 0, 111, 1,
-		0, 0, 0, 0, 113, 1, 0, 0, 0, 0, 115, 1, 0, 0, 0, 0, 117, 1, 0, 0, 0, 0,
-		119, 1, 0, 0, 0, 0, 121, 1, 0, 0, 0, 0, 123, 1, 0, 0, 0, 0, 125, 1, 0,
-		0, 0, 0, 127, 1, 0, 0, 0, 0, 129, 1, 0, 0, 0, 0, 131, 1, 0, 0, 0, 0, 133,
-		1, 0, 0, 0, 0, 135, 1, 0, 0, 0, 0, 137, 1, 0, 0, 0, 0, 139, 1, 0, 0, 0,
-		0, 141, 1, 0, 0, 0, 0, 143, 1, 0, 0, 0, 0, 145, 1, 0, 0, 0, 0, 147, 1,
-		0, 0, 0, 0, 149, 1, 0, 0, 0, 0, 151, 1, 0, 0, 0, 0, 153, 1, 0, 0, 0, 0,
-		155, 1, 0, 0, 0, 0, 157, 1, 0, 0, 0, 0, 159, 1, 0, 0, 0, 0, 161, 1, 0,
-		0, 0, 0, 163, 1, 0, 0, 0, 0, 165, 1, 0, 0, 0, 0, 167, 1, 0, 0, 0, 0, 169,
-		1, 0, 0, 0, 0, 171, 1, 0, 0, 0, 0, 173, 1, 0, 0, 0, 0, 175, 1, 0, 0, 0,
-		0, 177, 1, 0, 0, 0, 0, 179, 1, 0, 0, 0, 0, 181, 1, 0, 0, 0, 0, 183, 1,
-		0, 0, 0, 0, 185, 1, 0, 0, 0, 0, 187, 1, 0, 0, 0, 0, 189, 1, 0, 0, 0, 0,
-		191, 1, 0, 0, 0, 0, 193, 1, 0, 0, 0, 0, 195, 1, 0, 0, 0, 0, 197, 1, 0,
-		0, 0, 0, 199, 1, 0, 0, 0, 0, 201, 1, 0, 0, 0, 0, 203, 1, 0, 0, 0, 0, 205,
-		1, 0, 0, 0, 0, 207, 1, 0, 0, 0, 0, 209, 1, 0, 0, 0, 0, 211, 1, 0, 0, 0,
-		0, 213, 1, 0, 0, 0, 0, 215, 1, 0, 0, 0, 0, 217, 1, 0, 0, 0, 0, 219, 1,
-		0, 0, 0, 0, 221, 1, 0, 0, 0, 0, 223, 1, 0, 0, 0, 0, 225, 1, 0, 0, 0, 0,
-		227, 1, 0, 0, 0, 0, 229, 1, 0, 0, 0, 0, 231, 1, 0, 0, 0, 0, 233, 1, 0,
-		0, 0, 0, 235, 1, 0, 0, 0, 0, 237, 1, 0, 0, 0, 0, 239, 1, 0, 0, 0, 0, 241,
-		1, 0, 0, 0, 0, 243, 1, 0, 0, 0, 0, 245, 1, 0, 0, 0, 0, 247, 1, 0, 0, 0,
-		0, 249, 1, 0, 0, 0, 0, 251, 1, 0, 0, 0, 0, 253, 1, 0, 0, 0, 0, 255, 1,
-		0, 0, 0, 0, 257, 1, 0, 0, 0, 0, 259, 1, 0, 0, 0, 0, 261, 1, 0, 0, 0, 0,
-		263, 1, 0, 0, 0, 0, 265, 1, 0, 0, 0, 0, 267, 1, 0, 0, 0, 0, 269, 1, 0,
-		0, 0, 1, 329, 1, 0, 0, 0, 3, 334, 1, 0, 0, 0, 5, 340, 1, 0, 0, 0, 7, 345,
-		1, 0, 0, 0, 9, 355, 1, 0, 0, 0, 11, 360, 1, 0, 0, 0, 13, 366, 1, 0, 0,
-		0, 15, 368, 1, 0, 0, 0, 17, 370, 1, 0, 0, 0, 19, 377, 1, 0, 0, 0, 21, 383,
-		1, 0, 0, 0, 23, 390, 1, 0, 0, 0, 25, 397, 1, 0, 0, 0, 27, 401, 1, 0, 0,
-		0, 29, 406, 1, 0, 0, 0, 31, 415, 1, 0, 0, 0, 33, 420, 1, 0, 0, 0, 35, 426,
-		1, 0, 0, 0, 37, 438, 1, 0, 0, 0, 39, 445, 1, 0, 0, 0, 41, 449, 1, 0, 0,
-		0, 43, 457, 1, 0, 0, 0, 45, 465, 1, 0, 0, 0, 47, 475, 1, 0, 0, 0, 49, 480,
-		1, 0, 0, 0, 51, 483, 1, 0, 0, 0, 53, 488, 1, 0, 0, 0, 55, 496, 1, 0, 0,
-		0, 57, 500, 1, 0, 0, 0, 59, 511, 1, 0, 0, 0, 61, 525, 1, 0, 0, 0, 63, 532,
-		1, 0, 0, 0, 65, 541, 1, 0, 0, 0, 67, 547, 1, 0, 0, 0, 69, 552, 1, 0, 0,
-		0, 71, 561, 1, 0, 0, 0, 73, 569, 1, 0, 0, 0, 75, 576, 1, 0, 0, 0, 77, 581,
-		1, 0, 0, 0, 79, 589, 1, 0, 0, 0, 81, 595, 1, 0, 0, 0, 83, 603, 1, 0, 0,
-		0, 85, 612, 1, 0, 0, 0, 87, 622, 1, 0, 0, 0, 89, 632, 1, 0, 0, 0, 91, 643,
-		1, 0, 0, 0, 93, 648, 1, 0, 0, 0, 95, 656, 1, 0, 0, 0, 97, 663, 1, 0, 0,
-		0, 99, 669, 1, 0, 0, 0, 101, 676, 1, 0, 0, 0, 103, 680, 1, 0, 0, 0, 105,
-		685, 1, 0, 0, 0, 107, 690, 1, 0, 0, 0, 109, 694, 1, 0, 0, 0, 111, 699,
-		1, 0, 0, 0, 113, 706, 1, 0, 0, 0, 115, 712, 1, 0, 0, 0, 117, 717, 1, 0,
-		0, 0, 119, 723, 1, 0, 0, 0, 121, 729, 1, 0, 0, 0, 123, 737, 1, 0, 0, 0,
-		125, 743, 1, 0, 0, 0, 127, 751, 1, 0, 0, 0, 129, 761, 1, 0, 0, 0, 131,
-		768, 1, 0, 0, 0, 133, 771, 1, 0, 0, 0, 135, 775, 1, 0, 0, 0, 137, 778,
-		1, 0, 0, 0, 139, 783, 1, 0, 0, 0, 141, 788, 1, 0, 0, 0, 143, 797, 1, 0,
-		0, 0, 145, 803, 1, 0, 0, 0, 147, 807, 1, 0, 0, 0, 149, 812, 1, 0, 0, 0,
-		151, 817, 1, 0, 0, 0, 153, 821, 1, 0, 0, 0, 155, 829, 1, 0, 0, 0, 157,
-		832, 1, 0, 0, 0, 159, 838, 1, 0, 0, 0, 161, 845, 1, 0, 0, 0, 163, 848,
-		1, 0, 0, 0, 165, 852, 1, 0, 0, 0, 167, 858, 1, 0, 0, 0, 169, 863, 1, 0,
-		0, 0, 171, 867, 1, 0, 0, 0, 173, 870, 1, 0, 0, 0, 175, 874, 1, 0, 0, 0,
-		177, 882, 1, 0, 0, 0, 179, 891, 1, 0, 0, 0, 181, 899, 1, 0, 0, 0, 183,
-		902, 1, 0, 0, 0, 185, 906, 1, 0, 0, 0, 187, 910, 1, 0, 0, 0, 189, 914,
-		1, 0, 0, 0, 191, 920, 1, 0, 0, 0, 193, 925, 1, 0, 0, 0, 195, 931, 1, 0,
-		0, 0, 197, 935, 1, 0, 0, 0, 199, 942, 1, 0, 0, 0, 201, 951, 1, 0, 0, 0,
-		203, 956, 1, 0, 0, 0, 205, 958, 1, 0, 0, 0, 207, 960, 1, 0, 0, 0, 209,
-		962, 1, 0, 0, 0, 211, 964, 1, 0, 0, 0, 213, 966, 1, 0, 0, 0, 215, 968,
-		1, 0, 0, 0, 217, 970, 1, 0, 0, 0, 219, 972, 1, 0, 0, 0, 221, 974, 1, 0,
-		0, 0, 223, 976, 1, 0, 0, 0, 225, 979, 1, 0, 0, 0, 227, 982, 1, 0, 0, 0,
-		229, 984, 1, 0, 0, 0, 231, 987, 1, 0, 0, 0, 233, 989, 1, 0, 0, 0, 235,
-		992, 1, 0, 0, 0, 237, 995, 1, 0, 0, 0, 239, 998, 1, 0, 0, 0, 241, 1000,
-		1, 0, 0, 0, 243, 1002, 1, 0, 0, 0, 245, 1004, 1, 0, 0, 0, 247, 1006, 1,
-		0, 0, 0, 249, 1008, 1, 0, 0, 0, 251, 1010, 1, 0, 0, 0, 253, 1012, 1, 0,
-		0, 0, 255, 1014, 1, 0, 0, 0, 257, 1016, 1, 0, 0, 0, 259, 1018, 1, 0, 0,
-		0, 261, 1020, 1, 0, 0, 0, 263, 1022, 1, 0, 0, 0, 265, 1024, 1, 0, 0, 0,
+		4, 0, 138, 1225, 6, -1, 2, 0, 7, 0, 2, 1, 7, 1, 2, 2, 7, 2, 2, 3, 7, 3, 2,
+		4, 7, 4, 2, 5, 7, 5, 2, 6, 7, 6, 2, 7, 7, 7, 2, 8, 7, 8, 2, 9, 7, 9, 2,
+		10, 7, 10, 2, 11, 7, 11, 2, 12, 7, 12, 2, 13, 7, 13, 2, 14, 7, 14, 2, 15,
+		7, 15, 2, 16, 7, 16, 2, 17, 7, 17, 2, 18, 7, 18, 2, 19, 7, 19, 2, 20, 7,
+		20, 2, 21, 7, 21, 2, 22, 7, 22, 2, 23, 7, 23, 2, 24, 7, 24, 2, 25, 7, 25,
+		2, 26, 7, 26, 2, 27, 7, 27, 2, 28, 7, 28, 2, 29, 7, 29, 2, 30, 7, 30, 2,
+		31, 7, 31, 2, 32, 7, 32, 2, 33, 7, 33, 2, 34, 7, 34, 2, 35, 7, 35, 2, 36,
+		7, 36, 2, 37, 7, 37, 2, 38, 7, 38, 2, 39, 7, 39, 2, 40, 7, 40, 2, 41, 7,
+		41, 2, 42, 7, 42, 2, 43, 7, 43, 2, 44, 7, 44, 2, 45, 7, 45, 2, 46, 7, 46,
+		2, 47, 7, 47, 2, 48, 7, 48, 2, 49, 7, 49, 2, 50, 7, 50, 2, 51, 7, 51, 2,
+		52, 7, 52, 2, 53, 7, 53, 2, 54, 7, 54, 2, 55, 7, 55, 2, 56, 7, 56, 2, 57,
+		7, 57, 2, 58, 7, 58, 2, 59, 7, 59, 2, 60, 7, 60, 2, 61, 7, 61, 2, 62, 7,
+		62, 2, 63, 7, 63, 2, 64, 7, 64, 2, 65, 7, 65, 2, 66, 7, 66, 2, 67, 7, 67,
+		2, 68, 7, 68, 2, 69, 7, 69, 2, 70, 7, 70, 2, 71, 7, 71, 2, 72, 7, 72, 2,
+		73, 7, 73, 2, 74, 7, 74, 2, 75, 7, 75, 2, 76, 7, 76, 2, 77, 7, 77, 2, 78,
+		7, 78, 2, 79, 7, 79, 2, 80, 7, 80, 2, 81, 7, 81, 2, 82, 7, 82, 2, 83, 7,
+		83, 2, 84, 7, 84, 2, 85, 7, 85, 2, 86, 7, 86, 2, 87, 7, 87, 2, 88, 7, 88,
+		2, 89, 7, 89, 2, 90, 7, 90, 2, 91, 7, 91, 2, 92, 7, 92, 2, 93, 7, 93, 2,
+		94, 7, 94, 2, 95, 7, 95, 2, 96, 7, 96, 2, 97, 7, 97, 2, 98, 7, 98, 2, 99,
+		7, 99, 2, 100, 7, 100, 2, 101, 7, 101, 2, 102, 7, 102, 2, 103, 7, 103, 2,
+		104, 7, 104, 2, 105, 7, 105, 2, 106, 7, 106, 2, 107, 7, 107, 2, 108, 7,
+		108, 2, 109, 7, 109, 2, 110, 7, 110, 2, 111, 7, 111, 2, 112, 7, 112, 2,
+		113, 7, 113, 2, 114, 7, 114, 2, 115, 7, 115, 2, 116, 7, 116, 2, 117, 7,
+		117, 2, 118, 7, 118, 2, 119, 7, 119, 2, 120, 7, 120, 2, 121, 7, 121, 2,
+		122, 7, 122, 2, 123, 7, 123, 2, 124, 7, 124, 2, 125, 7, 125, 2, 126, 7,
+		126, 2, 127, 7, 127, 2, 128, 7, 128, 2, 129, 7, 129, 2, 130, 7, 130, 2,
+		131, 7, 131, 2, 132, 7, 132, 2, 133, 7, 133, 2, 134, 7, 134, 2, 135, 7,
+		135, 2, 136, 7, 136, 2, 137, 7, 137, 2, 138, 7, 138, 2, 139, 7, 139, 2,
+		140, 7, 140, 2, 141, 7, 141, 2, 142, 7, 142, 2, 143, 7, 143, 2, 144, 7,
+		144, 2, 145, 7, 145, 2, 146, 7, 146, 2, 147, 7, 147, 2, 148, 7, 148, 2,
+		149, 7, 149, 2, 150, 7, 150, 2, 151, 7, 151, 2, 152, 7, 152, 2, 153, 7,
+		153, 2, 154, 7, 154, 2, 155, 7, 155, 2, 156, 7, 156, 2, 157, 7, 157, 2,
+		158, 7, 158, 2, 159, 7, 159, 2, 160, 7, 160, 2, 161, 7, 161, 2, 162, 7,
+		162, 2, 163, 7, 163, 1, 0, 1, 0, 1, 0, 1, 0, 1, 0, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 2, 1, 2, 1, 2, 1, 2, 1, 2, 1, 3, 1, 3, 1, 3, 5, 3, 349, 8,
+		3, 10, 3, 12, 3, 352, 9, 3, 1, 3, 1, 3, 1, 4, 1, 4, 1, 4, 3, 4, 359, 8, 4,
+		1, 5, 1, 5, 1, 5, 1, 5, 1, 5, 1, 5, 1, 6, 1, 6, 1, 7, 1, 7, 1, 8, 1, 8, 3,
+		8, 373, 8, 8, 1, 8, 1, 8, 1, 9, 4, 9, 378, 8, 9, 11, 9, 12, 9, 379, 1, 9,
+		1, 9, 1, 10, 1, 10, 1, 10, 1, 10, 1, 10, 1, 10, 1, 10, 1, 11, 1, 11, 1,
+		11, 1, 11, 1, 11, 1, 11, 1, 11, 1, 12, 1, 12, 1, 12, 1, 12, 1, 13, 1, 13,
+		1, 13, 1, 13, 1, 13, 1, 14, 1, 14, 1, 14, 1, 14, 1, 14, 1, 14, 1, 14, 1,
+		14, 1, 14, 1, 15, 1, 15, 1, 15, 1, 15, 1, 15, 1, 16, 1, 16, 1, 16, 1, 16,
+		1, 16, 1, 16, 1, 17, 1, 17, 1, 17, 1, 17, 1, 17, 1, 17, 1, 17, 1, 17, 1,
+		17, 1, 17, 1, 17, 1, 17, 1, 18, 1, 18, 1, 18, 1, 18, 1, 18, 1, 18, 1, 18,
+		1, 19, 1, 19, 1, 19, 1, 19, 1, 20, 1, 20, 1, 20, 1, 20, 1, 20, 1, 20, 1,
+		20, 1, 20, 1, 21, 1, 21, 1, 21, 1, 21, 1, 21, 1, 21, 1, 21, 1, 21, 1, 22,
+		1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 22, 1, 23, 1,
+		23, 1, 23, 1, 23, 1, 23, 1, 24, 1, 24, 1, 24, 1, 25, 1, 25, 1, 25, 1, 25,
+		1, 25, 1, 26, 1, 26, 1, 26, 1, 26, 1, 26, 1, 26, 1, 26, 1, 26, 1, 27, 1,
+		27, 1, 27, 1, 27, 1, 28, 1, 28, 1, 28, 1, 28, 1, 28, 1, 28, 1, 28, 1, 28,
+		1, 28, 1, 28, 1, 28, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1,
+		29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 29, 1, 30, 1, 30, 1, 30, 1, 30,
+		1, 30, 1, 30, 1, 30, 1, 31, 1, 31, 1, 31, 1, 31, 1, 31, 1, 31, 1, 31, 1,
+		31, 1, 31, 1, 32, 1, 32, 1, 32, 1, 32, 1, 32, 1, 32, 1, 33, 1, 33, 1, 33,
+		1, 33, 1, 33, 1, 34, 1, 34, 1, 34, 1, 34, 1, 34, 1, 34, 1, 34, 1, 34, 1,
+		34, 1, 35, 1, 35, 1, 35, 1, 35, 1, 35, 1, 35, 1, 35, 1, 35, 1, 36, 1, 36,
+		1, 36, 1, 36, 1, 36, 1, 36, 1, 36, 1, 37, 1, 37, 1, 37, 1, 37, 1, 37, 1,
+		38, 1, 38, 1, 38, 1, 38, 1, 38, 1, 38, 1, 38, 1, 38, 1, 39, 1, 39, 1, 39,
+		1, 39, 1, 39, 1, 39, 1, 40, 1, 40, 1, 40, 1, 40, 1, 40, 1, 40, 1, 40, 1,
+		40, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1, 41, 1, 42,
+		1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 42, 1, 43, 1,
+		43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 43, 1, 44, 1, 44,
+		1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 44, 1, 45, 1,
+		45, 1, 45, 1, 45, 1, 45, 1, 46, 1, 46, 1, 46, 1, 46, 1, 46, 1, 46, 1, 46,
+		1, 46, 1, 47, 1, 47, 1, 47, 1, 47, 1, 47, 1, 47, 1, 47, 1, 48, 1, 48, 1,
+		48, 1, 48, 1, 48, 1, 48, 1, 49, 1, 49, 1, 49, 1, 49, 1, 49, 1, 49, 1, 49,
+		1, 50, 1, 50, 1, 50, 1, 50, 1, 51, 1, 51, 1, 51, 1, 51, 1, 51, 1, 52, 1,
+		52, 1, 52, 1, 52, 1, 52, 1, 53, 1, 53, 1, 53, 1, 53, 1, 54, 1, 54, 1, 54,
+		1, 54, 1, 54, 1, 55, 1, 55, 1, 55, 1, 55, 1, 55, 1, 55, 1, 55, 1, 56, 1,
+		56, 1, 56, 1, 56, 1, 56, 1, 56, 1, 57, 1, 57, 1, 57, 1, 57, 1, 57, 1, 58,
+		1, 58, 1, 58, 1, 58, 1, 58, 1, 58, 1, 59, 1, 59, 1, 59, 1, 59, 1, 59, 1,
+		59, 1, 60, 1, 60, 1, 60, 1, 60, 1, 60, 1, 60, 1, 60, 1, 60, 1, 61, 1, 61,
+		1, 61, 1, 61, 1, 61, 1, 61, 1, 62, 1, 62, 1, 62, 1, 62, 1, 62, 1, 62, 1,
+		62, 1, 62, 1, 63, 1, 63, 1, 63, 1, 63, 1, 63, 1, 63, 1, 63, 1, 63, 1, 63,
+		1, 63, 1, 64, 1, 64, 1, 64, 1, 64, 1, 64, 1, 64, 1, 64, 1, 65, 1, 65, 1,
+		65, 1, 66, 1, 66, 1, 66, 1, 66, 1, 67, 1, 67, 1, 67, 1, 68, 1, 68, 1, 68,
+		1, 68, 1, 68, 1, 69, 1, 69, 1, 69, 1, 69, 1, 69, 1, 70, 1, 70, 1, 70, 1,
+		70, 1, 70, 1, 70, 1, 70, 1, 70, 1, 70, 1, 71, 1, 71, 1, 71, 1, 71, 1, 71,
+		1, 71, 1, 72, 1, 72, 1, 72, 1, 72, 1, 73, 1, 73, 1, 73, 1, 73, 1, 73, 1,
+		74, 1, 74, 1, 74, 1, 74, 1, 74, 1, 75, 1, 75, 1, 75, 1, 75, 1, 76, 1, 76,
+		1, 76, 1, 76, 1, 76, 1, 76, 1, 76, 1, 76, 1, 77, 1, 77, 1, 77, 1, 78, 1,
+		78, 1, 78, 1, 78, 1, 78, 1, 78, 1, 79, 1, 79, 1, 79, 1, 79, 1, 79, 1, 79,
+		1, 79, 1, 80, 1, 80, 1, 80, 1, 81, 1, 81, 1, 81, 1, 81, 1, 82, 1, 82, 1,
+		82, 1, 82, 1, 82, 1, 82, 1, 83, 1, 83, 1, 83, 1, 83, 1, 83, 1, 84, 1, 84,
+		1, 84, 1, 84, 1, 85, 1, 85, 1, 85, 1, 86, 1, 86, 1, 86, 1, 86, 1, 87, 1,
+		87, 1, 87, 1, 87, 1, 87, 1, 87, 1, 87, 1, 87, 1, 88, 1, 88, 1, 88, 1, 88,
+		1, 88, 1, 88, 1, 88, 1, 88, 1, 88, 1, 89, 1, 89, 1, 89, 1, 89, 1, 89, 1,
+		89, 1, 89, 1, 89, 1, 90, 1, 90, 1, 90, 1, 91, 1, 91, 1, 91, 1, 91, 1, 92,
+		1, 92, 1, 92, 1, 92, 1, 93, 1, 93, 1, 93, 1, 93, 1, 94, 1, 94, 1, 94, 1,
+		94, 1, 94, 1, 94, 1, 95, 1, 95, 1, 95, 1, 95, 1, 95, 1, 96, 1, 96, 1, 96,
+		1, 96, 1, 96, 1, 96, 1, 97, 1, 97, 1, 97, 1, 97, 1, 98, 1, 98, 1, 98, 1,
+		98, 1, 98, 1, 98, 1, 98, 1, 99, 1, 99, 1, 99, 1, 99, 1, 99, 1, 99, 1, 99,
+		1, 99, 1, 99, 1, 100, 1, 100, 1, 100, 1, 100, 1, 100, 1, 101, 1, 101, 1,
+		102, 1, 102, 1, 103, 1, 103, 1, 104, 1, 104, 1, 105, 1, 105, 1, 106, 1,
+		106, 1, 107, 1, 107, 1, 108, 1, 108, 1, 109, 1, 109, 1, 110, 1, 110, 1,
+		111, 1, 111, 1, 111, 1, 112, 1, 112, 1, 112, 1, 113, 1, 113, 1, 114, 1,
+		114, 1, 114, 1, 115, 1, 115, 1, 116, 1, 116, 1, 116, 1, 117, 1, 117, 1,
+		117, 1, 118, 1, 118, 1, 118, 1, 119, 1, 119, 1, 120, 1, 120, 1, 121, 1,
+		121, 1, 122, 1, 122, 1, 123, 1, 123, 1, 124, 1, 124, 1, 125, 1, 125, 1,
+		126, 1, 126, 1, 127, 1, 127, 1, 128, 1, 128, 1, 129, 1, 129, 1, 130, 1,
+		130, 1, 131, 1, 131, 1, 132, 1, 132, 1, 133, 4, 133, 1028, 8, 133, 11,
+		133, 12, 133, 1029, 1, 134, 4, 134, 1033, 8, 134, 11, 134, 12, 134, 1034,
+		1, 134, 1, 134, 1, 134, 5, 134, 1040, 8, 134, 10, 134, 12, 134, 1043, 9,
+		134, 1, 134, 1, 134, 4, 134, 1047, 8, 134, 11, 134, 12, 134, 1048, 3, 134,
+		1051, 8, 134, 1, 135, 1, 135, 1, 136, 1, 136, 1, 137, 1, 137, 1, 137, 1,
+		137, 5, 137, 1061, 8, 137, 10, 137, 12, 137, 1064, 9, 137, 1, 137, 1, 137,
+		1, 137, 5, 137, 1069, 8, 137, 10, 137, 12, 137, 1072, 9, 137, 1, 137, 1,
+		137, 1, 137, 1, 137, 1, 137, 4, 137, 1079, 8, 137, 11, 137, 12, 137, 1080,
+		1, 137, 1, 137, 5, 137, 1085, 8, 137, 10, 137, 12, 137, 1088, 9, 137, 1,
+		137, 1, 137, 1, 137, 5, 137, 1093, 8, 137, 10, 137, 12, 137, 1096, 9, 137,
+		1, 137, 1, 137, 1, 137, 5, 137, 1101, 8, 137, 10, 137, 12, 137, 1104, 9,
+		137, 1, 137, 3, 137, 1107, 8, 137, 1, 138, 1, 138, 1, 139, 1, 139, 1, 140,
+		1, 140, 1, 141, 1, 141, 1, 142, 1, 142, 1, 143, 1, 143, 1, 144, 1, 144, 1,
+		145, 1, 145, 1, 146, 1, 146, 1, 147, 1, 147, 1, 148, 1, 148, 1, 149, 1,
+		149, 1, 150, 1, 150, 1, 151, 1, 151, 1, 152, 1, 152, 1, 153, 1, 153, 1,
+		154, 1, 154, 1, 155, 1, 155, 1, 156, 1, 156, 1, 157, 1, 157, 1, 158, 1,
+		158, 1, 159, 1, 159, 1, 160, 1, 160, 1, 161, 1, 161, 1, 162, 1, 162, 1,
+		163, 1, 163, 2, 164, 7, 164, 1, 164, 1, 164, 1, 164, 1, 164, 1, 164, 1,
+		164, 1, 164, 2, 165, 7, 165, 1, 165, 1, 165, 1, 165, 1, 165, 1, 165, 1,
+		165, 2, 166, 7, 166, 1, 166, 1, 166, 1, 166, 1, 166, 2, 167, 7, 167, 1,
+		167, 1, 167, 1, 167, 1, 167, 1, 167, 1, 167, 1, 167, 2, 168, 7, 168, 1,
+		168, 1, 168, 1, 168, 2, 169, 7, 169, 1, 169, 1, 169, 1, 169, 1, 169, 1,
+		169, 1, 169, 2, 170, 7, 170, 1, 170, 1, 170, 1, 170, 1, 170, 1, 170, 1,
+		170, 1, 170, 2, 171, 7, 171, 1, 171, 1, 171, 1, 171, 1, 171, 1, 171, 1,
+		171, 1, 171, 1, 171, 1, 171, 4, 1070, 1086, 1094, 1102, 0, 172, 1, 1, 3,
+		2, 5, 3, 7, 4, 9, 0, 11, 0, 13, 0, 15, 0, 17, 0, 19, 5, 21, 6, 23, 7, 25,
+		8, 27, 9, 29, 10, 31, 11, 33, 12, 35, 13, 37, 14, 39, 15, 41, 16, 43, 17,
+		45, 18, 47, 19, 49, 20, 51, 21, 53, 22, 55, 23, 57, 24, 59, 25, 61, 26,
+		63, 27, 65, 28, 67, 29, 69, 30, 71, 31, 73, 32, 75, 33, 77, 34, 79, 35,
+		81, 36, 83, 37, 85, 38, 87, 39, 89, 40, 91, 41, 93, 42, 95, 43, 97, 44,
+		99, 45, 101, 46, 103, 47, 105, 48, 107, 49, 109, 50, 111, 51, 113, 52,
+		115, 53, 117, 54, 119, 55, 121, 56, 123, 57, 125, 58, 127, 59, 129, 60,
+		131, 61, 133, 62, 135, 63, 137, 64, 139, 65, 141, 66, 143, 67, 145, 68,
+		147, 69, 149, 70, 151, 71, 153, 72, 155, 73, 157, 74, 159, 75, 161, 76,
+		163, 77, 165, 78, 167, 79, 169, 80, 171, 81, 173, 82, 175, 83, 177, 84,
+		179, 85, 181, 86, 183, 87, 185, 88, 187, 89, 189, 90, 191, 91, 193, 92,
+		195, 93, 197, 94, 199, 95, 201, 96, 203, 97, 205, 98, 207, 99, 209, 100,
+		211, 101, 213, 102, 215, 103, 217, 104, 219, 105, 221, 106, 223, 107, 225,
+		108, 227, 109, 229, 110, 231, 111, 233, 112, 235, 113, 237, 114, 239, 115,
+		241, 116, 243, 117, 245, 118, 247, 119, 249, 120, 251, 121, 253, 122, 255,
+		123, 257, 124, 259, 125, 261, 126, 263, 127, 265, 128, 267, 129, 269, 130,
+		271, 0, 273, 0, 275, 0, 277, 0, 279, 0, 281, 0, 283, 0, 285, 0, 287, 0,
+		289, 0, 291, 0, 293, 0, 295, 0, 297, 0, 299, 0, 301, 0, 303, 0, 305, 0,
+		307, 0, 309, 0, 311, 0, 313, 0, 315, 0, 317, 0, 319, 0, 321, 0, 323, 0,
+		325, 0, 327, 0, 1160, 131, 1169, 132, 1177, 133, 1183, 134, 1192, 135,
+		1197, 136, 1205, 137, 1214, 138, 1, 0, 37, 8, 0, 34, 34, 47, 47, 92, 92,
+		98, 98, 102, 102, 110, 110, 114, 114, 116, 116, 3, 0, 48, 57, 65, 70, 97,
+		102, 3, 0, 0, 31, 34, 34, 92, 92, 2, 0, 69, 69, 101, 101, 2, 0, 43, 43,
+		45, 45, 3, 0, 9, 10, 13, 13, 32, 32, 1, 0, 46, 46, 1, 0, 48, 57, 2, 0, 65,
+		90, 97, 122, 2, 0, 46, 46, 95, 95, 3, 0, 35, 36, 64, 64, 95, 95, 4, 0, 35,
+		36, 58, 58, 64, 64, 95, 95, 2, 0, 65, 65, 97, 97, 2, 0, 66, 66, 98, 98, 2,
+		0, 67, 67, 99, 99, 2, 0, 68, 68, 100, 100, 2, 0, 70, 70, 102, 102, 2, 0,
+		71, 71, 103, 103, 2, 0, 72, 72, 104, 104, 2, 0, 73, 73, 105, 105, 2, 0,
+		74, 74, 106, 106, 2, 0, 75, 75, 107, 107, 2, 0, 76, 76, 108, 108, 2, 0,
+		77, 77, 109, 109, 2, 0, 78, 78, 110, 110, 2, 0, 79, 79, 111, 111, 2, 0,
+		80, 80, 112, 112, 2, 0, 81, 81, 113, 113, 2, 0, 82, 82, 114, 114, 2, 0,
+		83, 83, 115, 115, 2, 0, 84, 84, 116, 116, 2, 0, 85, 85, 117, 117, 2, 0,
+		86, 86, 118, 118, 2, 0, 87, 87, 119, 119, 2, 0, 88, 88, 120, 120, 2, 0,
+		89, 89, 121, 121, 2, 0, 90, 90, 122, 122, 1215, 0, 1, 1, 0, 0, 0, 0, 3, 1,
+		0, 0, 0, 0, 5, 1, 0, 0, 0, 0, 7, 1, 0, 0, 0, 0, 19, 1, 0, 0, 0, 0, 21, 1,
+		0, 0, 0, 0, 23, 1, 0, 0, 0, 0, 25, 1, 0, 0, 0, 0, 27, 1, 0, 0, 0, 0, 29,
+		1, 0, 0, 0, 0, 31, 1, 0, 0, 0, 0, 33, 1, 0, 0, 0, 0, 35, 1, 0, 0, 0, 0,
+		37, 1, 0, 0, 0, 0, 39, 1, 0, 0, 0, 0, 41, 1, 0, 0, 0, 0, 43, 1, 0, 0, 0,
+		0, 45, 1, 0, 0, 0, 0, 47, 1, 0, 0, 0, 0, 49, 1, 0, 0, 0, 0, 51, 1, 0, 0,
+		0, 0, 53, 1, 0, 0, 0, 0, 55, 1, 0, 0, 0, 0, 57, 1, 0, 0, 0, 0, 59, 1, 0,
+		0, 0, 0, 61, 1, 0, 0, 0, 0, 63, 1, 0, 0, 0, 0, 65, 1, 0, 0, 0, 0, 67, 1,
+		0, 0, 0, 0, 69, 1, 0, 0, 0, 0, 71, 1, 0, 0, 0, 0, 73, 1, 0, 0, 0, 0, 75,
+		1, 0, 0, 0, 0, 77, 1, 0, 0, 0, 0, 79, 1, 0, 0, 0, 0, 81, 1, 0, 0, 0, 0,
+		83, 1, 0, 0, 0, 0, 85, 1, 0, 0, 0, 0, 87, 1, 0, 0, 0, 0, 89, 1, 0, 0, 0,
+		0, 91, 1, 0, 0, 0, 0, 93, 1, 0, 0, 0, 0, 95, 1, 0, 0, 0, 0, 97, 1, 0, 0,
+		0, 0, 99, 1, 0, 0, 0, 0, 101, 1, 0, 0, 0, 0, 103, 1, 0, 0, 0, 0, 105, 1,
+		0, 0, 0, 0, 107, 1, 0, 0, 0, 0, 109, 1, 0, 0, 0, 0, 111, 1, 0, 0, 0, 0,
+		113, 1, 0, 0, 0, 0, 115, 1, 0, 0, 0, 0, 117, 1, 0, 0, 0, 0, 119, 1, 0, 0,
+		0, 0, 121, 1, 0, 0, 0, 0, 123, 1, 0, 0, 0, 0, 125, 1, 0, 0, 0, 0, 127, 1,
+		0, 0, 0, 0, 129, 1, 0, 0, 0, 0, 131, 1, 0, 0, 0, 0, 133, 1, 0, 0, 0, 0,
+		135, 1, 0, 0, 0, 0, 137, 1, 0, 0, 0, 0, 139, 1, 0, 0, 0, 0, 141, 1, 0, 0,
+		0, 0, 143, 1, 0, 0, 0, 0, 145, 1, 0, 0, 0, 0, 147, 1, 0, 0, 0, 0, 149, 1,
+		0, 0, 0, 0, 151, 1, 0, 0, 0, 0, 153, 1, 0, 0, 0, 0, 155, 1, 0, 0, 0, 0,
+		157, 1, 0, 0, 0, 0, 159, 1, 0, 0, 0, 0, 161, 1, 0, 0, 0, 0, 163, 1, 0, 0,
+		0, 0, 165, 1, 0, 0, 0, 0, 167, 1, 0, 0, 0, 0, 169, 1, 0, 0, 0, 0, 171, 1,
+		0, 0, 0, 0, 173, 1, 0, 0, 0, 0, 175, 1, 0, 0, 0, 0, 177, 1, 0, 0, 0, 0,
+		179, 1, 0, 0, 0, 0, 181, 1, 0, 0, 0, 0, 183, 1, 0, 0, 0, 0, 185, 1, 0, 0,
+		0, 0, 187, 1, 0, 0, 0, 0, 189, 1, 0, 0, 0, 0, 191, 1, 0, 0, 0, 0, 193, 1,
+		0, 0, 0, 0, 195, 1, 0, 0, 0, 0, 197, 1, 0, 0, 0, 0, 199, 1, 0, 0, 0, 0,
+		201, 1, 0, 0, 0, 0, 203, 1, 0, 0, 0, 0, 205, 1, 0, 0, 0, 0, 207, 1, 0, 0,
+		0, 0, 209, 1, 0, 0, 0, 0, 211, 1, 0, 0, 0, 0, 213, 1, 0, 0, 0, 0, 215, 1,
+		0, 0, 0, 0, 217, 1, 0, 0, 0, 0, 219, 1, 0, 0, 0, 0, 221, 1, 0, 0, 0, 0,
+		223, 1, 0, 0, 0, 0, 225, 1, 0, 0, 0, 0, 227, 1, 0, 0, 0, 0, 229, 1, 0, 0,
+		0, 0, 231, 1, 0, 0, 0, 0, 233, 1, 0, 0, 0, 0, 235, 1, 0, 0, 0, 0, 237, 1,
+		0, 0, 0, 0, 239, 1, 0, 0, 0, 0, 241, 1, 0, 0, 0, 0, 243, 1, 0, 0, 0, 0,
+		245, 1, 0, 0, 0, 0, 247, 1, 0, 0, 0, 0, 249, 1, 0, 0, 0, 0, 251, 1, 0, 0,
+		0, 0, 253, 1, 0, 0, 0, 0, 255, 1, 0, 0, 0, 0, 257, 1, 0, 0, 0, 0, 259, 1,
+		0, 0, 0, 0, 261, 1, 0, 0, 0, 0, 263, 1, 0, 0, 0, 0, 1160, 1, 0, 0, 0, 0,
+		1169, 1, 0, 0, 0, 0, 1177, 1, 0, 0, 0, 0, 1183, 1, 0, 0, 0, 0, 1192, 1, 0,
+		0, 0, 0, 1197, 1, 0, 0, 0, 0, 1205, 1, 0, 0, 0, 0, 1214, 1, 0, 0, 0, 0,
+		265, 1, 0, 0, 0, 0, 267, 1, 0, 0, 0, 0, 269, 1, 0, 0, 0, 1, 329, 1, 0, 0,
+		0, 3, 334, 1, 0, 0, 0, 5, 340, 1, 0, 0, 0, 7, 345, 1, 0, 0, 0, 9, 355, 1,
+		0, 0, 0, 11, 360, 1, 0, 0, 0, 13, 366, 1, 0, 0, 0, 15, 368, 1, 0, 0, 0,
+		17, 370, 1, 0, 0, 0, 19, 377, 1, 0, 0, 0, 21, 383, 1, 0, 0, 0, 23, 390, 1,
+		0, 0, 0, 25, 397, 1, 0, 0, 0, 27, 401, 1, 0, 0, 0, 29, 406, 1, 0, 0, 0,
+		31, 415, 1, 0, 0, 0, 33, 420, 1, 0, 0, 0, 35, 426, 1, 0, 0, 0, 37, 438, 1,
+		0, 0, 0, 39, 445, 1, 0, 0, 0, 41, 449, 1, 0, 0, 0, 43, 457, 1, 0, 0, 0,
+		45, 465, 1, 0, 0, 0, 47, 475, 1, 0, 0, 0, 49, 480, 1, 0, 0, 0, 51, 483, 1,
+		0, 0, 0, 53, 488, 1, 0, 0, 0, 55, 496, 1, 0, 0, 0, 57, 500, 1, 0, 0, 0,
+		59, 511, 1, 0, 0, 0, 61, 525, 1, 0, 0, 0, 63, 532, 1, 0, 0, 0, 65, 541, 1,
+		0, 0, 0, 67, 547, 1, 0, 0, 0, 69, 552, 1, 0, 0, 0, 71, 561, 1, 0, 0, 0,
+		73, 569, 1, 0, 0, 0, 75, 576, 1, 0, 0, 0, 77, 581, 1, 0, 0, 0, 79, 589, 1,
+		0, 0, 0, 81, 595, 1, 0, 0, 0, 83, 603, 1, 0, 0, 0, 85, 612, 1, 0, 0, 0,
+		87, 622, 1, 0, 0, 0, 89, 632, 1, 0, 0, 0, 91, 643, 1, 0, 0, 0, 93, 648, 1,
+		0, 0, 0, 95, 656, 1, 0, 0, 0, 97, 663, 1, 0, 0, 0, 99, 669, 1, 0, 0, 0,
+		101, 676, 1, 0, 0, 0, 103, 680, 1, 0, 0, 0, 105, 685, 1, 0, 0, 0, 107,
+		690, 1, 0, 0, 0, 109, 694, 1, 0, 0, 0, 111, 699, 1, 0, 0, 0, 113, 706, 1,
+		0, 0, 0, 115, 712, 1, 0, 0, 0, 117, 717, 1, 0, 0, 0, 119, 723, 1, 0, 0, 0,
+		121, 729, 1, 0, 0, 0, 123, 737, 1, 0, 0, 0, 125, 743, 1, 0, 0, 0, 127,
+		751, 1, 0, 0, 0, 129, 761, 1, 0, 0, 0, 131, 768, 1, 0, 0, 0, 133, 771, 1,
+		0, 0, 0, 135, 775, 1, 0, 0, 0, 137, 778, 1, 0, 0, 0, 139, 783, 1, 0, 0, 0,
+		141, 788, 1, 0, 0, 0, 143, 797, 1, 0, 0, 0, 145, 803, 1, 0, 0, 0, 147,
+		807, 1, 0, 0, 0, 149, 812, 1, 0, 0, 0, 151, 817, 1, 0, 0, 0, 153, 821, 1,
+		0, 0, 0, 155, 829, 1, 0, 0, 0, 157, 832, 1, 0, 0, 0, 159, 838, 1, 0, 0, 0,
+		161, 845, 1, 0, 0, 0, 163, 848, 1, 0, 0, 0, 165, 852, 1, 0, 0, 0, 167,
+		858, 1, 0, 0, 0, 169, 863, 1, 0, 0, 0, 171, 867, 1, 0, 0, 0, 173, 870, 1,
+		0, 0, 0, 175, 874, 1, 0, 0, 0, 177, 882, 1, 0, 0, 0, 179, 891, 1, 0, 0, 0,
+		181, 899, 1, 0, 0, 0, 183, 902, 1, 0, 0, 0, 185, 906, 1, 0, 0, 0, 187,
+		910, 1, 0, 0, 0, 189, 914, 1, 0, 0, 0, 191, 920, 1, 0, 0, 0, 193, 925, 1,
+		0, 0, 0, 195, 931, 1, 0, 0, 0, 197, 935, 1, 0, 0, 0, 199, 942, 1, 0, 0, 0,
+		201, 951, 1, 0, 0, 0, 203, 956, 1, 0, 0, 0, 205, 958, 1, 0, 0, 0, 207,
+		960, 1, 0, 0, 0, 209, 962, 1, 0, 0, 0, 211, 964, 1, 0, 0, 0, 213, 966, 1,
+		0, 0, 0, 215, 968, 1, 0, 0, 0, 217, 970, 1, 0, 0, 0, 219, 972, 1, 0, 0, 0,
+		221, 974, 1, 0, 0, 0, 223, 976, 1, 0, 0, 0, 225, 979, 1, 0, 0, 0, 227,
+		982, 1, 0, 0, 0, 229, 984, 1, 0, 0, 0, 231, 987, 1, 0, 0, 0, 233, 989, 1,
+		0, 0, 0, 235, 992, 1, 0, 0, 0, 237, 995, 1, 0, 0, 0, 239, 998, 1, 0, 0, 0,
+		241, 1000, 1, 0, 0, 0, 243, 1002, 1, 0, 0, 0, 245, 1004, 1, 0, 0, 0, 247,
+		1006, 1, 0, 0, 0, 249, 1008, 1, 0, 0, 0, 251, 1010, 1, 0, 0, 0, 253, 1012,
+		1, 0, 0, 0, 255, 1014, 1, 0, 0, 0, 257, 1016, 1, 0, 0, 0, 259, 1018, 1, 0,
+		0, 0, 261, 1020, 1, 0, 0, 0, 263, 1022, 1, 0, 0, 0, 265, 1024, 1, 0, 0, 0,
 		267, 1027, 1, 0, 0, 0, 269, 1050, 1, 0, 0, 0, 271, 1052, 1, 0, 0, 0, 273,
 		1054, 1, 0, 0, 0, 275, 1106, 1, 0, 0, 0, 277, 1108, 1, 0, 0, 0, 279, 1110,
-		1, 0, 0, 0, 281, 1112, 1, 0, 0, 0, 283, 1114, 1, 0, 0, 0, 285, 1116, 1,
-		0, 0, 0, 287, 1118, 1, 0, 0, 0, 289, 1120, 1, 0, 0, 0, 291, 1122, 1, 0,
-		0, 0, 293, 1124, 1, 0, 0, 0, 295, 1126, 1, 0, 0, 0, 297, 1128, 1, 0, 0,
-		0, 299, 1130, 1, 0, 0, 0, 301, 1132, 1, 0, 0, 0, 303, 1134, 1, 0, 0, 0,
-		305, 1136, 1, 0, 0, 0, 307, 1138, 1, 0, 0, 0, 309, 1140, 1, 0, 0, 0, 311,
-		1142, 1, 0, 0, 0, 313, 1144, 1, 0, 0, 0, 315, 1146, 1, 0, 0, 0, 317, 1148,
-		1, 0, 0, 0, 319, 1150, 1, 0, 0, 0, 321, 1152, 1, 0, 0, 0, 323, 1154, 1,
-		0, 0, 0, 325, 1156, 1, 0, 0, 0, 327, 1158, 1, 0, 0, 0, 329, 330, 5, 116,
-		0, 0, 330, 331, 5, 114, 0, 0, 331, 332, 5, 117, 0, 0, 332, 333, 5, 101,
-		0, 0, 333, 2, 1, 0, 0, 0, 334, 335, 5, 102, 0, 0, 335, 336, 5, 97, 0, 0,
-		336, 337, 5, 108, 0, 0, 337, 338, 5, 115, 0, 0, 338, 339, 5, 101, 0, 0,
-		339, 4, 1, 0, 0, 0, 340, 341, 5, 110, 0, 0, 341, 342, 5, 117, 0, 0, 342,
-		343, 5, 108, 0, 0, 343, 344, 5, 108, 0, 0, 344, 6, 1, 0, 0, 0, 345, 350,
-		5, 34, 0, 0, 346, 349, 3, 9, 4, 0, 347, 349, 3, 15, 7, 0, 348, 346, 1,
-		0, 0, 0, 348, 347, 1, 0, 0, 0, 349, 352, 1, 0, 0, 0, 350, 348, 1, 0, 0,
-		0, 350, 351, 1, 0, 0, 0, 351, 353, 1, 0, 0, 0, 352, 350, 1, 0, 0, 0, 353,
-		354, 5, 34, 0, 0, 354, 8, 1, 0, 0, 0, 355, 358, 5, 92, 0, 0, 356, 359,
-		7, 0, 0, 0, 357, 359, 3, 11, 5, 0, 358, 356, 1, 0, 0, 0, 358, 357, 1, 0,
-		0, 0, 359, 10, 1, 0, 0, 0, 360, 361, 5, 117, 0, 0, 361, 362, 3, 13, 6,
-		0, 362, 363, 3, 13, 6, 0, 363, 364, 3, 13, 6, 0, 364, 365, 3, 13, 6, 0,
-		365, 12, 1, 0, 0, 0, 366, 367, 7, 1, 0, 0, 367, 14, 1, 0, 0, 0, 368, 369,
-		8, 2, 0, 0, 369, 16, 1, 0, 0, 0, 370, 372, 7, 3, 0, 0, 371, 373, 7, 4,
-		0, 0, 372, 371, 1, 0, 0, 0, 372, 373, 1, 0, 0, 0, 373, 374, 1, 0, 0, 0,
-		374, 375, 3, 267, 133, 0, 375, 18, 1, 0, 0, 0, 376, 378, 7, 5, 0, 0, 377,
-		376, 1, 0, 0, 0, 378, 379, 1, 0, 0, 0, 379, 377, 1, 0, 0, 0, 379, 380,
-		1, 0, 0, 0, 380, 381, 1, 0, 0, 0, 381, 382, 6, 9, 0, 0, 382, 20, 1, 0,
-		0, 0, 383, 384, 3, 281, 140, 0, 384, 385, 3, 311, 155, 0, 385, 386, 3,
-		285, 142, 0, 386, 387, 3, 277, 138, 0, 387, 388, 3, 315, 157, 0, 388, 389,
-		3, 285, 142, 0, 389, 22, 1, 0, 0, 0, 390, 391, 3, 317, 158, 0, 391, 392,
-		3, 307, 153, 0, 392, 393, 3, 283, 141, 0, 393, 394, 3, 277, 138, 0, 394,
-		395, 3, 315, 157, 0, 395, 396, 3, 285, 142, 0, 396, 24, 1, 0, 0, 0, 397,
-		398, 3, 313, 156, 0, 398, 399, 3, 285, 142, 0, 399, 400, 3, 315, 157, 0,
-		400, 26, 1, 0, 0, 0, 401, 402, 3, 283, 141, 0, 402, 403, 3, 311, 155, 0,
-		403, 404, 3, 305, 152, 0, 404, 405, 3, 307, 153, 0, 405, 28, 1, 0, 0, 0,
-		406, 407, 3, 293, 146, 0, 407, 408, 3, 303, 151, 0, 408, 409, 3, 315, 157,
-		0, 409, 410, 3, 285, 142, 0, 410, 411, 3, 311, 155, 0, 411, 412, 3, 319,
-		159, 0, 412, 413, 3, 277, 138, 0, 413, 414, 3, 299, 149, 0, 414, 30, 1,
-		0, 0, 0, 415, 416, 3, 303, 151, 0, 416, 417, 3, 277, 138, 0, 417, 418,
-		3, 301, 150, 0, 418, 419, 3, 285, 142, 0, 419, 32, 1, 0, 0, 0, 420, 421,
-		3, 313, 156, 0, 421, 422, 3, 291, 145, 0, 422, 423, 3, 277, 138, 0, 423,
-		424, 3, 311, 155, 0, 424, 425, 3, 283, 141, 0, 425, 34, 1, 0, 0, 0, 426,
-		427, 3, 311, 155, 0, 427, 428, 3, 285, 142, 0, 428, 429, 3, 307, 153, 0,
-		429, 430, 3, 299, 149, 0, 430, 431, 3, 293, 146, 0, 431, 432, 3, 281, 140,
-		0, 432, 433, 3, 277, 138, 0, 433, 434, 3, 315, 157, 0, 434, 435, 3, 293,
-		146, 0, 435, 436, 3, 305, 152, 0, 436, 437, 3, 303, 151, 0, 437, 36, 1,
-		0, 0, 0, 438, 439, 3, 301, 150, 0, 439, 440, 3, 285, 142, 0, 440, 441,
-		3, 301, 150, 0, 441, 442, 3, 305, 152, 0, 442, 443, 3, 311, 155, 0, 443,
-		444, 3, 325, 162, 0, 444, 38, 1, 0, 0, 0, 445, 446, 3, 315, 157, 0, 446,
-		447, 3, 315, 157, 0, 447, 448, 3, 299, 149, 0, 448, 40, 1, 0, 0, 0, 449,
-		450, 3, 301, 150, 0, 450, 451, 3, 285, 142, 0, 451, 452, 3, 315, 157, 0,
-		452, 453, 3, 277, 138, 0, 453, 454, 3, 315, 157, 0, 454, 455, 3, 315, 157,
-		0, 455, 456, 3, 299, 149, 0, 456, 42, 1, 0, 0, 0, 457, 458, 3, 307, 153,
-		0, 458, 459, 3, 277, 138, 0, 459, 460, 3, 313, 156, 0, 460, 461, 3, 315,
-		157, 0, 461, 462, 3, 315, 157, 0, 462, 463, 3, 315, 157, 0, 463, 464, 3,
-		299, 149, 0, 464, 44, 1, 0, 0, 0, 465, 466, 3, 287, 143, 0, 466, 467, 3,
-		317, 158, 0, 467, 468, 3, 315, 157, 0, 468, 469, 3, 317, 158, 0, 469, 470,
-		3, 311, 155, 0, 470, 471, 3, 285, 142, 0, 471, 472, 3, 315, 157, 0, 472,
-		473, 3, 315, 157, 0, 473, 474, 3, 299, 149, 0, 474, 46, 1, 0, 0, 0, 475,
-		476, 3, 297, 148, 0, 476, 477, 3, 293, 146, 0, 477, 478, 3, 299, 149, 0,
-		478, 479, 3, 299, 149, 0, 479, 48, 1, 0, 0, 0, 480, 481, 3, 305, 152, 0,
-		481, 482, 3, 303, 151, 0, 482, 50, 1, 0, 0, 0, 483, 484, 3, 313, 156, 0,
-		484, 485, 3, 291, 145, 0, 485, 486, 3, 305, 152, 0, 486, 487, 3, 321, 160,
-		0, 487, 52, 1, 0, 0, 0, 488, 489, 3, 311, 155, 0, 489, 490, 3, 285, 142,
-		0, 490, 491, 3, 281, 140, 0, 491, 492, 3, 305, 152, 0, 492, 493, 3, 319,
-		159, 0, 493, 494, 3, 285, 142, 0, 494, 495, 3, 311, 155, 0, 495, 54, 1,
-		0, 0, 0, 496, 497, 3, 317, 158, 0, 497, 498, 3, 313, 156, 0, 498, 499,
-		3, 285, 142, 0, 499, 56, 1, 0, 0, 0, 500, 501, 3, 313, 156, 0, 501, 502,
-		3, 315, 157, 0, 502, 503, 3, 277, 138, 0, 503, 504, 3, 315, 157, 0, 504,
-		505, 3, 285, 142, 0, 505, 506, 3, 263, 131, 0, 506, 507, 3, 311, 155, 0,
-		507, 508, 3, 285, 142, 0, 508, 509, 3, 307, 153, 0, 509, 510, 3, 305, 152,
-		0, 510, 58, 1, 0, 0, 0, 511, 512, 3, 313, 156, 0, 512, 513, 3, 315, 157,
-		0, 513, 514, 3, 277, 138, 0, 514, 515, 3, 315, 157, 0, 515, 516, 3, 285,
-		142, 0, 516, 517, 3, 263, 131, 0, 517, 518, 3, 301, 150, 0, 518, 519, 3,
-		277, 138, 0, 519, 520, 3, 281, 140, 0, 520, 521, 3, 291, 145, 0, 521, 522,
-		3, 293, 146, 0, 522, 523, 3, 303, 151, 0, 523, 524, 3, 285, 142, 0, 524,
-		60, 1, 0, 0, 0, 525, 526, 3, 301, 150, 0, 526, 527, 3, 277, 138, 0, 527,
-		528, 3, 313, 156, 0, 528, 529, 3, 315, 157, 0, 529, 530, 3, 285, 142, 0,
-		530, 531, 3, 311, 155, 0, 531, 62, 1, 0, 0, 0, 532, 533, 3, 301, 150, 0,
-		533, 534, 3, 285, 142, 0, 534, 535, 3, 315, 157, 0, 535, 536, 3, 277, 138,
-		0, 536, 537, 3, 283, 141, 0, 537, 538, 3, 277, 138, 0, 538, 539, 3, 315,
-		157, 0, 539, 540, 3, 277, 138, 0, 540, 64, 1, 0, 0, 0, 541, 542, 3, 315,
-		157, 0, 542, 543, 3, 325, 162, 0, 543, 544, 3, 307, 153, 0, 544, 545, 3,
-		285, 142, 0, 545, 546, 3, 313, 156, 0, 546, 66, 1, 0, 0, 0, 547, 548, 3,
-		315, 157, 0, 548, 549, 3, 325, 162, 0, 549, 550, 3, 307, 153, 0, 550, 551,
-		3, 285, 142, 0, 551, 68, 1, 0, 0, 0, 552, 553, 3, 313, 156, 0, 553, 554,
-		3, 315, 157, 0, 554, 555, 3, 305, 152, 0, 555, 556, 3, 311, 155, 0, 556,
-		557, 3, 277, 138, 0, 557, 558, 3, 289, 144, 0, 558, 559, 3, 285, 142, 0,
-		559, 560, 3, 313, 156, 0, 560, 70, 1, 0, 0, 0, 561, 562, 3, 313, 156, 0,
-		562, 563, 3, 315, 157, 0, 563, 564, 3, 305, 152, 0, 564, 565, 3, 311, 155,
-		0, 565, 566, 3, 277, 138, 0, 566, 567, 3, 289, 144, 0, 567, 568, 3, 285,
-		142, 0, 568, 72, 1, 0, 0, 0, 569, 570, 3, 279, 139, 0, 570, 571, 3, 311,
-		155, 0, 571, 572, 3, 305, 152, 0, 572, 573, 3, 297, 148, 0, 573, 574, 3,
-		285, 142, 0, 574, 575, 3, 311, 155, 0, 575, 74, 1, 0, 0, 0, 576, 577, 3,
-		311, 155, 0, 577, 578, 3, 305, 152, 0, 578, 579, 3, 305, 152, 0, 579, 580,
-		3, 315, 157, 0, 580, 76, 1, 0, 0, 0, 581, 582, 3, 279, 139, 0, 582, 583,
-		3, 311, 155, 0, 583, 584, 3, 305, 152, 0, 584, 585, 3, 297, 148, 0, 585,
-		586, 3, 285, 142, 0, 586, 587, 3, 311, 155, 0, 587, 588, 3, 313, 156, 0,
-		588, 78, 1, 0, 0, 0, 589, 590, 3, 277, 138, 0, 590, 591, 3, 299, 149, 0,
-		591, 592, 3, 293, 146, 0, 592, 593, 3, 319, 159, 0, 593, 594, 3, 285, 142,
-		0, 594, 80, 1, 0, 0, 0, 595, 596, 3, 313, 156, 0, 596, 597, 3, 281, 140,
-		0, 597, 598, 3, 291, 145, 0, 598, 599, 3, 285, 142, 0, 599, 600, 3, 301,
-		150, 0, 600, 601, 3, 277, 138, 0, 601, 602, 3, 313, 156, 0, 602, 82, 1,
-		0, 0, 0, 603, 604, 3, 283, 141, 0, 604, 605, 3, 277, 138, 0, 605, 606,
-		3, 315, 157, 0, 606, 607, 3, 277, 138, 0, 607, 608, 3, 279, 139, 0, 608,
-		609, 3, 277, 138, 0, 609, 610, 3, 313, 156, 0, 610, 611, 3, 285, 142, 0,
-		611, 84, 1, 0, 0, 0, 612, 613, 3, 283, 141, 0, 613, 614, 3, 277, 138, 0,
-		614, 615, 3, 315, 157, 0, 615, 616, 3, 277, 138, 0, 616, 617, 3, 279, 139,
-		0, 617, 618, 3, 277, 138, 0, 618, 619, 3, 313, 156, 0, 619, 620, 3, 285,
-		142, 0, 620, 621, 3, 313, 156, 0, 621, 86, 1, 0, 0, 0, 622, 623, 3, 303,
-		151, 0, 623, 624, 3, 277, 138, 0, 624, 625, 3, 301, 150, 0, 625, 626, 3,
-		285, 142, 0, 626, 627, 3, 313, 156, 0, 627, 628, 3, 307, 153, 0, 628, 629,
-		3, 277, 138, 0, 629, 630, 3, 281, 140, 0, 630, 631, 3, 285, 142, 0, 631,
-		88, 1, 0, 0, 0, 632, 633, 3, 303, 151, 0, 633, 634, 3, 277, 138, 0, 634,
-		635, 3, 301, 150, 0, 635, 636, 3, 285, 142, 0, 636, 637, 3, 313, 156, 0,
-		637, 638, 3, 307, 153, 0, 638, 639, 3, 277, 138, 0, 639, 640, 3, 281, 140,
-		0, 640, 641, 3, 285, 142, 0, 641, 642, 3, 313, 156, 0, 642, 90, 1, 0, 0,
-		0, 643, 644, 3, 303, 151, 0, 644, 645, 3, 305, 152, 0, 645, 646, 3, 283,
-		141, 0, 646, 647, 3, 285, 142, 0, 647, 92, 1, 0, 0, 0, 648, 649, 3, 301,
-		150, 0, 649, 650, 3, 285, 142, 0, 650, 651, 3, 315, 157, 0, 651, 652, 3,
-		311, 155, 0, 652, 653, 3, 293, 146, 0, 653, 654, 3, 281, 140, 0, 654, 655,
-		3, 313, 156, 0, 655, 94, 1, 0, 0, 0, 656, 657, 3, 301, 150, 0, 657, 658,
-		3, 285, 142, 0, 658, 659, 3, 315, 157, 0, 659, 660, 3, 311, 155, 0, 660,
-		661, 3, 293, 146, 0, 661, 662, 3, 281, 140, 0, 662, 96, 1, 0, 0, 0, 663,
-		664, 3, 287, 143, 0, 664, 665, 3, 293, 146, 0, 665, 666, 3, 285, 142, 0,
-		666, 667, 3, 299, 149, 0, 667, 668, 3, 283, 141, 0, 668, 98, 1, 0, 0, 0,
-		669, 670, 3, 287, 143, 0, 670, 671, 3, 293, 146, 0, 671, 672, 3, 285, 142,
-		0, 672, 673, 3, 299, 149, 0, 673, 674, 3, 283, 141, 0, 674, 675, 3, 313,
-		156, 0, 675, 100, 1, 0, 0, 0, 676, 677, 3, 315, 157, 0, 677, 678, 3, 277,
-		138, 0, 678, 679, 3, 289, 144, 0, 679, 102, 1, 0, 0, 0, 680, 681, 3, 293,
-		146, 0, 681, 682, 3, 303, 151, 0, 682, 683, 3, 287, 143, 0, 683, 684, 3,
-		305, 152, 0, 684, 104, 1, 0, 0, 0, 685, 686, 3, 297, 148, 0, 686, 687,
-		3, 285, 142, 0, 687, 688, 3, 325, 162, 0, 688, 689, 3, 313, 156, 0, 689,
-		106, 1, 0, 0, 0, 690, 691, 3, 297, 148, 0, 691, 692, 3, 285, 142, 0, 692,
-		693, 3, 325, 162, 0, 693, 108, 1, 0, 0, 0, 694, 695, 3, 321, 160, 0, 695,
-		696, 3, 293, 146, 0, 696, 697, 3, 315, 157, 0, 697, 698, 3, 291, 145, 0,
-		698, 110, 1, 0, 0, 0, 699, 700, 3, 319, 159, 0, 700, 701, 3, 277, 138,
-		0, 701, 702, 3, 299, 149, 0, 702, 703, 3, 317, 158, 0, 703, 704, 3, 285,
-		142, 0, 704, 705, 3, 313, 156, 0, 705, 112, 1, 0, 0, 0, 706, 707, 3, 319,
-		159, 0, 707, 708, 3, 277, 138, 0, 708, 709, 3, 299, 149, 0, 709, 710, 3,
-		317, 158, 0, 710, 711, 3, 285, 142, 0, 711, 114, 1, 0, 0, 0, 712, 713,
+		1, 0, 0, 0, 281, 1112, 1, 0, 0, 0, 283, 1114, 1, 0, 0, 0, 285, 1116, 1, 0,
+		0, 0, 287, 1118, 1, 0, 0, 0, 289, 1120, 1, 0, 0, 0, 291, 1122, 1, 0, 0, 0,
+		293, 1124, 1, 0, 0, 0, 295, 1126, 1, 0, 0, 0, 297, 1128, 1, 0, 0, 0, 299,
+		1130, 1, 0, 0, 0, 301, 1132, 1, 0, 0, 0, 303, 1134, 1, 0, 0, 0, 305, 1136,
+		1, 0, 0, 0, 307, 1138, 1, 0, 0, 0, 309, 1140, 1, 0, 0, 0, 311, 1142, 1, 0,
+		0, 0, 313, 1144, 1, 0, 0, 0, 315, 1146, 1, 0, 0, 0, 317, 1148, 1, 0, 0, 0,
+		319, 1150, 1, 0, 0, 0, 321, 1152, 1, 0, 0, 0, 323, 1154, 1, 0, 0, 0, 325,
+		1156, 1, 0, 0, 0, 327, 1158, 1, 0, 0, 0, 329, 330, 5, 116, 0, 0, 330, 331,
+		5, 114, 0, 0, 331, 332, 5, 117, 0, 0, 332, 333, 5, 101, 0, 0, 333, 2, 1,
+		0, 0, 0, 334, 335, 5, 102, 0, 0, 335, 336, 5, 97, 0, 0, 336, 337, 5, 108,
+		0, 0, 337, 338, 5, 115, 0, 0, 338, 339, 5, 101, 0, 0, 339, 4, 1, 0, 0, 0,
+		340, 341, 5, 110, 0, 0, 341, 342, 5, 117, 0, 0, 342, 343, 5, 108, 0, 0,
+		343, 344, 5, 108, 0, 0, 344, 6, 1, 0, 0, 0, 345, 350, 5, 34, 0, 0, 346,
+		349, 3, 9, 4, 0, 347, 349, 3, 15, 7, 0, 348, 346, 1, 0, 0, 0, 348, 347, 1,
+		0, 0, 0, 349, 352, 1, 0, 0, 0, 350, 348, 1, 0, 0, 0, 350, 351, 1, 0, 0, 0,
+		351, 353, 1, 0, 0, 0, 352, 350, 1, 0, 0, 0, 353, 354, 5, 34, 0, 0, 354, 8,
+		1, 0, 0, 0, 355, 358, 5, 92, 0, 0, 356, 359, 7, 0, 0, 0, 357, 359, 3, 11,
+		5, 0, 358, 356, 1, 0, 0, 0, 358, 357, 1, 0, 0, 0, 359, 10, 1, 0, 0, 0,
+		360, 361, 5, 117, 0, 0, 361, 362, 3, 13, 6, 0, 362, 363, 3, 13, 6, 0, 363,
+		364, 3, 13, 6, 0, 364, 365, 3, 13, 6, 0, 365, 12, 1, 0, 0, 0, 366, 367, 7,
+		1, 0, 0, 367, 14, 1, 0, 0, 0, 368, 369, 8, 2, 0, 0, 369, 16, 1, 0, 0, 0,
+		370, 372, 7, 3, 0, 0, 371, 373, 7, 4, 0, 0, 372, 371, 1, 0, 0, 0, 372,
+		373, 1, 0, 0, 0, 373, 374, 1, 0, 0, 0, 374, 375, 3, 267, 133, 0, 375, 18,
+		1, 0, 0, 0, 376, 378, 7, 5, 0, 0, 377, 376, 1, 0, 0, 0, 378, 379, 1, 0, 0,
+		0, 379, 377, 1, 0, 0, 0, 379, 380, 1, 0, 0, 0, 380, 381, 1, 0, 0, 0, 381,
+		382, 6, 9, 0, 0, 382, 20, 1, 0, 0, 0, 383, 384, 3, 281, 140, 0, 384, 385,
+		3, 311, 155, 0, 385, 386, 3, 285, 142, 0, 386, 387, 3, 277, 138, 0, 387,
+		388, 3, 315, 157, 0, 388, 389, 3, 285, 142, 0, 389, 22, 1, 0, 0, 0, 390,
+		391, 3, 317, 158, 0, 391, 392, 3, 307, 153, 0, 392, 393, 3, 283, 141, 0,
+		393, 394, 3, 277, 138, 0, 394, 395, 3, 315, 157, 0, 395, 396, 3, 285, 142,
+		0, 396, 24, 1, 0, 0, 0, 397, 398, 3, 313, 156, 0, 398, 399, 3, 285, 142,
+		0, 399, 400, 3, 315, 157, 0, 400, 26, 1, 0, 0, 0, 401, 402, 3, 283, 141,
+		0, 402, 403, 3, 311, 155, 0, 403, 404, 3, 305, 152, 0, 404, 405, 3, 307,
+		153, 0, 405, 28, 1, 0, 0, 0, 406, 407, 3, 293, 146, 0, 407, 408, 3, 303,
+		151, 0, 408, 409, 3, 315, 157, 0, 409, 410, 3, 285, 142, 0, 410, 411, 3,
+		311, 155, 0, 411, 412, 3, 319, 159, 0, 412, 413, 3, 277, 138, 0, 413, 414,
+		3, 299, 149, 0, 414, 30, 1, 0, 0, 0, 415, 416, 3, 303, 151, 0, 416, 417,
+		3, 277, 138, 0, 417, 418, 3, 301, 150, 0, 418, 419, 3, 285, 142, 0, 419,
+		32, 1, 0, 0, 0, 420, 421, 3, 313, 156, 0, 421, 422, 3, 291, 145, 0, 422,
+		423, 3, 277, 138, 0, 423, 424, 3, 311, 155, 0, 424, 425, 3, 283, 141, 0,
+		425, 34, 1, 0, 0, 0, 426, 427, 3, 311, 155, 0, 427, 428, 3, 285, 142, 0,
+		428, 429, 3, 307, 153, 0, 429, 430, 3, 299, 149, 0, 430, 431, 3, 293, 146,
+		0, 431, 432, 3, 281, 140, 0, 432, 433, 3, 277, 138, 0, 433, 434, 3, 315,
+		157, 0, 434, 435, 3, 293, 146, 0, 435, 436, 3, 305, 152, 0, 436, 437, 3,
+		303, 151, 0, 437, 36, 1, 0, 0, 0, 438, 439, 3, 301, 150, 0, 439, 440, 3,
+		285, 142, 0, 440, 441, 3, 301, 150, 0, 441, 442, 3, 305, 152, 0, 442, 443,
+		3, 311, 155, 0, 443, 444, 3, 325, 162, 0, 444, 38, 1, 0, 0, 0, 445, 446,
+		3, 315, 157, 0, 446, 447, 3, 315, 157, 0, 447, 448, 3, 299, 149, 0, 448,
+		40, 1, 0, 0, 0, 449, 450, 3, 301, 150, 0, 450, 451, 3, 285, 142, 0, 451,
+		452, 3, 315, 157, 0, 452, 453, 3, 277, 138, 0, 453, 454, 3, 315, 157, 0,
+		454, 455, 3, 315, 157, 0, 455, 456, 3, 299, 149, 0, 456, 42, 1, 0, 0, 0,
+		457, 458, 3, 307, 153, 0, 458, 459, 3, 277, 138, 0, 459, 460, 3, 313, 156,
+		0, 460, 461, 3, 315, 157, 0, 461, 462, 3, 315, 157, 0, 462, 463, 3, 315,
+		157, 0, 463, 464, 3, 299, 149, 0, 464, 44, 1, 0, 0, 0, 465, 466, 3, 287,
+		143, 0, 466, 467, 3, 317, 158, 0, 467, 468, 3, 315, 157, 0, 468, 469, 3,
+		317, 158, 0, 469, 470, 3, 311, 155, 0, 470, 471, 3, 285, 142, 0, 471, 472,
+		3, 315, 157, 0, 472, 473, 3, 315, 157, 0, 473, 474, 3, 299, 149, 0, 474,
+		46, 1, 0, 0, 0, 475, 476, 3, 297, 148, 0, 476, 477, 3, 293, 146, 0, 477,
+		478, 3, 299, 149, 0, 478, 479, 3, 299, 149, 0, 479, 48, 1, 0, 0, 0, 480,
+		481, 3, 305, 152, 0, 481, 482, 3, 303, 151, 0, 482, 50, 1, 0, 0, 0, 483,
+		484, 3, 313, 156, 0, 484, 485, 3, 291, 145, 0, 485, 486, 3, 305, 152, 0,
+		486, 487, 3, 321, 160, 0, 487, 52, 1, 0, 0, 0, 488, 489, 3, 311, 155, 0,
+		489, 490, 3, 285, 142, 0, 490, 491, 3, 281, 140, 0, 491, 492, 3, 305, 152,
+		0, 492, 493, 3, 319, 159, 0, 493, 494, 3, 285, 142, 0, 494, 495, 3, 311,
+		155, 0, 495, 54, 1, 0, 0, 0, 496, 497, 3, 317, 158, 0, 497, 498, 3, 313,
+		156, 0, 498, 499, 3, 285, 142, 0, 499, 56, 1, 0, 0, 0, 500, 501, 3, 313,
+		156, 0, 501, 502, 3, 315, 157, 0, 502, 503, 3, 277, 138, 0, 503, 504, 3,
+		315, 157, 0, 504, 505, 3, 285, 142, 0, 505, 506, 3, 263, 131, 0, 506, 507,
+		3, 311, 155, 0, 507, 508, 3, 285, 142, 0, 508, 509, 3, 307, 153, 0, 509,
+		510, 3, 305, 152, 0, 510, 58, 1, 0, 0, 0, 511, 512, 3, 313, 156, 0, 512,
+		513, 3, 315, 157, 0, 513, 514, 3, 277, 138, 0, 514, 515, 3, 315, 157, 0,
+		515, 516, 3, 285, 142, 0, 516, 517, 3, 263, 131, 0, 517, 518, 3, 301, 150,
+		0, 518, 519, 3, 277, 138, 0, 519, 520, 3, 281, 140, 0, 520, 521, 3, 291,
+		145, 0, 521, 522, 3, 293, 146, 0, 522, 523, 3, 303, 151, 0, 523, 524, 3,
+		285, 142, 0, 524, 60, 1, 0, 0, 0, 525, 526, 3, 301, 150, 0, 526, 527, 3,
+		277, 138, 0, 527, 528, 3, 313, 156, 0, 528, 529, 3, 315, 157, 0, 529, 530,
+		3, 285, 142, 0, 530, 531, 3, 311, 155, 0, 531, 62, 1, 0, 0, 0, 532, 533,
+		3, 301, 150, 0, 533, 534, 3, 285, 142, 0, 534, 535, 3, 315, 157, 0, 535,
+		536, 3, 277, 138, 0, 536, 537, 3, 283, 141, 0, 537, 538, 3, 277, 138, 0,
+		538, 539, 3, 315, 157, 0, 539, 540, 3, 277, 138, 0, 540, 64, 1, 0, 0, 0,
+		541, 542, 3, 315, 157, 0, 542, 543, 3, 325, 162, 0, 543, 544, 3, 307, 153,
+		0, 544, 545, 3, 285, 142, 0, 545, 546, 3, 313, 156, 0, 546, 66, 1, 0, 0,
+		0, 547, 548, 3, 315, 157, 0, 548, 549, 3, 325, 162, 0, 549, 550, 3, 307,
+		153, 0, 550, 551, 3, 285, 142, 0, 551, 68, 1, 0, 0, 0, 552, 553, 3, 313,
+		156, 0, 553, 554, 3, 315, 157, 0, 554, 555, 3, 305, 152, 0, 555, 556, 3,
+		311, 155, 0, 556, 557, 3, 277, 138, 0, 557, 558, 3, 289, 144, 0, 558, 559,
+		3, 285, 142, 0, 559, 560, 3, 313, 156, 0, 560, 70, 1, 0, 0, 0, 561, 562,
+		3, 313, 156, 0, 562, 563, 3, 315, 157, 0, 563, 564, 3, 305, 152, 0, 564,
+		565, 3, 311, 155, 0, 565, 566, 3, 277, 138, 0, 566, 567, 3, 289, 144, 0,
+		567, 568, 3, 285, 142, 0, 568, 72, 1, 0, 0, 0, 569, 570, 3, 279, 139, 0,
+		570, 571, 3, 311, 155, 0, 571, 572, 3, 305, 152, 0, 572, 573, 3, 297, 148,
+		0, 573, 574, 3, 285, 142, 0, 574, 575, 3, 311, 155, 0, 575, 74, 1, 0, 0,
+		0, 576, 577, 3, 311, 155, 0, 577, 578, 3, 305, 152, 0, 578, 579, 3, 305,
+		152, 0, 579, 580, 3, 315, 157, 0, 580, 76, 1, 0, 0, 0, 581, 582, 3, 279,
+		139, 0, 582, 583, 3, 311, 155, 0, 583, 584, 3, 305, 152, 0, 584, 585, 3,
+		297, 148, 0, 585, 586, 3, 285, 142, 0, 586, 587, 3, 311, 155, 0, 587, 588,
+		3, 313, 156, 0, 588, 78, 1, 0, 0, 0, 589, 590, 3, 277, 138, 0, 590, 591,
+		3, 299, 149, 0, 591, 592, 3, 293, 146, 0, 592, 593, 3, 319, 159, 0, 593,
+		594, 3, 285, 142, 0, 594, 80, 1, 0, 0, 0, 595, 596, 3, 313, 156, 0, 596,
+		597, 3, 281, 140, 0, 597, 598, 3, 291, 145, 0, 598, 599, 3, 285, 142, 0,
+		599, 600, 3, 301, 150, 0, 600, 601, 3, 277, 138, 0, 601, 602, 3, 313, 156,
+		0, 602, 82, 1, 0, 0, 0, 603, 604, 3, 283, 141, 0, 604, 605, 3, 277, 138,
+		0, 605, 606, 3, 315, 157, 0, 606, 607, 3, 277, 138, 0, 607, 608, 3, 279,
+		139, 0, 608, 609, 3, 277, 138, 0, 609, 610, 3, 313, 156, 0, 610, 611, 3,
+		285, 142, 0, 611, 84, 1, 0, 0, 0, 612, 613, 3, 283, 141, 0, 613, 614, 3,
+		277, 138, 0, 614, 615, 3, 315, 157, 0, 615, 616, 3, 277, 138, 0, 616, 617,
+		3, 279, 139, 0, 617, 618, 3, 277, 138, 0, 618, 619, 3, 313, 156, 0, 619,
+		620, 3, 285, 142, 0, 620, 621, 3, 313, 156, 0, 621, 86, 1, 0, 0, 0, 622,
+		623, 3, 303, 151, 0, 623, 624, 3, 277, 138, 0, 624, 625, 3, 301, 150, 0,
+		625, 626, 3, 285, 142, 0, 626, 627, 3, 313, 156, 0, 627, 628, 3, 307, 153,
+		0, 628, 629, 3, 277, 138, 0, 629, 630, 3, 281, 140, 0, 630, 631, 3, 285,
+		142, 0, 631, 88, 1, 0, 0, 0, 632, 633, 3, 303, 151, 0, 633, 634, 3, 277,
+		138, 0, 634, 635, 3, 301, 150, 0, 635, 636, 3, 285, 142, 0, 636, 637, 3,
+		313, 156, 0, 637, 638, 3, 307, 153, 0, 638, 639, 3, 277, 138, 0, 639, 640,
+		3, 281, 140, 0, 640, 641, 3, 285, 142, 0, 641, 642, 3, 313, 156, 0, 642,
+		90, 1, 0, 0, 0, 643, 644, 3, 303, 151, 0, 644, 645, 3, 305, 152, 0, 645,
+		646, 3, 283, 141, 0, 646, 647, 3, 285, 142, 0, 647, 92, 1, 0, 0, 0, 648,
+		649, 3, 301, 150, 0, 649, 650, 3, 285, 142, 0, 650, 651, 3, 315, 157, 0,
+		651, 652, 3, 311, 155, 0, 652, 653, 3, 293, 146, 0, 653, 654, 3, 281, 140,
+		0, 654, 655, 3, 313, 156, 0, 655, 94, 1, 0, 0, 0, 656, 657, 3, 301, 150,
+		0, 657, 658, 3, 285, 142, 0, 658, 659, 3, 315, 157, 0, 659, 660, 3, 311,
+		155, 0, 660, 661, 3, 293, 146, 0, 661, 662, 3, 281, 140, 0, 662, 96, 1, 0,
+		0, 0, 663, 664, 3, 287, 143, 0, 664, 665, 3, 293, 146, 0, 665, 666, 3,
+		285, 142, 0, 666, 667, 3, 299, 149, 0, 667, 668, 3, 283, 141, 0, 668, 98,
+		1, 0, 0, 0, 669, 670, 3, 287, 143, 0, 670, 671, 3, 293, 146, 0, 671, 672,
+		3, 285, 142, 0, 672, 673, 3, 299, 149, 0, 673, 674, 3, 283, 141, 0, 674,
+		675, 3, 313, 156, 0, 675, 100, 1, 0, 0, 0, 676, 677, 3, 315, 157, 0, 677,
+		678, 3, 277, 138, 0, 678, 679, 3, 289, 144, 0, 679, 102, 1, 0, 0, 0, 680,
+		681, 3, 293, 146, 0, 681, 682, 3, 303, 151, 0, 682, 683, 3, 287, 143, 0,
+		683, 684, 3, 305, 152, 0, 684, 104, 1, 0, 0, 0, 685, 686, 3, 297, 148, 0,
+		686, 687, 3, 285, 142, 0, 687, 688, 3, 325, 162, 0, 688, 689, 3, 313, 156,
+		0, 689, 106, 1, 0, 0, 0, 690, 691, 3, 297, 148, 0, 691, 692, 3, 285, 142,
+		0, 692, 693, 3, 325, 162, 0, 693, 108, 1, 0, 0, 0, 694, 695, 3, 321, 160,
+		0, 695, 696, 3, 293, 146, 0, 696, 697, 3, 315, 157, 0, 697, 698, 3, 291,
+		145, 0, 698, 110, 1, 0, 0, 0, 699, 700, 3, 319, 159, 0, 700, 701, 3, 277,
+		138, 0, 701, 702, 3, 299, 149, 0, 702, 703, 3, 317, 158, 0, 703, 704, 3,
+		285, 142, 0, 704, 705, 3, 313, 156, 0, 705, 112, 1, 0, 0, 0, 706, 707, 3,
+		319, 159, 0, 707, 708, 3, 277, 138, 0, 708, 709, 3, 299, 149, 0, 709, 710,
+		3, 317, 158, 0, 710, 711, 3, 285, 142, 0, 711, 114, 1, 0, 0, 0, 712, 713,
 		3, 287, 143, 0, 713, 714, 3, 311, 155, 0, 714, 715, 3, 305, 152, 0, 715,
 		716, 3, 301, 150, 0, 716, 116, 1, 0, 0, 0, 717, 718, 3, 321, 160, 0, 718,
 		719, 3, 291, 145, 0, 719, 720, 3, 285, 142, 0, 720, 721, 3, 311, 155, 0,
-		721, 722, 3, 285, 142, 0, 722, 118, 1, 0, 0, 0, 723, 724, 3, 299, 149,
-		0, 724, 725, 3, 293, 146, 0, 725, 726, 3, 301, 150, 0, 726, 727, 3, 293,
-		146, 0, 727, 728, 3, 315, 157, 0, 728, 120, 1, 0, 0, 0, 729, 730, 3, 309,
-		154, 0, 730, 731, 3, 317, 158, 0, 731, 732, 3, 285, 142, 0, 732, 733, 3,
-		311, 155, 0, 733, 734, 3, 293, 146, 0, 734, 735, 3, 285, 142, 0, 735, 736,
-		3, 313, 156, 0, 736, 122, 1, 0, 0, 0, 737, 738, 3, 309, 154, 0, 738, 739,
-		3, 317, 158, 0, 739, 740, 3, 285, 142, 0, 740, 741, 3, 311, 155, 0, 741,
-		742, 3, 325, 162, 0, 742, 124, 1, 0, 0, 0, 743, 744, 3, 285, 142, 0, 744,
-		745, 3, 323, 161, 0, 745, 746, 3, 307, 153, 0, 746, 747, 3, 299, 149, 0,
-		747, 748, 3, 277, 138, 0, 748, 749, 3, 293, 146, 0, 749, 750, 3, 303, 151,
-		0, 750, 126, 1, 0, 0, 0, 751, 752, 3, 321, 160, 0, 752, 753, 3, 293, 146,
-		0, 753, 754, 3, 315, 157, 0, 754, 755, 3, 291, 145, 0, 755, 756, 3, 319,
-		159, 0, 756, 757, 3, 277, 138, 0, 757, 758, 3, 299, 149, 0, 758, 759, 3,
-		317, 158, 0, 759, 760, 3, 285, 142, 0, 760, 128, 1, 0, 0, 0, 761, 762,
-		3, 313, 156, 0, 762, 763, 3, 285, 142, 0, 763, 764, 3, 299, 149, 0, 764,
-		765, 3, 285, 142, 0, 765, 766, 3, 281, 140, 0, 766, 767, 3, 315, 157, 0,
-		767, 130, 1, 0, 0, 0, 768, 769, 3, 277, 138, 0, 769, 770, 3, 313, 156,
-		0, 770, 132, 1, 0, 0, 0, 771, 772, 3, 277, 138, 0, 772, 773, 3, 303, 151,
-		0, 773, 774, 3, 283, 141, 0, 774, 134, 1, 0, 0, 0, 775, 776, 3, 305, 152,
-		0, 776, 777, 3, 311, 155, 0, 777, 136, 1, 0, 0, 0, 778, 779, 3, 287, 143,
-		0, 779, 780, 3, 293, 146, 0, 780, 781, 3, 299, 149, 0, 781, 782, 3, 299,
-		149, 0, 782, 138, 1, 0, 0, 0, 783, 784, 3, 303, 151, 0, 784, 785, 3, 317,
-		158, 0, 785, 786, 3, 299, 149, 0, 786, 787, 3, 299, 149, 0, 787, 140, 1,
-		0, 0, 0, 788, 789, 3, 307, 153, 0, 789, 790, 3, 311, 155, 0, 790, 791,
-		3, 285, 142, 0, 791, 792, 3, 319, 159, 0, 792, 793, 3, 293, 146, 0, 793,
-		794, 3, 305, 152, 0, 794, 795, 3, 317, 158, 0, 795, 796, 3, 313, 156, 0,
-		796, 142, 1, 0, 0, 0, 797, 798, 3, 305, 152, 0, 798, 799, 3, 311, 155,
-		0, 799, 800, 3, 283, 141, 0, 800, 801, 3, 285, 142, 0, 801, 802, 3, 311,
-		155, 0, 802, 144, 1, 0, 0, 0, 803, 804, 3, 277, 138, 0, 804, 805, 3, 313,
-		156, 0, 805, 806, 3, 281, 140, 0, 806, 146, 1, 0, 0, 0, 807, 808, 3, 283,
-		141, 0, 808, 809, 3, 285, 142, 0, 809, 810, 3, 313, 156, 0, 810, 811, 3,
-		281, 140, 0, 811, 148, 1, 0, 0, 0, 812, 813, 3, 299, 149, 0, 813, 814,
-		3, 293, 146, 0, 814, 815, 3, 297, 148, 0, 815, 816, 3, 285, 142, 0, 816,
-		150, 1, 0, 0, 0, 817, 818, 3, 303, 151, 0, 818, 819, 3, 305, 152, 0, 819,
-		820, 3, 315, 157, 0, 820, 152, 1, 0, 0, 0, 821, 822, 3, 279, 139, 0, 822,
-		823, 3, 285, 142, 0, 823, 824, 3, 315, 157, 0, 824, 825, 3, 321, 160, 0,
-		825, 826, 3, 285, 142, 0, 826, 827, 3, 285, 142, 0, 827, 828, 3, 303, 151,
-		0, 828, 154, 1, 0, 0, 0, 829, 830, 3, 293, 146, 0, 830, 831, 3, 313, 156,
-		0, 831, 156, 1, 0, 0, 0, 832, 833, 3, 289, 144, 0, 833, 834, 3, 311, 155,
-		0, 834, 835, 3, 305, 152, 0, 835, 836, 3, 317, 158, 0, 836, 837, 3, 307,
-		153, 0, 837, 158, 1, 0, 0, 0, 838, 839, 3, 291, 145, 0, 839, 840, 3, 277,
-		138, 0, 840, 841, 3, 319, 159, 0, 841, 842, 3, 293, 146, 0, 842, 843, 3,
-		303, 151, 0, 843, 844, 3, 289, 144, 0, 844, 160, 1, 0, 0, 0, 845, 846,
-		3, 279, 139, 0, 846, 847, 3, 325, 162, 0, 847, 162, 1, 0, 0, 0, 848, 849,
-		3, 287, 143, 0, 849, 850, 3, 305, 152, 0, 850, 851, 3, 311, 155, 0, 851,
-		164, 1, 0, 0, 0, 852, 853, 3, 313, 156, 0, 853, 854, 3, 315, 157, 0, 854,
-		855, 3, 277, 138, 0, 855, 856, 3, 315, 157, 0, 856, 857, 3, 313, 156, 0,
-		857, 166, 1, 0, 0, 0, 858, 859, 3, 315, 157, 0, 859, 860, 3, 293, 146,
-		0, 860, 861, 3, 301, 150, 0, 861, 862, 3, 285, 142, 0, 862, 168, 1, 0,
-		0, 0, 863, 864, 3, 303, 151, 0, 864, 865, 3, 305, 152, 0, 865, 866, 3,
-		321, 160, 0, 866, 170, 1, 0, 0, 0, 867, 868, 3, 293, 146, 0, 868, 869,
-		3, 303, 151, 0, 869, 172, 1, 0, 0, 0, 870, 871, 3, 299, 149, 0, 871, 872,
-		3, 305, 152, 0, 872, 873, 3, 289, 144, 0, 873, 174, 1, 0, 0, 0, 874, 875,
-		3, 307, 153, 0, 875, 876, 3, 311, 155, 0, 876, 877, 3, 305, 152, 0, 877,
-		878, 3, 287, 143, 0, 878, 879, 3, 293, 146, 0, 879, 880, 3, 299, 149, 0,
-		880, 881, 3, 285, 142, 0, 881, 176, 1, 0, 0, 0, 882, 883, 3, 311, 155,
-		0, 883, 884, 3, 285, 142, 0, 884, 885, 3, 309, 154, 0, 885, 886, 3, 317,
-		158, 0, 886, 887, 3, 285, 142, 0, 887, 888, 3, 313, 156, 0, 888, 889, 3,
-		315, 157, 0, 889, 890, 3, 313, 156, 0, 890, 178, 1, 0, 0, 0, 891, 892,
-		3, 311, 155, 0, 892, 893, 3, 285, 142, 0, 893, 894, 3, 309, 154, 0, 894,
-		895, 3, 317, 158, 0, 895, 896, 3, 285, 142, 0, 896, 897, 3, 313, 156, 0,
-		897, 898, 3, 315, 157, 0, 898, 180, 1, 0, 0, 0, 899, 900, 3, 293, 146,
-		0, 900, 901, 3, 283, 141, 0, 901, 182, 1, 0, 0, 0, 902, 903, 3, 313, 156,
-		0, 903, 904, 3, 317, 158, 0, 904, 905, 3, 301, 150, 0, 905, 184, 1, 0,
-		0, 0, 906, 907, 3, 301, 150, 0, 907, 908, 3, 293, 146, 0, 908, 909, 3,
-		303, 151, 0, 909, 186, 1, 0, 0, 0, 910, 911, 3, 301, 150, 0, 911, 912,
-		3, 277, 138, 0, 912, 913, 3, 323, 161, 0, 913, 188, 1, 0, 0, 0, 914, 915,
-		3, 281, 140, 0, 915, 916, 3, 305, 152, 0, 916, 917, 3, 317, 158, 0, 917,
-		918, 3, 303, 151, 0, 918, 919, 3, 315, 157, 0, 919, 190, 1, 0, 0, 0, 920,
-		921, 3, 299, 149, 0, 921, 922, 3, 277, 138, 0, 922, 923, 3, 313, 156, 0,
-		923, 924, 3, 315, 157, 0, 924, 192, 1, 0, 0, 0, 925, 926, 3, 287, 143,
-		0, 926, 927, 3, 293, 146, 0, 927, 928, 3, 311, 155, 0, 928, 929, 3, 313,
-		156, 0, 929, 930, 3, 315, 157, 0, 930, 194, 1, 0, 0, 0, 931, 932, 3, 277,
-		138, 0, 932, 933, 3, 319, 159, 0, 933, 934, 3, 289, 144, 0, 934, 196, 1,
-		0, 0, 0, 935, 936, 3, 313, 156, 0, 936, 937, 3, 315, 157, 0, 937, 938,
+		721, 722, 3, 285, 142, 0, 722, 118, 1, 0, 0, 0, 723, 724, 3, 299, 149, 0,
+		724, 725, 3, 293, 146, 0, 725, 726, 3, 301, 150, 0, 726, 727, 3, 293, 146,
+		0, 727, 728, 3, 315, 157, 0, 728, 120, 1, 0, 0, 0, 729, 730, 3, 309, 154,
+		0, 730, 731, 3, 317, 158, 0, 731, 732, 3, 285, 142, 0, 732, 733, 3, 311,
+		155, 0, 733, 734, 3, 293, 146, 0, 734, 735, 3, 285, 142, 0, 735, 736, 3,
+		313, 156, 0, 736, 122, 1, 0, 0, 0, 737, 738, 3, 309, 154, 0, 738, 739, 3,
+		317, 158, 0, 739, 740, 3, 285, 142, 0, 740, 741, 3, 311, 155, 0, 741, 742,
+		3, 325, 162, 0, 742, 124, 1, 0, 0, 0, 743, 744, 3, 285, 142, 0, 744, 745,
+		3, 323, 161, 0, 745, 746, 3, 307, 153, 0, 746, 747, 3, 299, 149, 0, 747,
+		748, 3, 277, 138, 0, 748, 749, 3, 293, 146, 0, 749, 750, 3, 303, 151, 0,
+		750, 126, 1, 0, 0, 0, 751, 752, 3, 321, 160, 0, 752, 753, 3, 293, 146, 0,
+		753, 754, 3, 315, 157, 0, 754, 755, 3, 291, 145, 0, 755, 756, 3, 319, 159,
+		0, 756, 757, 3, 277, 138, 0, 757, 758, 3, 299, 149, 0, 758, 759, 3, 317,
+		158, 0, 759, 760, 3, 285, 142, 0, 760, 128, 1, 0, 0, 0, 761, 762, 3, 313,
+		156, 0, 762, 763, 3, 285, 142, 0, 763, 764, 3, 299, 149, 0, 764, 765, 3,
+		285, 142, 0, 765, 766, 3, 281, 140, 0, 766, 767, 3, 315, 157, 0, 767, 130,
+		1, 0, 0, 0, 768, 769, 3, 277, 138, 0, 769, 770, 3, 313, 156, 0, 770, 132,
+		1, 0, 0, 0, 771, 772, 3, 277, 138, 0, 772, 773, 3, 303, 151, 0, 773, 774,
+		3, 283, 141, 0, 774, 134, 1, 0, 0, 0, 775, 776, 3, 305, 152, 0, 776, 777,
+		3, 311, 155, 0, 777, 136, 1, 0, 0, 0, 778, 779, 3, 287, 143, 0, 779, 780,
+		3, 293, 146, 0, 780, 781, 3, 299, 149, 0, 781, 782, 3, 299, 149, 0, 782,
+		138, 1, 0, 0, 0, 783, 784, 3, 303, 151, 0, 784, 785, 3, 317, 158, 0, 785,
+		786, 3, 299, 149, 0, 786, 787, 3, 299, 149, 0, 787, 140, 1, 0, 0, 0, 788,
+		789, 3, 307, 153, 0, 789, 790, 3, 311, 155, 0, 790, 791, 3, 285, 142, 0,
+		791, 792, 3, 319, 159, 0, 792, 793, 3, 293, 146, 0, 793, 794, 3, 305, 152,
+		0, 794, 795, 3, 317, 158, 0, 795, 796, 3, 313, 156, 0, 796, 142, 1, 0, 0,
+		0, 797, 798, 3, 305, 152, 0, 798, 799, 3, 311, 155, 0, 799, 800, 3, 283,
+		141, 0, 800, 801, 3, 285, 142, 0, 801, 802, 3, 311, 155, 0, 802, 144, 1,
+		0, 0, 0, 803, 804, 3, 277, 138, 0, 804, 805, 3, 313, 156, 0, 805, 806, 3,
+		281, 140, 0, 806, 146, 1, 0, 0, 0, 807, 808, 3, 283, 141, 0, 808, 809, 3,
+		285, 142, 0, 809, 810, 3, 313, 156, 0, 810, 811, 3, 281, 140, 0, 811, 148,
+		1, 0, 0, 0, 812, 813, 3, 299, 149, 0, 813, 814, 3, 293, 146, 0, 814, 815,
+		3, 297, 148, 0, 815, 816, 3, 285, 142, 0, 816, 150, 1, 0, 0, 0, 817, 818,
+		3, 303, 151, 0, 818, 819, 3, 305, 152, 0, 819, 820, 3, 315, 157, 0, 820,
+		152, 1, 0, 0, 0, 821, 822, 3, 279, 139, 0, 822, 823, 3, 285, 142, 0, 823,
+		824, 3, 315, 157, 0, 824, 825, 3, 321, 160, 0, 825, 826, 3, 285, 142, 0,
+		826, 827, 3, 285, 142, 0, 827, 828, 3, 303, 151, 0, 828, 154, 1, 0, 0, 0,
+		829, 830, 3, 293, 146, 0, 830, 831, 3, 313, 156, 0, 831, 156, 1, 0, 0, 0,
+		832, 833, 3, 289, 144, 0, 833, 834, 3, 311, 155, 0, 834, 835, 3, 305, 152,
+		0, 835, 836, 3, 317, 158, 0, 836, 837, 3, 307, 153, 0, 837, 158, 1, 0, 0,
+		0, 838, 839, 3, 291, 145, 0, 839, 840, 3, 277, 138, 0, 840, 841, 3, 319,
+		159, 0, 841, 842, 3, 293, 146, 0, 842, 843, 3, 303, 151, 0, 843, 844, 3,
+		289, 144, 0, 844, 160, 1, 0, 0, 0, 845, 846, 3, 279, 139, 0, 846, 847, 3,
+		325, 162, 0, 847, 162, 1, 0, 0, 0, 848, 849, 3, 287, 143, 0, 849, 850, 3,
+		305, 152, 0, 850, 851, 3, 311, 155, 0, 851, 164, 1, 0, 0, 0, 852, 853, 3,
+		313, 156, 0, 853, 854, 3, 315, 157, 0, 854, 855, 3, 277, 138, 0, 855, 856,
+		3, 315, 157, 0, 856, 857, 3, 313, 156, 0, 857, 166, 1, 0, 0, 0, 858, 859,
+		3, 315, 157, 0, 859, 860, 3, 293, 146, 0, 860, 861, 3, 301, 150, 0, 861,
+		862, 3, 285, 142, 0, 862, 168, 1, 0, 0, 0, 863, 864, 3, 303, 151, 0, 864,
+		865, 3, 305, 152, 0, 865, 866, 3, 321, 160, 0, 866, 170, 1, 0, 0, 0, 867,
+		868, 3, 293, 146, 0, 868, 869, 3, 303, 151, 0, 869, 172, 1, 0, 0, 0, 870,
+		871, 3, 299, 149, 0, 871, 872, 3, 305, 152, 0, 872, 873, 3, 289, 144, 0,
+		873, 174, 1, 0, 0, 0, 874, 875, 3, 307, 153, 0, 875, 876, 3, 311, 155, 0,
+		876, 877, 3, 305, 152, 0, 877, 878, 3, 287, 143, 0, 878, 879, 3, 293, 146,
+		0, 879, 880, 3, 299, 149, 0, 880, 881, 3, 285, 142, 0, 881, 176, 1, 0, 0,
+		0, 882, 883, 3, 311, 155, 0, 883, 884, 3, 285, 142, 0, 884, 885, 3, 309,
+		154, 0, 885, 886, 3, 317, 158, 0, 886, 887, 3, 285, 142, 0, 887, 888, 3,
+		313, 156, 0, 888, 889, 3, 315, 157, 0, 889, 890, 3, 313, 156, 0, 890, 178,
+		1, 0, 0, 0, 891, 892, 3, 311, 155, 0, 892, 893, 3, 285, 142, 0, 893, 894,
+		3, 309, 154, 0, 894, 895, 3, 317, 158, 0, 895, 896, 3, 285, 142, 0, 896,
+		897, 3, 313, 156, 0, 897, 898, 3, 315, 157, 0, 898, 180, 1, 0, 0, 0, 899,
+		900, 3, 293, 146, 0, 900, 901, 3, 283, 141, 0, 901, 182, 1, 0, 0, 0, 902,
+		903, 3, 313, 156, 0, 903, 904, 3, 317, 158, 0, 904, 905, 3, 301, 150, 0,
+		905, 184, 1, 0, 0, 0, 906, 907, 3, 301, 150, 0, 907, 908, 3, 293, 146, 0,
+		908, 909, 3, 303, 151, 0, 909, 186, 1, 0, 0, 0, 910, 911, 3, 301, 150, 0,
+		911, 912, 3, 277, 138, 0, 912, 913, 3, 323, 161, 0, 913, 188, 1, 0, 0, 0,
+		914, 915, 3, 281, 140, 0, 915, 916, 3, 305, 152, 0, 916, 917, 3, 317, 158,
+		0, 917, 918, 3, 303, 151, 0, 918, 919, 3, 315, 157, 0, 919, 190, 1, 0, 0,
+		0, 920, 921, 3, 299, 149, 0, 921, 922, 3, 277, 138, 0, 922, 923, 3, 313,
+		156, 0, 923, 924, 3, 315, 157, 0, 924, 192, 1, 0, 0, 0, 925, 926, 3, 287,
+		143, 0, 926, 927, 3, 293, 146, 0, 927, 928, 3, 311, 155, 0, 928, 929, 3,
+		313, 156, 0, 929, 930, 3, 315, 157, 0, 930, 194, 1, 0, 0, 0, 931, 932, 3,
+		277, 138, 0, 932, 933, 3, 319, 159, 0, 933, 934, 3, 289, 144, 0, 934, 196,
+		1, 0, 0, 0, 935, 936, 3, 313, 156, 0, 936, 937, 3, 315, 157, 0, 937, 938,
 		3, 283, 141, 0, 938, 939, 3, 283, 141, 0, 939, 940, 3, 285, 142, 0, 940,
 		941, 3, 319, 159, 0, 941, 198, 1, 0, 0, 0, 942, 943, 3, 309, 154, 0, 943,
 		944, 3, 317, 158, 0, 944, 945, 3, 277, 138, 0, 945, 946, 3, 303, 151, 0,
 		946, 947, 3, 315, 157, 0, 947, 948, 3, 293, 146, 0, 948, 949, 3, 299, 149,
 		0, 949, 950, 3, 285, 142, 0, 950, 200, 1, 0, 0, 0, 951, 952, 3, 311, 155,
 		0, 952, 953, 3, 277, 138, 0, 953, 954, 3, 315, 157, 0, 954, 955, 3, 285,
-		142, 0, 955, 202, 1, 0, 0, 0, 956, 957, 3, 313, 156, 0, 957, 204, 1, 0,
-		0, 0, 958, 959, 5, 109, 0, 0, 959, 206, 1, 0, 0, 0, 960, 961, 3, 291, 145,
-		0, 961, 208, 1, 0, 0, 0, 962, 963, 3, 283, 141, 0, 963, 210, 1, 0, 0, 0,
-		964, 965, 3, 321, 160, 0, 965, 212, 1, 0, 0, 0, 966, 967, 5, 77, 0, 0,
-		967, 214, 1, 0, 0, 0, 968, 969, 3, 325, 162, 0, 969, 216, 1, 0, 0, 0, 970,
-		971, 5, 46, 0, 0, 971, 218, 1, 0, 0, 0, 972, 973, 5, 58, 0, 0, 973, 220,
-		1, 0, 0, 0, 974, 975, 5, 61, 0, 0, 975, 222, 1, 0, 0, 0, 976, 977, 5, 60,
-		0, 0, 977, 978, 5, 62, 0, 0, 978, 224, 1, 0, 0, 0, 979, 980, 5, 33, 0,
-		0, 980, 981, 5, 61, 0, 0, 981, 226, 1, 0, 0, 0, 982, 983, 5, 62, 0, 0,
-		983, 228, 1, 0, 0, 0, 984, 985, 5, 62, 0, 0, 985, 986, 5, 61, 0, 0, 986,
-		230, 1, 0, 0, 0, 987, 988, 5, 60, 0, 0, 988, 232, 1, 0, 0, 0, 989, 990,
-		5, 60, 0, 0, 990, 991, 5, 61, 0, 0, 991, 234, 1, 0, 0, 0, 992, 993, 5,
-		61, 0, 0, 993, 994, 5, 126, 0, 0, 994, 236, 1, 0, 0, 0, 995, 996, 5, 33,
-		0, 0, 996, 997, 5, 126, 0, 0, 997, 238, 1, 0, 0, 0, 998, 999, 5, 44, 0,
-		0, 999, 240, 1, 0, 0, 0, 1000, 1001, 5, 123, 0, 0, 1001, 242, 1, 0, 0,
-		0, 1002, 1003, 5, 125, 0, 0, 1003, 244, 1, 0, 0, 0, 1004, 1005, 5, 91,
-		0, 0, 1005, 246, 1, 0, 0, 0, 1006, 1007, 5, 93, 0, 0, 1007, 248, 1, 0,
-		0, 0, 1008, 1009, 5, 40, 0, 0, 1009, 250, 1, 0, 0, 0, 1010, 1011, 5, 41,
-		0, 0, 1011, 252, 1, 0, 0, 0, 1012, 1013, 5, 43, 0, 0, 1013, 254, 1, 0,
-		0, 0, 1014, 1015, 5, 45, 0, 0, 1015, 256, 1, 0, 0, 0, 1016, 1017, 5, 47,
-		0, 0, 1017, 258, 1, 0, 0, 0, 1018, 1019, 5, 42, 0, 0, 1019, 260, 1, 0,
-		0, 0, 1020, 1021, 5, 37, 0, 0, 1021, 262, 1, 0, 0, 0, 1022, 1023, 5, 95,
-		0, 0, 1023, 264, 1, 0, 0, 0, 1024, 1025, 3, 275, 137, 0, 1025, 266, 1,
-		0, 0, 0, 1026, 1028, 3, 273, 136, 0, 1027, 1026, 1, 0, 0, 0, 1028, 1029,
-		1, 0, 0, 0, 1029, 1027, 1, 0, 0, 0, 1029, 1030, 1, 0, 0, 0, 1030, 268,
-		1, 0, 0, 0, 1031, 1033, 3, 273, 136, 0, 1032, 1031, 1, 0, 0, 0, 1033, 1034,
-		1, 0, 0, 0, 1034, 1032, 1, 0, 0, 0, 1034, 1035, 1, 0, 0, 0, 1035, 1036,
-		1, 0, 0, 0, 1036, 1037, 5, 46, 0, 0, 1037, 1041, 8, 6, 0, 0, 1038, 1040,
-		3, 273, 136, 0, 1039, 1038, 1, 0, 0, 0, 1040, 1043, 1, 0, 0, 0, 1041, 1039,
-		1, 0, 0, 0, 1041, 1042, 1, 0, 0, 0, 1042, 1051, 1, 0, 0, 0, 1043, 1041,
-		1, 0, 0, 0, 1044, 1046, 5, 46, 0, 0, 1045, 1047, 3, 273, 136, 0, 1046,
-		1045, 1, 0, 0, 0, 1047, 1048, 1, 0, 0, 0, 1048, 1046, 1, 0, 0, 0, 1048,
-		1049, 1, 0, 0, 0, 1049, 1051, 1, 0, 0, 0, 1050, 1032, 1, 0, 0, 0, 1050,
-		1044, 1, 0, 0, 0, 1051, 270, 1, 0, 0, 0, 1052, 1053, 7, 5, 0, 0, 1053,
-		272, 1, 0, 0, 0, 1054, 1055, 7, 7, 0, 0, 1055, 274, 1, 0, 0, 0, 1056, 1062,
-		7, 8, 0, 0, 1057, 1061, 7, 8, 0, 0, 1058, 1061, 3, 273, 136, 0, 1059, 1061,
-		7, 9, 0, 0, 1060, 1057, 1, 0, 0, 0, 1060, 1058, 1, 0, 0, 0, 1060, 1059,
-		1, 0, 0, 0, 1061, 1064, 1, 0, 0, 0, 1062, 1060, 1, 0, 0, 0, 1062, 1063,
-		1, 0, 0, 0, 1063, 1107, 1, 0, 0, 0, 1064, 1062, 1, 0, 0, 0, 1065, 1066,
-		5, 36, 0, 0, 1066, 1070, 5, 123, 0, 0, 1067, 1069, 9, 0, 0, 0, 1068, 1067,
-		1, 0, 0, 0, 1069, 1072, 1, 0, 0, 0, 1070, 1071, 1, 0, 0, 0, 1070, 1068,
-		1, 0, 0, 0, 1071, 1073, 1, 0, 0, 0, 1072, 1070, 1, 0, 0, 0, 1073, 1107,
-		5, 125, 0, 0, 1074, 1078, 7, 10, 0, 0, 1075, 1079, 7, 8, 0, 0, 1076, 1079,
-		3, 273, 136, 0, 1077, 1079, 7, 11, 0, 0, 1078, 1075, 1, 0, 0, 0, 1078,
-		1076, 1, 0, 0, 0, 1078, 1077, 1, 0, 0, 0, 1079, 1080, 1, 0, 0, 0, 1080,
-		1078, 1, 0, 0, 0, 1080, 1081, 1, 0, 0, 0, 1081, 1107, 1, 0, 0, 0, 1082,
-		1086, 5, 34, 0, 0, 1083, 1085, 9, 0, 0, 0, 1084, 1083, 1, 0, 0, 0, 1085,
-		1088, 1, 0, 0, 0, 1086, 1087, 1, 0, 0, 0, 1086, 1084, 1, 0, 0, 0, 1087,
-		1089, 1, 0, 0, 0, 1088, 1086, 1, 0, 0, 0, 1089, 1107, 5, 34, 0, 0, 1090,
-		1094, 5, 96, 0, 0, 1091, 1093, 9, 0, 0, 0, 1092, 1091, 1, 0, 0, 0, 1093,
-		1096, 1, 0, 0, 0, 1094, 1095, 1, 0, 0, 0, 1094, 1092, 1, 0, 0, 0, 1095,
-		1097, 1, 0, 0, 0, 1096, 1094, 1, 0, 0, 0, 1097, 1107, 5, 96, 0, 0, 1098,
-		1102, 5, 39, 0, 0, 1099, 1101, 9, 0, 0, 0, 1100, 1099, 1, 0, 0, 0, 1101,
-		1104, 1, 0, 0, 0, 1102, 1103, 1, 0, 0, 0, 1102, 1100, 1, 0, 0, 0, 1103,
-		1105, 1, 0, 0, 0, 1104, 1102, 1, 0, 0, 0, 1105, 1107, 5, 39, 0, 0, 1106,
-		1056, 1, 0, 0, 0, 1106, 1065, 1, 0, 0, 0, 1106, 1074, 1, 0, 0, 0, 1106,
-		1082, 1, 0, 0, 0, 1106, 1090, 1, 0, 0, 0, 1106, 1098, 1, 0, 0, 0, 1107,
-		276, 1, 0, 0, 0, 1108, 1109, 7, 12, 0, 0, 1109, 278, 1, 0, 0, 0, 1110,
-		1111, 7, 13, 0, 0, 1111, 280, 1, 0, 0, 0, 1112, 1113, 7, 14, 0, 0, 1113,
-		282, 1, 0, 0, 0, 1114, 1115, 7, 15, 0, 0, 1115, 284, 1, 0, 0, 0, 1116,
-		1117, 7, 3, 0, 0, 1117, 286, 1, 0, 0, 0, 1118, 1119, 7, 16, 0, 0, 1119,
-		288, 1, 0, 0, 0, 1120, 1121, 7, 17, 0, 0, 1121, 290, 1, 0, 0, 0, 1122,
-		1123, 7, 18, 0, 0, 1123, 292, 1, 0, 0, 0, 1124, 1125, 7, 19, 0, 0, 1125,
-		294, 1, 0, 0, 0, 1126, 1127, 7, 20, 0, 0, 1127, 296, 1, 0, 0, 0, 1128,
-		1129, 7, 21, 0, 0, 1129, 298, 1, 0, 0, 0, 1130, 1131, 7, 22, 0, 0, 1131,
-		300, 1, 0, 0, 0, 1132, 1133, 7, 23, 0, 0, 1133, 302, 1, 0, 0, 0, 1134,
-		1135, 7, 24, 0, 0, 1135, 304, 1, 0, 0, 0, 1136, 1137, 7, 25, 0, 0, 1137,
-		306, 1, 0, 0, 0, 1138, 1139, 7, 26, 0, 0, 1139, 308, 1, 0, 0, 0, 1140,
-		1141, 7, 27, 0, 0, 1141, 310, 1, 0, 0, 0, 1142, 1143, 7, 28, 0, 0, 1143,
-		312, 1, 0, 0, 0, 1144, 1145, 7, 29, 0, 0, 1145, 314, 1, 0, 0, 0, 1146,
-		1147, 7, 30, 0, 0, 1147, 316, 1, 0, 0, 0, 1148, 1149, 7, 31, 0, 0, 1149,
-		318, 1, 0, 0, 0, 1150, 1151, 7, 32, 0, 0, 1151, 320, 1, 0, 0, 0, 1152,
-		1153, 7, 33, 0, 0, 1153, 322, 1, 0, 0, 0, 1154, 1155, 7, 34, 0, 0, 1155,
-		324, 1, 0, 0, 0, 1156, 1157, 7, 35, 0, 0, 1157, 326, 1, 0, 0, 0, 1158,
-		1159, 7, 36, 0, 0, 1159, 328, 1, 0, 0, 0, 20, 0, 348, 350, 358, 372, 379,
-		1029, 1034, 1041, 1048, 1050, 1060, 1062, 1070, 1078, 1080, 1086, 1094,
-		1102, 1106, 1, 6, 0, 0,
+		142, 0, 955, 202, 1, 0, 0, 0, 956, 957, 3, 313, 156, 0, 957, 204, 1, 0, 0,
+		0, 958, 959, 5, 109, 0, 0, 959, 206, 1, 0, 0, 0, 960, 961, 3, 291, 145, 0,
+		961, 208, 1, 0, 0, 0, 962, 963, 3, 283, 141, 0, 963, 210, 1, 0, 0, 0, 964,
+		965, 3, 321, 160, 0, 965, 212, 1, 0, 0, 0, 966, 967, 5, 77, 0, 0, 967,
+		214, 1, 0, 0, 0, 968, 969, 3, 325, 162, 0, 969, 216, 1, 0, 0, 0, 970, 971,
+		5, 46, 0, 0, 971, 218, 1, 0, 0, 0, 972, 973, 5, 58, 0, 0, 973, 220, 1, 0,
+		0, 0, 974, 975, 5, 61, 0, 0, 975, 222, 1, 0, 0, 0, 976, 977, 5, 60, 0, 0,
+		977, 978, 5, 62, 0, 0, 978, 224, 1, 0, 0, 0, 979, 980, 5, 33, 0, 0, 980,
+		981, 5, 61, 0, 0, 981, 226, 1, 0, 0, 0, 982, 983, 5, 62, 0, 0, 983, 228,
+		1, 0, 0, 0, 984, 985, 5, 62, 0, 0, 985, 986, 5, 61, 0, 0, 986, 230, 1, 0,
+		0, 0, 987, 988, 5, 60, 0, 0, 988, 232, 1, 0, 0, 0, 989, 990, 5, 60, 0, 0,
+		990, 991, 5, 61, 0, 0, 991, 234, 1, 0, 0, 0, 992, 993, 5, 61, 0, 0, 993,
+		994, 5, 126, 0, 0, 994, 236, 1, 0, 0, 0, 995, 996, 5, 33, 0, 0, 996, 997,
+		5, 126, 0, 0, 997, 238, 1, 0, 0, 0, 998, 999, 5, 44, 0, 0, 999, 240, 1, 0,
+		0, 0, 1000, 1001, 5, 123, 0, 0, 1001, 242, 1, 0, 0, 0, 1002, 1003, 5, 125,
+		0, 0, 1003, 244, 1, 0, 0, 0, 1004, 1005, 5, 91, 0, 0, 1005, 246, 1, 0, 0,
+		0, 1006, 1007, 5, 93, 0, 0, 1007, 248, 1, 0, 0, 0, 1008, 1009, 5, 40, 0,
+		0, 1009, 250, 1, 0, 0, 0, 1010, 1011, 5, 41, 0, 0, 1011, 252, 1, 0, 0, 0,
+		1012, 1013, 5, 43, 0, 0, 1013, 254, 1, 0, 0, 0, 1014, 1015, 5, 45, 0, 0,
+		1015, 256, 1, 0, 0, 0, 1016, 1017, 5, 47, 0, 0, 1017, 258, 1, 0, 0, 0,
+		1018, 1019, 5, 42, 0, 0, 1019, 260, 1, 0, 0, 0, 1020, 1021, 5, 37, 0, 0,
+		1021, 262, 1, 0, 0, 0, 1022, 1023, 5, 95, 0, 0, 1023, 264, 1, 0, 0, 0,
+		1024, 1025, 3, 275, 137, 0, 1025, 266, 1, 0, 0, 0, 1026, 1028, 3, 273,
+		136, 0, 1027, 1026, 1, 0, 0, 0, 1028, 1029, 1, 0, 0, 0, 1029, 1027, 1, 0,
+		0, 0, 1029, 1030, 1, 0, 0, 0, 1030, 268, 1, 0, 0, 0, 1031, 1033, 3, 273,
+		136, 0, 1032, 1031, 1, 0, 0, 0, 1033, 1034, 1, 0, 0, 0, 1034, 1032, 1, 0,
+		0, 0, 1034, 1035, 1, 0, 0, 0, 1035, 1036, 1, 0, 0, 0, 1036, 1037, 5, 46,
+		0, 0, 1037, 1041, 8, 6, 0, 0, 1038, 1040, 3, 273, 136, 0, 1039, 1038, 1,
+		0, 0, 0, 1040, 1043, 1, 0, 0, 0, 1041, 1039, 1, 0, 0, 0, 1041, 1042, 1, 0,
+		0, 0, 1042, 1051, 1, 0, 0, 0, 1043, 1041, 1, 0, 0, 0, 1044, 1046, 5, 46,
+		0, 0, 1045, 1047, 3, 273, 136, 0, 1046, 1045, 1, 0, 0, 0, 1047, 1048, 1,
+		0, 0, 0, 1048, 1046, 1, 0, 0, 0, 1048, 1049, 1, 0, 0, 0, 1049, 1051, 1, 0,
+		0, 0, 1050, 1032, 1, 0, 0, 0, 1050, 1044, 1, 0, 0, 0, 1051, 270, 1, 0, 0,
+		0, 1052, 1053, 7, 5, 0, 0, 1053, 272, 1, 0, 0, 0, 1054, 1055, 7, 7, 0, 0,
+		1055, 274, 1, 0, 0, 0, 1056, 1062, 7, 8, 0, 0, 1057, 1061, 7, 8, 0, 0,
+		1058, 1061, 3, 273, 136, 0, 1059, 1061, 7, 9, 0, 0, 1060, 1057, 1, 0, 0,
+		0, 1060, 1058, 1, 0, 0, 0, 1060, 1059, 1, 0, 0, 0, 1061, 1064, 1, 0, 0, 0,
+		1062, 1060, 1, 0, 0, 0, 1062, 1063, 1, 0, 0, 0, 1063, 1107, 1, 0, 0, 0,
+		1064, 1062, 1, 0, 0, 0, 1065, 1066, 5, 36, 0, 0, 1066, 1070, 5, 123, 0, 0,
+		1067, 1069, 9, 0, 0, 0, 1068, 1067, 1, 0, 0, 0, 1069, 1072, 1, 0, 0, 0,
+		1070, 1071, 1, 0, 0, 0, 1070, 1068, 1, 0, 0, 0, 1071, 1073, 1, 0, 0, 0,
+		1072, 1070, 1, 0, 0, 0, 1073, 1107, 5, 125, 0, 0, 1074, 1078, 7, 10, 0, 0,
+		1075, 1079, 7, 8, 0, 0, 1076, 1079, 3, 273, 136, 0, 1077, 1079, 7, 11, 0,
+		0, 1078, 1075, 1, 0, 0, 0, 1078, 1076, 1, 0, 0, 0, 1078, 1077, 1, 0, 0, 0,
+		1079, 1080, 1, 0, 0, 0, 1080, 1078, 1, 0, 0, 0, 1080, 1081, 1, 0, 0, 0,
+		1081, 1107, 1, 0, 0, 0, 1082, 1086, 5, 34, 0, 0, 1083, 1085, 9, 0, 0, 0,
+		1084, 1083, 1, 0, 0, 0, 1085, 1088, 1, 0, 0, 0, 1086, 1087, 1, 0, 0, 0,
+		1086, 1084, 1, 0, 0, 0, 1087, 1089, 1, 0, 0, 0, 1088, 1086, 1, 0, 0, 0,
+		1089, 1107, 5, 34, 0, 0, 1090, 1094, 5, 96, 0, 0, 1091, 1093, 9, 0, 0, 0,
+		1092, 1091, 1, 0, 0, 0, 1093, 1096, 1, 0, 0, 0, 1094, 1095, 1, 0, 0, 0,
+		1094, 1092, 1, 0, 0, 0, 1095, 1097, 1, 0, 0, 0, 1096, 1094, 1, 0, 0, 0,
+		1097, 1107, 5, 96, 0, 0, 1098, 1102, 5, 39, 0, 0, 1099, 1101, 9, 0, 0, 0,
+		1100, 1099, 1, 0, 0, 0, 1101, 1104, 1, 0, 0, 0, 1102, 1103, 1, 0, 0, 0,
+		1102, 1100, 1, 0, 0, 0, 1103, 1105, 1, 0, 0, 0, 1104, 1102, 1, 0, 0, 0,
+		1105, 1107, 5, 39, 0, 0, 1106, 1056, 1, 0, 0, 0, 1106, 1065, 1, 0, 0, 0,
+		1106, 1074, 1, 0, 0, 0, 1106, 1082, 1, 0, 0, 0, 1106, 1090, 1, 0, 0, 0,
+		1106, 1098, 1, 0, 0, 0, 1107, 276, 1, 0, 0, 0, 1108, 1109, 7, 12, 0, 0,
+		1109, 278, 1, 0, 0, 0, 1110, 1111, 7, 13, 0, 0, 1111, 280, 1, 0, 0, 0,
+		1112, 1113, 7, 14, 0, 0, 1113, 282, 1, 0, 0, 0, 1114, 1115, 7, 15, 0, 0,
+		1115, 284, 1, 0, 0, 0, 1116, 1117, 7, 3, 0, 0, 1117, 286, 1, 0, 0, 0,
+		1118, 1119, 7, 16, 0, 0, 1119, 288, 1, 0, 0, 0, 1120, 1121, 7, 17, 0, 0,
+		1121, 290, 1, 0, 0, 0, 1122, 1123, 7, 18, 0, 0, 1123, 292, 1, 0, 0, 0,
+		1124, 1125, 7, 19, 0, 0, 1125, 294, 1, 0, 0, 0, 1126, 1127, 7, 20, 0, 0,
+		1127, 296, 1, 0, 0, 0, 1128, 1129, 7, 21, 0, 0, 1129, 298, 1, 0, 0, 0,
+		1130, 1131, 7, 22, 0, 0, 1131, 300, 1, 0, 0, 0, 1132, 1133, 7, 23, 0, 0,
+		1133, 302, 1, 0, 0, 0, 1134, 1135, 7, 24, 0, 0, 1135, 304, 1, 0, 0, 0,
+		1136, 1137, 7, 25, 0, 0, 1137, 306, 1, 0, 0, 0, 1138, 1139, 7, 26, 0, 0,
+		1139, 308, 1, 0, 0, 0, 1140, 1141, 7, 27, 0, 0, 1141, 310, 1, 0, 0, 0,
+		1142, 1143, 7, 28, 0, 0, 1143, 312, 1, 0, 0, 0, 1144, 1145, 7, 29, 0, 0,
+		1145, 314, 1, 0, 0, 0, 1146, 1147, 7, 30, 0, 0, 1147, 316, 1, 0, 0, 0,
+		1148, 1149, 7, 31, 0, 0, 1149, 318, 1, 0, 0, 0, 1150, 1151, 7, 32, 0, 0,
+		1151, 320, 1, 0, 0, 0, 1152, 1153, 7, 33, 0, 0, 1153, 322, 1, 0, 0, 0,
+		1154, 1155, 7, 34, 0, 0, 1155, 324, 1, 0, 0, 0, 1156, 1157, 7, 35, 0, 0,
+		1157, 326, 1, 0, 0, 0, 1158, 1159, 7, 36, 0, 0, 1159, 328, 1, 0, 0, 0,
+		1160, 1162, 1, 0, 0, 0, 1162, 1163, 3, 305, 152, 0, 1163, 1164, 3, 287,
+		143, 0, 1164, 1165, 3, 287, 143, 0, 1165, 1166, 3, 313, 156, 0, 1166,
+		1167, 3, 285, 142, 0, 1167, 1168, 3, 315, 157, 0, 1168, 1161, 1, 0, 0, 0,
+		1169, 1171, 1, 0, 0, 0, 1171, 1172, 3, 317, 158, 0, 1172, 1173, 3, 313,
+		156, 0, 1173, 1174, 3, 293, 146, 0, 1174, 1175, 3, 303, 151, 0, 1175,
+		1176, 3, 289, 144, 0, 1176, 1170, 1, 0, 0, 0, 1177, 1179, 1, 0, 0, 0,
+		1179, 1180, 3, 311, 155, 0, 1180, 1181, 3, 277, 138, 0, 1181, 1182, 3,
+		321, 160, 0, 1182, 1178, 1, 0, 0, 0, 1183, 1185, 1, 0, 0, 0, 1185, 1186,
+		3, 311, 155, 0, 1186, 1187, 3, 305, 152, 0, 1187, 1188, 3, 299, 149, 0,
+		1188, 1189, 3, 299, 149, 0, 1189, 1190, 3, 317, 158, 0, 1190, 1191, 3,
+		307, 153, 0, 1191, 1184, 1, 0, 0, 0, 1192, 1194, 1, 0, 0, 0, 1194, 1195,
+		3, 315, 157, 0, 1195, 1196, 3, 327, 163, 0, 1196, 1193, 1, 0, 0, 0, 1197,
+		1199, 1, 0, 0, 0, 1199, 1200, 3, 277, 138, 0, 1200, 1201, 3, 299, 149, 0,
+		1201, 1202, 3, 293, 146, 0, 1202, 1203, 3, 289, 144, 0, 1203, 1204, 3,
+		303, 151, 0, 1204, 1198, 1, 0, 0, 0, 1205, 1207, 1, 0, 0, 0, 1207, 1208,
+		3, 299, 149, 0, 1208, 1209, 3, 293, 146, 0, 1209, 1210, 3, 303, 151, 0,
+		1210, 1211, 3, 285, 142, 0, 1211, 1212, 3, 277, 138, 0, 1212, 1213, 3,
+		311, 155, 0, 1213, 1206, 1, 0, 0, 0, 1214, 1216, 1, 0, 0, 0, 1216, 1217,
+		3, 293, 146, 0, 1217, 1218, 3, 303, 151, 0, 1218, 1219, 3, 281, 140, 0,
+		1219, 1220, 3, 311, 155, 0, 1220, 1221, 3, 285, 142, 0, 1221, 1222, 3,
+		277, 138, 0, 1222, 1223, 3, 313, 156, 0, 1223, 1224, 3, 285, 142, 0, 1224,
+		1215, 1, 0, 0, 0, 20, 0, 348, 350, 358, 372, 379, 1029, 1034, 1041, 1048,
+		1050, 1060, 1062, 1070, 1078, 1080, 1086, 1094, 1102, 1106, 1, 6, 0, 0,
 	}
 	deserializer := antlr.NewATNDeserializer(nil)
 	staticData.atn = deserializer.Deserialize(staticData.serializedATN)
@@ -820,4 +850,12 @@ const (
 	SQLLexerL_ID            = 128
 	SQLLexerL_INT           = 129
 	SQLLexerL_DEC           = 130
+	SQLLexerT_OFFSET        = 131
+	SQLLexerT_USING         = 132
+	SQLLexerT_RAW           = 133
+	SQLLexerT_ROLLUP        = 134
+	SQLLexerT_TZ            = 135
+	SQLLexerT_ALIGN         = 136
+	SQLLexerT_LINEAR        = 137
+	SQLLexerT_INCREASE      = 138
 )
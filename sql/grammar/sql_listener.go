@@ -306,6 +306,15 @@ type SQLListener interface {
 	// EnterNonReservedWords is called when entering the nonReservedWords production.
 	EnterNonReservedWords(c *NonReservedWordsContext)
 
+	// EnterUsingClause is called when entering the usingClause production.
+	EnterUsingClause(c *UsingClauseContext)
+
+	// EnterTimeZoneClause is called when entering the timeZoneClause production.
+	EnterTimeZoneClause(c *TimeZoneClauseContext)
+
+	// EnterAlignClause is called when entering the alignClause production.
+	EnterAlignClause(c *AlignClauseContext)
+
 	// ExitStatement is called when exiting the statement production.
 	ExitStatement(c *StatementContext)
 
@@ -587,4 +596,13 @@ type SQLListener interface {
 
 	// ExitNonReservedWords is called when exiting the nonReservedWords production.
 	ExitNonReservedWords(c *NonReservedWordsContext)
+
+	// ExitUsingClause is called when exiting the usingClause production.
+	ExitUsingClause(c *UsingClauseContext)
+
+	// ExitTimeZoneClause is called when exiting the timeZoneClause production.
+	ExitTimeZoneClause(c *TimeZoneClauseContext)
+
+	// ExitAlignClause is called when exiting the alignClause production.
+	ExitAlignClause(c *AlignClauseContext)
 }
@@ -305,4 +305,13 @@ type SQLVisitor interface {
 
 	// Visit a parse tree produced by SQLParser#nonReservedWords.
 	VisitNonReservedWords(ctx *NonReservedWordsContext) interface{}
+
+	// Visit a parse tree produced by SQLParser#usingClause.
+	VisitUsingClause(ctx *UsingClauseContext) interface{}
+
+	// Visit a parse tree produced by SQLParser#timeZoneClause.
+	VisitTimeZoneClause(ctx *TimeZoneClauseContext) interface{}
+
+	// Visit a parse tree produced by SQLParser#alignClause.
+	VisitAlignClause(ctx *AlignClauseContext) interface{}
 }
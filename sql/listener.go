@@ -34,13 +34,32 @@ type listener struct {
 	storageStmt        *storageStmtParser
 	requestStmt        *requestStmtParser
 	brokerStmt         *brokerStmtParser
+
+	outerQueryStmts []*queryStmtParser // outer queries of sub query in from clause
 }
 
 // EnterQueryStmt is called when production queryStmt is entered.
 func (l *listener) EnterQueryStmt(ctx *grammar.QueryStmtContext) {
+	if l.queryStmt != nil {
+		l.outerQueryStmts = append(l.outerQueryStmts, l.queryStmt)
+	}
 	l.queryStmt = newQueryStmtParse(ctx.T_EXPLAIN() != nil)
 }
 
+// ExitQueryStmt is called when production queryStmt is exited.
+func (l *listener) ExitQueryStmt(_ *grammar.QueryStmtContext) {
+	switch {
+	case len(l.outerQueryStmts) > 0:
+		outer := l.outerQueryStmts[len(l.outerQueryStmts)-1]
+		l.outerQueryStmts = l.outerQueryStmts[:len(l.outerQueryStmts)-1]
+		outer.visitSubQuery(l.queryStmt)
+		l.queryStmt = outer
+	case l.metricMetadataStmt != nil:
+		l.metricMetadataStmt.visitSubQuery()
+		l.queryStmt = nil
+	}
+}
+
 // EnterShowMetadataTypesStmt is called when production showMetadataTypesStmt is entered.
 func (l *listener) EnterShowMetadataTypesStmt(_ *grammar.ShowMetadataTypesStmtContext) {
 	l.metadataStmt = newMetadataStmtParser(stmt.MetadataTypes)
@@ -300,7 +319,7 @@ func (l *listener) EnterSelectExpr(_ *grammar.SelectExprContext) {
 // EnterWhereClause is called when production whereClause is entered.
 func (l *listener) EnterWhereClause(_ *grammar.WhereClauseContext) {
 	if l.queryStmt != nil {
-		l.queryStmt.resetExprStack()
+		l.queryStmt.visitWhereClause()
 	}
 }
 
@@ -400,6 +419,34 @@ func (l *listener) EnterGroupByKey(ctx *grammar.GroupByKeyContext) {
 	}
 }
 
+// EnterTimeZoneClause is called when production timeZoneClause is entered.
+func (l *listener) EnterTimeZoneClause(ctx *grammar.TimeZoneClauseContext) {
+	if l.queryStmt != nil {
+		l.queryStmt.visitTimeZoneClause(ctx)
+	}
+}
+
+// EnterAlignClause is called when production alignClause is entered.
+func (l *listener) EnterAlignClause(ctx *grammar.AlignClauseContext) {
+	if l.queryStmt != nil {
+		l.queryStmt.visitAlignClause(ctx)
+	}
+}
+
+// EnterUsingClause is called when production usingClause is entered.
+func (l *listener) EnterUsingClause(ctx *grammar.UsingClauseContext) {
+	if l.queryStmt != nil {
+		l.queryStmt.visitUsingClause(ctx)
+	}
+}
+
+// EnterFillOption is called when production fillOption is entered.
+func (l *listener) EnterFillOption(ctx *grammar.FillOptionContext) {
+	if l.queryStmt != nil {
+		l.queryStmt.visitFillOption(ctx)
+	}
+}
+
 // EnterSortField is called when production sortField is entered.
 func (l *listener) EnterSortField(ctx *grammar.SortFieldContext) {
 	if l.queryStmt != nil {
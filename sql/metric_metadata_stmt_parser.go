@@ -18,6 +18,9 @@
 package sql
 
 import (
+	"errors"
+	"fmt"
+
 	commonconstants "github.com/lindb/common/constants"
 
 	"github.com/lindb/lindb/pkg/collections"
@@ -50,6 +53,9 @@ func (s *metricMetadataStmtParser) build() (stmt.Statement, error) {
 	if s.err != nil {
 		return nil, s.err
 	}
+	if s.offset > 0 {
+		return nil, fmt.Errorf("offset is only supported by metric data query")
+	}
 	if s.limit <= 0 {
 		s.limit = 100
 	}
@@ -64,6 +70,11 @@ func (s *metricMetadataStmtParser) build() (stmt.Statement, error) {
 	}, nil
 }
 
+// visitSubQuery visits when production sub query of from clause is exited
+func (s *metricMetadataStmtParser) visitSubQuery() {
+	s.err = errors.New("sub query is only supported in from clause of metric data query")
+}
+
 // visitPrefix visits when production prefix expression is entered
 func (s *metricMetadataStmtParser) visitPrefix(ctx *grammar.PrefixContext) {
 	s.prefix = strutil.GetStringValue(ctx.Ident().GetText())
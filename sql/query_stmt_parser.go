@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 
 	commonconstants "github.com/lindb/common/constants"
 
@@ -47,8 +48,18 @@ type queryStmtParser struct {
 	interval int64
 	orderBy  []stmt.Expr
 
+	intervalHint stmt.IntervalHintType
+	hintInterval timeutil.Interval
+	timeZone     string
+	align        stmt.AlignType
+
 	curOrderByExpr *stmt.OrderByExpr
 	hasOrderBy     bool
+
+	fill      stmt.FillType
+	fillValue float64
+
+	subQuery *stmt.Query
 }
 
 // newQueryStmtParse create a query statement parser
@@ -90,9 +101,24 @@ func (q *queryStmtParser) build() (stmt.Statement, error) {
 	}
 
 	query.Interval = timeutil.Interval(q.interval)
+	query.IntervalHint = q.intervalHint
+	query.HintInterval = q.hintInterval
+	query.TimeZone = q.timeZone
+	query.Align = q.align
 	query.GroupBy = q.groupBy
 	query.OrderByItems = q.orderBy
 	query.Limit = q.limit
+	query.Offset = q.offset
+	query.Fill = q.fill
+	query.FillValue = q.fillValue
+	if q.subQuery != nil {
+		// outer query consumes the result of sub query, so it inherits the metric and time range of sub query
+		query.Namespace = q.subQuery.Namespace
+		query.MetricName = q.subQuery.MetricName
+		query.TimeRange = q.subQuery.TimeRange
+		q.subQuery.Explain = q.explain
+		query.SubQuery = q.subQuery
+	}
 	return query, nil
 }
 
@@ -101,7 +127,7 @@ func (q *queryStmtParser) validation() error {
 	if q.err != nil {
 		return q.err
 	}
-	if q.metricName == "" {
+	if q.metricName == "" && q.subQuery == nil {
 		return fmt.Errorf("metric name cannot be empty")
 	}
 	if len(q.selectItems) == 0 {
@@ -115,6 +141,28 @@ func (q *queryStmtParser) resetExprStack() {
 	q.exprStack = collections.NewStack()
 }
 
+// visitSubQuery visits when production sub query of from clause is exited
+func (q *queryStmtParser) visitSubQuery(sub *queryStmtParser) {
+	if sub.subQuery != nil {
+		q.err = errors.New("nested sub query is not supported")
+		return
+	}
+	subQuery, err := sub.build()
+	if err != nil {
+		q.err = err
+		return
+	}
+	q.subQuery = subQuery.(*stmt.Query)
+}
+
+// visitWhereClause visits when production where clause is entered
+func (q *queryStmtParser) visitWhereClause() {
+	if q.subQuery != nil {
+		q.err = errors.New("where clause of query with sub query must be in sub query")
+	}
+	q.resetExprStack()
+}
+
 // visitGroupByKey visits when production groupBy key expression is entered
 func (q *queryStmtParser) visitGroupByKey(ctx *grammar.GroupByKeyContext) {
 	switch {
@@ -167,6 +215,26 @@ func (q *queryStmtParser) check() error {
 	return nil
 }
 
+// visitFillOption visits when production fill option expression is entered.
+func (q *queryStmtParser) visitFillOption(ctx *grammar.FillOptionContext) {
+	switch {
+	case ctx.T_NULL() != nil:
+		q.fill = stmt.FillNull
+	case ctx.T_PREVIOUS() != nil:
+		q.fill = stmt.FillPrevious
+	case ctx.T_LINEAR() != nil:
+		q.fill = stmt.FillLinear
+	default:
+		value, err := strconv.ParseFloat(ctx.GetText(), 64)
+		if err != nil {
+			q.err = err
+			return
+		}
+		q.fill = stmt.FillValue
+		q.fillValue = value
+	}
+}
+
 // visitTimeRangeExpr visits when production timeRange expression is entered.
 func (q *queryStmtParser) visitTimeRangeExpr(ctx *grammar.TimeRangeExprContext) {
 	timeExprCtxList := ctx.AllTimeExpr()
@@ -208,6 +276,46 @@ func (q *queryStmtParser) visitTimeRangeExpr(ctx *grammar.TimeRangeExprContext)
 	}
 }
 
+// visitTimeZoneClause visits when production time zone expression is entered
+func (q *queryStmtParser) visitTimeZoneClause(ctx *grammar.TimeZoneClauseContext) {
+	zone := strutil.GetStringValue(ctx.STRING().GetText())
+	if _, err := timeutil.LoadLocation(zone); err != nil {
+		q.err = err
+		return
+	}
+	q.timeZone = zone
+}
+
+// visitAlignClause visits when production align expression is entered
+func (q *queryStmtParser) visitAlignClause(ctx *grammar.AlignClauseContext) {
+	option := strutil.GetStringValue(ctx.Ident().GetText())
+	for _, align := range []stmt.AlignType{stmt.AlignStart, stmt.AlignEnd, stmt.AlignMiddle} {
+		if strings.EqualFold(option, align.String()) {
+			q.align = align
+			return
+		}
+	}
+	q.err = fmt.Errorf("align clause requires start/end/middle, but got: %s", option)
+}
+
+// visitUsingClause visits when production using clause expression is entered
+func (q *queryStmtParser) visitUsingClause(ctx *grammar.UsingClauseContext) {
+	switch {
+	case ctx.T_RAW() != nil:
+		q.intervalHint = stmt.RawInterval
+	case ctx.T_ROLLUP() != nil:
+		q.intervalHint = stmt.RollupInterval
+	case ctx.DurationLit() != nil:
+		interval := q.parseDuration(ctx.DurationLit())
+		if interval <= 0 {
+			q.err = fmt.Errorf("using clause requires positive interval, but got: %s", ctx.DurationLit().GetText())
+			return
+		}
+		q.intervalHint = stmt.SpecInterval
+		q.hintInterval = timeutil.Interval(interval)
+	}
+}
+
 // parseDuration parses time duration from duration string
 func (q *queryStmtParser) parseDuration(ctx grammar.IDurationLitContext) int64 {
 	if ctx == nil {
@@ -310,6 +418,8 @@ func (q *queryStmtParser) visitFuncName(ctx *grammar.FuncNameContext) {
 		callExpr.FuncType = function.Quantile
 	case ctx.T_RATE() != nil:
 		callExpr.FuncType = function.Rate
+	case ctx.T_INCREASE() != nil:
+		callExpr.FuncType = function.Increase
 	}
 }
 
@@ -90,6 +90,12 @@ func TestMetricName(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, "cpu", query.MetricName)
 
+	sql = `select sum(usage) from 'cpu\..*' group by host`
+	q, err = Parse(sql)
+	assert.Nil(t, err)
+	query = q.(*stmt.Query)
+	assert.Equal(t, `cpu\..*`, query.MetricName)
+
 	sql = "select f "
 	_, err = Parse(sql)
 	assert.NotNil(t, err)
@@ -143,6 +149,16 @@ func TestSelectFuncItem(t *testing.T) {
 		Expr: &stmt.CallExpr{FuncType: function.Quantile, Params: []stmt.Expr{&stmt.NumberLiteral{Val: 0.99}}},
 	}, *selectItem)
 
+	sql = "select quantile(0.95, latency) from memory"
+	q, err = Parse(sql)
+	query = q.(*stmt.Query)
+	assert.Nil(t, err)
+	selectItem = (query.SelectItems[0]).(*stmt.SelectItem)
+	assert.Equal(t, stmt.SelectItem{
+		Expr: &stmt.CallExpr{FuncType: function.Quantile,
+			Params: []stmt.Expr{&stmt.NumberLiteral{Val: 0.95}, &stmt.FieldExpr{Name: "latency"}}},
+	}, *selectItem)
+
 	sql = "select rate(f) from memory"
 	q, err = Parse(sql)
 	query = q.(*stmt.Query)
@@ -153,6 +169,23 @@ func TestSelectFuncItem(t *testing.T) {
 		Expr: &stmt.CallExpr{FuncType: function.Rate, Params: []stmt.Expr{&stmt.FieldExpr{Name: "f"}}},
 	}, *selectItem)
 
+	sql = "select increase(f), rate(f) from memory"
+	q, err = Parse(sql)
+	query = q.(*stmt.Query)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(query.SelectItems))
+	assert.Equal(t, stmt.SelectItem{
+		Expr: &stmt.CallExpr{FuncType: function.Increase, Params: []stmt.Expr{&stmt.FieldExpr{Name: "f"}}},
+	}, *(query.SelectItems[0]).(*stmt.SelectItem))
+	assert.Equal(t, "increase(f)", query.SelectItems[0].Rewrite())
+
+	// increase as field name
+	sql = "select increase from memory"
+	q, err = Parse(sql)
+	query = q.(*stmt.Query)
+	assert.Nil(t, err)
+	assert.Equal(t, stmt.SelectItem{Expr: &stmt.FieldExpr{Name: "increase"}}, *(query.SelectItems[0]).(*stmt.SelectItem))
+
 	sql = "select last(f) from memory"
 	q, err = Parse(sql)
 	query = q.(*stmt.Query)
@@ -352,6 +385,144 @@ func TestLimit(t *testing.T) {
 	assert.Equal(t, 20, query.Limit)
 }
 
+func TestOffset(t *testing.T) {
+	sql := "select f from cpu group by host limit 10 offset 5"
+	q, err := Parse(sql)
+	assert.NoError(t, err)
+	query := q.(*stmt.Query)
+	assert.Equal(t, 10, query.Limit)
+	assert.Equal(t, 5, query.Offset)
+
+	q, err = Parse("select f from cpu LIMIT 10 OFFSET 3")
+	assert.NoError(t, err)
+	query = q.(*stmt.Query)
+	assert.Equal(t, 10, query.Limit)
+	assert.Equal(t, 3, query.Offset)
+	// offset without limit
+	_, err = Parse("select f from cpu offset 3")
+	assert.Error(t, err)
+
+	// default
+	q, err = Parse("select f from cpu limit 10")
+	assert.NoError(t, err)
+	assert.Zero(t, q.(*stmt.Query).Offset)
+
+	// invalid offset value
+	_, err = Parse("select f from cpu limit 10 offset abc")
+	assert.Error(t, err)
+	_, err = Parse("select f from cpu limit 10 offset")
+	assert.Error(t, err)
+	_, err = Parse("select f from cpu limit 10 offset 99999999999")
+	assert.Error(t, err)
+
+	// offset for other statement
+	_, err = Parse("show databases offset 10")
+	assert.Error(t, err)
+	_, err = Parse("show metrics limit 10 offset 5")
+	assert.Error(t, err)
+	// unknown trailing token
+	_, err = Parse("select f from cpu limit 10 offset 2 abc")
+	assert.Error(t, err)
+	_, err = Parse("select f from cpu limit 10 abc")
+	assert.Error(t, err)
+}
+
+func TestIntervalHint(t *testing.T) {
+	cases := []struct {
+		sql      string
+		hint     stmt.IntervalHintType
+		interval timeutil.Interval
+		wantErr  bool
+	}{
+		{sql: "select f from cpu group by host", hint: stmt.AutoInterval},
+		{sql: "select f from cpu group by time(5m) using raw", hint: stmt.RawInterval},
+		{sql: "select f from cpu group by host, time(5m) USING Rollup limit 10", hint: stmt.RollupInterval},
+		{sql: "select f from cpu group by host, time(5m) limit 10 USING Rollup", wantErr: true},
+		{sql: "select f from cpu using 5m order by f limit 10 offset 2", hint: stmt.SpecInterval, interval: 5 * timeutil.Interval(timeutil.OneMinute)},
+		{sql: "select f from cpu using 5 m", hint: stmt.SpecInterval, interval: 5 * timeutil.Interval(timeutil.OneMinute)},
+		{sql: "select f from cpu using 1h", hint: stmt.SpecInterval, interval: timeutil.Interval(timeutil.OneHour)},
+		{sql: "select f from cpu using", wantErr: true},
+		{sql: "select f from cpu using abc", wantErr: true},
+		{sql: "select f from cpu using 5", wantErr: true},
+		{sql: "select f from cpu using 5x", wantErr: true},
+		{sql: "select f from cpu using 0s", wantErr: true},
+		{sql: "select f from cpu using -5m", wantErr: true},
+		{sql: "select f from cpu using raw rollup", wantErr: true},
+		{sql: "select f from cpu using 5m 1h", wantErr: true},
+	}
+	for _, tt := range cases {
+		q, err := Parse(tt.sql)
+		if tt.wantErr {
+			assert.Error(t, err, tt.sql)
+			continue
+		}
+		assert.NoError(t, err, tt.sql)
+		query := q.(*stmt.Query)
+		assert.Equal(t, tt.hint, query.IntervalHint, tt.sql)
+		assert.Equal(t, tt.interval, query.HintInterval, tt.sql)
+	}
+	assert.Equal(t, "auto", stmt.AutoInterval.String())
+	assert.Equal(t, "spec", stmt.SpecInterval.String())
+	assert.Equal(t, "unknown", stmt.IntervalHintType(100).String())
+}
+
+func TestTimeZone(t *testing.T) {
+	cases := []struct {
+		sql     string
+		zone    string
+		wantErr bool
+	}{
+		{sql: "select f from cpu group by time(1d)"},
+		{sql: "select f from cpu group by time(1d) tz \"Asia/Shanghai\"", zone: "Asia/Shanghai"},
+		{sql: "select f from cpu group by time(1d) fill(0) TZ \"UTC\" using raw limit 10", zone: "UTC"},
+		{sql: "select f from cpu group by time(1d) tz", wantErr: true},
+		{sql: "select f from cpu group by time(1d) tz \"Unknown/Zone\"", wantErr: true},
+		{sql: "select f from cpu group by time(1d) tz 'Asia/Shanghai'", wantErr: true},
+		{sql: "select f from cpu group by time(1d) tz UTC", wantErr: true},
+		{sql: "select f from cpu group by time(1d) tz 8", wantErr: true},
+		{sql: "select f from cpu group by time(1d) limit 10 tz \"UTC\"", wantErr: true},
+		{sql: "select f from cpu tz \"UTC\"", wantErr: true},
+	}
+	for _, tt := range cases {
+		q, err := Parse(tt.sql)
+		if tt.wantErr {
+			assert.Error(t, err, tt.sql)
+			continue
+		}
+		assert.NoError(t, err, tt.sql)
+		assert.Equal(t, tt.zone, q.(*stmt.Query).TimeZone, tt.sql)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	cases := []struct {
+		sql     string
+		align   stmt.AlignType
+		wantErr bool
+	}{
+		{sql: "select f from cpu group by time(1m)", align: stmt.AlignStart},
+		{sql: "select f from cpu group by time(1m) align(start)", align: stmt.AlignStart},
+		{sql: "select f from cpu group by time(1m) fill(0) align(END)", align: stmt.AlignEnd},
+		{sql: "select f from cpu group by time(1d) tz \"Asia/Shanghai\" align(middle) using raw", align: stmt.AlignMiddle},
+		{sql: "select f from cpu group by time(1m) align", wantErr: true},
+		{sql: "select f from cpu group by time(1m) align(end", wantErr: true},
+		{sql: "select f from cpu group by time(1m) align end", wantErr: true},
+		{sql: "select f from cpu group by time(1m) align(first)", wantErr: true},
+		{sql: "select f from cpu group by time(1m) align(start) align(end)", wantErr: true},
+		{sql: "select f from cpu group by time(1m) align(end) tz \"UTC\"", wantErr: true},
+		{sql: "select f from cpu align(end)", wantErr: true},
+	}
+	for _, tt := range cases {
+		q, err := Parse(tt.sql)
+		if tt.wantErr {
+			assert.Error(t, err, tt.sql)
+			continue
+		}
+		assert.NoError(t, err, tt.sql)
+		assert.Equal(t, tt.align, q.(*stmt.Query).Align, tt.sql)
+	}
+}
+
 func TestTimeRange(t *testing.T) {
 	sql := "select f from cpu where time>'20190410 00:00:00' and time<'20190410 10:00:00'"
 	q, err := Parse(sql)
@@ -422,6 +593,39 @@ func TestGroupBy(t *testing.T) {
 	assert.Equal(t, "/data", query.GroupBy[1])
 }
 
+func TestGroupByFill(t *testing.T) {
+	cases := []struct {
+		sql       string
+		fill      stmt.FillType
+		fillValue float64
+	}{
+		{sql: "select f from cpu group by host", fill: stmt.FillDrop},
+		{sql: "select f from cpu group by host fill(NULL)", fill: stmt.FillNull},
+		{sql: "select f from cpu group by host fill(Null)", fill: stmt.FillNull},
+		{sql: "select f from cpu group by host fill(previous)", fill: stmt.FillPrevious},
+		{sql: "select f from cpu group by host fill(0)", fill: stmt.FillValue},
+		{sql: "select f from cpu group by host fill(1.5) limit 10", fill: stmt.FillValue, fillValue: 1.5},
+		{sql: "select f from cpu group by host, time(1m) fill(linear)", fill: stmt.FillLinear},
+		{sql: "select f from cpu group by time(1m) fill(LINEAR) limit 10", fill: stmt.FillLinear},
+		{sql: "select linear from cpu where linear='a' group by linear fill(previous)", fill: stmt.FillPrevious},
+	}
+	for _, tt := range cases {
+		q, err := Parse(tt.sql)
+		assert.NoError(t, err, tt.sql)
+		query := q.(*stmt.Query)
+		assert.Equal(t, tt.fill, query.Fill, tt.sql)
+		assert.Equal(t, tt.fillValue, query.FillValue, tt.sql)
+	}
+	_, err := Parse("select f from cpu group by host fill(cubic)")
+	assert.Error(t, err)
+	assert.Equal(t, "drop", stmt.FillDrop.String())
+	assert.Equal(t, "null", stmt.FillNull.String())
+	assert.Equal(t, "previous", stmt.FillPrevious.String())
+	assert.Equal(t, "value", stmt.FillValue.String())
+	assert.Equal(t, "linear", stmt.FillLinear.String())
+	assert.Equal(t, "unknown", stmt.FillType(100).String())
+}
+
 func TestEmptyCondition(t *testing.T) {
 	sql := "select f from cpu"
 	q, err := Parse(sql)
@@ -667,3 +871,54 @@ func TestOrderBy(t *testing.T) {
 		})
 	}
 }
+
+func TestSubQuery_Parse(t *testing.T) {
+	q, err := Parse("explain select max(v) as peak from (select sum(f) as v from cpu on ns " +
+		"where host='h1' and time>'2020-10-10 10:00:00' and time<'2020-10-10 11:00:00' group by host,time(1m)) " +
+		"group by time(10m) align(end) limit 10")
+	assert.NoError(t, err)
+	query := q.(*stmt.Query)
+	assert.True(t, query.Explain)
+	assert.Equal(t, "cpu", query.MetricName)
+	assert.Equal(t, "ns", query.Namespace)
+	assert.Nil(t, query.Condition)
+	assert.Equal(t, timeutil.Interval(10*timeutil.OneMinute), query.Interval)
+	assert.Equal(t, 10, query.Limit)
+	assert.Equal(t, stmt.AlignEnd, query.Align)
+	assert.Equal(t, &stmt.SelectItem{
+		Expr:  &stmt.CallExpr{FuncType: function.Max, Params: []stmt.Expr{&stmt.FieldExpr{Name: "v"}}},
+		Alias: "peak",
+	}, query.SelectItems[0])
+
+	subQuery := query.SubQuery
+	assert.NotNil(t, subQuery)
+	assert.True(t, subQuery.Explain)
+	assert.Equal(t, "cpu", subQuery.MetricName)
+	assert.Equal(t, &stmt.EqualsExpr{Key: "host", Value: "h1"}, subQuery.Condition)
+	assert.Equal(t, []string{"host"}, subQuery.GroupBy)
+	assert.Equal(t, timeutil.Interval(timeutil.OneMinute), subQuery.Interval)
+	assert.Equal(t, subQuery.TimeRange, query.TimeRange)
+	assert.Nil(t, subQuery.SubQuery)
+
+	// tag value with multi-byte characters
+	q, err = Parse("select max(v) from (select sum(f) as v from cpu where host='主机(1)') group by time(10m)")
+	assert.NoError(t, err)
+	assert.Equal(t, &stmt.EqualsExpr{Key: "host", Value: "主机(1)"}, q.(*stmt.Query).SubQuery.Condition)
+}
+
+func TestSubQuery_Parse_Fail(t *testing.T) {
+	cases := []string{
+		"select max(v) from (select sum(f) as v from cpu group by time(1m)",
+		"select max(v) from (select sum(f) as v from cpu group by time(1m)) where host='h1' group by time(10m)",
+		"select max(v) from (select max(v) as v from (select sum(f) as v from cpu)) group by time(10m)",
+		"select max(v) from (show databases) group by time(10m)",
+		"show fields from (select sum(f) as v from cpu)",
+		"show tag values from (select sum(f) as v from cpu) with key=host",
+		"select max(v) from (select sum(f) as v from cpu where) group by time(10m)",
+		"select max(v from (select sum(f) as v from cpu) group by time(10m)",
+	}
+	for _, sql := range cases {
+		_, err := Parse(sql)
+		assert.Error(t, err, sql)
+	}
+}
@@ -60,8 +60,9 @@ type MetricMetadata struct {
 	Type       MetricMetadataType // metadata suggest type
 	TagKey     string
 	Prefix     string
-	Condition  Expr // tag filter condition expression
-	Limit      int  // result set limit
+	Condition  Expr   // tag filter condition expression
+	Limit      int    // result set limit
+	After      string // resume position of paginated query, values are returned after it in order
 }
 
 // StatementType returns metadata query type.
@@ -69,6 +70,11 @@ func (q *MetricMetadata) StatementType() StatementType {
 	return MetricMetadataStatement
 }
 
+// Paginated returns if the metadata query supports cursor based pagination(metric names/tag keys).
+func (q *MetricMetadata) Paginated() bool {
+	return q.Type == Metric || q.Type == TagKey
+}
+
 // innerMetadata represents a wrapper of metadata for json encoding
 type innerMetadata struct {
 	Namespace  string             `json:"namespace,omitempty"`
@@ -78,6 +84,7 @@ type innerMetadata struct {
 	Condition  json.RawMessage    `json:"condition,omitempty"`
 	Prefix     string             `json:"prefix,omitempty"`
 	Limit      int                `json:"limit,omitempty"`
+	After      string             `json:"after,omitempty"`
 }
 
 // MarshalJSON returns json data of query
@@ -90,6 +97,7 @@ func (q *MetricMetadata) MarshalJSON() ([]byte, error) {
 		Type:       q.Type,
 		Prefix:     q.Prefix,
 		Limit:      q.Limit,
+		After:      q.After,
 	}
 	return encoding.JSONMarshal(&inner), nil
 }
@@ -113,5 +121,6 @@ func (q *MetricMetadata) UnmarshalJSON(value []byte) error {
 	q.TagKey = inner.TagKey
 	q.Prefix = inner.Prefix
 	q.Limit = inner.Limit
+	q.After = inner.After
 	return nil
 }
@@ -54,6 +54,7 @@ func TestMetadata_MarshalJSON(t *testing.T) {
 		},
 		TagKey: "tagKey",
 		Prefix: "prefix",
+		After:  "after",
 		Limit:  100,
 	}
 
@@ -75,3 +76,10 @@ func TestMetadata_Marshal_Fail(t *testing.T) {
 func TestMetricMetadata_StatementType(t *testing.T) {
 	assert.Equal(t, MetricMetadataStatement, (&MetricMetadata{}).StatementType())
 }
+
+func TestMetricMetadata_Paginated(t *testing.T) {
+	assert.True(t, (&MetricMetadata{Type: Metric}).Paginated())
+	assert.True(t, (&MetricMetadata{Type: TagKey}).Paginated())
+	assert.False(t, (&MetricMetadata{Type: TagValue}).Paginated())
+	assert.False(t, (&MetricMetadata{Type: Field}).Paginated())
+}
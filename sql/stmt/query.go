@@ -24,6 +24,114 @@ import (
 	"github.com/lindb/lindb/pkg/timeutil"
 )
 
+// FillType represents the fill policy for missing series/slots when combining metrics,
+// or the missing slots of group by time query.
+type FillType uint8
+
+// Defines all types of fill policy
+const (
+	// FillDrop drops the series/slots which missing in any metric(default).
+	FillDrop FillType = iota
+	// FillNull keeps the series, the slots which missing in any metric are null.
+	// NOTICE: lowercase null is json literal in grammar, so need to use fill(NULL).
+	FillNull
+	// FillPrevious fills the missing slot using previous value of the metric.
+	FillPrevious
+	// FillValue fills the missing series/slots using given value.
+	FillValue
+	// FillLinear fills the missing slot using linear interpolation between two real points,
+	// never extrapolates before the first or after the last real point.
+	FillLinear
+)
+
+// String returns string value of fill type.
+func (f FillType) String() string {
+	switch f {
+	case FillDrop:
+		return "drop"
+	case FillNull:
+		return "null"
+	case FillPrevious:
+		return "previous"
+	case FillValue:
+		return "value"
+	case FillLinear:
+		return "linear"
+	default:
+		return unknown
+	}
+}
+
+// IntervalHintType represents the storage interval selection hint of query.
+type IntervalHintType uint8
+
+// Defines all types of storage interval selection hint
+const (
+	// AutoInterval picks storage interval based on query time range/interval(default).
+	AutoInterval IntervalHintType = iota
+	// RawInterval forces using the smallest(raw) storage interval, like: using raw.
+	RawInterval
+	// RollupInterval forces using the biggest rollup interval which retention covers query time range, like: using rollup.
+	RollupInterval
+	// SpecInterval forces using given storage interval, like: using 5m.
+	SpecInterval
+)
+
+// String returns string value of interval hint type.
+func (h IntervalHintType) String() string {
+	switch h {
+	case AutoInterval:
+		return "auto"
+	case RawInterval:
+		return "raw"
+	case RollupInterval:
+		return "rollup"
+	case SpecInterval:
+		return "spec"
+	default:
+		return unknown
+	}
+}
+
+// AlignType represents which timestamp of time bucket reported in result of group by time query.
+type AlignType uint8
+
+// Defines all types of time bucket alignment
+const (
+	// AlignStart reports the start time of bucket(default).
+	AlignStart AlignType = iota
+	// AlignEnd reports the end time of bucket, like: align(end).
+	AlignEnd
+	// AlignMiddle reports the midpoint of bucket, like: align(middle).
+	AlignMiddle
+)
+
+// String returns string value of align type.
+func (a AlignType) String() string {
+	switch a {
+	case AlignStart:
+		return "start"
+	case AlignEnd:
+		return "end"
+	case AlignMiddle:
+		return "middle"
+	default:
+		return unknown
+	}
+}
+
+// Timestamp returns the aligned timestamp of time bucket [bucketStart, bucketEnd).
+func (a AlignType) Timestamp(bucketStart, bucketEnd int64) int64 {
+	switch a {
+	case AlignEnd:
+		return bucketEnd
+	case AlignMiddle:
+		return bucketStart + (bucketEnd-bucketStart)/2
+	default:
+		return bucketStart
+	}
+}
+
 // Query represents search statement
 type Query struct {
 	Explain     bool   // need explain query execute stat
@@ -37,10 +145,30 @@ type Query struct {
 	Interval        timeutil.Interval  // down sampling interval
 	IntervalRatio   int                // down sampling interval ratio
 	StorageInterval timeutil.Interval  // down sampling storage interval, data find
+	IntervalHint    IntervalHintType   // storage interval selection hint, like: using raw
+	HintInterval    timeutil.Interval  // storage interval for spec interval hint
+	TimeZone        string             // time zone of group by time buckets, like: tz "Asia/Shanghai"
+	ZoneInterval    timeutil.Interval  // interval of time zone buckets, Interval is the common divisor of buckets
+	Align           AlignType          // timestamp alignment of time buckets in result, like: align(end)
 
 	GroupBy      []string // group by tag keys
 	OrderByItems []Expr   // order by field expr list
 	Limit        int      // num. of time series list for result
+	Offset       int      // num. of time series skipped before limit
+	// num. of candidate groups kept by each leaf node for top-n query based on partial aggregates, set by broker plan
+	TopNCandidates int
+	// reports the max data timestamp of each shard queried for leader read consistency, set by broker plan
+	TrackTimestamp bool
+	// returns the stats of leaf task without collecting operators' stats, set by local query
+	ReturnStats bool
+
+	// fill policy for cross metric query or group by time query, like: group by host fill(0)
+	Fill      FillType
+	FillValue float64
+
+	// sub query of from clause which is executed first, outer query aggregates the result of sub query in broker,
+	// like: select max(v) from (select sum(f) as v from cpu group by time(1m)) group by time(10m)
+	SubQuery *Query
 }
 
 // StatementType returns metric query type.
@@ -65,10 +193,22 @@ type innerQuery struct {
 	Interval        timeutil.Interval  `json:"interval,omitempty"`
 	IntervalRatio   int                `json:"intervalRatio,omitempty"`
 	StorageInterval timeutil.Interval  `json:"storageInterval,omitempty"`
+	IntervalHint    IntervalHintType   `json:"intervalHint,omitempty"`
+	HintInterval    timeutil.Interval  `json:"hintInterval,omitempty"`
+	TimeZone        string             `json:"timeZone,omitempty"`
+	ZoneInterval    timeutil.Interval  `json:"zoneInterval,omitempty"`
+	Align           AlignType          `json:"align,omitempty"`
 
-	GroupBy      []string          `json:"groupBy,omitempty"`
-	OrderByItems []json.RawMessage `json:"orderByItems,omitempty"`
-	Limit        int               `json:"limit,omitempty"`
+	GroupBy        []string          `json:"groupBy,omitempty"`
+	OrderByItems   []json.RawMessage `json:"orderByItems,omitempty"`
+	Limit          int               `json:"limit,omitempty"`
+	Offset         int               `json:"offset,omitempty"`
+	TopNCandidates int               `json:"topNCandidates,omitempty"`
+	TrackTimestamp bool              `json:"trackTimestamp,omitempty"`
+	ReturnStats    bool              `json:"returnStats,omitempty"`
+	Fill           FillType          `json:"fill,omitempty"`
+	FillValue      float64           `json:"fillValue,omitempty"`
+	SubQuery       *Query            `json:"subQuery,omitempty"`
 }
 
 // MarshalJSON returns json data of query
@@ -82,8 +222,20 @@ func (q *Query) MarshalJSON() ([]byte, error) {
 		Interval:        q.Interval,
 		IntervalRatio:   q.IntervalRatio,
 		StorageInterval: q.StorageInterval,
+		IntervalHint:    q.IntervalHint,
+		HintInterval:    q.HintInterval,
+		TimeZone:        q.TimeZone,
+		ZoneInterval:    q.ZoneInterval,
+		Align:           q.Align,
 		GroupBy:         q.GroupBy,
 		Limit:           q.Limit,
+		Offset:          q.Offset,
+		TopNCandidates:  q.TopNCandidates,
+		TrackTimestamp:  q.TrackTimestamp,
+		ReturnStats:     q.ReturnStats,
+		Fill:            q.Fill,
+		FillValue:       q.FillValue,
+		SubQuery:        q.SubQuery,
 	}
 	for _, item := range q.SelectItems {
 		inner.SelectItems = append(inner.SelectItems, Marshal(item))
@@ -134,8 +286,20 @@ func (q *Query) UnmarshalJSON(value []byte) error {
 	q.Interval = inner.Interval
 	q.IntervalRatio = inner.IntervalRatio
 	q.StorageInterval = inner.StorageInterval
+	q.IntervalHint = inner.IntervalHint
+	q.HintInterval = inner.HintInterval
+	q.TimeZone = inner.TimeZone
+	q.ZoneInterval = inner.ZoneInterval
+	q.Align = inner.Align
 	q.GroupBy = inner.GroupBy
 	q.OrderByItems = orderByItems
 	q.Limit = inner.Limit
+	q.Offset = inner.Offset
+	q.TopNCandidates = inner.TopNCandidates
+	q.TrackTimestamp = inner.TrackTimestamp
+	q.ReturnStats = inner.ReturnStats
+	q.Fill = inner.Fill
+	q.FillValue = inner.FillValue
+	q.SubQuery = inner.SubQuery
 	return nil
 }
@@ -62,9 +62,14 @@ func TestQuery_Marshal(t *testing.T) {
 				Right:    &EqualsExpr{Key: "path", Value: "/home"},
 			}},
 		},
-		TimeRange: timeutil.TimeRange{Start: 10, End: 30},
-		Interval:  1000,
-		GroupBy:   []string{"a", "b", "c"},
+		TimeRange:    timeutil.TimeRange{Start: 10, End: 30},
+		Interval:     1000,
+		IntervalHint: SpecInterval,
+		HintInterval: 5000,
+		TimeZone:     "Asia/Shanghai",
+		ZoneInterval: 86400000,
+		Align:        AlignMiddle,
+		GroupBy:      []string{"a", "b", "c"},
 		OrderByItems: []Expr{
 			&FieldExpr{Name: "b"},
 			&CallExpr{
@@ -72,7 +77,19 @@ func TestQuery_Marshal(t *testing.T) {
 				Params:   []Expr{&FieldExpr{Name: "c"}},
 			},
 		},
-		Limit: 100,
+		Limit:          100,
+		Offset:         10,
+		TopNCandidates: 330,
+		TrackTimestamp: true,
+		ReturnStats:    true,
+		Fill:           FillValue,
+		FillValue:      1.5,
+		SubQuery: &Query{
+			MetricName:  "cpu",
+			SelectItems: []Expr{&SelectItem{Expr: &FieldExpr{Name: "f"}, Alias: "v"}},
+			TimeRange:   timeutil.TimeRange{Start: 10, End: 30},
+			Interval:    1000,
+		},
 	}
 
 	data := encoding.JSONMarshal(&query)
@@ -95,6 +112,17 @@ func TestQuery_Marshal_Fail(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestAlignType(t *testing.T) {
+	assert.Equal(t, "start", AlignStart.String())
+	assert.Equal(t, "end", AlignEnd.String())
+	assert.Equal(t, "middle", AlignMiddle.String())
+	assert.Equal(t, "unknown", AlignType(100).String())
+
+	assert.Equal(t, int64(10), AlignStart.Timestamp(10, 20))
+	assert.Equal(t, int64(20), AlignEnd.Timestamp(10, 20))
+	assert.Equal(t, int64(15), AlignMiddle.Timestamp(10, 20))
+}
+
 func TestQuery_StatementType(t *testing.T) {
 	assert.Equal(t, QueryStatement, (&Query{}).StatementType())
 }
@@ -18,8 +18,11 @@
 package tsdb
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"strconv"
 	"sync"
 	"time"
@@ -27,12 +30,16 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/lindb/common/pkg/fasttime"
+	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/fault"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -43,6 +50,9 @@ import (
 
 //go:generate mockgen -source=./data_family.go -destination=./data_family_mock.go -package=tsdb
 
+// maxFlushFailures represents the num. of consecutive flush failures before family becomes unhealthy.
+const maxFlushFailures = 3
+
 // DataFamily represents a storage unit for time series data, support multi-version.
 type DataFamily interface {
 	// Indicator returns data family indicator's string.
@@ -57,14 +67,28 @@ type DataFamily interface {
 	TimeRange() timeutil.TimeRange
 	// Family returns the raw kv family
 	Family() kv.Family
-	// WriteRows writes metric rows with same family in batch.
-	WriteRows(rows []metric.StorageRow) error
+	// WriteRows writes metric rows with same family in batch, which are replicated from leader,
+	// returns the backoff hint for writer when memory database is above its soft threshold(flush falls behind).
+	WriteRows(leader int32, rows []metric.StorageRow) (retryAfter time.Duration, err error)
 	// ValidateSequence validates replica sequence if valid.
 	ValidateSequence(leader int32, seq int64) bool
 	// CommitSequence commits written sequence after write data.
 	CommitSequence(leader int32, seq int64)
+	// GetLeaderWriteState returns the write state(failures/last error) of rows replicated from leader.
+	GetLeaderWriteState(leader int32) models.LeaderWriteState
 	// AckSequence acknowledges sequence after memory database flush successfully.
 	AckSequence(leader int32, fn func(seq int64))
+	// IsTiered returns if the files of family are moved to object storage.
+	IsTiered() bool
+	// KeepLocal returns if the family is un-tiered on demand, which is skipped by scheduled tiering until reopened.
+	KeepLocal() bool
+	// Tier moves the files of family to object storage, family must have no data in memory.
+	Tier(ctx context.Context, throttle kv.Throttle) error
+	// Untier downloads the files of family from object storage back to local disk.
+	Untier(ctx context.Context) error
+	// InstallSnapshot installs the family files transferred from leader into empty family,
+	// the write sequences of family are reset to the sequence cut of snapshot.
+	InstallSnapshot(files []kv.ExternalFile, sequences map[int32]int64) error
 
 	// NeedFlush checks if memory database need to flush.
 	NeedFlush() bool
@@ -74,6 +98,14 @@ type DataFamily interface {
 	Flush() error
 	// MemDBSize returns memory database heap size.
 	MemDBSize() int64
+	// IsHealthy returns false if flush job fails persistently.
+	IsHealthy() bool
+	// IngestionLag returns the duration between now and the latest data written into family,
+	// returns the duration since family start time if no data written after family opened.
+	IngestionLag() time.Duration
+	// MaxDataTimestamp returns the timestamp of the highest time slot written into family,
+	// returns 0 if no data written after family opened.
+	MaxDataTimestamp() int64
 
 	// GetState returns the current state include memory database state.
 	GetState() models.DataFamilyState
@@ -87,6 +119,9 @@ type DataFamily interface {
 	// if ref==0, no data will write this family.
 	Release()
 
+	// GetSeriesIDs returns the series ids of metric written in family, includes memory database and files.
+	GetSeriesIDs(metricID metric.ID) (*roaring.Bitmap, error)
+
 	// DataFilter filters data under data family based on query condition
 	flow.DataFilter
 	io.Closer
@@ -95,6 +130,7 @@ type DataFamily interface {
 // dataFamily represents a wrapper of kv store's family with basic info
 type dataFamily struct {
 	indicator     string // database + shard + family time
+	database      string
 	shard         Shard
 	segment       Segment
 	interval      timeutil.Interval
@@ -106,21 +142,48 @@ type dataFamily struct {
 
 	mutableMemDB   memdb.MemoryDatabase
 	immutableMemDB memdb.MemoryDatabase
-
-	// leader => seq
-	seq          map[int32]atomic.Int64
+	ttlJitter      float64 // random ratio in [0, 1) of mutable memory database, scaled by ttl jitter config
+
+	// created time of memory database not flushed before restart, restored into the first memory database,
+	// so that the ttl of memory database continues rather than resets after restart.
+	restoredMemDBCreatedTime int64
+	// created time of memory database persisted in kv version, guarded by memory database state lock
+	persistedMemDBCreatedTime int64
+	memDBStateLock            sync.Mutex
+
+	// leader => write context(replica sequence/write errors),
+	// guarded by leader lock, sequence ops of leaders never contend with family mutex.
+	leaders      map[int32]*leaderWriter
+	leaderLock   sync.RWMutex
 	immutableSeq map[int32]int64
 	persistSeq   map[int32]atomic.Int64
 
 	callbacks map[int32][]func(seq int64) // leader => callback
 
+	latestSlots map[int32]uint16 // leader => the highest time slot written
+
 	isFlushing     atomic.Bool    // restrict flusher concurrency
 	flushCondition sync.WaitGroup // flush condition
+	flushFailures  atomic.Int32   // num. of consecutive flush failures
+	// flushed data mismatches memory database, immutable memory database is retained for debugging
+	flushVerifyFailed atomic.Bool
+
+	tiered    atomic.Bool // files moved to object storage, writes/flushes are rejected
+	keepLocal atomic.Bool // un-tiered on demand, skipped by scheduled tiering
 
 	ref          atomic.Int32 // ref count for writing
 	lastReadTime *atomic.Int64
 	mutex        sync.Mutex
 
+	engineCtx *engineContext // resources of engine which family belongs to
+
+	// slot range of metric data in file, file path => metric id => slot range,
+	// files removed by compaction are invalidated when version of kv family changed.
+	slotRanges            map[string]map[uint32]timeutil.SlotRange
+	diskSize, numOfFiles  int64 // disk stats of kv family reported to statistics
+	removeVersionListener func()
+	versionLock           sync.Mutex
+
 	statistics *metrics.FamilyStatistics
 	logger     *logger.Logger
 }
@@ -134,9 +197,11 @@ func newDataFamily(
 	familyTime int64,
 	family kv.Family,
 ) DataFamily {
-	dbName := shard.Database().Name()
+	db := shard.Database()
+	dbName := db.Name()
 	shardIDStr := strconv.Itoa(int(shard.ShardID()))
 	f := &dataFamily{
+		database:      dbName,
 		shard:         shard,
 		segment:       segment,
 		interval:      interval,
@@ -145,13 +210,14 @@ func newDataFamily(
 		familyTime:    familyTime,
 		family:        family,
 		lastFlushTime: timeutil.Now(),
-		seq:           make(map[int32]atomic.Int64),
+		leaders:       make(map[int32]*leaderWriter),
 		persistSeq:    make(map[int32]atomic.Int64),
 		callbacks:     make(map[int32][]func(seq int64)),
 		lastReadTime:  atomic.NewInt64(fasttime.UnixMilliseconds()),
+		engineCtx:     engineContextOf(db),
+		slotRanges:    make(map[string]map[uint32]timeutil.SlotRange),
 
-		statistics: metrics.NewFamilyStatistics(dbName, shardIDStr),
-		logger:     logger.GetLogger("TSDB", "Family"),
+		logger: logger.GetLogger("TSDB", "Family"),
 	}
 	// get current persist write sequence
 	snapshot := family.GetSnapshot()
@@ -160,19 +226,39 @@ func newDataFamily(
 	// init replica/ack sequence
 	sequences := snapshot.GetCurrent().GetSequences()
 	for leader, seq := range sequences {
-		f.seq[leader] = *atomic.NewInt64(seq)
+		f.leaders[leader] = newLeaderWriter(seq)
 		f.persistSeq[leader] = *atomic.NewInt64(seq)
 	}
+	f.tiered.Store(family.IsTiered())
+	f.restoredMemDBCreatedTime = snapshot.GetCurrent().GetMemDBCreatedTime()
+	f.persistedMemDBCreatedTime = f.restoredMemDBCreatedTime
 
 	f.indicator = fmt.Sprintf("%s/%s/%s", dbName, shardIDStr,
 		timeutil.FormatTimestamp(familyTime, timeutil.DataTimeFormat4))
+	f.statistics = metrics.NewFamilyStatistics(dbName, shardIDStr, f.indicator)
 
-	// add data family into global family manager
-	GetFamilyManager().AddFamily(f)
-	f.statistics.ActiveFamilies.Incr()
+	// init disk stats, then refresh them after flush/compaction
+	files := snapshot.GetCurrent().GetAllFiles()
+	var diskSize int64
+	for _, file := range files {
+		diskSize += int64(file.GetFileSize())
+	}
+	f.onVersionChanged(kv.VersionChange{DiskSize: diskSize, NumOfFiles: len(files)})
+	f.removeVersionListener = family.AddVersionListener(f.onVersionChanged)
+
+	// add data family into family manager of engine
+	f.engineContext().familyManager().AddFamily(f)
 	return f
 }
 
+// engineContext returns the resources of engine which family belongs to.
+func (f *dataFamily) engineContext() *engineContext {
+	if f.engineCtx == nil {
+		return defaultEngineContext
+	}
+	return f.engineCtx
+}
+
 // Indicator returns data family indicator's string.
 func (f *dataFamily) Indicator() string {
 	return f.indicator
@@ -203,11 +289,19 @@ func (f *dataFamily) FamilyTime() int64 {
 	return f.familyTime
 }
 
-// NeedFlush checks if memory database need to flush.
-func (f *dataFamily) NeedFlush() bool {
-	if f.IsFlushing() {
+// NeedFlush checks if memory database need to flush,
+// persists the created time of memory database if it need not flush.
+func (f *dataFamily) NeedFlush() (need bool) {
+	if f.IsFlushing() || f.IsTiered() {
 		return false
 	}
+	var memDBCreatedTime int64
+	defer func() {
+		// persist after family lock released, avoid blocking writes
+		if !need && memDBCreatedTime > 0 {
+			f.persistMemDBCreatedTime(memDBCreatedTime)
+		}
+	}()
 
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
@@ -221,7 +315,9 @@ func (f *dataFamily) NeedFlush() bool {
 		return false
 	}
 
-	ttl := config.GlobalStorageConfig().TSDB.MutableMemDBTTL.Duration()
+	memDBCreatedTime = f.mutableMemDB.CreatedTime()
+	ttl := jitterTTL(config.GlobalStorageConfig().TSDB.MutableMemDBTTL.Duration(),
+		config.GlobalStorageConfig().TSDB.MutableMemDBTTLJitter, f.ttlJitter)
 	maxMemDBSize := config.GlobalStorageConfig().TSDB.MaxMemDBSize
 
 	f.logger.Info("check memory database if need flush",
@@ -232,6 +328,16 @@ func (f *dataFamily) NeedFlush() bool {
 		logger.String("max-memdb-size", maxMemDBSize.String()),
 	)
 
+	if f.engineContext().familyManager().IsFlushPaused(f.database) {
+		// flushing is paused for maintenance, only the hard memory ceiling forces a flush
+		if f.mutableMemDB.MemSize() < hardMemDBSizeCeiling(maxMemDBSize) {
+			return false
+		}
+		f.logger.Warn("memory database is above hard ceiling, force flush while flushing is paused",
+			logger.String("family", f.indicator))
+		metrics.FlushCheckerStatistics.ForcedFlushes.WithTagValues(f.database).Incr()
+		return true
+	}
 	// check memory database's uptime
 	if f.mutableMemDB.Uptime() >= ttl {
 		return true
@@ -243,6 +349,38 @@ func (f *dataFamily) NeedFlush() bool {
 	return false
 }
 
+// jitterTTL shortens the ttl of memory database by jitter ratio multiplied by random ratio in [0, 1),
+// spreads out the expirations of memory databases created at the same time(e.g. after restart).
+func jitterTTL(ttl time.Duration, jitter, random float64) time.Duration {
+	if jitter <= 0 {
+		return ttl
+	}
+	return ttl - time.Duration(float64(ttl)*jitter*random)
+}
+
+// persistMemDBCreatedTime persists the created time of memory database into kv version,
+// which is restored after restart, skips if family is flushing(flush clears it).
+func (f *dataFamily) persistMemDBCreatedTime(createdTime int64) {
+	f.memDBStateLock.Lock()
+	defer f.memDBStateLock.Unlock()
+
+	if f.IsFlushing() || createdTime == f.persistedMemDBCreatedTime {
+		return
+	}
+	if err := f.family.CommitMemDBCreatedTime(createdTime); err != nil {
+		f.logger.Warn("persist created time of memory database failure",
+			logger.String("family", f.indicator), logger.Error(err))
+		return
+	}
+	f.persistedMemDBCreatedTime = createdTime
+}
+
+// hardMemDBSizeCeiling returns the hard ceiling of memory database size, memory database above it
+// is flushed even if flushing is paused, where the write backoff of writer reaches the max.
+func hardMemDBSizeCeiling(maxMemDBSize ltoml.Size) int64 {
+	return 2 * int64(maxMemDBSize)
+}
+
 // IsFlushing returns it has flush job doing in background.
 func (f *dataFamily) IsFlushing() bool {
 	return f.isFlushing.Load()
@@ -250,6 +388,9 @@ func (f *dataFamily) IsFlushing() bool {
 
 // Flush flushes memory database.
 func (f *dataFamily) Flush() error {
+	if f.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
 	if f.isFlushing.CAS(false, true) {
 		defer func() {
 			// mark flush job complete, notify
@@ -262,6 +403,10 @@ func (f *dataFamily) Flush() error {
 
 		startTime := time.Now()
 
+		if err := fault.Inject(fault.FlushBeforeSwapMemDB); err != nil {
+			f.flushFailures.Inc()
+			return err
+		}
 		// add lock when switch memory database
 		f.mutex.Lock()
 		if f.immutableMemDB != nil || f.mutableMemDB == nil || f.mutableMemDB.NumOfMetrics() == 0 {
@@ -270,20 +415,33 @@ func (f *dataFamily) Flush() error {
 			return nil
 		}
 		waitingFlushMemDB := f.mutableMemDB
+		// defer flush job if free disk space is not enough, avoid leaving partially written files
+		if enough, free := hasEnoughDiskSpace(waitingFlushMemDB.MemSize()); !enough {
+			f.mutex.Unlock()
+			f.statistics.MemDBFlushDeferred.Incr()
+			f.logger.Warn("defer flush memory database, because of insufficient disk space",
+				logger.String("family", f.indicator),
+				logger.Int64("memDBSize", waitingFlushMemDB.MemSize()),
+				logger.Any("free", free))
+			return constants.ErrInsufficientDiskSpace
+		}
 		f.immutableMemDB = waitingFlushMemDB
 		f.mutableMemDB = nil
 		// mark mutable memory database nil, write data will be created
 		waitingFlushMemDB.MarkReadOnly()
-		immutableSeq := make(map[int32]int64)
-		for leader, seq := range f.seq {
-			immutableSeq[leader] = seq.Load()
-		}
+		immutableSeq := f.replicaSequences()
 		f.immutableSeq = immutableSeq
 		f.mutex.Unlock()
 
+		if err := fault.Inject(fault.FlushAfterSwapMemDB); err != nil {
+			f.flushFailures.Inc()
+			return err
+		}
 		if err := f.flushMemoryDatabase(immutableSeq, waitingFlushMemDB); err != nil {
+			f.flushFailures.Inc()
 			return err
 		}
+		f.flushFailures.Store(0)
 
 		// flush success, mark immutable memory database nil
 		f.mutex.Lock()
@@ -309,8 +467,16 @@ func (f *dataFamily) Flush() error {
 	return nil
 }
 
+// IsHealthy returns false if flush job fails persistently or flushed data mismatches memory database.
+func (f *dataFamily) IsHealthy() bool {
+	return f.flushFailures.Load() < maxFlushFailures && !f.flushVerifyFailed.Load()
+}
+
 // Compact compacts all data if long term no data write.
 func (f *dataFamily) Compact() {
+	if f.engineContext().readOnly {
+		return
+	}
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 	if f.mutableMemDB != nil || f.immutableMemDB != nil {
@@ -365,6 +531,7 @@ func (f *dataFamily) Evict() {
 			f.logger.Error("close family err when evict", logger.String("family", f.Indicator()))
 		} else {
 			f.segment.EvictFamily(f.familyTime)
+			f.statistics.Close()
 		}
 	}
 }
@@ -384,17 +551,42 @@ func (f *dataFamily) MemDBSize() int64 {
 }
 
 // Filter filters the data based on metric/version/seriesIDs,
-// if it finds data then returns the FilterResultSet, else returns nil
+// if it finds data then returns the FilterResultSet, else returns nil,
+// returns error only if it fails to read data, data not found isn't an error.
 func (f *dataFamily) Filter(executeCtx *flow.ShardExecuteContext) (resultSet []flow.FilterResultSet, err error) {
 	f.lastReadTime.Store(fasttime.UnixMilliseconds())
+	if err := executeCtx.CheckTimeout(); err != nil {
+		return nil, err
+	}
+	// collect filter stats only for explain query
+	stats := executeCtx.FamilyFilterStats
+	var start time.Time
+	if stats != nil {
+		start = time.Now()
+	}
 	memRS, err := f.memoryFilter(executeCtx)
 	if err != nil {
 		return nil, err
 	}
+	if stats != nil {
+		stats.MemoryFilterCost = time.Since(start).Nanoseconds()
+		stats.NumOfMemoryRS = len(memRS)
+	}
+	// check query deadline before reading files, which is more expensive
+	if err := executeCtx.CheckTimeout(); err != nil {
+		return nil, err
+	}
+	if stats != nil {
+		start = time.Now()
+	}
 	fileRS, err := f.fileFilter(executeCtx)
 	if err != nil {
 		return nil, err
 	}
+	if stats != nil {
+		stats.FileFilterCost = time.Since(start).Nanoseconds()
+		stats.NumOfFileRS = len(fileRS)
+	}
 	resultSet = append(resultSet, memRS...)
 	resultSet = append(resultSet, fileRS...)
 	return
@@ -406,15 +598,10 @@ func (f *dataFamily) GetState() models.DataFamilyState {
 	defer f.mutex.Unlock()
 
 	ackSequences := make(map[int32]int64)
-	replicaSequences := make(map[int32]int64)
-
 	for k, v := range f.persistSeq {
 		ackSequences[k] = v.Load()
 	}
-
-	for k, v := range f.seq {
-		replicaSequences[k] = v.Load()
-	}
+	replicaSequences := f.replicaSequences()
 
 	var memoryDatabaseState []models.MemoryDatabaseState
 
@@ -441,16 +628,68 @@ func (f *dataFamily) GetState() models.DataFamilyState {
 		FamilyTime:       timeutil.FormatTimestamp(f.familyTime, timeutil.DataTimeFormat2),
 		AckSequences:     ackSequences,
 		ReplicaSequences: replicaSequences,
+		LatestTimestamps: f.latestTimestamps(),
+		LeaderWrites:     f.leaderWriteStates(),
 		MemoryDatabases:  memoryDatabaseState,
+		FlushPaused:      f.engineContext().familyManager().IsFlushPaused(f.database),
+		Tiered:           f.IsTiered(),
+		VerifyFailed:     f.flushVerifyFailed.Load(),
 	}
-
+	if f.family != nil {
+		state.Compaction = f.family.CompactionState()
+	}
+	state.IngestionLag = f.ingestionLag(state.LatestTimestamps)
 	return state
 }
 
+// GetSeriesIDs returns the series ids of metric written in family, includes memory database and files.
+func (f *dataFamily) GetSeriesIDs(metricID metric.ID) (*roaring.Bitmap, error) {
+	result := roaring.New()
+	f.mutex.Lock()
+	for _, memDB := range []memdb.MemoryDatabase{f.mutableMemDB, f.immutableMemDB} {
+		if memDB == nil {
+			continue
+		}
+		if seriesIDs := memDB.GetSeriesIDs(metricID); seriesIDs != nil {
+			result.Or(seriesIDs)
+		}
+	}
+	f.mutex.Unlock()
+
+	snapShot := f.family.GetSnapshot()
+	defer snapShot.Close()
+
+	metricKey := uint32(metricID)
+	readers, err := snapShot.FindReaders(metricKey)
+	if err != nil {
+		return nil, err
+	}
+	for _, reader := range readers {
+		value, err0 := reader.Get(metricKey)
+		if errors.Is(err0, table.ErrKeyNotExist) {
+			// metric data not found
+			continue
+		}
+		if err0 != nil {
+			return nil, err0
+		}
+		r, err := newReaderFunc(reader.Path(), value)
+		if err != nil {
+			return nil, err
+		}
+		result.Or(r.GetSeriesIDs())
+	}
+	return result, nil
+}
+
 func (f *dataFamily) memoryFilter(shardExecuteContext *flow.ShardExecuteContext) (resultSet []flow.FilterResultSet, err error) {
 	memFilter := func(memDB memdb.MemoryDatabase) error {
 		rs, err := memDB.Filter(shardExecuteContext)
 		if err != nil {
+			if errors.Is(err, constants.ErrNotFound) {
+				// data not found in this memory database, try others
+				return nil
+			}
 			return err
 		}
 		resultSet = append(resultSet, rs...)
@@ -480,7 +719,14 @@ func (f *dataFamily) fileFilter(shardExecuteContext *flow.ShardExecuteContext) (
 		}
 	}()
 	metricKey := uint32(shardExecuteContext.StorageExecuteCtx.MetricID)
+	// files of tiered family are located through local copies, may need downloading
+	tiered := f.IsTiered()
+	startTime := time.Now()
 	readers, err := snapShot.FindReaders(metricKey)
+	if tiered {
+		metrics.TierStatistics.Reads.Incr()
+		metrics.TierStatistics.ReadDuration.UpdateSince(startTime)
+	}
 	if err != nil {
 		engineLogger.Error("filter data family error", logger.Error(err))
 		return
@@ -488,16 +734,27 @@ func (f *dataFamily) fileFilter(shardExecuteContext *flow.ShardExecuteContext) (
 	querySlotRange := shardExecuteContext.StorageExecuteCtx.CalcSourceSlotRange(f.familyTime)
 	var metricReaders []metricsdata.MetricReader
 	for _, reader := range readers {
+		if slotRange, ok := f.getSlotRange(reader.Path(), metricKey); ok && !slotRange.Overlap(querySlotRange) {
+			// skip the file whose metric data is out of query time range
+			continue
+		}
 		value, err0 := reader.Get(metricKey)
-		// metric data not found
-		if err0 != nil {
+		if errors.Is(err0, table.ErrKeyNotExist) {
+			// metric data not found
 			continue
 		}
+		if err0 != nil {
+			return nil, err0
+		}
+		if tiered {
+			metrics.TierStatistics.ReadBytes.Add(float64(len(value)))
+		}
 		r, err := newReaderFunc(reader.Path(), value)
 		if err != nil {
 			return nil, err
 		}
 		storageSlotRange := r.GetTimeRange()
+		f.putSlotRange(reader.Path(), metricKey, storageSlotRange)
 		if storageSlotRange.Overlap(querySlotRange) {
 			metricReaders = append(metricReaders, r)
 		}
@@ -506,20 +763,108 @@ func (f *dataFamily) fileFilter(shardExecuteContext *flow.ShardExecuteContext) (
 		return
 	}
 	filter := newFilterFunc(f.timeRange.Start, snapShot, metricReaders)
-	return filter.Filter(shardExecuteContext.SeriesIDsAfterFiltering, shardExecuteContext.StorageExecuteCtx.Fields)
+	resultSet, err = filter.Filter(shardExecuteContext.SeriesIDsAfterFiltering,
+		shardExecuteContext.StorageExecuteCtx.Fields, querySlotRange)
+	if errors.Is(err, constants.ErrNotFound) {
+		// fields/series not found in files
+		return nil, nil
+	}
+	return resultSet, err
+}
+
+// getSlotRange returns the slot range of metric data in file if it's cached.
+func (f *dataFamily) getSlotRange(filePath string, metricID uint32) (slotRange timeutil.SlotRange, ok bool) {
+	f.versionLock.Lock()
+	defer f.versionLock.Unlock()
+
+	slotRange, ok = f.slotRanges[filePath][metricID]
+	return
 }
 
-// WriteRows writes metric rows with same family in batch.
-func (f *dataFamily) WriteRows(rows []metric.StorageRow) error {
+// putSlotRange caches the slot range of metric data in file.
+func (f *dataFamily) putSlotRange(filePath string, metricID uint32, slotRange timeutil.SlotRange) {
+	f.versionLock.Lock()
+	defer f.versionLock.Unlock()
+
+	if f.slotRanges == nil {
+		f.slotRanges = make(map[string]map[uint32]timeutil.SlotRange)
+	}
+	slotRanges, ok := f.slotRanges[filePath]
+	if !ok {
+		slotRanges = make(map[uint32]timeutil.SlotRange)
+		f.slotRanges[filePath] = slotRanges
+	}
+	slotRanges[metricID] = slotRange
+}
+
+// onVersionChanged invalidates the slot ranges of files removed by compaction, then refreshes disk stats.
+func (f *dataFamily) onVersionChanged(change kv.VersionChange) {
+	f.versionLock.Lock()
+	defer f.versionLock.Unlock()
+
+	for _, filePath := range change.RemovedFiles {
+		delete(f.slotRanges, filePath)
+	}
+	f.statistics.DiskSize.Add(float64(change.DiskSize - f.diskSize))
+	f.statistics.NumOfFiles.Add(float64(int64(change.NumOfFiles) - f.numOfFiles))
+	f.diskSize = change.DiskSize
+	f.numOfFiles = int64(change.NumOfFiles)
+}
+
+// WriteRows writes metric rows with same family in batch,
+// returns the backoff hint for writer when memory database is above its soft threshold(flush falls behind).
+func (f *dataFamily) WriteRows(leader int32, rows []metric.StorageRow) (retryAfter time.Duration, err error) {
 	if len(rows) == 0 {
-		return nil
+		return 0, nil
+	}
+	// isolate write failure of leader, panic is recovered after resources of batch released(registered first),
+	// so that one bad leader's batch never blocks writing of other leaders.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", constants.ErrWritePanic, r)
+			retryAfter = 0
+			f.logger.Error("panic when writing rows",
+				logger.String("family", f.indicator), logger.Any("leader", leader),
+				logger.Any("err", r), logger.Stack())
+		}
+		if err != nil {
+			f.getOrCreateLeaderWriter(leader).recordFailure(err)
+		}
+	}()
+	if f.engineContext().readOnly {
+		return 0, constants.ErrReadOnly
 	}
 
+	dbName := f.shard.Database().Name()
+	deadLetter := GetDeadLetterSink()
+	if IsWriteRejected() {
+		// all rows are rejected
+		f.statistics.WriteMetricFailures.Add(float64(len(rows)))
+		rejectedWrites.Add(int64(len(rows)))
+		for idx := range rows {
+			deadLetter.Send(dbName, f.indicator, DeadLetterRejected, &rows[idx])
+		}
+		return 0, constants.ErrWriteRejected
+	}
+	now := timeutil.Now()
+	if f.isTooLate(now) {
+		// family is out of out-of-order window, all rows are dropped
+		f.statistics.TooLateDropped.Add(float64(len(rows)))
+		for idx := range rows {
+			deadLetter.Send(dbName, f.indicator, DeadLetterTooLate, &rows[idx])
+		}
+		return 0, nil
+	}
+	late := f.isLate(now)
+	// memory database is re-created if flushed before, late rows are visible after next flush
 	db, err := f.GetOrCreateMemoryDatabase(f.familyTime)
 	if err != nil {
 		// all rows are dropped
 		f.statistics.WriteMetricFailures.Add(float64(len(rows)))
-		return err
+		for idx := range rows {
+			deadLetter.Send(dbName, f.indicator, DeadLetterNoMemDB+": "+err.Error(), &rows[idx])
+		}
+		return 0, err
 	}
 	db.AcquireWrite()
 	releaseFunc := db.WithLock()
@@ -531,14 +876,43 @@ func (f *dataFamily) WriteRows(rows []metric.StorageRow) error {
 		releaseFunc()
 	}()
 
+	// track the highest time slot written of batch for ingestion lag
+	latestSlot := -1
+	defer func() {
+		if latestSlot >= 0 {
+			f.recordLatestSlot(leader, uint16(latestSlot))
+		}
+	}()
+
+	// aggregate points of consecutive rows with same metric, rows of batch are mostly grouped by metric
+	usageTracker := GetMetricUsageTracker()
+	var usageMetricID metric.ID
+	var usageMetricName []byte
+	usagePoints := 0
+	defer func() {
+		if usagePoints > 0 {
+			usageTracker.RecordWrite(dbName, usageMetricID, usageMetricName, usagePoints)
+		}
+	}()
+
 	for idx := range rows {
 		row := rows[idx]
 		if !row.Writable {
 			f.statistics.WriteMetricFailures.Incr()
+			reason := DeadLetterUnwritable
+			if row.LookupErr != nil {
+				reason += ": " + row.LookupErr.Error()
+			}
+			deadLetter.Send(dbName, f.indicator, reason, &row)
 			continue
 		}
+		timestamp := row.Timestamp()
+		if timestamp < f.familyTime {
+			// rows routed to the latest family when wall clock regresses, write into the first slot
+			timestamp = f.familyTime
+		}
 		row.SlotIndex = uint16(f.intervalCalc.CalcSlot(
-			row.Timestamp(),
+			timestamp,
 			f.familyTime,
 			f.interval.Int64()),
 		)
@@ -546,34 +920,133 @@ func (f *dataFamily) WriteRows(rows []metric.StorageRow) error {
 		if err == nil {
 			f.statistics.WriteMetrics.Incr()
 			f.statistics.WriteFields.Add(float64(len(row.FieldIDs)))
+			if usagePoints > 0 && row.MetricID != usageMetricID {
+				usageTracker.RecordWrite(dbName, usageMetricID, usageMetricName, usagePoints)
+				usagePoints = 0
+			}
+			if usagePoints == 0 {
+				usageMetricID, usageMetricName = row.MetricID, row.Name()
+			}
+			usagePoints += len(row.FieldIDs)
+			if int(row.SlotIndex) > latestSlot {
+				latestSlot = int(row.SlotIndex)
+			}
+			if late {
+				f.statistics.LateAccepted.Incr()
+			}
 		} else {
 			f.statistics.WriteMetricFailures.Incr()
+			deadLetter.Send(dbName, f.indicator, DeadLetterWriteFailed+": "+err.Error(), &row)
 			f.logger.Error("failed writing row", logger.String("family", f.indicator), logger.Error(err))
 		}
 	}
 
-	return nil
+	return writeBackoff(db.MemSize()), nil
 }
 
-// ValidateSequence validates replica sequence if valid.
-func (f *dataFamily) ValidateSequence(leader int32, seq int64) bool {
+// writeBackoff returns the backoff hint for writer based on memory database size,
+// the hint is proportional to the overflow above soft threshold(max-memdb-size), capped by max-write-backoff.
+// NOTICE: hint clears automatically once memory database flushed(switched to immutable).
+func writeBackoff(memSize int64) time.Duration {
+	tsdbCfg := config.GlobalStorageConfig().TSDB
+	maxBackoff := tsdbCfg.MaxWriteBackoff.Duration()
+	softLimit := int64(tsdbCfg.MaxMemDBSize)
+	if maxBackoff <= 0 || softLimit <= 0 || memSize <= softLimit {
+		return 0
+	}
+	overflow := float64(memSize-softLimit) / float64(softLimit)
+	if overflow >= 1 {
+		return maxBackoff
+	}
+	return time.Duration(float64(maxBackoff) * overflow)
+}
+
+// recordLatestSlot records the highest time slot written by leader.
+func (f *dataFamily) recordLatestSlot(leader int32, slot uint16) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.latestSlots == nil {
+		f.latestSlots = make(map[int32]uint16)
+	}
+	if latest, ok := f.latestSlots[leader]; !ok || slot > latest {
+		f.latestSlots[leader] = slot
+	}
+}
+
+// latestTimestamps returns the timestamp of the highest time slot written by each leader, must be called with lock.
+func (f *dataFamily) latestTimestamps() map[int32]int64 {
+	timestamps := make(map[int32]int64, len(f.latestSlots))
+	for leader, slot := range f.latestSlots {
+		timestamps[leader] = f.familyTime + int64(slot)*f.interval.Int64()
+	}
+	return timestamps
+}
+
+// MaxDataTimestamp returns the timestamp of the highest time slot written into family,
+// returns 0 if no data written after family opened.
+func (f *dataFamily) MaxDataTimestamp() int64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	var latest int64
+	for _, timestamp := range f.latestTimestamps() {
+		if timestamp > latest {
+			latest = timestamp
+		}
+	}
+	return latest
+}
+
+// IngestionLag returns the duration between now and the latest data written into family,
+// returns the duration since family start time if no data written after family opened,
+// so that the lag restarts from the new family at interval rollover.
+func (f *dataFamily) IngestionLag() time.Duration {
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
-	if seqForLeader, ok := f.seq[leader]; ok {
-		return seq > seqForLeader.Load()
+	return time.Duration(f.ingestionLag(f.latestTimestamps())) * time.Millisecond
+}
+
+// ingestionLag returns the lag(ms) between now and the latest timestamp of leaders(family start time if not written).
+func (f *dataFamily) ingestionLag(latestTimestamps map[int32]int64) int64 {
+	latest := f.timeRange.Start
+	for _, timestamp := range latestTimestamps {
+		if timestamp > latest {
+			latest = timestamp
+		}
+	}
+	if lag := timeutil.Now() - latest; lag > 0 {
+		return lag
+	}
+	// data written ahead
+	return 0
+}
+
+// isLate returns if family is older than the family of current time.
+func (f *dataFamily) isLate(now int64) bool {
+	return f.familyTime < f.intervalCalc.CalcFamilyTime(now)
+}
+
+// isTooLate returns if family is out of the out-of-order window of database, which cannot be written.
+func (f *dataFamily) isTooLate(now int64) bool {
+	window := f.shard.Database().GetOption().GetOutOfOrderWindow()
+	return window > 0 && f.timeRange.End+window < now
+}
+
+// ValidateSequence validates replica sequence if valid.
+func (f *dataFamily) ValidateSequence(leader int32, seq int64) bool {
+	if w, ok := f.getLeaderWriter(leader); ok {
+		return seq > w.seq.Load()
 	}
 	return true
 }
 
 // CommitSequence commits written sequence after write data.
 func (f *dataFamily) CommitSequence(leader int32, seq int64) {
-	f.mutex.Lock()
-	defer f.mutex.Unlock()
+	_ = fault.Inject(fault.CommitSequence)
 
-	seqForLeader := f.seq[leader]
-	seqForLeader.Store(seq)
-	f.seq[leader] = seqForLeader
+	f.getOrCreateLeaderWriter(leader).seq.Store(seq)
 }
 
 // AckSequence acknowledges sequence after memory database flush successfully.
@@ -585,7 +1058,7 @@ func (f *dataFamily) AckSequence(leader int32, fn func(seq int64)) {
 
 	seqForLeader, ok := f.persistSeq[leader]
 	f.logger.Info("register ack sequence callback",
-		logger.String("family", f.indicator), logger.Any("sequences", f.seq),
+		logger.String("family", f.indicator), logger.Any("sequences", f.replicaSequences()),
 		logger.Any("leader", leader), logger.Any("exist", ok))
 	if ok {
 		// invoke ack sequence after register function, maybe some cases lost ack index.
@@ -593,20 +1066,63 @@ func (f *dataFamily) AckSequence(leader int32, fn func(seq int64)) {
 	}
 }
 
+// InstallSnapshot installs the family files transferred from leader into empty family,
+// the write sequences of family are reset to the sequence cut of snapshot.
+func (f *dataFamily) InstallSnapshot(files []kv.ExternalFile, sequences map[int32]int64) error {
+	if f.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	snapshot := f.family.GetSnapshot()
+	numOfFiles := len(snapshot.GetCurrent().GetAllFiles())
+	snapshot.Close()
+	if numOfFiles > 0 || f.mutableMemDB != nil || f.immutableMemDB != nil || len(f.replicaSequences()) > 0 {
+		return fmt.Errorf("family[%s] is not empty, cannot install snapshot", f.indicator)
+	}
+	if err := f.family.IngestFiles(files, sequences); err != nil {
+		return err
+	}
+	for leader, seq := range sequences {
+		f.getOrCreateLeaderWriter(leader).seq.Store(seq)
+		f.persistSeq[leader] = *atomic.NewInt64(seq)
+		// invoke sequence ack callback, data of snapshot is persisted
+		for _, fn := range f.callbacks[leader] {
+			fn(seq)
+		}
+	}
+	f.logger.Info("install snapshot successfully",
+		logger.String("family", f.indicator),
+		logger.Int("files", len(files)), logger.Any("sequences", sequences))
+	return nil
+}
+
 // GetOrCreateMemoryDatabase returns memory database by given family time.
 func (f *dataFamily) GetOrCreateMemoryDatabase(familyTime int64) (memdb.MemoryDatabase, error) {
+	if f.engineContext().readOnly {
+		return nil, constants.ErrReadOnly
+	}
 	f.mutex.Lock()
 	defer f.mutex.Unlock()
 
+	if f.IsTiered() {
+		return nil, fmt.Errorf("%w: %s", kv.ErrFamilyTiered, f.indicator)
+	}
 	if f.mutableMemDB == nil {
 		newDB, err := newMemoryDBFunc(memdb.MemoryDatabaseCfg{
-			FamilyTime: familyTime,
-			Name:       f.shard.Database().Name(),
-			BufferMgr:  f.shard.BufferManager(),
+			FamilyTime:    familyTime,
+			Name:          f.shard.Database().Name(),
+			BufferMgr:     f.shard.BufferManager(),
+			LastWriteWins: f.shard.Database().GetOption().IsLastWriteWins(),
+			CreatedTime:   f.restoredMemDBCreatedTime,
 		})
 		if err != nil {
 			return nil, err
 		}
+		// only the first memory database after restart holds the data not flushed before restart
+		f.restoredMemDBCreatedTime = 0
+		f.ttlJitter = rand.Float64() // nolint:gosec
 		f.mutableMemDB = newDB
 		f.statistics.ActiveMemDBs.Incr()
 	}
@@ -623,23 +1139,35 @@ func (f *dataFamily) Close() error {
 
 	f.flushCondition.Wait()
 
+	if f.immutableMemDB != nil && f.flushVerifyFailed.Load() {
+		// data of memory database retained by flush verification is already in files, need not flush again
+		f.statistics.ActiveMemDBs.Decr()
+		f.statistics.MemDBTotalSize.Sub(float64(f.immutableMemDB.MemSize()))
+		if err := f.immutableMemDB.Close(); err != nil {
+			f.logger.Warn("failed to close retained memory database",
+				logger.String("family", f.indicator), logger.Error(err))
+		}
+		f.immutableMemDB = nil
+	}
 	if f.immutableMemDB != nil {
 		if err := f.flushMemoryDatabase(f.immutableSeq, f.immutableMemDB); err != nil {
 			return err
 		}
 	}
 	if f.mutableMemDB != nil {
-		sequences := make(map[int32]int64)
-		for leader, seq := range f.seq {
-			sequences[leader] = seq.Load()
-		}
-		if err := f.flushMemoryDatabase(sequences, f.mutableMemDB); err != nil {
+		if err := f.flushMemoryDatabase(f.replicaSequences(), f.mutableMemDB); err != nil {
 			return err
 		}
 	}
 
-	GetFamilyManager().RemoveFamily(f)
-	f.statistics.ActiveFamilies.Decr()
+	f.engineContext().familyManager().RemoveFamily(f)
+	if f.removeVersionListener != nil {
+		f.removeVersionListener()
+	}
+	// remove disk stats of family from shard's statistics
+	f.onVersionChanged(kv.VersionChange{})
+	// unregister family statistics, avoid reporting metrics of closed family
+	f.statistics.Close()
 
 	f.logger.Info("close data family complete", logger.String("family", f.indicator), logger.Any("cost", time.Since(start)))
 	return nil
@@ -657,11 +1185,18 @@ func (f *dataFamily) flushMemoryDatabase(sequences map[int32]int64, memDB memdb.
 	for leader, seq := range sequences {
 		flusher.Sequence(leader, seq)
 	}
+	// wait persisting created time of memory database completed, then clear it with flushed data
+	f.memDBStateLock.Lock()
+	f.memDBStateLock.Unlock() // nolint:staticcheck
+	flusher.MemDBCreatedTime(0)
 
 	dataFlusher, err := newMetricDataFlusher(flusher)
 	if err != nil {
 		return err
 	}
+	// compress metric blocks by the current codec of database
+	dataFlusher.SetCodec(metricsdata.NewCodec(f.shard.Database().GetOption().GetCompression()))
+	dataFlusher = newMetricUsageFlusher(f.shard.Database().Name(), dataFlusher)
 	// flush family data
 	if err := memDB.FlushFamilyTo(dataFlusher); err != nil {
 		f.logger.Error("failed to flush memory database",
@@ -670,6 +1205,17 @@ func (f *dataFamily) flushMemoryDatabase(sequences map[int32]int64, memDB memdb.
 		f.statistics.MemDBFlushFailures.Incr()
 		return err
 	}
+	// verify flushed data before acknowledging sequences and releasing memory database
+	if sampleRate := f.shard.Database().GetOption().GetFlushVerifySampleRate(); sampleRate > 0 {
+		if err := f.verifyFlush(memDB, flusher.Outputs(), sampleRate); err != nil {
+			f.flushVerifyFailed.Store(true)
+			return err
+		}
+	}
+
+	f.memDBStateLock.Lock()
+	f.persistedMemDBCreatedTime = 0
+	f.memDBStateLock.Unlock()
 
 	// invoke sequence ack callback
 	for leader, seq := range sequences {
@@ -683,7 +1229,11 @@ func (f *dataFamily) flushMemoryDatabase(sequences map[int32]int64, memDB memdb.
 	f.statistics.ActiveMemDBs.Decr()
 	f.statistics.MemDBTotalSize.Sub(float64(memDB.MemSize()))
 
-	if err := memDB.Close(); err != nil {
+	err = fault.Inject(fault.FlushBeforeCloseMemDB)
+	if err == nil {
+		err = memDB.Close()
+	}
+	if err != nil {
 		// ignore close memory database err, if not maybe write duplicate data into file storage
 		f.logger.Warn("failed to close memory database",
 			logger.String("family", f.indicator),
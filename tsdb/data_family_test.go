@@ -18,18 +18,24 @@
 package tsdb
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
+	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
 
 	"github.com/lindb/common/pkg/fasttime"
 	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/kv/table"
@@ -40,6 +46,7 @@ import (
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/metric"
 	stmtpkg "github.com/lindb/lindb/sql/stmt"
 	"github.com/lindb/lindb/tsdb/memdb"
@@ -60,21 +67,95 @@ func TestDataFamily_BaseTime(t *testing.T) {
 	snapshot := version.NewMockSnapshot(ctrl)
 	v := version.NewMockVersion(ctrl)
 	v.EXPECT().GetSequences().Return(map[int32]int64{1: 10})
-	snapshot.EXPECT().GetCurrent().Return(v)
+	v.EXPECT().GetMemDBCreatedTime().Return(int64(0))
+	v.EXPECT().GetAllFiles().Return([]*version.FileMeta{
+		version.NewFileMeta(1, 1, 10, 100),
+		version.NewFileMeta(2, 1, 10, 200),
+	})
+	snapshot.EXPECT().GetCurrent().Return(v).Times(3)
 	snapshot.EXPECT().Close()
 	family.EXPECT().GetSnapshot().Return(snapshot)
+	removed := false
+	family.EXPECT().AddVersionListener(gomock.Any()).Return(func() { removed = true })
+	family.EXPECT().IsTiered().Return(false)
 	shard := NewMockShard(ctrl)
 	shard.EXPECT().Database().Return(database)
 	shard.EXPECT().ShardID().Return(models.ShardID(1))
-	dataFamily := newDataFamily(shard, nil, timeutil.Interval(timeutil.OneSecond*10), timeRange, 10, family)
-	assert.Equal(t, timeRange, dataFamily.TimeRange())
-	assert.Equal(t, timeutil.Interval(10000), dataFamily.Interval())
-	assert.NotNil(t, dataFamily.Family())
-	assert.Equal(t, shard, dataFamily.Shard())
-	assert.Equal(t, int64(10), dataFamily.FamilyTime())
-
-	err := dataFamily.Close()
+	f := newDataFamily(shard, nil, timeutil.Interval(timeutil.OneSecond*10), timeRange, 10, family)
+	assert.Equal(t, timeRange, f.TimeRange())
+	assert.Equal(t, timeutil.Interval(10000), f.Interval())
+	assert.NotNil(t, f.Family())
+	assert.Equal(t, shard, f.Shard())
+	assert.Equal(t, int64(10), f.FamilyTime())
+	statistics := f.(*dataFamily).statistics
+	assert.Equal(t, 300.0, statistics.DiskSize.Get())
+	assert.Equal(t, 2.0, statistics.NumOfFiles.Get())
+
+	err := f.Close()
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.Equal(t, 0.0, statistics.DiskSize.Get())
+	assert.Equal(t, 0.0, statistics.NumOfFiles.Get())
+}
+
+func TestDataFamily_onVersionChanged(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := timeutil.Now()
+	f := &dataFamily{familyTime: now, statistics: metrics.NewFamilyStatistics("version-db", "1", "family")}
+	defer f.statistics.Close()
+
+	reader := table.NewMockReader(ctrl)
+	reader.EXPECT().Path().Return("family/000001.sst").AnyTimes()
+	snapshot := version.NewMockSnapshot(ctrl)
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{reader}, nil).AnyTimes()
+	snapshot.EXPECT().Close().AnyTimes()
+	family := kv.NewMockFamily(ctrl)
+	family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	f.family = family
+	metricReader := metricsdata.NewMockMetricReader(ctrl)
+	defer func() {
+		newReaderFunc = metricsdata.NewReader
+	}()
+	newReaderFunc = func(path string, buf []byte) (metricsdata.MetricReader, error) {
+		return metricReader, nil
+	}
+	shardCtx := &flow.ShardExecuteContext{
+		StorageExecuteCtx: &flow.StorageExecuteContext{
+			Query: &stmtpkg.Query{
+				StorageInterval: timeutil.Interval(timeutil.OneMinute),
+				TimeRange:       timeutil.TimeRange{Start: now, End: now + 60000},
+			},
+		},
+	}
+	// slot range of metric data in file is cached after first read
+	reader.EXPECT().Get(gomock.Any()).Return([]byte{1}, nil)
+	metricReader.EXPECT().GetTimeRange().Return(timeutil.SlotRange{Start: 1000, End: 1000})
+	rs, err := f.fileFilter(shardCtx)
+	assert.NoError(t, err)
+	assert.Empty(t, rs)
+	// skip file by cached slot range
+	rs, err = f.fileFilter(shardCtx)
+	assert.NoError(t, err)
+	assert.Empty(t, rs)
+
+	// file compacted, refresh disk stats and invalidate cached slot range
+	f.onVersionChanged(kv.VersionChange{
+		AddedFiles:   []*version.FileMeta{version.NewFileMeta(2, 1, 10, 100)},
+		RemovedFiles: []string{"family/000001.sst"},
+		DiskSize:     100,
+		NumOfFiles:   1,
+	})
+	assert.Equal(t, 100.0, f.statistics.DiskSize.Get())
+	assert.Equal(t, 1.0, f.statistics.NumOfFiles.Get())
+	_, ok := f.getSlotRange("family/000001.sst", 0)
+	assert.False(t, ok)
+	reader.EXPECT().Get(gomock.Any()).Return([]byte{1}, nil)
+	metricReader.EXPECT().GetTimeRange().Return(timeutil.SlotRange{Start: 1000, End: 1000})
+	rs, err = f.fileFilter(shardCtx)
 	assert.NoError(t, err)
+	assert.Empty(t, rs)
 }
 
 func TestDataFamily_Filter(t *testing.T) {
@@ -90,10 +171,37 @@ func TestDataFamily_Filter(t *testing.T) {
 	now := timeutil.Now()
 	cases := []struct {
 		name    string
+		taskCtx *flow.TaskContext
+		stats   *models.DataFamilyFilterStats
 		prepare func(f *dataFamily)
 		len     int
 		wantErr bool
 	}{
+		{
+			name: "query timeout before filtering",
+			taskCtx: func() *flow.TaskContext {
+				taskCtx := flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)
+				taskCtx.Release()
+				return taskCtx
+			}(),
+			wantErr: true,
+		},
+		{
+			name: "query timeout after filtering memory database",
+			taskCtx: func() *flow.TaskContext {
+				return flow.NewTaskContextWithTimeout(context.TODO(), time.Minute)
+			}(),
+			prepare: func(f *dataFamily) {
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = memDB
+				memDB.EXPECT().Filter(gomock.Any()).DoAndReturn(
+					func(executeCtx *flow.ShardExecuteContext) ([]flow.FilterResultSet, error) {
+						executeCtx.StorageExecuteCtx.TaskCtx.Release()
+						return []flow.FilterResultSet{nil}, nil
+					})
+			},
+			wantErr: true,
+		},
 		{
 			name: "filter memory database failure",
 			prepare: func(f *dataFamily) {
@@ -123,6 +231,18 @@ func TestDataFamily_Filter(t *testing.T) {
 			wantErr: false,
 			len:     1,
 		},
+		{
+			name:  "filter memory database with stats",
+			stats: &models.DataFamilyFilterStats{},
+			prepare: func(f *dataFamily) {
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.immutableMemDB = memDB
+				memDB.EXPECT().Filter(gomock.Any()).Return([]flow.FilterResultSet{nil, nil}, nil)
+				snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
+			},
+			wantErr: false,
+			len:     2,
+		},
 		{
 			name: "get file reader failure",
 			prepare: func(_ *dataFamily) {
@@ -131,14 +251,45 @@ func TestDataFamily_Filter(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name: "get metric reader data failure",
+			name: "metric data not found in memory database",
+			prepare: func(f *dataFamily) {
+				mutable := memdb.NewMockMemoryDatabase(ctrl)
+				immutable := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = mutable
+				f.immutableMemDB = immutable
+				mutable.EXPECT().Filter(gomock.Any()).Return(nil, constants.ErrFieldNotFound)
+				immutable.EXPECT().Filter(gomock.Any()).Return([]flow.FilterResultSet{nil}, nil)
+				snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil)
+			},
+			wantErr: false,
+			len:     1,
+		},
+		{
+			name: "filter memory database failure",
+			prepare: func(f *dataFamily) {
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = memDB
+				memDB.EXPECT().Filter(gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "metric data not found in file",
 			prepare: func(_ *dataFamily) {
 				snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{reader}, nil)
-				reader.EXPECT().Get(gomock.Any()).Return(nil, fmt.Errorf("err"))
+				reader.EXPECT().Get(gomock.Any()).Return(nil, table.ErrKeyNotExist)
 			},
 			wantErr: false,
 			len:     0,
 		},
+		{
+			name: "get metric reader data failure",
+			prepare: func(_ *dataFamily) {
+				snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{reader}, nil)
+				reader.EXPECT().Get(gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
 		{
 			name: "new metric reader failure",
 			prepare: func(_ *dataFamily) {
@@ -179,11 +330,31 @@ func TestDataFamily_Filter(t *testing.T) {
 					readers []metricsdata.MetricReader) metricsdata.Filter {
 					return filter
 				}
-				filter.EXPECT().Filter(gomock.Any(), gomock.Any()).Return([]flow.FilterResultSet{nil}, nil)
+				filter.EXPECT().Filter(gomock.Any(), gomock.Any(), gomock.Any()).Return([]flow.FilterResultSet{nil}, nil)
 			},
 			wantErr: false,
 			len:     1,
 		},
+		{
+			name: "fields not found in file",
+			prepare: func(_ *dataFamily) {
+				snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{reader}, nil)
+				reader.EXPECT().Get(gomock.Any()).Return([]byte{1, 2, 3}, nil)
+				mReader := metricsdata.NewMockMetricReader(ctrl)
+				newReaderFunc = func(path string, metricBlock []byte) (metricsdata.MetricReader, error) {
+					return mReader, nil
+				}
+				mReader.EXPECT().GetTimeRange().Return(timeutil.SlotRange{Start: 0, End: 1000})
+				filter := metricsdata.NewMockFilter(ctrl)
+				newFilterFunc = func(familyTime int64, snapshot version.Snapshot,
+					readers []metricsdata.MetricReader) metricsdata.Filter {
+					return filter
+				}
+				filter.EXPECT().Filter(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, constants.ErrNotFound)
+			},
+			wantErr: false,
+			len:     0,
+		},
 	}
 
 	for _, tt := range cases {
@@ -202,7 +373,9 @@ func TestDataFamily_Filter(t *testing.T) {
 				tt.prepare(f)
 			}
 			rs, err := f.Filter(&flow.ShardExecuteContext{
+				FamilyFilterStats: tt.stats,
 				StorageExecuteCtx: &flow.StorageExecuteContext{
+					TaskCtx:  tt.taskCtx,
 					MetricID: 1,
 					Query: &stmtpkg.Query{
 						StorageInterval: timeutil.Interval(timeutil.OneMinute),
@@ -217,14 +390,71 @@ func TestDataFamily_Filter(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Len(t, rs, tt.len)
 			}
+			if tt.stats != nil {
+				assert.Equal(t, 2, tt.stats.NumOfMemoryRS)
+				assert.Zero(t, tt.stats.NumOfFileRS)
+			}
 		})
 	}
 }
 
+func TestDataFamily_GetSeriesIDs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newReaderFunc = metricsdata.NewReader
+		ctrl.Finish()
+	}()
+
+	family := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	snapshot.EXPECT().Close().AnyTimes()
+	family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	reader := table.NewMockReader(ctrl)
+	reader.EXPECT().Path().Return("test").AnyTimes()
+	mutable := memdb.NewMockMemoryDatabase(ctrl)
+	immutable := memdb.NewMockMemoryDatabase(ctrl)
+	f := &dataFamily{family: family, mutableMemDB: mutable, immutableMemDB: immutable}
+
+	// find readers failure
+	mutable.EXPECT().GetSeriesIDs(metric.ID(1)).Return(nil).AnyTimes()
+	immutable.EXPECT().GetSeriesIDs(metric.ID(1)).Return(roaring.BitmapOf(1)).AnyTimes()
+	snapshot.EXPECT().FindReaders(uint32(1)).Return(nil, fmt.Errorf("err"))
+	seriesIDs, err := f.GetSeriesIDs(1)
+	assert.Error(t, err)
+	assert.Nil(t, seriesIDs)
+	// new metric reader failure
+	snapshot.EXPECT().FindReaders(uint32(1)).Return([]table.Reader{reader, reader}, nil).AnyTimes()
+	reader.EXPECT().Get(uint32(1)).Return(nil, table.ErrKeyNotExist)
+	reader.EXPECT().Get(uint32(1)).Return([]byte{1, 2, 3}, nil)
+	newReaderFunc = func(path string, metricBlock []byte) (metricsdata.MetricReader, error) {
+		return nil, fmt.Errorf("err")
+	}
+	seriesIDs, err = f.GetSeriesIDs(1)
+	assert.Error(t, err)
+	assert.Nil(t, seriesIDs)
+	// get metric data failure
+	reader.EXPECT().Get(uint32(1)).Return(nil, fmt.Errorf("err"))
+	seriesIDs, err = f.GetSeriesIDs(1)
+	assert.Error(t, err)
+	assert.Nil(t, seriesIDs)
+	// series ids in memory database and files
+	mReader := metricsdata.NewMockMetricReader(ctrl)
+	newReaderFunc = func(path string, metricBlock []byte) (metricsdata.MetricReader, error) {
+		return mReader, nil
+	}
+	reader.EXPECT().Get(uint32(1)).Return([]byte{1, 2, 3}, nil).Times(2)
+	mReader.EXPECT().GetSeriesIDs().Return(roaring.BitmapOf(2))
+	mReader.EXPECT().GetSeriesIDs().Return(roaring.BitmapOf(3))
+	seriesIDs, err = f.GetSeriesIDs(1)
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1, 2, 3), seriesIDs)
+}
+
 func TestDataFamily_NeedFlush(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
+	family := kv.NewMockFamily(ctrl)
 	cases := []struct {
 		name      string
 		prepare   func(f *dataFamily)
@@ -267,6 +497,7 @@ func TestDataFamily_NeedFlush(t *testing.T) {
 				f.mutableMemDB = memDB
 				memDB.EXPECT().MemSize().Return(int64(10))
 				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
 				memDB.EXPECT().Uptime().Return(time.Duration(timeutil.Now() - timeutil.OneMinute)).MaxTimes(2)
 			},
 			needFlush: true,
@@ -281,11 +512,48 @@ func TestDataFamily_NeedFlush(t *testing.T) {
 				memDB := memdb.NewMockMemoryDatabase(ctrl)
 				f.mutableMemDB = memDB
 				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
 				memDB.EXPECT().Uptime().Return(time.Duration(timeutil.Now() - timeutil.OneMinute)).MaxTimes(2)
 				memDB.EXPECT().MemSize().Return(int64(1000)).MaxTimes(2)
 			},
 			needFlush: true,
 		},
+		{
+			name: "flushing paused",
+			prepare: func(f *dataFamily) {
+				cfg := config.NewDefaultStorageBase()
+				cfg.TSDB.MutableMemDBTTL = ltoml.Duration(time.Second)
+				cfg.TSDB.MaxMemDBSize = 10
+				config.SetGlobalStorageConfig(cfg)
+				f.database = "paused-db"
+				GetFamilyManager().PauseFlush(f.database)
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = memDB
+				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
+				memDB.EXPECT().Uptime().Return(time.Duration(timeutil.Now() - timeutil.OneMinute)).AnyTimes()
+				memDB.EXPECT().MemSize().Return(int64(15)).AnyTimes()
+				family.EXPECT().CommitMemDBCreatedTime(int64(10)).Return(nil)
+			},
+			needFlush: false,
+		},
+		{
+			name: "flushing paused, but above hard ceiling",
+			prepare: func(f *dataFamily) {
+				cfg := config.NewDefaultStorageBase()
+				cfg.TSDB.MaxMemDBSize = 10
+				config.SetGlobalStorageConfig(cfg)
+				f.database = "paused-db"
+				GetFamilyManager().PauseFlush(f.database)
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = memDB
+				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
+				memDB.EXPECT().Uptime().Return(time.Duration(timeutil.Now() - timeutil.OneMinute)).AnyTimes()
+				memDB.EXPECT().MemSize().Return(int64(20)).AnyTimes()
+			},
+			needFlush: true,
+		},
 		{
 			name: "no trigger any threshold",
 			prepare: func(f *dataFamily) {
@@ -296,11 +564,62 @@ func TestDataFamily_NeedFlush(t *testing.T) {
 				memDB := memdb.NewMockMemoryDatabase(ctrl)
 				f.mutableMemDB = memDB
 				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
 				memDB.EXPECT().Uptime().Return(time.Duration(timeutil.Now() - timeutil.OneMinute)).MaxTimes(2)
 				memDB.EXPECT().MemSize().Return(int64(10)).MaxTimes(2)
+				family.EXPECT().CommitMemDBCreatedTime(int64(10)).Return(nil)
+			},
+			needFlush: false,
+		},
+		{
+			name: "created time of memory database persisted",
+			prepare: func(f *dataFamily) {
+				cfg := config.NewDefaultStorageBase()
+				cfg.TSDB.MutableMemDBTTL = ltoml.Duration(time.Hour)
+				config.SetGlobalStorageConfig(cfg)
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = memDB
+				f.persistedMemDBCreatedTime = 10
+				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
+				memDB.EXPECT().Uptime().Return(time.Minute).MaxTimes(2)
+				memDB.EXPECT().MemSize().Return(int64(10)).MaxTimes(2)
 			},
 			needFlush: false,
 		},
+		{
+			name: "persist created time of memory database failure",
+			prepare: func(f *dataFamily) {
+				cfg := config.NewDefaultStorageBase()
+				cfg.TSDB.MutableMemDBTTL = ltoml.Duration(time.Hour)
+				config.SetGlobalStorageConfig(cfg)
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = memDB
+				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
+				memDB.EXPECT().Uptime().Return(time.Minute).MaxTimes(2)
+				memDB.EXPECT().MemSize().Return(int64(10)).MaxTimes(2)
+				family.EXPECT().CommitMemDBCreatedTime(int64(10)).Return(fmt.Errorf("err"))
+			},
+			needFlush: false,
+		},
+		{
+			name: "trigger jittered time threshold",
+			prepare: func(f *dataFamily) {
+				cfg := config.NewDefaultStorageBase()
+				cfg.TSDB.MutableMemDBTTL = ltoml.Duration(time.Hour)
+				cfg.TSDB.MutableMemDBTTLJitter = 0.2
+				config.SetGlobalStorageConfig(cfg)
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				f.mutableMemDB = memDB
+				f.ttlJitter = 0.5
+				memDB.EXPECT().NumOfMetrics().Return(10)
+				memDB.EXPECT().CreatedTime().Return(int64(10))
+				memDB.EXPECT().MemSize().Return(int64(10))
+				memDB.EXPECT().Uptime().Return(54 * time.Minute).MaxTimes(2)
+			},
+			needFlush: true,
+		},
 	}
 
 	for _, tt := range cases {
@@ -308,8 +627,10 @@ func TestDataFamily_NeedFlush(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			defer func() {
 				config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+				GetFamilyManager().ResumeFlush("paused-db")
 			}()
 			f := &dataFamily{
+				family: family,
 				logger: logger.GetLogger("TSDB", "Test"),
 			}
 			if tt.prepare != nil {
@@ -320,6 +641,95 @@ func TestDataFamily_NeedFlush(t *testing.T) {
 	}
 }
 
+func TestDataFamily_RestoreMemDBCreatedTime(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newMemoryDBFunc = memdb.NewMemoryDatabase
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+		ctrl.Finish()
+	}()
+	cfg := config.NewDefaultStorageBase()
+	cfg.TSDB.MutableMemDBTTL = ltoml.Duration(time.Hour)
+	cfg.TSDB.MutableMemDBTTLJitter = 0
+	config.SetGlobalStorageConfig(cfg)
+
+	dir := t.TempDir()
+	openKVFamily := func() (kv.StoreManager, kv.Family) {
+		storeMgr := kv.NewStoreManager(kv.StoreOptions{Dir: dir})
+		store, err := storeMgr.CreateStore("restart", kv.DefaultStoreOption())
+		assert.NoError(t, err)
+		family, err := store.CreateFamily("20220101", kv.FamilyOption{Merger: string(metricsdata.MetricDataMerger)})
+		assert.NoError(t, err)
+		return storeMgr, family
+	}
+	storeMgr, family := openKVFamily()
+	f := openFaultTestFamily(t, ctrl, family)
+	assert.Zero(t, f.restoredMemDBCreatedTime)
+
+	// memory database is created 40 minutes ago, persist created time by flush checker
+	createdTime := fasttime.UnixNano() - int64(40*time.Minute)
+	memDB := newFaultTestMemDB(ctrl)
+	memDB.EXPECT().CreatedTime().Return(createdTime).AnyTimes()
+	memDB.EXPECT().Uptime().Return(40 * time.Minute).AnyTimes()
+	f.mutableMemDB = memDB
+	assert.False(t, f.NeedFlush())
+	assert.False(t, f.NeedFlush()) // persisted already
+	assert.Equal(t, createdTime, persistedMemDBCreatedTime(family))
+
+	// restart storage without flushing memory database
+	defaultEngineContext.familyManager().RemoveFamily(f)
+	assert.NoError(t, storeMgr.CloseStore("restart"))
+	storeMgr, family = openKVFamily()
+	defer func() {
+		_ = storeMgr.CloseStore("restart")
+	}()
+	f = openFaultTestFamily(t, ctrl, family)
+	assert.Equal(t, createdTime, f.restoredMemDBCreatedTime)
+
+	// memory database replaying wal continues the ttl
+	var cfgCreatedTimes []int64
+	memDB = newFaultTestMemDB(ctrl)
+	newMemoryDBFunc = func(cfg memdb.MemoryDatabaseCfg) (memdb.MemoryDatabase, error) {
+		cfgCreatedTimes = append(cfgCreatedTimes, cfg.CreatedTime)
+		return memDB, nil
+	}
+	_, err := f.GetOrCreateMemoryDatabase(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{createdTime}, cfgCreatedTimes)
+	assert.Zero(t, f.restoredMemDBCreatedTime)
+	memDB.EXPECT().CreatedTime().Return(createdTime).AnyTimes()
+	memDB.EXPECT().Uptime().Return(61 * time.Minute).AnyTimes()
+	assert.True(t, f.NeedFlush())
+
+	// flush clears created time of memory database
+	memDB.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric)
+	memDB.EXPECT().Close().Return(nil)
+	assert.NoError(t, f.Flush())
+	assert.Zero(t, f.persistedMemDBCreatedTime)
+	assert.Zero(t, persistedMemDBCreatedTime(family))
+	_, err = f.GetOrCreateMemoryDatabase(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{createdTime, 0}, cfgCreatedTimes)
+}
+
+func TestDataFamily_jitterTTL(t *testing.T) {
+	ttl := time.Hour
+	assert.Equal(t, ttl, jitterTTL(ttl, 0, 0.5))
+	assert.Equal(t, ttl, jitterTTL(ttl, 0.2, 0))
+	assert.Equal(t, 54*time.Minute, jitterTTL(ttl, 0.2, 0.5))
+
+	// jittered ttl is uniformly distributed in (ttl*(1-jitter), ttl]
+	buckets := make([]int, 4)
+	for i := 0; i < 4000; i++ {
+		jittered := jitterTTL(ttl, 0.2, rand.Float64())
+		assert.True(t, jittered > 48*time.Minute && jittered <= ttl)
+		buckets[int((ttl-jittered)/(3*time.Minute))]++
+	}
+	for _, count := range buckets {
+		assert.InDelta(t, 1000, count, 200)
+	}
+}
+
 func TestDataFamily_Flush(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -329,6 +739,12 @@ func TestDataFamily_Flush(t *testing.T) {
 	family.EXPECT().NewFlusher().Return(flusher).AnyTimes()
 	flusher.EXPECT().Release().AnyTimes()
 	flusher.EXPECT().Sequence(gomock.Any(), gomock.Any()).AnyTimes()
+	flusher.EXPECT().MemDBCreatedTime(int64(0)).AnyTimes()
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	db.EXPECT().Name().Return("db").AnyTimes()
+	db.EXPECT().GetOption().Return(&option.DatabaseOption{Compression: "zstd"}).AnyTimes()
 	cases := []struct {
 		name    string
 		prepare func(f *dataFamily)
@@ -345,12 +761,26 @@ func TestDataFamily_Flush(t *testing.T) {
 			name:    "no data need flush",
 			wantErr: false,
 		},
+		{
+			name: "insufficient disk space, defer flush",
+			prepare: func(f *dataFamily) {
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				memDB.EXPECT().NumOfMetrics().Return(100)
+				memDB.EXPECT().MemSize().Return(int64(1000)).AnyTimes()
+				f.mutableMemDB = memDB
+				diskUsageFn = func(_ string) (*disk.UsageStat, error) {
+					return &disk.UsageStat{Free: 100}, nil
+				}
+			},
+			wantErr: true,
+		},
 		{
 			name: "create data flusher failure",
 			prepare: func(f *dataFamily) {
 				memDB := memdb.NewMockMemoryDatabase(ctrl)
 				memDB.EXPECT().NumOfMetrics().Return(100)
 				memDB.EXPECT().MarkReadOnly()
+				memDB.EXPECT().MemSize().AnyTimes()
 				f.mutableMemDB = memDB
 				newMetricDataFlusher = func(kvFlusher kv.Flusher) (metricsdata.Flusher, error) {
 					return nil, fmt.Errorf("err")
@@ -366,9 +796,10 @@ func TestDataFamily_Flush(t *testing.T) {
 				memDB.EXPECT().MarkReadOnly()
 				memDB.EXPECT().FlushFamilyTo(gomock.Any()).Return(nil)
 				memDB.EXPECT().Close().Return(nil)
-				memDB.EXPECT().MemSize().MaxTimes(2)
+				memDB.EXPECT().MemSize().AnyTimes()
 				f.mutableMemDB = memDB
 				dataFlusher := metricsdata.NewMockFlusher(ctrl)
+				dataFlusher.EXPECT().SetCodec(metricsdata.Codec{Type: metricsdata.CodecZstd, Level: 3}).AnyTimes()
 				newMetricDataFlusher = func(kvFlusher kv.Flusher) (metricsdata.Flusher, error) {
 					return dataFlusher, nil
 				}
@@ -382,9 +813,10 @@ func TestDataFamily_Flush(t *testing.T) {
 				memDB.EXPECT().NumOfMetrics().Return(100)
 				memDB.EXPECT().MarkReadOnly()
 				memDB.EXPECT().FlushFamilyTo(gomock.Any()).Return(fmt.Errorf("err"))
-				memDB.EXPECT().MemSize()
+				memDB.EXPECT().MemSize().AnyTimes()
 				f.mutableMemDB = memDB
 				dataFlusher := metricsdata.NewMockFlusher(ctrl)
+				dataFlusher.EXPECT().SetCodec(metricsdata.Codec{Type: metricsdata.CodecZstd, Level: 3}).AnyTimes()
 				newMetricDataFlusher = func(kvFlusher kv.Flusher) (metricsdata.Flusher, error) {
 					return dataFlusher, nil
 				}
@@ -399,9 +831,10 @@ func TestDataFamily_Flush(t *testing.T) {
 				memDB.EXPECT().MarkReadOnly()
 				memDB.EXPECT().FlushFamilyTo(gomock.Any()).Return(nil)
 				memDB.EXPECT().Close().Return(fmt.Errorf("err"))
-				memDB.EXPECT().MemSize().MaxTimes(3)
+				memDB.EXPECT().MemSize().AnyTimes()
 				f.mutableMemDB = memDB
 				dataFlusher := metricsdata.NewMockFlusher(ctrl)
+				dataFlusher.EXPECT().SetCodec(metricsdata.Codec{Type: metricsdata.CodecZstd, Level: 3}).AnyTimes()
 				newMetricDataFlusher = func(kvFlusher kv.Flusher) (metricsdata.Flusher, error) {
 					return dataFlusher, nil
 				}
@@ -415,17 +848,19 @@ func TestDataFamily_Flush(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			defer func() {
 				newMetricDataFlusher = metricsdata.NewFlusher
+				diskUsageFn = disk.Usage
 			}()
 			f := &dataFamily{
+				shard:  shard,
 				family: family,
-				seq: map[int32]atomic.Int64{
-					1: *atomic.NewInt64(10),
+				leaders: map[int32]*leaderWriter{
+					1: newLeaderWriter(10),
 				},
 				persistSeq: make(map[int32]atomic.Int64),
 				callbacks: map[int32][]func(seq int64){
 					1: {func(seq int64) {}},
 				},
-				statistics: metrics.NewFamilyStatistics("data", "1"),
+				statistics: metrics.NewFamilyStatistics("data", "1", "family"),
 				logger:     logger.GetLogger("TSDB", "Test"),
 			}
 			if tt.prepare != nil {
@@ -439,6 +874,18 @@ func TestDataFamily_Flush(t *testing.T) {
 	}
 }
 
+func TestDataFamily_IsHealthy(t *testing.T) {
+	f := &dataFamily{}
+	assert.True(t, f.IsHealthy())
+	f.flushFailures.Store(maxFlushFailures - 1)
+	assert.True(t, f.IsHealthy())
+	f.flushFailures.Inc()
+	assert.False(t, f.IsHealthy())
+	f.flushFailures.Store(0)
+	f.flushVerifyFailed.Store(true)
+	assert.False(t, f.IsHealthy())
+}
+
 func TestDataFamily_Close(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -448,6 +895,7 @@ func TestDataFamily_Close(t *testing.T) {
 	family.EXPECT().NewFlusher().Return(flusher).AnyTimes()
 	flusher.EXPECT().Release().AnyTimes()
 	flusher.EXPECT().Sequence(gomock.Any(), gomock.Any()).AnyTimes()
+	flusher.EXPECT().MemDBCreatedTime(int64(0)).AnyTimes()
 	cases := []struct {
 		name    string
 		prepare func(f *dataFamily)
@@ -479,6 +927,17 @@ func TestDataFamily_Close(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "close immutable mem data which failed to verify",
+			prepare: func(f *dataFamily) {
+				memDB := memdb.NewMockMemoryDatabase(ctrl)
+				memDB.EXPECT().MemSize().Return(int64(10))
+				memDB.EXPECT().Close().Return(fmt.Errorf("err"))
+				f.immutableMemDB = memDB
+				f.flushVerifyFailed.Store(true)
+			},
+			wantErr: false,
+		},
 	}
 
 	for _, tt := range cases {
@@ -489,13 +948,13 @@ func TestDataFamily_Close(t *testing.T) {
 			}()
 			f := &dataFamily{
 				family: family,
-				seq: map[int32]atomic.Int64{
-					1: *atomic.NewInt64(10),
+				leaders: map[int32]*leaderWriter{
+					1: newLeaderWriter(10),
 				},
 				callbacks: map[int32][]func(seq int64){
 					1: {func(seq int64) {}},
 				},
-				statistics: metrics.NewFamilyStatistics("data", "1"),
+				statistics: metrics.NewFamilyStatistics("data", "1", "family"),
 				logger:     logger.GetLogger("TSDB", "Test"),
 			}
 			if tt.prepare != nil {
@@ -533,13 +992,15 @@ func TestDataFamily_GetOrCreateMemoryDatabase(t *testing.T) {
 	db := NewMockDatabase(ctrl)
 	shard.EXPECT().Database().Return(db).AnyTimes()
 	db.EXPECT().Name().Return("db").AnyTimes()
+	db.EXPECT().GetOption().Return(&option.DatabaseOption{WriteSemantics: option.WriteSemanticsLastWriteWins}).AnyTimes()
 	shard.EXPECT().BufferManager().Return(memdb.NewMockBufferManager(ctrl)).AnyTimes()
 
 	f := &dataFamily{
 		shard:      shard,
-		statistics: metrics.NewFamilyStatistics("data", "1"),
+		statistics: metrics.NewFamilyStatistics("data", "1", "family"),
 	}
 	newMemoryDBFunc = func(cfg memdb.MemoryDatabaseCfg) (memdb.MemoryDatabase, error) {
+		assert.True(t, cfg.LastWriteWins)
 		return nil, fmt.Errorf("err")
 	}
 	memDB, err := f.GetOrCreateMemoryDatabase(1)
@@ -562,7 +1023,6 @@ func TestDataFamily_GetOrCreateMemoryDatabase(t *testing.T) {
 
 func TestDataFamily_Sequence(t *testing.T) {
 	f := &dataFamily{
-		seq: make(map[int32]atomic.Int64),
 		persistSeq: map[int32]atomic.Int64{
 			1: *atomic.NewInt64(10),
 		},
@@ -583,6 +1043,51 @@ func TestDataFamily_Sequence(t *testing.T) {
 	assert.Equal(t, 1, c)
 }
 
+func TestDataFamily_InstallSnapshot(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	family := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+	snapshot.EXPECT().Close().AnyTimes()
+	newFamily := func() *dataFamily {
+		return &dataFamily{
+			family:     family,
+			persistSeq: make(map[int32]atomic.Int64),
+			callbacks:  make(map[int32][]func(seq int64)),
+			logger:     logger.GetLogger("TSDB", "Test"),
+		}
+	}
+	files := []kv.ExternalFile{{Path: "1.sst", MinKey: 1, MaxKey: 10, Size: 100}}
+	sequences := map[int32]int64{1: 10}
+
+	// case 1: family has files
+	v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 1, 10, 100)})
+	assert.Error(t, newFamily().InstallSnapshot(files, sequences))
+	// case 2: family has written data
+	v.EXPECT().GetAllFiles().Return(nil).AnyTimes()
+	f := newFamily()
+	f.CommitSequence(1, 5)
+	assert.Error(t, f.InstallSnapshot(files, sequences))
+	// case 3: ingest files failure
+	family.EXPECT().IngestFiles(files, sequences).Return(fmt.Errorf("err"))
+	assert.Error(t, newFamily().InstallSnapshot(files, sequences))
+	// case 4: install successfully
+	family.EXPECT().IngestFiles(files, sequences).Return(nil)
+	f = newFamily()
+	var ackSeq int64
+	f.AckSequence(1, func(seq int64) {
+		ackSeq = seq
+	})
+	assert.NoError(t, f.InstallSnapshot(files, sequences))
+	assert.Equal(t, int64(10), ackSeq)
+	assert.False(t, f.ValidateSequence(1, 10))
+	assert.True(t, f.ValidateSequence(1, 11))
+}
+
 func TestDataFamily_WriteRows(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -596,7 +1101,14 @@ func TestDataFamily_WriteRows(t *testing.T) {
 	db := NewMockDatabase(ctrl)
 	shard.EXPECT().Database().Return(db).AnyTimes()
 	db.EXPECT().Name().Return("db").AnyTimes()
+	opt := &option.DatabaseOption{}
+	db.EXPECT().GetOption().Return(opt).AnyTimes()
 	shard.EXPECT().BufferManager().Return(memdb.NewMockBufferManager(ctrl)).AnyTimes()
+	deadLetter := NewMockDeadLetterSink(ctrl)
+	dlSink = deadLetter
+	defer func() {
+		dlSink = &noopDeadLetterSink{}
+	}()
 
 	cases := []struct {
 		name    string
@@ -610,12 +1122,30 @@ func TestDataFamily_WriteRows(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "write rejected",
+			prepare: func() []metric.StorageRow {
+				RejectWrites()
+				deadLetter.EXPECT().Send("db", gomock.Any(), DeadLetterRejected, gomock.Any())
+				return mockBatchRows(&protoMetricsV1.Metric{
+					Name:      "test",
+					Timestamp: timeutil.Now(),
+					SimpleFields: []*protoMetricsV1.SimpleField{{
+						Name:  "f1",
+						Value: 1.0,
+						Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
+					}},
+				})
+			},
+			wantErr: true,
+		},
 		{
 			name: "get memory database failure",
 			prepare: func() []metric.StorageRow {
 				newMemoryDBFunc = func(cfg memdb.MemoryDatabaseCfg) (memdb.MemoryDatabase, error) {
 					return nil, fmt.Errorf("err")
 				}
+				deadLetter.EXPECT().Send("db", gomock.Any(), DeadLetterNoMemDB+": err", gomock.Any())
 				return mockBatchRows(&protoMetricsV1.Metric{
 					Name:      "test",
 					Timestamp: timeutil.Now(),
@@ -628,9 +1158,27 @@ func TestDataFamily_WriteRows(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "family is out of out-of-order window",
+			prepare: func() []metric.StorageRow {
+				opt.OutOfOrderWindow = "6h"
+				deadLetter.EXPECT().Send("db", gomock.Any(), DeadLetterTooLate, gomock.Any())
+				return mockBatchRows(&protoMetricsV1.Metric{
+					Name:      "test",
+					Timestamp: timeutil.Now(),
+					SimpleFields: []*protoMetricsV1.SimpleField{{
+						Name:  "f1",
+						Value: 1.0,
+						Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
+					}},
+				})
+			},
+			wantErr: false,
+		},
 		{
 			name: "metric is not writable",
 			prepare: func() []metric.StorageRow {
+				deadLetter.EXPECT().Send("db", gomock.Any(), DeadLetterUnwritable, gomock.Any())
 				return mockBatchRows(&protoMetricsV1.Metric{
 					Name:      "test",
 					Timestamp: timeutil.Now(),
@@ -643,10 +1191,29 @@ func TestDataFamily_WriteRows(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "metric is not writable with lookup failure",
+			prepare: func() []metric.StorageRow {
+				deadLetter.EXPECT().Send("db", gomock.Any(), DeadLetterUnwritable+": series limit", gomock.Any())
+				rows := mockBatchRows(&protoMetricsV1.Metric{
+					Name:      "test",
+					Timestamp: timeutil.Now(),
+					SimpleFields: []*protoMetricsV1.SimpleField{{
+						Name:  "f1",
+						Value: 1.0,
+						Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
+					}},
+				})
+				rows[0].LookupErr = fmt.Errorf("series limit")
+				return rows
+			},
+			wantErr: false,
+		},
 		{
 			name: "write metric failure",
 			prepare: func() []metric.StorageRow {
 				memDB.EXPECT().WriteRow(gomock.Any()).Return(fmt.Errorf("err"))
+				deadLetter.EXPECT().Send("db", gomock.Any(), DeadLetterWriteFailed+": err", gomock.Any())
 				rows := mockBatchRows(&protoMetricsV1.Metric{
 					Name:      "test",
 					Timestamp: timeutil.Now(),
@@ -679,18 +1246,63 @@ func TestDataFamily_WriteRows(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "record write usage of metric",
+			prepare: func() []metric.StorageRow {
+				tracker := NewMockMetricUsageTracker(ctrl)
+				mUsageTracker = tracker
+				memDB.EXPECT().WriteRow(gomock.Any()).Return(nil).Times(3)
+				rows := append(mockBatchRows(&protoMetricsV1.Metric{
+					Name:      "test",
+					Timestamp: timeutil.Now(),
+					SimpleFields: []*protoMetricsV1.SimpleField{
+						{Name: "f1", Value: 1.0, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM},
+						{Name: "f2", Value: 1.0, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM},
+					},
+				}), mockBatchRows(&protoMetricsV1.Metric{
+					Name:      "test",
+					Timestamp: timeutil.Now(),
+					SimpleFields: []*protoMetricsV1.SimpleField{
+						{Name: "f1", Value: 1.0, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM},
+					},
+				})...)
+				rows = append(rows, mockBatchRows(&protoMetricsV1.Metric{
+					Name:      "test2",
+					Timestamp: timeutil.Now(),
+					SimpleFields: []*protoMetricsV1.SimpleField{
+						{Name: "f1", Value: 1.0, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM},
+					},
+				})...)
+				for idx := range rows {
+					rows[idx].Writable = true
+				}
+				rows[0].MetricID, rows[0].FieldIDs = 1, []field.ID{1, 2}
+				rows[1].MetricID, rows[1].FieldIDs = 1, []field.ID{1}
+				rows[2].MetricID, rows[2].FieldIDs = 2, []field.ID{1}
+				gomock.InOrder(
+					tracker.EXPECT().RecordWrite("db", metric.ID(1), []byte("test"), 3),
+					tracker.EXPECT().RecordWrite("db", metric.ID(2), []byte("test2"), 1),
+				)
+				return rows
+			},
+			wantErr: false,
+		},
 	}
 
+	usageTracker := GetMetricUsageTracker()
 	for _, tt := range cases {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			defer func() {
 				newMemoryDBFunc = memdb.NewMemoryDatabase
+				AcceptWrites()
+				opt.OutOfOrderWindow = ""
+				mUsageTracker = usageTracker
 			}()
 			f := &dataFamily{
 				shard:      shard,
 				interval:   timeutil.Interval(10 * timeutil.OneSecond),
-				statistics: metrics.NewFamilyStatistics("data", "1"),
+				statistics: metrics.NewFamilyStatistics("data", "1", "family"),
 				logger:     logger.GetLogger("TSDB", "Test"),
 			}
 			f.intervalCalc = f.interval.Calculator()
@@ -698,7 +1310,7 @@ func TestDataFamily_WriteRows(t *testing.T) {
 				return memDB, nil
 			}
 			rows := tt.prepare()
-			err := f.WriteRows(rows)
+			_, err := f.WriteRows(1, rows)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("WriteRows() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -706,6 +1318,199 @@ func TestDataFamily_WriteRows(t *testing.T) {
 	}
 }
 
+func TestDataFamily_LeaderWriteIsolation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	memDB := memdb.NewMockMemoryDatabase(ctrl)
+	memDB.EXPECT().WithLock().Return(func() {}).AnyTimes()
+	memDB.EXPECT().CompleteWrite().AnyTimes()
+	memDB.EXPECT().AcquireWrite().AnyTimes()
+	memDB.EXPECT().MemSize().Return(int64(10)).AnyTimes()
+	// rows of leader 2 are corrupted, writing always panics
+	memDB.EXPECT().WriteRow(gomock.Any()).DoAndReturn(func(row *metric.StorageRow) error {
+		if row.MetricID == 2 {
+			panic("corrupted row")
+		}
+		return nil
+	}).AnyTimes()
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	db.EXPECT().Name().Return("db").AnyTimes()
+	db.EXPECT().GetOption().Return(&option.DatabaseOption{}).AnyTimes()
+	f := &dataFamily{
+		shard:        shard,
+		interval:     timeutil.Interval(10 * timeutil.OneSecond),
+		familyTime:   timeutil.Now(),
+		mutableMemDB: memDB,
+		leaders:      make(map[int32]*leaderWriter),
+		persistSeq:   make(map[int32]atomic.Int64),
+		statistics:   metrics.NewFamilyStatistics("data", "1", "family"),
+		logger:       logger.GetLogger("TSDB", "Test"),
+	}
+	f.intervalCalc = f.interval.Calculator()
+	newRows := func(metricID metric.ID) []metric.StorageRow {
+		rows := mockBatchRows(&protoMetricsV1.Metric{
+			Name:      "test",
+			Timestamp: timeutil.Now(),
+			SimpleFields: []*protoMetricsV1.SimpleField{
+				{Name: "f1", Value: 1.0, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM},
+			},
+		})
+		rows[0].Writable, rows[0].MetricID, rows[0].FieldIDs = true, metricID, []field.ID{1}
+		return rows
+	}
+
+	const batches = 100
+	var wait sync.WaitGroup
+	errs := make([][]error, 3) // leader => errors of batches
+	for _, leader := range []int32{1, 2} {
+		leader := leader
+		rows := newRows(metric.ID(leader))
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for seq := int64(1); seq <= batches; seq++ {
+				if !f.ValidateSequence(leader, seq) {
+					continue
+				}
+				_, err := f.WriteRows(leader, rows)
+				errs[leader] = append(errs[leader], err)
+				f.CommitSequence(leader, seq)
+			}
+		}()
+	}
+	wait.Wait()
+
+	// leader 1 keeps writing, failures of leader 2 are isolated
+	assert.Len(t, errs[1], batches)
+	for _, err := range errs[1] {
+		assert.NoError(t, err)
+	}
+	assert.Len(t, errs[2], batches)
+	for _, err := range errs[2] {
+		assert.ErrorIs(t, err, constants.ErrWritePanic)
+	}
+	assert.Equal(t, models.LeaderWriteState{}, f.GetLeaderWriteState(1))
+	state := f.GetLeaderWriteState(2)
+	assert.Equal(t, int64(batches), state.Failures)
+	assert.Equal(t, int64(batches), state.Panics)
+	assert.Contains(t, state.LastErr, "corrupted row")
+	assert.NotZero(t, state.LastErrAt)
+	assert.Equal(t, models.LeaderWriteState{}, f.GetLeaderWriteState(3))
+	// sequence of bad leader still moves forward, batches are dropped
+	assert.Equal(t, map[int32]int64{1: batches, 2: batches}, f.replicaSequences())
+	assert.Equal(t, map[int32]models.LeaderWriteState{2: state}, f.leaderWriteStates())
+
+	// write failure without panic
+	RejectWrites()
+	defer AcceptWrites()
+	_, err := f.WriteRows(1, newRows(1))
+	assert.ErrorIs(t, err, constants.ErrWriteRejected)
+	state = f.GetLeaderWriteState(1)
+	assert.Equal(t, int64(1), state.Failures)
+	assert.Zero(t, state.Panics)
+	assert.Equal(t, constants.ErrWriteRejected.Error(), state.LastErr)
+}
+
+func TestDataFamily_WriteBackpressure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+		ctrl.Finish()
+	}()
+	cfg := config.NewDefaultStorageBase()
+	cfg.TSDB.MaxMemDBSize = ltoml.Size(100)
+	cfg.TSDB.MaxWriteBackoff = ltoml.Duration(time.Second)
+	config.SetGlobalStorageConfig(cfg)
+
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	db.EXPECT().Name().Return("db").AnyTimes()
+	db.EXPECT().GetOption().Return(&option.DatabaseOption{}).AnyTimes()
+	f := &dataFamily{
+		shard:      shard,
+		interval:   timeutil.Interval(10 * timeutil.OneSecond),
+		statistics: metrics.NewFamilyStatistics("data", "1", "family"),
+		logger:     logger.GetLogger("TSDB", "Test"),
+	}
+	f.intervalCalc = f.interval.Calculator()
+	rows := mockBatchRows(&protoMetricsV1.Metric{
+		Name:      "test",
+		Timestamp: timeutil.Now(),
+		SimpleFields: []*protoMetricsV1.SimpleField{
+			{Name: "f1", Value: 1.0, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM},
+		},
+	})
+	newMemDB := func(size int64) memdb.MemoryDatabase {
+		memDB := memdb.NewMockMemoryDatabase(ctrl)
+		memDB.EXPECT().WithLock().Return(func() {}).AnyTimes()
+		memDB.EXPECT().CompleteWrite().AnyTimes()
+		memDB.EXPECT().AcquireWrite().AnyTimes()
+		memDB.EXPECT().MemSize().Return(size).AnyTimes()
+		return memDB
+	}
+	// below soft threshold
+	f.mutableMemDB = newMemDB(100)
+	retryAfter, err := f.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.Zero(t, retryAfter)
+	// flush falls behind, backoff is proportional to the overflow
+	f.mutableMemDB = newMemDB(150)
+	retryAfter, err = f.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+	f.mutableMemDB = newMemDB(1000)
+	retryAfter, err = f.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, retryAfter)
+	// flush completed, hint clears
+	f.mutableMemDB = newMemDB(10)
+	retryAfter, err = f.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.Zero(t, retryAfter)
+	// backpressure disabled
+	cfg.TSDB.MaxWriteBackoff = 0
+	f.mutableMemDB = newMemDB(1000)
+	retryAfter, err = f.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.Zero(t, retryAfter)
+}
+
+func TestDataFamily_OutOfOrderWindow(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	opt := &option.DatabaseOption{}
+	db.EXPECT().GetOption().Return(opt).AnyTimes()
+
+	now := timeutil.Now()
+	interval := timeutil.Interval(10 * timeutil.OneSecond)
+	calc := interval.Calculator()
+	familyTime := calc.CalcFamilyTime(now - 3*timeutil.OneHour)
+	f := &dataFamily{
+		shard:        shard,
+		intervalCalc: calc,
+		familyTime:   familyTime,
+		timeRange:    timeutil.TimeRange{Start: familyTime, End: calc.CalcFamilyEndTime(familyTime)},
+	}
+	assert.True(t, f.isLate(now))
+	assert.False(t, f.isTooLate(now))
+	opt.OutOfOrderWindow = "6h"
+	assert.False(t, f.isTooLate(now))
+	opt.OutOfOrderWindow = "1h"
+	assert.True(t, f.isTooLate(now))
+
+	f.familyTime = calc.CalcFamilyTime(now)
+	assert.False(t, f.isLate(now))
+}
+
 func TestDataFamily_GetState(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -718,21 +1523,28 @@ func TestDataFamily_GetState(t *testing.T) {
 	db.EXPECT().MemSize().Return(int64(10)).MaxTimes(2)
 	db.EXPECT().Uptime().Return(time.Duration(10)).MaxTimes(2)
 	now := timeutil.Now()
+	familyTime := now - timeutil.OneHour
 	f := &dataFamily{
 		shard:          shard,
-		familyTime:     now,
+		familyTime:     familyTime,
+		interval:       timeutil.Interval(10 * timeutil.OneSecond),
+		timeRange:      timeutil.TimeRange{Start: familyTime, End: now},
 		mutableMemDB:   db,
 		immutableMemDB: db,
-		seq:            map[int32]atomic.Int64{10: *atomic.NewInt64(10)},
+		leaders:        map[int32]*leaderWriter{10: newLeaderWriter(10)},
 		persistSeq:     map[int32]atomic.Int64{10: *atomic.NewInt64(10)},
+		latestSlots:    map[int32]uint16{10: 6},
 	}
 
 	state := f.GetState()
+	assert.GreaterOrEqual(t, state.IngestionLag, timeutil.OneHour-timeutil.OneMinute)
+	state.IngestionLag = 0
 	assert.Equal(t, models.DataFamilyState{
 		ShardID:          models.ShardID(1),
-		FamilyTime:       timeutil.FormatTimestamp(now, timeutil.DataTimeFormat2),
+		FamilyTime:       timeutil.FormatTimestamp(familyTime, timeutil.DataTimeFormat2),
 		AckSequences:     map[int32]int64{10: 10},
 		ReplicaSequences: map[int32]int64{10: 10},
+		LatestTimestamps: map[int32]int64{10: familyTime + timeutil.OneMinute},
 		MemoryDatabases: []models.MemoryDatabaseState{
 			{
 				State:        "immutable",
@@ -750,6 +1562,32 @@ func TestDataFamily_GetState(t *testing.T) {
 	}, state)
 }
 
+func TestDataFamily_IngestionLag(t *testing.T) {
+	now := timeutil.Now()
+	familyTime := now - timeutil.OneHour
+	f := &dataFamily{
+		familyTime: familyTime,
+		interval:   timeutil.Interval(10 * timeutil.OneSecond),
+		timeRange:  timeutil.TimeRange{Start: familyTime, End: familyTime + timeutil.OneHour - 1},
+	}
+	// no data written, lag since family start time
+	assert.GreaterOrEqual(t, f.IngestionLag(), time.Hour)
+	assert.Zero(t, f.MaxDataTimestamp())
+	f.recordLatestSlot(1, 180)
+	f.recordLatestSlot(2, 6)
+	f.recordLatestSlot(1, 30)
+	assert.Equal(t, map[int32]uint16{1: 180, 2: 6}, f.latestSlots)
+	assert.Equal(t, familyTime+30*timeutil.OneMinute, f.MaxDataTimestamp())
+	lag := f.IngestionLag()
+	assert.GreaterOrEqual(t, lag, 30*time.Minute)
+	assert.Less(t, lag, time.Hour)
+	// data written ahead
+	f.recordLatestSlot(1, 359)
+	f.timeRange.Start = now + timeutil.OneHour
+	f.familyTime = now + timeutil.OneHour
+	assert.Zero(t, f.IngestionLag())
+}
+
 func TestDataFamily_Compact(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -835,7 +1673,7 @@ func TestDataFamily_Evict(t *testing.T) {
 				shard:        shard,
 				segment:      segment,
 				lastReadTime: atomic.NewInt64(fasttime.UnixMilliseconds()),
-				statistics:   metrics.NewFamilyStatistics("data", "1"),
+				statistics:   metrics.NewFamilyStatistics("data", "1", "family"),
 				logger:       logger.GetLogger("TSDB", "Test"),
 			}
 			if tt.prepare != nil {
@@ -845,3 +1683,70 @@ func TestDataFamily_Evict(t *testing.T) {
 		})
 	}
 }
+
+// flushTestMetric flushes one metric block of memory database.
+func flushTestMetric(flusher metricsdata.Flusher) error {
+	flusher.PrepareMetric(10, field.Metas{{ID: 1, Type: field.SumField}})
+	if err := flusher.FlushField([]byte{1, 2, 3}); err != nil {
+		return err
+	}
+	if err := flusher.FlushSeries(1); err != nil {
+		return err
+	}
+	if err := flusher.CommitMetric(timeutil.SlotRange{Start: 10, End: 13}); err != nil {
+		return err
+	}
+	return flusher.Close()
+}
+
+// newFaultTestKVFamily returns the kv family under temp dir.
+func newFaultTestKVFamily(t *testing.T) kv.Family {
+	storeMgr := kv.NewStoreManager(kv.StoreOptions{Dir: t.TempDir()})
+	store, err := storeMgr.CreateStore("fault", kv.DefaultStoreOption())
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = storeMgr.CloseStore("fault")
+	})
+	family, err := store.CreateFamily("20220101", kv.FamilyOption{Merger: string(metricsdata.MetricDataMerger)})
+	assert.NoError(t, err)
+	return family
+}
+
+// openFaultTestFamily opens the data family based on kv family, like restarting storage.
+func openFaultTestFamily(t *testing.T, ctrl *gomock.Controller, family kv.Family) *dataFamily {
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db").AnyTimes()
+	db.EXPECT().GetOption().Return(&option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}}).AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	shard.EXPECT().BufferManager().Return(nil).AnyTimes()
+	f := newDataFamily(shard, nil, timeutil.Interval(10*1000), timeutil.TimeRange{}, 0, family).(*dataFamily)
+	t.Cleanup(func() {
+		defaultEngineContext.familyManager().RemoveFamily(f)
+	})
+	return f
+}
+
+// newFaultTestMemDB returns the memory database with data.
+func newFaultTestMemDB(ctrl *gomock.Controller) *memdb.MockMemoryDatabase {
+	memDB := memdb.NewMockMemoryDatabase(ctrl)
+	memDB.EXPECT().NumOfMetrics().Return(1).AnyTimes()
+	memDB.EXPECT().MemSize().Return(int64(100)).AnyTimes()
+	memDB.EXPECT().MarkReadOnly().AnyTimes()
+	return memDB
+}
+
+// persistedSequences returns the write sequences persisted in kv family.
+func persistedSequences(family kv.Family) map[int32]int64 {
+	snapshot := family.GetSnapshot()
+	defer snapshot.Close()
+	return snapshot.GetCurrent().GetSequences()
+}
+
+// persistedMemDBCreatedTime returns the created time of memory database persisted in kv family.
+func persistedMemDBCreatedTime(family kv.Family) int64 {
+	snapshot := family.GetSnapshot()
+	defer snapshot.Close()
+	return snapshot.GetCurrent().GetMemDBCreatedTime()
+}
@@ -43,7 +43,7 @@ var (
 )
 
 // DataFlushChecker represents the memory database flush checker.
-// There are 4 flush policies of the Engine as below:
+// There are 5 flush policies of the Engine as below:
 //  1. FullFlush
 //     the highest priority, triggered by external API from the users.
 //     this action will block any other flush checkers.
@@ -54,7 +54,11 @@ var (
 //  3. FamilyMemoryUsageChecker
 //     This checker will check each family's memory usage periodically,
 //     If this family is above FamilyMemoryUsedThreshold. it will be flushed to disk.
-//  4. DatabaseMetaFlusher
+//  4. NodeMemDBUsageChecker
+//     This checker will check total memdb size of all families periodically,
+//     when it is above max-memdb-total-size, the biggest families will be flushed
+//     until total size is lower than target-memdb-total-size, restricted by idle flush workers.
+//  5. DatabaseMetaFlusher
 //     It is a simple checker which flush the meta of database to disk periodically.
 //
 // a). Each family or database is restricted to flush by one goroutine at the same time via CAS operation;
@@ -90,10 +94,12 @@ type dataFlushChecker struct {
 
 	dbInFlushing         sync.Map           // database name => flush request
 	flushRequestCh       chan *flushRequest // family to flush
+	cfgReloadedCh        chan struct{}      // tsdb config reloaded, check with new thresholds
 	flushInFlight        atomic.Int32       // current pending in flushing
 	isWatermarkFlushing  atomic.Bool        // this flag symbols if it has goroutine in high water-mark flushing
 	running              *atomic.Bool
 	memoryStatGetterFunc monitoring.MemoryStatGetter // used for mocking
+	governor             *memoryGovernor             // node-level memdb size governor
 
 	logger *logger.Logger
 }
@@ -105,7 +111,9 @@ func newDataFlushChecker(ctx context.Context) DataFlushChecker {
 		ctx:                  c,
 		cancel:               cancel,
 		flushRequestCh:       make(chan *flushRequest, 8),
+		cfgReloadedCh:        make(chan struct{}, 1),
 		memoryStatGetterFunc: mem.VirtualMemory,
+		governor:             newMemoryGovernor(GetFamilyManager()),
 		running:              atomic.NewBool(false),
 		logger:               engineLogger,
 	}
@@ -137,7 +145,10 @@ func (fc *dataFlushChecker) startCheckDataFlush() {
 	}
 	fc.logger.Info("Data flush checker is running",
 		logger.Int32("workers", int32(config.GlobalStorageConfig().TSDB.FlushConcurrency)))
+	// thresholds are read from global storage config when checking, so check immediately after config reloaded
+	removeCfgListener := config.AddStorageConfigListener(fc.onConfigReloaded)
 	defer func() {
+		removeCfgListener()
 		fc.logger.Info("Data flush checker exist")
 	}()
 
@@ -145,6 +156,8 @@ func (fc *dataFlushChecker) startCheckDataFlush() {
 		select {
 		case <-fc.ctx.Done():
 			return
+		case <-fc.cfgReloadedCh:
+			fc.check()
 		case <-timer.C:
 			fc.check()
 			// reset check interval
@@ -153,34 +166,32 @@ func (fc *dataFlushChecker) startCheckDataFlush() {
 	}
 }
 
+// onConfigReloaded notifies the checker to check with new thresholds after tsdb config reloaded.
+func (fc *dataFlushChecker) onConfigReloaded(_ int64, _ []string) {
+	select {
+	case fc.cfgReloadedCh <- struct{}{}:
+	default:
+		// check is pending
+	}
+}
+
 // check finds family which need flush data.
 func (fc *dataFlushChecker) check() {
 	needFlushDBs := make(map[string]*flushRequest)
+	needFlushFamilies := make(map[string]struct{})
 	// check each family if it needs to do flush job
 	GetFamilyManager().WalkEntry(func(family DataFamily) {
 		if family.NeedFlush() {
-			shard := family.Shard()
-			dbName := shard.Database().Name()
-			needFlushDB, ok := needFlushDBs[dbName]
-			if !ok {
-				needFlushDB = &flushRequest{
-					db:     shard.Database(),
-					shards: make(map[models.ShardID]*flushShard),
-					global: false,
-				}
-				needFlushDBs[dbName] = needFlushDB
-			}
-			needFlushShard, ok := needFlushDB.shards[shard.ShardID()]
-			if !ok {
-				needFlushShard = &flushShard{
-					shard: shard,
-				}
-				needFlushDB.shards[shard.ShardID()] = needFlushShard
-			}
-			needFlushShard.families = append(needFlushShard.families, family)
-			metrics.FlushCheckerStatistics.FlushInFlight.WithTagValues(dbName, strconv.Itoa(int(shard.ShardID()))).Incr()
+			addFlushFamily(needFlushDBs, family)
+			needFlushFamilies[family.Indicator()] = struct{}{}
 		}
 	})
+	// check total memdb size of node, pick the biggest families to flush if above high watermark,
+	// restrict picked families by idle flush workers.
+	idleWorkers := config.GlobalStorageConfig().TSDB.FlushConcurrency - int(fc.flushInFlight.Load()) - len(needFlushDBs)
+	for _, family := range fc.governor.pick(idleWorkers, needFlushFamilies) {
+		addFlushFamily(needFlushDBs, family)
+	}
 
 	for _, request := range needFlushDBs {
 		fc.requestFlushJob(request)
@@ -201,6 +212,30 @@ func (fc *dataFlushChecker) check() {
 	}
 }
 
+// addFlushFamily adds family into flush request of its database.
+func addFlushFamily(needFlushDBs map[string]*flushRequest, family DataFamily) {
+	shard := family.Shard()
+	dbName := shard.Database().Name()
+	needFlushDB, ok := needFlushDBs[dbName]
+	if !ok {
+		needFlushDB = &flushRequest{
+			db:     shard.Database(),
+			shards: make(map[models.ShardID]*flushShard),
+			global: false,
+		}
+		needFlushDBs[dbName] = needFlushDB
+	}
+	needFlushShard, ok := needFlushDB.shards[shard.ShardID()]
+	if !ok {
+		needFlushShard = &flushShard{
+			shard: shard,
+		}
+		needFlushDB.shards[shard.ShardID()] = needFlushShard
+	}
+	needFlushShard.families = append(needFlushShard.families, family)
+	metrics.FlushCheckerStatistics.FlushInFlight.WithTagValues(dbName, strconv.Itoa(int(shard.ShardID()))).Incr()
+}
+
 // requestFlushJob requests a flush job for the spec shard/families.
 func (fc *dataFlushChecker) requestFlushJob(request *flushRequest) {
 	if !fc.running.Load() {
@@ -60,6 +60,25 @@ func TestDataFlushCheck_startCheckDataFlush(t *testing.T) {
 	checker1.startCheckDataFlush()
 }
 
+func TestDataFlushCheck_onConfigReloaded(t *testing.T) {
+	defer config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+
+	checker := newDataFlushChecker(context.TODO())
+	checker1 := checker.(*dataFlushChecker)
+	checker1.running.Store(true)
+	go checker1.startCheckDataFlush()
+	time.Sleep(50 * time.Millisecond)
+
+	tsdbCfg := config.GlobalStorageConfig().TSDB
+	tsdbCfg.MaxMemDBSize *= 2
+	assert.NoError(t, config.ReloadStorageTSDBConfig(tsdbCfg))
+	// pending check, ignore notification
+	checker1.onConfigReloaded(1, nil)
+	checker1.onConfigReloaded(1, nil)
+	time.Sleep(50 * time.Millisecond)
+	checker.Stop()
+}
+
 func TestDataFamilyCheck_check(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -161,6 +180,37 @@ func TestDataFamilyCheck_check(t *testing.T) {
 				}, v)
 			},
 		},
+		{
+			name: "pick family for node memdb total size limit",
+			prepare: func(_ *dataFlushChecker) {
+				GetFamilyManager().AddFamily(family2)
+				family2.EXPECT().NeedFlush().Return(false)
+				family2.EXPECT().MemDBSize().Return(int64(200))
+				family2.EXPECT().IsFlushing().Return(false)
+				GetFamilyManager().AddFamily(family1)
+				family1.EXPECT().NeedFlush().Return(true)
+				family1.EXPECT().MemDBSize().Return(int64(300))
+				cfg := config.GlobalStorageConfig()
+				cfg.TSDB.FlushConcurrency = 4
+				cfg.TSDB.MaxMemDBTotalSize = 100
+				cfg.TSDB.TargetMemDBTotalSize = 0
+				config.SetGlobalStorageConfig(cfg)
+			},
+			assert: func(c *dataFlushChecker) {
+				v, ok := c.dbInFlushing.Load("db")
+				assert.True(t, ok)
+				assert.Equal(t, &flushRequest{
+					db: db,
+					shards: map[models.ShardID]*flushShard{
+						models.ShardID(1): {
+							families: []DataFamily{family1, family2},
+							shard:    shard,
+						},
+					},
+					global: false,
+				}, v)
+			},
+		},
 		{
 			name: "pick family for Global memory limit, but no match family",
 			prepare: func(_ *dataFlushChecker) {
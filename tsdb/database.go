@@ -27,6 +27,7 @@ import (
 
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/internal/concurrent"
 	"github.com/lindb/lindb/internal/linmetric"
 	"github.com/lindb/lindb/kv"
@@ -34,12 +35,16 @@ import (
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/tsdb/metadb"
 	"github.com/lindb/lindb/tsdb/tblstore/tagkeymeta"
 )
 
 //go:generate mockgen -source=./database.go -destination=./database_mock.go -package=tsdb
 
+// for testing
+var flushingCheckInterval = 10 * time.Millisecond
+
 // Database represents an abstract time series database
 type Database interface {
 	// Name returns time series database's name
@@ -50,6 +55,8 @@ type Database interface {
 	GetConfig() *models.DatabaseConfig
 	// GetOption returns the database options
 	GetOption() *option.DatabaseOption
+	// SetOption sets the database options after modified, changed compaction policy takes effect for new compactions.
+	SetOption(databaseOption *option.DatabaseOption) error
 	// CreateShards creates families for data partition
 	CreateShards(shardIDs []models.ShardID) error
 	// GetShard returns shard by given shard id
@@ -66,12 +73,31 @@ type Database interface {
 	WaitFlushMetaCompleted()
 	// Flush flushes memory data of all families to disk
 	Flush() error
+	// ForceFlush flushes metadata, index and memory data of all families to disk,
+	// blocks until flush jobs completed(include the jobs running in background).
+	ForceFlush(ctx context.Context) error
 	// Drop drops current database include all data.
 	Drop() error
 	// TTL expires the data of each shard base on time to live.
 	TTL()
+	// GCSeries reclaims the unused series of each shard.
+	GCSeries(horizon time.Duration, limit int)
 	// EvictSegment evicts segment which long term no read operation.
 	EvictSegment()
+	// HibernateIdleShards hibernates the shards without writes/queries within idle period.
+	HibernateIdleShards(idle time.Duration)
+	// WarmUpMetric warms up the index and the newest family of metric in each shard with throttled io.
+	WarmUpMetric(ctx context.Context, metricID metric.ID, throttle func(ctx context.Context, bytes int) error) error
+	// TierFamilies moves the files of families older than the tier after option to object storage,
+	// returns the num. of families tiered.
+	TierFamilies(ctx context.Context, throttle kv.Throttle) (int, error)
+
+	// recoveryTasks returns the tasks for opening the segments of all shards after startup.
+	recoveryTasks() []*recoveryTask
+	// getFieldRetention returns the field level retention of database.
+	getFieldRetention() *fieldRetention
+	// getFieldPrecision returns the field value precision of database.
+	getFieldPrecision() *fieldPrecision
 }
 
 // database implements Database for storing families,
@@ -87,26 +113,28 @@ type database struct {
 	metaStore      kv.Store               // underlying meta kv store
 	isFlushing     atomic.Bool            // restrict flusher concurrency
 	flushCondition *sync.Cond             // flush condition
+	fieldRetention *fieldRetention        // field level retention
+	fieldPrecision *fieldPrecision        // field value precision
 
 	statistics *metrics.DatabaseStatistics
 
-	flushChecker DataFlushChecker
+	engineCtx *engineContext // resources of engine which database belongs to
 }
 
 // newDatabase creates the database instance
 func newDatabase(
 	databaseName string,
 	cfg *models.DatabaseConfig,
-	flushChecker DataFlushChecker,
+	engineCtx *engineContext,
 ) (Database, error) {
 	if err := cfg.Option.Validate(); err != nil {
 		return nil, fmt.Errorf("database option is invalid, err: %s", err)
 	}
 	db := &database{
-		name:         databaseName,
-		flushChecker: flushChecker,
-		config:       cfg,
-		shardSet:     *newShardSet(),
+		name:      databaseName,
+		engineCtx: engineCtx,
+		config:    cfg,
+		shardSet:  *newShardSet(),
 		executorPool: &ExecutorPool{
 			Filtering: concurrent.NewPool(
 				databaseName+"-filtering-pool",
@@ -131,13 +159,16 @@ func newDatabase(
 		flushCondition: sync.NewCond(&sync.Mutex{}),
 		statistics:     metrics.NewDatabaseStatistics(databaseName),
 	}
-	dbPath, err0 := createDatabasePath(databaseName)
+	dbPath, err0 := createDatabasePath(engineCtx.rootDir(), databaseName)
 	if err0 != nil {
 		return nil, err0
 	}
 	db.dir = dbPath
-	if err := db.dumpDatabaseConfig(cfg); err != nil {
-		return nil, err
+	// read-only database uses the config loaded from local file
+	if !engineCtx.readOnly {
+		if err := db.dumpDatabaseConfig(cfg); err != nil {
+			return nil, err
+		}
 	}
 	if err := db.initMetadata(); err != nil {
 		return nil, err
@@ -151,20 +182,68 @@ func newDatabase(
 			}
 		}
 	}()
+	if db.fieldRetention, err = newFieldRetention(fieldTruncationsPath(engineCtx.rootDir(), databaseName), db.metadata); err != nil {
+		return nil, err
+	}
+	db.fieldRetention.setRules(cfg.Option.FieldRetentions)
+	db.fieldPrecision = newFieldPrecision(db.metadata)
+	db.fieldPrecision.setRules(cfg.Option.FieldPrecisions)
+	if cfg.Option.AllowFieldTypeChange {
+		db.metadata.MetadataDatabase().SetAllowFieldTypeChange(true)
+	}
 	// load families if engine is existed
-	var shard Shard
-	if len(db.config.ShardIDs) > 0 {
-		for _, shardID := range db.config.ShardIDs {
-			shard, err = newShardFunc(db, shardID)
-			if err != nil {
-				return nil, fmt.Errorf("cannot create shard[%d] of database[%s] with error: %s",
-					shardID, databaseName, err)
+	if err = db.loadShards(engineCtx.recovery); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// loadShards opens the existed shards of database in parallel.
+func (db *database) loadShards(recovery *recoveryTracker) error {
+	shardIDs := db.config.ShardIDs
+	if len(shardIDs) == 0 {
+		return nil
+	}
+	recovery.addTotal(len(shardIDs))
+	shards := make([]Shard, len(shardIDs))
+	errs := make([]error, len(shardIDs))
+	limit := make(chan struct{}, recoveryConcurrency())
+	var wait sync.WaitGroup
+	for idx := range shardIDs {
+		wait.Add(1)
+		limit <- struct{}{}
+		go func(idx int) {
+			defer func() {
+				<-limit
+				wait.Done()
+			}()
+			shards[idx], errs[idx] = newShardFunc(db, shardIDs[idx])
+			if errs[idx] == nil {
+				recovery.incOpened()
+			}
+		}(idx)
+	}
+	wait.Wait()
+	for idx, err := range errs {
+		if err != nil {
+			// close the opened shards
+			for _, shard := range shards {
+				if shard == nil {
+					continue
+				}
+				if err0 := shard.Close(); err0 != nil {
+					engineLogger.Error("close shard error when load shards fail",
+						logger.String("database", db.name), logger.Any("shardID", shard.ShardID()), logger.Error(err0))
+				}
 			}
-			db.shardSet.InsertShard(shardID, shard)
+			return fmt.Errorf("cannot create shard[%d] of database[%s] with error: %s",
+				shardIDs[idx], db.name, err)
 		}
 	}
-
-	return db, nil
+	for idx, shardID := range shardIDs {
+		db.shardSet.InsertShard(shardID, shards[idx])
+	}
+	return nil
 }
 
 // Metadata returns the metadata include metric/tag
@@ -192,10 +271,67 @@ func (db *database) GetOption() *option.DatabaseOption {
 	return db.config.Option
 }
 
+// SetOption sets the database options after modified, changed compaction policy takes effect for new compactions.
+func (db *database) SetOption(databaseOption *option.DatabaseOption) error {
+	if databaseOption == nil {
+		return nil
+	}
+	if db.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	oldOption := db.config.Option
+	addedIntervals, err := databaseOption.AddedIntervals(oldOption)
+	if err != nil {
+		return err
+	}
+	// added rollup intervals take effect for new flushed files, history files are rolled up by backfill job,
+	// adding is idempotent, so it is done before dumping config for retrying if failure
+	if len(addedIntervals) > 0 {
+		for _, shardEntry := range db.shardSet.Entries() {
+			if err := shardEntry.shard.AddRollupIntervals(addedIntervals); err != nil {
+				return err
+			}
+		}
+		engineLogger.Info("rollup intervals of database added",
+			logger.String("database", db.name), logger.Any("intervals", addedIntervals))
+	}
+	newCfg := &models.DatabaseConfig{Option: databaseOption, ShardIDs: db.config.ShardIDs}
+	if err := db.dumpDatabaseConfig(newCfg); err != nil {
+		return err
+	}
+	// field retention takes effect for new compactions and next retention reaper
+	if db.fieldRetention != nil {
+		db.fieldRetention.setRules(databaseOption.FieldRetentions)
+	}
+	// field precision takes effect for new compactions
+	if db.fieldPrecision != nil {
+		db.fieldPrecision.setRules(databaseOption.FieldPrecisions)
+	}
+	if oldOption == nil || oldOption.AllowFieldTypeChange != databaseOption.AllowFieldTypeChange {
+		db.metadata.MetadataDatabase().SetAllowFieldTypeChange(databaseOption.AllowFieldTypeChange)
+	}
+	policy := databaseOption.GetCompactionPolicy()
+	if oldOption != nil && oldOption.GetCompactionPolicy() == policy {
+		return nil
+	}
+	for _, shardEntry := range db.shardSet.Entries() {
+		shardEntry.shard.SetCompactionPolicy(policy)
+	}
+	engineLogger.Info("compaction policy of database changed",
+		logger.String("database", db.name), logger.Any("policy", policy))
+	return nil
+}
+
 // CreateShards creates families for data partition
 func (db *database) CreateShards(
 	shardIDs []models.ShardID,
 ) error {
+	if db.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
 	if len(shardIDs) == 0 {
 		return fmt.Errorf("shardIDs list is empty")
 	}
@@ -257,7 +393,7 @@ func (db *database) Close() error {
 	if err := db.metadata.Close(); err != nil {
 		return err
 	}
-	if err := kv.GetStoreManager().CloseStore(db.metaStore.Name()); err != nil {
+	if err := db.engineContext().storeManager().CloseStore(db.metaStore.Name()); err != nil {
 		return err
 	}
 	for _, shardEntry := range db.shardSet.Entries() {
@@ -267,15 +403,83 @@ func (db *database) Close() error {
 				"close shard[%d] of database[%s]", shardEntry.shardID, db.name), logger.Error(err))
 		}
 	}
+	db.flushFieldTruncations()
 	return nil
 }
 
-// TTL expires the data of each shard base on time to live.
+// TTL expires the data of each shard base on time to live,
+// rewrites the families which contain field data out of field level retention.
 func (db *database) TTL() {
+	if db.engineContext().readOnly {
+		return
+	}
 	for _, shardEntry := range db.shardSet.Entries() {
 		thisShard := shardEntry.shard
 		thisShard.TTL()
 	}
+	db.flushFieldTruncations()
+}
+
+// getFieldRetention returns the field level retention of database.
+func (db *database) getFieldRetention() *fieldRetention {
+	return db.fieldRetention
+}
+
+// getFieldPrecision returns the field value precision of database.
+func (db *database) getFieldPrecision() *fieldPrecision {
+	return db.fieldPrecision
+}
+
+// engineContext returns the resources of engine which database belongs to.
+func (db *database) engineContext() *engineContext {
+	if db.engineCtx == nil {
+		return defaultEngineContext
+	}
+	return db.engineCtx
+}
+
+// flushFieldTruncations persists the truncation records of field level retention.
+func (db *database) flushFieldTruncations() {
+	if db.fieldRetention == nil || db.engineContext().readOnly {
+		return
+	}
+	if err := db.fieldRetention.flush(); err != nil {
+		engineLogger.Warn("persist field truncations failure",
+			logger.String("database", db.name), logger.Error(err))
+	}
+}
+
+// GCSeries reclaims the unused series of each shard.
+func (db *database) GCSeries(horizon time.Duration, limit int) {
+	if db.engineContext().readOnly {
+		return
+	}
+	for _, shardEntry := range db.shardSet.Entries() {
+		thisShard := shardEntry.shard
+		thisShard.GCSeries(horizon, limit)
+	}
+}
+
+// WarmUpMetric warms up the index and the newest family of metric in each shard with throttled io.
+func (db *database) WarmUpMetric(
+	ctx context.Context,
+	metricID metric.ID,
+	throttle func(ctx context.Context, bytes int) error,
+) error {
+	for _, shardEntry := range db.shardSet.Entries() {
+		if err := warmUpMetricFunc(ctx, shardEntry.shard, metricID, throttle); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoveryTasks returns the tasks for opening the segments of all shards after startup.
+func (db *database) recoveryTasks() (tasks []*recoveryTask) {
+	for _, shardEntry := range db.shardSet.Entries() {
+		tasks = append(tasks, shardEntry.shard.recoveryTasks()...)
+	}
+	return tasks
 }
 
 // EvictSegment evicts segment which long term no read operation.
@@ -286,9 +490,16 @@ func (db *database) EvictSegment() {
 	}
 }
 
+// HibernateIdleShards hibernates the shards without writes/queries within idle period.
+func (db *database) HibernateIdleShards(idle time.Duration) {
+	for _, shardEntry := range db.shardSet.Entries() {
+		shardEntry.shard.Hibernate(idle)
+	}
+}
+
 // dumpDatabaseConfig persists option info to OPTIONS file
 func (db *database) dumpDatabaseConfig(newConfig *models.DatabaseConfig) error {
-	cfgPath := optionsPath(db.name)
+	cfgPath := optionsPath(db.engineContext().rootDir(), db.name)
 	// write store info using toml format
 	if err := encodeToml(cfgPath, newConfig); err != nil {
 		return fmt.Errorf("write engine options to file[%s] error:%s", cfgPath, err)
@@ -300,7 +511,7 @@ func (db *database) dumpDatabaseConfig(newConfig *models.DatabaseConfig) error {
 // initMetadata initializes metadata backend storage
 func (db *database) initMetadata() error {
 	// FIXME close kv store if err??
-	metaStore, err := kv.GetStoreManager().CreateStore(tagMetaIndicator(db.name), kv.DefaultStoreOption())
+	metaStore, err := db.engineContext().storeManager().CreateStore(tagMetaIndicator(db.name), kv.DefaultStoreOption())
 	if err != nil {
 		return err
 	}
@@ -313,7 +524,7 @@ func (db *database) initMetadata() error {
 		return err
 	}
 	db.metaStore = metaStore
-	metadata, err := newMetadataFunc(context.TODO(), db.name, metricsMetaPath(db.name), tagMetaFamily)
+	metadata, err := newMetadataFunc(context.TODO(), db.name, metricsMetaPath(db.engineContext().rootDir(), db.name), tagMetaFamily)
 	if err != nil {
 		return err
 	}
@@ -323,6 +534,9 @@ func (db *database) initMetadata() error {
 
 // FlushMeta flushes meta to disk.
 func (db *database) FlushMeta() (err error) {
+	if db.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
 	// another flush process is running
 	if !db.isFlushing.CAS(false, true) {
 		return nil
@@ -353,14 +567,17 @@ func (db *database) WaitFlushMetaCompleted() {
 
 // Flush flushes memory data of all families to disk.
 func (db *database) Flush() error {
+	if db.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
 	for _, shardEntry := range db.shardSet.Entries() {
 		shard := shardEntry.shard
-		db.flushChecker.requestFlushJob(&flushRequest{
+		db.engineContext().flushChecker.requestFlushJob(&flushRequest{
 			db: db,
 			shards: map[models.ShardID]*flushShard{
 				shard.ShardID(): {
 					shard:    shard,
-					families: GetFamilyManager().GetFamiliesByShard(shard),
+					families: db.engineContext().familyManager().GetFamiliesByShard(shard),
 				},
 			},
 			global: false,
@@ -369,8 +586,63 @@ func (db *database) Flush() error {
 	return nil
 }
 
+// ForceFlush flushes metadata, index and memory data of all families to disk,
+// blocks until flush jobs completed(include the jobs running in background).
+func (db *database) ForceFlush(ctx context.Context) error {
+	if db.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	// wait the running job which maybe doesn't include the latest metadata
+	db.WaitFlushMetaCompleted()
+	if err := db.FlushMeta(); err != nil {
+		return err
+	}
+	db.WaitFlushMetaCompleted()
+	for _, shardEntry := range db.shardSet.Entries() {
+		shard := shardEntry.shard
+		shard.WaitFlushIndexCompleted()
+		if err := shard.FlushIndex(); err != nil {
+			return err
+		}
+		shard.WaitFlushIndexCompleted()
+		for _, family := range db.engineContext().familyManager().GetFamiliesByShard(shard) {
+			if err := forceFlushFamily(ctx, family); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forceFlushFamily flushes memory database of family, waits the running flush job completed before/after flushing,
+// because flush is skipped if family is flushing.
+func forceFlushFamily(ctx context.Context, family DataFamily) error {
+	waitFlushing := func() error {
+		ticker := time.NewTicker(flushingCheckInterval)
+		defer ticker.Stop()
+		for family.IsFlushing() {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		}
+		return nil
+	}
+	if err := waitFlushing(); err != nil {
+		return err
+	}
+	if err := family.Flush(); err != nil {
+		return err
+	}
+	return waitFlushing()
+}
+
 // Drop drops current database include all data.
 func (db *database) Drop() error {
+	if db.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
 	if err := db.Close(); err != nil {
 		return err
 	}
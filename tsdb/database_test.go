@@ -35,6 +35,7 @@ import (
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/tsdb/metadb"
 )
 
@@ -155,6 +156,29 @@ func TestDatabase_New(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "close opened shards when create shard failure",
+			prepare: func() {
+				storeMgr.EXPECT().CreateStore(gomock.Any(), gomock.Any()).Return(store, nil)
+				store.EXPECT().CreateFamily(gomock.Any(), gomock.Any()).Return(nil, nil)
+				metadata := metadb.NewMockMetadata(ctrl)
+				newMetadataFunc = func(ctx context.Context, databaseName, parent string,
+					tagFamily kv.Family) (metadb.Metadata, error) {
+					return metadata, nil
+				}
+				shard := NewMockShard(ctrl)
+				shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+				shard.EXPECT().Close().Return(fmt.Errorf("err")).Times(2)
+				newShardFunc = func(db Database, shardID models.ShardID) (s Shard, err error) {
+					if shardID == 2 {
+						return nil, fmt.Errorf("err")
+					}
+					return shard, nil
+				}
+				metadata.EXPECT().Close().Return(nil)
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range cases {
@@ -184,7 +208,7 @@ func TestDatabase_New(t *testing.T) {
 			if tt.cfg != nil {
 				cfg = tt.cfg
 			}
-			db, err := newDatabase("db", cfg, nil)
+			db, err := newDatabase("db", cfg, &engineContext{})
 			if ((err != nil) != tt.wantErr && db == nil) || (!tt.wantErr && db == nil) {
 				t.Errorf("newDatabase() error = %v, wantErr %v", err, tt.wantErr)
 			}
@@ -428,9 +452,9 @@ func TestDatabase_Flush(t *testing.T) {
 	checker := NewMockDataFlushChecker(ctrl)
 
 	db := &database{
-		shardSet:     *newShardSet(),
-		isFlushing:   *atomic.NewBool(false),
-		flushChecker: checker,
+		shardSet:   *newShardSet(),
+		isFlushing: *atomic.NewBool(false),
+		engineCtx:  &engineContext{flushChecker: checker},
 	}
 	shard1 := NewMockShard(ctrl)
 	shard2 := NewMockShard(ctrl)
@@ -444,6 +468,59 @@ func TestDatabase_Flush(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDatabase_ForceFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	metadata := metadb.NewMockMetadata(ctrl)
+	familyMgr := newFamilyManager()
+	db := &database{
+		metadata:       metadata,
+		shardSet:       *newShardSet(),
+		flushCondition: sync.NewCond(&sync.Mutex{}),
+		isFlushing:     *atomic.NewBool(false),
+		statistics:     metrics.NewDatabaseStatistics("test"),
+		engineCtx:      &engineContext{familyMgr: familyMgr},
+	}
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Indicator().Return("db/1").AnyTimes()
+	shard.EXPECT().WaitFlushIndexCompleted().AnyTimes()
+	db.shardSet.InsertShard(1, shard)
+	family := NewMockDataFamily(ctrl)
+	family.EXPECT().Indicator().Return("db/1/1").AnyTimes()
+	family.EXPECT().Shard().Return(shard).AnyTimes()
+	familyMgr.AddFamily(family)
+
+	// flush meta failure
+	metadata.EXPECT().Flush().Return(fmt.Errorf("err"))
+	assert.Error(t, db.ForceFlush(context.TODO()))
+	// flush index failure
+	metadata.EXPECT().Flush().Return(nil).AnyTimes()
+	shard.EXPECT().FlushIndex().Return(fmt.Errorf("err"))
+	assert.Error(t, db.ForceFlush(context.TODO()))
+	// flush family failure
+	shard.EXPECT().FlushIndex().Return(nil).AnyTimes()
+	family.EXPECT().IsFlushing().Return(false)
+	family.EXPECT().Flush().Return(fmt.Errorf("err"))
+	assert.Error(t, db.ForceFlush(context.TODO()))
+	// wait background flush job completed
+	gomock.InOrder(
+		family.EXPECT().IsFlushing().Return(true),
+		family.EXPECT().IsFlushing().Return(false),
+		family.EXPECT().Flush().Return(nil),
+		family.EXPECT().IsFlushing().Return(false),
+	)
+	assert.NoError(t, db.ForceFlush(context.TODO()))
+	// wait timeout
+	family.EXPECT().IsFlushing().Return(true).AnyTimes()
+	ctx, cancel := context.WithTimeout(context.TODO(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, db.ForceFlush(ctx), context.DeadlineExceeded)
+	// read only
+	db.engineCtx = &engineContext{readOnly: true}
+	assert.Error(t, db.ForceFlush(context.TODO()))
+}
+
 func Test_ShardSet_multi(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -549,6 +626,20 @@ func TestDatabase_TTL(t *testing.T) {
 	db.TTL()
 }
 
+func TestDatabase_GCSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	set := newShardSet()
+	shard1 := NewMockShard(ctrl)
+	set.InsertShard(models.ShardID(0), shard1)
+	db := &database{
+		shardSet: *set,
+	}
+	shard1.EXPECT().GCSeries(time.Hour, 10)
+	db.GCSeries(time.Hour, 10)
+}
+
 func TestDatabase_EvictSegment(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -563,6 +654,155 @@ func TestDatabase_EvictSegment(t *testing.T) {
 	db.EvictSegment()
 }
 
+func TestDatabase_HibernateIdleShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	set := newShardSet()
+	shard1 := NewMockShard(ctrl)
+	set.InsertShard(models.ShardID(0), shard1)
+	db := &database{
+		shardSet: *set,
+	}
+	shard1.EXPECT().Hibernate(time.Hour).Return(true)
+	db.HibernateIdleShards(time.Hour)
+}
+
+func TestDatabase_WarmUpMetric(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		warmUpMetricFunc = warmUpMetric
+		ctrl.Finish()
+	}()
+
+	set := newShardSet()
+	shard1 := NewMockShard(ctrl)
+	shard2 := NewMockShard(ctrl)
+	set.InsertShard(models.ShardID(0), shard1)
+	set.InsertShard(models.ShardID(1), shard2)
+	db := &database{
+		shardSet: *set,
+	}
+	throttle := func(_ context.Context, _ int) error { return nil }
+	var warmed []Shard
+	warmUpMetricFunc = func(_ context.Context, shard Shard, metricID metric.ID,
+		_ func(ctx context.Context, bytes int) error) error {
+		assert.Equal(t, metric.ID(10), metricID)
+		warmed = append(warmed, shard)
+		return nil
+	}
+	assert.NoError(t, db.WarmUpMetric(context.TODO(), 10, throttle))
+	assert.Equal(t, []Shard{shard1, shard2}, warmed)
+
+	// stop warming up if failure
+	warmed = nil
+	warmUpMetricFunc = func(_ context.Context, shard Shard, _ metric.ID,
+		_ func(ctx context.Context, bytes int) error) error {
+		warmed = append(warmed, shard)
+		return fmt.Errorf("err")
+	}
+	assert.Error(t, db.WarmUpMetric(context.TODO(), 10, throttle))
+	assert.Len(t, warmed, 1)
+}
+
+func TestDatabase_SetOption(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		encodeToml = ltoml.EncodeToml
+		ctrl.Finish()
+	}()
+
+	set := newShardSet()
+	shard1 := NewMockShard(ctrl)
+	set.InsertShard(models.ShardID(0), shard1)
+	db := &database{
+		name:     "test",
+		shardSet: *set,
+		config:   &models.DatabaseConfig{Option: &option.DatabaseOption{}, ShardIDs: []models.ShardID{0}},
+	}
+	encodeToml = func(fileName string, v interface{}) error {
+		return nil
+	}
+	assert.NoError(t, db.SetOption(nil))
+	// compaction policy not changed
+	assert.NoError(t, db.SetOption(&option.DatabaseOption{CompactionPolicy: option.CompactionPolicyDefault}))
+	// compaction policy changed
+	shard1.EXPECT().SetCompactionPolicy(option.CompactionPolicyLeveled)
+	opt := &option.DatabaseOption{CompactionPolicy: option.CompactionPolicyLeveled}
+	assert.NoError(t, db.SetOption(opt))
+	assert.Equal(t, opt, db.GetOption())
+	assert.Equal(t, []models.ShardID{0}, db.GetConfig().ShardIDs)
+
+	encodeToml = func(fileName string, v interface{}) error {
+		return fmt.Errorf("err")
+	}
+	assert.Error(t, db.SetOption(&option.DatabaseOption{}))
+	assert.Equal(t, opt, db.GetOption())
+}
+
+func TestDatabase_SetOption_AllowFieldTypeChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		encodeToml = ltoml.EncodeToml
+		ctrl.Finish()
+	}()
+
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	db := &database{
+		name:     "test",
+		shardSet: *newShardSet(),
+		metadata: metadata,
+		config:   &models.DatabaseConfig{Option: &option.DatabaseOption{}},
+	}
+	encodeToml = func(fileName string, v interface{}) error {
+		return nil
+	}
+	metadataDB.EXPECT().SetAllowFieldTypeChange(true)
+	assert.NoError(t, db.SetOption(&option.DatabaseOption{AllowFieldTypeChange: true}))
+	// not changed
+	assert.NoError(t, db.SetOption(&option.DatabaseOption{AllowFieldTypeChange: true}))
+	metadataDB.EXPECT().SetAllowFieldTypeChange(false)
+	assert.NoError(t, db.SetOption(&option.DatabaseOption{}))
+}
+
+func TestDatabase_SetOption_AddRollupIntervals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		encodeToml = ltoml.EncodeToml
+		ctrl.Finish()
+	}()
+
+	set := newShardSet()
+	shard1 := NewMockShard(ctrl)
+	set.InsertShard(models.ShardID(0), shard1)
+	oldOpt := &option.DatabaseOption{Intervals: option.Intervals{{Interval: timeutil.Interval(10 * timeutil.OneSecond)}}}
+	db := &database{
+		name:     "test",
+		shardSet: *set,
+		config:   &models.DatabaseConfig{Option: oldOpt, ShardIDs: []models.ShardID{0}},
+	}
+	encodeToml = func(fileName string, v interface{}) error {
+		return nil
+	}
+	// writable interval changed
+	assert.Error(t, db.SetOption(&option.DatabaseOption{Intervals: option.Intervals{{Interval: timeutil.Interval(timeutil.OneMinute)}}}))
+	assert.Equal(t, oldOpt, db.GetOption())
+
+	opt := &option.DatabaseOption{Intervals: option.Intervals{
+		{Interval: timeutil.Interval(10 * timeutil.OneSecond)},
+		{Interval: timeutil.Interval(5 * timeutil.OneMinute)},
+	}}
+	// add rollup intervals failure
+	shard1.EXPECT().AddRollupIntervals(option.Intervals{{Interval: timeutil.Interval(5 * timeutil.OneMinute)}}).Return(fmt.Errorf("err"))
+	assert.Error(t, db.SetOption(opt))
+	// add rollup intervals successfully
+	shard1.EXPECT().AddRollupIntervals(option.Intervals{{Interval: timeutil.Interval(5 * timeutil.OneMinute)}}).Return(nil)
+	assert.NoError(t, db.SetOption(opt))
+	assert.Equal(t, opt, db.GetOption())
+}
+
 func Benchmark_LoadSyncMap(b *testing.B) {
 	var m sync.Map
 	for i := 0; i < boundaryShardSetLen; i++ {
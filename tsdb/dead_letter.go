@@ -0,0 +1,298 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	resty "github.com/go-resty/resty/v2"
+	"golang.org/x/time/rate"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/queue"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+)
+
+//go:generate mockgen -source=./dead_letter.go -destination=./dead_letter_mock.go -package=tsdb
+
+var (
+	dlSink      DeadLetterSink = &noopDeadLetterSink{}
+	dlSinkMutex sync.RWMutex
+
+	// for testing
+	newDeadLetterQueueFunc = queue.NewQueue
+
+	errDeadLetterQueueDisabled = errors.New("dead-letter queue is not enabled")
+)
+
+const (
+	// num. of recent dead-letters kept in memory for sampling.
+	recentDeadLetters = 100
+	// num. of dead-letters waiting for forwarding to http endpoint.
+	forwardDeadLetters = 1024
+	forwardTimeout     = 5 * time.Second
+)
+
+// Dead-letter rejection reasons.
+const (
+	DeadLetterUnwritable  = "unwritable"
+	DeadLetterWriteFailed = "write_failed"
+	DeadLetterNoMemDB     = "memdb_unavailable"
+	DeadLetterRejected    = "write_rejected"
+	DeadLetterTooLate     = "too_late"
+)
+
+// DeadLetterSink represents the sink which keeps the rows rejected by storage write path,
+// rejected rows are persisted into a bounded local queue or forwarded to http endpoint.
+type DeadLetterSink interface {
+	// Send sends the rejected row into sink, the row will be dropped when exceeding rate limit.
+	Send(database, family, reason string, row *metric.StorageRow)
+	// Recent returns the recent dead-letters in memory(newest first).
+	Recent(limit int) []*models.DeadLetter
+	// Read reads dead-letters from local queue starting with given sequence.
+	Read(fromSeq int64, limit int) ([]*models.DeadLetter, error)
+	// Ack acknowledges the dead-letters before sequence(include), which will be removed from local queue.
+	Ack(seq int64) error
+	// Close closes the sink.
+	Close()
+}
+
+// GetDeadLetterSink returns the dead-letter sink of storage.
+func GetDeadLetterSink() DeadLetterSink {
+	dlSinkMutex.RLock()
+	defer dlSinkMutex.RUnlock()
+	return dlSink
+}
+
+// initDeadLetterSink initializes the dead-letter sink based on config, closes the previous sink.
+func initDeadLetterSink(cfg *config.DeadLetter) error {
+	var sink DeadLetterSink = &noopDeadLetterSink{}
+	if cfg != nil && cfg.Enabled {
+		var err error
+		if sink, err = newDeadLetterSink(cfg); err != nil {
+			return err
+		}
+	}
+	dlSinkMutex.Lock()
+	prev := dlSink
+	dlSink = sink
+	dlSinkMutex.Unlock()
+	prev.Close()
+	return nil
+}
+
+// deadLetterSink implements DeadLetterSink interface.
+type deadLetterSink struct {
+	ctx       context.Context
+	cancel    context.CancelFunc
+	limiter   *rate.Limiter
+	q         queue.Queue // nil if forwarding to http endpoint
+	endpoint  string
+	forwardCh chan []byte
+
+	recent    []*models.DeadLetter // ring buffer of recent dead-letters
+	recentIdx int
+	mutex     sync.Mutex
+
+	statistics *metrics.DeadLetterStatistics
+	logger     *logger.Logger
+}
+
+// newDeadLetterSink creates the dead-letter sink.
+func newDeadLetterSink(cfg *config.DeadLetter) (DeadLetterSink, error) {
+	ctx, cancel := context.WithCancel(context.TODO())
+	s := &deadLetterSink{
+		ctx:        ctx,
+		cancel:     cancel,
+		limiter:    rate.NewLimiter(rate.Limit(cfg.RateLimit), cfg.RateLimit),
+		endpoint:   cfg.Endpoint,
+		statistics: metrics.NewDeadLetterStatistics(),
+		logger:     logger.GetLogger("TSDB", "DeadLetter"),
+	}
+	if s.endpoint != "" {
+		s.forwardCh = make(chan []byte, forwardDeadLetters)
+		go s.forward()
+		return s, nil
+	}
+	q, err := newDeadLetterQueueFunc(cfg.Dir, int64(cfg.MaxSize))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	s.q = q
+	return s, nil
+}
+
+// Send sends the rejected row into sink, the row will be dropped when exceeding rate limit.
+func (s *deadLetterSink) Send(database, family, reason string, row *metric.StorageRow) {
+	if !s.limiter.Allow() {
+		s.statistics.RateLimited.Incr()
+		return
+	}
+	letter := &models.DeadLetter{
+		Database:   database,
+		Family:     family,
+		Reason:     reason,
+		RejectedAt: timeutil.Now(),
+		Row:        append([]byte(nil), row.Bytes()...),
+	}
+	data := encoding.JSONMarshal(letter)
+
+	s.mutex.Lock()
+	if s.q != nil {
+		if err := s.q.Put(data); err != nil {
+			s.mutex.Unlock()
+			s.statistics.Dropped.Incr()
+			return
+		}
+		letter.Seq = s.q.AppendedSeq()
+	}
+	s.appendRecent(letter)
+	s.mutex.Unlock()
+
+	if s.forwardCh != nil {
+		select {
+		case s.forwardCh <- data:
+		default:
+			s.statistics.Dropped.Incr()
+			return
+		}
+	}
+	s.statistics.Sent.Incr()
+}
+
+// appendRecent appends dead-letter into recent ring buffer, overwrites the oldest one if full.
+func (s *deadLetterSink) appendRecent(letter *models.DeadLetter) {
+	if len(s.recent) < recentDeadLetters {
+		s.recent = append(s.recent, letter)
+	} else {
+		s.recent[s.recentIdx] = letter
+	}
+	s.recentIdx = (s.recentIdx + 1) % recentDeadLetters
+}
+
+// Recent returns the recent dead-letters in memory(newest first).
+func (s *deadLetterSink) Recent(limit int) []*models.DeadLetter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	size := len(s.recent)
+	if limit <= 0 || limit > size {
+		limit = size
+	}
+	rs := make([]*models.DeadLetter, 0, limit)
+	for i := 1; i <= limit; i++ {
+		rs = append(rs, s.recent[(s.recentIdx-i+size)%size])
+	}
+	return rs
+}
+
+// Read reads dead-letters from local queue starting with given sequence.
+func (s *deadLetterSink) Read(fromSeq int64, limit int) ([]*models.DeadLetter, error) {
+	if s.q == nil {
+		return nil, errDeadLetterQueueDisabled
+	}
+	if ackSeq := s.q.AcknowledgedSeq(); fromSeq <= ackSeq {
+		fromSeq = ackSeq + 1
+	}
+	var rs []*models.DeadLetter
+	for seq := fromSeq; seq <= s.q.AppendedSeq() && (limit <= 0 || len(rs) < limit); seq++ {
+		data, err := s.q.Get(seq)
+		if err != nil {
+			return nil, err
+		}
+		letter := &models.DeadLetter{}
+		if err := encoding.JSONUnmarshal(data, letter); err != nil {
+			return nil, err
+		}
+		letter.Seq = seq
+		rs = append(rs, letter)
+	}
+	return rs, nil
+}
+
+// Ack acknowledges the dead-letters before sequence(include), which will be removed from local queue.
+func (s *deadLetterSink) Ack(seq int64) error {
+	if s.q == nil {
+		return errDeadLetterQueueDisabled
+	}
+	if appendedSeq := s.q.AppendedSeq(); seq > appendedSeq {
+		seq = appendedSeq
+	}
+	if seq <= s.q.AcknowledgedSeq() {
+		return nil
+	}
+	s.q.SetAcknowledgedSeq(seq)
+	s.q.GC()
+	return nil
+}
+
+// Close closes the sink.
+func (s *deadLetterSink) Close() {
+	s.cancel()
+	if s.q != nil {
+		s.q.Close()
+	}
+}
+
+// forward forwards dead-letters to http endpoint.
+func (s *deadLetterSink) forward() {
+	cli := resty.New().SetTimeout(forwardTimeout)
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case data := <-s.forwardCh:
+			resp, err := cli.R().
+				SetHeader("Content-Type", "application/json").
+				SetBody(data).
+				Post(s.endpoint)
+			if err == nil && resp.IsError() {
+				err = errors.New(resp.Status())
+			}
+			if err != nil {
+				s.statistics.Dropped.Incr()
+				s.logger.Warn("forward dead-letter failure",
+					logger.String("endpoint", s.endpoint), logger.Error(err))
+			}
+		}
+	}
+}
+
+// noopDeadLetterSink represents the dead-letter sink which drops all rejected rows.
+type noopDeadLetterSink struct{}
+
+func (s *noopDeadLetterSink) Send(_, _, _ string, _ *metric.StorageRow) {}
+
+func (s *noopDeadLetterSink) Recent(_ int) []*models.DeadLetter { return nil }
+
+func (s *noopDeadLetterSink) Read(_ int64, _ int) ([]*models.DeadLetter, error) {
+	return nil, errDeadLetterQueueDisabled
+}
+
+func (s *noopDeadLetterSink) Ack(_ int64) error { return errDeadLetterQueueDisabled }
+
+func (s *noopDeadLetterSink) Close() {}
@@ -0,0 +1,222 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/queue"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+)
+
+func mockDeadLetterRows() []metric.StorageRow {
+	return mockBatchRows(&protoMetricsV1.Metric{
+		Name:      "test",
+		Timestamp: timeutil.Now(),
+		SimpleFields: []*protoMetricsV1.SimpleField{{
+			Name:  "f1",
+			Value: 1.0,
+			Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
+		}},
+	})
+}
+
+func TestDeadLetterSink_Queue(t *testing.T) {
+	defer func() {
+		_ = initDeadLetterSink(nil)
+	}()
+	assert.NoError(t, initDeadLetterSink(&config.DeadLetter{
+		Enabled:   true,
+		Dir:       t.TempDir(),
+		MaxSize:   ltoml.Size(1024 * 1024),
+		RateLimit: 10,
+	}))
+	sink := GetDeadLetterSink()
+	rows := mockDeadLetterRows()
+	for i := 0; i < 3; i++ {
+		sink.Send("db", "family", fmt.Sprintf("reason-%d", i), &rows[0])
+	}
+
+	recent := sink.Recent(2)
+	assert.Len(t, recent, 2)
+	assert.Equal(t, "reason-2", recent[0].Reason)
+	assert.Equal(t, "reason-1", recent[1].Reason)
+	assert.Len(t, sink.Recent(0), 3)
+
+	letters, err := sink.Read(-1, 10)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 3)
+	assert.Equal(t, "db", letters[0].Database)
+	assert.Equal(t, "family", letters[0].Family)
+	assert.Equal(t, rows[0].Bytes(), letters[0].Row)
+	letters, err = sink.Read(letters[0].Seq, 1)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "reason-0", letters[0].Reason)
+
+	// ack
+	assert.NoError(t, sink.Ack(letters[0].Seq+1))
+	letters, err = sink.Read(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "reason-2", letters[0].Reason)
+	assert.NoError(t, sink.Ack(0))
+	assert.NoError(t, sink.Ack(100))
+	letters, err = sink.Read(0, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, letters)
+}
+
+func TestDeadLetterSink_RecentRing(t *testing.T) {
+	sink := &deadLetterSink{}
+	for i := 0; i < recentDeadLetters+10; i++ {
+		sink.appendRecent(&models.DeadLetter{Seq: int64(i)})
+	}
+	recent := sink.Recent(0)
+	assert.Len(t, recent, recentDeadLetters)
+	assert.Equal(t, int64(recentDeadLetters+9), recent[0].Seq)
+	assert.Equal(t, int64(10), recent[recentDeadLetters-1].Seq)
+}
+
+func TestDeadLetterSink_RateLimit(t *testing.T) {
+	defer func() {
+		_ = initDeadLetterSink(nil)
+	}()
+	assert.NoError(t, initDeadLetterSink(&config.DeadLetter{
+		Enabled:   true,
+		Dir:       t.TempDir(),
+		MaxSize:   ltoml.Size(1024 * 1024),
+		RateLimit: 1,
+	}))
+	sink := GetDeadLetterSink()
+	rows := mockDeadLetterRows()
+	for i := 0; i < 3; i++ {
+		sink.Send("db", "family", "reason", &rows[0])
+	}
+	assert.Len(t, sink.Recent(0), 1)
+}
+
+func TestDeadLetterSink_QueueFailure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newDeadLetterQueueFunc = queue.NewQueue
+		_ = initDeadLetterSink(nil)
+		ctrl.Finish()
+	}()
+	cfg := &config.DeadLetter{Enabled: true, RateLimit: 10}
+	newDeadLetterQueueFunc = func(_ string, _ int64) (queue.Queue, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, initDeadLetterSink(cfg))
+
+	q := queue.NewMockQueue(ctrl)
+	q.EXPECT().Close().AnyTimes()
+	newDeadLetterQueueFunc = func(_ string, _ int64) (queue.Queue, error) {
+		return q, nil
+	}
+	assert.NoError(t, initDeadLetterSink(cfg))
+	sink := GetDeadLetterSink()
+	rows := mockDeadLetterRows()
+	// queue is full
+	q.EXPECT().Put(gomock.Any()).Return(queue.ErrExceedingTotalSizeLimit)
+	sink.Send("db", "family", "reason", &rows[0])
+	assert.Empty(t, sink.Recent(0))
+
+	// read failure
+	q.EXPECT().AcknowledgedSeq().Return(int64(-1)).AnyTimes()
+	q.EXPECT().AppendedSeq().Return(int64(0)).AnyTimes()
+	q.EXPECT().Get(int64(0)).Return(nil, fmt.Errorf("err"))
+	letters, err := sink.Read(0, 10)
+	assert.Error(t, err)
+	assert.Nil(t, letters)
+	q.EXPECT().Get(int64(0)).Return([]byte("abc"), nil)
+	letters, err = sink.Read(0, 10)
+	assert.Error(t, err)
+	assert.Nil(t, letters)
+}
+
+func TestDeadLetterSink_Forward(t *testing.T) {
+	received := make(chan *models.DeadLetter, 2)
+	fail := atomic.NewBool(false)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			received <- nil
+			return
+		}
+		letter := &models.DeadLetter{}
+		body, _ := io.ReadAll(r.Body)
+		_ = encoding.JSONUnmarshal(body, letter)
+		received <- letter
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	defer func() {
+		_ = initDeadLetterSink(nil)
+	}()
+
+	assert.NoError(t, initDeadLetterSink(&config.DeadLetter{
+		Enabled:   true,
+		RateLimit: 10,
+		Endpoint:  server.URL,
+	}))
+	sink := GetDeadLetterSink()
+	rows := mockDeadLetterRows()
+	sink.Send("db", "family", "reason", &rows[0])
+	select {
+	case letter := <-received:
+		assert.Equal(t, "reason", letter.Reason)
+		assert.Equal(t, rows[0].Bytes(), letter.Row)
+	case <-time.After(5 * time.Second):
+		t.Fatal("forward dead-letter timeout")
+	}
+	fail.Store(true)
+	sink.Send("db", "family", "reason", &rows[0])
+	<-received
+	assert.Len(t, sink.Recent(0), 2)
+
+	_, err := sink.Read(0, 10)
+	assert.Error(t, err)
+	assert.Error(t, sink.Ack(0))
+}
+
+func TestNoopDeadLetterSink(t *testing.T) {
+	sink := GetDeadLetterSink()
+	sink.Send("db", "family", "reason", nil)
+	assert.Empty(t, sink.Recent(10))
+	_, err := sink.Read(0, 10)
+	assert.Error(t, err)
+	assert.Error(t, sink.Ack(0))
+	sink.Close()
+}
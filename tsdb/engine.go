@@ -21,8 +21,11 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/logger"
@@ -55,16 +58,39 @@ type Engine interface {
 	GetAllDatabases() map[string]Database
 	// FlushDatabase produces a signal to workers for flushing memory database by name
 	FlushDatabase(ctx context.Context, databaseName string) bool
+	// ForceFlush flushes metadata, index and memory data of all databases to disk, blocks until flushed.
+	ForceFlush(ctx context.Context) error
 	// DropDatabases drops databases, keep active database.
 	DropDatabases(activeDatabases map[string]struct{})
 	// TTL expires the data of each database base on time to live.
 	TTL()
+	// GCSeries reclaims the series which are not written within horizon and not present in any retained family,
+	// does nothing if series gc is disabled.
+	GCSeries()
 	// EvictSegment evicts segment which long term no read operation.
 	EvictSegment()
+	// HibernateIdleShards hibernates the shards without writes/queries within idle period of storage config,
+	// does nothing if shard hibernation is disabled.
+	HibernateIdleShards()
+	// RecoverFamilies opens the segments and families of writable interval after startup(newest first),
+	// blocks until all families opened.
+	RecoverFamilies() error
+	// RecoveryProgress returns the progress of recovery after startup.
+	RecoveryProgress() *models.RecoveryProgress
 	// Close closes the cached time series databases
 	Close()
 }
 
+// EngineOption represents the options of creating time series engine.
+type EngineOption struct {
+	// Dir is the root path of time series data, uses the tsdb dir of storage config if empty,
+	// only supported by read-only engine.
+	Dir string
+	// ReadOnly opens the existed databases under root path for querying only(e.g. restore tooling),
+	// neither flush checker nor compaction is started, all writes are rejected with constants.ErrReadOnly.
+	ReadOnly bool
+}
+
 // engine implements Engine
 type engine struct {
 	mutex            sync.Mutex         // mutex for creating database
@@ -72,21 +98,54 @@ type engine struct {
 	ctx              context.Context    // context
 	cancel           context.CancelFunc // cancel function of flusher
 	dataFlushChecker DataFlushChecker
+	recovery         *recoveryTracker
+	engineCtx        *engineContext
 }
 
 // NewEngine creates an engine for manipulating the databases
 func NewEngine() (Engine, error) {
-	// create time series storage path
-	if err := mkDirIfNotExist(config.GlobalStorageConfig().TSDB.Dir); err != nil {
-		return nil, fmt.Errorf("create time sereis storage path[%s] erorr: %s",
-			config.GlobalStorageConfig().TSDB.Dir, err)
+	return NewEngineWithOption(EngineOption{})
+}
+
+// NewEngineWithOption creates an engine with options, a read-only engine is isolated from the writable engine
+// of process(own kv store manager and family manager), so that it can open a copied data directory side by side.
+func NewEngineWithOption(opt EngineOption) (Engine, error) {
+	engineCtx := &engineContext{readOnly: opt.ReadOnly}
+	if opt.ReadOnly {
+		engineCtx.dir = opt.Dir
+		if engineCtx.dir == "" {
+			engineCtx.dir = config.GlobalStorageConfig().TSDB.Dir
+		}
+		if !fileExist(engineCtx.dir) {
+			return nil, fmt.Errorf("time series storage path[%s] not exist", engineCtx.dir)
+		}
+		engineCtx.storeMgr = kv.NewStoreManager(kv.StoreOptions{Dir: engineCtx.dir})
+		engineCtx.familyMgr = newFamilyManager()
+	} else {
+		if opt.Dir != "" {
+			return nil, fmt.Errorf("custom time series storage path[%s] is only supported by read-only engine", opt.Dir)
+		}
+		// create time series storage path
+		if err := mkDirIfNotExist(config.GlobalStorageConfig().TSDB.Dir); err != nil {
+			return nil, fmt.Errorf("create time sereis storage path[%s] erorr: %s",
+				config.GlobalStorageConfig().TSDB.Dir, err)
+		}
+		if err := initDeadLetterSink(&config.GlobalStorageConfig().DeadLetter); err != nil {
+			return nil, fmt.Errorf("create dead-letter sink error: %s", err)
+		}
 	}
 	e := &engine{
-		dbSet: *newDatabaseSet(),
+		dbSet:     *newDatabaseSet(),
+		recovery:  newRecoveryTracker(),
+		engineCtx: engineCtx,
 	}
 	e.ctx, e.cancel = context.WithCancel(context.Background())
-	e.dataFlushChecker = newDataFlushChecker(e.ctx)
-	e.dataFlushChecker.Start()
+	engineCtx.recovery = e.recovery
+	if !opt.ReadOnly {
+		e.dataFlushChecker = newDataFlushChecker(e.ctx)
+		e.dataFlushChecker.Start()
+		engineCtx.flushChecker = e.dataFlushChecker
+	}
 
 	if err := e.load(); err != nil {
 		engineLogger.Error("load engine data error when create a new engine", logger.Error(err))
@@ -97,10 +156,18 @@ func NewEngine() (Engine, error) {
 	return e, nil
 }
 
+// engineContext returns the resources scoped to engine.
+func (e *engine) engineContext() *engineContext {
+	if e.engineCtx == nil {
+		return defaultEngineContext
+	}
+	return e.engineCtx
+}
+
 // createDatabase creates database instance by database's name
 // return success when creating database's path successfully
 func (e *engine) createDatabase(databaseName string, dbOption *option.DatabaseOption) (Database, error) {
-	cfgPath := optionsPath(databaseName)
+	cfgPath := optionsPath(e.engineContext().rootDir(), databaseName)
 	cfg := &models.DatabaseConfig{Option: dbOption}
 	engineLogger.Info("load database option from local storage", logger.String("path", cfgPath))
 	if fileExist(cfgPath) {
@@ -109,7 +176,7 @@ func (e *engine) createDatabase(databaseName string, dbOption *option.DatabaseOp
 				databaseName, cfgPath, err)
 		}
 	}
-	db, err := newDatabaseFunc(databaseName, cfg, e.dataFlushChecker)
+	db, err := newDatabaseFunc(databaseName, cfg, e.engineContext())
 	if err != nil {
 		return nil, err
 	}
@@ -122,11 +189,21 @@ func (e *engine) CreateShards(
 	databaseOption *option.DatabaseOption,
 	shardIDs ...models.ShardID,
 ) error {
+	if e.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
 	if len(shardIDs) == 0 {
 		return fmt.Errorf("cannot create empty shard for database[%s]", databaseName)
 	}
 	db, ok := e.GetDatabase(databaseName)
-	if !ok {
+	if ok {
+		// apply the modified option of database
+		if err := db.SetOption(databaseOption); err != nil {
+			engineLogger.Error("failed to set database option",
+				logger.String("database", databaseName), logger.Error(err))
+			return err
+		}
+	} else {
 		e.mutex.Lock()
 		defer e.mutex.Unlock()
 		if db, ok = e.GetDatabase(databaseName); !ok {
@@ -183,10 +260,17 @@ func (e *engine) Close() {
 				logger.Error(err))
 		}
 	}
+	if !e.engineContext().readOnly {
+		// reset dead-letter sink after all databases closed
+		_ = initDeadLetterSink(nil)
+	}
 }
 
 // FlushDatabase produces a signal to workers for flushing memory database by name
 func (e *engine) FlushDatabase(_ context.Context, name string) bool {
+	if e.engineContext().readOnly {
+		return false
+	}
 	if db, ok := e.dbSet.GetDatabase(name); ok {
 		if err := db.Flush(); err != nil {
 			return false
@@ -196,8 +280,24 @@ func (e *engine) FlushDatabase(_ context.Context, name string) bool {
 	return false
 }
 
+// ForceFlush flushes metadata, index and memory data of all databases to disk, blocks until flushed.
+func (e *engine) ForceFlush(ctx context.Context) error {
+	if e.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	for name, db := range e.dbSet.Entries() {
+		if err := db.ForceFlush(ctx); err != nil {
+			return fmt.Errorf("force flush database [%s] failure: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // DropDatabases drops databases, keep active database.
 func (e *engine) DropDatabases(activeDatabases map[string]struct{}) {
+	if e.engineContext().readOnly {
+		return
+	}
 	for dbName, db := range e.dbSet.Entries() {
 		_, ok := activeDatabases[dbName]
 		if ok {
@@ -214,11 +314,26 @@ func (e *engine) DropDatabases(activeDatabases map[string]struct{}) {
 
 // TTL expires the data of each database base on time to live.
 func (e *engine) TTL() {
+	if e.engineContext().readOnly {
+		return
+	}
 	for _, db := range e.dbSet.Entries() {
 		db.TTL()
 	}
 }
 
+// GCSeries reclaims the series which are not written within horizon and not present in any retained family,
+// does nothing if series gc is disabled.
+func (e *engine) GCSeries() {
+	cfg := config.GlobalStorageConfig().TSDB
+	if e.engineContext().readOnly || cfg.SeriesGCHorizon <= 0 {
+		return
+	}
+	for _, db := range e.dbSet.Entries() {
+		db.GCSeries(cfg.SeriesGCHorizon.Duration(), cfg.SeriesGCBatchSize)
+	}
+}
+
 // EvictSegment evicts segment which long term no read operation.
 func (e *engine) EvictSegment() {
 	for _, db := range e.dbSet.Entries() {
@@ -226,14 +341,66 @@ func (e *engine) EvictSegment() {
 	}
 }
 
+// HibernateIdleShards hibernates the shards without writes/queries within idle period of storage config,
+// does nothing if shard hibernation is disabled.
+func (e *engine) HibernateIdleShards() {
+	idle := config.GlobalStorageConfig().TSDB.ShardHibernateAfter
+	if e.engineContext().readOnly || idle <= 0 {
+		return
+	}
+	for _, db := range e.dbSet.Entries() {
+		db.HibernateIdleShards(idle.Duration())
+	}
+}
+
+// RecoverFamilies opens the segments and families of writable interval after startup(newest first),
+// blocks until all families opened.
+func (e *engine) RecoverFamilies() error {
+	var tasks []*recoveryTask
+	for _, db := range e.dbSet.Entries() {
+		tasks = append(tasks, db.recoveryTasks()...)
+	}
+	e.recovery.startPhase(models.RecoveryPhaseFamily)
+	e.recovery.addTotal(len(tasks))
+	ctx, cancel := context.WithCancel(e.ctx)
+	defer cancel()
+	go e.recovery.logPeriodically(ctx)
+
+	startTime := time.Now()
+	tolerateCorrupt := config.GlobalStorageConfig().TSDB.TolerateCorruptFamily
+	if err := runRecoveryTasks(ctx, tasks, tolerateCorrupt, e.recovery); err != nil {
+		e.recovery.fail(err)
+		return err
+	}
+	e.recovery.complete()
+	progress := e.recovery.progress()
+	engineLogger.Info("recover families completed",
+		logger.Int("segments", len(tasks)),
+		logger.Int("families", progress.Families),
+		logger.Any("quarantined", progress.Quarantined),
+		logger.String("cost", time.Since(startTime).String()))
+	return nil
+}
+
+// RecoveryProgress returns the progress of recovery after startup.
+func (e *engine) RecoveryProgress() *models.RecoveryProgress {
+	return e.recovery.progress()
+}
+
 // load the time series engines if exist
 func (e *engine) load() error {
-	databaseNames, err := listDir(config.GlobalStorageConfig().TSDB.Dir)
+	databaseNames, err := listDir(e.engineContext().rootDir())
 	if err != nil {
 		return err
 	}
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
+
+	e.recovery.startPhase(models.RecoveryPhaseShard)
+	ctx, cancel := context.WithCancel(e.ctx)
+	defer cancel()
+	go e.recovery.logPeriodically(ctx)
+
 	for _, databaseName := range databaseNames {
 		_, err := e.createDatabase(databaseName, &option.DatabaseOption{}) // need load config from local file
 		if err != nil {
@@ -0,0 +1,88 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/models"
+)
+
+// engineContext represents the resources scoped to an engine, shared by its databases/shards/families,
+// the zero value uses the global resources(tsdb dir of storage config, kv store manager and family manager).
+type engineContext struct {
+	dir          string          // root path of time series data
+	readOnly     bool            // rejects all writes if set
+	storeMgr     kv.StoreManager // nil means the global kv store manager
+	familyMgr    FamilyManager   // nil means the global family manager
+	flushChecker DataFlushChecker
+	recovery     *recoveryTracker
+}
+
+// defaultEngineContext is used by the database not created by engine(e.g. mock database).
+var defaultEngineContext = &engineContext{}
+
+// rootDir returns the root path of time series data.
+func (c *engineContext) rootDir() string {
+	if c.dir != "" {
+		return c.dir
+	}
+	return config.GlobalStorageConfig().TSDB.Dir
+}
+
+// bufferPath returns the memdb write buffer path of shard, uses the buffer dir template of storage config
+// if set(only for the writable engine), else the buffer dir under shard path.
+func (c *engineContext) bufferPath(database string, shardID models.ShardID) string {
+	if c.dir == "" {
+		if path := config.GlobalStorageConfig().TSDB.BufferPath(database, int(shardID)); path != "" {
+			return path
+		}
+	}
+	return shardTempBufferPath(c.rootDir(), database, shardID)
+}
+
+// storeManager returns the kv store manager which creates the stores of engine.
+func (c *engineContext) storeManager() kv.StoreManager {
+	if c.storeMgr != nil {
+		return c.storeMgr
+	}
+	return kv.GetStoreManager()
+}
+
+// familyManager returns the family manager which the families of engine are registered in.
+func (c *engineContext) familyManager() FamilyManager {
+	if c.familyMgr != nil {
+		return c.familyMgr
+	}
+	return GetFamilyManager()
+}
+
+// engineScoped represents the database holding the resources of engine which it belongs to.
+type engineScoped interface {
+	engineContext() *engineContext
+}
+
+// engineContextOf returns the resources of engine which database belongs to.
+func engineContextOf(db Database) *engineContext {
+	if scoped, ok := db.(engineScoped); ok {
+		if ctx := scoped.engineContext(); ctx != nil {
+			return ctx
+		}
+	}
+	return defaultEngineContext
+}
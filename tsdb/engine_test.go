@@ -20,19 +20,27 @@ package tsdb
 import (
 	"context"
 	"fmt"
+	"os"
 	"path"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
+	commonconstants "github.com/lindb/common/constants"
+	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
 )
 
 var writeConfigTestLock sync.Mutex
@@ -84,7 +92,7 @@ func TestEngine_New(t *testing.T) {
 					return []string{"db"}, nil
 				}
 				newDatabaseFunc = func(databaseName string, cfg *models.DatabaseConfig,
-					flushChecker DataFlushChecker) (Database, error) {
+					_ *engineContext) (Database, error) {
 					return nil, fmt.Errorf("err")
 				}
 			},
@@ -128,7 +136,7 @@ func TestEngine_createDatabase(t *testing.T) {
 			newDatabaseFunc = newDatabase
 		}()
 		mockDB := NewMockDatabase(ctrl)
-		newDatabaseFunc = func(databaseName string, cfg *models.DatabaseConfig, flushChecker DataFlushChecker) (Database, error) {
+		newDatabaseFunc = func(databaseName string, cfg *models.DatabaseConfig, _ *engineContext) (Database, error) {
 			return mockDB, nil
 		}
 		withTestPath(path.Join(tmpDir, "new"))
@@ -168,7 +176,7 @@ func TestEngine_createDatabase(t *testing.T) {
 			listDir = fileutil.ListDir
 		}()
 		mockDB := NewMockDatabase(ctrl)
-		newDatabaseFunc = func(databaseName string, cfg *models.DatabaseConfig, flushChecker DataFlushChecker) (Database, error) {
+		newDatabaseFunc = func(databaseName string, cfg *models.DatabaseConfig, _ *engineContext) (Database, error) {
 			return mockDB, nil
 		}
 		withTestPath(path.Join(tmpDir, "re-open"))
@@ -263,6 +271,28 @@ func Test_Engine_Flush_Database(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestEngine_ForceFlush(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	withTestPath(t.TempDir())
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	defer engineImpl.cancel()
+	assert.NoError(t, e.ForceFlush(context.TODO()))
+
+	mockDatabase := NewMockDatabase(ctrl)
+	engineImpl.dbSet.PutDatabase("test_db_1", mockDatabase)
+	mockDatabase.EXPECT().ForceFlush(gomock.Any()).Return(nil)
+	assert.NoError(t, e.ForceFlush(context.TODO()))
+	mockDatabase.EXPECT().ForceFlush(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, e.ForceFlush(context.TODO()))
+}
+
 func TestEngine_DropDatabases(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -296,6 +326,27 @@ func TestEngine_TTL(t *testing.T) {
 	e.TTL()
 }
 
+func TestEngine_GCSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+		ctrl.Finish()
+	}()
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	mockDatabase1 := NewMockDatabase(ctrl)
+	engineImpl.dbSet.PutDatabase("test_db_1", mockDatabase1)
+	// series gc disabled
+	e.GCSeries()
+
+	cfg := config.NewDefaultStorageBase()
+	cfg.TSDB.SeriesGCHorizon = ltoml.Duration(24 * time.Hour)
+	config.SetGlobalStorageConfig(cfg)
+	mockDatabase1.EXPECT().GCSeries(24*time.Hour, cfg.TSDB.SeriesGCBatchSize)
+	e.GCSeries()
+}
+
 func TestEngine_EvictSegment(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -308,6 +359,56 @@ func TestEngine_EvictSegment(t *testing.T) {
 	e.EvictSegment()
 }
 
+func TestEngine_HibernateIdleShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+		ctrl.Finish()
+	}()
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	mockDatabase1 := NewMockDatabase(ctrl)
+	engineImpl.dbSet.PutDatabase("test_db_1", mockDatabase1)
+	// shard hibernation disabled
+	e.HibernateIdleShards()
+
+	cfg := config.NewDefaultStorageBase()
+	cfg.TSDB.ShardHibernateAfter = ltoml.Duration(30 * time.Minute)
+	config.SetGlobalStorageConfig(cfg)
+	mockDatabase1.EXPECT().HibernateIdleShards(30 * time.Minute)
+	e.HibernateIdleShards()
+}
+
+func TestEngine_RecoverFamilies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	e, _ := NewEngine()
+	engineImpl := e.(*engine)
+	mockDatabase1 := NewMockDatabase(ctrl)
+	engineImpl.dbSet.PutDatabase("test_db_1", mockDatabase1)
+
+	// recover failure
+	mockDatabase1.EXPECT().recoveryTasks().Return([]*recoveryTask{{
+		run: func() (int, error) { return 0, fmt.Errorf("err") },
+	}})
+	assert.Error(t, e.RecoverFamilies())
+	progress := e.RecoveryProgress()
+	assert.Equal(t, models.RecoveryPhaseFamily, progress.Phase)
+	assert.Equal(t, "err", progress.Err)
+
+	// recover successfully
+	engineImpl.recovery = newRecoveryTracker()
+	mockDatabase1.EXPECT().recoveryTasks().Return([]*recoveryTask{{
+		run: func() (int, error) { return 2, nil },
+	}})
+	assert.NoError(t, e.RecoverFamilies())
+	assert.Equal(t, &models.RecoveryProgress{
+		Phase: models.RecoveryPhaseDone, Opened: 1, Total: 1, Families: 2,
+	}, e.RecoveryProgress())
+}
+
 func TestEngine_CreateShards(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -335,6 +436,7 @@ func TestEngine_CreateShards(t *testing.T) {
 			db:       "test",
 			shardIDs: []models.ShardID{1},
 			prepare: func(e *engine) {
+				mockDatabase.EXPECT().SetOption(gomock.Any()).Return(nil)
 				mockDatabase.EXPECT().CreateShards(gomock.Any()).Return(fmt.Errorf("err"))
 			},
 			wantErr: true,
@@ -344,17 +446,27 @@ func TestEngine_CreateShards(t *testing.T) {
 			db:       "test",
 			shardIDs: []models.ShardID{1},
 			prepare: func(e *engine) {
+				mockDatabase.EXPECT().SetOption(gomock.Any()).Return(nil)
 				mockDatabase.EXPECT().CreateShards(gomock.Any()).Return(nil)
 			},
 			wantErr: false,
 		},
+		{
+			name:     "set database option failure",
+			db:       "test",
+			shardIDs: []models.ShardID{1},
+			prepare: func(e *engine) {
+				mockDatabase.EXPECT().SetOption(gomock.Any()).Return(fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
 		{
 			name:     "create db failure",
 			db:       "test-2",
 			shardIDs: []models.ShardID{1},
 			prepare: func(e *engine) {
 				newDatabaseFunc = func(databaseName string, cfg *models.DatabaseConfig,
-					flushChecker DataFlushChecker) (Database, error) {
+					_ *engineContext) (Database, error) {
 					return nil, fmt.Errorf("err")
 				}
 			},
@@ -366,7 +478,7 @@ func TestEngine_CreateShards(t *testing.T) {
 			shardIDs: []models.ShardID{1},
 			prepare: func(e *engine) {
 				newDatabaseFunc = func(databaseName string, cfg *models.DatabaseConfig,
-					flushChecker DataFlushChecker) (Database, error) {
+					_ *engineContext) (Database, error) {
 					return mockDatabase, nil
 				}
 				mockDatabase.EXPECT().CreateShards(gomock.Any()).Return(nil)
@@ -382,6 +494,9 @@ func TestEngine_CreateShards(t *testing.T) {
 				dbSet: *newDatabaseSet(),
 			}
 			e.dbSet.PutDatabase("test", mockDatabase)
+			defer func() {
+				newDatabaseFunc = newDatabase
+			}()
 			if tt.prepare != nil {
 				tt.prepare(e)
 			}
@@ -393,6 +508,142 @@ func TestEngine_CreateShards(t *testing.T) {
 	}
 }
 
+func TestEngine_NewWithOption(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	dir := t.TempDir()
+	// custom path only supported by read-only engine
+	e, err := NewEngineWithOption(EngineOption{Dir: dir})
+	assert.Error(t, err)
+	assert.Nil(t, e)
+	// path not exist
+	e, err = NewEngineWithOption(EngineOption{Dir: path.Join(dir, "not_exist"), ReadOnly: true})
+	assert.Error(t, err)
+	assert.Nil(t, e)
+	// load engine failure
+	listDir = func(path string) ([]string, error) {
+		return nil, fmt.Errorf("err")
+	}
+	e, err = NewEngineWithOption(EngineOption{Dir: dir, ReadOnly: true})
+	assert.Error(t, err)
+	assert.Nil(t, e)
+	listDir = fileutil.GetDirectoryList
+	// empty engine
+	e, err = NewEngineWithOption(EngineOption{Dir: dir, ReadOnly: true})
+	assert.NoError(t, err)
+	assert.Empty(t, e.GetAllDatabases())
+	e.Close()
+}
+
+func TestEngine_ReadOnly(t *testing.T) {
+	writeConfigTestLock.Lock()
+	defer writeConfigTestLock.Unlock()
+
+	dir := t.TempDir()
+	withTestPath(dir)
+	kv.InitStoreManager(kv.NewStoreManager(kv.StoreOptions{Dir: dir}))
+	defer kv.InitStoreManager(nil)
+
+	// writes data by writable engine
+	e, err := NewEngine()
+	assert.NoError(t, err)
+	defer e.Close()
+	opt := &option.DatabaseOption{Intervals: option.Intervals{
+		{Interval: timeutil.Interval(10 * timeutil.OneSecond), Retention: timeutil.Interval(timeutil.OneMonth)},
+	}}
+	assert.NoError(t, e.CreateShards("db", opt, 1))
+	db, _ := e.GetDatabase("db")
+	shard, _ := e.GetShard("db", 1)
+	now := timeutil.Now()
+	family, err := shard.GetOrCrateDataFamily(now)
+	assert.NoError(t, err)
+	rows := mockBatchRows(&protoMetricsV1.Metric{
+		Name:         "cpu",
+		Timestamp:    now,
+		Tags:         []*protoMetricsV1.KeyValue{{Key: "host", Value: "1.1.1.1"}},
+		SimpleFields: []*protoMetricsV1.SimpleField{{Name: "f1", Value: 1, Type: protoMetricsV1.SimpleFieldType_DELTA_SUM}},
+	})
+	assert.NoError(t, shard.LookupRowMetricMeta(rows))
+	_, err = family.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.NoError(t, family.Flush())
+	assert.NoError(t, db.FlushMeta())
+	assert.NoError(t, shard.FlushIndex())
+
+	// opens the copied data directory by read-only engine side by side
+	copyDir := t.TempDir()
+	assert.NoError(t, copyTestDir(dir, copyDir))
+	roEngine, err := NewEngineWithOption(EngineOption{Dir: copyDir, ReadOnly: true})
+	assert.NoError(t, err)
+	roDB, ok := roEngine.GetDatabase("db")
+	assert.True(t, ok)
+	assert.Equal(t, []models.ShardID{1}, roDB.GetConfig().ShardIDs)
+	roShard, ok := roEngine.GetShard("db", 1)
+	assert.True(t, ok)
+	metricID, err := roDB.Metadata().MetadataDatabase().GetMetricID(commonconstants.DefaultNamespace, "cpu")
+	assert.NoError(t, err)
+	families := roShard.GetDataFamilies(timeutil.Day, timeutil.TimeRange{Start: now - timeutil.OneHour, End: now})
+	assert.Len(t, families, 1)
+	seriesIDs, err := families[0].GetSeriesIDs(metricID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), seriesIDs.GetCardinality())
+	// families of read-only engine are managed by its own family manager, not mixed with writable engine
+	assert.Equal(t, []DataFamily{family}, GetFamilyManager().GetFamiliesByShard(shard))
+	assert.Equal(t, families, engineContextOf(roDB).familyManager().GetFamiliesByShard(roShard))
+
+	// all writes are rejected
+	assert.ErrorIs(t, roEngine.CreateShards("db", opt, 2), constants.ErrReadOnly)
+	assert.ErrorIs(t, roShard.LookupRowMetricMeta(rows), constants.ErrReadOnly)
+	_, err = roShard.GetOrCrateDataFamily(now)
+	assert.ErrorIs(t, err, constants.ErrReadOnly)
+	_, err = families[0].WriteRows(1, rows)
+	assert.ErrorIs(t, err, constants.ErrReadOnly)
+	assert.ErrorIs(t, families[0].InstallSnapshot(nil, nil), constants.ErrReadOnly)
+	assert.ErrorIs(t, families[0].Flush(), constants.ErrReadOnly)
+	assert.ErrorIs(t, roShard.FlushIndex(), constants.ErrReadOnly)
+	assert.ErrorIs(t, roDB.FlushMeta(), constants.ErrReadOnly)
+	assert.ErrorIs(t, roDB.Flush(), constants.ErrReadOnly)
+	assert.ErrorIs(t, roDB.ForceFlush(context.TODO()), constants.ErrReadOnly)
+	assert.ErrorIs(t, roEngine.ForceFlush(context.TODO()), constants.ErrReadOnly)
+	assert.ErrorIs(t, roDB.SetOption(opt), constants.ErrReadOnly)
+	assert.ErrorIs(t, roDB.Drop(), constants.ErrReadOnly)
+	assert.False(t, roEngine.FlushDatabase(context.TODO(), "db"))
+	roEngine.TTL()
+	roEngine.GCSeries()
+	roEngine.DropDatabases(nil)
+	_, ok = roEngine.GetDatabase("db")
+	assert.True(t, ok)
+	roEngine.Close()
+
+	// writable engine still works after read-only engine closed
+	_, err = family.WriteRows(1, rows)
+	assert.NoError(t, err)
+	assert.Equal(t, []DataFamily{family}, GetFamilyManager().GetFamiliesByShard(shard))
+	// memory data is flushed synchronously
+	assert.NotZero(t, family.MemDBSize())
+	assert.NoError(t, e.ForceFlush(context.TODO()))
+	assert.Zero(t, family.MemDBSize())
+}
+
+func copyTestDir(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, info.Mode())
+	})
+}
+
 var testDatabaseNames = []string{
 	"_internal", "system", "docker", "network", "java",
 	"runtime", "go", "php", "k8s", "infra", "prometheus",
@@ -0,0 +1,69 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"sync"
+
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// familyCreationKey represents the unique key of family creation in shard.
+type familyCreationKey struct {
+	interval   timeutil.Interval
+	familyTime int64
+}
+
+// familyCreation represents an in-flight family creation.
+type familyCreation struct {
+	done   chan struct{}
+	family DataFamily
+	err    error
+}
+
+// familyCreationGroup deduplicates the concurrent creations of same family(single flight),
+// so that concurrent creators share one creation, the zero value is ready to use.
+type familyCreationGroup struct {
+	mutex     sync.Mutex
+	creations map[familyCreationKey]*familyCreation
+}
+
+// do executes the creation of family for key, waits and returns the result of the in-flight
+// creation if there is one for same key.
+func (g *familyCreationGroup) do(key familyCreationKey, create func() (DataFamily, error)) (DataFamily, error) {
+	g.mutex.Lock()
+	if g.creations == nil {
+		g.creations = make(map[familyCreationKey]*familyCreation)
+	}
+	if c, ok := g.creations[key]; ok {
+		g.mutex.Unlock()
+		<-c.done
+		return c.family, c.err
+	}
+	c := &familyCreation{done: make(chan struct{})}
+	g.creations[key] = c
+	g.mutex.Unlock()
+
+	c.family, c.err = create()
+	close(c.done)
+
+	g.mutex.Lock()
+	delete(g.creations, key)
+	g.mutex.Unlock()
+	return c.family, c.err
+}
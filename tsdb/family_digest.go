@@ -0,0 +1,148 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/metadb"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+// metricName represents the name of metric which metric id mapping to.
+type metricName struct {
+	namespace, name string
+}
+
+// DigestFamily computes the metric digests of closed family for consistency check between replicas.
+// Metric blocks of family files are scanned one by one(without loading whole family),
+// throttle is invoked with the bytes scanned after each metric block, digest computing stops if it returns err.
+func DigestFamily(
+	ctx context.Context,
+	family DataFamily,
+	throttle func(ctx context.Context, bytes int) error,
+) ([]models.MetricDigest, error) {
+	if family.TimeRange().End > timeutil.Now() || len(family.GetState().MemoryDatabases) > 0 {
+		return nil, fmt.Errorf("family[%s] is not closed", family.Indicator())
+	}
+	metadata := family.Shard().Database().Metadata().MetadataDatabase()
+	metricNames, err := getMetricNames(metadata)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := family.Family().GetSnapshot()
+	defer snapshot.Close()
+
+	digests := make(map[metric.ID]*metricsdata.Digest)
+	fieldNames := make(map[metric.ID]map[field.ID]field.Name)
+	for _, file := range snapshot.GetCurrent().GetAllFiles() {
+		reader, err := snapshot.GetReader(file.GetFileNumber())
+		if err != nil {
+			return nil, err
+		}
+		it := reader.Iterator()
+		for it.HasNext() {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			metricID := metric.ID(it.Key())
+			name, ok := metricNames[metricID]
+			if !ok {
+				// metric metadata not found, cannot be compared with other replicas
+				continue
+			}
+			names, ok := fieldNames[metricID]
+			if !ok {
+				fields, err := metadata.GetAllFields(name.namespace, name.name)
+				if err != nil {
+					return nil, err
+				}
+				names = make(map[field.ID]field.Name, len(fields))
+				for _, f := range fields {
+					names[f.ID] = f.Name
+				}
+				fieldNames[metricID] = names
+			}
+			r, err := newReaderFunc(reader.Path(), it.Value())
+			if err != nil {
+				return nil, err
+			}
+			digest, ok := digests[metricID]
+			if !ok {
+				digest = metricsdata.NewDigest()
+				digests[metricID] = digest
+			}
+			scanned, err := digest.Add(r, names)
+			if err != nil {
+				return nil, err
+			}
+			if err := throttle(ctx, scanned); err != nil {
+				return nil, err
+			}
+		}
+	}
+	rs := make([]models.MetricDigest, 0, len(digests))
+	for metricID, digest := range digests {
+		name := metricNames[metricID]
+		rs = append(rs, models.MetricDigest{
+			Namespace: name.namespace,
+			Metric:    name.name,
+			Series:    digest.SeriesIDs.GetCardinality(),
+			Points:    digest.Points,
+			Sum:       digest.Sum,
+			Hash:      digest.Hash,
+		})
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Namespace == rs[j].Namespace {
+			return rs[i].Metric < rs[j].Metric
+		}
+		return rs[i].Namespace < rs[j].Namespace
+	})
+	return rs, nil
+}
+
+// getMetricNames returns the metric names of database, metric id => metric name.
+func getMetricNames(metadata metadb.MetadataDatabase) (map[metric.ID]metricName, error) {
+	namespaces, err := metadata.SuggestNamespace("", math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	rs := make(map[metric.ID]metricName)
+	for _, namespace := range namespaces {
+		names, err := metadata.SuggestMetrics(namespace, "", "", math.MaxInt32)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			metricID, err := metadata.GetMetricID(namespace, name)
+			if err != nil {
+				return nil, err
+			}
+			rs[metricID] = metricName{namespace: namespace, name: name}
+		}
+	}
+	return rs, nil
+}
@@ -0,0 +1,270 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/bit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/metadb"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+func mockDigestMetricBlock(t *testing.T) []byte {
+	nopKVFlusher := kv.NewNopFlusher()
+	flusher, err := metricsdata.NewFlusher(nopKVFlusher)
+	assert.NoError(t, err)
+	flusher.PrepareMetric(10, field.Metas{{ID: 1, Type: field.SumField}})
+	for i := 0; i < 3; i++ {
+		encoder := encoding.NewTSDEncoder(5)
+		encoder.AppendTime(bit.One)
+		encoder.AppendValue(math.Float64bits(float64(i)))
+		data, _ := encoder.BytesWithoutTime()
+		assert.NoError(t, flusher.FlushField(data))
+		assert.NoError(t, flusher.FlushSeries(uint32(i)))
+	}
+	assert.NoError(t, flusher.CommitMetric(timeutil.SlotRange{Start: 5, End: 5}))
+	return nopKVFlusher.Bytes()
+}
+
+func TestDigestFamily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newReaderFunc = metricsdata.NewReader
+		ctrl.Finish()
+	}()
+	family := NewMockDataFamily(ctrl)
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	kvFamily := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	reader := table.NewMockReader(ctrl)
+	it := table.NewMockIterator(ctrl)
+	family.EXPECT().Indicator().Return("db/1/20221017").AnyTimes()
+	family.EXPECT().Shard().Return(shard).AnyTimes()
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	db.EXPECT().Metadata().Return(metadata).AnyTimes()
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	family.EXPECT().Family().Return(kvFamily).AnyTimes()
+	kvFamily.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	snapshot.EXPECT().Close().AnyTimes()
+	snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+	reader.EXPECT().Path().Return("1.sst").AnyTimes()
+	reader.EXPECT().Iterator().Return(it).AnyTimes()
+	closed := func() {
+		family.EXPECT().TimeRange().Return(timeutil.TimeRange{End: timeutil.Now() - 1000})
+		family.EXPECT().GetState().Return(models.DataFamilyState{})
+	}
+	metricNames := func() {
+		metadataDB.EXPECT().SuggestNamespace("", math.MaxInt32).Return([]string{"ns"}, nil)
+		metadataDB.EXPECT().SuggestMetrics("ns", "", "", math.MaxInt32).Return([]string{"cpu"}, nil)
+		metadataDB.EXPECT().GetMetricID("ns", "cpu").Return(metric.ID(10), nil)
+	}
+	block := mockDigestMetricBlock(t)
+	throttle := func(_ context.Context, _ int) error { return nil }
+
+	cases := []struct {
+		name    string
+		prepare func()
+		ctx     context.Context
+		wantErr bool
+		digests []models.MetricDigest
+	}{
+		{
+			name: "family not closed",
+			prepare: func() {
+				family.EXPECT().TimeRange().Return(timeutil.TimeRange{End: timeutil.Now() + 1000})
+			},
+			wantErr: true,
+		},
+		{
+			name: "memory database not flushed",
+			prepare: func() {
+				family.EXPECT().TimeRange().Return(timeutil.TimeRange{End: timeutil.Now() - 1000})
+				family.EXPECT().GetState().Return(models.DataFamilyState{MemoryDatabases: []models.MemoryDatabaseState{{}}})
+			},
+			wantErr: true,
+		},
+		{
+			name: "suggest namespace failure",
+			prepare: func() {
+				closed()
+				metadataDB.EXPECT().SuggestNamespace(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "suggest metric failure",
+			prepare: func() {
+				closed()
+				metadataDB.EXPECT().SuggestNamespace(gomock.Any(), gomock.Any()).Return([]string{"ns"}, nil)
+				metadataDB.EXPECT().SuggestMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "get metric id failure",
+			prepare: func() {
+				closed()
+				metadataDB.EXPECT().SuggestNamespace(gomock.Any(), gomock.Any()).Return([]string{"ns"}, nil)
+				metadataDB.EXPECT().SuggestMetrics(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]string{"cpu"}, nil)
+				metadataDB.EXPECT().GetMetricID(gomock.Any(), gomock.Any()).Return(metric.ID(0), fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "get reader failure",
+			prepare: func() {
+				closed()
+				metricNames()
+				v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 1, 10, 100)})
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "ctx canceled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.TODO())
+				cancel()
+				return ctx
+			}(),
+			prepare: func() {
+				closed()
+				metricNames()
+				v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 1, 10, 100)})
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil)
+				it.EXPECT().HasNext().Return(true)
+			},
+			wantErr: true,
+		},
+		{
+			name: "get fields failure",
+			prepare: func() {
+				closed()
+				metricNames()
+				v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 1, 10, 100)})
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil)
+				it.EXPECT().HasNext().Return(true)
+				it.EXPECT().Key().Return(uint32(10))
+				metadataDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "new reader failure",
+			prepare: func() {
+				closed()
+				metricNames()
+				v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 1, 10, 100)})
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil)
+				it.EXPECT().HasNext().Return(true)
+				it.EXPECT().Key().Return(uint32(10))
+				it.EXPECT().Value().Return([]byte{1, 2, 3})
+				metadataDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).
+					Return(field.Metas{{ID: 1, Name: "f"}}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "throttle failure",
+			prepare: func() {
+				closed()
+				metricNames()
+				v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 1, 10, 100)})
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil)
+				it.EXPECT().HasNext().Return(true)
+				it.EXPECT().Key().Return(uint32(10))
+				it.EXPECT().Value().Return(block)
+				metadataDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).
+					Return(field.Metas{{ID: 1, Name: "f"}}, nil)
+				throttle = func(_ context.Context, _ int) error { return fmt.Errorf("err") }
+			},
+			wantErr: true,
+		},
+		{
+			name: "compute digests successfully",
+			prepare: func() {
+				closed()
+				metricNames()
+				throttle = func(_ context.Context, _ int) error { return nil }
+				v.EXPECT().GetAllFiles().Return([]*version.FileMeta{
+					version.NewFileMeta(1, 1, 10, 100),
+					version.NewFileMeta(2, 1, 10, 100),
+				})
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil).Times(2)
+				gomock.InOrder(
+					// file 1: metric without metadata is skipped
+					it.EXPECT().HasNext().Return(true),
+					it.EXPECT().Key().Return(uint32(20)),
+					it.EXPECT().HasNext().Return(true),
+					it.EXPECT().Key().Return(uint32(10)),
+					it.EXPECT().Value().Return(block),
+					it.EXPECT().HasNext().Return(false),
+					// file 2
+					it.EXPECT().HasNext().Return(true),
+					it.EXPECT().Key().Return(uint32(10)),
+					it.EXPECT().Value().Return(block),
+					it.EXPECT().HasNext().Return(false),
+				)
+				metadataDB.EXPECT().GetAllFields(gomock.Any(), gomock.Any()).
+					Return(field.Metas{{ID: 1, Name: "f"}}, nil)
+			},
+			digests: []models.MetricDigest{{Namespace: "ns", Metric: "cpu", Series: 3, Points: 6, Sum: 6}},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.TODO()
+			}
+			digests, err := DigestFamily(ctx, family, throttle)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, digests, len(tt.digests))
+			for idx := range tt.digests {
+				assert.NotZero(t, digests[idx].Hash)
+				digests[idx].Hash = 0
+			}
+			assert.Equal(t, tt.digests, digests)
+		})
+	}
+}
@@ -18,23 +18,43 @@
 package tsdb
 
 import (
+	"sort"
 	"sync"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/metrics"
 )
 
 var (
-	fManager           FamilyManager
-	once4FamilyManager sync.Once
+	fManager     FamilyManager
+	fManagerLock sync.RWMutex
 )
 
 // GetFamilyManager returns the data family manager singleton instance.
 // FIXME: need clean readonly family when no read long term
 func GetFamilyManager() FamilyManager {
-	once4FamilyManager.Do(func() {
+	fManagerLock.RLock()
+	familyMgr := fManager
+	fManagerLock.RUnlock()
+	if familyMgr != nil {
+		return familyMgr
+	}
+	fManagerLock.Lock()
+	defer fManagerLock.Unlock()
+	if fManager == nil {
 		fManager = newFamilyManager()
-	})
+	}
 	return fManager
 }
 
+// ResetFamilyManager drops the data family manager singleton instance(families/flush pause/shard states),
+// a new instance is created on next access, used when engine is restarted in process(e.g. embedded in tests).
+func ResetFamilyManager() {
+	fManagerLock.Lock()
+	defer fManagerLock.Unlock()
+	fManager = nil
+}
+
 // FamilyManager represents the data family manager.
 type FamilyManager interface {
 	// AddFamily adds the family.
@@ -45,11 +65,37 @@ type FamilyManager interface {
 	WalkEntry(fn func(family DataFamily))
 	// GetFamiliesByShard returns families for spec shard.
 	GetFamiliesByShard(shard Shard) []DataFamily
+	// PauseFlush pauses flushing of the families for database during maintenance(writes are still accepted),
+	// NOTICE: pause state is kept in memory only, not survives process restarts.
+	PauseFlush(database string)
+	// ResumeFlush resumes flushing of the families for database.
+	ResumeFlush(database string)
+	// IsFlushPaused returns if flushing of the families for database is paused.
+	IsFlushPaused(database string) bool
+	// FlushPausedDatabases returns the databases whose flushing is paused.
+	FlushPausedDatabases() []string
+	// SetShardHibernated registers the shard with hibernation state(active if false).
+	SetShardHibernated(shard Shard, hibernated bool)
+	// RemoveShard removes the shard after closed.
+	RemoveShard(shard Shard)
+	// ShardStats returns the number of active/hibernated shards.
+	ShardStats() (active, hibernated int)
+	// FamilyStats returns the memory/flush stats of all families.
+	FamilyStats() FamilyStats
+}
+
+// FamilyStats represents the memory/flush stats of all families.
+type FamilyStats struct {
+	MemDBTotalSize int64 // total heap size of memory databases
+	NeedFlush      int   // families in flushing or whose memory database is above max size(flush backlog)
+	FlushFailed    int   // families whose flush job fails persistently
 }
 
 // familyManager implements FamilyManager interface.
 type familyManager struct {
-	families sync.Map
+	families    sync.Map
+	flushPaused sync.Map // database => struct{}
+	shards      sync.Map // shard indicator => hibernated(bool)
 }
 
 // newFamilyManager creates the family manager.
@@ -87,3 +133,80 @@ func (sm *familyManager) GetFamiliesByShard(shard Shard) (rs []DataFamily) {
 	})
 	return
 }
+
+// PauseFlush pauses flushing of the families for database during maintenance(writes are still accepted),
+// NOTICE: pause state is kept in memory only, not survives process restarts.
+func (sm *familyManager) PauseFlush(database string) {
+	sm.flushPaused.Store(database, struct{}{})
+	metrics.FlushCheckerStatistics.FlushPaused.WithTagValues(database).Update(1)
+}
+
+// ResumeFlush resumes flushing of the families for database.
+func (sm *familyManager) ResumeFlush(database string) {
+	sm.flushPaused.Delete(database)
+	metrics.FlushCheckerStatistics.FlushPaused.WithTagValues(database).Update(0)
+}
+
+// IsFlushPaused returns if flushing of the families for database is paused.
+func (sm *familyManager) IsFlushPaused(database string) bool {
+	_, ok := sm.flushPaused.Load(database)
+	return ok
+}
+
+// FlushPausedDatabases returns the databases whose flushing is paused.
+func (sm *familyManager) FlushPausedDatabases() (rs []string) {
+	sm.flushPaused.Range(func(key, _ interface{}) bool {
+		rs = append(rs, key.(string))
+		return true
+	})
+	sort.Strings(rs)
+	return
+}
+
+// SetShardHibernated registers the shard with hibernation state(active if false).
+func (sm *familyManager) SetShardHibernated(shard Shard, hibernated bool) {
+	sm.shards.Store(shard.Indicator(), hibernated)
+	sm.updateShardStats()
+}
+
+// RemoveShard removes the shard after closed.
+func (sm *familyManager) RemoveShard(shard Shard) {
+	sm.shards.Delete(shard.Indicator())
+	sm.updateShardStats()
+}
+
+// ShardStats returns the number of active/hibernated shards.
+func (sm *familyManager) ShardStats() (active, hibernated int) {
+	sm.shards.Range(func(_, value interface{}) bool {
+		if value.(bool) {
+			hibernated++
+		} else {
+			active++
+		}
+		return true
+	})
+	return
+}
+
+// FamilyStats returns the memory/flush stats of all families.
+func (sm *familyManager) FamilyStats() (stats FamilyStats) {
+	maxMemDBSize := int64(config.GlobalStorageConfig().TSDB.MaxMemDBSize)
+	sm.WalkEntry(func(family DataFamily) {
+		size := family.MemDBSize()
+		stats.MemDBTotalSize += size
+		if family.IsFlushing() || (maxMemDBSize > 0 && size >= maxMemDBSize) {
+			stats.NeedFlush++
+		}
+		if !family.IsHealthy() {
+			stats.FlushFailed++
+		}
+	})
+	return
+}
+
+// updateShardStats updates the statistics of active/hibernated shards.
+func (sm *familyManager) updateShardStats() {
+	active, hibernated := sm.ShardStats()
+	metrics.ShardHibernationStatistics.ActiveShards.Update(float64(active))
+	metrics.ShardHibernationStatistics.HibernatedShards.Update(float64(hibernated))
+}
@@ -58,3 +58,90 @@ func TestFamilyManager_AddFamily(t *testing.T) {
 	})
 	assert.Equal(t, 0, c)
 }
+
+func TestResetFamilyManager(t *testing.T) {
+	old := GetFamilyManager()
+	defer func() {
+		fManager = old
+	}()
+	assert.Same(t, old, GetFamilyManager())
+	old.PauseFlush("db")
+	ResetFamilyManager()
+	familyMgr := GetFamilyManager()
+	assert.NotSame(t, old, familyMgr)
+	assert.False(t, familyMgr.IsFlushPaused("db"))
+	old.ResumeFlush("db")
+}
+
+func TestFamilyManager_PauseFlush(t *testing.T) {
+	fm := newFamilyManager()
+	assert.False(t, fm.IsFlushPaused("db"))
+	assert.Empty(t, fm.FlushPausedDatabases())
+
+	fm.PauseFlush("db2")
+	fm.PauseFlush("db")
+	fm.PauseFlush("db")
+	assert.True(t, fm.IsFlushPaused("db"))
+	assert.Equal(t, []string{"db", "db2"}, fm.FlushPausedDatabases())
+
+	fm.ResumeFlush("db")
+	assert.False(t, fm.IsFlushPaused("db"))
+	assert.Equal(t, []string{"db2"}, fm.FlushPausedDatabases())
+	// resume not paused database
+	fm.ResumeFlush("db3")
+	assert.Equal(t, []string{"db2"}, fm.FlushPausedDatabases())
+}
+
+func TestFamilyManager_ShardStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shard1 := NewMockShard(ctrl)
+	shard1.EXPECT().Indicator().Return("shard1").AnyTimes()
+	shard2 := NewMockShard(ctrl)
+	shard2.EXPECT().Indicator().Return("shard2").AnyTimes()
+
+	fm := newFamilyManager()
+	fm.SetShardHibernated(shard1, false)
+	fm.SetShardHibernated(shard2, false)
+	active, hibernated := fm.ShardStats()
+	assert.Equal(t, 2, active)
+	assert.Equal(t, 0, hibernated)
+
+	fm.SetShardHibernated(shard1, true)
+	active, hibernated = fm.ShardStats()
+	assert.Equal(t, 1, active)
+	assert.Equal(t, 1, hibernated)
+
+	fm.RemoveShard(shard1)
+	active, hibernated = fm.ShardStats()
+	assert.Equal(t, 1, active)
+	assert.Equal(t, 0, hibernated)
+}
+
+func TestFamilyManager_FamilyStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Indicator().Return("shard").AnyTimes()
+	fm := newFamilyManager()
+	assert.Equal(t, FamilyStats{}, fm.FamilyStats())
+
+	family1 := NewMockDataFamily(ctrl)
+	family1.EXPECT().Indicator().Return("family1").AnyTimes()
+	family1.EXPECT().Shard().Return(shard).AnyTimes()
+	family1.EXPECT().MemDBSize().Return(int64(100))
+	family1.EXPECT().IsFlushing().Return(true)
+	family1.EXPECT().IsHealthy().Return(true)
+	family2 := NewMockDataFamily(ctrl)
+	family2.EXPECT().Indicator().Return("family2").AnyTimes()
+	family2.EXPECT().Shard().Return(shard).AnyTimes()
+	family2.EXPECT().MemDBSize().Return(int64(50))
+	family2.EXPECT().IsFlushing().Return(false)
+	family2.EXPECT().IsHealthy().Return(false)
+	fm.AddFamily(family1)
+	fm.AddFamily(family2)
+
+	assert.Equal(t, FamilyStats{MemDBTotalSize: 150, NeedFlush: 1, FlushFailed: 1}, fm.FamilyStats())
+}
@@ -0,0 +1,210 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+//go:build faultinject
+
+package tsdb
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/fault"
+	"github.com/lindb/lindb/tsdb/memdb"
+)
+
+func TestFaultInjection_FlushFailureRecovery(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		fault.DisableAll()
+		ctrl.Finish()
+	}()
+
+	family := newFaultTestKVFamily(t)
+	f := openFaultTestFamily(t, ctrl, family)
+	var acked []int64
+	f.AckSequence(1, func(seq int64) {
+		acked = append(acked, seq)
+	})
+
+	// flush failure before switching memory database, retry by next flush
+	memDB1 := newFaultTestMemDB(ctrl)
+	f.mutableMemDB = memDB1
+	f.CommitSequence(1, 10)
+	fault.Enable(fault.FlushBeforeSwapMemDB, fault.Once(fault.Error(fmt.Errorf("err"))))
+	assert.Error(t, f.Flush())
+	assert.Equal(t, memDB1, f.mutableMemDB)
+	assert.Nil(t, f.immutableMemDB)
+	memDB1.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric)
+	memDB1.EXPECT().Close().Return(nil)
+	assert.NoError(t, f.Flush())
+	assert.Equal(t, int32(0), f.flushFailures.Load())
+	assert.Equal(t, []int64{10}, acked)
+	assert.Equal(t, map[int32]int64{1: 10}, persistedSequences(family))
+
+	// commit edit log failure, memory database kept as immutable, sequence not acknowledged
+	memDB2 := newFaultTestMemDB(ctrl)
+	f.mutableMemDB = memDB2
+	f.CommitSequence(1, 20)
+	fault.Enable(fault.KVFlusherCommit, fault.Once(fault.Error(fmt.Errorf("err"))))
+	memDB2.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric).Times(2)
+	assert.Error(t, f.Flush())
+	assert.Equal(t, int32(1), f.flushFailures.Load())
+	assert.True(t, f.IsHealthy())
+	assert.Equal(t, memDB2, f.immutableMemDB)
+	assert.Nil(t, f.mutableMemDB)
+	assert.Equal(t, []int64{10}, acked)
+	assert.Equal(t, map[int32]int64{1: 10}, persistedSequences(family))
+	persistSeq := f.persistSeq[1]
+	assert.Equal(t, int64(10), persistSeq.Load())
+
+	// new writes go into new memory database, closing family flushes immutable then mutable memory database
+	memDB3 := newFaultTestMemDB(ctrl)
+	f.mutableMemDB = memDB3
+	f.CommitSequence(1, 30)
+	memDB2.EXPECT().Close().Return(nil)
+	memDB3.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric)
+	memDB3.EXPECT().Close().Return(nil)
+	assert.NoError(t, f.Close())
+	assert.Equal(t, []int64{10, 20, 30}, acked)
+	assert.Equal(t, map[int32]int64{1: 30}, persistedSequences(family))
+}
+
+func TestFaultInjection_CrashBetweenFlushAndClose(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		fault.DisableAll()
+		ctrl.Finish()
+	}()
+
+	family := newFaultTestKVFamily(t)
+	f := openFaultTestFamily(t, ctrl, family)
+	memDB1 := newFaultTestMemDB(ctrl)
+	memDB1.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric)
+	f.mutableMemDB = memDB1
+	f.CommitSequence(1, 10)
+	// crash after data flushed, before memory database closed
+	fault.Enable(fault.FlushBeforeCloseMemDB, fault.Panic("crash"))
+	assert.Panics(t, func() {
+		_ = f.Flush()
+	})
+	assert.Equal(t, map[int32]int64{1: 10}, persistedSequences(family))
+
+	// restart, replayed rows written before crash are rejected, no duplicate data
+	f = openFaultTestFamily(t, ctrl, family)
+	assert.False(t, f.ValidateSequence(1, 10))
+	assert.True(t, f.ValidateSequence(1, 11))
+
+	// close memory database failure after data flushed, memory database cannot be flushed again
+	fault.Enable(fault.FlushBeforeCloseMemDB, fault.Error(fmt.Errorf("err")))
+	memDB2 := newFaultTestMemDB(ctrl)
+	memDB2.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric)
+	f.mutableMemDB = memDB2
+	f.CommitSequence(1, 20)
+	assert.NoError(t, f.Flush())
+	assert.Nil(t, f.immutableMemDB)
+	assert.Nil(t, f.mutableMemDB)
+	assert.NoError(t, f.Close())
+	assert.Equal(t, map[int32]int64{1: 20}, persistedSequences(family))
+}
+
+func TestFaultInjection_SequenceCallbackOrdering(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		fault.DisableAll()
+		newMemoryDBFunc = memdb.NewMemoryDatabase
+		ctrl.Finish()
+	}()
+
+	family := newFaultTestKVFamily(t)
+	f := openFaultTestFamily(t, ctrl, family)
+	var (
+		lock  sync.Mutex
+		acked []int64
+	)
+	getAcked := func() []int64 {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]int64(nil), acked...)
+	}
+	f.AckSequence(1, func(seq int64) {
+		// sequence is acknowledged after persisted
+		assert.Equal(t, seq, persistedSequences(family)[1])
+		lock.Lock()
+		acked = append(acked, seq)
+		lock.Unlock()
+	})
+	memDB1 := newFaultTestMemDB(ctrl)
+	memDB1.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric)
+	memDB1.EXPECT().Close().Return(nil)
+	memDB2 := newFaultTestMemDB(ctrl)
+	memDB2.EXPECT().FlushFamilyTo(gomock.Any()).DoAndReturn(flushTestMetric)
+	memDB2.EXPECT().Close().Return(nil)
+	newMemoryDBFunc = func(_ memdb.MemoryDatabaseCfg) (memdb.MemoryDatabase, error) {
+		return memDB2, nil
+	}
+	f.mutableMemDB = memDB1
+	f.CommitSequence(1, 10)
+
+	// write committed during flushing isn't acknowledged by this flush
+	swapped := make(chan struct{})
+	resume := make(chan struct{})
+	fault.Enable(fault.FlushAfterSwapMemDB, fault.Once(func() error {
+		close(swapped)
+		<-resume
+		return nil
+	}))
+	flushed := make(chan error)
+	go func() {
+		flushed <- f.Flush()
+	}()
+	<-swapped
+	memDB, err := f.GetOrCreateMemoryDatabase(0)
+	assert.NoError(t, err)
+	assert.Equal(t, memDB2, memDB)
+	f.CommitSequence(1, 20)
+	close(resume)
+	assert.NoError(t, <-flushed)
+	assert.Equal(t, []int64{10}, getAcked())
+	persistSeq := f.persistSeq[1]
+	assert.Equal(t, int64(10), persistSeq.Load())
+
+	// write committing while flushing, acknowledged by next flush
+	committing := make(chan struct{})
+	committed := make(chan struct{})
+	resumeCommit := make(chan struct{})
+	fault.Enable(fault.CommitSequence, fault.Once(func() error {
+		close(committing)
+		<-resumeCommit
+		return nil
+	}))
+	go func() {
+		f.CommitSequence(1, 30)
+		close(committed)
+	}()
+	<-committing
+	assert.NoError(t, f.Flush())
+	assert.Equal(t, []int64{10, 20}, getAcked())
+	close(resumeCommit)
+	<-committed
+	assert.False(t, f.ValidateSequence(1, 30))
+	assert.Equal(t, map[int32]int64{1: 20}, persistedSequences(family))
+}
@@ -0,0 +1,122 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"sync"
+
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+// metricPrecision represents the resolved value precisions of metric.
+type metricPrecision struct {
+	defaultPrecision uint8              // precision of non-sum fields without override
+	fields           map[field.ID]uint8 // field id => precision override(maybe 0)
+}
+
+// fieldPrecision represents the field value precision rules of database,
+// compaction rounds the field data to the significant decimal digits of rules.
+type fieldPrecision struct {
+	metadata metadb.Metadata
+
+	rules      []option.FieldPrecision
+	resolved   map[metric.ID]*metricPrecision
+	resolvedAt int64
+
+	mutex sync.Mutex
+}
+
+// newFieldPrecision creates the field value precision of database.
+func newFieldPrecision(metadata metadb.Metadata) *fieldPrecision {
+	return &fieldPrecision{metadata: metadata}
+}
+
+// setRules sets the precision rules of fields, the metric/field ids are resolved again.
+func (p *fieldPrecision) setRules(rules []option.FieldPrecision) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.rules = rules
+	p.resolved = nil
+	p.resolvedAt = 0
+}
+
+// getPrecision returns the precision of field, 0 means full precision.
+// Default precision of metric excludes sum/histogram fields, because rounding accumulated values causes drift.
+func (p *fieldPrecision) getPrecision(metricID metric.ID, f field.Meta) uint8 {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.resolve()
+	precision, ok := p.resolved[metricID]
+	if !ok {
+		return 0
+	}
+	if fieldPrecision, ok := precision.fields[f.ID]; ok {
+		return fieldPrecision
+	}
+	switch f.Type {
+	case field.SumField, field.HistogramField:
+		return 0
+	default:
+		return precision.defaultPrecision
+	}
+}
+
+// resolve resolves the metric/field ids of rules if not resolved recently, must be called with lock.
+func (p *fieldPrecision) resolve() {
+	if len(p.rules) == 0 {
+		return
+	}
+	now := timeutil.Now()
+	if p.resolved != nil && now-p.resolvedAt < resolveInterval {
+		return
+	}
+	resolved := make(map[metric.ID]*metricPrecision)
+	metadata := p.metadata.MetadataDatabase()
+	for idx := range p.rules {
+		rule := &p.rules[idx]
+		if rule.Precision < 0 || rule.Precision > option.MaxFieldPrecision {
+			continue
+		}
+		metricID, err := metadata.GetMetricID(rule.Namespace, rule.Metric)
+		if err != nil {
+			continue
+		}
+		precision, ok := resolved[metricID]
+		if !ok {
+			precision = &metricPrecision{fields: make(map[field.ID]uint8)}
+			resolved[metricID] = precision
+		}
+		if rule.Field == "" {
+			precision.defaultPrecision = uint8(rule.Precision)
+			continue
+		}
+		f, err := metadata.GetField(rule.Namespace, rule.Metric, field.Name(rule.Field))
+		if err != nil {
+			continue
+		}
+		precision.fields[f.ID] = uint8(rule.Precision)
+	}
+	p.resolved = resolved
+	p.resolvedAt = now
+}
@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+// newTestFieldPrecision returns the field precision which keeps 3 digits of metric(id: 10),
+// 5 digits of sum field(id: 2), full precision of field(id: 3).
+func newTestFieldPrecision(ctrl *gomock.Controller) *fieldPrecision {
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	metadataDB.EXPECT().GetMetricID("ns", "cpu").Return(metric.ID(10), nil).AnyTimes()
+	metadataDB.EXPECT().GetMetricID("ns", "mem").Return(metric.ID(0), fmt.Errorf("err")).AnyTimes()
+	metadataDB.EXPECT().GetField("ns", "cpu", field.Name("count")).
+		Return(field.Meta{ID: 2, Name: "count", Type: field.SumField}, nil).AnyTimes()
+	metadataDB.EXPECT().GetField("ns", "cpu", field.Name("idle")).
+		Return(field.Meta{ID: 3, Name: "idle", Type: field.LastField}, nil).AnyTimes()
+	metadataDB.EXPECT().GetField("ns", "cpu", field.Name("unknown")).
+		Return(field.Meta{}, fmt.Errorf("err")).AnyTimes()
+	p := newFieldPrecision(metadata)
+	p.setRules([]option.FieldPrecision{
+		{Namespace: "ns", Metric: "cpu", Precision: 3},
+		{Namespace: "ns", Metric: "cpu", Field: "count", Precision: 5},
+		{Namespace: "ns", Metric: "cpu", Field: "idle", Precision: 0},
+		{Namespace: "ns", Metric: "cpu", Field: "unknown", Precision: 2},
+		{Namespace: "ns", Metric: "cpu", Field: "invalid", Precision: 100},
+		{Namespace: "ns", Metric: "mem", Precision: 2},
+	})
+	return p
+}
+
+func TestFieldPrecision_getPrecision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// no rules
+	assert.Zero(t, newFieldPrecision(nil).getPrecision(10, field.Meta{ID: 1, Type: field.LastField}))
+
+	p := newTestFieldPrecision(ctrl)
+	// default precision of metric
+	assert.Equal(t, uint8(3), p.getPrecision(10, field.Meta{ID: 1, Type: field.LastField}))
+	assert.Equal(t, uint8(3), p.getPrecision(10, field.Meta{ID: 4, Type: field.MaxField}))
+	// sum/histogram fields are excluded from default precision
+	assert.Zero(t, p.getPrecision(10, field.Meta{ID: 5, Type: field.SumField}))
+	assert.Zero(t, p.getPrecision(10, field.Meta{ID: 6, Type: field.HistogramField}))
+	// field overrides
+	assert.Equal(t, uint8(5), p.getPrecision(10, field.Meta{ID: 2, Type: field.SumField}))
+	assert.Zero(t, p.getPrecision(10, field.Meta{ID: 3, Type: field.LastField}))
+	// metric without rule
+	assert.Zero(t, p.getPrecision(20, field.Meta{ID: 1, Type: field.LastField}))
+
+	// rules removed
+	p.setRules(nil)
+	assert.Zero(t, p.getPrecision(10, field.Meta{ID: 1, Type: field.LastField}))
+}
@@ -0,0 +1,222 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+// resolveInterval represents the interval of resolving the metric/field ids of field retention rules again,
+// because metric/field of rule maybe written after rule set.
+const resolveInterval = timeutil.OneMinute
+
+// fieldTruncationKey represents the field of metric in shard.
+type fieldTruncationKey struct {
+	shardID  models.ShardID
+	metricID metric.ID
+	fieldID  field.ID
+}
+
+// fieldTruncation represents the truncation record of field, field data before truncated time is dropped.
+type fieldTruncation struct {
+	ShardID         models.ShardID `toml:"shardID"`
+	MetricID        metric.ID      `toml:"metricID"`
+	FieldID         field.ID       `toml:"fieldID"`
+	TruncatedBefore int64          `toml:"truncatedBefore"`
+}
+
+// fieldTruncationRecords represents the truncation records of database, persisted in FIELD_TRUNCATIONS file.
+type fieldTruncationRecords struct {
+	Truncations []fieldTruncation `toml:"truncations"`
+}
+
+// fieldRetention represents the field level retention rules of database,
+// compaction drops the field data out of retention, retention reaper rewrites the families which need truncation.
+type fieldRetention struct {
+	path     string
+	metadata metadb.Metadata
+
+	rules       []option.FieldRetention
+	resolved    map[metric.ID]map[field.ID]int64 // metric id => field id => retention
+	resolvedAt  int64
+	truncations map[fieldTruncationKey]int64 // field => truncated before
+	dirty       bool
+
+	mutex sync.Mutex
+}
+
+// newFieldRetention creates the field level retention of database, loads the truncation records if exist.
+func newFieldRetention(path string, metadata metadb.Metadata) (*fieldRetention, error) {
+	r := &fieldRetention{
+		path:        path,
+		metadata:    metadata,
+		truncations: make(map[fieldTruncationKey]int64),
+	}
+	if !fileExist(path) {
+		return r, nil
+	}
+	records := &fieldTruncationRecords{}
+	if err := decodeToml(path, records); err != nil {
+		return nil, err
+	}
+	for _, t := range records.Truncations {
+		r.truncations[fieldTruncationKey{shardID: t.ShardID, metricID: t.MetricID, fieldID: t.FieldID}] = t.TruncatedBefore
+	}
+	return r, nil
+}
+
+// setRules sets the retention rules of fields, the metric/field ids are resolved again.
+func (r *fieldRetention) setRules(rules []option.FieldRetention) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.rules = rules
+	r.resolved = nil
+	r.resolvedAt = 0
+}
+
+// hasRules returns true if database has field retention rules.
+func (r *fieldRetention) hasRules() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return len(r.rules) > 0
+}
+
+// isExpired returns true if the field data of metric stored in family(end time) is out of retention.
+func (r *fieldRetention) isExpired(familyEndTime int64, metricID metric.ID, fieldID field.ID) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.resolve()
+	retention, ok := r.resolved[metricID][fieldID]
+	return ok && timeutil.Now()-familyEndTime > retention
+}
+
+// truncate returns true if the family of shard contains the field data out of retention which is not truncated,
+// marks the fields truncated if fn returns true(family is rewritten).
+func (r *fieldRetention) truncate(shardID models.ShardID, familyEndTime int64, fn func() bool) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.resolve()
+	now := timeutil.Now()
+	var keys []fieldTruncationKey
+	for metricID, fields := range r.resolved {
+		for fieldID, retention := range fields {
+			key := fieldTruncationKey{shardID: shardID, metricID: metricID, fieldID: fieldID}
+			if now-familyEndTime > retention && r.truncations[key] < familyEndTime {
+				keys = append(keys, key)
+			}
+		}
+	}
+	if len(keys) == 0 || !fn() {
+		return false
+	}
+	for _, key := range keys {
+		r.truncations[key] = familyEndTime
+	}
+	r.dirty = true
+	return true
+}
+
+// truncatedBefore returns the time before which the field data of shard is truncated, 0 if not truncated.
+func (r *fieldRetention) truncatedBefore(shardID models.ShardID, metricID metric.ID, fieldID field.ID) int64 {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.truncations[fieldTruncationKey{shardID: shardID, metricID: metricID, fieldID: fieldID}]
+}
+
+// flush persists the truncation records if changed.
+func (r *fieldRetention) flush() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.dirty {
+		return nil
+	}
+	records := &fieldTruncationRecords{}
+	for key, truncatedBefore := range r.truncations {
+		records.Truncations = append(records.Truncations, fieldTruncation{
+			ShardID:         key.shardID,
+			MetricID:        key.metricID,
+			FieldID:         key.fieldID,
+			TruncatedBefore: truncatedBefore,
+		})
+	}
+	sort.Slice(records.Truncations, func(i, j int) bool {
+		a, b := records.Truncations[i], records.Truncations[j]
+		if a.ShardID != b.ShardID {
+			return a.ShardID < b.ShardID
+		}
+		if a.MetricID != b.MetricID {
+			return a.MetricID < b.MetricID
+		}
+		return a.FieldID < b.FieldID
+	})
+	if err := encodeToml(r.path, records); err != nil {
+		return err
+	}
+	r.dirty = false
+	return nil
+}
+
+// resolve resolves the metric/field ids of rules if not resolved recently, must be called with lock.
+func (r *fieldRetention) resolve() {
+	if len(r.rules) == 0 {
+		return
+	}
+	now := timeutil.Now()
+	if r.resolved != nil && now-r.resolvedAt < resolveInterval {
+		return
+	}
+	resolved := make(map[metric.ID]map[field.ID]int64)
+	metadata := r.metadata.MetadataDatabase()
+	for idx := range r.rules {
+		rule := &r.rules[idx]
+		retention := rule.GetRetention()
+		if retention <= 0 {
+			continue
+		}
+		metricID, err := metadata.GetMetricID(rule.Namespace, rule.Metric)
+		if err != nil {
+			continue
+		}
+		f, err := metadata.GetField(rule.Namespace, rule.Metric, field.Name(rule.Field))
+		if err != nil {
+			continue
+		}
+		fields, ok := resolved[metricID]
+		if !ok {
+			fields = make(map[field.ID]int64)
+			resolved[metricID] = fields
+		}
+		fields[f.ID] = retention
+	}
+	r.resolved = resolved
+	r.resolvedAt = now
+}
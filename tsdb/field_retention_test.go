@@ -0,0 +1,154 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+// newTestFieldRetention returns the field retention which expires field(metric id: 10, field id: 1) after 1 day.
+func newTestFieldRetention(ctrl *gomock.Controller) *fieldRetention {
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	metadataDB.EXPECT().GetMetricID("ns", "cpu").Return(metric.ID(10), nil).AnyTimes()
+	metadataDB.EXPECT().GetField("ns", "cpu", field.Name("idle")).
+		Return(field.Meta{ID: 1, Name: "idle"}, nil).AnyTimes()
+	r := &fieldRetention{metadata: metadata, truncations: make(map[fieldTruncationKey]int64)}
+	r.setRules([]option.FieldRetention{{Namespace: "ns", Metric: "cpu", Field: "idle", Retention: "1d"}})
+	return r
+}
+
+func TestFieldRetention_New(t *testing.T) {
+	defer func() {
+		decodeToml = ltoml.DecodeToml
+	}()
+	path := filepath.Join(t.TempDir(), fieldTruncations)
+	// file not exist
+	r, err := newFieldRetention(path, nil)
+	assert.NoError(t, err)
+	assert.False(t, r.hasRules())
+	assert.NoError(t, r.flush())
+	// load truncation records
+	r.truncations[fieldTruncationKey{shardID: 1, metricID: 10, fieldID: 1}] = 100
+	r.truncations[fieldTruncationKey{shardID: 2, metricID: 10, fieldID: 1}] = 200
+	r.truncations[fieldTruncationKey{shardID: 1, metricID: 5, fieldID: 2}] = 300
+	r.dirty = true
+	assert.NoError(t, r.flush())
+	r, err = newFieldRetention(path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), r.truncatedBefore(1, 10, 1))
+	assert.Equal(t, int64(200), r.truncatedBefore(2, 10, 1))
+	assert.Equal(t, int64(300), r.truncatedBefore(1, 5, 2))
+	assert.Equal(t, int64(0), r.truncatedBefore(3, 10, 1))
+	// decode failure
+	decodeToml = func(fileName string, v interface{}) error {
+		return fmt.Errorf("err")
+	}
+	r, err = newFieldRetention(path, nil)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+}
+
+func TestFieldRetention_flush(t *testing.T) {
+	defer func() {
+		encodeToml = ltoml.EncodeToml
+	}()
+	r := &fieldRetention{
+		path:        filepath.Join(t.TempDir(), fieldTruncations),
+		truncations: map[fieldTruncationKey]int64{{shardID: 1, metricID: 10, fieldID: 1}: 100},
+		dirty:       true,
+	}
+	encodeToml = func(fileName string, v interface{}) error {
+		return fmt.Errorf("err")
+	}
+	assert.Error(t, r.flush())
+	assert.True(t, r.dirty)
+	encodeToml = ltoml.EncodeToml
+	assert.NoError(t, r.flush())
+	assert.False(t, r.dirty)
+}
+
+func TestFieldRetention_isExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := timeutil.Now()
+	r := newTestFieldRetention(ctrl)
+	assert.True(t, r.hasRules())
+	assert.True(t, r.isExpired(now-2*timeutil.OneDay, 10, 1))
+	assert.False(t, r.isExpired(now-timeutil.OneHour, 10, 1))
+	assert.False(t, r.isExpired(now-2*timeutil.OneDay, 10, 2))
+	assert.False(t, r.isExpired(now-2*timeutil.OneDay, 11, 1))
+
+	// metric/field not found or invalid retention
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	metadataDB.EXPECT().GetMetricID("ns", "mem").Return(metric.ID(0), fmt.Errorf("err"))
+	metadataDB.EXPECT().GetMetricID("ns", "cpu").Return(metric.ID(10), nil)
+	metadataDB.EXPECT().GetField("ns", "cpu", field.Name("idle")).Return(field.Meta{}, fmt.Errorf("err"))
+	r = &fieldRetention{metadata: metadata}
+	r.setRules([]option.FieldRetention{
+		{Namespace: "ns", Metric: "mem", Field: "used", Retention: "1d"},
+		{Namespace: "ns", Metric: "cpu", Field: "idle", Retention: "1d"},
+		{Namespace: "ns", Metric: "cpu", Field: "user", Retention: "abc"},
+	})
+	assert.False(t, r.isExpired(now-2*timeutil.OneDay, 10, 1))
+	// resolved recently
+	assert.False(t, r.isExpired(now-2*timeutil.OneDay, 10, 1))
+}
+
+func TestFieldRetention_truncate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	now := timeutil.Now()
+	r := newTestFieldRetention(ctrl)
+	compact := func() bool {
+		return true
+	}
+	// family not expired
+	assert.False(t, r.truncate(1, now-timeutil.OneHour, compact))
+	// compact fail
+	assert.False(t, r.truncate(1, now-2*timeutil.OneDay, func() bool {
+		return false
+	}))
+	assert.Equal(t, int64(0), r.truncatedBefore(1, 10, 1))
+	// truncate
+	assert.True(t, r.truncate(1, now-2*timeutil.OneDay, compact))
+	assert.Equal(t, now-2*timeutil.OneDay, r.truncatedBefore(1, 10, 1))
+	assert.True(t, r.dirty)
+	// truncated already
+	assert.False(t, r.truncate(1, now-3*timeutil.OneDay, compact))
+	assert.False(t, r.truncate(1, now-2*timeutil.OneDay, compact))
+	// other shard
+	assert.True(t, r.truncate(2, now-2*timeutil.OneDay, compact))
+}
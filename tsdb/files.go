@@ -22,7 +22,6 @@ import (
 	"path/filepath"
 	"strconv"
 
-	"github.com/lindb/lindb/config"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/timeutil"
 )
@@ -31,9 +30,11 @@ import (
 // directory tree for database[xx]:
 //
 //	xx/OPTIONS => config file
+//	xx/FIELD_TRUNCATIONS => truncation records of field level retention
 //	xx/meta/namespace => namespace metadata
 //	xx/meta/metric => metrics' name metadata
 //	xx/meta/field => metrics' field metadata
+//	xx/meta/FIELD_TYPE_CHANGES => change points of field types
 //	xx/meta/tagkey => metrics' tag key metadata
 //	xx/meta/tagvalue => metrics' tag value metadata
 //	xx/shard/1/(path)
@@ -44,6 +45,7 @@ import (
 //	xx/shard/1/segment/month/201910/
 const (
 	options          = "OPTIONS"
+	fieldTruncations = "FIELD_TRUNCATIONS"
 	shardDir         = "shard"
 	metaDir          = "meta"
 	tagValueMetaDir  = "tagvalue"
@@ -55,9 +57,9 @@ const (
 	bufferDir        = "buffer"
 )
 
-// createDatabasePath creates database's root path if existed.
-func createDatabasePath(database string) (string, error) {
-	dbPath := filepath.Join(config.GlobalStorageConfig().TSDB.Dir, database)
+// createDatabasePath creates database's root path under the root path of engine if existed.
+func createDatabasePath(dir, database string) (string, error) {
+	dbPath := filepath.Join(dir, database)
 	if err := mkDirIfNotExist(dbPath); err != nil {
 		return "", fmt.Errorf("create database[%s]'s path with error: %s", database, err)
 	}
@@ -65,13 +67,18 @@ func createDatabasePath(database string) (string, error) {
 }
 
 // optionsPath returns database's options file path.
-func optionsPath(database string) string {
-	return filepath.Join(config.GlobalStorageConfig().TSDB.Dir, database, options)
+func optionsPath(dir, database string) string {
+	return filepath.Join(dir, database, options)
+}
+
+// fieldTruncationsPath returns database's truncation records file path of field level retention.
+func fieldTruncationsPath(dir, database string) string {
+	return filepath.Join(dir, database, fieldTruncations)
 }
 
 // metricsMetaPath returns metrics' metadata storage path.
-func metricsMetaPath(database string) string {
-	return filepath.Join(config.GlobalStorageConfig().TSDB.Dir, database, metaDir)
+func metricsMetaPath(dir, database string) string {
+	return filepath.Join(dir, database, metaDir)
 }
 
 // tagMetaIndicator returns database's tag metadata indicator information.
@@ -85,13 +92,13 @@ func shardIndicator(database string, shardID models.ShardID) string {
 }
 
 // shardPath returns shard's storage path.
-func shardPath(database string, shardID models.ShardID) string {
-	return filepath.Join(config.GlobalStorageConfig().TSDB.Dir, shardIndicator(database, shardID))
+func shardPath(dir, database string, shardID models.ShardID) string {
+	return filepath.Join(dir, shardIndicator(database, shardID))
 }
 
 // shardTempBufferPath returns temp buffer path for write data.
-func shardTempBufferPath(database string, shardID models.ShardID) string {
-	return filepath.Join(shardPath(database, shardID), bufferDir)
+func shardTempBufferPath(dir, database string, shardID models.ShardID) string {
+	return filepath.Join(shardPath(dir, database, shardID), bufferDir)
 }
 
 // shardIndexIndicator returns shard level index indicator information.
@@ -100,8 +107,8 @@ func shardIndexIndicator(database string, shardID models.ShardID) string {
 }
 
 // shardMetaPath returns shard level metadata path.
-func shardMetaPath(database string, shardID models.ShardID) string {
-	return filepath.Join(shardPath(database, shardID), metaDir)
+func shardMetaPath(dir, database string, shardID models.ShardID) string {
+	return filepath.Join(shardPath(dir, database, shardID), metaDir)
 }
 
 // ShardSegmentIndicator returns the segment name indicator information.
@@ -110,6 +117,6 @@ func ShardSegmentIndicator(database string, shardID models.ShardID, interval tim
 }
 
 // ShardSegmentPath returns segment path in shard dir.
-func ShardSegmentPath(database string, shardID models.ShardID, interval timeutil.Interval) string {
-	return filepath.Join(shardPath(database, shardID), segmentDir, interval.Type().String())
+func ShardSegmentPath(dir, database string, shardID models.ShardID, interval timeutil.Interval) string {
+	return filepath.Join(shardPath(dir, database, shardID), segmentDir, interval.Type().String())
 }
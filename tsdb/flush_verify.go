@@ -0,0 +1,177 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/memdb"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+// maxFlushVerifyDiffs represents the max num. of mismatched points dumped for debugging.
+const maxFlushVerifyDiffs = 100
+
+// errFlushedDataMismatch represents the data flushed into files mismatches memory database.
+var errFlushedDataMismatch = errors.New("flushed data mismatches memory database")
+
+// verifyFlush re-reads the points sampled from memory database in the files written by flush, then compares the values.
+// If any mismatch found, returns errFlushedDataMismatch and dumps the diff for debugging,
+// memory database need be retained(not released) by caller.
+func (f *dataFamily) verifyFlush(memDB memdb.MemoryDatabase, outputs []table.FileNumber, sampleRate float64) error {
+	startTime := time.Now()
+	points := memDB.SamplePoints(sampleRate)
+
+	snapShot := f.family.GetSnapshot()
+	defer snapShot.Close()
+
+	verifier := &flushVerifier{}
+	defer verifier.close()
+	for _, fileNumber := range outputs {
+		reader, err := snapShot.GetReader(fileNumber)
+		if err != nil {
+			return err
+		}
+		verifier.readers = append(verifier.readers, reader)
+	}
+	mismatches := 0
+	var diffs []string
+	for idx := range points {
+		point := &points[idx]
+		value, ok, err := verifier.getValue(point)
+		if err != nil {
+			return err
+		}
+		if ok == point.HasValue && math.Float64bits(value) == math.Float64bits(point.Value) {
+			continue
+		}
+		mismatches++
+		if len(diffs) < maxFlushVerifyDiffs {
+			diffs = append(diffs, fmt.Sprintf("metric:%d,series:%d,field:%d,slot:%d,memdb:%s,file:%s",
+				point.MetricID, point.SeriesID, point.FieldID, point.Slot,
+				formatPointValue(point.Value, point.HasValue), formatPointValue(value, ok)))
+		}
+	}
+	cost := time.Since(startTime)
+	f.statistics.FlushVerifyPoints.Add(float64(len(points)))
+	f.statistics.FlushVerifyDuration.UpdateDuration(cost)
+	if mismatches > 0 {
+		f.statistics.FlushVerifyFailures.Incr()
+		f.logger.Error("flushed data mismatches memory database, retain memory database",
+			logger.String("family", f.indicator),
+			logger.Any("files", outputs),
+			logger.Int("points", len(points)),
+			logger.Int("mismatches", mismatches),
+			logger.Any("diffs", diffs))
+		return errFlushedDataMismatch
+	}
+	f.logger.Info("verify flushed data successfully",
+		logger.String("family", f.indicator),
+		logger.Int("points", len(points)),
+		logger.String("cost", cost.String()))
+	return nil
+}
+
+// formatPointValue returns the string of point value for dumping diff.
+func formatPointValue(value float64, hasValue bool) string {
+	if !hasValue {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// flushVerifier reads the value of points from the files written by flush.
+// !!!!NOTICE: need read points in order by metric/series/field
+type flushVerifier struct {
+	readers []table.Reader
+
+	started      bool
+	metricID     metric.ID
+	pointReaders []*metricsdata.PointReader // point readers of current metric in each file
+	seriesID     uint32
+	fieldID      field.ID
+	values       map[uint16]float64 // time slot => value of current series field
+}
+
+// getValue returns the value of point in files, returns false if not found.
+func (v *flushVerifier) getValue(point *memdb.Point) (value float64, ok bool, err error) {
+	if !v.started || point.MetricID != v.metricID {
+		if err := v.seekMetric(point.MetricID); err != nil {
+			return 0, false, err
+		}
+		v.started = true
+		v.readFieldValues(point)
+	} else if point.SeriesID != v.seriesID || point.FieldID != v.fieldID {
+		v.readFieldValues(point)
+	}
+	value, ok = v.values[point.Slot]
+	return value, ok, nil
+}
+
+// seekMetric creates the point readers of metric in files.
+func (v *flushVerifier) seekMetric(metricID metric.ID) error {
+	v.close()
+	v.metricID = metricID
+	for _, reader := range v.readers {
+		value, err := reader.Get(uint32(metricID))
+		if errors.Is(err, table.ErrKeyNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		r, err := newReaderFunc(reader.Path(), value)
+		if err != nil {
+			return err
+		}
+		pointReader, err := metricsdata.NewPointReader(r)
+		if err != nil {
+			return err
+		}
+		v.pointReaders = append(v.pointReaders, pointReader)
+	}
+	return nil
+}
+
+// readFieldValues reads the values of series field from the file which series found.
+func (v *flushVerifier) readFieldValues(point *memdb.Point) {
+	v.seriesID = point.SeriesID
+	v.fieldID = point.FieldID
+	v.values = nil
+	for _, pointReader := range v.pointReaders {
+		if pointReader.Seek(point.SeriesID) {
+			v.values = pointReader.GetPoints(point.FieldID)
+			return
+		}
+	}
+}
+
+// close releases the point readers of current metric.
+func (v *flushVerifier) close() {
+	for _, pointReader := range v.pointReaders {
+		pointReader.Close()
+	}
+	v.pointReaders = nil
+}
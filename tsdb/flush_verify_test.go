@@ -0,0 +1,237 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/kv/version"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/bit"
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/tsdb/memdb"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+// mockFlushedMetricBlock returns the metric block of series(10/20) which field(1) has value(series id) in slot 5.
+func mockFlushedMetricBlock() []byte {
+	nopKVFlusher := kv.NewNopFlusher()
+	flusher, _ := metricsdata.NewFlusher(nopKVFlusher)
+	flusher.PrepareMetric(1, field.Metas{{ID: 1, Type: field.SumField}, {ID: 2, Type: field.MaxField}})
+	for _, seriesID := range []uint32{10, 20} {
+		encoder := encoding.NewTSDEncoder(5)
+		encoder.AppendTime(bit.One)
+		encoder.AppendValue(math.Float64bits(float64(seriesID)))
+		data, _ := encoder.BytesWithoutTime()
+		_ = flusher.FlushField(data)
+		_ = flusher.FlushField(nil)
+		_ = flusher.FlushSeries(seriesID)
+	}
+	_ = flusher.CommitMetric(timeutil.SlotRange{Start: 5, End: 6})
+	return nopKVFlusher.Bytes()
+}
+
+func TestDataFamily_verifyFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	family := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	snapshot.EXPECT().Close().AnyTimes()
+	reader := table.NewMockReader(ctrl)
+	reader.EXPECT().Path().Return("1.sst").AnyTimes()
+	memDB := memdb.NewMockMemoryDatabase(ctrl)
+
+	flushedPoints := []memdb.Point{
+		{MetricID: 1, SeriesID: 10, FieldID: 1, Slot: 5, Value: 10, HasValue: true},
+		{MetricID: 1, SeriesID: 10, FieldID: 1, Slot: 6},
+		{MetricID: 1, SeriesID: 10, FieldID: 2, Slot: 5},
+		{MetricID: 1, SeriesID: 20, FieldID: 1, Slot: 5, Value: 20, HasValue: true},
+	}
+	cases := []struct {
+		name    string
+		prepare func()
+		wantErr error
+	}{
+		{
+			name: "get file reader failure",
+			prepare: func() {
+				memDB.EXPECT().SamplePoints(0.5).Return(flushedPoints)
+				snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: fmt.Errorf("err"),
+		},
+		{
+			name: "get metric data failure",
+			prepare: func() {
+				memDB.EXPECT().SamplePoints(0.5).Return(flushedPoints)
+				snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader, nil)
+				reader.EXPECT().Get(uint32(1)).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: fmt.Errorf("err"),
+		},
+		{
+			name: "new metric reader failure",
+			prepare: func() {
+				memDB.EXPECT().SamplePoints(0.5).Return(flushedPoints)
+				snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader, nil)
+				reader.EXPECT().Get(uint32(1)).Return([]byte{1, 2, 3}, nil)
+			},
+			wantErr: fmt.Errorf("block length too short"),
+		},
+		{
+			name: "flushed data matches memory database",
+			prepare: func() {
+				memDB.EXPECT().SamplePoints(0.5).Return(flushedPoints)
+				snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader, nil)
+				reader.EXPECT().Get(uint32(1)).Return(mockFlushedMetricBlock(), nil)
+			},
+		},
+		{
+			name: "value mismatches memory database",
+			prepare: func() {
+				memDB.EXPECT().SamplePoints(0.5).Return([]memdb.Point{
+					{MetricID: 1, SeriesID: 10, FieldID: 1, Slot: 5, Value: 11, HasValue: true},
+					{MetricID: 1, SeriesID: 10, FieldID: 2, Slot: 5, Value: 10, HasValue: true},
+					{MetricID: 1, SeriesID: 30, FieldID: 1, Slot: 5, Value: 30, HasValue: true},
+				})
+				snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader, nil)
+				reader.EXPECT().Get(uint32(1)).Return(mockFlushedMetricBlock(), nil)
+			},
+			wantErr: errFlushedDataMismatch,
+		},
+		{
+			name: "metric not found in files",
+			prepare: func() {
+				memDB.EXPECT().SamplePoints(0.5).Return([]memdb.Point{
+					{MetricID: 2, SeriesID: 10, FieldID: 1, Slot: 5},
+					{MetricID: 3, SeriesID: 10, FieldID: 1, Slot: 5, Value: 10, HasValue: true},
+				})
+				snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader, nil)
+				reader.EXPECT().Get(gomock.Any()).Return(nil, table.ErrKeyNotExist).Times(2)
+			},
+			wantErr: errFlushedDataMismatch,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			f := &dataFamily{
+				family:     family,
+				statistics: metrics.NewFamilyStatistics("data", "1", "family"),
+				logger:     logger.GetLogger("TSDB", "Test"),
+			}
+			tt.prepare()
+			err := f.verifyFlush(memDB, []table.FileNumber{1}, 0.5)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+				if tt.wantErr == errFlushedDataMismatch {
+					assert.ErrorIs(t, err, errFlushedDataMismatch)
+				}
+			}
+		})
+	}
+}
+
+func TestDataFamily_Flush_verify(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newMetricDataFlusher = metricsdata.NewFlusher
+		ctrl.Finish()
+	}()
+
+	family := kv.NewMockFamily(ctrl)
+	flusher := kv.NewMockFlusher(ctrl)
+	family.EXPECT().NewFlusher().Return(flusher).AnyTimes()
+	flusher.EXPECT().Release().AnyTimes()
+	flusher.EXPECT().Sequence(gomock.Any(), gomock.Any()).AnyTimes()
+	flusher.EXPECT().MemDBCreatedTime(int64(0)).AnyTimes()
+	flusher.EXPECT().Outputs().Return([]table.FileNumber{1}).AnyTimes()
+	snapshot := version.NewMockSnapshot(ctrl)
+	family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	snapshot.EXPECT().Close().AnyTimes()
+	reader := table.NewMockReader(ctrl)
+	reader.EXPECT().Path().Return("1.sst").AnyTimes()
+	snapshot.EXPECT().GetReader(table.FileNumber(1)).Return(reader, nil).AnyTimes()
+	reader.EXPECT().Get(uint32(1)).Return(mockFlushedMetricBlock(), nil).AnyTimes()
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	db.EXPECT().Name().Return("db").AnyTimes()
+	db.EXPECT().GetOption().Return(&option.DatabaseOption{FlushVerify: &option.FlushVerifyOption{Enabled: true}}).AnyTimes()
+	dataFlusher := metricsdata.NewMockFlusher(ctrl)
+	dataFlusher.EXPECT().SetCodec(gomock.Any()).AnyTimes()
+	newMetricDataFlusher = func(kvFlusher kv.Flusher) (metricsdata.Flusher, error) {
+		return dataFlusher, nil
+	}
+
+	acked := 0
+	f := &dataFamily{
+		shard:  shard,
+		family: family,
+		leaders: map[int32]*leaderWriter{
+			1: newLeaderWriter(10),
+		},
+		persistSeq: make(map[int32]atomic.Int64),
+		callbacks: map[int32][]func(seq int64){
+			1: {func(seq int64) { acked++ }},
+		},
+		statistics: metrics.NewFamilyStatistics("data", "1", "family"),
+		logger:     logger.GetLogger("TSDB", "Test"),
+	}
+	newMemDB := func(points []memdb.Point) *memdb.MockMemoryDatabase {
+		memDB := memdb.NewMockMemoryDatabase(ctrl)
+		memDB.EXPECT().NumOfMetrics().Return(1)
+		memDB.EXPECT().MarkReadOnly()
+		memDB.EXPECT().FlushFamilyTo(gomock.Any()).Return(nil)
+		memDB.EXPECT().SamplePoints(option.DefaultFlushVerifySampleRate).Return(points)
+		memDB.EXPECT().MemSize().AnyTimes()
+		f.mutableMemDB = memDB
+		return memDB
+	}
+	// flushed data matches memory database
+	memDB := newMemDB([]memdb.Point{{MetricID: 1, SeriesID: 10, FieldID: 1, Slot: 5, Value: 10, HasValue: true}})
+	memDB.EXPECT().Close().Return(nil)
+	assert.NoError(t, f.Flush())
+	assert.Equal(t, 1, acked)
+	assert.Nil(t, f.immutableMemDB)
+	assert.True(t, f.IsHealthy())
+
+	// flushed data mismatches memory database, memory database retained without acknowledging sequences
+	memDB = newMemDB([]memdb.Point{{MetricID: 1, SeriesID: 10, FieldID: 1, Slot: 5, Value: 11, HasValue: true}})
+	assert.ErrorIs(t, f.Flush(), errFlushedDataMismatch)
+	assert.Equal(t, 1, acked)
+	assert.Equal(t, memDB, f.immutableMemDB)
+	assert.False(t, f.IsHealthy())
+	assert.True(t, f.flushVerifyFailed.Load())
+}
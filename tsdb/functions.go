@@ -18,8 +18,11 @@
 package tsdb
 
 import (
+	"os"
+
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/tsdb/indexdb"
 	"github.com/lindb/lindb/tsdb/memdb"
 	"github.com/lindb/lindb/tsdb/metadb"
@@ -46,4 +49,8 @@ var (
 	newDataFamilyFunc      = newDataFamily
 	newMetricDataFlusher   = metricsdata.NewFlusher
 	closeFamilyFunc        = closeFamily
+	checkFamilyFormatFunc  = checkFamilyFormat
+	renameFunc             = os.Rename
+	warmUpMetricFunc       = warmUpMetric
+	nowFunc                = timeutil.Now
 )
@@ -0,0 +1,86 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// warmUpMetric touches the index of metric in shard(tag value ids of tag keys and the inverted postings),
+// then the footers of metric blocks in the newest family of shard, so that the first queries after restart
+// need not wait for the cold index/blocks read from disk.
+// throttle is invoked with the bytes touched after each tag key and family, warming up stops if it returns err.
+func warmUpMetric(
+	ctx context.Context,
+	shard Shard,
+	metricID metric.ID,
+	throttle func(ctx context.Context, bytes int) error,
+) error {
+	metadata := shard.Database().Metadata()
+	tagKeys, err := metadata.MetadataDatabase().GetAllTagKeysByMetricID(metricID)
+	if err != nil {
+		return err
+	}
+	indexDB := shard.IndexDatabase()
+	for _, tagKey := range tagKeys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		tagValueIDs, err := metadata.TagMetadata().GetTagValueIDsForTag(tagKey.ID)
+		if err != nil {
+			if errors.Is(err, constants.ErrNotFound) {
+				continue
+			}
+			return err
+		}
+		seriesIDs, err := indexDB.GetSeriesIDsByTagValueIDs(tagKey.ID, tagValueIDs)
+		if err != nil {
+			return err
+		}
+		if err := throttle(ctx, int(tagValueIDs.GetSizeInBytes()+seriesIDs.GetSizeInBytes())); err != nil {
+			return err
+		}
+	}
+	family := newestFamily(shard)
+	if family == nil {
+		return nil
+	}
+	seriesIDs, err := family.GetSeriesIDs(metricID)
+	if err != nil {
+		return err
+	}
+	return throttle(ctx, int(seriesIDs.GetSizeInBytes()))
+}
+
+// newestFamily returns the newest opened family of writable interval for shard, returns nil if not found.
+func newestFamily(shard Shard) (newest DataFamily) {
+	interval := shard.CurrentInterval()
+	for _, family := range GetFamilyManager().GetFamiliesByShard(shard) {
+		if family.Interval() != interval {
+			continue
+		}
+		if newest == nil || family.FamilyTime() > newest.FamilyTime() {
+			newest = family
+		}
+	}
+	return newest
+}
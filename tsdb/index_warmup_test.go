@@ -0,0 +1,160 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/tag"
+	"github.com/lindb/lindb/tsdb/indexdb"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+func TestWarmUpMetric_shard(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	metadata := metadb.NewMockMetadata(ctrl)
+	metadataDB := metadb.NewMockMetadataDatabase(ctrl)
+	tagMetadata := metadb.NewMockTagMetadata(ctrl)
+	indexDB := indexdb.NewMockIndexDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	shard.EXPECT().IndexDatabase().Return(indexDB).AnyTimes()
+	shard.EXPECT().Indicator().Return("db/warm-up").AnyTimes()
+	shard.EXPECT().CurrentInterval().Return(timeutil.Interval(timeutil.OneSecond * 10)).AnyTimes()
+	db.EXPECT().Metadata().Return(metadata).AnyTimes()
+	metadata.EXPECT().MetadataDatabase().Return(metadataDB).AnyTimes()
+	metadata.EXPECT().TagMetadata().Return(tagMetadata).AnyTimes()
+
+	// families of writable interval and rollup interval
+	newFamily := func(name string, interval timeutil.Interval, familyTime int64) *MockDataFamily {
+		family := NewMockDataFamily(ctrl)
+		family.EXPECT().Indicator().Return(name).AnyTimes()
+		family.EXPECT().Shard().Return(shard).AnyTimes()
+		family.EXPECT().Interval().Return(interval).AnyTimes()
+		family.EXPECT().FamilyTime().Return(familyTime).AnyTimes()
+		return family
+	}
+	oldFamily := newFamily("db/warm-up/old", timeutil.Interval(timeutil.OneSecond*10), 1)
+	newest := newFamily("db/warm-up/newest", timeutil.Interval(timeutil.OneSecond*10), 2)
+	rollupFamily := newFamily("db/warm-up/rollup", timeutil.Interval(timeutil.OneMinute*5), 3)
+	defer func() {
+		GetFamilyManager().RemoveFamily(oldFamily)
+		GetFamilyManager().RemoveFamily(newest)
+		GetFamilyManager().RemoveFamily(rollupFamily)
+	}()
+
+	noThrottle := func(_ context.Context, _ int) error { return nil }
+	cases := []struct {
+		name     string
+		prepare  func()
+		throttle func(ctx context.Context, bytes int) error
+		wantErr  bool
+	}{
+		{
+			name: "get tag keys failure",
+			prepare: func() {
+				metadataDB.EXPECT().GetAllTagKeysByMetricID(gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "get tag value ids failure",
+			prepare: func() {
+				metadataDB.EXPECT().GetAllTagKeysByMetricID(gomock.Any()).Return(tag.Metas{{ID: 1}}, nil)
+				tagMetadata.EXPECT().GetTagValueIDsForTag(tag.KeyID(1)).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "get series ids failure",
+			prepare: func() {
+				metadataDB.EXPECT().GetAllTagKeysByMetricID(gomock.Any()).Return(tag.Metas{{ID: 1}}, nil)
+				tagMetadata.EXPECT().GetTagValueIDsForTag(tag.KeyID(1)).Return(roaring.BitmapOf(1), nil)
+				indexDB.EXPECT().GetSeriesIDsByTagValueIDs(tag.KeyID(1), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "throttle failure",
+			prepare: func() {
+				metadataDB.EXPECT().GetAllTagKeysByMetricID(gomock.Any()).Return(tag.Metas{{ID: 1}}, nil)
+				tagMetadata.EXPECT().GetTagValueIDsForTag(tag.KeyID(1)).Return(roaring.BitmapOf(1), nil)
+				indexDB.EXPECT().GetSeriesIDsByTagValueIDs(tag.KeyID(1), gomock.Any()).Return(roaring.BitmapOf(1), nil)
+			},
+			throttle: func(_ context.Context, _ int) error { return context.Canceled },
+			wantErr:  true,
+		},
+		{
+			name: "warm up index without family",
+			prepare: func() {
+				metadataDB.EXPECT().GetAllTagKeysByMetricID(gomock.Any()).Return(tag.Metas{{ID: 1}, {ID: 2}}, nil)
+				tagMetadata.EXPECT().GetTagValueIDsForTag(tag.KeyID(1)).Return(nil, constants.ErrNotFound)
+				tagMetadata.EXPECT().GetTagValueIDsForTag(tag.KeyID(2)).Return(roaring.BitmapOf(1), nil)
+				indexDB.EXPECT().GetSeriesIDsByTagValueIDs(tag.KeyID(2), gomock.Any()).Return(roaring.BitmapOf(1), nil)
+			},
+		},
+		{
+			name: "get series ids of newest family failure",
+			prepare: func() {
+				GetFamilyManager().AddFamily(oldFamily)
+				GetFamilyManager().AddFamily(newest)
+				GetFamilyManager().AddFamily(rollupFamily)
+				metadataDB.EXPECT().GetAllTagKeysByMetricID(gomock.Any()).Return(nil, nil)
+				newest.EXPECT().GetSeriesIDs(gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "warm up newest family",
+			prepare: func() {
+				metadataDB.EXPECT().GetAllTagKeysByMetricID(gomock.Any()).Return(nil, nil)
+				newest.EXPECT().GetSeriesIDs(gomock.Any()).Return(roaring.BitmapOf(1, 2), nil)
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.prepare != nil {
+				tt.prepare()
+			}
+			throttle := tt.throttle
+			if throttle == nil {
+				throttle = noThrottle
+			}
+			err := warmUpMetric(context.TODO(), shard, 1, throttle)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -20,8 +20,10 @@ package indexdb
 import (
 	"context"
 	"errors"
+	"os"
 	"sync"
 
+	"github.com/lindb/common/pkg/fasttime"
 	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/config"
@@ -38,6 +40,10 @@ import (
 // for testing
 var (
 	createBackendFn = newIDMappingBackend
+	nowFunc         = fasttime.UnixMilliseconds
+	readFileFn      = os.ReadFile
+	writeFileFn     = os.WriteFile
+	renameFn        = os.Rename
 )
 
 // indexDatabase implements IndexDatabase interface
@@ -50,9 +56,14 @@ type indexDatabase struct {
 	metadata         metadb.Metadata               // the metadata for generating ID of metric, field
 	index            InvertedIndex
 
+	activity  *seriesActivity               // series written per day for series gc
+	reclaimed map[metric.ID]*roaring.Bitmap // key: metric id, value: series ids reclaimed by series gc
+	gcCursor  metric.ID                     // metric id where next round of series gc starts
+
 	statistics *metrics.IndexDBStatistics
 
-	rwMutex sync.RWMutex // lock of create metric index
+	rwMutex      sync.RWMutex // lock of create metric index
+	gcStateMutex sync.Mutex   // lock of persisting series gc state
 }
 
 // NewIndexDatabase creates a new index database
@@ -73,9 +84,15 @@ func NewIndexDatabase(ctx context.Context, parent string, metadata metadb.Metada
 		metadata:         metadata,
 		metricID2Mapping: make(map[metric.ID]MetricIDMapping),
 		index:            newInvertedIndex(metadata, forwardFamily, invertedFamily),
+		activity:         newSeriesActivity(),
+		reclaimed:        make(map[metric.ID]*roaring.Bitmap),
 		statistics:       metrics.NewIndexDBStatistics(metadata.DatabaseName()),
 	}
-
+	if err := db.loadGCState(); err != nil {
+		cancel()
+		_ = backend.Close()
+		return nil, err
+	}
 	return db, nil
 }
 
@@ -97,6 +114,21 @@ func (db *indexDatabase) GetOrCreateSeriesID(metricID metric.ID, tagsHash uint64
 	db.rwMutex.Lock()
 	defer db.rwMutex.Unlock()
 
+	seriesID, isCreated, err = db.getOrCreateSeriesID(metricID, tagsHash)
+	if err != nil {
+		return series.EmptySeriesID, false, err
+	}
+	if db.trackSeries(metricID, seriesID) {
+		// series is reclaimed by series gc, need rebuild inverted index
+		isCreated = true
+	}
+	return seriesID, isCreated, nil
+}
+
+// getOrCreateSeriesID gets series by tags hash, if not exist generate new series id.
+// NOTE: must hold the lock of index database.
+func (db *indexDatabase) getOrCreateSeriesID(metricID metric.ID, tagsHash uint64,
+) (seriesID uint32, isCreated bool, err error) {
 	metricIDMapping, ok := db.metricID2Mapping[metricID]
 	if ok {
 		// get series id from memory cache
@@ -196,7 +228,10 @@ func (db *indexDatabase) Flush() error {
 	}
 	db.rwMutex.Unlock()
 
-	return db.index.Flush()
+	if err := db.index.Flush(); err != nil {
+		return err
+	}
+	return db.saveGCState()
 }
 
 // Close closes the database, releases the resources
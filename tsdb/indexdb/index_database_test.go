@@ -122,6 +122,7 @@ func TestIndexDatabase_BuildInvertIndex(t *testing.T) {
 	db.BuildInvertIndex("ns", "cpu", mockTagKeyValueIterator(map[string]string{"ip": "1.1.1.1"}), 10)
 
 	index.EXPECT().Flush().Return(nil)
+	index.EXPECT().getTombstones().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
@@ -138,6 +139,8 @@ func TestIndexDatabase_GetOrCreateSeriesID(t *testing.T) {
 		metricID2Mapping: map[metric.ID]MetricIDMapping{
 			2: mapping,
 		},
+		activity:  newSeriesActivity(),
+		reclaimed: make(map[metric.ID]*roaring.Bitmap),
 	}
 
 	cases := []struct {
@@ -297,6 +300,24 @@ func TestIndexDatabase_GetOrCreateSeriesID(t *testing.T) {
 				err:      nil,
 			},
 		},
+		{
+			name:     "get series reclaimed by gc",
+			metricID: 2,
+			tagsHash: 4,
+			prepare: func() {
+				db.reclaimed[2] = roaring.BitmapOf(4)
+				mapping.EXPECT().GetSeriesID(gomock.Any()).Return(uint32(4), true)
+			},
+			out: struct {
+				seriesID uint32
+				isCreate bool
+				err      error
+			}{
+				seriesID: uint32(4),
+				isCreate: true,
+				err:      nil,
+			},
+		},
 		{
 			name:     "load mapping failure",
 			metricID: 3,
@@ -347,6 +368,11 @@ func TestIndexDatabase_GetOrCreateSeriesID(t *testing.T) {
 			assert.Equal(t, tt.out.err, err)
 		})
 	}
+	// written series are tracked, reclaimed series are revived
+	assert.Equal(t, roaring.BitmapOf(2, 3), db.activity.metrics)
+	assert.True(t, db.activity.writtenSince(2, 0).Contains(4))
+	assert.True(t, db.activity.writtenSince(2, 0).Contains(333))
+	assert.Empty(t, db.reclaimed)
 }
 
 func TestIndexDatabase_GetGroupingContext(t *testing.T) {
@@ -374,6 +400,7 @@ func TestIndexDatabase_GetGroupingContext(t *testing.T) {
 	assert.Nil(t, shardExecuteCtx.GroupingContext)
 
 	index.EXPECT().Flush().Return(nil)
+	index.EXPECT().getTombstones().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
@@ -422,6 +449,7 @@ func TestIndexDatabase_GetSeriesIDs(t *testing.T) {
 	assert.NotNil(t, seriesIDs)
 
 	index.EXPECT().Flush().Return(nil)
+	index.EXPECT().getTombstones().Return(nil)
 	err = db.Close()
 	assert.NoError(t, err)
 }
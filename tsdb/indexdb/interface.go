@@ -19,6 +19,7 @@ package indexdb
 
 import (
 	"io"
+	"time"
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/pkg/logger"
@@ -53,6 +54,9 @@ type IndexDatabase interface {
 	// BuildInvertIndex builds the inverted index for tag value => series ids,
 	// the tags is considered as an empty key-value pair while tags is nil.
 	BuildInvertIndex(namespace, metricName string, tagIterator *metric.KeyValueIterator, seriesID uint32)
+	// GCSeries reclaims the series which are not written within horizon and not present in any retained family,
+	// at most limit series are reclaimed each round, returns the number of reclaimed series.
+	GCSeries(horizon time.Duration, limit int, presentFn PresentSeriesFunc) (reclaimed int, err error)
 	// Flush flushes index data to disk
 	Flush() error
 }
@@ -58,6 +58,12 @@ type InvertedIndex interface {
 	buildInvertIndex(namespace, metricName string, tagIterator *metric.KeyValueIterator, seriesID uint32)
 	// Flush flushes the inverted-index of tag value id=>series ids under tag key
 	Flush() error
+	// tombstone marks the series ids under tag keys as reclaimed, which are excluded from query.
+	tombstone(tagKeyIDs []tag.KeyID, seriesIDs *roaring.Bitmap)
+	// getTombstones returns the copy of reclaimed series ids under each tag key.
+	getTombstones() map[tag.KeyID]*roaring.Bitmap
+	// restoreTombstones restores the reclaimed series ids under each tag key.
+	restoreTombstones(tombstones map[tag.KeyID]*roaring.Bitmap)
 }
 
 type invertedIndex struct {
@@ -65,8 +71,9 @@ type invertedIndex struct {
 	forwardFamily  kv.Family // store tag value forward index(series id=>tag value id)
 	metadata       metadb.Metadata
 
-	mutable   *TagIndexStore
-	immutable *TagIndexStore
+	mutable    *TagIndexStore
+	immutable  *TagIndexStore
+	tombstones map[tag.KeyID]*roaring.Bitmap // key: tag key id, value: series ids reclaimed by series gc
 
 	rwMutex sync.RWMutex
 }
@@ -77,6 +84,7 @@ func newInvertedIndex(metadata metadb.Metadata, forwardFamily, invertedFamily kv
 		forwardFamily:  forwardFamily,
 		metadata:       metadata,
 		mutable:        NewTagIndexStore(),
+		tombstones:     make(map[tag.KeyID]*roaring.Bitmap),
 	}
 }
 
@@ -102,6 +110,7 @@ func (index *invertedIndex) GetSeriesIDsByTagValueIDs(tagKeyID tag.KeyID, tagVal
 	}); err != nil {
 		return nil, err
 	}
+	index.excludeTombstones(tagKeyID, result)
 	return result, nil
 }
 
@@ -139,6 +148,7 @@ func (index *invertedIndex) getSeriesIDsForTag(tagKeyID tag.KeyID, snapshot vers
 		}
 		result.Or(seriesIDs)
 	}
+	index.excludeTombstones(tagKeyID, result)
 	return result, nil
 }
 
@@ -269,6 +279,58 @@ func (index *invertedIndex) buildInvertIndex(namespace, metricName string, tagIt
 			continue
 		}
 		tagIndex.buildInvertedIndex(tagValueID, seriesID)
+		// revive series if it is reclaimed before
+		if seriesIDs, ok := index.tombstones[tagKeyID]; ok {
+			seriesIDs.Remove(seriesID)
+			if seriesIDs.IsEmpty() {
+				delete(index.tombstones, tagKeyID)
+			}
+		}
+	}
+}
+
+// tombstone marks the series ids under tag keys as reclaimed, which are excluded from query.
+func (index *invertedIndex) tombstone(tagKeyIDs []tag.KeyID, seriesIDs *roaring.Bitmap) {
+	index.rwMutex.Lock()
+	defer index.rwMutex.Unlock()
+
+	for _, tagKeyID := range tagKeyIDs {
+		tombstones, ok := index.tombstones[tagKeyID]
+		if !ok {
+			tombstones = roaring.New()
+			index.tombstones[tagKeyID] = tombstones
+		}
+		tombstones.Or(seriesIDs)
+	}
+}
+
+// getTombstones returns the copy of reclaimed series ids under each tag key.
+func (index *invertedIndex) getTombstones() map[tag.KeyID]*roaring.Bitmap {
+	index.rwMutex.RLock()
+	defer index.rwMutex.RUnlock()
+
+	result := make(map[tag.KeyID]*roaring.Bitmap, len(index.tombstones))
+	for tagKeyID, seriesIDs := range index.tombstones {
+		result[tagKeyID] = seriesIDs.Clone()
+	}
+	return result
+}
+
+// restoreTombstones restores the reclaimed series ids under each tag key.
+func (index *invertedIndex) restoreTombstones(tombstones map[tag.KeyID]*roaring.Bitmap) {
+	index.rwMutex.Lock()
+	defer index.rwMutex.Unlock()
+
+	index.tombstones = tombstones
+}
+
+// excludeTombstones removes the reclaimed series ids under tag key from result.
+func (index *invertedIndex) excludeTombstones(tagKeyID tag.KeyID, result *roaring.Bitmap) {
+	index.rwMutex.RLock()
+	defer index.rwMutex.RUnlock()
+
+	if tombstones, ok := index.tombstones[tagKeyID]; ok {
+		result.AndNot(tombstones)
 	}
 }
 
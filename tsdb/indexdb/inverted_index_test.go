@@ -178,6 +178,45 @@ func TestInvertedIndex_GetSeriesIDsForTag(t *testing.T) {
 	assert.Equal(t, roaring.BitmapOf(1, 2, 3), seriesIDs)
 }
 
+func TestInvertedIndex_tombstone(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	index := prepareInvertedIndex(ctrl)
+	family := kv.NewMockFamily(ctrl)
+	idx := index.(*invertedIndex)
+	idx.forwardFamily = family
+	idx.invertedFamily = family
+	snapshot := version.NewMockSnapshot(ctrl)
+	snapshot.EXPECT().Close().AnyTimes()
+	snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, nil).AnyTimes()
+	family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+
+	// reclaimed series are excluded from query
+	index.tombstone([]tag.KeyID{1, 2}, roaring.BitmapOf(2))
+	seriesIDs, err := index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1))
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1), seriesIDs)
+	seriesIDs, err = index.GetSeriesIDsForTags([]tag.KeyID{1, 2})
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1), seriesIDs)
+	assert.Equal(t, map[tag.KeyID]*roaring.Bitmap{1: roaring.BitmapOf(2), 2: roaring.BitmapOf(2)}, index.getTombstones())
+
+	// series is revived when building inverted index again
+	tagMetadata := idx.metadata.TagMetadata().(*metadb.MockTagMetadata)
+	tagMetadata.EXPECT().GenTagValueID(tag.KeyID(1), "1.1.1.1").Return(uint32(1), nil)
+	index.buildInvertIndex("ns", "name", mockTagKeyValueIterator(map[string]string{"host": "1.1.1.1"}), 2)
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1))
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(1, 2), seriesIDs)
+	assert.Equal(t, map[tag.KeyID]*roaring.Bitmap{2: roaring.BitmapOf(2)}, index.getTombstones())
+
+	index.restoreTombstones(map[tag.KeyID]*roaring.Bitmap{1: roaring.BitmapOf(1)})
+	seriesIDs, err = index.GetSeriesIDsByTagValueIDs(1, roaring.BitmapOf(1))
+	assert.NoError(t, err)
+	assert.Equal(t, roaring.BitmapOf(2), seriesIDs)
+}
+
 func TestInvertedIndex_GetGroupingContext(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -0,0 +1,311 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"errors"
+	"os"
+	"path"
+	"time"
+
+	"github.com/lindb/roaring"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/series/tag"
+)
+
+// seriesGCStateFile is the file name of series gc state under index database dir.
+const seriesGCStateFile = "series_gc.json"
+
+// PresentSeriesFunc returns the series ids(subset of given series ids) which present in any retained family.
+type PresentSeriesFunc func(metricID metric.ID, seriesIDs *roaring.Bitmap) (*roaring.Bitmap, error)
+
+// seriesActivity tracks the series written per day for each metric,
+// which is the coarse last-written time of series.
+type seriesActivity struct {
+	metrics *roaring.Bitmap                         // metric ids which have series written
+	days    map[int64]map[metric.ID]*roaring.Bitmap // day => metric id => written series ids
+}
+
+// newSeriesActivity creates a series activity tracker.
+func newSeriesActivity() *seriesActivity {
+	return &seriesActivity{
+		metrics: roaring.New(),
+		days:    make(map[int64]map[metric.ID]*roaring.Bitmap),
+	}
+}
+
+// record records the series written at given day.
+func (a *seriesActivity) record(day int64, metricID metric.ID, seriesID uint32) {
+	metrics, ok := a.days[day]
+	if !ok {
+		metrics = make(map[metric.ID]*roaring.Bitmap)
+		a.days[day] = metrics
+	}
+	seriesIDs, ok := metrics[metricID]
+	if !ok {
+		seriesIDs = roaring.New()
+		metrics[metricID] = seriesIDs
+		a.metrics.Add(uint32(metricID))
+	}
+	seriesIDs.Add(seriesID)
+}
+
+// writtenSince returns the series ids of metric which are written since given day.
+func (a *seriesActivity) writtenSince(metricID metric.ID, day int64) *roaring.Bitmap {
+	result := roaring.New()
+	for d, metrics := range a.days {
+		if d < day {
+			continue
+		}
+		if seriesIDs, ok := metrics[metricID]; ok {
+			result.Or(seriesIDs)
+		}
+	}
+	return result
+}
+
+// expire removes the activity of days before given day.
+func (a *seriesActivity) expire(day int64) {
+	for d := range a.days {
+		if d < day {
+			delete(a.days, d)
+		}
+	}
+}
+
+// seriesGCState represents the persisted state of series gc.
+type seriesGCState struct {
+	Metrics    []byte                         `json:"metrics"`
+	Days       map[int64]map[metric.ID][]byte `json:"days"`
+	Reclaimed  map[metric.ID][]byte           `json:"reclaimed"`
+	Tombstones map[tag.KeyID][]byte           `json:"tombstones"`
+	Cursor     metric.ID                      `json:"cursor"`
+}
+
+// GCSeries reclaims the series which are not written within horizon and not present in any retained family,
+// the reclaimed series are removed from inverted index(tombstoned), if series is written again, it will be revived.
+// GC is incremental, at most limit series are reclaimed each round(limit <= 0 means no limit),
+// the next round continues from the metric where previous round stopped.
+func (db *indexDatabase) GCSeries(horizon time.Duration, limit int, presentFn PresentSeriesFunc) (reclaimed int, err error) {
+	startDay := timeutil.Truncate(nowFunc()-horizon.Milliseconds(), timeutil.OneDay)
+
+	db.rwMutex.Lock()
+	db.activity.expire(startDay)
+	metricIDs := db.activity.metrics.Clone()
+	cursor := db.gcCursor
+	db.rwMutex.Unlock()
+
+	// continue from the metric where previous round stopped,
+	// reset cursor if all metrics are checked.
+	next := metric.EmptyMetricID
+	it := metricIDs.Iterator()
+	it.AdvanceIfNeeded(uint32(cursor))
+	for it.HasNext() {
+		metricID := metric.ID(it.Next())
+		if db.ctx.Err() != nil || (limit > 0 && reclaimed >= limit) {
+			// interrupted or reach the limit of this round
+			next = metricID
+			break
+		}
+		n, err0 := db.gcMetricSeries(metricID, startDay, limit-reclaimed, presentFn)
+		if err0 != nil {
+			next = metricID
+			err = err0
+			break
+		}
+		reclaimed += n
+	}
+
+	db.rwMutex.Lock()
+	db.gcCursor = next
+	db.rwMutex.Unlock()
+
+	if reclaimed > 0 {
+		if err0 := db.saveGCState(); err0 != nil && err == nil {
+			err = err0
+		}
+	}
+	return reclaimed, err
+}
+
+// gcMetricSeries reclaims the unused series of metric, returns the number of reclaimed series.
+func (db *indexDatabase) gcMetricSeries(metricID metric.ID, startDay int64, limit int, presentFn PresentSeriesFunc) (int, error) {
+	tags, err := db.metadata.MetadataDatabase().GetAllTagKeysByMetricID(metricID)
+	if err != nil {
+		return 0, err
+	}
+	if len(tags) == 0 {
+		// metric without tags only has default series id
+		return 0, nil
+	}
+	tagKeyIDs := make([]tag.KeyID, len(tags))
+	for idx, tagMeta := range tags {
+		tagKeyIDs[idx] = tagMeta.ID
+	}
+	seriesIDs, err := db.index.GetSeriesIDsForTags(tagKeyIDs)
+	if err != nil {
+		return 0, err
+	}
+	db.rwMutex.RLock()
+	seriesIDs.AndNot(db.activity.writtenSince(metricID, startDay))
+	db.rwMutex.RUnlock()
+	if seriesIDs.IsEmpty() {
+		return 0, nil
+	}
+	// the series which present in retained family cannot be reclaimed, else historical query will lose them.
+	present, err := presentFn(metricID, seriesIDs)
+	if err != nil {
+		return 0, err
+	}
+	seriesIDs.AndNot(present)
+	if limit > 0 && seriesIDs.GetCardinality() > uint64(limit) {
+		seriesIDs = roaring.BitmapOf(seriesIDs.ToArray()[:limit]...)
+	}
+
+	db.rwMutex.Lock()
+	defer db.rwMutex.Unlock()
+	// keep the series which are written during checking
+	seriesIDs.AndNot(db.activity.writtenSince(metricID, startDay))
+	if seriesIDs.IsEmpty() {
+		return 0, nil
+	}
+	db.index.tombstone(tagKeyIDs, seriesIDs)
+	reclaimedSeriesIDs, ok := db.reclaimed[metricID]
+	if !ok {
+		reclaimedSeriesIDs = roaring.New()
+		db.reclaimed[metricID] = reclaimedSeriesIDs
+	}
+	reclaimedSeriesIDs.Or(seriesIDs)
+	n := int(seriesIDs.GetCardinality())
+	db.statistics.ReclaimedSeries.Add(float64(n))
+	return n, nil
+}
+
+// trackSeries records the series written, returns true if the series is reclaimed before(need rebuild inverted index).
+// NOTE: must hold the lock of index database.
+func (db *indexDatabase) trackSeries(metricID metric.ID, seriesID uint32) (revived bool) {
+	db.activity.record(timeutil.Truncate(nowFunc(), timeutil.OneDay), metricID, seriesID)
+
+	reclaimedSeriesIDs, ok := db.reclaimed[metricID]
+	if !ok || !reclaimedSeriesIDs.Contains(seriesID) {
+		return false
+	}
+	reclaimedSeriesIDs.Remove(seriesID)
+	if reclaimedSeriesIDs.IsEmpty() {
+		delete(db.reclaimed, metricID)
+	}
+	return true
+}
+
+// loadGCState loads the persisted state of series gc.
+func (db *indexDatabase) loadGCState() error {
+	data, err := readFileFn(path.Join(db.path, seriesGCStateFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	state := &seriesGCState{}
+	if err := encoding.JSONUnmarshal(data, state); err != nil {
+		return err
+	}
+	unmarshal := func(data []byte) (*roaring.Bitmap, error) {
+		bitmap := roaring.New()
+		if err := bitmap.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		return bitmap, nil
+	}
+	if len(state.Metrics) > 0 {
+		if db.activity.metrics, err = unmarshal(state.Metrics); err != nil {
+			return err
+		}
+	}
+	for day, metrics := range state.Days {
+		seriesOfMetrics := make(map[metric.ID]*roaring.Bitmap)
+		for metricID, data := range metrics {
+			if seriesOfMetrics[metricID], err = unmarshal(data); err != nil {
+				return err
+			}
+		}
+		db.activity.days[day] = seriesOfMetrics
+	}
+	for metricID, data := range state.Reclaimed {
+		if db.reclaimed[metricID], err = unmarshal(data); err != nil {
+			return err
+		}
+	}
+	tombstones := make(map[tag.KeyID]*roaring.Bitmap)
+	for tagKeyID, data := range state.Tombstones {
+		if tombstones[tagKeyID], err = unmarshal(data); err != nil {
+			return err
+		}
+	}
+	db.index.restoreTombstones(tombstones)
+	db.gcCursor = state.Cursor
+	return nil
+}
+
+// saveGCState persists the state of series gc, writes temp file then renames it.
+func (db *indexDatabase) saveGCState() error {
+	marshal := func(bitmap *roaring.Bitmap) []byte {
+		data, err := bitmap.ToBytes()
+		if err != nil {
+			// never happen, write into bytes buffer
+			indexLogger.Warn("marshal series ids failure", logger.Error(err))
+		}
+		return data
+	}
+	db.gcStateMutex.Lock()
+	defer db.gcStateMutex.Unlock()
+
+	db.rwMutex.RLock()
+	state := &seriesGCState{
+		Metrics:    marshal(db.activity.metrics),
+		Days:       make(map[int64]map[metric.ID][]byte),
+		Reclaimed:  make(map[metric.ID][]byte),
+		Tombstones: make(map[tag.KeyID][]byte),
+		Cursor:     db.gcCursor,
+	}
+	for day, metrics := range db.activity.days {
+		seriesOfMetrics := make(map[metric.ID][]byte)
+		for metricID, seriesIDs := range metrics {
+			seriesOfMetrics[metricID] = marshal(seriesIDs)
+		}
+		state.Days[day] = seriesOfMetrics
+	}
+	for metricID, seriesIDs := range db.reclaimed {
+		state.Reclaimed[metricID] = marshal(seriesIDs)
+	}
+	for tagKeyID, seriesIDs := range db.index.getTombstones() {
+		state.Tombstones[tagKeyID] = marshal(seriesIDs)
+	}
+	db.rwMutex.RUnlock()
+
+	file := path.Join(db.path, seriesGCStateFile)
+	tmp := file + ".tmp"
+	if err := writeFileFn(tmp, encoding.JSONMarshal(state), 0644); err != nil {
+		return err
+	}
+	return renameFn(tmp, file)
+}
@@ -0,0 +1,286 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package indexdb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/lindb/common/pkg/fasttime"
+	"github.com/lindb/roaring"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/series/tag"
+	"github.com/lindb/lindb/tsdb/metadb"
+)
+
+func TestSeriesActivity(t *testing.T) {
+	activity := newSeriesActivity()
+	activity.record(timeutil.OneDay, 1, 1)
+	activity.record(2*timeutil.OneDay, 1, 2)
+	activity.record(2*timeutil.OneDay, 2, 3)
+	assert.Equal(t, roaring.BitmapOf(1, 2), activity.metrics)
+	assert.Equal(t, roaring.BitmapOf(1, 2), activity.writtenSince(1, timeutil.OneDay))
+	assert.Equal(t, roaring.BitmapOf(2), activity.writtenSince(1, 2*timeutil.OneDay))
+	assert.Equal(t, roaring.New(), activity.writtenSince(3, 0))
+
+	activity.expire(2 * timeutil.OneDay)
+	assert.Len(t, activity.days, 1)
+	assert.Equal(t, roaring.BitmapOf(2), activity.writtenSince(1, 0))
+	// metrics are kept for gc after activity expired
+	assert.Equal(t, roaring.BitmapOf(1, 2), activity.metrics)
+}
+
+func TestIndexDatabase_GCSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	now := 10 * timeutil.OneDay
+	defer func() {
+		nowFunc = fasttime.UnixMilliseconds
+		ctrl.Finish()
+	}()
+	nowFunc = func() int64 { return now }
+
+	index := NewMockInvertedIndex(ctrl)
+	metaDB := metadb.NewMockMetadataDatabase(ctrl)
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().MetadataDatabase().Return(metaDB).AnyTimes()
+	index.EXPECT().getTombstones().Return(nil).AnyTimes()
+	newDB := func() *indexDatabase {
+		db := &indexDatabase{
+			path:       t.TempDir(),
+			ctx:        context.TODO(),
+			metadata:   meta,
+			index:      index,
+			activity:   newSeriesActivity(),
+			reclaimed:  make(map[metric.ID]*roaring.Bitmap),
+			statistics: metrics.NewIndexDBStatistics("test"),
+		}
+		// series 1 is written within horizon, series 2 is written before horizon
+		db.activity.record(now, 1, 1)
+		db.activity.record(now-5*timeutil.OneDay, 1, 2)
+		return db
+	}
+	present := func(_ metric.ID, _ *roaring.Bitmap) (*roaring.Bitmap, error) {
+		return roaring.BitmapOf(3), nil
+	}
+	horizon := 2 * 24 * time.Hour
+	var gcDB *indexDatabase
+
+	cases := []struct {
+		name      string
+		limit     int
+		presentFn PresentSeriesFunc
+		prepare   func(db *indexDatabase)
+		reclaimed *roaring.Bitmap
+		cursor    metric.ID
+		wantErr   bool
+	}{
+		{
+			name:      "reclaim series not written and not present in family",
+			presentFn: present,
+			prepare: func(_ *indexDatabase) {
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(tag.Metas{{ID: 10}}, nil)
+				index.EXPECT().GetSeriesIDsForTags([]tag.KeyID{10}).Return(roaring.BitmapOf(1, 2, 3, 4), nil)
+				index.EXPECT().tombstone([]tag.KeyID{10}, roaring.BitmapOf(2, 4))
+			},
+			reclaimed: roaring.BitmapOf(2, 4),
+		},
+		{
+			name:      "reclaim series with limit",
+			limit:     1,
+			presentFn: present,
+			prepare: func(db *indexDatabase) {
+				db.activity.record(now, 2, 1)
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(tag.Metas{{ID: 10}}, nil)
+				index.EXPECT().GetSeriesIDsForTags([]tag.KeyID{10}).Return(roaring.BitmapOf(1, 2, 3, 4), nil)
+				index.EXPECT().tombstone([]tag.KeyID{10}, roaring.BitmapOf(2))
+			},
+			reclaimed: roaring.BitmapOf(2),
+			cursor:    2,
+		},
+		{
+			name:      "metric without tags",
+			presentFn: present,
+			prepare: func(_ *indexDatabase) {
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(nil, nil)
+			},
+		},
+		{
+			name:      "all series are written or present",
+			presentFn: present,
+			prepare: func(_ *indexDatabase) {
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(tag.Metas{{ID: 10}}, nil)
+				index.EXPECT().GetSeriesIDsForTags([]tag.KeyID{10}).Return(roaring.BitmapOf(1), nil)
+			},
+		},
+		{
+			name:      "get tag keys failure",
+			presentFn: present,
+			prepare: func(_ *indexDatabase) {
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(nil, fmt.Errorf("err"))
+			},
+			cursor:  1,
+			wantErr: true,
+		},
+		{
+			name:      "get series ids failure",
+			presentFn: present,
+			prepare: func(_ *indexDatabase) {
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(tag.Metas{{ID: 10}}, nil)
+				index.EXPECT().GetSeriesIDsForTags([]tag.KeyID{10}).Return(nil, fmt.Errorf("err"))
+			},
+			cursor:  1,
+			wantErr: true,
+		},
+		{
+			name: "check present series failure, cannot reclaim",
+			presentFn: func(_ metric.ID, _ *roaring.Bitmap) (*roaring.Bitmap, error) {
+				return nil, fmt.Errorf("err")
+			},
+			prepare: func(_ *indexDatabase) {
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(tag.Metas{{ID: 10}}, nil)
+				index.EXPECT().GetSeriesIDsForTags([]tag.KeyID{10}).Return(roaring.BitmapOf(1, 2), nil)
+			},
+			cursor:  1,
+			wantErr: true,
+		},
+		{
+			name: "series written during checking present",
+			presentFn: func(_ metric.ID, _ *roaring.Bitmap) (*roaring.Bitmap, error) {
+				gcDB.activity.record(now, 1, 2)
+				return roaring.New(), nil
+			},
+			prepare: func(_ *indexDatabase) {
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(1)).Return(tag.Metas{{ID: 10}}, nil)
+				index.EXPECT().GetSeriesIDsForTags([]tag.KeyID{10}).Return(roaring.BitmapOf(2), nil)
+			},
+		},
+		{
+			name:      "gc interrupted",
+			presentFn: present,
+			prepare: func(db *indexDatabase) {
+				ctx, cancel := context.WithCancel(context.TODO())
+				cancel()
+				db.ctx = ctx
+			},
+			cursor: 1,
+		},
+		{
+			name:      "continue from cursor",
+			presentFn: present,
+			prepare: func(db *indexDatabase) {
+				db.activity.record(now, 2, 1)
+				db.gcCursor = 2
+				metaDB.EXPECT().GetAllTagKeysByMetricID(metric.ID(2)).Return(nil, nil)
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			db := newDB()
+			gcDB = db
+			if tt.prepare != nil {
+				tt.prepare(db)
+			}
+			reclaimed, err := db.GCSeries(horizon, tt.limit, tt.presentFn)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			if tt.reclaimed == nil {
+				assert.Zero(t, reclaimed)
+				assert.Empty(t, db.reclaimed)
+			} else {
+				assert.Equal(t, int(tt.reclaimed.GetCardinality()), reclaimed)
+				assert.Equal(t, tt.reclaimed, db.reclaimed[1])
+			}
+			assert.Equal(t, tt.cursor, db.gcCursor)
+		})
+	}
+}
+
+func TestIndexDatabase_GCState(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		readFileFn = os.ReadFile
+		writeFileFn = os.WriteFile
+		renameFn = os.Rename
+		ctrl.Finish()
+	}()
+	meta := metadb.NewMockMetadata(ctrl)
+	meta.EXPECT().DatabaseName().Return("test").AnyTimes()
+	newDB := func(dir string) *indexDatabase {
+		return &indexDatabase{
+			path:      dir,
+			index:     newInvertedIndex(meta, nil, nil),
+			activity:  newSeriesActivity(),
+			reclaimed: make(map[metric.ID]*roaring.Bitmap),
+		}
+	}
+	dir := t.TempDir()
+	db := newDB(dir)
+	// no state file
+	assert.NoError(t, db.loadGCState())
+
+	db.activity.record(timeutil.OneDay, 1, 1)
+	db.activity.record(2*timeutil.OneDay, 2, 2)
+	db.reclaimed[1] = roaring.BitmapOf(3, 4)
+	db.index.tombstone([]tag.KeyID{5, 6}, roaring.BitmapOf(3, 4))
+	db.gcCursor = 2
+	assert.NoError(t, db.saveGCState())
+
+	db2 := newDB(dir)
+	assert.NoError(t, db2.loadGCState())
+	assert.Equal(t, db.activity.metrics.ToArray(), db2.activity.metrics.ToArray())
+	assert.Len(t, db2.activity.days, 2)
+	assert.Equal(t, []uint32{1}, db2.activity.writtenSince(1, 0).ToArray())
+	assert.Equal(t, []uint32{2}, db2.activity.writtenSince(2, 2*timeutil.OneDay).ToArray())
+	assert.Equal(t, []uint32{3, 4}, db2.reclaimed[1].ToArray())
+	tombstones := db2.index.getTombstones()
+	assert.Len(t, tombstones, 2)
+	assert.Equal(t, []uint32{3, 4}, tombstones[5].ToArray())
+	assert.Equal(t, metric.ID(2), db2.gcCursor)
+
+	// write state failure
+	writeFileFn = func(_ string, _ []byte, _ os.FileMode) error {
+		return fmt.Errorf("err")
+	}
+	assert.Error(t, db.saveGCState())
+	// read state failure
+	readFileFn = func(_ string) ([]byte, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, newDB(dir).loadGCState())
+	readFileFn = os.ReadFile
+	// corrupt state
+	assert.NoError(t, os.WriteFile(path.Join(dir, seriesGCStateFile), []byte("abc"), 0644))
+	assert.Error(t, newDB(dir).loadGCState())
+	assert.NoError(t, os.WriteFile(path.Join(dir, seriesGCStateFile), []byte(`{"metrics":"YWJj"}`), 0644))
+	assert.Error(t, newDB(dir).loadGCState())
+}
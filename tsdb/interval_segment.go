@@ -20,6 +20,7 @@ package tsdb
 import (
 	"fmt"
 	"path"
+	"strings"
 	"sync"
 
 	"github.com/lindb/lindb/pkg/logger"
@@ -41,6 +42,16 @@ type IntervalSegment interface {
 	TTL() error
 	// EvictSegment evicts segment which long term no read operation.
 	EvictSegment()
+	// SetCompactionPolicy sets the compaction policy of loaded segments, which takes effect for new compactions.
+	SetCompactionPolicy(policy option.CompactionPolicy)
+	// SetRollup sets the source interval and target intervals of rollup for loaded segments,
+	// which takes effect for new flushed files.
+	SetRollup(source timeutil.Interval, rollup []timeutil.Interval)
+
+	// recoveryTasks returns the tasks for opening the segments which are not expired after startup.
+	recoveryTasks() []*recoveryTask
+	// walkFamilies walks all families of segments which are not expired, returns err if family cannot be loaded.
+	walkFamilies(fn func(family DataFamily) error) error
 }
 
 // intervalSegment implements IntervalSegment interface
@@ -57,7 +68,8 @@ type intervalSegment struct {
 
 // newIntervalSegment create interval segment based on interval/type/path etc.
 func newIntervalSegment(shard Shard, interval option.Interval) (segment IntervalSegment, err error) {
-	dir := ShardSegmentPath(shard.Database().Name(), shard.ShardID(), interval.Interval)
+	db := shard.Database()
+	dir := ShardSegmentPath(engineContextOf(db).rootDir(), db.Name(), shard.ShardID(), interval.Interval)
 	err = mkDirIfNotExist(dir)
 	if err != nil {
 		return nil, err
@@ -165,11 +177,33 @@ func (s *intervalSegment) TTL() error {
 	now := timeutil.Now()
 	expireInterval := s.interval.Retention.Int64()
 
-	return s.walkSegment(func(segmentName string, segmentTime int64) {
+	if err := s.walkSegment(func(segmentName string, segmentTime int64) {
 		// add 2 hours buffer, for some cases stop write.
 		if now-segmentTime > expireInterval+2*timeutil.OneHour {
 			s.dropSegment(segmentName)
 		}
+	}); err != nil {
+		return err
+	}
+	return s.truncateFields()
+}
+
+// truncateFields rewrites the families which contain the field data out of field level retention.
+func (s *intervalSegment) truncateFields() error {
+	retention := s.shard.Database().getFieldRetention()
+	if retention == nil || !retention.hasRules() {
+		return nil
+	}
+	return s.walkFamilies(func(family DataFamily) error {
+		kvFamily := family.Family()
+		if kvFamily == nil {
+			return nil
+		}
+		if retention.truncate(s.shard.ShardID(), family.TimeRange().End, kvFamily.CompactAll) {
+			s.logger.Info("truncate expired fields of family",
+				logger.String("path", s.dir), logger.String("family", family.Indicator()))
+		}
+		return nil
 	})
 }
 
@@ -191,6 +225,104 @@ func (s *intervalSegment) EvictSegment() {
 	}
 }
 
+// SetCompactionPolicy sets the compaction policy of loaded segments, which takes effect for new compactions.
+func (s *intervalSegment) SetCompactionPolicy(policy option.CompactionPolicy) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, segment := range s.segments {
+		segment.SetCompactionPolicy(policy)
+	}
+}
+
+// SetRollup sets the source interval and target intervals of rollup for loaded segments,
+// which takes effect for new flushed files.
+func (s *intervalSegment) SetRollup(source timeutil.Interval, rollup []timeutil.Interval) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, segment := range s.segments {
+		segment.SetRollup(source, rollup)
+	}
+}
+
+// recoveryTasks returns the tasks for opening the segments which are not expired after startup.
+func (s *intervalSegment) recoveryTasks() (tasks []*recoveryTask) {
+	now := timeutil.Now()
+	expireInterval := s.interval.Retention.Int64()
+	if err := s.walkSegment(func(segmentName string, segmentTime int64) {
+		if now-segmentTime >= expireInterval {
+			// segment is expired, no need to open
+			return
+		}
+		tasks = append(tasks, &recoveryTask{
+			indicator: path.Join(s.dir, segmentName),
+			time:      segmentTime,
+			run: func() (int, error) {
+				return s.recoverSegment(segmentName)
+			},
+			quarantine: func() (string, error) {
+				return s.quarantineSegment(segmentName)
+			},
+		})
+	}); err != nil {
+		s.logger.Warn("list segment failure when recover segments",
+			logger.String("path", s.dir), logger.Error(err))
+	}
+	return tasks
+}
+
+// walkFamilies walks all families of segments which are not expired, returns err if family cannot be loaded.
+func (s *intervalSegment) walkFamilies(fn func(family DataFamily) error) error {
+	now := timeutil.Now()
+	expireInterval := s.interval.Retention.Int64()
+	var segmentNames []string
+	if err := s.walkSegment(func(segmentName string, segmentTime int64) {
+		if now-segmentTime >= expireInterval {
+			// segment is expired, need to ignore
+			return
+		}
+		segmentNames = append(segmentNames, segmentName)
+	}); err != nil {
+		return err
+	}
+	for _, segmentName := range segmentNames {
+		segment, err := s.getOrLoadSegment(segmentName)
+		if err != nil {
+			return err
+		}
+		if err := segment.walkFamilies(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recoverSegment opens the segment and the families of it, returns the number of families.
+func (s *intervalSegment) recoverSegment(segmentName string) (int, error) {
+	segment, err := s.getOrLoadSegment(segmentName)
+	if err != nil {
+		return 0, err
+	}
+	return segment.recoverFamilies()
+}
+
+// quarantineSegment closes the segment which cannot be opened, then moves it aside, returns the new path.
+func (s *intervalSegment) quarantineSegment(segmentName string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if segment, ok := s.segments[segmentName]; ok {
+		segment.Close()
+		delete(s.segments, segmentName)
+	}
+	quarantinedPath := path.Join(s.dir, fmt.Sprintf("%s%s%d", segmentName, quarantineSuffix, timeutil.Now()))
+	if err := renameFunc(path.Join(s.dir, segmentName), quarantinedPath); err != nil {
+		return "", err
+	}
+	return quarantinedPath, nil
+}
+
 // walkSegment lists all segment under current interval segment dir.
 func (s *intervalSegment) walkSegment(fn func(segmentName string, segmentTime int64)) error {
 	segmentNames, err := listDir(s.dir)
@@ -198,6 +330,10 @@ func (s *intervalSegment) walkSegment(fn func(segmentName string, segmentTime in
 		return err
 	}
 	for _, segmentName := range segmentNames {
+		if strings.Contains(segmentName, quarantineSuffix) {
+			// ignore quarantined segment
+			continue
+		}
 		calc := s.interval.Interval.Calculator()
 		baseTime, err := calc.ParseSegmentTime(segmentName)
 		if err != nil {
@@ -19,11 +19,14 @@ package tsdb
 
 import (
 	"fmt"
+	"os"
+	"path"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
@@ -307,6 +310,10 @@ func TestIntervalSegment_TTL(t *testing.T) {
 	segmentDir := timeutil.FormatTimestamp(now, "20060102")
 
 	segment := NewMockSegment(ctrl)
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	db.EXPECT().getFieldRetention().Return(nil).AnyTimes()
 	cases := []struct {
 		name    string
 		prepare func()
@@ -365,6 +372,7 @@ func TestIntervalSegment_TTL(t *testing.T) {
 				listDir = fileutil.ListDir
 			}()
 			s := &intervalSegment{
+				shard: shard,
 				interval: option.Interval{
 					Interval:  timeutil.Interval(10 * timeutil.OneSecond),
 					Retention: timeutil.Interval(30 * timeutil.OneDay),
@@ -385,6 +393,60 @@ func TestIntervalSegment_TTL(t *testing.T) {
 	}
 }
 
+func TestIntervalSegment_truncateFields(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		listDir = fileutil.ListDir
+		ctrl.Finish()
+	}()
+
+	now := timeutil.Now()
+	segmentName := timeutil.FormatTimestamp(now, "20060102")
+	shard := NewMockShard(ctrl)
+	db := NewMockDatabase(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	shard.EXPECT().ShardID().Return(models.ShardID(1)).AnyTimes()
+	segment := NewMockSegment(ctrl)
+	s := &intervalSegment{
+		dir:   "interval",
+		shard: shard,
+		interval: option.Interval{
+			Interval:  timeutil.Interval(10 * timeutil.OneSecond),
+			Retention: timeutil.Interval(30 * timeutil.OneDay),
+		},
+		segments: map[string]Segment{segmentName: segment},
+		logger:   logger.GetLogger("TSDB", "IntervalSegment"),
+	}
+	listDir = func(path string) ([]string, error) {
+		return []string{segmentName}, nil
+	}
+
+	// no field retention rules
+	db.EXPECT().getFieldRetention().Return(nil)
+	assert.NoError(t, s.truncateFields())
+	retention := newTestFieldRetention(ctrl)
+	retention.setRules(nil)
+	db.EXPECT().getFieldRetention().Return(retention)
+	assert.NoError(t, s.truncateFields())
+
+	// truncate expired fields
+	retention = newTestFieldRetention(ctrl)
+	db.EXPECT().getFieldRetention().Return(retention).AnyTimes()
+	family := NewMockDataFamily(ctrl)
+	kvFamily := kv.NewMockFamily(ctrl)
+	segment.EXPECT().walkFamilies(gomock.Any()).DoAndReturn(func(fn func(family DataFamily) error) error {
+		return fn(family)
+	}).Times(2)
+	family.EXPECT().Family().Return(nil)
+	assert.NoError(t, s.truncateFields())
+	family.EXPECT().Family().Return(kvFamily)
+	family.EXPECT().TimeRange().Return(timeutil.TimeRange{End: now - 2*timeutil.OneDay})
+	family.EXPECT().Indicator().Return("family")
+	kvFamily.EXPECT().CompactAll().Return(true)
+	assert.NoError(t, s.truncateFields())
+	assert.Equal(t, now-2*timeutil.OneDay, retention.truncatedBefore(1, 10, 1))
+}
+
 func TestIntervalSegment_EvictSegment(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -408,3 +470,157 @@ func TestIntervalSegment_EvictSegment(t *testing.T) {
 	s.EvictSegment()
 	assert.Len(t, s.segments, 0)
 }
+
+func TestIntervalSegment_SetCompactionPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	segment := NewMockSegment(ctrl)
+	s := &intervalSegment{
+		segments: map[string]Segment{
+			segmentDir: segment,
+		},
+	}
+	segment.EXPECT().SetCompactionPolicy(option.CompactionPolicyLeveled)
+	s.SetCompactionPolicy(option.CompactionPolicyLeveled)
+}
+
+func TestIntervalSegment_SetRollup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	segment := NewMockSegment(ctrl)
+	s := &intervalSegment{
+		segments: map[string]Segment{
+			segmentDir: segment,
+		},
+	}
+	source := timeutil.Interval(10 * timeutil.OneSecond)
+	rollup := []timeutil.Interval{timeutil.Interval(timeutil.OneHour)}
+	segment.EXPECT().SetRollup(source, rollup)
+	s.SetRollup(source, rollup)
+}
+
+func TestIntervalSegment_walkFamilies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		listDir = fileutil.ListDir
+		newSegmentFunc = newSegment
+		ctrl.Finish()
+	}()
+
+	now := timeutil.Now()
+	segmentName := timeutil.FormatTimestamp(now, "20060102")
+	unloadedSegmentName := timeutil.FormatTimestamp(now-2*timeutil.OneDay, "20060102")
+	expiredSegmentName := timeutil.FormatTimestamp(now-40*timeutil.OneDay, "20060102")
+	segment := NewMockSegment(ctrl)
+	s := &intervalSegment{
+		dir: "interval",
+		interval: option.Interval{
+			Interval:  timeutil.Interval(10 * timeutil.OneSecond),
+			Retention: timeutil.Interval(30 * timeutil.OneDay),
+		},
+		segments: map[string]Segment{segmentName: segment},
+		logger:   logger.GetLogger("TSDB", "IntervalSegment"),
+	}
+	walk := func(_ DataFamily) error {
+		return nil
+	}
+
+	// list segment dir failure
+	listDir = func(path string) ([]string, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, s.walkFamilies(walk))
+
+	// walk family failure
+	listDir = func(path string) ([]string, error) {
+		return []string{segmentName, expiredSegmentName, unloadedSegmentName}, nil
+	}
+	segment.EXPECT().walkFamilies(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, s.walkFamilies(walk))
+
+	// load segment failure, expired segment is ignored
+	segment.EXPECT().walkFamilies(gomock.Any()).Return(nil)
+	newSegmentFunc = func(_ Shard, name string, _ timeutil.Interval) (Segment, error) {
+		assert.Equal(t, unloadedSegmentName, name)
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, s.walkFamilies(walk))
+
+	// walk all segments
+	segment2 := NewMockSegment(ctrl)
+	newSegmentFunc = func(_ Shard, _ string, _ timeutil.Interval) (Segment, error) {
+		return segment2, nil
+	}
+	segment.EXPECT().walkFamilies(gomock.Any()).Return(nil)
+	segment2.EXPECT().walkFamilies(gomock.Any()).Return(nil)
+	assert.NoError(t, s.walkFamilies(walk))
+}
+
+func TestIntervalSegment_recoveryTasks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		listDir = fileutil.ListDir
+		newSegmentFunc = newSegment
+		renameFunc = os.Rename
+		ctrl.Finish()
+	}()
+
+	now := timeutil.Now()
+	segmentName := timeutil.FormatTimestamp(now, "20060102")
+	expiredSegmentName := timeutil.FormatTimestamp(now-40*timeutil.OneDay, "20060102")
+	segment := NewMockSegment(ctrl)
+	s := &intervalSegment{
+		dir: "interval",
+		interval: option.Interval{
+			Interval:  timeutil.Interval(10 * timeutil.OneSecond),
+			Retention: timeutil.Interval(30 * timeutil.OneDay),
+		},
+		segments: map[string]Segment{segmentName: segment},
+		logger:   logger.GetLogger("TSDB", "IntervalSegment"),
+	}
+
+	// list segment dir failure
+	listDir = func(path string) ([]string, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Empty(t, s.recoveryTasks())
+
+	// ignore expired/quarantined segment
+	listDir = func(path string) ([]string, error) {
+		return []string{segmentName, expiredSegmentName, "abc", segmentName + quarantineSuffix + "1"}, nil
+	}
+	tasks := s.recoveryTasks()
+	assert.Len(t, tasks, 1)
+	task := tasks[0]
+	assert.Equal(t, path.Join("interval", segmentName), task.indicator)
+
+	// recover families of segment
+	segment.EXPECT().recoverFamilies().Return(2, nil)
+	families, err := task.run()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, families)
+
+	// rename failure
+	segment.EXPECT().Close()
+	renameFunc = func(_, _ string) error {
+		return fmt.Errorf("err")
+	}
+	_, err = task.quarantine()
+	assert.Error(t, err)
+	assert.Empty(t, s.segments)
+
+	// quarantine successfully
+	renameFunc = func(_, _ string) error {
+		return nil
+	}
+	quarantinedPath, err := task.quarantine()
+	assert.NoError(t, err)
+	assert.Contains(t, quarantinedPath, path.Join("interval", segmentName+quarantineSuffix))
+
+	// open segment failure
+	newSegmentFunc = func(_ Shard, _ string, _ timeutil.Interval) (Segment, error) {
+		return nil, fmt.Errorf("err")
+	}
+	_, err = task.run()
+	assert.Error(t, err)
+}
@@ -0,0 +1,131 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"errors"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// leaderWriter represents the write context of one leader which replicates data into family,
+// replica sequence and write errors are tracked per leader, so one bad leader never blocks others.
+type leaderWriter struct {
+	seq       atomic.Int64
+	failures  atomic.Int64 // num. of failed write batches
+	panics    atomic.Int64 // num. of write batches recovered from panic
+	lastErr   atomic.String
+	lastErrAt atomic.Int64
+}
+
+// newLeaderWriter creates a leader write context with replica sequence.
+func newLeaderWriter(seq int64) *leaderWriter {
+	w := &leaderWriter{}
+	w.seq.Store(seq)
+	return w
+}
+
+// recordFailure records the failure of write batch.
+func (w *leaderWriter) recordFailure(err error) {
+	w.failures.Inc()
+	if errors.Is(err, constants.ErrWritePanic) {
+		w.panics.Inc()
+	}
+	w.lastErr.Store(err.Error())
+	w.lastErrAt.Store(timeutil.Now())
+}
+
+// state returns the write state of leader.
+func (w *leaderWriter) state() models.LeaderWriteState {
+	return models.LeaderWriteState{
+		Failures:  w.failures.Load(),
+		Panics:    w.panics.Load(),
+		LastErr:   w.lastErr.Load(),
+		LastErrAt: w.lastErrAt.Load(),
+	}
+}
+
+// getLeaderWriter returns the write context of leader if exist.
+func (f *dataFamily) getLeaderWriter(leader int32) (*leaderWriter, bool) {
+	f.leaderLock.RLock()
+	defer f.leaderLock.RUnlock()
+
+	w, ok := f.leaders[leader]
+	return w, ok
+}
+
+// getOrCreateLeaderWriter returns the write context of leader, creates it if not exist.
+func (f *dataFamily) getOrCreateLeaderWriter(leader int32) *leaderWriter {
+	if w, ok := f.getLeaderWriter(leader); ok {
+		return w
+	}
+	f.leaderLock.Lock()
+	defer f.leaderLock.Unlock()
+
+	if w, ok := f.leaders[leader]; ok {
+		return w
+	}
+	if f.leaders == nil {
+		f.leaders = make(map[int32]*leaderWriter)
+	}
+	w := newLeaderWriter(0)
+	f.leaders[leader] = w
+	return w
+}
+
+// replicaSequences returns the replica sequence of each leader.
+func (f *dataFamily) replicaSequences() map[int32]int64 {
+	f.leaderLock.RLock()
+	defer f.leaderLock.RUnlock()
+
+	sequences := make(map[int32]int64, len(f.leaders))
+	for leader, w := range f.leaders {
+		sequences[leader] = w.seq.Load()
+	}
+	return sequences
+}
+
+// leaderWriteStates returns the write state of leaders which have write failures.
+func (f *dataFamily) leaderWriteStates() map[int32]models.LeaderWriteState {
+	f.leaderLock.RLock()
+	defer f.leaderLock.RUnlock()
+
+	var states map[int32]models.LeaderWriteState
+	for leader, w := range f.leaders {
+		if w.failures.Load() == 0 {
+			continue
+		}
+		if states == nil {
+			states = make(map[int32]models.LeaderWriteState)
+		}
+		states[leader] = w.state()
+	}
+	return states
+}
+
+// GetLeaderWriteState returns the write state of rows replicated from leader.
+func (f *dataFamily) GetLeaderWriteState(leader int32) models.LeaderWriteState {
+	if w, ok := f.getLeaderWriter(leader); ok {
+		return w.state()
+	}
+	return models.LeaderWriteState{}
+}
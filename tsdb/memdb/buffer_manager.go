@@ -114,6 +114,7 @@ func (b *bufferManager) Cleanup() {
 			b.logger.Error("close data point write buffer", logger.String("path", b.path), logger.Error(err))
 		}
 	}
+	b.value.Store(make([]DataPointBuffer, 0))
 
 	err := removeFunc(b.path)
 	if err != nil {
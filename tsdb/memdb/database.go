@@ -25,6 +25,7 @@ import (
 	"go.uber.org/atomic"
 
 	"github.com/lindb/common/pkg/fasttime"
+	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/metrics"
@@ -67,10 +68,17 @@ type MemoryDatabase interface {
 	FamilyTime() int64
 	// Uptime returns duration since created
 	Uptime() time.Duration
+	// CreatedTime returns the created time(unix nano) of memory database.
+	CreatedTime() int64
 	// NumOfMetrics returns the number of metrics.
 	NumOfMetrics() int
 	// NumOfSeries returns the number of series.
 	NumOfSeries() int
+	// GetSeriesIDs returns the series ids written for metric, returns nil if metric not found.
+	GetSeriesIDs(metricID metric.ID) *roaring.Bitmap
+	// SamplePoints returns the points of (metric, series, slot) triples sampled by rate(0~1],
+	// be used to verify the flushed data.
+	SamplePoints(rate float64) []Point
 }
 
 // MemoryDatabaseCfg represents the memory database config
@@ -78,11 +86,17 @@ type MemoryDatabaseCfg struct {
 	FamilyTime int64
 	Name       string
 	BufferMgr  BufferManager
+	// LastWriteWins replaces the old value of sum field when same time slot is written again, else aggregates them.
+	LastWriteWins bool
+	// CreatedTime(unix nano) restores the created time of memory database not flushed before restart,
+	// 0 means created now.
+	CreatedTime int64
 }
 
 // flushContext holds the context for flushing
 type flushContext struct {
-	metricID uint32
+	metricID      uint32
+	lastWriteWins bool // replaces compressed value of sum field with current value
 
 	timeutil.SlotRange // start/end time slot, metric level flush context
 	fieldIdx           int
@@ -93,8 +107,9 @@ type memoryDatabase struct {
 	allocSize   atomic.Int64 // allocated size
 	numOfSeries atomic.Int32 // num of series
 
-	familyTime int64
-	name       string
+	familyTime    int64
+	name          string
+	lastWriteWins bool // write semantics of rewriting same time slot
 
 	mStores *MetricBucketStore // metric id => mStoreINTF
 	buf     DataPointBuffer
@@ -116,13 +131,17 @@ func NewMemoryDatabase(cfg MemoryDatabaseCfg) (MemoryDatabase, error) {
 		return nil, err
 	}
 	db := &memoryDatabase{
-		familyTime:  cfg.FamilyTime,
-		name:        cfg.Name,
-		buf:         buf,
-		mStores:     NewMetricBucketStore(),
-		allocSize:   *atomic.NewInt64(0),
-		createdTime: fasttime.UnixNano(),
-		statistics:  metrics.NewMemDBStatistics(cfg.Name),
+		familyTime:    cfg.FamilyTime,
+		name:          cfg.Name,
+		lastWriteWins: cfg.LastWriteWins,
+		buf:           buf,
+		mStores:       NewMetricBucketStore(),
+		allocSize:     *atomic.NewInt64(0),
+		createdTime:   fasttime.UnixNano(),
+		statistics:    metrics.NewMemDBStatistics(cfg.Name),
+	}
+	if cfg.CreatedTime > 0 {
+		db.createdTime = cfg.CreatedTime
 	}
 	return db, nil
 }
@@ -312,7 +331,7 @@ func (md *memoryDatabase) writeLinField(
 		md.numOfSeries.Inc()
 	}
 	beforeFStoreCapacity := fStore.Capacity()
-	fStore.Write(fieldType, slotIndex, fieldValue)
+	fStore.Write(fieldType, slotIndex, fieldValue, md.lastWriteWins)
 	return writtenSize + fStore.Capacity() - beforeFStoreCapacity, nil
 }
 
@@ -325,7 +344,8 @@ func (md *memoryDatabase) FlushFamilyTo(flusher metricsdata.Flusher) error {
 	if err := md.mStores.WalkEntry(func(metricID uint32, value mStoreINTF) error {
 		flushCtx.metricID = metricID
 		if err := value.FlushMetricsDataTo(flusher, &flushContext{
-			metricID: metricID,
+			metricID:      metricID,
+			lastWriteWins: md.lastWriteWins,
 		}); err != nil {
 			return err
 		}
@@ -368,6 +388,11 @@ func (md *memoryDatabase) Uptime() time.Duration {
 	return time.Duration(fasttime.UnixNano() - md.createdTime)
 }
 
+// CreatedTime returns the created time(unix nano) of memory database.
+func (md *memoryDatabase) CreatedTime() int64 {
+	return md.createdTime
+}
+
 // NumOfMetrics returns the number of metrics.
 func (md *memoryDatabase) NumOfMetrics() int {
 	md.rwMutex.RLock()
@@ -380,3 +405,14 @@ func (md *memoryDatabase) NumOfMetrics() int {
 func (md *memoryDatabase) NumOfSeries() int {
 	return int(md.numOfSeries.Load())
 }
+
+// GetSeriesIDs returns the series ids written for metric, returns nil if metric not found.
+func (md *memoryDatabase) GetSeriesIDs(metricID metric.ID) *roaring.Bitmap {
+	md.rwMutex.RLock()
+	defer md.rwMutex.RUnlock()
+
+	if mStore, ok := md.mStores.Get(uint32(metricID)); ok {
+		return mStore.Keys().Clone()
+	}
+	return nil
+}
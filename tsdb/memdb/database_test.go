@@ -28,7 +28,9 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/common/pkg/fasttime"
 	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+	"github.com/lindb/roaring"
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/pkg/timeutil"
@@ -64,6 +66,14 @@ func TestMemoryDatabase_New(t *testing.T) {
 	time.Sleep(time.Millisecond * 100)
 	assert.True(t, mdINTF.Uptime() > 0)
 
+	// restore created time of memory database before restart
+	bufferMgr.EXPECT().AllocBuffer(gomock.Any()).Return(buf, nil)
+	createdTime := fasttime.UnixNano() - int64(time.Minute)
+	mdINTF, err = NewMemoryDatabase(MemoryDatabaseCfg{BufferMgr: bufferMgr, CreatedTime: createdTime})
+	assert.NoError(t, err)
+	assert.Equal(t, createdTime, mdINTF.CreatedTime())
+	assert.True(t, mdINTF.Uptime() >= time.Minute)
+
 	bufferMgr.EXPECT().AllocBuffer(gomock.Any()).Return(nil, fmt.Errorf("err"))
 	mdINTF, err = NewMemoryDatabase(cfg)
 	assert.Error(t, err)
@@ -152,7 +162,7 @@ func TestMemoryDatabase_Write(t *testing.T) {
 	// case 1: write ok
 	gomock.InOrder(
 		tStore.EXPECT().GetFStore(gomock.Any()).Return(fStore, true),
-		fStore.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()),
+		fStore.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()),
 		mockMStore.EXPECT().SetSlot(gomock.Any()).Times(1),
 	)
 
@@ -306,7 +316,7 @@ func TestMemoryDatabase_WriteHistogram_Err(t *testing.T) {
 	// mock
 	fStore := NewMockfStoreINTF(ctrl)
 	fStore.EXPECT().Capacity().Return(100).AnyTimes()
-	fStore.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	fStore.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
 	mockMStore := NewMockmStoreINTF(ctrl)
 	mockMStore.EXPECT().Capacity().Return(100).AnyTimes()
 	tStore := NewMocktStoreINTF(ctrl)
@@ -466,6 +476,11 @@ func TestMemoryDatabase_Filter(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Nil(t, rs)
 
+	// get series ids
+	assert.Nil(t, md.GetSeriesIDs(metric.ID(4444)))
+	mockMStore.EXPECT().Keys().Return(roaring.BitmapOf(1, 2))
+	assert.Equal(t, roaring.BitmapOf(1, 2), md.GetSeriesIDs(metric.ID(3333)))
+
 	err = md.Close()
 	assert.NoError(t, err)
 }
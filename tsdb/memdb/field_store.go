@@ -52,7 +52,8 @@ const (
 	markContainer = 8
 
 	emptyFieldStoreSize = 24 + // empty buf slice cost
-		24 // empty compress slice cost
+		24 + // empty compress slice cost
+		8 // written slot range cost(aligned)
 )
 
 // fStoreINTF represents field-store,
@@ -64,10 +65,15 @@ type fStoreINTF interface {
 	GetFieldID() field.ID
 	// Write writes the field data into current buffer
 	// if time slot out of current time window, need compress time window then resets the current buffer
-	// if it has same time slot in current buffer, need do rollup operation by field type
-	Write(fieldType field.Type, slotIndex uint16, value float64)
+	// if it has same time slot in current buffer, need do rollup operation by field type,
+	// or replaces the old value of sum field if last write wins.
+	Write(fieldType field.Type, slotIndex uint16, value float64, lastWriteWins bool)
+	// WrittenSlotRange returns the slot range(first/last written slot) of field store.
+	WrittenSlotRange() timeutil.SlotRange
 	// FlushFieldTo flushes field store data into kv store, need align slot range in metric level
 	FlushFieldTo(tableFlusher metricsdata.Flusher, fieldMeta field.Meta, flushCtx *flushContext) error
+	// Points returns the points(time slot => value) in slot range, the value is merged as flushing.
+	Points(fieldType field.Type, slotRange timeutil.SlotRange, lastWriteWins bool) map[uint16]float64
 	// Load loads field series data.
 	Load(ctx *flow.DataLoadContext,
 		seriesIdxFromQuery uint16, fieldIdx int,
@@ -77,8 +83,9 @@ type fStoreINTF interface {
 
 // fieldStore implements fStoreINTF interface
 type fieldStore struct {
-	buf      []byte // current write buffer, accept write data
-	compress []byte // immutable compress data
+	buf      []byte             // current write buffer, accept write data
+	compress []byte             // immutable compress data
+	written  timeutil.SlotRange // first/last written slot
 }
 
 // newFieldStore creates a new field store
@@ -94,7 +101,9 @@ func (fs *fieldStore) GetFieldID() field.ID {
 	return field.ID(stream.ReadUint16(fs.buf, fieldOffset))
 }
 
-func (fs *fieldStore) Write(fieldType field.Type, slotIndex uint16, value float64) {
+func (fs *fieldStore) Write(fieldType field.Type, slotIndex uint16, value float64, lastWriteWins bool) {
+	fs.markWritten(slotIndex)
+
 	if fs.buf[markOffset+1] == 0 {
 		// no data written before
 		fs.writeFirstPoint(slotIndex, value)
@@ -104,7 +113,7 @@ func (fs *fieldStore) Write(fieldType field.Type, slotIndex uint16, value float6
 	startTime := fs.getStart()
 	if slotIndex < startTime || slotIndex > startTime+fs.timeWindow()-1 {
 		// if current slot time out of current time window, need compress block data, start new time window
-		fs.compact(fieldType, startTime)
+		fs.compact(fieldType, startTime, lastWriteWins)
 
 		// write first point after compact
 		fs.writeFirstPoint(slotIndex, value)
@@ -116,8 +125,10 @@ func (fs *fieldStore) Write(fieldType field.Type, slotIndex uint16, value float6
 	pos, markIdx, flagIdx := fs.position(delta)
 	if fs.buf[markOffset+markIdx]&flagIdx != 0 {
 		// there is same point of same time slot
-		oldValue := math.Float64frombits(binary.LittleEndian.Uint64(fs.buf[pos:]))
-		value = fieldType.AggType().Aggregate(oldValue, value)
+		if !fieldType.ReplaceOnRewrite(lastWriteWins) {
+			oldValue := math.Float64frombits(binary.LittleEndian.Uint64(fs.buf[pos:]))
+			value = fieldType.AggType().Aggregate(oldValue, value)
+		}
 	} else {
 		// new data for time slot
 		fs.buf[endOffset] = byte(delta)
@@ -127,6 +138,26 @@ func (fs *fieldStore) Write(fieldType field.Type, slotIndex uint16, value float6
 	binary.LittleEndian.PutUint64(fs.buf[pos:], math.Float64bits(value))
 }
 
+// WrittenSlotRange returns the slot range(first/last written slot) of field store.
+func (fs *fieldStore) WrittenSlotRange() timeutil.SlotRange {
+	return fs.written
+}
+
+// markWritten updates the first/last written slot of field store.
+func (fs *fieldStore) markWritten(slotIndex uint16) {
+	if fs.buf[markOffset+1] == 0 && len(fs.compress) == 0 {
+		// no data written before
+		fs.written.Start, fs.written.End = slotIndex, slotIndex
+		return
+	}
+	if slotIndex < fs.written.Start {
+		fs.written.Start = slotIndex
+	}
+	if slotIndex > fs.written.End {
+		fs.written.End = slotIndex
+	}
+}
+
 // FlushFieldTo flushes field store data into kv store, need align slot range in metric level
 func (fs *fieldStore) FlushFieldTo(tableFlusher metricsdata.Flusher, fieldMeta field.Meta, flushCtx *flushContext) error {
 	var decoder *encoding.TSDDecoder
@@ -140,7 +171,7 @@ func (fs *fieldStore) FlushFieldTo(tableFlusher metricsdata.Flusher, fieldMeta f
 	encoder := tableFlusher.GetEncoder(flushCtx.fieldIdx)
 	encoder.RestWithStartTime(flushCtx.SlotRange.Start)
 
-	data, err := fs.merge(fieldMeta.Type, encoder, decoder, fs.getStart(), flushCtx.SlotRange, false)
+	data, err := fs.merge(fieldMeta.Type, encoder, decoder, fs.getStart(), flushCtx.SlotRange, false, flushCtx.lastWriteWins)
 	if err != nil {
 		memDBLogger.Error("flush field store err, data lost", logger.Error(err))
 		return nil
@@ -176,7 +207,7 @@ func (fs *fieldStore) Capacity() int {
 
 // compact the current write buffer,
 // new compress operation will be executed when it's necessary
-func (fs *fieldStore) compact(fieldType field.Type, startTime uint16) {
+func (fs *fieldStore) compact(fieldType field.Type, startTime uint16, lastWriteWins bool) {
 	length := len(fs.compress)
 	thisSlotRange := fs.slotRange(startTime)
 
@@ -190,7 +221,7 @@ func (fs *fieldStore) compact(fieldType field.Type, startTime uint16) {
 	encoder := encoding.TSDEncodeFunc(thisSlotRange.Start)
 	defer encoding.ReleaseTSDEncoder(encoder)
 
-	data, err := fs.merge(fieldType, encoder, decoder, startTime, thisSlotRange, true)
+	data, err := fs.merge(fieldType, encoder, decoder, startTime, thisSlotRange, true, lastWriteWins)
 	if err != nil {
 		memDBLogger.Error("compact field store data err", logger.Error(err))
 	}
@@ -222,6 +253,7 @@ func (fs *fieldStore) getEnd() uint16 {
 }
 
 // merge the current and compress data based on field aggregate function,
+// current data replaces compress data of sum field if last write wins,
 // startTime => current write start time
 // start/end slot => target compact time slot
 func (fs *fieldStore) merge(
@@ -231,7 +263,9 @@ func (fs *fieldStore) merge(
 	startTime uint16,
 	thisSlotRange timeutil.SlotRange,
 	withTimeRange bool,
+	lastWriteWins bool,
 ) (compress []byte, err error) {
+	replace := fieldType.ReplaceOnRewrite(lastWriteWins)
 	for i := thisSlotRange.Start; i <= thisSlotRange.End; i++ {
 		newValue, hasNewValue := fs.getCurrentValue(startTime, i)
 		oldValue, hasOldValue := getOldFloatValue(decoder, i)
@@ -240,6 +274,10 @@ func (fs *fieldStore) merge(
 			// just compress current block value with pos
 			encoder.AppendTime(bit.One)
 			encoder.AppendValue(math.Float64bits(newValue))
+		case hasNewValue && hasOldValue && replace:
+			// current value replaces old value
+			encoder.AppendTime(bit.One)
+			encoder.AppendValue(math.Float64bits(newValue))
 		case hasNewValue && hasOldValue:
 			// merge and compress
 			encoder.AppendTime(bit.One)
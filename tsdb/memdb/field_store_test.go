@@ -58,7 +58,7 @@ func TestFieldStore_Write(t *testing.T) {
 	s := store.(*fieldStore)
 
 	capacity := store.Capacity()
-	store.Write(field.SumField, 10, 10.1)
+	store.Write(field.SumField, 10, 10.1, false)
 	// length not changed
 	assert.Zero(t, store.Capacity()-capacity)
 	// case 1: get write value
@@ -75,7 +75,7 @@ func TestFieldStore_Write(t *testing.T) {
 	assert.Equal(t, 0.0, value)
 	// case 3: write exist value, need rollup
 	capacity = store.Capacity()
-	store.Write(field.SumField, 10, 10.1)
+	store.Write(field.SumField, 10, 10.1, false)
 	assert.Zero(t, store.Capacity()-capacity)
 	value, ok = s.getCurrentValue(10, 10)
 	assert.True(t, ok)
@@ -83,7 +83,7 @@ func TestFieldStore_Write(t *testing.T) {
 	assert.Equal(t, uint16(0), s.getEnd())
 	// case 3: write new value
 	capacity = store.Capacity()
-	store.Write(field.SumField, 12, 12.1)
+	store.Write(field.SumField, 12, 12.1, false)
 	assert.Zero(t, store.Capacity()-capacity)
 	value, ok = s.getCurrentValue(10, 12)
 	assert.True(t, ok)
@@ -99,7 +99,7 @@ func TestFieldStore_Write(t *testing.T) {
 	assert.Equal(t, uint16(12), thisSlotRange.End)
 	// case 6: compact for slot < start time, time range[5,12]
 	capacity = store.Capacity()
-	store.Write(field.SumField, 5, 5.3)
+	store.Write(field.SumField, 5, 5.3, false)
 	assert.True(t, valueSize < store.Capacity()-capacity)
 	thisSlotRange = s.slotRange(s.getStart())
 	assert.Equal(t, uint16(5), thisSlotRange.Start)
@@ -110,12 +110,12 @@ func TestFieldStore_Write(t *testing.T) {
 	assert.Equal(t, uint16(0), s.getEnd())
 	// case 7: write old value
 	capacity = store.Capacity()
-	store.Write(field.SumField, 10, 10.1)
+	store.Write(field.SumField, 10, 10.1, false)
 	assert.Zero(t, store.Capacity()-capacity)
 	assert.Equal(t, uint16(5), s.getEnd())
 	// case 8: compact for slot > end time, time range[5,12]
 	capacity = store.Capacity()
-	store.Write(field.SumField, 50, 50.1)
+	store.Write(field.SumField, 50, 50.1, false)
 	assert.True(t, valueSize < store.Capacity()-capacity)
 	thisSlotRange = s.slotRange(s.getStart())
 	assert.Equal(t, uint16(5), thisSlotRange.Start)
@@ -126,7 +126,7 @@ func TestFieldStore_Write(t *testing.T) {
 	assert.Equal(t, uint16(0), s.getEnd())
 	// case 9: write 10 slot, compact old value
 	capacity = store.Capacity()
-	store.Write(field.SumField, 10, 10.1)
+	store.Write(field.SumField, 10, 10.1, false)
 	assert.True(t, valueSize < store.Capacity()-capacity)
 	assert.Equal(t, uint16(0), s.getEnd())
 	value, ok = s.getCurrentValue(10, 10)
@@ -138,7 +138,7 @@ func TestFieldStore_Write2(t *testing.T) {
 	buf := make([]byte, pageSize)
 	store := newFieldStore(buf, field.ID(1))
 	s := store.(*fieldStore)
-	store.Write(field.SumField, 10, 178)
+	store.Write(field.SumField, 10, 178, false)
 	capacity := s.Capacity()
 	assert.NotZero(t, valueSize+headLen, capacity)
 	value, ok := s.getCurrentValue(10, 10)
@@ -147,7 +147,7 @@ func TestFieldStore_Write2(t *testing.T) {
 	assert.Equal(t, uint16(0), s.getEnd())
 	// write with old slot
 	capacity = s.Capacity()
-	store.Write(field.SumField, 10, 178)
+	store.Write(field.SumField, 10, 178, false)
 	assert.Zero(t, store.Capacity()-capacity)
 	value, ok = s.getCurrentValue(10, 10)
 	assert.True(t, ok)
@@ -155,6 +155,53 @@ func TestFieldStore_Write2(t *testing.T) {
 	assert.Equal(t, uint16(0), s.getEnd())
 }
 
+func TestFieldStore_WrittenSlotRange(t *testing.T) {
+	store := newFieldStore(make([]byte, pageSize), field.ID(1))
+	// first write
+	store.Write(field.SumField, 10, 10.1, false)
+	assert.Equal(t, timeutil.SlotRange{Start: 10, End: 10}, store.WrittenSlotRange())
+	// out of order write
+	store.Write(field.SumField, 5, 5.1, false)
+	assert.Equal(t, timeutil.SlotRange{Start: 5, End: 10}, store.WrittenSlotRange())
+	store.Write(field.SumField, 12, 12.1, false)
+	assert.Equal(t, timeutil.SlotRange{Start: 5, End: 12}, store.WrittenSlotRange())
+	// write after compact
+	store.Write(field.SumField, 100, 100.1, false)
+	assert.Equal(t, timeutil.SlotRange{Start: 5, End: 100}, store.WrittenSlotRange())
+	store.Write(field.SumField, 2, 2.1, false)
+	assert.Equal(t, timeutil.SlotRange{Start: 2, End: 100}, store.WrittenSlotRange())
+}
+
+func TestFieldStore_Write_LastWriteWins(t *testing.T) {
+	buf := make([]byte, pageSize)
+	store := newFieldStore(buf, field.ID(1))
+	s := store.(*fieldStore)
+	// case 1: rewrite replaces old value of sum field
+	store.Write(field.SumField, 10, 178, true)
+	store.Write(field.SumField, 10, 178, true)
+	value, ok := s.getCurrentValue(10, 10)
+	assert.True(t, ok)
+	assert.InDelta(t, 178.0, value, 0)
+	// case 2: compact replaces compressed value of sum field
+	store.Write(field.SumField, 50, 50.1, true)
+	store.Write(field.SumField, 10, 100, true)
+	store.Write(field.SumField, 100, 100.1, true)
+	decoder := encoding.GetTSDDecoder()
+	defer encoding.ReleaseTSDDecoder(decoder)
+	decoder.Reset(s.compress)
+	value, ok = getOldFloatValue(decoder, 10)
+	assert.True(t, ok)
+	assert.InDelta(t, 100.0, value, 0)
+	// case 3: other aggregations are not affected
+	store = newFieldStore(make([]byte, pageSize), field.ID(2))
+	s = store.(*fieldStore)
+	store.Write(field.MaxField, 10, 178, true)
+	store.Write(field.MaxField, 10, 100, true)
+	value, ok = s.getCurrentValue(10, 10)
+	assert.True(t, ok)
+	assert.InDelta(t, 178.0, value, 0)
+}
+
 func TestFieldStore_Write_Compact_err(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -167,10 +214,10 @@ func TestFieldStore_Write_Compact_err(t *testing.T) {
 	assert.NotNil(t, store)
 	s := store.(*fieldStore)
 
-	store.Write(field.SumField, 10, 10.1)
+	store.Write(field.SumField, 10, 10.1, false)
 	assert.NotZero(t, store.Capacity())
 	capacity := store.Capacity()
-	store.Write(field.SumField, 100, 100.1)
+	store.Write(field.SumField, 100, 100.1, false)
 	assert.Equal(t, 13, store.Capacity()-capacity)
 	value, ok := s.getCurrentValue(100, 100)
 	assert.True(t, ok)
@@ -196,7 +243,7 @@ func TestFieldStore_FlushFieldTo(t *testing.T) {
 	for idx, f := range fields {
 		buf := make([]byte, pageSize)
 		store := newFieldStore(buf, f.ID)
-		store.Write(field.SumField, 5, float64(f.ID))
+		store.Write(field.SumField, 5, float64(f.ID), false)
 		assert.NoError(t, store.FlushFieldTo(flusher,
 			field.Meta{Type: field.SumField},
 			&flushContext{SlotRange: slotRange, fieldIdx: idx}))
@@ -240,7 +287,7 @@ func TestFieldStore_FlushFieldTo(t *testing.T) {
 			Decoder: encoding.GetTSDDecoder(),
 		}
 		ctx.Grouping()
-		loader := r.Load(ctx)
+		loader := r.Load(ctx, timeutil.SlotRange{Start: 5, End: 5})
 		loader.Load(ctx)
 	}
 	assert.Equal(t, 2, found)
@@ -250,7 +297,7 @@ func TestFieldStore_Load(t *testing.T) {
 	buf := make([]byte, pageSize)
 	f := field.Meta{ID: 1}
 	store := newFieldStore(buf, f.ID)
-	store.Write(field.SumField, 5, float64(f.ID))
+	store.Write(field.SumField, 5, float64(f.ID), false)
 
 	ctx := &flow.DataLoadContext{
 		DownSampling: func(slotRange timeutil.SlotRange, seriesIdx uint16, fieldIdx int, getter encoding.TSDValueGetter) {
@@ -267,6 +314,6 @@ func TestFieldStore_Load(t *testing.T) {
 	}
 	store.Load(ctx, 0, 0, field.SumField, timeutil.SlotRange{Start: 5, End: 10})
 	store1 := store.(*fieldStore)
-	store1.compact(field.SumField, 5)
+	store1.compact(field.SumField, 5, false)
 	store.Load(ctx, 0, 0, field.SumField, timeutil.SlotRange{Start: 5, End: 10})
 }
@@ -27,6 +27,7 @@ import (
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
 )
 
@@ -60,12 +61,16 @@ type mStoreINTF interface {
 	SetSlot(slot uint16)
 	// GetSlotRange returns slot range.
 	GetSlotRange() *timeutil.SlotRange
+	// Keys returns the series ids of metric.
+	Keys() *roaring.Bitmap
 	// AddField adds field meta into metric level
 	AddField(fieldID field.ID, fieldType field.Type)
 	// GetOrCreateTStore constructs the index and return a tStore
 	GetOrCreateTStore(seriesID uint32) (tStore tStoreINTF, created bool)
 	// FlushMetricsDataTo flushes metric-block of mStore to the Writer.
 	FlushMetricsDataTo(tableFlusher metricsdata.Flusher, flushCtx *flushContext) (err error)
+	// SamplePoints appends the points of series/slot sampled into points.
+	SamplePoints(metricID metric.ID, sample func() bool, lastWriteWins bool, points []Point) []Point
 }
 
 // metricStore represents metric level storage, stores all series data, and fields/family times metadata
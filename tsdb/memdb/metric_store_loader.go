@@ -19,9 +19,11 @@ package memdb
 
 import (
 	"github.com/lindb/roaring"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/pkg/tracing"
 	"github.com/lindb/lindb/series/field"
 )
 
@@ -52,12 +54,20 @@ func newMetricStoreLoader(db MemoryDatabase,
 
 // Load loads the metric data by given series id from memory storage.
 func (s *metricStoreLoader) Load(loadCtx *flow.DataLoadContext) {
+	_, span := tracing.StartSpan(loadCtx.Context(), "storage.memdb_scan")
+	defer span.End()
+
 	release := s.db.WithLock()
 	defer release()
 
+	numOfSeries := 0
 	loadCtx.IterateLowSeriesIDs(s.lowContainer, func(seriesIdxFromQuery uint16, seriesIdxFromStorage int) {
 		store := s.timeSeriesStores[seriesIdxFromStorage]
 		// read series data of fields
 		store.load(loadCtx, seriesIdxFromQuery, s.fields, s.slotRange)
+		numOfSeries++
 	})
+	if span.IsRecording() {
+		span.SetAttributes(attribute.Int("series", numOfSeries))
+	}
 }
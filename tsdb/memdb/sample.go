@@ -0,0 +1,119 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package memdb
+
+import (
+	"math/rand"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+)
+
+// Point represents the value of field in time slot of series, be used to verify the flushed data.
+type Point struct {
+	MetricID metric.ID
+	SeriesID uint32
+	FieldID  field.ID
+	Slot     uint16
+	Value    float64
+	HasValue bool // false means no value written in time slot
+}
+
+// SamplePoints returns the points of (metric, series, slot) triples sampled by rate(0~1],
+// the value of point is merged as flushing, points are in order by metric/series/field/slot.
+func (md *memoryDatabase) SamplePoints(rate float64) (points []Point) {
+	md.rwMutex.RLock()
+	defer md.rwMutex.RUnlock()
+
+	sample := func() bool {
+		return rand.Float64() < rate // nolint:gosec
+	}
+	_ = md.mStores.WalkEntry(func(metricID uint32, mStore mStoreINTF) error {
+		points = mStore.SamplePoints(metric.ID(metricID), sample, md.lastWriteWins, points)
+		return nil
+	})
+	return points
+}
+
+// SamplePoints appends the points of series/slot sampled into points.
+func (ms *metricStore) SamplePoints(metricID metric.ID, sample func() bool, lastWriteWins bool, points []Point) []Point {
+	if ms.slotRange == nil || len(ms.fields) == 0 {
+		return points
+	}
+	slotRange := *ms.slotRange
+	var slots []uint16
+	_ = ms.WalkEntry(func(seriesID uint32, tStore tStoreINTF) error {
+		slots = slots[:0]
+		for slot := int(slotRange.Start); slot <= int(slotRange.End); slot++ {
+			if sample() {
+				slots = append(slots, uint16(slot))
+			}
+		}
+		if len(slots) == 0 {
+			return nil
+		}
+		for _, f := range ms.fields {
+			var values map[uint16]float64
+			if fStore, ok := tStore.GetFStore(f.ID); ok {
+				values = fStore.Points(f.Type, slotRange, lastWriteWins)
+			}
+			for _, slot := range slots {
+				value, ok := values[slot]
+				points = append(points, Point{
+					MetricID: metricID,
+					SeriesID: seriesID,
+					FieldID:  f.ID,
+					Slot:     slot,
+					Value:    value,
+					HasValue: ok,
+				})
+			}
+		}
+		return nil
+	})
+	return points
+}
+
+// Points returns the points(time slot => value) in slot range, the value of current/compress data is merged as flushing.
+func (fs *fieldStore) Points(fieldType field.Type, slotRange timeutil.SlotRange, lastWriteWins bool) map[uint16]float64 {
+	var decoder *encoding.TSDDecoder
+	if len(fs.compress) > 0 {
+		decoder = encoding.GetTSDDecoder()
+		defer encoding.ReleaseTSDDecoder(decoder)
+		decoder.Reset(fs.compress)
+	}
+	replace := fieldType.ReplaceOnRewrite(lastWriteWins)
+	startTime := fs.getStart()
+	points := make(map[uint16]float64)
+	for i := int(slotRange.Start); i <= int(slotRange.End); i++ {
+		slot := uint16(i)
+		newValue, hasNewValue := fs.getCurrentValue(startTime, slot)
+		oldValue, hasOldValue := getOldFloatValue(decoder, slot)
+		switch {
+		case hasNewValue && hasOldValue && !replace:
+			points[slot] = fieldType.AggType().Aggregate(newValue, oldValue)
+		case hasNewValue:
+			points[slot] = newValue
+		case hasOldValue:
+			points[slot] = oldValue
+		}
+	}
+	return points
+}
@@ -0,0 +1,91 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package memdb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+func TestFieldStore_Points(t *testing.T) {
+	store := newFieldStore(make([]byte, pageSize), field.ID(1))
+	fs := store.(*fieldStore)
+	store.Write(field.SumField, 10, 1, false)
+	// compact slot 10
+	store.Write(field.SumField, 100, 2, false)
+	store.Write(field.SumField, 100, 3, false)
+	slotRange := timeutil.SlotRange{Start: 10, End: 100}
+	assert.Equal(t, map[uint16]float64{10: 1, 100: 5}, fs.Points(field.SumField, slotRange, false))
+	// compact slot 100, then rewrite slot 10 in current buffer
+	store.Write(field.SumField, 10, 4, false)
+	assert.Equal(t, map[uint16]float64{10: 5, 100: 5}, fs.Points(field.SumField, slotRange, false))
+	assert.Equal(t, map[uint16]float64{10: 4, 100: 5}, fs.Points(field.SumField, slotRange, true))
+	assert.Equal(t, map[uint16]float64{10: 5}, fs.Points(field.SumField, timeutil.SlotRange{Start: 10, End: 20}, false))
+}
+
+func TestMemoryDatabase_SamplePoints(t *testing.T) {
+	mStore := newMetricStore()
+	mStore.AddField(1, field.SumField)
+	mStore.AddField(2, field.MaxField)
+	mStore.SetSlot(5)
+	mStore.SetSlot(8)
+	for _, seriesID := range []uint32{10, 65536 + 20} {
+		tStore, _ := mStore.GetOrCreateTStore(seriesID)
+		fStore := newFieldStore(make([]byte, pageSize), field.ID(1))
+		fStore.Write(field.SumField, 5, float64(seriesID), false)
+		fStore.Write(field.SumField, 8, 1, false)
+		tStore.InsertFStore(fStore)
+	}
+	md := &memoryDatabase{mStores: NewMetricBucketStore()}
+	md.mStores.Put(1, mStore)
+	// empty metric store
+	md.mStores.Put(2, newMetricStore())
+
+	assert.Empty(t, md.SamplePoints(0))
+	points := md.SamplePoints(1)
+	// 2 series * 2 fields * 4 slots
+	assert.Len(t, points, 16)
+	assert.Equal(t, Point{MetricID: 1, SeriesID: 10, FieldID: 1, Slot: 5, Value: 10, HasValue: true}, points[0])
+	assert.Equal(t, Point{MetricID: 1, SeriesID: 10, FieldID: 1, Slot: 6}, points[1])
+	assert.Equal(t, Point{MetricID: 1, SeriesID: 10, FieldID: 2, Slot: 5}, points[4])
+
+	// points sampled from memory database are same as flushed data
+	nopKVFlusher := kv.NewNopFlusher()
+	flusher, err := metricsdata.NewFlusher(nopKVFlusher)
+	assert.NoError(t, err)
+	assert.NoError(t, mStore.FlushMetricsDataTo(flusher, &flushContext{metricID: 1}))
+	r, err := metricsdata.NewReader("1.sst", nopKVFlusher.Bytes())
+	assert.NoError(t, err)
+	pr, err := metricsdata.NewPointReader(r)
+	assert.NoError(t, err)
+	defer pr.Close()
+	for _, p := range points {
+		if p.FieldID == 1 {
+			assert.True(t, pr.Seek(p.SeriesID))
+		}
+		value, ok := pr.GetPoints(p.FieldID)[p.Slot]
+		assert.Equal(t, p.HasValue, ok)
+		assert.Equal(t, p.Value, value)
+	}
+}
@@ -112,6 +112,7 @@ func (ts *timeSeriesStore) FlushFieldsTo(flusher metricsdata.Flusher, flushCtx *
 	}
 	fieldMetas := flusher.GetFieldMetas()
 	idx := 0
+	var slotRange timeutil.SlotRange
 	for _, fieldMeta := range fieldMetas {
 		if idx < fStoreLen && fieldMeta.ID == stores[idx].GetFieldID() {
 			// flush field data
@@ -119,6 +120,11 @@ func (ts *timeSeriesStore) FlushFieldsTo(flusher metricsdata.Flusher, flushCtx *
 			if err := stores[idx].FlushFieldTo(flusher, fieldMeta, flushCtx); err != nil {
 				return err
 			}
+			if idx == 0 {
+				slotRange = stores[idx].WrittenSlotRange()
+			} else {
+				slotRange = slotRange.Union(stores[idx].WrittenSlotRange())
+			}
 			idx++
 		} else {
 			// must flush nil data for metric has multi-field.
@@ -126,6 +132,10 @@ func (ts *timeSeriesStore) FlushFieldsTo(flusher metricsdata.Flusher, flushCtx *
 			_ = flusher.FlushField(nil)
 		}
 	}
+	if idx > 0 {
+		// slot range of series is used for pruning series by query slot range
+		flusher.SetSeriesSlotRange(slotRange)
+	}
 	return nil
 }
 
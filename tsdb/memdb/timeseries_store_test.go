@@ -79,7 +79,9 @@ func TestTimeSeriesStore_FlushSeriesTo(t *testing.T) {
 		flusher.EXPECT().FlushField(nil),
 		fStore.EXPECT().GetFieldID().Return(field.ID(2)),
 		fStore.EXPECT().FlushFieldTo(gomock.Any(), gomock.Any(), gomock.Any()),
+		fStore.EXPECT().WrittenSlotRange().Return(timeutil.SlotRange{Start: 5, End: 8}),
 		flusher.EXPECT().FlushField(nil),
+		flusher.EXPECT().SetSeriesSlotRange(timeutil.SlotRange{Start: 5, End: 8}),
 	)
 	assert.NoError(t, tStore.FlushFieldsTo(flusher, &flushContext{}))
 
@@ -91,6 +93,20 @@ func TestTimeSeriesStore_FlushSeriesTo(t *testing.T) {
 		fStore.EXPECT().FlushFieldTo(gomock.Any(), gomock.Any(), gomock.Any()).Return(fmt.Errorf("err")),
 	)
 	assert.Error(t, tStore.FlushFieldsTo(flusher, &flushContext{}))
+
+	// case: slot range of series is union of fields
+	fStore3 := NewMockfStoreINTF(ctrl)
+	fStore3.EXPECT().GetFieldID().Return(field.ID(3)).AnyTimes()
+	fStore.EXPECT().GetFieldID().Return(field.ID(2)).AnyTimes()
+	s.InsertFStore(fStore3)
+	flusher.EXPECT().GetFieldMetas().Return(field.Metas{{ID: 1}, {ID: 2}, {ID: 3}})
+	flusher.EXPECT().FlushField(nil)
+	fStore.EXPECT().FlushFieldTo(gomock.Any(), gomock.Any(), gomock.Any())
+	fStore.EXPECT().WrittenSlotRange().Return(timeutil.SlotRange{Start: 5, End: 8})
+	fStore3.EXPECT().FlushFieldTo(gomock.Any(), gomock.Any(), gomock.Any())
+	fStore3.EXPECT().WrittenSlotRange().Return(timeutil.SlotRange{Start: 2, End: 6})
+	flusher.EXPECT().SetSeriesSlotRange(timeutil.SlotRange{Start: 2, End: 8})
+	assert.NoError(t, tStore.FlushFieldsTo(flusher, &flushContext{}))
 }
 
 func TestTimeSeriesStore_load(t *testing.T) {
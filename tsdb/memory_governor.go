@@ -0,0 +1,108 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"sort"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+// memoryGovernor tracks total memory database size of all families on the node,
+// when total size is above the high watermark(max-memdb-total-size),
+// it picks the biggest families to flush until total size drops below the low watermark(target-memdb-total-size).
+// Per-family max-memdb-size is static, so node with many small families may OOM without it.
+type memoryGovernor struct {
+	familyManager FamilyManager
+	logger        *logger.Logger
+}
+
+// newMemoryGovernor creates the node-level memory governor.
+func newMemoryGovernor(familyManager FamilyManager) *memoryGovernor {
+	return &memoryGovernor{
+		familyManager: familyManager,
+		logger:        engineLogger,
+	}
+}
+
+// pick returns the biggest families need to flush, at most limit families,
+// families in flushing or in picked set are not picked again.
+func (g *memoryGovernor) pick(limit int, picked map[string]struct{}) (families []DataFamily) {
+	tsdbCfg := config.GlobalStorageConfig().TSDB
+	highWatermark := int64(tsdbCfg.MaxMemDBTotalSize)
+	if highWatermark <= 0 {
+		// node-level memory governor disabled
+		return nil
+	}
+
+	var (
+		totalSize  int64
+		candidates []DataFamily
+		sizes      = make(map[string]int64)
+	)
+	g.familyManager.WalkEntry(func(family DataFamily) {
+		size := family.MemDBSize()
+		totalSize += size
+		if _, ok := picked[family.Indicator()]; ok {
+			// family will be flushed, exclude its memory
+			totalSize -= size
+			return
+		}
+		if size <= 0 || family.IsFlushing() {
+			return
+		}
+		sizes[family.Indicator()] = size
+		candidates = append(candidates, family)
+	})
+	metrics.MemoryGovernorStatistics.MemDBTotalSize.Update(float64(totalSize))
+
+	if totalSize <= highWatermark {
+		return nil
+	}
+	metrics.MemoryGovernorStatistics.Triggers.Incr()
+	lowWatermark := int64(tsdbCfg.TargetMemDBTotalSize)
+	if limit <= 0 {
+		g.logger.Warn("total memdb size is higher than the high watermark, but no flush worker available",
+			logger.String("memdbTotalSize", ltoml.Size(totalSize).String()),
+			logger.String("highWatermark", tsdbCfg.MaxMemDBTotalSize.String()))
+		return nil
+	}
+	// flush the biggest family first
+	sort.Slice(candidates, func(i, j int) bool {
+		return sizes[candidates[i].Indicator()] > sizes[candidates[j].Indicator()]
+	})
+	for _, family := range candidates {
+		if totalSize <= lowWatermark || len(families) >= limit {
+			break
+		}
+		size := sizes[family.Indicator()]
+		g.logger.Info("total memdb size is higher than the high watermark, pick family to flush",
+			logger.String("family", family.Indicator()),
+			logger.String("memdbSize", ltoml.Size(size).String()),
+			logger.String("memdbTotalSize", ltoml.Size(totalSize).String()),
+			logger.String("highWatermark", tsdbCfg.MaxMemDBTotalSize.String()),
+			logger.String("lowWatermark", tsdbCfg.TargetMemDBTotalSize.String()))
+		metrics.MemoryGovernorStatistics.PickedFamilies.WithTagValues(family.Shard().Database().Name()).Incr()
+		totalSize -= size
+		families = append(families, family)
+	}
+	return families
+}
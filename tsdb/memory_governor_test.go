@@ -0,0 +1,129 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/pkg/ltoml"
+)
+
+func TestMemoryGovernor_pick(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+		ctrl.Finish()
+	}()
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db").AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(db).AnyTimes()
+	newFamily := func(name string, size int64, flushing bool) DataFamily {
+		family := NewMockDataFamily(ctrl)
+		family.EXPECT().Indicator().Return(name).AnyTimes()
+		family.EXPECT().Shard().Return(shard).AnyTimes()
+		family.EXPECT().MemDBSize().Return(size).AnyTimes()
+		family.EXPECT().IsFlushing().Return(flushing).AnyTimes()
+		return family
+	}
+	f1 := newFamily("f1", 100, false)
+	f2 := newFamily("f2", 300, false)
+	f3 := newFamily("f3", 200, false)
+	f4 := newFamily("f4", 400, true)
+
+	cases := []struct {
+		name         string
+		high, low    ltoml.Size
+		limit        int
+		picked       map[string]struct{}
+		wantFamilies []DataFamily
+		families     []DataFamily
+	}{
+		{
+			name:     "governor disabled",
+			families: []DataFamily{f1, f2, f3},
+			limit:    3,
+		},
+		{
+			name:     "total size below high watermark",
+			high:     1000,
+			low:      800,
+			families: []DataFamily{f1, f2, f3},
+			limit:    3,
+		},
+		{
+			name:         "pick biggest family until below low watermark",
+			high:         500,
+			low:          400,
+			families:     []DataFamily{f1, f2, f3},
+			limit:        3,
+			wantFamilies: []DataFamily{f2},
+		},
+		{
+			name:         "pick families, skip flushing family",
+			high:         500,
+			low:          500,
+			families:     []DataFamily{f1, f2, f3, f4},
+			limit:        3,
+			wantFamilies: []DataFamily{f2, f3},
+		},
+		{
+			name:         "pick families restricted by limit",
+			high:         500,
+			low:          0,
+			families:     []DataFamily{f1, f2, f3},
+			limit:        2,
+			wantFamilies: []DataFamily{f2, f3},
+		},
+		{
+			name:     "no idle flush worker",
+			high:     500,
+			low:      0,
+			families: []DataFamily{f1, f2, f3},
+			limit:    0,
+		},
+		{
+			name:     "exclude picked families",
+			high:     500,
+			low:      0,
+			families: []DataFamily{f1, f2, f3},
+			picked:   map[string]struct{}{"f2": {}},
+			limit:    3,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.NewDefaultStorageBase()
+			cfg.TSDB.MaxMemDBTotalSize = tt.high
+			cfg.TSDB.TargetMemDBTotalSize = tt.low
+			config.SetGlobalStorageConfig(cfg)
+			fm := newFamilyManager()
+			for _, f := range tt.families {
+				fm.AddFamily(f)
+			}
+			g := newMemoryGovernor(fm)
+			families := g.pick(tt.limit, tt.picked)
+			assert.Equal(t, tt.wantFamilies, families)
+		})
+	}
+}
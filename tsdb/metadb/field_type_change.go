@@ -0,0 +1,68 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"path/filepath"
+
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/series/field"
+)
+
+// fieldTypeChanges represents the file name of field type change records under metadata path.
+const fieldTypeChanges = "FIELD_TYPE_CHANGES"
+
+// FieldTypeChange represents the change point of field type,
+// the data of field written before changed time was written with old type.
+type FieldTypeChange struct {
+	Namespace  string     `toml:"namespace" json:"namespace"`
+	MetricName string     `toml:"metricName" json:"metricName"`
+	FieldName  field.Name `toml:"fieldName" json:"fieldName"`
+	FieldID    field.ID   `toml:"fieldID" json:"fieldID"`
+	OldType    field.Type `toml:"oldType" json:"oldType"`
+	NewType    field.Type `toml:"newType" json:"newType"`
+	ChangedAt  int64      `toml:"changedAt" json:"changedAt"`
+}
+
+// fieldTypeChangeRecords represents the field type change records of database, persisted in FIELD_TYPE_CHANGES file.
+type fieldTypeChangeRecords struct {
+	Changes []FieldTypeChange `toml:"changes"`
+}
+
+// fieldTypeChangesPath returns the path of field type change records.
+func fieldTypeChangesPath(parent string) string {
+	return filepath.Join(parent, fieldTypeChanges)
+}
+
+// loadFieldTypeChanges loads the field type change records if exist.
+func loadFieldTypeChanges(parent string) ([]FieldTypeChange, error) {
+	path := fieldTypeChangesPath(parent)
+	if !fileutil.Exist(path) {
+		return nil, nil
+	}
+	records := &fieldTypeChangeRecords{}
+	if err := decodeTomlFn(path, records); err != nil {
+		return nil, err
+	}
+	return records.Changes, nil
+}
+
+// saveFieldTypeChanges persists the field type change records.
+func saveFieldTypeChanges(parent string, changes []FieldTypeChange) error {
+	return encodeTomlFn(fieldTypeChangesPath(parent), &fieldTypeChangeRecords{Changes: changes})
+}
@@ -36,7 +36,7 @@ type IDGenerator interface {
 	// GenMetricID generates the metric id in the memory
 	GenMetricID(namespace, metricName string) (metricID metric.ID, err error)
 	// GenFieldID generates the field id in the memory
-	// error-case1: field type doesn't match to before
+	// error-case1: field type doesn't match to before(series.ErrWrongFieldType), unless field type change is allowed
 	// error-case2: there are too many fields
 	GenFieldID(namespace, metricName string, fieldName field.Name, fieldType field.Type) (field.ID, error)
 	// GenTagKeyID generates the tag key id in the memory
@@ -54,6 +54,8 @@ type IDGetter interface {
 	// GetAllTagKeys returns the all tag keys by namespace/metric name,
 	// if not exist return  constants.ErrMetricIDNotFound.
 	GetAllTagKeys(namespace, metricName string) (tags tag.Metas, err error)
+	// GetAllTagKeysByMetricID returns the all tag keys by metric id, if not exist returns empty.
+	GetAllTagKeysByMetricID(metricID metric.ID) (tags tag.Metas, err error)
 	// GetField gets the field meta by namespace/metric name/field name, if not exist return series.ErrNotFound
 	GetField(namespace, metricName string, fieldName field.Name) (field field.Meta, err error)
 	// GetAllFields returns the all visible fields by namespace/metric name,
@@ -85,6 +87,14 @@ type MetadataDatabase interface {
 
 	// SuggestNamespace suggests the namespace by namespace's prefix
 	SuggestNamespace(prefix string, limit int) (namespaces []string, err error)
+	// SuggestCloseMetrics suggests the metric names close to the given metric name(by edit distance) in namespace,
+	// at most constants.MaxCloseMatchScanMetrics metric names are scanned.
+	SuggestCloseMetrics(namespace, metricName string, limit int) (metricNames []string, err error)
+	// SetAllowFieldTypeChange sets if the field type is allowed to be changed by writing,
+	// if not allowed, writing field with changed type returns series.ErrWrongFieldType.
+	SetAllowFieldTypeChange(allow bool)
+	// GetFieldTypeChanges returns the change points of field types.
+	GetFieldTypeChanges() []FieldTypeChange
 	// Sync syncs the pending metadata update event
 	Sync() error
 }
@@ -30,6 +30,7 @@ import (
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/pkg/fileutil"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/strutil"
 	"github.com/lindb/lindb/pkg/unique"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/metric"
@@ -72,8 +73,12 @@ type MetadataBackend interface {
 
 	// suggestNamespace suggests the namespace by namespace's prefix.
 	suggestNamespace(prefix string, limit int) (namespaces []string, err error)
-	// suggestMetricName suggests the metric name by namespace and name's prefix.
-	suggestMetricName(namespace, prefix string, limit int) (metricNames []string, err error)
+	// suggestMetricName suggests the metric name by namespace and name's prefix in order,
+	// starts after the given metric name if not empty.
+	suggestMetricName(namespace, prefix, after string, limit int) (metricNames []string, err error)
+	// suggestCloseMetricName suggests the metric names close to the given metric name(by edit distance) in namespace,
+	// scans bounded metric names.
+	suggestCloseMetricName(namespace, metricName string, limit int) (metricNames []string, err error)
 	// getMetricID gets the metric id by namespace and metric name,
 	// if not exist return constants.ErrMetricIDNotFound.
 	getMetricID(namespace string, metricName string) (metricID metric.ID, err error)
@@ -221,7 +226,7 @@ func newStorageDB(parent string) (map[string]unique.IDStore, error) {
 
 // suggestNamespace suggests the namespace by namespace's prefix.
 func (mb *metadataBackend) suggestNamespace(prefix string, limit int) (namespaces []string, err error) {
-	values, err := mb.namespace.IterKeys([]byte(prefix), limit)
+	values, err := mb.namespace.IterKeys([]byte(prefix), nil, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -234,8 +239,9 @@ func (mb *metadataBackend) suggestNamespace(prefix string, limit int) (namespace
 	return
 }
 
-// suggestMetricName suggests the metric name by namespace and name's prefix.
-func (mb *metadataBackend) suggestMetricName(namespace, prefix string, limit int) (metricNames []string, err error) {
+// suggestMetricName suggests the metric name by namespace and name's prefix in order,
+// starts after the given metric name if not empty.
+func (mb *metadataBackend) suggestMetricName(namespace, prefix, after string, limit int) (metricNames []string, err error) {
 	// 1. get namespace id
 	namespaceVal, exist, err := mb.namespace.Get([]byte(namespace))
 	if err != nil {
@@ -248,7 +254,12 @@ func (mb *metadataBackend) suggestMetricName(namespace, prefix string, limit int
 	var key []byte
 	key = append(key, namespaceVal...)
 	key = append(key, prefix...)
-	values, err := mb.metric.IterKeys(key, limit)
+	var afterKey []byte
+	if after != "" {
+		afterKey = append(afterKey, namespaceVal...)
+		afterKey = append(afterKey, after...)
+	}
+	values, err := mb.metric.IterKeys(key, afterKey, limit)
 	if err != nil {
 		return
 	}
@@ -259,6 +270,33 @@ func (mb *metadataBackend) suggestMetricName(namespace, prefix string, limit int
 	return
 }
 
+// suggestCloseMetricName suggests the metric names close to the given metric name(by edit distance) in namespace,
+// scans at most constants.MaxCloseMatchScanMetrics metric names page by page.
+func (mb *metadataBackend) suggestCloseMetricName(namespace, metricName string, limit int) (metricNames []string, err error) {
+	namespaceVal, exist, err := mb.namespace.Get([]byte(namespace))
+	if err != nil || !exist {
+		return nil, err
+	}
+	matcher := strutil.NewFuzzyMatcher(metricName, limit)
+	nsLen := len(namespaceVal)
+	var afterKey []byte
+	for scanned := 0; scanned < constants.MaxCloseMatchScanMetrics; {
+		values, err := mb.metric.IterKeys(namespaceVal, afterKey, constants.MaxSuggestionPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, val := range values {
+			matcher.Add(string(val[nsLen:]))
+		}
+		scanned += len(values)
+		if len(values) < constants.MaxSuggestionPageSize {
+			break
+		}
+		afterKey = values[len(values)-1]
+	}
+	return matcher.Matches(), nil
+}
+
 // getMetricID gets the metric id by namespace and metric name,
 // if not exist return constants.ErrMetricIDNotFound.
 func (mb *metadataBackend) getMetricID(namespace, metricName string) (metricID metric.ID, err error) {
@@ -350,7 +388,23 @@ func (mb *metadataBackend) getAllFields(metricID metric.ID) (fields field.Metas,
 	if err != nil {
 		return nil, 0, err
 	}
-	return
+	return latestFields(fields), max, nil
+}
+
+// latestFields keeps the latest meta of each field in saved order,
+// because field meta with same id is saved again after field type changed.
+func latestFields(fields field.Metas) field.Metas {
+	positions := make(map[field.ID]int, len(fields))
+	rs := make(field.Metas, 0, len(fields))
+	for _, f := range fields {
+		if idx, ok := positions[f.ID]; ok {
+			rs[idx] = f
+			continue
+		}
+		positions[f.ID] = len(rs)
+		rs = append(rs, f)
+	}
+	return rs
 }
 
 // getOrCreateMetricMetadata creates metric metadata if not exist, else load metric metadata from backend storage.
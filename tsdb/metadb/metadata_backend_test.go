@@ -185,7 +185,7 @@ func TestMetadataBackend_suggestNamespace(t *testing.T) {
 		{
 			name: "suggest failure",
 			prepare: func(idStore *unique.MockIDStore) {
-				idStore.EXPECT().IterKeys(gomock.Any(), gomock.Any()).
+				idStore.EXPECT().IterKeys(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return(nil, fmt.Errorf("err"))
 			},
 			out: struct {
@@ -199,7 +199,7 @@ func TestMetadataBackend_suggestNamespace(t *testing.T) {
 		{
 			name: "suggest successfully",
 			prepare: func(idStore *unique.MockIDStore) {
-				idStore.EXPECT().IterKeys(gomock.Any(), gomock.Any()).
+				idStore.EXPECT().IterKeys(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return([][]byte{[]byte("test"), []byte("ns"), namespaceIDSequenceKey}, nil)
 			},
 			out: struct {
@@ -272,7 +272,7 @@ func TestMetadataBackend_suggestMetricName(t *testing.T) {
 			name: "suggest metric name failure",
 			prepare: func(ns, metric *unique.MockIDStore) {
 				ns.EXPECT().Get(gomock.Any()).Return([]byte{1, 2, 3, 4}, true, nil)
-				metric.EXPECT().IterKeys(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+				metric.EXPECT().IterKeys(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
 			},
 			out: struct {
 				metricNames []string
@@ -286,7 +286,7 @@ func TestMetadataBackend_suggestMetricName(t *testing.T) {
 			name: "suggest metric name successfully",
 			prepare: func(ns, metric *unique.MockIDStore) {
 				ns.EXPECT().Get(gomock.Any()).Return([]byte{1, 2, 3, 4}, true, nil)
-				metric.EXPECT().IterKeys(gomock.Any(), gomock.Any()).
+				metric.EXPECT().IterKeys(gomock.Any(), gomock.Any(), gomock.Any()).
 					Return([][]byte{[]byte("1234name")}, nil)
 			},
 			out: struct {
@@ -312,11 +312,107 @@ func TestMetadataBackend_suggestMetricName(t *testing.T) {
 				tt.prepare(nsStore, metricStore)
 			}
 
-			metricNames, err := backend.suggestMetricName("ns", "name", 10)
+			metricNames, err := backend.suggestMetricName("ns", "name", "", 10)
 			assert.Equal(t, tt.out.metricNames, metricNames)
 			assert.Equal(t, tt.out.err, err)
 		})
 	}
+
+	t.Run("suggest metric name after given name", func(t *testing.T) {
+		nsStore := unique.NewMockIDStore(ctrl)
+		metricStore := unique.NewMockIDStore(ctrl)
+		backend := &metadataBackend{
+			namespace: nsStore,
+			metric:    metricStore,
+		}
+		nsStore.EXPECT().Get(gomock.Any()).Return([]byte{1, 2, 3, 4}, true, nil)
+		metricStore.EXPECT().IterKeys([]byte("\x01\x02\x03\x04name"), []byte("\x01\x02\x03\x04name.a"), 10).
+			Return([][]byte{[]byte("1234name.b")}, nil)
+		metricNames, err := backend.suggestMetricName("ns", "name", "name.a", 10)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"name.b"}, metricNames)
+	})
+}
+
+func TestMetadataBackend_suggestCloseMetricName(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nsID := []byte{1, 2, 3, 4}
+	cases := []struct {
+		name        string
+		prepare     func(ns, metric *unique.MockIDStore)
+		metricNames []string
+		wantErr     bool
+	}{
+		{
+			name: "get ns id failure",
+			prepare: func(ns, _ *unique.MockIDStore) {
+				ns.EXPECT().Get(gomock.Any()).Return(nil, false, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "ns id not found",
+			prepare: func(ns, _ *unique.MockIDStore) {
+				ns.EXPECT().Get(gomock.Any()).Return(nil, false, nil)
+			},
+		},
+		{
+			name: "scan metric name failure",
+			prepare: func(ns, metric *unique.MockIDStore) {
+				ns.EXPECT().Get(gomock.Any()).Return(nsID, true, nil)
+				metric.EXPECT().IterKeys(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "suggest close metric names page by page",
+			prepare: func(ns, metric *unique.MockIDStore) {
+				ns.EXPECT().Get(gomock.Any()).Return(nsID, true, nil)
+				page := make([][]byte, constants.MaxSuggestionPageSize)
+				for idx := range page {
+					page[idx] = append(append([]byte{}, nsID...), fmt.Sprintf("a_%05d", idx)...)
+				}
+				page[10] = append(append([]byte{}, nsID...), "cpu_usage"...)
+				gomock.InOrder(
+					metric.EXPECT().IterKeys(nsID, nil, constants.MaxSuggestionPageSize).Return(page, nil),
+					metric.EXPECT().IterKeys(nsID, page[len(page)-1], constants.MaxSuggestionPageSize).
+						Return([][]byte{append(append([]byte{}, nsID...), "cpu_usage_sum"...),
+							append(append([]byte{}, nsID...), "cpu_user"...)}, nil),
+				)
+			},
+			metricNames: []string{"cpu_usage", "cpu_user"},
+		},
+		{
+			name: "scan bounded metric names",
+			prepare: func(ns, metric *unique.MockIDStore) {
+				ns.EXPECT().Get(gomock.Any()).Return(nsID, true, nil)
+				page := make([][]byte, constants.MaxSuggestionPageSize)
+				for idx := range page {
+					page[idx] = append(append([]byte{}, nsID...), "cpu_usage"...)
+				}
+				metric.EXPECT().IterKeys(gomock.Any(), gomock.Any(), gomock.Any()).Return(page, nil).
+					Times(constants.MaxCloseMatchScanMetrics / constants.MaxSuggestionPageSize)
+			},
+			metricNames: []string{"cpu_usage", "cpu_usage"},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			nsStore := unique.NewMockIDStore(ctrl)
+			metricStore := unique.NewMockIDStore(ctrl)
+			backend := &metadataBackend{
+				namespace: nsStore,
+				metric:    metricStore,
+			}
+			tt.prepare(nsStore, metricStore)
+			metricNames, err := backend.suggestCloseMetricName("ns", "cpu_usge", 2)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.metricNames, metricNames)
+		})
+	}
 }
 
 func TestMetadataBackend_getMetricID(t *testing.T) {
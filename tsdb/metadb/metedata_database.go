@@ -23,9 +23,13 @@ import (
 	"sync"
 
 	commonseries "github.com/lindb/common/series"
+	"go.uber.org/atomic"
 
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/ltoml"
+	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/metric"
@@ -35,6 +39,8 @@ import (
 // for testing
 var (
 	createMetadataBackendFn = newMetadataBackend
+	encodeTomlFn            = ltoml.EncodeToml
+	decodeTomlFn            = ltoml.DecodeToml
 )
 
 // metadataDatabase implements the MetadataDatabase interface,
@@ -47,6 +53,9 @@ type metadataDatabase struct {
 	backend      MetadataBackend
 	metrics      map[string]MetricMetadata // metadata cache(key: namespace + delimiter + metric-name, value: metric metadata)
 
+	allowFieldTypeChange atomic.Bool
+	fieldTypeChanges     []FieldTypeChange // change points of field types
+
 	rwMux sync.RWMutex
 
 	statistics *metrics.MetaDBStatistics
@@ -54,6 +63,10 @@ type metadataDatabase struct {
 
 // NewMetadataDatabase creates new metadata database
 func NewMetadataDatabase(ctx context.Context, databaseName, parent string) (MetadataDatabase, error) {
+	fieldTypeChanges, err := loadFieldTypeChanges(parent)
+	if err != nil {
+		return nil, err
+	}
 	backend, err := createMetadataBackendFn(parent)
 	if err != nil {
 		return nil, err
@@ -61,24 +74,44 @@ func NewMetadataDatabase(ctx context.Context, databaseName, parent string) (Meta
 
 	c, cancel := context.WithCancel(ctx)
 	return &metadataDatabase{
-		databaseName: databaseName,
-		path:         parent,
-		ctx:          c,
-		cancel:       cancel,
-		backend:      backend,
-		metrics:      make(map[string]MetricMetadata),
-		statistics:   metrics.NewMetaDBStatistics(databaseName),
+		databaseName:     databaseName,
+		path:             parent,
+		ctx:              c,
+		cancel:           cancel,
+		backend:          backend,
+		metrics:          make(map[string]MetricMetadata),
+		fieldTypeChanges: fieldTypeChanges,
+		statistics:       metrics.NewMetaDBStatistics(databaseName),
 	}, nil
 }
 
+// SetAllowFieldTypeChange sets if the field type is allowed to be changed by writing.
+func (mdb *metadataDatabase) SetAllowFieldTypeChange(allow bool) {
+	mdb.allowFieldTypeChange.Store(allow)
+}
+
+// GetFieldTypeChanges returns the change points of field types.
+func (mdb *metadataDatabase) GetFieldTypeChanges() []FieldTypeChange {
+	mdb.rwMux.RLock()
+	defer mdb.rwMux.RUnlock()
+
+	return append([]FieldTypeChange(nil), mdb.fieldTypeChanges...)
+}
+
 // SuggestNamespace suggests the namespace by namespace's prefix
 func (mdb *metadataDatabase) SuggestNamespace(prefix string, limit int) (namespaces []string, err error) {
 	return mdb.backend.suggestNamespace(prefix, limit)
 }
 
-// SuggestMetrics returns suggestions from a given prefix of metricName
-func (mdb *metadataDatabase) SuggestMetrics(namespace, metricPrefix string, limit int) ([]string, error) {
-	return mdb.backend.suggestMetricName(namespace, metricPrefix, limit)
+// SuggestMetrics returns suggestions from a given prefix of metricName in order,
+// starts after the given metric name if not empty(for paginated query).
+func (mdb *metadataDatabase) SuggestMetrics(namespace, metricPrefix, after string, limit int) ([]string, error) {
+	return mdb.backend.suggestMetricName(namespace, metricPrefix, after, limit)
+}
+
+// SuggestCloseMetrics suggests the metric names close to the given metric name(by edit distance) in namespace.
+func (mdb *metadataDatabase) SuggestCloseMetrics(namespace, metricName string, limit int) ([]string, error) {
+	return mdb.backend.suggestCloseMetricName(namespace, metricName, limit)
 }
 
 // GetMetricID gets the metric id by namespace and metric name, if not exist return constants.ErrMetricIDNotFound.
@@ -111,6 +144,11 @@ func (mdb *metadataDatabase) GetAllTagKeys(namespace, metricName string) (tags t
 	return
 }
 
+// GetAllTagKeysByMetricID returns the all tag keys by metric id, if not exist returns empty.
+func (mdb *metadataDatabase) GetAllTagKeysByMetricID(metricID metric.ID) (tags tag.Metas, err error) {
+	return mdb.backend.getAllTagKeys(metricID)
+}
+
 // GetTagKeyID gets the tag key id by namespace/metric name/tag key, if not exist return constants.ErrTagKeyIDNotFound
 func (mdb *metadataDatabase) GetTagKeyID(namespace, metricName, tagKey string) (tagKeyID tag.KeyID, err error) {
 	tagKeys, err := mdb.GetAllTagKeys(namespace, metricName)
@@ -215,9 +253,13 @@ func (mdb *metadataDatabase) GenFieldID(
 		if f.Type == fieldType {
 			return f.ID, nil
 		}
+		if mdb.allowFieldTypeChange.Load() {
+			return mdb.changeFieldType(namespace, metricName, metricMetadata, f, fieldType)
+		}
+		mdb.statistics.FieldTypeConflicts.Incr()
 		mdb.statistics.GenFieldIDFailures.Incr()
-		return field.EmptyFieldID, fmt.Errorf("field name:%s,field type:%s/%s,err:%s", fieldName,
-			fieldType.String(), f.Type.String(), series.ErrWrongFieldType)
+		return field.EmptyFieldID, fmt.Errorf("%w, field name: %s, field type: %s, stored type: %s",
+			series.ErrWrongFieldType, fieldName, fieldType.String(), f.Type.String())
 	}
 	// assign new field id, then add field into metric metadata
 	fieldMeta, err := metricMetadata.createField(fieldName, fieldType)
@@ -235,6 +277,44 @@ func (mdb *metadataDatabase) GenFieldID(
 	return fieldMeta.ID, nil
 }
 
+// changeFieldType changes the type of field with same field id, records the change point before saving new type,
+// must be called with lock.
+func (mdb *metadataDatabase) changeFieldType(
+	namespace, metricName string,
+	metricMetadata MetricMetadata,
+	f field.Meta, fieldType field.Type,
+) (field.ID, error) {
+	change := FieldTypeChange{
+		Namespace:  namespace,
+		MetricName: metricName,
+		FieldName:  f.Name,
+		FieldID:    f.ID,
+		OldType:    f.Type,
+		NewType:    fieldType,
+		ChangedAt:  timeutil.Now(),
+	}
+	changes := append(append([]FieldTypeChange(nil), mdb.fieldTypeChanges...), change)
+	if err := saveFieldTypeChanges(mdb.path, changes); err != nil {
+		mdb.statistics.GenFieldIDFailures.Incr()
+		return field.EmptyFieldID, err
+	}
+	mdb.fieldTypeChanges = changes
+	fieldMeta, _ := metricMetadata.changeFieldType(f.Name, fieldType)
+	if err := mdb.backend.saveField(metricMetadata.getMetricID(), fieldMeta); err != nil {
+		mdb.statistics.GenFieldIDFailures.Incr()
+		return field.EmptyFieldID, err
+	}
+	mdb.statistics.FieldTypeChanges.Incr()
+	metaLogger.Warn("field type changed by writing",
+		logger.String("database", mdb.databaseName),
+		logger.String("namespace", namespace),
+		logger.String("metric", metricName),
+		logger.String("field", string(f.Name)),
+		logger.String("oldType", f.Type.String()),
+		logger.String("newType", fieldType.String()))
+	return fieldMeta.ID, nil
+}
+
 // GenTagKeyID generates the tag key id in the memory
 // !!!!! NOTICE: metric metadata must be existed in memory, because gen metric has been saved
 func (mdb *metadataDatabase) GenTagKeyID(namespace, metricName, tagKey string) (tagKeyID tag.KeyID, err error) {
@@ -19,6 +19,7 @@ package metadb
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -28,6 +29,8 @@ import (
 	commonseries "github.com/lindb/common/series"
 
 	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/ltoml"
 	"github.com/lindb/lindb/series"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/series/metric"
@@ -83,12 +86,26 @@ func TestMetadataDatabase_SuggestMetricName(t *testing.T) {
 	db := &metadataDatabase{
 		backend: mockBackend,
 	}
-	mockBackend.EXPECT().suggestMetricName(gomock.Any(), gomock.Any(), gomock.Any()).Return([]string{"a"}, nil)
-	values, err := db.SuggestMetrics("ns", "pp", 10)
+	mockBackend.EXPECT().suggestMetricName(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return([]string{"a"}, nil)
+	values, err := db.SuggestMetrics("ns", "pp", "", 10)
 	assert.NoError(t, err)
 	assert.Equal(t, []string{"a"}, values)
 }
 
+func TestMetadataDatabase_SuggestCloseMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockBackend := NewMockMetadataBackend(ctrl)
+	db := &metadataDatabase{
+		backend: mockBackend,
+	}
+	mockBackend.EXPECT().suggestCloseMetricName("ns", "cpu_usge", 5).Return([]string{"cpu_usage"}, nil)
+	values, err := db.SuggestCloseMetrics("ns", "cpu_usge", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cpu_usage"}, values)
+}
+
 func TestMetadataDatabase_GetMetricID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -194,6 +211,25 @@ func TestMetadataDatabase_GetAllTagKeys(t *testing.T) {
 	}
 }
 
+func TestMetadataDatabase_GetAllTagKeysByMetricID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		createMetadataBackendFn = newMetadataBackend
+		ctrl.Finish()
+	}()
+	backend := NewMockMetadataBackend(ctrl)
+	createMetadataBackendFn = func(parent string) (MetadataBackend, error) {
+		return backend, nil
+	}
+	tags := tag.Metas{{ID: 1, Key: "key1"}, {ID: 2, Key: "key2"}}
+	db := newMockMetadataDatabase(t, t.TempDir())
+
+	backend.EXPECT().getAllTagKeys(metric.ID(3)).Return(tags, nil)
+	rs, err := db.GetAllTagKeysByMetricID(metric.ID(3))
+	assert.NoError(t, err)
+	assert.Equal(t, tags, rs)
+}
+
 func TestMetadataDatabase_GetTagKey(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
@@ -624,8 +660,8 @@ func TestMetadataDatabase_GenFieldID(t *testing.T) {
 			out: struct {
 				id  field.ID
 				err error
-			}{id: field.EmptyFieldID, err: fmt.Errorf("field name:%s,field type:%s/%s,err:%s", "sum",
-				field.MaxField.String(), field.SumField.String(), series.ErrWrongFieldType)},
+			}{id: field.EmptyFieldID, err: fmt.Errorf("%w, field name: %s, field type: %s, stored type: %s",
+				series.ErrWrongFieldType, "sum", field.MaxField.String(), field.SumField.String())},
 		},
 		{
 			name:       "get field from memory cache",
@@ -698,6 +734,98 @@ func TestMetadataDatabase_GenFieldID(t *testing.T) {
 	}
 }
 
+func TestMetadataDatabase_FieldTypeChange_Restart(t *testing.T) {
+	dir := t.TempDir()
+	open := func() MetadataDatabase {
+		db := newMockMetadataDatabase(t, dir)
+		_, err := db.GenMetricID("ns", "cpu")
+		assert.NoError(t, err)
+		return db
+	}
+	db := open()
+	sumID, err := db.GenFieldID("ns", "cpu", "f1", field.SumField)
+	assert.NoError(t, err)
+	_, err = db.GenFieldID("ns", "cpu", "f2", field.MaxField)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Close())
+
+	// field type is validated against stored schema after restart
+	db = open()
+	id, err := db.GenFieldID("ns", "cpu", "f1", field.LastField)
+	assert.True(t, errors.Is(err, series.ErrWrongFieldType))
+	assert.Equal(t, field.EmptyFieldID, id)
+	id, err = db.GenFieldID("ns", "cpu", "f1", field.SumField)
+	assert.NoError(t, err)
+	assert.Equal(t, sumID, id)
+
+	// field type changed with same field id if allowed
+	db.SetAllowFieldTypeChange(true)
+	id, err = db.GenFieldID("ns", "cpu", "f1", field.LastField)
+	assert.NoError(t, err)
+	assert.Equal(t, sumID, id)
+	assert.NoError(t, db.Close())
+
+	db = open()
+	f, err := db.GetField("ns", "cpu", "f1")
+	assert.NoError(t, err)
+	assert.Equal(t, field.Meta{ID: sumID, Name: "f1", Type: field.LastField}, f)
+	fields, err := db.GetAllFields("ns", "cpu")
+	assert.NoError(t, err)
+	assert.Len(t, fields, 2)
+	changes := db.GetFieldTypeChanges()
+	assert.Len(t, changes, 1)
+	assert.Equal(t, field.SumField, changes[0].OldType)
+	assert.Equal(t, field.LastField, changes[0].NewType)
+	assert.Equal(t, sumID, changes[0].FieldID)
+	assert.True(t, changes[0].ChangedAt > 0)
+	_, err = db.GenFieldID("ns", "cpu", "f1", field.SumField)
+	assert.True(t, errors.Is(err, series.ErrWrongFieldType))
+	assert.NoError(t, db.Close())
+
+	// load change records failure
+	decodeTomlFn = func(fileName string, v interface{}) error {
+		return fmt.Errorf("err")
+	}
+	defer func() {
+		decodeTomlFn = ltoml.DecodeToml
+	}()
+	db, err = NewMetadataDatabase(context.TODO(), "test", dir)
+	assert.Error(t, err)
+	assert.Nil(t, db)
+}
+
+func TestMetadataDatabase_changeFieldType(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		encodeTomlFn = ltoml.EncodeToml
+		ctrl.Finish()
+	}()
+	mockBackend := NewMockMetadataBackend(ctrl)
+	meta := NewMockMetricMetadata(ctrl)
+	db := &metadataDatabase{
+		path:       t.TempDir(),
+		backend:    mockBackend,
+		statistics: metrics.NewMetaDBStatistics("test"),
+	}
+	f := field.Meta{ID: 1, Name: "f1", Type: field.SumField}
+
+	encodeTomlFn = func(fileName string, v interface{}) error {
+		return fmt.Errorf("err")
+	}
+	id, err := db.changeFieldType("ns", "cpu", meta, f, field.LastField)
+	assert.Error(t, err)
+	assert.Equal(t, field.EmptyFieldID, id)
+	assert.Empty(t, db.GetFieldTypeChanges())
+
+	encodeTomlFn = ltoml.EncodeToml
+	meta.EXPECT().changeFieldType(f.Name, field.LastField).Return(field.Meta{ID: 1, Name: "f1", Type: field.LastField}, true)
+	meta.EXPECT().getMetricID().Return(metric.ID(1))
+	mockBackend.EXPECT().saveField(metric.ID(1), gomock.Any()).Return(fmt.Errorf("err"))
+	id, err = db.changeFieldType("ns", "cpu", meta, f, field.LastField)
+	assert.Error(t, err)
+	assert.Equal(t, field.EmptyFieldID, id)
+}
+
 func TestMetadataDatabase_GenTagKeyID(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
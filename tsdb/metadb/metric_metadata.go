@@ -43,6 +43,8 @@ type MetricMetadata interface {
 	createField(fieldName field.Name, fieldType field.Type) (field.Meta, error)
 	// getField gets the field meta by field name, if not exist return false
 	getField(fieldName field.Name) (field.Meta, bool)
+	// changeFieldType changes the type of field with same field id, if not exist return false
+	changeFieldType(fieldName field.Name, fieldType field.Type) (field.Meta, bool)
 	// getAllFields returns the all fields of the metric
 	getAllFields() (fields field.Metas)
 
@@ -107,6 +109,17 @@ func (mm *metricMetadata) getField(fieldName field.Name) (field.Meta, bool) {
 	return mm.fields.Find(fieldName)
 }
 
+// changeFieldType changes the type of field with same field id, if not exist return false
+func (mm *metricMetadata) changeFieldType(fieldName field.Name, fieldType field.Type) (field.Meta, bool) {
+	for idx := range mm.fields {
+		if mm.fields[idx].Name == fieldName {
+			mm.fields[idx].Type = fieldType
+			return mm.fields[idx], true
+		}
+	}
+	return field.Meta{}, false
+}
+
 // getAllFields returns the all fields of the metric
 func (mm *metricMetadata) getAllFields() (fields field.Metas) {
 	length := len(mm.fields)
@@ -116,6 +116,20 @@ func TestMetricMetadata_getField(t *testing.T) {
 	assert.False(t, ok)
 }
 
+func TestMetricMetadata_changeFieldType(t *testing.T) {
+	m := newMetricMetadata(metric.ID(2))
+	m.initialize(field.Metas{{ID: field.ID(1), Type: field.SumField, Name: "sum"}}, 1, nil)
+	f, ok := m.changeFieldType("sum", field.LastField)
+	assert.True(t, ok)
+	assert.Equal(t, field.Meta{ID: field.ID(1), Type: field.LastField, Name: "sum"}, f)
+	f, ok = m.getField("sum")
+	assert.True(t, ok)
+	assert.Equal(t, field.LastField, f.Type)
+	f, ok = m.changeFieldType("min", field.LastField)
+	assert.False(t, ok)
+	assert.Equal(t, field.Meta{}, f)
+}
+
 func TestMetricMetadata_createTagKey(t *testing.T) {
 	m := newMetricMetadata(metric.ID(2))
 	mid := m.getMetricID()
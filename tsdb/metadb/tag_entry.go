@@ -25,6 +25,7 @@ import (
 
 	"github.com/lindb/roaring"
 
+	"github.com/lindb/lindb/pkg/strutil"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
@@ -188,7 +189,7 @@ func (t *tagEntry) findSeriesIDsByRegex(expr *stmt.RegexExpr) *roaring.Bitmap {
 		return nil
 	}
 	// the regex pattern is regarded as a prefix string + pattern
-	literalPrefix, _ := pattern.LiteralPrefix()
+	literalPrefix := strutil.RegexLiteralPrefix(pattern)
 	result := roaring.New()
 	for value, tagValueID := range t.tagValues {
 		if !strings.HasPrefix(value, literalPrefix) {
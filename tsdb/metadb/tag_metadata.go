@@ -21,6 +21,7 @@ import (
 	"errors"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/lindb/roaring"
 
@@ -47,6 +48,10 @@ type TagMetadata interface {
 	GenTagValueID(tagKeyID tag.KeyID, tagValue string) (uint32, error)
 	// SuggestTagValues returns suggestions from given tag key id and prefix of tag value
 	SuggestTagValues(tagKeyID tag.KeyID, tagValuePrefix string, limit int) []string
+	// FindTagValues finds tag values which begin with prefix and match tag filter expr(optional) for spec tag key,
+	// stops scanning once limit reached, truncated is true if there are more matched tag values.
+	FindTagValues(tagKeyID tag.KeyID, tagValuePrefix string, expr stmt.TagFilter, limit int,
+	) (tagValues []string, truncated bool, err error)
 	// FindTagValueDsByExpr finds tag value ids by tag filter expr for spec tag key,
 	// if not exist, return nil, constants.ErrNotFound, else returns tag value ids
 	FindTagValueDsByExpr(tagKeyID tag.KeyID, expr stmt.TagFilter) (*roaring.Bitmap, error)
@@ -185,6 +190,68 @@ func (m *tagMetadata) SuggestTagValues(tagKeyID tag.KeyID, tagValuePrefix string
 	return result
 }
 
+// FindTagValues finds tag values which begin with prefix and match tag filter expr(optional) for spec tag key,
+// tag values in kv store are scanned by prefix iterator of trie tree, stops scanning once limit reached.
+func (m *tagMetadata) FindTagValues(tagKeyID tag.KeyID, tagValuePrefix string, expr stmt.TagFilter, limit int,
+) (tagValues []string, truncated bool, err error) {
+	start := time.Now()
+	scanned := 0
+	m.statistics.FindTagValues.Incr()
+	defer func() {
+		m.statistics.ScanTagValues.Add(float64(scanned))
+		m.statistics.FindTagValuesDuration.UpdateSince(start)
+		if err != nil {
+			m.statistics.FindTagValueFailures.Incr()
+		}
+	}()
+
+	matcher, err := newTagValueMatcher(tagValuePrefix, expr)
+	if err != nil {
+		return nil, false, err
+	}
+	found := make(map[string]struct{})
+	// collect returns false if it has enough tag values
+	collect := func(tagValue string) bool {
+		scanned++
+		if !strings.HasPrefix(tagValue, matcher.prefix) || !matcher.match(tagValue) {
+			return true
+		}
+		if _, ok := found[tagValue]; ok {
+			return true
+		}
+		if limit > 0 && len(tagValues) >= limit {
+			truncated = true
+			return false
+		}
+		found[tagValue] = struct{}{}
+		tagValues = append(tagValues, tagValue)
+		return true
+	}
+	m.loadTagValueIDsInMem(tagKeyID, func(tagEntry TagEntry) {
+		if truncated {
+			return
+		}
+		for tagValue := range tagEntry.getTagValues() {
+			if !collect(tagValue) {
+				return
+			}
+		}
+	})
+	if truncated {
+		return tagValues, truncated, nil
+	}
+	err = m.loadTagValueIDsInKV(tagKeyID, func(reader tagkeymeta.Reader) error {
+		return reader.WalkTagValues(tagKeyID, matcher.prefix, func(tagValue []byte, _ uint32) bool {
+			// copy tag value, because underlying bytes will be reused
+			return collect(string(tagValue))
+		})
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return tagValues, truncated, nil
+}
+
 // FindTagValueDsByExpr finds tag value ids by tag filter expr for spec tag key,
 // if not exist, return nil, constants.ErrNotFound, else returns tag value ids
 func (m *tagMetadata) FindTagValueDsByExpr(tagKeyID tag.KeyID, expr stmt.TagFilter) (*roaring.Bitmap, error) {
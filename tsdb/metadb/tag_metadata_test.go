@@ -143,6 +143,75 @@ func TestTagMetadata_SuggestTagValues(t *testing.T) {
 	assert.Equal(t, []string{"tag-value-8"}, values)
 }
 
+func TestTagMetadata_FindTagValues(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newTagReaderFunc = tagkeymeta.NewReader
+		ctrl.Finish()
+	}()
+
+	meta, _, snapshot := mockTagMetadata(ctrl)
+	m := meta.(*tagMetadata)
+	m.rwMutex.Lock()
+	tagEntry := newTagEntry(10)
+	tagEntry.addTagValue("web-1", 1)
+	tagEntry.addTagValue("db-1", 2)
+	m.mutable.Put(5, tagEntry)
+	m.rwMutex.Unlock()
+
+	r := tagkeymeta.NewMockReader(ctrl)
+	newTagReaderFunc = func(readers []table.Reader) tagkeymeta.Reader {
+		return r
+	}
+	walk := func(values ...string) func(_ tag.KeyID, _ string, fn func(tagValue []byte, tagValueID uint32) bool) error {
+		return func(_ tag.KeyID, _ string, fn func(tagValue []byte, tagValueID uint32) bool) error {
+			for idx, value := range values {
+				if !fn([]byte(value), uint32(idx)) {
+					return nil
+				}
+			}
+			return nil
+		}
+	}
+
+	t.Run("bad regex", func(t *testing.T) {
+		values, truncated, err := meta.FindTagValues(5, "", &stmt.RegexExpr{Regexp: "[a"}, 10)
+		assert.Error(t, err)
+		assert.False(t, truncated)
+		assert.Empty(t, values)
+	})
+	t.Run("find readers failure", func(t *testing.T) {
+		snapshot.EXPECT().FindReaders(gomock.Any()).Return(nil, fmt.Errorf("err"))
+		values, _, err := meta.FindTagValues(5, "web", nil, 10)
+		assert.Error(t, err)
+		assert.Empty(t, values)
+	})
+	t.Run("find in memory and kv store with dedupe", func(t *testing.T) {
+		snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{table.NewMockReader(ctrl)}, nil)
+		r.EXPECT().WalkTagValues(gomock.Any(), "web-1", gomock.Any()).
+			DoAndReturn(walk("web-1", "web-10", "web-11", "web-2"))
+		values, truncated, err := meta.FindTagValues(5, "", &stmt.RegexExpr{Regexp: "web-1.*"}, 10)
+		assert.NoError(t, err)
+		assert.False(t, truncated)
+		assert.Equal(t, []string{"web-1", "web-10", "web-11"}, values)
+	})
+	t.Run("stop scanning when limit reached", func(t *testing.T) {
+		snapshot.EXPECT().FindReaders(gomock.Any()).Return([]table.Reader{table.NewMockReader(ctrl)}, nil)
+		r.EXPECT().WalkTagValues(gomock.Any(), "web", gomock.Any()).
+			DoAndReturn(walk("web-10", "web-11", "web-12"))
+		values, truncated, err := meta.FindTagValues(5, "", &stmt.LikeExpr{Value: "web*"}, 2)
+		assert.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Equal(t, []string{"web-1", "web-10"}, values)
+	})
+	t.Run("truncated in memory", func(t *testing.T) {
+		values, truncated, err := meta.FindTagValues(5, "", nil, 1)
+		assert.NoError(t, err)
+		assert.True(t, truncated)
+		assert.Len(t, values, 1)
+	})
+}
+
 func TestTagMetadata_FindTagValueDsByExpr(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer func() {
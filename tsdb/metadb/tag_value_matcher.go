@@ -0,0 +1,104 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lindb/lindb/pkg/strutil"
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+// tagValueMatcher represents the tag value matcher built from prefix and tag filter expr,
+// all matched tag values must begin with prefix, so that it can be used for prefix scan.
+type tagValueMatcher struct {
+	prefix string
+	match  func(tagValue string) bool
+}
+
+// newTagValueMatcher creates a tag value matcher based on tag value prefix and tag filter expr(optional).
+func newTagValueMatcher(tagValuePrefix string, expr stmt.TagFilter) (*tagValueMatcher, error) {
+	exprPrefix, match, err := buildTagFilterMatch(expr)
+	if err != nil {
+		return nil, err
+	}
+	// pick the longer prefix for scanning, both prefix will be checked when matching
+	prefix := tagValuePrefix
+	if len(exprPrefix) > len(prefix) && strings.HasPrefix(exprPrefix, prefix) {
+		prefix = exprPrefix
+	}
+	return &tagValueMatcher{
+		prefix: prefix,
+		match: func(tagValue string) bool {
+			return strings.HasPrefix(tagValue, tagValuePrefix) && match(tagValue)
+		},
+	}, nil
+}
+
+// buildTagFilterMatch builds match function and the literal prefix of matched tag values by tag filter expr,
+// semantics of like/regex are same as tag entry.
+func buildTagFilterMatch(expr stmt.TagFilter) (prefix string, match func(tagValue string) bool, err error) {
+	matchAll := func(_ string) bool { return true }
+	switch expression := expr.(type) {
+	case nil:
+		return "", matchAll, nil
+	case *stmt.EqualsExpr:
+		return expression.Value, func(tagValue string) bool { return tagValue == expression.Value }, nil
+	case *stmt.InExpr:
+		values := make(map[string]struct{}, len(expression.Values))
+		for _, value := range expression.Values {
+			values[value] = struct{}{}
+		}
+		return "", func(tagValue string) bool {
+			_, ok := values[tagValue]
+			return ok
+		}, nil
+	case *stmt.LikeExpr:
+		likeTo := expression.Value
+		length := len(likeTo)
+		hasPrefix := strings.HasPrefix(likeTo, "*")
+		hasSuffix := strings.HasSuffix(likeTo, "*")
+		switch {
+		case length == 0:
+			return "", func(_ string) bool { return false }, nil
+		case likeTo == "*":
+			return "", matchAll, nil
+		case hasPrefix && hasSuffix:
+			like := likeTo[1 : length-1]
+			return "", func(tagValue string) bool { return strings.Contains(tagValue, like) }, nil
+		case hasPrefix:
+			like := likeTo[1:]
+			return "", func(tagValue string) bool { return strings.HasSuffix(tagValue, like) }, nil
+		case hasSuffix:
+			like := likeTo[:length-1]
+			return like, func(tagValue string) bool { return strings.HasPrefix(tagValue, like) }, nil
+		default:
+			return likeTo, func(tagValue string) bool { return tagValue == likeTo }, nil
+		}
+	case *stmt.RegexExpr:
+		pattern, err := regexp.Compile(expression.Regexp)
+		if err != nil {
+			return "", nil, err
+		}
+		// the regex pattern is regarded as a prefix string + pattern
+		return strutil.RegexLiteralPrefix(pattern), pattern.MatchString, nil
+	}
+	return "", nil, fmt.Errorf("not support tag filter expr for matching tag value: %s", expr.Rewrite())
+}
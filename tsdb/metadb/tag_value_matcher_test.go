@@ -0,0 +1,134 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metadb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/sql/stmt"
+)
+
+func TestTagValueMatcher(t *testing.T) {
+	cases := []struct {
+		name       string
+		prefix     string
+		expr       stmt.TagFilter
+		scanPrefix string
+		matches    []string
+		notMatches []string
+		wantErr    bool
+	}{
+		{
+			name:       "prefix only",
+			prefix:     "web",
+			scanPrefix: "web",
+			matches:    []string{"web", "web-1"},
+			notMatches: []string{"db-1"},
+		},
+		{
+			name:       "equals",
+			expr:       &stmt.EqualsExpr{Value: "web-1"},
+			scanPrefix: "web-1",
+			matches:    []string{"web-1"},
+			notMatches: []string{"web-10"},
+		},
+		{
+			name:       "in",
+			expr:       &stmt.InExpr{Values: []string{"a", "b"}},
+			matches:    []string{"a", "b"},
+			notMatches: []string{"c"},
+		},
+		{
+			name:       "empty like",
+			expr:       &stmt.LikeExpr{Value: ""},
+			notMatches: []string{"a"},
+		},
+		{
+			name:    "like all",
+			expr:    &stmt.LikeExpr{Value: "*"},
+			matches: []string{"a", "b"},
+		},
+		{
+			name:       "like contains",
+			expr:       &stmt.LikeExpr{Value: "*eb*"},
+			matches:    []string{"web-1"},
+			notMatches: []string{"db-1"},
+		},
+		{
+			name:       "like suffix",
+			expr:       &stmt.LikeExpr{Value: "*-1"},
+			matches:    []string{"web-1"},
+			notMatches: []string{"web-2"},
+		},
+		{
+			name:       "like prefix",
+			prefix:     "w",
+			expr:       &stmt.LikeExpr{Value: "web*"},
+			scanPrefix: "web",
+			matches:    []string{"web-1"},
+			notMatches: []string{"db-1"},
+		},
+		{
+			name:       "like equals",
+			expr:       &stmt.LikeExpr{Value: "web"},
+			scanPrefix: "web",
+			matches:    []string{"web"},
+			notMatches: []string{"web-1"},
+		},
+		{
+			name:       "regex",
+			expr:       &stmt.RegexExpr{Regexp: "web-1.*"},
+			scanPrefix: "web-1",
+			matches:    []string{"web-1", "web-10"},
+			notMatches: []string{"web-2"},
+		},
+		{
+			name:       "prefix not match expr prefix",
+			prefix:     "db",
+			expr:       &stmt.RegexExpr{Regexp: "web-1.*"},
+			scanPrefix: "db",
+			notMatches: []string{"web-1", "db-1"},
+		},
+		{
+			name:    "bad regex",
+			expr:    &stmt.RegexExpr{Regexp: "[a"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := newTagValueMatcher(tt.prefix, tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newTagValueMatcher() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.scanPrefix, matcher.prefix)
+			for _, value := range tt.matches {
+				assert.True(t, matcher.match(value), value)
+			}
+			for _, value := range tt.notMatches {
+				assert.False(t, matcher.match(value), value)
+			}
+		})
+	}
+}
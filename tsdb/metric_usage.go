@@ -0,0 +1,326 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+//go:generate mockgen -source=./metric_usage.go -destination=./metric_usage_mock.go -package=tsdb
+
+// for testing
+var (
+	metricUsageNowFn = time.Now
+)
+
+const (
+	// metricUsageShards is the number of sketch shards, reduces lock contention on write path.
+	metricUsageShards = 16
+	// metricUsageCapacity is the max number of metrics tracked(all shards), bounds memory under metric-id churn.
+	metricUsageCapacity = 4096
+	// metricUsageDecayInterval is the interval of halving counters, old usage fades out.
+	metricUsageDecayInterval = 10 * time.Minute
+)
+
+var (
+	mUsageTracker     MetricUsageTracker
+	mUsageTrackerLock sync.RWMutex
+)
+
+// GetMetricUsageTracker returns the metric usage tracker singleton instance.
+func GetMetricUsageTracker() MetricUsageTracker {
+	mUsageTrackerLock.RLock()
+	tracker := mUsageTracker
+	mUsageTrackerLock.RUnlock()
+	if tracker != nil {
+		return tracker
+	}
+	mUsageTrackerLock.Lock()
+	defer mUsageTrackerLock.Unlock()
+	if mUsageTracker == nil {
+		mUsageTracker = newMetricUsageTracker(metricUsageCapacity, metricUsageDecayInterval)
+	}
+	return mUsageTracker
+}
+
+// ResetMetricUsageTracker drops the metric usage tracker singleton instance,
+// a new instance is created on next access, used when engine is restarted in process(e.g. embedded in tests).
+func ResetMetricUsageTracker() {
+	mUsageTrackerLock.Lock()
+	defer mUsageTrackerLock.Unlock()
+	mUsageTracker = nil
+}
+
+// MetricUsageTracker tracks the write/query usage of metrics on storage node for capacity attribution.
+// Usage is kept in a bounded top-k sketch(space-saving) with periodic decay,
+// so that metrics with low activity are evicted by hot ones and memory does not grow with metric-id churn.
+type MetricUsageTracker interface {
+	// RecordWrite records the data points written for metric.
+	RecordWrite(database string, metricID metric.ID, metricName []byte, points int)
+	// RecordFlush records the bytes of metric block on disk after flush.
+	RecordFlush(database string, metricID metric.ID, bytes int)
+	// RecordQuery records the query touching metric and the bytes of data scanned.
+	RecordQuery(database string, metricID metric.ID, metricName string, scanBytes int)
+	// Top returns the top n metric usages sorted by given dimension, returns all tracked if n <= 0.
+	Top(sortBy models.MetricUsageSortBy, n int) []*models.MetricUsage
+	// TopQueried returns the top n metrics touched by queries recently(decayed), returns all queried if n <= 0.
+	TopQueried(n int) []QueriedMetric
+}
+
+// QueriedMetric represents the metric touched by queries, which is warmed up after restart.
+type QueriedMetric struct {
+	Database string    `json:"database"`
+	MetricID metric.ID `json:"metricID"`
+	Queries  int64     `json:"queries"`
+}
+
+// metricUsageKey represents the key of metric usage.
+type metricUsageKey struct {
+	database string
+	metricID metric.ID
+}
+
+// metricUsageEntry represents the counters of metric in sketch.
+type metricUsageEntry struct {
+	name string
+	// weight is the activity of metric(points+flushes+queries) used for eviction,
+	// inherits the weight of evicted entry as space-saving does(over-estimated).
+	weight     int64
+	points     int64
+	flushBytes int64
+	queries    int64
+	scanBytes  int64
+}
+
+// metricUsageShard represents a shard of sketch with fixed capacity.
+type metricUsageShard struct {
+	entries   map[metricUsageKey]*metricUsageEntry
+	capacity  int
+	lastDecay time.Time
+	lock      sync.Mutex
+}
+
+// metricUsageTracker implements MetricUsageTracker interface.
+type metricUsageTracker struct {
+	shards        []*metricUsageShard
+	decayInterval time.Duration
+}
+
+// newMetricUsageTracker creates a MetricUsageTracker instance.
+func newMetricUsageTracker(capacity int, decayInterval time.Duration) MetricUsageTracker {
+	capacityOfShard := capacity / metricUsageShards
+	if capacityOfShard <= 0 {
+		capacityOfShard = 1
+	}
+	now := metricUsageNowFn()
+	t := &metricUsageTracker{
+		shards:        make([]*metricUsageShard, metricUsageShards),
+		decayInterval: decayInterval,
+	}
+	for idx := range t.shards {
+		t.shards[idx] = &metricUsageShard{
+			entries:   make(map[metricUsageKey]*metricUsageEntry, capacityOfShard),
+			capacity:  capacityOfShard,
+			lastDecay: now,
+		}
+	}
+	return t
+}
+
+// RecordWrite records the data points written for metric.
+func (t *metricUsageTracker) RecordWrite(database string, metricID metric.ID, metricName []byte, points int) {
+	if points <= 0 {
+		return
+	}
+	t.record(database, metricID, func(entry *metricUsageEntry) {
+		if entry.name == "" && len(metricName) > 0 {
+			entry.name = string(metricName)
+		}
+		entry.weight += int64(points)
+		entry.points += int64(points)
+	})
+}
+
+// RecordFlush records the bytes of metric block on disk after flush.
+func (t *metricUsageTracker) RecordFlush(database string, metricID metric.ID, bytes int) {
+	if bytes <= 0 {
+		return
+	}
+	t.record(database, metricID, func(entry *metricUsageEntry) {
+		entry.weight++
+		entry.flushBytes += int64(bytes)
+	})
+}
+
+// RecordQuery records the query touching metric and the bytes of data scanned.
+func (t *metricUsageTracker) RecordQuery(database string, metricID metric.ID, metricName string, scanBytes int) {
+	t.record(database, metricID, func(entry *metricUsageEntry) {
+		if entry.name == "" {
+			entry.name = metricName
+		}
+		entry.weight++
+		entry.queries++
+		entry.scanBytes += int64(scanBytes)
+	})
+}
+
+// Top returns the top n metric usages sorted by given dimension, returns all tracked if n <= 0.
+func (t *metricUsageTracker) Top(sortBy models.MetricUsageSortBy, n int) []*models.MetricUsage {
+	var rs []*models.MetricUsage
+	for _, shard := range t.shards {
+		shard.lock.Lock()
+		t.decay(shard)
+		for key, entry := range shard.entries {
+			name := entry.name
+			if name == "" {
+				// only flushed after tracked, name unknown
+				name = "#" + strconv.FormatUint(uint64(key.metricID), 10)
+			}
+			rs = append(rs, &models.MetricUsage{
+				Database:   key.database,
+				Metric:     name,
+				Points:     entry.points,
+				FlushBytes: entry.flushBytes,
+				Queries:    entry.queries,
+				ScanBytes:  entry.scanBytes,
+			})
+		}
+		shard.lock.Unlock()
+	}
+	return models.TopMetricUsages(rs, sortBy, n)
+}
+
+// TopQueried returns the top n metrics touched by queries recently(decayed), returns all queried if n <= 0.
+func (t *metricUsageTracker) TopQueried(n int) []QueriedMetric {
+	var rs []QueriedMetric
+	for _, shard := range t.shards {
+		shard.lock.Lock()
+		t.decay(shard)
+		for key, entry := range shard.entries {
+			if entry.queries > 0 {
+				rs = append(rs, QueriedMetric{Database: key.database, MetricID: key.metricID, Queries: entry.queries})
+			}
+		}
+		shard.lock.Unlock()
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].Queries != rs[j].Queries {
+			return rs[i].Queries > rs[j].Queries
+		}
+		if rs[i].Database != rs[j].Database {
+			return rs[i].Database < rs[j].Database
+		}
+		return rs[i].MetricID < rs[j].MetricID
+	})
+	if n > 0 && len(rs) > n {
+		rs = rs[:n]
+	}
+	return rs
+}
+
+// record finds(or evicts the entry with min weight if shard is full) the entry of metric, then updates it via fn.
+func (t *metricUsageTracker) record(database string, metricID metric.ID, fn func(entry *metricUsageEntry)) {
+	key := metricUsageKey{database: database, metricID: metricID}
+	shard := t.shards[(xxhash.Sum64String(database)+uint64(metricID))%uint64(len(t.shards))]
+
+	shard.lock.Lock()
+	defer shard.lock.Unlock()
+
+	t.decay(shard)
+	entry, ok := shard.entries[key]
+	if !ok {
+		entry = &metricUsageEntry{}
+		if len(shard.entries) >= shard.capacity {
+			var minKey metricUsageKey
+			var minEntry *metricUsageEntry
+			for k, e := range shard.entries {
+				if minEntry == nil || e.weight < minEntry.weight {
+					minKey, minEntry = k, e
+				}
+			}
+			delete(shard.entries, minKey)
+			entry.weight = minEntry.weight
+		}
+		shard.entries[key] = entry
+	}
+	fn(entry)
+}
+
+// decay halves the counters of shard if decay interval passed, removes the entries without activity.
+func (t *metricUsageTracker) decay(shard *metricUsageShard) {
+	now := metricUsageNowFn()
+	if now.Sub(shard.lastDecay) < t.decayInterval {
+		return
+	}
+	// halve once per interval passed
+	times := int(now.Sub(shard.lastDecay) / t.decayInterval)
+	shard.lastDecay = now
+	for key, entry := range shard.entries {
+		for i := 0; i < times && entry.weight > 0; i++ {
+			entry.weight /= 2
+			entry.points /= 2
+			entry.flushBytes /= 2
+			entry.queries /= 2
+			entry.scanBytes /= 2
+		}
+		if entry.weight == 0 {
+			delete(shard.entries, key)
+		}
+	}
+}
+
+// metricUsageFlusher wraps metric data flusher, records the bytes of each metric block after committed.
+type metricUsageFlusher struct {
+	metricsdata.Flusher
+	database string
+	metricID metric.ID
+}
+
+// newMetricUsageFlusher creates a metric data flusher which records flush usage of metric.
+func newMetricUsageFlusher(database string, flusher metricsdata.Flusher) metricsdata.Flusher {
+	return &metricUsageFlusher{
+		Flusher:  flusher,
+		database: database,
+	}
+}
+
+// PrepareMetric prepares to write a new metric block.
+func (f *metricUsageFlusher) PrepareMetric(metricID uint32, fieldMetas field.Metas) {
+	f.metricID = metric.ID(metricID)
+	f.Flusher.PrepareMetric(metricID, fieldMetas)
+}
+
+// CommitMetric ends writing a full metric block, then records the size of block.
+func (f *metricUsageFlusher) CommitMetric(slotRange timeutil.SlotRange) error {
+	if err := f.Flusher.CommitMetric(slotRange); err != nil {
+		return err
+	}
+	GetMetricUsageTracker().RecordFlush(f.database, f.metricID, int(f.Flusher.MetricBlockSize()))
+	return nil
+}
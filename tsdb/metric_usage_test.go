@@ -0,0 +1,137 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/metric"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
+)
+
+func TestMetricUsageTracker_Record(t *testing.T) {
+	assert.NotNil(t, GetMetricUsageTracker())
+
+	tracker := newMetricUsageTracker(metricUsageCapacity, time.Minute)
+	tracker.RecordWrite("db", 1, []byte("cpu"), 10)
+	tracker.RecordWrite("db", 1, []byte("cpu"), 5)
+	tracker.RecordWrite("db", 2, nil, 0) // ignore empty points
+	tracker.RecordFlush("db", 1, 100)
+	tracker.RecordFlush("db", 3, 0) // ignore empty block
+	tracker.RecordFlush("db", 3, 20)
+	tracker.RecordQuery("db", 1, "cpu", 1000)
+	tracker.RecordQuery("db2", 1, "mem", 10)
+
+	rs := tracker.Top(models.SortByPoints, 0)
+	assert.Len(t, rs, 3)
+	assert.Equal(t, &models.MetricUsage{Database: "db", Metric: "cpu", Points: 15, FlushBytes: 100, Queries: 1, ScanBytes: 1000}, rs[0])
+	rs = tracker.Top(models.SortByFlushBytes, 2)
+	assert.Len(t, rs, 2)
+	assert.Equal(t, "#3", rs[1].Metric)
+	rs = tracker.Top(models.SortByQueries, 0)
+	assert.Equal(t, []string{"cpu", "mem"}, []string{rs[0].Metric, rs[1].Metric})
+
+	tracker.RecordQuery("db2", 1, "mem", 10)
+	assert.Equal(t, []QueriedMetric{
+		{Database: "db2", MetricID: 1, Queries: 2},
+		{Database: "db", MetricID: 1, Queries: 1},
+	}, tracker.TopQueried(0))
+	assert.Len(t, tracker.TopQueried(1), 1)
+}
+
+func TestMetricUsageTracker_Evict(t *testing.T) {
+	// each shard holds 4 entries
+	tracker := newMetricUsageTracker(4*metricUsageShards, time.Minute)
+	for i := 0; i < 10*metricUsageShards; i++ {
+		tracker.RecordWrite("db", 1000, []byte("hot"), 100)
+		tracker.RecordWrite("db", metric.ID(1000+i+1), []byte(fmt.Sprintf("cold-%d", i)), 1)
+	}
+	rs := tracker.Top(models.SortByPoints, 0)
+	// memory bounded
+	assert.LessOrEqual(t, len(rs), 4*metricUsageShards)
+	// hot metric kept
+	assert.Equal(t, "hot", rs[0].Metric)
+	assert.Equal(t, int64(100*10*metricUsageShards), rs[0].Points)
+}
+
+func TestMetricUsageTracker_Decay(t *testing.T) {
+	now := time.Now()
+	metricUsageNowFn = func() time.Time { return now }
+	defer func() {
+		metricUsageNowFn = time.Now
+	}()
+	tracker := newMetricUsageTracker(metricUsageCapacity, time.Minute)
+	tracker.RecordWrite("db", 1, []byte("cpu"), 100)
+	tracker.RecordQuery("db", 2, "mem", 10)
+
+	now = now.Add(time.Minute)
+	rs := tracker.Top(models.SortByPoints, 0)
+	assert.Len(t, rs, 1)
+	assert.Equal(t, &models.MetricUsage{Database: "db", Metric: "cpu", Points: 50}, rs[0])
+	// halve once per interval passed
+	now = now.Add(3 * time.Minute)
+	rs = tracker.Top(models.SortByPoints, 0)
+	assert.Equal(t, int64(6), rs[0].Points)
+	// not reach decay interval
+	now = now.Add(time.Second)
+	rs = tracker.Top(models.SortByPoints, 0)
+	assert.Equal(t, int64(6), rs[0].Points)
+}
+
+func TestResetMetricUsageTracker(t *testing.T) {
+	old := GetMetricUsageTracker()
+	defer func() {
+		mUsageTracker = old
+	}()
+	ResetMetricUsageTracker()
+	tracker := GetMetricUsageTracker()
+	assert.NotSame(t, old, tracker)
+	assert.Same(t, tracker, GetMetricUsageTracker())
+}
+
+func TestMetricUsageFlusher(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	old := GetMetricUsageTracker()
+	tracker := NewMockMetricUsageTracker(ctrl)
+	mUsageTracker = tracker
+	defer func() {
+		mUsageTracker = old
+	}()
+
+	flusher := metricsdata.NewMockFlusher(ctrl)
+	usageFlusher := newMetricUsageFlusher("db", flusher)
+	flusher.EXPECT().PrepareMetric(uint32(10), gomock.Any())
+	usageFlusher.PrepareMetric(10, nil)
+	// commit failure
+	flusher.EXPECT().CommitMetric(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, usageFlusher.CommitMetric(timeutil.SlotRange{}))
+	// commit successfully
+	flusher.EXPECT().CommitMetric(gomock.Any()).Return(nil)
+	flusher.EXPECT().MetricBlockSize().Return(uint32(100))
+	tracker.EXPECT().RecordFlush("db", metric.ID(10), 100)
+	assert.NoError(t, usageFlusher.CommitMetric(timeutil.SlotRange{}))
+}
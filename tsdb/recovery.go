@@ -0,0 +1,238 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/logger"
+)
+
+// quarantineSuffix is the suffix of segment dir which is moved aside because of open failure.
+const quarantineSuffix = ".corrupt-"
+
+// for testing
+var (
+	recoveryLogInterval = 5 * time.Second
+)
+
+// recoveryConcurrency returns the concurrency of goroutines for recovery.
+func recoveryConcurrency() int {
+	if concurrency := config.GlobalStorageConfig().TSDB.RecoveryConcurrency; concurrency > 0 {
+		return concurrency
+	}
+	return 1
+}
+
+// recoveryTask represents a task of recovery, which opens the segment(kv store) and the families of it.
+type recoveryTask struct {
+	indicator string
+	time      int64 // segment time, newer segment is recovered first
+	run       func() (families int, err error)
+	// quarantine moves the segment aside if it cannot be opened, returns the new path.
+	quarantine func() (string, error)
+}
+
+// recoveryTracker tracks the progress of recovery after storage node startup,
+// 1. shard phase: opens shards(index database) of all databases;
+// 2. family phase: opens the segments and the families of writable interval, newest first.
+type recoveryTracker struct {
+	phase    atomic.String
+	opened   atomic.Int32
+	total    atomic.Int32
+	families atomic.Int32
+
+	quarantined []string
+	err         error
+	mutex       sync.Mutex
+}
+
+// newRecoveryTracker creates a recovery tracker.
+func newRecoveryTracker() *recoveryTracker {
+	return &recoveryTracker{}
+}
+
+// startPhase starts a new phase of recovery, resets the counter.
+func (t *recoveryTracker) startPhase(phase string) {
+	if t == nil {
+		return
+	}
+	t.phase.Store(phase)
+	t.opened.Store(0)
+	t.total.Store(0)
+}
+
+// complete marks recovery completed, keeps the counter of last phase.
+func (t *recoveryTracker) complete() {
+	t.phase.Store(models.RecoveryPhaseDone)
+}
+
+// addTotal adds the number of units which need to be opened in current phase.
+func (t *recoveryTracker) addTotal(n int) {
+	if t == nil {
+		return
+	}
+	t.total.Add(int32(n))
+}
+
+// incOpened increases the number of opened units in current phase.
+func (t *recoveryTracker) incOpened() {
+	if t == nil {
+		return
+	}
+	t.opened.Inc()
+}
+
+// addFamilies adds the number of opened families.
+func (t *recoveryTracker) addFamilies(n int) {
+	if t == nil {
+		return
+	}
+	t.families.Add(int32(n))
+}
+
+// quarantine records the segment which is moved aside because of open failure.
+func (t *recoveryTracker) quarantine(path string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.quarantined = append(t.quarantined, path)
+}
+
+// fail records the error which aborts recovery.
+func (t *recoveryTracker) fail(err error) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.err = err
+}
+
+// progress returns the progress of recovery.
+func (t *recoveryTracker) progress() *models.RecoveryProgress {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	progress := &models.RecoveryProgress{
+		Phase:       t.phase.Load(),
+		Opened:      int(t.opened.Load()),
+		Total:       int(t.total.Load()),
+		Families:    int(t.families.Load()),
+		Quarantined: append([]string(nil), t.quarantined...),
+	}
+	if t.err != nil {
+		progress.Err = t.err.Error()
+	}
+	return progress
+}
+
+// logPeriodically logs the progress of recovery periodically until ctx done.
+func (t *recoveryTracker) logPeriodically(ctx context.Context) {
+	ticker := time.NewTicker(recoveryLogInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			progress := t.progress()
+			engineLogger.Info("storage recovery in progress",
+				logger.String("phase", progress.Phase),
+				logger.Int("opened", progress.Opened),
+				logger.Int("total", progress.Total),
+				logger.Int("families", progress.Families),
+				logger.Int("quarantined", len(progress.Quarantined)))
+		}
+	}
+}
+
+// runRecoveryTasks runs recovery tasks by bounded workers, the newest segment is recovered first,
+// if tolerateCorrupt is true, the segment which cannot be opened is quarantined instead of aborting recovery.
+func runRecoveryTasks(ctx context.Context, tasks []*recoveryTask, tolerateCorrupt bool, tracker *recoveryTracker) error {
+	sort.SliceStable(tasks, func(i, j int) bool {
+		return tasks[i].time > tasks[j].time
+	})
+	taskCh := make(chan *recoveryTask, len(tasks))
+	for _, task := range tasks {
+		taskCh <- task
+	}
+	close(taskCh)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wait     sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+	for i := 0; i < recoveryConcurrency(); i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for task := range taskCh {
+				if ctx.Err() != nil {
+					return
+				}
+				if err := recoverTask(task, tolerateCorrupt, tracker); err != nil {
+					errOnce.Do(func() {
+						firstErr = err
+						cancel()
+					})
+					return
+				}
+				tracker.incOpened()
+			}
+		}()
+	}
+	wait.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+// recoverTask runs recovery task, quarantines the segment if it cannot be opened and tolerateCorrupt is true.
+func recoverTask(task *recoveryTask, tolerateCorrupt bool, tracker *recoveryTracker) error {
+	families, err := task.run()
+	if err == nil {
+		tracker.addFamilies(families)
+		return nil
+	}
+	if !tolerateCorrupt || task.quarantine == nil {
+		return err
+	}
+	quarantinedPath, err0 := task.quarantine()
+	if err0 != nil {
+		engineLogger.Error("quarantine corrupt segment failure",
+			logger.String("segment", task.indicator), logger.Error(err), logger.Any("quarantineErr", err0))
+		return err
+	}
+	engineLogger.Warn("segment cannot be opened, quarantined it",
+		logger.String("segment", task.indicator), logger.String("path", quarantinedPath), logger.Error(err))
+	tracker.quarantine(quarantinedPath)
+	return nil
+}
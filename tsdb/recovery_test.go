@@ -0,0 +1,185 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/models"
+)
+
+func TestRecoveryTracker(t *testing.T) {
+	var nilTracker *recoveryTracker
+	nilTracker.startPhase(models.RecoveryPhaseShard)
+	nilTracker.addTotal(1)
+	nilTracker.incOpened()
+	nilTracker.addFamilies(1)
+	nilTracker.quarantine("path")
+	nilTracker.fail(fmt.Errorf("err"))
+
+	tracker := newRecoveryTracker()
+	tracker.startPhase(models.RecoveryPhaseShard)
+	tracker.addTotal(2)
+	tracker.incOpened()
+	assert.Equal(t, &models.RecoveryProgress{Phase: models.RecoveryPhaseShard, Opened: 1, Total: 2}, tracker.progress())
+
+	tracker.startPhase(models.RecoveryPhaseFamily)
+	tracker.addTotal(1)
+	tracker.incOpened()
+	tracker.addFamilies(3)
+	tracker.quarantine("path")
+	tracker.complete()
+	assert.Equal(t, &models.RecoveryProgress{
+		Phase: models.RecoveryPhaseDone, Opened: 1, Total: 1, Families: 3, Quarantined: []string{"path"},
+	}, tracker.progress())
+
+	tracker.fail(fmt.Errorf("err"))
+	assert.Equal(t, "err", tracker.progress().Err)
+}
+
+func TestRecoveryTracker_logPeriodically(t *testing.T) {
+	defer func() {
+		recoveryLogInterval = 5 * time.Second
+	}()
+	recoveryLogInterval = time.Millisecond
+	ctx, cancel := context.WithCancel(context.TODO())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	newRecoveryTracker().logPeriodically(ctx)
+}
+
+func TestRunRecoveryTasks(t *testing.T) {
+	cfg := config.GlobalStorageConfig()
+	concurrency := cfg.TSDB.RecoveryConcurrency
+	defer func() {
+		cfg.TSDB.RecoveryConcurrency = concurrency
+	}()
+	cfg.TSDB.RecoveryConcurrency = 1
+
+	var (
+		recovered []string
+		lock      sync.Mutex
+	)
+	newTask := func(indicator string, segmentTime int64, err error, quarantineErr error) *recoveryTask {
+		return &recoveryTask{
+			indicator: indicator,
+			time:      segmentTime,
+			run: func() (int, error) {
+				lock.Lock()
+				defer lock.Unlock()
+				recovered = append(recovered, indicator)
+				return 2, err
+			},
+			quarantine: func() (string, error) {
+				return indicator + quarantineSuffix + "1", quarantineErr
+			},
+		}
+	}
+	cases := []struct {
+		name            string
+		ctx             func() context.Context
+		tasks           []*recoveryTask
+		tolerateCorrupt bool
+		wantErr         bool
+		assert          func(tracker *recoveryTracker)
+	}{
+		{
+			name: "newest segment is recovered first",
+			tasks: []*recoveryTask{
+				newTask("a", 1, nil, nil), newTask("c", 3, nil, nil), newTask("b", 2, nil, nil),
+			},
+			assert: func(tracker *recoveryTracker) {
+				assert.Equal(t, []string{"c", "b", "a"}, recovered)
+				progress := tracker.progress()
+				assert.Equal(t, 3, progress.Opened)
+				assert.Equal(t, 6, progress.Families)
+			},
+		},
+		{
+			name: "abort recovery if segment cannot be opened",
+			tasks: []*recoveryTask{
+				newTask("a", 1, nil, nil), newTask("b", 2, fmt.Errorf("err"), nil),
+			},
+			wantErr: true,
+			assert: func(_ *recoveryTracker) {
+				assert.Equal(t, []string{"b"}, recovered)
+			},
+		},
+		{
+			name: "quarantine corrupt segment",
+			tasks: []*recoveryTask{
+				newTask("a", 1, nil, nil), newTask("b", 2, fmt.Errorf("err"), nil),
+			},
+			tolerateCorrupt: true,
+			assert: func(tracker *recoveryTracker) {
+				assert.Equal(t, []string{"b", "a"}, recovered)
+				progress := tracker.progress()
+				assert.Equal(t, 2, progress.Opened)
+				assert.Equal(t, []string{"b" + quarantineSuffix + "1"}, progress.Quarantined)
+			},
+		},
+		{
+			name: "quarantine corrupt segment failure",
+			tasks: []*recoveryTask{
+				newTask("a", 1, nil, nil), newTask("b", 2, fmt.Errorf("err"), fmt.Errorf("err")),
+			},
+			tolerateCorrupt: true,
+			wantErr:         true,
+		},
+		{
+			name: "recovery canceled",
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.TODO())
+				cancel()
+				return ctx
+			},
+			tasks:   []*recoveryTask{newTask("a", 1, nil, nil)},
+			wantErr: true,
+			assert: func(_ *recoveryTracker) {
+				assert.Empty(t, recovered)
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			recovered = nil
+			ctx := context.TODO()
+			if tt.ctx != nil {
+				ctx = tt.ctx()
+			}
+			tracker := newRecoveryTracker()
+			err := runRecoveryTasks(ctx, tt.tasks, tt.tolerateCorrupt, tracker)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("runRecoveryTasks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.assert != nil {
+				tt.assert(tracker)
+			}
+		})
+	}
+}
@@ -26,7 +26,10 @@ import (
 	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
 )
 
@@ -45,8 +48,19 @@ type Segment interface {
 	NeedEvict() bool
 	// EvictFamily evicts data family.
 	EvictFamily(familyTime int64)
+	// SetCompactionPolicy sets the compaction policy of all families, which takes effect for new compactions.
+	SetCompactionPolicy(policy option.CompactionPolicy)
+	// SetRollup sets the source interval and target intervals of rollup, which takes effect for new flushed files.
+	SetRollup(source timeutil.Interval, rollup []timeutil.Interval)
 	// Close closes segment, include kv store.
 	Close()
+
+	// recoverFamilies opens all families of segment after startup, newest first, returns the number of families.
+	recoverFamilies() (int, error)
+	// walkFamilies walks all families of segment, returns err if family cannot be loaded.
+	walkFamilies(fn func(family DataFamily) error) error
+	// existDataFamily returns if the data family of timestamp exists in memory or storage.
+	existDataFamily(timestamp int64) bool
 }
 
 // segment implements Segment interface.
@@ -57,6 +71,7 @@ type segment struct {
 	kvStore   kv.Store
 	interval  timeutil.Interval
 	families  map[int]DataFamily
+	engineCtx *engineContext // resources of engine which segment belongs to
 
 	mutex sync.RWMutex
 
@@ -65,7 +80,8 @@ type segment struct {
 
 // newSegment returns segment, segment is wrapper of kv store.
 func newSegment(shard Shard, segmentName string, interval timeutil.Interval) (Segment, error) {
-	indicator := ShardSegmentIndicator(shard.Database().Name(), shard.ShardID(), interval, segmentName)
+	db := shard.Database()
+	indicator := ShardSegmentIndicator(db.Name(), shard.ShardID(), interval, segmentName)
 	// parse base time from segment name
 	calc := interval.Calculator()
 	baseTime, err := calc.ParseSegmentTime(segmentName)
@@ -74,7 +90,7 @@ func newSegment(shard Shard, segmentName string, interval timeutil.Interval) (Se
 	}
 
 	storeOption := kv.DefaultStoreOption()
-	intervals := shard.Database().GetOption().Intervals
+	intervals := db.GetOption().Intervals
 	if shard.CurrentInterval() == interval && len(intervals) > 1 {
 		// if interval == writeable interval and database set auto rollup intervals
 		sort.Sort(intervals) // need sort interval
@@ -85,19 +101,74 @@ func newSegment(shard Shard, segmentName string, interval timeutil.Interval) (Se
 		storeOption.Rollup = rollup[1:]
 		storeOption.Source = interval
 	}
-	kvStore, err := kv.GetStoreManager().CreateStore(indicator, storeOption)
+	engineCtx := engineContextOf(db)
+	kvStore, err := engineCtx.storeManager().CreateStore(indicator, storeOption)
 	if err != nil {
-		return nil, fmt.Errorf("create kv store for segment error:%s", err)
+		return nil, fmt.Errorf("create kv store for segment error:%w", err)
 	}
-	return &segment{
+	// families loaded from storage use current compaction policy of database
+	kvStore.SetCompactionPolicy(db.GetOption().GetCompactionPolicy())
+	s := &segment{
 		shard:     shard,
+		engineCtx: engineCtx,
 		indicator: indicator,
 		baseTime:  baseTime,
 		kvStore:   kvStore,
 		interval:  interval,
 		families:  make(map[int]DataFamily),
 		logger:    logger.GetLogger("TSDB", "Segment"),
-	}, nil
+	}
+	// compaction of families drops the field data out of field level retention
+	kvStore.SetMergerParam(kv.FieldRetentionContext, s)
+	// compaction of families rounds the field data to the field value precision
+	kvStore.SetMergerParam(kv.FieldPrecisionContext, s)
+	// compaction of families follows the write semantics of database
+	kvStore.SetMergerParam(kv.WriteSemanticsContext, s)
+	// compaction of families transcodes metric blocks to the current codec of database
+	kvStore.SetMergerParam(kv.CompressionContext, s)
+	return s, nil
+}
+
+// engineContext returns the resources of engine which segment belongs to.
+func (s *segment) engineContext() *engineContext {
+	if s.engineCtx == nil {
+		return defaultEngineContext
+	}
+	return s.engineCtx
+}
+
+// MetricBlockCodec returns the current codec compressing the metric blocks written by compaction.
+func (s *segment) MetricBlockCodec() metricsdata.Codec {
+	return metricsdata.NewCodec(s.shard.Database().GetOption().GetCompression())
+}
+
+// IsLastWriteWins returns true if the newer value replaces the older value of same time slot when compacting families.
+func (s *segment) IsLastWriteWins() bool {
+	return s.shard.Database().GetOption().IsLastWriteWins()
+}
+
+// GetPrecision returns the value precision(significant decimal digits) of field, 0 means full precision.
+func (s *segment) GetPrecision(metricID metric.ID, f field.Meta) uint8 {
+	precision := s.shard.Database().getFieldPrecision()
+	if precision == nil {
+		return 0
+	}
+	return precision.getPrecision(metricID, f)
+}
+
+// IsExpired returns true if the field data of metric stored in family is out of field level retention.
+func (s *segment) IsExpired(familyName string, metricID metric.ID, fieldID field.ID) bool {
+	retention := s.shard.Database().getFieldRetention()
+	if retention == nil {
+		return false
+	}
+	familyTime, err := strconv.Atoi(familyName)
+	if err != nil {
+		return false
+	}
+	calc := s.interval.Calculator()
+	familyEndTime := calc.CalcFamilyEndTime(calc.CalcFamilyStartTime(s.baseTime, familyTime))
+	return retention.isExpired(familyEndTime, metricID, fieldID)
 }
 
 // BaseTime returns segment base time
@@ -122,7 +193,12 @@ func (s *segment) GetDataFamilies(timeRange timeutil.TimeRange) []DataFamily {
 			// TODO: add metric
 			continue
 		}
-		family := s.getOrLoadFamily(familyName, familyTime)
+		family, err := s.getOrLoadFamily(familyName, familyTime)
+		if err != nil {
+			s.logger.Error("load data family error, skip it",
+				logger.String("segment", s.indicator), logger.String("family", familyName), logger.Error(err))
+			continue
+		}
 		timeRange := family.TimeRange()
 		if familyQueryTimeRange.Overlap(timeRange) {
 			result = append(result, family)
@@ -171,20 +247,47 @@ func (s *segment) GetOrCreateDataFamily(timestamp int64) (DataFamily, error) {
 	familyOption := kv.FamilyOption{
 		CompactThreshold: 0,
 		Merger:           string(metricsdata.MetricDataMerger),
+		CompactionPolicy: s.shard.Database().GetOption().GetCompactionPolicy(),
 	}
 	familyName := strconv.Itoa(familyTime)
 	family := s.kvStore.GetFamily(familyName)
 	if family == nil {
 		// create kv family
 		var err error
-		family, err = s.kvStore.CreateFamily(fmt.Sprintf("%d", familyTime), familyOption)
+		family, err = s.kvStore.CreateFamily(familyName, familyOption)
 		if err != nil {
-			return nil, fmt.Errorf("%w ,failed to create data family: %s",
-				constants.ErrDataFamilyNotFound, err)
+			// family may be created by concurrent creator(already exists), re-fetch it
+			if family = s.kvStore.GetFamily(familyName); family == nil {
+				return nil, fmt.Errorf("%w ,failed to create data family: %s",
+					constants.ErrDataFamilyNotFound, err)
+			}
+			s.logger.Warn("create kv family failure, use the existing family",
+				logger.String("family", familyName), logger.Error(err))
 		}
 	}
-	dataFamily := s.initDataFamily(familyTime, family)
-	return dataFamily, nil
+	return s.initDataFamily(familyTime, family)
+}
+
+// existDataFamily returns if the data family of timestamp exists in memory or storage.
+func (s *segment) existDataFamily(timestamp int64) bool {
+	familyTime := s.interval.Calculator().CalcFamily(timestamp, s.baseTime)
+	s.mutex.Lock()
+	_, ok := s.families[familyTime]
+	s.mutex.Unlock()
+	if ok {
+		return true
+	}
+	return s.kvStore.GetFamily(strconv.Itoa(familyTime)) != nil
+}
+
+// SetCompactionPolicy sets the compaction policy of all families, which takes effect for new compactions.
+func (s *segment) SetCompactionPolicy(policy option.CompactionPolicy) {
+	s.kvStore.SetCompactionPolicy(policy)
+}
+
+// SetRollup sets the source interval and target intervals of rollup, which takes effect for new flushed files.
+func (s *segment) SetRollup(source timeutil.Interval, rollup []timeutil.Interval) {
+	s.kvStore.SetRollup(source, rollup)
 }
 
 // Close closes segment, include kv store.
@@ -197,7 +300,7 @@ func (s *segment) Close() {
 			s.logger.Error("close family err", logger.String("family", family.Indicator()))
 		}
 	}
-	if err := kv.GetStoreManager().CloseStore(s.kvStore.Name()); err != nil {
+	if err := s.engineContext().storeManager().CloseStore(s.kvStore.Name()); err != nil {
 		s.logger.Error("close kv store error", logger.Error(err))
 	}
 	// clear family cache
@@ -205,18 +308,63 @@ func (s *segment) Close() {
 }
 
 // getOrLoadFamily returns data family if it's exist in memory or storage.
-func (s *segment) getOrLoadFamily(familyName string, familyTime int) DataFamily {
+func (s *segment) getOrLoadFamily(familyName string, familyTime int) (DataFamily, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
 	if family, ok := s.families[familyTime]; ok {
-		return family
+		return family, nil
 	}
 	return s.initDataFamily(familyTime, s.kvStore.GetFamily(familyName))
 }
 
-// initDataFamily initializes data family from storage.
-func (s *segment) initDataFamily(familyTime int, family kv.Family) DataFamily {
+// recoverFamilies opens all families of segment after startup, newest first, returns the number of families.
+func (s *segment) recoverFamilies() (int, error) {
+	familyTimes := make(map[int]string)
+	var times []int
+	for _, familyName := range s.kvStore.ListFamilyNames() {
+		familyTime, err := strconv.Atoi(familyName)
+		if err != nil {
+			continue
+		}
+		familyTimes[familyTime] = familyName
+		times = append(times, familyTime)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(times)))
+	for _, familyTime := range times {
+		if _, err := s.getOrLoadFamily(familyTimes[familyTime], familyTime); err != nil {
+			return 0, err
+		}
+	}
+	return len(times), nil
+}
+
+// walkFamilies walks all families of segment, returns err if family cannot be loaded.
+func (s *segment) walkFamilies(fn func(family DataFamily) error) error {
+	for _, familyName := range s.kvStore.ListFamilyNames() {
+		familyTime, err := strconv.Atoi(familyName)
+		if err != nil {
+			continue
+		}
+		family, err := s.getOrLoadFamily(familyName, familyTime)
+		if err != nil {
+			return err
+		}
+		if err := fn(family); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initDataFamily initializes data family from storage,
+// returns err if family contains metric blocks written by newer format version.
+func (s *segment) initDataFamily(familyTime int, family kv.Family) (DataFamily, error) {
+	if family != nil {
+		if err := checkFamilyFormatFunc(family); err != nil {
+			return nil, fmt.Errorf("family[%s] of segment[%s] is incompatible: %w", family.Name(), s.indicator, err)
+		}
+	}
 	calc := s.interval.Calculator()
 	// create data family
 	familyStartTime := calc.CalcFamilyStartTime(s.baseTime, familyTime)
@@ -225,5 +373,27 @@ func (s *segment) initDataFamily(familyTime int, family kv.Family) DataFamily {
 		End:   calc.CalcFamilyEndTime(familyStartTime),
 	}, familyStartTime, family)
 	s.families[familyTime] = dataFamily
-	return dataFamily
+	return dataFamily, nil
+}
+
+// checkFamilyFormat checks the format version of metric blocks in family files,
+// all blocks of one file are written in one pass, so only checks the first block of each file.
+func checkFamilyFormat(family kv.Family) error {
+	snapshot := family.GetSnapshot()
+	defer snapshot.Close()
+
+	for _, file := range snapshot.GetCurrent().GetAllFiles() {
+		reader, err := snapshot.GetReader(file.GetFileNumber())
+		if err != nil {
+			return err
+		}
+		it := reader.Iterator()
+		if !it.HasNext() {
+			continue
+		}
+		if err := metricsdata.CheckFormatVersion(it.Value()); err != nil {
+			return fmt.Errorf("file[%s]: %w", reader.FileName(), err)
+		}
+	}
+	return nil
 }
@@ -19,16 +19,21 @@ package tsdb
 
 import (
 	"fmt"
+	"strconv"
 	"testing"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/kv/version"
 	"github.com/lindb/lindb/models"
 	"github.com/lindb/lindb/pkg/logger"
 	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/tsdb/tblstore/metricsdata"
 )
 
 func TestSegment_New(t *testing.T) {
@@ -60,8 +65,13 @@ func TestSegment_New(t *testing.T) {
 			name:        "create segment successfully",
 			segmentName: segmentName,
 			prepare: func() {
-				database.EXPECT().GetOption().Return(&option.DatabaseOption{Intervals: option.Intervals{{Interval: interval}}})
+				database.EXPECT().GetOption().Return(&option.DatabaseOption{Intervals: option.Intervals{{Interval: interval}}}).Times(2)
 				storeMgr.EXPECT().CreateStore(gomock.Any(), gomock.Any()).Return(store, nil)
+				store.EXPECT().SetCompactionPolicy(option.CompactionPolicyDefault)
+				store.EXPECT().SetMergerParam(kv.FieldRetentionContext, gomock.Any())
+				store.EXPECT().SetMergerParam(kv.FieldPrecisionContext, gomock.Any())
+				store.EXPECT().SetMergerParam(kv.WriteSemanticsContext, gomock.Any())
+				store.EXPECT().SetMergerParam(kv.CompressionContext, gomock.Any())
 			},
 		},
 		{
@@ -73,8 +83,14 @@ func TestSegment_New(t *testing.T) {
 						{Interval: interval},
 						{Interval: timeutil.Interval(5 * timeutil.OneMinute)},
 					},
-				})
+					CompactionPolicy: option.CompactionPolicyLeveled,
+				}).Times(2)
 				storeMgr.EXPECT().CreateStore(gomock.Any(), gomock.Any()).Return(store, nil)
+				store.EXPECT().SetCompactionPolicy(option.CompactionPolicyLeveled)
+				store.EXPECT().SetMergerParam(kv.FieldRetentionContext, gomock.Any())
+				store.EXPECT().SetMergerParam(kv.FieldPrecisionContext, gomock.Any())
+				store.EXPECT().SetMergerParam(kv.WriteSemanticsContext, gomock.Any())
+				store.EXPECT().SetMergerParam(kv.CompressionContext, gomock.Any())
 			},
 		},
 		{
@@ -121,6 +137,10 @@ func TestSegment_GetOrCreateDataFamily(t *testing.T) {
 	defer ctrl.Finish()
 
 	store := kv.NewMockStore(ctrl)
+	database := NewMockDatabase(ctrl)
+	database.EXPECT().GetOption().Return(&option.DatabaseOption{CompactionPolicy: option.CompactionPolicyLeveled}).AnyTimes()
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
 	interval := timeutil.Interval(10 * 1000)
 	baseTime, _ := timeutil.ParseTimestamp("20190904 00:00:00", "20060102 15:04:05")
 	cases := []struct {
@@ -144,7 +164,10 @@ func TestSegment_GetOrCreateDataFamily(t *testing.T) {
 					return NewMockDataFamily(ctrl)
 				}
 				store.EXPECT().GetFamily(gomock.Any()).Return(nil)
-				store.EXPECT().CreateFamily(gomock.Any(), gomock.Any()).Return(nil, nil)
+				store.EXPECT().CreateFamily(gomock.Any(), kv.FamilyOption{
+					Merger:           string(metricsdata.MetricDataMerger),
+					CompactionPolicy: option.CompactionPolicyLeveled,
+				}).Return(nil, nil)
 			},
 		},
 		{
@@ -156,18 +179,51 @@ func TestSegment_GetOrCreateDataFamily(t *testing.T) {
 					familyTime int64, family kv.Family) DataFamily {
 					return NewMockDataFamily(ctrl)
 				}
+				checkFamilyFormatFunc = func(_ kv.Family) error {
+					return nil
+				}
 				store.EXPECT().GetFamily(gomock.Any()).Return(kv.NewMockFamily(ctrl))
 			},
 		},
+		{
+			name:      "family format incompatible",
+			timestamp: "20190904 19:10:48",
+			prepare: func(_ *segment) {
+				checkFamilyFormatFunc = func(_ kv.Family) error {
+					return metricsdata.ErrUnsupportedFormatVersion
+				}
+				family := kv.NewMockFamily(ctrl)
+				family.EXPECT().Name().Return("10")
+				store.EXPECT().GetFamily(gomock.Any()).Return(family)
+			},
+			wantErr: true,
+		},
 		{
 			name:      "create new family err",
 			timestamp: "20190904 20:10:48",
 			prepare: func(_ *segment) {
-				store.EXPECT().GetFamily(gomock.Any()).Return(nil)
+				store.EXPECT().GetFamily(gomock.Any()).Return(nil).Times(2)
 				store.EXPECT().CreateFamily(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("err"))
 			},
 			wantErr: true,
 		},
+		{
+			name:      "family created by concurrent creator",
+			timestamp: "20190904 21:10:48",
+			prepare: func(_ *segment) {
+				newDataFamilyFunc = func(shard Shard, _ Segment,
+					interval timeutil.Interval, timeRange timeutil.TimeRange,
+					familyTime int64, family kv.Family) DataFamily {
+					return NewMockDataFamily(ctrl)
+				}
+				gomock.InOrder(
+					store.EXPECT().GetFamily(gomock.Any()).Return(nil),
+					store.EXPECT().CreateFamily(gomock.Any(), gomock.Any()).Return(nil, fmt.Errorf("already exists")),
+					store.EXPECT().GetFamily(gomock.Any()).Return(kv.NewMockFamily(ctrl)),
+				)
+				checkFamilyFormatFunc = func(_ kv.Family) error { return nil }
+			},
+		},
 		{
 			name:      "get exist family",
 			timestamp: "20190904 22:10:48",
@@ -186,12 +242,15 @@ func TestSegment_GetOrCreateDataFamily(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			defer func() {
 				newDataFamilyFunc = newDataFamily
+				checkFamilyFormatFunc = checkFamilyFormat
 			}()
 			seg := &segment{
+				shard:    shard,
 				baseTime: baseTime,
 				kvStore:  store,
 				interval: interval,
 				families: make(map[int]DataFamily),
+				logger:   logger.GetLogger("TSDB", "Test"),
 			}
 			if tt.prepare != nil {
 				tt.prepare(seg)
@@ -253,6 +312,9 @@ func TestSegment_GetDataFamilies(t *testing.T) {
 				dataFamily := NewMockDataFamily(ctrl)
 				family := kv.NewMockFamily(ctrl)
 				store.EXPECT().GetFamily(gomock.Any()).Return(family)
+				checkFamilyFormatFunc = func(_ kv.Family) error {
+					return nil
+				}
 				newDataFamilyFunc = func(shard Shard, _ Segment, interval timeutil.Interval,
 					timeRange timeutil.TimeRange, familyTime int64, family kv.Family) DataFamily {
 					return dataFamily
@@ -262,15 +324,34 @@ func TestSegment_GetDataFamilies(t *testing.T) {
 			},
 			len: 1,
 		},
+		{
+			name:      "family format incompatible",
+			timeRange: timeRange,
+			prepare: func(_ *segment) {
+				family := kv.NewMockFamily(ctrl)
+				family.EXPECT().Name().Return("10")
+				store.EXPECT().GetFamily(gomock.Any()).Return(family)
+				checkFamilyFormatFunc = func(_ kv.Family) error {
+					return metricsdata.ErrUnsupportedFormatVersion
+				}
+				store.EXPECT().ListFamilyNames().Return([]string{"10"})
+			},
+			len: 0,
+		},
 	}
 
 	for _, tt := range cases {
 		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				newDataFamilyFunc = newDataFamily
+				checkFamilyFormatFunc = checkFamilyFormat
+			}()
 			s := &segment{
 				kvStore:  store,
 				interval: timeutil.Interval(10 * 1000),
 				families: make(map[int]DataFamily),
+				logger:   logger.GetLogger("TSDB", "Test"),
 			}
 			if tt.prepare != nil {
 				tt.prepare(s)
@@ -358,3 +439,299 @@ func TestSegment_NeedEvict(t *testing.T) {
 	assert.True(t, s.NeedEvict())
 	s.EvictFamily(timeutil.Now())
 }
+
+func TestSegment_existDataFamily(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := kv.NewMockStore(ctrl)
+	baseTime, _ := timeutil.ParseTimestamp("20190904 00:00:00", "20060102 15:04:05")
+	s := &segment{
+		baseTime: baseTime,
+		kvStore:  store,
+		interval: timeutil.Interval(10 * 1000),
+		families: map[int]DataFamily{10: NewMockDataFamily(ctrl)},
+	}
+	// family in memory
+	assert.True(t, s.existDataFamily(baseTime+10*timeutil.OneHour+timeutil.OneMinute))
+	// family in storage(evicted)
+	store.EXPECT().GetFamily("11").Return(kv.NewMockFamily(ctrl))
+	assert.True(t, s.existDataFamily(baseTime+11*timeutil.OneHour))
+	// family not exist
+	store.EXPECT().GetFamily("12").Return(nil)
+	assert.False(t, s.existDataFamily(baseTime+12*timeutil.OneHour))
+}
+
+func TestSegment_SetCompactionPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := kv.NewMockStore(ctrl)
+	store.EXPECT().SetCompactionPolicy(option.CompactionPolicyLeveled)
+	s := &segment{kvStore: store}
+	s.SetCompactionPolicy(option.CompactionPolicyLeveled)
+}
+
+func TestSegment_SetRollup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	source := timeutil.Interval(10 * timeutil.OneSecond)
+	rollup := []timeutil.Interval{timeutil.Interval(timeutil.OneHour)}
+	store := kv.NewMockStore(ctrl)
+	store.EXPECT().SetRollup(source, rollup)
+	s := &segment{kvStore: store}
+	s.SetRollup(source, rollup)
+}
+
+func TestSegment_IsExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	database := NewMockDatabase(ctrl)
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	interval := timeutil.Interval(10 * timeutil.OneSecond)
+	calc := interval.Calculator()
+	now := timeutil.Now()
+	oldTime := now - 2*timeutil.OneDay
+	s := &segment{shard: shard, interval: interval, baseTime: calc.CalcSegmentTime(oldTime)}
+	familyName := strconv.Itoa(calc.CalcFamily(oldTime, s.baseTime))
+
+	// no field retention
+	database.EXPECT().getFieldRetention().Return(nil)
+	assert.False(t, s.IsExpired(familyName, 10, 1))
+
+	database.EXPECT().getFieldRetention().Return(newTestFieldRetention(ctrl)).AnyTimes()
+	assert.False(t, s.IsExpired("abc", 10, 1))
+	assert.True(t, s.IsExpired(familyName, 10, 1))
+	assert.False(t, s.IsExpired(familyName, 10, 2))
+
+	s.baseTime = calc.CalcSegmentTime(now)
+	assert.False(t, s.IsExpired(strconv.Itoa(calc.CalcFamily(now, s.baseTime)), 10, 1))
+}
+
+func TestSegment_GetPrecision(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	database := NewMockDatabase(ctrl)
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	s := &segment{shard: shard}
+
+	// no field precision
+	database.EXPECT().getFieldPrecision().Return(nil)
+	assert.Zero(t, s.GetPrecision(10, field.Meta{ID: 1, Type: field.LastField}))
+
+	database.EXPECT().getFieldPrecision().Return(newTestFieldPrecision(ctrl)).AnyTimes()
+	assert.Equal(t, uint8(3), s.GetPrecision(10, field.Meta{ID: 1, Type: field.LastField}))
+	assert.Zero(t, s.GetPrecision(10, field.Meta{ID: 1, Type: field.SumField}))
+}
+
+func TestSegment_IsLastWriteWins(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	database := NewMockDatabase(ctrl)
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	s := &segment{shard: shard}
+	database.EXPECT().GetOption().Return(&option.DatabaseOption{})
+	assert.False(t, s.IsLastWriteWins())
+	database.EXPECT().GetOption().Return(&option.DatabaseOption{WriteSemantics: option.WriteSemanticsLastWriteWins})
+	assert.True(t, s.IsLastWriteWins())
+}
+
+func TestSegment_MetricBlockCodec(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	database := NewMockDatabase(ctrl)
+	shard := NewMockShard(ctrl)
+	shard.EXPECT().Database().Return(database).AnyTimes()
+	s := &segment{shard: shard}
+	database.EXPECT().GetOption().Return(&option.DatabaseOption{})
+	assert.Equal(t, metricsdata.Codec{Type: metricsdata.CodecNone}, s.MetricBlockCodec())
+	database.EXPECT().GetOption().Return(&option.DatabaseOption{Compression: "zstd:5"})
+	assert.Equal(t, metricsdata.Codec{Type: metricsdata.CodecZstd, Level: 5}, s.MetricBlockCodec())
+}
+
+func TestSegment_checkFamilyFormat(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	family := kv.NewMockFamily(ctrl)
+	snapshot := version.NewMockSnapshot(ctrl)
+	v := version.NewMockVersion(ctrl)
+	reader := table.NewMockReader(ctrl)
+	it := table.NewMockIterator(ctrl)
+	family.EXPECT().GetSnapshot().Return(snapshot).AnyTimes()
+	snapshot.EXPECT().Close().AnyTimes()
+	snapshot.EXPECT().GetCurrent().Return(v).AnyTimes()
+	v.EXPECT().GetAllFiles().Return([]*version.FileMeta{version.NewFileMeta(1, 1, 10, 1024)}).AnyTimes()
+	reader.EXPECT().Iterator().Return(it).AnyTimes()
+	reader.EXPECT().FileName().Return("000001.sst").AnyTimes()
+
+	cases := []struct {
+		name    string
+		prepare func()
+		wantErr bool
+	}{
+		{
+			name: "get reader failure",
+			prepare: func() {
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(nil, fmt.Errorf("err"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "empty file",
+			prepare: func() {
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil)
+				it.EXPECT().HasNext().Return(false)
+			},
+		},
+		{
+			name: "newer format version",
+			prepare: func() {
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil)
+				it.EXPECT().HasNext().Return(true)
+				it.EXPECT().Value().Return(mockMetricBlockWithVersion(metricsdata.CurrentFormatVersion + 1))
+			},
+			wantErr: true,
+		},
+		{
+			name: "current format version",
+			prepare: func() {
+				snapshot.EXPECT().GetReader(gomock.Any()).Return(reader, nil)
+				it.EXPECT().HasNext().Return(true)
+				it.EXPECT().Value().Return(mockMetricBlockWithVersion(metricsdata.CurrentFormatVersion))
+			},
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.prepare()
+			err := checkFamilyFormat(family)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func mockMetricBlockWithVersion(formatVersion metricsdata.FormatVersion) []byte {
+	nopKVFlusher := kv.NewNopFlusher()
+	flusher, _ := metricsdata.NewFlusher(nopKVFlusher)
+	flusher.PrepareMetric(10, field.Metas{{ID: 1, Type: field.SumField}})
+	_ = flusher.FlushField([]byte{1, 2, 3})
+	_ = flusher.FlushSeries(10)
+	_ = flusher.CommitMetric(timeutil.SlotRange{Start: 5, End: 5})
+	block := nopKVFlusher.Bytes()
+	if formatVersion == metricsdata.FormatVersionV3 {
+		// insert codec type before version trailer
+		trailer := append([]byte{}, block[len(block)-5:]...)
+		block = append(append(block[:len(block)-5], byte(metricsdata.CodecSnappy)), trailer...)
+	}
+	block[len(block)-5] = byte(formatVersion)
+	return block
+}
+
+func TestSegment_walkFamilies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newDataFamilyFunc = newDataFamily
+		checkFamilyFormatFunc = checkFamilyFormat
+		ctrl.Finish()
+	}()
+
+	store := kv.NewMockStore(ctrl)
+	store.EXPECT().ListFamilyNames().Return([]string{"abc", "1", "10"}).AnyTimes()
+	family := kv.NewMockFamily(ctrl)
+	family.EXPECT().Name().Return("10").AnyTimes()
+	store.EXPECT().GetFamily(gomock.Any()).Return(family).AnyTimes()
+	newDataFamilyFunc = func(_ Shard, _ Segment, _ timeutil.Interval, _ timeutil.TimeRange,
+		_ int64, _ kv.Family) DataFamily {
+		return NewMockDataFamily(ctrl)
+	}
+	seg := &segment{
+		kvStore:  store,
+		interval: timeutil.Interval(10 * timeutil.OneSecond),
+		families: make(map[int]DataFamily),
+	}
+	walk := func(_ DataFamily) error {
+		return nil
+	}
+
+	// load family failure
+	checkFamilyFormatFunc = func(_ kv.Family) error {
+		return metricsdata.ErrUnsupportedFormatVersion
+	}
+	assert.ErrorIs(t, seg.walkFamilies(walk), metricsdata.ErrUnsupportedFormatVersion)
+
+	// walk all families
+	checkFamilyFormatFunc = func(_ kv.Family) error {
+		return nil
+	}
+	count := 0
+	assert.NoError(t, seg.walkFamilies(func(_ DataFamily) error {
+		count++
+		return nil
+	}))
+	assert.Equal(t, 2, count)
+	// walk failure
+	assert.Error(t, seg.walkFamilies(func(_ DataFamily) error {
+		return fmt.Errorf("err")
+	}))
+}
+
+func TestSegment_recoverFamilies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newDataFamilyFunc = newDataFamily
+		checkFamilyFormatFunc = checkFamilyFormat
+		ctrl.Finish()
+	}()
+
+	store := kv.NewMockStore(ctrl)
+	store.EXPECT().ListFamilyNames().Return([]string{"abc", "1", "10"}).AnyTimes()
+	family := kv.NewMockFamily(ctrl)
+	family.EXPECT().Name().Return("10").AnyTimes()
+	store.EXPECT().GetFamily(gomock.Any()).Return(family).AnyTimes()
+	var familyTimes []int64
+	newDataFamilyFunc = func(_ Shard, _ Segment, _ timeutil.Interval, _ timeutil.TimeRange,
+		familyTime int64, _ kv.Family) DataFamily {
+		familyTimes = append(familyTimes, familyTime)
+		return NewMockDataFamily(ctrl)
+	}
+	newSeg := func() *segment {
+		return &segment{
+			kvStore:  store,
+			interval: timeutil.Interval(10 * timeutil.OneSecond),
+			families: make(map[int]DataFamily),
+		}
+	}
+
+	// incompatible family
+	checkFamilyFormatFunc = func(_ kv.Family) error {
+		return metricsdata.ErrUnsupportedFormatVersion
+	}
+	_, err := newSeg().recoverFamilies()
+	assert.ErrorIs(t, err, metricsdata.ErrUnsupportedFormatVersion)
+
+	// newest family is opened first
+	checkFamilyFormatFunc = func(_ kv.Family) error {
+		return nil
+	}
+	seg := newSeg()
+	families, err := seg.recoverFamilies()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, families)
+	assert.Len(t, seg.families, 2)
+	assert.Len(t, familyTimes, 2)
+	assert.Greater(t, familyTimes[0], familyTimes[1])
+}
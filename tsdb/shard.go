@@ -27,8 +27,10 @@ import (
 	"time"
 
 	commonconstants "github.com/lindb/common/constants"
+	"github.com/lindb/roaring"
 	"go.uber.org/atomic"
 
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/models"
@@ -56,8 +58,12 @@ type Shard interface {
 	CurrentInterval() timeutil.Interval
 	// Indicator returns the unique shard info.
 	Indicator() string
-	// GetOrCrateDataFamily returns data family, if not exist create a new data family.
+	// GetOrCrateDataFamily returns data family, if not exist create a new data family,
+	// returns the latest family instead of creating earlier family if wall clock regresses.
 	GetOrCrateDataFamily(familyTime int64) (DataFamily, error)
+	// GetOrCreateBackfillDataFamily returns data family for backfill(e.g. install replica snapshot),
+	// if not exist create a new data family even if it's earlier than the latest family.
+	GetOrCreateBackfillDataFamily(familyTime int64) (DataFamily, error)
 	// GetDataFamilies returns data family list by interval type and time range, return nil if not match
 	GetDataFamilies(intervalType timeutil.IntervalType, timeRange timeutil.TimeRange) []DataFamily
 	// IndexDatabase returns the index-database
@@ -70,12 +76,33 @@ type Shard interface {
 	FlushIndex() error
 	// WaitFlushIndexCompleted waits flush index job completed.
 	WaitFlushIndexCompleted()
+	// SetCompactionPolicy sets the compaction policy of data families, which takes effect for new compactions.
+	SetCompactionPolicy(policy option.CompactionPolicy)
+	// AddRollupIntervals adds the rollup target intervals, new flushed files of writable interval are rolled up to them.
+	AddRollupIntervals(intervals option.Intervals) error
+	// BackfillRollup rolls up the history families of writable interval to given intervals, newest family first,
+	// families whose start time >= resumeFrom are skipped(resumeFrom <= 0 means starting from scratch),
+	// progress is invoked after each family completed with the start time of completed family.
+	BackfillRollup(intervals option.Intervals, resumeFrom int64, progress func(completed, total int, completedFrom int64)) error
 	// initIndexDatabase initializes index database
 	initIndexDatabase() error
 	// TTL expires the data of each segment base on time to live.
 	TTL()
 	// EvictSegment evicts segment which long term no read operation.
 	EvictSegment()
+	// TierFamilies moves the files of families which end before given timestamp to object storage,
+	// returns the num. of families tiered.
+	TierFamilies(ctx context.Context, before int64, throttle kv.Throttle) (int, error)
+	// GCSeries reclaims the series which are not written within horizon and not present in any retained family,
+	// at most limit series are reclaimed each round.
+	GCSeries(horizon time.Duration, limit int)
+	// recoveryTasks returns the tasks for opening the segments of writable interval after startup.
+	recoveryTasks() []*recoveryTask
+	// Hibernate hibernates the shard if no writes/queries within idle period and no data in memory,
+	// releases write buffers and skips per-shard statistics collecting, returns if hibernated by this call.
+	Hibernate(idle time.Duration) bool
+	// IsHibernated returns if the shard is hibernated.
+	IsHibernated() bool
 	// Closer releases shard's resource, such as flush data, spawned goroutines etc.
 	io.Closer
 }
@@ -95,15 +122,28 @@ type shard struct {
 	// segments keeps all rollup target interval segments,
 	// includes one smallest interval segment for writing data, and rollup interval segments
 	rollupTargets  map[timeutil.Interval]IntervalSegment
+	rollupMutex    sync.RWMutex    // mutex for adding rollup target interval segments
 	segment        IntervalSegment // smallest interval for writing data
 	isFlushing     atomic.Bool     // restrict flusher concurrency
 	flushCondition *sync.Cond      // flush condition
 
+	familyCreations familyCreationGroup // deduplicates concurrent creations of same family
+
+	bufferPath     string       // path of write buffers, re-created when woken up
+	lastAccessTime atomic.Int64 // the last time of write/query
+	hibernated     atomic.Bool  // if shard is hibernated after idle period
+	hibernateMutex sync.Mutex   // mutex for hibernating/waking up
+
 	indexStore     kv.Store  // kv stores
 	forwardFamily  kv.Family // forward store
 	invertedFamily kv.Family // inverted store
 	logger         *logger.Logger
 
+	latestDataTime atomic.Int64 // the latest data time of families, kept after families evicted
+	latestFamily   atomic.Int64 // the start time of the latest family created, keeps family creation monotonic
+
+	engineCtx *engineContext // resources of engine which shard belongs to
+
 	statistics *metrics.ShardStatistics
 }
 
@@ -113,11 +153,13 @@ func newShard(
 	db Database,
 	shardID models.ShardID,
 ) (s Shard, err error) {
-	shardPath := shardPath(db.Name(), shardID)
+	engineCtx := engineContextOf(db)
+	shardPath := shardPath(engineCtx.rootDir(), db.Name(), shardID)
 	err = mkDirIfNotExist(shardPath)
 	if err != nil {
 		return nil, err
 	}
+	bufferPath := engineCtx.bufferPath(db.Name(), shardID)
 	dbOption := db.GetOption()
 	createdShard := &shard{
 		db:             db,
@@ -125,15 +167,31 @@ func newShard(
 		id:             shardID,
 		option:         dbOption,
 		metadata:       db.Metadata(),
-		bufferMgr:      memdb.NewBufferManager(shardTempBufferPath(db.Name(), shardID)),
+		bufferMgr:      memdb.NewBufferManager(bufferPath),
+		bufferPath:     bufferPath,
 		rollupTargets:  make(map[timeutil.Interval]IntervalSegment),
 		isFlushing:     *atomic.NewBool(false),
 		flushCondition: sync.NewCond(&sync.Mutex{}),
+		engineCtx:      engineCtx,
 		statistics:     metrics.NewShardStatistics(db.Name(), strconv.Itoa(int(shardID))),
 		logger:         logger.GetLogger("TSDB", "Shard"),
 	}
-	// try cleanup history dirty write buffer
+	createdShard.lastAccessTime.Store(timeutil.Now())
+	createdShard.statistics.IngestionLag.SetGetValueFn(func(val *atomic.Float64) {
+		if createdShard.IsHibernated() {
+			// skip collecting for hibernated shard, keeps the last value
+			return
+		}
+		val.Store(float64(createdShard.ingestionLag().Milliseconds()))
+	})
+	// try cleanup history dirty write buffer, unflushed data is replayed from wal
 	createdShard.bufferMgr.Cleanup()
+	if err = createdShard.cleanupLegacyBuffer(bufferPath); err != nil {
+		return nil, err
+	}
+	if err = mkDirIfNotExist(bufferPath); err != nil {
+		return nil, err
+	}
 
 	// sort intervals
 	sort.Sort(dbOption.Intervals)
@@ -169,9 +227,18 @@ func newShard(
 	if err = createdShard.initIndexDatabase(); err != nil {
 		return nil, fmt.Errorf("create index database for shard[%d] error: %s", shardID, err)
 	}
+	engineCtx.familyManager().SetShardHibernated(createdShard, false)
 	return createdShard, nil
 }
 
+// engineContext returns the resources of engine which shard belongs to.
+func (s *shard) engineContext() *engineContext {
+	if s.engineCtx == nil {
+		return defaultEngineContext
+	}
+	return s.engineCtx
+}
+
 // Database returns the database.
 func (s *shard) Database() Database { return s.db }
 
@@ -191,35 +258,114 @@ func (s *shard) BufferManager() memdb.BufferManager {
 	return s.bufferMgr
 }
 
+// cleanupLegacyBuffer abandons the write buffers under default path of shard after buffer dir changed,
+// buffers are temporary(never reused after restart), so that they can be removed safely.
+func (s *shard) cleanupLegacyBuffer(bufferPath string) error {
+	legacyPath := shardTempBufferPath(s.engineContext().rootDir(), s.db.Name(), s.id)
+	if legacyPath == bufferPath || !fileExist(legacyPath) {
+		return nil
+	}
+	s.logger.Info("abandon write buffer under legacy path",
+		logger.String("shard", s.indicator), logger.String("path", legacyPath))
+	return removeDir(legacyPath)
+}
+
+// GetOrCrateDataFamily returns data family, if not exist create a new data family,
+// returns the latest family instead of creating earlier family if wall clock regresses.
 func (s *shard) GetOrCrateDataFamily(familyTime int64) (DataFamily, error) {
-	segmentName := s.interval.Calculator().GetSegment(familyTime)
+	return s.getOrCreateDataFamily(familyTime, false)
+}
+
+// GetOrCreateBackfillDataFamily returns data family for backfill(e.g. install replica snapshot),
+// if not exist create a new data family even if it's earlier than the latest family.
+func (s *shard) GetOrCreateBackfillDataFamily(familyTime int64) (DataFamily, error) {
+	return s.getOrCreateDataFamily(familyTime, true)
+}
+
+func (s *shard) getOrCreateDataFamily(familyTime int64, backfill bool) (DataFamily, error) {
+	if s.engineContext().readOnly {
+		return nil, constants.ErrReadOnly
+	}
+	if err := s.touch(); err != nil {
+		return nil, err
+	}
+	calc := s.interval.Calculator()
 	// source segment
-	segment, err := s.segment.GetOrCreateSegment(segmentName)
+	segment, err := s.segment.GetOrCreateSegment(calc.GetSegment(familyTime))
 	if err != nil {
 		return nil, err
 	}
+	segmentTime := calc.CalcSegmentTime(familyTime)
+	familyStartTime := calc.CalcFamilyStartTime(segmentTime, calc.CalcFamily(familyTime, segmentTime))
+	if !backfill && s.isFamilyRegressed(segment, familyStartTime) {
+		latest := s.latestFamily.Load()
+		s.statistics.FamilyRegressed.Incr()
+		s.logger.Warn("wall clock regresses, route writes to the latest family",
+			logger.String("shard", s.indicator),
+			logger.String("family", timeutil.FormatTimestamp(familyStartTime, timeutil.DataTimeFormat2)),
+			logger.String("latest", timeutil.FormatTimestamp(latest, timeutil.DataTimeFormat2)))
+		familyTime = latest
+		familyStartTime = latest
+		segment, err = s.segment.GetOrCreateSegment(calc.GetSegment(familyTime))
+		if err != nil {
+			return nil, err
+		}
+	}
 	// build rollup target segment if set auto rollup interval
-	for interval, rollupSegment := range s.rollupTargets {
+	for interval, rollupSegment := range s.getRollupTargets() {
 		_, err = rollupSegment.GetOrCreateSegment(interval.Calculator().GetSegment(familyTime))
 		if err != nil {
 			return nil, err
 		}
 	}
-	family, err := segment.GetOrCreateDataFamily(familyTime)
+	// concurrent writers at interval boundary share one creation of same family
+	key := familyCreationKey{
+		interval:   s.interval,
+		familyTime: familyStartTime,
+	}
+	family, err := s.familyCreations.do(key, func() (DataFamily, error) {
+		return segment.GetOrCreateDataFamily(familyTime)
+	})
 	if err != nil {
 		return nil, err
 	}
+	for {
+		latest := s.latestFamily.Load()
+		if familyStartTime <= latest || s.latestFamily.CAS(latest, familyStartTime) {
+			break
+		}
+	}
 	return family, nil
 }
 
+// isFamilyRegressed returns if the family to be created is earlier than the latest family,
+// but not earlier than the family of current wall clock, which means wall clock regresses(e.g. NTP steps back),
+// writes of current time would oscillate between families if such family is created.
+// The family earlier than the family of current wall clock is late writes(within out-of-order window).
+func (s *shard) isFamilyRegressed(segment Segment, familyStartTime int64) bool {
+	if familyStartTime >= s.latestFamily.Load() {
+		return false
+	}
+	if familyStartTime < s.interval.Calculator().CalcFamilyTime(nowFunc()) {
+		return false
+	}
+	// writes of existing family are accepted
+	return !segment.existDataFamily(familyStartTime)
+}
+
 func (s *shard) GetDataFamilies(intervalType timeutil.IntervalType, timeRange timeutil.TimeRange) []DataFamily {
+	if err := s.touch(); err != nil {
+		// query reads the flushed data only, which is not affected by write buffers
+		s.logger.Warn("wake up shard failure when query",
+			logger.String("shard", s.indicator), logger.Error(err))
+	}
 	// first check query interval is writable interval.
-	if s.interval.Type() == intervalType || len(s.rollupTargets) == 1 {
+	if s.interval.Type() == intervalType || len(s.getRollupTargets()) == 1 {
 		// if no rollup, need to use current writable interval.
 		return s.segment.GetDataFamilies(timeRange)
 	}
 	// then find family from rollup targets
-	for interval, rollupSegment := range s.rollupTargets {
+	for interval, rollupSegment := range s.getRollupTargets() {
 		if interval.Type() == intervalType {
 			return rollupSegment.GetDataFamilies(timeRange)
 		}
@@ -320,8 +466,15 @@ Done:
 
 // LookupRowMetricMeta lookups the metadata of metric data for each row with same family in batch.
 func (s *shard) LookupRowMetricMeta(rows []metric.StorageRow) error {
+	if s.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	if err := s.touch(); err != nil {
+		return err
+	}
 	for idx := range rows {
 		if err := s.lookupRowMeta(&rows[idx]); err != nil {
+			rows[idx].LookupErr = err
 			s.statistics.LookupMetricMetaFailures.Incr()
 			s.logger.Error("failed to lookup meta of row",
 				logger.String("database", s.db.Name()),
@@ -332,9 +485,30 @@ func (s *shard) LookupRowMetricMeta(rows []metric.StorageRow) error {
 	return nil
 }
 
+// ingestionLag returns the duration between now and the latest data written into shard,
+// which is the minimum ingestion lag of families(the latest data time is kept after families evicted).
+func (s *shard) ingestionLag() time.Duration {
+	now := timeutil.Now()
+	for _, family := range s.engineContext().familyManager().GetFamiliesByShard(s) {
+		dataTime := now - family.IngestionLag().Milliseconds()
+		for {
+			latest := s.latestDataTime.Load()
+			if dataTime <= latest || s.latestDataTime.CAS(latest, dataTime) {
+				break
+			}
+		}
+	}
+	latest := s.latestDataTime.Load()
+	if latest <= 0 || latest > now {
+		return 0
+	}
+	return time.Duration(now-latest) * time.Millisecond
+}
+
 func (s *shard) Close() error {
 	// finally, cleanup temp buffer.
 	defer s.bufferMgr.Cleanup()
+	defer s.engineContext().familyManager().RemoveShard(s)
 	// wait previous flush job completed
 	s.WaitFlushIndexCompleted()
 
@@ -344,20 +518,25 @@ func (s *shard) Close() error {
 		}
 	}
 	if s.indexStore != nil {
-		if err := kv.GetStoreManager().CloseStore(s.indexStore.Name()); err != nil {
+		if err := s.engineContext().storeManager().CloseStore(s.indexStore.Name()); err != nil {
 			return err
 		}
 	}
 	// close segment/flush family data
 	s.segment.Close()
-	for _, rollupSegment := range s.rollupTargets {
+	for _, rollupSegment := range s.getRollupTargets() {
 		rollupSegment.Close()
 	}
+	// unregister shard statistics, avoid reporting metrics of closed shard
+	s.statistics.Close()
 	return nil
 }
 
 // FlushIndex flushes index data to disk
 func (s *shard) FlushIndex() (err error) {
+	if s.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
 	// another flush process is running
 	if !s.isFlushing.CAS(false, true) {
 		return nil
@@ -400,7 +579,7 @@ func (s *shard) WaitFlushIndexCompleted() {
 
 // TTL expires the data of each segment base on time to live.
 func (s *shard) TTL() {
-	for interval, rollupSegment := range s.rollupTargets {
+	for interval, rollupSegment := range s.getRollupTargets() {
 		if err := rollupSegment.TTL(); err != nil {
 			s.logger.Warn("do segment ttl failure",
 				logger.String("database", s.db.Name()),
@@ -412,17 +591,167 @@ func (s *shard) TTL() {
 	}
 }
 
+// GCSeries reclaims the series which are not written within horizon and not present in any retained family,
+// at most limit series are reclaimed each round.
+func (s *shard) GCSeries(horizon time.Duration, limit int) {
+	startTime := time.Now()
+	reclaimed, err := s.indexDB.GCSeries(horizon, limit, s.presentSeriesIDs)
+	if err != nil {
+		s.logger.Warn("do series gc failure",
+			logger.String("database", s.db.Name()),
+			logger.Any("shardID", s.id),
+			logger.Int("reclaimed", reclaimed),
+			logger.Error(err),
+		)
+		return
+	}
+	s.logger.Info("do series gc successfully",
+		logger.String("database", s.db.Name()),
+		logger.Any("shardID", s.id),
+		logger.Int("reclaimed", reclaimed),
+		logger.String("cost", time.Since(startTime).String()),
+	)
+}
+
+// presentSeriesIDs returns the series ids which present in any retained family of all intervals.
+func (s *shard) presentSeriesIDs(metricID metric.ID, seriesIDs *roaring.Bitmap) (*roaring.Bitmap, error) {
+	result := roaring.New()
+	for _, segment := range s.getRollupTargets() {
+		if err := segment.walkFamilies(func(family DataFamily) error {
+			present, err := family.GetSeriesIDs(metricID)
+			if err != nil {
+				return err
+			}
+			result.Or(present)
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+	result.And(seriesIDs)
+	return result, nil
+}
+
 // EvictSegment evicts segment which long term no read operation.
 func (s *shard) EvictSegment() {
-	for _, rollupSegment := range s.rollupTargets {
+	for _, rollupSegment := range s.getRollupTargets() {
 		rollupSegment.EvictSegment()
 	}
 }
 
+// recoveryTasks returns the tasks for opening the segments of writable interval after startup,
+// rollup segments are opened lazily because they are only written by rollup job.
+func (s *shard) recoveryTasks() []*recoveryTask {
+	return s.segment.recoveryTasks()
+}
+
+// SetCompactionPolicy sets the compaction policy of data families, which takes effect for new compactions.
+func (s *shard) SetCompactionPolicy(policy option.CompactionPolicy) {
+	for _, rollupSegment := range s.getRollupTargets() {
+		rollupSegment.SetCompactionPolicy(policy)
+	}
+}
+
+// AddRollupIntervals adds the rollup target intervals, new flushed files of writable interval are rolled up to them.
+func (s *shard) AddRollupIntervals(intervals option.Intervals) error {
+	if len(intervals) == 0 {
+		return nil
+	}
+	s.rollupMutex.Lock()
+	defer s.rollupMutex.Unlock()
+
+	targets := make(map[timeutil.Interval]IntervalSegment, len(s.rollupTargets)+len(intervals))
+	for interval, segment := range s.rollupTargets {
+		targets[interval] = segment
+	}
+	for _, targetInterval := range intervals {
+		if _, ok := targets[targetInterval.Interval]; ok {
+			continue
+		}
+		segment, err := newIntervalSegmentFunc(s, targetInterval)
+		if err != nil {
+			return err
+		}
+		targets[targetInterval.Interval] = segment
+	}
+	var rollup []timeutil.Interval
+	for interval := range targets {
+		if interval != s.interval {
+			rollup = append(rollup, interval)
+		}
+	}
+	sort.Slice(rollup, func(i, j int) bool { return rollup[i] < rollup[j] })
+	s.rollupTargets = targets
+	s.segment.SetRollup(s.interval, rollup)
+	s.logger.Info("add rollup intervals successfully",
+		logger.String("database", s.db.Name()),
+		logger.Any("shardID", s.id),
+		logger.Any("rollup", rollup))
+	return nil
+}
+
+// BackfillRollup rolls up the history families of writable interval to given intervals, newest family first,
+// families whose start time >= resumeFrom are skipped(resumeFrom <= 0 means starting from scratch),
+// progress is invoked after each family completed with the start time of completed family.
+func (s *shard) BackfillRollup(intervals option.Intervals, resumeFrom int64,
+	progress func(completed, total int, completedFrom int64),
+) error {
+	if s.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	targets := s.getRollupTargets()
+	for _, targetInterval := range intervals {
+		if _, ok := targets[targetInterval.Interval]; !ok {
+			return fmt.Errorf("rollup interval %s not found in shard %s", targetInterval.Interval.String(), s.indicator)
+		}
+	}
+	var families []DataFamily
+	if err := s.segment.walkFamilies(func(family DataFamily) error {
+		families = append(families, family)
+		return nil
+	}); err != nil {
+		return err
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return families[i].TimeRange().Start > families[j].TimeRange().Start
+	})
+	total := len(families)
+	completed := 0
+	for _, family := range families {
+		familyStart := family.TimeRange().Start
+		if resumeFrom > 0 && familyStart >= resumeFrom {
+			// family completed by previous backfill
+			completed++
+			continue
+		}
+		for _, targetInterval := range intervals {
+			interval := targetInterval.Interval
+			// make sure the kv store of target segment exists
+			if _, err := targets[interval].GetOrCreateSegment(interval.Calculator().GetSegment(familyStart)); err != nil {
+				return err
+			}
+			if err := family.Family().BackfillRollup(interval); err != nil {
+				return err
+			}
+		}
+		completed++
+		progress(completed, total, familyStart)
+	}
+	return nil
+}
+
+// getRollupTargets returns all rollup target interval segments.
+func (s *shard) getRollupTargets() map[timeutil.Interval]IntervalSegment {
+	s.rollupMutex.RLock()
+	defer s.rollupMutex.RUnlock()
+
+	return s.rollupTargets
+}
+
 // initIndexDatabase initializes the index database
 func (s *shard) initIndexDatabase() error {
 	var err error
-	s.indexStore, err = kv.GetStoreManager().CreateStore(shardIndexIndicator(s.db.Name(), s.id), kv.DefaultStoreOption())
+	s.indexStore, err = s.engineContext().storeManager().CreateStore(shardIndexIndicator(s.db.Name(), s.id), kv.DefaultStoreOption())
 	if err != nil {
 		return err
 	}
@@ -444,7 +773,7 @@ func (s *shard) initIndexDatabase() error {
 	}
 	s.indexDB, err = newIndexDBFunc(
 		context.TODO(),
-		shardMetaPath(s.db.Name(), s.id),
+		shardMetaPath(s.engineContext().rootDir(), s.db.Name(), s.id),
 		s.metadata, s.forwardFamily,
 		s.invertedFamily)
 	if err != nil {
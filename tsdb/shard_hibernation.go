@@ -0,0 +1,101 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"time"
+
+	"github.com/lindb/lindb/metrics"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// Hibernate hibernates the shard if no writes/queries within idle period and no data in memory,
+// releases write buffers and skips per-shard statistics collecting, returns if hibernated by this call.
+func (s *shard) Hibernate(idle time.Duration) bool {
+	if idle <= 0 || s.engineContext().readOnly || s.IsHibernated() || !s.isIdle(idle) {
+		return false
+	}
+	s.hibernateMutex.Lock()
+	defer s.hibernateMutex.Unlock()
+
+	if s.IsHibernated() {
+		return false
+	}
+	// mark hibernated before re-checking, so that the writer which touched shard concurrently
+	// either is seen by re-checking, or sees hibernated then waits waking up.
+	s.hibernated.Store(true)
+	if !s.isIdle(idle) || s.hasDataInMemory() {
+		s.hibernated.Store(false)
+		return false
+	}
+	s.bufferMgr.Cleanup()
+	s.engineContext().familyManager().SetShardHibernated(s, true)
+	metrics.ShardHibernationStatistics.Hibernations.Incr()
+	s.logger.Info("shard hibernated after idle period",
+		logger.String("shard", s.indicator), logger.String("idle", idle.String()))
+	return true
+}
+
+// IsHibernated returns if the shard is hibernated.
+func (s *shard) IsHibernated() bool {
+	return s.hibernated.Load()
+}
+
+// touch records the access time of write/query, then wakes up the shard if hibernated.
+func (s *shard) touch() error {
+	s.lastAccessTime.Store(timeutil.Now())
+	if !s.IsHibernated() {
+		return nil
+	}
+	return s.wakeup()
+}
+
+// wakeup re-creates the write buffer dir of hibernated shard, concurrent first writes/queries
+// wait the same waking up.
+func (s *shard) wakeup() error {
+	s.hibernateMutex.Lock()
+	defer s.hibernateMutex.Unlock()
+
+	if !s.IsHibernated() {
+		return nil
+	}
+	if err := mkDirIfNotExist(s.bufferPath); err != nil {
+		return err
+	}
+	s.hibernated.Store(false)
+	s.engineContext().familyManager().SetShardHibernated(s, false)
+	metrics.ShardHibernationStatistics.Wakeups.Incr()
+	s.logger.Info("shard woken up", logger.String("shard", s.indicator))
+	return nil
+}
+
+// isIdle returns if no writes/queries within idle period.
+func (s *shard) isIdle(idle time.Duration) bool {
+	return timeutil.Now()-s.lastAccessTime.Load() >= idle.Milliseconds()
+}
+
+// hasDataInMemory returns if any family of shard has memory database(holding write buffers) or is flushing.
+func (s *shard) hasDataInMemory() bool {
+	for _, family := range s.engineContext().familyManager().GetFamiliesByShard(s) {
+		if family.IsFlushing() || len(family.GetState().MemoryDatabases) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,241 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/fileutil"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb/memdb"
+)
+
+func newHibernationTestShard(ctrl *gomock.Controller) (*shard, *memdb.MockBufferManager) {
+	bufferMgr := memdb.NewMockBufferManager(ctrl)
+	s := &shard{
+		indicator:  "db/1",
+		bufferPath: "buffer/db/1",
+		bufferMgr:  bufferMgr,
+		engineCtx:  &engineContext{familyMgr: newFamilyManager()},
+		logger:     logger.GetLogger("TSDB", "Test"),
+	}
+	s.lastAccessTime.Store(timeutil.Now() - time.Hour.Milliseconds())
+	s.engineCtx.familyManager().SetShardHibernated(s, false)
+	return s, bufferMgr
+}
+
+func TestShard_Hibernate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	cases := []struct {
+		name       string
+		idle       time.Duration
+		prepare    func(s *shard, bufferMgr *memdb.MockBufferManager)
+		hibernated bool
+	}{
+		{
+			name: "hibernation disabled",
+			idle: 0,
+		},
+		{
+			name: "engine read only",
+			idle: time.Minute,
+			prepare: func(s *shard, _ *memdb.MockBufferManager) {
+				s.engineCtx.readOnly = true
+			},
+		},
+		{
+			name: "shard not idle",
+			idle: time.Minute,
+			prepare: func(s *shard, _ *memdb.MockBufferManager) {
+				s.lastAccessTime.Store(timeutil.Now())
+			},
+		},
+		{
+			name: "family is flushing",
+			idle: time.Minute,
+			prepare: func(s *shard, _ *memdb.MockBufferManager) {
+				family := NewMockDataFamily(ctrl)
+				family.EXPECT().Indicator().Return("db/1/1").AnyTimes()
+				family.EXPECT().Shard().Return(s).AnyTimes()
+				family.EXPECT().IsFlushing().Return(true)
+				s.engineCtx.familyManager().AddFamily(family)
+			},
+		},
+		{
+			name: "family has memory database",
+			idle: time.Minute,
+			prepare: func(s *shard, _ *memdb.MockBufferManager) {
+				family := NewMockDataFamily(ctrl)
+				family.EXPECT().Indicator().Return("db/1/1").AnyTimes()
+				family.EXPECT().Shard().Return(s).AnyTimes()
+				family.EXPECT().IsFlushing().Return(false)
+				family.EXPECT().GetState().Return(models.DataFamilyState{
+					MemoryDatabases: []models.MemoryDatabaseState{{}},
+				})
+				s.engineCtx.familyManager().AddFamily(family)
+			},
+		},
+		{
+			name: "hibernate successfully",
+			idle: time.Minute,
+			prepare: func(s *shard, bufferMgr *memdb.MockBufferManager) {
+				family := NewMockDataFamily(ctrl)
+				family.EXPECT().Indicator().Return("db/1/1").AnyTimes()
+				family.EXPECT().Shard().Return(s).AnyTimes()
+				family.EXPECT().IsFlushing().Return(false)
+				family.EXPECT().GetState().Return(models.DataFamilyState{})
+				s.engineCtx.familyManager().AddFamily(family)
+				bufferMgr.EXPECT().Cleanup()
+			},
+			hibernated: true,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			s, bufferMgr := newHibernationTestShard(ctrl)
+			if tt.prepare != nil {
+				tt.prepare(s, bufferMgr)
+			}
+			assert.Equal(t, tt.hibernated, s.Hibernate(tt.idle))
+			assert.Equal(t, tt.hibernated, s.IsHibernated())
+			active, hibernated := s.engineCtx.familyManager().ShardStats()
+			if tt.hibernated {
+				assert.Equal(t, 0, active)
+				assert.Equal(t, 1, hibernated)
+				// already hibernated
+				assert.False(t, s.Hibernate(tt.idle))
+			} else {
+				assert.Equal(t, 1, active)
+				assert.Equal(t, 0, hibernated)
+			}
+		})
+	}
+}
+
+func TestShard_Wakeup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		mkDirIfNotExist = fileutil.MkDirIfNotExist
+		ctrl.Finish()
+	}()
+
+	s, bufferMgr := newHibernationTestShard(ctrl)
+	bufferMgr.EXPECT().Cleanup().AnyTimes()
+	segment := NewMockIntervalSegment(ctrl)
+	segment.EXPECT().GetDataFamilies(gomock.Any()).Return(nil).AnyTimes()
+	s.interval = timeutil.Interval(10 * 1000)
+	s.segment = segment
+
+	// wake up failure, query still works
+	assert.True(t, s.Hibernate(time.Minute))
+	mkDirIfNotExist = func(path string) error {
+		return fmt.Errorf("err")
+	}
+	s.GetDataFamilies(timeutil.Day, timeutil.TimeRange{})
+	assert.True(t, s.IsHibernated())
+	// write returns wake up err
+	_, err := s.GetOrCrateDataFamily(timeutil.Now())
+	assert.Error(t, err)
+	assert.True(t, s.IsHibernated())
+
+	// wake up by query
+	var dirs []string
+	mkDirIfNotExist = func(path string) error {
+		dirs = append(dirs, path)
+		return nil
+	}
+	s.GetDataFamilies(timeutil.Day, timeutil.TimeRange{})
+	assert.False(t, s.IsHibernated())
+	assert.Equal(t, []string{"buffer/db/1"}, dirs)
+	active, hibernated := s.engineCtx.familyManager().ShardStats()
+	assert.Equal(t, 1, active)
+	assert.Equal(t, 0, hibernated)
+	// just accessed, not idle
+	assert.False(t, s.Hibernate(time.Minute))
+}
+
+func TestShard_Wakeup_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		mkDirIfNotExist = fileutil.MkDirIfNotExist
+		ctrl.Finish()
+	}()
+
+	s, bufferMgr := newHibernationTestShard(ctrl)
+	bufferMgr.EXPECT().Cleanup()
+	assert.True(t, s.Hibernate(time.Minute))
+
+	var wakeups atomic.Int32
+	mkDirIfNotExist = func(path string) error {
+		wakeups.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}
+	var wait sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			assert.NoError(t, s.touch())
+		}()
+	}
+	wait.Wait()
+	assert.Equal(t, int32(1), wakeups.Load())
+	assert.False(t, s.IsHibernated())
+}
+
+func TestShard_Hibernate_Touch_Race(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		mkDirIfNotExist = fileutil.MkDirIfNotExist
+		ctrl.Finish()
+	}()
+	mkDirIfNotExist = func(path string) error {
+		return nil
+	}
+
+	for i := 0; i < 100; i++ {
+		s, bufferMgr := newHibernationTestShard(ctrl)
+		bufferMgr.EXPECT().Cleanup().AnyTimes()
+		var wait sync.WaitGroup
+		wait.Add(2)
+		go func() {
+			defer wait.Done()
+			s.Hibernate(time.Minute)
+		}()
+		go func() {
+			defer wait.Done()
+			assert.NoError(t, s.touch())
+		}()
+		wait.Wait()
+		// shard must be active after accessed
+		assert.False(t, s.IsHibernated())
+	}
+}
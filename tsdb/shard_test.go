@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -28,8 +29,11 @@ import (
 	"github.com/golang/mock/gomock"
 	commonconstants "github.com/lindb/common/constants"
 	protoMetricsV1 "github.com/lindb/common/proto/gen/v1/linmetrics"
+	"github.com/lindb/roaring"
 	"github.com/stretchr/testify/assert"
 
+	"github.com/lindb/lindb/config"
+	"github.com/lindb/lindb/constants"
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/metrics"
 	"github.com/lindb/lindb/pkg/fileutil"
@@ -196,6 +200,203 @@ func TestShard_New(t *testing.T) {
 	}
 }
 
+func TestShard_BufferPath(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	tmpDir := t.TempDir()
+	defer func() {
+		config.SetGlobalStorageConfig(config.NewDefaultStorageBase())
+		newIntervalSegmentFunc = newIntervalSegment
+		newIndexDBFunc = indexdb.NewIndexDatabase
+		kv.InitStoreManager(nil)
+		ctrl.Finish()
+	}()
+	mkDirIfNotExist = fileutil.MkDirIfNotExist
+	fileExist = fileutil.Exist
+	removeDir = fileutil.RemoveDir
+
+	cfg := config.NewDefaultStorageBase()
+	cfg.TSDB.Dir = filepath.Join(tmpDir, "data")
+	cfg.TSDB.BufferDir = filepath.Join(tmpDir, "buffer", "{database}", "{shard}")
+	config.SetGlobalStorageConfig(cfg)
+
+	storeMgr := kv.NewMockStoreManager(ctrl)
+	kv.InitStoreManager(storeMgr)
+	store := kv.NewMockStore(ctrl)
+	storeMgr.EXPECT().CreateStore(gomock.Any(), gomock.Any()).Return(store, nil)
+	store.EXPECT().CreateFamily(gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+	newIndexDBFunc = func(ctx context.Context, parent string, metadata metadb.Metadata,
+		forwardFamily kv.Family, invertedFamily kv.Family) (indexdb.IndexDatabase, error) {
+		return nil, nil
+	}
+	seg := NewMockIntervalSegment(ctrl)
+	newIntervalSegmentFunc = func(shard Shard, interval option.Interval) (IntervalSegment, error) {
+		return seg, nil
+	}
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("db").AnyTimes()
+	db.EXPECT().Metadata().Return(nil).AnyTimes()
+	db.EXPECT().GetOption().Return(&option.DatabaseOption{Intervals: option.Intervals{{Interval: 10 * 1000}}})
+
+	// legacy buffer under shard path
+	legacyPath := shardTempBufferPath(cfg.TSDB.Dir, "db", 1)
+	assert.NoError(t, fileutil.MkDirIfNotExist(legacyPath))
+
+	s, err := newShard(db, 1)
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	assert.False(t, fileutil.Exist(legacyPath))
+	assert.True(t, fileutil.Exist(filepath.Join(tmpDir, "buffer", "db", "1")))
+	assert.Equal(t, filepath.Join(tmpDir, "buffer", "db", "1"), defaultEngineContext.bufferPath("db", 1))
+
+	// read-only engine uses buffer dir under shard path
+	readOnlyCtx := &engineContext{dir: tmpDir, readOnly: true}
+	assert.Equal(t, shardTempBufferPath(tmpDir, "db", 1), readOnlyCtx.bufferPath("db", 1))
+}
+
+func TestShard_GetOrCreateDataFamily_Concurrent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	familyMgr := newFamilyManager()
+	interval := timeutil.Interval(10 * 1000) // 10s
+	intervalSeg := NewMockIntervalSegment(ctrl)
+	seg := NewMockSegment(ctrl)
+	s := &shard{
+		interval:      interval,
+		segment:       intervalSeg,
+		rollupTargets: map[timeutil.Interval]IntervalSegment{interval: intervalSeg},
+		engineCtx:     &engineContext{familyMgr: familyMgr},
+	}
+	intervalSeg.EXPECT().GetOrCreateSegment(gomock.Any()).Return(seg, nil).AnyTimes()
+
+	// segment creates family without dedup, each creation registers a family
+	var (
+		mutex   sync.Mutex
+		created DataFamily
+		seq     int
+	)
+	seg.EXPECT().GetOrCreateDataFamily(gomock.Any()).DoAndReturn(func(_ int64) (DataFamily, error) {
+		mutex.Lock()
+		family := created
+		mutex.Unlock()
+		if family != nil {
+			return family, nil
+		}
+		time.Sleep(50 * time.Millisecond)
+		mutex.Lock()
+		defer mutex.Unlock()
+		seq++
+		f := NewMockDataFamily(ctrl)
+		f.EXPECT().Indicator().Return(fmt.Sprintf("family-%d", seq)).AnyTimes()
+		familyMgr.AddFamily(f)
+		created = f
+		return f, nil
+	}).AnyTimes()
+
+	now := timeutil.Now()
+	var wait sync.WaitGroup
+	families := make([]DataFamily, 32)
+	for i := range families {
+		wait.Add(1)
+		go func(idx int) {
+			defer wait.Done()
+			family, err := s.GetOrCrateDataFamily(now)
+			assert.NoError(t, err)
+			families[idx] = family
+		}(i)
+	}
+	wait.Wait()
+
+	count := 0
+	familyMgr.WalkEntry(func(_ DataFamily) {
+		count++
+	})
+	assert.Equal(t, 1, count)
+	for _, family := range families {
+		assert.Equal(t, families[0], family)
+	}
+}
+
+func TestShard_GetOrCreateDataFamily_ClockRegressed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		nowFunc = timeutil.Now
+		ctrl.Finish()
+	}()
+
+	interval := timeutil.Interval(10 * 1000) // 10s, family is one hour, segment is one day
+	intervalSeg := NewMockIntervalSegment(ctrl)
+	seg := NewMockSegment(ctrl)
+	s := &shard{
+		indicator:     "db/1",
+		interval:      interval,
+		segment:       intervalSeg,
+		rollupTargets: map[timeutil.Interval]IntervalSegment{interval: intervalSeg},
+		engineCtx:     &engineContext{familyMgr: newFamilyManager()},
+		statistics:    metrics.NewShardStatistics("db", "1"),
+		logger:        logger.GetLogger("TSDB", "Test"),
+	}
+	defer s.statistics.Close()
+	intervalSeg.EXPECT().GetOrCreateSegment(gomock.Any()).Return(seg, nil).AnyTimes()
+	family := NewMockDataFamily(ctrl)
+	latestFamily := NewMockDataFamily(ctrl)
+	ts := func(str string) int64 {
+		t0, err := timeutil.ParseTimestamp(str)
+		assert.NoError(t, err)
+		return t0
+	}
+	setNow := func(str string) {
+		now := ts(str)
+		nowFunc = func() int64 { return now }
+	}
+	seg.EXPECT().GetOrCreateDataFamily(gomock.Any()).DoAndReturn(func(familyTime int64) (DataFamily, error) {
+		if familyTime == ts("2026-10-18 00:00:00") {
+			return latestFamily, nil
+		}
+		return family, nil
+	}).AnyTimes()
+
+	// writes before day boundary, then next day family created
+	setNow("2026-10-17 23:10:00")
+	_, err := s.GetOrCrateDataFamily(ts("2026-10-17 23:10:00"))
+	assert.NoError(t, err)
+	setNow("2026-10-18 00:30:00")
+	_, err = s.GetOrCrateDataFamily(ts("2026-10-18 00:30:00"))
+	assert.NoError(t, err)
+	assert.Equal(t, ts("2026-10-18 00:00:00"), s.latestFamily.Load())
+
+	// NTP steps wall clock back 2 hours across day boundary
+	setNow("2026-10-17 22:30:00")
+	// writes of current wall clock which is earlier than the latest family land in the latest family
+	seg.EXPECT().existDataFamily(ts("2026-10-17 22:00:00")).Return(false)
+	f, err := s.GetOrCrateDataFamily(ts("2026-10-17 22:40:00"))
+	assert.NoError(t, err)
+	assert.Equal(t, latestFamily, f)
+	assert.Equal(t, float64(1), s.statistics.FamilyRegressed.Get())
+	assert.Equal(t, ts("2026-10-18 00:00:00"), s.latestFamily.Load())
+	// writes of existing family are accepted
+	seg.EXPECT().existDataFamily(ts("2026-10-17 23:00:00")).Return(true)
+	_, err = s.GetOrCrateDataFamily(ts("2026-10-17 23:20:00"))
+	assert.NoError(t, err)
+	// late writes earlier than the family of wall clock(out-of-order window) are accepted
+	_, err = s.GetOrCrateDataFamily(ts("2026-10-17 21:10:00"))
+	assert.NoError(t, err)
+	// writes of the latest family are accepted
+	_, err = s.GetOrCrateDataFamily(ts("2026-10-18 00:40:00"))
+	assert.NoError(t, err)
+	// backfill creates earlier family
+	_, err = s.GetOrCreateBackfillDataFamily(ts("2026-10-17 22:40:00"))
+	assert.NoError(t, err)
+	assert.Equal(t, ts("2026-10-18 00:00:00"), s.latestFamily.Load())
+	assert.Equal(t, float64(1), s.statistics.FamilyRegressed.Get())
+
+	// wall clock catches up, family rolls over
+	setNow("2026-10-18 01:05:00")
+	_, err = s.GetOrCrateDataFamily(ts("2026-10-18 01:05:00"))
+	assert.NoError(t, err)
+	assert.Equal(t, ts("2026-10-18 01:00:00"), s.latestFamily.Load())
+}
+
 func TestShard_GetDataFamilies(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -291,6 +492,7 @@ func TestShard_Close(t *testing.T) {
 		},
 		flushCondition: sync.NewCond(&sync.Mutex{}),
 		bufferMgr:      bufferMgr,
+		statistics:     metrics.NewShardStatistics("close", "1"),
 	}
 	cases := []struct {
 		name    string
@@ -437,7 +639,7 @@ func TestShard_Write(t *testing.T) {
 			if tt.prepare != nil {
 				tt.prepare()
 			}
-			err := s.LookupRowMetricMeta(mockBatchRows(&protoMetricsV1.Metric{
+			rows := mockBatchRows(&protoMetricsV1.Metric{
 				Name:      "test",
 				Timestamp: timeutil.Now(),
 				SimpleFields: []*protoMetricsV1.SimpleField{{
@@ -445,10 +647,13 @@ func TestShard_Write(t *testing.T) {
 					Value: 1.0,
 					Type:  protoMetricsV1.SimpleFieldType_DELTA_SUM,
 				}},
-			}))
+			})
+			err := s.LookupRowMetricMeta(rows)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("WriteRows() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			assert.False(t, rows[0].Writable)
+			assert.Error(t, rows[0].LookupErr)
 		})
 	}
 }
@@ -584,6 +789,59 @@ func TestShard_TTL(t *testing.T) {
 	s.TTL()
 }
 
+func TestShard_GCSeries(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("test").AnyTimes()
+	indexDB := indexdb.NewMockIndexDatabase(ctrl)
+	segment1 := NewMockIntervalSegment(ctrl)
+	segment2 := NewMockIntervalSegment(ctrl)
+	s := &shard{
+		rollupTargets: map[timeutil.Interval]IntervalSegment{
+			10: segment1,
+			20: segment2,
+		},
+		indexDB: indexDB,
+		db:      db,
+		logger:  logger.GetLogger("TSDB", "Test"),
+	}
+	family1 := NewMockDataFamily(ctrl)
+	family2 := NewMockDataFamily(ctrl)
+	walk := func(family DataFamily) func(fn func(family DataFamily) error) error {
+		return func(fn func(family DataFamily) error) error {
+			return fn(family)
+		}
+	}
+
+	// gc failure
+	indexDB.EXPECT().GCSeries(time.Hour, 10, gomock.Any()).Return(0, fmt.Errorf("err"))
+	s.GCSeries(time.Hour, 10)
+
+	// series present in families of all intervals
+	indexDB.EXPECT().GCSeries(time.Hour, 10, gomock.Any()).
+		DoAndReturn(func(_ time.Duration, _ int, presentFn indexdb.PresentSeriesFunc) (int, error) {
+			segment1.EXPECT().walkFamilies(gomock.Any()).DoAndReturn(walk(family1))
+			segment2.EXPECT().walkFamilies(gomock.Any()).DoAndReturn(walk(family2))
+			family1.EXPECT().GetSeriesIDs(metric.ID(1)).Return(roaring.BitmapOf(1, 10), nil)
+			family2.EXPECT().GetSeriesIDs(metric.ID(1)).Return(roaring.BitmapOf(2), nil)
+			present, err := presentFn(1, roaring.BitmapOf(1, 2, 3))
+			assert.NoError(t, err)
+			assert.Equal(t, roaring.BitmapOf(1, 2), present)
+			return 1, nil
+		})
+	s.GCSeries(time.Hour, 10)
+
+	// check present series failure
+	segment1.EXPECT().walkFamilies(gomock.Any()).DoAndReturn(walk(family1)).AnyTimes()
+	segment2.EXPECT().walkFamilies(gomock.Any()).DoAndReturn(walk(family2)).AnyTimes()
+	family1.EXPECT().GetSeriesIDs(metric.ID(1)).Return(nil, fmt.Errorf("err")).AnyTimes()
+	family2.EXPECT().GetSeriesIDs(metric.ID(1)).Return(nil, fmt.Errorf("err")).AnyTimes()
+	present, err := s.presentSeriesIDs(1, roaring.BitmapOf(1))
+	assert.Error(t, err)
+	assert.Nil(t, present)
+}
+
 func TestShard_EvictSegment(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -611,3 +869,151 @@ func mockBatchRows(m *protoMetricsV1.Metric) []metric.StorageRow {
 	br.UnmarshalRows(buf.Bytes())
 	return br.Rows()
 }
+
+func TestShard_SetCompactionPolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	segment := NewMockIntervalSegment(ctrl)
+	s := &shard{
+		rollupTargets: map[timeutil.Interval]IntervalSegment{
+			10: segment,
+		},
+	}
+	segment.EXPECT().SetCompactionPolicy(option.CompactionPolicySizeTiered)
+	s.SetCompactionPolicy(option.CompactionPolicySizeTiered)
+}
+
+func TestShard_AddRollupIntervals(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer func() {
+		newIntervalSegmentFunc = newIntervalSegment
+		ctrl.Finish()
+	}()
+	db := NewMockDatabase(ctrl)
+	db.EXPECT().Name().Return("test").AnyTimes()
+	writable := timeutil.Interval(10 * timeutil.OneSecond)
+	segment := NewMockIntervalSegment(ctrl)
+	rollupSegment := NewMockIntervalSegment(ctrl)
+	s := &shard{
+		db:       db,
+		interval: writable,
+		segment:  segment,
+		rollupTargets: map[timeutil.Interval]IntervalSegment{
+			writable: segment,
+		},
+		logger: logger.GetLogger("TSDB", "Test"),
+	}
+	// no interval added
+	assert.NoError(t, s.AddRollupIntervals(nil))
+	// create interval segment failure
+	newIntervalSegmentFunc = func(_ Shard, _ option.Interval) (IntervalSegment, error) {
+		return nil, fmt.Errorf("err")
+	}
+	assert.Error(t, s.AddRollupIntervals(option.Intervals{{Interval: timeutil.Interval(timeutil.OneHour)}}))
+	assert.Len(t, s.getRollupTargets(), 1)
+	// add interval successfully
+	newIntervalSegmentFunc = func(_ Shard, _ option.Interval) (IntervalSegment, error) {
+		return rollupSegment, nil
+	}
+	segment.EXPECT().SetRollup(writable, []timeutil.Interval{timeutil.Interval(timeutil.OneHour)}).Times(2)
+	assert.NoError(t, s.AddRollupIntervals(option.Intervals{{Interval: timeutil.Interval(timeutil.OneHour)}}))
+	assert.Len(t, s.getRollupTargets(), 2)
+	// add existing interval
+	assert.NoError(t, s.AddRollupIntervals(option.Intervals{{Interval: timeutil.Interval(timeutil.OneHour)}}))
+	assert.Equal(t, rollupSegment, s.getRollupTargets()[timeutil.Interval(timeutil.OneHour)])
+}
+
+func TestShard_BackfillRollup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	writable := timeutil.Interval(10 * timeutil.OneSecond)
+	hour := timeutil.Interval(timeutil.OneHour)
+	segment := NewMockIntervalSegment(ctrl)
+	rollupSegment := NewMockIntervalSegment(ctrl)
+	family1 := NewMockDataFamily(ctrl)
+	family2 := NewMockDataFamily(ctrl)
+	kvFamily := kv.NewMockFamily(ctrl)
+	now := timeutil.Now()
+	family1.EXPECT().TimeRange().Return(timeutil.TimeRange{Start: now - timeutil.OneHour}).AnyTimes()
+	family2.EXPECT().TimeRange().Return(timeutil.TimeRange{Start: now}).AnyTimes()
+	family1.EXPECT().Family().Return(kvFamily).AnyTimes()
+	family2.EXPECT().Family().Return(kvFamily).AnyTimes()
+	segment.EXPECT().walkFamilies(gomock.Any()).DoAndReturn(func(fn func(family DataFamily) error) error {
+		if err := fn(family1); err != nil {
+			return err
+		}
+		return fn(family2)
+	}).AnyTimes()
+	s := &shard{
+		indicator: "test/1",
+		interval:  writable,
+		segment:   segment,
+		rollupTargets: map[timeutil.Interval]IntervalSegment{
+			writable: segment,
+			hour:     rollupSegment,
+		},
+	}
+	type progress struct {
+		completed, total int
+		completedFrom    int64
+	}
+	var progresses []progress
+	fn := func(completed, total int, completedFrom int64) {
+		progresses = append(progresses, progress{completed: completed, total: total, completedFrom: completedFrom})
+	}
+	// read only
+	s.engineCtx = &engineContext{readOnly: true}
+	assert.Equal(t, constants.ErrReadOnly, s.BackfillRollup(option.Intervals{{Interval: hour}}, 0, fn))
+	s.engineCtx = nil
+	// interval not found
+	assert.Error(t, s.BackfillRollup(option.Intervals{{Interval: timeutil.Interval(timeutil.OneDay)}}, 0, fn))
+	// create target segment failure
+	rollupSegment.EXPECT().GetOrCreateSegment(gomock.Any()).Return(nil, fmt.Errorf("err"))
+	assert.Error(t, s.BackfillRollup(option.Intervals{{Interval: hour}}, 0, fn))
+	// backfill family failure
+	rollupSegment.EXPECT().GetOrCreateSegment(gomock.Any()).Return(nil, nil)
+	kvFamily.EXPECT().BackfillRollup(hour).Return(fmt.Errorf("err"))
+	assert.Error(t, s.BackfillRollup(option.Intervals{{Interval: hour}}, 0, fn))
+	assert.Empty(t, progresses)
+	// backfill successfully, newest family first
+	rollupSegment.EXPECT().GetOrCreateSegment(gomock.Any()).Return(nil, nil).Times(2)
+	kvFamily.EXPECT().BackfillRollup(hour).Return(nil).Times(2)
+	assert.NoError(t, s.BackfillRollup(option.Intervals{{Interval: hour}}, 0, fn))
+	assert.Equal(t, []progress{{1, 2, now}, {2, 2, now - timeutil.OneHour}}, progresses)
+	// resume from previous backfill
+	progresses = nil
+	rollupSegment.EXPECT().GetOrCreateSegment(gomock.Any()).Return(nil, nil)
+	kvFamily.EXPECT().BackfillRollup(hour).Return(nil)
+	assert.NoError(t, s.BackfillRollup(option.Intervals{{Interval: hour}}, now, fn))
+	assert.Equal(t, []progress{{2, 2, now - timeutil.OneHour}}, progresses)
+}
+
+func TestShard_ingestionLag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := &shard{indicator: "db/1"}
+	// no data written
+	assert.Zero(t, s.ingestionLag())
+
+	family1 := NewMockDataFamily(ctrl)
+	family1.EXPECT().Indicator().Return("db/1/1").AnyTimes()
+	family1.EXPECT().Shard().Return(s).AnyTimes()
+	family1.EXPECT().IngestionLag().Return(time.Hour).AnyTimes()
+	family2 := NewMockDataFamily(ctrl)
+	family2.EXPECT().Indicator().Return("db/1/2").AnyTimes()
+	family2.EXPECT().Shard().Return(s).AnyTimes()
+	family2.EXPECT().IngestionLag().Return(time.Minute).AnyTimes()
+	GetFamilyManager().AddFamily(family1)
+	GetFamilyManager().AddFamily(family2)
+	lag := s.ingestionLag()
+	assert.GreaterOrEqual(t, lag, time.Minute)
+	assert.Less(t, lag, time.Hour)
+	// latest data time is kept after families evicted
+	GetFamilyManager().RemoveFamily(family1)
+	GetFamilyManager().RemoveFamily(family2)
+	lag = s.ingestionLag()
+	assert.GreaterOrEqual(t, lag, time.Minute)
+	assert.Less(t, lag, time.Hour)
+}
@@ -0,0 +1,229 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"strconv"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/pkg/option"
+)
+
+// CodecType represents the type of codec compressing metric block.
+type CodecType uint8
+
+const (
+	// CodecNone writes metric block without compression.
+	CodecNone CodecType = iota
+	// CodecSnappy compresses metric block with snappy.
+	CodecSnappy
+	// CodecZstd compresses metric block with zstd.
+	CodecZstd
+)
+
+// codecTrailerSize represents the size of codec type before version trailer of compressed metric block.
+const codecTrailerSize = 1
+
+// ErrUnsupportedCodec represents metric block compressed by unknown codec.
+var ErrUnsupportedCodec = errors.New("unsupported metric block codec")
+
+var (
+	zstdEncoders   = make(map[int]*zstd.Encoder) // level => encoder
+	zstdEncoderMux sync.Mutex
+	zstdDecoder    *zstd.Decoder
+	zstdDecoderErr error
+	zstdOnce       sync.Once
+)
+
+// Codec represents the codec compressing the whole metric block,
+// which is applied after the delta/xor encoding of field data.
+type Codec struct {
+	Type  CodecType
+	Level int // compression level, only for zstd
+}
+
+// NewCodec creates the codec of metric block based on the compression option of database.
+func NewCodec(compression option.Compression, level int) Codec {
+	switch compression {
+	case option.CompressionSnappy:
+		return Codec{Type: CodecSnappy}
+	case option.CompressionZstd:
+		return Codec{Type: CodecZstd, Level: level}
+	default:
+		return Codec{Type: CodecNone}
+	}
+}
+
+// String returns the string value of codec.
+func (c Codec) String() string {
+	switch c.Type {
+	case CodecNone:
+		return string(option.CompressionNone)
+	case CodecSnappy:
+		return string(option.CompressionSnappy)
+	case CodecZstd:
+		return string(option.CompressionZstd) + ":" + strconv.Itoa(c.Level)
+	default:
+		return "unknown:" + strconv.Itoa(int(c.Type))
+	}
+}
+
+// compress compresses src into dst(reused if capacity is enough), returns the compressed data.
+func (c Codec) compress(dst, src []byte) ([]byte, error) {
+	switch c.Type {
+	case CodecNone:
+		return append(dst[:0], src...), nil
+	case CodecSnappy:
+		return snappy.Encode(dst[:cap(dst)], src), nil
+	case CodecZstd:
+		encoder, err := getZstdEncoder(c.Level)
+		if err != nil {
+			return nil, err
+		}
+		return encoder.EncodeAll(src, dst[:0]), nil
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedCodec, c.Type)
+	}
+}
+
+// decompressBlock decompresses the metric block of format v3(compressed data + codec type),
+// returns the raw metric block with version trailer.
+func decompressBlock(block []byte) ([]byte, error) {
+	if len(block) < codecTrailerSize {
+		return nil, fmt.Errorf("%w: block too short", ErrUnsupportedCodec)
+	}
+	codecType := CodecType(block[len(block)-codecTrailerSize])
+	data := block[:len(block)-codecTrailerSize]
+	switch codecType {
+	case CodecSnappy:
+		return snappy.Decode(nil, data)
+	case CodecZstd:
+		decoder, err := getZstdDecoder()
+		if err != nil {
+			return nil, err
+		}
+		return decoder.DecodeAll(data, nil)
+	default:
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedCodec, codecType)
+	}
+}
+
+// getZstdEncoder returns the shared zstd encoder by level, encoder is safe for concurrent EncodeAll.
+func getZstdEncoder(level int) (*zstd.Encoder, error) {
+	zstdEncoderMux.Lock()
+	defer zstdEncoderMux.Unlock()
+
+	if encoder, ok := zstdEncoders[level]; ok {
+		return encoder, nil
+	}
+	encoder, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+	if err != nil {
+		return nil, err
+	}
+	zstdEncoders[level] = encoder
+	return encoder, nil
+}
+
+// getZstdDecoder returns the shared zstd decoder, decoder is safe for concurrent DecodeAll.
+func getZstdDecoder() (*zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdDecoder, zstdDecoderErr = zstd.NewReader(nil)
+	})
+	return zstdDecoder, zstdDecoderErr
+}
+
+// codecWriter implements table.StreamWriter, buffers the metric block then compresses it by codec when committing,
+// the compressed block is written as format v3(compressed block + codec type + version trailer).
+// If compression doesn't save space, the raw block(format v2) is written instead.
+type codecWriter struct {
+	table.StreamWriter // underlying writer of kv table
+
+	codec      Codec
+	block      []byte
+	compressed []byte
+	crc32      hash.Hash32
+	trailer    [codecTrailerSize + versionTrailerSize]byte
+}
+
+// newCodecWriter creates the writer which compresses metric block by codec.
+func newCodecWriter(writer table.StreamWriter, codec Codec) *codecWriter {
+	return &codecWriter{
+		StreamWriter: writer,
+		codec:        codec,
+		crc32:        crc32.New(crc32.IEEETable),
+	}
+}
+
+// Prepare the writer with specified key, resets the buffered block.
+func (w *codecWriter) Prepare(key uint32) {
+	w.StreamWriter.Prepare(key)
+	w.block = w.block[:0]
+	w.crc32.Reset()
+}
+
+// Write buffers the data of metric block.
+func (w *codecWriter) Write(data []byte) (int, error) {
+	w.block = append(w.block, data...)
+	_, _ = w.crc32.Write(data)
+	return len(data), nil
+}
+
+// Size returns the size of raw metric block.
+func (w *codecWriter) Size() uint32 {
+	return uint32(len(w.block))
+}
+
+// CRC32CheckSum returns a IEEE checksum of raw metric block.
+func (w *codecWriter) CRC32CheckSum() uint32 {
+	return w.crc32.Sum32()
+}
+
+// Commit compresses the buffered metric block, then writes it into underlying writer.
+func (w *codecWriter) Commit() error {
+	compressed, err := w.codec.compress(w.compressed, w.block)
+	if err != nil {
+		return err
+	}
+	w.compressed = compressed
+	if len(compressed)+len(w.trailer) >= len(w.block) {
+		// compression doesn't save space, write raw block
+		if _, err := w.StreamWriter.Write(w.block); err != nil {
+			return err
+		}
+		return w.StreamWriter.Commit()
+	}
+	if _, err := w.StreamWriter.Write(compressed); err != nil {
+		return err
+	}
+	w.trailer[0] = byte(w.codec.Type)
+	w.trailer[1] = byte(FormatVersionV3)
+	binary.LittleEndian.PutUint32(w.trailer[2:], formatMagic)
+	if _, err := w.StreamWriter.Write(w.trailer[:]); err != nil {
+		return err
+	}
+	return w.StreamWriter.Commit()
+}
@@ -0,0 +1,240 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/kv/table"
+	"github.com/lindb/lindb/pkg/option"
+)
+
+type mockBlockCompression struct {
+	codec Codec
+}
+
+func (m *mockBlockCompression) MetricBlockCodec() Codec {
+	return m.codec
+}
+
+func TestNewCodec(t *testing.T) {
+	assert.Equal(t, Codec{Type: CodecNone}, NewCodec(option.CompressionNone, 0))
+	assert.Equal(t, Codec{Type: CodecNone}, NewCodec("", 0))
+	assert.Equal(t, Codec{Type: CodecSnappy}, NewCodec(option.CompressionSnappy, 10))
+	assert.Equal(t, Codec{Type: CodecZstd, Level: 5}, NewCodec(option.CompressionZstd, 5))
+
+	assert.Equal(t, "none", Codec{Type: CodecNone}.String())
+	assert.Equal(t, "snappy", Codec{Type: CodecSnappy}.String())
+	assert.Equal(t, "zstd:3", Codec{Type: CodecZstd, Level: 3}.String())
+	assert.Equal(t, "unknown:100", Codec{Type: 100}.String())
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	rawBlock := mockMetricBlock()
+	raw, err := NewReader("1.sst", rawBlock)
+	assert.NoError(t, err)
+
+	for _, codec := range []Codec{
+		{Type: CodecSnappy},
+		{Type: CodecZstd, Level: 1},
+		{Type: CodecZstd, Level: option.DefaultZstdLevel},
+		{Type: CodecZstd, Level: option.MaxZstdLevel},
+	} {
+		codec := codec
+		t.Run(codec.String(), func(t *testing.T) {
+			block := mockMetricBlockWithCodec(codec)
+			assert.Less(t, len(block), len(rawBlock))
+			version, body := ParseFormatVersion(block)
+			assert.Equal(t, FormatVersionV3, version)
+			decompressed, err := decompressBlock(body)
+			assert.NoError(t, err)
+			assert.Equal(t, rawBlock, decompressed)
+
+			r, err := NewReader("1.sst", block)
+			assert.NoError(t, err)
+			assert.Equal(t, raw.GetFields(), r.GetFields())
+			assert.Equal(t, raw.GetTimeRange(), r.GetTimeRange())
+			assert.Equal(t, raw.GetSeriesIDs().ToArray(), r.GetSeriesIDs().ToArray())
+		})
+	}
+}
+
+func TestCodec_Compress(t *testing.T) {
+	_, err := Codec{Type: 100}.compress(nil, []byte{1, 2, 3})
+	assert.True(t, errors.Is(err, ErrUnsupportedCodec))
+	data, err := Codec{Type: CodecNone}.compress(nil, []byte{1, 2, 3})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2, 3}, data)
+}
+
+func TestCodec_DecompressBlock(t *testing.T) {
+	_, err := decompressBlock(nil)
+	assert.True(t, errors.Is(err, ErrUnsupportedCodec))
+	_, err = decompressBlock([]byte{1, 2, byte(CodecNone)})
+	assert.True(t, errors.Is(err, ErrUnsupportedCodec))
+	// corrupted data
+	_, err = decompressBlock([]byte{1, 2, byte(CodecSnappy)})
+	assert.Error(t, err)
+	_, err = decompressBlock([]byte{1, 2, byte(CodecZstd)})
+	assert.Error(t, err)
+
+	// corrupted compressed block
+	block := mockMetricBlockWithCodec(Codec{Type: CodecSnappy})
+	block[0]++
+	r, err := NewReader("1.sst", block)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	// compressed block isn't v2 block
+	kvFlusher := kv.NewNopFlusher()
+	sw, err := kvFlusher.StreamWriter()
+	assert.NoError(t, err)
+	w := newCodecWriter(sw, Codec{Type: CodecSnappy})
+	w.Prepare(1)
+	_, _ = w.Write(make([]byte, 1024))
+	assert.NoError(t, w.Commit())
+	r, err = NewReader("1.sst", kvFlusher.Bytes())
+	assert.True(t, errors.Is(err, ErrUnsupportedFormatVersion))
+	assert.Nil(t, r)
+}
+
+func TestCodecWriter_Commit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	sw := table.NewMockStreamWriter(ctrl)
+	w := newCodecWriter(sw, Codec{Type: CodecSnappy})
+	sw.EXPECT().Prepare(uint32(1)).AnyTimes()
+	w.Prepare(1)
+	// compression doesn't save space, write raw block
+	_, _ = w.Write([]byte{1, 2, 3})
+	assert.Equal(t, uint32(3), w.Size())
+	assert.NotZero(t, w.CRC32CheckSum())
+	sw.EXPECT().Write([]byte{1, 2, 3}).Return(0, fmt.Errorf("err"))
+	assert.Error(t, w.Commit())
+	sw.EXPECT().Write([]byte{1, 2, 3}).Return(3, nil)
+	sw.EXPECT().Commit().Return(nil)
+	assert.NoError(t, w.Commit())
+	// write compressed block failure
+	w.Prepare(1)
+	_, _ = w.Write(make([]byte, 1024))
+	sw.EXPECT().Write(gomock.Any()).Return(0, fmt.Errorf("err"))
+	assert.Error(t, w.Commit())
+	// write trailer failure
+	sw.EXPECT().Write(gomock.Any()).Return(0, nil)
+	sw.EXPECT().Write(gomock.Any()).Return(0, fmt.Errorf("err"))
+	assert.Error(t, w.Commit())
+	// unknown codec
+	w.codec = Codec{Type: 100}
+	assert.Error(t, w.Commit())
+}
+
+func TestFlusher_SetCodec(t *testing.T) {
+	nopKVFlusher := kv.NewNopFlusher()
+	dataFlusher, err := NewFlusher(nopKVFlusher)
+	assert.NoError(t, err)
+	f := dataFlusher.(*flusher)
+	raw := f.kvWriter
+	dataFlusher.SetCodec(Codec{Type: CodecSnappy})
+	assert.Equal(t, f.codecW, f.kvWriter)
+	dataFlusher.SetCodec(Codec{Type: CodecZstd, Level: 1})
+	assert.Equal(t, Codec{Type: CodecZstd, Level: 1}, f.codecW.codec)
+	dataFlusher.SetCodec(Codec{Type: CodecNone})
+	assert.Equal(t, raw, f.kvWriter)
+}
+
+func TestMerger_Transcode(t *testing.T) {
+	// metric block written before codec introduced
+	v1Block, err := os.ReadFile("testdata/metric_block_v1.bin")
+	assert.NoError(t, err)
+	blocks := [][]byte{
+		v1Block,
+		mockMetricBlock(),
+		mockMetricBlockWithCodec(Codec{Type: CodecSnappy}),
+	}
+	for _, block := range blocks {
+		assert.NoError(t, CheckFormatVersion(block))
+	}
+
+	compact := func(codec Codec) []byte {
+		kvFlusher := kv.NewNopFlusher()
+		m, err := NewMerger(kvFlusher)
+		assert.NoError(t, err)
+		m.Init(map[string]interface{}{kv.CompressionContext: &mockBlockCompression{codec: codec}})
+		assert.NoError(t, m.Merge(10, blocks))
+		return kvFlusher.Bytes()
+	}
+	rawBlock := compact(Codec{Type: CodecNone})
+	version, _ := ParseFormatVersion(rawBlock)
+	assert.Equal(t, FormatVersionV2, version)
+	zstdBlock := compact(Codec{Type: CodecZstd, Level: option.DefaultZstdLevel})
+	version, body := ParseFormatVersion(zstdBlock)
+	assert.Equal(t, FormatVersionV3, version)
+	decompressed, err := decompressBlock(body)
+	assert.NoError(t, err)
+	assert.Equal(t, rawBlock, decompressed)
+
+	r, err := NewReader("1.sst", zstdBlock)
+	assert.NoError(t, err)
+	v1Reader, err := NewReader("1.sst", v1Block)
+	assert.NoError(t, err)
+	assert.Equal(t, v1Reader.GetFields(), r.GetFields())
+	assert.Equal(t, v1Reader.GetSeriesIDs().ToArray(), r.GetSeriesIDs().ToArray())
+}
+
+var benchmarkCodecs = []Codec{
+	{Type: CodecNone},
+	{Type: CodecSnappy},
+	{Type: CodecZstd, Level: 1},
+	{Type: CodecZstd, Level: option.DefaultZstdLevel},
+}
+
+func BenchmarkCodec_Compress(b *testing.B) {
+	rawBlock := mockMetricBlock()
+	for _, codec := range benchmarkCodecs {
+		codec := codec
+		b.Run(codec.String(), func(b *testing.B) {
+			var dst []byte
+			b.SetBytes(int64(len(rawBlock)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				dst, _ = codec.compress(dst, rawBlock)
+			}
+			b.ReportMetric(float64(len(rawBlock))/float64(len(dst)), "ratio")
+		})
+	}
+}
+
+func BenchmarkCodec_NewReader(b *testing.B) {
+	for _, codec := range benchmarkCodecs {
+		codec := codec
+		block := mockMetricBlockWithCodec(codec)
+		b.Run(codec.String(), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_, _ = NewReader("1.sst", block)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+
+	"github.com/lindb/roaring"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/series/field"
+)
+
+// Digest represents the digest of metric data, be used to detect the divergence between replicas.
+type Digest struct {
+	SeriesIDs *roaring.Bitmap // series ids of metric
+	Points    uint64
+	Sum       float64
+	Hash      uint64 // sum of the hash of each encoded field block, so it is independent of scanning order
+}
+
+// NewDigest creates an empty metric data digest.
+func NewDigest() *Digest {
+	return &Digest{SeriesIDs: roaring.New()}
+}
+
+// Add scans the series data of metric block one by one, then accumulates the data into digest,
+// returns the bytes of series data scanned.
+// Field is hashed by name instead of id, because field id is generated by each node,
+// fields without name are ignored.
+func (d *Digest) Add(r MetricReader, fieldNames map[field.ID]field.Name) (scanned int, err error) {
+	scanner, err := newDataScanner(r)
+	if err != nil {
+		return 0, err
+	}
+	d.SeriesIDs.Or(r.GetSeriesIDs())
+
+	fields := r.GetFields()
+	slotRange := r.GetTimeRange()
+	fieldReader := newFieldReader(scanner.fieldIndexes(), nil, slotRange)
+	decoder := encoding.GetTSDDecoder()
+	defer encoding.ReleaseTSDDecoder(decoder)
+
+	var seriesKey [4]byte
+	highKeys := r.GetSeriesIDs().GetHighKeys()
+	for idx, highKey := range highKeys {
+		it := r.GetSeriesIDs().GetContainerAtIndex(idx).PeekableIterator()
+		for it.HasNext() {
+			lowSeriesID := it.Next()
+			seriesEntry := scanner.scan(highKey, lowSeriesID)
+			if len(seriesEntry) == 0 {
+				continue
+			}
+			scanned += len(seriesEntry)
+			binary.LittleEndian.PutUint32(seriesKey[:], encoding.ValueWithHighLowBits(uint32(highKey)<<16, lowSeriesID))
+			fieldReader.Reset(seriesEntry, slotRange)
+			for _, f := range fields {
+				fieldName, ok := fieldNames[f.ID]
+				if !ok {
+					continue
+				}
+				block := fieldReader.GetFieldData(f.ID)
+				if len(block) == 0 {
+					continue
+				}
+				h := fnv.New64a()
+				_, _ = h.Write(seriesKey[:])
+				_, _ = h.Write([]byte(fieldName))
+				_, _ = h.Write(block)
+				d.Hash += h.Sum64()
+
+				decoder.ResetWithTimeRange(block, slotRange.Start, slotRange.End)
+				for slot := int(slotRange.Start); slot <= int(slotRange.End); slot++ {
+					if value, ok := decoder.GetValue(uint16(slot)); ok {
+						d.Points++
+						d.Sum += value
+					}
+				}
+			}
+		}
+	}
+	return scanned, nil
+}
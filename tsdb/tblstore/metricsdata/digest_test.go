@@ -0,0 +1,76 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/series/field"
+)
+
+func TestDigest_Add(t *testing.T) {
+	r, err := NewReader("1.sst", mockMetricBlockForOneField())
+	assert.NoError(t, err)
+	d := NewDigest()
+	scanned, err := d.Add(r, map[field.ID]field.Name{2: "f"})
+	assert.NoError(t, err)
+	assert.True(t, scanned > 0)
+	assert.Equal(t, uint64(11), d.SeriesIDs.GetCardinality())
+	assert.Equal(t, uint64(11), d.Points)
+	assert.Equal(t, 10.0, d.Sum)
+
+	// same data in other file, hash is independent of scanning order
+	d2 := NewDigest()
+	_, err = d2.Add(r, map[field.ID]field.Name{2: "f"})
+	assert.NoError(t, err)
+	assert.Equal(t, d.Hash, d2.Hash)
+	_, err = d.Add(r, map[field.ID]field.Name{2: "f"})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(11), d.SeriesIDs.GetCardinality())
+	assert.Equal(t, uint64(22), d.Points)
+	assert.Equal(t, 2*d2.Hash, d.Hash)
+
+	// field hashed by name
+	d3 := NewDigest()
+	_, err = d3.Add(r, map[field.ID]field.Name{2: "f2"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, d2.Hash, d3.Hash)
+	// field without name ignored
+	d4 := NewDigest()
+	_, err = d4.Add(r, nil)
+	assert.NoError(t, err)
+	assert.Zero(t, d4.Points)
+	assert.Zero(t, d4.Hash)
+
+	r, err = NewReader("2.sst", mockMetricBlock())
+	assert.NoError(t, err)
+	d5 := NewDigest()
+	_, err = d5.Add(r, map[field.ID]field.Name{2: "f2", 30: "f30"})
+	assert.NoError(t, err)
+	assert.True(t, d5.Points >= 20)
+}
+
+func TestDigest_Add_bad_reader(t *testing.T) {
+	r, err := NewReader("1.sst", mockMetricBlockForOneField())
+	assert.NoError(t, err)
+	r.(*metricReader).seriesIDs.Clear()
+	_, err = NewDigest().Add(r, nil)
+	assert.Error(t, err)
+}
@@ -31,8 +31,8 @@ import (
 
 // Filter implements filtering metrics from sst files.
 type Filter interface {
-	// Filter filters data under each sst file based on query condition
-	Filter(seriesIDs *roaring.Bitmap, fields field.Metas) ([]flow.FilterResultSet, error)
+	// Filter filters data under each sst file based on query condition(series ids/fields/slot range)
+	Filter(seriesIDs *roaring.Bitmap, fields field.Metas, slotRange timeutil.SlotRange) ([]flow.FilterResultSet, error)
 }
 
 // metricsDataFilter represents the sst file data filter
@@ -51,10 +51,11 @@ func NewFilter(familyTime int64, snapshot version.Snapshot, readers []MetricRead
 	}
 }
 
-// Filter filters the data under each sst file based on metric/version/seriesIDs,
+// Filter filters the data under each sst file based on metric/version/seriesIDs/slot range,
+// the series which data is out of slot range are pruned if the slot range of series recorded,
 // if finds data then returns the flow.FilterResultSet, else returns nil
 func (f *metricsDataFilter) Filter(
-	seriesIDs *roaring.Bitmap, fields field.Metas,
+	seriesIDs *roaring.Bitmap, fields field.Metas, slotRange timeutil.SlotRange,
 ) (rs []flow.FilterResultSet, err error) {
 	for _, reader := range f.readers {
 		fieldMetas, _ := reader.GetFields().Intersects(fields)
@@ -68,7 +69,12 @@ func (f *metricsDataFilter) Filter(
 			// series ids not found
 			continue
 		}
-		rs = append(rs, newFileFilterResultSet(f.familyTime, matchSeriesIDs, reader, f.snapshot))
+		matchSeriesIDs = reader.FilterSeriesIDs(matchSeriesIDs, slotRange)
+		if matchSeriesIDs.IsEmpty() {
+			// data of series out of slot range
+			continue
+		}
+		rs = append(rs, newFileFilterResultSet(f.familyTime, matchSeriesIDs, slotRange, reader, f.snapshot))
 	}
 	// not founds
 	if len(rs) == 0 {
@@ -83,12 +89,14 @@ type fileFilterResultSet struct {
 	reader     MetricReader
 	familyTime int64
 	seriesIDs  *roaring.Bitmap
+	slotRange  timeutil.SlotRange // query slot range
 }
 
 // newFileFilterResultSet creates the file filter result set
 func newFileFilterResultSet(
 	familyTime int64,
 	seriesIDs *roaring.Bitmap,
+	slotRange timeutil.SlotRange,
 	reader MetricReader,
 	snapshot version.Snapshot,
 ) flow.FilterResultSet {
@@ -96,6 +104,7 @@ func newFileFilterResultSet(
 		familyTime: familyTime,
 		reader:     reader,
 		seriesIDs:  seriesIDs,
+		slotRange:  slotRange,
 		snapshot:   snapshot,
 	}
 }
@@ -122,7 +131,7 @@ func (f *fileFilterResultSet) SlotRange() timeutil.SlotRange {
 
 // Load reads data from sst files, then returns the data file scanner.
 func (f *fileFilterResultSet) Load(ctx *flow.DataLoadContext) flow.DataLoader {
-	return f.reader.Load(ctx)
+	return f.reader.Load(ctx, f.slotRange)
 }
 
 // Close release the resource during doing query operation.
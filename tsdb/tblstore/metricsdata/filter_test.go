@@ -39,8 +39,8 @@ func TestFileFilterResultSet_Load(t *testing.T) {
 
 	reader := NewMockMetricReader(ctrl)
 	snapshot := version.NewMockSnapshot(ctrl)
-	rs := newFileFilterResultSet(1, nil, reader, snapshot)
-	reader.EXPECT().Load(gomock.Any())
+	rs := newFileFilterResultSet(1, nil, timeutil.SlotRange{Start: 5, End: 10}, reader, snapshot)
+	reader.EXPECT().Load(gomock.Any(), timeutil.SlotRange{Start: 5, End: 10})
 	rs.Load(&flow.DataLoadContext{})
 	assert.Equal(t, int64(1), rs.FamilyTime())
 	reader.EXPECT().GetTimeRange().Return(timeutil.SlotRange{
@@ -61,22 +61,31 @@ func TestMetricsDataFilter_Filter(t *testing.T) {
 
 	reader := NewMockMetricReader(ctrl)
 	filter := NewFilter(10, nil, []MetricReader{reader})
+	slotRange := timeutil.SlotRange{Start: 5, End: 10}
 
 	// case 1: field not found
 	reader.EXPECT().GetFields().Return(field.Metas{{ID: 2}, {ID: 20}})
-	rs, err := filter.Filter(roaring.BitmapOf(1, 2, 3), field.Metas{{ID: 1}, {ID: 30}})
+	rs, err := filter.Filter(roaring.BitmapOf(1, 2, 3), field.Metas{{ID: 1}, {ID: 30}}, slotRange)
 	assert.True(t, errors.Is(err, constants.ErrNotFound))
 	assert.Nil(t, rs)
 	// case 2: series ids found
 	reader.EXPECT().GetFields().Return(field.Metas{{ID: 2}, {ID: 20}})
 	reader.EXPECT().GetSeriesIDs().Return(roaring.BitmapOf(10, 200))
-	rs, err = filter.Filter(roaring.BitmapOf(1, 2, 3), field.Metas{{ID: 2}, {ID: 30}})
+	rs, err = filter.Filter(roaring.BitmapOf(1, 2, 3), field.Metas{{ID: 2}, {ID: 30}}, slotRange)
 	assert.True(t, errors.Is(err, constants.ErrNotFound))
 	assert.Nil(t, rs)
-	// case 3: data found
+	// case 3: data of series out of slot range
 	reader.EXPECT().GetFields().Return(field.Metas{{ID: 2}, {ID: 20}})
 	reader.EXPECT().GetSeriesIDs().Return(roaring.BitmapOf(10, 200))
-	rs, err = filter.Filter(roaring.BitmapOf(1, 200, 3), field.Metas{{ID: 2}, {ID: 30}})
+	reader.EXPECT().FilterSeriesIDs(roaring.BitmapOf(200), slotRange).Return(roaring.New())
+	rs, err = filter.Filter(roaring.BitmapOf(1, 200, 3), field.Metas{{ID: 2}, {ID: 30}}, slotRange)
+	assert.True(t, errors.Is(err, constants.ErrNotFound))
+	assert.Nil(t, rs)
+	// case 4: data found
+	reader.EXPECT().GetFields().Return(field.Metas{{ID: 2}, {ID: 20}})
+	reader.EXPECT().GetSeriesIDs().Return(roaring.BitmapOf(10, 200))
+	reader.EXPECT().FilterSeriesIDs(roaring.BitmapOf(200), slotRange).Return(roaring.BitmapOf(200))
+	rs, err = filter.Filter(roaring.BitmapOf(1, 200, 3), field.Metas{{ID: 2}, {ID: 30}}, slotRange)
 	assert.NoError(t, err)
 	assert.Len(t, rs, 1)
 	assert.EqualValues(t, roaring.BitmapOf(200).ToArray(), rs[0].SeriesIDs().ToArray())
@@ -53,15 +53,25 @@ type Flusher interface {
 	// FlushField writes a compressed field data to writer.
 	// It will be called in order with field metas even if field data is empty
 	FlushField(data []byte) error
+	// SetSeriesSlotRange sets the slot range(first/last written slot) of current series,
+	// must be called before FlushSeries, slot range of series not set is same as metric level.
+	SetSeriesSlotRange(slotRange timeutil.SlotRange)
 	// FlushSeries writes a full series, this will be called after writing all fields of this entry.
 	FlushSeries(seriesID uint32) error
 	// CommitMetric ends writing a full metric block
 	// this will be called after writing all entries of this metric.
 	CommitMetric(slotRange timeutil.SlotRange) error
+	// MetricBlockSize returns the size of current metric block, 0 if no data written.
+	MetricBlockSize() uint32
 	// GetFieldMetas returns current field metas of metric.
 	GetFieldMetas() field.Metas
 	// GetEncoder returns tsd encoder by field index.
 	GetEncoder(fieldIdx int) *encoding.TSDEncoder
+	// SetCodec sets the codec compressing the following metric blocks, must be called before PrepareMetric.
+	SetCodec(codec Codec)
+	// SetFieldPrecisions sets the precisions(significant decimal digits) applied to field data of current metric,
+	// aligned with field metas, 0 means full precision, must be called after PrepareMetric.
+	SetFieldPrecisions(precisions []uint8)
 
 	// Closer closes the writer, syncs all data to the file.
 	io.Closer
@@ -71,7 +81,9 @@ type Flusher interface {
 type flusher struct {
 	// Level1 flusher
 	kvFlusher kv.Flusher
-	kvWriter  table.StreamWriter
+	kvWriter  table.StreamWriter // writer of metric block, raw writer or codec writer
+	rawWriter table.StreamWriter
+	codecW    *codecWriter // nil if codec not set
 
 	encoders []*encoding.TSDEncoder // each encoder ref field store
 
@@ -113,14 +125,59 @@ type flusher struct {
 	// │  4 Byte  │ 4 Bytes  │ 4 Bytes  │ 4 Bytes  │  4 Bytes │
 	// └──────────┴──────────┴──────────┴──────────┴──────────┘
 	//
+	// Level2 (Version Trailer, appended after footer since format v2)
+	// ┌─────────────────────┐
+	// │   Version Trailer   │
+	// ├──────────┬──────────┤
+	// │  Format  │  Magic   │
+	// │  Version │          │
+	// ├──────────┼──────────┤
+	// │  1 Byte  │ 4 Bytes  │
+	// └──────────┴──────────┘
+	//
+	// Level2 (Field Precisions, appended after footer since format v4, if precision of any field applied)
+	// ┌────────────────────────────────────────────────────────────┐
+	// │                     Field Precisions                       │
+	// ├──────────┬──────────┬──────────┬──────────┬────────────────┤
+	// │ FieldID  │ Precision│  ......  │  Count   │ Version Trailer│
+	// ├──────────┼──────────┼──────────┼──────────┼────────────────┤
+	// │  1 Byte  │  1 Byte  │          │  1 Byte  │    5 Bytes     │
+	// └──────────┴──────────┴──────────┴──────────┴────────────────┘
+	//
+	// Level2 (Series Slot Ranges, appended after footer since format v5, if slot range of any series set,
+	// followed by field precisions which count may be zero)
+	// ┌─────────────────────────────────────────────────────────────┐
+	// │                     Series Slot Ranges                      │
+	// ├──────────┬──────────┬──────────┬──────────┬─────────────────┤
+	// │  Start   │   End    │  ......  │  Count   │Field Precisions │
+	// ├──────────┼──────────┼──────────┼──────────┼─────────────────┤
+	// │  2 Bytes │  2 Bytes │          │  4 Bytes │    N Bytes      │
+	// └──────────┴──────────┴──────────┴──────────┴─────────────────┘
+	// slot ranges are aligned with series ids bitmap.
+	//
+	// Level1 (Compressed Metric Block, format v3, if codec set and compression saves space)
+	// ┌──────────────────────────────────────────────────┐
+	// │              Compressed Metric Block             │
+	// ├──────────────────┬──────────┬──────────┬─────────┤
+	// │ Compressed Block │  Codec   │  Format  │  Magic  │
+	// │ (v2 block)       │  Type    │  Version │         │
+	// ├──────────────────┼──────────┼──────────┼─────────┤
+	// │  N Bytes         │  1 Byte  │  1 Byte  │ 4 Bytes │
+	// └──────────────────┴──────────┴──────────┴─────────┘
+	//
 	// Level2 is a context of the second level in kv table, used for a writing a full metric
 	// each entry is a series bucket ordered by roaring high key
 	// Resets it after completed writing a metric
 	Level2 struct {
-		fieldMetas     field.Metas
-		seriesIDs      *roaring.Bitmap
-		highKeyOffsets *encoding.FixedOffsetEncoder
-		footer         [dataFooterSize]byte
+		fieldMetas field.Metas
+		precisions []uint8 // precisions of fields, aligned with field metas
+		seriesIDs  *roaring.Bitmap
+		// slot ranges of series, aligned with series ids
+		seriesSlotRanges []timeutil.SlotRange
+		// symbols if slot range of any series set
+		hasSeriesSlotRange bool
+		highKeyOffsets     *encoding.FixedOffsetEncoder
+		footer             [dataFooterSize + versionTrailerSize]byte
 	}
 	// +--------+--------+--------+--------+--------+--------v
 	// │ Series │ Series │  Field | Series │ HighKey│ Footer │
@@ -182,6 +239,9 @@ type flusher struct {
 		fieldDataOffsets *encoding.FixedOffsetEncoder
 		fieldBuffer      [][]byte
 		fieldAppendIdx   int
+		// slot range of current series
+		slotRange    timeutil.SlotRange
+		slotRangeSet bool
 	}
 }
 
@@ -195,6 +255,7 @@ func NewFlusher(kvFlusher kv.Flusher) (Flusher, error) {
 	flusher := &flusher{
 		kvFlusher: kvFlusher,
 		kvWriter:  sw,
+		rawWriter: sw,
 	}
 	// level2 context
 	flusher.Level2.seriesIDs = roaring.New()
@@ -287,6 +348,7 @@ func (w *flusher) FlushSeries(seriesID uint32) error {
 	defer func() {
 		w.Level4.startAt = int(w.kvWriter.Size())
 		w.Level4.fieldDataOffsets.Reset()
+		w.Level4.slotRangeSet = false
 	}()
 
 	seriesHasData := w.Level4.fieldAppendIdx > 0
@@ -326,9 +388,22 @@ func (w *flusher) FlushSeries(seriesID uint32) error {
 	}
 	// add series id into index block of metric
 	w.Level2.seriesIDs.Add(seriesID)
+	// keep slot range of series, unknown slot range is replaced by metric level when committing
+	slotRange := unknownSlotRange
+	if w.Level4.slotRangeSet {
+		slotRange = w.Level4.slotRange
+		w.Level2.hasSeriesSlotRange = true
+	}
+	w.Level2.seriesSlotRanges = append(w.Level2.seriesSlotRanges, slotRange)
 	return nil
 }
 
+// SetSeriesSlotRange sets the slot range(first/last written slot) of current series.
+func (w *flusher) SetSeriesSlotRange(slotRange timeutil.SlotRange) {
+	w.Level4.slotRange = slotRange
+	w.Level4.slotRangeSet = true
+}
+
 func (w *flusher) flushLevel2SeriesBucket() error {
 	posOfLowKeyOffsets := int(w.kvWriter.Size()) - w.Level3.startAt
 	if !(posOfLowKeyOffsets > 0) {
@@ -347,8 +422,11 @@ func (w *flusher) flushLevel2SeriesBucket() error {
 
 func (w *flusher) reset() {
 	w.Level2.fieldMetas = w.Level2.fieldMetas[:0]
+	w.Level2.precisions = nil
 	w.Level2.seriesIDs.Clear()
 	w.Level2.highKeyOffsets.Reset()
+	w.Level2.seriesSlotRanges = w.Level2.seriesSlotRanges[:0]
+	w.Level2.hasSeriesSlotRange = false
 
 	w.Level3.startAt = 0
 	w.Level3.isHighKeySetEver = false
@@ -356,6 +434,7 @@ func (w *flusher) reset() {
 
 	w.Level4.startAt = 0
 	w.Level4.fieldDataOffsets.Reset()
+	w.Level4.slotRangeSet = false
 }
 
 // CommitMetric writes a full metric-block,
@@ -414,13 +493,75 @@ func (w *flusher) CommitMetric(slotRange timeutil.SlotRange) error {
 	binary.LittleEndian.PutUint32(w.Level2.footer[12:16], highKeyOffsetsAt)
 	// write CRC32 checksum
 	binary.LittleEndian.PutUint32(w.Level2.footer[16:20], w.kvWriter.CRC32CheckSum())
-
-	if _, err := w.kvWriter.Write(w.Level2.footer[:]); err != nil {
+	if _, err := w.kvWriter.Write(w.Level2.footer[:dataFooterSize]); err != nil {
+		return err
+	}
+	// write series slot ranges/field precisions if applied, and version trailer,
+	// the block compressed by codec writer is converted to v3
+	version, err := w.writeSections(slotRange)
+	if err != nil {
+		return err
+	}
+	w.Level2.footer[dataFooterSize] = byte(version)
+	binary.LittleEndian.PutUint32(w.Level2.footer[dataFooterSize+1:], formatMagic)
+	if _, err := w.kvWriter.Write(w.Level2.footer[dataFooterSize:]); err != nil {
 		return err
 	}
 	return w.kvWriter.Commit()
 }
 
+// writeSections writes the slot ranges of series if any slot range set(v5),
+// then the precisions of fields if any precision applied(v4), returns the format version of block.
+func (w *flusher) writeSections(metricSlotRange timeutil.SlotRange) (FormatVersion, error) {
+	var section []byte
+	for idx, precision := range w.Level2.precisions {
+		if precision > 0 && idx < len(w.Level2.fieldMetas) {
+			section = append(section, byte(w.Level2.fieldMetas[idx].ID), precision)
+		}
+	}
+	if w.Level2.hasSeriesSlotRange {
+		if err := w.writeSeriesSlotRanges(metricSlotRange); err != nil {
+			return 0, err
+		}
+		// field precisions(count may be zero) always follows series slot ranges
+		section = append(section, byte(len(section)/2))
+		if _, err := w.kvWriter.Write(section); err != nil {
+			return 0, err
+		}
+		return FormatVersionV5, nil
+	}
+	if len(section) == 0 {
+		return FormatVersionV2, nil
+	}
+	section = append(section, byte(len(section)/2))
+	if _, err := w.kvWriter.Write(section); err != nil {
+		return 0, err
+	}
+	return FormatVersionV4, nil
+}
+
+// writeSeriesSlotRanges writes the slot ranges of series, unknown slot range is replaced by metric level.
+func (w *flusher) writeSeriesSlotRanges(metricSlotRange timeutil.SlotRange) error {
+	slotRanges := w.Level2.seriesSlotRanges
+	section := make([]byte, len(slotRanges)*seriesSlotRangeSize+4)
+	for idx, slotRange := range slotRanges {
+		if slotRange == unknownSlotRange {
+			slotRange = metricSlotRange
+		}
+		pos := idx * seriesSlotRangeSize
+		binary.LittleEndian.PutUint16(section[pos:], slotRange.Start)
+		binary.LittleEndian.PutUint16(section[pos+2:], slotRange.End)
+	}
+	binary.LittleEndian.PutUint32(section[len(section)-4:], uint32(len(slotRanges)))
+	_, err := w.kvWriter.Write(section)
+	return err
+}
+
+// MetricBlockSize returns the size of current metric block, 0 if no data written.
+func (w *flusher) MetricBlockSize() uint32 {
+	return w.kvWriter.Size()
+}
+
 // Close adds the footer and then closes the kv builder,
 // this will be called after writing all metric-blocks.
 func (w *flusher) Close() error {
@@ -438,3 +579,21 @@ func (w *flusher) GetFieldMetas() field.Metas {
 func (w *flusher) GetEncoder(fieldIdx int) *encoding.TSDEncoder {
 	return w.encoders[fieldIdx]
 }
+
+// SetFieldPrecisions sets the precisions(significant decimal digits) applied to field data of current metric.
+func (w *flusher) SetFieldPrecisions(precisions []uint8) {
+	w.Level2.precisions = precisions
+}
+
+// SetCodec sets the codec compressing the following metric blocks, must be called before PrepareMetric.
+func (w *flusher) SetCodec(codec Codec) {
+	if codec.Type == CodecNone {
+		w.kvWriter = w.rawWriter
+		return
+	}
+	if w.codecW == nil {
+		w.codecW = newCodecWriter(w.rawWriter, codec)
+	}
+	w.codecW.codec = codec
+	w.kvWriter = w.codecW
+}
@@ -83,6 +83,7 @@ func TestFlusher_flush_metric(t *testing.T) {
 	assert.Equal(t, field.Metas{{ID: 1, Type: field.SumField}, {ID: 2, Type: field.SumField}}, f)
 	assert.NoError(t, flusher.CommitMetric(timeutil.SlotRange{Start: 10, End: 13}))
 	assert.NoError(t, err)
+	assert.True(t, flusher.MetricBlockSize() > 0)
 
 	// field not exist, not flush metric
 	assert.Empty(t, flusher.GetFieldMetas())
@@ -94,11 +95,41 @@ func TestFlusher_flush_metric(t *testing.T) {
 	flusher.PrepareMetric(50, []field.Meta{{ID: 1, Type: field.SumField}})
 	assert.NoError(t, flusher.FlushField(nil))
 	assert.NoError(t, flusher.CommitMetric(timeutil.SlotRange{Start: 10, End: 13}))
+	assert.Zero(t, flusher.MetricBlockSize())
 
 	// close
 	assert.NoError(t, flusher.Close())
 }
 
+func TestFlusher_SeriesSlotRange(t *testing.T) {
+	nopKVFlusher := kv.NewNopFlusher()
+	flusher, err := NewFlusher(nopKVFlusher)
+	assert.NoError(t, err)
+	flusher.PrepareMetric(39, []field.Meta{{ID: 1, Type: field.SumField}})
+	// series without data, slot range ignored
+	flusher.SetSeriesSlotRange(timeutil.SlotRange{Start: 1, End: 1})
+	assert.NoError(t, flusher.FlushSeries(5))
+	flusher.SetSeriesSlotRange(timeutil.SlotRange{Start: 11, End: 12})
+	assert.NoError(t, flusher.FlushField([]byte{1, 2, 3}))
+	assert.NoError(t, flusher.FlushSeries(10))
+	assert.NoError(t, flusher.FlushField([]byte{1, 2, 3}))
+	assert.NoError(t, flusher.FlushSeries(100))
+	assert.NoError(t, flusher.CommitMetric(timeutil.SlotRange{Start: 10, End: 13}))
+	version, block := ParseFormatVersion(nopKVFlusher.Bytes())
+	assert.Equal(t, FormatVersionV5, version)
+	// precisions count, series slot ranges count
+	assert.Equal(t, byte(0), block[len(block)-1])
+	assert.Equal(t, []byte{11, 0, 12, 0, 10, 0, 13, 0, 2, 0, 0, 0}, block[len(block)-13:len(block)-1])
+
+	// slot range of series reset after metric committed
+	flusher.PrepareMetric(40, []field.Meta{{ID: 1, Type: field.SumField}})
+	assert.NoError(t, flusher.FlushField([]byte{1, 2, 3}))
+	assert.NoError(t, flusher.FlushSeries(10))
+	assert.NoError(t, flusher.CommitMetric(timeutil.SlotRange{Start: 10, End: 13}))
+	version, _ = ParseFormatVersion(nopKVFlusher.Bytes())
+	assert.Equal(t, FormatVersionV2, version)
+}
+
 func TestFlusher_flush_big_series_id(t *testing.T) {
 	nopKVFlusher := kv.NewNopFlusher()
 	flusher, _ := NewFlusher(nopKVFlusher)
@@ -178,7 +209,7 @@ func flushMoreData(t *testing.T,
 			Decoder: encoding.GetTSDDecoder(),
 		}
 		ctx.Grouping()
-		loader := r.Load(ctx)
+		loader := r.Load(ctx, allSlotRange)
 		loader.Load(ctx)
 	}
 	assert.Equal(t, int(seriesIDs.GetCardinality())*int(assertRatio), found)
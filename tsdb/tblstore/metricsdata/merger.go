@@ -26,6 +26,7 @@ import (
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
 )
 
 var MetricDataMerger kv.MergerType = "MetricDataMerger"
@@ -35,14 +36,46 @@ func init() {
 	kv.RegisterMerger(MetricDataMerger, NewMerger)
 }
 
+// FieldRetention checks if the field data of metric stored in family is out of field level retention,
+// expired fields are omitted when merging metric data.
+type FieldRetention interface {
+	// IsExpired returns true if the field data of metric stored in family is out of retention.
+	IsExpired(familyName string, metricID metric.ID, fieldID field.ID) bool
+}
+
+// FieldPrecision provides the value precision(significant decimal digits) of metric field,
+// field data is rounded to the precision when merging metric data.
+type FieldPrecision interface {
+	// GetPrecision returns the precision of field, 0 means full precision.
+	GetPrecision(metricID metric.ID, f field.Meta) uint8
+}
+
+// WriteSemantics checks the write semantics of rewriting same time slot,
+// the newer value replaces the older value of sum field when merging metric data if last write wins.
+type WriteSemantics interface {
+	// IsLastWriteWins returns true if the newer value replaces the older value of same time slot.
+	IsLastWriteWins() bool
+}
+
+// BlockCompression provides the codec compressing the metric blocks written by compaction,
+// so that old blocks are transcoded to the current codec of database when compacting.
+type BlockCompression interface {
+	// MetricBlockCodec returns the current codec of metric block.
+	MetricBlockCodec() Codec
+}
+
 type mergerContext struct {
 	scanners     []*dataScanner
 	seriesIDs    *roaring.Bitmap // target series ids
 	targetFields field.Metas     // target fields
+	precisions   []uint8         // precisions of target fields, nil if full precision
+	// keeps slot ranges of series if any source block has, only for compaction
+	keepSeriesSlotRange bool
 
 	targetRange, sourceRange timeutil.SlotRange
 	ratio                    uint16
 	baseSlot                 uint16
+	lastWriteWins            bool
 }
 
 // merger implements kv.Merger for merging series data for each metric
@@ -50,6 +83,11 @@ type merger struct {
 	dataFlusher  Flusher
 	seriesMerger SeriesMerger
 	rollup       kv.Rollup
+
+	familyName     string
+	fieldRetention FieldRetention
+	fieldPrecision FieldPrecision
+	lastWriteWins  bool
 }
 
 // NewMerger creates a metric data merger
@@ -64,11 +102,27 @@ func NewMerger(flusher kv.Flusher) (kv.Merger, error) {
 	}, nil
 }
 
-// Init initializes metric data merger, if rollup context exist do rollup job, else do compact job
+// Init initializes metric data merger, if rollup context exist do rollup job, else do compact job,
+// if field retention exist drops the expired fields, if field precision exist rounds the field data,
+// if last write wins the value of newer file replaces the value of older file for same time slot when compacting,
+// merged blocks are written by current codec.
 func (m *merger) Init(params map[string]interface{}) {
 	if rollupCtx, ok := params[kv.RollupContext]; ok {
 		m.rollup = rollupCtx.(kv.Rollup)
 	}
+	if retention, ok := params[kv.FieldRetentionContext].(FieldRetention); ok {
+		m.fieldRetention = retention
+		m.familyName, _ = params[kv.FamilyNameContext].(string)
+	}
+	if precision, ok := params[kv.FieldPrecisionContext].(FieldPrecision); ok {
+		m.fieldPrecision = precision
+	}
+	if semantics, ok := params[kv.WriteSemanticsContext].(WriteSemantics); ok {
+		m.lastWriteWins = semantics.IsLastWriteWins()
+	}
+	if compression, ok := params[kv.CompressionContext].(BlockCompression); ok {
+		m.dataFlusher.SetCodec(compression.MetricBlockCodec())
+	}
 }
 
 // Merge merges the multi metric data into one target metric data for same metric id
@@ -79,8 +133,22 @@ func (m *merger) Merge(key uint32, metricBlocks [][]byte) error {
 	if err != nil {
 		return err
 	}
+	if m.fieldRetention != nil {
+		mergeCtx.targetFields = m.dropExpiredFields(metric.ID(key), mergeCtx.targetFields)
+		if len(mergeCtx.targetFields) == 0 {
+			// all fields of metric are expired, drop metric data
+			return nil
+		}
+	}
+	if m.fieldPrecision != nil {
+		mergeCtx.precisions = m.getPrecisions(metric.ID(key), mergeCtx.targetFields)
+	}
 	// 2. Prepare metric
 	m.dataFlusher.PrepareMetric(key, mergeCtx.targetFields)
+	if mergeCtx.precisions != nil {
+		// record the applied precisions in metric block
+		m.dataFlusher.SetFieldPrecisions(mergeCtx.precisions)
+	}
 	// 3. merge series data by roaring container
 	highKeys := mergeCtx.seriesIDs.GetHighKeys()
 	decodeStreams := make([]*encoding.TSDDecoder, blockCount) // make decodeStreams for reuse
@@ -95,6 +163,8 @@ func (m *merger) Merge(key uint32, metricBlocks [][]byte) error {
 		it := container.PeekableIterator()
 		for it.HasNext() {
 			lowSeriesID := it.Next()
+			// slot range of series is union of source blocks
+			seriesSlotRange, hasSeriesSlotRange := timeutil.SlotRange{}, false
 			// maybe series id not exist in some values block
 			for blockIdx, scanner := range mergeCtx.scanners {
 				seriesEntry := scanner.scan(highKey, lowSeriesID)
@@ -107,10 +177,21 @@ func (m *merger) Merge(key uint32, metricBlocks [][]byte) error {
 				} else {
 					fieldReaders[blockIdx].Reset(seriesEntry, timeRange)
 				}
+				if mergeCtx.keepSeriesSlotRange {
+					if hasSeriesSlotRange {
+						seriesSlotRange = seriesSlotRange.Union(scanner.seriesSlotRange())
+					} else {
+						seriesSlotRange = scanner.seriesSlotRange()
+						hasSeriesSlotRange = true
+					}
+				}
 			}
 			if err := m.seriesMerger.merge(mergeCtx, decodeStreams, fieldReaders); err != nil {
 				return err
 			}
+			if hasSeriesSlotRange {
+				m.dataFlusher.SetSeriesSlotRange(seriesSlotRange)
+			}
 			// flush series id
 			if err := m.dataFlusher.FlushSeries(encoding.ValueWithHighLowBits(uint32(highKey)<<16, lowSeriesID)); err != nil {
 				return err
@@ -124,6 +205,33 @@ func (m *merger) Merge(key uint32, metricBlocks [][]byte) error {
 	return nil
 }
 
+// dropExpiredFields returns the fields which are not out of field level retention.
+func (m *merger) dropExpiredFields(metricID metric.ID, fields field.Metas) field.Metas {
+	rs := fields[:0]
+	for _, f := range fields {
+		if !m.fieldRetention.IsExpired(m.familyName, metricID, f.ID) {
+			rs = append(rs, f)
+		}
+	}
+	return rs
+}
+
+// getPrecisions returns the precisions of fields, returns nil if all fields are full precision.
+func (m *merger) getPrecisions(metricID metric.ID, fields field.Metas) []uint8 {
+	var precisions []uint8
+	for idx, f := range fields {
+		precision := m.fieldPrecision.GetPrecision(metricID, f)
+		if precision == 0 {
+			continue
+		}
+		if precisions == nil {
+			precisions = make([]uint8, len(fields))
+		}
+		precisions[idx] = precision
+	}
+	return precisions
+}
+
 func (m *merger) prepare(metricBlocks [][]byte) (*mergerContext, error) {
 	ctx := &mergerContext{
 		scanners:     make([]*dataScanner, len(metricBlocks)),
@@ -160,6 +268,10 @@ func (m *merger) prepare(metricBlocks [][]byte) (*mergerContext, error) {
 		if ctx.scanners[idx], err = newDataScanner(reader); err != nil {
 			return nil, err
 		}
+		if m.rollup == nil && ctx.scanners[idx].hasSeriesSlotRange() {
+			// rollup changes the interval of slot, so slot ranges of series are only kept by compaction
+			ctx.keepSeriesSlotRange = true
+		}
 	}
 	// sort by field id
 	sort.Slice(ctx.targetFields, func(i, j int) bool { return ctx.targetFields[i].ID < ctx.targetFields[j].ID })
@@ -175,6 +287,8 @@ func (m *merger) prepare(metricBlocks [][]byte) (*mergerContext, error) {
 		ctx.targetRange.Start = ctx.sourceRange.Start
 		ctx.targetRange.End = ctx.sourceRange.End
 		ctx.ratio = 1
+		// rollup aggregates the values of different source slots, so only compaction replaces the value of same slot
+		ctx.lastWriteWins = m.lastWriteWins
 	}
 	return ctx, nil
 }
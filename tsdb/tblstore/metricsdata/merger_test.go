@@ -33,6 +33,7 @@ import (
 	"github.com/lindb/lindb/pkg/encoding"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series/field"
+	"github.com/lindb/lindb/series/metric"
 	"github.com/lindb/lindb/sql/stmt"
 )
 
@@ -94,7 +95,7 @@ func assertMergeReentrant(t *testing.T, flusher kv.Flusher, mergerIntf kv.Merger
 		},
 		Decoder: encoding.GetTSDDecoder(),
 	}
-	loader := r.Load(ctx)
+	loader := r.Load(ctx, allSlotRange)
 	// not exist
 	ctx.LowSeriesIDsContainer = roaring.BitmapOf(0).GetContainerAtIndex(0)
 	ctx.Grouping()
@@ -262,3 +263,237 @@ func mockMetricMergeBlockOneField(seriesIDs []uint32, start, end uint16) []byte
 	_ = flusher.CommitMetric(timeutil.SlotRange{Start: start, End: end})
 	return nopKVFlusher.Bytes()
 }
+
+type mockFieldRetention struct {
+	expired map[field.ID]bool
+}
+
+func (r *mockFieldRetention) IsExpired(familyName string, metricID metric.ID, fieldID field.ID) bool {
+	return familyName == "5" && metricID == 1 && r.expired[fieldID]
+}
+
+func TestMerger_Compact_FieldRetention(t *testing.T) {
+	flusher := kv.NewNopFlusher()
+	mergerIntf, err := NewMerger(flusher)
+	assert.NoError(t, err)
+	retention := &mockFieldRetention{expired: map[field.ID]bool{10: true}}
+	mergerIntf.Init(map[string]interface{}{kv.FieldRetentionContext: retention, kv.FamilyNameContext: "5"})
+	// drop expired field
+	err = mergerIntf.Merge(1, [][]byte{
+		mockRealMetricBlock([]uint32{1, 2, 4}, 11, 15),
+		mockRealMetricBlock([]uint32{2, 20}, 16, 20),
+	})
+	assert.NoError(t, err)
+	r, err := NewReader("test", flusher.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, field.Metas{{ID: 2, Type: field.SumField}}, r.GetFields())
+	assert.Equal(t, uint64(4), r.GetSeriesIDs().GetCardinality())
+	// drop metric if all fields are expired
+	flusher = kv.NewNopFlusher()
+	mergerIntf, err = NewMerger(flusher)
+	assert.NoError(t, err)
+	retention.expired[2] = true
+	mergerIntf.Init(map[string]interface{}{kv.FieldRetentionContext: retention, kv.FamilyNameContext: "5"})
+	err = mergerIntf.Merge(1, [][]byte{mockRealMetricBlock([]uint32{1, 2, 4}, 11, 15)})
+	assert.NoError(t, err)
+	assert.Empty(t, flusher.Bytes())
+	// other family not expired
+	flusher = kv.NewNopFlusher()
+	mergerIntf, err = NewMerger(flusher)
+	assert.NoError(t, err)
+	mergerIntf.Init(map[string]interface{}{kv.FieldRetentionContext: retention, kv.FamilyNameContext: "6"})
+	err = mergerIntf.Merge(1, [][]byte{mockRealMetricBlock([]uint32{1, 2, 4}, 11, 15)})
+	assert.NoError(t, err)
+	r, err = NewReader("test", flusher.Bytes())
+	assert.NoError(t, err)
+	assert.Len(t, r.GetFields(), 2)
+}
+
+type mockFieldPrecision struct {
+	precisions map[field.ID]uint8
+}
+
+func (p *mockFieldPrecision) GetPrecision(metricID metric.ID, f field.Meta) uint8 {
+	if metricID != 1 {
+		return 0
+	}
+	return p.precisions[f.ID]
+}
+
+func TestMerger_Compact_FieldPrecision(t *testing.T) {
+	block := func() []byte {
+		nopKVFlusher := kv.NewNopFlusher()
+		flusher, _ := NewFlusher(nopKVFlusher)
+		flusher.PrepareMetric(1, field.Metas{{ID: 2, Type: field.SumField}, {ID: 10, Type: field.MinField}})
+		encoder := encoding.NewTSDEncoder(5)
+		encoder.AppendTime(true)
+		encoder.AppendValue(math.Float64bits(12.3456))
+		data, _ := encoder.BytesWithoutTime()
+		_ = flusher.FlushField(data)
+		_ = flusher.FlushField(data)
+		_ = flusher.FlushSeries(1)
+		_ = flusher.CommitMetric(timeutil.SlotRange{Start: 5, End: 5})
+		return nopKVFlusher.Bytes()
+	}
+	readFieldValue := func(r MetricReader, fieldID field.ID) float64 {
+		scanner, err := newDataScanner(r)
+		assert.NoError(t, err)
+		fieldReader := newFieldReader(scanner.fieldIndexes(), scanner.scan(0, 1), scanner.slotRange())
+		slotRange := fieldReader.SlotRange()
+		decoder := encoding.GetTSDDecoder()
+		defer encoding.ReleaseTSDDecoder(decoder)
+		decoder.ResetWithTimeRange(fieldReader.GetFieldData(fieldID), slotRange.Start, slotRange.End)
+		assert.True(t, decoder.HasValueWithSlot(5))
+		return math.Float64frombits(decoder.Value())
+	}
+	precision := &mockFieldPrecision{precisions: map[field.ID]uint8{10: 3}}
+	// case 1: round the field data and record the precision
+	flusher := kv.NewNopFlusher()
+	mergerIntf, err := NewMerger(flusher)
+	assert.NoError(t, err)
+	mergerIntf.Init(map[string]interface{}{kv.FieldPrecisionContext: precision})
+	assert.NoError(t, mergerIntf.Merge(1, [][]byte{block()}))
+	version, _ := ParseFormatVersion(flusher.Bytes())
+	assert.Equal(t, FormatVersionV4, version)
+	r, err := NewReader("test", flusher.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, map[field.ID]uint8{10: 3}, r.GetFieldPrecisions())
+	assert.Equal(t, 12.3456, readFieldValue(r, 2))
+	assert.Equal(t, 12.3, readFieldValue(r, 10))
+	// case 2: full precision for other metric
+	flusher = kv.NewNopFlusher()
+	mergerIntf, err = NewMerger(flusher)
+	assert.NoError(t, err)
+	mergerIntf.Init(map[string]interface{}{kv.FieldPrecisionContext: precision})
+	assert.NoError(t, mergerIntf.Merge(2, [][]byte{block()}))
+	version, _ = ParseFormatVersion(flusher.Bytes())
+	assert.Equal(t, FormatVersionV2, version)
+	r, err = NewReader("test", flusher.Bytes())
+	assert.NoError(t, err)
+	assert.Nil(t, r.GetFieldPrecisions())
+	assert.Equal(t, 12.3456, readFieldValue(r, 10))
+}
+
+func TestMerger_Compact_SeriesSlotRange(t *testing.T) {
+	// block mocks metric block, slot range of series set if withSlotRange
+	block := func(slotRange timeutil.SlotRange, seriesSlotRanges map[uint32]timeutil.SlotRange, withSlotRange bool) []byte {
+		nopKVFlusher := kv.NewNopFlusher()
+		flusher, _ := NewFlusher(nopKVFlusher)
+		flusher.PrepareMetric(1, field.Metas{{ID: 2, Type: field.SumField}})
+		for _, seriesID := range []uint32{1, 2, 3, 70000} {
+			seriesSlotRange, ok := seriesSlotRanges[seriesID]
+			if !ok {
+				continue
+			}
+			encoder := encoding.NewTSDEncoder(seriesSlotRange.Start)
+			for slot := seriesSlotRange.Start; slot <= seriesSlotRange.End; slot++ {
+				encoder.AppendTime(true)
+				encoder.AppendValue(math.Float64bits(float64(slot)))
+			}
+			data, _ := encoder.BytesWithoutTime()
+			_ = flusher.FlushField(data)
+			if withSlotRange {
+				flusher.SetSeriesSlotRange(seriesSlotRange)
+			}
+			_ = flusher.FlushSeries(seriesID)
+		}
+		_ = flusher.CommitMetric(slotRange)
+		return append([]byte{}, nopKVFlusher.Bytes()...)
+	}
+	block1 := block(timeutil.SlotRange{Start: 5, End: 10}, map[uint32]timeutil.SlotRange{
+		1:     {Start: 5, End: 6},
+		2:     {Start: 10, End: 10},
+		70000: {Start: 8, End: 8},
+	}, true)
+	block2 := block(timeutil.SlotRange{Start: 20, End: 21}, map[uint32]timeutil.SlotRange{
+		2: {Start: 20, End: 20},
+		3: {Start: 21, End: 21},
+	}, false)
+	merge := func(blocks ...[]byte) []byte {
+		flusher := kv.NewNopFlusher()
+		mergerIntf, err := NewMerger(flusher)
+		assert.NoError(t, err)
+		mergerIntf.Init(nil)
+		assert.NoError(t, mergerIntf.Merge(1, blocks))
+		return flusher.Bytes()
+	}
+	// case 1: slot range of series is union of source blocks,
+	// slot range of metric used if source block without slot ranges of series
+	data := merge(block1, block2)
+	version, _ := ParseFormatVersion(data)
+	assert.Equal(t, FormatVersionV5, version)
+	r, err := NewReader("test", data)
+	assert.NoError(t, err)
+	seriesIDs := roaring.BitmapOf(1, 2, 3, 70000)
+	assert.Equal(t, []uint32{1}, r.FilterSeriesIDs(seriesIDs, timeutil.SlotRange{Start: 5, End: 5}).ToArray())
+	assert.Equal(t, []uint32{70000}, r.FilterSeriesIDs(seriesIDs, timeutil.SlotRange{Start: 7, End: 9}).ToArray())
+	assert.Equal(t, []uint32{2, 3}, r.FilterSeriesIDs(seriesIDs, timeutil.SlotRange{Start: 15, End: 21}).ToArray())
+	// case 2: source blocks without slot ranges of series
+	data = merge(block2)
+	version, _ = ParseFormatVersion(data)
+	assert.Equal(t, FormatVersionV2, version)
+}
+
+type mockWriteSemantics struct {
+	lastWriteWins bool
+}
+
+func (s *mockWriteSemantics) IsLastWriteWins() bool {
+	return s.lastWriteWins
+}
+
+func TestMerger_Compact_LastWriteWins(t *testing.T) {
+	// readFieldValue reads the value of field(id: 2) with series(id: 1) at slot 11
+	readFieldValue := func(data []byte) float64 {
+		r, err := NewReader("test", data)
+		assert.NoError(t, err)
+		scanner, err := newDataScanner(r)
+		assert.NoError(t, err)
+		seriesEntry := scanner.scan(0, 1)
+		fieldReader := newFieldReader(scanner.fieldIndexes(), seriesEntry, scanner.slotRange())
+		slotRange := fieldReader.SlotRange()
+		decoder := encoding.GetTSDDecoder()
+		defer encoding.ReleaseTSDDecoder(decoder)
+		decoder.ResetWithTimeRange(fieldReader.GetFieldData(2), slotRange.Start, slotRange.End)
+		assert.True(t, decoder.HasValueWithSlot(11))
+		return math.Float64frombits(decoder.Value())
+	}
+	blocks := func() [][]byte {
+		return [][]byte{
+			mockRealMetricBlock([]uint32{1, 2}, 11, 15),
+			mockRealMetricBlock([]uint32{1, 4}, 11, 15),
+		}
+	}
+	cases := []struct {
+		name   string
+		params map[string]interface{}
+		value  float64
+	}{
+		{
+			name:   "aggregate by default",
+			params: map[string]interface{}{},
+			value:  22,
+		},
+		{
+			name:   "aggregate",
+			params: map[string]interface{}{kv.WriteSemanticsContext: &mockWriteSemantics{}},
+			value:  22,
+		},
+		{
+			name:   "last write wins",
+			params: map[string]interface{}{kv.WriteSemanticsContext: &mockWriteSemantics{lastWriteWins: true}},
+			value:  11,
+		},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			flusher := kv.NewNopFlusher()
+			mergerIntf, err := NewMerger(flusher)
+			assert.NoError(t, err)
+			mergerIntf.Init(tt.params)
+			assert.NoError(t, mergerIntf.Merge(1, blocks()))
+			assert.Equal(t, tt.value, readFieldValue(flusher.Bytes()))
+		})
+	}
+}
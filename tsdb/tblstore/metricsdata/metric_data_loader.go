@@ -19,9 +19,11 @@ package metricsdata
 
 import (
 	"github.com/lindb/roaring"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/lindb/lindb/flow"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/tracing"
 )
 
 // metricLoader implements flow.DataLoader interface that loads metric data from file storage.
@@ -30,6 +32,8 @@ type metricLoader struct {
 	lowContainer       roaring.Container
 	lowKeyOffsets      *encoding.FixedOffsetDecoder
 	seriesEntriesBlock []byte
+	// skipSeries returns true if series data is out of query slot range, nil if no series skipped
+	skipSeries func(seriesIdxFromStorage int) bool
 }
 
 // newMetricLoader creates a file storage metric loader.
@@ -38,23 +42,39 @@ func newMetricLoader(
 	seriesEntriesBlock []byte,
 	lowContainer roaring.Container,
 	lowKeyOffsets *encoding.FixedOffsetDecoder,
+	skipSeries func(seriesIdxFromStorage int) bool,
 ) flow.DataLoader {
 	return &metricLoader{
 		seriesEntriesBlock: seriesEntriesBlock,
 		reader:             reader,
 		lowContainer:       lowContainer,
 		lowKeyOffsets:      lowKeyOffsets,
+		skipSeries:         skipSeries,
 	}
 }
 
 // Load loads the metric data by given series id from file storage.
 func (s *metricLoader) Load(loadCtx *flow.DataLoadContext) {
+	_, span := tracing.StartSpan(loadCtx.Context(), "storage.file_decode")
+	defer span.End()
+
+	numOfSeries, numOfBytes, numOfSkipped := 0, 0, 0
 	loadCtx.IterateLowSeriesIDs(s.lowContainer, func(seriesIdxFromQuery uint16, seriesIdxFromStorage int) {
+		if s.skipSeries != nil && s.skipSeries(seriesIdxFromStorage) {
+			numOfSkipped++
+			return
+		}
 		seriesEntry, err := s.lowKeyOffsets.GetBlock(seriesIdxFromStorage, s.seriesEntriesBlock)
 		if err != nil {
 			return
 		}
 		// read series data of fields
 		s.reader.readSeriesData(loadCtx, seriesIdxFromQuery, seriesEntry)
+		numOfSeries++
+		numOfBytes += len(seriesEntry)
 	})
+	if span.IsRecording() {
+		span.SetAttributes(attribute.Int("series", numOfSeries), attribute.Int("bytes", numOfBytes),
+			attribute.Int("skipped", numOfSkipped))
+	}
 }
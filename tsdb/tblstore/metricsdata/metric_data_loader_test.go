@@ -92,7 +92,7 @@ func TestMetricLoader_Load(t *testing.T) {
 				tt.prepare()
 			}
 
-			s := newMetricLoader(r, nil, roaring.BitmapOf(10).GetContainer(0), seriesOffsets)
+			s := newMetricLoader(r, nil, roaring.BitmapOf(10).GetContainer(0), seriesOffsets, nil)
 			ctx.Grouping()
 			s.Load(ctx)
 		})
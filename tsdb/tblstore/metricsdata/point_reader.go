@@ -0,0 +1,77 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/series/field"
+)
+
+// PointReader reads the points of series from metric block, be used to verify the flushed data.
+// !!!!NOTICE: need seek series in order by series id
+type PointReader struct {
+	scanner     *dataScanner
+	fieldReader FieldReader
+	decoder     *encoding.TSDDecoder
+}
+
+// NewPointReader creates the point reader of metric block.
+func NewPointReader(r MetricReader) (*PointReader, error) {
+	scanner, err := newDataScanner(r)
+	if err != nil {
+		return nil, err
+	}
+	return &PointReader{
+		scanner:     scanner,
+		fieldReader: newFieldReader(scanner.fieldIndexes(), nil, r.GetTimeRange()),
+		decoder:     encoding.GetTSDDecoder(),
+	}, nil
+}
+
+// Seek seeks the series for reading points, returns false if series not found.
+func (r *PointReader) Seek(seriesID uint32) bool {
+	seriesEntry := r.scanner.scan(encoding.HighBits(seriesID), encoding.LowBits(seriesID))
+	if len(seriesEntry) == 0 {
+		r.fieldReader.Close()
+		return false
+	}
+	r.fieldReader.Reset(seriesEntry, r.scanner.slotRange())
+	return true
+}
+
+// GetPoints returns the points(time slot => value) of field for current series, nil if field not found.
+func (r *PointReader) GetPoints(fieldID field.ID) map[uint16]float64 {
+	block := r.fieldReader.GetFieldData(fieldID)
+	if len(block) == 0 {
+		return nil
+	}
+	slotRange := r.fieldReader.SlotRange()
+	r.decoder.ResetWithTimeRange(block, slotRange.Start, slotRange.End)
+	points := make(map[uint16]float64)
+	for slot := int(slotRange.Start); slot <= int(slotRange.End); slot++ {
+		if value, ok := r.decoder.GetValue(uint16(slot)); ok {
+			points[uint16(slot)] = value
+		}
+	}
+	return points
+}
+
+// Close releases the resource of reader.
+func (r *PointReader) Close() {
+	encoding.ReleaseTSDDecoder(r.decoder)
+}
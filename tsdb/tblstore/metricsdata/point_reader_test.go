@@ -0,0 +1,51 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointReader_GetPoints(t *testing.T) {
+	r, err := NewReader("1.sst", mockMetricBlockForOneField())
+	assert.NoError(t, err)
+	pr, err := NewPointReader(r)
+	assert.NoError(t, err)
+	defer pr.Close()
+
+	assert.True(t, pr.Seek(4096))
+	assert.Equal(t, map[uint16]float64{5: 0}, pr.GetPoints(2))
+	// field not found
+	assert.Nil(t, pr.GetPoints(3))
+	// series not found
+	assert.False(t, pr.Seek(4097))
+	assert.Nil(t, pr.GetPoints(2))
+	assert.True(t, pr.Seek(65536+10))
+	assert.Equal(t, map[uint16]float64{5: 10}, pr.GetPoints(2))
+}
+
+func TestPointReader_bad_reader(t *testing.T) {
+	r, err := NewReader("1.sst", mockMetricBlockForOneField())
+	assert.NoError(t, err)
+	r.(*metricReader).seriesIDs.Clear()
+	pr, err := NewPointReader(r)
+	assert.Error(t, err)
+	assert.Nil(t, pr)
+}
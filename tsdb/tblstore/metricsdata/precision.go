@@ -0,0 +1,37 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"math"
+	"strconv"
+)
+
+// RoundToPrecision rounds the value to given significant decimal digits, 0 means full precision.
+// Value is formatted/parsed in decimal instead of scaling by power of 10,
+// so that the rounded value is the closest float64 of the decimal without scaling error.
+func RoundToPrecision(value float64, precision uint8) float64 {
+	if precision == 0 || value == 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	rounded, err := strconv.ParseFloat(strconv.FormatFloat(value, 'g', int(precision), 64), 64)
+	if err != nil {
+		return value
+	}
+	return rounded
+}
@@ -0,0 +1,91 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"encoding/csv"
+	"math"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/option"
+)
+
+func TestRoundToPrecision(t *testing.T) {
+	cases := []struct {
+		value     float64
+		precision uint8
+		expect    float64
+	}{
+		{value: 12.3456, precision: 0, expect: 12.3456},
+		{value: 12.3456, precision: 3, expect: 12.3},
+		{value: 12.3556, precision: 3, expect: 12.4},
+		{value: -0.00123456, precision: 2, expect: -0.0012},
+		{value: 123456789, precision: 4, expect: 123500000},
+		{value: 90.9090909090909, precision: 4, expect: 90.91},
+		{value: 0, precision: 3, expect: 0},
+		{value: math.Inf(1), precision: 3, expect: math.Inf(1)},
+	}
+	for _, tt := range cases {
+		assert.Equal(t, tt.expect, RoundToPrecision(tt.value, tt.precision))
+	}
+	assert.True(t, math.IsNaN(RoundToPrecision(math.NaN(), 3)))
+}
+
+// TestRoundToPrecision_CompressionRatio compares the encoded size of field data with full/reduced precision,
+// testdata/host_gauges.csv is sampled from /proc/stat and /proc/meminfo of linux host every 100ms(360 points).
+func TestRoundToPrecision_CompressionRatio(t *testing.T) {
+	f, err := os.Open("testdata/host_gauges.csv")
+	assert.NoError(t, err)
+	defer func() {
+		_ = f.Close()
+	}()
+	records, err := csv.NewReader(f).ReadAll()
+	assert.NoError(t, err)
+	header, rows := records[0], records[1:]
+
+	zstd := Codec{Type: CodecZstd, Level: option.DefaultZstdLevel}
+	// encode returns the size of encoded field data, and the size compressed by zstd codec
+	encode := func(column int, precision uint8) (size, compressed int) {
+		encoder := encoding.NewTSDEncoder(0)
+		for slot, row := range rows {
+			value, err := strconv.ParseFloat(row[column], 64)
+			assert.NoError(t, err)
+			encoder.EmitDownSamplingValue(slot, RoundToPrecision(value, precision))
+		}
+		data, err := encoder.BytesWithoutTime()
+		assert.NoError(t, err)
+		zstdData, err := zstd.compress(nil, data)
+		assert.NoError(t, err)
+		return len(data), len(zstdData)
+	}
+	for column, name := range header {
+		full, fullCompressed := encode(column, 0)
+		for _, precision := range []uint8{6, 4, 3} {
+			reduced, reducedCompressed := encode(column, precision)
+			assert.LessOrEqual(t, reduced, full)
+			t.Logf("%s: precision %d, encoded %d=>%d bytes(%.2fx), zstd %d=>%d bytes(%.2fx)",
+				name, precision, full, reduced, float64(full)/float64(reduced),
+				fullCompressed, reducedCompressed, float64(fullCompressed)/float64(reducedCompressed))
+		}
+	}
+}
@@ -20,6 +20,7 @@ package metricsdata
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 	"sort"
 
 	"github.com/lindb/roaring"
@@ -42,8 +43,14 @@ const (
 		4 // crc32 checksum
 
 	fieldNotFound = -1
+
+	seriesSlotRangeSize = 2 + // start time slot
+		2 // end time slot
 )
 
+// unknownSlotRange marks the series which slot range not set when flushing.
+var unknownSlotRange = timeutil.SlotRange{Start: math.MaxUint16, End: 0}
+
 // MetricReader represents the metric block metricReader
 type MetricReader interface {
 	// Path returns file path
@@ -54,8 +61,15 @@ type MetricReader interface {
 	GetFields() field.Metas
 	// GetTimeRange returns the time range in this sst file
 	GetTimeRange() timeutil.SlotRange
-	// Load loads the data from sst file, then returns the file metric scanner.
-	Load(ctx *flow.DataLoadContext) flow.DataLoader
+	// GetFieldPrecisions returns the precisions(significant decimal digits) applied to field data by compaction,
+	// nil if field data is full precision.
+	GetFieldPrecisions() map[field.ID]uint8
+	// FilterSeriesIDs returns the series ids which data overlaps with slot range,
+	// returns the series ids directly if block has no slot range of series(written before format v5).
+	FilterSeriesIDs(seriesIDs *roaring.Bitmap, slotRange timeutil.SlotRange) *roaring.Bitmap
+	// Load loads the data in slot range from sst file, then returns the file metric scanner,
+	// the series which data is out of slot range are skipped before decoding.
+	Load(ctx *flow.DataLoadContext, slotRange timeutil.SlotRange) flow.DataLoader
 	// readSeriesData reads series data from file by seriesEntryBlock
 	readSeriesData(ctx *flow.DataLoadContext, seriesIdx uint16, seriesEntryBlock []byte)
 }
@@ -70,20 +84,85 @@ type metricReader struct {
 	fields         field.Metas
 	crc32CheckSum  uint32
 	timeRange      timeutil.SlotRange
+	precisions     map[field.ID]uint8
+	// slot ranges of series(aligned with series ids), nil if not recorded
+	seriesSlotRanges []byte
+	// index of first series in each container of series ids
+	containerSeriesIdx []int
 
 	readFieldIndexes []int // read field indexes be used when query metric data
 }
 
-// NewReader creates a metric block metricReader
+// NewReader creates a metric block metricReader based on the format version of metric block,
+// the block compressed by codec is decompressed into memory first.
 func NewReader(path string, metricBlock []byte) (MetricReader, error) {
-	r := &metricReader{
-		path:        path,
-		metricBlock: metricBlock,
-	}
-	if err := r.initReader(); err != nil {
-		return nil, err
+	version, block := ParseFormatVersion(metricBlock)
+	switch version {
+	case FormatVersionV1, FormatVersionV2:
+		// v2 only appends version trailer after footer, the layout of block is same as v1
+		r := &metricReader{
+			path:        path,
+			metricBlock: block,
+		}
+		if err := r.initReader(); err != nil {
+			return nil, err
+		}
+		return r, nil
+	case FormatVersionV3:
+		// v3 is the v2 block compressed by codec, decompresses it before decoding
+		rawBlock, err := decompressBlock(block)
+		if err != nil {
+			return nil, fmt.Errorf("decompress metric block failure: %w, path: %s", err, path)
+		}
+		if rawVersion, _ := ParseFormatVersion(rawBlock); rawVersion != FormatVersionV2 && rawVersion != FormatVersionV4 &&
+			rawVersion != FormatVersionV5 {
+			return nil, fmt.Errorf("%w: %d(compressed), path: %s", ErrUnsupportedFormatVersion, rawVersion, path)
+		}
+		return NewReader(path, rawBlock)
+	case FormatVersionV4:
+		// v4 is the v2 block with field precisions before version trailer
+		precisions, rawBlock, err := parseFieldPrecisions(block)
+		if err != nil {
+			return nil, fmt.Errorf("%w, path: %s", err, path)
+		}
+		r := &metricReader{
+			path:        path,
+			metricBlock: rawBlock,
+			precisions:  precisions,
+		}
+		if err := r.initReader(); err != nil {
+			return nil, err
+		}
+		return r, nil
+	case FormatVersionV5:
+		// v5 is the v2 block with series slot ranges and field precisions before version trailer
+		precisions, block, err := parseFieldPrecisions(block)
+		if err != nil {
+			return nil, fmt.Errorf("%w, path: %s", err, path)
+		}
+		seriesSlotRanges, rawBlock, err := parseSeriesSlotRanges(block)
+		if err != nil {
+			return nil, fmt.Errorf("%w, path: %s", err, path)
+		}
+		if len(precisions) == 0 {
+			precisions = nil
+		}
+		r := &metricReader{
+			path:             path,
+			metricBlock:      rawBlock,
+			precisions:       precisions,
+			seriesSlotRanges: seriesSlotRanges,
+		}
+		if err := r.initReader(); err != nil {
+			return nil, err
+		}
+		if err := r.initSeriesSlotRanges(); err != nil {
+			return nil, fmt.Errorf("%w, path: %s", err, path)
+		}
+		return r, nil
+	default:
+		return nil, fmt.Errorf("%w: %d, path: %s", ErrUnsupportedFormatVersion, version, path)
 	}
-	return r, nil
 }
 
 // Path returns the file path
@@ -106,6 +185,11 @@ func (r *metricReader) GetTimeRange() timeutil.SlotRange {
 	return r.timeRange
 }
 
+// GetFieldPrecisions returns the precisions(significant decimal digits) applied to field data by compaction.
+func (r *metricReader) GetFieldPrecisions() map[field.ID]uint8 {
+	return r.precisions
+}
+
 // prepare the field aggregator based on query condition.
 func (r *metricReader) prepare(fields field.Metas) (found bool) {
 	fieldMap := make(map[field.ID]int)
@@ -124,8 +208,44 @@ func (r *metricReader) prepare(fields field.Metas) (found bool) {
 	return
 }
 
-// Load loads the data from sst file, then returns the file metric scanner.
-func (r *metricReader) Load(ctx *flow.DataLoadContext) flow.DataLoader {
+// FilterSeriesIDs returns the series ids which data overlaps with slot range.
+func (r *metricReader) FilterSeriesIDs(seriesIDs *roaring.Bitmap, slotRange timeutil.SlotRange) *roaring.Bitmap {
+	if r.seriesSlotRanges == nil {
+		return seriesIDs
+	}
+	result := roaring.New()
+	for idx, highKey := range seriesIDs.GetHighKeys() {
+		containerIdx := r.seriesIDs.GetContainerIndex(highKey)
+		if containerIdx < 0 {
+			continue
+		}
+		storeContainer := r.seriesIDs.GetContainerAtIndex(containerIdx)
+		it := seriesIDs.GetContainerAtIndex(idx).PeekableIterator()
+		for it.HasNext() {
+			lowSeriesID := it.Next()
+			if !storeContainer.Contains(lowSeriesID) {
+				continue
+			}
+			seriesSlotRange := r.seriesSlotRange(r.containerSeriesIdx[containerIdx] + storeContainer.Rank(lowSeriesID) - 1)
+			if seriesSlotRange.Overlap(slotRange) {
+				result.Add(encoding.ValueWithHighLowBits(uint32(highKey)<<16, lowSeriesID))
+			}
+		}
+	}
+	return result
+}
+
+// seriesSlotRange returns the slot range of series by index of series ids.
+func (r *metricReader) seriesSlotRange(seriesIdx int) timeutil.SlotRange {
+	pos := seriesIdx * seriesSlotRangeSize
+	return timeutil.SlotRange{
+		Start: binary.LittleEndian.Uint16(r.seriesSlotRanges[pos:]),
+		End:   binary.LittleEndian.Uint16(r.seriesSlotRanges[pos+2:]),
+	}
+}
+
+// Load loads the data in slot range from sst file, then returns the file metric scanner.
+func (r *metricReader) Load(ctx *flow.DataLoadContext, slotRange timeutil.SlotRange) flow.DataLoader {
 	// 1. get high container index by the high key of series ID
 	highContainerIdx := r.seriesIDs.GetContainerIndex(ctx.SeriesIDHighKey)
 	if highContainerIdx < 0 {
@@ -163,8 +283,18 @@ func (r *metricReader) Load(ctx *flow.DataLoadContext) flow.DataLoader {
 		return nil
 	}
 	seriesEntriesBlock := level3Block[:lowKeyOffsetsAt]
+	ctx.ShardExecuteCtx.StorageExecuteCtx.AddScanBytes(len(seriesEntriesBlock))
+	var skipSeries func(seriesIdxFromStorage int) bool
+	if r.seriesSlotRanges != nil && (slotRange.Start > r.timeRange.Start || slotRange.End < r.timeRange.End) {
+		// skip the series which data is out of slot range
+		seriesIdx := r.containerSeriesIdx[highContainerIdx]
+		skipSeries = func(seriesIdxFromStorage int) bool {
+			seriesSlotRange := r.seriesSlotRange(seriesIdx + seriesIdxFromStorage)
+			return !seriesSlotRange.Overlap(slotRange)
+		}
+	}
 	// must use lowContainer from store, because get series index based on container
-	return newMetricLoader(r, seriesEntriesBlock, lowContainer, lowKeyOffsetsDecoder)
+	return newMetricLoader(r, seriesEntriesBlock, lowContainer, lowKeyOffsetsDecoder, skipSeries)
 }
 
 // readSeriesData reads series data from file by given position.
@@ -202,6 +332,55 @@ func (r *metricReader) readSeriesData(ctx *flow.DataLoadContext, seriesIdx uint1
 	encoding.ReleaseFixedOffsetDecoder(fieldOffsetsDecoder)
 }
 
+// parseFieldPrecisions parses the field precisions of v4 block, returns the v2 block without field precisions.
+func parseFieldPrecisions(block []byte) (map[field.ID]uint8, []byte, error) {
+	n := len(block)
+	if n < 1 {
+		return nil, nil, fmt.Errorf("metric block's length too small: %d", n)
+	}
+	count := int(block[n-1])
+	sectionAt := n - 1 - count*2
+	if sectionAt < 0 {
+		return nil, nil, fmt.Errorf("corrupted field precisions, count: %d", count)
+	}
+	precisions := make(map[field.ID]uint8, count)
+	for pos := sectionAt; pos < n-1; pos += 2 {
+		precisions[field.ID(block[pos])] = block[pos+1]
+	}
+	return precisions, block[:sectionAt], nil
+}
+
+// parseSeriesSlotRanges parses the series slot ranges of v5 block, returns the v2 block without series slot ranges.
+func parseSeriesSlotRanges(block []byte) ([]byte, []byte, error) {
+	n := len(block)
+	if n < 4 {
+		return nil, nil, fmt.Errorf("metric block's length too small: %d", n)
+	}
+	count := int(binary.LittleEndian.Uint32(block[n-4:]))
+	sectionAt := n - 4 - count*seriesSlotRangeSize
+	if count < 0 || sectionAt < 0 {
+		return nil, nil, fmt.Errorf("corrupted series slot ranges, count: %d", count)
+	}
+	return block[sectionAt : n-4], block[:sectionAt], nil
+}
+
+// initSeriesSlotRanges checks the series slot ranges and builds the index of first series in each container.
+func (r *metricReader) initSeriesSlotRanges() error {
+	numOfSeries := r.seriesIDs.GetCardinality()
+	if uint64(len(r.seriesSlotRanges)/seriesSlotRangeSize) != numOfSeries {
+		return fmt.Errorf("corrupted series slot ranges, count: %d, series: %d",
+			len(r.seriesSlotRanges)/seriesSlotRangeSize, numOfSeries)
+	}
+	highKeys := r.seriesIDs.GetHighKeys()
+	r.containerSeriesIdx = make([]int, len(highKeys))
+	seriesIdx := 0
+	for idx := range highKeys {
+		r.containerSeriesIdx[idx] = seriesIdx
+		seriesIdx += r.seriesIDs.GetContainerAtIndex(idx).GetCardinality()
+	}
+	return nil
+}
+
 // initReader initializes the metricReader context includes tag value ids/high offsets
 func (r *metricReader) initReader() error {
 	if len(r.metricBlock) <= dataFooterSize {
@@ -272,6 +451,7 @@ type dataScanner struct {
 	highKeys         []uint16
 	highKey          uint16
 	highContainerIdx int
+	seriesIdx        int // index of last scanned series in current container
 }
 
 // newDataScanner creates a data scanner for data merge
@@ -324,6 +504,20 @@ func (s *dataScanner) slotRange() timeutil.SlotRange {
 	return s.reader.GetTimeRange()
 }
 
+// hasSeriesSlotRange returns if the slot ranges of series recorded in current sst file.
+func (s *dataScanner) hasSeriesSlotRange() bool {
+	return s.reader.seriesSlotRanges != nil
+}
+
+// seriesSlotRange returns the slot range of last scanned series, slot range of metric level if not recorded.
+func (s *dataScanner) seriesSlotRange() timeutil.SlotRange {
+	if !s.hasSeriesSlotRange() {
+		return s.slotRange()
+	}
+	// high container index is moved to next after container loaded
+	return s.reader.seriesSlotRange(s.reader.containerSeriesIdx[s.highContainerIdx-1] + s.seriesIdx)
+}
+
 // scan the data and returns the seriesEntry if series id exist, else returns nil.
 func (s *dataScanner) scan(highKey, lowSeriesID uint16) []byte {
 	if s.highKey < highKey {
@@ -343,6 +537,7 @@ func (s *dataScanner) scan(highKey, lowSeriesID uint16) []byte {
 	if s.container.Contains(lowSeriesID) {
 		// get the index of low series id in container
 		idx := s.container.Rank(lowSeriesID)
+		s.seriesIdx = idx - 1
 		// get series data data position
 		seriesEntry, _ := s.lowKeyOffsets.GetBlock(idx-1, s.seriesEntries)
 		return seriesEntry
@@ -31,6 +31,7 @@ import (
 	"github.com/lindb/lindb/kv"
 	"github.com/lindb/lindb/pkg/bit"
 	"github.com/lindb/lindb/pkg/encoding"
+	"github.com/lindb/lindb/pkg/option"
 	"github.com/lindb/lindb/pkg/timeutil"
 	"github.com/lindb/lindb/series/field"
 	"github.com/lindb/lindb/sql/stmt"
@@ -38,6 +39,9 @@ import (
 
 var bitmapUnmarshal = encoding.BitmapUnmarshal
 
+// allSlotRange is the query slot range which contains all time slots.
+var allSlotRange = timeutil.SlotRange{Start: 0, End: math.MaxUint16}
+
 func TestNewReader(t *testing.T) {
 	defer func() {
 		encoding.BitmapUnmarshal = bitmapUnmarshal
@@ -96,21 +100,26 @@ func TestReader_Load(t *testing.T) {
 				Fields: field.Metas{{ID: 2}, {ID: 30}, {ID: 50}},
 			},
 		},
-	})
+	}, allSlotRange)
 	// case 3: load data success
 	r, err = NewReader("1.sst", mockMetricBlock())
 	assert.NoError(t, err)
+	scanBytes := 0
 	scanner := r.Load(&flow.DataLoadContext{
 		SeriesIDHighKey:       0,
 		LowSeriesIDsContainer: roaring.BitmapOf(4096, 8192).GetContainer(0),
 		ShardExecuteCtx: &flow.ShardExecuteContext{
 			StorageExecuteCtx: &flow.StorageExecuteContext{
 				Fields: field.Metas{{ID: 2}, {ID: 30}, {ID: 50}},
+				OnScan: func(bytes int) {
+					scanBytes += bytes
+				},
 			},
 		},
-	})
+	}, allSlotRange)
 
 	assert.NotNil(t, scanner)
+	assert.Positive(t, scanBytes)
 	// case 4: series ids not found
 	r, err = NewReader("1.sst", mockMetricBlock())
 	assert.NoError(t, err)
@@ -122,7 +131,7 @@ func TestReader_Load(t *testing.T) {
 				Fields: field.Metas{{ID: 2}, {ID: 30}, {ID: 50}},
 			},
 		},
-	})
+	}, allSlotRange)
 	assert.Nil(t, scanner)
 
 	found := 0
@@ -147,7 +156,7 @@ func TestReader_Load(t *testing.T) {
 		Decoder: encoding.GetTSDDecoder(),
 	}
 	ctx.Grouping()
-	scanner = r.Load(ctx)
+	scanner = r.Load(ctx, allSlotRange)
 	// case 5: load data success, metric has one field
 	r, err = NewReader("1.sst", mockMetricBlockForOneField())
 	assert.NoError(t, err)
@@ -159,7 +168,7 @@ func TestReader_Load(t *testing.T) {
 	assert.NoError(t, err)
 	ctx.SeriesIDHighKey = 10
 	ctx.Grouping()
-	scanner = r.Load(ctx)
+	scanner = r.Load(ctx, allSlotRange)
 	assert.Nil(t, scanner)
 }
 
@@ -191,15 +200,165 @@ func TestReader_scan(t *testing.T) {
 	assert.Empty(t, seriesEntry)
 }
 
+func TestReader_FieldPrecisions(t *testing.T) {
+	for _, codec := range []Codec{{Type: CodecNone}, {Type: CodecZstd, Level: option.DefaultZstdLevel}} {
+		block := mockMetricBlockWithPrecisions(codec, []uint8{0, 3, 0, 5})
+		r, err := NewReader("1.sst", block)
+		assert.NoError(t, err)
+		assert.Equal(t, map[field.ID]uint8{10: 3, 100: 5}, r.GetFieldPrecisions())
+		expect, err := NewReader("1.sst", mockMetricBlockWithCodec(codec))
+		assert.NoError(t, err)
+		assert.Nil(t, expect.GetFieldPrecisions())
+		assert.Equal(t, expect.GetFields(), r.GetFields())
+		assert.Equal(t, expect.GetTimeRange(), r.GetTimeRange())
+		assert.Equal(t, expect.GetSeriesIDs().ToArray(), r.GetSeriesIDs().ToArray())
+	}
+	// corrupted field precisions
+	block := mockMetricBlockWithPrecisions(Codec{Type: CodecNone}, []uint8{0, 3, 0, 5})
+	block[len(block)-versionTrailerSize-1] = 255
+	r, err := NewReader("1.sst", block[len(block)-versionTrailerSize-5:])
+	assert.Error(t, err)
+	assert.Nil(t, r)
+}
+
+func TestReader_SeriesSlotRanges(t *testing.T) {
+	// slot range of series(j*4096) is [5+j,5+j], slot range of series(65546) not set
+	seriesSlotRange := func(seriesID uint32) (timeutil.SlotRange, bool) {
+		if seriesID > math.MaxUint16 {
+			return timeutil.SlotRange{}, false
+		}
+		slot := uint16(5 + seriesID/4096)
+		return timeutil.SlotRange{Start: slot, End: slot}, true
+	}
+	for _, codec := range []Codec{{Type: CodecNone}, {Type: CodecZstd, Level: option.DefaultZstdLevel}} {
+		for _, precisions := range [][]uint8{nil, {0, 3, 0, 5}} {
+			block := mockMetricBlockWithSeriesSlotRanges(codec, precisions, timeutil.SlotRange{Start: 5, End: 5}, seriesSlotRange)
+			version, _ := ParseFormatVersion(block)
+			if codec.Type == CodecNone {
+				assert.Equal(t, FormatVersionV5, version)
+			} else {
+				assert.Equal(t, FormatVersionV3, version)
+			}
+			r, err := NewReader("1.sst", block)
+			assert.NoError(t, err)
+			expect, err := NewReader("1.sst", mockMetricBlockWithPrecisions(codec, precisions))
+			assert.NoError(t, err)
+			assert.Equal(t, expect.GetFieldPrecisions(), r.GetFieldPrecisions())
+			assert.Equal(t, expect.GetFields(), r.GetFields())
+			assert.Equal(t, expect.GetTimeRange(), r.GetTimeRange())
+			assert.Equal(t, expect.GetSeriesIDs().ToArray(), r.GetSeriesIDs().ToArray())
+
+			seriesIDs := roaring.BitmapOf(1, 4096, 2*4096, 3*4096, 9*4096, 65536+10, 65536+11, 3*65536)
+			// series which slot range not set uses slot range of metric
+			assert.Equal(t, []uint32{4096, 2 * 4096, 65536 + 10},
+				r.FilterSeriesIDs(seriesIDs, timeutil.SlotRange{Start: 5, End: 7}).ToArray())
+			assert.Equal(t, []uint32{9 * 4096},
+				r.FilterSeriesIDs(seriesIDs, timeutil.SlotRange{Start: 14, End: 20}).ToArray())
+			assert.True(t, r.FilterSeriesIDs(seriesIDs, timeutil.SlotRange{Start: 20, End: 30}).IsEmpty())
+			assert.Equal(t, []uint32{4096, 2 * 4096, 3 * 4096, 9 * 4096, 65536 + 10},
+				r.FilterSeriesIDs(seriesIDs, allSlotRange).ToArray())
+			// old block without series slot ranges
+			assert.Equal(t, seriesIDs, expect.FilterSeriesIDs(seriesIDs, timeutil.SlotRange{Start: 20, End: 30}))
+		}
+	}
+}
+
+func TestReader_Load_SeriesSlotRanges(t *testing.T) {
+	block := mockMetricBlockWithSeriesSlotRanges(Codec{Type: CodecNone}, nil, timeutil.SlotRange{Start: 0, End: 16},
+		func(seriesID uint32) (timeutil.SlotRange, bool) {
+			return timeutil.SlotRange{Start: uint16(seriesID / 4096), End: uint16(seriesID / 4096)}, true
+		})
+	r, err := NewReader("1.sst", block)
+	assert.NoError(t, err)
+	// returns the low series ids which data loaded
+	load := func(slotRange timeutil.SlotRange) []uint16 {
+		var loaded []uint16
+		ctx := &flow.DataLoadContext{
+			SeriesIDHighKey:       0,
+			LowSeriesIDsContainer: roaring.BitmapOf(0, 4096, 2*4096, 5*4096, 9*4096).GetContainer(0),
+			ShardExecuteCtx: &flow.ShardExecuteContext{
+				StorageExecuteCtx: &flow.StorageExecuteContext{
+					Fields: field.Metas{{ID: 2}},
+					Query:  &stmt.Query{},
+				},
+			},
+			DownSampling: func(_ timeutil.SlotRange, seriesIdx uint16, fieldIdx int, _ encoding.TSDValueGetter) {
+				loaded = append(loaded, seriesIdx)
+			},
+			Decoder: encoding.GetTSDDecoder(),
+		}
+		ctx.Grouping()
+		scanner := r.Load(ctx, slotRange)
+		assert.NotNil(t, scanner)
+		scanner.Load(ctx)
+		return loaded
+	}
+	// query slot range contains time range of metric block, no series skipped
+	assert.Equal(t, []uint16{0, 4096, 2 * 4096, 5 * 4096, 9 * 4096}, load(allSlotRange))
+	assert.Equal(t, []uint16{0, 4096, 2 * 4096, 5 * 4096, 9 * 4096}, load(timeutil.SlotRange{Start: 0, End: 16}))
+	// skip series which data is out of query slot range
+	assert.Equal(t, []uint16{2 * 4096, 5 * 4096}, load(timeutil.SlotRange{Start: 2, End: 5}))
+	assert.Empty(t, load(timeutil.SlotRange{Start: 10, End: 20}))
+}
+
+func TestReader_SeriesSlotRanges_Corrupted(t *testing.T) {
+	block := mockMetricBlockWithSeriesSlotRanges(Codec{Type: CodecNone}, nil, timeutil.SlotRange{Start: 5, End: 5},
+		func(_ uint32) (timeutil.SlotRange, bool) {
+			return timeutil.SlotRange{Start: 5, End: 5}, true
+		})
+	// count of series slot ranges not match series ids
+	countAt := len(block) - versionTrailerSize - 1 - 4
+	corrupted := append([]byte{}, block...)
+	corrupted[countAt] = 1
+	r, err := NewReader("1.sst", corrupted)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	// count of series slot ranges too large
+	corrupted = append([]byte{}, block...)
+	corrupted[countAt+3] = 255
+	r, err = NewReader("1.sst", corrupted)
+	assert.Error(t, err)
+	assert.Nil(t, r)
+	// block too small
+	r, err = NewReader("1.sst", block[len(block)-versionTrailerSize-3:])
+	assert.Error(t, err)
+	assert.Nil(t, r)
+}
+
 func mockMetricBlock() []byte {
+	return mockMetricBlockWithCodec(Codec{Type: CodecNone})
+}
+
+func mockMetricBlockWithCodec(codec Codec) []byte {
+	return mockMetricBlockWithPrecisions(codec, nil)
+}
+
+func mockMetricBlockWithPrecisions(codec Codec, precisions []uint8) []byte {
+	return mockMetricBlockWithSeriesSlotRanges(codec, precisions, timeutil.SlotRange{Start: 5, End: 5}, nil)
+}
+
+// mockMetricBlockWithSeriesSlotRanges mocks metric block, slot range of series set if seriesSlotRange returns true.
+func mockMetricBlockWithSeriesSlotRanges(codec Codec, precisions []uint8, metricSlotRange timeutil.SlotRange,
+	seriesSlotRange func(seriesID uint32) (timeutil.SlotRange, bool),
+) []byte {
 	nopKVFlusher := kv.NewNopFlusher()
 	flusher, _ := NewFlusher(nopKVFlusher)
+	flusher.SetCodec(codec)
 	flusher.PrepareMetric(10, field.Metas{
 		{ID: 2, Type: field.SumField},
 		{ID: 10, Type: field.MinField},
 		{ID: 30, Type: field.SumField},
 		{ID: 100, Type: field.MaxField},
 	})
+	flusher.SetFieldPrecisions(precisions)
+	setSeriesSlotRange := func(seriesID uint32) {
+		if seriesSlotRange == nil {
+			return
+		}
+		if slotRange, ok := seriesSlotRange(seriesID); ok {
+			flusher.SetSeriesSlotRange(slotRange)
+		}
+	}
 
 	for j := 0; j < 10; j++ {
 		encoder := encoding.NewTSDEncoder(5)
@@ -212,6 +371,7 @@ func mockMetricBlock() []byte {
 		_ = flusher.FlushField(data)
 		_ = flusher.FlushField(data)
 		_ = flusher.FlushField(data)
+		setSeriesSlotRange(uint32(j * 4096))
 		_ = flusher.FlushSeries(uint32(j * 4096))
 	}
 	// mock just has one field
@@ -220,8 +380,9 @@ func mockMetricBlock() []byte {
 	encoder.AppendValue(math.Float64bits(10.0))
 	data, _ := encoder.BytesWithoutTime()
 	_ = flusher.FlushField(data)
+	setSeriesSlotRange(uint32(65536 + 10))
 	_ = flusher.FlushSeries(uint32(65536 + 10))
-	_ = flusher.CommitMetric(timeutil.SlotRange{Start: 5, End: 5})
+	_ = flusher.CommitMetric(metricSlotRange)
 
 	return nopKVFlusher.Bytes()
 }
@@ -296,3 +457,65 @@ func Benchmark_Roaring_FrozenView(b *testing.B) {
 		_ = r2.FrozenView(data)
 	}
 }
+
+// Benchmark_Reader_Load_Sparse loads narrow slot range from sparse dataset(each series has one point),
+// compares the block with series slot ranges(v5) and without(v2).
+func Benchmark_Reader_Load_Sparse(b *testing.B) {
+	const (
+		numOfSeries = 10000
+		numOfSlots  = 360
+	)
+	mockBlock := func(withSlotRange bool) []byte {
+		nopKVFlusher := kv.NewNopFlusher()
+		flusher, _ := NewFlusher(nopKVFlusher)
+		flusher.PrepareMetric(10, field.Metas{{ID: 2, Type: field.SumField}})
+		for i := 0; i < numOfSeries; i++ {
+			slot := uint16(i % numOfSlots)
+			encoder := encoding.NewTSDEncoder(slot)
+			encoder.AppendTime(bit.One)
+			encoder.AppendValue(math.Float64bits(float64(i)))
+			data, _ := encoder.BytesWithoutTime()
+			_ = flusher.FlushField(data)
+			if withSlotRange {
+				flusher.SetSeriesSlotRange(timeutil.SlotRange{Start: slot, End: slot})
+			}
+			_ = flusher.FlushSeries(uint32(i))
+		}
+		_ = flusher.CommitMetric(timeutil.SlotRange{Start: 0, End: numOfSlots - 1})
+		return append([]byte{}, nopKVFlusher.Bytes()...)
+	}
+	seriesIDs := roaring.New()
+	seriesIDs.AddRange(0, numOfSeries)
+	querySlotRange := timeutil.SlotRange{Start: 10, End: 11}
+
+	for _, withSlotRange := range []bool{false, true} {
+		r, err := NewReader("1.sst", mockBlock(withSlotRange))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprintf("series_slot_range_%t", withSlotRange), func(b *testing.B) {
+			decoded := 0
+			ctx := &flow.DataLoadContext{
+				SeriesIDHighKey:       0,
+				LowSeriesIDsContainer: seriesIDs.GetContainer(0),
+				ShardExecuteCtx: &flow.ShardExecuteContext{
+					StorageExecuteCtx: &flow.StorageExecuteContext{
+						Fields: field.Metas{{ID: 2}},
+						Query:  &stmt.Query{},
+					},
+				},
+				DownSampling: func(_ timeutil.SlotRange, _ uint16, _ int, _ encoding.TSDValueGetter) {
+					decoded++
+				},
+				Decoder: encoding.GetTSDDecoder(),
+			}
+			ctx.Grouping()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				r.Load(ctx, querySlotRange).Load(ctx)
+			}
+			b.ReportMetric(float64(decoded)/float64(b.N), "decoded_series/op")
+		})
+	}
+}
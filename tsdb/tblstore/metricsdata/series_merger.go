@@ -72,13 +72,20 @@ func (sm *seriesMerger) merge(
 				streams[idx].ResetWithTimeRange(fieldData, oldSlotRange.Start, oldSlotRange.End)
 			}
 		}
+		emit := encodeStream.EmitDownSamplingValue
+		if mergeCtx.precisions != nil && mergeCtx.precisions[idx] > 0 {
+			precision := mergeCtx.precisions[idx]
+			emit = func(pos int, value float64) {
+				encodeStream.EmitDownSamplingValue(pos, RoundToPrecision(value, precision))
+			}
+		}
 		// merges field data from source time range => target time range,
 		// compact merge: source range = target range and ratio = 1
 		// rollup merge: source range[5,182]=>target range[0,6], ratio:30, source interval:10s, target interval:5min
 		aggregation.DownSamplingMultiSeriesInto(
 			mergeCtx.targetRange, mergeCtx.ratio, mergeCtx.baseSlot,
-			f.Type, streams,
-			encodeStream.EmitDownSamplingValue,
+			f.Type, mergeCtx.lastWriteWins, streams,
+			emit,
 		)
 
 		data, err := encodeStream.BytesWithoutTime()
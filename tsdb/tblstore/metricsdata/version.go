@@ -0,0 +1,82 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// FormatVersion represents the on-disk format version of metric block.
+type FormatVersion uint8
+
+const (
+	// FormatVersionV1 is the legacy metric block format without version trailer.
+	FormatVersionV1 FormatVersion = 1
+	// FormatVersionV2 is the metric block format with version trailer after footer.
+	FormatVersionV2 FormatVersion = 2
+	// FormatVersionV3 is the v2 metric block compressed by codec, codec type is recorded before version trailer.
+	FormatVersionV3 FormatVersion = 3
+	// FormatVersionV4 is the v2 metric block with field precisions applied by compaction, recorded before version trailer.
+	FormatVersionV4 FormatVersion = 4
+	// FormatVersionV5 is the v2 block with per-series slot ranges and field precisions(may be empty),
+	// recorded before version trailer.
+	FormatVersionV5 FormatVersion = 5
+	// CurrentFormatVersion is the max metric block format version written by flusher.
+	CurrentFormatVersion = FormatVersionV5
+)
+
+const (
+	// formatMagic marks a metric block which has version trailer,
+	// block without it is treated as legacy v1 block.
+	formatMagic uint32 = 0x4C444256 // "LDBV"
+
+	versionTrailerSize = 1 + // format version
+		4 // magic
+)
+
+// ErrUnsupportedFormatVersion represents metric block written by newer binary which cannot be decoded.
+var ErrUnsupportedFormatVersion = errors.New("unsupported metric block format version")
+
+// ParseFormatVersion returns the format version of metric block and the block without version trailer.
+func ParseFormatVersion(metricBlock []byte) (FormatVersion, []byte) {
+	n := len(metricBlock)
+	// compressed block(v3) may be smaller than footer
+	if n < versionTrailerSize ||
+		binary.LittleEndian.Uint32(metricBlock[n-4:]) != formatMagic {
+		return FormatVersionV1, metricBlock
+	}
+	return FormatVersion(metricBlock[n-versionTrailerSize]), metricBlock[:n-versionTrailerSize]
+}
+
+// CheckFormatVersion checks if the metric block can be decoded by current binary.
+func CheckFormatVersion(metricBlock []byte) error {
+	version, _ := ParseFormatVersion(metricBlock)
+	if version < FormatVersionV1 || version > CurrentFormatVersion {
+		return fmt.Errorf("%w: %d, max supported: %d", ErrUnsupportedFormatVersion, version, CurrentFormatVersion)
+	}
+	if version == FormatVersionV3 {
+		// check if the codec of compressed block is supported
+		n := len(metricBlock) - versionTrailerSize
+		if n < codecTrailerSize || CodecType(metricBlock[n-codecTrailerSize]) > CodecZstd {
+			return fmt.Errorf("%w, format version: %d", ErrUnsupportedCodec, version)
+		}
+	}
+	return nil
+}
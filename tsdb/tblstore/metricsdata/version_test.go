@@ -0,0 +1,115 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package metricsdata
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/series/field"
+)
+
+func TestParseFormatVersion(t *testing.T) {
+	block := mockMetricBlock()
+	version, body := ParseFormatVersion(block)
+	assert.Equal(t, FormatVersionV2, version)
+	assert.Len(t, body, len(block)-versionTrailerSize)
+	assert.NoError(t, CheckFormatVersion(block))
+
+	// legacy block without version trailer
+	version, body = ParseFormatVersion(body)
+	assert.Equal(t, FormatVersionV1, version)
+	assert.Len(t, body, len(block)-versionTrailerSize)
+	// too short
+	version, _ = ParseFormatVersion([]byte{1, 2, 3})
+	assert.Equal(t, FormatVersionV1, version)
+
+	// block with field precisions
+	block = mockMetricBlockWithPrecisions(Codec{Type: CodecNone}, []uint8{3})
+	version, _ = ParseFormatVersion(block)
+	assert.Equal(t, FormatVersionV4, version)
+	assert.NoError(t, CheckFormatVersion(block))
+
+	// compressed block
+	block = mockMetricBlockWithCodec(Codec{Type: CodecZstd, Level: option.DefaultZstdLevel})
+	version, _ = ParseFormatVersion(block)
+	assert.Equal(t, FormatVersionV3, version)
+	assert.NoError(t, CheckFormatVersion(block))
+	// unknown codec
+	block[len(block)-versionTrailerSize-codecTrailerSize] = 100
+	assert.True(t, errors.Is(CheckFormatVersion(block), ErrUnsupportedCodec))
+}
+
+func TestCheckFormatVersion(t *testing.T) {
+	cases := []struct {
+		name    string
+		version FormatVersion
+		wantErr bool
+	}{
+		{name: "v1", version: FormatVersionV1},
+		{name: "v2", version: FormatVersionV2},
+		{name: "unknown version", version: 0, wantErr: true},
+		{name: "newer version", version: CurrentFormatVersion + 1, wantErr: true},
+	}
+	for _, tt := range cases {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			block := mockMetricBlock()
+			block[len(block)-versionTrailerSize] = byte(tt.version)
+			err := CheckFormatVersion(block)
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, ErrUnsupportedFormatVersion))
+				r, err := NewReader("1.sst", block)
+				assert.True(t, errors.Is(err, ErrUnsupportedFormatVersion))
+				assert.Nil(t, r)
+			} else {
+				assert.NoError(t, err)
+				r, err := NewReader("1.sst", block)
+				assert.NoError(t, err)
+				assert.NotNil(t, r)
+			}
+		})
+	}
+}
+
+func TestReader_DecodeV1Block(t *testing.T) {
+	// metric block written by flusher before version trailer introduced
+	block, err := os.ReadFile("testdata/metric_block_v1.bin")
+	assert.NoError(t, err)
+	version, _ := ParseFormatVersion(block)
+	assert.Equal(t, FormatVersionV1, version)
+
+	r, err := NewReader("1.sst", block)
+	assert.NoError(t, err)
+	timeRange := r.GetTimeRange()
+	assert.Equal(t, uint16(5), timeRange.Start)
+	assert.Equal(t, uint16(5), timeRange.End)
+	assert.Equal(t, field.Metas{
+		{ID: 2, Type: field.SumField},
+		{ID: 10, Type: field.MinField},
+		{ID: 30, Type: field.SumField},
+		{ID: 100, Type: field.MaxField},
+	}, r.GetFields())
+	current, err := NewReader("1.sst", mockMetricBlock())
+	assert.NoError(t, err)
+	assert.Equal(t, current.GetSeriesIDs().ToArray(), r.GetSeriesIDs().ToArray())
+}
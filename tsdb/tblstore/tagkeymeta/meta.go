@@ -339,8 +339,8 @@ func (meta *tagKeyMeta) FindTagValueIDsByRegex(tagValuePattern string) (tagValue
 	if err != nil {
 		return nil
 	}
-	literalPrefix, _ := rp.LiteralPrefix()
-	literalPrefixByte := strutil.String2ByteSlice(literalPrefix)
+	// scan tag values by literal prefix(range scan of trie tree) instead of scanning all tag values
+	literalPrefixByte := strutil.String2ByteSlice(strutil.RegexLiteralPrefix(rp))
 	itr, err := meta.PrefixIterator(literalPrefixByte)
 	if err != nil {
 		return nil
@@ -174,6 +174,18 @@ func TestTagKeyMeta_FindTagValueIDsByRegex(t *testing.T) {
 
 	// case3: regex all
 	assert.Len(t, meta.FindTagValueIDsByRegex(".*"), 10000)
+
+	// case4: anchored prefix regex
+	assert.Len(t, meta.FindTagValueIDsByRegex("^1\\.1\\.1\\..*"), 10)
+}
+
+func BenchmarkTagKeyMeta_FindTagValueIDsByRegex(b *testing.B) {
+	meta, _ := newTagKeyMeta(buildTestTrieData())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		// anchored prefix regex is scanned by prefix iterator
+		meta.FindTagValueIDsByRegex("^1\\.1\\.1\\..*")
+	}
 }
 
 func TestTagKeyMeta_CollectTagValues(t *testing.T) {
@@ -0,0 +1,131 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lindb/lindb/constants"
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/timeutil"
+)
+
+// IsTiered returns if the files of family are moved to object storage.
+func (f *dataFamily) IsTiered() bool {
+	return f.tiered.Load()
+}
+
+// KeepLocal returns if the family is un-tiered on demand, which is skipped by scheduled tiering until reopened.
+func (f *dataFamily) KeepLocal() bool {
+	return f.keepLocal.Load()
+}
+
+// Tier moves the files of family to object storage, family must have no data in memory,
+// writes/flushes of family are rejected after tiered.
+func (f *dataFamily) Tier(ctx context.Context, throttle kv.Throttle) error {
+	if f.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	f.mutex.Lock()
+	if f.mutableMemDB != nil || f.immutableMemDB != nil || f.IsFlushing() {
+		f.mutex.Unlock()
+		return fmt.Errorf("%w: family has data in memory", kv.ErrFamilyBusy)
+	}
+	// reject the creation of memory database before moving files
+	f.tiered.Store(true)
+	f.mutex.Unlock()
+
+	startTime := time.Now()
+	if err := f.family.Tier(ctx, throttle); err != nil {
+		// keep tiered if part of files moved
+		f.tiered.Store(f.family.IsTiered())
+		return err
+	}
+	f.keepLocal.Store(false)
+	f.logger.Info("tier family successfully",
+		logger.String("family", f.indicator),
+		logger.String("cost", time.Since(startTime).String()))
+	return nil
+}
+
+// Untier downloads the files of family from object storage back to local disk,
+// family is kept local until reopened.
+func (f *dataFamily) Untier(ctx context.Context) error {
+	if f.engineContext().readOnly {
+		return constants.ErrReadOnly
+	}
+	startTime := time.Now()
+	if err := f.family.Untier(ctx); err != nil {
+		return err
+	}
+	f.keepLocal.Store(true)
+	f.tiered.Store(false)
+	f.logger.Info("untier family successfully",
+		logger.String("family", f.indicator),
+		logger.String("cost", time.Since(startTime).String()))
+	return nil
+}
+
+// TierFamilies moves the files of families which end before given timestamp to object storage,
+// returns the num. of families tiered, failed family is skipped.
+func (s *shard) TierFamilies(ctx context.Context, before int64, throttle kv.Throttle) (int, error) {
+	tiered := 0
+	for interval, rollupSegment := range s.getRollupTargets() {
+		families := rollupSegment.GetDataFamilies(timeutil.TimeRange{Start: 0, End: before})
+		for _, family := range families {
+			if err := ctx.Err(); err != nil {
+				return tiered, err
+			}
+			if family.TimeRange().End >= before || family.IsTiered() || family.KeepLocal() {
+				continue
+			}
+			if err := family.Tier(ctx, throttle); err != nil {
+				s.logger.Warn("tier family failure, skip it",
+					logger.String("shard", s.indicator),
+					logger.String("segment", interval.Type().String()),
+					logger.String("family", family.Indicator()),
+					logger.Error(err))
+				continue
+			}
+			tiered++
+		}
+	}
+	return tiered, nil
+}
+
+// TierFamilies moves the files of families older than the tier after option of database to object storage,
+// returns the num. of families tiered, does nothing if tiering not enabled for database.
+func (db *database) TierFamilies(ctx context.Context, throttle kv.Throttle) (int, error) {
+	tierAfter := db.GetOption().GetTierAfter()
+	if tierAfter <= 0 {
+		return 0, nil
+	}
+	before := timeutil.Now() - tierAfter
+	tiered := 0
+	for _, shardEntry := range db.shardSet.Entries() {
+		n, err := shardEntry.shard.TierFamilies(ctx, before, throttle)
+		tiered += n
+		if err != nil {
+			return tiered, err
+		}
+	}
+	return tiered, nil
+}
@@ -0,0 +1,146 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lindb/lindb/kv"
+	"github.com/lindb/lindb/models"
+	"github.com/lindb/lindb/pkg/logger"
+	"github.com/lindb/lindb/pkg/option"
+	"github.com/lindb/lindb/pkg/timeutil"
+	"github.com/lindb/lindb/tsdb/memdb"
+)
+
+func TestDataFamily_Tier(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	family := kv.NewMockFamily(ctrl)
+	f := &dataFamily{family: family, indicator: "test/1/20221010", logger: logger.GetLogger("TSDB", "Test")}
+	throttle := func(_ context.Context, _ int) error { return nil }
+
+	// family has data in memory
+	f.mutableMemDB = memdb.NewMockMemoryDatabase(ctrl)
+	assert.True(t, errors.Is(f.Tier(context.TODO(), throttle), kv.ErrFamilyBusy))
+	assert.False(t, f.IsTiered())
+	f.mutableMemDB = nil
+
+	// tier failure, no files moved
+	family.EXPECT().Tier(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	family.EXPECT().IsTiered().Return(false)
+	assert.Error(t, f.Tier(context.TODO(), throttle))
+	assert.False(t, f.IsTiered())
+
+	family.EXPECT().Tier(gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, f.Tier(context.TODO(), throttle))
+	assert.True(t, f.IsTiered())
+	assert.False(t, f.NeedFlush())
+	// reject writing into tiered family
+	_, err := f.GetOrCreateMemoryDatabase(10)
+	assert.True(t, errors.Is(err, kv.ErrFamilyTiered))
+
+	// untier failure
+	family.EXPECT().Untier(gomock.Any()).Return(fmt.Errorf("err"))
+	assert.Error(t, f.Untier(context.TODO()))
+	assert.True(t, f.IsTiered())
+
+	family.EXPECT().Untier(gomock.Any()).Return(nil)
+	assert.NoError(t, f.Untier(context.TODO()))
+	assert.False(t, f.IsTiered())
+	assert.True(t, f.KeepLocal())
+}
+
+func TestShard_TierFamilies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	segment := NewMockIntervalSegment(ctrl)
+	s := &shard{
+		indicator:     "test/1",
+		rollupTargets: map[timeutil.Interval]IntervalSegment{10: segment},
+		logger:        logger.GetLogger("TSDB", "Test"),
+	}
+	newFamily := func(end int64, tiered, keepLocal bool) *MockDataFamily {
+		f := NewMockDataFamily(ctrl)
+		f.EXPECT().TimeRange().Return(timeutil.TimeRange{Start: end - 10, End: end}).AnyTimes()
+		f.EXPECT().IsTiered().Return(tiered).AnyTimes()
+		f.EXPECT().KeepLocal().Return(keepLocal).AnyTimes()
+		f.EXPECT().Indicator().Return("family").AnyTimes()
+		return f
+	}
+	f1 := newFamily(10, false, false)
+	f2 := newFamily(20, false, false)
+	f3 := newFamily(30, true, false)
+	f4 := newFamily(40, false, true)
+	f5 := newFamily(100, false, false)
+	segment.EXPECT().GetDataFamilies(timeutil.TimeRange{End: 100}).
+		Return([]DataFamily{f1, f2, f3, f4, f5}).Times(2)
+	f1.EXPECT().Tier(gomock.Any(), gomock.Any()).Return(nil)
+	// skip failure family
+	f2.EXPECT().Tier(gomock.Any(), gomock.Any()).Return(fmt.Errorf("err"))
+	n, err := s.TierFamilies(context.TODO(), 100, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	// canceled
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	n, err = s.TierFamilies(ctx, 100, nil)
+	assert.Error(t, err)
+	assert.Zero(t, n)
+}
+
+func TestDatabase_TierFamilies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	set := newShardSet()
+	shard1 := NewMockShard(ctrl)
+	shard2 := NewMockShard(ctrl)
+	set.InsertShard(models.ShardID(0), shard1)
+	set.InsertShard(models.ShardID(1), shard2)
+	db := &database{
+		shardSet: *set,
+		config:   &models.DatabaseConfig{Option: &option.DatabaseOption{}},
+	}
+	// tiering not enabled
+	n, err := db.TierFamilies(context.TODO(), nil)
+	assert.NoError(t, err)
+	assert.Zero(t, n)
+
+	db.config.Option.TierAfter = "90d"
+	shard1.EXPECT().TierFamilies(gomock.Any(), gomock.Any(), gomock.Any()).Return(2, nil)
+	shard2.EXPECT().TierFamilies(gomock.Any(), gomock.Any(), gomock.Any()).Return(1, nil)
+	n, err = db.TierFamilies(context.TODO(), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	// stop tiering if failure
+	shard1.EXPECT().TierFamilies(gomock.Any(), gomock.Any(), gomock.Any()).Return(1, context.Canceled)
+	n, err = db.TierFamilies(context.TODO(), nil)
+	assert.Error(t, err)
+	assert.Equal(t, 1, n)
+}
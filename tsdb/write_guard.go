@@ -0,0 +1,68 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"github.com/shirou/gopsutil/v3/disk"
+	"go.uber.org/atomic"
+
+	"github.com/lindb/lindb/config"
+)
+
+// for testing
+var (
+	diskUsageFn = disk.Usage
+)
+
+var (
+	// writeRejected represents if all families reject writes(such as disk space low).
+	writeRejected atomic.Bool
+	// rejectedWrites represents the number of rows rejected since node started.
+	rejectedWrites atomic.Int64
+)
+
+// RejectWrites switches all families into write-rejection mode,
+// the rejected rows are sent into dead-letter sink.
+func RejectWrites() {
+	writeRejected.Store(true)
+}
+
+// AcceptWrites switches all families back to normal write mode.
+func AcceptWrites() {
+	writeRejected.Store(false)
+}
+
+// IsWriteRejected returns if all families reject writes.
+func IsWriteRejected() bool {
+	return writeRejected.Load()
+}
+
+// RejectedWrites returns the number of rows rejected in write-rejection mode since node started.
+func RejectedWrites() int64 {
+	return rejectedWrites.Load()
+}
+
+// hasEnoughDiskSpace checks if free space of data disk is enough for flushing data with estimated size,
+// returns true if it cannot get disk usage, let flush job decide.
+func hasEnoughDiskSpace(estimatedSize int64) (enough bool, free uint64) {
+	stat, err := diskUsageFn(config.GlobalStorageConfig().TSDB.Dir)
+	if err != nil {
+		return true, 0
+	}
+	return stat.Free > uint64(estimatedSize), stat.Free
+}
@@ -0,0 +1,56 @@
+// Licensed to LinDB under one or more contributor
+// license agreements. See the NOTICE file distributed with
+// this work for additional information regarding copyright
+// ownership. LinDB licenses this file to you under
+// the Apache License, Version 2.0 (the "License"); you may
+// not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package tsdb
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteRejection(t *testing.T) {
+	defer AcceptWrites()
+
+	assert.False(t, IsWriteRejected())
+	RejectWrites()
+	assert.True(t, IsWriteRejected())
+	AcceptWrites()
+	assert.False(t, IsWriteRejected())
+}
+
+func TestHasEnoughDiskSpace(t *testing.T) {
+	defer func() {
+		diskUsageFn = disk.Usage
+	}()
+	diskUsageFn = func(_ string) (*disk.UsageStat, error) {
+		return nil, fmt.Errorf("err")
+	}
+	enough, _ := hasEnoughDiskSpace(100)
+	assert.True(t, enough)
+
+	diskUsageFn = func(_ string) (*disk.UsageStat, error) {
+		return &disk.UsageStat{Free: 100}, nil
+	}
+	enough, free := hasEnoughDiskSpace(100)
+	assert.False(t, enough)
+	assert.Equal(t, uint64(100), free)
+	enough, _ = hasEnoughDiskSpace(10)
+	assert.True(t, enough)
+}
@@ -26,6 +26,7 @@ import {
 import { useWatchURLChange } from "@src/hooks";
 import { Variate, Metadata } from "@src/models";
 import { URLStore } from "@src/stores";
+import { ApiKit } from "@src/utils";
 import * as _ from "lodash-es";
 import React, { MutableRefObject, useRef, useState } from "react";
 import { ExecService } from "@src/services";
@@ -93,7 +94,7 @@ const TagValueSelect: React.FC<MetadataSelectProps> = (
     } catch (err) {
       Notification.error({
         title: "Fetch tag values error",
-        content: _.get(err, "response.data", "Unknown internal error"),
+        content: ApiKit.getErrorMsg(err, "Unknown internal error"),
         position: "top",
         theme: "light",
         duration: 5,
@@ -58,6 +58,60 @@ export default function NodeView(props: NodeViewProps) {
     key: "id",
   };
 
+  const loadCol = {
+    title: NodeView.load,
+    key: "load",
+    render: (_text: any, record: Node, _index: any) => {
+      const load = record.load;
+      if (!load) {
+        return "-";
+      }
+      return (
+        <Descriptions
+          className="lin-small-desc"
+          row
+          size="small"
+          data={[
+            {
+              key: NodeView.memDBTotalSize,
+              value: (
+                <Text link>
+                  {FormatKit.format(
+                    _.get(load, "memDBTotalSize", 0),
+                    Unit.Bytes
+                  )}
+                </Text>
+              ),
+            },
+            {
+              key: NodeView.familiesNeedFlush,
+              value: <Text link>{_.get(load, "familiesNeedFlush", 0)}</Text>,
+            },
+            {
+              key: NodeView.familiesFlushFailed,
+              value: <Text link>{_.get(load, "familiesFlushFailed", 0)}</Text>,
+            },
+            {
+              key: NodeView.diskFree,
+              value: (
+                <Text link>
+                  {FormatKit.format(
+                    _.get(load, "diskFree", 0),
+                    Unit.Bytes
+                  )}
+                </Text>
+              ),
+            },
+            {
+              key: NodeView.writeRejected,
+              value: <Text link>{_.get(load, "writeRejected", 0)}</Text>,
+            },
+          ]}
+        />
+      );
+    },
+  };
+
   const columns: any[] = [
     {
       title: NodeView.title,
@@ -200,7 +254,16 @@ export default function NodeView(props: NodeViewProps) {
       <Table
         size="small"
         bordered={false}
-        columns={showNodeId ? _.concat([nodeIdCol], columns) : columns}
+        columns={
+          showNodeId
+            ? _.concat(
+                [nodeIdCol],
+                columns.slice(0, -1),
+                [loadCol],
+                columns.slice(-1)
+              )
+            : columns
+        }
         dataSource={nodes}
         pagination={false}
         empty={statusTip}
@@ -211,5 +211,39 @@ export const LocalReplicationDashboard: Dashboard = {
         },
       ],
     },
+    {
+      panels: [
+        {
+          chart: {
+            title: "Write Backpressure",
+            config: { type: "line", options: chartOptions },
+            targets: [
+              {
+                db: MonitoringDB,
+                sql: "select 'backpressure' from 'lindb.storage.replicator.runner' where type='local' group by db,node",
+                watch: ["node", "db"],
+              },
+            ],
+            unit: Unit.Short,
+          },
+          span: 12,
+        },
+        {
+          chart: {
+            title: "Write Backpressure Duration",
+            config: { type: "line", options: chartOptions },
+            targets: [
+              {
+                db: MonitoringDB,
+                sql: "select 'backpressure_ms' from 'lindb.storage.replicator.runner' where type='local' group by db,node",
+                watch: ["node", "db"],
+              },
+            ],
+            unit: Unit.Milliseconds,
+          },
+          span: 12,
+        },
+      ],
+    },
   ],
 };
@@ -52,6 +52,12 @@ const local = {
     cpu: "CPU",
     memory: "Memory",
     nodeId: "Node ID",
+    load: "Load",
+    memDBTotalSize: "MemDB Size",
+    familiesNeedFlush: "Need Flush",
+    familiesFlushFailed: "Flush Failed",
+    diskFree: "Disk Free",
+    writeRejected: "Write Rejected",
   },
   DatabaseView: {
     name: "Name",
@@ -52,6 +52,12 @@ const local = {
     cpu: "CPU",
     memory: "内存",
     nodeId: "节点 ID",
+    load: "负载",
+    memDBTotalSize: "内存数据库大小",
+    familiesNeedFlush: "待刷盘",
+    familiesFlushFailed: "刷盘失败",
+    diskFree: "磁盘剩余",
+    writeRejected: "拒绝写入",
   },
   BrokerView: {
     name: "集群名(Namespace)",
@@ -57,6 +57,16 @@ export interface Database {
     autoCreateNS?: boolean;
     behind?: string;
     ahead?: string;
+    maxSeriesPerQuery?: number;
+    queryTimeout?: string;
+    normalize?: {
+      lowercaseMetricName?: boolean;
+      lowercaseTagKey?: boolean;
+      tagKeyRenames?: { [key: string]: string };
+      tagFilter?: TagFilter;
+      namespaceTagFilters?: { [namespace: string]: TagFilter };
+      metricNameRewrites?: { pattern: string; replacement: string }[];
+    };
     data: {
       timeThreshold?: number;
       sizeThreshold?: number;
@@ -65,6 +75,11 @@ export interface Database {
   desc?: string;
 }
 
+export interface TagFilter {
+  allow?: string[];
+  deny?: string[];
+}
+
 export interface Interval {
   interval?: string;
   retention?: string;
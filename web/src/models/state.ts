@@ -29,6 +29,17 @@ export type Node = {
   httpPort?: number;
   version?: string;
   onlineTime?: string;
+  notReady?: boolean;
+  load?: NodeLoad;
+};
+
+export type NodeLoad = {
+  memDBTotalSize?: number;
+  familiesNeedFlush?: number;
+  familiesFlushFailed?: number;
+  diskFree?: number;
+  writeRejected?: number;
+  reportTime?: number;
 };
 
 export type Request = {
@@ -36,7 +36,7 @@ import { ClusterType, Route } from "@src/constants";
 import { UIContext } from "@src/context/UIContextProvider";
 import { ExecService } from "@src/services";
 import { URLStore } from "@src/stores";
-import * as _ from "lodash-es";
+import { ApiKit } from "@src/utils";
 import React, { useContext, useState } from "react";
 
 export const ClusterConfig: React.FC<{ type: ClusterType }> = (props) => {
@@ -63,7 +63,7 @@ export const ClusterConfig: React.FC<{ type: ClusterType }> = (props) => {
               : Route.MetadataBroker,
         });
       } catch (err) {
-        setError(_.get(err, "response.data", Common.unknownInternalError));
+        setError(ApiKit.getErrorMsg(err, Common.unknownInternalError));
       } finally {
         setSubmiting(false);
       }
@@ -37,6 +37,7 @@ import { UIContext } from "@src/context/UIContextProvider";
 import { Storage } from "@src/models";
 import { ExecService } from "@src/services";
 import { URLStore } from "@src/stores";
+import { ApiKit } from "@src/utils";
 import * as _ from "lodash-es";
 import React, {
   MutableRefObject,
@@ -88,7 +89,7 @@ export default function DatabaseConfig() {
         });
         URLStore.changeURLParams({ path: Route.MetadataDatabase });
       } catch (err) {
-        setError(_.get(err, "response.data", Common.unknownInternalError));
+        setError(ApiKit.getErrorMsg(err, Common.unknownInternalError));
       } finally {
         setSubmiting(false);
       }
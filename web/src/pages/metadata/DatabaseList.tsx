@@ -37,6 +37,7 @@ import { Database } from "@src/models";
 import { ExecService } from "@src/services";
 import { URLStore } from "@src/stores";
 import { useQuery } from "@tanstack/react-query";
+import { ApiKit } from "@src/utils";
 import * as _ from "lodash-es";
 import React, { useContext } from "react";
 
@@ -67,7 +68,7 @@ export default function DatabaseList() {
     } catch (err) {
       Notification.error({
         title: "Drop database error",
-        content: _.get(err, "response.data", "Unknown internal error"),
+        content: ApiKit.getErrorMsg(err, "Unknown internal error"),
         position: "top",
         theme: "light",
         duration: 5,
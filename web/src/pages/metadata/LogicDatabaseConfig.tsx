@@ -41,6 +41,7 @@ import React, {
   useRef,
   useState,
 } from "react";
+import { ApiKit } from "@src/utils";
 import * as _ from "lodash-es";
 import { URLStore } from "@src/stores";
 import { Route } from "@src/constants";
@@ -85,7 +86,7 @@ export const LogicDatabaseConfig: React.FC = () => {
         });
         URLStore.changeURLParams({ path: Route.MetadataLogicDatabase });
       } catch (err) {
-        setError(_.get(err, "response.data", Common.unknownInternalError));
+        setError(ApiKit.getErrorMsg(err, Common.unknownInternalError));
       } finally {
         setSubmiting(false);
       }
@@ -39,6 +39,7 @@ import { URLStore } from "@src/stores";
 import { useQuery } from "@tanstack/react-query";
 import React, { useContext } from "react";
 import { StatusTip } from "@src/components";
+import { ApiKit } from "@src/utils";
 import * as _ from "lodash-es";
 
 const { Text } = Typography;
@@ -68,7 +69,7 @@ export const LogicDatabaseList: React.FC = () => {
     } catch (err) {
       Notification.error({
         title: "Drop database error",
-        content: _.get(err, "response.data", Common.unknownInternalError),
+        content: ApiKit.getErrorMsg(err, Common.unknownInternalError),
         position: "top",
         theme: "light",
         duration: 5,
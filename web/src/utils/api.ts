@@ -77,8 +77,13 @@ async function POST<T>(
     });
 }
 
-const getErrorMsg = (err: any) => {
-  return _.get(err, "response.data", "Unknown internal error");
+const getErrorMsg = (err: any, defaultMsg = "Unknown internal error") => {
+  const data = _.get(err, "response.data");
+  if (_.isString(data) && data !== "") {
+    return data;
+  }
+  // structured error response: {error, code, retriable, message}
+  return _.get(data, "error") || _.get(data, "message") || defaultMsg;
 };
 
 const getErrorCode = (err: any) => {